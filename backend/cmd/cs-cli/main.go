@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/cli"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cli.Run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if cli.IsUsageError(err) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "cs-cli: %v\n", err)
+		os.Exit(1)
+	}
+}
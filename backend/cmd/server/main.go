@@ -11,24 +11,37 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	// Embed the time zone database so that the configured timezone loads in
+	// images without tzdata.
+	_ "time/tzdata"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	genadmin "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/admin"
+	genassets "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/assets"
 	gencheckpoints "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/checkpoints"
 	gencomfyui "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/comfyui"
 	gendemo "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/demo"
 	gendocs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/docs"
+	gengalleries "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/galleries"
 	genhealth "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/health"
 	genimages "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/images"
+	genjobtemplates "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/job_templates"
 	genpresets "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/presets"
 	gensamplejobs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sample_jobs"
+	genstorage "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/storage"
 	genstudies "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/studies"
+	gensync "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sync"
+	genvotes "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/votes"
+	genmeta "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/meta"
 	gentrainingruns "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/training_runs"
 	genworkflows "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/workflows"
 	genws "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/ws"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/config"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -38,11 +51,20 @@ func main() {
 }
 
 func run() error {
-	// Initialize logger
+	// Initialize logger. LOG_FORMAT selects text (default) or JSON output.
 	logger := logrus.New()
-	logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
+	logFormat := strings.ToLower(os.Getenv("LOG_FORMAT"))
+	switch logFormat {
+	case "json":
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+		if logFormat != "" && logFormat != "text" {
+			logger.WithField("log_format", logFormat).Warn("invalid LOG_FORMAT value, defaulting to text")
+		}
+	}
 
 	// Parse and set log level from environment variable (default: info)
 	logLevelStr := os.Getenv("LOG_LEVEL")
@@ -66,10 +88,76 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
-	logger.WithField("config_path", os.Getenv("CONFIG_PATH")).Info("configuration loaded")
+	logger.WithField("config_path", config.Path()).Info("configuration loaded")
+
+	// log_levels overrides LOG_LEVEL for individual components.
+	componentLogLevels := service.NewComponentLogLevels(logger)
+	if err := componentLogLevels.SetOverrides(cfg.LogLevels); err != nil {
+		return fmt.Errorf("setting log levels: %w", err)
+	}
+	if len(cfg.LogLevels) > 0 {
+		logger.WithField("log_levels", cfg.LogLevels).Info("component log levels set")
+	}
+
+	// Settings that can change at runtime register a handler with the
+	// reloader as their components are created below.
+	reloader := service.NewConfigReloader(config.Load, *cfg, logger)
+	reloader.OnLogLevels(func(levels map[string]string) {
+		if err := componentLogLevels.SetOverrides(levels); err != nil {
+			logger.WithError(err).Error("failed to apply log levels")
+		}
+	})
+
+	// Start listening before initializing so that container orchestration can
+	// probe the process while it starts: /healthz succeeds right away,
+	// /readyz once every startup step has completed, and other requests are
+	// answered with 503 until the API is mounted.
+	readiness := service.NewReadiness(logger,
+		model.ReadinessStepMigrations,
+		model.ReadinessStepOpenAPISpec,
+		model.ReadinessStepWatcher,
+		model.ReadinessStepAPI,
+	)
+	healthSvc := api.NewHealthService()
+	healthSvc.SetReadiness(readiness)
+	healthEndpoints := genhealth.NewEndpoints(healthSvc)
+	startupHandler := api.NewStartupHandler(healthEndpoints, logger)
+
+	addr := net.JoinHostPort(cfg.IPAddress, fmt.Sprintf("%d", cfg.Port))
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      startupHandler,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		defer close(serverErr)
+		logger.WithField("address", addr).Info("starting server")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+	// Closes the listener when initialization fails; a no-op after a
+	// graceful shutdown.
+	defer srv.Close()
 
-	// Open database and run migrations
-	db, err := store.OpenDB(cfg.DBPath)
+	// Schedules are evaluated in the configured time zone, not the host's.
+	serverClock, err := service.NewServerClock(cfg.Timezone, cfg.Locale)
+	if err != nil {
+		return fmt.Errorf("creating server clock: %w", err)
+	}
+	logger.WithField("timezone", cfg.Timezone).Info("server time zone set")
+
+	// Open database and run migrations. In multi-process mode the database is
+	// shared with other backend instances and opened with contention-tolerant
+	// settings.
+	openDB := store.OpenDB
+	if cfg.MultiProcess != nil {
+		openDB = store.OpenSharedDB
+	}
+	db, err := openDB(cfg.DBPath)
 	if err != nil {
 		return fmt.Errorf("opening database: %w", err)
 	}
@@ -78,7 +166,9 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("initializing store: %w", err)
 	}
+	st.SetSlowQueryThreshold(time.Duration(cfg.SlowQueryMs) * time.Millisecond)
 	defer st.Close()
+	readiness.MarkReady(model.ReadinessStepMigrations)
 
 	// Read the generated OpenAPI spec
 	specPath := openAPISpecPath()
@@ -86,16 +176,45 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("reading openapi spec at %s: %w", specPath, err)
 	}
+	readiness.MarkReady(model.ReadinessStepOpenAPISpec)
+
+	// In multi-process mode, processes whose role allows it compete for the
+	// executor lease; only the holder processes jobs.
+	var executorLease *service.ExecutorLease
+	if cfg.MultiProcess != nil {
+		instanceID := cfg.MultiProcess.InstanceID
+		if instanceID == "" {
+			instanceID = defaultInstanceID()
+		}
+		executorLease = service.NewExecutorLease(st, instanceID, time.Duration(cfg.MultiProcess.LeaseTTL)*time.Second, logger)
+		logger.WithFields(logrus.Fields{
+			"instance_id": instanceID,
+			"role":        cfg.MultiProcess.Role,
+		}).Info("multi-process mode enabled")
+	}
 
 	// Create filesystem, discovery, and scanner services
 	fs := store.NewFileSystem(logger)
 	discovery := service.NewDiscoveryService(fs, cfg.CheckpointDirs, cfg.SampleDir, logger)
+	discovery.SetConfigSource(st)
 	viewerDiscovery := service.NewViewerDiscoveryService(fs, cfg.SampleDir, logger)
 
 	// Determine thumbnail settings
 	thumbnailsEnabled := cfg.Thumbnails != nil && cfg.Thumbnails.Enabled
 	scanner := service.NewScannerWithThumbnails(fs, cfg.SampleDir, thumbnailsEnabled, logger)
 
+	// Serve sample directory listings from the image index. Reconciling it
+	// with the sample directory runs in the background; directories it has
+	// not reached yet are read from disk.
+	imageIndex := service.NewImageIndex(st, fs, cfg.SampleDir, logger)
+	scanner.SetImageLister(imageIndex)
+	// Images saved under an output layout are in subdirectories of the
+	// checkpoint directories and are described by their sidecars.
+	if !cfg.OutputLayout.IsZero() {
+		scanner.SetSidecarScan(fs)
+	}
+	go imageIndex.Reconcile(context.Background())
+
 	// Create WebSocket hub and filesystem watcher
 	hub := service.NewHub(logger)
 	notifier, err := service.NewFSNotifier()
@@ -104,26 +223,51 @@ func run() error {
 	}
 	defer notifier.Close()
 	watcher := service.NewWatcher(notifier, hub, cfg.SampleDir, logger)
+	watcher.SetImageIndex(imageIndex)
 	defer watcher.Stop()
+	watcher.WatchCheckpointDirs(cfg.CheckpointDirs, fs)
+	readiness.MarkReady(model.ReadinessStepWatcher)
+	reloader.OnCheckpointDirs(func(dirs []string) {
+		discovery.SetCheckpointDirs(dirs)
+		watcher.WatchCheckpointDirs(dirs, fs)
+	})
 
 	// Create ComfyUI services if configured
 	var comfyuiSvc *api.ComfyUIService
 	var workflowsSvc *api.WorkflowService
-	var modelDiscovery *service.ComfyUIModelDiscovery
+	var modelCache *service.ComfyUIModelCache
 	var jobExecutor *service.JobExecutor
+	var workflowLoader *service.WorkflowLoader
+	var vramGuard *service.VRAMGuard
 	var bgPauser api.BackgroundPauser // remains nil (interface nil) when ComfyUI is not configured
 	if cfg.ComfyUI != nil {
 		httpClient := store.NewComfyUIHTTPClient(cfg.ComfyUI.URL, logger)
 		wsClient := store.NewComfyUIWSClient(cfg.ComfyUI.URL, logger)
-		modelDiscovery = service.NewComfyUIModelDiscovery(httpClient, logger)
-		comfyuiSvc = api.NewComfyUIService(httpClient, modelDiscovery)
+		modelDiscovery := service.NewComfyUIModelDiscovery(httpClient, logger)
+		modelCache = service.NewComfyUIModelCache(modelDiscovery, time.Duration(cfg.ComfyUI.ModelCacheTTL)*time.Second, logger)
+		comfyuiSvc = api.NewComfyUIService(httpClient, modelCache)
+		comfyuiSvc.SetModelCache(modelCache)
+		comfyuiSvc.SetQueueReader(service.NewComfyUIQueueService(httpClient, st, logger))
+		comfyUIStats := service.NewComfyUIStatsMonitor(httpClient, httpClient, logger)
+		comfyuiSvc.SetStatsReader(comfyUIStats)
+		comfyUIStatsStop := make(chan struct{})
+		comfyUIStatsDone := make(chan struct{})
+		go func() {
+			defer close(comfyUIStatsDone)
+			comfyUIStats.Run(comfyUIStatsStop)
+		}()
+		defer func() {
+			close(comfyUIStatsStop)
+			<-comfyUIStatsDone
+		}()
 
 		// Create workflow loader and ensure workflow directory exists
-		workflowLoader := service.NewWorkflowLoader(cfg.ComfyUI.WorkflowDir, logger)
+		workflowLoader = service.NewWorkflowLoader(cfg.ComfyUI.WorkflowDir, logger)
 		if err := workflowLoader.EnsureWorkflowDir(); err != nil {
 			return fmt.Errorf("ensuring workflow directory: %w", err)
 		}
 		workflowsSvc = api.NewWorkflowService(workflowLoader)
+		vramGuard = service.NewVRAMGuard(httpClient, workflowLoader, cfg.ComfyUI.VRAMHardLimitMB, logger)
 
 		// Create job executor (with optional thumbnail generator)
 		fsWriter := &service.RealFileSystemWriter{}
@@ -132,8 +276,58 @@ func run() error {
 			thumbGen = service.NewThumbnailGenerator(*cfg.Thumbnails, logger)
 		}
 		reconnectInterval := time.Duration(cfg.ComfyUI.ReconnectInterval) * time.Second
-		jobExecutor = service.NewJobExecutorWithThumbnails(st, httpClient, wsClient, workflowLoader, hub, cfg.SampleDir, fsWriter, fs, thumbGen, reconnectInterval, logger)
+		var executorStore service.JobExecutorStore = st
+		var executorClient service.ComfyUIClient = httpClient
+		var executorWS service.ComfyUIWS = wsClient
+		var executorFS service.FileSystemWriter = fsWriter
+		if cfg.FaultInjection != nil {
+			// Fault injection is for integration tests and demos; the
+			// environment variable guards against a config file copied
+			// into production.
+			if os.Getenv("ENABLE_FAULT_INJECTION") == "true" {
+				logger.Warn("fault injection enabled: the job executor will fail at random")
+				faults := service.NewFaultInjector(*cfg.FaultInjection, logger)
+				executorStore = faults.Store(st)
+				executorClient = faults.ComfyUIClient(httpClient)
+				executorWS = faults.ComfyUIWS(wsClient)
+				executorFS = faults.FileSystem(fsWriter)
+			} else {
+				logger.Warn("fault_injection is configured but ENABLE_FAULT_INJECTION is not \"true\", ignoring it")
+			}
+		}
+		jobExecutor = service.NewJobExecutorWithThumbnails(executorStore, executorClient, executorWS, workflowLoader, hub, cfg.SampleDir, executorFS, fs, thumbGen, reconnectInterval, logger)
+		jobExecutor.SetDrainTimeout(time.Duration(cfg.ComfyUI.DrainTimeout) * time.Second)
+		jobExecutor.SetItemTimeout(time.Duration(cfg.ComfyUI.ItemTimeout) * time.Second)
+		jobExecutor.SetMaxInFlight(cfg.ComfyUI.MaxInFlight)
+		jobExecutor.SetOutputLayout(cfg.OutputLayout)
+		jobExecutor.SetImageIndex(imageIndex)
+		jobExecutor.SetInputImageUploader(fs, httpClient)
+		if cfg.ComfyUI.Prewarm {
+			jobExecutor.SetPrewarm(vramGuard)
+		}
+		if fl := cfg.ComfyUI.FailureLog; fl != nil {
+			var logReader service.ComfyUILogReader = httpClient
+			if fl.Source == model.ComfyUILogSourceFile {
+				logReader = store.NewComfyUILogFile(fl.Path, logger)
+			}
+			jobExecutor.SetFailureLogReader(logReader, fl.Lines)
+		}
 		bgPauser = jobExecutor
+
+		// A new ComfyUI URL is switched to between samples, so that the
+		// sample in flight completes on the server that is running it.
+		reloader.OnComfyUIURL(func(url string) {
+			jobExecutor.RunWhenIdle(func() {
+				httpClient.SetBaseURL(url)
+				wsClient.SetBaseURL(url)
+			})
+		})
+		reloader.OnWorkflowDir(func(dir string) {
+			workflowLoader.SetWorkflowDir(dir)
+			if err := workflowLoader.EnsureWorkflowDir(); err != nil {
+				logger.WithError(err).Warn("failed to create reloaded workflow directory")
+			}
+		})
 	} else {
 		// Create disabled service when ComfyUI is not configured
 		comfyuiSvc = api.NewComfyUIService(nil, nil)
@@ -142,74 +336,296 @@ func run() error {
 	}
 
 	// Create service implementations
-	healthSvc := api.NewHealthService()
+	if executorLease != nil {
+		healthSvc.SetExecutorStatus(cfg.ComfyUI != nil, cfg.MultiProcess.Role, executorLease)
+	} else {
+		healthSvc.SetExecutorStatus(cfg.ComfyUI != nil, "", nil)
+	}
+	healthSvc.SetWatcherStats(watcher)
+	healthSvc.SetDBStats(st)
 	docsSvc := api.NewDocsService(spec)
 	validationSvc := service.NewValidationService(fs, cfg.SampleDir, logger)
+	if !cfg.OutputLayout.IsZero() {
+		validationSvc.SetRecursiveImageLister(fs)
+	}
+	pinSvc := service.NewPinService(st, logger)
 	trainingRunsSvc := api.NewTrainingRunsService(viewerDiscovery, discovery, scanner, validationSvc, watcher, st)
+	trainingRunsSvc.SetConfigService(service.NewTrainingRunConfigService(st, logger))
+	trainingRunsSvc.SetPinSetProvider(pinSvc)
+	trainingRunsSvc.SetChangeCurveService(service.NewChangeCurveService(st, fs, logger))
 	presetSvc := service.NewPresetService(st, logger)
 	presetsSvc := api.NewPresetsService(presetSvc)
 	studyAvailSvc := service.NewStudyAvailabilityService(fs, cfg.SampleDir, logger)
 	studyDirRemover := store.NewStudyDirRemover(fs, cfg.SampleDir)
-	studySvc := service.NewStudyService(st, studyAvailSvc, logger).WithSampleRemover(studyDirRemover)
+	studySvc := service.NewStudyService(st, studyAvailSvc, logger).WithSampleRemover(studyDirRemover).WithPinGuard(pinSvc)
 	studiesSvc := api.NewStudiesService(studySvc, studyAvailSvc, discovery)
+	if workflowLoader != nil {
+		workflowLoader.SetStudySource(studySvc)
+	}
 	demoSvc := service.NewDemoService(fs, st, cfg.SampleDir, logger)
 	demoAPISvc := api.NewDemoAPIService(demoSvc)
 
 	// Auto-install demo dataset on first run if not already present
 	if !demoSvc.Status().Installed {
-		if err := demoSvc.Install(); err != nil {
+		if err := demoSvc.Install(context.Background()); err != nil {
 			logger.WithError(err).Warn("failed to auto-install demo dataset on first run")
 		} else {
 			logger.Info("demo dataset auto-installed on first run")
 		}
 	}
 	checkpointMetadataSvc := service.NewCheckpointMetadataService(fs, cfg.CheckpointDirs, logger)
+	reloader.OnCheckpointDirs(checkpointMetadataSvc.SetCheckpointDirs)
 	checkpointsSvc := api.NewCheckpointsService(checkpointMetadataSvc)
 	imageMetadataSvc := service.NewImageMetadataService(fs, cfg.SampleDir, logger)
 	imagesSvc := api.NewImagesService(cfg.SampleDir, imageMetadataSvc, logger)
+	imagesSvc.SetPinService(pinSvc)
+	imagesSvc.SetImageAnnotationService(service.NewImageAnnotationService(st, cfg.SampleDir, logger))
+	imagesSvc.SetGridRenderer(viewerDiscovery, scanner, service.NewGridRenderer(fs, cfg.SampleDir, logger))
+	imagesSvc.SetTimelineRenderer(viewerDiscovery, scanner, service.NewTimelineRenderer(service.RealThumbnailCacheFS{}, cfg.SampleDir, logger))
+	imagesSvc.SetComparisonService(service.NewComparisonService(fs, imageMetadataSvc, cfg.SampleDir, logger))
+	imagesSvc.SetSidecarBackfillService(service.NewSidecarBackfillService(fs, &service.RealFileSystemWriter{}, cfg.SampleDir, logger))
+	sampleDeleteSvc := service.NewSampleDeleteService(fs, hub, cfg.SampleDir, logger)
+	sampleDeleteSvc.SetPinGuard(pinSvc)
+	sampleDeleteSvc.SetImageIndex(imageIndex)
+	imagesSvc.SetSampleDeleteService(sampleDeleteSvc)
+	imagesSvc.SetCheckpointArchiveService(service.NewCheckpointArchiveService(fs, cfg.SampleDir, logger))
+	thumbCacheCfg := service.DefaultThumbnailCacheConfig
+	if cfg.Thumbnails != nil {
+		thumbCacheCfg = *cfg.Thumbnails
+	}
+	thumbCache := service.NewThumbnailCache(service.RealThumbnailCacheFS{}, thumbCacheCfg, cfg.SampleDir, logger)
+	imagesSvc.SetThumbnailCache(thumbCache)
+	watcher.SetThumbnailInvalidator(thumbCache)
+	galleriesSvc := api.NewGalleriesService(service.NewGalleryService(st, viewerDiscovery, scanner, logger), cfg.SampleDir, logger)
+
+	// Create asset upload service if configured
+	var assetSvc *service.AssetService
+	if cfg.Assets != nil {
+		assetSvc = service.NewAssetService(st, *cfg.Assets, logger)
+		if err := assetSvc.EnsureDir(); err != nil {
+			return fmt.Errorf("ensuring assets directory: %w", err)
+		}
+	}
+	assetsSvc := api.NewAssetsService(assetSvc)
 	wsPingInterval := time.Duration(cfg.WsPingInterval) * time.Second
+	requestTimeout := time.Duration(cfg.RequestTimeout) * time.Second
 	wsSvc := api.NewWSServiceWithPing(hub, wsPingInterval, logger)
 
+	jobTemplateSvc := service.NewJobTemplateService(st, logger)
+
 	// Create sample job service (requires ComfyUI model discovery for path matching)
 	var sampleJobsSvc *api.SampleJobsService
 	if cfg.ComfyUI != nil {
-		pathMatcher := service.NewCheckpointPathMatcher(modelDiscovery, logger)
+		checkpointPathMatcher := service.NewCheckpointPathMatcher(modelCache, logger)
+		checkpointPathMatcher.SetModelsRefresher(modelCache)
+		if err := checkpointPathMatcher.SetMappingRules(cfg.ComfyUI.PathMapping); err != nil {
+			return fmt.Errorf("configuring checkpoint path mapping: %w", err)
+		}
+		checkpointsSvc.SetMatchPreviewer(checkpointPathMatcher, discovery)
+		var pathMatcher service.PathMatcher = checkpointPathMatcher
+		if staging := cfg.ComfyUI.CheckpointStaging; staging != nil {
+			// Checkpoints ComfyUI cannot see are copied into a directory it
+			// shares with the sampler before they are sampled.
+			checkpointStaging := service.NewCheckpointStaging(pathMatcher, checkpointMetadataSvc, fs, *staging, logger)
+			pathMatcher = checkpointStaging
+			jobExecutor.SetCheckpointStager(checkpointStaging)
+		}
 		dirRemover := store.NewCheckpointSampleDirRemover(fs, cfg.SampleDir)
 		sampleJobSvc := service.NewSampleJobService(st, pathMatcher, dirRemover, cfg.SampleDir, logger)
 		sampleJobSvc.SetFileChecker(&service.RealOutputFileChecker{})
 		sampleJobSvc.SetJobDataRemover(store.NewJobSampleDirRemover(fs, cfg.SampleDir))
+		sampleJobSvc.SetPinGuard(pinSvc)
+		sampleJobSvc.SetItemDurationSource(st)
+		sampleJobSvc.SetWorkflowSource(workflowLoader)
+		sampleJobSvc.SetTrainingRunSource(discovery)
+		sampleJobSvc.SetVRAMChecker(vramGuard)
+		sampleJobSvc.SetOutputLayout(cfg.OutputLayout)
+		sampleJobSvc.SetTrashRetention(time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour)
+
+		// WebP output needs the cwebp binary; JPEG is encoded in-process.
+		var webpEncoder service.WebPEncoder
+		if enc, err := store.FindCWebPEncoder(logger); err != nil {
+			logger.WithError(err).Info("cwebp not found, webp output format is disabled")
+		} else {
+			webpEncoder = enc
+		}
+		transcoder := service.NewImageTranscoder(webpEncoder, logger)
+		sampleJobSvc.SetOutputFormatSupport(transcoder)
+		jobExecutor.SetTranscoder(transcoder)
 
 		// Wire the executor and service together (avoiding circular dependency)
 		sampleJobSvc.SetExecutor(jobExecutor)
 		jobExecutor.SetDirRemover(dirRemover)
+		jobExecutor.SetPinGuard(pinSvc)
+		jobExecutor.SetCheckpointAppender(sampleJobSvc)
+
+		// Automatic image scoring by an external scoring service
+		if cfg.Scoring != nil {
+			jobExecutor.SetImageScorer(store.NewScoringClient(cfg.Scoring.URL), time.Duration(cfg.Scoring.Timeout)*time.Second)
+		}
 
-		// Start the job executor (non-fatal if ComfyUI is unreachable)
-		if err := jobExecutor.Start(); err != nil {
-			logger.WithError(err).Warn("job executor failed to start, sample jobs may not work until ComfyUI is available")
-			// Continue - the executor will retry connection in the background
+		// Webhook notifications on job completion, failure, and stop. Waiting
+		// is deferred before the executor's Stop so that notifications sent
+		// during shutdown are delivered first.
+		if cfg.Notifications != nil {
+			notifier := service.NewWebhookNotifier(st, store.NewWebhookClient(), *cfg.Notifications, logger)
+			jobExecutor.SetJobNotifier(notifier)
+			defer notifier.Wait()
 		}
-		defer jobExecutor.Stop()
 
+		// Start the job executor (non-fatal if ComfyUI is unreachable).
+		// In multi-process mode, api-role processes never run the executor and
+		// other processes only process jobs while holding the executor lease.
+		if cfg.MultiProcess == nil || cfg.MultiProcess.Role.RunsExecutor() {
+			if executorLease != nil {
+				jobExecutor.SetExecutorGate(executorLease)
+				leaseStop := make(chan struct{})
+				leaseDone := make(chan struct{})
+				go func() {
+					defer close(leaseDone)
+					executorLease.Run(leaseStop)
+				}()
+				defer func() {
+					close(leaseStop)
+					<-leaseDone
+				}()
+			}
+			if err := jobExecutor.Start(); err != nil {
+				logger.WithError(err).Warn("job executor failed to start, sample jobs may not work until ComfyUI is available")
+				// Continue - the executor will retry connection in the background
+			}
+			defer jobExecutor.Stop()
+
+			// Heartbeat: signal external monitoring while the executor is
+			// connected and processing, so a stalled executor raises an alert.
+			if cfg.Heartbeat != nil {
+				var targets []service.HeartbeatTarget
+				if cfg.Heartbeat.File != "" {
+					targets = append(targets, store.NewHeartbeatFile(cfg.Heartbeat.File))
+				}
+				if cfg.Heartbeat.URL != "" {
+					targets = append(targets, store.NewHeartbeatURL(cfg.Heartbeat.URL))
+				}
+				heartbeat := service.NewHeartbeat(jobExecutor, targets, *cfg.Heartbeat, logger)
+				heartbeatStop := make(chan struct{})
+				heartbeatDone := make(chan struct{})
+				go func() {
+					defer close(heartbeatDone)
+					heartbeat.Run(heartbeatStop)
+				}()
+				defer func() {
+					close(heartbeatStop)
+					<-heartbeatDone
+				}()
+			}
+
+			// Watch mode: sample new checkpoints of configured training runs
+			// automatically. Only processes that may run the executor create
+			// these jobs, and in multi-process mode only the lease holder.
+			if cfg.AutoSample != nil {
+				checkpointNotifier, err := service.NewFSNotifier()
+				if err != nil {
+					return fmt.Errorf("creating checkpoint notifier: %w", err)
+				}
+				defer checkpointNotifier.Close()
+				autoSampler := service.NewAutoSampler(checkpointNotifier, discovery, st, sampleJobSvc, fs, cfg.CheckpointDirs, *cfg.AutoSample, logger)
+				if executorLease != nil {
+					autoSampler.SetExecutorGate(executorLease)
+				}
+				reloader.OnCheckpointDirs(autoSampler.SetCheckpointDirs)
+				autoSampler.Start()
+				defer autoSampler.Stop()
+			}
+
+			// Purge sample jobs that have been in the trash for longer than
+			// the trash retention.
+			trashStop := make(chan struct{})
+			trashDone := make(chan struct{})
+			go func() {
+				defer close(trashDone)
+				sampleJobSvc.RunTrashPurge(trashStop)
+			}()
+			defer func() {
+				close(trashStop)
+				<-trashDone
+			}()
+		} else {
+			logger.Info("job executor disabled for api role")
+		}
+
+		jobTemplateSvc.SetJobCreator(sampleJobSvc)
 		sampleJobsSvc = api.NewSampleJobsService(sampleJobSvc, discovery)
+		sampleJobsSvc.SetStudyService(studySvc)
+		sampleJobsSvc.SetJobTemplateService(jobTemplateSvc)
 	} else {
 		// Create a disabled service when ComfyUI is not configured
 		// dirRemover is nil since there are no jobs to clear
 		sampleJobsSvc = api.NewSampleJobsService(nil, discovery)
 	}
 
+	// Storage: disk usage reporting and the sample retention policy. In
+	// multi-process mode only processes that may run the executor apply the
+	// policy in the background.
+	storageSvc := service.NewStorageService(fs, st, pinSvc, cfg.SampleDir, logger)
+	if cfg.Retention != nil {
+		storageSvc.SetRetentionPolicy(*cfg.Retention)
+		if cfg.MultiProcess == nil || cfg.MultiProcess.Role.RunsExecutor() {
+			retentionStop := make(chan struct{})
+			retentionDone := make(chan struct{})
+			go func() {
+				defer close(retentionDone)
+				storageSvc.RunRetention(retentionStop)
+			}()
+			defer func() {
+				close(retentionStop)
+				<-retentionDone
+			}()
+		}
+	}
+	trainingRunsSvc.SetSummaryService(service.NewTrainingRunSummaryService(discovery, st, imageIndex, fs, storageSvc, cfg.SampleDir, logger))
+
+	// Checkpoint hashes: a fast hash of each discovered checkpoint, used to
+	// warn about the same checkpoint under several training runs or
+	// filenames. In multi-process mode only processes that may run the
+	// executor hash in the background; every process reports the hashes.
+	checkpointHashSvc := service.NewCheckpointHashService(discovery, st, fs, cfg.CheckpointDirs, logger)
+	reloader.OnCheckpointDirs(checkpointHashSvc.SetCheckpointDirs)
+	checkpointsSvc.SetHashReporter(checkpointHashSvc)
+	if cfg.MultiProcess == nil || cfg.MultiProcess.Role.RunsExecutor() {
+		hashStop := make(chan struct{})
+		hashDone := make(chan struct{})
+		go func() {
+			defer close(hashDone)
+			checkpointHashSvc.Run(hashStop)
+		}()
+		defer func() {
+			close(hashStop)
+			<-hashDone
+		}()
+	}
+
 	// Create Goa endpoints
-	healthEndpoints := genhealth.NewEndpoints(healthSvc)
 	docsEndpoints := gendocs.NewEndpoints(docsSvc)
 	trainingRunsEndpoints := gentrainingruns.NewEndpoints(trainingRunsSvc)
 	presetsEndpoints := genpresets.NewEndpoints(presetsSvc)
 	studiesEndpoints := genstudies.NewEndpoints(studiesSvc)
 	sampleJobsEndpoints := gensamplejobs.NewEndpoints(sampleJobsSvc)
+	jobTemplatesEndpoints := genjobtemplates.NewEndpoints(api.NewJobTemplatesService(jobTemplateSvc))
+	storageEndpoints := genstorage.NewEndpoints(api.NewStorageService(storageSvc))
 	checkpointsEndpoints := gencheckpoints.NewEndpoints(checkpointsSvc)
 	comfyuiEndpoints := gencomfyui.NewEndpoints(comfyuiSvc)
 	demoEndpoints := gendemo.NewEndpoints(demoAPISvc)
 	workflowsEndpoints := genworkflows.NewEndpoints(workflowsSvc)
 	imagesEndpoints := genimages.NewEndpoints(imagesSvc)
+	galleriesEndpoints := gengalleries.NewEndpoints(galleriesSvc)
+	assetsEndpoints := genassets.NewEndpoints(assetsSvc)
 	wsEndpoints := genws.NewEndpoints(wsSvc)
+	adminEndpoints := genadmin.NewEndpoints(api.NewAdminService(reloader))
+	syncEndpoints := gensync.NewEndpoints(api.NewSyncService(service.NewSyncService(st, logger)))
+	votesSvc := api.NewVotesService(service.NewVoteService(st, cfg.SampleDir, logger))
+	votesSvc.SetCheckpointScoreService(service.NewCheckpointScoreService(st, cfg.SampleDir, logger))
+	votesEndpoints := genvotes.NewEndpoints(votesSvc)
+	metaEndpoints := genmeta.NewEndpoints(api.NewMetaService(serverClock))
 
 	// Create sample directory cleaner and fixture seeder for test reset endpoint
 	sampleDirCleaner := store.NewSampleDirCleaner(fs, cfg.SampleDir)
@@ -227,32 +643,79 @@ func run() error {
 		PresetsEndpoints:       presetsEndpoints,
 		StudiesEndpoints:       studiesEndpoints,
 		SampleJobsEndpoints:    sampleJobsEndpoints,
+		JobTemplatesEndpoints:  jobTemplatesEndpoints,
+		StorageEndpoints:       storageEndpoints,
 		CheckpointsEndpoints:   checkpointsEndpoints,
 		ComfyUIEndpoints:       comfyuiEndpoints,
 		WorkflowsEndpoints:     workflowsEndpoints,
 		ImagesEndpoints:        imagesEndpoints,
+		GalleriesEndpoints:     galleriesEndpoints,
+		AssetsEndpoints:        assetsEndpoints,
+		AdminEndpoints:         adminEndpoints,
+		SyncEndpoints:          syncEndpoints,
+		VotesEndpoints:         votesEndpoints,
+		MetaEndpoints:          metaEndpoints,
 		WSEndpoints:            wsEndpoints,
 		DemoEndpoints:          demoEndpoints,
 		SwaggerUIDir:           http.Dir(swaggerUIDir()),
 		Logger:                 logger,
 		Debug:                  true,
 		WsPingInterval:         wsPingInterval,
+		RequestTimeout:         requestTimeout,
 		DBResetter:             st,
 		BackgroundPauser:       bgPauser,
 		SampleDirCleaner:       sampleDirCleaner,
 		FixtureSeeder:          fixtureSeeder,
 		JobSeeder:              st,
 		PartialSampleSeeder:    partialSampleSeeder,
+		RateLimit:              cfg.RateLimit,
+		Users:                  cfg.Users,
 	})
 
-	// Create HTTP server
-	addr := net.JoinHostPort(cfg.IPAddress, fmt.Sprintf("%d", cfg.Port))
-	srv := &http.Server{
-		Addr:         addr,
-		Handler:      handler,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	// Reload the configuration when the file changes. Reloads can also be
+	// requested with POST /api/admin/reload-config.
+	configNotifier, err := service.NewFSNotifier()
+	if err != nil {
+		return fmt.Errorf("creating config notifier: %w", err)
+	}
+	defer configNotifier.Close()
+	configWatchStop := make(chan struct{})
+	configWatchDone := make(chan struct{})
+	go func() {
+		defer close(configWatchDone)
+		reloader.Watch(configNotifier, config.Path(), configWatchStop)
+	}()
+	defer func() {
+		close(configWatchStop)
+		<-configWatchDone
+	}()
+
+	// Serve the API from the already listening HTTP server
+	startupHandler.SetHandler(handler)
+	readiness.MarkReady(model.ReadinessStepAPI)
+
+	// Create the gRPC server, which serves a subset of the services on its
+	// own port with the same endpoints as the HTTP server.
+	var grpcSrv *grpc.Server
+	if cfg.GRPCPort > 0 {
+		grpcAddr := net.JoinHostPort(cfg.IPAddress, fmt.Sprintf("%d", cfg.GRPCPort))
+		grpcListener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			return fmt.Errorf("listening for gRPC on %s: %w", grpcAddr, err)
+		}
+		grpcSrv = api.NewGRPCServer(api.GRPCServerConfig{
+			SampleJobsEndpoints:   sampleJobsEndpoints,
+			StudiesEndpoints:      studiesEndpoints,
+			TrainingRunsEndpoints: trainingRunsEndpoints,
+			Logger:                logger,
+			Users:                 cfg.Users,
+		})
+		go func() {
+			logger.WithField("address", grpcAddr).Info("starting gRPC server")
+			if err := grpcSrv.Serve(grpcListener); err != nil {
+				logger.WithError(err).Error("gRPC server error")
+			}
+		}()
 	}
 
 	// Graceful shutdown
@@ -264,19 +727,32 @@ func run() error {
 		logger.Info("shutdown signal received")
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
+		if grpcSrv != nil {
+			grpcSrv.GracefulStop()
+		}
 		if err := srv.Shutdown(shutdownCtx); err != nil {
 			logger.WithError(err).Error("shutdown error")
 		}
 	}()
 
-	logger.WithField("address", addr).Info("starting server")
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	// Wait for the HTTP server to stop after a graceful shutdown or fail
+	if err, failed := <-serverErr; failed {
 		return fmt.Errorf("server error: %w", err)
 	}
 
 	return nil
 }
 
+// defaultInstanceID identifies this process in multi-process mode when no
+// instance_id is configured.
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
 // openAPISpecPath returns the path to the generated OpenAPI 3.0 spec.
 // In production (Dockerfile), it's at backend/gen/http/openapi3.json.
 // In development, it's at backend/internal/api/gen/http/openapi3.json.
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	genadmin "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/admin"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// ConfigReloader re-reads the configuration file and applies its changes.
+type ConfigReloader interface {
+	Reload() (model.ConfigReloadResult, error)
+}
+
+// AdminService implements the generated admin service interface.
+type AdminService struct {
+	reloader ConfigReloader
+}
+
+// NewAdminService returns a new AdminService. If reloader is nil,
+// reload_config returns a service_unavailable error.
+func NewAdminService(reloader ConfigReloader) *AdminService {
+	return &AdminService{reloader: reloader}
+}
+
+// ReloadConfig re-reads the configuration file and reports which changed
+// settings were applied and which need a restart.
+func (s *AdminService) ReloadConfig(ctx context.Context) (*genadmin.ConfigReloadResponse, error) {
+	if s.reloader == nil {
+		return nil, genadmin.MakeServiceUnavailable(fmt.Errorf("config reload not available"))
+	}
+	result, err := s.reloader.Reload()
+	if err != nil {
+		// Reload only fails when the file cannot be read or is invalid.
+		return nil, genadmin.MakeInvalidConfig(err)
+	}
+	return &genadmin.ConfigReloadResponse{
+		Applied:         result.Applied,
+		RestartRequired: result.RestartRequired,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// fakeConfigReloader implements api.ConfigReloader for testing.
+type fakeConfigReloader struct {
+	result model.ConfigReloadResult
+	err    error
+}
+
+func (f *fakeConfigReloader) Reload() (model.ConfigReloadResult, error) {
+	return f.result, f.err
+}
+
+var _ = Describe("AdminService", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	Describe("ReloadConfig", func() {
+		It("reports applied and restart-required settings", func() {
+			svc := api.NewAdminService(&fakeConfigReloader{result: model.ConfigReloadResult{
+				Applied:         []string{"checkpoint_dirs"},
+				RestartRequired: []string{"sample_dir"},
+			}})
+
+			res, err := svc.ReloadConfig(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Applied).To(Equal([]string{"checkpoint_dirs"}))
+			Expect(res.RestartRequired).To(Equal([]string{"sample_dir"}))
+		})
+
+		It("returns invalid_config when the file is invalid", func() {
+			svc := api.NewAdminService(&fakeConfigReloader{err: errors.New("invalid config: config: sample_dir is required")})
+
+			_, err := svc.ReloadConfig(ctx)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_config"))
+		})
+
+		It("returns service_unavailable without a reloader", func() {
+			svc := api.NewAdminService(nil)
+
+			_, err := svc.ReloadConfig(ctx)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+	})
+})
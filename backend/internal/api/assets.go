@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	genassets "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/assets"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// errAssetsDisabled is returned by every method except List when the assets
+// section is not configured.
+var errAssetsDisabled = fmt.Errorf("asset uploads not available: assets are not configured")
+
+// AssetsService implements the generated assets service interface.
+type AssetsService struct {
+	svc     *service.AssetService
+	enabled bool
+}
+
+// NewAssetsService returns a new AssetsService.
+// If svc is nil (assets not configured), List returns an empty list and the
+// other methods return a service_unavailable error.
+func NewAssetsService(svc *service.AssetService) *AssetsService {
+	return &AssetsService{
+		svc:     svc,
+		enabled: svc != nil,
+	}
+}
+
+// List returns the uploaded assets, newest first.
+func (s *AssetsService) List(ctx context.Context, p *genassets.ListPayload) ([]*genassets.AssetResponse, error) {
+	if !s.enabled {
+		return []*genassets.AssetResponse{}, nil
+	}
+	var kind model.AssetKind
+	if p.Kind != nil {
+		kind = model.AssetKind(*p.Kind)
+	}
+	assets, err := s.svc.List(ctx, kind)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			return nil, genassets.MakeInvalidPayload(err)
+		}
+		return nil, genassets.MakeInternalError(err)
+	}
+	result := make([]*genassets.AssetResponse, len(assets))
+	for i, a := range assets {
+		result[i] = assetToResponse(a)
+	}
+	return result, nil
+}
+
+// Delete removes an asset and its file.
+func (s *AssetsService) Delete(ctx context.Context, p *genassets.DeletePayload) error {
+	if !s.enabled {
+		return genassets.MakeServiceUnavailable(errAssetsDisabled)
+	}
+	if err := s.svc.Delete(ctx, p.ID); err != nil {
+		if isNotFound(err) {
+			return genassets.MakeNotFound(err)
+		}
+		return genassets.MakeInternalError(err)
+	}
+	return nil
+}
+
+// StartUpload validates a new asset and creates an upload for its content.
+func (s *AssetsService) StartUpload(ctx context.Context, p *genassets.StartAssetUploadPayload) (*genassets.AssetUploadResponse, error) {
+	if !s.enabled {
+		return nil, genassets.MakeServiceUnavailable(errAssetsDisabled)
+	}
+	u, err := s.svc.StartUpload(ctx, model.AssetKind(p.Kind), p.Filename, p.ContentType, p.Size)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			return nil, genassets.MakeInvalidPayload(err)
+		}
+		return nil, genassets.MakeInternalError(err)
+	}
+	return assetUploadToResponse(u), nil
+}
+
+// ShowUpload returns an upload's progress.
+func (s *AssetsService) ShowUpload(ctx context.Context, p *genassets.ShowUploadPayload) (*genassets.AssetUploadResponse, error) {
+	if !s.enabled {
+		return nil, genassets.MakeServiceUnavailable(errAssetsDisabled)
+	}
+	u, err := s.svc.GetUpload(ctx, p.UploadID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genassets.MakeNotFound(err)
+		}
+		return nil, genassets.MakeInternalError(err)
+	}
+	return assetUploadToResponse(u), nil
+}
+
+// UploadChunk appends the request body to an upload.
+func (s *AssetsService) UploadChunk(ctx context.Context, p *genassets.UploadChunkPayload, body io.ReadCloser) (*genassets.AssetUploadResponse, error) {
+	defer body.Close()
+	if !s.enabled {
+		return nil, genassets.MakeServiceUnavailable(errAssetsDisabled)
+	}
+	u, err := s.svc.UploadChunk(ctx, p.UploadID, p.Offset, body)
+	if err != nil {
+		switch {
+		case isNotFound(err):
+			return nil, genassets.MakeNotFound(err)
+		case errors.Is(err, service.ErrAssetUploadOffset):
+			return nil, genassets.MakeConflict(err)
+		case strings.HasPrefix(err.Error(), "invalid"):
+			return nil, genassets.MakeInvalidPayload(err)
+		}
+		return nil, genassets.MakeInternalError(err)
+	}
+	return assetUploadToResponse(u), nil
+}
+
+// CompleteUpload turns a fully received upload into an asset.
+func (s *AssetsService) CompleteUpload(ctx context.Context, p *genassets.CompleteUploadPayload) (*genassets.AssetResponse, error) {
+	if !s.enabled {
+		return nil, genassets.MakeServiceUnavailable(errAssetsDisabled)
+	}
+	var sum string
+	if p.Sha256 != nil {
+		sum = *p.Sha256
+	}
+	a, err := s.svc.CompleteUpload(ctx, p.UploadID, sum)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genassets.MakeNotFound(err)
+		}
+		if strings.HasPrefix(err.Error(), "invalid") {
+			return nil, genassets.MakeInvalidPayload(err)
+		}
+		return nil, genassets.MakeInternalError(err)
+	}
+	return assetToResponse(a), nil
+}
+
+// AbortUpload discards an upload.
+func (s *AssetsService) AbortUpload(ctx context.Context, p *genassets.AbortUploadPayload) error {
+	if !s.enabled {
+		return genassets.MakeServiceUnavailable(errAssetsDisabled)
+	}
+	if err := s.svc.AbortUpload(ctx, p.UploadID); err != nil {
+		if isNotFound(err) {
+			return genassets.MakeNotFound(err)
+		}
+		return genassets.MakeInternalError(err)
+	}
+	return nil
+}
+
+func assetToResponse(a model.Asset) *genassets.AssetResponse {
+	return &genassets.AssetResponse{
+		ID:          a.ID,
+		Kind:        string(a.Kind),
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Sha256:      a.SHA256,
+		Path:        a.Path,
+		CreatedAt:   a.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func assetUploadToResponse(u model.AssetUpload) *genassets.AssetUploadResponse {
+	return &genassets.AssetUploadResponse{
+		ID:          u.ID,
+		Kind:        string(u.Kind),
+		Filename:    u.Filename,
+		ContentType: u.ContentType,
+		Size:        u.Size,
+		Received:    u.Received,
+		CreatedAt:   u.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   u.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,199 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	goahttp "goa.design/goa/v3/http"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	genassets "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/assets"
+	genassetssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/assets/server"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("AssetsService", func() {
+	var (
+		svc    *api.AssetsService
+		st     *store.Store
+		tmpDir string
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		var err error
+		ctx = context.Background()
+		tmpDir, err = os.MkdirTemp("", "assets-api-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		assetSvc := service.NewAssetService(st, model.AssetsConfig{
+			Dir:                filepath.Join(tmpDir, "assets"),
+			MaxImageSizeMB:     1,
+			MaxWildcardsSizeMB: 1,
+			UploadTTL:          24,
+		}, logger)
+		Expect(assetSvc.EnsureDir()).To(Succeed())
+		svc = api.NewAssetsService(assetSvc)
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	startWildcards := func(size int64) *genassets.AssetUploadResponse {
+		u, err := svc.StartUpload(ctx, &genassets.StartAssetUploadPayload{
+			Kind:        "wildcards",
+			Filename:    "colors.txt",
+			ContentType: "text/plain",
+			Size:        size,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return u
+	}
+
+	chunk := func(id string, offset int64, data string) (*genassets.AssetUploadResponse, error) {
+		return svc.UploadChunk(ctx, &genassets.UploadChunkPayload{UploadID: id, Offset: offset}, io.NopCloser(strings.NewReader(data)))
+	}
+
+	It("uploads, lists, and deletes an asset", func() {
+		u := startWildcards(9)
+		Expect(u.Received).To(BeZero())
+
+		_, err := chunk(u.ID, 0, "red\n")
+		Expect(err).NotTo(HaveOccurred())
+		progress, err := chunk(u.ID, 4, "blue\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(progress.Received).To(Equal(int64(9)))
+
+		a, err := svc.CompleteUpload(ctx, &genassets.CompleteUploadPayload{UploadID: u.ID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.Kind).To(Equal("wildcards"))
+		Expect(a.Filename).To(Equal("colors.txt"))
+		Expect(a.Size).To(Equal(int64(9)))
+		Expect(a.Path).To(Equal("wildcards/" + a.Sha256 + ".txt"))
+
+		kind := "wildcards"
+		assets, err := svc.List(ctx, &genassets.ListPayload{Kind: &kind})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(assets).To(HaveLen(1))
+		Expect(assets[0].ID).To(Equal(a.ID))
+
+		Expect(svc.Delete(ctx, &genassets.DeletePayload{ID: a.ID})).To(Succeed())
+		assets, err = svc.List(ctx, &genassets.ListPayload{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(assets).To(BeEmpty())
+	})
+
+	It("resumes an upload from the received offset", func() {
+		u := startWildcards(9)
+		_, err := chunk(u.ID, 0, "red\n")
+		Expect(err).NotTo(HaveOccurred())
+
+		shown, err := svc.ShowUpload(ctx, &genassets.ShowUploadPayload{UploadID: u.ID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shown.Received).To(Equal(int64(4)))
+	})
+
+	DescribeTable("maps errors to Goa error names",
+		func(call func() error, expected string) {
+			err := call()
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal(expected))
+		},
+		Entry("unsupported content type", func() error {
+			_, err := svc.StartUpload(ctx, &genassets.StartAssetUploadPayload{Kind: "image", Filename: "a.gif", ContentType: "image/gif", Size: 10})
+			return err
+		}, "invalid_payload"),
+		Entry("chunk at the wrong offset", func() error {
+			u := startWildcards(9)
+			_, err := chunk(u.ID, 4, "blue\n")
+			return err
+		}, "conflict"),
+		Entry("chunk past the declared size", func() error {
+			u := startWildcards(2)
+			_, err := chunk(u.ID, 0, "red\n")
+			return err
+		}, "invalid_payload"),
+		Entry("completing an incomplete upload", func() error {
+			u := startWildcards(9)
+			_, err := svc.CompleteUpload(ctx, &genassets.CompleteUploadPayload{UploadID: u.ID})
+			return err
+		}, "invalid_payload"),
+		Entry("unknown upload", func() error {
+			_, err := svc.ShowUpload(ctx, &genassets.ShowUploadPayload{UploadID: "missing"})
+			return err
+		}, "not_found"),
+		Entry("unknown asset", func() error {
+			return svc.Delete(ctx, &genassets.DeletePayload{ID: "missing"})
+		}, "not_found"),
+	)
+
+	It("accepts raw chunk bodies over HTTP", func() {
+		mux := goahttp.NewMuxer()
+		server := genassetssvr.New(genassets.NewEndpoints(svc), mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, nil)
+		server.Mount(mux)
+		ts := httptest.NewServer(mux)
+		defer ts.Close()
+
+		u := startWildcards(9)
+		req, err := http.NewRequest(http.MethodPut, ts.URL+"/api/assets/uploads/"+u.ID+"?offset=0", bytes.NewReader([]byte("red\nblue\n")))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/octet-stream")
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		var body struct {
+			Received int64 `json:"received"`
+		}
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+		Expect(body.Received).To(Equal(int64(9)))
+	})
+
+	Context("when assets are not configured", func() {
+		BeforeEach(func() {
+			svc = api.NewAssetsService(nil)
+		})
+
+		It("lists no assets", func() {
+			assets, err := svc.List(ctx, &genassets.ListPayload{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(assets).NotTo(BeNil())
+			Expect(assets).To(BeEmpty())
+		})
+
+		It("returns service_unavailable for uploads", func() {
+			_, err := svc.StartUpload(ctx, &genassets.StartAssetUploadPayload{Kind: "image", Filename: "a.png", ContentType: "image/png", Size: 10})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+	})
+})
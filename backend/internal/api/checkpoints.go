@@ -6,12 +6,28 @@ import (
 	"strings"
 
 	gencheckpoints "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/checkpoints"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 )
 
+// CheckpointMatchPreviewer resolves checkpoint filenames to ComfyUI model
+// paths without creating a job.
+type CheckpointMatchPreviewer interface {
+	PreviewMatches(ctx context.Context, trainingRunName string, filenames []string) ([]model.CheckpointMatch, error)
+}
+
+// CheckpointHashReporter reports the hashes of the discovered checkpoints and
+// the duplicates among them.
+type CheckpointHashReporter interface {
+	Report(ctx context.Context) (model.CheckpointHashReport, error)
+}
+
 // CheckpointsService implements the generated checkpoints service interface.
 type CheckpointsService struct {
 	metadataSvc *service.CheckpointMetadataService
+	previewer   CheckpointMatchPreviewer
+	discovery   TrainingRunDiscoverer
+	hashes      CheckpointHashReporter
 }
 
 // NewCheckpointsService returns a new CheckpointsService.
@@ -19,6 +35,18 @@ func NewCheckpointsService(metadataSvc *service.CheckpointMetadataService) *Chec
 	return &CheckpointsService{metadataSvc: metadataSvc}
 }
 
+// SetMatchPreviewer enables the match_preview endpoint. discovery lists the
+// training runs whose checkpoints are resolved.
+func (s *CheckpointsService) SetMatchPreviewer(previewer CheckpointMatchPreviewer, discovery TrainingRunDiscoverer) {
+	s.previewer = previewer
+	s.discovery = discovery
+}
+
+// SetHashReporter enables the hashes endpoint.
+func (s *CheckpointsService) SetHashReporter(hashes CheckpointHashReporter) {
+	s.hashes = hashes
+}
+
 // Metadata returns training metadata (ss_* fields) from a safetensors checkpoint file header.
 func (s *CheckpointsService) Metadata(ctx context.Context, p *gencheckpoints.MetadataPayload) (*gencheckpoints.CheckpointMetadataResponse, error) {
 	metadata, err := s.metadataSvc.GetMetadata(p.Filename)
@@ -41,3 +69,96 @@ func (s *CheckpointsService) Metadata(ctx context.Context, p *gencheckpoints.Met
 		Metadata: metadata,
 	}, nil
 }
+
+// MatchPreview shows how the training run's checkpoints, or the given
+// filenames, resolve to ComfyUI model paths.
+func (s *CheckpointsService) MatchPreview(ctx context.Context, p *gencheckpoints.MatchPreviewPayload) (*gencheckpoints.CheckpointMatchPreviewResult, error) {
+	if s.previewer == nil {
+		return nil, gencheckpoints.MakeServiceUnavailable(fmt.Errorf("ComfyUI is not configured"))
+	}
+
+	filenames := p.Filenames
+	if len(filenames) == 0 {
+		runs, err := s.discovery.Discover(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("discovering training runs: %w", err)
+		}
+		var run *model.TrainingRun
+		for i := range runs {
+			if runs[i].Name == p.TrainingRunName {
+				run = &runs[i]
+				break
+			}
+		}
+		if run == nil {
+			return nil, gencheckpoints.MakeNotFound(fmt.Errorf("training run not found: %s", p.TrainingRunName))
+		}
+		for _, cp := range run.Checkpoints {
+			filenames = append(filenames, cp.Filename)
+		}
+	}
+
+	matches, err := s.previewer.PreviewMatches(ctx, p.TrainingRunName, filenames)
+	if err != nil {
+		return nil, gencheckpoints.MakeServiceUnavailable(err)
+	}
+	result := &gencheckpoints.CheckpointMatchPreviewResult{
+		Matches: make([]*gencheckpoints.CheckpointMatchResponse, len(matches)),
+	}
+	for i, m := range matches {
+		r := &gencheckpoints.CheckpointMatchResponse{
+			Filename:   m.Filename,
+			MappedPath: m.MappedPath,
+			Matched:    m.ModelPath != "",
+		}
+		if m.ModelPath != "" {
+			modelPath := m.ModelPath
+			r.ComfyuiPath = &modelPath
+		}
+		result.Matches[i] = r
+	}
+	return result, nil
+}
+
+// Hashes lists the discovered checkpoints with their hashes and the
+// checkpoints that appear under more than one training run or filename.
+func (s *CheckpointsService) Hashes(ctx context.Context) (*gencheckpoints.CheckpointHashReportResponse, error) {
+	if s.hashes == nil {
+		return nil, gencheckpoints.MakeServiceUnavailable(fmt.Errorf("checkpoint hashing is not enabled"))
+	}
+	report, err := s.hashes.Report(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reporting checkpoint hashes: %w", err)
+	}
+	result := &gencheckpoints.CheckpointHashReportResponse{
+		Checkpoints:  checkpointHashesToResponse(report.Checkpoints),
+		Duplicates:   make([]*gencheckpoints.CheckpointDuplicateResponse, len(report.Duplicates)),
+		PendingCount: report.PendingCount,
+	}
+	for i, d := range report.Duplicates {
+		result.Duplicates[i] = &gencheckpoints.CheckpointDuplicateResponse{
+			Hash:        d.Hash,
+			Checkpoints: checkpointHashesToResponse(d.Checkpoints),
+		}
+	}
+	return result, nil
+}
+
+func checkpointHashesToResponse(checkpoints []model.HashedCheckpoint) []*gencheckpoints.CheckpointHashResponse {
+	res := make([]*gencheckpoints.CheckpointHashResponse, len(checkpoints))
+	for i, c := range checkpoints {
+		r := &gencheckpoints.CheckpointHashResponse{
+			TrainingRun:        c.TrainingRun,
+			Filename:           c.Filename,
+			RelativePath:       c.RelativePath,
+			CheckpointDirIndex: c.CheckpointDirIndex,
+			Size:               c.Size,
+		}
+		if c.Hash != "" {
+			hash := c.Hash
+			r.Hash = &hash
+		}
+		res[i] = r
+	}
+	return res
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -16,9 +17,39 @@ import (
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
 	gencheckpoints "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/checkpoints"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 )
 
+// fakeMatchPreviewer matches the filenames in its paths map.
+type fakeMatchPreviewer struct {
+	paths     map[string]string
+	err       error
+	filenames []string
+}
+
+func (f *fakeMatchPreviewer) PreviewMatches(ctx context.Context, trainingRunName string, filenames []string) ([]model.CheckpointMatch, error) {
+	f.filenames = filenames
+	if f.err != nil {
+		return nil, f.err
+	}
+	matches := make([]model.CheckpointMatch, len(filenames))
+	for i, fn := range filenames {
+		matches[i] = model.CheckpointMatch{Filename: fn, MappedPath: trainingRunName + "/" + fn, ModelPath: f.paths[fn]}
+	}
+	return matches, nil
+}
+
+// fakeHashReporter returns a fixed checkpoint hash report.
+type fakeHashReporter struct {
+	report model.CheckpointHashReport
+	err    error
+}
+
+func (f *fakeHashReporter) Report(ctx context.Context) (model.CheckpointHashReport, error) {
+	return f.report, f.err
+}
+
 // fakeMetadataReader implements service.CheckpointMetadataReader for testing.
 type fakeMetadataReader struct {
 	files map[string][]byte
@@ -229,6 +260,119 @@ var _ = Describe("CheckpointsService", func() {
 			Expect(result.Metadata["ss_output_name"]).To(Equal("model-in-dir2"))
 		})
 	})
+
+	Describe("MatchPreview", func() {
+		var (
+			svc       *api.CheckpointsService
+			previewer *fakeMatchPreviewer
+		)
+
+		BeforeEach(func() {
+			svc = api.NewCheckpointsService(service.NewCheckpointMetadataService(newFakeMetadataReader(), []string{tmpDir}, logger))
+			previewer = &fakeMatchPreviewer{paths: map[string]string{"a.safetensors": "unet/run/a.safetensors"}}
+			svc.SetMatchPreviewer(previewer, &fakeDiscoverer{runs: []model.TrainingRun{{
+				Name:        "run",
+				Checkpoints: []model.Checkpoint{{Filename: "a.safetensors"}, {Filename: "b.safetensors"}},
+			}}})
+		})
+
+		It("resolves the training run's checkpoints", func() {
+			result, err := svc.MatchPreview(context.Background(), &gencheckpoints.MatchPreviewPayload{TrainingRunName: "run"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Matches).To(HaveLen(2))
+			Expect(result.Matches[0].Filename).To(Equal("a.safetensors"))
+			Expect(result.Matches[0].MappedPath).To(Equal("run/a.safetensors"))
+			Expect(result.Matches[0].Matched).To(BeTrue())
+			Expect(*result.Matches[0].ComfyuiPath).To(Equal("unet/run/a.safetensors"))
+			Expect(result.Matches[1].Matched).To(BeFalse())
+			Expect(result.Matches[1].ComfyuiPath).To(BeNil())
+		})
+
+		It("resolves the given filenames instead of the run's checkpoints", func() {
+			_, err := svc.MatchPreview(context.Background(), &gencheckpoints.MatchPreviewPayload{
+				TrainingRunName: "unknown-run",
+				Filenames:       []string{"c.safetensors"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(previewer.filenames).To(Equal([]string{"c.safetensors"}))
+		})
+
+		It("returns not_found for an unknown training run", func() {
+			_, err := svc.MatchPreview(context.Background(), &gencheckpoints.MatchPreviewPayload{TrainingRunName: "unknown-run"})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("returns service_unavailable when ComfyUI cannot be queried", func() {
+			previewer.err = errors.New("connection refused")
+
+			_, err := svc.MatchPreview(context.Background(), &gencheckpoints.MatchPreviewPayload{TrainingRunName: "run"})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+
+		It("returns service_unavailable when ComfyUI is not configured", func() {
+			svc = api.NewCheckpointsService(service.NewCheckpointMetadataService(newFakeMetadataReader(), []string{tmpDir}, logger))
+
+			_, err := svc.MatchPreview(context.Background(), &gencheckpoints.MatchPreviewPayload{TrainingRunName: "run"})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+	})
+
+	Describe("Hashes", func() {
+		var (
+			svc      *api.CheckpointsService
+			reporter *fakeHashReporter
+		)
+
+		BeforeEach(func() {
+			svc = api.NewCheckpointsService(service.NewCheckpointMetadataService(newFakeMetadataReader(), []string{tmpDir}, logger))
+			a := model.HashedCheckpoint{TrainingRun: "run-a", Filename: "a.safetensors", RelativePath: "run-a/a.safetensors", Size: 10, Hash: "00000000000000aa"}
+			b := model.HashedCheckpoint{TrainingRun: "run-b", Filename: "b.safetensors", RelativePath: "run-b/b.safetensors", CheckpointDirIndex: 1, Size: 10, Hash: "00000000000000aa"}
+			pending := model.HashedCheckpoint{TrainingRun: "run-b", Filename: "c.safetensors", RelativePath: "run-b/c.safetensors", Size: 20}
+			reporter = &fakeHashReporter{report: model.CheckpointHashReport{
+				Checkpoints:  []model.HashedCheckpoint{a, b, pending},
+				Duplicates:   []model.CheckpointDuplicate{{Hash: "00000000000000aa", Checkpoints: []model.HashedCheckpoint{a, b}}},
+				PendingCount: 1,
+			}}
+			svc.SetHashReporter(reporter)
+		})
+
+		It("returns the checkpoint hashes and duplicates", func() {
+			result, err := svc.Hashes(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.PendingCount).To(Equal(1))
+			Expect(result.Checkpoints).To(HaveLen(3))
+			Expect(result.Checkpoints[1].TrainingRun).To(Equal("run-b"))
+			Expect(result.Checkpoints[1].CheckpointDirIndex).To(Equal(1))
+			Expect(result.Checkpoints[1].Size).To(Equal(int64(10)))
+			Expect(result.Checkpoints[1].Hash).To(HaveValue(Equal("00000000000000aa")))
+			Expect(result.Checkpoints[2].Hash).To(BeNil())
+			Expect(result.Duplicates).To(HaveLen(1))
+			Expect(result.Duplicates[0].Hash).To(Equal("00000000000000aa"))
+			Expect(result.Duplicates[0].Checkpoints).To(HaveLen(2))
+			Expect(result.Duplicates[0].Checkpoints[0].Filename).To(Equal("a.safetensors"))
+		})
+
+		It("returns reporter errors", func() {
+			reporter.err = errors.New("disk gone")
+			_, err := svc.Hashes(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("disk gone")))
+		})
+
+		It("returns service_unavailable when hashing is not enabled", func() {
+			svc = api.NewCheckpointsService(service.NewCheckpointMetadataService(newFakeMetadataReader(), []string{tmpDir}, logger))
+
+			_, err := svc.Hashes(context.Background())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+	})
 })
 
 // realFileReader opens real files from the filesystem.
@@ -237,3 +381,7 @@ type realFileReader struct{}
 func (r *realFileReader) OpenFile(path string) (io.ReadCloser, error) {
 	return os.Open(path)
 }
+
+func (r *realFileReader) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
@@ -2,8 +2,11 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	gencomfyui "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/comfyui"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 )
 
@@ -17,10 +20,29 @@ type ComfyUIModelLister interface {
 	GetModels(ctx context.Context, modelType service.ComfyUIModelType) ([]string, error)
 }
 
+// ComfyUIModelCacheRefresher clears the cached ComfyUI model lists.
+type ComfyUIModelCacheRefresher interface {
+	Refresh()
+}
+
+// ComfyUIQueueReader defines the interface for reading ComfyUI's queue.
+type ComfyUIQueueReader interface {
+	Queue(ctx context.Context) (model.ComfyUIQueue, error)
+}
+
+// ComfyUIStatsReader defines the interface for reading the cached ComfyUI
+// system stats.
+type ComfyUIStatsReader interface {
+	Snapshot() model.ComfyUIStatsSnapshot
+}
+
 // ComfyUIService implements the generated comfyui service interface.
 type ComfyUIService struct {
 	healthChecker ComfyUIHealthChecker
 	modelLister   ComfyUIModelLister
+	modelCache    ComfyUIModelCacheRefresher // optional; nil when ComfyUI is not configured
+	queueReader   ComfyUIQueueReader         // optional; nil when ComfyUI is not configured
+	statsReader   ComfyUIStatsReader         // optional; nil when ComfyUI is not configured
 	enabled       bool
 }
 
@@ -35,6 +57,24 @@ func NewComfyUIService(healthChecker ComfyUIHealthChecker, modelLister ComfyUIMo
 	}
 }
 
+// SetModelCache sets the model cache cleared by RefreshModels. This is
+// optional; if not set, RefreshModels reports the service as unavailable.
+func (s *ComfyUIService) SetModelCache(modelCache ComfyUIModelCacheRefresher) {
+	s.modelCache = modelCache
+}
+
+// SetQueueReader sets the reader used by Queue. This is optional; if not
+// set, Queue reports the service as unavailable.
+func (s *ComfyUIService) SetQueueReader(queueReader ComfyUIQueueReader) {
+	s.queueReader = queueReader
+}
+
+// SetStatsReader sets the reader used by SystemStats. This is optional; if
+// not set, SystemStats reports the service as unavailable.
+func (s *ComfyUIService) SetStatsReader(statsReader ComfyUIStatsReader) {
+	s.statsReader = statsReader
+}
+
 // Status returns the connection status of ComfyUI.
 func (s *ComfyUIService) Status(ctx context.Context) (*gencomfyui.ComfyUIStatusResult, error) {
 	if !s.enabled {
@@ -75,3 +115,74 @@ func (s *ComfyUIService) Models(ctx context.Context, p *gencomfyui.ModelsPayload
 		Models: models,
 	}, nil
 }
+
+// RefreshModels clears the cached model lists.
+func (s *ComfyUIService) RefreshModels(ctx context.Context) error {
+	if s.modelCache == nil {
+		return gencomfyui.MakeServiceUnavailable(fmt.Errorf("ComfyUI is not configured"))
+	}
+	s.modelCache.Refresh()
+	return nil
+}
+
+// Queue returns ComfyUI's running and pending prompts.
+func (s *ComfyUIService) Queue(ctx context.Context) (*gencomfyui.ComfyUIQueueResult, error) {
+	if s.queueReader == nil {
+		return nil, gencomfyui.MakeServiceUnavailable(fmt.Errorf("ComfyUI is not configured"))
+	}
+	queue, err := s.queueReader.Queue(ctx)
+	if err != nil {
+		return nil, gencomfyui.MakeServiceUnavailable(err)
+	}
+	return &gencomfyui.ComfyUIQueueResult{
+		Running: comfyUIQueueEntriesToResponse(queue.Running),
+		Pending: comfyUIQueueEntriesToResponse(queue.Pending),
+	}, nil
+}
+
+func comfyUIQueueEntriesToResponse(entries []model.ComfyUIQueueEntry) []*gencomfyui.ComfyUIQueueEntryResponse {
+	result := make([]*gencomfyui.ComfyUIQueueEntryResponse, len(entries))
+	for i, e := range entries {
+		r := &gencomfyui.ComfyUIQueueEntryResponse{
+			Number:   e.Number,
+			PromptID: e.PromptID,
+		}
+		if e.JobID != "" {
+			jobID, itemID := e.JobID, e.ItemID
+			r.JobID = &jobID
+			r.ItemID = &itemID
+		}
+		result[i] = r
+	}
+	return result
+}
+
+// SystemStats returns the cached ComfyUI devices and queue length.
+func (s *ComfyUIService) SystemStats(ctx context.Context) (*gencomfyui.ComfyUISystemStatsResult, error) {
+	if s.statsReader == nil {
+		return nil, gencomfyui.MakeServiceUnavailable(fmt.Errorf("ComfyUI is not configured"))
+	}
+	snapshot := s.statsReader.Snapshot()
+	result := &gencomfyui.ComfyUISystemStatsResult{
+		Devices:      make([]*gencomfyui.ComfyUIDeviceResponse, len(snapshot.Devices)),
+		QueueRunning: snapshot.QueueRunning,
+		QueuePending: snapshot.QueuePending,
+	}
+	for i, d := range snapshot.Devices {
+		result.Devices[i] = &gencomfyui.ComfyUIDeviceResponse{
+			Name:      d.Name,
+			Type:      d.Type,
+			VramTotal: d.VRAMTotal,
+			VramFree:  d.VRAMFree,
+		}
+	}
+	if !snapshot.UpdatedAt.IsZero() {
+		updatedAt := snapshot.UpdatedAt.Format(time.RFC3339)
+		result.UpdatedAt = &updatedAt
+	}
+	if snapshot.Error != "" {
+		errMsg := snapshot.Error
+		result.Error = &errMsg
+	}
+	return result, nil
+}
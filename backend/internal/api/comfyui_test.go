@@ -3,12 +3,14 @@ package api_test
 import (
 	"context"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
 	gencomfyui "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/comfyui"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 )
 
@@ -36,6 +38,32 @@ func (m *mockModelLister) GetModels(ctx context.Context, modelType service.Comfy
 	return []string{}, nil
 }
 
+// fakeModelCacheRefresher counts cache refreshes.
+type fakeModelCacheRefresher struct {
+	refreshed int
+}
+
+func (f *fakeModelCacheRefresher) Refresh() { f.refreshed++ }
+
+// fakeComfyUIQueueReader returns a fixed ComfyUI queue.
+type fakeComfyUIQueueReader struct {
+	queue model.ComfyUIQueue
+	err   error
+}
+
+func (f *fakeComfyUIQueueReader) Queue(ctx context.Context) (model.ComfyUIQueue, error) {
+	return f.queue, f.err
+}
+
+// fakeComfyUIStatsReader returns a fixed stats snapshot.
+type fakeComfyUIStatsReader struct {
+	snapshot model.ComfyUIStatsSnapshot
+}
+
+func (f *fakeComfyUIStatsReader) Snapshot() model.ComfyUIStatsSnapshot {
+	return f.snapshot
+}
+
 var _ = Describe("ComfyUIService", func() {
 	var (
 		ctx context.Context
@@ -232,4 +260,109 @@ var _ = Describe("ComfyUIService", func() {
 			Expect(result.Enabled).To(BeTrue())
 		})
 	})
+
+	Describe("RefreshModels", func() {
+		It("clears the model cache", func() {
+			svc := api.NewComfyUIService(&mockHealthChecker{}, &mockModelLister{})
+			cache := &fakeModelCacheRefresher{}
+			svc.SetModelCache(cache)
+
+			Expect(svc.RefreshModels(ctx)).To(Succeed())
+			Expect(cache.refreshed).To(Equal(1))
+		})
+
+		It("returns service_unavailable when ComfyUI is not configured", func() {
+			svc := api.NewComfyUIService(nil, nil)
+
+			err := svc.RefreshModels(ctx)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+	})
+
+	Describe("Queue", func() {
+		It("returns the queue with the IDs of recognized prompts", func() {
+			svc := api.NewComfyUIService(&mockHealthChecker{}, &mockModelLister{})
+			svc.SetQueueReader(&fakeComfyUIQueueReader{queue: model.ComfyUIQueue{
+				Running: []model.ComfyUIQueueEntry{{Number: 3, PromptID: "p1", JobID: "job-1", ItemID: "item-1"}},
+				Pending: []model.ComfyUIQueueEntry{{Number: 4, PromptID: "p2"}},
+			}})
+
+			result, err := svc.Queue(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Running).To(HaveLen(1))
+			Expect(result.Running[0].PromptID).To(Equal("p1"))
+			Expect(*result.Running[0].JobID).To(Equal("job-1"))
+			Expect(*result.Running[0].ItemID).To(Equal("item-1"))
+			Expect(result.Pending).To(HaveLen(1))
+			Expect(result.Pending[0].Number).To(Equal(4))
+			Expect(result.Pending[0].JobID).To(BeNil())
+		})
+
+		It("returns service_unavailable when ComfyUI is not configured", func() {
+			svc := api.NewComfyUIService(nil, nil)
+
+			_, err := svc.Queue(ctx)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+
+		It("returns service_unavailable when ComfyUI cannot be reached", func() {
+			svc := api.NewComfyUIService(&mockHealthChecker{}, &mockModelLister{})
+			svc.SetQueueReader(&fakeComfyUIQueueReader{err: fmt.Errorf("connection refused")})
+
+			_, err := svc.Queue(ctx)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+	})
+
+	Describe("SystemStats", func() {
+		It("returns the cached devices and queue length", func() {
+			svc := api.NewComfyUIService(&mockHealthChecker{}, &mockModelLister{})
+			svc.SetStatsReader(&fakeComfyUIStatsReader{snapshot: model.ComfyUIStatsSnapshot{
+				Devices:      []model.ComfyUIDevice{{Name: "cuda:0", Type: "cuda", VRAMTotal: 1000, VRAMFree: 400}},
+				QueueRunning: 1,
+				QueuePending: 2,
+				UpdatedAt:    time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+			}})
+
+			result, err := svc.SystemStats(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Devices).To(HaveLen(1))
+			Expect(result.Devices[0].VramTotal).To(Equal(int64(1000)))
+			Expect(result.Devices[0].VramFree).To(Equal(int64(400)))
+			Expect(result.QueueRunning).To(Equal(1))
+			Expect(result.QueuePending).To(Equal(2))
+			Expect(*result.UpdatedAt).To(Equal("2025-06-01T12:00:00Z"))
+			Expect(result.Error).To(BeNil())
+		})
+
+		It("omits the time before the first reading and reports a failed reading", func() {
+			svc := api.NewComfyUIService(&mockHealthChecker{}, &mockModelLister{})
+			svc.SetStatsReader(&fakeComfyUIStatsReader{snapshot: model.ComfyUIStatsSnapshot{Error: "connection refused"}})
+
+			result, err := svc.SystemStats(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Devices).To(BeEmpty())
+			Expect(result.UpdatedAt).To(BeNil())
+			Expect(*result.Error).To(Equal("connection refused"))
+		})
+
+		It("returns service_unavailable when ComfyUI is not configured", func() {
+			svc := api.NewComfyUIService(nil, nil)
+
+			_, err := svc.SystemStats(ctx)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+	})
 })
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConditionalRequestMiddleware returns middleware that answers conditional and
+// range GET requests on behalf of endpoints that stream a file. Goa allows an
+// endpoint that streams its body only one success status, so such endpoints
+// always respond 200 and describe the file with validators: ETag,
+// Last-Modified, and, if ranges are supported, "Accept-Ranges: bytes" with the
+// file's Content-Length. When the endpoint writes its 200 status, the
+// middleware compares the validators with the request's headers and instead
+// sends:
+//   - 304 without a body if If-None-Match (or, without it, If-Modified-Since)
+//     matches;
+//   - 206 with the requested bytes for a single satisfiable Range, unless
+//     If-Range names an older version of the file;
+//   - 416 if the Range starts beyond the end of the file.
+//
+// Requests for several ranges are served the whole file. The body is still
+// read in full and the bytes outside the range are dropped; the files served
+// are small enough that seeking is not worth bypassing the endpoint for.
+func ConditionalRequestMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(&conditionalResponseWriter{ResponseWriter: w, req: r, remaining: -1}, r)
+		})
+	}
+}
+
+// conditionalResponseWriter rewrites a 200 response according to the
+// conditional and range headers of req.
+type conditionalResponseWriter struct {
+	http.ResponseWriter
+	req         *http.Request
+	wroteHeader bool
+	discard     bool  // the response has no body (304, 416)
+	skip        int64 // bytes to drop before the requested range
+	remaining   int64 // bytes of the requested range still to send; -1 sends the rest
+}
+
+func (w *conditionalResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status == http.StatusOK {
+			status = w.evaluate()
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write sends the part of p that falls within the response. It never fails
+// for dropped bytes, since the endpoint aborts the connection if writing the
+// body fails after it has started.
+func (w *conditionalResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n := len(p)
+	if w.discard {
+		return n, nil
+	}
+	if w.skip > 0 {
+		if int64(len(p)) <= w.skip {
+			w.skip -= int64(len(p))
+			return n, nil
+		}
+		p = p[w.skip:]
+		w.skip = 0
+	}
+	if w.remaining >= 0 {
+		if int64(len(p)) > w.remaining {
+			p = p[:w.remaining]
+		}
+		w.remaining -= int64(len(p))
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+	if _, err := w.ResponseWriter.Write(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// ReadFrom lets whole-file responses keep using the underlying writer's
+// ReadFrom (sendfile for files served over TCP).
+func (w *conditionalResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok && !w.discard && w.skip == 0 && w.remaining < 0 {
+		return rf.ReadFrom(src)
+	}
+	return io.Copy(writerOnly{w}, src)
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *conditionalResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writerOnly hides the ReadFrom method of a writer so that io.Copy does not
+// call it recursively.
+type writerOnly struct {
+	io.Writer
+}
+
+// evaluate returns the status to send instead of 200 and adjusts the headers
+// and body to match it.
+func (w *conditionalResponseWriter) evaluate() int {
+	h := w.Header()
+	etag := h.Get("ETag")
+	lastModified, _ := http.ParseTime(h.Get("Last-Modified"))
+	if etag == "" && lastModified.IsZero() {
+		return http.StatusOK
+	}
+
+	if notModified(w.req, etag, lastModified) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		w.discard = true
+		return http.StatusNotModified
+	}
+
+	rangeHeader := w.req.Header.Get("Range")
+	if rangeHeader == "" || h.Get("Accept-Ranges") != "bytes" || !rangeApplies(w.req, etag, lastModified) {
+		return http.StatusOK
+	}
+	size, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return http.StatusOK
+	}
+	start, end, result := parseByteRange(rangeHeader, size)
+	switch result {
+	case byteRangeIgnored:
+		return http.StatusOK
+	case byteRangeUnsatisfiable:
+		h.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		h.Set("Content-Length", "0")
+		w.discard = true
+		return http.StatusRequestedRangeNotSatisfiable
+	}
+	h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	h.Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.skip = start
+	w.remaining = end - start + 1
+	return http.StatusPartialContent
+}
+
+// notModified reports whether the client's copy is current: one of the ETags
+// of If-None-Match matches etag, or, if If-None-Match is absent, the file has
+// not been modified since If-Modified-Since.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(ims)
+}
+
+// rangeApplies reports whether the Range header of r should be honored: If-Range
+// is absent, or it names the current version of the file by a strong ETag or
+// its exact modification date.
+func rangeApplies(r *http.Request, etag string, lastModified time.Time) bool {
+	ifRange := r.Header.Get("If-Range")
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		return etag != "" && !strings.HasPrefix(etag, "W/") && ifRange == etag
+	}
+	date, err := http.ParseTime(ifRange)
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+	return lastModified.Truncate(time.Second).Equal(date)
+}
+
+// byteRangeResult is the outcome of parsing a Range header.
+type byteRangeResult int
+
+const (
+	// byteRangeOK is a single range within the file.
+	byteRangeOK byteRangeResult = iota
+	// byteRangeIgnored is a malformed header or one that requests several
+	// ranges; the whole file is served.
+	byteRangeIgnored
+	// byteRangeUnsatisfiable is a range that starts beyond the end of the file.
+	byteRangeUnsatisfiable
+)
+
+// parseByteRange parses a Range header requesting a single byte range
+// ("bytes=first-last", "bytes=first-", or "bytes=-suffix") of a file of size
+// bytes, and returns the first and last byte of the range.
+func parseByteRange(header string, size int64) (int64, int64, byteRangeResult) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, byteRangeIgnored
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, byteRangeIgnored
+	}
+
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix < 0 {
+			return 0, 0, byteRangeIgnored
+		}
+		if suffix == 0 || size == 0 {
+			return 0, 0, byteRangeUnsatisfiable
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, size - 1, byteRangeOK
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, byteRangeIgnored
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, byteRangeIgnored
+		}
+	}
+	if start >= size {
+		return 0, 0, byteRangeUnsatisfiable
+	}
+	if end >= size {
+		end = size - 1
+	}
+	return start, end, byteRangeOK
+}
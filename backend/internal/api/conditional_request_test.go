@@ -0,0 +1,157 @@
+package api_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+)
+
+var _ = Describe("ConditionalRequestMiddleware", func() {
+	const (
+		body         = "0123456789abcdefghij"
+		etag         = `"14-18a2b3c4d5e6f708"`
+		lastModified = "Wed, 01 Jan 2025 00:00:00 GMT"
+	)
+
+	var handler http.Handler
+
+	BeforeEach(func() {
+		// Stands in for the image download endpoint: always 200 with
+		// validators, body streamed through io.Copy.
+		handler = api.ConditionalRequestMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", lastModified)
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.Copy(w, strings.NewReader(body))
+		}))
+	})
+
+	serve := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/images/test.png", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	It("serves the whole file without conditional headers", func() {
+		rec := serve(nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal(body))
+		Expect(rec.Header().Get("ETag")).To(Equal(etag))
+	})
+
+	Describe("conditional requests", func() {
+		It("responds 304 without a body when If-None-Match matches", func() {
+			rec := serve(map[string]string{"If-None-Match": `"other", ` + etag})
+			Expect(rec.Code).To(Equal(http.StatusNotModified))
+			Expect(rec.Body.Len()).To(BeZero())
+			Expect(rec.Header().Get("ETag")).To(Equal(etag))
+			Expect(rec.Header().Get("Content-Length")).To(BeEmpty())
+		})
+
+		It("matches a weak If-None-Match", func() {
+			rec := serve(map[string]string{"If-None-Match": "W/" + etag})
+			Expect(rec.Code).To(Equal(http.StatusNotModified))
+		})
+
+		It("serves the file when If-None-Match names another version", func() {
+			rec := serve(map[string]string{"If-None-Match": `"14-0"`})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(Equal(body))
+		})
+
+		It("responds 304 when the file has not changed since If-Modified-Since", func() {
+			rec := serve(map[string]string{"If-Modified-Since": lastModified})
+			Expect(rec.Code).To(Equal(http.StatusNotModified))
+		})
+
+		It("serves the file when it changed after If-Modified-Since", func() {
+			rec := serve(map[string]string{"If-Modified-Since": "Tue, 31 Dec 2024 00:00:00 GMT"})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+		})
+
+		It("ignores If-Modified-Since when If-None-Match is sent", func() {
+			rec := serve(map[string]string{
+				"If-None-Match":     `"14-0"`,
+				"If-Modified-Since": lastModified,
+			})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("range requests", func() {
+		DescribeTable("serves a single range with 206",
+			func(rangeHeader, contentRange, want string) {
+				rec := serve(map[string]string{"Range": rangeHeader})
+				Expect(rec.Code).To(Equal(http.StatusPartialContent))
+				Expect(rec.Header().Get("Content-Range")).To(Equal(contentRange))
+				Expect(rec.Header().Get("Content-Length")).To(Equal(strconv.Itoa(len(want))))
+				Expect(rec.Body.String()).To(Equal(want))
+			},
+			Entry("first and last byte", "bytes=2-5", "bytes 2-5/20", "2345"),
+			Entry("open-ended", "bytes=15-", "bytes 15-19/20", "fghij"),
+			Entry("suffix", "bytes=-3", "bytes 17-19/20", "hij"),
+			Entry("last byte beyond the end", "bytes=18-100", "bytes 18-19/20", "ij"),
+		)
+
+		It("responds 416 to a range beyond the end of the file", func() {
+			rec := serve(map[string]string{"Range": "bytes=20-"})
+			Expect(rec.Code).To(Equal(http.StatusRequestedRangeNotSatisfiable))
+			Expect(rec.Header().Get("Content-Range")).To(Equal("bytes */20"))
+			Expect(rec.Body.Len()).To(BeZero())
+		})
+
+		It("serves the whole file for several ranges", func() {
+			rec := serve(map[string]string{"Range": "bytes=0-1,4-5"})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(Equal(body))
+		})
+
+		It("serves the whole file for a malformed range", func() {
+			rec := serve(map[string]string{"Range": "bytes=5-2"})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(Equal(body))
+		})
+
+		It("honors the range when If-Range matches the ETag", func() {
+			rec := serve(map[string]string{"Range": "bytes=0-1", "If-Range": etag})
+			Expect(rec.Code).To(Equal(http.StatusPartialContent))
+			Expect(rec.Body.String()).To(Equal("01"))
+		})
+
+		It("serves the whole file when If-Range names another version", func() {
+			rec := serve(map[string]string{"Range": "bytes=0-1", "If-Range": `"14-0"`})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(Equal(body))
+		})
+
+		It("honors the range when If-Range matches the Last-Modified date", func() {
+			rec := serve(map[string]string{"Range": "bytes=0-1", "If-Range": lastModified})
+			Expect(rec.Code).To(Equal(http.StatusPartialContent))
+		})
+	})
+
+	It("leaves responses without validators unchanged", func() {
+		handler = api.ConditionalRequestMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}))
+		rec := serve(map[string]string{"If-None-Match": "*", "Range": "bytes=0-1"})
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal(`{"ok":true}`))
+	})
+})
@@ -8,7 +8,8 @@ func CORSMiddleware(allowedOrigin string) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-Id")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Request-Id")
 
 			if r.Method == http.MethodOptions {
 				w.WriteHeader(http.StatusOK)
@@ -40,6 +40,8 @@ var _ = Describe("CORSMiddleware", func() {
 			Expect(recorder.Header().Get("Access-Control-Allow-Methods")).To(ContainSubstring("POST"))
 			Expect(recorder.Header().Get("Access-Control-Allow-Methods")).To(ContainSubstring("PUT"))
 			Expect(recorder.Header().Get("Access-Control-Allow-Methods")).To(ContainSubstring("DELETE"))
+			Expect(recorder.Header().Get("Access-Control-Expose-Headers")).To(ContainSubstring("X-Total-Count"))
+			Expect(recorder.Header().Get("Access-Control-Expose-Headers")).To(ContainSubstring("X-Request-Id"))
 			Expect(recorder.Body.String()).To(Equal("ok"))
 		})
 
@@ -26,7 +26,7 @@ func (s *DemoAPIService) Status(ctx context.Context) (*gendemo.DemoStatusRespons
 
 // Install creates the demo dataset and seeds the demo preset.
 func (s *DemoAPIService) Install(ctx context.Context) (*gendemo.DemoStatusResponse, error) {
-	if err := s.svc.Install(); err != nil {
+	if err := s.svc.Install(ctx); err != nil {
 		return nil, gendemo.MakeInternalError(fmt.Errorf("installing demo: %w", err))
 	}
 	status := s.svc.Status()
@@ -35,7 +35,7 @@ func (s *DemoAPIService) Install(ctx context.Context) (*gendemo.DemoStatusRespon
 
 // Uninstall removes the demo dataset and demo preset.
 func (s *DemoAPIService) Uninstall(ctx context.Context) (*gendemo.DemoStatusResponse, error) {
-	if err := s.svc.Uninstall(); err != nil {
+	if err := s.svc.Uninstall(ctx); err != nil {
 		return nil, gendemo.MakeInternalError(fmt.Errorf("uninstalling demo: %w", err))
 	}
 	status := s.svc.Status()
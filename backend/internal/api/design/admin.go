@@ -0,0 +1,33 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+var _ = Service("admin", func() {
+	Description("Server administration")
+
+	Method("reload_config", func() {
+		Description("Re-read the configuration file and apply changed settings without a restart. checkpoint_dirs, comfyui.url, and comfyui.workflow_dir are applied at runtime; a ComfyUI change takes effect once the sample in flight finishes. Other changed settings are listed as requiring a restart.")
+		Result(ConfigReloadResponse)
+		Error("invalid_config", ErrorResult, "The configuration file could not be read or is invalid; the running configuration is unchanged")
+		Error("service_unavailable", ErrorResult, "Config reload is not available")
+		HTTP(func() {
+			POST("/api/admin/reload-config")
+			Response(StatusOK)
+			Response("invalid_config", StatusUnprocessableEntity)
+			Response("service_unavailable", StatusServiceUnavailable)
+		})
+	})
+})
+
+var ConfigReloadResponse = Type("ConfigReloadResponse", func() {
+	Description("Outcome of a configuration reload")
+	Attribute("applied", ArrayOf(String), "Changed settings that were applied, by config file key", func() {
+		Example([]string{"checkpoint_dirs", "comfyui.url"})
+	})
+	Attribute("restart_required", ArrayOf(String), "Changed settings that take effect only after a restart, by config file key", func() {
+		Example([]string{"sample_dir"})
+	})
+	Required("applied", "restart_required")
+})
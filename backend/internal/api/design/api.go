@@ -12,6 +12,7 @@ var _ = API("checkpoint_sampler", func() {
 	Server("checkpoint_sampler", func() {
 		Host("localhost", func() {
 			URI("http://localhost:8080")
+			URI("grpc://localhost:9090")
 		})
 	})
 
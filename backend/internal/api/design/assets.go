@@ -0,0 +1,243 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+var _ = Service("assets", func() {
+	Description("Managed assets: reference images and wildcards files used by presets and workflows. Files are uploaded in chunks so that large uploads can be resumed, and are stored once per distinct content.")
+
+	Method("list", func() {
+		Description("List uploaded assets, newest first. Empty when asset uploads are not configured.")
+		Payload(func() {
+			Attribute("kind", String, "Only list assets of this kind", func() {
+				Enum("image", "wildcards")
+			})
+		})
+		Result(ArrayOf(AssetResponse))
+		Error("invalid_payload", ErrorResult, "Invalid asset kind")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/assets")
+			Param("kind")
+			Response(StatusOK)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("delete", func() {
+		Description("Delete an asset and its file")
+		Payload(func() {
+			Attribute("id", String, "Asset ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("id")
+		})
+		Error("not_found", ErrorResult, "Asset not found")
+		Error("service_unavailable", ErrorResult, "Asset uploads are not configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/assets/{id}")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("start_upload", func() {
+		Description("Start a chunked upload. The kind, content type, and size are validated before any data is sent.")
+		Payload(StartAssetUploadPayload)
+		Result(AssetUploadResponse)
+		Error("invalid_payload", ErrorResult, "Invalid kind, filename, content type, or size")
+		Error("service_unavailable", ErrorResult, "Asset uploads are not configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/assets/uploads")
+			Response(StatusCreated)
+			Response("invalid_payload", StatusBadRequest)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("show_upload", func() {
+		Description("Get an upload's progress. An interrupted upload is resumed by sending the next chunk at the received offset.")
+		Payload(func() {
+			Attribute("upload_id", String, "Upload ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("upload_id")
+		})
+		Result(AssetUploadResponse)
+		Error("not_found", ErrorResult, "Upload not found")
+		Error("service_unavailable", ErrorResult, "Asset uploads are not configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/assets/uploads/{upload_id}")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("upload_chunk", func() {
+		Description("Append a chunk to an upload. The request body is the raw chunk data. The offset must equal the bytes received so far; chunks may be of any size up to the remaining declared size.")
+		Payload(func() {
+			Attribute("upload_id", String, "Upload ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Attribute("offset", Int64, "Byte offset of the chunk within the file", func() {
+				Minimum(0)
+				Example(0)
+			})
+			Required("upload_id", "offset")
+		})
+		Result(AssetUploadResponse)
+		Error("not_found", ErrorResult, "Upload not found")
+		Error("invalid_payload", ErrorResult, "Chunk exceeds the declared size")
+		Error("conflict", ErrorResult, "Offset does not match the bytes received; query the upload and resume from its received offset")
+		Error("service_unavailable", ErrorResult, "Asset uploads are not configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			PUT("/api/assets/uploads/{upload_id}")
+			Param("offset")
+			SkipRequestBodyEncodeDecode()
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("conflict", StatusConflict)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("complete_upload", func() {
+		Description("Finish an upload whose data has been received in full. The content is hashed and its type checked against the declared content type. Uploading content that already exists returns the existing asset.")
+		Payload(func() {
+			Attribute("upload_id", String, "Upload ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Attribute("sha256", String, "Expected SHA256 of the content (hex); the upload is rejected if it differs", func() {
+				Pattern(`^[0-9a-fA-F]{64}$`)
+				Example("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+			})
+			Required("upload_id")
+		})
+		Result(AssetResponse)
+		Error("not_found", ErrorResult, "Upload not found")
+		Error("invalid_payload", ErrorResult, "Upload incomplete, hash mismatch, or content does not match the declared type")
+		Error("service_unavailable", ErrorResult, "Asset uploads are not configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/assets/uploads/{upload_id}/complete")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("abort_upload", func() {
+		Description("Discard an upload and the data received for it")
+		Payload(func() {
+			Attribute("upload_id", String, "Upload ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("upload_id")
+		})
+		Error("not_found", ErrorResult, "Upload not found")
+		Error("service_unavailable", ErrorResult, "Asset uploads are not configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/assets/uploads/{upload_id}")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+})
+
+var AssetResponse = Type("AssetResponse", func() {
+	Description("An uploaded asset")
+	Attribute("id", String, "Asset ID (UUID)", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("kind", String, "Asset kind", func() {
+		Enum("image", "wildcards")
+		Example("image")
+	})
+	Attribute("filename", String, "Original filename", func() {
+		Example("reference.png")
+	})
+	Attribute("content_type", String, "Content type", func() {
+		Example("image/png")
+	})
+	Attribute("size", Int64, "Size in bytes", func() {
+		Example(1048576)
+	})
+	Attribute("sha256", String, "SHA256 of the content (hex)", func() {
+		Example("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+	})
+	Attribute("path", String, "Path relative to the assets directory", func() {
+		Example("image/9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.png")
+	})
+	Attribute("created_at", String, "Upload completion timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Required("id", "kind", "filename", "content_type", "size", "sha256", "path", "created_at")
+})
+
+var AssetUploadResponse = Type("AssetUploadResponse", func() {
+	Description("A chunked upload in progress")
+	Attribute("id", String, "Upload ID (UUID)", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("kind", String, "Asset kind", func() {
+		Enum("image", "wildcards")
+		Example("image")
+	})
+	Attribute("filename", String, "Original filename", func() {
+		Example("reference.png")
+	})
+	Attribute("content_type", String, "Declared content type", func() {
+		Example("image/png")
+	})
+	Attribute("size", Int64, "Declared size in bytes", func() {
+		Example(1048576)
+	})
+	Attribute("received", Int64, "Bytes received so far; the offset of the next chunk", func() {
+		Example(524288)
+	})
+	Attribute("created_at", String, "Creation timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Attribute("updated_at", String, "Timestamp of the last received chunk (RFC3339)", func() {
+		Example("2025-01-01T00:00:05Z")
+	})
+	Required("id", "kind", "filename", "content_type", "size", "received", "created_at", "updated_at")
+})
+
+var StartAssetUploadPayload = Type("StartAssetUploadPayload", func() {
+	Description("Payload for starting a chunked asset upload")
+	Attribute("kind", String, "Asset kind: image (png, jpeg, webp) or wildcards (UTF-8 text)", func() {
+		Enum("image", "wildcards")
+		Example("image")
+	})
+	Attribute("filename", String, "Original filename, without directories", func() {
+		MinLength(1)
+		Example("reference.png")
+	})
+	Attribute("content_type", String, "Content type of the file", func() {
+		Example("image/png")
+	})
+	Attribute("size", Int64, "Total size in bytes", func() {
+		Minimum(1)
+		Example(1048576)
+	})
+	Required("kind", "filename", "content_type", "size")
+})
@@ -25,6 +25,99 @@ var _ = Service("checkpoints", func() {
 			Response("invalid_filename", StatusBadRequest)
 		})
 	})
+
+	Method("match_preview", func() {
+		Description("Show how checkpoint filenames resolve to ComfyUI model paths under the configured path mapping rules, without creating a job")
+		Payload(func() {
+			Attribute("training_run_name", String, "Training run whose checkpoints are resolved", func() {
+				Example("psai4rt-v0.3.0-no-reg")
+			})
+			Attribute("filenames", ArrayOf(String), "Checkpoint filenames to resolve instead of the training run's checkpoints", func() {
+				Example([]string{"psai4rt-v0.3.0-no-reg-step00004500.safetensors"})
+			})
+			Required("training_run_name")
+		})
+		Result(CheckpointMatchPreviewResult)
+		Error("not_found", ErrorResult, "Training run not found")
+		Error("service_unavailable", ErrorResult, "ComfyUI is not configured or cannot be reached")
+		HTTP(func() {
+			POST("/api/checkpoints/match-preview")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("service_unavailable", StatusServiceUnavailable)
+		})
+	})
+
+	Method("hashes", func() {
+		Description("List the discovered checkpoints with the fast hash computed for each in the background (xxHash64 of the first 16 MiB and the file size), and the checkpoints that appear under more than one training run or filename")
+		Result(CheckpointHashReportResponse)
+		Error("service_unavailable", ErrorResult, "Checkpoint hashing is not enabled")
+		HTTP(func() {
+			GET("/api/checkpoints/hashes")
+			Response(StatusOK)
+			Response("service_unavailable", StatusServiceUnavailable)
+		})
+	})
+})
+
+var CheckpointHashReportResponse = Type("CheckpointHashReportResponse", func() {
+	Description("Hashes of the discovered checkpoints and the duplicates among them")
+	Attribute("checkpoints", ArrayOf(CheckpointHashResponse), "Discovered checkpoints ordered by training run name")
+	Attribute("duplicates", ArrayOf(CheckpointDuplicateResponse), "Groups of checkpoints with the same hash under more than one training run or filename, ordered by hash")
+	Attribute("pending_count", Int, "Number of checkpoints not hashed yet or changed since they were hashed", func() {
+		Example(0)
+	})
+	Required("checkpoints", "duplicates", "pending_count")
+})
+
+var CheckpointHashResponse = Type("CheckpointHashResponse", func() {
+	Attribute("training_run", String, "Training run name", func() {
+		Example("psai4rt-v0.3.0-no-reg")
+	})
+	Attribute("filename", String, "Checkpoint filename", func() {
+		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Attribute("relative_path", String, "Path relative to the checkpoint directory", func() {
+		Example("psai4rt-v0.3.0-no-reg/psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Attribute("checkpoint_dir_index", Int, "Index of the checkpoint directory the file was found in", func() {
+		Example(0)
+	})
+	Attribute("size", Int64, "File size in bytes", func() {
+		Example(228454400)
+	})
+	Attribute("hash", String, "Hex-encoded hash; absent until the checkpoint has been hashed", func() {
+		Example("9f2c4e1d7a3b5c60")
+	})
+	Required("training_run", "filename", "relative_path", "checkpoint_dir_index", "size")
+})
+
+var CheckpointDuplicateResponse = Type("CheckpointDuplicateResponse", func() {
+	Attribute("hash", String, "Hash shared by the checkpoints", func() {
+		Example("9f2c4e1d7a3b5c60")
+	})
+	Attribute("checkpoints", ArrayOf(CheckpointHashResponse), "Checkpoints with the hash")
+	Required("hash", "checkpoints")
+})
+
+var CheckpointMatchPreviewResult = Type("CheckpointMatchPreviewResult", func() {
+	Description("How each checkpoint filename resolves to a ComfyUI model path")
+	Attribute("matches", ArrayOf(CheckpointMatchResponse), "One entry per checkpoint filename, in request order")
+	Required("matches")
+})
+
+var CheckpointMatchResponse = Type("CheckpointMatchResponse", func() {
+	Attribute("filename", String, "Checkpoint filename", func() {
+		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Attribute("mapped_path", String, "Filename after the path mapping rules, which ComfyUI model paths are matched against", func() {
+		Example("psai4rt-v0.3.0-no-reg/psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Attribute("comfyui_path", String, "Matched ComfyUI model path; absent when no model matches", func() {
+		Example("loras/psai4rt-v0.3.0-no-reg/psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Attribute("matched", Boolean, "Whether a ComfyUI model matches the checkpoint")
+	Required("filename", "mapped_path", "matched")
 })
 
 var CheckpointMetadataResponse = Type("CheckpointMetadataResponse", func() {
@@ -31,6 +31,38 @@ var _ = Service("comfyui", func() {
 			Response(StatusOK)
 		})
 	})
+
+	Method("refresh_models", func() {
+		Description("Clear the cached model lists, so that models added to ComfyUI since they were read are listed and matched")
+		Error("service_unavailable", ErrorResult, "ComfyUI is not configured")
+		HTTP(func() {
+			POST("/api/comfyui/models/refresh")
+			Response(StatusNoContent)
+			Response("service_unavailable", StatusServiceUnavailable)
+		})
+	})
+
+	Method("queue", func() {
+		Description("Get ComfyUI's running and pending prompts, with the sample job and item behind each prompt the sampler submitted")
+		Result(ComfyUIQueueResult)
+		Error("service_unavailable", ErrorResult, "ComfyUI is not configured or cannot be reached")
+		HTTP(func() {
+			GET("/api/comfyui/queue")
+			Response(StatusOK)
+			Response("service_unavailable", StatusServiceUnavailable)
+		})
+	})
+
+	Method("system_stats", func() {
+		Description("Get ComfyUI's GPU memory and queue length, as last read by the server. The readings are refreshed every few seconds.")
+		Result(ComfyUISystemStatsResult)
+		Error("service_unavailable", ErrorResult, "ComfyUI is not configured")
+		HTTP(func() {
+			GET("/api/comfyui/system-stats")
+			Response(StatusOK)
+			Response("service_unavailable", StatusServiceUnavailable)
+		})
+	})
 })
 
 var ComfyUIStatusResult = Type("ComfyUIStatusResult", func() {
@@ -49,3 +81,50 @@ var ComfyUIModelsResult = Type("ComfyUIModelsResult", func() {
 	})
 	Required("models")
 })
+
+var ComfyUIQueueResult = Type("ComfyUIQueueResult", func() {
+	Attribute("running", ArrayOf(ComfyUIQueueEntryResponse), "Prompts ComfyUI is executing")
+	Attribute("pending", ArrayOf(ComfyUIQueueEntryResponse), "Prompts waiting in ComfyUI's queue, in queue order")
+	Required("running", "pending")
+})
+
+var ComfyUIQueueEntryResponse = Type("ComfyUIQueueEntryResponse", func() {
+	Attribute("number", Int, "ComfyUI queue number", func() {
+		Example(42)
+	})
+	Attribute("prompt_id", String, "ComfyUI prompt ID", func() {
+		Example("8f7e6d5c-4b3a-2918-0706-f5e4d3c2b1a0")
+	})
+	Attribute("job_id", String, "Sample job the prompt was submitted for; absent for prompts the sampler does not recognize")
+	Attribute("item_id", String, "Sample job item the prompt was submitted for; absent for prompts the sampler does not recognize")
+	Required("number", "prompt_id")
+})
+
+var ComfyUISystemStatsResult = Type("ComfyUISystemStatsResult", func() {
+	Attribute("devices", ArrayOf(ComfyUIDeviceResponse), "Compute devices ComfyUI runs on")
+	Attribute("queue_running", Int, "Number of prompts ComfyUI is executing", func() {
+		Example(1)
+	})
+	Attribute("queue_pending", Int, "Number of prompts waiting in ComfyUI's queue", func() {
+		Example(3)
+	})
+	Attribute("updated_at", String, "When the readings were taken (RFC3339); absent before the first successful reading")
+	Attribute("error", String, "Why the latest reading failed; absent when it succeeded. The readings are then those of the last successful reading.")
+	Required("devices", "queue_running", "queue_pending")
+})
+
+var ComfyUIDeviceResponse = Type("ComfyUIDeviceResponse", func() {
+	Attribute("name", String, "Device name as reported by ComfyUI", func() {
+		Example("cuda:0 NVIDIA GeForce RTX 4090 : cudaMallocAsync")
+	})
+	Attribute("type", String, "Device type, e.g. cuda, mps, or cpu", func() {
+		Example("cuda")
+	})
+	Attribute("vram_total", Int64, "Total device memory in bytes", func() {
+		Example(25757220864)
+	})
+	Attribute("vram_free", Int64, "Free device memory in bytes", func() {
+		Example(21474836480)
+	})
+	Required("name", "type", "vram_total", "vram_free")
+})
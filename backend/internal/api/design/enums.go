@@ -0,0 +1,44 @@
+package design
+
+// Enumerated values shared by several types. They are listed once here so
+// that the REST responses and the WebSocket payloads describing the same
+// field cannot drift apart in the generated OpenAPI spec.
+
+// jobStatuses are the statuses of a sample job (model.SampleJobStatus).
+var jobStatuses = []any{"pending", "running", "stopped", "completed", "completed_with_errors", "failed"}
+
+// jobItemStatuses are the statuses of a sample job item (model.SampleJobItemStatus).
+var jobItemStatuses = []any{"pending", "running", "completed", "failed", "skipped", "interrupted"}
+
+// jobItemSkipReasons are the reasons a sample job item is skipped
+// (model.SampleJobItemSkipReason).
+var jobItemSkipReasons = []any{"checkpoint_not_found", "budget_exhausted", "user_skipped", "filtered", "duplicate"}
+
+// seedModes are the seed modes of a study (model.SeedMode).
+var seedModes = []any{"explicit", "random_per_item", "random_per_checkpoint"}
+
+// importConflictPolicies are the ways importing a preset or study resolves a
+// name conflict (model.ImportConflictPolicy).
+var importConflictPolicies = []any{"rename", "overwrite", "skip"}
+
+// importActions are what importing a preset or study did (model.ImportAction).
+var importActions = []any{"created", "renamed", "overwritten", "skipped"}
+
+// outputFormats are the image formats a sample job can save (model.OutputFormat).
+var outputFormats = []any{"png", "webp", "jpeg"}
+
+// wsEventTypes are the event types pushed over the WebSocket
+// (model.EventType), plus "connected", which is sent once when a client
+// subscribes.
+var wsEventTypes = []any{"connected", "image_added", "image_removed", "directory_added", "checkpoint_added", "checkpoint_removed", "job_progress", "inference_progress"}
+
+// wsV2MessageTypes are the message types of WebSocket protocol version 2:
+// the event types plus "subscribed", which acknowledges a subscription
+// change.
+var wsV2MessageTypes = append(append([]any{}, wsEventTypes...), "subscribed")
+
+// csRolesJSON lists the known cs_role values a workflow node can be tagged
+// with (model.KnownCSRoles), as the JSON value of the x-known-keys OpenAPI
+// extension on workflow role maps. Workflows may use other roles, so the map
+// keys cannot be an enum; unknown roles are reported as validation warnings.
+const csRolesJSON = `["save_image", "unet_loader", "clip_loader", "vae_loader", "sampler", "positive_prompt", "negative_prompt", "shift", "latent_image", "load_image", "denoise", "controlnet_loader", "controlnet_apply"]`
@@ -0,0 +1,183 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+var _ = Service("galleries", func() {
+	Description("Published read-only galleries of finished training runs. The public routes expose only the selected images and their dimension values, never job, study, or filesystem details.")
+
+	Method("publish", func() {
+		Description("Publish a snapshot of a training run's images as a public gallery. Images are selected by dimension filters; the gallery does not change when the training run does.")
+		Payload(func() {
+			Attribute("id", Int, "Training run index (zero-based)", func() {
+				Minimum(0)
+			})
+			Attribute("study_name", String, "Study name to scope the scan to a study subdirectory. Defaults to the training run's study.", func() {
+				Example("my-study")
+			})
+			Attribute("title", String, "Gallery title. Defaults to the training run name.", func() {
+				Example("My LoRA v2 — checkpoint comparison")
+			})
+			Attribute("filters", MapOf(String, ArrayOf(String)), "Only publish images whose value for each listed dimension is one of the given values", func() {
+				Example(map[string][]string{"seed": {"420"}})
+			})
+			Attribute("dimensions", ArrayOf(String), "Dimensions shown to visitors. Defaults to all dimensions that vary across the published images.", func() {
+				Example([]string{"checkpoint", "prompt_name"})
+			})
+			Required("id")
+		})
+		Result(GalleryResponse)
+		Error("not_found", ErrorResult, "Training run not found")
+		Error("bad_request", ErrorResult, "Unknown dimension or no images match the filters")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/training-runs/{id}/publish")
+			Response(StatusCreated)
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("list", func() {
+		Description("List published galleries, newest first")
+		Result(ArrayOf(GalleryResponse))
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/galleries")
+			Response(StatusOK)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("unpublish", func() {
+		Description("Remove a published gallery. Its public URLs stop working, although caches may serve them until they expire.")
+		Payload(func() {
+			Attribute("id", String, "Gallery ID")
+			Required("id")
+		})
+		Error("not_found", ErrorResult, "Gallery not found")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/galleries/{id}")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("show_public", func() {
+		Description("Public, read-only view of a published gallery")
+		Payload(func() {
+			Attribute("slug", String, "Gallery slug", func() {
+				Example("q3Zk0bW1nS8vXl2fJc9tHA")
+			})
+			Required("slug")
+		})
+		Result(PublicGalleryResult)
+		Error("not_found", ErrorResult, "Gallery not found")
+		HTTP(func() {
+			GET("/api/public/galleries/{slug}")
+			Response(StatusOK, func() {
+				Header("cache_control:Cache-Control")
+				Body(func() {
+					Attribute("title")
+					Attribute("training_run_name")
+					Attribute("published_at")
+					Attribute("dimensions")
+					Attribute("images")
+					Required("title", "training_run_name", "published_at", "dimensions", "images")
+				})
+			})
+			Response("not_found", StatusNotFound)
+		})
+	})
+
+	Method("public_image", func() {
+		Description("Download an image of a published gallery by its index")
+		Payload(func() {
+			Attribute("slug", String, "Gallery slug", func() {
+				Example("q3Zk0bW1nS8vXl2fJc9tHA")
+			})
+			Attribute("index", Int, "Image index within the gallery", func() {
+				Minimum(0)
+				Example(0)
+			})
+			Required("slug", "index")
+		})
+		Result(ImageDownloadResult)
+		Error("not_found", ErrorResult, "Gallery or image not found")
+		HTTP(func() {
+			GET("/api/public/galleries/{slug}/images/{index}")
+			SkipResponseBodyEncodeDecode()
+			Response(StatusOK, func() {
+				Header("content_type:Content-Type")
+				Header("content_length:Content-Length")
+				Header("cache_control:Cache-Control")
+			})
+			Response("not_found", StatusNotFound)
+		})
+	})
+})
+
+var GalleryResponse = Type("GalleryResponse", func() {
+	Description("A published gallery as seen by the tool")
+	Attribute("id", String, "Gallery ID", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("slug", String, "Unguessable slug used in the public URLs", func() {
+		Example("q3Zk0bW1nS8vXl2fJc9tHA")
+	})
+	Attribute("public_url", String, "Path of the public gallery view", func() {
+		Example("/api/public/galleries/q3Zk0bW1nS8vXl2fJc9tHA")
+	})
+	Attribute("title", String, "Gallery title", func() {
+		Example("My LoRA v2 — checkpoint comparison")
+	})
+	Attribute("training_run_name", String, "Name of the published training run", func() {
+		Example("my-lora")
+	})
+	Attribute("study_name", String, "Study the images were taken from", func() {
+		Example("my-study")
+	})
+	Attribute("filters", MapOf(String, ArrayOf(String)), "Dimension filters the images were selected by")
+	Attribute("dimensions", ArrayOf(DimensionResponse), "Dimensions shown to visitors")
+	Attribute("image_count", Int, "Number of published images", func() {
+		Example(24)
+	})
+	Attribute("created_at", String, "Publish timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Required("id", "slug", "public_url", "title", "training_run_name", "study_name", "filters", "dimensions", "image_count", "created_at")
+})
+
+var PublicGalleryResult = Type("PublicGalleryResult", func() {
+	Description("Public view of a published gallery")
+	Attribute("title", String, "Gallery title", func() {
+		Example("My LoRA v2 — checkpoint comparison")
+	})
+	Attribute("training_run_name", String, "Name of the published training run", func() {
+		Example("my-lora")
+	})
+	Attribute("published_at", String, "Publish timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Attribute("dimensions", ArrayOf(DimensionResponse), "Dimensions with the values present among the images")
+	Attribute("images", ArrayOf(PublicGalleryImageResponse), "Published images")
+	Attribute("cache_control", String, "Cache-Control header value", func() {
+		Example("public, max-age=300")
+	})
+	Required("title", "training_run_name", "published_at", "dimensions", "images", "cache_control")
+})
+
+var PublicGalleryImageResponse = Type("PublicGalleryImageResponse", func() {
+	Description("An image in a public gallery")
+	Attribute("url", String, "Image URL", func() {
+		Example("/api/public/galleries/q3Zk0bW1nS8vXl2fJc9tHA/images/0")
+	})
+	Attribute("dimensions", MapOf(String, String), "The image's values for the gallery dimensions", func() {
+		Example(map[string]string{"checkpoint": "my-lora-step00001000.safetensors"})
+	})
+	Required("url", "dimensions")
+})
@@ -15,6 +15,56 @@ var _ = Service("health", func() {
 			Response(StatusOK)
 		})
 	})
+
+	Method("live", func() {
+		Description("Liveness probe for container orchestration. Succeeds as long as the process is serving HTTP, including while it is still starting up.")
+		Result(HealthResult)
+		HTTP(func() {
+			GET("/healthz")
+			Response(StatusOK)
+		})
+	})
+
+	Method("ready", func() {
+		Description("Readiness probe for container orchestration. Responds 200 once database migrations are applied, the OpenAPI spec is loaded, the filesystem watcher is started and the API is mounted, and 503 with the pending startup steps until then.")
+		Result(ReadinessResult)
+		HTTP(func() {
+			GET("/readyz")
+			Response(StatusServiceUnavailable, func() {
+				Tag("status", "not_ready")
+			})
+			Response(StatusOK)
+		})
+	})
+
+	Method("executor", func() {
+		Description("Report which backend process holds the job executor lease. In single-process mode this process is always the executor.")
+		Result(ExecutorStatusResult)
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/health/executor")
+			Response(StatusOK)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("watcher", func() {
+		Description("Report the filesystem watcher's event counters since startup. A growing overflows count means the OS event queue is too small for the generation rate; each overflow triggers a rescan of the watched directories.")
+		Result(WatcherStatsResult)
+		HTTP(func() {
+			GET("/health/watcher")
+			Response(StatusOK)
+		})
+	})
+
+	Method("db", func() {
+		Description("Report database query counts and durations since startup, per statement and in total, and the state of the connection pool. Statements are sorted by total duration, slowest first.")
+		Result(DBStatsResult)
+		HTTP(func() {
+			GET("/health/db")
+			Response(StatusOK)
+		})
+	})
 })
 
 var HealthResult = Type("HealthResult", func() {
@@ -23,3 +73,138 @@ var HealthResult = Type("HealthResult", func() {
 	})
 	Required("status")
 })
+
+var ReadinessResult = Type("ReadinessResult", func() {
+	Description("Readiness of this backend process to serve requests")
+	Attribute("status", String, "Whether every startup step has completed", func() {
+		Enum("ready", "not_ready")
+		Example("ready")
+	})
+	Attribute("checks", ArrayOf(ReadinessCheckResponse), "Startup steps in the order they run")
+	Required("status", "checks")
+})
+
+var ReadinessCheckResponse = Type("ReadinessCheckResponse", func() {
+	Description("A startup step the process waits for before it is ready")
+	Attribute("name", String, "Step name", func() {
+		Enum("migrations", "openapi_spec", "watcher", "api")
+		Example("migrations")
+	})
+	Attribute("ready", Boolean, "Whether the step has completed", func() {
+		Example(true)
+	})
+	Required("name", "ready")
+})
+
+var ExecutorStatusResult = Type("ExecutorStatusResult", func() {
+	Description("Executor election status for this backend process")
+	Attribute("mode", String, "Process mode", func() {
+		Enum("single", "multi_process")
+		Example("multi_process")
+	})
+	Attribute("instance_id", String, "Identifier of this process in multi-process mode", func() {
+		Example("worker-1")
+	})
+	Attribute("role", String, "Configured role of this process in multi-process mode", func() {
+		Enum("all", "api", "executor")
+		Example("executor")
+	})
+	Attribute("is_executor", Boolean, "Whether this process is running the job executor", func() {
+		Example(true)
+	})
+	Attribute("lease", ExecutorLeaseResponse, "Current executor lease, absent if no process has taken it")
+	Required("mode", "is_executor")
+})
+
+var ExecutorLeaseResponse = Type("ExecutorLeaseResponse", func() {
+	Description("Advisory lease electing the single active job executor")
+	Attribute("holder", String, "Instance ID of the process holding the lease", func() {
+		Example("worker-1")
+	})
+	Attribute("acquired_at", String, "When the holder took the lease (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Attribute("renewed_at", String, "When the holder last renewed the lease (RFC3339)", func() {
+		Example("2025-01-01T00:05:00Z")
+	})
+	Attribute("expires_at", String, "When the lease lapses unless renewed (RFC3339)", func() {
+		Example("2025-01-01T00:05:30Z")
+	})
+	Attribute("expired", Boolean, "Whether the lease has lapsed and may be taken over", func() {
+		Example(false)
+	})
+	Required("holder", "acquired_at", "renewed_at", "expires_at", "expired")
+})
+
+var WatcherStatsResult = Type("WatcherStatsResult", func() {
+	Description("Filesystem watcher event counters since startup")
+	Attribute("events_received", Int64, "Events read from the OS notifier", func() {
+		Example(15230)
+	})
+	Attribute("events_coalesced", Int64, "Received events dropped because they repeated the preceding event", func() {
+		Example(4100)
+	})
+	Attribute("overflows", Int64, "Times the OS event queue overflowed and events were lost", func() {
+		Example(1)
+	})
+	Attribute("rescans_triggered", Int64, "Rescans of the watched directories run to recover from overflows", func() {
+		Example(1)
+	})
+	Required("events_received", "events_coalesced", "overflows", "rescans_triggered")
+})
+
+var DBStatsResult = Type("DBStatsResult", func() {
+	Description("Database activity since startup")
+	Attribute("queries", Int64, "Statements executed", func() {
+		Example(48211)
+	})
+	Attribute("errors", Int64, "Statements that failed", func() {
+		Example(0)
+	})
+	Attribute("total_duration_ms", Float64, "Time spent executing statements in milliseconds", func() {
+		Example(10234.5)
+	})
+	Attribute("slow_queries", Int64, "Statements that took at least slow_query_threshold_ms", func() {
+		Example(3)
+	})
+	Attribute("slow_query_threshold_ms", Float64, "Duration from which a statement is logged as slow, in milliseconds", func() {
+		Example(100)
+	})
+	Attribute("open_connections", Int, "Open database connections", func() {
+		Example(2)
+	})
+	Attribute("in_use", Int, "Connections currently in use", func() {
+		Example(1)
+	})
+	Attribute("idle", Int, "Idle connections", func() {
+		Example(1)
+	})
+	Attribute("wait_count", Int64, "Times a caller waited for a free connection", func() {
+		Example(0)
+	})
+	Attribute("wait_duration_ms", Float64, "Time spent waiting for a free connection in milliseconds", func() {
+		Example(0)
+	})
+	Attribute("statements", ArrayOf(DBStatementStatsResponse), "Per-statement totals, slowest total first")
+	Required("queries", "errors", "total_duration_ms", "slow_queries", "slow_query_threshold_ms", "open_connections", "in_use", "idle", "wait_count", "wait_duration_ms", "statements")
+})
+
+var DBStatementStatsResponse = Type("DBStatementStatsResponse", func() {
+	Description("Totals of one SQL statement")
+	Attribute("query", String, "The statement's SQL with whitespace collapsed", func() {
+		Example("UPDATE sample_job_items SET status = ?, updated_at = ? WHERE id = ?")
+	})
+	Attribute("count", Int64, "Times the statement was executed", func() {
+		Example(12000)
+	})
+	Attribute("errors", Int64, "Times the statement failed", func() {
+		Example(0)
+	})
+	Attribute("total_duration_ms", Float64, "Total execution time in milliseconds", func() {
+		Example(5210.25)
+	})
+	Attribute("max_duration_ms", Float64, "Longest execution in milliseconds", func() {
+		Example(132.4)
+	})
+	Required("query", "count", "errors", "total_duration_ms", "max_duration_ms")
+})
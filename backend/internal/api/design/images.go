@@ -8,22 +8,30 @@ var _ = Service("images", func() {
 	Description("Image serving and metadata service")
 
 	Method("download", func() {
-		Description("Download an image file from the sample directory")
+		Description("Download an image file from the sample directory. Responses carry an ETag and Last-Modified date derived from the file, and must be revalidated before reuse so that regenerated images are fetched again: a request whose If-None-Match or If-Modified-Since header still matches is answered with 304 and no body. A single byte range requested with a Range header (and If-Range) is served with 206; a range starting beyond the end of the file is answered with 416.")
 		Payload(func() {
 			Attribute("filepath", String, "Relative path to the image file", func() {
 				Example("checkpoint.safetensors/image.png")
 			})
+			Attribute("size", String, "Image size: 'full' serves the original file, 'thumb' a cached JPEG thumbnail generated on first request", func() {
+				Enum("full", "thumb")
+				Default("full")
+			})
 			Required("filepath")
 		})
-		Result(ImageDownloadResult)
+		Result(ImageFileResult)
 		Error("not_found", ErrorResult, "Image file not found")
 		Error("bad_request", ErrorResult, "Invalid file path (traversal rejected)")
 		HTTP(func() {
 			GET("/api/images/{*filepath}")
+			Param("size")
 			SkipResponseBodyEncodeDecode()
 			Response(StatusOK, func() {
 				Header("content_type:Content-Type")
 				Header("content_length:Content-Length")
+				Header("accept_ranges:Accept-Ranges")
+				Header("etag:ETag")
+				Header("last_modified:Last-Modified")
 				Header("cache_control:Cache-Control")
 			})
 			Response("not_found", StatusNotFound)
@@ -31,6 +39,35 @@ var _ = Service("images", func() {
 		})
 	})
 
+	Method("checkpoint_archive", func() {
+		Description("Download the sample images of one checkpoint directory, with their sidecars, as a ZIP archive. The archive is streamed as it is written. Thumbnails are not included.")
+		Payload(func() {
+			Attribute("filename", String, "Checkpoint filename, which names its sample directory", func() {
+				Example("model-step00001000.safetensors")
+			})
+			Attribute("dir", String, "Directory holding the checkpoint directory, relative to the sample directory: the training run's study_output_dir ({training_run}/{study}); empty for legacy checkpoint directories at the root of the sample directory", func() {
+				Example("my-model/550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("filename")
+		})
+		Result(ArchiveDownloadResult)
+		Error("not_found", ErrorResult, "Checkpoint directory not found or without sample images")
+		Error("bad_request", ErrorResult, "Invalid checkpoint filename or directory")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/images/checkpoint/{filename}/archive")
+			Param("dir")
+			SkipResponseBodyEncodeDecode()
+			Response(StatusOK, func() {
+				Header("content_type:Content-Type")
+				Header("content_disposition:Content-Disposition")
+			})
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
 	Method("metadata", func() {
 		Description("Get PNG tEXt chunk metadata from an image file")
 		Payload(func() {
@@ -42,6 +79,7 @@ var _ = Service("images", func() {
 		Result(ImageMetadataResponse)
 		Error("not_found", ErrorResult, "Image file not found")
 		Error("bad_request", ErrorResult, "Invalid file path (traversal rejected)")
+		Error("internal_error", ErrorResult, "Internal server error")
 		HTTP(func() {
 			// Note: The actual HTTP path will be /api/images/{filepath}/metadata
 			// but we register it under a different pattern due to chi router limitations
@@ -50,8 +88,462 @@ var _ = Service("images", func() {
 			Response(StatusOK)
 			Response("not_found", StatusNotFound)
 			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("compare", func() {
+		Description("Prepare two images for the A/B comparison slider. Returns normalized metadata and aligned thumbnails, and refuses pairs whose generation parameters differ in anything other than the checkpoint.")
+		Payload(func() {
+			Attribute("a", String, "Relative path of the first image", func() {
+				Example("my-run/my-study/model-step00001000.safetensors/index=0&prompt_name=forest&seed=420&cfg=7&_00001_.png")
+			})
+			Attribute("b", String, "Relative path of the second image", func() {
+				Example("my-run/my-study/model-step00002000.safetensors/index=0&prompt_name=forest&seed=420&cfg=7&_00001_.png")
+			})
+			Required("a", "b")
+		})
+		Result(ImageComparisonResponse)
+		Error("not_found", ErrorResult, "Image file not found")
+		Error("bad_request", ErrorResult, "Invalid file path (traversal rejected)")
+		Error("not_comparable", ErrorResult, "Images differ in more than the checkpoint")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/image-comparison")
+			Param("a")
+			Param("b")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("not_comparable", StatusUnprocessableEntity)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("backfill_sidecars", func() {
+		Description("Admin: write JSON sidecar files for images generated before sidecars existed. Metadata is reconstructed from the query-encoded filename and checkpoint directory; existing sidecars are never overwritten.")
+		Result(SidecarBackfillResponse)
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/admin/sidecar-backfill")
+			Response(StatusOK)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("list_pins", func() {
+		Description("List all pinned images and checkpoint sample sets")
+		Result(ArrayOf(PinResponse))
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/image-pins")
+			Response(StatusOK)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("pin", func() {
+		Description("Pin an image or a whole checkpoint sample directory so that cleanup operations never remove it. Pinning an already-pinned path is a no-op.")
+		Payload(PinPayload)
+		Result(PinResponse)
+		Error("bad_request", ErrorResult, "Invalid path or kind")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/image-pins")
+			Response(StatusCreated)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("unpin", func() {
+		Description("Remove a pin")
+		Payload(func() {
+			Attribute("path", String, "Pinned path relative to the sample directory", func() {
+				Example("my-run/my-study/checkpoint.safetensors")
+			})
+			Required("path")
+		})
+		Error("not_found", ErrorResult, "Path is not pinned")
+		Error("bad_request", ErrorResult, "Invalid path")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/image-pins")
+			Param("path")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("list_annotations", func() {
+		Description("List image annotations (star ratings and tags), optionally restricted to a directory, a minimum rating, or a tag")
+		Payload(func() {
+			Attribute("prefix", String, "Only list images under this directory relative to the sample directory (e.g. a training run or checkpoint sample directory)", func() {
+				Example("my-run/my-study")
+			})
+			Attribute("min_rating", Int, "Only list images rated at least this many stars", func() {
+				Minimum(0)
+				Maximum(5)
+				Default(0)
+			})
+			Attribute("tag", String, "Only list images carrying this tag", func() {
+				Example("keeper")
+			})
+		})
+		Result(ArrayOf(ImageAnnotationResponse))
+		Error("bad_request", ErrorResult, "Invalid prefix or minimum rating")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/image-annotations")
+			Param("prefix")
+			Param("min_rating")
+			Param("tag")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("annotate", func() {
+		Description("Set the star rating and tags of an image, replacing its previous annotation. The image is identified by its path or by the ID of the sample job item that generated it. A rating of 0 with no tags clears the annotation.")
+		Payload(AnnotateImagePayload)
+		Result(ImageAnnotationResponse)
+		Error("not_found", ErrorResult, "Sample job item not found")
+		Error("bad_request", ErrorResult, "Invalid image reference, rating, or tag")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			PUT("/api/image-annotations")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("delete_annotation", func() {
+		Description("Remove the rating and tags of an image")
+		Payload(func() {
+			Attribute("path", String, "Image path relative to the sample directory", func() {
+				Example("my-run/my-study/checkpoint.safetensors/index=0&prompt_name=forest&seed=420&cfg=1&_00001_.png")
+			})
+			Attribute("item_id", String, "ID of the sample job item that generated the image, instead of path", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+		})
+		Error("not_found", ErrorResult, "Image is not annotated or sample job item not found")
+		Error("bad_request", ErrorResult, "Invalid image reference")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/image-annotations")
+			Param("path")
+			Param("item_id")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("bulk_annotate", func() {
+		Description("Apply one rating and tag change to many images in a single transaction. Images are identified by path, by sample job item ID, or both. Tags are added to and removed from each image's existing tags; the rating, if given, replaces each image's rating.")
+		Payload(BulkAnnotateImagesPayload)
+		Result(ArrayOf(ImageAnnotationResponse))
+		Error("not_found", ErrorResult, "Sample job item not found")
+		Error("bad_request", ErrorResult, "Invalid image reference, rating, or tag, or no change given")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/image-annotations/bulk")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("grid", func() {
+		Description("Render a labeled comparison grid of a training run's sample images as a single PNG. One dimension is laid out on each axis (e.g. checkpoints on Y, CFG on X) and the remaining dimensions are pinned with filters.")
+		Payload(GridPayload)
+		Result(ImageDownloadResult)
+		Error("not_found", ErrorResult, "Training run not found")
+		Error("bad_request", ErrorResult, "Invalid axis mapping, filter, or cell size")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/image-grid")
+			SkipResponseBodyEncodeDecode()
+			Response(StatusOK, func() {
+				Header("content_type:Content-Type")
+				Header("content_length:Content-Length")
+				Header("cache_control:Cache-Control")
+			})
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("timeline", func() {
+		Description("Render an animated GIF of a training run's sample images across its checkpoints, one frame per checkpoint labeled with its step, for a fixed set of generation parameters. Rendered clips are cached on disk.")
+		Payload(TimelinePayload)
+		Result(ImageDownloadResult)
+		Error("not_found", ErrorResult, "Training run not found, or no images match the filters")
+		Error("bad_request", ErrorResult, "Invalid filter, frame size, or frame delay")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/image-timeline")
+			SkipResponseBodyEncodeDecode()
+			Response(StatusOK, func() {
+				Header("content_type:Content-Type")
+				Header("content_length:Content-Length")
+				Header("cache_control:Cache-Control")
+			})
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
 		})
 	})
+
+	Method("delete_samples", func() {
+		Description("Delete the sample images of a training run that match a filter, together with their sidecars and thumbnails. Every given filter field must match. Pinned images are skipped. Each deleted image is broadcast as an image_removed WebSocket event.")
+		Payload(func() {
+			Attribute("training_run", String, "Training run name", func() {
+				Example("my-model")
+			})
+			Attribute("checkpoint", ArrayOf(String), "Only delete images of these checkpoint filenames", func() {
+				Example([]string{"my-model-step00001000.safetensors"})
+			})
+			Attribute("prompt_name", String, "Only delete images generated with this prompt name", func() {
+				Example("forest")
+			})
+			Attribute("seed", Int64, "Only delete images generated with this seed", func() {
+				Example(420)
+			})
+			Attribute("before", String, "Only delete images last modified before this time (RFC3339)", func() {
+				Example("2025-06-01T00:00:00Z")
+			})
+			Attribute("dry_run", Boolean, "When true, only report what would be deleted", func() {
+				Default(false)
+			})
+			Required("training_run")
+		})
+		Result(SampleDeleteResponse)
+		Error("bad_request", ErrorResult, "Invalid training run or before time")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/images")
+			Param("training_run")
+			Param("checkpoint")
+			Param("prompt_name")
+			Param("seed")
+			Param("before")
+			Param("dry_run")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+})
+
+var SampleDeleteResponse = Type("SampleDeleteResponse", func() {
+	Description("Outcome of a bulk sample deletion")
+	Attribute("dry_run", Boolean, "Whether this was a dry run in which nothing was deleted")
+	Attribute("deleted", ArrayOf(String), "Deleted image paths relative to the sample directory, or the images that would be deleted in a dry run", func() {
+		Example([]string{"my-model/forest/my-model-step00001000.safetensors/index=0&prompt_name=forest&seed=420&cfg=1&_00001_.png"})
+	})
+	Attribute("skipped_pinned", Int, "Matching images left in place because they are pinned")
+	Attribute("errors", ArrayOf(String), "Images that could not be deleted, with the reason")
+	Required("dry_run", "deleted", "skipped_pinned", "errors")
+})
+
+var GridPayload = Type("GridPayload", func() {
+	Description("Training run, axis mapping, and fixed dimension values for a comparison grid")
+	Attribute("training_run_id", Int, "Training run index, as returned by the training runs list", func() {
+		Example(0)
+	})
+	Attribute("study_name", String, "Study output directory to scan. Auto-derived from the training run name when omitted.", func() {
+		Example("my-study")
+	})
+	Attribute("x_axis", String, "Dimension laid out across the columns", func() {
+		Example("cfg")
+	})
+	Attribute("y_axis", String, "Dimension laid out down the rows", func() {
+		Example("checkpoint")
+	})
+	Attribute("x_values", ArrayOf(String), "Column values to include, in order. Defaults to all values of x_axis.", func() {
+		Example([]string{"3", "7"})
+	})
+	Attribute("y_values", ArrayOf(String), "Row values to include, in order. Defaults to all values of y_axis.", func() {
+		Example([]string{"1000", "2000"})
+	})
+	Attribute("filters", MapOf(String, String), "Fixed values for the remaining dimensions; only matching images are placed", func() {
+		Example(map[string]string{"prompt_name": "forest", "seed": "420"})
+	})
+	Attribute("cell_size", Int, "Maximum width and height in pixels of each image cell", func() {
+		Minimum(32)
+		Maximum(1024)
+		Default(256)
+	})
+	Required("training_run_id", "x_axis", "y_axis")
+})
+
+var TimelinePayload = Type("TimelinePayload", func() {
+	Description("Training run and fixed dimension values for a checkpoint timeline")
+	Attribute("training_run_id", Int, "Training run index, as returned by the training runs list", func() {
+		Example(0)
+	})
+	Attribute("study_name", String, "Study output directory to scan. Auto-derived from the training run name when omitted.", func() {
+		Example("my-study")
+	})
+	Attribute("filters", MapOf(String, String), "Fixed values for the dimensions other than checkpoint; each frame shows the checkpoint's first matching image", func() {
+		Example(map[string]string{"prompt_name": "forest", "seed": "420", "cfg": "7"})
+	})
+	Attribute("frame_size", Int, "Maximum width and height in pixels of each frame's image", func() {
+		Minimum(32)
+		Maximum(1024)
+		Default(512)
+	})
+	Attribute("frame_delay_ms", Int, "How long each frame is shown, in milliseconds", func() {
+		Minimum(20)
+		Maximum(10000)
+		Default(500)
+	})
+	Required("training_run_id")
+})
+
+var ImageComparisonResponse = Type("ImageComparisonResponse", func() {
+	Description("A verified A/B image pair with aligned thumbnails")
+	Attribute("a", ComparisonImageResponse, "First image")
+	Attribute("b", ComparisonImageResponse, "Second image")
+	Attribute("shared_parameters", MapOf(String, String), "Generation parameters verified identical on both images", func() {
+		Example(map[string]string{"prompt_name": "forest", "seed": "420", "cfg": "7"})
+	})
+	Attribute("thumbnail_width", Int, "Width in pixels of both thumbnails", func() {
+		Example(512)
+	})
+	Attribute("thumbnail_height", Int, "Height in pixels of both thumbnails", func() {
+		Example(512)
+	})
+	Required("a", "b", "shared_parameters", "thumbnail_width", "thumbnail_height")
+})
+
+var ComparisonImageResponse = Type("ComparisonImageResponse", func() {
+	Description("One side of an A/B image comparison")
+	Attribute("path", String, "Image path relative to the sample directory", func() {
+		Example("my-study/model-step00001000.safetensors/prompt_name=forest&seed=42&cfg=4&_00001_.png")
+	})
+	Attribute("checkpoint_filename", String, "Checkpoint that produced the image", func() {
+		Example("model-step00001000.safetensors")
+	})
+	Attribute("step_number", Int, "Training step of the checkpoint; absent for a final checkpoint", func() {
+		Example(1000)
+	})
+	Attribute("width", Int, "Image width in pixels", func() {
+		Example(1024)
+	})
+	Attribute("height", Int, "Image height in pixels", func() {
+		Example(1024)
+	})
+	Attribute("parameters", MapOf(String, String), "Normalized generation parameters from the filename and metadata")
+	Attribute("thumbnail", Bytes, "JPEG thumbnail (base64 in JSON), same dimensions as the other image's")
+	Required("path", "checkpoint_filename", "width", "height", "parameters", "thumbnail")
+})
+
+var SidecarBackfillResponse = Type("SidecarBackfillResponse", func() {
+	Description("Counts from a sidecar backfill run")
+	Attribute("scanned", Int, "PNG files examined", func() {
+		Example(120)
+	})
+	Attribute("written", Int, "Sidecar files created", func() {
+		Example(80)
+	})
+	Attribute("skipped", Int, "Images that already had a sidecar or have no query-encoded filename", func() {
+		Example(40)
+	})
+	Attribute("failed", Int, "Images whose sidecar could not be written", func() {
+		Example(0)
+	})
+	Attribute("failed_paths", ArrayOf(String), "Relative paths of failed images (at most 100)")
+	Required("scanned", "written", "skipped", "failed", "failed_paths")
+})
+
+var PinPayload = Type("PinPayload", func() {
+	Description("Payload for pinning an image or checkpoint sample set")
+	Attribute("path", String, "Path relative to the sample directory (an image file or a checkpoint sample directory)", func() {
+		Example("my-run/my-study/checkpoint.safetensors/index=0&prompt_name=forest&seed=420&cfg=1&_00001_.png")
+		MinLength(1)
+	})
+	Attribute("kind", String, "What the pin protects", func() {
+		Enum("image", "checkpoint")
+		Default("image")
+	})
+	Required("path")
+})
+
+var PinResponse = Type("PinResponse", func() {
+	Description("A pinned image or checkpoint sample set")
+	Attribute("path", String, "Pinned path relative to the sample directory", func() {
+		Example("my-run/my-study/checkpoint.safetensors")
+	})
+	Attribute("kind", String, "What the pin protects", func() {
+		Example("checkpoint")
+		Enum("image", "checkpoint")
+	})
+	Attribute("created_at", String, "Creation timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Required("path", "kind", "created_at")
+})
+
+var AnnotateImagePayload = Type("AnnotateImagePayload", func() {
+	Description("Payload for setting the rating and tags of an image. Exactly one of path and item_id must be given.")
+	Attribute("path", String, "Image path relative to the sample directory", func() {
+		Example("my-run/my-study/checkpoint.safetensors/index=0&prompt_name=forest&seed=420&cfg=1&_00001_.png")
+	})
+	Attribute("item_id", String, "ID of the sample job item that generated the image, instead of path", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("rating", Int, "Star rating from 1 to 5, or 0 for unrated", func() {
+		Minimum(0)
+		Maximum(5)
+		Default(0)
+	})
+	Attribute("tags", ArrayOf(String), "Tags of the image; duplicates are removed", func() {
+		Example([]string{"keeper", "hands-ok"})
+	})
+})
+
+var BulkAnnotateImagesPayload = Type("BulkAnnotateImagesPayload", func() {
+	Description("Payload for changing the rating and tags of many images at once (at most 10000)")
+	Attribute("paths", ArrayOf(String), "Image paths relative to the sample directory")
+	Attribute("item_ids", ArrayOf(String), "IDs of the sample job items that generated the images")
+	Attribute("rating", Int, "Star rating to set on every image from 1 to 5, or 0 to clear it; omit to keep each image's rating", func() {
+		Minimum(0)
+		Maximum(5)
+	})
+	Attribute("add_tags", ArrayOf(String), "Tags to add to every image", func() {
+		Example([]string{"keeper"})
+	})
+	Attribute("remove_tags", ArrayOf(String), "Tags to remove from every image", func() {
+		Example([]string{"reject"})
+	})
+})
+
+var ImageAnnotationResponse = Type("ImageAnnotationResponse", func() {
+	Description("Star rating and tags of an image")
+	Attribute("path", String, "Image path relative to the sample directory", func() {
+		Example("my-run/my-study/checkpoint.safetensors/index=0&prompt_name=forest&seed=420&cfg=1&_00001_.png")
+	})
+	Attribute("rating", Int, "Star rating from 1 to 5, or 0 for unrated", func() {
+		Example(4)
+	})
+	Attribute("tags", ArrayOf(String), "Tags of the image, sorted", func() {
+		Example([]string{"hands-ok", "keeper"})
+	})
+	Attribute("updated_at", String, "Last update timestamp (RFC3339), empty for images that were never annotated", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Required("path", "rating", "tags", "updated_at")
 })
 
 var ImageDownloadResult = Type("ImageDownloadResult", func() {
@@ -68,20 +560,59 @@ var ImageDownloadResult = Type("ImageDownloadResult", func() {
 	Required("content_type", "content_length", "cache_control")
 })
 
+var ImageFileResult = Type("ImageFileResult", func() {
+	Description("Result headers for an image file download")
+	Attribute("content_type", String, "Content-Type header value", func() {
+		Example("image/png")
+	})
+	Attribute("content_length", Int64, "Content-Length header value", func() {
+		Example(123456)
+	})
+	Attribute("accept_ranges", String, "Accept-Ranges header value", func() {
+		Example("bytes")
+	})
+	Attribute("etag", String, "ETag header value, derived from the file's size and modification time", func() {
+		Example(`"1e240-18a2b3c4d5e6f708"`)
+	})
+	Attribute("last_modified", String, "Last-Modified header value", func() {
+		Example("Wed, 01 Jan 2025 00:00:00 GMT")
+	})
+	Attribute("cache_control", String, "Cache-Control header value", func() {
+		Example("no-cache")
+	})
+	Required("content_type", "content_length", "cache_control")
+})
+
+var ArchiveDownloadResult = Type("ArchiveDownloadResult", func() {
+	Description("Result headers for a ZIP archive download")
+	Attribute("content_type", String, "Content-Type header value", func() {
+		Example("application/zip")
+	})
+	Attribute("content_disposition", String, "Content-Disposition header value", func() {
+		Example(`attachment; filename="model-step00001000-samples.zip"`)
+	})
+	Required("content_type", "content_disposition")
+})
+
 var ImageMetadataResponse = Type("ImageMetadataResponse", func() {
 	Description("Image metadata with string and numeric fields differentiated for richer frontend display")
-	Attribute("string_metadata", MapOf(String, String), "Text-valued metadata fields (e.g. prompt_name, sampler_name, workflow_name)", func() {
+	Attribute("string_metadata", MapOf(String, String), "Text-valued metadata fields (e.g. prompt_name, sampler_name, vae, clip, workflow_name, job_id, timestamp)", func() {
 		Example(map[string]string{
 			"prompt_name":  "forest",
 			"sampler_name": "euler",
 		})
 	})
-	Attribute("numeric_metadata", MapOf(String, Float64), "Quantitative metadata fields (seed, steps, cfg)", func() {
+	Attribute("numeric_metadata", MapOf(String, Float64), "Quantitative metadata fields (seed, steps, cfg, width, height, shift)", func() {
 		Example(map[string]float64{
 			"seed":  420,
 			"steps": 20,
 			"cfg":   7.5,
 		})
 	})
-	Required("string_metadata", "numeric_metadata")
+	Attribute("source", String, "Where the metadata was read from: sidecar (JSON sidecar file, includes vae, clip, shift, workflow_name, job_id, timestamp), filename (query-encoded filename of an image without a sidecar), png (PNG tEXt chunks only), or none", func() {
+		Enum("sidecar", "filename", "png", "none")
+		Example("sidecar")
+	})
+	Attribute("annotation", ImageAnnotationResponse, "Star rating and tags of the image; omitted when annotations are not configured")
+	Required("string_metadata", "numeric_metadata", "source")
 })
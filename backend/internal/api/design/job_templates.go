@@ -0,0 +1,263 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+var _ = Service("job_templates", func() {
+	Description("Saved sample job configurations. Running a template against a training run creates a sample job with the template's study and options; see the sample_jobs run_template method.")
+
+	Method("list", func() {
+		Description("List all job templates, ordered by name")
+		Result(ArrayOf(JobTemplateResponse))
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/job-templates")
+			Response(StatusOK)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("show", func() {
+		Description("Get a job template")
+		Payload(func() {
+			Attribute("id", String, "Job template ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("id")
+		})
+		Result(JobTemplateResponse)
+		Error("not_found", ErrorResult, "Job template not found")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/job-templates/{id}")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("create", func() {
+		Description("Save a job configuration as a template")
+		Payload(CreateJobTemplatePayload)
+		Result(JobTemplateResponse)
+		Error("invalid_payload", ErrorResult, "Invalid template, e.g. an unknown study or a malformed checkpoint filter")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/job-templates")
+			Response(StatusCreated)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("update", func() {
+		Description("Replace the configuration of a job template")
+		Payload(UpdateJobTemplatePayload)
+		Result(JobTemplateResponse)
+		Error("not_found", ErrorResult, "Job template not found")
+		Error("invalid_payload", ErrorResult, "Invalid template")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			PUT("/api/job-templates/{id}")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("delete", func() {
+		Description("Delete a job template. Jobs created from it are kept.")
+		Payload(func() {
+			Attribute("id", String, "Job template ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("id")
+		})
+		Error("not_found", ErrorResult, "Job template not found")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/job-templates/{id}")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+})
+
+var CreateJobTemplatePayload = Type("CreateJobTemplatePayload", func() {
+	Description("Payload for creating a job template")
+	Attribute("name", String, "Template display name", func() {
+		Example("Nightly sweep")
+		MinLength(1)
+	})
+	Attribute("study_id", String, "ID of the study jobs are created from; the workflow template is the study's", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("checkpoint_filter", String, "Glob matched against checkpoint filenames (* ? [...] as in path/filepath.Match); only matching checkpoints are sampled. Empty samples all checkpoints.", func() {
+		Example("*-step0000[5-9]*.safetensors")
+		Default("")
+	})
+	Attribute("clear_existing", Boolean, "Delete existing sample directories of the selected checkpoints when the job starts", func() {
+		Default(false)
+	})
+	Attribute("missing_only", Boolean, "Only generate samples that are missing on disk", func() {
+		Default(false)
+	})
+	Attribute("skip_existing", Boolean, "Create items whose output file already exists as skipped", func() {
+		Default(false)
+	})
+	Attribute("append_new_checkpoints", Boolean, "Add checkpoints of the training run that appear later to the job", func() {
+		Default(false)
+	})
+	Attribute("output_format", String, "Format to save sample images in", func() {
+		Enum(outputFormats...)
+		Default("png")
+	})
+	Attribute("output_quality", Int, "Encoder quality for webp and jpeg output (default 90); ignored for png", func() {
+		Minimum(1)
+		Maximum(100)
+		Example(90)
+	})
+	Attribute("vae", String, "VAE override (ComfyUI path); defaults to the study's VAE, then the workflow default", func() {
+		Example("ae.safetensors")
+	})
+	Attribute("clip", String, "Text encoder override (ComfyUI path); defaults to the study's text encoder, then the workflow default", func() {
+		Example("clip_l.safetensors")
+	})
+	Attribute("shift", Float64, "AuraFlow shift override; defaults to the study's shift, then the workflow default", func() {
+		Example(3.1)
+	})
+	Attribute("controlnet_model", String, "ControlNet model (ComfyUI path) for the workflow's controlnet_loader node", func() {
+		Example("control_canny.safetensors")
+	})
+	Attribute("controlnet_strength", Float64, "Strength for the workflow's controlnet_apply node", func() {
+		Minimum(0)
+		Maximum(10)
+		Example(0.8)
+	})
+	Attribute("controlnet_image", String, "Server path of the ControlNet conditioning image", func() {
+		Example("/data/assets/image/edges.png")
+	})
+	Required("name", "study_id")
+})
+
+var UpdateJobTemplatePayload = Type("UpdateJobTemplatePayload", func() {
+	Description("Payload for updating a job template")
+	Attribute("id", String, "Job template ID", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("name", String, "Template display name", func() {
+		Example("Nightly sweep")
+		MinLength(1)
+	})
+	Attribute("study_id", String, "ID of the study jobs are created from; the workflow template is the study's", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("checkpoint_filter", String, "Glob matched against checkpoint filenames (* ? [...] as in path/filepath.Match); only matching checkpoints are sampled. Empty samples all checkpoints.", func() {
+		Example("*-step0000[5-9]*.safetensors")
+		Default("")
+	})
+	Attribute("clear_existing", Boolean, "Delete existing sample directories of the selected checkpoints when the job starts", func() {
+		Default(false)
+	})
+	Attribute("missing_only", Boolean, "Only generate samples that are missing on disk", func() {
+		Default(false)
+	})
+	Attribute("skip_existing", Boolean, "Create items whose output file already exists as skipped", func() {
+		Default(false)
+	})
+	Attribute("append_new_checkpoints", Boolean, "Add checkpoints of the training run that appear later to the job", func() {
+		Default(false)
+	})
+	Attribute("output_format", String, "Format to save sample images in", func() {
+		Enum(outputFormats...)
+		Default("png")
+	})
+	Attribute("output_quality", Int, "Encoder quality for webp and jpeg output (default 90); ignored for png", func() {
+		Minimum(1)
+		Maximum(100)
+		Example(90)
+	})
+	Attribute("vae", String, "VAE override (ComfyUI path); defaults to the study's VAE, then the workflow default", func() {
+		Example("ae.safetensors")
+	})
+	Attribute("clip", String, "Text encoder override (ComfyUI path); defaults to the study's text encoder, then the workflow default", func() {
+		Example("clip_l.safetensors")
+	})
+	Attribute("shift", Float64, "AuraFlow shift override; defaults to the study's shift, then the workflow default", func() {
+		Example(3.1)
+	})
+	Attribute("controlnet_model", String, "ControlNet model (ComfyUI path) for the workflow's controlnet_loader node", func() {
+		Example("control_canny.safetensors")
+	})
+	Attribute("controlnet_strength", Float64, "Strength for the workflow's controlnet_apply node", func() {
+		Minimum(0)
+		Maximum(10)
+		Example(0.8)
+	})
+	Attribute("controlnet_image", String, "Server path of the ControlNet conditioning image", func() {
+		Example("/data/assets/image/edges.png")
+	})
+	Required("id", "name", "study_id")
+})
+
+var JobTemplateResponse = Type("JobTemplateResponse", func() {
+	Description("A saved sample job configuration")
+	Attribute("id", String, "Job template ID (UUID)", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("name", String, "Template display name", func() {
+		Example("Nightly sweep")
+	})
+	Attribute("study_id", String, "ID of the study jobs are created from; the workflow template is the study's", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("checkpoint_filter", String, "Glob matched against checkpoint filenames (* ? [...] as in path/filepath.Match); only matching checkpoints are sampled. Empty samples all checkpoints.", func() {
+		Example("*-step0000[5-9]*.safetensors")
+	})
+	Attribute("clear_existing", Boolean, "Delete existing sample directories of the selected checkpoints when the job starts", func() {
+	})
+	Attribute("missing_only", Boolean, "Only generate samples that are missing on disk", func() {
+	})
+	Attribute("skip_existing", Boolean, "Create items whose output file already exists as skipped", func() {
+	})
+	Attribute("append_new_checkpoints", Boolean, "Add checkpoints of the training run that appear later to the job", func() {
+	})
+	Attribute("output_format", String, "Format to save sample images in", func() {
+		Enum(outputFormats...)
+	})
+	Attribute("output_quality", Int, "Encoder quality for webp and jpeg output (default 90); ignored for png", func() {
+		Minimum(1)
+		Maximum(100)
+		Example(90)
+	})
+	Attribute("vae", String, "VAE override (ComfyUI path); defaults to the study's VAE, then the workflow default", func() {
+		Example("ae.safetensors")
+	})
+	Attribute("clip", String, "Text encoder override (ComfyUI path); defaults to the study's text encoder, then the workflow default", func() {
+		Example("clip_l.safetensors")
+	})
+	Attribute("shift", Float64, "AuraFlow shift override; defaults to the study's shift, then the workflow default", func() {
+		Example(3.1)
+	})
+	Attribute("controlnet_model", String, "ControlNet model (ComfyUI path) for the workflow's controlnet_loader node", func() {
+		Example("control_canny.safetensors")
+	})
+	Attribute("controlnet_strength", Float64, "Strength for the workflow's controlnet_apply node", func() {
+		Minimum(0)
+		Maximum(10)
+		Example(0.8)
+	})
+	Attribute("controlnet_image", String, "Server path of the ControlNet conditioning image", func() {
+		Example("/data/assets/image/edges.png")
+	})
+	Attribute("created_at", String, "Creation timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Attribute("updated_at", String, "Last update timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Required("id", "name", "study_id", "checkpoint_filter", "clear_existing", "missing_only", "skip_existing", "append_new_checkpoints", "output_format", "created_at", "updated_at")
+})
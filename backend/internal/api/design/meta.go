@@ -0,0 +1,51 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+var _ = Service("meta", func() {
+	Description("Server time zone and locale hints for formatting dates and numbers")
+
+	Method("show", func() {
+		Description("Report the time zone schedules are evaluated in, its current offset and next DST change, and the locale to format dates and numbers with. The locale is the most preferred language of the Accept-Language header, or the configured default when the header names none.")
+		Payload(func() {
+			Attribute("accept_language", String, "Accept-Language header of the request")
+		})
+		Result(ServerMetaResponse)
+		HTTP(func() {
+			GET("/api/meta")
+			Header("accept_language:Accept-Language")
+			Response(StatusOK)
+		})
+	})
+})
+
+var ServerMetaResponse = Type("ServerMetaResponse", func() {
+	Attribute("timezone", String, "IANA name of the time zone schedules are evaluated in", func() {
+		Example("Europe/Berlin")
+	})
+	Attribute("zone_abbreviation", String, "Abbreviation of the zone in effect now", func() {
+		Example("CEST")
+	})
+	Attribute("utc_offset_seconds", Int, "Offset from UTC in effect now, in seconds", func() {
+		Example(7200)
+	})
+	Attribute("dst", Boolean, "Whether daylight saving time is in effect now")
+	Attribute("next_transition", String, "When the zone's offset or abbreviation next changes (RFC3339, UTC); absent if it never does", func() {
+		Example("2026-10-25T01:00:00Z")
+	})
+	Attribute("server_time", String, "Current time in the server's zone (RFC3339 with offset)", func() {
+		Example("2026-07-01T14:00:00+02:00")
+	})
+	Attribute("locale", String, "Locale to format dates and numbers with", func() {
+		Example("de-DE")
+	})
+	Attribute("default_locale", String, "Configured locale used when Accept-Language names no language", func() {
+		Example("en-US")
+	})
+	Attribute("accepted_locales", ArrayOf(String), "Languages of the Accept-Language header, most preferred first", func() {
+		Example([]string{"de-DE", "en"})
+	})
+	Required("timezone", "zone_abbreviation", "utc_offset_seconds", "dst", "server_time", "locale", "default_locale", "accepted_locales")
+})
@@ -0,0 +1,13 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+// ownerAttribute defines the owner attribute of a list method's payload. Map
+// it to a query parameter with Param("owner"). tag is its gRPC field number.
+func ownerAttribute(tag int) {
+	Field(tag, "owner", String, "Only list the items of this user, or of every user with \"all\"; listing other users' items requires an admin user. By default a user lists their own and the unowned items, and requests without a token list every item.", func() {
+		Example("alice")
+	})
+}
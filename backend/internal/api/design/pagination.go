@@ -0,0 +1,24 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+// maxPageLimit is the largest page a list endpoint returns at once.
+const maxPageLimit = 1000
+
+// pageAttributes defines the limit and offset attributes of a paginated list
+// method's payload. Map them to query parameters with Param("limit") and
+// Param("offset"). tag is the gRPC field number of limit; offset is tag+1.
+func pageAttributes(tag int) {
+	Field(tag, "limit", Int, "Maximum number of entries to return; all entries from offset on when omitted", func() {
+		Minimum(1)
+		Maximum(maxPageLimit)
+		Example(100)
+	})
+	Field(tag+1, "offset", Int, "Number of entries to skip", func() {
+		Minimum(0)
+		Default(0)
+		Example(0)
+	})
+}
@@ -8,12 +8,42 @@ var _ = Service("presets", func() {
 	Description("Preset management service for dimension mapping configurations")
 
 	Method("list", func() {
-		Description("List all saved presets")
+		Description("List all saved presets, or the presets of a training run together with the presets that apply to every training run")
+		Payload(func() {
+			Attribute("training_run", String, "Only list the presets of this training run and unscoped presets", func() {
+				Example("my-lora")
+			})
+			ownerAttribute(2)
+		})
 		Result(ArrayOf(PresetResponse))
+		Error("forbidden", ErrorResult, "Listing the presets of another user requires an admin user")
 		Error("internal_error", ErrorResult, "Internal server error")
 		HTTP(func() {
 			GET("/api/presets")
+			Param("training_run")
+			Param("owner")
+			Response(StatusOK)
+			Response("forbidden", StatusForbidden)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("default", func() {
+		Description("Get the default view preset of a training run")
+		Payload(func() {
+			Attribute("training_run", String, "Training run name", func() {
+				Example("my-lora")
+			})
+			Required("training_run")
+		})
+		Result(PresetResponse)
+		Error("not_found", ErrorResult, "The training run has no default preset")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/presets/default")
+			Param("training_run")
 			Response(StatusOK)
+			Response("not_found", StatusNotFound)
 			Response("internal_error", StatusInternalServerError)
 		})
 	})
@@ -38,12 +68,64 @@ var _ = Service("presets", func() {
 		Result(PresetResponse)
 		Error("not_found", ErrorResult, "Preset not found")
 		Error("invalid_payload", ErrorResult, "Invalid preset data")
+		Error("forbidden", ErrorResult, "Preset owned by another user")
 		Error("internal_error", ErrorResult, "Internal server error")
 		HTTP(func() {
 			PUT("/api/presets/{id}")
 			Response(StatusOK)
 			Response("not_found", StatusNotFound)
 			Response("invalid_payload", StatusBadRequest)
+			Response("forbidden", StatusForbidden)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("export", func() {
+		Description("Export a preset, or all presets when no ID is given, as a portable JSON document that import accepts")
+		Payload(func() {
+			Attribute("id", String, "ID of the preset to export; all presets when omitted", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+		})
+		Result(PresetExportDocument)
+		Error("not_found", ErrorResult, "Preset not found")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/presets/export")
+			Param("id")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("import", func() {
+		Description("Import the presets of an exported document. A preset is matched to an existing preset by name; on_conflict decides whether a match is renamed (\"<name> 2\", ...), overwritten, or skipped. Every preset is validated before any is imported.")
+		Payload(func() {
+			Attribute("format_version", Int, "Format version of the document; newer versions than the server's are rejected", func() {
+				Minimum(1)
+				Example(1)
+			})
+			Attribute("exported_at", String, "Export timestamp (RFC3339); informational", func() {
+				Example("2025-01-01T00:00:00Z")
+			})
+			Attribute("presets", ArrayOf(CreatePresetPayload), "Presets to import")
+			Attribute("on_conflict", String, "What to do with a preset whose name is taken", func() {
+				Enum(importConflictPolicies...)
+				Default("rename")
+			})
+			Required("format_version", "presets")
+		})
+		Result(ArrayOf(PresetImportResult))
+		Error("invalid_payload", ErrorResult, "Invalid document; the message names the offending preset")
+		Error("forbidden", ErrorResult, "A preset to overwrite is owned by another user")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/presets/import")
+			Param("on_conflict")
+			Response(StatusOK)
+			Response("invalid_payload", StatusBadRequest)
+			Response("forbidden", StatusForbidden)
 			Response("internal_error", StatusInternalServerError)
 		})
 	})
@@ -57,11 +139,13 @@ var _ = Service("presets", func() {
 			Required("id")
 		})
 		Error("not_found", ErrorResult, "Preset not found")
+		Error("forbidden", ErrorResult, "Preset owned by another user")
 		Error("internal_error", ErrorResult, "Internal server error")
 		HTTP(func() {
 			DELETE("/api/presets/{id}")
 			Response(StatusNoContent)
 			Response("not_found", StatusNotFound)
+			Response("forbidden", StatusForbidden)
 			Response("internal_error", StatusInternalServerError)
 		})
 	})
@@ -76,13 +160,23 @@ var PresetResponse = Type("PresetResponse", func() {
 		Example("My Config")
 	})
 	Attribute("mapping", PresetMappingResponse, "Dimension-to-role assignments")
+	Attribute("training_run", String, "Training run the preset is a view of; empty for a preset that applies to every training run", func() {
+		Example("my-lora")
+	})
+	Attribute("view", PresetView, "Filter and sort state of the view")
+	Attribute("default", Boolean, "Whether the preset is the view opened for its training run. Setting it unsets the run's previous default.", func() {
+		Default(false)
+	})
+	Attribute("owner", String, "Name of the user that created the preset; absent for unowned presets", func() {
+		Example("alice")
+	})
 	Attribute("created_at", String, "Creation timestamp (RFC3339)", func() {
 		Example("2025-01-01T00:00:00Z")
 	})
 	Attribute("updated_at", String, "Last update timestamp (RFC3339)", func() {
 		Example("2025-01-01T00:00:00Z")
 	})
-	Required("id", "name", "mapping", "created_at", "updated_at")
+	Required("id", "name", "mapping", "training_run", "view", "default", "created_at", "updated_at")
 })
 
 var PresetMappingResponse = Type("PresetMappingResponse", func() {
@@ -115,6 +209,13 @@ var CreatePresetPayload = Type("CreatePresetPayload", func() {
 		MinLength(1)
 	})
 	Attribute("mapping", PresetMappingPayload, "Dimension-to-role assignments")
+	Attribute("training_run", String, "Training run the preset is a view of; empty for a preset that applies to every training run", func() {
+		Example("my-lora")
+	})
+	Attribute("view", PresetView, "Filter and sort state of the view")
+	Attribute("default", Boolean, "Whether the preset is the view opened for its training run. Setting it unsets the run's previous default.", func() {
+		Default(false)
+	})
 	Required("name", "mapping")
 })
 
@@ -128,6 +229,13 @@ var UpdatePresetPayload = Type("UpdatePresetPayload", func() {
 		MinLength(1)
 	})
 	Attribute("mapping", PresetMappingPayload, "Dimension-to-role assignments")
+	Attribute("training_run", String, "Training run the preset is a view of; empty for a preset that applies to every training run", func() {
+		Example("my-lora")
+	})
+	Attribute("view", PresetView, "Filter and sort state of the view")
+	Attribute("default", Boolean, "Whether the preset is the view opened for its training run. Setting it unsets the run's previous default.", func() {
+		Default(false)
+	})
 	Required("id", "name", "mapping")
 })
 
@@ -153,3 +261,51 @@ var PresetMappingPayload = Type("PresetMappingPayload", func() {
 	})
 	Required("combos")
 })
+
+var PresetView = Type("PresetView", func() {
+	Description("Grid view state saved with a preset besides its mapping")
+	Attribute("filters", MapOf(String, ArrayOf(String)), "Dimension values the view is restricted to", func() {
+		Example(map[string][]string{"seed": {"420"}, "prompt_name": {"forest"}})
+	})
+	Attribute("sort", ArrayOf(PresetSort), "Sort order of dimension values; dimensions without an entry keep their natural order")
+})
+
+var PresetSort = Type("PresetSort", func() {
+	Description("Sort order of one dimension's values")
+	Attribute("dimension", String, "Dimension name", func() {
+		Example("checkpoint")
+	})
+	Attribute("descending", Boolean, "Whether the values are sorted in descending order", func() {
+		Default(false)
+	})
+	Required("dimension")
+})
+
+var PresetExportDocument = Type("PresetExportDocument", func() {
+	Description("Portable JSON document of exported presets")
+	Attribute("format_version", Int, "Format version of the document", func() {
+		Example(1)
+	})
+	Attribute("exported_at", String, "Export timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Attribute("presets", ArrayOf(CreatePresetPayload), "Exported presets, without IDs or timestamps")
+	Required("format_version", "exported_at", "presets")
+})
+
+var PresetImportResult = Type("PresetImportResult", func() {
+	Description("Outcome of importing one preset of a document")
+	Attribute("source_name", String, "Name of the preset in the document", func() {
+		Example("My Config")
+	})
+	Attribute("id", String, "ID of the created or overwritten preset, or of the existing preset when skipped", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("name", String, "Name of the preset now; differs from source_name when renamed", func() {
+		Example("My Config 2")
+	})
+	Attribute("action", String, "What importing the preset did", func() {
+		Enum(importActions...)
+	})
+	Required("source_name", "id", "name", "action")
+})
@@ -8,20 +8,50 @@ var _ = Service("sample_jobs", func() {
 	Description("Sample job orchestration service")
 
 	Method("list", func() {
-		Description("List all sample jobs (newest first)")
-		Result(ArrayOf(SampleJobResponse))
+		Description("List sample jobs (newest first). Without limit, every job from offset on is returned. The total number of jobs is returned in the X-Total-Count header.")
+		Payload(func() {
+			pageAttributes(1)
+			ownerAttribute(3)
+			Field(4, "label", ArrayOf(String), "Only list jobs carrying every given label: \"key\" matches a label with any value, \"key=value\" only that value", func() {
+				Example([]string{"experiment=lr-sweep", "baseline"})
+			})
+		})
+		Result(func() {
+			Field(1, "jobs", ArrayOf(SampleJobResponse), "Sample jobs in the requested page")
+			Field(2, "total", Int, "Total number of sample jobs", func() {
+				Example(42)
+			})
+			Required("jobs", "total")
+		})
+		Error("invalid_payload", ErrorResult, "Invalid label filter")
+		Error("forbidden", ErrorResult, "Listing the sample jobs of another user requires an admin user")
 		Error("internal_error", ErrorResult, "Internal server error")
 		HTTP(func() {
 			GET("/api/sample-jobs")
-			Response(StatusOK)
+			Param("limit")
+			Param("offset")
+			Param("owner")
+			Param("label")
+			Response(StatusOK, func() {
+				Header("total:X-Total-Count")
+				Body("jobs")
+			})
+			Response("invalid_payload", StatusBadRequest)
+			Response("forbidden", StatusForbidden)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("show", func() {
 		Description("Get a sample job by ID with progress metrics")
 		Payload(func() {
-			Attribute("id", String, "Sample job ID", func() {
+			Field(1, "id", String, "Sample job ID", func() {
 				Example("550e8400-e29b-41d4-a716-446655440000")
 			})
 			Required("id")
@@ -35,6 +65,120 @@ var _ = Service("sample_jobs", func() {
 			Response("not_found", StatusNotFound)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("list_items", func() {
+		Description("List the items of a sample job, including per-item timing metrics and automatic scores. Items can be filtered by status, skip reason, checkpoint, prompt, and sampler, and sorted by creation (the default), duration, seed, or the automatic score named by score. Without limit, every matching item from offset on is returned. The number of matching items is returned in the X-Total-Count header.")
+		Payload(func() {
+			Field(1, "id", String, "Sample job ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Field(2, "status", String, "Only return items with this status", func() {
+				Enum(jobItemStatuses...)
+				Example("failed")
+			})
+			Field(3, "skip_reason", String, "Only return skipped items with this skip reason", func() {
+				Enum(jobItemSkipReasons...)
+				Example("checkpoint_not_found")
+			})
+			Field(4, "checkpoint", String, "Only return items for this checkpoint filename", func() {
+				Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+			})
+			Field(5, "prompt_name", String, "Only return items for this prompt name", func() {
+				Example("forest")
+			})
+			Field(6, "sampler", String, "Only return items using this sampler", func() {
+				Example("euler")
+			})
+			Field(7, "sort", String, "Sort key; items that have not finished sort last by duration, and items without the score sort last by score", func() {
+				Enum("created_at", "duration", "seed", "score")
+				Default("created_at")
+				Example("duration")
+			})
+			Field(8, "score", String, "Name of the automatic score to sort by; required when sort is score", func() {
+				Example("aesthetic")
+			})
+			Field(9, "order", String, "Sort direction", func() {
+				Enum("asc", "desc")
+				Default("asc")
+				Example("desc")
+			})
+			pageAttributes(10)
+			Required("id")
+		})
+		Result(func() {
+			Field(1, "items", ArrayOf(SampleJobItemResponse), "Matching sample job items in the requested page")
+			Field(2, "total", Int, "Number of items in the job that match the filters", func() {
+				Example(540)
+			})
+			Required("items", "total")
+		})
+		Error("invalid_payload", ErrorResult, "Invalid score name")
+		Error("not_found", ErrorResult, "Sample job not found")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/sample-jobs/{id}/items")
+			Param("status")
+			Param("skip_reason")
+			Param("checkpoint")
+			Param("prompt_name")
+			Param("sampler")
+			Param("sort")
+			Param("score")
+			Param("order")
+			Param("limit")
+			Param("offset")
+			Response(StatusOK, func() {
+				Header("total:X-Total-Count")
+				Body("items")
+			})
+			Response("invalid_payload", StatusBadRequest)
+			Response("not_found", StatusNotFound)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("not_found", CodeNotFound)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("compare", func() {
+		Description("Pair up the items of two sample jobs generated with the same prompt, seed, CFG, steps, sampler, and scheduler, for a side-by-side comparison. Items sharing parameters (one per checkpoint) are paired in item order; items without a counterpart are returned unmatched.")
+		Payload(func() {
+			Field(1, "a", String, "ID of the first sample job", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Field(2, "b", String, "ID of the second sample job", func() {
+				Example("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+			})
+			Required("a", "b")
+		})
+		Result(SampleJobComparisonResponse)
+		Error("not_found", ErrorResult, "Sample job not found")
+		Error("service_unavailable", ErrorResult, "ComfyUI service unavailable")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/sample-jobs/compare")
+			Param("a")
+			Param("b")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("service_unavailable", CodeUnavailable)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("create", func() {
@@ -49,232 +193,852 @@ var _ = Service("sample_jobs", func() {
 			Response("not_found", StatusNotFound)
 			Response("invalid_payload", StatusBadRequest)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+		})
+	})
+
+	Method("preview", func() {
+		Description("Expand a sample job as create would, without storing it or clearing existing samples. Reports the item counts, the checkpoints that failed ComfyUI path matching, and an estimated runtime.")
+		Payload(CreateSampleJobPayload)
+		Result(SampleJobPreviewResponse)
+		Error("not_found", ErrorResult, "Training run or study not found")
+		Error("invalid_payload", ErrorResult, "Invalid sample job data")
+		HTTP(func() {
+			POST("/api/sample-jobs/preview")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+		})
+	})
+
+	Method("create_bulk", func() {
+		Description("Create one sample job per study for a single training run. Jobs are created, and run, in the order the studies are listed.")
+		Payload(BulkCreateSampleJobsPayload)
+		Result(BulkCreateSampleJobsResponse)
+		Error("not_found", ErrorResult, "Training run or study not found")
+		Error("invalid_payload", ErrorResult, "Invalid sample job data")
+		HTTP(func() {
+			POST("/api/sample-jobs/bulk")
+			Response(StatusCreated)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+		})
+	})
+
+	Method("create_with_study", func() {
+		Description("Create a new study and a sample job of it in a single transaction. Neither is stored if either cannot be created, so a failed request leaves no orphaned study behind.")
+		Payload(CreateSampleJobWithStudyPayload)
+		Result(SampleJobWithStudyResponse)
+		Error("not_found", ErrorResult, "Training run not found")
+		Error("invalid_payload", ErrorResult, "Invalid study or sample job data")
+		HTTP(func() {
+			POST("/api/sample-jobs/with-study")
+			Response(StatusCreated)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+		})
+	})
+
+	Method("run_template", func() {
+		Description("Create a sample job of a training run from a job template, with the template's study and options. Only the checkpoints matching the template's checkpoint filter are sampled. Like any new job, it starts when the executor picks it up.")
+		Payload(func() {
+			Field(1, "id", String, "Job template ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Field(2, "training_run", String, "Name of the training run to sample", func() {
+				Example("qwen/psai4rt-v0.3.0-no-reg")
+				MinLength(1)
+			})
+			Required("id", "training_run")
+		})
+		Result(SampleJobResponse)
+		Error("not_found", ErrorResult, "Job template, training run, or study not found")
+		Error("invalid_payload", ErrorResult, "The job cannot be created, e.g. no checkpoint matches the template's filter")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/job-templates/{id}/run")
+			Param("training_run")
+			Response(StatusCreated)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("start", func() {
 		Description("Start a pending sample job")
 		Payload(func() {
-			Attribute("id", String, "Sample job ID", func() {
+			Field(1, "id", String, "Sample job ID", func() {
 				Example("550e8400-e29b-41d4-a716-446655440000")
 			})
 			Required("id")
 		})
 		Result(SampleJobResponse)
 		Error("not_found", ErrorResult, "Sample job not found")
+		Error("forbidden", ErrorResult, "Sample job owned by another user")
 		Error("invalid_state", ErrorResult, "Cannot start job in current state")
 		Error("service_unavailable", ErrorResult, "ComfyUI service unavailable")
 		HTTP(func() {
 			POST("/api/sample-jobs/{id}/start")
 			Response(StatusOK)
 			Response("not_found", StatusNotFound)
+			Response("forbidden", StatusForbidden)
 			Response("invalid_state", StatusBadRequest)
 			Response("service_unavailable", StatusServiceUnavailable)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("forbidden", CodePermissionDenied)
+			Response("invalid_state", CodeFailedPrecondition)
+			Response("service_unavailable", CodeUnavailable)
+		})
 	})
 
 	Method("stop", func() {
 		Description("Stop a running sample job (stops after current item)")
 		Payload(func() {
-			Attribute("id", String, "Sample job ID", func() {
+			Field(1, "id", String, "Sample job ID", func() {
 				Example("550e8400-e29b-41d4-a716-446655440000")
 			})
 			Required("id")
 		})
 		Result(SampleJobResponse)
 		Error("not_found", ErrorResult, "Sample job not found")
+		Error("forbidden", ErrorResult, "Sample job owned by another user")
 		Error("invalid_state", ErrorResult, "Cannot stop job in current state")
 		HTTP(func() {
 			POST("/api/sample-jobs/{id}/stop")
 			Response(StatusOK)
 			Response("not_found", StatusNotFound)
+			Response("forbidden", StatusForbidden)
 			Response("invalid_state", StatusBadRequest)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("forbidden", CodePermissionDenied)
+			Response("invalid_state", CodeFailedPrecondition)
+		})
 	})
 
 	Method("resume", func() {
 		Description("Resume a stopped sample job")
 		Payload(func() {
-			Attribute("id", String, "Sample job ID", func() {
+			Field(1, "id", String, "Sample job ID", func() {
 				Example("550e8400-e29b-41d4-a716-446655440000")
 			})
 			Required("id")
 		})
 		Result(SampleJobResponse)
 		Error("not_found", ErrorResult, "Sample job not found")
+		Error("forbidden", ErrorResult, "Sample job owned by another user")
 		Error("invalid_state", ErrorResult, "Cannot resume job in current state")
 		Error("service_unavailable", ErrorResult, "ComfyUI service unavailable")
 		HTTP(func() {
 			POST("/api/sample-jobs/{id}/resume")
 			Response(StatusOK)
 			Response("not_found", StatusNotFound)
+			Response("forbidden", StatusForbidden)
 			Response("invalid_state", StatusBadRequest)
 			Response("service_unavailable", StatusServiceUnavailable)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("forbidden", CodePermissionDenied)
+			Response("invalid_state", CodeFailedPrecondition)
+			Response("service_unavailable", CodeUnavailable)
+		})
 	})
 
 	Method("retry_failed", func() {
 		Description("Retry failed items in a completed_with_errors job by re-queuing only the failed and skipped items")
 		Payload(func() {
-			Attribute("id", String, "Sample job ID", func() {
+			Field(1, "id", String, "Sample job ID", func() {
 				Example("550e8400-e29b-41d4-a716-446655440000")
 			})
 			Required("id")
 		})
 		Result(SampleJobResponse)
 		Error("not_found", ErrorResult, "Sample job not found")
+		Error("forbidden", ErrorResult, "Sample job owned by another user")
 		Error("invalid_state", ErrorResult, "Cannot retry job in current state")
 		Error("service_unavailable", ErrorResult, "ComfyUI service unavailable")
 		HTTP(func() {
 			POST("/api/sample-jobs/{id}/retry-failed")
 			Response(StatusOK)
 			Response("not_found", StatusNotFound)
+			Response("forbidden", StatusForbidden)
 			Response("invalid_state", StatusBadRequest)
 			Response("service_unavailable", StatusServiceUnavailable)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("forbidden", CodePermissionDenied)
+			Response("invalid_state", CodeFailedPrecondition)
+			Response("service_unavailable", CodeUnavailable)
+		})
+	})
+
+	Method("annotate", func() {
+		Description("Replace the notes and labels of a sample job. Annotations can be changed in any status, including while the job runs.")
+		Payload(func() {
+			Field(1, "id", String, "Sample job ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Field(2, "notes", String, "Free-text notes; omitted or empty clears them", func() {
+				Example("lr=1e-5 experiment, bad data mix")
+				MaxLength(10000)
+			})
+			Field(3, "labels", MapOf(String, String), "Key/value labels; omitted or empty clears them", func() {
+				Example(map[string]string{"lr": "1e-5", "data": "mix-b"})
+			})
+			Required("id")
+		})
+		Result(SampleJobResponse)
+		Error("not_found", ErrorResult, "Sample job not found")
+		Error("invalid_payload", ErrorResult, "Invalid notes or labels")
+		Error("forbidden", ErrorResult, "Sample job owned by another user")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			PUT("/api/sample-jobs/{id}/annotations")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("forbidden", StatusForbidden)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("delete", func() {
-		Description("Delete a sample job and all its items. When delete_data is true, also removes the generated sample files from disk.")
+		Description("Move a sample job to the trash. A running job is stopped first. Trashed jobs are hidden from the job list until they are restored, and are removed with their items when purged, by hand or after the configured trash retention. When delete_data is true, purging also removes the generated sample files from disk.")
 		Payload(func() {
-			Attribute("id", String, "Sample job ID", func() {
+			Field(1, "id", String, "Sample job ID", func() {
 				Example("550e8400-e29b-41d4-a716-446655440000")
 			})
-			Attribute("delete_data", Boolean, "When true, also deletes the generated sample files from disk", func() {
+			Field(2, "delete_data", Boolean, "When true, purging the job also deletes the generated sample files from disk", func() {
 				Default(false)
 			})
 			Required("id")
 		})
 		Error("not_found", ErrorResult, "Sample job not found")
+		Error("forbidden", ErrorResult, "Sample job owned by another user")
 		Error("internal_error", ErrorResult, "Internal server error")
 		HTTP(func() {
 			DELETE("/api/sample-jobs/{id}")
 			Param("delete_data")
 			Response(StatusNoContent)
 			Response("not_found", StatusNotFound)
+			Response("forbidden", StatusForbidden)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("list_trash", func() {
+		Description("List the sample jobs in the trash, most recently deleted first.")
+		Payload(func() {
+			ownerAttribute(1)
+		})
+		Result(func() {
+			Field(1, "jobs", ArrayOf(SampleJobResponse), "Sample jobs in the trash")
+			Required("jobs")
+		})
+		Error("forbidden", ErrorResult, "Listing the sample jobs of another user requires an admin user")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/sample-jobs/trash")
+			Param("owner")
+			Response(StatusOK, func() {
+				Body("jobs")
+			})
+			Response("forbidden", StatusForbidden)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("restore", func() {
+		Description("Restore a sample job from the trash. A job that was running when it was deleted is restored as stopped.")
+		Payload(func() {
+			Field(1, "id", String, "Sample job ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("id")
+		})
+		Result(SampleJobResponse)
+		Error("not_found", ErrorResult, "Sample job not found in the trash")
+		Error("forbidden", ErrorResult, "Sample job owned by another user")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/sample-jobs/{id}/restore")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("forbidden", StatusForbidden)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("purge", func() {
+		Description("Permanently remove a sample job in the trash and all its items. When the job was deleted with delete_data, also removes its generated sample files from disk.")
+		Payload(func() {
+			Field(1, "id", String, "Sample job ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("id")
+		})
+		Error("not_found", ErrorResult, "Sample job not found in the trash")
+		Error("forbidden", ErrorResult, "Sample job owned by another user")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/sample-jobs/trash/{id}")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("forbidden", StatusForbidden)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("empty_trash", func() {
+		Description("Purge every sample job in the trash that the user may change, like purge.")
+		Result(func() {
+			Field(1, "purged", Int, "Number of sample jobs purged", func() {
+				Example(3)
+			})
+			Required("purged")
+		})
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/sample-jobs/trash")
+			Response(StatusOK)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("internal_error", CodeInternal)
+		})
 	})
 })
 
 var SampleJobResponse = Type("SampleJobResponse", func() {
 	Description("A sample job")
-	Attribute("id", String, "Job ID (UUID)", func() {
+	Field(1, "id", String, "Job ID (UUID)", func() {
 		Example("550e8400-e29b-41d4-a716-446655440000")
 	})
-	Attribute("training_run_name", String, "Training run identifier", func() {
+	Field(2, "training_run_name", String, "Training run identifier", func() {
 		Example("qwen/psai4rt-v0.3.0-no-reg")
 	})
-	Attribute("study_id", String, "Study ID (UUID)", func() {
+	Field(3, "study_id", String, "Study ID (UUID)", func() {
 		Example("550e8400-e29b-41d4-a716-446655440000")
 	})
-	Attribute("study_name", String, "Study display name (denormalized)", func() {
+	Field(4, "study_name", String, "Study display name (denormalized)", func() {
 		Example("My Study")
 	})
-	Attribute("workflow_name", String, "Workflow template filename", func() {
+	Field(5, "study_version", Int, "Version of the study the job was created from", func() {
+		Example(1)
+	})
+	Field(6, "workflow_name", String, "Workflow template filename", func() {
 		Example("qwen-image.json")
 	})
-	Attribute("vae", String, "Selected VAE (ComfyUI path)", func() {
+	Field(7, "vae", String, "Selected VAE (ComfyUI path)", func() {
 		Example("ae.safetensors")
 	})
-	Attribute("clip", String, "Selected CLIP (ComfyUI path)", func() {
+	Field(8, "clip", String, "Selected CLIP (ComfyUI path)", func() {
 		Example("clip_l.safetensors")
 	})
-	Attribute("shift", Float64, "AuraFlow shift value (nullable)")
-	Attribute("status", String, "Job status: pending, running, stopped, completed, completed_with_errors, failed", func() {
+	Field(9, "shift", Float64, "AuraFlow shift value (nullable)")
+	Field(10, "input_image", String, "Server path of the image an img2img job starts from, copied from the study; empty for text-to-image jobs", func() {
+		Example("/data/assets/image/reference.png")
+	})
+	Field(11, "controlnet_model", String, "ControlNet model substituted into the controlnet_loader node (optional)", func() {
+		Example("control_canny.safetensors")
+	})
+	Field(12, "controlnet_strength", Float64, "ControlNet strength substituted into the controlnet_apply node (optional)", func() {
+		Example(0.8)
+	})
+	Field(13, "controlnet_image", String, "Server path of the ControlNet conditioning image (optional)", func() {
+		Example("/data/assets/image/edges.png")
+	})
+	Field(14, "status", String, "Job status: pending, running, stopped, completed, completed_with_errors, failed", func() {
 		Example("running")
-		Enum("pending", "running", "stopped", "completed", "completed_with_errors", "failed")
+		Enum(jobStatuses...)
 	})
-	Attribute("total_items", Int, "Total work items", func() {
+	Field(15, "total_items", Int, "Total work items", func() {
 		Example(540)
 	})
-	Attribute("completed_items", Int, "Completed work items", func() {
+	Field(16, "completed_items", Int, "Completed work items", func() {
 		Example(120)
 	})
-	Attribute("failed_items", Int, "Failed work items (computed on-the-fly from item statuses)", func() {
+	Field(17, "failed_items", Int, "Failed work items (computed on-the-fly from item statuses)", func() {
 		Example(5)
 	})
-	Attribute("pending_items", Int, "Pending work items (computed on-the-fly from item statuses)", func() {
+	Field(18, "pending_items", Int, "Pending work items (computed on-the-fly from item statuses)", func() {
 		Example(390)
 	})
-	Attribute("failed_item_details", ArrayOf(FailedItemDetailResponse), "Details of failed checkpoints (populated only when job has failed items)")
-	Attribute("checkpoint_filenames", ArrayOf(String), "List of checkpoint filenames selected at job creation (empty means all checkpoints were included)", func() {
+	Field(19, "skipped_items", MapOf(String, Int), "Skipped work items, which failed_items includes, by skip reason (omitted when no item was skipped)", func() {
+		Key(func() {
+			Enum(jobItemSkipReasons...)
+		})
+		Example(map[string]int{"checkpoint_not_found": 5})
+	})
+	Field(20, "failed_item_details", ArrayOf(FailedItemDetailResponse), "Details of failed checkpoints (populated only when job has failed items)")
+	Field(21, "warnings", ArrayOf(String), "Warnings about the job, reported only when it is created; e.g. when its resolution likely does not fit in the GPU memory reported by ComfyUI", func() {
+		Example([]string{"flux at 2048x2048 needs about 26583 MB of VRAM, but the GPU has 24217 MB; sampling may fail or be slow"})
+	})
+	Field(22, "checkpoint_filenames", ArrayOf(String), "List of checkpoint filenames selected at job creation (empty means all checkpoints were included)", func() {
 		Example([]string{"psai4rt-v0.3.0-no-reg-step00004500.safetensors", "psai4rt-v0.3.0-no-reg-step00004750.safetensors"})
 	})
-	Attribute("error_message", String, "Error details if failed")
-	Attribute("created_at", String, "Creation timestamp (RFC3339)", func() {
+	Field(23, "append_new_checkpoints", Boolean, "Whether checkpoints of the training run that appear after creation are added to the job (append mode) instead of sampling only the checkpoints that existed at creation (snapshot mode)", func() {
+		Example(false)
+	})
+	Field(24, "output_format", String, "Format sample images are saved in", func() {
+		Enum(outputFormats...)
+		Example("webp")
+	})
+	Field(25, "output_quality", Int, "Encoder quality for webp and jpeg output (absent for png)", func() {
+		Example(90)
+	})
+	Field(26, "error_message", String, "Error details if failed", func() {
+		Example("ComfyUI is not reachable")
+	})
+	Field(27, "created_at", String, "Creation timestamp (RFC3339)", func() {
 		Example("2025-01-01T00:00:00Z")
 	})
-	Attribute("updated_at", String, "Last update timestamp (RFC3339)", func() {
+	Field(28, "updated_at", String, "Last update timestamp (RFC3339)", func() {
 		Example("2025-01-01T00:00:00Z")
 	})
-	Required("id", "training_run_name", "study_id", "study_name", "workflow_name", "status", "total_items", "completed_items", "failed_items", "pending_items", "checkpoint_filenames", "created_at", "updated_at")
+	Field(29, "workflow_overrides", ArrayOf(WorkflowOverride), "Workflows used instead of workflow_name for the checkpoints matching their pattern (omitted when the job has none)")
+	Field(30, "created_by_request_id", String, "ID of the API request that created the job, as returned in its X-Request-Id header (omitted for jobs created in the background, e.g. by the auto-sampler)", func() {
+		Example("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+	})
+	Field(31, "owner", String, "Name of the user that created the job; absent for unowned jobs", func() {
+		Example("alice")
+	})
+	Field(32, "deleted_at", String, "When the job was moved to the trash (RFC3339); absent for jobs not in the trash", func() {
+		Example("2025-01-02T00:00:00Z")
+	})
+	Field(33, "delete_data", Boolean, "Whether purging the trashed job also deletes its sample files; absent for jobs not in the trash")
+	Field(34, "notes", String, "Free-text notes recorded with the job (omitted when empty)", func() {
+		Example("lr=1e-5 experiment, bad data mix")
+	})
+	Field(35, "labels", MapOf(String, String), "Key/value labels of the job (omitted when it has none)", func() {
+		Example(map[string]string{"lr": "1e-5", "data": "mix-b"})
+	})
+	Required("id", "training_run_name", "study_id", "study_name", "study_version", "workflow_name", "input_image", "status", "total_items", "completed_items", "failed_items", "pending_items", "checkpoint_filenames", "append_new_checkpoints", "output_format", "created_at", "updated_at")
 })
 
 var FailedItemDetailResponse = Type("FailedItemDetailResponse", func() {
 	Description("Details of a failed checkpoint")
-	Attribute("checkpoint_filename", String, "Checkpoint filename that failed", func() {
+	Field(1, "checkpoint_filename", String, "Checkpoint filename that failed", func() {
 		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
 	})
-	Attribute("error_message", String, "Error message describing the failure", func() {
+	Field(2, "error_message", String, "Error message describing the failure", func() {
 		Example("[RuntimeError] VAEDecode: sizes must match")
 	})
-	Attribute("exception_type", String, "Python exception type from ComfyUI (e.g. RuntimeError)", func() {
+	Field(3, "exception_type", String, "Python exception type from ComfyUI (e.g. RuntimeError)", func() {
 		Example("RuntimeError")
 	})
-	Attribute("node_type", String, "ComfyUI node type that failed (e.g. VAEDecode)", func() {
+	Field(4, "node_type", String, "ComfyUI node type that failed (e.g. VAEDecode)", func() {
 		Example("VAEDecode")
 	})
-	Attribute("traceback", String, "Full Python stack trace from ComfyUI execution error", func() {
+	Field(5, "traceback", String, "Full Python stack trace from ComfyUI execution error", func() {
 		Example("Traceback (most recent call last):\n  File ...")
 	})
+	Field(6, "comfyui_log", String, "ComfyUI log lines captured when the item failed (requires comfyui.failure_log)", func() {
+		Example("!!! Exception during processing !!! sizes must match\nTraceback (most recent call last):\n  File ...")
+	})
 	Required("checkpoint_filename", "error_message")
 })
 
+var SampleJobComparisonResponse = Type("SampleJobComparisonResponse", func() {
+	Description("The items of two sample jobs paired up by their generation parameters")
+	Field(1, "a", SampleJobResponse, "The first sample job")
+	Field(2, "b", SampleJobResponse, "The second sample job")
+	Field(3, "pairs", ArrayOf(SampleJobItemPairResponse), "Items of both jobs with the same parameters, in the item order of the first job")
+	Field(4, "unmatched_a", ArrayOf(SampleJobItemResponse), "Items of the first job without a counterpart in the second")
+	Field(5, "unmatched_b", ArrayOf(SampleJobItemResponse), "Items of the second job without a counterpart in the first")
+	Required("a", "b", "pairs", "unmatched_a", "unmatched_b")
+})
+
+var SampleJobItemPairResponse = Type("SampleJobItemPairResponse", func() {
+	Description("An item of each of two sample jobs, generated with the same parameters")
+	Field(1, "a", SampleJobItemResponse, "The item of the first job")
+	Field(2, "b", SampleJobItemResponse, "The item of the second job")
+	Required("a", "b")
+})
+
+var SampleJobItemResponse = Type("SampleJobItemResponse", func() {
+	Description("A single work item of a sample job with its timing metrics")
+	Field(1, "id", String, "Item ID (UUID)", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Field(2, "checkpoint_filename", String, "Checkpoint filename", func() {
+		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Field(3, "prompt_name", String, "Prompt name", func() {
+		Example("forest")
+	})
+	Field(4, "steps", Int, "Sampling steps", func() {
+		Example(30)
+	})
+	Field(5, "cfg", Float64, "CFG scale", func() {
+		Example(7.5)
+	})
+	Field(6, "sampler_name", String, "Sampler name", func() {
+		Example("euler")
+	})
+	Field(7, "scheduler", String, "Scheduler name", func() {
+		Example("normal")
+	})
+	Field(8, "seed", Int64, "Seed", func() {
+		Example(420)
+	})
+	Field(9, "width", Int, "Image width in pixels", func() {
+		Example(1024)
+	})
+	Field(10, "height", Int, "Image height in pixels", func() {
+		Example(1024)
+	})
+	Field(11, "denoise", Float64, "Denoise strength of an img2img item (nullable)", func() {
+		Example(0.6)
+	})
+	Field(12, "wildcards", MapOf(String, String), "Values of the prompt wildcards the item's prompt text was rendered with (omitted when the prompt uses none)", func() {
+		Example(map[string]string{"color": "red"})
+	})
+	Field(13, "scores", MapOf(String, Float64), "Automatic quality scores of the item's image by name, from the configured scoring service (omitted when the image was not scored)", func() {
+		Example(map[string]float64{"aesthetic": 6.25, "clip_similarity": 0.31})
+	})
+	Field(14, "status", String, "Item status: pending, running, completed, failed, skipped", func() {
+		Example("completed")
+		Enum(jobItemStatuses...)
+	})
+	Field(15, "skip_reason", String, "Why the item was skipped (only for skipped items); error_message carries the details", func() {
+		Enum(jobItemSkipReasons...)
+		Example("checkpoint_not_found")
+	})
+	Field(16, "error_message", String, "Error details if failed or skipped", func() {
+		Example("[RuntimeError] VAEDecode: sizes must match")
+	})
+	Field(17, "comfyui_log", String, "ComfyUI log lines captured when the item failed (requires comfyui.failure_log)", func() {
+		Example("!!! Exception during processing !!! sizes must match\nTraceback (most recent call last):\n  File ...")
+	})
+	Field(18, "started_at", String, "Timestamp when the item was submitted to ComfyUI (RFC3339, nullable)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Field(19, "completed_at", String, "Timestamp when the item finished (RFC3339, nullable)", func() {
+		Example("2025-01-01T00:00:12Z")
+	})
+	Field(20, "image_path", String, "Path of the item's image relative to the sample directory, as served by GET /api/images/{filepath}; absent until the image is saved", func() {
+		Example("Sweep/psai4rt-v0.3.0-no-reg-step00004500.safetensors/prompt=forest&seed=42_00001_.png")
+	})
+	Field(21, "duration_ms", Int64, "Wall-clock execution time in milliseconds (nullable)", func() {
+		Example(12345)
+	})
+	Field(22, "workflow_name", String, "Workflow override the item is sampled with (omitted when it uses the job's workflow)", func() {
+		Example("flux-dev.json")
+	})
+	Field(23, "resolution", String, "Resolution of the study's resolution sweep the item is sampled at, as WxH (omitted when the study sweeps none for the item's prompt)", func() {
+		Example("832x1216")
+	})
+	Required("id", "checkpoint_filename", "prompt_name", "steps", "cfg", "sampler_name", "scheduler", "seed", "width", "height", "status")
+})
+
 var SampleJobDetailResponse = Type("SampleJobDetailResponse", func() {
 	Description("A sample job with progress metrics")
-	Attribute("job", SampleJobResponse, "Job metadata")
-	Attribute("progress", JobProgressResponse, "Progress metrics")
+	Field(1, "job", SampleJobResponse, "Job metadata")
+	Field(2, "progress", JobProgressResponse, "Progress metrics")
 	Required("job", "progress")
 })
 
 var JobProgressResponse = Type("JobProgressResponse", func() {
 	Description("Job progress metrics")
-	Attribute("checkpoints_completed", Int, "Fully completed checkpoints", func() {
+	Field(1, "checkpoints_completed", Int, "Fully completed checkpoints", func() {
 		Example(2)
 	})
-	Attribute("total_checkpoints", Int, "Total checkpoints in job", func() {
+	Field(2, "total_checkpoints", Int, "Total checkpoints in job", func() {
 		Example(5)
 	})
-	Attribute("current_checkpoint", String, "Filename of checkpoint currently being processed", func() {
+	Field(3, "current_checkpoint", String, "Filename of checkpoint currently being processed", func() {
 		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
 	})
-	Attribute("current_checkpoint_progress", Int, "Completed items in current checkpoint", func() {
+	Field(4, "current_checkpoint_progress", Int, "Completed items in current checkpoint", func() {
 		Example(30)
 	})
-	Attribute("current_checkpoint_total", Int, "Total items in current checkpoint", func() {
+	Field(5, "current_checkpoint_total", Int, "Total items in current checkpoint", func() {
 		Example(108)
 	})
-	Attribute("estimated_completion_time", String, "Estimated completion timestamp (RFC3339, nullable)")
+	Field(6, "estimated_completion_time", String, "Estimated completion timestamp (RFC3339, nullable)", func() {
+		Example("2025-01-01T01:30:00Z")
+	})
 	Required("checkpoints_completed", "total_checkpoints")
 })
 
 var CreateSampleJobPayload = Type("CreateSampleJobPayload", func() {
-	Description("Payload for creating a new sample job. Workflow template, VAE, text encoder, and shift are read from the study definition.")
-	Attribute("training_run_name", String, "Training run identifier", func() {
+	Description("Payload for creating a new sample job. The workflow template is read from the study definition. VAE, text encoder, and shift default to the study's values, then to the workflow's cs_default values, and may be overridden per job.")
+	Field(1, "training_run_name", String, "Training run identifier", func() {
 		Example("qwen/psai4rt-v0.3.0-no-reg")
 		MinLength(1)
 	})
-	Attribute("study_id", String, "Study ID (UUID)", func() {
+	Field(2, "study_id", String, "Study ID (UUID)", func() {
 		Example("550e8400-e29b-41d4-a716-446655440000")
 	})
-	Attribute("checkpoint_filenames", ArrayOf(String), "Optional list of checkpoint filenames to include; when omitted all checkpoints are included", func() {
+	Field(3, "checkpoint_filenames", ArrayOf(String), "Optional list of checkpoint filenames to include; when omitted all checkpoints are included", func() {
 		Example([]string{"psai4rt-v0.3.0-no-reg-step00004500.safetensors"})
 	})
-	Attribute("clear_existing", Boolean, "When true, delete existing sample directories for selected checkpoints before creating job items", func() {
+	Field(4, "min_step", Int, "Only include checkpoints whose step number is at least this", func() {
+		Minimum(0)
+		Example(10000)
+	})
+	Field(5, "max_step", Int, "Only include checkpoints whose step number is at most this", func() {
+		Minimum(0)
+		Example(50000)
+	})
+	Field(6, "every_nth", Int, "Only include every nth checkpoint within the step range, in step order, starting with the first", func() {
+		Minimum(1)
+		Example(4)
+	})
+	Field(7, "clear_existing", Boolean, "When true, delete existing sample directories for selected checkpoints before creating job items", func() {
 		Default(false)
 	})
-	Attribute("missing_only", Boolean, "When true, only generate samples that are missing on disk (skips items whose output file already exists)", func() {
+	Field(8, "missing_only", Boolean, "When true, only generate samples that are missing on disk (skips items whose output file already exists)", func() {
 		Default(false)
 	})
+	Field(9, "skip_existing", Boolean, "When true, items whose output file already exists on disk are created as skipped, so only the missing combinations are generated while the job still lists every combination", func() {
+		Default(false)
+	})
+	Field(10, "append_new_checkpoints", Boolean, "When true, checkpoints of the training run that appear later are added to the job and their items queued automatically; when false, the job samples the checkpoints that exist now", func() {
+		Default(false)
+	})
+	Field(11, "output_format", String, "Format to save sample images in. ComfyUI output is transcoded server-side for webp and jpeg.", func() {
+		Enum(outputFormats...)
+		Default("png")
+	})
+	Field(12, "output_quality", Int, "Encoder quality for webp and jpeg output (default 90); ignored for png", func() {
+		Minimum(1)
+		Maximum(100)
+		Example(90)
+	})
+	Field(13, "vae", String, "VAE override (ComfyUI path); defaults to the study's VAE, then the workflow default", func() {
+		Example("ae.safetensors")
+	})
+	Field(14, "clip", String, "Text encoder override (ComfyUI path); defaults to the study's text encoder, then the workflow default", func() {
+		Example("clip_l.safetensors")
+	})
+	Field(15, "shift", Float64, "AuraFlow shift override; defaults to the study's shift, then the workflow default", func() {
+		Example(3.1)
+	})
+	Field(16, "controlnet_model", String, "ControlNet model (ComfyUI path) for the workflow's controlnet_loader node; defaults to the node's own model", func() {
+		Example("control_canny.safetensors")
+	})
+	Field(17, "controlnet_strength", Float64, "Strength for the workflow's controlnet_apply node; defaults to the node's own strength", func() {
+		Minimum(0)
+		Maximum(10)
+		Example(0.8)
+	})
+	Field(18, "controlnet_image", String, "Server path of the conditioning image, uploaded to ComfyUI and wired into the LoadImage node linked to the controlnet_apply node's image input; defaults to the node's own image", func() {
+		Example("/data/assets/image/edges.png")
+	})
+	Field(19, "workflow_overrides", ArrayOf(WorkflowOverride), "Workflows to use instead of the study's for the checkpoints matching their pattern; the first matching override applies, and checkpoints matching none use the study's workflow")
+	Field(20, "notes", String, "Free-text notes to record with the job; ignored by preview", func() {
+		Example("lr=1e-5 experiment, bad data mix")
+		MaxLength(10000)
+	})
+	Field(21, "labels", MapOf(String, String), "Key/value labels to record with the job, which the job list can be filtered by; ignored by preview", func() {
+		Example(map[string]string{"lr": "1e-5", "data": "mix-b"})
+	})
 	Required("training_run_name", "study_id")
 })
+
+var WorkflowOverride = Type("WorkflowOverride", func() {
+	Description("A workflow used instead of the study's for the checkpoints whose filename matches a glob pattern")
+	Field(1, "pattern", String, "Glob pattern (path/filepath.Match syntax) matched against checkpoint filenames", func() {
+		Example("*-flux-*.safetensors")
+		MinLength(1)
+	})
+	Field(2, "workflow", String, "Workflow template filename", func() {
+		Example("flux-dev.json")
+		MinLength(1)
+	})
+	Required("pattern", "workflow")
+})
+
+var CreateSampleJobWithStudyPayload = Type("CreateSampleJobWithStudyPayload", func() {
+	Description("Payload for creating a study and a sample job of it. The job uses the new study's workflow template, VAE, text encoder, and shift.")
+	Field(1, "study", CreateStudyPayload, "The study to create")
+	Field(2, "training_run_name", String, "Training run identifier", func() {
+		Example("qwen/psai4rt-v0.3.0-no-reg")
+		MinLength(1)
+	})
+	Field(3, "checkpoint_filenames", ArrayOf(String), "Optional list of checkpoint filenames to include; when omitted all checkpoints are included", func() {
+		Example([]string{"psai4rt-v0.3.0-no-reg-step00004500.safetensors"})
+	})
+	Field(4, "clear_existing", Boolean, "When true, delete existing sample directories for selected checkpoints before creating job items", func() {
+		Default(false)
+	})
+	Field(5, "missing_only", Boolean, "When true, only generate samples that are missing on disk (skips items whose output file already exists)", func() {
+		Default(false)
+	})
+	Field(6, "skip_existing", Boolean, "When true, items whose output file already exists on disk are created as skipped, so only the missing combinations are generated while the job still lists every combination", func() {
+		Default(false)
+	})
+	Field(7, "append_new_checkpoints", Boolean, "When true, checkpoints of the training run that appear later are added to the job and their items queued automatically; when false, the job samples the checkpoints that exist now", func() {
+		Default(false)
+	})
+	Field(8, "output_format", String, "Format to save sample images in. ComfyUI output is transcoded server-side for webp and jpeg.", func() {
+		Enum(outputFormats...)
+		Default("png")
+	})
+	Field(9, "output_quality", Int, "Encoder quality for webp and jpeg output (default 90); ignored for png", func() {
+		Minimum(1)
+		Maximum(100)
+		Example(90)
+	})
+	Required("study", "training_run_name")
+})
+
+var SampleJobWithStudyResponse = Type("SampleJobWithStudyResponse", func() {
+	Description("A study and the sample job created with it")
+	Field(1, "study", StudyResponse, "The created study")
+	Field(2, "job", SampleJobResponse, "The created sample job")
+	Required("study", "job")
+})
+
+var BulkCreateSampleJobsPayload = Type("BulkCreateSampleJobsPayload", func() {
+	Description("Payload for creating one sample job per study. Options apply to every created job.")
+	Field(1, "training_run_name", String, "Training run identifier", func() {
+		Example("qwen/psai4rt-v0.3.0-no-reg")
+		MinLength(1)
+	})
+	Field(2, "study_ids", ArrayOf(String), "Study IDs in the order their jobs should run", func() {
+		Example([]string{"550e8400-e29b-41d4-a716-446655440000", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"})
+		MinLength(1)
+		MaxLength(50)
+	})
+	Field(3, "checkpoint_filenames", ArrayOf(String), "Optional list of checkpoint filenames to include; when omitted all checkpoints are included", func() {
+		Example([]string{"psai4rt-v0.3.0-no-reg-step00004500.safetensors"})
+	})
+	Field(4, "clear_existing", Boolean, "When true, delete existing sample directories for selected checkpoints before creating job items", func() {
+		Default(false)
+	})
+	Field(5, "missing_only", Boolean, "When true, only generate samples that are missing on disk (skips items whose output file already exists)", func() {
+		Default(false)
+	})
+	Field(6, "skip_existing", Boolean, "When true, items whose output file already exists on disk are created as skipped, so only the missing combinations are generated while the job still lists every combination", func() {
+		Default(false)
+	})
+	Field(7, "output_format", String, "Format to save sample images in. ComfyUI output is transcoded server-side for webp and jpeg.", func() {
+		Enum(outputFormats...)
+		Default("png")
+	})
+	Field(8, "output_quality", Int, "Encoder quality for webp and jpeg output (default 90); ignored for png", func() {
+		Minimum(1)
+		Maximum(100)
+		Example(90)
+	})
+	Required("training_run_name", "study_ids")
+})
+
+var SampleJobPreviewResponse = Type("SampleJobPreviewResponse", func() {
+	Description("The sample job a create request would produce")
+	Field(1, "total_items", Int, "Work items the job would have, including items of unmatched checkpoints", func() {
+		Example(120)
+	})
+	Field(2, "runnable_items", Int, "Work items that would be sampled; items of unmatched checkpoints and existing outputs are skipped", func() {
+		Example(110)
+	})
+	Field(3, "existing_items", Int, "Work items that would be skipped because their output already exists (with skip_existing)", func() {
+		Example(0)
+	})
+	Field(4, "checkpoints", ArrayOf(CheckpointPreviewResponse), "Selected checkpoints with their item counts, in sampling order")
+	Field(5, "unmatched_checkpoints", ArrayOf(String), "Filenames of selected checkpoints that could not be matched to a ComfyUI model path", func() {
+		Example([]string{"my-lora-step00001000.safetensors"})
+	})
+	Field(6, "estimated_duration_seconds", Int64, "Estimated time to run the runnable items, from the average duration of recently completed items (absent when there is no timing history)", func() {
+		Example(1320)
+	})
+	Field(7, "warnings", ArrayOf(String), "Warnings the created job would report, e.g. when its resolution likely does not fit in GPU memory")
+	Required("total_items", "runnable_items", "existing_items", "checkpoints", "unmatched_checkpoints")
+})
+
+var CheckpointPreviewResponse = Type("CheckpointPreviewResponse", func() {
+	Description("A checkpoint's share of a previewed sample job")
+	Field(1, "filename", String, "Checkpoint filename", func() {
+		Example("my-lora-step00002000.safetensors")
+	})
+	Field(2, "items", Int, "Work items for this checkpoint; zero when only missing items were requested and all exist", func() {
+		Example(10)
+	})
+	Field(3, "path_matched", Boolean, "Whether the checkpoint was matched to a ComfyUI model path; items of unmatched checkpoints are skipped", func() {
+		Example(true)
+	})
+	Required("filename", "items", "path_matched")
+})
+
+var BulkCreateSampleJobsResponse = Type("BulkCreateSampleJobsResponse", func() {
+	Description("Jobs created by a bulk request with aggregate estimates")
+	Field(1, "job_ids", ArrayOf(String), "IDs of the created jobs, in run order", func() {
+		Example([]string{"550e8400-e29b-41d4-a716-446655440001", "550e8400-e29b-41d4-a716-446655440002"})
+	})
+	Field(2, "jobs", ArrayOf(SampleJobResponse), "The created jobs, in run order")
+	Field(3, "total_items", Int, "Total work items across all created jobs", func() {
+		Example(480)
+	})
+	Field(4, "estimated_duration_seconds", Int64, "Estimated time to run all created jobs, from the average duration of recently completed items (absent when there is no timing history)", func() {
+		Example(5760)
+	})
+	Required("job_ids", "jobs", "total_items")
+})
@@ -0,0 +1,95 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+var _ = Service("storage", func() {
+	Description("Disk usage of the sample directory and the sample retention policy")
+
+	Method("usage", func() {
+		Description("Report the disk usage of the sample directory per training run and per checkpoint. Thumbnails and sidecar files count towards the checkpoint they belong to.")
+		Result(StorageUsageResponse)
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/storage/usage")
+			Response(StatusOK)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("apply_retention", func() {
+		Description("Apply the configured retention policy once. In a dry run (the default) the samples that would be deleted are listed and nothing is removed.")
+		Payload(func() {
+			Attribute("dry_run", Boolean, "When true, only report what would be deleted", func() {
+				Default(true)
+			})
+		})
+		Result(RetentionResultResponse)
+		Error("service_unavailable", ErrorResult, "No retention policy is configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/storage/retention")
+			Param("dry_run")
+			Response(StatusOK)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+})
+
+var StorageUsageResponse = Type("StorageUsageResponse", func() {
+	Description("Disk usage of the sample directory")
+	Attribute("total_bytes", Int64, "Total size of all files in the sample directory", func() {
+		Example(1073741824)
+	})
+	Attribute("file_count", Int, "Number of files in the sample directory", func() {
+		Example(2048)
+	})
+	Attribute("training_runs", ArrayOf(TrainingRunStorageUsageResponse), "Usage per top-level directory, ordered by name")
+	Required("total_bytes", "file_count", "training_runs")
+})
+
+var TrainingRunStorageUsageResponse = Type("TrainingRunStorageUsageResponse", func() {
+	Description("Disk usage of one training run directory")
+	Attribute("directory", String, "Training run directory name (the sanitized training run name)", func() {
+		Example("my-model")
+	})
+	Attribute("bytes", Int64, "Total size of the directory, including files outside checkpoint directories")
+	Attribute("file_count", Int, "Number of files in the directory")
+	Attribute("checkpoints", ArrayOf(CheckpointStorageUsageResponse), "Usage per checkpoint, summed across studies and ordered by filename")
+	Required("directory", "bytes", "file_count", "checkpoints")
+})
+
+var CheckpointStorageUsageResponse = Type("CheckpointStorageUsageResponse", func() {
+	Description("Disk usage of the samples of one checkpoint")
+	Attribute("checkpoint_filename", String, "Checkpoint filename", func() {
+		Example("my-model-step00004500.safetensors")
+	})
+	Attribute("bytes", Int64, "Total size of the checkpoint's samples")
+	Attribute("file_count", Int, "Number of files")
+	Attribute("study_count", Int, "Number of studies the checkpoint has samples in")
+	Required("checkpoint_filename", "bytes", "file_count", "study_count")
+})
+
+var RetentionResultResponse = Type("RetentionResultResponse", func() {
+	Description("Outcome of applying the retention policy")
+	Attribute("dry_run", Boolean, "Whether this was a dry run in which nothing was deleted")
+	Attribute("deleted", ArrayOf(RetentionCandidateResponse), "Checkpoint sample directories deleted, or that would be deleted in a dry run")
+	Attribute("freed_bytes", Int64, "Total size of the deleted directories")
+	Attribute("errors", ArrayOf(String), "Directories that could not be deleted, with the reason")
+	Required("dry_run", "deleted", "freed_bytes", "errors")
+})
+
+var RetentionCandidateResponse = Type("RetentionCandidateResponse", func() {
+	Description("A checkpoint sample directory selected by the retention policy")
+	Attribute("path", String, "Directory path relative to the sample directory", func() {
+		Example("my-model/forest/my-model-step00001000.safetensors")
+	})
+	Attribute("training_run", String, "Training run directory name")
+	Attribute("study_name", String, "Study directory name")
+	Attribute("checkpoint_filename", String, "Checkpoint filename")
+	Attribute("bytes", Int64, "Size of the directory")
+	Attribute("file_count", Int, "Number of files in the directory")
+	Required("path", "training_run", "study_name", "checkpoint_filename", "bytes", "file_count")
+})
@@ -9,13 +9,24 @@ var _ = Service("studies", func() {
 
 	Method("list", func() {
 		Description("List all saved studies")
+		Payload(func() {
+			ownerAttribute(1)
+		})
 		Result(ArrayOf(StudyResponse))
+		Error("forbidden", ErrorResult, "Listing the studies of another user requires an admin user")
 		Error("internal_error", ErrorResult, "Internal server error")
 		HTTP(func() {
 			GET("/api/studies")
+			Param("owner")
 			Response(StatusOK)
+			Response("forbidden", StatusForbidden)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("create", func() {
@@ -30,6 +41,11 @@ var _ = Service("studies", func() {
 			Response("invalid_payload", StatusBadRequest)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("update", func() {
@@ -38,14 +54,23 @@ var _ = Service("studies", func() {
 		Result(StudyResponse)
 		Error("not_found", ErrorResult, "Study not found")
 		Error("invalid_payload", ErrorResult, "Invalid study data")
+		Error("forbidden", ErrorResult, "Study owned by another user")
 		Error("internal_error", ErrorResult, "Internal server error")
 		HTTP(func() {
 			PUT("/api/studies/{id}")
 			Response(StatusOK)
 			Response("not_found", StatusNotFound)
 			Response("invalid_payload", StatusBadRequest)
+			Response("forbidden", StatusForbidden)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("fork", func() {
@@ -62,12 +87,196 @@ var _ = Service("studies", func() {
 			Response("invalid_payload", StatusBadRequest)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("duplicate", func() {
+		Description("Duplicate a study: create a new study with all of its settings under a new name. The copy starts at version 1.")
+		Payload(func() {
+			Field(1, "id", String, "ID of the study to duplicate", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Field(2, "name", String, "Name of the copy; defaults to the study's name followed by \"copy\" (\"copy 2\", ... when taken)", func() {
+				Example("My Study copy")
+			})
+			Required("id")
+		})
+		Result(StudyResponse)
+		Error("not_found", ErrorResult, "Study not found")
+		Error("invalid_payload", ErrorResult, "Invalid study name")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/studies/{id}/duplicate")
+			Response(StatusCreated)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("versions", func() {
+		Description("List the version history of a study, oldest first. Editing a study that a sample job was created from archives its configuration as a version; the last entry is the current version.")
+		Payload(func() {
+			Field(1, "id", String, "Study ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("id")
+		})
+		Result(ArrayOf(StudyVersionResponse))
+		Error("not_found", ErrorResult, "Study not found")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/studies/{id}/versions")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("show_version", func() {
+		Description("Get a study as it was at a version, e.g. the study_version of a sample job")
+		Payload(func() {
+			Field(1, "id", String, "Study ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Field(2, "version", Int, "Study version", func() {
+				Minimum(1)
+				Example(2)
+			})
+			Required("id", "version")
+		})
+		Result(StudyResponse)
+		Error("not_found", ErrorResult, "Study or version not found")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/studies/{id}/versions/{version}")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("import", func() {
+		Description("Import seeds, steps, CFGs, and sampler/scheduler pairs from a CSV or JSON file into a study. Lists present in the file replace the study's lists; other settings are kept. When name is given, a new study with that name is created from the study instead of updating it.")
+		Payload(func() {
+			Field(1, "id", String, "Study ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Field(2, "format", String, "Format of data", func() {
+				Enum("csv", "json")
+				Example("csv")
+			})
+			Field(3, "data", String, "File contents. CSV has a header row naming any of the columns seeds, steps, cfgs, sampler, scheduler; JSON is an array of objects keyed by the same column names.", func() {
+				Example("seeds,steps,cfgs,sampler,scheduler\n420,20,3.5,euler,simple\n421,30,7,dpmpp_2m,karras\n422,,,,\n")
+			})
+			Field(4, "name", String, "Name of a new study to create instead of updating the study", func() {
+				Example("My Study (imported seeds)")
+			})
+			Required("id", "format", "data")
+		})
+		Result(StudyResponse)
+		Error("not_found", ErrorResult, "Study not found")
+		Error("invalid_payload", ErrorResult, "Invalid import file or resulting study; the message names the offending row")
+		Error("forbidden", ErrorResult, "Study owned by another user")
+		Error("internal_error", ErrorResult, "Internal server error")
+		// Not exposed over gRPC: Goa's gRPC client calls the method Import_
+		// because import is a Go keyword, which protoc-gen-go-grpc does not do.
+		HTTP(func() {
+			POST("/api/studies/{id}/import")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("forbidden", StatusForbidden)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("export", func() {
+		Description("Export a study, or all studies when no ID is given, as a portable JSON document that import_studies accepts")
+		Payload(func() {
+			Field(1, "id", String, "ID of the study to export; all studies when omitted", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+		})
+		Result(StudyExportDocument)
+		Error("not_found", ErrorResult, "Study not found")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/studies/export")
+			Param("id")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("import_studies", func() {
+		Description("Import the studies of an exported document. A study is matched to an existing study by name; on_conflict decides whether a match is renamed (\"<name> 2\", ...), overwritten like an edit, or skipped. Every study is validated before any is imported.")
+		Payload(func() {
+			Field(1, "format_version", Int, "Format version of the document; newer versions than the server's are rejected", func() {
+				Minimum(1)
+				Example(1)
+			})
+			Field(2, "exported_at", String, "Export timestamp (RFC3339); informational", func() {
+				Example("2025-01-01T00:00:00Z")
+			})
+			Field(3, "studies", ArrayOf(CreateStudyPayload), "Studies to import")
+			Field(4, "on_conflict", String, "What to do with a study whose name is taken", func() {
+				Enum(importConflictPolicies...)
+				Default("rename")
+			})
+			Required("format_version", "studies")
+		})
+		Result(ArrayOf(StudyImportResult))
+		Error("invalid_payload", ErrorResult, "Invalid document; the message names the offending study")
+		Error("forbidden", ErrorResult, "A study to overwrite is owned by another user")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/studies/import")
+			Param("on_conflict")
+			Response(StatusOK)
+			Response("invalid_payload", StatusBadRequest)
+			Response("forbidden", StatusForbidden)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("has_samples", func() {
 		Description("Check whether a study has generated samples on disk")
 		Payload(func() {
-			Attribute("id", String, "Study ID", func() {
+			Field(1, "id", String, "Study ID", func() {
 				Example("550e8400-e29b-41d4-a716-446655440000")
 			})
 			Required("id")
@@ -81,34 +290,47 @@ var _ = Service("studies", func() {
 			Response("not_found", StatusNotFound)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("delete", func() {
 		Description("Delete a study. When delete_data is true, also removes the study's sample output directory from disk.")
 		Payload(func() {
-			Attribute("id", String, "Study ID", func() {
+			Field(1, "id", String, "Study ID", func() {
 				Example("550e8400-e29b-41d4-a716-446655440000")
 			})
-			Attribute("delete_data", Boolean, "When true, also deletes the study's sample output directory from disk", func() {
+			Field(2, "delete_data", Boolean, "When true, also deletes the study's sample output directory from disk", func() {
 				Default(false)
 			})
 			Required("id")
 		})
 		Error("not_found", ErrorResult, "Study not found")
+		Error("forbidden", ErrorResult, "Study owned by another user")
 		Error("internal_error", ErrorResult, "Internal server error")
 		HTTP(func() {
 			DELETE("/api/studies/{id}")
 			Param("delete_data")
 			Response(StatusNoContent)
 			Response("not_found", StatusNotFound)
+			Response("forbidden", StatusForbidden)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("forbidden", CodePermissionDenied)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("affected_runs", func() {
 		Description("Get training runs that have generated samples for a specific study. Returns a list of training runs with their checkpoint-level sample details.")
 		Payload(func() {
-			Attribute("id", String, "Study ID", func() {
+			Field(1, "id", String, "Study ID", func() {
 				Example("550e8400-e29b-41d4-a716-446655440000")
 			})
 			Required("id")
@@ -122,12 +344,17 @@ var _ = Service("studies", func() {
 			Response("not_found", StatusNotFound)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("internal_error", CodeInternal)
+		})
 	})
 
 	Method("availability", func() {
 		Description("Get per-study sample availability for a training run. For each study, returns whether it has samples matching the training run's checkpoints.")
 		Payload(func() {
-			Attribute("training_run_id", Int, "Training run index (zero-based) to check availability against", func() {
+			Field(1, "training_run_id", Int, "Training run index (zero-based) to check availability against", func() {
 				Minimum(0)
 			})
 			Required("training_run_id")
@@ -142,268 +369,430 @@ var _ = Service("studies", func() {
 			Response("not_found", StatusNotFound)
 			Response("internal_error", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("internal_error", CodeInternal)
+		})
 	})
 })
 
 var StudyResponse = Type("StudyResponse", func() {
 	Description("A saved study")
-	Attribute("id", String, "Study ID (UUID)", func() {
+	Field(1, "id", String, "Study ID (UUID)", func() {
 		Example("550e8400-e29b-41d4-a716-446655440000")
 	})
-	Attribute("name", String, "Study display name", func() {
+	Field(2, "name", String, "Study display name", func() {
 		Example("My Study")
 	})
-	Attribute("prompt_prefix", String, "Text prepended to each prompt at generation time", func() {
+	Field(3, "version", Int, "Study version; incremented when the study is edited after a sample job was created from it", func() {
+		Example(1)
+	})
+	Field(4, "prompt_prefix", String, "Text prepended to each prompt at generation time", func() {
 		Example("photo of a person, ")
 	})
-	Attribute("prompts", ArrayOf(NamedPrompt), "List of named prompts")
-	Attribute("negative_prompt", String, "Negative prompt text", func() {
+	Field(5, "prompts", ArrayOf(NamedPrompt), "List of named prompts")
+	Field(6, "negative_prompt", String, "Negative prompt text", func() {
 		Example("low quality, blurry")
 	})
-	Attribute("steps", ArrayOf(Int), "Step counts to iterate", func() {
+	Field(7, "steps", ArrayOf(Int), "Step counts to iterate", func() {
 		Example([]int{1, 4, 8})
 	})
-	Attribute("cfgs", ArrayOf(Float64), "CFG scale values to iterate", func() {
+	Field(8, "cfgs", ArrayOf(Float64), "CFG scale values to iterate", func() {
 		Example([]float64{1.0, 3.0, 7.0})
 	})
-	Attribute("sampler_scheduler_pairs", ArrayOf(SamplerSchedulerPair), "Sampler/scheduler pair combinations")
-	Attribute("seeds", ArrayOf(Int64), "Seed values to iterate", func() {
+	Field(9, "sampler_scheduler_pairs", ArrayOf(SamplerSchedulerPair), "Sampler/scheduler pair combinations")
+	Field(10, "seeds", ArrayOf(Int64), "Seed values to iterate (explicit seed mode)", func() {
 		Example([]int64{420, 421, 422})
 	})
-	Attribute("width", Int, "Image width in pixels", func() {
+	Field(11, "seed_mode", String, "Where item seeds come from: explicit, random_per_item, or random_per_checkpoint", func() {
+		Enum(seedModes...)
+	})
+	Field(12, "random_seed_count", Int, "Seeds drawn in the random seed modes; 0 in the explicit mode", func() {
+		Example(4)
+	})
+	Field(13, "width", Int, "Image width in pixels", func() {
 		Example(1344)
 	})
-	Attribute("height", Int, "Image height in pixels", func() {
+	Field(14, "height", Int, "Image height in pixels", func() {
 		Example(1344)
 	})
-	Attribute("workflow_template", String, "ComfyUI workflow template filename (optional)", func() {
+	Field(15, "workflow_template", String, "ComfyUI workflow template filename (optional)", func() {
 		Example("qwen-image.json")
 		Default("")
 	})
-	Attribute("vae", String, "ComfyUI VAE model path (optional)", func() {
+	Field(16, "vae", String, "ComfyUI VAE model path (optional)", func() {
 		Example("ae.safetensors")
 		Default("")
 	})
-	Attribute("text_encoder", String, "ComfyUI CLIP/text encoder model path (optional)", func() {
+	Field(17, "text_encoder", String, "ComfyUI CLIP/text encoder model path (optional)", func() {
 		Example("clip_l.safetensors")
 		Default("")
 	})
-	Attribute("shift", Float64, "AuraFlow shift value (optional, nullable)")
-	Attribute("images_per_checkpoint", Int, "Computed: total images per checkpoint", func() {
+	Field(18, "shift", Float64, "AuraFlow shift value (optional, nullable)")
+	Field(19, "input_image", String, "Server path of the image img2img samples start from; it is uploaded to ComfyUI and wired into the load_image node (optional)", func() {
+		Example("/data/assets/image/reference.png")
+		Default("")
+	})
+	Field(20, "denoise_strengths", ArrayOf(Float64), "Denoise strengths to iterate when input_image is set, each in (0, 1]; when empty the workflow's denoise strength is used (optional)", func() {
+		Example([]float64{0.4, 0.6, 0.8})
+	})
+	Field(21, "wildcards", ArrayOf(Wildcard), "Template variables of the prompt texts; each prompt is sampled once per combination of the values of the wildcards its text uses as {name} placeholders (optional)")
+	Field(25, "resolutions", ArrayOf(Resolution), "Image sizes to iterate instead of width x height for the prompts that do not set their own size; each item records its resolution, which becomes a filename key (optional)")
+	Field(22, "images_per_checkpoint", Int, "Computed: total images per checkpoint", func() {
 		Example(54)
 	})
-	Attribute("created_at", String, "Creation timestamp (RFC3339)", func() {
+	Field(26, "owner", String, "Name of the user that created the study; absent for unowned studies", func() {
+		Example("alice")
+	})
+	Field(23, "created_at", String, "Creation timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Field(24, "updated_at", String, "Last update timestamp (RFC3339)", func() {
 		Example("2025-01-01T00:00:00Z")
 	})
-	Attribute("updated_at", String, "Last update timestamp (RFC3339)", func() {
+	Required("id", "name", "version", "prompt_prefix", "prompts", "negative_prompt", "steps", "cfgs", "sampler_scheduler_pairs", "seeds", "seed_mode", "random_seed_count", "width", "height", "workflow_template", "vae", "text_encoder", "input_image", "denoise_strengths", "wildcards", "resolutions", "images_per_checkpoint", "created_at", "updated_at")
+})
+
+var StudyVersionResponse = Type("StudyVersionResponse", func() {
+	Description("A version of a study")
+	Field(1, "version", Int, "Study version", func() {
+		Example(1)
+	})
+	Field(2, "current", Boolean, "Whether this is the study's current version")
+	Field(3, "archived_at", String, "When the version was archived by an edit (RFC3339); absent for the current version", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Field(4, "study", StudyResponse, "The study as it was at this version")
+	Required("version", "current", "study")
+})
+
+var StudyExportDocument = Type("StudyExportDocument", func() {
+	Description("Portable JSON document of exported studies")
+	Field(1, "format_version", Int, "Format version of the document", func() {
+		Example(1)
+	})
+	Field(2, "exported_at", String, "Export timestamp (RFC3339)", func() {
 		Example("2025-01-01T00:00:00Z")
 	})
-	Required("id", "name", "prompt_prefix", "prompts", "negative_prompt", "steps", "cfgs", "sampler_scheduler_pairs", "seeds", "width", "height", "workflow_template", "vae", "text_encoder", "images_per_checkpoint", "created_at", "updated_at")
+	Field(3, "studies", ArrayOf(CreateStudyPayload), "Exported studies, without IDs, versions, or timestamps")
+	Required("format_version", "exported_at", "studies")
+})
+
+var StudyImportResult = Type("StudyImportResult", func() {
+	Description("Outcome of importing one study of a document")
+	Field(1, "source_name", String, "Name of the study in the document", func() {
+		Example("My Study")
+	})
+	Field(2, "id", String, "ID of the created or overwritten study, or of the existing study when skipped", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Field(3, "name", String, "Name of the study now; differs from source_name when renamed", func() {
+		Example("My Study 2")
+	})
+	Field(4, "action", String, "What importing the study did", func() {
+		Enum(importActions...)
+	})
+	Required("source_name", "id", "name", "action")
 })
 
 var CreateStudyPayload = Type("CreateStudyPayload", func() {
 	Description("Payload for creating a new study")
-	Attribute("name", String, "Study display name", func() {
+	Field(1, "name", String, "Study display name", func() {
 		Example("My Study")
 		MinLength(1)
 	})
-	Attribute("prompt_prefix", String, "Text prepended to each prompt at generation time", func() {
+	Field(2, "prompt_prefix", String, "Text prepended to each prompt at generation time", func() {
 		Example("photo of a person, ")
 		Default("")
 	})
-	Attribute("prompts", ArrayOf(NamedPrompt), "List of named prompts", func() {
+	Field(3, "prompts", ArrayOf(NamedPrompt), "List of named prompts", func() {
 		MinLength(1)
 	})
-	Attribute("negative_prompt", String, "Negative prompt text", func() {
+	Field(4, "negative_prompt", String, "Negative prompt text", func() {
 		Example("low quality, blurry")
 		Default("")
 	})
-	Attribute("steps", ArrayOf(Int), "Step counts to iterate", func() {
+	Field(5, "steps", ArrayOf(Int), "Step counts to iterate", func() {
 		Example([]int{1, 4, 8})
 		MinLength(1)
 	})
-	Attribute("cfgs", ArrayOf(Float64), "CFG scale values to iterate", func() {
+	Field(6, "cfgs", ArrayOf(Float64), "CFG scale values to iterate", func() {
 		Example([]float64{1.0, 3.0, 7.0})
 		MinLength(1)
 	})
-	Attribute("sampler_scheduler_pairs", ArrayOf(SamplerSchedulerPair), "Sampler/scheduler pair combinations", func() {
+	Field(7, "sampler_scheduler_pairs", ArrayOf(SamplerSchedulerPair), "Sampler/scheduler pair combinations", func() {
 		MinLength(1)
 	})
-	Attribute("seeds", ArrayOf(Int64), "Seed values to iterate", func() {
+	Field(8, "seeds", ArrayOf(Int64), "Seed values to iterate; required in the explicit seed mode, empty in the random ones", func() {
 		Example([]int64{420, 421, 422})
-		MinLength(1)
 	})
-	Attribute("width", Int, "Image width in pixels", func() {
+	Field(9, "seed_mode", String, "Where item seeds come from: explicit iterates seeds; random_per_item draws a new seed for each of random_seed_count items per combination; random_per_checkpoint draws random_seed_count seeds per checkpoint, shared by all its combinations", func() {
+		Enum(seedModes...)
+		Default("explicit")
+	})
+	Field(10, "random_seed_count", Int, "Seeds drawn in the random seed modes; 0 in the explicit mode", func() {
+		Minimum(0)
+		Default(0)
+		Example(4)
+	})
+	Field(11, "width", Int, "Image width in pixels", func() {
 		Example(1344)
 		Minimum(1)
 	})
-	Attribute("height", Int, "Image height in pixels", func() {
+	Field(12, "height", Int, "Image height in pixels", func() {
 		Example(1344)
 		Minimum(1)
 	})
-	Attribute("workflow_template", String, "ComfyUI workflow template filename (optional)", func() {
+	Field(13, "workflow_template", String, "ComfyUI workflow template filename (optional)", func() {
 		Example("qwen-image.json")
 		Default("")
 	})
-	Attribute("vae", String, "ComfyUI VAE model path (optional)", func() {
+	Field(14, "vae", String, "ComfyUI VAE model path (optional)", func() {
 		Example("ae.safetensors")
 		Default("")
 	})
-	Attribute("text_encoder", String, "ComfyUI CLIP/text encoder model path (optional)", func() {
+	Field(15, "text_encoder", String, "ComfyUI CLIP/text encoder model path (optional)", func() {
 		Example("clip_l.safetensors")
 		Default("")
 	})
-	Attribute("shift", Float64, "AuraFlow shift value (optional, nullable)")
-	Required("name", "prompt_prefix", "prompts", "negative_prompt", "steps", "cfgs", "sampler_scheduler_pairs", "seeds", "width", "height")
+	Field(16, "shift", Float64, "AuraFlow shift value (optional, nullable)")
+	Field(17, "input_image", String, "Server path of the image img2img samples start from; it is uploaded to ComfyUI and wired into the load_image node (optional)", func() {
+		Example("/data/assets/image/reference.png")
+		Default("")
+	})
+	Field(18, "denoise_strengths", ArrayOf(Float64), "Denoise strengths to iterate when input_image is set, each in (0, 1]; when empty the workflow's denoise strength is used (optional)", func() {
+		Example([]float64{0.4, 0.6, 0.8})
+	})
+	Field(19, "wildcards", ArrayOf(Wildcard), "Template variables of the prompt texts; each prompt is sampled once per combination of the values of the wildcards its text uses as {name} placeholders (optional)")
+	Field(20, "resolutions", ArrayOf(Resolution), "Image sizes to iterate instead of width x height for the prompts that do not set their own size; each item records its resolution, which becomes a filename key (optional)")
+	Required("name", "prompt_prefix", "prompts", "negative_prompt", "steps", "cfgs", "sampler_scheduler_pairs", "width", "height")
 })
 
 var UpdateStudyPayload = Type("UpdateStudyPayload", func() {
 	Description("Payload for updating a study")
-	Attribute("id", String, "Study ID", func() {
+	Field(1, "id", String, "Study ID", func() {
 		Example("550e8400-e29b-41d4-a716-446655440000")
 	})
-	Attribute("name", String, "Study display name", func() {
+	Field(2, "name", String, "Study display name", func() {
 		Example("My Study")
 		MinLength(1)
 	})
-	Attribute("prompt_prefix", String, "Text prepended to each prompt at generation time", func() {
+	Field(3, "prompt_prefix", String, "Text prepended to each prompt at generation time", func() {
 		Example("photo of a person, ")
 		Default("")
 	})
-	Attribute("prompts", ArrayOf(NamedPrompt), "List of named prompts", func() {
+	Field(4, "prompts", ArrayOf(NamedPrompt), "List of named prompts", func() {
 		MinLength(1)
 	})
-	Attribute("negative_prompt", String, "Negative prompt text", func() {
+	Field(5, "negative_prompt", String, "Negative prompt text", func() {
 		Example("low quality, blurry")
 		Default("")
 	})
-	Attribute("steps", ArrayOf(Int), "Step counts to iterate", func() {
+	Field(6, "steps", ArrayOf(Int), "Step counts to iterate", func() {
 		Example([]int{1, 4, 8})
 		MinLength(1)
 	})
-	Attribute("cfgs", ArrayOf(Float64), "CFG scale values to iterate", func() {
+	Field(7, "cfgs", ArrayOf(Float64), "CFG scale values to iterate", func() {
 		Example([]float64{1.0, 3.0, 7.0})
 		MinLength(1)
 	})
-	Attribute("sampler_scheduler_pairs", ArrayOf(SamplerSchedulerPair), "Sampler/scheduler pair combinations", func() {
+	Field(8, "sampler_scheduler_pairs", ArrayOf(SamplerSchedulerPair), "Sampler/scheduler pair combinations", func() {
 		MinLength(1)
 	})
-	Attribute("seeds", ArrayOf(Int64), "Seed values to iterate", func() {
+	Field(9, "seeds", ArrayOf(Int64), "Seed values to iterate; required in the explicit seed mode, empty in the random ones", func() {
 		Example([]int64{420, 421, 422})
-		MinLength(1)
 	})
-	Attribute("width", Int, "Image width in pixels", func() {
+	Field(10, "seed_mode", String, "Where item seeds come from: explicit iterates seeds; random_per_item draws a new seed for each of random_seed_count items per combination; random_per_checkpoint draws random_seed_count seeds per checkpoint, shared by all its combinations", func() {
+		Enum(seedModes...)
+		Default("explicit")
+	})
+	Field(11, "random_seed_count", Int, "Seeds drawn in the random seed modes; 0 in the explicit mode", func() {
+		Minimum(0)
+		Default(0)
+		Example(4)
+	})
+	Field(12, "width", Int, "Image width in pixels", func() {
 		Example(1344)
 		Minimum(1)
 	})
-	Attribute("height", Int, "Image height in pixels", func() {
+	Field(13, "height", Int, "Image height in pixels", func() {
 		Example(1344)
 		Minimum(1)
 	})
-	Attribute("workflow_template", String, "ComfyUI workflow template filename (optional)", func() {
+	Field(14, "workflow_template", String, "ComfyUI workflow template filename (optional)", func() {
 		Example("qwen-image.json")
 		Default("")
 	})
-	Attribute("vae", String, "ComfyUI VAE model path (optional)", func() {
+	Field(15, "vae", String, "ComfyUI VAE model path (optional)", func() {
 		Example("ae.safetensors")
 		Default("")
 	})
-	Attribute("text_encoder", String, "ComfyUI CLIP/text encoder model path (optional)", func() {
+	Field(16, "text_encoder", String, "ComfyUI CLIP/text encoder model path (optional)", func() {
 		Example("clip_l.safetensors")
 		Default("")
 	})
-	Attribute("shift", Float64, "AuraFlow shift value (optional, nullable)")
-	Required("id", "name", "prompt_prefix", "prompts", "negative_prompt", "steps", "cfgs", "sampler_scheduler_pairs", "seeds", "width", "height")
+	Field(17, "shift", Float64, "AuraFlow shift value (optional, nullable)")
+	Field(18, "input_image", String, "Server path of the image img2img samples start from; it is uploaded to ComfyUI and wired into the load_image node (optional)", func() {
+		Example("/data/assets/image/reference.png")
+		Default("")
+	})
+	Field(19, "denoise_strengths", ArrayOf(Float64), "Denoise strengths to iterate when input_image is set, each in (0, 1]; when empty the workflow's denoise strength is used (optional)", func() {
+		Example([]float64{0.4, 0.6, 0.8})
+	})
+	Field(20, "wildcards", ArrayOf(Wildcard), "Template variables of the prompt texts; each prompt is sampled once per combination of the values of the wildcards its text uses as {name} placeholders (optional)")
+	Field(21, "resolutions", ArrayOf(Resolution), "Image sizes to iterate instead of width x height for the prompts that do not set their own size; each item records its resolution, which becomes a filename key (optional)")
+	Required("id", "name", "prompt_prefix", "prompts", "negative_prompt", "steps", "cfgs", "sampler_scheduler_pairs", "width", "height")
 })
 
 var ForkStudyPayload = Type("ForkStudyPayload", func() {
 	Description("Payload for forking a study (creating a new study from an existing one)")
-	Attribute("source_id", String, "Source study ID to fork from", func() {
+	Field(1, "source_id", String, "Source study ID to fork from", func() {
 		Example("550e8400-e29b-41d4-a716-446655440000")
 	})
-	Attribute("name", String, "New study display name", func() {
+	Field(2, "name", String, "New study display name", func() {
 		Example("My Study - copy")
 		MinLength(1)
 	})
-	Attribute("prompt_prefix", String, "Text prepended to each prompt at generation time", func() {
+	Field(3, "prompt_prefix", String, "Text prepended to each prompt at generation time", func() {
 		Example("photo of a person, ")
 		Default("")
 	})
-	Attribute("prompts", ArrayOf(NamedPrompt), "List of named prompts", func() {
+	Field(4, "prompts", ArrayOf(NamedPrompt), "List of named prompts", func() {
 		MinLength(1)
 	})
-	Attribute("negative_prompt", String, "Negative prompt text", func() {
+	Field(5, "negative_prompt", String, "Negative prompt text", func() {
 		Example("low quality, blurry")
 		Default("")
 	})
-	Attribute("steps", ArrayOf(Int), "Step counts to iterate", func() {
+	Field(6, "steps", ArrayOf(Int), "Step counts to iterate", func() {
 		Example([]int{1, 4, 8})
 		MinLength(1)
 	})
-	Attribute("cfgs", ArrayOf(Float64), "CFG scale values to iterate", func() {
+	Field(7, "cfgs", ArrayOf(Float64), "CFG scale values to iterate", func() {
 		Example([]float64{1.0, 3.0, 7.0})
 		MinLength(1)
 	})
-	Attribute("sampler_scheduler_pairs", ArrayOf(SamplerSchedulerPair), "Sampler/scheduler pair combinations", func() {
+	Field(8, "sampler_scheduler_pairs", ArrayOf(SamplerSchedulerPair), "Sampler/scheduler pair combinations", func() {
 		MinLength(1)
 	})
-	Attribute("seeds", ArrayOf(Int64), "Seed values to iterate", func() {
+	Field(9, "seeds", ArrayOf(Int64), "Seed values to iterate; required in the explicit seed mode, empty in the random ones", func() {
 		Example([]int64{420, 421, 422})
-		MinLength(1)
 	})
-	Attribute("width", Int, "Image width in pixels", func() {
+	Field(10, "seed_mode", String, "Where item seeds come from: explicit iterates seeds; random_per_item draws a new seed for each of random_seed_count items per combination; random_per_checkpoint draws random_seed_count seeds per checkpoint, shared by all its combinations", func() {
+		Enum(seedModes...)
+		Default("explicit")
+	})
+	Field(11, "random_seed_count", Int, "Seeds drawn in the random seed modes; 0 in the explicit mode", func() {
+		Minimum(0)
+		Default(0)
+		Example(4)
+	})
+	Field(12, "width", Int, "Image width in pixels", func() {
 		Example(1344)
 		Minimum(1)
 	})
-	Attribute("height", Int, "Image height in pixels", func() {
+	Field(13, "height", Int, "Image height in pixels", func() {
 		Example(1344)
 		Minimum(1)
 	})
-	Attribute("workflow_template", String, "ComfyUI workflow template filename (optional)", func() {
+	Field(14, "workflow_template", String, "ComfyUI workflow template filename (optional)", func() {
 		Example("qwen-image.json")
 		Default("")
 	})
-	Attribute("vae", String, "ComfyUI VAE model path (optional)", func() {
+	Field(15, "vae", String, "ComfyUI VAE model path (optional)", func() {
 		Example("ae.safetensors")
 		Default("")
 	})
-	Attribute("text_encoder", String, "ComfyUI CLIP/text encoder model path (optional)", func() {
+	Field(16, "text_encoder", String, "ComfyUI CLIP/text encoder model path (optional)", func() {
 		Example("clip_l.safetensors")
 		Default("")
 	})
-	Attribute("shift", Float64, "AuraFlow shift value (optional, nullable)")
-	Required("source_id", "name", "prompt_prefix", "prompts", "negative_prompt", "steps", "cfgs", "sampler_scheduler_pairs", "seeds", "width", "height")
+	Field(17, "shift", Float64, "AuraFlow shift value (optional, nullable)")
+	Field(18, "input_image", String, "Server path of the image img2img samples start from; it is uploaded to ComfyUI and wired into the load_image node (optional)", func() {
+		Example("/data/assets/image/reference.png")
+		Default("")
+	})
+	Field(19, "denoise_strengths", ArrayOf(Float64), "Denoise strengths to iterate when input_image is set, each in (0, 1]; when empty the workflow's denoise strength is used (optional)", func() {
+		Example([]float64{0.4, 0.6, 0.8})
+	})
+	Field(20, "wildcards", ArrayOf(Wildcard), "Template variables of the prompt texts; each prompt is sampled once per combination of the values of the wildcards its text uses as {name} placeholders (optional)")
+	Field(21, "resolutions", ArrayOf(Resolution), "Image sizes to iterate instead of width x height for the prompts that do not set their own size; each item records its resolution, which becomes a filename key (optional)")
+	Required("source_id", "name", "prompt_prefix", "prompts", "negative_prompt", "steps", "cfgs", "sampler_scheduler_pairs", "width", "height")
 })
 
 var NamedPrompt = Type("NamedPrompt", func() {
-	Description("A prompt with a name and text")
-	Attribute("name", String, "Prompt name (used in filename)", func() {
+	Description("A prompt with a name and text, optionally overriding the study's steps, CFGs, seeds, and image size for its images")
+	Field(1, "name", String, "Prompt name (used in filename)", func() {
 		Example("forest_portals")
 		MinLength(1)
 	})
-	Attribute("text", String, "Prompt text", func() {
+	Field(2, "text", String, "Prompt text", func() {
 		Example("a mystical forest with glowing portals")
 		MinLength(1)
 	})
+	Field(3, "steps", ArrayOf(Int), "Step counts for this prompt; defaults to the study's steps (optional)", func() {
+		Example([]int{20, 30})
+	})
+	Field(4, "cfgs", ArrayOf(Float64), "CFG values for this prompt; defaults to the study's CFGs (optional)", func() {
+		Example([]float64{3.5, 7.0})
+	})
+	Field(5, "seeds", ArrayOf(Int64), "Seeds for this prompt; defaults to the study's seeds (optional)", func() {
+		Example([]int64{42})
+	})
+	Field(6, "width", Int, "Image width for this prompt; defaults to the study's width (optional)", func() {
+		Minimum(1)
+		Example(832)
+	})
+	Field(7, "height", Int, "Image height for this prompt; defaults to the study's height (optional)", func() {
+		Minimum(1)
+		Example(1216)
+	})
 	Required("name", "text")
 })
 
 var SamplerSchedulerPair = Type("SamplerSchedulerPair", func() {
 	Description("A specific sampler and scheduler combination")
-	Attribute("sampler", String, "Sampler name", func() {
+	Field(1, "sampler", String, "Sampler name", func() {
 		Example("euler")
 		MinLength(1)
 	})
-	Attribute("scheduler", String, "Scheduler name", func() {
+	Field(2, "scheduler", String, "Scheduler name", func() {
 		Example("normal")
 		MinLength(1)
 	})
 	Required("sampler", "scheduler")
 })
 
+var Wildcard = Type("Wildcard", func() {
+	Description("A template variable of a study's prompt texts")
+	Field(1, "name", String, "Wildcard name, used as {name} in prompt texts and as a filename key", func() {
+		Example("color")
+		Pattern(`^[a-z][a-z0-9_]*$`)
+	})
+	Field(2, "values", ArrayOf(String), "Values the placeholder is replaced with", func() {
+		Example([]string{"red", "blue"})
+		MinLength(1)
+	})
+	Required("name", "values")
+})
+
+var Resolution = Type("Resolution", func() {
+	Description("An image size of a study's resolution sweep")
+	Field(1, "width", Int, "Image width in pixels", func() {
+		Example(832)
+		Minimum(1)
+	})
+	Field(2, "height", Int, "Image height in pixels", func() {
+		Example(1216)
+		Minimum(1)
+	})
+	Required("width", "height")
+})
+
 var HasSamplesResponse = Type("HasSamplesResponse", func() {
 	Description("Response for checking if a study has generated samples")
-	Attribute("has_samples", Boolean, "Whether the study has generated samples on disk", func() {
+	Field(1, "has_samples", Boolean, "Whether the study has generated samples on disk", func() {
 		Example(true)
 	})
 	Required("has_samples")
@@ -411,23 +800,23 @@ var HasSamplesResponse = Type("HasSamplesResponse", func() {
 
 var StudyAvailabilityResponse = Type("StudyAvailabilityResponse", func() {
 	Description("Per-study sample availability for a training run")
-	Attribute("study_id", String, "Study ID (UUID)", func() {
+	Field(1, "study_id", String, "Study ID (UUID)", func() {
 		Example("550e8400-e29b-41d4-a716-446655440000")
 	})
-	Attribute("study_name", String, "Study display name", func() {
+	Field(2, "study_name", String, "Study display name", func() {
 		Example("My Study")
 	})
-	Attribute("has_samples", Boolean, "Whether this study has samples for the target training run", func() {
+	Field(3, "has_samples", Boolean, "Whether this study has samples for the target training run", func() {
 		Example(true)
 	})
-	Attribute("sample_status", String, "Sample completeness status for the target training run: 'none' = no samples, 'partial' = some checkpoints have samples, 'complete' = all checkpoints have samples", func() {
+	Field(4, "sample_status", String, "Sample completeness status for the target training run: 'none' = no samples, 'partial' = some checkpoints have samples, 'complete' = all checkpoints have samples", func() {
 		Example("complete")
 		Enum("none", "partial", "complete")
 	})
-	Attribute("checkpoints_with_samples", Int, "Number of training run checkpoints that have a matching sample directory for this study", func() {
+	Field(5, "checkpoints_with_samples", Int, "Number of training run checkpoints that have a matching sample directory for this study", func() {
 		Example(3)
 	})
-	Attribute("total_checkpoints", Int, "Total number of checkpoints in the training run", func() {
+	Field(6, "total_checkpoints", Int, "Total number of checkpoints in the training run", func() {
 		Example(5)
 	})
 	Required("study_id", "study_name", "has_samples", "sample_status", "checkpoints_with_samples", "total_checkpoints")
@@ -435,13 +824,13 @@ var StudyAvailabilityResponse = Type("StudyAvailabilityResponse", func() {
 
 var AffectedRunResponse = Type("AffectedRunResponse", func() {
 	Description("A training run that has generated samples for a study")
-	Attribute("training_run_name", String, "Training run name", func() {
+	Field(1, "training_run_name", String, "Training run name", func() {
 		Example("qwen/psai4rt-v0.3.0-no-reg")
 	})
-	Attribute("checkpoints_with_samples", Int, "Number of checkpoints with sample directories", func() {
+	Field(2, "checkpoints_with_samples", Int, "Number of checkpoints with sample directories", func() {
 		Example(3)
 	})
-	Attribute("total_checkpoints", Int, "Total number of checkpoints in the training run", func() {
+	Field(3, "total_checkpoints", Int, "Total number of checkpoints in the training run", func() {
 		Example(5)
 	})
 	Required("training_run_name", "checkpoints_with_samples", "total_checkpoints")
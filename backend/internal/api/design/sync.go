@@ -0,0 +1,134 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+var _ = Service("sync", func() {
+	Description("Differential sync of frontend state")
+
+	Method("changes", func() {
+		Description("List the entities that changed after a cursor, as they are now, so that a client can reconcile its state without fetching everything again. Start with since=0, which returns every entity, then pass the returned cursor. While more is true, call again right away. Entities are summaries; fetch an entity when its details are needed. An entity may be reported again by a later sync if it changed meanwhile.")
+		Payload(func() {
+			Attribute("since", Int64, "Cursor returned by the previous sync, or 0 for a full sync", func() {
+				Minimum(0)
+				Default(0)
+				Example(1234)
+			})
+			Attribute("limit", Int, "Maximum number of changed entities to return (default 500, at most 5000)", func() {
+				Minimum(1)
+				Maximum(5000)
+				Example(500)
+			})
+		})
+		Result(SyncResponse)
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/sync")
+			Param("since")
+			Param("limit")
+			Response(StatusOK)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+})
+
+var SyncResponse = Type("SyncResponse", func() {
+	Description("Entities that changed after a sync cursor")
+	Attribute("cursor", Int64, "Cursor to pass as since on the next sync", func() {
+		Example(1302)
+	})
+	Attribute("more", Boolean, "Whether changes remain after cursor", func() {
+		Example(false)
+	})
+	Attribute("jobs", ArrayOf(SyncSampleJob), "Sample jobs that were created or changed")
+	Attribute("job_items", ArrayOf(SyncJobItemCounts), "Item status counts of sample jobs whose items changed")
+	Attribute("studies", ArrayOf(SyncEntitySummary), "Studies that were created or changed")
+	Attribute("presets", ArrayOf(SyncEntitySummary), "Presets that were created or changed")
+	Attribute("image_dirs", ArrayOf(SyncImageDir), "Image index directories whose images changed")
+	Attribute("deleted", ArrayOf(SyncDeletedEntity), "Entities that were deleted")
+	Required("cursor", "more", "jobs", "job_items", "studies", "presets", "image_dirs", "deleted")
+})
+
+var SyncSampleJob = Type("SyncSampleJob", func() {
+	Description("Summary of a sample job")
+	Attribute("id", String, "Sample job ID", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("training_run_name", String, "Training run name", func() {
+		Example("my-model")
+	})
+	Attribute("study_id", String, "Study ID", func() {
+		Example("660e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("study_name", String, "Study name", func() {
+		Example("Quick comparison")
+	})
+	Attribute("status", String, "Job status", func() {
+		Enum(jobStatuses...)
+		Example("running")
+	})
+	Attribute("total_items", Int, "Total number of items", func() {
+		Example(120)
+	})
+	Attribute("completed_items", Int, "Number of completed items", func() {
+		Example(42)
+	})
+	Attribute("error_message", String, "Error message, if the job failed")
+	Attribute("updated_at", String, "Last update timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Required("id", "training_run_name", "study_id", "study_name", "status", "total_items", "completed_items", "updated_at")
+})
+
+var SyncJobItemCounts = Type("SyncJobItemCounts", func() {
+	Description("Item status counts of a sample job")
+	Attribute("job_id", String, "Sample job ID", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("completed", Int, "Number of completed items", func() {
+		Example(42)
+	})
+	Attribute("failed", Int, "Number of failed or skipped items", func() {
+		Example(1)
+	})
+	Attribute("pending", Int, "Number of pending items", func() {
+		Example(77)
+	})
+	Required("job_id", "completed", "failed", "pending")
+})
+
+var SyncEntitySummary = Type("SyncEntitySummary", func() {
+	Description("Summary of a study or preset")
+	Attribute("id", String, "ID", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("name", String, "Name", func() {
+		Example("Quick comparison")
+	})
+	Attribute("updated_at", String, "Last update timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Required("id", "name", "updated_at")
+})
+
+var SyncImageDir = Type("SyncImageDir", func() {
+	Description("Directory of the image index and the sample images in it")
+	Attribute("dir", String, "Directory relative to the sample directory", func() {
+		Example("my-study/checkpoint-000100.safetensors")
+	})
+	Attribute("filenames", ArrayOf(String), "Names of the sample images, in ascending order")
+	Required("dir", "filenames")
+})
+
+var SyncDeletedEntity = Type("SyncDeletedEntity", func() {
+	Description("An entity that was deleted")
+	Attribute("entity", String, "Kind of entity; job_items means the items of a deleted job", func() {
+		Enum("job", "job_items", "study", "preset", "image_dir")
+		Example("job")
+	})
+	Attribute("id", String, "Entity ID; the job ID for job_items, the directory for image_dir", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Required("entity", "id")
+})
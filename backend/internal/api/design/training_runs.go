@@ -10,7 +10,7 @@ var _ = Service("training_runs", func() {
 	Method("list", func() {
 		Description("List auto-discovered training runs")
 		Payload(func() {
-			Attribute("source", String, "Discovery source: 'samples' discovers from sample output directories (viewer), 'checkpoints' discovers from checkpoint files (job creation)", func() {
+			Field(1, "source", String, "Discovery source: 'samples' discovers from sample output directories (viewer), 'checkpoints' discovers from checkpoint files (job creation)", func() {
 				Default("samples")
 				Enum("samples", "checkpoints")
 			})
@@ -23,16 +23,20 @@ var _ = Service("training_runs", func() {
 			Response(StatusOK)
 			Response("discovery_failed", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("discovery_failed", CodeInternal)
+		})
 	})
 
 	Method("validate", func() {
 		Description("Validate sample set completeness for a training run by comparing PNG file counts per checkpoint. When study_id is provided, uses the study's expected images-per-checkpoint for comparison instead of the max-file-count heuristic.")
 		Payload(func() {
-			Attribute("id", Int, "Training run index (zero-based)", func() {
+			Field(1, "id", Int, "Training run index (zero-based)", func() {
 				Minimum(0)
 			})
-			Attribute("study_id", String, "Optional study ID for study-aware validation (uses study images_per_checkpoint as expected count)")
-			Attribute("study_output_dir", String, "Study output directory for legacy validation scoping")
+			Field(2, "study_id", String, "Optional study ID for study-aware validation (uses study images_per_checkpoint as expected count)")
+			Field(3, "study_output_dir", String, "Study output directory for legacy validation scoping")
 			Required("id")
 		})
 		Result(ValidationResultResponse)
@@ -46,15 +50,20 @@ var _ = Service("training_runs", func() {
 			Response("not_found", StatusNotFound)
 			Response("validation_failed", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("validation_failed", CodeInternal)
+		})
 	})
 
 	Method("scan", func() {
 		Description("Scan a training run's sample directories and return image metadata with discovered dimensions")
 		Payload(func() {
-			Attribute("id", Int, "Training run index (zero-based)", func() {
+			Field(1, "id", Int, "Training run index (zero-based)", func() {
 				Minimum(0)
 			})
-			Attribute("study_name", String, "Study name to scope the scan to a study subdirectory", func() {
+			Field(2, "study_name", String, "Study name to scope the scan to a study subdirectory", func() {
 				Default("")
 			})
 			Required("id")
@@ -69,75 +78,334 @@ var _ = Service("training_runs", func() {
 			Response("not_found", StatusNotFound)
 			Response("scan_failed", StatusInternalServerError)
 		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("scan_failed", CodeInternal)
+		})
+	})
+
+	Method("dimensions", func() {
+		Description("Return the dimensions discovered in a training run's sample directories with their sorted values, without the image list, so that grid axis selectors can be built without fetching every image")
+		Payload(func() {
+			Field(1, "id", Int, "Training run index (zero-based)", func() {
+				Minimum(0)
+			})
+			Field(2, "study_name", String, "Study name to scope the index to a study subdirectory", func() {
+				Default("")
+			})
+			Required("id")
+		})
+		Result(DimensionIndexResponse)
+		Error("not_found", ErrorResult, "Training run not found")
+		Error("scan_failed", ErrorResult, "Scan operation failed")
+		HTTP(func() {
+			GET("/api/training-runs/{id}/dimensions")
+			Param("study_name")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("scan_failed", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("scan_failed", CodeInternal)
+		})
 	})
+
+	Method("change_curve", func() {
+		Description("Measure how much a training run's images change between consecutive checkpoints, comparing completed sample job images of the same parameters, and suggest where checkpoints should be sampled densely or sparsely")
+		Payload(func() {
+			Field(1, "training_run", String, "Training run name", func() {
+				Example("my-lora")
+			})
+			Required("training_run")
+		})
+		Result(ChangeCurveResponse)
+		Error("not_found", ErrorResult, "Fewer than two checkpoints of the training run have images")
+		Error("invalid_payload", ErrorResult, "Invalid training run name")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/training-runs/change-curve")
+			Param("training_run")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("summary", func() {
+		Description("Summarize a training run in one call: its discovered checkpoints and which of them have samples, when it was last sampled, the sample jobs run against it with their failures, and the disk space its samples take. Names containing slashes must have them escaped as %2F.")
+		Payload(func() {
+			Field(1, "name", String, "Training run name", func() {
+				Example("my-lora")
+			})
+			Required("name")
+		})
+		Result(TrainingRunSummaryResponse)
+		Error("not_found", ErrorResult, "Training run not found")
+		Error("invalid_payload", ErrorResult, "Invalid training run name")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/training-runs/{name}/summary")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("list_configs", func() {
+		Description("List user-defined training runs")
+		Result(ArrayOf(TrainingRunConfigResponse))
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/training-runs/configs")
+			Response(StatusOK)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("create_config", func() {
+		Description("Define a training run. Checkpoints whose path matches the pattern are grouped into it on the next discovery.")
+		Payload(CreateTrainingRunConfigPayload)
+		Result(TrainingRunConfigResponse)
+		Error("invalid_payload", ErrorResult, "Invalid training run config")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/training-runs/configs")
+			Response(StatusCreated)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("update_config", func() {
+		Description("Update a user-defined training run")
+		Payload(UpdateTrainingRunConfigPayload)
+		Result(TrainingRunConfigResponse)
+		Error("not_found", ErrorResult, "Training run config not found")
+		Error("invalid_payload", ErrorResult, "Invalid training run config")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			PUT("/api/training-runs/configs/{config_id}")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("invalid_payload", CodeInvalidArgument)
+			Response("internal_error", CodeInternal)
+		})
+	})
+
+	Method("delete_config", func() {
+		Description("Delete a user-defined training run. Its checkpoints return to auto-discovered training runs; samples are not deleted.")
+		Payload(func() {
+			Field(1, "config_id", String, "Training run config ID", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Required("config_id")
+		})
+		Error("not_found", ErrorResult, "Training run config not found")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/training-runs/configs/{config_id}")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("internal_error", StatusInternalServerError)
+		})
+		GRPC(func() {
+			Response(CodeOK)
+			Response("not_found", CodeNotFound)
+			Response("internal_error", CodeInternal)
+		})
+	})
+})
+
+var TrainingRunConfigResponse = Type("TrainingRunConfigResponse", func() {
+	Description("A user-defined training run")
+	Field(1, "id", String, "Training run config ID (UUID)", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Field(2, "name", String, "Display name, used as the training run name", func() {
+		Example("psai4rt v0.3.0")
+	})
+	Field(3, "pattern", String, "Regular expression matched against checkpoint paths relative to their checkpoint directory", func() {
+		Example(`^qwen/psai4rt-v0\.3\.0-`)
+	})
+	Field(4, "dimensions", ArrayOf(TrainingRunDimensionConfig), "Dimensions extracted from matching checkpoint paths")
+	Field(5, "created_at", String, "Creation timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Field(6, "updated_at", String, "Last update timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Required("id", "name", "pattern", "dimensions", "created_at", "updated_at")
+})
+
+var TrainingRunDimensionConfig = Type("TrainingRunDimensionConfig", func() {
+	Description("Extracts a dimension value from checkpoint paths. A dimension named step replaces the parsed step number.")
+	Field(1, "name", String, "Dimension name", func() {
+		Example("step")
+		MinLength(1)
+	})
+	Field(2, "type", String, "Dimension type (int or string)", func() {
+		Example("int")
+		Enum("int", "string")
+	})
+	Field(3, "pattern", String, "Regular expression with exactly one capture group, whose match is the value", func() {
+		Example(`-steps-(\d+)-`)
+	})
+	Required("name", "type", "pattern")
+})
+
+var CreateTrainingRunConfigPayload = Type("CreateTrainingRunConfigPayload", func() {
+	Description("Payload for defining a training run")
+	Field(1, "name", String, "Display name, used as the training run name", func() {
+		Example("psai4rt v0.3.0")
+		MinLength(1)
+	})
+	Field(2, "pattern", String, "Regular expression matched against checkpoint paths relative to their checkpoint directory", func() {
+		Example(`^qwen/psai4rt-v0\.3\.0-`)
+		MinLength(1)
+	})
+	Field(3, "dimensions", ArrayOf(TrainingRunDimensionConfig), "Dimensions extracted from matching checkpoint paths")
+	Required("name", "pattern")
+})
+
+var UpdateTrainingRunConfigPayload = Type("UpdateTrainingRunConfigPayload", func() {
+	Description("Payload for updating a user-defined training run")
+	Field(1, "config_id", String, "Training run config ID", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Field(2, "name", String, "Display name, used as the training run name", func() {
+		Example("psai4rt v0.3.0")
+		MinLength(1)
+	})
+	Field(3, "pattern", String, "Regular expression matched against checkpoint paths relative to their checkpoint directory", func() {
+		Example(`^qwen/psai4rt-v0\.3\.0-`)
+		MinLength(1)
+	})
+	Field(4, "dimensions", ArrayOf(TrainingRunDimensionConfig), "Dimensions extracted from matching checkpoint paths")
+	Required("config_id", "name", "pattern")
 })
 
 var TrainingRunResponse = Type("TrainingRunResponse", func() {
 	Description("An auto-discovered training run")
-	Attribute("id", Int, "Training run index (zero-based)", func() {
+	Field(1, "id", Int, "Training run index (zero-based)", func() {
 		Example(0)
 	})
-	Attribute("name", String, "Training run base name (after stripping checkpoint suffixes)", func() {
+	Field(2, "name", String, "Training run base name (after stripping checkpoint suffixes)", func() {
 		Example("qwen/psai4rt-v0.3.0-no-reg")
 	})
-	Attribute("checkpoint_count", Int, "Number of checkpoint files in this training run", func() {
+	Field(3, "checkpoint_count", Int, "Number of checkpoint files in this training run", func() {
 		Example(3)
 	})
-	Attribute("has_samples", Boolean, "Whether at least one checkpoint has a matching sample directory", func() {
+	Field(4, "has_samples", Boolean, "Whether at least one checkpoint has a matching sample directory", func() {
 		Example(true)
 	})
-	Attribute("checkpoints", ArrayOf(CheckpointResponse), "Checkpoints in this training run (sorted by step number)")
-	Attribute("training_run_dir", String, "Top-level sample directory name (viewer source only)")
-	Attribute("study_label", String, "Study directory name (viewer source only)")
-	Attribute("study_output_dir", String, "Full study output directory prefix for scan/validation scoping (viewer source only)")
+	Field(5, "checkpoints", ArrayOf(CheckpointResponse), "Checkpoints in this training run (sorted by step number)")
+	Field(6, "training_run_dir", String, "Top-level sample directory name (viewer source only)", func() {
+		Example("psai4rt-v0.3.0-no-reg")
+	})
+	Field(7, "study_label", String, "Study directory name (viewer source only)", func() {
+		Example("my-study")
+	})
+	Field(8, "study_output_dir", String, "Full study output directory prefix for scan/validation scoping (viewer source only)", func() {
+		Example("my-study/psai4rt-v0.3.0-no-reg")
+	})
+	Field(9, "config_id", String, "ID of the user-defined training run config, when the run is not auto-discovered (checkpoints source only)", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
 	Required("id", "name", "checkpoint_count", "has_samples", "checkpoints")
 })
 
 var CheckpointResponse = Type("CheckpointResponse", func() {
 	Description("A checkpoint file within a training run")
-	Attribute("filename", String, "Checkpoint filename", func() {
+	Field(1, "filename", String, "Checkpoint filename", func() {
 		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
 	})
-	Attribute("step_number", Int, "Extracted step/epoch number (-1 if not parseable)", func() {
+	Field(2, "step_number", Int, "Extracted step/epoch number (-1 if not parseable)", func() {
 		Example(4500)
 	})
-	Attribute("has_samples", Boolean, "Whether a matching sample directory exists", func() {
+	Field(3, "has_samples", Boolean, "Whether a matching sample directory exists", func() {
 		Example(true)
 	})
+	Field(4, "dimensions", MapOf(String, String), "Dimension values extracted by the training run config (user-defined runs only)", func() {
+		Example(map[string]string{"step": "4500"})
+	})
 	Required("filename", "step_number", "has_samples")
 })
 
 var ScanResultResponse = Type("ScanResultResponse", func() {
 	Description("Result of scanning a training run's sample directories")
-	Attribute("images", ArrayOf(ImageResponse), "Discovered images with dimension values")
-	Attribute("dimensions", ArrayOf(DimensionResponse), "Discovered dimensions with unique values")
+	Field(1, "images", ArrayOf(ImageResponse), "Discovered images with dimension values")
+	Field(2, "dimensions", ArrayOf(DimensionResponse), "Discovered dimensions with unique values")
 	Required("images", "dimensions")
 })
 
+var DimensionIndexResponse = Type("DimensionIndexResponse", func() {
+	Description("Dimensions discovered in a training run's sample directories")
+	Field(1, "dimensions", ArrayOf(DimensionResponse), "Discovered dimensions with sorted unique values")
+	Field(2, "image_count", Int, "Number of images the dimensions were collected from", func() {
+		Example(270)
+	})
+	Required("dimensions", "image_count")
+})
+
 var ImageResponse = Type("ImageResponse", func() {
 	Description("A discovered image with its dimension values")
-	Attribute("relative_path", String, "Image path relative to sample directory", func() {
+	Field(1, "relative_path", String, "Image path relative to sample directory", func() {
 		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors/index=0&prompt_name=forest&seed=420&cfg=1&_00001_.png")
 	})
-	Attribute("dimensions", MapOf(String, String), "Dimension key-value pairs for this image", func() {
+	Field(2, "dimensions", MapOf(String, String), "Dimension key-value pairs for this image", func() {
 		Example(map[string]string{"checkpoint": "4500", "prompt_name": "forest", "seed": "420"})
 	})
-	Attribute("thumbnail_path", String, "Thumbnail path relative to sample directory (empty if thumbnails disabled or not yet generated)", func() {
+	Field(3, "thumbnail_path", String, "Thumbnail path relative to sample directory (empty if thumbnails disabled or not yet generated)", func() {
 		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors/thumbnails/index=0&prompt_name=forest&seed=420&cfg=1&_00001_.jpg")
 	})
-	Required("relative_path", "dimensions", "thumbnail_path")
+	Field(4, "pinned", Boolean, "Whether the image is pinned, directly or via its checkpoint sample set", func() {
+		Example(false)
+	})
+	Required("relative_path", "dimensions", "thumbnail_path", "pinned")
 })
 
 var DimensionResponse = Type("DimensionResponse", func() {
 	Description("A discovered dimension with its unique values")
-	Attribute("name", String, "Dimension name", func() {
+	Field(1, "name", String, "Dimension name", func() {
 		Example("checkpoint")
 	})
-	Attribute("type", String, "Dimension type (int or string)", func() {
+	Field(2, "type", String, "Dimension type (int or string)", func() {
 		Example("int")
 		Enum("int", "string")
 	})
-	Attribute("values", ArrayOf(String), "Sorted unique values for this dimension", func() {
+	Field(3, "values", ArrayOf(String), "Sorted unique values for this dimension", func() {
 		Example([]string{"4500", "4750", "5000"})
 	})
 	Required("name", "type", "values")
@@ -145,20 +413,20 @@ var DimensionResponse = Type("DimensionResponse", func() {
 
 var ValidationResultResponse = Type("ValidationResultResponse", func() {
 	Description("Result of validating sample set completeness for a training run")
-	Attribute("checkpoints", ArrayOf(CheckpointCompletenessResponse), "Per-checkpoint completeness counts")
-	Attribute("expected_per_checkpoint", Int, "Study-derived expected images per checkpoint (0 when no study context)", func() {
+	Field(1, "checkpoints", ArrayOf(CheckpointCompletenessResponse), "Per-checkpoint completeness counts")
+	Field(2, "expected_per_checkpoint", Int, "Study-derived expected images per checkpoint (0 when no study context)", func() {
 		Example(54)
 	})
-	Attribute("total_expected", Int, "Total expected images across all checkpoints", func() {
+	Field(3, "total_expected", Int, "Total expected images across all checkpoints", func() {
 		Example(270)
 	})
-	Attribute("total_verified", Int, "Total verified images across all checkpoints", func() {
+	Field(4, "total_verified", Int, "Total verified images across all checkpoints", func() {
 		Example(216)
 	})
-	Attribute("total_actual", Int, "Total sample images found on disk across all checkpoints", func() {
+	Field(5, "total_actual", Int, "Total sample images found on disk across all checkpoints", func() {
 		Example(240)
 	})
-	Attribute("total_missing", Int, "Total missing sample images across all checkpoints (total_expected - total_actual)", func() {
+	Field(6, "total_missing", Int, "Total missing sample images across all checkpoints (total_expected - total_actual)", func() {
 		Example(30)
 	})
 	Required("checkpoints", "expected_per_checkpoint", "total_expected", "total_verified", "total_actual", "total_missing")
@@ -166,17 +434,113 @@ var ValidationResultResponse = Type("ValidationResultResponse", func() {
 
 var CheckpointCompletenessResponse = Type("CheckpointCompletenessResponse", func() {
 	Description("Completeness info for a single checkpoint's sample directory")
-	Attribute("checkpoint", String, "Checkpoint filename", func() {
+	Field(1, "checkpoint", String, "Checkpoint filename", func() {
 		Example("model-step00001000.safetensors")
 	})
-	Attribute("expected", Int, "Expected number of sample images (max count across all checkpoints)", func() {
+	Field(2, "expected", Int, "Expected number of sample images (max count across all checkpoints)", func() {
 		Example(54)
 	})
-	Attribute("verified", Int, "Number of sample images found on disk", func() {
+	Field(3, "verified", Int, "Number of sample images found on disk", func() {
 		Example(54)
 	})
-	Attribute("missing", Int, "Number of missing sample images (expected - verified)", func() {
+	Field(4, "missing", Int, "Number of missing sample images (expected - verified)", func() {
 		Example(0)
 	})
 	Required("checkpoint", "expected", "verified", "missing")
 })
+
+var ChangeCurveResponse = Type("ChangeCurveResponse", func() {
+	Description("How quickly a training run's images change from checkpoint to checkpoint")
+	Field(1, "training_run", String, "Training run name", func() {
+		Example("my-lora")
+	})
+	Field(2, "transitions", ArrayOf(CheckpointTransitionResponse), "Changes between consecutive checkpoints, ordered by step")
+	Field(3, "suggested_checkpoints", ArrayOf(String), "Checkpoints worth sampling, ordered by step; every other checkpoint of a sparse stretch is left out. Can be passed as a sample job's checkpoint_filenames.", func() {
+		Example([]string{"my-lora-step00001000.safetensors", "my-lora-step00002000.safetensors"})
+	})
+	Required("training_run", "transitions", "suggested_checkpoints")
+})
+
+var CheckpointTransitionResponse = Type("CheckpointTransitionResponse", func() {
+	Description("The change in images between two consecutive checkpoints")
+	Field(1, "from_checkpoint", String, "Earlier checkpoint filename", func() {
+		Example("my-lora-step00001000.safetensors")
+	})
+	Field(2, "to_checkpoint", String, "Later checkpoint filename", func() {
+		Example("my-lora-step00002000.safetensors")
+	})
+	Field(3, "from_step", Int, "Step number of the earlier checkpoint (-1 if unknown)", func() {
+		Example(1000)
+	})
+	Field(4, "to_step", Int, "Step number of the later checkpoint (-1 if unknown)", func() {
+		Example(2000)
+	})
+	Field(5, "difference", Float64, "Mean perceptual difference between the checkpoints' images of the same parameters, from 0 (identical) to 1", func() {
+		Example(0.18)
+	})
+	Field(6, "rate", Float64, "Difference per 1000 training steps, or the difference itself when the step gap is unknown", func() {
+		Example(0.18)
+	})
+	Field(7, "pairs", Int, "Number of image pairs compared; without pairs the difference is 0 and the density normal", func() {
+		Example(24)
+	})
+	Field(8, "density", String, "Suggested sampling density of this stretch, relative to the run's median rate of change", func() {
+		Enum("dense", "normal", "sparse")
+		Example("dense")
+	})
+	Required("from_checkpoint", "to_checkpoint", "from_step", "to_step", "difference", "rate", "pairs", "density")
+})
+
+var TrainingRunSummaryResponse = Type("TrainingRunSummaryResponse", func() {
+	Description("Overview of a training run")
+	Field(1, "name", String, "Training run name", func() {
+		Example("my-lora")
+	})
+	Field(2, "checkpoint_count", Int, "Number of checkpoints discovered in the checkpoint directories", func() {
+		Example(12)
+	})
+	Field(3, "sampled_checkpoint_count", Int, "Number of discovered checkpoints with samples", func() {
+		Example(8)
+	})
+	Field(4, "checkpoints", ArrayOf(CheckpointSummaryResponse), "Discovered checkpoints, ordered by step")
+	Field(5, "last_sampled_at", String, "When the most recent sample job item of the run completed (RFC3339); absent if none has", func() {
+		Format(FormatDateTime)
+	})
+	Field(6, "job_count", Int, "Number of sample jobs run against the training run, not counting jobs in the trash", func() {
+		Example(5)
+	})
+	Field(7, "job_status_counts", MapOf(String, Int), "Number of sample jobs per status; statuses without jobs are left out", func() {
+		Example(map[string]int{"completed": 3, "completed_with_errors": 1, "failed": 1})
+	})
+	Field(8, "failed_job_count", Int, "Number of failed sample jobs", func() {
+		Example(1)
+	})
+	Field(9, "failed_item_count", Int, "Number of failed and skipped sample job items across all jobs", func() {
+		Example(7)
+	})
+	Field(10, "disk_bytes", Int64, "Total size of the training run's sample directory, including thumbnails and sidecars", func() {
+		Example(1073741824)
+	})
+	Field(11, "disk_file_count", Int, "Number of files in the training run's sample directory", func() {
+		Example(2048)
+	})
+	Required("name", "checkpoint_count", "sampled_checkpoint_count", "checkpoints", "job_count", "job_status_counts", "failed_job_count", "failed_item_count", "disk_bytes", "disk_file_count")
+})
+
+var CheckpointSummaryResponse = Type("CheckpointSummaryResponse", func() {
+	Description("Sampling state of one checkpoint of a training run")
+	Field(1, "filename", String, "Checkpoint filename", func() {
+		Example("my-lora-step00001000.safetensors")
+	})
+	Field(2, "step_number", Int, "Step number (-1 if unknown)", func() {
+		Example(1000)
+	})
+	Field(3, "has_samples", Boolean, "Whether the checkpoint has sample images")
+	Field(4, "image_count", Int, "Number of sample images, summed across studies", func() {
+		Example(48)
+	})
+	Field(5, "completed_items", Int, "Number of completed sample job items")
+	Field(6, "failed_items", Int, "Number of failed and skipped sample job items")
+	Field(7, "bytes", Int64, "Total size of the checkpoint's samples")
+	Required("filename", "step_number", "has_samples", "image_count", "completed_items", "failed_items", "bytes")
+})
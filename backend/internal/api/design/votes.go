@@ -0,0 +1,296 @@
+package design
+
+import (
+	. "goa.design/goa/v3/dsl"
+)
+
+var _ = Service("votes", func() {
+	Description("Pairwise checkpoint voting, Elo rankings, and star rating scores")
+
+	Method("pair", func() {
+		Description("Return a random pair of completed images of a training run, generated with the same parameters by different checkpoints, to vote on.")
+		Payload(func() {
+			Attribute("training_run", String, "Training run name", func() {
+				Example("qwen/psai4rt-v0.3.0-no-reg")
+				MinLength(1)
+			})
+			Attribute("prompt_name", String, "Only pair images of this prompt", func() {
+				Example("forest")
+			})
+			Required("training_run")
+		})
+		Result(VotePairResponse)
+		Error("not_found", ErrorResult, "The training run has no comparable images")
+		Error("invalid_payload", ErrorResult, "Invalid request")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/votes/pair")
+			Param("training_run")
+			Param("prompt_name")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("vote", func() {
+		Description("Record that one image of a pair was preferred over the other. Both images must be completed images of the training run that differ only by checkpoint.")
+		Payload(func() {
+			Attribute("training_run", String, "Training run name", func() {
+				Example("qwen/psai4rt-v0.3.0-no-reg")
+				MinLength(1)
+			})
+			Attribute("winner_item_id", String, "Sample job item ID of the preferred image", func() {
+				Example("550e8400-e29b-41d4-a716-446655440000")
+			})
+			Attribute("loser_item_id", String, "Sample job item ID of the other image", func() {
+				Example("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+			})
+			Required("training_run", "winner_item_id", "loser_item_id")
+		})
+		Result(VoteResponse)
+		Error("not_found", ErrorResult, "An image is not a completed image of the training run")
+		Error("not_comparable", ErrorResult, "The images differ in more than the checkpoint")
+		Error("invalid_payload", ErrorResult, "Invalid vote")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/votes")
+			Response(StatusCreated)
+			Response("not_found", StatusNotFound)
+			Response("not_comparable", StatusUnprocessableEntity)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("rankings", func() {
+		Description("Rank the checkpoints of a training run by Elo rating, computed from its votes in the order they were cast, across all prompts and per prompt.")
+		Payload(func() {
+			Attribute("training_run", String, "Training run name", func() {
+				Example("qwen/psai4rt-v0.3.0-no-reg")
+				MinLength(1)
+			})
+			Required("training_run")
+		})
+		Result(VoteRankingsResponse)
+		Error("invalid_payload", ErrorResult, "Invalid request")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/votes/rankings")
+			Param("training_run")
+			Response(StatusOK)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("scores", func() {
+		Description("Rank the checkpoints of a training run by the average star rating of their completed images, across all prompts and per prompt. Unrated images are counted but do not affect averages.")
+		Payload(func() {
+			Attribute("training_run", String, "Training run name", func() {
+				Example("qwen/psai4rt-v0.3.0-no-reg")
+				MinLength(1)
+			})
+			Required("training_run")
+		})
+		Result(CheckpointScoreReportResponse)
+		Error("invalid_payload", ErrorResult, "Invalid request")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/votes/scores")
+			Param("training_run")
+			Response(StatusOK)
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("scores_csv", func() {
+		Description("Download the checkpoint score report of a training run as CSV: the leaderboard rows (scope overall) followed by the rows of each prompt (scope prompt).")
+		Payload(func() {
+			Attribute("training_run", String, "Training run name", func() {
+				Example("qwen/psai4rt-v0.3.0-no-reg")
+				MinLength(1)
+			})
+			Required("training_run")
+		})
+		Result(CSVDownloadResult)
+		Error("invalid_payload", ErrorResult, "Invalid request")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			GET("/api/votes/scores/csv")
+			Param("training_run")
+			SkipResponseBodyEncodeDecode()
+			Response(StatusOK, func() {
+				Header("content_type:Content-Type")
+				Header("content_disposition:Content-Disposition")
+			})
+			Response("invalid_payload", StatusBadRequest)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+})
+
+var VotePairImageResponse = Type("VotePairImageResponse", func() {
+	Description("One side of a vote pair")
+	Attribute("item_id", String, "Sample job item ID, passed back when voting", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("checkpoint_filename", String, "Checkpoint that produced the image", func() {
+		Example("model-step00001000.safetensors")
+	})
+	Attribute("image_path", String, "Image path relative to the sample directory, for /api/images", func() {
+		Example("my-run/my-study/model-step00001000.safetensors/index=0&prompt_name=forest&seed=420&cfg=7&_00001_.png")
+	})
+	Required("item_id", "checkpoint_filename", "image_path")
+})
+
+var VotePairResponse = Type("VotePairResponse", func() {
+	Description("A pair of images generated with the same parameters by different checkpoints")
+	Attribute("training_run", String, "Training run name", func() {
+		Example("qwen/psai4rt-v0.3.0-no-reg")
+	})
+	Attribute("prompt_name", String, "Prompt name", func() {
+		Example("forest")
+	})
+	Attribute("steps", Int, "Sampling steps", func() {
+		Example(20)
+	})
+	Attribute("cfg", Float64, "CFG scale", func() {
+		Example(7.0)
+	})
+	Attribute("sampler_name", String, "Sampler", func() {
+		Example("euler")
+	})
+	Attribute("scheduler", String, "Scheduler", func() {
+		Example("simple")
+	})
+	Attribute("seed", Int64, "Seed", func() {
+		Example(420)
+	})
+	Attribute("a", VotePairImageResponse, "First image")
+	Attribute("b", VotePairImageResponse, "Second image")
+	Required("training_run", "prompt_name", "steps", "cfg", "sampler_name", "scheduler", "seed", "a", "b")
+})
+
+var VoteResponse = Type("VoteResponse", func() {
+	Description("A recorded vote")
+	Attribute("id", String, "Vote ID (UUID)", func() {
+		Example("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+	})
+	Attribute("training_run", String, "Training run name", func() {
+		Example("qwen/psai4rt-v0.3.0-no-reg")
+	})
+	Attribute("prompt_name", String, "Prompt name of both images", func() {
+		Example("forest")
+	})
+	Attribute("winner_checkpoint", String, "Checkpoint of the preferred image", func() {
+		Example("model-step00002000.safetensors")
+	})
+	Attribute("loser_checkpoint", String, "Checkpoint of the other image", func() {
+		Example("model-step00001000.safetensors")
+	})
+	Attribute("created_at", String, "Creation timestamp (RFC3339)", func() {
+		Example("2025-01-01T00:00:00Z")
+	})
+	Required("id", "training_run", "prompt_name", "winner_checkpoint", "loser_checkpoint", "created_at")
+})
+
+var CheckpointRatingResponse = Type("CheckpointRatingResponse", func() {
+	Description("Elo rating of a checkpoint")
+	Attribute("checkpoint_filename", String, "Checkpoint filename", func() {
+		Example("model-step00002000.safetensors")
+	})
+	Attribute("rating", Float64, "Elo rating; every checkpoint starts at 1500", func() {
+		Example(1532.5)
+	})
+	Attribute("wins", Int, "Votes won", func() {
+		Example(3)
+	})
+	Attribute("losses", Int, "Votes lost", func() {
+		Example(1)
+	})
+	Required("checkpoint_filename", "rating", "wins", "losses")
+})
+
+var PromptRatingsResponse = Type("PromptRatingsResponse", func() {
+	Description("Checkpoint ratings from the votes on one prompt")
+	Attribute("prompt_name", String, "Prompt name", func() {
+		Example("forest")
+	})
+	Attribute("ratings", ArrayOf(CheckpointRatingResponse), "Ratings, best first")
+	Required("prompt_name", "ratings")
+})
+
+var VoteRankingsResponse = Type("VoteRankingsResponse", func() {
+	Description("Elo rankings of the checkpoints of a training run")
+	Attribute("training_run", String, "Training run name", func() {
+		Example("qwen/psai4rt-v0.3.0-no-reg")
+	})
+	Attribute("vote_count", Int, "Number of votes", func() {
+		Example(42)
+	})
+	Attribute("overall", ArrayOf(CheckpointRatingResponse), "Ratings from all votes, best first")
+	Attribute("prompts", ArrayOf(PromptRatingsResponse), "Ratings per prompt, ordered by prompt name")
+	Required("training_run", "vote_count", "overall", "prompts")
+})
+
+var CheckpointScoreResponse = Type("CheckpointScoreResponse", func() {
+	Description("Average star rating of the images of a checkpoint")
+	Attribute("rank", Int, "1-based position in the leaderboard", func() {
+		Example(1)
+	})
+	Attribute("checkpoint_filename", String, "Checkpoint filename", func() {
+		Example("model-step00002000.safetensors")
+	})
+	Attribute("step_number", Int, "Training step parsed from the filename, or -1 for a final checkpoint", func() {
+		Example(2000)
+	})
+	Attribute("image_count", Int, "Completed images of the checkpoint", func() {
+		Example(24)
+	})
+	Attribute("rated_count", Int, "Images with a star rating", func() {
+		Example(18)
+	})
+	Attribute("average_rating", Float64, "Average star rating of the rated images; 0 when none are rated", func() {
+		Example(4.25)
+	})
+	Required("rank", "checkpoint_filename", "step_number", "image_count", "rated_count", "average_rating")
+})
+
+var PromptScoresResponse = Type("PromptScoresResponse", func() {
+	Description("Checkpoint scores from the images of one prompt")
+	Attribute("prompt_name", String, "Prompt name", func() {
+		Example("forest")
+	})
+	Attribute("scores", ArrayOf(CheckpointScoreResponse), "Scores, best first")
+	Required("prompt_name", "scores")
+})
+
+var CheckpointScoreReportResponse = Type("CheckpointScoreReportResponse", func() {
+	Description("Star rating scores of the checkpoints of a training run")
+	Attribute("training_run", String, "Training run name", func() {
+		Example("qwen/psai4rt-v0.3.0-no-reg")
+	})
+	Attribute("image_count", Int, "Completed images of the training run", func() {
+		Example(240)
+	})
+	Attribute("rated_count", Int, "Images with a star rating", func() {
+		Example(180)
+	})
+	Attribute("leaderboard", ArrayOf(CheckpointScoreResponse), "Scores from all prompts, best first; checkpoints without rated images come last")
+	Attribute("prompts", ArrayOf(PromptScoresResponse), "Scores per prompt, ordered by prompt name")
+	Required("training_run", "image_count", "rated_count", "leaderboard", "prompts")
+})
+
+var CSVDownloadResult = Type("CSVDownloadResult", func() {
+	Description("Result headers for a CSV download")
+	Attribute("content_type", String, "Content-Type header value", func() {
+		Example("text/csv; charset=utf-8")
+	})
+	Attribute("content_disposition", String, "Content-Disposition header value", func() {
+		Example(`attachment; filename="my-run-checkpoint-scores.csv"`)
+	})
+	Required("content_type", "content_disposition")
+})
@@ -32,6 +32,173 @@ var _ = Service("workflows", func() {
 			Response("not_found", StatusNotFound)
 		})
 	})
+
+	Method("upload", func() {
+		Description("Save an API-format ComfyUI workflow to the workflow directory. The workflow must have a node with the save_image cs_role.")
+		Payload(func() {
+			Attribute("name", String, "Workflow template name; .json is appended if missing", func() {
+				MinLength(1)
+				Example("qwen-image.json")
+			})
+			Attribute("workflow", MapOf(String, Any), "Workflow JSON in ComfyUI API format", func() {
+				Example(map[string]interface{}{
+					"9": map[string]interface{}{
+						"class_type": "SaveImage",
+						"_meta":      map[string]interface{}{"cs_role": "save_image"},
+					},
+				})
+			})
+			Attribute("overwrite", Boolean, "Replace an existing workflow of the same name", func() {
+				Default(false)
+			})
+			Required("name", "workflow")
+		})
+		Result(WorkflowDetails)
+		Error("invalid_payload", ErrorResult, "Invalid name, or the workflow is not in API format or lacks a required cs_role")
+		Error("conflict", ErrorResult, "A workflow of the same name exists and overwrite is not set")
+		Error("service_unavailable", ErrorResult, "ComfyUI is not configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			POST("/api/workflows")
+			Response(StatusCreated)
+			Response("invalid_payload", StatusBadRequest)
+			Response("conflict", StatusConflict)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("rename", func() {
+		Description("Rename a workflow template. Workflows used by studies cannot be renamed.")
+		Payload(func() {
+			Attribute("name", String, "Workflow template name")
+			Attribute("new_name", String, "New workflow template name; .json is appended if missing", func() {
+				MinLength(1)
+				Example("qwen-image-v2.json")
+			})
+			Required("name", "new_name")
+		})
+		Result(WorkflowDetails)
+		Error("invalid_payload", ErrorResult, "Invalid workflow name")
+		Error("not_found", ErrorResult, "Workflow not found")
+		Error("conflict", ErrorResult, "The new name is taken, or studies use the workflow")
+		Error("service_unavailable", ErrorResult, "ComfyUI is not configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			PUT("/api/workflows/{name}")
+			Response(StatusOK)
+			Response("invalid_payload", StatusBadRequest)
+			Response("not_found", StatusNotFound)
+			Response("conflict", StatusConflict)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("delete", func() {
+		Description("Delete a workflow template. Workflows used by studies cannot be deleted; sample jobs keep the workflow they were created with.")
+		Payload(func() {
+			Attribute("name", String, "Workflow template name")
+			Required("name")
+		})
+		Error("invalid_payload", ErrorResult, "Invalid workflow name")
+		Error("not_found", ErrorResult, "Workflow not found")
+		Error("conflict", ErrorResult, "Studies use the workflow")
+		Error("service_unavailable", ErrorResult, "ComfyUI is not configured")
+		Error("internal_error", ErrorResult, "Internal server error")
+		HTTP(func() {
+			DELETE("/api/workflows/{name}")
+			Response(StatusNoContent)
+			Response("invalid_payload", StatusBadRequest)
+			Response("not_found", StatusNotFound)
+			Response("conflict", StatusConflict)
+			Response("service_unavailable", StatusServiceUnavailable)
+			Response("internal_error", StatusInternalServerError)
+		})
+	})
+
+	Method("preview", func() {
+		Description("Substitute the values of a sample into a workflow template as the job executor would, without submitting it. Returns the workflow and the inputs that differ from the template.")
+		Payload(func() {
+			Attribute("name", String, "Workflow template name")
+			Attribute("checkpoint", String, "ComfyUI model path written to unet_loader nodes", func() {
+				MinLength(1)
+				Example("qwen/my-lora-step00001000.safetensors")
+			})
+			Attribute("prompt", String, "Positive prompt text", func() {
+				Example("a cat on a windowsill")
+			})
+			Attribute("negative_prompt", String, "Negative prompt text; the node's own text is kept when empty", func() {
+				Example("blurry")
+			})
+			Attribute("seed", Int64, "Seed", func() {
+				Default(0)
+				Example(42)
+			})
+			Attribute("steps", Int, "Sampling steps", func() {
+				Default(20)
+				Minimum(1)
+			})
+			Attribute("cfg", Float64, "CFG scale", func() {
+				Default(7.0)
+			})
+			Attribute("sampler_name", String, "Sampler", func() {
+				Default("euler")
+			})
+			Attribute("scheduler", String, "Scheduler", func() {
+				Default("normal")
+			})
+			Attribute("width", Int, "Image width", func() {
+				Default(1024)
+				Minimum(1)
+			})
+			Attribute("height", Int, "Image height", func() {
+				Default(1024)
+				Minimum(1)
+			})
+			Attribute("vae", String, "VAE (ComfyUI path); defaults to the workflow's cs_default")
+			Attribute("clip", String, "Text encoder (ComfyUI path); defaults to the workflow's cs_default")
+			Attribute("shift", Float64, "AuraFlow shift; defaults to the workflow's cs_default")
+			Attribute("denoise", Float64, "img2img denoise strength", func() {
+				Minimum(0)
+				Maximum(1)
+			})
+			Attribute("input_image", String, "Server path of the img2img input image; shown in place of the name of its uploaded copy")
+			Attribute("controlnet_model", String, "ControlNet model (ComfyUI path)")
+			Attribute("controlnet_strength", Float64, "ControlNet strength", func() {
+				Minimum(0)
+				Maximum(10)
+			})
+			Attribute("controlnet_image", String, "Server path of the ControlNet conditioning image; shown in place of the name of its uploaded copy")
+			Required("name", "checkpoint", "prompt")
+		})
+		Result(WorkflowPreview)
+		Error("not_found", ErrorResult, "Workflow not found")
+		Error("invalid_workflow", ErrorResult, "The values cannot be substituted into the workflow")
+		Error("service_unavailable", ErrorResult, "ComfyUI is not configured")
+		HTTP(func() {
+			POST("/api/workflows/{name}/preview")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("invalid_workflow", StatusUnprocessableEntity)
+			Response("service_unavailable", StatusServiceUnavailable)
+		})
+	})
+
+	Method("graph", func() {
+		Description("Get a workflow template as a graph of nodes and links, marking the inputs substituted for each cs_role")
+		Payload(func() {
+			Attribute("name", String, "Workflow template name")
+			Required("name")
+		})
+		Result(WorkflowGraph)
+		Error("not_found", ErrorResult, "Workflow not found")
+		HTTP(func() {
+			GET("/api/workflows/{name}/graph")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+		})
+	})
 })
 
 var WorkflowSummary = Type("WorkflowSummary", func() {
@@ -42,7 +209,8 @@ var WorkflowSummary = Type("WorkflowSummary", func() {
 		Enum("valid", "invalid")
 		Example("valid")
 	})
-	Attribute("roles", MapOf(String, ArrayOf(String)), "Map of cs_role to node IDs", func() {
+	Attribute("roles", MapOf(String, ArrayOf(String)), "Map of cs_role to node IDs. Known roles are listed in x-known-keys; other roles are kept and reported in warnings.", func() {
+		Meta("openapi:extension:x-known-keys", csRolesJSON)
 		Example(map[string][]string{
 			"save_image":  {"9"},
 			"unet_loader": {"4"},
@@ -52,7 +220,8 @@ var WorkflowSummary = Type("WorkflowSummary", func() {
 	Attribute("warnings", ArrayOf(String), "Validation warnings (e.g., unknown roles)", func() {
 		Example([]string{"unknown cs_role \"custom_role\" on node 5"})
 	})
-	Required("name", "validation_state", "roles", "warnings")
+	Attribute("defaults", WorkflowDefaults, "Default model values declared with cs_default in the workflow")
+	Required("name", "validation_state", "roles", "warnings", "defaults")
 })
 
 var WorkflowDetails = Type("WorkflowDetails", func() {
@@ -63,7 +232,8 @@ var WorkflowDetails = Type("WorkflowDetails", func() {
 		Enum("valid", "invalid")
 		Example("valid")
 	})
-	Attribute("roles", MapOf(String, ArrayOf(String)), "Map of cs_role to node IDs", func() {
+	Attribute("roles", MapOf(String, ArrayOf(String)), "Map of cs_role to node IDs. Known roles are listed in x-known-keys; other roles are kept and reported in warnings.", func() {
+		Meta("openapi:extension:x-known-keys", csRolesJSON)
 		Example(map[string][]string{
 			"save_image":  {"9"},
 			"unet_loader": {"4"},
@@ -73,6 +243,7 @@ var WorkflowDetails = Type("WorkflowDetails", func() {
 	Attribute("warnings", ArrayOf(String), "Validation warnings (e.g., unknown roles)", func() {
 		Example([]string{"unknown cs_role \"custom_role\" on node 5"})
 	})
+	Attribute("defaults", WorkflowDefaults, "Default model values declared with cs_default in the workflow")
 	Attribute("workflow", Any, "Full workflow JSON data", func() {
 		Example(map[string]interface{}{
 			"3": map[string]interface{}{
@@ -81,5 +252,106 @@ var WorkflowDetails = Type("WorkflowDetails", func() {
 			},
 		})
 	})
-	Required("name", "validation_state", "roles", "warnings", "workflow")
+	Required("name", "validation_state", "roles", "warnings", "defaults", "workflow")
+})
+
+var WorkflowPreview = Type("WorkflowPreview", func() {
+	Description("A workflow template with the values of a sample substituted")
+	Attribute("name", String, "Workflow template name", func() {
+		Example("qwen-image.json")
+	})
+	Attribute("workflow", Any, "Workflow JSON as it would be submitted to ComfyUI")
+	Attribute("substitutions", ArrayOf(WorkflowSubstitution), "Inputs that differ from the template, ordered by node ID and input name")
+	Required("name", "workflow", "substitutions")
+})
+
+var WorkflowSubstitution = Type("WorkflowSubstitution", func() {
+	Attribute("node_id", String, "Node ID", func() {
+		Example("3")
+	})
+	Attribute("role", String, "cs_role of the node (empty if unset, e.g. the image loader linked to a controlnet_apply node)", func() {
+		Example("sampler")
+	})
+	Attribute("input", String, "Input name", func() {
+		Example("seed")
+	})
+	Attribute("previous", Any, "Value in the template; a [node_id, output] link for linked inputs, absent if unset", func() {
+		Example(0)
+	})
+	Attribute("value", Any, "Substituted value", func() {
+		Example(42)
+	})
+	Required("node_id", "role", "input")
+})
+
+var WorkflowDefaults = Type("WorkflowDefaults", func() {
+	Description("Model values used for sample jobs when neither the job nor the study sets them")
+	Attribute("vae", String, "Default VAE (ComfyUI path)", func() {
+		Example("ae.safetensors")
+	})
+	Attribute("clip", String, "Default text encoder (ComfyUI path)", func() {
+		Example("clip_l.safetensors")
+	})
+	Attribute("shift", Float64, "Default AuraFlow shift", func() {
+		Example(3.1)
+	})
+})
+
+var WorkflowGraph = Type("WorkflowGraph", func() {
+	Description("A workflow template laid out for rendering as a node graph")
+	Attribute("name", String, "Workflow template name", func() {
+		Example("qwen-image.json")
+	})
+	Attribute("nodes", ArrayOf(WorkflowGraphNode), "Nodes ordered by ID")
+	Attribute("links", ArrayOf(WorkflowGraphLink), "Links from node outputs to node inputs")
+	Attribute("warnings", ArrayOf(String), "Substitutions that may not apply as expected, and links to missing nodes", func() {
+		Example([]string{"input \"text\" of node 7 is linked from node 12; cs_role negative_prompt replaces the link with a value"})
+	})
+	Required("name", "nodes", "links", "warnings")
+})
+
+var WorkflowGraphNode = Type("WorkflowGraphNode", func() {
+	Attribute("id", String, "Node ID", func() {
+		Example("3")
+	})
+	Attribute("class_type", String, "ComfyUI node class", func() {
+		Example("KSampler")
+	})
+	Attribute("title", String, "Node title from _meta.title (empty if unset)", func() {
+		Example("KSampler")
+	})
+	Attribute("role", String, "cs_role of the node (empty if unset)", func() {
+		Example("sampler")
+	})
+	Attribute("known_role", Boolean, "Whether role is a cs_role Checkpoint Sampler substitutes")
+	Attribute("inputs", ArrayOf(WorkflowGraphInput), "Inputs ordered by name, followed by substituted inputs the node does not declare")
+	Required("id", "class_type", "title", "role", "known_role", "inputs")
+})
+
+var WorkflowGraphInput = Type("WorkflowGraphInput", func() {
+	Attribute("name", String, "Input name", func() {
+		Example("seed")
+	})
+	Attribute("value", Any, "Literal value in the workflow (absent for linked inputs)", func() {
+		Example(42)
+	})
+	Attribute("linked", Boolean, "Whether the input takes its value from another node's output")
+	Attribute("substituted", Boolean, "Whether Checkpoint Sampler writes this input when submitting a sample")
+	Required("name", "linked", "substituted")
+})
+
+var WorkflowGraphLink = Type("WorkflowGraphLink", func() {
+	Attribute("from_node", String, "Source node ID", func() {
+		Example("4")
+	})
+	Attribute("from_output", Int, "Output index on the source node", func() {
+		Example(0)
+	})
+	Attribute("to_node", String, "Target node ID", func() {
+		Example("3")
+	})
+	Attribute("to_input", String, "Input name on the target node", func() {
+		Example("model")
+	})
+	Required("from_node", "from_output", "to_node", "to_input")
 })
@@ -15,70 +15,226 @@ var _ = Service("ws", func() {
 			Response(StatusOK)
 		})
 	})
+
+	Method("subscribe_v2", func() {
+		Description("Subscribe to events via WebSocket with protocol version 2: every message is a WSMessage envelope, and the client selects the events it receives. The initial subscription is taken from the query parameters; the client replaces it by sending a WSSubscription message. A client without a subscription receives every event.")
+		Payload(func() {
+			Attribute("job_id", ArrayOf(String), "Jobs whose events are received", func() {
+				Example([]string{"550e8400-e29b-41d4-a716-446655440000"})
+			})
+			Attribute("training_run", ArrayOf(String), "Training runs whose job and sample events are received", func() {
+				Example([]string{"psai4rt-v0.3.0-no-reg"})
+			})
+			Attribute("fs_events", Boolean, "Receive every filesystem event", func() {
+				Default(false)
+			})
+		})
+		StreamingPayload(WSSubscription)
+		StreamingResult(WSMessage)
+		HTTP(func() {
+			GET("/api/ws/v2")
+			Param("job_id")
+			Param("training_run")
+			Param("fs_events")
+			Response(StatusOK)
+		})
+	})
+
+	Method("stats", func() {
+		Description("Get the number of connected WebSocket clients and how many events and clients were dropped because clients could not keep up (for debugging)")
+		Result(WSStatsResponse)
+		HTTP(func() {
+			GET("/api/ws/stats")
+			Response(StatusOK)
+		})
+	})
+})
+
+var WSStatsResponse = Type("WSStatsResponse", func() {
+	Description("WebSocket hub counters since the server started")
+	Attribute("client_count", Int, "Connected WebSocket clients", func() {
+		Example(3)
+	})
+	Attribute("dropped_messages", Int64, "Events not delivered because a client's send queue was full or a write to it failed", func() {
+		Example(0)
+	})
+	Attribute("evicted_clients", Int64, "Clients disconnected because they could not keep up", func() {
+		Example(0)
+	})
+	Required("client_count", "dropped_messages", "evicted_clients")
+})
+
+var WSSubscription = Type("WSSubscription", func() {
+	Description("The events a protocol v2 WebSocket client receives: events of the listed jobs, events of the listed training runs, and with fs_events every filesystem event. An empty subscription receives every event.")
+	Attribute("job_ids", ArrayOf(String), "Jobs whose job_progress and inference_progress events are received", func() {
+		Example([]string{"550e8400-e29b-41d4-a716-446655440000"})
+	})
+	Attribute("training_runs", ArrayOf(String), "Training runs whose job events and image and directory events are received", func() {
+		Example([]string{"psai4rt-v0.3.0-no-reg"})
+	})
+	Attribute("fs_events", Boolean, "Receive every filesystem event (image_*, directory_added, checkpoint_*)", func() {
+		Default(false)
+	})
+})
+
+var WSMessage = Type("WSMessage", func() {
+	Description("A protocol v2 WebSocket message. connected is sent once when the client connects and subscribed after each subscription change, both carrying the subscription in effect; every other type carries the event in payload.")
+	Attribute("version", Int, "Protocol version", func() {
+		Enum(2)
+		Example(2)
+	})
+	Attribute("type", String, "Message type", func() {
+		Enum(wsV2MessageTypes...)
+		Example("job_progress")
+	})
+	Attribute("job_id", String, "Job the event belongs to, if any", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("training_run", String, "Training run the event belongs to, if known", func() {
+		Example("psai4rt-v0.3.0-no-reg")
+	})
+	Attribute("payload", FSEventResponse, "The event (absent for connected and subscribed)")
+	Attribute("subscription", WSSubscription, "The subscription in effect (only for connected and subscribed)")
+	Required("version", "type")
 })
 
 var CheckpointCompletenessInfo = Type("CheckpointCompletenessInfo", func() {
 	Description("Result of verifying expected images exist on disk for a completed checkpoint")
-	Attribute("checkpoint", String, "Checkpoint filename")
-	Attribute("expected", Int, "Number of expected images")
-	Attribute("verified", Int, "Number of images verified on disk")
-	Attribute("missing", Int, "Number of missing images")
+	Attribute("checkpoint", String, "Checkpoint filename", func() {
+		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Attribute("expected", Int, "Number of expected images", func() {
+		Example(108)
+	})
+	Attribute("verified", Int, "Number of images verified on disk", func() {
+		Example(107)
+	})
+	Attribute("missing", Int, "Number of missing images", func() {
+		Example(1)
+	})
 	Required("checkpoint", "expected", "verified", "missing")
 })
 
 var WSFailedItemDetail = Type("WSFailedItemDetail", func() {
 	Description("Details of a failed checkpoint in a job progress WebSocket event")
-	Attribute("checkpoint_filename", String, "Checkpoint filename that failed")
-	Attribute("error_message", String, "Error message describing the failure")
-	Attribute("exception_type", String, "Python exception type (e.g. RuntimeError)")
-	Attribute("node_type", String, "ComfyUI node type that failed (e.g. VAEDecode)")
-	Attribute("traceback", String, "Full Python stack trace")
+	Attribute("checkpoint_filename", String, "Checkpoint filename that failed", func() {
+		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Attribute("error_message", String, "Error message describing the failure", func() {
+		Example("[RuntimeError] VAEDecode: sizes must match")
+	})
+	Attribute("exception_type", String, "Python exception type (e.g. RuntimeError)", func() {
+		Example("RuntimeError")
+	})
+	Attribute("node_type", String, "ComfyUI node type that failed (e.g. VAEDecode)", func() {
+		Example("VAEDecode")
+	})
+	Attribute("traceback", String, "Full Python stack trace", func() {
+		Example("Traceback (most recent call last):\n  File \"execution.py\", line 151, in recursive_execute\nRuntimeError: sizes must match")
+	})
 	Required("checkpoint_filename", "error_message")
 })
 
 var WSSampleParams = Type("WSSampleParams", func() {
 	Description("Generation parameters for the sample currently being generated (only present when a sample is actively running)")
-	Attribute("checkpoint_filename", String, "Checkpoint filename being sampled (e.g. step-000010.safetensors)")
-	Attribute("prompt_name", String, "Named prompt slot in use (e.g. forest)")
-	Attribute("cfg", Float64, "Classifier-free guidance scale (floating-point)")
-	Attribute("steps", Int, "Number of sampler steps (integer)")
-	Attribute("sampler_name", String, "ComfyUI sampler name (e.g. euler)")
-	Attribute("scheduler", String, "ComfyUI scheduler name (e.g. normal)")
-	Attribute("seed", Int64, "Generation seed")
-	Attribute("width", Int, "Output image width in pixels")
-	Attribute("height", Int, "Output image height in pixels")
+	Attribute("checkpoint_filename", String, "Checkpoint filename being sampled (e.g. step-000010.safetensors)", func() {
+		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Attribute("prompt_name", String, "Named prompt slot in use (e.g. forest)", func() {
+		Example("forest")
+	})
+	Attribute("cfg", Float64, "Classifier-free guidance scale (floating-point)", func() {
+		Example(4.5)
+	})
+	Attribute("steps", Int, "Number of sampler steps (integer)", func() {
+		Example(30)
+	})
+	Attribute("sampler_name", String, "ComfyUI sampler name (e.g. euler)", func() {
+		Example("euler")
+	})
+	Attribute("scheduler", String, "ComfyUI scheduler name (e.g. normal)", func() {
+		Example("normal")
+	})
+	Attribute("seed", Int64, "Generation seed", func() {
+		Example(420)
+	})
+	Attribute("width", Int, "Output image width in pixels", func() {
+		Example(1024)
+	})
+	Attribute("height", Int, "Output image height in pixels", func() {
+		Example(1024)
+	})
 	Required("checkpoint_filename", "prompt_name", "cfg", "steps", "sampler_name", "scheduler", "seed", "width", "height")
 })
 
 var FSEventResponse = Type("FSEventResponse", func() {
-	Description("A filesystem change event or job progress update pushed to WebSocket clients")
+	Description("A message pushed to WebSocket clients. Which optional fields are present depends on type: filesystem events (image_*, directory_added, checkpoint_*) carry only path; job_progress carries the job fields; inference_progress carries prompt_id, current_value, and max_value; connected is sent once with an empty path when the client subscribes.")
 	Attribute("type", String, "Event type", func() {
-		Enum("image_added", "image_removed", "directory_added", "job_progress", "inference_progress")
+		Enum(wsEventTypes...)
 		Example("image_added")
 	})
-	Attribute("path", String, "Path relative to the sample directory", func() {
+	Attribute("path", String, "Path relative to the sample directory, or for checkpoint events relative to the checkpoint directory", func() {
 		Example("checkpoint.safetensors/index=0&prompt_name=forest&seed=420&cfg=1&_00001_.png")
 	})
 	// Job progress fields (only present when type=job_progress)
-	Attribute("job_id", String, "Job ID (only for job_progress events)")
-	Attribute("status", String, "Job status (only for job_progress events)")
-	Attribute("total_items", Int, "Total work items (only for job_progress events)")
-	Attribute("completed_items", Int, "Completed work items (only for job_progress events)")
-	Attribute("failed_items", Int, "Failed work items (only for job_progress events)")
-	Attribute("pending_items", Int, "Pending work items (only for job_progress events)")
-	Attribute("checkpoints_completed", Int, "Fully completed checkpoints (only for job_progress events)")
-	Attribute("total_checkpoints", Int, "Total checkpoints (only for job_progress events)")
-	Attribute("current_checkpoint", String, "Current checkpoint being processed (only for job_progress events)")
-	Attribute("current_checkpoint_progress", Int, "Items completed in current checkpoint (only for job_progress events)")
-	Attribute("current_checkpoint_total", Int, "Total items in current checkpoint (only for job_progress events)")
+	Attribute("job_id", String, "Job ID (only for job_progress events)", func() {
+		Example("550e8400-e29b-41d4-a716-446655440000")
+	})
+	Attribute("status", String, "Job status (only for job_progress events)", func() {
+		Enum(jobStatuses...)
+		Example("running")
+	})
+	Attribute("total_items", Int, "Total work items (only for job_progress events)", func() {
+		Example(540)
+	})
+	Attribute("completed_items", Int, "Completed work items (only for job_progress events)", func() {
+		Example(120)
+	})
+	Attribute("failed_items", Int, "Failed work items (only for job_progress events)", func() {
+		Example(5)
+	})
+	Attribute("pending_items", Int, "Pending work items (only for job_progress events)", func() {
+		Example(415)
+	})
+	Attribute("skipped_items", MapOf(String, Int), "Skipped work items, which failed_items includes, by skip reason (only for job_progress events, omitted when no item was skipped)", func() {
+		Key(func() {
+			Enum(jobItemSkipReasons...)
+		})
+		Example(map[string]int{"checkpoint_not_found": 5})
+	})
+	Attribute("checkpoints_completed", Int, "Fully completed checkpoints (only for job_progress events)", func() {
+		Example(2)
+	})
+	Attribute("total_checkpoints", Int, "Total checkpoints (only for job_progress events)", func() {
+		Example(5)
+	})
+	Attribute("current_checkpoint", String, "Current checkpoint being processed (only for job_progress events)", func() {
+		Example("psai4rt-v0.3.0-no-reg-step00004500.safetensors")
+	})
+	Attribute("current_checkpoint_progress", Int, "Items completed in current checkpoint (only for job_progress events)", func() {
+		Example(30)
+	})
+	Attribute("current_checkpoint_total", Int, "Total items in current checkpoint (only for job_progress events)", func() {
+		Example(108)
+	})
 	Attribute("checkpoint_completeness", ArrayOf(CheckpointCompletenessInfo), "Per-checkpoint completeness verification results (only for job_progress events)")
 	Attribute("failed_item_details", ArrayOf(WSFailedItemDetail), "Details of failed checkpoints with error info (only for job_progress events)")
-	Attribute("sample_eta_seconds", Float64, "Estimated seconds remaining for the current sample (only for job_progress events, 0 if unavailable)")
-	Attribute("job_eta_seconds", Float64, "Estimated seconds remaining for the entire job (only for job_progress events, 0 if unavailable)")
+	Attribute("sample_eta_seconds", Float64, "Estimated seconds remaining for the current sample (only for job_progress events, 0 if unavailable)", func() {
+		Example(12.5)
+	})
+	Attribute("job_eta_seconds", Float64, "Estimated seconds remaining for the entire job (only for job_progress events, 0 if unavailable)", func() {
+		Example(5400)
+	})
 	Attribute("current_sample_params", WSSampleParams, "Generation parameters for the currently generating sample (only present when a sample is actively running)")
 	// Inference progress fields (only present when type=inference_progress)
-	Attribute("prompt_id", String, "ComfyUI prompt ID (only for inference_progress events)")
-	Attribute("current_value", Int, "Current inference step (only for inference_progress events)")
-	Attribute("max_value", Int, "Total inference steps (only for inference_progress events)")
+	Attribute("prompt_id", String, "ComfyUI prompt ID (only for inference_progress events)", func() {
+		Example("8c1f4bb2-6a57-4b8e-9d0e-3f1f5e9f2a11")
+	})
+	Attribute("current_value", Int, "Current inference step (only for inference_progress events)", func() {
+		Example(12)
+	})
+	Attribute("max_value", Int, "Total inference steps (only for inference_progress events)", func() {
+		Example(30)
+	})
 	Required("type", "path")
 })
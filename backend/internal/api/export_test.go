@@ -2,9 +2,12 @@ package api
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 )
 
 // RunPingLoopForTest exposes the internal runPingLoop function for unit testing.
@@ -12,3 +15,9 @@ import (
 func RunPingLoopForTest(conn PingableConn, interval time.Duration, cancel context.CancelFunc, logger *logrus.Logger) {
 	runPingLoop(conn, interval, cancel, logger)
 }
+
+// NewRateLimitMiddlewareForTest exposes RateLimitMiddleware with an injectable
+// clock for unit testing.
+func NewRateLimitMiddlewareForTest(cfg model.RateLimitConfig, logger *logrus.Logger, now func() time.Time) func(http.Handler) http.Handler {
+	return newRateLimiter(cfg, logger, now).middleware
+}
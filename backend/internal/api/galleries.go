@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	gengalleries "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/galleries"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// publicGalleryCacheControl lets browsers and CDNs cache the public view
+	// briefly, so that unpublishing takes effect within a few minutes.
+	publicGalleryCacheControl = "public, max-age=300"
+	// publicGalleryImageCacheControl caches gallery images indefinitely; a
+	// gallery's images never change once published.
+	publicGalleryImageCacheControl = "public, max-age=31536000, immutable"
+)
+
+// GalleriesService implements the generated galleries service interface.
+type GalleriesService struct {
+	svc       *service.GalleryService
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewGalleriesService returns a new GalleriesService.
+func NewGalleriesService(svc *service.GalleryService, sampleDir string, logger *logrus.Logger) *GalleriesService {
+	return &GalleriesService{
+		svc:       svc,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "galleries_service"),
+	}
+}
+
+// Publish snapshots a training run's images into a new public gallery.
+func (s *GalleriesService) Publish(ctx context.Context, p *gengalleries.PublishPayload) (*gengalleries.GalleryResponse, error) {
+	req := model.GalleryPublishRequest{
+		TrainingRunID: p.ID,
+		Filters:       p.Filters,
+		Dimensions:    p.Dimensions,
+	}
+	if p.StudyName != nil {
+		req.StudyName = *p.StudyName
+	}
+	if p.Title != nil {
+		req.Title = *p.Title
+	}
+	g, err := s.svc.Publish(ctx, req)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gengalleries.MakeNotFound(err)
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			return nil, gengalleries.MakeBadRequest(err)
+		}
+		return nil, gengalleries.MakeInternalError(err)
+	}
+	return galleryToResponse(g), nil
+}
+
+// List returns all published galleries.
+func (s *GalleriesService) List(ctx context.Context) ([]*gengalleries.GalleryResponse, error) {
+	galleries, err := s.svc.List(ctx)
+	if err != nil {
+		return nil, gengalleries.MakeInternalError(err)
+	}
+	result := make([]*gengalleries.GalleryResponse, len(galleries))
+	for i, g := range galleries {
+		result[i] = galleryToResponse(g)
+	}
+	return result, nil
+}
+
+// Unpublish removes a published gallery.
+func (s *GalleriesService) Unpublish(ctx context.Context, p *gengalleries.UnpublishPayload) error {
+	if err := s.svc.Unpublish(ctx, p.ID); err != nil {
+		if isNotFound(err) {
+			return gengalleries.MakeNotFound(err)
+		}
+		return gengalleries.MakeInternalError(err)
+	}
+	return nil
+}
+
+// ShowPublic returns the public view of a gallery. Only the title, training
+// run name, dimensions, and image URLs are exposed.
+func (s *GalleriesService) ShowPublic(ctx context.Context, p *gengalleries.ShowPublicPayload) (*gengalleries.PublicGalleryResult, error) {
+	g, err := s.svc.GetBySlug(ctx, p.Slug)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gengalleries.MakeNotFound(fmt.Errorf("gallery not found"))
+		}
+		return nil, err
+	}
+
+	images := make([]*gengalleries.PublicGalleryImageResponse, len(g.Images))
+	for i, img := range g.Images {
+		images[i] = &gengalleries.PublicGalleryImageResponse{
+			URL:        fmt.Sprintf("%s/images/%d", publicGalleryPath(g.Slug), i),
+			Dimensions: img.Dimensions,
+		}
+	}
+	return &gengalleries.PublicGalleryResult{
+		Title:           g.Title,
+		TrainingRunName: g.TrainingRunName,
+		PublishedAt:     g.CreatedAt.Format(time.RFC3339),
+		Dimensions:      galleryDimensionsToResponse(g.Dimensions),
+		Images:          images,
+		CacheControl:    publicGalleryCacheControl,
+	}, nil
+}
+
+// PublicImage streams an image of a published gallery. Unknown galleries,
+// out-of-range indexes, and images removed from disk are all reported as
+// not found.
+func (s *GalleriesService) PublicImage(ctx context.Context, p *gengalleries.PublicImagePayload) (*gengalleries.ImageDownloadResult, io.ReadCloser, error) {
+	relPath, err := s.svc.ImagePath(ctx, p.Slug, p.Index)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil, gengalleries.MakeNotFound(fmt.Errorf("image not found"))
+		}
+		return nil, nil, err
+	}
+
+	file, info, contentType, err := openSampleImage(s.sampleDir, relPath, s.logger)
+	if err != nil {
+		if errors.Is(err, errInvalidImagePath) {
+			s.logger.WithField("slug", p.Slug).Error("gallery references an invalid image path")
+		}
+		return nil, nil, gengalleries.MakeNotFound(fmt.Errorf("image not found"))
+	}
+	return &gengalleries.ImageDownloadResult{
+		ContentType:   contentType,
+		ContentLength: info.Size(),
+		CacheControl:  publicGalleryImageCacheControl,
+	}, file, nil
+}
+
+// publicGalleryPath returns the path of the public view of a gallery.
+func publicGalleryPath(slug string) string {
+	return "/api/public/galleries/" + slug
+}
+
+func galleryToResponse(g model.Gallery) *gengalleries.GalleryResponse {
+	filters := g.Filters
+	if filters == nil {
+		filters = map[string][]string{}
+	}
+	return &gengalleries.GalleryResponse{
+		ID:              g.ID,
+		Slug:            g.Slug,
+		PublicURL:       publicGalleryPath(g.Slug),
+		Title:           g.Title,
+		TrainingRunName: g.TrainingRunName,
+		StudyName:       g.StudyName,
+		Filters:         filters,
+		Dimensions:      galleryDimensionsToResponse(g.Dimensions),
+		ImageCount:      len(g.Images),
+		CreatedAt:       g.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func galleryDimensionsToResponse(dims []model.Dimension) []*gengalleries.DimensionResponse {
+	result := make([]*gengalleries.DimensionResponse, len(dims))
+	for i, d := range dims {
+		values := d.Values
+		if values == nil {
+			values = []string{}
+		}
+		result[i] = &gengalleries.DimensionResponse{
+			Name:   d.Name,
+			Type:   string(d.Type),
+			Values: values,
+		}
+	}
+	return result
+}
@@ -0,0 +1,171 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	gengalleries "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/galleries"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("GalleriesService", func() {
+	var (
+		svc       *api.GalleriesService
+		st        *store.Store
+		tmpDir    string
+		sampleDir string
+	)
+
+	writeSample := func(ckpt, name string) {
+		dir := filepath.Join(sampleDir, ckpt)
+		Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, name), buildTestMinimalPNG(), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "galleries-api-test-*")
+		Expect(err).NotTo(HaveOccurred())
+		sampleDir = filepath.Join(tmpDir, "samples")
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		writeSample("model-step00001000.safetensors", "seed=1&prompt_name=forest&_00001_.png")
+		writeSample("model-step00001000.safetensors", "seed=2&prompt_name=forest&_00001_.png")
+		writeSample("model-step00002000.safetensors", "seed=1&prompt_name=forest&_00001_.png")
+
+		fs := store.NewFileSystem(logger)
+		gallerySvc := service.NewGalleryService(
+			st,
+			service.NewViewerDiscoveryService(fs, sampleDir, logger),
+			service.NewScanner(fs, sampleDir, logger),
+			logger,
+		)
+		svc = api.NewGalleriesService(gallerySvc, sampleDir, logger)
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	publish := func(filters map[string][]string) *gengalleries.GalleryResponse {
+		title := "My LoRA"
+		resp, err := svc.Publish(context.Background(), &gengalleries.PublishPayload{
+			ID:      0,
+			Title:   &title,
+			Filters: filters,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	It("publishes a filtered snapshot and lists it", func() {
+		resp := publish(map[string][]string{"seed": {"1"}})
+		Expect(resp.Title).To(Equal("My LoRA"))
+		Expect(resp.ImageCount).To(Equal(2))
+		Expect(resp.PublicURL).To(Equal("/api/public/galleries/" + resp.Slug))
+		Expect(resp.Filters).To(HaveKeyWithValue("seed", []string{"1"}))
+
+		list, err := svc.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].ID).To(Equal(resp.ID))
+	})
+
+	It("serves the public view without paths and with cache headers", func() {
+		resp := publish(nil)
+
+		view, err := svc.ShowPublic(context.Background(), &gengalleries.ShowPublicPayload{Slug: resp.Slug})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(view.Title).To(Equal("My LoRA"))
+		Expect(view.CacheControl).To(Equal("public, max-age=300"))
+		Expect(view.Images).To(HaveLen(3))
+		Expect(view.Images[0].URL).To(Equal("/api/public/galleries/" + resp.Slug + "/images/0"))
+		for _, img := range view.Images {
+			Expect(img.URL).NotTo(ContainSubstring("safetensors/"))
+		}
+	})
+
+	It("streams a gallery image by index", func() {
+		resp := publish(nil)
+
+		result, body, err := svc.PublicImage(context.Background(), &gengalleries.PublicImagePayload{Slug: resp.Slug, Index: 1})
+		Expect(err).NotTo(HaveOccurred())
+		defer body.Close()
+		Expect(result.ContentType).To(Equal("image/png"))
+		Expect(result.CacheControl).To(Equal("public, max-age=31536000, immutable"))
+		data, err := io.ReadAll(body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal(buildTestMinimalPNG()))
+	})
+
+	DescribeTable("returns not_found from the public routes",
+		func(useSlug bool, index int, removeFiles bool) {
+			resp := publish(nil)
+			slug := "unknown"
+			if useSlug {
+				slug = resp.Slug
+			}
+			if removeFiles {
+				Expect(os.RemoveAll(sampleDir)).To(Succeed())
+			}
+			_, _, err := svc.PublicImage(context.Background(), &gengalleries.PublicImagePayload{Slug: slug, Index: index})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		},
+		Entry("unknown slug", false, 0, false),
+		Entry("index out of range", true, 3, false),
+		Entry("image removed from disk", true, 0, true),
+	)
+
+	It("returns not_found for an unknown public gallery", func() {
+		_, err := svc.ShowPublic(context.Background(), &gengalleries.ShowPublicPayload{Slug: "unknown"})
+		serviceErr, ok := err.(errorNamer)
+		Expect(ok).To(BeTrue())
+		Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+	})
+
+	DescribeTable("maps publish errors",
+		func(payload *gengalleries.PublishPayload, errName string) {
+			_, err := svc.Publish(context.Background(), payload)
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal(errName))
+		},
+		Entry("unknown training run", &gengalleries.PublishPayload{ID: 5}, "not_found"),
+		Entry("unknown dimension", &gengalleries.PublishPayload{Filters: map[string][]string{"cfg": {"7"}}}, "bad_request"),
+		Entry("no matching images", &gengalleries.PublishPayload{Filters: map[string][]string{"seed": {"9"}}}, "bad_request"),
+	)
+
+	It("unpublishes a gallery", func() {
+		resp := publish(nil)
+		Expect(svc.Unpublish(context.Background(), &gengalleries.UnpublishPayload{ID: resp.ID})).To(Succeed())
+
+		_, err := svc.ShowPublic(context.Background(), &gengalleries.ShowPublicPayload{Slug: resp.Slug})
+		Expect(err).To(HaveOccurred())
+
+		err = svc.Unpublish(context.Background(), &gengalleries.UnpublishPayload{ID: resp.ID})
+		serviceErr, ok := err.(errorNamer)
+		Expect(ok).To(BeTrue())
+		Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+	})
+})
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	samplejobspb "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/grpc/sample_jobs/pb"
+	gensamplejobsgrpcsvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/grpc/sample_jobs/server"
+	studiespb "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/grpc/studies/pb"
+	genstudiesgrpcsvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/grpc/studies/server"
+	trainingrunspb "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/grpc/training_runs/pb"
+	gentrainingrunsgrpcsvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/grpc/training_runs/server"
+	gensamplejobs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sample_jobs"
+	genstudies "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/studies"
+	gentrainingruns "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/training_runs"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// GRPCServerConfig holds the endpoints served over gRPC. They are the same
+// endpoints the HTTP transport serves.
+type GRPCServerConfig struct {
+	SampleJobsEndpoints   *gensamplejobs.Endpoints
+	StudiesEndpoints      *genstudies.Endpoints
+	TrainingRunsEndpoints *gentrainingruns.Endpoints
+	Logger                *logrus.Logger
+	// Users are the users calls act for by sending their token in their
+	// authorization metadata; see HTTPHandlerConfig.Users.
+	Users []model.UserConfig
+}
+
+// NewGRPCServer creates a gRPC server for the sample_jobs, studies, and
+// training_runs services. The caller is responsible for serving it on a
+// listener and stopping it.
+func NewGRPCServer(cfg GRPCServerConfig) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{grpcLogInterceptor(cfg.Logger)}
+	if len(cfg.Users) > 0 {
+		interceptors = append(interceptors, grpcUserAuthInterceptor(cfg.Users, cfg.Logger))
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	samplejobspb.RegisterSampleJobsServer(srv, gensamplejobsgrpcsvr.New(cfg.SampleJobsEndpoints, nil))
+	studiespb.RegisterStudiesServer(srv, genstudiesgrpcsvr.New(cfg.StudiesEndpoints, nil))
+	trainingrunspb.RegisterTrainingRunsServer(srv, gentrainingrunsgrpcsvr.New(cfg.TrainingRunsEndpoints, nil))
+	return srv
+}
+
+// grpcLogInterceptor logs each gRPC call with its status code and duration,
+// at error level for server-side failures as ErrorLoggingMiddleware does for
+// HTTP 5xx responses.
+func grpcLogInterceptor(logger *logrus.Logger) grpc.UnaryServerInterceptor {
+	log := logger.WithField("component", "grpc")
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+		entry := log.WithFields(logrus.Fields{
+			"method":   info.FullMethod,
+			"code":     code.String(),
+			"duration": time.Since(start).String(),
+		})
+		if isServerError(code) {
+			entry.WithError(err).Error("gRPC request failed")
+		} else {
+			entry.Info("gRPC request")
+		}
+		return resp, err
+	}
+}
+
+// isServerError reports whether a gRPC status code is a failure of the server
+// rather than of the request.
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
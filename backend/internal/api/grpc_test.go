@@ -0,0 +1,87 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	goa "goa.design/goa/v3/pkg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	trainingrunspb "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/grpc/training_runs/pb"
+	gensamplejobs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sample_jobs"
+	genstudies "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/studies"
+	gentrainingruns "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/training_runs"
+)
+
+var _ = Describe("gRPC server", func() {
+	var (
+		list        goa.Endpoint
+		changeCurve goa.Endpoint
+		client      trainingrunspb.TrainingRunsClient
+	)
+
+	BeforeEach(func() {
+		// The server binds the endpoints when it is created; each test sets
+		// the behavior behind them.
+		endpoints := &gentrainingruns.Endpoints{
+			List:        func(ctx context.Context, req any) (any, error) { return list(ctx, req) },
+			ChangeCurve: func(ctx context.Context, req any) (any, error) { return changeCurve(ctx, req) },
+		}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		srv := api.NewGRPCServer(api.GRPCServerConfig{
+			SampleJobsEndpoints:   &gensamplejobs.Endpoints{},
+			StudiesEndpoints:      &genstudies.Endpoints{},
+			TrainingRunsEndpoints: endpoints,
+			Logger:                logger,
+		})
+
+		// Serve over an in-memory listener so that no network is used.
+		lis := bufconn.Listen(1 << 20)
+		go srv.Serve(lis) //nolint:errcheck
+		DeferCleanup(srv.Stop)
+
+		conn, err := grpc.NewClient("passthrough:///bufconn",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return lis.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(conn.Close)
+		client = trainingrunspb.NewTrainingRunsClient(conn)
+	})
+
+	It("serves the endpoints of a service", func() {
+		list = func(ctx context.Context, req any) (any, error) {
+			Expect(req.(*gentrainingruns.ListPayload).Source).To(Equal("checkpoints"))
+			return []*gentrainingruns.TrainingRunResponse{{ID: 0, Name: "my-lora", CheckpointCount: 3}}, nil
+		}
+
+		source := "checkpoints"
+		resp, err := client.List(context.Background(), &trainingrunspb.ListRequest{Source: &source})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.GetField()).To(HaveLen(1))
+		Expect(resp.GetField()[0].GetName()).To(Equal("my-lora"))
+		Expect(resp.GetField()[0].GetCheckpointCount()).To(Equal(int32(3)))
+	})
+
+	It("maps design errors to gRPC status codes", func() {
+		changeCurve = func(ctx context.Context, req any) (any, error) {
+			return nil, gentrainingruns.MakeNotFound(errors.New("fewer than two checkpoints have images"))
+		}
+
+		_, err := client.ChangeCurve(context.Background(), &trainingrunspb.ChangeCurveRequest{TrainingRun: "my-lora"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+})
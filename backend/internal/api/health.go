@@ -2,19 +2,192 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	genhealth "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/health"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 )
 
+// ExecutorLeaseStatus exposes the executor lease state reported by the
+// executor health endpoint in multi-process mode.
+type ExecutorLeaseStatus interface {
+	InstanceID() string
+	Held() bool
+	Current(ctx context.Context) (*model.ProcessLease, error)
+}
+
+// WatcherStatsSource exposes the filesystem watcher's event counters.
+type WatcherStatsSource interface {
+	Stats() model.WatcherStats
+}
+
+// DBStatsSource exposes the store's database activity.
+type DBStatsSource interface {
+	DBStats() model.DBStats
+}
+
+// ReadinessSource reports whether the server's startup steps have completed.
+type ReadinessSource interface {
+	Status() model.ReadinessStatus
+}
+
 // HealthService implements the generated health service interface.
-type HealthService struct{}
+type HealthService struct {
+	executorEnabled bool
+	role            model.ProcessRole
+	lease           ExecutorLeaseStatus
+	watcher         WatcherStatsSource // nil when no watcher is configured
+	db              DBStatsSource      // nil when no store is configured
+	readiness       ReadinessSource    // nil when startup is not tracked
+	timeNow         func() time.Time
+}
 
 // NewHealthService returns a new HealthService.
 func NewHealthService() *HealthService {
-	return &HealthService{}
+	return &HealthService{timeNow: time.Now}
+}
+
+// SetExecutorStatus configures what the executor method reports.
+// executorEnabled is whether this process has a job executor at all (ComfyUI
+// configured). lease is nil in single-process mode; otherwise role and lease
+// determine whether this process is the elected executor.
+func (s *HealthService) SetExecutorStatus(executorEnabled bool, role model.ProcessRole, lease ExecutorLeaseStatus) {
+	s.executorEnabled = executorEnabled
+	s.role = role
+	s.lease = lease
+}
+
+// SetWatcherStats sets the watcher whose counters the watcher method reports.
+func (s *HealthService) SetWatcherStats(watcher WatcherStatsSource) {
+	s.watcher = watcher
+}
+
+// SetDBStats sets the store whose database activity the db method reports.
+func (s *HealthService) SetDBStats(db DBStatsSource) {
+	s.db = db
+}
+
+// SetReadiness sets the startup tracker whose state the ready method reports.
+func (s *HealthService) SetReadiness(readiness ReadinessSource) {
+	s.readiness = readiness
 }
 
 // Check returns the health status of the service.
 func (s *HealthService) Check(ctx context.Context) (*genhealth.HealthResult, error) {
 	return &genhealth.HealthResult{Status: "ok"}, nil
 }
+
+// Live reports that the process is alive. It succeeds whenever the process
+// can answer, including while it is still starting up.
+func (s *HealthService) Live(ctx context.Context) (*genhealth.HealthResult, error) {
+	return &genhealth.HealthResult{Status: "ok"}, nil
+}
+
+// Ready reports whether every startup step has completed, with the state of
+// each step. The transport responds 503 while the status is not_ready. The
+// service is ready without any checks when no startup tracker is set.
+func (s *HealthService) Ready(ctx context.Context) (*genhealth.ReadinessResult, error) {
+	if s.readiness == nil {
+		return &genhealth.ReadinessResult{Status: "ready", Checks: []*genhealth.ReadinessCheckResponse{}}, nil
+	}
+	status := s.readiness.Status()
+	result := &genhealth.ReadinessResult{
+		Status: "ready",
+		Checks: make([]*genhealth.ReadinessCheckResponse, len(status.Checks)),
+	}
+	if !status.Ready {
+		result.Status = "not_ready"
+	}
+	for i, c := range status.Checks {
+		result.Checks[i] = &genhealth.ReadinessCheckResponse{Name: c.Name, Ready: c.Ready}
+	}
+	return result, nil
+}
+
+// Executor reports whether this process runs the job executor and, in
+// multi-process mode, which process currently holds the executor lease.
+func (s *HealthService) Executor(ctx context.Context) (*genhealth.ExecutorStatusResult, error) {
+	if s.lease == nil {
+		return &genhealth.ExecutorStatusResult{
+			Mode:       "single",
+			IsExecutor: s.executorEnabled,
+		}, nil
+	}
+
+	current, err := s.lease.Current(ctx)
+	if err != nil {
+		return nil, genhealth.MakeInternalError(fmt.Errorf("fetching executor lease: %w", err))
+	}
+
+	instanceID := s.lease.InstanceID()
+	role := string(s.role)
+	result := &genhealth.ExecutorStatusResult{
+		Mode:       "multi_process",
+		InstanceID: &instanceID,
+		Role:       &role,
+		IsExecutor: s.executorEnabled && s.role.RunsExecutor() && s.lease.Held(),
+	}
+	if current != nil {
+		result.Lease = &genhealth.ExecutorLeaseResponse{
+			Holder:     current.Holder,
+			AcquiredAt: current.AcquiredAt.UTC().Format(time.RFC3339),
+			RenewedAt:  current.RenewedAt.UTC().Format(time.RFC3339),
+			ExpiresAt:  current.ExpiresAt.UTC().Format(time.RFC3339),
+			Expired:    !s.timeNow().Before(current.ExpiresAt),
+		}
+	}
+	return result, nil
+}
+
+// Watcher reports the filesystem watcher's event counters. All counters are
+// zero when no watcher is configured.
+func (s *HealthService) Watcher(ctx context.Context) (*genhealth.WatcherStatsResult, error) {
+	if s.watcher == nil {
+		return &genhealth.WatcherStatsResult{}, nil
+	}
+	stats := s.watcher.Stats()
+	return &genhealth.WatcherStatsResult{
+		EventsReceived:   stats.EventsReceived,
+		EventsCoalesced:  stats.EventsCoalesced,
+		Overflows:        stats.Overflows,
+		RescansTriggered: stats.RescansTriggered,
+	}, nil
+}
+
+// Db reports the store's query counts and durations and the state of the
+// connection pool. All counters are zero when no store is configured.
+func (s *HealthService) Db(ctx context.Context) (*genhealth.DBStatsResult, error) {
+	if s.db == nil {
+		return &genhealth.DBStatsResult{Statements: []*genhealth.DBStatementStatsResponse{}}, nil
+	}
+	stats := s.db.DBStats()
+	result := &genhealth.DBStatsResult{
+		Queries:              stats.Queries,
+		Errors:               stats.Errors,
+		TotalDurationMs:      durationMs(stats.TotalDuration),
+		SlowQueries:          stats.SlowQueries,
+		SlowQueryThresholdMs: durationMs(stats.SlowQueryThreshold),
+		OpenConnections:      stats.OpenConnections,
+		InUse:                stats.InUse,
+		Idle:                 stats.Idle,
+		WaitCount:            stats.WaitCount,
+		WaitDurationMs:       durationMs(stats.WaitDuration),
+		Statements:           make([]*genhealth.DBStatementStatsResponse, len(stats.Statements)),
+	}
+	for i, st := range stats.Statements {
+		result.Statements[i] = &genhealth.DBStatementStatsResponse{
+			Query:           st.Query,
+			Count:           st.Count,
+			Errors:          st.Errors,
+			TotalDurationMs: durationMs(st.TotalDuration),
+			MaxDurationMs:   durationMs(st.MaxDuration),
+		}
+	}
+	return result, nil
+}
+
+// durationMs converts d to fractional milliseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -2,13 +2,51 @@ package api_test
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 )
 
+// fakeExecutorLeaseStatus is a test double for api.ExecutorLeaseStatus.
+type fakeExecutorLeaseStatus struct {
+	instanceID string
+	held       bool
+	current    *model.ProcessLease
+	err        error
+}
+
+func (f *fakeExecutorLeaseStatus) InstanceID() string { return f.instanceID }
+func (f *fakeExecutorLeaseStatus) Held() bool         { return f.held }
+func (f *fakeExecutorLeaseStatus) Current(ctx context.Context) (*model.ProcessLease, error) {
+	return f.current, f.err
+}
+
+// fakeWatcherStats is a test double for api.WatcherStatsSource.
+type fakeWatcherStats struct {
+	stats model.WatcherStats
+}
+
+func (f *fakeWatcherStats) Stats() model.WatcherStats { return f.stats }
+
+// fakeDBStats is a test double for api.DBStatsSource.
+type fakeDBStats struct {
+	stats model.DBStats
+}
+
+func (f *fakeDBStats) DBStats() model.DBStats { return f.stats }
+
+// fakeReadiness is a test double for api.ReadinessSource.
+type fakeReadiness struct {
+	status model.ReadinessStatus
+}
+
+func (f *fakeReadiness) Status() model.ReadinessStatus { return f.status }
+
 var _ = Describe("HealthService", func() {
 	var svc *api.HealthService
 
@@ -22,4 +60,187 @@ var _ = Describe("HealthService", func() {
 		Expect(result).NotTo(BeNil())
 		Expect(result.Status).To(Equal("ok"))
 	})
+
+	It("reports the process as live", func() {
+		result, err := svc.Live(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Status).To(Equal("ok"))
+	})
+
+	Describe("Ready", func() {
+		It("reports not_ready with the pending startup steps", func() {
+			svc.SetReadiness(&fakeReadiness{status: model.ReadinessStatus{
+				Checks: []model.ReadinessCheck{
+					{Name: model.ReadinessStepMigrations, Ready: true},
+					{Name: model.ReadinessStepWatcher},
+				},
+			}})
+			result, err := svc.Ready(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Status).To(Equal("not_ready"))
+			Expect(result.Checks).To(HaveLen(2))
+			Expect(result.Checks[0].Name).To(Equal("migrations"))
+			Expect(result.Checks[0].Ready).To(BeTrue())
+			Expect(result.Checks[1].Name).To(Equal("watcher"))
+			Expect(result.Checks[1].Ready).To(BeFalse())
+		})
+
+		It("reports ready once every startup step has completed", func() {
+			svc.SetReadiness(&fakeReadiness{status: model.ReadinessStatus{
+				Ready:  true,
+				Checks: []model.ReadinessCheck{{Name: model.ReadinessStepMigrations, Ready: true}},
+			}})
+			result, err := svc.Ready(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Status).To(Equal("ready"))
+		})
+
+		It("reports ready without checks when startup is not tracked", func() {
+			result, err := svc.Ready(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Status).To(Equal("ready"))
+			Expect(result.Checks).To(BeEmpty())
+		})
+	})
+
+	Describe("Executor", func() {
+		It("reports single mode with the executor running when ComfyUI is configured", func() {
+			svc.SetExecutorStatus(true, "", nil)
+			result, err := svc.Executor(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Mode).To(Equal("single"))
+			Expect(result.IsExecutor).To(BeTrue())
+			Expect(result.Lease).To(BeNil())
+		})
+
+		It("reports the lease holder in multi-process mode", func() {
+			expires := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+			lease := &fakeExecutorLeaseStatus{
+				instanceID: "worker-1",
+				held:       true,
+				current: &model.ProcessLease{
+					Name:       model.ExecutorLeaseName,
+					Holder:     "worker-1",
+					AcquiredAt: expires.Add(-2 * time.Minute),
+					RenewedAt:  expires.Add(-time.Minute),
+					ExpiresAt:  expires,
+				},
+			}
+			svc.SetExecutorStatus(true, model.ProcessRoleExecutor, lease)
+
+			result, err := svc.Executor(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Mode).To(Equal("multi_process"))
+			Expect(*result.InstanceID).To(Equal("worker-1"))
+			Expect(*result.Role).To(Equal("executor"))
+			Expect(result.IsExecutor).To(BeTrue())
+			Expect(result.Lease).NotTo(BeNil())
+			Expect(result.Lease.Holder).To(Equal("worker-1"))
+			Expect(result.Lease.ExpiresAt).To(Equal(expires.Format(time.RFC3339)))
+			Expect(result.Lease.Expired).To(BeFalse())
+		})
+
+		It("never reports an api-role process as the executor", func() {
+			lease := &fakeExecutorLeaseStatus{instanceID: "api-1", held: true}
+			svc.SetExecutorStatus(true, model.ProcessRoleAPI, lease)
+
+			result, err := svc.Executor(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.IsExecutor).To(BeFalse())
+			Expect(result.Lease).To(BeNil())
+		})
+
+		It("marks a lapsed lease as expired", func() {
+			lease := &fakeExecutorLeaseStatus{
+				instanceID: "worker-2",
+				current: &model.ProcessLease{
+					Holder:    "worker-1",
+					ExpiresAt: time.Now().Add(-time.Minute),
+				},
+			}
+			svc.SetExecutorStatus(true, model.ProcessRoleAll, lease)
+
+			result, err := svc.Executor(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.IsExecutor).To(BeFalse())
+			Expect(result.Lease.Expired).To(BeTrue())
+		})
+
+		It("returns internal_error when the lease cannot be read", func() {
+			lease := &fakeExecutorLeaseStatus{instanceID: "worker-1", err: errors.New("database is locked")}
+			svc.SetExecutorStatus(true, model.ProcessRoleAll, lease)
+
+			_, err := svc.Executor(context.Background())
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("Watcher", func() {
+		It("reports the watcher's counters", func() {
+			svc.SetWatcherStats(&fakeWatcherStats{stats: model.WatcherStats{
+				EventsReceived:   120,
+				EventsCoalesced:  30,
+				Overflows:        2,
+				RescansTriggered: 2,
+			}})
+			result, err := svc.Watcher(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.EventsReceived).To(Equal(int64(120)))
+			Expect(result.EventsCoalesced).To(Equal(int64(30)))
+			Expect(result.Overflows).To(Equal(int64(2)))
+			Expect(result.RescansTriggered).To(Equal(int64(2)))
+		})
+
+		It("reports zero counters when no watcher is configured", func() {
+			result, err := svc.Watcher(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.EventsReceived).To(BeZero())
+			Expect(result.Overflows).To(BeZero())
+		})
+	})
+
+	Describe("Db", func() {
+		It("reports the store's query stats in milliseconds", func() {
+			svc.SetDBStats(&fakeDBStats{stats: model.DBStats{
+				Queries:            10,
+				Errors:             1,
+				TotalDuration:      1500 * time.Microsecond,
+				SlowQueries:        1,
+				SlowQueryThreshold: 100 * time.Millisecond,
+				OpenConnections:    2,
+				InUse:              1,
+				Idle:               1,
+				WaitCount:          3,
+				WaitDuration:       20 * time.Millisecond,
+				Statements: []model.DBStatementStats{
+					{Query: "SELECT 1", Count: 10, Errors: 1, TotalDuration: 1500 * time.Microsecond, MaxDuration: 250 * time.Microsecond},
+				},
+			}})
+
+			result, err := svc.Db(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Queries).To(Equal(int64(10)))
+			Expect(result.Errors).To(Equal(int64(1)))
+			Expect(result.TotalDurationMs).To(Equal(1.5))
+			Expect(result.SlowQueries).To(Equal(int64(1)))
+			Expect(result.SlowQueryThresholdMs).To(Equal(100.0))
+			Expect(result.OpenConnections).To(Equal(2))
+			Expect(result.WaitCount).To(Equal(int64(3)))
+			Expect(result.WaitDurationMs).To(Equal(20.0))
+			Expect(result.Statements).To(HaveLen(1))
+			Expect(result.Statements[0].Query).To(Equal("SELECT 1"))
+			Expect(result.Statements[0].Count).To(Equal(int64(10)))
+			Expect(result.Statements[0].MaxDurationMs).To(Equal(0.25))
+		})
+
+		It("reports empty stats when no store is configured", func() {
+			result, err := svc.Db(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Queries).To(BeZero())
+			Expect(result.Statements).To(BeEmpty())
+		})
+	})
 })
@@ -9,30 +9,47 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	genadmin "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/admin"
+	genassets "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/assets"
 	gencheckpoints "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/checkpoints"
 	gencomfyui "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/comfyui"
 	gendemo "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/demo"
 	gendocs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/docs"
+	gengalleries "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/galleries"
 	genhealth "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/health"
+	genadminsvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/admin/server"
+	genassetssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/assets/server"
 	gencheckpointssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/checkpoints/server"
 	gencomfyuisvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/comfyui/server"
 	gendemosvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/demo/server"
 	gendocssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/docs/server"
+	gengalleriessvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/galleries/server"
 	genhealthsvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/health/server"
 	genimagessvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/images/server"
+	genjobtemplatessvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/job_templates/server"
 	genpresetssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/presets/server"
 	gensamplejobssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/sample_jobs/server"
+	genstoragesvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/storage/server"
 	genstudiessvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/studies/server"
+	gensyncsvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/sync/server"
+	genvotessvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/votes/server"
+	genmetasvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/meta/server"
 	gentrainingrunssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/training_runs/server"
 	genworkflowssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/workflows/server"
 	genwssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/ws/server"
 	genimages "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/images"
+	genjobtemplates "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/job_templates"
 	genpresets "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/presets"
 	gensamplejobs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sample_jobs"
+	genstorage "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/storage"
 	genstudies "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/studies"
+	gensync "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sync"
+	genvotes "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/votes"
+	genmeta "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/meta"
 	gentrainingruns "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/training_runs"
 	genworkflows "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/workflows"
 	genws "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/ws"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/sirupsen/logrus"
 	goahttp "goa.design/goa/v3/http"
 	goahttpmiddleware "goa.design/goa/v3/http/middleware"
@@ -47,10 +64,18 @@ type HTTPHandlerConfig struct {
 	PresetsEndpoints       *genpresets.Endpoints
 	StudiesEndpoints       *genstudies.Endpoints
 	SampleJobsEndpoints    *gensamplejobs.Endpoints
+	JobTemplatesEndpoints  *genjobtemplates.Endpoints
+	StorageEndpoints       *genstorage.Endpoints
 	CheckpointsEndpoints   *gencheckpoints.Endpoints
 	ComfyUIEndpoints       *gencomfyui.Endpoints
 	WorkflowsEndpoints     *genworkflows.Endpoints
 	ImagesEndpoints        *genimages.Endpoints
+	GalleriesEndpoints     *gengalleries.Endpoints
+	AssetsEndpoints        *genassets.Endpoints
+	AdminEndpoints         *genadmin.Endpoints
+	SyncEndpoints          *gensync.Endpoints
+	VotesEndpoints         *genvotes.Endpoints
+	MetaEndpoints          *genmeta.Endpoints
 	WSEndpoints            *genws.Endpoints
 	DemoEndpoints          *gendemo.Endpoints
 	SwaggerUIDir           http.FileSystem
@@ -62,6 +87,18 @@ type HTTPHandlerConfig struct {
 	// alive through proxies with short read timeouts. Zero disables pings.
 	WsPingInterval time.Duration
 
+	// RateLimit configures per-IP request rate limiting and the cap on
+	// concurrent heavy (image) requests. When nil, requests are not limited.
+	RateLimit *model.RateLimitConfig
+
+	// RequestTimeout bounds how long a request may run before its context
+	// is canceled. Zero disables the limit.
+	RequestTimeout time.Duration
+
+	// Users are the users requests act for by sending their token. When
+	// empty, requests act for no user and tokens are not checked.
+	Users []model.UserConfig
+
 	// DBResetter is an optional dependency for the test-only reset endpoint.
 	// When non-nil and ENABLE_TEST_ENDPOINTS=true, DELETE /api/test/reset is
 	// mounted to drop and recreate all tables.
@@ -116,10 +153,18 @@ func NewHTTPHandler(cfg HTTPHandlerConfig) http.Handler {
 	presetsServer := genpresetssvr.New(cfg.PresetsEndpoints, mux, dec, enc, eh, nil)
 	studiesServer := genstudiessvr.New(cfg.StudiesEndpoints, mux, dec, enc, eh, nil)
 	sampleJobsServer := gensamplejobssvr.New(cfg.SampleJobsEndpoints, mux, dec, enc, eh, nil)
+	jobTemplatesServer := genjobtemplatessvr.New(cfg.JobTemplatesEndpoints, mux, dec, enc, eh, nil)
+	storageServer := genstoragesvr.New(cfg.StorageEndpoints, mux, dec, enc, eh, nil)
 	checkpointsServer := gencheckpointssvr.New(cfg.CheckpointsEndpoints, mux, dec, enc, eh, nil)
 	comfyuiServer := gencomfyuisvr.New(cfg.ComfyUIEndpoints, mux, dec, enc, eh, nil)
 	workflowsServer := genworkflowssvr.New(cfg.WorkflowsEndpoints, mux, dec, enc, eh, nil)
 	imagesServer := genimagessvr.New(cfg.ImagesEndpoints, mux, dec, enc, eh, nil)
+	galleriesServer := gengalleriessvr.New(cfg.GalleriesEndpoints, mux, dec, enc, eh, nil)
+	assetsServer := genassetssvr.New(cfg.AssetsEndpoints, mux, dec, enc, eh, nil)
+	adminServer := genadminsvr.New(cfg.AdminEndpoints, mux, dec, enc, eh, nil)
+	syncServer := gensyncsvr.New(cfg.SyncEndpoints, mux, dec, enc, eh, nil)
+	votesServer := genvotessvr.New(cfg.VotesEndpoints, mux, dec, enc, eh, nil)
+	metaServer := genmetasvr.New(cfg.MetaEndpoints, mux, dec, enc, eh, nil)
 	demoServer := gendemosvr.New(cfg.DemoEndpoints, mux, dec, enc, eh, nil)
 
 	// WebSocket upgrader with permissive origin check for local/LAN use
@@ -128,13 +173,22 @@ func NewHTTPHandler(cfg HTTPHandlerConfig) http.Handler {
 	}
 	// Install a per-connection ping goroutine via the Goa conn configurer hook.
 	// When WsPingInterval > 0 each upgraded connection gets a background goroutine
-	// that sends periodic ping frames to keep the tunnel alive through proxies.
+	// that sends periodic ping frames to keep the tunnel alive through proxies,
+	// and connections that stop answering pings are closed. The v1 stream
+	// never reads, so its configurer also reads to process the pongs.
 	var wsConfigurer *genwssvr.ConnConfigurer
 	if cfg.WsPingInterval > 0 {
-		wsConfigurer = genwssvr.NewConnConfigurer(NewWSConnConfigurer(cfg.WsPingInterval, cfg.Logger))
+		wsConfigurer = &genwssvr.ConnConfigurer{
+			SubscribeFn:   NewWSPushConnConfigurer(cfg.WsPingInterval, cfg.Logger),
+			SubscribeV2Fn: NewWSConnConfigurer(cfg.WsPingInterval, cfg.Logger),
+		}
 	}
 	wsServer := genwssvr.New(cfg.WSEndpoints, mux, dec, enc, eh, nil, upgrader, wsConfigurer)
 
+	// Image downloads always respond 200 with validators; conditional and
+	// range requests are answered from them here.
+	imagesServer.Use(ConditionalRequestMiddleware())
+
 	// Apply Debug middleware when debug mode is enabled (logs full request/response).
 	// Heartbeat servers (health, comfyui) only get debug logging at trace level
 	// to reduce noise from frequent polling.
@@ -145,11 +199,19 @@ func NewHTTPHandler(cfg HTTPHandlerConfig) http.Handler {
 		presetsServer.Use(debugMw)
 		studiesServer.Use(debugMw)
 		sampleJobsServer.Use(debugMw)
+		jobTemplatesServer.Use(debugMw)
+		storageServer.Use(debugMw)
 		checkpointsServer.Use(debugMw)
 		workflowsServer.Use(debugMw)
 		// DO NOT LOG BINARY IMAGE DATA, IT'S ANNOYING imagesServer.Use(debugMw)
+		// galleriesServer serves images too, so it is skipped for the same reason
+		// assetsServer receives uploaded file chunks, so it is skipped as well
 		wsServer.Use(debugMw)
 		demoServer.Use(debugMw)
+		adminServer.Use(debugMw)
+		syncServer.Use(debugMw)
+		votesServer.Use(debugMw)
+		metaServer.Use(debugMw)
 		// Heartbeat/polling servers: debug only at trace level
 		if cfg.Logger.IsLevelEnabled(logrus.TraceLevel) {
 			healthServer.Use(debugMw)
@@ -163,10 +225,18 @@ func NewHTTPHandler(cfg HTTPHandlerConfig) http.Handler {
 	presetsServer.Mount(mux)
 	studiesServer.Mount(mux)
 	sampleJobsServer.Mount(mux)
+	jobTemplatesServer.Mount(mux)
+	storageServer.Mount(mux)
 	checkpointsServer.Mount(mux)
 	comfyuiServer.Mount(mux)
 	workflowsServer.Mount(mux)
 	imagesServer.Mount(mux)
+	galleriesServer.Mount(mux)
+	assetsServer.Mount(mux)
+	adminServer.Mount(mux)
+	syncServer.Mount(mux)
+	votesServer.Mount(mux)
+	metaServer.Mount(mux)
 	demoServer.Mount(mux)
 	wsServer.Mount(mux)
 
@@ -228,6 +298,20 @@ func NewHTTPHandler(cfg HTTPHandlerConfig) http.Handler {
 				"pattern": m.Pattern,
 			}).Debug("HTTP endpoint mounted")
 		}
+		for _, m := range jobTemplatesServer.Mounts {
+			cfg.Logger.WithFields(logrus.Fields{
+				"method":  m.Method,
+				"verb":    m.Verb,
+				"pattern": m.Pattern,
+			}).Debug("HTTP endpoint mounted")
+		}
+		for _, m := range storageServer.Mounts {
+			cfg.Logger.WithFields(logrus.Fields{
+				"method":  m.Method,
+				"verb":    m.Verb,
+				"pattern": m.Pattern,
+			}).Debug("HTTP endpoint mounted")
+		}
 		for _, m := range checkpointsServer.Mounts {
 			cfg.Logger.WithFields(logrus.Fields{
 				"method":  m.Method,
@@ -256,6 +340,48 @@ func NewHTTPHandler(cfg HTTPHandlerConfig) http.Handler {
 				"pattern": m.Pattern,
 			}).Debug("HTTP endpoint mounted")
 		}
+		for _, m := range galleriesServer.Mounts {
+			cfg.Logger.WithFields(logrus.Fields{
+				"method":  m.Method,
+				"verb":    m.Verb,
+				"pattern": m.Pattern,
+			}).Debug("HTTP endpoint mounted")
+		}
+		for _, m := range assetsServer.Mounts {
+			cfg.Logger.WithFields(logrus.Fields{
+				"method":  m.Method,
+				"verb":    m.Verb,
+				"pattern": m.Pattern,
+			}).Debug("HTTP endpoint mounted")
+		}
+		for _, m := range adminServer.Mounts {
+			cfg.Logger.WithFields(logrus.Fields{
+				"method":  m.Method,
+				"verb":    m.Verb,
+				"pattern": m.Pattern,
+			}).Debug("HTTP endpoint mounted")
+		}
+		for _, m := range syncServer.Mounts {
+			cfg.Logger.WithFields(logrus.Fields{
+				"method":  m.Method,
+				"verb":    m.Verb,
+				"pattern": m.Pattern,
+			}).Debug("HTTP endpoint mounted")
+		}
+		for _, m := range votesServer.Mounts {
+			cfg.Logger.WithFields(logrus.Fields{
+				"method":  m.Method,
+				"verb":    m.Verb,
+				"pattern": m.Pattern,
+			}).Debug("HTTP endpoint mounted")
+		}
+		for _, m := range metaServer.Mounts {
+			cfg.Logger.WithFields(logrus.Fields{
+				"method":  m.Method,
+				"verb":    m.Verb,
+				"pattern": m.Pattern,
+			}).Debug("HTTP endpoint mounted")
+		}
 		for _, m := range demoServer.Mounts {
 			cfg.Logger.WithFields(logrus.Fields{
 				"method":  m.Method,
@@ -277,11 +403,22 @@ func NewHTTPHandler(cfg HTTPHandlerConfig) http.Handler {
 	var handler http.Handler = mux
 	// Apply URL rewrite middleware first (innermost, closest to the mux)
 	handler = imageMetadataRewriteMiddleware(handler)
+	if cfg.RequestTimeout > 0 {
+		handler = RequestTimeoutMiddleware(cfg.RequestTimeout)(handler)
+	}
 	// Create a logrus adapter for Goa middleware
 	adapter := &logrusAdapter{logger: cfg.Logger.WithField("component", "http")}
 	handler = goahttpmiddleware.Log(adapter)(handler)
 	handler = ErrorLoggingMiddleware(cfg.Logger)(handler)
-	handler = goahttpmiddleware.RequestID()(handler)
+	if len(cfg.Users) > 0 {
+		handler = UserAuthMiddleware(cfg.Users, cfg.Logger)(handler)
+	}
+	if cfg.RateLimit != nil {
+		// Rejected requests are answered before logging so that a flood of
+		// them stays cheap; the limiter logs them at debug level.
+		handler = RateLimitMiddleware(*cfg.RateLimit, cfg.Logger)(handler)
+	}
+	handler = RequestIDMiddleware()(handler)
 	handler = CORSMiddleware("*")(handler)
 
 	return handler
@@ -326,6 +463,8 @@ type logrusAdapter struct {
 var quietPaths = []string{
 	"/api/comfyui/status",
 	"/api/health",
+	"/healthz",
+	"/readyz",
 }
 
 func (a *logrusAdapter) Log(keyvals ...interface{}) error {
@@ -1,31 +1,43 @@
 package api_test
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	genadmin "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/admin"
+	genassets "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/assets"
 	gencheckpoints "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/checkpoints"
 	gencomfyui "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/comfyui"
 	gendemo "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/demo"
 	gendocs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/docs"
+	gengalleries "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/galleries"
 	genhealth "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/health"
 	genimages "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/images"
+	genjobtemplates "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/job_templates"
 	genpresets "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/presets"
 	gensamplejobs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sample_jobs"
+	genstorage "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/storage"
 	genstudies "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/studies"
+	gensync "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sync"
+	genvotes "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/votes"
+	genmeta "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/meta"
 	gentrainingruns "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/training_runs"
 	genworkflows "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/workflows"
 	genws "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/ws"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
 )
 
 // fakeCheckpointFS implements service.CheckpointFileSystem for testing.
@@ -81,6 +93,14 @@ var _ = Describe("NewHTTPHandler", func() {
 		*genimages.Endpoints,
 		*genws.Endpoints,
 		*gendemo.Endpoints,
+		*gengalleries.Endpoints,
+		*genassets.Endpoints,
+		*genadmin.Endpoints,
+		*gensync.Endpoints,
+		*genvotes.Endpoints,
+		*genmeta.Endpoints,
+		*genjobtemplates.Endpoints,
+		*genstorage.Endpoints,
 	) {
 		// Service layer services
 		viewerDiscoverySvc := service.NewViewerDiscoveryService(viewerFS, sampleDir, logger)
@@ -95,6 +115,8 @@ var _ = Describe("NewHTTPHandler", func() {
 		demoFS := newFakeViewerDiscoveryFS()
 		fakePS := newFakePresetStore()
 		demoSvc := service.NewDemoService(demoFS, fakePS, sampleDir, logger)
+		serverClock, err := service.NewServerClock("UTC", "en-US")
+		Expect(err).NotTo(HaveOccurred())
 
 		// API layer services
 		healthAPISvc := api.NewHealthService()
@@ -109,6 +131,7 @@ var _ = Describe("NewHTTPHandler", func() {
 		imagesAPISvc := api.NewImagesService(sampleDir, imageMetadataSvc, logger)
 		wsAPISvc := api.NewWSService(hub)
 		demoAPISvc := api.NewDemoAPIService(demoSvc)
+		galleriesAPISvc := api.NewGalleriesService(service.NewGalleryService(nil, viewerDiscoverySvc, scannerSvc, logger), sampleDir, logger)
 
 		return genhealth.NewEndpoints(healthAPISvc),
 			gendocs.NewEndpoints(docsAPISvc),
@@ -121,7 +144,15 @@ var _ = Describe("NewHTTPHandler", func() {
 			genworkflows.NewEndpoints(workflowsAPISvc),
 			genimages.NewEndpoints(imagesAPISvc),
 			genws.NewEndpoints(wsAPISvc),
-			gendemo.NewEndpoints(demoAPISvc)
+			gendemo.NewEndpoints(demoAPISvc),
+			gengalleries.NewEndpoints(galleriesAPISvc),
+			genassets.NewEndpoints(api.NewAssetsService(nil)),
+			genadmin.NewEndpoints(api.NewAdminService(nil)),
+			gensync.NewEndpoints(api.NewSyncService(service.NewSyncService(nil, logger))),
+			genvotes.NewEndpoints(api.NewVotesService(service.NewVoteService(nil, sampleDir, logger))),
+			genmeta.NewEndpoints(api.NewMetaService(serverClock)),
+			genjobtemplates.NewEndpoints(api.NewJobTemplatesService(service.NewJobTemplateService(nil, logger))),
+			genstorage.NewEndpoints(api.NewStorageService(service.NewStorageService(nil, nil, nil, sampleDir, logger)))
 	}
 
 	Describe("Debug middleware", func() {
@@ -129,7 +160,7 @@ var _ = Describe("NewHTTPHandler", func() {
 			healthEndpoints, docsEndpoints, trainingRunsEndpoints, presetsEndpoints,
 				studiesEndpoints, sampleJobsEndpoints, checkpointsEndpoints,
 				comfyuiEndpoints, workflowsEndpoints, imagesEndpoints, wsEndpoints,
-				demoEndpoints := createAllEndpoints()
+				demoEndpoints, galleriesEndpoints, assetsEndpoints, adminEndpoints, syncEndpoints, votesEndpoints, metaEndpoints, jobTemplatesEndpoints, storageEndpoints := createAllEndpoints()
 
 			cfg := api.HTTPHandlerConfig{
 				HealthEndpoints:        healthEndpoints,
@@ -138,12 +169,20 @@ var _ = Describe("NewHTTPHandler", func() {
 				PresetsEndpoints:       presetsEndpoints,
 				StudiesEndpoints:       studiesEndpoints,
 				SampleJobsEndpoints:    sampleJobsEndpoints,
+				JobTemplatesEndpoints:  jobTemplatesEndpoints,
+				StorageEndpoints:       storageEndpoints,
 				CheckpointsEndpoints:   checkpointsEndpoints,
 				ComfyUIEndpoints:       comfyuiEndpoints,
 				WorkflowsEndpoints:     workflowsEndpoints,
 				ImagesEndpoints:        imagesEndpoints,
 				WSEndpoints:            wsEndpoints,
 				DemoEndpoints:          demoEndpoints,
+				GalleriesEndpoints:     galleriesEndpoints,
+				AssetsEndpoints:        assetsEndpoints,
+				AdminEndpoints:         adminEndpoints,
+				SyncEndpoints:          syncEndpoints,
+				VotesEndpoints:         votesEndpoints,
+				MetaEndpoints:          metaEndpoints,
 				SwaggerUIDir:           nil,
 				Logger:                 logger,
 				Debug:                  true,
@@ -166,7 +205,7 @@ var _ = Describe("NewHTTPHandler", func() {
 			healthEndpoints, docsEndpoints, trainingRunsEndpoints, presetsEndpoints,
 				studiesEndpoints, sampleJobsEndpoints, checkpointsEndpoints,
 				comfyuiEndpoints, workflowsEndpoints, imagesEndpoints, wsEndpoints,
-				demoEndpoints := createAllEndpoints()
+				demoEndpoints, galleriesEndpoints, assetsEndpoints, adminEndpoints, syncEndpoints, votesEndpoints, metaEndpoints, jobTemplatesEndpoints, storageEndpoints := createAllEndpoints()
 
 			cfg := api.HTTPHandlerConfig{
 				HealthEndpoints:        healthEndpoints,
@@ -175,12 +214,20 @@ var _ = Describe("NewHTTPHandler", func() {
 				PresetsEndpoints:       presetsEndpoints,
 				StudiesEndpoints:       studiesEndpoints,
 				SampleJobsEndpoints:    sampleJobsEndpoints,
+				JobTemplatesEndpoints:  jobTemplatesEndpoints,
+				StorageEndpoints:       storageEndpoints,
 				CheckpointsEndpoints:   checkpointsEndpoints,
 				ComfyUIEndpoints:       comfyuiEndpoints,
 				WorkflowsEndpoints:     workflowsEndpoints,
 				ImagesEndpoints:        imagesEndpoints,
 				WSEndpoints:            wsEndpoints,
 				DemoEndpoints:          demoEndpoints,
+				GalleriesEndpoints:     galleriesEndpoints,
+				AssetsEndpoints:        assetsEndpoints,
+				AdminEndpoints:         adminEndpoints,
+				SyncEndpoints:          syncEndpoints,
+				VotesEndpoints:         votesEndpoints,
+				MetaEndpoints:          metaEndpoints,
 				SwaggerUIDir:           nil,
 				Logger:                 logger,
 				Debug:                  false,
@@ -220,7 +267,7 @@ var _ = Describe("NewHTTPHandler", func() {
 			healthEndpoints, docsEndpoints, trainingRunsEndpoints, presetsEndpoints,
 				studiesEndpoints, sampleJobsEndpoints, checkpointsEndpoints,
 				comfyuiEndpoints, workflowsEndpoints, _, wsEndpoints,
-				demoEndpoints := createAllEndpoints()
+				demoEndpoints, galleriesEndpoints, assetsEndpoints, adminEndpoints, syncEndpoints, votesEndpoints, metaEndpoints, jobTemplatesEndpoints, storageEndpoints := createAllEndpoints()
 
 			// Create images service with the test directory
 			fs := &realFileReader{}
@@ -235,12 +282,20 @@ var _ = Describe("NewHTTPHandler", func() {
 				PresetsEndpoints:       presetsEndpoints,
 				StudiesEndpoints:       studiesEndpoints,
 				SampleJobsEndpoints:    sampleJobsEndpoints,
+				JobTemplatesEndpoints:  jobTemplatesEndpoints,
+				StorageEndpoints:       storageEndpoints,
 				CheckpointsEndpoints:   checkpointsEndpoints,
 				ComfyUIEndpoints:       comfyuiEndpoints,
 				WorkflowsEndpoints:     workflowsEndpoints,
 				ImagesEndpoints:        imagesEndpoints,
 				WSEndpoints:            wsEndpoints,
 				DemoEndpoints:          demoEndpoints,
+				GalleriesEndpoints:     galleriesEndpoints,
+				AssetsEndpoints:        assetsEndpoints,
+				AdminEndpoints:         adminEndpoints,
+				SyncEndpoints:          syncEndpoints,
+				VotesEndpoints:         votesEndpoints,
+				MetaEndpoints:          metaEndpoints,
 				SwaggerUIDir:           nil,
 				Logger:                 logger,
 				Debug:                  false,
@@ -267,4 +322,106 @@ var _ = Describe("NewHTTPHandler", func() {
 			Expect(stringMetadata["prompt"]).To(Equal("test prompt"))
 		})
 	})
+
+	Describe("Image routes", func() {
+		var (
+			tmpDir  string
+			pngData []byte
+			server  *httptest.Server
+		)
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = os.MkdirTemp("", "http-test-images-*")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.RemoveAll, tmpDir)
+
+			pngData = buildTestPNGWithTextChunks(map[string]string{"prompt": "test prompt"})
+			Expect(os.WriteFile(filepath.Join(tmpDir, "test.png"), pngData, 0644)).To(Succeed())
+
+			healthEndpoints, docsEndpoints, trainingRunsEndpoints, presetsEndpoints,
+				studiesEndpoints, sampleJobsEndpoints, checkpointsEndpoints,
+				comfyuiEndpoints, workflowsEndpoints, _, wsEndpoints,
+				demoEndpoints, galleriesEndpoints, assetsEndpoints, adminEndpoints, syncEndpoints, votesEndpoints, metaEndpoints, jobTemplatesEndpoints, storageEndpoints := createAllEndpoints()
+			metadataSvc := service.NewImageMetadataService(&realFileReader{}, tmpDir, logger)
+			imagesSvc := api.NewImagesService(tmpDir, metadataSvc, logger)
+			imagesSvc.SetCheckpointArchiveService(service.NewCheckpointArchiveService(store.NewFileSystem(logger), tmpDir, logger))
+			imagesEndpoints := genimages.NewEndpoints(imagesSvc)
+
+			handler := api.NewHTTPHandler(api.HTTPHandlerConfig{
+				HealthEndpoints:       healthEndpoints,
+				DocsEndpoints:         docsEndpoints,
+				TrainingRunEndpoints:  trainingRunsEndpoints,
+				PresetsEndpoints:      presetsEndpoints,
+				StudiesEndpoints:      studiesEndpoints,
+				SampleJobsEndpoints:   sampleJobsEndpoints,
+				JobTemplatesEndpoints: jobTemplatesEndpoints,
+				StorageEndpoints:      storageEndpoints,
+				CheckpointsEndpoints:  checkpointsEndpoints,
+				ComfyUIEndpoints:      comfyuiEndpoints,
+				WorkflowsEndpoints:    workflowsEndpoints,
+				ImagesEndpoints:       imagesEndpoints,
+				WSEndpoints:           wsEndpoints,
+				DemoEndpoints:         demoEndpoints,
+				GalleriesEndpoints:    galleriesEndpoints,
+				AssetsEndpoints:       assetsEndpoints,
+				AdminEndpoints:        adminEndpoints,
+				SyncEndpoints:         syncEndpoints,
+				VotesEndpoints:        votesEndpoints,
+				MetaEndpoints:         metaEndpoints,
+				Logger:                logger,
+			})
+			server = httptest.NewServer(handler)
+			DeferCleanup(server.Close)
+		})
+
+		get := func(url string, headers map[string]string) (*http.Response, []byte) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+url, nil)
+			Expect(err).NotTo(HaveOccurred())
+			for k, v := range headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return resp, body
+		}
+
+		It("answers conditional and range requests for image downloads", func() {
+			resp, body := get("/api/images/test.png", nil)
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(body).To(Equal(pngData))
+			Expect(resp.Header.Get("Cache-Control")).To(Equal("no-cache"))
+			Expect(resp.Header.Get("Accept-Ranges")).To(Equal("bytes"))
+			etag := resp.Header.Get("ETag")
+			Expect(etag).NotTo(BeEmpty())
+			Expect(resp.Header.Get("Last-Modified")).NotTo(BeEmpty())
+
+			resp, body = get("/api/images/test.png", map[string]string{"If-None-Match": etag})
+			Expect(resp.StatusCode).To(Equal(http.StatusNotModified))
+			Expect(body).To(BeEmpty())
+
+			resp, body = get("/api/images/test.png", map[string]string{"Range": "bytes=1-3"})
+			Expect(resp.StatusCode).To(Equal(http.StatusPartialContent))
+			Expect(resp.Header.Get("Content-Range")).To(Equal("bytes 1-3/" + strconv.Itoa(len(pngData))))
+			Expect(body).To(Equal(pngData[1:4]))
+		})
+
+		It("routes checkpoint archives apart from image downloads", func() {
+			cpDir := filepath.Join(tmpDir, "run", "study", "model.safetensors")
+			Expect(os.MkdirAll(cpDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cpDir, "a.png"), pngData, 0644)).To(Succeed())
+
+			resp, body := get("/api/images/checkpoint/model.safetensors/archive?dir=run/study", nil)
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/zip"))
+			Expect(resp.Header.Get("Content-Disposition")).To(Equal(`attachment; filename="model-samples.zip"`))
+			zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zr.File).To(HaveLen(1))
+			Expect(zr.File[0].Name).To(Equal("model.safetensors/a.png"))
+		})
+	})
 })
@@ -1,24 +1,41 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	genimages "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/images"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 	"github.com/sirupsen/logrus"
 )
 
 // ImagesService implements the generated images service interface.
 type ImagesService struct {
-	sampleDir   string
-	metadataSvc *service.ImageMetadataService
-	logger      *logrus.Entry
+	sampleDir       string
+	metadataSvc     *service.ImageMetadataService
+	pinSvc          *service.PinService
+	annotations     *service.ImageAnnotationService
+	gridRuns        *service.ViewerDiscoveryService
+	gridScanner     *service.Scanner
+	gridRender      *service.GridRenderer
+	timelineRuns    *service.ViewerDiscoveryService
+	timelineScanner *service.Scanner
+	timelineRender  *service.TimelineRenderer
+	compareSvc      *service.ComparisonService
+	backfillSvc     *service.SidecarBackfillService
+	thumbCache      *service.ThumbnailCache
+	deleteSvc       *service.SampleDeleteService
+	archiveSvc      *service.CheckpointArchiveService
+	logger          *logrus.Entry
 }
 
 // NewImagesService returns a new ImagesService.
@@ -30,45 +47,212 @@ func NewImagesService(sampleDir string, metadataSvc *service.ImageMetadataServic
 	}
 }
 
+// SetPinService sets the pin service backing the list_pins, pin, and unpin
+// methods. If not set, those methods return an internal_error.
+func (s *ImagesService) SetPinService(pinSvc *service.PinService) {
+	s.pinSvc = pinSvc
+}
+
+// SetImageAnnotationService sets the service backing the annotation methods
+// and the annotation of metadata responses. If not set, the annotation methods
+// return an internal_error and metadata responses carry no annotation.
+func (s *ImagesService) SetImageAnnotationService(annotations *service.ImageAnnotationService) {
+	s.annotations = annotations
+}
+
+// SetGridRenderer sets the training run discovery, scanner, and renderer backing
+// the grid method. If not set, grid returns an internal_error.
+func (s *ImagesService) SetGridRenderer(viewerDiscovery *service.ViewerDiscoveryService, scanner *service.Scanner, renderer *service.GridRenderer) {
+	s.gridRuns = viewerDiscovery
+	s.gridScanner = scanner
+	s.gridRender = renderer
+}
+
+// SetTimelineRenderer sets the training run discovery, scanner, and renderer
+// backing the timeline method. If not set, timeline returns an internal_error.
+func (s *ImagesService) SetTimelineRenderer(viewerDiscovery *service.ViewerDiscoveryService, scanner *service.Scanner, renderer *service.TimelineRenderer) {
+	s.timelineRuns = viewerDiscovery
+	s.timelineScanner = scanner
+	s.timelineRender = renderer
+}
+
+// SetComparisonService sets the service backing the compare method. If not
+// set, compare returns an internal_error.
+func (s *ImagesService) SetComparisonService(compareSvc *service.ComparisonService) {
+	s.compareSvc = compareSvc
+}
+
+// SetSidecarBackfillService sets the service backing the backfill_sidecars
+// method. If not set, backfill_sidecars returns an internal_error.
+func (s *ImagesService) SetSidecarBackfillService(backfillSvc *service.SidecarBackfillService) {
+	s.backfillSvc = backfillSvc
+}
+
+// SetThumbnailCache sets the cache backing size=thumb downloads. If not set,
+// size=thumb serves the original image.
+func (s *ImagesService) SetThumbnailCache(thumbCache *service.ThumbnailCache) {
+	s.thumbCache = thumbCache
+}
+
+// SetSampleDeleteService sets the service backing the delete_samples method.
+// If not set, delete_samples returns an internal_error.
+func (s *ImagesService) SetSampleDeleteService(deleteSvc *service.SampleDeleteService) {
+	s.deleteSvc = deleteSvc
+}
+
+// SetCheckpointArchiveService sets the service backing the checkpoint_archive
+// method. If not set, checkpoint_archive returns an internal_error.
+func (s *ImagesService) SetCheckpointArchiveService(archiveSvc *service.CheckpointArchiveService) {
+	s.archiveSvc = archiveSvc
+}
+
+// imageCacheControl lets clients store sample images but requires them to
+// revalidate before reuse, since a regenerated image replaces the file at the
+// same path. Revalidation is answered with 304 while the ETag still matches.
+const imageCacheControl = "no-cache"
+
 // Download serves an image file from the sample directory with path traversal protection
-// and immutable cache headers. Returns the file as an io.ReadCloser that Goa will stream.
-func (s *ImagesService) Download(ctx context.Context, p *genimages.DownloadPayload) (*genimages.ImageDownloadResult, io.ReadCloser, error) {
-	s.logger.WithField("filepath", p.Filepath).Debug("download request")
+// and validators (ETag, Last-Modified) for conditional and range requests, which
+// ConditionalRequestMiddleware answers. With size=thumb a cached JPEG thumbnail is
+// served instead. Returns the file as an io.ReadCloser that Goa will stream.
+func (s *ImagesService) Download(ctx context.Context, p *genimages.DownloadPayload) (*genimages.ImageFileResult, io.ReadCloser, error) {
+	s.logger.WithFields(logrus.Fields{
+		"filepath": p.Filepath,
+		"size":     p.Size,
+	}).Debug("download request")
+
+	servePath := p.Filepath
+	if p.Size == "thumb" && s.thumbCache != nil {
+		thumbPath, err := s.thumbCache.Get(p.Filepath)
+		switch {
+		case err == nil:
+			servePath = thumbPath
+		case strings.HasPrefix(err.Error(), "invalid path"):
+			return nil, nil, genimages.MakeBadRequest(fmt.Errorf("invalid file path"))
+		case strings.HasPrefix(err.Error(), "image not found"):
+			return nil, nil, genimages.MakeNotFound(fmt.Errorf("image not found"))
+		default:
+			// Formats the thumbnailer cannot decode (e.g. WebP) are served
+			// at full size rather than failing the request.
+			s.logger.WithFields(logrus.Fields{
+				"filepath": p.Filepath,
+				"error":    err.Error(),
+			}).Warn("thumbnail unavailable, serving original image")
+		}
+	}
+
+	file, info, contentType, err := openSampleImage(s.sampleDir, servePath, s.logger)
+	if err != nil {
+		if errors.Is(err, errInvalidImagePath) {
+			return nil, nil, genimages.MakeBadRequest(fmt.Errorf("invalid file path"))
+		}
+		return nil, nil, genimages.MakeNotFound(fmt.Errorf("image not found"))
+	}
+
+	etag := fileETag(info)
+	lastModified := info.ModTime().UTC().Format(http.TimeFormat)
+	acceptRanges := "bytes"
+	result := &genimages.ImageFileResult{
+		ContentType:   contentType,
+		ContentLength: info.Size(),
+		AcceptRanges:  &acceptRanges,
+		Etag:          &etag,
+		LastModified:  &lastModified,
+		CacheControl:  imageCacheControl,
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"filepath":     servePath,
+		"content_type": contentType,
+		"size":         info.Size(),
+		"etag":         etag,
+	}).Debug("serving image")
+
+	return result, file, nil
+}
+
+// CheckpointArchive streams a ZIP archive of the sample images of one
+// checkpoint directory, with their sidecars.
+func (s *ImagesService) CheckpointArchive(ctx context.Context, p *genimages.CheckpointArchivePayload) (*genimages.ArchiveDownloadResult, io.ReadCloser, error) {
+	s.logger.WithFields(logrus.Fields{
+		"filename": p.Filename,
+		"dir":      p.Dir,
+	}).Debug("checkpoint archive request")
+
+	if s.archiveSvc == nil {
+		return nil, nil, genimages.MakeInternalError(fmt.Errorf("checkpoint archives are not configured"))
+	}
+	dir := ""
+	if p.Dir != nil {
+		dir = *p.Dir
+	}
+	body, err := s.archiveSvc.Open(dir, p.Filename)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid checkpoint directory"):
+			return nil, nil, genimages.MakeBadRequest(err)
+		case strings.HasPrefix(err.Error(), "checkpoint directory not found"):
+			return nil, nil, genimages.MakeNotFound(err)
+		}
+		return nil, nil, genimages.MakeInternalError(err)
+	}
+	return &genimages.ArchiveDownloadResult{
+		ContentType:        "application/zip",
+		ContentDisposition: fmt.Sprintf("attachment; filename=%q", service.CheckpointArchiveFilename(p.Filename)),
+	}, body, nil
+}
+
+// errInvalidImagePath and errImageNotFound are returned by openSampleImage.
+var (
+	errInvalidImagePath = errors.New("invalid file path")
+	errImageNotFound    = errors.New("image not found")
+)
+
+// fileETag returns a strong ETag for the file described by info, derived from
+// its size and modification time so that it changes when the file is
+// regenerated.
+func fileETag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+}
 
+// openSampleImage opens the file at relPath within sampleDir for streaming,
+// rejecting path traversal, and detects its content type. The caller must
+// close the returned file.
+func openSampleImage(sampleDir, relPath string, logger *logrus.Entry) (*os.File, os.FileInfo, string, error) {
 	// Validate the path doesn't contain traversal components
-	if !isPathSafe(p.Filepath) {
-		s.logger.WithField("filepath", p.Filepath).Warn("invalid path rejected")
-		return nil, nil, genimages.MakeBadRequest(fmt.Errorf("invalid file path"))
+	if !isPathSafe(relPath) {
+		logger.WithField("filepath", relPath).Warn("invalid path rejected")
+		return nil, nil, "", errInvalidImagePath
 	}
 
-	absPath := filepath.Join(s.sampleDir, filepath.FromSlash(p.Filepath))
+	absPath := filepath.Join(sampleDir, filepath.FromSlash(relPath))
 
 	// Double-check the resolved path is within sampleDir
-	cleanRoot := filepath.Clean(s.sampleDir)
+	cleanRoot := filepath.Clean(sampleDir)
 	cleanPath := filepath.Clean(absPath)
 	if !strings.HasPrefix(cleanPath, cleanRoot+string(filepath.Separator)) && cleanPath != cleanRoot {
-		s.logger.WithField("filepath", p.Filepath).Warn("path traversal attempt rejected")
-		return nil, nil, genimages.MakeBadRequest(fmt.Errorf("invalid file path"))
+		logger.WithField("filepath", relPath).Warn("path traversal attempt rejected")
+		return nil, nil, "", errInvalidImagePath
 	}
 
 	// Check file exists and is a regular file
 	info, err := os.Stat(absPath)
 	if err != nil || info.IsDir() {
-		s.logger.WithFields(logrus.Fields{
-			"filepath": p.Filepath,
+		logger.WithFields(logrus.Fields{
+			"filepath": relPath,
 			"error":    err,
 		}).Debug("image not found")
-		return nil, nil, genimages.MakeNotFound(fmt.Errorf("image not found"))
+		return nil, nil, "", errImageNotFound
 	}
 
 	// Open the file for streaming
 	file, err := os.Open(absPath)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"filepath": p.Filepath,
+		logger.WithFields(logrus.Fields{
+			"filepath": relPath,
 			"error":    err.Error(),
 		}).Error("error opening image file")
-		return nil, nil, genimages.MakeNotFound(fmt.Errorf("image not found"))
+		return nil, nil, "", errImageNotFound
 	}
 
 	// Detect content type by reading the first 512 bytes
@@ -76,11 +260,11 @@ func (s *ImagesService) Download(ctx context.Context, p *genimages.DownloadPaylo
 	n, err := file.Read(buffer)
 	if err != nil && err != io.EOF {
 		file.Close()
-		s.logger.WithFields(logrus.Fields{
-			"filepath": p.Filepath,
+		logger.WithFields(logrus.Fields{
+			"filepath": relPath,
 			"error":    err.Error(),
 		}).Error("error reading image file for content type detection")
-		return nil, nil, genimages.MakeNotFound(fmt.Errorf("image not found"))
+		return nil, nil, "", errImageNotFound
 	}
 
 	contentType := http.DetectContentType(buffer[:n])
@@ -88,31 +272,20 @@ func (s *ImagesService) Download(ctx context.Context, p *genimages.DownloadPaylo
 	// Seek back to the start of the file
 	if _, err := file.Seek(0, 0); err != nil {
 		file.Close()
-		s.logger.WithFields(logrus.Fields{
-			"filepath": p.Filepath,
+		logger.WithFields(logrus.Fields{
+			"filepath": relPath,
 			"error":    err.Error(),
 		}).Error("error seeking to start of image file")
-		return nil, nil, genimages.MakeNotFound(fmt.Errorf("image not found"))
-	}
-
-	result := &genimages.ImageDownloadResult{
-		ContentType:   contentType,
-		ContentLength: info.Size(),
-		CacheControl:  "max-age=31536000, immutable",
+		return nil, nil, "", errImageNotFound
 	}
 
-	s.logger.WithFields(logrus.Fields{
-		"filepath":     p.Filepath,
-		"content_type": contentType,
-		"size":         info.Size(),
-	}).Debug("serving image")
-
-	return result, file, nil
+	return file, info, contentType, nil
 }
 
-// Metadata returns image metadata from a JSON sidecar or PNG tEXt chunks.
-// Numeric fields (seed, steps, cfg) are returned in NumericMetadata; all
-// other fields are returned in StringMetadata.
+// Metadata returns image metadata from a JSON sidecar, or from the filename and
+// PNG tEXt chunks for images without one. Numeric fields (seed, steps, cfg,
+// width, height, shift) are returned in NumericMetadata; all other fields are
+// returned in StringMetadata. Source reports which of these was used.
 func (s *ImagesService) Metadata(ctx context.Context, p *genimages.MetadataPayload) (*genimages.ImageMetadataResponse, error) {
 	s.logger.WithField("filepath", p.Filepath).Debug("metadata request")
 
@@ -137,12 +310,412 @@ func (s *ImagesService) Metadata(ctx context.Context, p *genimages.MetadataPaylo
 		numericMeta = map[string]float64{}
 	}
 
-	return &genimages.ImageMetadataResponse{
+	source := values.Source
+	if source == "" {
+		source = model.ImageMetadataSourceNone
+	}
+
+	res := &genimages.ImageMetadataResponse{
 		StringMetadata:  stringMeta,
 		NumericMetadata: numericMeta,
+		Source:          string(source),
+	}
+	if s.annotations != nil {
+		a, err := s.annotations.Get(ctx, p.Filepath)
+		if err != nil {
+			return nil, genimages.MakeInternalError(err)
+		}
+		res.Annotation = annotationToResponse(a)
+	}
+	return res, nil
+}
+
+// Compare returns two images' normalized metadata and aligned thumbnails for
+// the A/B comparison slider. Pairs that differ in anything other than the
+// checkpoint are rejected as not_comparable.
+func (s *ImagesService) Compare(ctx context.Context, p *genimages.ComparePayload) (*genimages.ImageComparisonResponse, error) {
+	s.logger.WithFields(logrus.Fields{
+		"path_a": p.A,
+		"path_b": p.B,
+	}).Debug("compare request")
+
+	if s.compareSvc == nil {
+		return nil, genimages.MakeInternalError(fmt.Errorf("image comparison is not configured"))
+	}
+	cmp, err := s.compareSvc.Compare(p.A, p.B)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case strings.Contains(errMsg, "invalid path"):
+			return nil, genimages.MakeBadRequest(fmt.Errorf("invalid file path"))
+		case strings.Contains(errMsg, "not comparable"):
+			return nil, genimages.MakeNotComparable(err)
+		case isNotFound(err):
+			return nil, genimages.MakeNotFound(err)
+		}
+		return nil, genimages.MakeInternalError(err)
+	}
+
+	return &genimages.ImageComparisonResponse{
+		A:                comparisonImageToResponse(cmp.A),
+		B:                comparisonImageToResponse(cmp.B),
+		SharedParameters: cmp.SharedParameters,
+		ThumbnailWidth:   cmp.ThumbnailWidth,
+		ThumbnailHeight:  cmp.ThumbnailHeight,
+	}, nil
+}
+
+// BackfillSidecars writes sidecars for images that lack them and reports how
+// many were written, skipped, and failed.
+func (s *ImagesService) BackfillSidecars(ctx context.Context) (*genimages.SidecarBackfillResponse, error) {
+	s.logger.Info("sidecar backfill requested")
+
+	if s.backfillSvc == nil {
+		return nil, genimages.MakeInternalError(fmt.Errorf("sidecar backfill is not configured"))
+	}
+	result, err := s.backfillSvc.Backfill()
+	if err != nil {
+		return nil, genimages.MakeInternalError(err)
+	}
+
+	failedPaths := result.FailedPaths
+	if failedPaths == nil {
+		failedPaths = []string{}
+	}
+	return &genimages.SidecarBackfillResponse{
+		Scanned:     result.Scanned,
+		Written:     result.Written,
+		Skipped:     result.Skipped,
+		Failed:      result.Failed,
+		FailedPaths: failedPaths,
 	}, nil
 }
 
+// ListPins returns all pinned images and checkpoint sample sets.
+func (s *ImagesService) ListPins(ctx context.Context) ([]*genimages.PinResponse, error) {
+	if s.pinSvc == nil {
+		return nil, genimages.MakeInternalError(fmt.Errorf("pinning is not configured"))
+	}
+	pins, err := s.pinSvc.List(ctx)
+	if err != nil {
+		return nil, genimages.MakeInternalError(fmt.Errorf("listing pins: %w", err))
+	}
+	result := make([]*genimages.PinResponse, len(pins))
+	for i, p := range pins {
+		result[i] = pinToResponse(p)
+	}
+	return result, nil
+}
+
+// Pin protects an image or checkpoint sample directory from cleanup.
+func (s *ImagesService) Pin(ctx context.Context, p *genimages.PinPayload) (*genimages.PinResponse, error) {
+	if s.pinSvc == nil {
+		return nil, genimages.MakeInternalError(fmt.Errorf("pinning is not configured"))
+	}
+	pin, err := s.pinSvc.Pin(ctx, p.Path, model.PinKind(p.Kind))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return nil, genimages.MakeBadRequest(err)
+		}
+		return nil, genimages.MakeInternalError(err)
+	}
+	return pinToResponse(pin), nil
+}
+
+// Unpin removes a pin.
+func (s *ImagesService) Unpin(ctx context.Context, p *genimages.UnpinPayload) error {
+	if s.pinSvc == nil {
+		return genimages.MakeInternalError(fmt.Errorf("pinning is not configured"))
+	}
+	if err := s.pinSvc.Unpin(ctx, p.Path); err != nil {
+		if isNotFound(err) {
+			return genimages.MakeNotFound(err)
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			return genimages.MakeBadRequest(err)
+		}
+		return genimages.MakeInternalError(err)
+	}
+	return nil
+}
+
+// ListAnnotations returns the image annotations matching the given filters.
+func (s *ImagesService) ListAnnotations(ctx context.Context, p *genimages.ListAnnotationsPayload) ([]*genimages.ImageAnnotationResponse, error) {
+	if s.annotations == nil {
+		return nil, genimages.MakeInternalError(fmt.Errorf("image annotations are not configured"))
+	}
+	filter := model.ImageAnnotationFilter{MinRating: p.MinRating}
+	if p.Prefix != nil {
+		filter.PathPrefix = *p.Prefix
+	}
+	if p.Tag != nil {
+		filter.Tag = *p.Tag
+	}
+	annotations, err := s.annotations.List(ctx, filter)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return nil, genimages.MakeBadRequest(err)
+		}
+		return nil, genimages.MakeInternalError(err)
+	}
+	result := make([]*genimages.ImageAnnotationResponse, len(annotations))
+	for i, a := range annotations {
+		result[i] = annotationToResponse(a)
+	}
+	return result, nil
+}
+
+// Annotate replaces the rating and tags of an image.
+func (s *ImagesService) Annotate(ctx context.Context, p *genimages.AnnotateImagePayload) (*genimages.ImageAnnotationResponse, error) {
+	if s.annotations == nil {
+		return nil, genimages.MakeInternalError(fmt.Errorf("image annotations are not configured"))
+	}
+	a, err := s.annotations.Set(ctx, imageRef(p.Path, p.ItemID), p.Rating, p.Tags)
+	if err != nil {
+		return nil, annotationError(err)
+	}
+	return annotationToResponse(a), nil
+}
+
+// DeleteAnnotation removes the rating and tags of an image.
+func (s *ImagesService) DeleteAnnotation(ctx context.Context, p *genimages.DeleteAnnotationPayload) error {
+	if s.annotations == nil {
+		return genimages.MakeInternalError(fmt.Errorf("image annotations are not configured"))
+	}
+	if err := s.annotations.Delete(ctx, imageRef(p.Path, p.ItemID)); err != nil {
+		return annotationError(err)
+	}
+	return nil
+}
+
+// BulkAnnotate applies one rating and tag change to many images.
+func (s *ImagesService) BulkAnnotate(ctx context.Context, p *genimages.BulkAnnotateImagesPayload) ([]*genimages.ImageAnnotationResponse, error) {
+	if s.annotations == nil {
+		return nil, genimages.MakeInternalError(fmt.Errorf("image annotations are not configured"))
+	}
+	refs := make([]service.ImageRef, 0, len(p.Paths)+len(p.ItemIds))
+	for _, path := range p.Paths {
+		refs = append(refs, service.ImageRef{Path: path})
+	}
+	for _, id := range p.ItemIds {
+		refs = append(refs, service.ImageRef{ItemID: id})
+	}
+	annotations, err := s.annotations.BulkUpdate(ctx, refs, model.ImageAnnotationChange{
+		Rating:     p.Rating,
+		AddTags:    p.AddTags,
+		RemoveTags: p.RemoveTags,
+	})
+	if err != nil {
+		return nil, annotationError(err)
+	}
+	result := make([]*genimages.ImageAnnotationResponse, len(annotations))
+	for i, a := range annotations {
+		result[i] = annotationToResponse(a)
+	}
+	return result, nil
+}
+
+// Grid renders a labeled comparison grid for a training run as a PNG. The
+// training run is resolved the same way as the training runs scan endpoint.
+func (s *ImagesService) Grid(ctx context.Context, p *genimages.GridPayload) (*genimages.ImageDownloadResult, io.ReadCloser, error) {
+	s.logger.WithFields(logrus.Fields{
+		"training_run_id": p.TrainingRunID,
+		"x_axis":          p.XAxis,
+		"y_axis":          p.YAxis,
+	}).Debug("grid request")
+
+	if s.gridRender == nil {
+		return nil, nil, genimages.MakeInternalError(fmt.Errorf("grid rendering is not configured"))
+	}
+
+	runs, err := s.gridRuns.DiscoverViewable()
+	if err != nil {
+		return nil, nil, genimages.MakeInternalError(fmt.Errorf("discovering viewable training runs: %w", err))
+	}
+	if p.TrainingRunID < 0 || p.TrainingRunID >= len(runs) {
+		return nil, nil, genimages.MakeNotFound(fmt.Errorf("training run %d not found", p.TrainingRunID))
+	}
+	tr := runs[p.TrainingRunID]
+
+	studyName := service.StudyNameForRun(tr.Name)
+	if p.StudyName != nil && *p.StudyName != "" {
+		studyName = *p.StudyName
+	}
+
+	scanResult, err := s.gridScanner.ScanTrainingRun(tr, studyName)
+	if err != nil {
+		return nil, nil, genimages.MakeInternalError(fmt.Errorf("scanning training run %q: %w", tr.Name, err))
+	}
+
+	data, err := s.gridRender.Render(scanResult, model.GridSpec{
+		XAxis:    p.XAxis,
+		YAxis:    p.YAxis,
+		XValues:  p.XValues,
+		YValues:  p.YValues,
+		Filters:  p.Filters,
+		CellSize: p.CellSize,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return nil, nil, genimages.MakeBadRequest(err)
+		}
+		return nil, nil, genimages.MakeInternalError(fmt.Errorf("rendering grid: %w", err))
+	}
+
+	result := &genimages.ImageDownloadResult{
+		ContentType:   "image/png",
+		ContentLength: int64(len(data)),
+		CacheControl:  "no-store",
+	}
+	return result, io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Timeline renders an animated GIF of a training run's images across its
+// checkpoints. The training run is resolved the same way as for grid.
+func (s *ImagesService) Timeline(ctx context.Context, p *genimages.TimelinePayload) (*genimages.ImageDownloadResult, io.ReadCloser, error) {
+	s.logger.WithFields(logrus.Fields{
+		"training_run_id": p.TrainingRunID,
+		"filters":         p.Filters,
+	}).Debug("timeline request")
+
+	if s.timelineRender == nil {
+		return nil, nil, genimages.MakeInternalError(fmt.Errorf("timeline rendering is not configured"))
+	}
+
+	runs, err := s.timelineRuns.DiscoverViewable()
+	if err != nil {
+		return nil, nil, genimages.MakeInternalError(fmt.Errorf("discovering viewable training runs: %w", err))
+	}
+	if p.TrainingRunID < 0 || p.TrainingRunID >= len(runs) {
+		return nil, nil, genimages.MakeNotFound(fmt.Errorf("training run %d not found", p.TrainingRunID))
+	}
+	tr := runs[p.TrainingRunID]
+
+	studyName := service.StudyNameForRun(tr.Name)
+	if p.StudyName != nil && *p.StudyName != "" {
+		studyName = *p.StudyName
+	}
+
+	scanResult, err := s.timelineScanner.ScanTrainingRun(tr, studyName)
+	if err != nil {
+		return nil, nil, genimages.MakeInternalError(fmt.Errorf("scanning training run %q: %w", tr.Name, err))
+	}
+
+	data, err := s.timelineRender.Render(scanResult, model.TimelineSpec{
+		Filters:      p.Filters,
+		FrameSize:    p.FrameSize,
+		FrameDelayMs: p.FrameDelayMs,
+	})
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid"):
+			return nil, nil, genimages.MakeBadRequest(err)
+		case strings.Contains(err.Error(), "no images"):
+			return nil, nil, genimages.MakeNotFound(err)
+		}
+		return nil, nil, genimages.MakeInternalError(fmt.Errorf("rendering timeline: %w", err))
+	}
+
+	result := &genimages.ImageDownloadResult{
+		ContentType:   "image/gif",
+		ContentLength: int64(len(data)),
+		CacheControl:  "no-store",
+	}
+	return result, io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// DeleteSamples deletes the sample images of a training run that match the
+// payload's filter, or lists them in a dry run.
+func (s *ImagesService) DeleteSamples(ctx context.Context, p *genimages.DeleteSamplesPayload) (*genimages.SampleDeleteResponse, error) {
+	s.logger.WithFields(logrus.Fields{
+		"training_run": p.TrainingRun,
+		"dry_run":      p.DryRun,
+	}).Info("bulk sample deletion requested")
+
+	if s.deleteSvc == nil {
+		return nil, genimages.MakeInternalError(fmt.Errorf("sample deletion is not configured"))
+	}
+	filter := model.SampleDeleteFilter{
+		TrainingRunName: p.TrainingRun,
+		Checkpoints:     p.Checkpoint,
+		Seed:            p.Seed,
+	}
+	if p.PromptName != nil {
+		filter.PromptName = *p.PromptName
+	}
+	if p.Before != nil {
+		before, err := time.Parse(time.RFC3339, *p.Before)
+		if err != nil {
+			return nil, genimages.MakeBadRequest(fmt.Errorf("invalid before time %q: must be RFC3339", *p.Before))
+		}
+		filter.Before = &before
+	}
+
+	result, err := s.deleteSvc.Delete(ctx, filter, p.DryRun)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return nil, genimages.MakeBadRequest(err)
+		}
+		return nil, genimages.MakeInternalError(err)
+	}
+	return &genimages.SampleDeleteResponse{
+		DryRun:        result.DryRun,
+		Deleted:       result.Deleted,
+		SkippedPinned: result.SkippedPinned,
+		Errors:        result.Errors,
+	}, nil
+}
+
+func pinToResponse(p model.Pin) *genimages.PinResponse {
+	return &genimages.PinResponse{
+		Path:      p.Path,
+		Kind:      string(p.Kind),
+		CreatedAt: p.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// imageRef builds a service.ImageRef from optional path and item ID
+// attributes.
+func imageRef(path, itemID *string) service.ImageRef {
+	var ref service.ImageRef
+	if path != nil {
+		ref.Path = *path
+	}
+	if itemID != nil {
+		ref.ItemID = *itemID
+	}
+	return ref
+}
+
+// annotationError maps an ImageAnnotationService error to an images service
+// error.
+func annotationError(err error) error {
+	switch {
+	case isNotFound(err):
+		return genimages.MakeNotFound(err)
+	case strings.Contains(err.Error(), "invalid"):
+		return genimages.MakeBadRequest(err)
+	}
+	return genimages.MakeInternalError(err)
+}
+
+func annotationToResponse(a model.ImageAnnotation) *genimages.ImageAnnotationResponse {
+	tags := a.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	var updatedAt string
+	if !a.UpdatedAt.IsZero() {
+		updatedAt = a.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return &genimages.ImageAnnotationResponse{
+		Path:      a.Path,
+		Rating:    a.Rating,
+		Tags:      tags,
+		UpdatedAt: updatedAt,
+	}
+}
+
 // isPathSafe checks that a relative path does not contain path traversal components.
 func isPathSafe(p string) bool {
 	// Reject empty paths
@@ -165,3 +738,15 @@ func isPathSafe(p string) bool {
 
 	return true
 }
+
+func comparisonImageToResponse(img model.ComparisonImage) *genimages.ComparisonImageResponse {
+	return &genimages.ComparisonImageResponse{
+		Path:               img.Path,
+		CheckpointFilename: img.CheckpointFilename,
+		StepNumber:         img.StepNumber,
+		Width:              img.Width,
+		Height:             img.Height,
+		Parameters:         img.Parameters,
+		Thumbnail:          img.Thumbnail,
+	}
+}
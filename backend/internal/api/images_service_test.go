@@ -3,10 +3,15 @@ package api_test
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/binary"
+	"image"
+	"image/png"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -14,7 +19,9 @@ import (
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
 	genimages "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/images"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
 )
 
 // buildTestPNGWithTextChunks creates a minimal PNG file with the given tEXt chunks.
@@ -64,6 +71,42 @@ func writeTestChunk(buf *bytes.Buffer, chunkType string, data []byte) {
 	binary.Write(buf, binary.BigEndian, uint32(0))
 }
 
+// fakePinStore is an in-memory test double for service.PinStore.
+type fakePinStore struct {
+	pins map[string]model.Pin
+}
+
+func (f *fakePinStore) ListPins(ctx context.Context) ([]model.Pin, error) {
+	var result []model.Pin
+	for _, p := range f.pins {
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (f *fakePinStore) GetPin(ctx context.Context, path string) (model.Pin, error) {
+	p, ok := f.pins[path]
+	if !ok {
+		return model.Pin{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (f *fakePinStore) CreatePin(ctx context.Context, p model.Pin) error {
+	if _, ok := f.pins[p.Path]; !ok {
+		f.pins[p.Path] = p
+	}
+	return nil
+}
+
+func (f *fakePinStore) DeletePin(ctx context.Context, path string) error {
+	if _, ok := f.pins[path]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.pins, path)
+	return nil
+}
+
 var _ = Describe("ImagesService", func() {
 	var (
 		sampleDir string
@@ -106,7 +149,12 @@ var _ = Describe("ImagesService", func() {
 			Expect(result).NotTo(BeNil())
 			Expect(result.ContentType).To(Equal("image/png"))
 			Expect(result.ContentLength).To(Equal(int64(len(pngData))))
-			Expect(result.CacheControl).To(Equal("max-age=31536000, immutable"))
+			Expect(result.CacheControl).To(Equal("no-cache"))
+			Expect(result.AcceptRanges).To(HaveValue(Equal("bytes")))
+			Expect(result.Etag).To(HaveValue(MatchRegexp(`^"[0-9a-f]+-[0-9a-f]+"$`)))
+			info, err := os.Stat(imagePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.LastModified).To(HaveValue(Equal(info.ModTime().UTC().Format(http.TimeFormat))))
 
 			// Read the body and verify it matches the original data
 			Expect(body).NotTo(BeNil())
@@ -116,6 +164,26 @@ var _ = Describe("ImagesService", func() {
 			Expect(readData).To(Equal(pngData))
 		})
 
+		It("changes the ETag and Last-Modified date when the image is regenerated", func() {
+			imagePath := filepath.Join(sampleDir, "test.png")
+			Expect(os.WriteFile(imagePath, buildTestMinimalPNG(), 0644)).To(Succeed())
+			old := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			Expect(os.Chtimes(imagePath, old, old)).To(Succeed())
+
+			first, body, err := svc.Download(context.Background(), &genimages.DownloadPayload{Filepath: "test.png"})
+			Expect(err).NotTo(HaveOccurred())
+			body.Close()
+
+			Expect(os.WriteFile(imagePath, buildTestMinimalPNG(), 0644)).To(Succeed())
+			second, body, err := svc.Download(context.Background(), &genimages.DownloadPayload{Filepath: "test.png"})
+			Expect(err).NotTo(HaveOccurred())
+			body.Close()
+
+			Expect(first.LastModified).To(HaveValue(Equal("Wed, 01 Jan 2025 00:00:00 GMT")))
+			Expect(*second.Etag).NotTo(Equal(*first.Etag))
+			Expect(*second.LastModified).NotTo(Equal(*first.LastModified))
+		})
+
 		It("detects content type correctly for different image types", func() {
 			// Create a JPEG-like file
 			jpegHeader := []byte{0xFF, 0xD8, 0xFF}
@@ -188,6 +256,108 @@ var _ = Describe("ImagesService", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("invalid file path"))
 		})
+
+		Context("with size=thumb", func() {
+			const relPath = "checkpoint.safetensors/seed=1&_00001_.png"
+
+			BeforeEach(func() {
+				img := image.NewRGBA(image.Rect(0, 0, 1024, 1024))
+				var buf bytes.Buffer
+				Expect(png.Encode(&buf, img)).To(Succeed())
+				absPath := filepath.Join(sampleDir, relPath)
+				Expect(os.MkdirAll(filepath.Dir(absPath), 0755)).To(Succeed())
+				Expect(os.WriteFile(absPath, buf.Bytes(), 0644)).To(Succeed())
+
+				svc.SetThumbnailCache(service.NewThumbnailCache(service.RealThumbnailCacheFS{}, service.DefaultThumbnailCacheConfig, sampleDir, logger))
+			})
+
+			It("serves a cached JPEG thumbnail", func() {
+				result, body, err := svc.Download(context.Background(), &genimages.DownloadPayload{
+					Filepath: relPath,
+					Size:     "thumb",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				defer body.Close()
+				Expect(result.ContentType).To(Equal("image/jpeg"))
+
+				data, err := io.ReadAll(body)
+				Expect(err).NotTo(HaveOccurred())
+				cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.Width).To(Equal(256))
+				Expect(filepath.Join(sampleDir, "checkpoint.safetensors", ".thumbnails", "seed=1&_00001_.jpg")).To(BeARegularFile())
+			})
+
+			It("serves the original when the image cannot be thumbnailed", func() {
+				Expect(os.WriteFile(filepath.Join(sampleDir, relPath), buildTestMinimalPNG(), 0644)).To(Succeed())
+
+				result, body, err := svc.Download(context.Background(), &genimages.DownloadPayload{
+					Filepath: relPath,
+					Size:     "thumb",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				defer body.Close()
+				Expect(result.ContentType).To(Equal("image/png"))
+			})
+
+			It("returns not_found for a missing image", func() {
+				_, _, err := svc.Download(context.Background(), &genimages.DownloadPayload{
+					Filepath: "checkpoint.safetensors/missing.png",
+					Size:     "thumb",
+				})
+				serviceErr, ok := err.(errorNamer)
+				Expect(ok).To(BeTrue())
+				Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+			})
+		})
+	})
+
+	Describe("CheckpointArchive", func() {
+		BeforeEach(func() {
+			svc.SetCheckpointArchiveService(service.NewCheckpointArchiveService(store.NewFileSystem(logger), sampleDir, logger))
+			cpDir := filepath.Join(sampleDir, "run", "study", "model-step00001000.safetensors")
+			Expect(os.MkdirAll(cpDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cpDir, "seed=1&_00001_.png"), buildTestMinimalPNG(), 0644)).To(Succeed())
+		})
+
+		It("streams a ZIP archive named after the checkpoint", func() {
+			dir := "run/study"
+			result, body, err := svc.CheckpointArchive(context.Background(), &genimages.CheckpointArchivePayload{
+				Filename: "model-step00001000.safetensors",
+				Dir:      &dir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer body.Close()
+			Expect(result.ContentType).To(Equal("application/zip"))
+			Expect(result.ContentDisposition).To(Equal(`attachment; filename="model-step00001000-samples.zip"`))
+			data, err := io.ReadAll(body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(HavePrefix("PK"))
+		})
+
+		DescribeTable("maps errors",
+			func(filename, dir, name string) {
+				_, _, err := svc.CheckpointArchive(context.Background(), &genimages.CheckpointArchivePayload{
+					Filename: filename,
+					Dir:      &dir,
+				})
+				Expect(err).To(HaveOccurred())
+				serviceErr, ok := err.(errorNamer)
+				Expect(ok).To(BeTrue())
+				Expect(serviceErr.ErrorName()).To(Equal(name))
+			},
+			Entry("missing directory to not_found", "model-step00002000.safetensors", "run/study", "not_found"),
+			Entry("traversal to bad_request", "model-step00001000.safetensors", "../run", "bad_request"),
+		)
+
+		It("returns internal_error when checkpoint archives are not configured", func() {
+			unconfigured := api.NewImagesService(sampleDir, nil, logger)
+			_, _, err := unconfigured.CheckpointArchive(context.Background(), &genimages.CheckpointArchivePayload{Filename: "model.safetensors"})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
 	})
 
 	Describe("Metadata", func() {
@@ -213,6 +383,30 @@ var _ = Describe("ImagesService", func() {
 			Expect(result.NumericMetadata).To(BeEmpty())
 			Expect(result.StringMetadata["prompt"]).To(Equal(`{"3": {"class_type": "KSampler"}}`))
 			Expect(result.StringMetadata["workflow"]).To(Equal(`{"nodes": []}`))
+			Expect(result.Source).To(Equal("png"))
+		})
+
+		It("returns sidecar fields including VAE, CLIP, shift, workflow, job ID, and timestamp", func() {
+			subDir := filepath.Join(sampleDir, "checkpoint.safetensors")
+			Expect(os.MkdirAll(subDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(subDir, "image.png"), buildTestMinimalPNG(), 0644)).To(Succeed())
+			sidecar := `{"prompt_name":"forest","seed":420,"vae":"ae.safetensors","clip":"t5xxl.safetensors","shift":3.1,` +
+				`"workflow_name":"flux.json","job_id":"job-1","timestamp":"2026-02-25T12:00:00Z"}`
+			Expect(os.WriteFile(filepath.Join(subDir, "image.json"), []byte(sidecar), 0644)).To(Succeed())
+
+			result, err := svc.Metadata(context.Background(), &genimages.MetadataPayload{
+				Filepath: "checkpoint.safetensors/image.png",
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Source).To(Equal("sidecar"))
+			Expect(result.StringMetadata).To(HaveKeyWithValue("vae", "ae.safetensors"))
+			Expect(result.StringMetadata).To(HaveKeyWithValue("clip", "t5xxl.safetensors"))
+			Expect(result.StringMetadata).To(HaveKeyWithValue("workflow_name", "flux.json"))
+			Expect(result.StringMetadata).To(HaveKeyWithValue("job_id", "job-1"))
+			Expect(result.StringMetadata).To(HaveKeyWithValue("timestamp", "2026-02-25T12:00:00Z"))
+			Expect(result.NumericMetadata).To(HaveKeyWithValue("shift", 3.1))
+			Expect(result.NumericMetadata).To(HaveKeyWithValue("seed", 420.0))
 		})
 
 		It("returns empty metadata for PNG without tEXt chunks", func() {
@@ -227,6 +421,7 @@ var _ = Describe("ImagesService", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.StringMetadata).To(BeEmpty())
 			Expect(result.NumericMetadata).To(BeEmpty())
+			Expect(result.Source).To(Equal("none"))
 		})
 
 		It("returns not_found error for nonexistent image", func() {
@@ -256,4 +451,408 @@ var _ = Describe("ImagesService", func() {
 			Expect(err.Error()).To(ContainSubstring("invalid file path"))
 		})
 	})
+
+	Describe("Pins", func() {
+		var pinStore *fakePinStore
+
+		BeforeEach(func() {
+			pinStore = &fakePinStore{pins: make(map[string]model.Pin)}
+			svc.SetPinService(service.NewPinService(pinStore, logger))
+		})
+
+		It("pins, lists, and unpins a path", func() {
+			resp, err := svc.Pin(context.Background(), &genimages.PinPayload{
+				Path: "run/study/ckpt.safetensors/a.png",
+				Kind: "image",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Path).To(Equal("run/study/ckpt.safetensors/a.png"))
+			Expect(resp.Kind).To(Equal("image"))
+			Expect(resp.CreatedAt).NotTo(BeEmpty())
+
+			list, err := svc.ListPins(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list).To(HaveLen(1))
+
+			err = svc.Unpin(context.Background(), &genimages.UnpinPayload{Path: resp.Path})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pinStore.pins).To(BeEmpty())
+		})
+
+		It("returns bad_request for a path traversal attempt", func() {
+			_, err := svc.Pin(context.Background(), &genimages.PinPayload{
+				Path: "../etc/passwd",
+				Kind: "image",
+			})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("bad_request"))
+		})
+
+		It("returns not_found when unpinning a path that is not pinned", func() {
+			err := svc.Unpin(context.Background(), &genimages.UnpinPayload{Path: "missing.png"})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("returns internal_error when pinning is not configured", func() {
+			unconfigured := api.NewImagesService(sampleDir, nil, logger)
+			_, err := unconfigured.ListPins(context.Background())
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("Annotations", func() {
+		var st *store.Store
+
+		BeforeEach(func() {
+			db, err := store.OpenDB(filepath.Join(sampleDir, "test.db"))
+			Expect(err).NotTo(HaveOccurred())
+			st, err = store.New(db, logger)
+			Expect(err).NotTo(HaveOccurred())
+			svc.SetImageAnnotationService(service.NewImageAnnotationService(st, sampleDir, logger))
+		})
+
+		AfterEach(func() {
+			st.Close()
+		})
+
+		It("annotates, lists, and deletes an annotation", func() {
+			path := "run/study/ckpt.safetensors/a.png"
+			resp, err := svc.Annotate(context.Background(), &genimages.AnnotateImagePayload{
+				Path:   &path,
+				Rating: 4,
+				Tags:   []string{"keeper"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Path).To(Equal(path))
+			Expect(resp.Rating).To(Equal(4))
+			Expect(resp.Tags).To(Equal([]string{"keeper"}))
+			Expect(resp.UpdatedAt).NotTo(BeEmpty())
+
+			tag := "keeper"
+			list, err := svc.ListAnnotations(context.Background(), &genimages.ListAnnotationsPayload{MinRating: 4, Tag: &tag})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list).To(HaveLen(1))
+
+			Expect(svc.DeleteAnnotation(context.Background(), &genimages.DeleteAnnotationPayload{Path: &path})).To(Succeed())
+			err = svc.DeleteAnnotation(context.Background(), &genimages.DeleteAnnotationPayload{Path: &path})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("bulk annotates images by path", func() {
+			rating := 5
+			resp, err := svc.BulkAnnotate(context.Background(), &genimages.BulkAnnotateImagesPayload{
+				Paths:   []string{"a.png", "b.png"},
+				Rating:  &rating,
+				AddTags: []string{"keeper"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp).To(HaveLen(2))
+			Expect(resp[1].Path).To(Equal("b.png"))
+			Expect(resp[1].Rating).To(Equal(5))
+			Expect(resp[1].Tags).To(Equal([]string{"keeper"}))
+		})
+
+		It("returns not_found for an unknown sample job item", func() {
+			itemID := "missing"
+			_, err := svc.Annotate(context.Background(), &genimages.AnnotateImagePayload{ItemID: &itemID, Rating: 1})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("returns bad_request for an invalid rating", func() {
+			path := "a.png"
+			_, err := svc.Annotate(context.Background(), &genimages.AnnotateImagePayload{Path: &path, Rating: 9})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("bad_request"))
+		})
+
+		It("includes the annotation in metadata responses", func() {
+			subDir := filepath.Join(sampleDir, "checkpoint.safetensors")
+			Expect(os.MkdirAll(subDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(subDir, "image.png"), buildTestMinimalPNG(), 0644)).To(Succeed())
+
+			result, err := svc.Metadata(context.Background(), &genimages.MetadataPayload{Filepath: "checkpoint.safetensors/image.png"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Annotation).NotTo(BeNil())
+			Expect(result.Annotation.Rating).To(Equal(0))
+			Expect(result.Annotation.UpdatedAt).To(BeEmpty())
+
+			path := "checkpoint.safetensors/image.png"
+			_, err = svc.Annotate(context.Background(), &genimages.AnnotateImagePayload{Path: &path, Rating: 3, Tags: []string{"keeper"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err = svc.Metadata(context.Background(), &genimages.MetadataPayload{Filepath: "checkpoint.safetensors/image.png"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Annotation.Rating).To(Equal(3))
+			Expect(result.Annotation.Tags).To(Equal([]string{"keeper"}))
+		})
+
+		It("returns internal_error when annotations are not configured", func() {
+			unconfigured := api.NewImagesService(sampleDir, nil, logger)
+			_, err := unconfigured.ListAnnotations(context.Background(), &genimages.ListAnnotationsPayload{})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("Grid", func() {
+		var viewerFS *fakeViewerDiscoveryFS
+
+		BeforeEach(func() {
+			viewerFS = newFakeViewerDiscoveryFS()
+			viewerFS.subdirs[sampleDir] = []string{"model-step00001000.safetensors"}
+			scanFS := newFakeScanFS()
+			scanFS.files[filepath.Join(sampleDir, "model-step00001000.safetensors")] = []string{"cfg=3&prompt_name=forest&_00001_.png"}
+			svc.SetGridRenderer(
+				service.NewViewerDiscoveryService(viewerFS, sampleDir, logger),
+				service.NewScanner(scanFS, sampleDir, logger),
+				service.NewGridRenderer(scanFS, sampleDir, logger),
+			)
+		})
+
+		It("returns a PNG with no-store caching for a valid axis mapping", func() {
+			result, body, err := svc.Grid(context.Background(), &genimages.GridPayload{
+				TrainingRunID: 0,
+				XAxis:         "cfg",
+				YAxis:         "checkpoint",
+				CellSize:      32,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer body.Close()
+			Expect(result.ContentType).To(Equal("image/png"))
+			Expect(result.CacheControl).To(Equal("no-store"))
+
+			data, err := io.ReadAll(body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(int64(len(data))).To(Equal(result.ContentLength))
+			Expect(data[:4]).To(Equal([]byte{0x89, 'P', 'N', 'G'}))
+		})
+
+		It("returns not_found for an unknown training run", func() {
+			_, _, err := svc.Grid(context.Background(), &genimages.GridPayload{
+				TrainingRunID: 5,
+				XAxis:         "cfg",
+				YAxis:         "checkpoint",
+			})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("returns bad_request for an unknown axis dimension", func() {
+			_, _, err := svc.Grid(context.Background(), &genimages.GridPayload{
+				TrainingRunID: 0,
+				XAxis:         "lora",
+				YAxis:         "checkpoint",
+			})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("bad_request"))
+		})
+
+		It("returns internal_error when grid rendering is not configured", func() {
+			unconfigured := api.NewImagesService(sampleDir, nil, logger)
+			_, _, err := unconfigured.Grid(context.Background(), &genimages.GridPayload{XAxis: "cfg", YAxis: "checkpoint"})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("Timeline", func() {
+		BeforeEach(func() {
+			viewerFS := newFakeViewerDiscoveryFS()
+			viewerFS.subdirs[sampleDir] = []string{"model-step00001000.safetensors"}
+			scanFS := newFakeScanFS()
+			scanFS.files[filepath.Join(sampleDir, "model-step00001000.safetensors")] = []string{"cfg=3&prompt_name=forest&_00001_.png"}
+
+			var buf bytes.Buffer
+			Expect(png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 32, 32)))).To(Succeed())
+			absPath := filepath.Join(sampleDir, "model-step00001000.safetensors", "cfg=3&prompt_name=forest&_00001_.png")
+			Expect(os.MkdirAll(filepath.Dir(absPath), 0755)).To(Succeed())
+			Expect(os.WriteFile(absPath, buf.Bytes(), 0644)).To(Succeed())
+
+			svc.SetTimelineRenderer(
+				service.NewViewerDiscoveryService(viewerFS, sampleDir, logger),
+				service.NewScanner(scanFS, sampleDir, logger),
+				service.NewTimelineRenderer(service.RealThumbnailCacheFS{}, sampleDir, logger),
+			)
+		})
+
+		It("returns a GIF with no-store caching", func() {
+			result, body, err := svc.Timeline(context.Background(), &genimages.TimelinePayload{
+				TrainingRunID: 0,
+				Filters:       map[string]string{"prompt_name": "forest", "cfg": "3"},
+				FrameSize:     32,
+				FrameDelayMs:  500,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer body.Close()
+			Expect(result.ContentType).To(Equal("image/gif"))
+			Expect(result.CacheControl).To(Equal("no-store"))
+
+			data, err := io.ReadAll(body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(int64(len(data))).To(Equal(result.ContentLength))
+			Expect(string(data[:6])).To(Equal("GIF89a"))
+		})
+
+		DescribeTable("maps errors to API errors",
+			func(p *genimages.TimelinePayload, expected string) {
+				_, _, err := svc.Timeline(context.Background(), p)
+				Expect(err).To(HaveOccurred())
+				serviceErr, ok := err.(errorNamer)
+				Expect(ok).To(BeTrue())
+				Expect(serviceErr.ErrorName()).To(Equal(expected))
+			},
+			Entry("unknown training run", &genimages.TimelinePayload{TrainingRunID: 5}, "not_found"),
+			Entry("no matching images", &genimages.TimelinePayload{Filters: map[string]string{"prompt_name": "city"}}, "not_found"),
+			Entry("checkpoint filter", &genimages.TimelinePayload{Filters: map[string]string{"checkpoint": "1000"}}, "bad_request"),
+		)
+
+		It("returns internal_error when timeline rendering is not configured", func() {
+			unconfigured := api.NewImagesService(sampleDir, nil, logger)
+			_, _, err := unconfigured.Timeline(context.Background(), &genimages.TimelinePayload{})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("Compare", func() {
+		const (
+			pathA = "model-step00001000.safetensors/prompt_name=forest&seed=420&_00001_.png"
+			pathB = "model-step00002000.safetensors/prompt_name=forest&seed=420&_00001_.png"
+		)
+
+		writeImage := func(relPath string, w, h int) {
+			img := image.NewRGBA(image.Rect(0, 0, w, h))
+			var buf bytes.Buffer
+			Expect(png.Encode(&buf, img)).To(Succeed())
+			absPath := filepath.Join(sampleDir, relPath)
+			Expect(os.MkdirAll(filepath.Dir(absPath), 0755)).To(Succeed())
+			Expect(os.WriteFile(absPath, buf.Bytes(), 0644)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			fs := &realFileReader{}
+			svc.SetComparisonService(service.NewComparisonService(fs, service.NewImageMetadataService(fs, sampleDir, logger), sampleDir, logger))
+		})
+
+		It("returns both sides with aligned thumbnails", func() {
+			writeImage(pathA, 32, 16)
+			writeImage(pathB, 32, 16)
+
+			result, err := svc.Compare(context.Background(), &genimages.ComparePayload{A: pathA, B: pathB})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.A.CheckpointFilename).To(Equal("model-step00001000.safetensors"))
+			Expect(result.B.StepNumber).To(HaveValue(Equal(2000)))
+			Expect(result.SharedParameters).To(HaveKeyWithValue("seed", "420"))
+			Expect(result.ThumbnailWidth).To(Equal(32))
+			Expect(result.ThumbnailHeight).To(Equal(16))
+			Expect(result.A.Thumbnail).NotTo(BeEmpty())
+			Expect(result.B.Thumbnail).NotTo(BeEmpty())
+		})
+
+		DescribeTable("maps service errors to API errors",
+			func(a, b string, expected string) {
+				writeImage(pathA, 32, 16)
+				writeImage("model-step00002000.safetensors/prompt_name=city&seed=420&_00001_.png", 32, 16)
+
+				_, err := svc.Compare(context.Background(), &genimages.ComparePayload{A: a, B: b})
+				Expect(err).To(HaveOccurred())
+				serviceErr, ok := err.(errorNamer)
+				Expect(ok).To(BeTrue())
+				Expect(serviceErr.ErrorName()).To(Equal(expected))
+			},
+			Entry("path traversal", "../secret.png", pathA, "bad_request"),
+			Entry("missing image", pathA, pathB, "not_found"),
+			Entry("different prompt", pathA, "model-step00002000.safetensors/prompt_name=city&seed=420&_00001_.png", "not_comparable"),
+		)
+
+		It("returns internal_error when comparison is not configured", func() {
+			unconfigured := api.NewImagesService(sampleDir, nil, logger)
+			_, err := unconfigured.Compare(context.Background(), &genimages.ComparePayload{A: pathA, B: pathB})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("BackfillSidecars", func() {
+		It("writes missing sidecars and reports the counts", func() {
+			dir := filepath.Join(sampleDir, "model.safetensors")
+			Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "prompt=forest&seed=1.png"), buildTestMinimalPNG(), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "prompt=city&seed=1.png"), buildTestMinimalPNG(), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "prompt=city&seed=1.json"), []byte(`{}`), 0644)).To(Succeed())
+			svc.SetSidecarBackfillService(service.NewSidecarBackfillService(store.NewFileSystem(logger), &service.RealFileSystemWriter{}, sampleDir, logger))
+
+			result, err := svc.BackfillSidecars(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Scanned).To(Equal(2))
+			Expect(result.Written).To(Equal(1))
+			Expect(result.Skipped).To(Equal(1))
+			Expect(result.Failed).To(Equal(0))
+			Expect(result.FailedPaths).To(BeEmpty())
+			Expect(filepath.Join(dir, "prompt=forest&seed=1.json")).To(BeAnExistingFile())
+		})
+
+		It("returns internal_error when sidecar backfill is not configured", func() {
+			_, err := svc.BackfillSidecars(context.Background())
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("DeleteSamples", func() {
+		BeforeEach(func() {
+			svc.SetSampleDeleteService(service.NewSampleDeleteService(store.NewFileSystem(logger), service.NewHub(logger), sampleDir, logger))
+		})
+
+		It("deletes the matching images and their sidecars", func() {
+			dir := filepath.Join(sampleDir, "run", "study", "model.safetensors")
+			Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "prompt_name=forest&seed=1.png"), buildTestMinimalPNG(), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "prompt_name=forest&seed=1.json"), []byte(`{}`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "prompt_name=city&seed=1.png"), buildTestMinimalPNG(), 0644)).To(Succeed())
+			promptName := "forest"
+
+			result, err := svc.DeleteSamples(context.Background(), &genimages.DeleteSamplesPayload{TrainingRun: "run", PromptName: &promptName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Deleted).To(Equal([]string{"run/study/model.safetensors/prompt_name=forest&seed=1.png"}))
+			Expect(filepath.Join(dir, "prompt_name=forest&seed=1.png")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(dir, "prompt_name=forest&seed=1.json")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(dir, "prompt_name=city&seed=1.png")).To(BeAnExistingFile())
+		})
+
+		It("returns bad_request for an invalid before time", func() {
+			before := "yesterday"
+			_, err := svc.DeleteSamples(context.Background(), &genimages.DeleteSamplesPayload{TrainingRun: "run", Before: &before})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("bad_request"))
+		})
+	})
 })
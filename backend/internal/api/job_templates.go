@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	genjobtemplates "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/job_templates"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// JobTemplatesService implements the generated job_templates service
+// interface. Templates are run through the sample_jobs service.
+type JobTemplatesService struct {
+	svc *service.JobTemplateService
+}
+
+// NewJobTemplatesService returns a new JobTemplatesService.
+func NewJobTemplatesService(svc *service.JobTemplateService) *JobTemplatesService {
+	return &JobTemplatesService{svc: svc}
+}
+
+// List returns all job templates.
+func (s *JobTemplatesService) List(ctx context.Context) ([]*genjobtemplates.JobTemplateResponse, error) {
+	templates, err := s.svc.List(ctx)
+	if err != nil {
+		return nil, genjobtemplates.MakeInternalError(fmt.Errorf("listing job templates: %w", err))
+	}
+	result := make([]*genjobtemplates.JobTemplateResponse, len(templates))
+	for i, t := range templates {
+		result[i] = jobTemplateToResponse(t)
+	}
+	return result, nil
+}
+
+// Show returns a job template.
+func (s *JobTemplatesService) Show(ctx context.Context, p *genjobtemplates.ShowPayload) (*genjobtemplates.JobTemplateResponse, error) {
+	t, err := s.svc.Get(ctx, p.ID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genjobtemplates.MakeNotFound(err)
+		}
+		return nil, genjobtemplates.MakeInternalError(fmt.Errorf("fetching job template: %w", err))
+	}
+	return jobTemplateToResponse(t), nil
+}
+
+// Create saves a new job template.
+func (s *JobTemplatesService) Create(ctx context.Context, p *genjobtemplates.CreateJobTemplatePayload) (*genjobtemplates.JobTemplateResponse, error) {
+	t, err := s.svc.Create(ctx, createJobTemplatePayloadToModel(p))
+	if err != nil {
+		return nil, genjobtemplates.MakeInvalidPayload(fmt.Errorf("creating job template: %w", err))
+	}
+	return jobTemplateToResponse(t), nil
+}
+
+// Update replaces the configuration of a job template.
+func (s *JobTemplatesService) Update(ctx context.Context, p *genjobtemplates.UpdateJobTemplatePayload) (*genjobtemplates.JobTemplateResponse, error) {
+	t := createJobTemplatePayloadToModel(&genjobtemplates.CreateJobTemplatePayload{
+		Name:                 p.Name,
+		StudyID:              p.StudyID,
+		CheckpointFilter:     p.CheckpointFilter,
+		ClearExisting:        p.ClearExisting,
+		MissingOnly:          p.MissingOnly,
+		SkipExisting:         p.SkipExisting,
+		AppendNewCheckpoints: p.AppendNewCheckpoints,
+		OutputFormat:         p.OutputFormat,
+		OutputQuality:        p.OutputQuality,
+		Vae:                  p.Vae,
+		Clip:                 p.Clip,
+		Shift:                p.Shift,
+		ControlnetModel:      p.ControlnetModel,
+		ControlnetStrength:   p.ControlnetStrength,
+		ControlnetImage:      p.ControlnetImage,
+	})
+	t.ID = p.ID
+	t, err := s.svc.Update(ctx, t)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genjobtemplates.MakeNotFound(err)
+		}
+		return nil, genjobtemplates.MakeInvalidPayload(fmt.Errorf("updating job template: %w", err))
+	}
+	return jobTemplateToResponse(t), nil
+}
+
+// Delete removes a job template.
+func (s *JobTemplatesService) Delete(ctx context.Context, p *genjobtemplates.DeletePayload) error {
+	if err := s.svc.Delete(ctx, p.ID); err != nil {
+		if isNotFound(err) {
+			return genjobtemplates.MakeNotFound(err)
+		}
+		return genjobtemplates.MakeInternalError(fmt.Errorf("deleting job template: %w", err))
+	}
+	return nil
+}
+
+func createJobTemplatePayloadToModel(p *genjobtemplates.CreateJobTemplatePayload) model.JobTemplate {
+	t := model.JobTemplate{
+		Name:                 p.Name,
+		StudyID:              p.StudyID,
+		CheckpointFilter:     p.CheckpointFilter,
+		ClearExisting:        p.ClearExisting,
+		MissingOnly:          p.MissingOnly,
+		SkipExisting:         p.SkipExisting,
+		AppendNewCheckpoints: p.AppendNewCheckpoints,
+		Output:               outputOptions(p.OutputFormat, p.OutputQuality),
+	}
+	if p.Vae != nil {
+		t.Overrides.VAE = *p.Vae
+	}
+	if p.Clip != nil {
+		t.Overrides.CLIP = *p.Clip
+	}
+	t.Overrides.Shift = p.Shift
+	if p.ControlnetModel != nil {
+		t.Overrides.ControlNetModel = *p.ControlnetModel
+	}
+	t.Overrides.ControlNetStrength = p.ControlnetStrength
+	if p.ControlnetImage != nil {
+		t.Overrides.ControlNetImage = *p.ControlnetImage
+	}
+	return t
+}
+
+func jobTemplateToResponse(t model.JobTemplate) *genjobtemplates.JobTemplateResponse {
+	resp := &genjobtemplates.JobTemplateResponse{
+		ID:                   t.ID,
+		Name:                 t.Name,
+		StudyID:              t.StudyID,
+		CheckpointFilter:     t.CheckpointFilter,
+		ClearExisting:        t.ClearExisting,
+		MissingOnly:          t.MissingOnly,
+		SkipExisting:         t.SkipExisting,
+		AppendNewCheckpoints: t.AppendNewCheckpoints,
+		OutputFormat:         string(t.Output.Format),
+		Shift:                t.Overrides.Shift,
+		ControlnetStrength:   t.Overrides.ControlNetStrength,
+		CreatedAt:            t.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:            t.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if t.Output.Quality > 0 {
+		resp.OutputQuality = &t.Output.Quality
+	}
+	if t.Overrides.VAE != "" {
+		resp.Vae = &t.Overrides.VAE
+	}
+	if t.Overrides.CLIP != "" {
+		resp.Clip = &t.Overrides.CLIP
+	}
+	if t.Overrides.ControlNetModel != "" {
+		resp.ControlnetModel = &t.Overrides.ControlNetModel
+	}
+	if t.Overrides.ControlNetImage != "" {
+		resp.ControlnetImage = &t.Overrides.ControlNetImage
+	}
+	return resp
+}
@@ -0,0 +1,148 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	genjobtemplates "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/job_templates"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("JobTemplatesService", func() {
+	var (
+		svc    *api.JobTemplatesService
+		st     *store.Store
+		tmpDir string
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		tmpDir, err = os.MkdirTemp("", "job-templates-api-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		now := time.Now().UTC()
+		Expect(st.CreateStudy(ctx, model.Study{
+			ID:                    "study-1",
+			Name:                  "Sweep",
+			Prompts:               []model.NamedPrompt{{Name: "forest", Text: "a forest"}},
+			Steps:                 []int{20},
+			CFGs:                  []float64{7},
+			SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+			Seeds:                 []int64{42},
+			Width:                 512,
+			Height:                512,
+			CreatedAt:             now,
+			UpdatedAt:             now,
+		})).To(Succeed())
+
+		svc = api.NewJobTemplatesService(service.NewJobTemplateService(st, logger))
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	newPayload := func() *genjobtemplates.CreateJobTemplatePayload {
+		vae := "ae.safetensors"
+		shift := 3.1
+		return &genjobtemplates.CreateJobTemplatePayload{
+			Name:             "Nightly",
+			StudyID:          "study-1",
+			CheckpointFilter: "*-step*.safetensors",
+			ClearExisting:    true,
+			OutputFormat:     "png",
+			Vae:              &vae,
+			Shift:            &shift,
+		}
+	}
+
+	It("creates, shows, and lists a template", func() {
+		created, err := svc.Create(ctx, newPayload())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.ID).NotTo(BeEmpty())
+		Expect(created.CheckpointFilter).To(Equal("*-step*.safetensors"))
+		Expect(created.ClearExisting).To(BeTrue())
+		Expect(*created.Vae).To(Equal("ae.safetensors"))
+		Expect(*created.Shift).To(Equal(3.1))
+		Expect(created.Clip).To(BeNil())
+
+		shown, err := svc.Show(ctx, &genjobtemplates.ShowPayload{ID: created.ID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shown).To(Equal(created))
+
+		list, err := svc.List(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list).To(HaveLen(1))
+	})
+
+	It("updates a template", func() {
+		created, err := svc.Create(ctx, newPayload())
+		Expect(err).NotTo(HaveOccurred())
+
+		updated, err := svc.Update(ctx, &genjobtemplates.UpdateJobTemplatePayload{
+			ID:           created.ID,
+			Name:         "Weekly",
+			StudyID:      "study-1",
+			OutputFormat: "png",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Name).To(Equal("Weekly"))
+		Expect(updated.Vae).To(BeNil())
+		Expect(updated.CreatedAt).To(Equal(created.CreatedAt))
+	})
+
+	It("deletes a template", func() {
+		created, err := svc.Create(ctx, newPayload())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(svc.Delete(ctx, &genjobtemplates.DeletePayload{ID: created.ID})).To(Succeed())
+		_, err = svc.Show(ctx, &genjobtemplates.ShowPayload{ID: created.ID})
+		Expect(err.(errorNamer).ErrorName()).To(Equal("not_found"))
+	})
+
+	It("returns invalid_payload for a template of an unknown study", func() {
+		p := newPayload()
+		p.StudyID = "missing"
+		_, err := svc.Create(ctx, p)
+		Expect(err.(errorNamer).ErrorName()).To(Equal("invalid_payload"))
+		Expect(err.Error()).To(ContainSubstring("unknown study missing"))
+	})
+
+	It("distinguishes an unknown template from an unknown study on update", func() {
+		_, err := svc.Update(ctx, &genjobtemplates.UpdateJobTemplatePayload{ID: "missing", Name: "Weekly", StudyID: "study-1", OutputFormat: "png"})
+		Expect(err.(errorNamer).ErrorName()).To(Equal("not_found"))
+
+		created, err := svc.Create(ctx, newPayload())
+		Expect(err).NotTo(HaveOccurred())
+		_, err = svc.Update(ctx, &genjobtemplates.UpdateJobTemplatePayload{ID: created.ID, Name: "Weekly", StudyID: "missing", OutputFormat: "png"})
+		Expect(err.(errorNamer).ErrorName()).To(Equal("invalid_payload"))
+	})
+
+	It("returns not_found when deleting an unknown template", func() {
+		err := svc.Delete(ctx, &genjobtemplates.DeletePayload{ID: "missing"})
+		Expect(err.(errorNamer).ErrorName()).To(Equal("not_found"))
+	})
+})
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	genmeta "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/meta"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// MetaService implements the generated meta service interface.
+type MetaService struct {
+	clock *service.ServerClock
+}
+
+// NewMetaService returns a new MetaService.
+func NewMetaService(clock *service.ServerClock) *MetaService {
+	return &MetaService{clock: clock}
+}
+
+// Show reports the server's time zone and the locale for the request.
+func (s *MetaService) Show(ctx context.Context, p *genmeta.ShowPayload) (*genmeta.ServerMetaResponse, error) {
+	acceptLanguage := ""
+	if p.AcceptLanguage != nil {
+		acceptLanguage = *p.AcceptLanguage
+	}
+	meta := s.clock.Meta(acceptLanguage)
+
+	resp := &genmeta.ServerMetaResponse{
+		Timezone:         meta.Timezone,
+		ZoneAbbreviation: meta.ZoneAbbreviation,
+		UtcOffsetSeconds: meta.UTCOffsetSeconds,
+		Dst:              meta.DST,
+		ServerTime:       meta.ServerTime.Format(time.RFC3339),
+		Locale:           meta.Locale,
+		DefaultLocale:    meta.DefaultLocale,
+		AcceptedLocales:  meta.AcceptedLocales,
+	}
+	if meta.NextTransition != nil {
+		t := meta.NextTransition.UTC().Format(time.RFC3339)
+		resp.NextTransition = &t
+	}
+	return resp, nil
+}
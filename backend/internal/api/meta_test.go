@@ -0,0 +1,50 @@
+package api_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	genmeta "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/meta"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("MetaService", func() {
+	newService := func(timezone string) *api.MetaService {
+		clock, err := service.NewServerClock(timezone, "en-US")
+		Expect(err).NotTo(HaveOccurred())
+		return api.NewMetaService(clock)
+	}
+
+	It("reports the time zone and the default locale without Accept-Language", func() {
+		resp, err := newService("UTC").Show(context.Background(), &genmeta.ShowPayload{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Timezone).To(Equal("UTC"))
+		Expect(resp.ZoneAbbreviation).To(Equal("UTC"))
+		Expect(resp.UtcOffsetSeconds).To(BeZero())
+		Expect(resp.Dst).To(BeFalse())
+		Expect(resp.NextTransition).To(BeNil())
+		_, err = time.Parse(time.RFC3339, resp.ServerTime)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Locale).To(Equal("en-US"))
+		Expect(resp.DefaultLocale).To(Equal("en-US"))
+		Expect(resp.AcceptedLocales).NotTo(BeNil())
+		Expect(resp.AcceptedLocales).To(BeEmpty())
+	})
+
+	It("picks the locale from Accept-Language and reports the next DST change", func() {
+		acceptLanguage := "en;q=0.5, de-DE"
+		resp, err := newService("Europe/Berlin").Show(context.Background(), &genmeta.ShowPayload{AcceptLanguage: &acceptLanguage})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Timezone).To(Equal("Europe/Berlin"))
+		Expect(resp.Locale).To(Equal("de-DE"))
+		Expect(resp.AcceptedLocales).To(Equal([]string{"de-DE", "en"}))
+		Expect(resp.NextTransition).NotTo(BeNil())
+		next, err := time.Parse(time.RFC3339, *resp.NextTransition)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(next.Location()).To(Equal(time.UTC))
+	})
+})
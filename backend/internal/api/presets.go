@@ -21,10 +21,24 @@ func NewPresetsService(svc *service.PresetService) *PresetsService {
 	return &PresetsService{svc: svc}
 }
 
-// List returns all saved presets.
-func (s *PresetsService) List(ctx context.Context) ([]*genpresets.PresetResponse, error) {
-	presets, err := s.svc.List()
+// List returns all saved presets, or those of a training run and the
+// unscoped presets, of the requested owner.
+func (s *PresetsService) List(ctx context.Context, p *genpresets.ListPayload) ([]*genpresets.PresetResponse, error) {
+	var owner string
+	if p.Owner != nil {
+		owner = *p.Owner
+	}
+	var presets []model.Preset
+	var err error
+	if p.TrainingRun != nil && *p.TrainingRun != "" {
+		presets, err = s.svc.ListForTrainingRun(ctx, *p.TrainingRun, owner)
+	} else {
+		presets, err = s.svc.List(ctx, owner)
+	}
 	if err != nil {
+		if isForbidden(err) {
+			return nil, genpresets.MakeForbidden(err)
+		}
 		return nil, genpresets.MakeInternalError(fmt.Errorf("listing presets: %w", err))
 	}
 	result := make([]*genpresets.PresetResponse, len(presets))
@@ -34,10 +48,21 @@ func (s *PresetsService) List(ctx context.Context) ([]*genpresets.PresetResponse
 	return result, nil
 }
 
+// Default returns the default preset of a training run.
+func (s *PresetsService) Default(ctx context.Context, p *genpresets.DefaultPayload) (*genpresets.PresetResponse, error) {
+	preset, err := s.svc.Default(ctx, p.TrainingRun)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genpresets.MakeNotFound(err)
+		}
+		return nil, genpresets.MakeInternalError(fmt.Errorf("getting default preset: %w", err))
+	}
+	return presetToResponse(preset), nil
+}
+
 // Create creates a new preset.
 func (s *PresetsService) Create(ctx context.Context, p *genpresets.CreatePresetPayload) (*genpresets.PresetResponse, error) {
-	mapping := payloadToMapping(p.Mapping)
-	preset, err := s.svc.Create(p.Name, mapping)
+	preset, err := s.svc.Create(ctx, createPayloadToPreset(p))
 	if err != nil {
 		return nil, genpresets.MakeInvalidPayload(fmt.Errorf("creating preset: %w", err))
 	}
@@ -46,24 +71,95 @@ func (s *PresetsService) Create(ctx context.Context, p *genpresets.CreatePresetP
 
 // Update modifies an existing preset.
 func (s *PresetsService) Update(ctx context.Context, p *genpresets.UpdatePresetPayload) (*genpresets.PresetResponse, error) {
-	mapping := payloadToMapping(p.Mapping)
-	preset, err := s.svc.Update(p.ID, p.Name, mapping)
+	preset, err := s.svc.Update(ctx, p.ID, createPayloadToPreset(&genpresets.CreatePresetPayload{
+		Name:        p.Name,
+		Mapping:     p.Mapping,
+		TrainingRun: p.TrainingRun,
+		View:        p.View,
+		Default:     p.Default,
+	}))
 	if err != nil {
 		if isNotFound(err) {
 			return nil, genpresets.MakeNotFound(err)
 		}
+		if isForbidden(err) {
+			return nil, genpresets.MakeForbidden(err)
+		}
 		return nil, genpresets.MakeInvalidPayload(fmt.Errorf("updating preset: %w", err))
 	}
 	return presetToResponse(preset), nil
 }
 
+// Export returns a preset, or all presets, as a portable document.
+func (s *PresetsService) Export(ctx context.Context, p *genpresets.ExportPayload) (*genpresets.PresetExportDocument, error) {
+	var id string
+	if p.ID != nil {
+		id = *p.ID
+	}
+	presets, err := s.svc.Export(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genpresets.MakeNotFound(err)
+		}
+		return nil, genpresets.MakeInternalError(fmt.Errorf("exporting presets: %w", err))
+	}
+	doc := &genpresets.PresetExportDocument{
+		FormatVersion: model.ExportFormatVersion,
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		Presets:       make([]*genpresets.CreatePresetPayload, len(presets)),
+	}
+	for i, preset := range presets {
+		doc.Presets[i] = &genpresets.CreatePresetPayload{
+			Name:    preset.Name,
+			Mapping: mappingToPayload(preset.Mapping),
+			View:    viewToPayload(preset.View),
+			Default: preset.Default,
+		}
+		if preset.TrainingRun != "" {
+			doc.Presets[i].TrainingRun = &preset.TrainingRun
+		}
+	}
+	return doc, nil
+}
+
+// Import creates the presets of an exported document.
+func (s *PresetsService) Import(ctx context.Context, p *genpresets.ImportPayload) ([]*genpresets.PresetImportResult, error) {
+	if err := checkExportFormatVersion(p.FormatVersion); err != nil {
+		return nil, genpresets.MakeInvalidPayload(err)
+	}
+	presets := make([]model.Preset, len(p.Presets))
+	for i, preset := range p.Presets {
+		presets[i] = createPayloadToPreset(preset)
+	}
+	entries, err := s.svc.Import(ctx, presets, model.ImportConflictPolicy(p.OnConflict))
+	if err != nil {
+		if isForbidden(err) {
+			return nil, genpresets.MakeForbidden(err)
+		}
+		return nil, genpresets.MakeInvalidPayload(fmt.Errorf("importing presets: %w", err))
+	}
+	result := make([]*genpresets.PresetImportResult, len(entries))
+	for i, e := range entries {
+		result[i] = &genpresets.PresetImportResult{
+			SourceName: e.SourceName,
+			ID:         e.ID,
+			Name:       e.Name,
+			Action:     string(e.Action),
+		}
+	}
+	return result, nil
+}
+
 // Delete removes a preset.
 func (s *PresetsService) Delete(ctx context.Context, p *genpresets.DeletePayload) error {
-	err := s.svc.Delete(p.ID)
+	err := s.svc.Delete(ctx, p.ID)
 	if err != nil {
 		if isNotFound(err) {
 			return genpresets.MakeNotFound(err)
 		}
+		if isForbidden(err) {
+			return genpresets.MakeForbidden(err)
+		}
 		return genpresets.MakeInternalError(fmt.Errorf("deleting preset: %w", err))
 	}
 	return nil
@@ -91,13 +187,60 @@ func presetToResponse(p model.Preset) *genpresets.PresetResponse {
 	if p.Mapping.YSlider != "" {
 		mapping.YSlider = &p.Mapping.YSlider
 	}
-	return &genpresets.PresetResponse{
-		ID:        p.ID,
-		Name:      p.Name,
-		Mapping:   mapping,
-		CreatedAt: p.CreatedAt.UTC().Format(time.RFC3339),
-		UpdatedAt: p.UpdatedAt.UTC().Format(time.RFC3339),
+	resp := &genpresets.PresetResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Mapping:     mapping,
+		TrainingRun: p.TrainingRun,
+		View:        viewToPayload(p.View),
+		Default:     p.Default,
+		CreatedAt:   p.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   p.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if p.Owner != "" {
+		resp.Owner = &p.Owner
+	}
+	return resp
+}
+
+func createPayloadToPreset(p *genpresets.CreatePresetPayload) model.Preset {
+	preset := model.Preset{
+		Name:    p.Name,
+		Mapping: payloadToMapping(p.Mapping),
+		View:    payloadToView(p.View),
+		Default: p.Default,
+	}
+	if p.TrainingRun != nil {
+		preset.TrainingRun = *p.TrainingRun
+	}
+	return preset
+}
+
+func payloadToView(p *genpresets.PresetView) model.PresetView {
+	if p == nil {
+		return model.PresetView{}
+	}
+	v := model.PresetView{Filters: p.Filters}
+	for _, sort := range p.Sort {
+		v.Sort = append(v.Sort, model.PresetSort{Dimension: sort.Dimension, Descending: sort.Descending})
+	}
+	return v
+}
+
+// viewToPayload converts a view for a response or an export document, with
+// empty filters and sort rather than absent ones.
+func viewToPayload(v model.PresetView) *genpresets.PresetView {
+	p := &genpresets.PresetView{
+		Filters: v.Filters,
+		Sort:    make([]*genpresets.PresetSort, len(v.Sort)),
+	}
+	if p.Filters == nil {
+		p.Filters = map[string][]string{}
 	}
+	for i, sort := range v.Sort {
+		p.Sort[i] = &genpresets.PresetSort{Dimension: sort.Dimension, Descending: sort.Descending}
+	}
+	return p
 }
 
 func payloadToMapping(p *genpresets.PresetMappingPayload) model.PresetMapping {
@@ -122,6 +265,48 @@ func payloadToMapping(p *genpresets.PresetMappingPayload) model.PresetMapping {
 	return m
 }
 
+// mappingToPayload converts a mapping for an export document, omitting the
+// roles it does not assign.
+func mappingToPayload(m model.PresetMapping) *genpresets.PresetMappingPayload {
+	p := &genpresets.PresetMappingPayload{
+		Combos: m.Combos,
+	}
+	if p.Combos == nil {
+		p.Combos = []string{}
+	}
+	if m.X != "" {
+		p.X = &m.X
+	}
+	if m.Y != "" {
+		p.Y = &m.Y
+	}
+	if m.Slider != "" {
+		p.Slider = &m.Slider
+	}
+	if m.XSlider != "" {
+		p.XSlider = &m.XSlider
+	}
+	if m.YSlider != "" {
+		p.YSlider = &m.YSlider
+	}
+	return p
+}
+
+// checkExportFormatVersion rejects an import document of a format version
+// newer than this server writes.
+func checkExportFormatVersion(version int) error {
+	if version > model.ExportFormatVersion {
+		return fmt.Errorf("document format version %d is newer than the supported version %d", version, model.ExportFormatVersion)
+	}
+	return nil
+}
+
 func isNotFound(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "not found")
 }
+
+// isForbidden reports whether err denies the request's user a change to, or
+// a list of, another user's items.
+func isForbidden(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "forbidden: ")
+}
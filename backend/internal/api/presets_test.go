@@ -28,7 +28,7 @@ func newFakePresetStore() *fakePresetStore {
 	return &fakePresetStore{presets: make(map[string]model.Preset)}
 }
 
-func (f *fakePresetStore) ListPresets() ([]model.Preset, error) {
+func (f *fakePresetStore) ListPresets(ctx context.Context) ([]model.Preset, error) {
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
@@ -39,7 +39,7 @@ func (f *fakePresetStore) ListPresets() ([]model.Preset, error) {
 	return result, nil
 }
 
-func (f *fakePresetStore) GetPreset(id string) (model.Preset, error) {
+func (f *fakePresetStore) GetPreset(ctx context.Context, id string) (model.Preset, error) {
 	p, ok := f.presets[id]
 	if !ok {
 		return model.Preset{}, sql.ErrNoRows
@@ -47,7 +47,7 @@ func (f *fakePresetStore) GetPreset(id string) (model.Preset, error) {
 	return p, nil
 }
 
-func (f *fakePresetStore) CreatePreset(p model.Preset) error {
+func (f *fakePresetStore) CreatePreset(ctx context.Context, p model.Preset) error {
 	if f.createErr != nil {
 		return f.createErr
 	}
@@ -55,7 +55,7 @@ func (f *fakePresetStore) CreatePreset(p model.Preset) error {
 	return nil
 }
 
-func (f *fakePresetStore) UpdatePreset(p model.Preset) error {
+func (f *fakePresetStore) UpdatePreset(ctx context.Context, p model.Preset) error {
 	if _, ok := f.presets[p.ID]; !ok {
 		return sql.ErrNoRows
 	}
@@ -63,7 +63,7 @@ func (f *fakePresetStore) UpdatePreset(p model.Preset) error {
 	return nil
 }
 
-func (f *fakePresetStore) DeletePreset(id string) error {
+func (f *fakePresetStore) DeletePreset(ctx context.Context, id string) error {
 	if f.deleteErr != nil {
 		return f.deleteErr
 	}
@@ -99,7 +99,7 @@ var _ = Describe("PresetsService", func() {
 
 	Describe("List", func() {
 		It("returns empty slice when no presets exist", func() {
-			result, err := presets.List(ctx)
+			result, err := presets.List(ctx, &genpresets.ListPayload{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(HaveLen(0))
 		})
@@ -115,7 +115,7 @@ var _ = Describe("PresetsService", func() {
 				},
 			}
 
-			result, err := presets.List(ctx)
+			result, err := presets.List(ctx, &genpresets.ListPayload{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(HaveLen(1))
 			Expect(result[0].ID).To(Equal("p1"))
@@ -128,7 +128,7 @@ var _ = Describe("PresetsService", func() {
 
 		It("returns error when store fails", func() {
 			store.listErr = errors.New("db error")
-			_, err := presets.List(ctx)
+			_, err := presets.List(ctx, &genpresets.ListPayload{})
 			Expect(err).To(HaveOccurred())
 		})
 	})
@@ -166,6 +166,66 @@ var _ = Describe("PresetsService", func() {
 		})
 	})
 
+	Describe("training run views", func() {
+		It("creates a default view of a training run and returns it as the run's default", func() {
+			run := "my-lora"
+			created, err := presets.Create(ctx, &genpresets.CreatePresetPayload{
+				Name:        "Seed 420",
+				Mapping:     &genpresets.PresetMappingPayload{Combos: []string{}},
+				TrainingRun: &run,
+				View: &genpresets.PresetView{
+					Filters: map[string][]string{"seed": {"420"}},
+					Sort:    []*genpresets.PresetSort{{Dimension: "checkpoint", Descending: true}},
+				},
+				Default: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.TrainingRun).To(Equal("my-lora"))
+			Expect(created.Default).To(BeTrue())
+
+			result, err := presets.Default(ctx, &genpresets.DefaultPayload{TrainingRun: "my-lora"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ID).To(Equal(created.ID))
+			Expect(result.View.Filters).To(Equal(map[string][]string{"seed": {"420"}}))
+			Expect(result.View.Sort).To(Equal([]*genpresets.PresetSort{{Dimension: "checkpoint", Descending: true}}))
+		})
+
+		It("returns not_found when the training run has no default", func() {
+			_, err := presets.Default(ctx, &genpresets.DefaultPayload{TrainingRun: "my-lora"})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("lists the presets of a training run and unscoped presets", func() {
+			store.presets["global"] = model.Preset{ID: "global", Name: "Global"}
+			store.presets["mine"] = model.Preset{ID: "mine", Name: "Mine", TrainingRun: "my-lora"}
+			store.presets["other"] = model.Preset{ID: "other", Name: "Other", TrainingRun: "other-lora"}
+
+			run := "my-lora"
+			result, err := presets.List(ctx, &genpresets.ListPayload{TrainingRun: &run})
+			Expect(err).NotTo(HaveOccurred())
+			ids := make([]string, len(result))
+			for i, p := range result {
+				ids[i] = p.ID
+			}
+			Expect(ids).To(ConsistOf("global", "mine"))
+		})
+
+		It("returns invalid_payload for a default without a training run", func() {
+			_, err := presets.Create(ctx, &genpresets.CreatePresetPayload{
+				Name:    "View",
+				Mapping: &genpresets.PresetMappingPayload{Combos: []string{}},
+				Default: true,
+			})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+		})
+	})
+
 	Describe("Update", func() {
 		BeforeEach(func() {
 			store.presets["existing"] = model.Preset{
@@ -233,10 +293,59 @@ var _ = Describe("PresetsService", func() {
 		})
 	})
 
+	Describe("Export and Import", func() {
+		BeforeEach(func() {
+			store.presets["p1"] = model.Preset{
+				ID:      "p1",
+				Name:    "Config A",
+				Mapping: model.PresetMapping{X: "cfg", Combos: []string{"seed"}},
+			}
+		})
+
+		It("round-trips presets through an export document", func() {
+			doc, err := presets.Export(ctx, &genpresets.ExportPayload{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(doc.FormatVersion).To(Equal(model.ExportFormatVersion))
+			Expect(doc.Presets).To(HaveLen(1))
+			Expect(doc.Presets[0].Name).To(Equal("Config A"))
+			Expect(*doc.Presets[0].Mapping.X).To(Equal("cfg"))
+			Expect(doc.Presets[0].Mapping.Y).To(BeNil())
+
+			res, err := presets.Import(ctx, &genpresets.ImportPayload{
+				FormatVersion: doc.FormatVersion,
+				Presets:       doc.Presets,
+				OnConflict:    "skip",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(1))
+			Expect(res[0].ID).To(Equal("p1"))
+			Expect(res[0].Action).To(Equal("skipped"))
+			Expect(store.presets).To(HaveLen(1))
+		})
+
+		It("returns not_found when exporting an unknown preset", func() {
+			id := "nonexistent"
+			_, err := presets.Export(ctx, &genpresets.ExportPayload{ID: &id})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("returns invalid_payload for a newer format version", func() {
+			_, err := presets.Import(ctx, &genpresets.ImportPayload{
+				FormatVersion: model.ExportFormatVersion + 1,
+				OnConflict:    "rename",
+			})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+		})
+	})
+
 	Describe("Error responses include Goa ServiceError structure", func() {
 		It("List returns ServiceError with proper fields on store failure", func() {
 			store.listErr = errors.New("database connection failed")
-			_, err := presets.List(ctx)
+			_, err := presets.List(ctx, &genpresets.ListPayload{})
 			Expect(err).To(HaveOccurred())
 
 			// Verify it's a Goa ServiceError with proper structure
@@ -247,6 +356,7 @@ var _ = Describe("PresetsService", func() {
 		})
 
 		It("Delete returns ServiceError with proper fields on internal error", func() {
+			store.presets["test-id"] = model.Preset{ID: "test-id", Name: "Test"}
 			store.deleteErr = errors.New("database write failed")
 			err := presets.Delete(ctx, &genpresets.DeletePayload{ID: "test-id"})
 			Expect(err).To(HaveOccurred())
@@ -268,5 +378,24 @@ var _ = Describe("PresetsService", func() {
 			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
+
+		It("Delete returns forbidden ServiceError for another user's preset", func() {
+			store.presets["owned"] = model.Preset{ID: "owned", Name: "Owned", Owner: "bob"}
+			err := presets.Delete(service.WithUser(ctx, model.User{Name: "alice"}), &genpresets.DeletePayload{ID: "owned"})
+			Expect(err).To(HaveOccurred())
+
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("forbidden"))
+			Expect(store.presets).To(HaveKey("owned"))
+		})
+
+		It("List returns forbidden ServiceError when a user lists every user's presets", func() {
+			owner := model.OwnerAll
+			_, err := presets.List(service.WithUser(ctx, model.User{Name: "alice"}), &genpresets.ListPayload{Owner: &owner})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("forbidden"))
+		})
 	})
 })
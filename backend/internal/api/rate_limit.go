@@ -0,0 +1,185 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// heavyPathPrefixes lists the request paths whose handlers read whole image
+// files from disk or render images. They are limited by MaxConcurrentHeavy in addition to the
+// per-IP request rate. Public gallery images are matched separately by
+// isHeavyPath since the gallery itself is served under the same prefix.
+var heavyPathPrefixes = []string{
+	"/api/images/",
+	"/api/image-grid",
+	"/api/image-comparison",
+}
+
+// rateLimitSweepInterval is how often idle per-IP buckets are discarded.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitMiddleware returns middleware that limits the request rate of each
+// client IP with a token bucket, responding 429 with a Retry-After header when
+// the bucket is empty, and caps the number of heavy requests served at once.
+// A heavy request waits up to cfg.QueueTimeout seconds for a free slot and
+// then fails with 503.
+//
+// The client IP is taken from the connection's remote address; forwarding
+// headers are not trusted, so behind a reverse proxy all clients share one
+// bucket.
+func RateLimitMiddleware(cfg model.RateLimitConfig, logger *logrus.Logger) func(http.Handler) http.Handler {
+	return newRateLimiter(cfg, logger, time.Now).middleware
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	rate         float64
+	burst        float64
+	heavy        chan struct{} // nil when heavy requests are not capped
+	queueTimeout time.Duration
+	now          func() time.Time
+	logger       *logrus.Entry
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(cfg model.RateLimitConfig, logger *logrus.Logger, now func() time.Time) *rateLimiter {
+	l := &rateLimiter{
+		rate:         float64(cfg.RequestsPerSecond),
+		burst:        float64(cfg.Burst),
+		queueTimeout: time.Duration(cfg.QueueTimeout) * time.Second,
+		now:          now,
+		logger:       logger.WithField("component", "rate_limit"),
+		buckets:      make(map[string]*tokenBucket),
+		lastSweep:    now(),
+	}
+	if cfg.MaxConcurrentHeavy > 0 {
+		l.heavy = make(chan struct{}, cfg.MaxConcurrentHeavy)
+	}
+	return l
+}
+
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		if l.rate > 0 {
+			if wait, ok := l.allow(ip); !ok {
+				l.logger.WithFields(logrus.Fields{
+					"client_ip": ip,
+					"path":      r.URL.Path,
+				}).Debug("request rate limited")
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		if l.heavy != nil && isHeavyPath(r.URL.Path) {
+			if !l.acquireHeavy(r) {
+				l.logger.WithFields(logrus.Fields{
+					"client_ip": ip,
+					"path":      r.URL.Path,
+				}).Debug("no free slot for heavy request")
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "server busy, retry later", http.StatusServiceUnavailable)
+				return
+			}
+			defer func() { <-l.heavy }()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token from ip's bucket. When the bucket is empty it returns
+// false and how long until the next token is available.
+func (l *rateLimiter) allow(ip string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= rateLimitSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[ip] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// sweep discards buckets that have refilled completely, since a new bucket
+// behaves the same. Must be called with l.mu held.
+func (l *rateLimiter) sweep(now time.Time) {
+	for ip, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, ip)
+		}
+	}
+	l.lastSweep = now
+}
+
+// acquireHeavy waits for a heavy request slot until the queue timeout expires
+// or the client goes away.
+func (l *rateLimiter) acquireHeavy(r *http.Request) bool {
+	select {
+	case l.heavy <- struct{}{}:
+		return true
+	default:
+	}
+	if l.queueTimeout <= 0 {
+		return false
+	}
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.heavy <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func isHeavyPath(path string) bool {
+	for _, prefix := range heavyPathPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	rest, ok := strings.CutPrefix(path, "/api/public/galleries/")
+	return ok && strings.Contains(rest, "/images/")
+}
+
+// clientIP returns the IP address of the client that sent r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,193 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+var _ = Describe("RateLimitMiddleware", func() {
+	var (
+		logger *logrus.Logger
+		mu     sync.Mutex
+		now    time.Time
+	)
+
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	request := func(handler http.Handler, path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	BeforeEach(func() {
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+		now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	Describe("request rate", func() {
+		var handler http.Handler
+
+		BeforeEach(func() {
+			handler = api.NewRateLimitMiddlewareForTest(model.RateLimitConfig{
+				RequestsPerSecond: 2,
+				Burst:             3,
+			}, logger, clock)(ok)
+		})
+
+		It("allows a burst and then responds 429 with Retry-After", func() {
+			for i := 0; i < 3; i++ {
+				Expect(request(handler, "/api/training-runs", "10.0.0.1:5000").Code).To(Equal(http.StatusOK))
+			}
+			rec := request(handler, "/api/training-runs", "10.0.0.1:5000")
+			Expect(rec.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(rec.Header().Get("Retry-After")).To(Equal("1"))
+		})
+
+		It("refills tokens at the configured rate", func() {
+			for i := 0; i < 3; i++ {
+				request(handler, "/api/training-runs", "10.0.0.1:5000")
+			}
+			advance(500 * time.Millisecond)
+			Expect(request(handler, "/api/training-runs", "10.0.0.1:5000").Code).To(Equal(http.StatusOK))
+			Expect(request(handler, "/api/training-runs", "10.0.0.1:5000").Code).To(Equal(http.StatusTooManyRequests))
+		})
+
+		It("keeps a separate bucket per client IP regardless of port", func() {
+			for i := 0; i < 3; i++ {
+				request(handler, "/api/training-runs", "10.0.0.1:5000")
+			}
+			Expect(request(handler, "/api/training-runs", "10.0.0.1:5001").Code).To(Equal(http.StatusTooManyRequests))
+			Expect(request(handler, "/api/training-runs", "10.0.0.2:5000").Code).To(Equal(http.StatusOK))
+		})
+
+		It("does not limit requests when requests_per_second is 0", func() {
+			handler = api.NewRateLimitMiddlewareForTest(model.RateLimitConfig{}, logger, clock)(ok)
+			for i := 0; i < 50; i++ {
+				Expect(request(handler, "/api/training-runs", "10.0.0.1:5000").Code).To(Equal(http.StatusOK))
+			}
+		})
+	})
+
+	Describe("heavy request cap", func() {
+		var (
+			release  chan struct{}
+			started  chan struct{}
+			blocking http.Handler
+		)
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			started = make(chan struct{}, 10)
+			blocking = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				started <- struct{}{}
+				<-release
+				w.WriteHeader(http.StatusOK)
+			})
+		})
+
+		serveInBackground := func(handler http.Handler, path string) <-chan int {
+			code := make(chan int, 1)
+			go func() {
+				defer GinkgoRecover()
+				code <- request(handler, path, "10.0.0.1:5000").Code
+			}()
+			return code
+		}
+
+		It("fails a heavy request with 503 when no slot frees up in time", func() {
+			handler := api.NewRateLimitMiddlewareForTest(model.RateLimitConfig{
+				MaxConcurrentHeavy: 1,
+			}, logger, clock)(blocking)
+
+			first := serveInBackground(handler, "/api/images/run/a.png")
+			Eventually(started).Should(Receive())
+
+			rec := request(handler, "/api/images/run/b.png", "10.0.0.2:5000")
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rec.Header().Get("Retry-After")).To(Equal("1"))
+
+			close(release)
+			Eventually(first).Should(Receive(Equal(http.StatusOK)))
+		})
+
+		It("queues a heavy request until a slot frees up", func() {
+			handler := api.NewRateLimitMiddlewareForTest(model.RateLimitConfig{
+				MaxConcurrentHeavy: 1,
+				QueueTimeout:       5,
+			}, logger, clock)(blocking)
+
+			first := serveInBackground(handler, "/api/images/run/a.png")
+			Eventually(started).Should(Receive())
+			second := serveInBackground(handler, "/api/image-comparison?a=1")
+			Consistently(started, 50*time.Millisecond).ShouldNot(Receive())
+
+			close(release)
+			Eventually(first).Should(Receive(Equal(http.StatusOK)))
+			Eventually(second).Should(Receive(Equal(http.StatusOK)))
+		})
+
+		It("stops waiting when the client goes away", func() {
+			handler := api.NewRateLimitMiddlewareForTest(model.RateLimitConfig{
+				MaxConcurrentHeavy: 1,
+				QueueTimeout:       60,
+			}, logger, clock)(blocking)
+
+			first := serveInBackground(handler, "/api/images/run/a.png")
+			Eventually(started).Should(Receive())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			req := httptest.NewRequest(http.MethodGet, "/api/public/galleries/g1/images/0", nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+			cancel()
+			handler.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+
+			close(release)
+			Eventually(first).Should(Receive(Equal(http.StatusOK)))
+		})
+
+		It("does not cap other requests", func() {
+			handler := api.NewRateLimitMiddlewareForTest(model.RateLimitConfig{
+				MaxConcurrentHeavy: 1,
+			}, logger, clock)(blocking)
+
+			first := serveInBackground(handler, "/api/images/run/a.png")
+			Eventually(started).Should(Receive())
+			second := serveInBackground(handler, "/api/public/galleries/g1")
+			Eventually(started).Should(Receive())
+
+			close(release)
+			Eventually(first).Should(Receive(Equal(http.StatusOK)))
+			Eventually(second).Should(Receive(Equal(http.StatusOK)))
+		})
+	})
+})
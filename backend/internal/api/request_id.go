@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	goamiddleware "goa.design/goa/v3/middleware"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// RequestIDHeader is the header a request ID is read from and returned in.
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds the length of a request ID taken from the
+// request's header; longer IDs are replaced by a generated one.
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware returns middleware that assigns each request an ID and
+// returns it in the X-Request-Id response header. A client-supplied
+// X-Request-Id is used if present and at most 128 characters long; otherwise
+// a UUID is generated. The ID is stored in the request context both for the
+// HTTP logging middleware and for the services, which log it with every entry
+// for the request and record it on the sample jobs the request creates.
+func RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" || len(id) > maxRequestIDLength {
+				id = uuid.New().String()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := context.WithValue(r.Context(), goamiddleware.RequestIDKey, id)
+			ctx = service.WithRequestID(ctx, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goamiddleware "goa.design/goa/v3/middleware"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("RequestIDMiddleware", func() {
+	var (
+		serviceID string
+		goaID     string
+		handler   http.Handler
+	)
+
+	BeforeEach(func() {
+		serviceID, goaID = "", ""
+		handler = api.RequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serviceID = service.RequestIDFromContext(r.Context())
+			goaID, _ = r.Context().Value(goamiddleware.RequestIDKey).(string)
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	It("generates an ID and returns it in the response header", func() {
+		rec := serve(httptest.NewRequest(http.MethodPost, "/api/sample-jobs", nil))
+
+		id := rec.Header().Get(api.RequestIDHeader)
+		Expect(id).NotTo(BeEmpty())
+		Expect(serviceID).To(Equal(id))
+		Expect(goaID).To(Equal(id))
+	})
+
+	It("generates a different ID for each request", func() {
+		first := serve(httptest.NewRequest(http.MethodGet, "/api/presets", nil)).Header().Get(api.RequestIDHeader)
+		second := serve(httptest.NewRequest(http.MethodGet, "/api/presets", nil)).Header().Get(api.RequestIDHeader)
+		Expect(first).NotTo(Equal(second))
+	})
+
+	It("uses the ID the client sent", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/sample-jobs", nil)
+		req.Header.Set(api.RequestIDHeader, "client-id-1")
+		rec := serve(req)
+
+		Expect(rec.Header().Get(api.RequestIDHeader)).To(Equal("client-id-1"))
+		Expect(serviceID).To(Equal("client-id-1"))
+	})
+
+	It("replaces a client ID longer than 128 characters", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/sample-jobs", nil)
+		req.Header.Set(api.RequestIDHeader, strings.Repeat("x", 129))
+		rec := serve(req)
+
+		id := rec.Header().Get(api.RequestIDHeader)
+		Expect(id).NotTo(BeEmpty())
+		Expect(id).NotTo(HavePrefix("x"))
+		Expect(serviceID).To(Equal(id))
+	})
+})
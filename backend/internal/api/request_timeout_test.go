@@ -0,0 +1,56 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+)
+
+var _ = Describe("RequestTimeoutMiddleware", func() {
+	var (
+		deadline    time.Time
+		hasDeadline bool
+		handler     http.Handler
+	)
+
+	BeforeEach(func() {
+		hasDeadline = false
+		handler = api.RequestTimeoutMiddleware(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, hasDeadline = r.Context().Deadline()
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	It("gives the request context a deadline of the timeout", func() {
+		before := time.Now()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/sample-jobs", nil))
+
+		Expect(hasDeadline).To(BeTrue())
+		Expect(deadline).To(BeTemporally("~", before.Add(time.Minute), time.Second))
+	})
+
+	It("cancels the request context once the handler returns", func() {
+		var reqCtx context.Context
+		handler = api.RequestTimeoutMiddleware(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqCtx = r.Context()
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/presets", nil))
+
+		Expect(reqCtx.Err()).To(MatchError(context.Canceled))
+	})
+
+	It("leaves WebSocket upgrade requests without a deadline", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/ws", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		Expect(hasDeadline).To(BeFalse())
+	})
+})
@@ -15,6 +15,8 @@ import (
 type SampleJobsService struct {
 	svc       *service.SampleJobService
 	discovery *service.DiscoveryService
+	studies   *service.StudyService
+	templates *service.JobTemplateService
 	enabled   bool
 }
 
@@ -29,18 +31,42 @@ func NewSampleJobsService(svc *service.SampleJobService, discovery *service.Disc
 	}
 }
 
-// List returns all sample jobs ordered by creation time (newest first).
-func (s *SampleJobsService) List(ctx context.Context) ([]*gensamplejobs.SampleJobResponse, error) {
+// SetStudyService sets the study service used by create_with_study to
+// validate the new study. If not set, create_with_study returns an
+// invalid_payload error.
+func (s *SampleJobsService) SetStudyService(studies *service.StudyService) {
+	s.studies = studies
+}
+
+// SetJobTemplateService sets the job template service used by run_template.
+// If not set, run_template returns an invalid_payload error.
+func (s *SampleJobsService) SetJobTemplateService(templates *service.JobTemplateService) {
+	s.templates = templates
+}
+
+// List returns a page of the requested owner's sample jobs carrying the
+// requested labels, ordered by creation time (newest first).
+func (s *SampleJobsService) List(ctx context.Context, p *gensamplejobs.ListPayload) (*gensamplejobs.ListResult, error) {
 	if !s.enabled {
-		return []*gensamplejobs.SampleJobResponse{}, nil
+		return &gensamplejobs.ListResult{Jobs: []*gensamplejobs.SampleJobResponse{}}, nil
+	}
+	var owner string
+	if p.Owner != nil {
+		owner = *p.Owner
 	}
-	jobs, err := s.svc.List()
+	jobs, total, err := s.svc.List(ctx, owner, p.Label, pageFromPayload(p.Limit, p.Offset))
 	if err != nil {
+		if isForbidden(err) {
+			return nil, gensamplejobs.MakeForbidden(err)
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			return nil, gensamplejobs.MakeInvalidPayload(err)
+		}
 		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("listing sample jobs: %w", err))
 	}
 	result := make([]*gensamplejobs.SampleJobResponse, len(jobs))
 	for i, j := range jobs {
-		progress, err := s.svc.GetProgress(j.ID)
+		progress, err := s.svc.GetProgress(ctx, j.ID)
 		if err != nil {
 			progress = model.JobProgress{
 				ItemCounts:        model.ItemStatusCounts{},
@@ -49,7 +75,7 @@ func (s *SampleJobsService) List(ctx context.Context) ([]*gensamplejobs.SampleJo
 		}
 		result[i] = sampleJobToResponse(j, progress.ItemCounts, progress.FailedItemDetails)
 	}
-	return result, nil
+	return &gensamplejobs.ListResult{Jobs: result, Total: total}, nil
 }
 
 // Show returns a sample job by ID with progress metrics.
@@ -57,7 +83,7 @@ func (s *SampleJobsService) Show(ctx context.Context, p *gensamplejobs.ShowPaylo
 	if !s.enabled {
 		return nil, gensamplejobs.MakeServiceUnavailable(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
 	}
-	job, err := s.svc.Get(p.ID)
+	job, err := s.svc.Get(ctx, p.ID)
 	if err != nil {
 		if isNotFound(err) {
 			return nil, gensamplejobs.MakeNotFound(err)
@@ -65,7 +91,7 @@ func (s *SampleJobsService) Show(ctx context.Context, p *gensamplejobs.ShowPaylo
 		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("fetching sample job: %w", err))
 	}
 
-	progress, err := s.svc.GetProgress(p.ID)
+	progress, err := s.svc.GetProgress(ctx, p.ID)
 	if err != nil {
 		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("computing job progress: %w", err))
 	}
@@ -76,45 +102,501 @@ func (s *SampleJobsService) Show(ctx context.Context, p *gensamplejobs.ShowPaylo
 	}, nil
 }
 
+// ListItems returns a page of the items of a sample job that match the
+// payload filters, in the requested order, with their timing metrics.
+func (s *SampleJobsService) ListItems(ctx context.Context, p *gensamplejobs.ListItemsPayload) (*gensamplejobs.ListItemsResult, error) {
+	if !s.enabled {
+		return nil, gensamplejobs.MakeServiceUnavailable(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
+	}
+	query := model.SampleJobItemQuery{
+		Sort:       model.SampleJobItemSort(p.Sort),
+		Descending: p.Order == "desc",
+	}
+	if p.Score != nil {
+		query.ScoreName = *p.Score
+	}
+	if p.Status != nil {
+		query.Filter.Status = model.SampleJobItemStatus(*p.Status)
+	}
+	if p.SkipReason != nil {
+		query.Filter.SkipReason = model.SampleJobItemSkipReason(*p.SkipReason)
+	}
+	if p.Checkpoint != nil {
+		query.Filter.CheckpointFilename = *p.Checkpoint
+	}
+	if p.PromptName != nil {
+		query.Filter.PromptName = *p.PromptName
+	}
+	if p.Sampler != nil {
+		query.Filter.SamplerName = *p.Sampler
+	}
+	items, total, err := s.svc.ListItems(ctx, p.ID, query, pageFromPayload(p.Limit, p.Offset))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gensamplejobs.MakeNotFound(err)
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			return nil, gensamplejobs.MakeInvalidPayload(err)
+		}
+		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("listing sample job items: %w", err))
+	}
+	result := make([]*gensamplejobs.SampleJobItemResponse, len(items))
+	for i, item := range items {
+		result[i] = s.itemToResponse(item)
+	}
+	return &gensamplejobs.ListItemsResult{Items: result, Total: total}, nil
+}
+
+// pageFromPayload converts the limit and offset of a paginated list payload
+// into a model.Page. An omitted limit means no limit.
+func pageFromPayload(limit *int, offset int) model.Page {
+	page := model.Page{Offset: offset}
+	if limit != nil {
+		page.Limit = *limit
+	}
+	return page
+}
+
+// Compare pairs up the items of two sample jobs generated with the same
+// parameters.
+func (s *SampleJobsService) Compare(ctx context.Context, p *gensamplejobs.ComparePayload) (*gensamplejobs.SampleJobComparisonResponse, error) {
+	if !s.enabled {
+		return nil, gensamplejobs.MakeServiceUnavailable(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
+	}
+	cmp, err := s.svc.Compare(ctx, p.A, p.B)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gensamplejobs.MakeNotFound(err)
+		}
+		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("comparing sample jobs: %w", err))
+	}
+	countsA, _ := s.svc.GetItemCounts(ctx, p.A)
+	countsB, _ := s.svc.GetItemCounts(ctx, p.B)
+	resp := &gensamplejobs.SampleJobComparisonResponse{
+		A:          sampleJobToResponse(cmp.A, countsA, []model.FailedItemDetail{}),
+		B:          sampleJobToResponse(cmp.B, countsB, []model.FailedItemDetail{}),
+		Pairs:      make([]*gensamplejobs.SampleJobItemPairResponse, len(cmp.Pairs)),
+		UnmatchedA: make([]*gensamplejobs.SampleJobItemResponse, len(cmp.UnmatchedA)),
+		UnmatchedB: make([]*gensamplejobs.SampleJobItemResponse, len(cmp.UnmatchedB)),
+	}
+	for i, pair := range cmp.Pairs {
+		resp.Pairs[i] = &gensamplejobs.SampleJobItemPairResponse{
+			A: s.itemToResponse(pair.A),
+			B: s.itemToResponse(pair.B),
+		}
+	}
+	for i, item := range cmp.UnmatchedA {
+		resp.UnmatchedA[i] = s.itemToResponse(item)
+	}
+	for i, item := range cmp.UnmatchedB {
+		resp.UnmatchedB[i] = s.itemToResponse(item)
+	}
+	return resp, nil
+}
+
 // Create creates a new sample job by expanding preset parameters across training run checkpoints.
 func (s *SampleJobsService) Create(ctx context.Context, p *gensamplejobs.CreateSampleJobPayload) (*gensamplejobs.SampleJobResponse, error) {
 	if !s.enabled {
 		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
 	}
-	// Discover training runs to get checkpoints
-	runs, err := s.discovery.Discover()
+	trainingRun, err := s.findTrainingRun(ctx, p.TrainingRunName)
 	if err != nil {
-		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("discovering training runs: %w", err))
+		return nil, err
 	}
 
-	// Find the training run by name
-	var trainingRun *model.TrainingRun
-	for i := range runs {
-		if runs[i].Name == p.TrainingRunName {
-			trainingRun = &runs[i]
-			break
+	// Create the job — the workflow is read from the study definition; VAE,
+	// text encoder, and shift fall back to the study, then the workflow defaults.
+	job, err := s.svc.CreateWithOverrides(ctx,
+		p.TrainingRunName,
+		trainingRun.Checkpoints,
+		p.StudyID,
+		p.CheckpointFilenames,
+		stepFilter(p),
+		p.ClearExisting,
+		p.MissingOnly,
+		p.SkipExisting,
+		outputOptions(p.OutputFormat, p.OutputQuality),
+		createOverrides(p),
+		p.AppendNewCheckpoints,
+		annotations(p.Notes, p.Labels),
+	)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gensamplejobs.MakeNotFound(err)
 		}
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("creating sample job: %w", err))
+	}
+
+	// New job: all items are pending, none completed/failed
+	counts := model.ItemStatusCounts{Pending: job.TotalItems}
+	return sampleJobToResponse(job, counts, []model.FailedItemDetail{}), nil
+}
+
+// Preview expands a sample job as Create would, without storing it.
+func (s *SampleJobsService) Preview(ctx context.Context, p *gensamplejobs.CreateSampleJobPayload) (*gensamplejobs.SampleJobPreviewResponse, error) {
+	if !s.enabled {
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
 	}
-	if trainingRun == nil {
-		return nil, gensamplejobs.MakeNotFound(fmt.Errorf("training run %s not found", p.TrainingRunName))
+	trainingRun, err := s.findTrainingRun(ctx, p.TrainingRunName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create the job — workflow, VAE, text encoder, and shift are read from the study definition.
-	job, err := s.svc.Create(
+	preview, err := s.svc.Preview(ctx,
 		p.TrainingRunName,
 		trainingRun.Checkpoints,
 		p.StudyID,
 		p.CheckpointFilenames,
-		p.ClearExisting,
+		stepFilter(p),
 		p.MissingOnly,
+		p.SkipExisting,
+		outputOptions(p.OutputFormat, p.OutputQuality),
+		createOverrides(p),
 	)
 	if err != nil {
 		if isNotFound(err) {
 			return nil, gensamplejobs.MakeNotFound(err)
 		}
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("previewing sample job: %w", err))
+	}
+
+	resp := &gensamplejobs.SampleJobPreviewResponse{
+		TotalItems:           preview.TotalItems,
+		RunnableItems:        preview.RunnableItems,
+		ExistingItems:        preview.ExistingItems,
+		Checkpoints:          make([]*gensamplejobs.CheckpointPreviewResponse, len(preview.Checkpoints)),
+		UnmatchedCheckpoints: preview.UnmatchedCheckpoints,
+	}
+	for i, cp := range preview.Checkpoints {
+		resp.Checkpoints[i] = &gensamplejobs.CheckpointPreviewResponse{
+			Filename:    cp.Filename,
+			Items:       cp.Items,
+			PathMatched: cp.PathMatched,
+		}
+	}
+	if preview.EstimatedDuration != nil {
+		seconds := int64(preview.EstimatedDuration.Seconds())
+		resp.EstimatedDurationSeconds = &seconds
+	}
+	if len(preview.Warnings) > 0 {
+		resp.Warnings = preview.Warnings
+	}
+	return resp, nil
+}
+
+// CreateWithStudy creates a study and a sample job of it in one transaction,
+// so that a job that cannot be created leaves no orphaned study behind.
+func (s *SampleJobsService) CreateWithStudy(ctx context.Context, p *gensamplejobs.CreateSampleJobWithStudyPayload) (*gensamplejobs.SampleJobWithStudyResponse, error) {
+	if !s.enabled {
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
+	}
+	if s.studies == nil {
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("creating studies is not available"))
+	}
+	sp := p.Study
+	prompts := make([]model.NamedPrompt, len(sp.Prompts))
+	for i, np := range sp.Prompts {
+		prompts[i] = sampleJobsNamedPromptToModel(np)
+	}
+	pairs := make([]model.SamplerSchedulerPair, len(sp.SamplerSchedulerPairs))
+	for i, pair := range sp.SamplerSchedulerPairs {
+		pairs[i] = model.SamplerSchedulerPair{Sampler: pair.Sampler, Scheduler: pair.Scheduler}
+	}
+	study, err := s.studies.NewStudy(ctx,
+		sp.Name,
+		sp.PromptPrefix,
+		prompts,
+		sp.NegativePrompt,
+		sp.Steps,
+		sp.Cfgs,
+		pairs,
+		sp.Seeds,
+		sp.Width,
+		sp.Height,
+		sp.WorkflowTemplate,
+		sp.Vae,
+		sp.TextEncoder,
+		sp.Shift,
+		sp.InputImage,
+		sp.DenoiseStrengths,
+		sampleJobsWildcardsToModel(sp.Wildcards),
+		model.SeedMode(sp.SeedMode),
+		sp.RandomSeedCount,
+		sampleJobsResolutionsToModel(sp.Resolutions),
+	)
+	if err != nil {
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("creating study: %w", err))
+	}
+	trainingRun, err := s.findTrainingRun(ctx, p.TrainingRunName)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.svc.CreateWithStudy(ctx,
+		p.TrainingRunName,
+		trainingRun.Checkpoints,
+		study,
+		p.CheckpointFilenames,
+		p.ClearExisting,
+		p.MissingOnly,
+		p.SkipExisting,
+		outputOptions(p.OutputFormat, p.OutputQuality),
+		p.AppendNewCheckpoints,
+	)
+	if err != nil {
 		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("creating sample job: %w", err))
 	}
 
+	// New job: all items are pending, none completed/failed
+	counts := model.ItemStatusCounts{Pending: job.TotalItems}
+	return &gensamplejobs.SampleJobWithStudyResponse{
+		Study: studyToSampleJobsResponse(study),
+		Job:   sampleJobToResponse(job, counts, []model.FailedItemDetail{}),
+	}, nil
+}
+
+// CreateBulk creates one sample job per study for a single training run, in
+// the order the studies are listed.
+func (s *SampleJobsService) CreateBulk(ctx context.Context, p *gensamplejobs.BulkCreateSampleJobsPayload) (*gensamplejobs.BulkCreateSampleJobsResponse, error) {
+	if !s.enabled {
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
+	}
+	trainingRun, err := s.findTrainingRun(ctx, p.TrainingRunName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.svc.CreateBulk(ctx,
+		p.TrainingRunName,
+		trainingRun.Checkpoints,
+		p.StudyIds,
+		p.CheckpointFilenames,
+		p.ClearExisting,
+		p.MissingOnly,
+		p.SkipExisting,
+		outputOptions(p.OutputFormat, p.OutputQuality),
+	)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gensamplejobs.MakeNotFound(err)
+		}
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("creating sample jobs: %w", err))
+	}
+
+	resp := &gensamplejobs.BulkCreateSampleJobsResponse{
+		JobIds:     make([]string, len(result.Jobs)),
+		Jobs:       make([]*gensamplejobs.SampleJobResponse, len(result.Jobs)),
+		TotalItems: result.TotalItems,
+	}
+	for i, job := range result.Jobs {
+		resp.JobIds[i] = job.ID
+		// New job: all items are pending, none completed/failed
+		counts := model.ItemStatusCounts{Pending: job.TotalItems}
+		resp.Jobs[i] = sampleJobToResponse(job, counts, []model.FailedItemDetail{})
+	}
+	if result.EstimatedDuration != nil {
+		seconds := int64(result.EstimatedDuration.Seconds())
+		resp.EstimatedDurationSeconds = &seconds
+	}
+	return resp, nil
+}
+
+// sampleJobsNamedPromptToModel is namedPromptToModel for the sample_jobs
+// service.
+func sampleJobsNamedPromptToModel(np *gensamplejobs.NamedPrompt) model.NamedPrompt {
+	prompt := model.NamedPrompt{
+		Name:  np.Name,
+		Text:  np.Text,
+		Steps: np.Steps,
+		CFGs:  np.Cfgs,
+		Seeds: np.Seeds,
+	}
+	if np.Width != nil {
+		prompt.Width = *np.Width
+	}
+	if np.Height != nil {
+		prompt.Height = *np.Height
+	}
+	return prompt
+}
+
+// sampleJobsWildcardsToModel is wildcardsToModel for the sample_jobs
+// service.
+func sampleJobsWildcardsToModel(wildcards []*gensamplejobs.Wildcard) []model.Wildcard {
+	var result []model.Wildcard
+	for _, w := range wildcards {
+		result = append(result, model.Wildcard{Name: w.Name, Values: w.Values})
+	}
+	return result
+}
+
+// wildcardsToSampleJobsResponse is wildcardsToResponse for the sample_jobs
+// service.
+func wildcardsToSampleJobsResponse(wildcards []model.Wildcard) []*gensamplejobs.Wildcard {
+	result := make([]*gensamplejobs.Wildcard, len(wildcards))
+	for i, w := range wildcards {
+		result[i] = &gensamplejobs.Wildcard{Name: w.Name, Values: w.Values}
+	}
+	return result
+}
+
+// sampleJobsResolutionsToModel is resolutionsToModel for the sample_jobs
+// service.
+func sampleJobsResolutionsToModel(resolutions []*gensamplejobs.Resolution) []model.Resolution {
+	var result []model.Resolution
+	for _, r := range resolutions {
+		result = append(result, model.Resolution{Width: r.Width, Height: r.Height})
+	}
+	return result
+}
+
+// resolutionsToSampleJobsResponse is resolutionsToResponse for the
+// sample_jobs service.
+func resolutionsToSampleJobsResponse(resolutions []model.Resolution) []*gensamplejobs.Resolution {
+	result := make([]*gensamplejobs.Resolution, len(resolutions))
+	for i, r := range resolutions {
+		result[i] = &gensamplejobs.Resolution{Width: r.Width, Height: r.Height}
+	}
+	return result
+}
+
+// namedPromptToSampleJobsResponse is namedPromptToResponse for the
+// sample_jobs service.
+func namedPromptToSampleJobsResponse(np model.NamedPrompt) *gensamplejobs.NamedPrompt {
+	resp := &gensamplejobs.NamedPrompt{
+		Name:  np.Name,
+		Text:  np.Text,
+		Steps: np.Steps,
+		Cfgs:  np.CFGs,
+		Seeds: np.Seeds,
+	}
+	if np.Width > 0 {
+		resp.Width = &np.Width
+	}
+	if np.Height > 0 {
+		resp.Height = &np.Height
+	}
+	return resp
+}
+
+// studyToSampleJobsResponse is studyToResponse for the sample_jobs service,
+// which has its own generated copy of the study types.
+func studyToSampleJobsResponse(st model.Study) *gensamplejobs.StudyResponse {
+	prompts := make([]*gensamplejobs.NamedPrompt, len(st.Prompts))
+	for i, np := range st.Prompts {
+		prompts[i] = namedPromptToSampleJobsResponse(np)
+	}
+	pairs := make([]*gensamplejobs.SamplerSchedulerPair, len(st.SamplerSchedulerPairs))
+	for i, pair := range st.SamplerSchedulerPairs {
+		pairs[i] = &gensamplejobs.SamplerSchedulerPair{Sampler: pair.Sampler, Scheduler: pair.Scheduler}
+	}
+	return &gensamplejobs.StudyResponse{
+		ID:                    st.ID,
+		Name:                  st.Name,
+		Version:               st.Version,
+		PromptPrefix:          st.PromptPrefix,
+		Prompts:               prompts,
+		NegativePrompt:        st.NegativePrompt,
+		Steps:                 st.Steps,
+		Cfgs:                  st.CFGs,
+		SamplerSchedulerPairs: pairs,
+		Seeds:                 seeds(st.Seeds),
+		SeedMode:              string(st.SeedMode),
+		RandomSeedCount:       st.RandomSeedCount,
+		Width:                 st.Width,
+		Height:                st.Height,
+		WorkflowTemplate:      st.WorkflowTemplate,
+		Vae:                   st.VAE,
+		TextEncoder:           st.TextEncoder,
+		Shift:                 st.Shift,
+		InputImage:            st.InputImage,
+		DenoiseStrengths:      denoiseStrengths(st.DenoiseStrengths),
+		Wildcards:             wildcardsToSampleJobsResponse(st.Wildcards),
+		Resolutions:           resolutionsToSampleJobsResponse(st.Resolutions),
+		ImagesPerCheckpoint:   st.ImagesPerCheckpoint(),
+		CreatedAt:             st.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:             st.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// outputOptions converts the output format fields of a create payload.
+func outputOptions(format string, quality *int) model.ImageOutputOptions {
+	opts := model.ImageOutputOptions{Format: model.OutputFormat(format)}
+	if quality != nil {
+		opts.Quality = *quality
+	}
+	return opts
+}
+
+// createOverrides converts the model, ControlNet, and workflow override fields
+// of a create payload.
+func createOverrides(p *gensamplejobs.CreateSampleJobPayload) model.ModelOverrides {
+	var overrides model.ModelOverrides
+	if p.Vae != nil {
+		overrides.VAE = *p.Vae
+	}
+	if p.Clip != nil {
+		overrides.CLIP = *p.Clip
+	}
+	overrides.Shift = p.Shift
+	if p.ControlnetModel != nil {
+		overrides.ControlNetModel = *p.ControlnetModel
+	}
+	overrides.ControlNetStrength = p.ControlnetStrength
+	if p.ControlnetImage != nil {
+		overrides.ControlNetImage = *p.ControlnetImage
+	}
+	for _, o := range p.WorkflowOverrides {
+		overrides.Workflows = append(overrides.Workflows, model.WorkflowOverride{Pattern: o.Pattern, Workflow: o.Workflow})
+	}
+	return overrides
+}
+
+// stepFilter returns the checkpoint step filter of a create payload.
+func stepFilter(p *gensamplejobs.CreateSampleJobPayload) model.StepFilter {
+	f := model.StepFilter{MinStep: p.MinStep, MaxStep: p.MaxStep}
+	if p.EveryNth != nil {
+		f.EveryNth = *p.EveryNth
+	}
+	return f
+}
+
+// findTrainingRun discovers training runs and returns the one with the given
+// name, or a not_found error.
+func (s *SampleJobsService) findTrainingRun(ctx context.Context, name string) (*model.TrainingRun, error) {
+	runs, err := s.discovery.Discover(ctx)
+	if err != nil {
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("discovering training runs: %w", err))
+	}
+	for i := range runs {
+		if runs[i].Name == name {
+			return &runs[i], nil
+		}
+	}
+	return nil, gensamplejobs.MakeNotFound(fmt.Errorf("training run %s not found", name))
+}
+
+// RunTemplate creates a sample job of a training run from a job template.
+func (s *SampleJobsService) RunTemplate(ctx context.Context, p *gensamplejobs.RunTemplatePayload) (*gensamplejobs.SampleJobResponse, error) {
+	if !s.enabled {
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
+	}
+	if s.templates == nil {
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("job templates are not available"))
+	}
+	trainingRun, err := s.findTrainingRun(ctx, p.TrainingRun)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.templates.Run(ctx, p.ID, *trainingRun)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gensamplejobs.MakeNotFound(err)
+		}
+		return nil, gensamplejobs.MakeInvalidPayload(fmt.Errorf("running job template: %w", err))
+	}
+
 	// New job: all items are pending, none completed/failed
 	counts := model.ItemStatusCounts{Pending: job.TotalItems}
 	return sampleJobToResponse(job, counts, []model.FailedItemDetail{}), nil
@@ -125,18 +607,21 @@ func (s *SampleJobsService) Start(ctx context.Context, p *gensamplejobs.StartPay
 	if !s.enabled {
 		return nil, gensamplejobs.MakeServiceUnavailable(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
 	}
-	job, err := s.svc.Start(p.ID)
+	job, err := s.svc.Start(ctx, p.ID)
 	if err != nil {
 		if isNotFound(err) {
 			return nil, gensamplejobs.MakeNotFound(err)
 		}
+		if isForbidden(err) {
+			return nil, gensamplejobs.MakeForbidden(err)
+		}
 		if isServiceUnavailable(err) {
 			return nil, gensamplejobs.MakeServiceUnavailable(err)
 		}
 		// Check if error is about invalid state
 		return nil, gensamplejobs.MakeInvalidState(err)
 	}
-	counts, _ := s.svc.GetItemCounts(p.ID)
+	counts, _ := s.svc.GetItemCounts(ctx, p.ID)
 	return sampleJobToResponse(job, counts, []model.FailedItemDetail{}), nil
 }
 
@@ -145,15 +630,18 @@ func (s *SampleJobsService) Stop(ctx context.Context, p *gensamplejobs.StopPaylo
 	if !s.enabled {
 		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
 	}
-	job, err := s.svc.Stop(p.ID)
+	job, err := s.svc.Stop(ctx, p.ID)
 	if err != nil {
 		if isNotFound(err) {
 			return nil, gensamplejobs.MakeNotFound(err)
 		}
+		if isForbidden(err) {
+			return nil, gensamplejobs.MakeForbidden(err)
+		}
 		// Check if error is about invalid state
 		return nil, gensamplejobs.MakeInvalidState(err)
 	}
-	counts, _ := s.svc.GetItemCounts(p.ID)
+	counts, _ := s.svc.GetItemCounts(ctx, p.ID)
 	return sampleJobToResponse(job, counts, []model.FailedItemDetail{}), nil
 }
 
@@ -162,18 +650,21 @@ func (s *SampleJobsService) Resume(ctx context.Context, p *gensamplejobs.ResumeP
 	if !s.enabled {
 		return nil, gensamplejobs.MakeServiceUnavailable(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
 	}
-	job, err := s.svc.Resume(p.ID)
+	job, err := s.svc.Resume(ctx, p.ID)
 	if err != nil {
 		if isNotFound(err) {
 			return nil, gensamplejobs.MakeNotFound(err)
 		}
+		if isForbidden(err) {
+			return nil, gensamplejobs.MakeForbidden(err)
+		}
 		if isServiceUnavailable(err) {
 			return nil, gensamplejobs.MakeServiceUnavailable(err)
 		}
 		// Check if error is about invalid state
 		return nil, gensamplejobs.MakeInvalidState(err)
 	}
-	counts, _ := s.svc.GetItemCounts(p.ID)
+	counts, _ := s.svc.GetItemCounts(ctx, p.ID)
 	return sampleJobToResponse(job, counts, []model.FailedItemDetail{}), nil
 }
 
@@ -182,56 +673,180 @@ func (s *SampleJobsService) RetryFailed(ctx context.Context, p *gensamplejobs.Re
 	if !s.enabled {
 		return nil, gensamplejobs.MakeServiceUnavailable(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
 	}
-	job, err := s.svc.RetryFailed(p.ID)
+	job, err := s.svc.RetryFailed(ctx, p.ID)
 	if err != nil {
 		if isNotFound(err) {
 			return nil, gensamplejobs.MakeNotFound(err)
 		}
+		if isForbidden(err) {
+			return nil, gensamplejobs.MakeForbidden(err)
+		}
 		if isServiceUnavailable(err) {
 			return nil, gensamplejobs.MakeServiceUnavailable(err)
 		}
 		return nil, gensamplejobs.MakeInvalidState(err)
 	}
-	counts, _ := s.svc.GetItemCounts(p.ID)
+	counts, _ := s.svc.GetItemCounts(ctx, p.ID)
 	return sampleJobToResponse(job, counts, []model.FailedItemDetail{}), nil
 }
 
-// Delete removes a sample job and all its items.
-// When p.DeleteData is true, also removes the generated sample files from disk.
+// Annotate replaces the notes and labels of a sample job.
+func (s *SampleJobsService) Annotate(ctx context.Context, p *gensamplejobs.AnnotatePayload) (*gensamplejobs.SampleJobResponse, error) {
+	if !s.enabled {
+		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
+	}
+	job, err := s.svc.Annotate(ctx, p.ID, annotations(p.Notes, p.Labels))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gensamplejobs.MakeNotFound(err)
+		}
+		if isForbidden(err) {
+			return nil, gensamplejobs.MakeForbidden(err)
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			return nil, gensamplejobs.MakeInvalidPayload(err)
+		}
+		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("annotating sample job: %w", err))
+	}
+	progress, err := s.svc.GetProgress(ctx, p.ID)
+	if err != nil {
+		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("computing job progress: %w", err))
+	}
+	return sampleJobToResponse(job, progress.ItemCounts, progress.FailedItemDetails), nil
+}
+
+// annotations returns the job annotations of optional payload notes and
+// labels.
+func annotations(notes *string, labels map[string]string) model.SampleJobAnnotations {
+	a := model.SampleJobAnnotations{Labels: labels}
+	if notes != nil {
+		a.Notes = *notes
+	}
+	return a
+}
+
+// Delete moves a sample job to the trash.
+// When p.DeleteData is true, purging it also removes the generated sample files from disk.
 func (s *SampleJobsService) Delete(ctx context.Context, p *gensamplejobs.DeletePayload) error {
 	if !s.enabled {
 		return gensamplejobs.MakeInternalError(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
 	}
-	err := s.svc.Delete(p.ID, p.DeleteData)
+	err := s.svc.Delete(ctx, p.ID, p.DeleteData)
 	if err != nil {
 		if isNotFound(err) {
 			return gensamplejobs.MakeNotFound(err)
 		}
+		if isForbidden(err) {
+			return gensamplejobs.MakeForbidden(err)
+		}
 		return gensamplejobs.MakeInternalError(fmt.Errorf("deleting sample job: %w", err))
 	}
 	return nil
 }
 
+// ListTrash returns the requested owner's sample jobs in the trash, most
+// recently deleted first.
+func (s *SampleJobsService) ListTrash(ctx context.Context, p *gensamplejobs.ListTrashPayload) (*gensamplejobs.ListTrashResult, error) {
+	if !s.enabled {
+		return &gensamplejobs.ListTrashResult{Jobs: []*gensamplejobs.SampleJobResponse{}}, nil
+	}
+	var owner string
+	if p.Owner != nil {
+		owner = *p.Owner
+	}
+	jobs, err := s.svc.ListTrash(ctx, owner)
+	if err != nil {
+		if isForbidden(err) {
+			return nil, gensamplejobs.MakeForbidden(err)
+		}
+		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("listing trashed sample jobs: %w", err))
+	}
+	result := make([]*gensamplejobs.SampleJobResponse, len(jobs))
+	for i, j := range jobs {
+		counts, _ := s.svc.GetItemCounts(ctx, j.ID)
+		result[i] = sampleJobToResponse(j, counts, []model.FailedItemDetail{})
+	}
+	return &gensamplejobs.ListTrashResult{Jobs: result}, nil
+}
+
+// Restore moves a sample job out of the trash.
+func (s *SampleJobsService) Restore(ctx context.Context, p *gensamplejobs.RestorePayload) (*gensamplejobs.SampleJobResponse, error) {
+	if !s.enabled {
+		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
+	}
+	job, err := s.svc.Restore(ctx, p.ID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gensamplejobs.MakeNotFound(err)
+		}
+		if isForbidden(err) {
+			return nil, gensamplejobs.MakeForbidden(err)
+		}
+		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("restoring sample job: %w", err))
+	}
+	counts, _ := s.svc.GetItemCounts(ctx, p.ID)
+	return sampleJobToResponse(job, counts, []model.FailedItemDetail{}), nil
+}
+
+// Purge permanently removes a sample job in the trash and all its items.
+func (s *SampleJobsService) Purge(ctx context.Context, p *gensamplejobs.PurgePayload) error {
+	if !s.enabled {
+		return gensamplejobs.MakeInternalError(fmt.Errorf("sample jobs not available: ComfyUI is not configured"))
+	}
+	if err := s.svc.Purge(ctx, p.ID); err != nil {
+		if isNotFound(err) {
+			return gensamplejobs.MakeNotFound(err)
+		}
+		if isForbidden(err) {
+			return gensamplejobs.MakeForbidden(err)
+		}
+		return gensamplejobs.MakeInternalError(fmt.Errorf("purging sample job: %w", err))
+	}
+	return nil
+}
+
+// EmptyTrash purges the sample jobs in the trash.
+func (s *SampleJobsService) EmptyTrash(ctx context.Context) (*gensamplejobs.EmptyTrashResult, error) {
+	if !s.enabled {
+		return &gensamplejobs.EmptyTrashResult{}, nil
+	}
+	purged, err := s.svc.EmptyTrash(ctx)
+	if err != nil {
+		return nil, gensamplejobs.MakeInternalError(fmt.Errorf("emptying the trash: %w", err))
+	}
+	return &gensamplejobs.EmptyTrashResult{Purged: purged}, nil
+}
+
 func sampleJobToResponse(j model.SampleJob, counts model.ItemStatusCounts, failedDetails []model.FailedItemDetail) *gensamplejobs.SampleJobResponse {
 	checkpointFilenames := j.CheckpointFilenames
 	if checkpointFilenames == nil {
 		checkpointFilenames = []string{}
 	}
 
+	outputFormat := j.OutputFormat
+	if outputFormat == "" {
+		outputFormat = model.OutputFormatPNG
+	}
+
 	resp := &gensamplejobs.SampleJobResponse{
-		ID:                  j.ID,
-		TrainingRunName:     j.TrainingRunName,
-		StudyID:             j.StudyID,
-		StudyName:           j.StudyName,
-		WorkflowName:        j.WorkflowName,
-		CheckpointFilenames: checkpointFilenames,
-		Status:              string(j.Status),
-		TotalItems:          j.TotalItems,
-		CompletedItems:      j.CompletedItems,
-		FailedItems:         counts.Failed,
-		PendingItems:        counts.Pending,
-		CreatedAt:           j.CreatedAt.UTC().Format(time.RFC3339),
-		UpdatedAt:           j.UpdatedAt.UTC().Format(time.RFC3339),
+		ID:                   j.ID,
+		TrainingRunName:      j.TrainingRunName,
+		StudyID:              j.StudyID,
+		StudyName:            j.StudyName,
+		StudyVersion:         j.StudyVersion,
+		WorkflowName:         j.WorkflowName,
+		CheckpointFilenames:  checkpointFilenames,
+		AppendNewCheckpoints: j.AppendNewCheckpoints,
+		OutputFormat:         string(outputFormat),
+		InputImage:           j.InputImage,
+		Status:               string(j.Status),
+		TotalItems:           j.TotalItems,
+		CompletedItems:       j.CompletedItems,
+		FailedItems:          counts.Failed,
+		PendingItems:         counts.Pending,
+		SkippedItems:         skippedItems(counts.Skipped),
+		CreatedAt:            j.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:            j.UpdatedAt.UTC().Format(time.RFC3339),
 	}
 
 	if j.VAE != "" {
@@ -246,9 +861,47 @@ func sampleJobToResponse(j model.SampleJob, counts model.ItemStatusCounts, faile
 		resp.Shift = j.Shift
 	}
 
+	if j.ControlNetModel != "" {
+		resp.ControlnetModel = &j.ControlNetModel
+	}
+	resp.ControlnetStrength = j.ControlNetStrength
+	if j.ControlNetImage != "" {
+		resp.ControlnetImage = &j.ControlNetImage
+	}
+
+	if outputFormat.IsLossy() {
+		quality := j.OutputQuality
+		resp.OutputQuality = &quality
+	}
+
 	if j.ErrorMessage != "" {
 		resp.ErrorMessage = &j.ErrorMessage
 	}
+	if j.CreatedByRequestID != "" {
+		resp.CreatedByRequestID = &j.CreatedByRequestID
+	}
+	if j.Owner != "" {
+		resp.Owner = &j.Owner
+	}
+	if j.Notes != "" {
+		resp.Notes = &j.Notes
+	}
+	if len(j.Labels) > 0 {
+		resp.Labels = j.Labels
+	}
+	if j.DeletedAt != nil {
+		deletedAt := j.DeletedAt.UTC().Format(time.RFC3339)
+		resp.DeletedAt = &deletedAt
+		resp.DeleteData = &j.DeleteData
+	}
+
+	if len(j.Warnings) > 0 {
+		resp.Warnings = j.Warnings
+	}
+
+	for _, o := range j.WorkflowOverrides {
+		resp.WorkflowOverrides = append(resp.WorkflowOverrides, &gensamplejobs.WorkflowOverride{Pattern: o.Pattern, Workflow: o.Workflow})
+	}
 
 	// Populate failed item details with structured error info
 	resp.FailedItemDetails = make([]*gensamplejobs.FailedItemDetailResponse, len(failedDetails))
@@ -266,12 +919,91 @@ func sampleJobToResponse(j model.SampleJob, counts model.ItemStatusCounts, faile
 		if d.Traceback != "" {
 			fd.Traceback = &d.Traceback
 		}
+		if d.ComfyUILog != "" {
+			fd.ComfyuiLog = &d.ComfyUILog
+		}
 		resp.FailedItemDetails[i] = fd
 	}
 
 	return resp
 }
 
+// skippedItems converts skipped item counts by reason to their response
+// form, nil when no item was skipped.
+func skippedItems(skipped map[model.SampleJobItemSkipReason]int) map[string]int {
+	if len(skipped) == 0 {
+		return nil
+	}
+	result := make(map[string]int, len(skipped))
+	for reason, n := range skipped {
+		result[string(reason)] = n
+	}
+	return result
+}
+
+// itemToResponse converts an item to its response, with the path of its image
+// relative to the sample directory.
+func (s *SampleJobsService) itemToResponse(i model.SampleJobItem) *gensamplejobs.SampleJobItemResponse {
+	resp := sampleJobItemToResponse(i)
+	if path := s.svc.ImagePath(i); path != "" {
+		resp.ImagePath = &path
+	}
+	return resp
+}
+
+func sampleJobItemToResponse(i model.SampleJobItem) *gensamplejobs.SampleJobItemResponse {
+	resp := &gensamplejobs.SampleJobItemResponse{
+		ID:                 i.ID,
+		CheckpointFilename: i.CheckpointFilename,
+		PromptName:         i.PromptName,
+		Steps:              i.Steps,
+		Cfg:                i.CFG,
+		SamplerName:        i.SamplerName,
+		Scheduler:          i.Scheduler,
+		Seed:               i.Seed,
+		Width:              i.Width,
+		Height:             i.Height,
+		Status:             string(i.Status),
+		DurationMs:         i.DurationMs,
+		Denoise:            i.Denoise,
+		Wildcards:          i.Wildcards,
+		Scores:             i.Scores,
+	}
+
+	if i.WorkflowName != "" {
+		resp.WorkflowName = &i.WorkflowName
+	}
+
+	if i.Resolution != "" {
+		resp.Resolution = &i.Resolution
+	}
+
+	if i.SkipReason != "" {
+		reason := string(i.SkipReason)
+		resp.SkipReason = &reason
+	}
+
+	if i.ErrorMessage != "" {
+		resp.ErrorMessage = &i.ErrorMessage
+	}
+
+	if i.ComfyUILog != "" {
+		resp.ComfyuiLog = &i.ComfyUILog
+	}
+
+	if i.StartedAt != nil {
+		t := i.StartedAt.UTC().Format(time.RFC3339Nano)
+		resp.StartedAt = &t
+	}
+
+	if i.CompletedAt != nil {
+		t := i.CompletedAt.UTC().Format(time.RFC3339Nano)
+		resp.CompletedAt = &t
+	}
+
+	return resp
+}
+
 func jobProgressToResponse(p model.JobProgress) *gensamplejobs.JobProgressResponse {
 	resp := &gensamplejobs.JobProgressResponse{
 		CheckpointsCompleted: p.CheckpointsCompleted,
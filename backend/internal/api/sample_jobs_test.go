@@ -3,22 +3,32 @@ package api_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
+	goahttp "goa.design/goa/v3/http"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	gensamplejobssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/sample_jobs/server"
 	gensamplejobs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sample_jobs"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
 )
 
 // fakeSampleJobStore is an in-memory test double for service.SampleJobStore.
 type fakeSampleJobStore struct {
 	jobs       map[string]model.SampleJob
+	trash      map[string]model.SampleJob // jobs moved to the trash
 	items      map[string][]model.SampleJobItem
 	studies    map[string]model.Study
 	listErr    error
@@ -26,17 +36,20 @@ type fakeSampleJobStore struct {
 	createErr  error
 	updateErr  error
 	deleteErr  error
+
+	lastItemQuery model.SampleJobItemQuery
 }
 
 func newFakeSampleJobStore() *fakeSampleJobStore {
 	return &fakeSampleJobStore{
 		jobs:    make(map[string]model.SampleJob),
+		trash:   make(map[string]model.SampleJob),
 		items:   make(map[string][]model.SampleJobItem),
 		studies: make(map[string]model.Study),
 	}
 }
 
-func (f *fakeSampleJobStore) ListSampleJobs() ([]model.SampleJob, error) {
+func (f *fakeSampleJobStore) ListSampleJobs(ctx context.Context) ([]model.SampleJob, error) {
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
@@ -47,18 +60,34 @@ func (f *fakeSampleJobStore) ListSampleJobs() ([]model.SampleJob, error) {
 	return result, nil
 }
 
-func (f *fakeSampleJobStore) ListSampleJobsDesc() ([]model.SampleJob, error) {
+func (f *fakeSampleJobStore) ListSampleJobsDesc(ctx context.Context, filter model.SampleJobFilter, page model.Page) ([]model.SampleJob, error) {
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
 	var result []model.SampleJob
 	for _, j := range f.jobs {
-		result = append(result, j)
+		if filter.Includes(j.Owner, j.Labels) {
+			result = append(result, j)
+		}
 	}
-	return result, nil
+	sort.Slice(result, func(a, b int) bool { return result[a].ID > result[b].ID })
+	return pageOf(result, page), nil
+}
+
+func (f *fakeSampleJobStore) CountSampleJobs(ctx context.Context, filter model.SampleJobFilter) (int, error) {
+	if f.listErr != nil {
+		return 0, f.listErr
+	}
+	count := 0
+	for _, j := range f.jobs {
+		if filter.Includes(j.Owner, j.Labels) {
+			count++
+		}
+	}
+	return count, nil
 }
 
-func (f *fakeSampleJobStore) GetSampleJob(id string) (model.SampleJob, error) {
+func (f *fakeSampleJobStore) GetSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
 	if f.getErr != nil {
 		return model.SampleJob{}, f.getErr
 	}
@@ -69,7 +98,7 @@ func (f *fakeSampleJobStore) GetSampleJob(id string) (model.SampleJob, error) {
 	return j, nil
 }
 
-func (f *fakeSampleJobStore) HasRunningJob() (bool, error) {
+func (f *fakeSampleJobStore) HasRunningJob(ctx context.Context) (bool, error) {
 	for _, j := range f.jobs {
 		if j.Status == model.SampleJobStatusRunning {
 			return true, nil
@@ -78,38 +107,116 @@ func (f *fakeSampleJobStore) HasRunningJob() (bool, error) {
 	return false, nil
 }
 
-func (f *fakeSampleJobStore) CreateSampleJob(job model.SampleJob) error {
+func (f *fakeSampleJobStore) CreateSampleJobWithItems(ctx context.Context, job model.SampleJob, items []model.SampleJobItem) error {
 	if f.createErr != nil {
 		return f.createErr
 	}
 	f.jobs[job.ID] = job
+	if len(items) > 0 {
+		f.items[job.ID] = append(f.items[job.ID], items...)
+	}
 	return nil
 }
 
-func (f *fakeSampleJobStore) UpdateSampleJob(job model.SampleJob) error {
+func (f *fakeSampleJobStore) CreateStudyWithSampleJob(ctx context.Context, st model.Study, job model.SampleJob, items []model.SampleJobItem) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.studies[st.ID] = st
+	f.jobs[job.ID] = job
+	if len(items) > 0 {
+		f.items[job.ID] = append(f.items[job.ID], items...)
+	}
+	return nil
+}
+
+func (f *fakeSampleJobStore) AppendSampleJobItems(ctx context.Context, job model.SampleJob, items []model.SampleJobItem) error {
+	f.jobs[job.ID] = job
+	f.items[job.ID] = append(f.items[job.ID], items...)
+	return nil
+}
+
+func (f *fakeSampleJobStore) UpdateSampleJob(ctx context.Context, job *model.SampleJob) error {
 	if f.updateErr != nil {
 		return f.updateErr
 	}
 	if _, ok := f.jobs[job.ID]; !ok {
 		return sql.ErrNoRows
 	}
-	f.jobs[job.ID] = job
+	job.Version++
+	f.jobs[job.ID] = *job
 	return nil
 }
 
-func (f *fakeSampleJobStore) DeleteSampleJob(id string) error {
+func (f *fakeSampleJobStore) TransitionSampleJob(ctx context.Context, job *model.SampleJob, from model.SampleJobStatus) error {
+	if current, ok := f.jobs[job.ID]; ok && current.Status != from {
+		return fmt.Errorf("%w: job %s is %s", store.ErrSampleJobStatusChanged, job.ID, current.Status)
+	}
+	return f.UpdateSampleJob(ctx, job)
+}
+
+func (f *fakeSampleJobStore) DeleteSampleJob(ctx context.Context, id string) error {
 	if f.deleteErr != nil {
 		return f.deleteErr
 	}
-	if _, ok := f.jobs[id]; !ok {
+	_, inJobs := f.jobs[id]
+	_, inTrash := f.trash[id]
+	if !inJobs && !inTrash {
 		return sql.ErrNoRows
 	}
 	delete(f.jobs, id)
+	delete(f.trash, id)
 	delete(f.items, id)
 	return nil
 }
 
-func (f *fakeSampleJobStore) ListSampleJobItems(jobID string) ([]model.SampleJobItem, error) {
+func (f *fakeSampleJobStore) ListTrashedSampleJobs(ctx context.Context, scope model.OwnerScope) ([]model.SampleJob, error) {
+	var result []model.SampleJob
+	for _, j := range f.trash {
+		if scope.Includes(j.Owner) {
+			result = append(result, j)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].ID < result[b].ID })
+	return result, nil
+}
+
+func (f *fakeSampleJobStore) GetTrashedSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
+	j, ok := f.trash[id]
+	if !ok {
+		return model.SampleJob{}, sql.ErrNoRows
+	}
+	return j, nil
+}
+
+func (f *fakeSampleJobStore) TrashSampleJob(ctx context.Context, id string, deletedAt time.Time, deleteData bool) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	j, ok := f.jobs[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	j.DeletedAt = &deletedAt
+	j.DeleteData = deleteData
+	f.trash[id] = j
+	delete(f.jobs, id)
+	return nil
+}
+
+func (f *fakeSampleJobStore) RestoreSampleJob(ctx context.Context, id string) error {
+	j, ok := f.trash[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	j.DeletedAt = nil
+	j.DeleteData = false
+	f.jobs[id] = j
+	delete(f.trash, id)
+	return nil
+}
+
+func (f *fakeSampleJobStore) ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error) {
 	items, ok := f.items[jobID]
 	if !ok {
 		return []model.SampleJobItem{}, nil
@@ -117,16 +224,32 @@ func (f *fakeSampleJobStore) ListSampleJobItems(jobID string) ([]model.SampleJob
 	return items, nil
 }
 
-func (f *fakeSampleJobStore) CreateSampleJobItem(item model.SampleJobItem) error {
-	f.items[item.JobID] = append(f.items[item.JobID], item)
-	return nil
+func (f *fakeSampleJobStore) ListSampleJobItemsPage(ctx context.Context, jobID string, query model.SampleJobItemQuery, page model.Page) ([]model.SampleJobItem, error) {
+	f.lastItemQuery = query
+	return pageOf(f.items[jobID], page), nil
 }
 
-func (f *fakeSampleJobStore) UpdateSampleJobItem(item model.SampleJobItem) error {
+func (f *fakeSampleJobStore) CountSampleJobItems(ctx context.Context, jobID string, filter model.SampleJobItemFilter) (int, error) {
+	return len(f.items[jobID]), nil
+}
+
+// pageOf returns the window of all selected by page, like LIMIT/OFFSET.
+func pageOf[T any](all []T, page model.Page) []T {
+	if page.Offset >= len(all) {
+		return nil
+	}
+	all = all[page.Offset:]
+	if page.Limit > 0 && page.Limit < len(all) {
+		all = all[:page.Limit]
+	}
+	return all
+}
+
+func (f *fakeSampleJobStore) UpdateSampleJobItem(ctx context.Context, item *model.SampleJobItem) error {
 	return nil
 }
 
-func (f *fakeSampleJobStore) GetStudy(id string) (model.Study, error) {
+func (f *fakeSampleJobStore) GetStudy(ctx context.Context, id string) (model.Study, error) {
 	s, ok := f.studies[id]
 	if !ok {
 		return model.Study{}, sql.ErrNoRows
@@ -134,10 +257,18 @@ func (f *fakeSampleJobStore) GetStudy(id string) (model.Study, error) {
 	return s, nil
 }
 
+func (f *fakeSampleJobStore) ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error) {
+	return nil, nil
+}
+
+func (f *fakeSampleJobStore) GetSampleJobParameters(ctx context.Context, jobID string) (model.SampleJobParameters, error) {
+	return model.SampleJobParameters{}, sql.ErrNoRows
+}
+
 // fakePathMatcher is a test double for service.PathMatcher.
 type fakePathMatcher struct{}
 
-func (f *fakePathMatcher) MatchCheckpointPath(filename string) (string, error) {
+func (f *fakePathMatcher) MatchCheckpointPath(trainingRunName, filename string) (string, error) {
 	return filename, nil
 }
 
@@ -189,7 +320,7 @@ var _ = Describe("SampleJobsService", func() {
 	Describe("Error responses include Goa ServiceError structure", func() {
 		It("List returns ServiceError with proper fields on store failure", func() {
 			store.listErr = errors.New("database connection failed")
-			_, err := sampleJobs.List(ctx)
+			_, err := sampleJobs.List(ctx, &gensamplejobs.ListPayload{})
 			Expect(err).To(HaveOccurred())
 
 			// Verify it's a Goa ServiceError with proper structure
@@ -222,9 +353,9 @@ var _ = Describe("SampleJobsService", func() {
 		})
 
 		It("Delete returns ServiceError with proper fields on internal error", func() {
-			// Pre-populate job so GetSampleJob succeeds and reaches DeleteSampleJob
+			// Pre-populate job so GetSampleJob succeeds and reaches TrashSampleJob
 			store.jobs["test-id"] = model.SampleJob{ID: "test-id", StudyName: "study-1"}
-			store.deleteErr = errors.New("database write failed")
+			store.updateErr = errors.New("database write failed")
 			err := sampleJobs.Delete(ctx, &gensamplejobs.DeletePayload{ID: "test-id"})
 			Expect(err).To(HaveOccurred())
 
@@ -246,6 +377,445 @@ var _ = Describe("SampleJobsService", func() {
 		})
 	})
 
+	Describe("trash", func() {
+		BeforeEach(func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", StudyName: "study-1", Status: model.SampleJobStatusCompleted}
+			Expect(sampleJobs.Delete(ctx, &gensamplejobs.DeletePayload{ID: "job-1", DeleteData: true})).To(Succeed())
+		})
+
+		It("lists deleted jobs with when they were deleted", func() {
+			result, err := sampleJobs.ListTrash(ctx, &gensamplejobs.ListTrashPayload{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Jobs).To(HaveLen(1))
+			Expect(result.Jobs[0].DeletedAt).NotTo(BeNil())
+			Expect(result.Jobs[0].DeleteData).To(HaveValue(BeTrue()))
+
+			list, err := sampleJobs.List(ctx, &gensamplejobs.ListPayload{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list.Jobs).To(BeEmpty())
+		})
+
+		It("restores a deleted job", func() {
+			job, err := sampleJobs.Restore(ctx, &gensamplejobs.RestorePayload{ID: "job-1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.ID).To(Equal("job-1"))
+			Expect(job.DeletedAt).To(BeNil())
+			Expect(job.DeleteData).To(BeNil())
+		})
+
+		It("purges a deleted job", func() {
+			Expect(sampleJobs.Purge(ctx, &gensamplejobs.PurgePayload{ID: "job-1"})).To(Succeed())
+			Expect(store.trash).To(BeEmpty())
+		})
+
+		It("returns not_found for jobs not in the trash", func() {
+			store.jobs["job-2"] = model.SampleJob{ID: "job-2"}
+
+			_, err := sampleJobs.Restore(ctx, &gensamplejobs.RestorePayload{ID: "job-2"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("not_found"))
+			err = sampleJobs.Purge(ctx, &gensamplejobs.PurgePayload{ID: "job-2"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("not_found"))
+		})
+
+		It("returns internal_error when purging fails", func() {
+			store.deleteErr = errors.New("database write failed")
+
+			err := sampleJobs.Purge(ctx, &gensamplejobs.PurgePayload{ID: "job-1"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("internal_error"))
+			_, err = sampleJobs.EmptyTrash(ctx)
+			Expect(err.(errorNamer).ErrorName()).To(Equal("internal_error"))
+		})
+
+		It("empties the trash", func() {
+			result, err := sampleJobs.EmptyTrash(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Purged).To(Equal(1))
+			Expect(store.trash).To(BeEmpty())
+		})
+	})
+
+	Describe("annotations", func() {
+		BeforeEach(func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", StudyName: "study-1", Status: model.SampleJobStatusRunning}
+			store.jobs["job-2"] = model.SampleJob{ID: "job-2", StudyName: "study-1", Status: model.SampleJobStatusCompleted}
+		})
+
+		It("annotates a job and returns its notes and labels", func() {
+			notes := "lr=1e-5 experiment, bad data mix"
+			job, err := sampleJobs.Annotate(ctx, &gensamplejobs.AnnotatePayload{
+				ID:     "job-1",
+				Notes:  &notes,
+				Labels: map[string]string{"lr": "1e-5"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Notes).To(HaveValue(Equal(notes)))
+			Expect(job.Labels).To(Equal(map[string]string{"lr": "1e-5"}))
+			Expect(job.Status).To(Equal("running"))
+		})
+
+		It("filters the job list by label", func() {
+			_, err := sampleJobs.Annotate(ctx, &gensamplejobs.AnnotatePayload{ID: "job-2", Labels: map[string]string{"lr": "3e-5"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			list, err := sampleJobs.List(ctx, &gensamplejobs.ListPayload{Label: []string{"lr=3e-5"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list.Total).To(Equal(1))
+			Expect(list.Jobs[0].ID).To(Equal("job-2"))
+			Expect(list.Jobs[0].Notes).To(BeNil())
+		})
+
+		It("returns invalid_payload for a malformed label filter", func() {
+			_, err := sampleJobs.List(ctx, &gensamplejobs.ListPayload{Label: []string{"=3e-5"}})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("invalid_payload"))
+		})
+
+		It("returns invalid_payload for invalid labels", func() {
+			_, err := sampleJobs.Annotate(ctx, &gensamplejobs.AnnotatePayload{ID: "job-1", Labels: map[string]string{"lr=1": "x"}})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("invalid_payload"))
+		})
+
+		It("returns not_found for an unknown job", func() {
+			_, err := sampleJobs.Annotate(ctx, &gensamplejobs.AnnotatePayload{ID: "missing"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("not_found"))
+		})
+
+		It("returns forbidden for another user's job", func() {
+			store.jobs["job-3"] = model.SampleJob{ID: "job-3", Owner: "bob"}
+
+			_, err := sampleJobs.Annotate(service.WithUser(ctx, model.User{Name: "alice"}), &gensamplejobs.AnnotatePayload{ID: "job-3"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("forbidden"))
+		})
+	})
+
+	Describe("CreateBulk", func() {
+		It("returns not_found when the training run does not exist", func() {
+			_, err := sampleJobs.CreateBulk(ctx, &gensamplejobs.BulkCreateSampleJobsPayload{
+				TrainingRunName: "missing-run",
+				StudyIds:        []string{"study-1", "study-2"},
+			})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+	})
+
+	Describe("Preview", func() {
+		It("returns not_found when the training run does not exist", func() {
+			_, err := sampleJobs.Preview(ctx, &gensamplejobs.CreateSampleJobPayload{
+				TrainingRunName: "missing-run",
+				StudyID:         "study-1",
+			})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+			Expect(store.jobs).To(BeEmpty())
+		})
+	})
+
+	Describe("CreateWithStudy", func() {
+		var payload *gensamplejobs.CreateSampleJobWithStudyPayload
+
+		BeforeEach(func() {
+			sampleJobs.SetStudyService(service.NewStudyService(newFakeStudyStoreAPI(), &fakeSampleCheckerAPI{}, logger))
+			payload = &gensamplejobs.CreateSampleJobWithStudyPayload{
+				TrainingRunName: "missing-run",
+				Study: &gensamplejobs.CreateStudyPayload{
+					Name:                  "Inline Study",
+					Prompts:               []*gensamplejobs.NamedPrompt{{Name: "cat", Text: "a cat"}},
+					Steps:                 []int{20},
+					Cfgs:                  []float64{5.0},
+					SamplerSchedulerPairs: []*gensamplejobs.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}},
+					Seeds:                 []int64{1},
+					Width:                 512,
+					Height:                512,
+					WorkflowTemplate:      "workflow.json",
+				},
+			}
+		})
+
+		It("returns invalid_payload and stores nothing when the study is invalid", func() {
+			payload.Study.Steps = nil
+
+			_, err := sampleJobs.CreateWithStudy(ctx, payload)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+			Expect(err.Error()).To(ContainSubstring("creating study"))
+			Expect(store.studies).To(BeEmpty())
+		})
+
+		It("returns not_found and stores nothing when the training run does not exist", func() {
+			_, err := sampleJobs.CreateWithStudy(ctx, payload)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+			Expect(store.studies).To(BeEmpty())
+			Expect(store.jobs).To(BeEmpty())
+		})
+
+		It("returns invalid_payload when no study service is set", func() {
+			sampleJobs.SetStudyService(nil)
+
+			_, err := sampleJobs.CreateWithStudy(ctx, payload)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+		})
+	})
+
+	Describe("Show output format", func() {
+		It("reports png without a quality for jobs created before output formats", func() {
+			store.jobs["legacy"] = model.SampleJob{ID: "legacy", Status: model.SampleJobStatusPending}
+
+			resp, err := sampleJobs.Show(ctx, &gensamplejobs.ShowPayload{ID: "legacy"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Job.OutputFormat).To(Equal("png"))
+			Expect(resp.Job.OutputQuality).To(BeNil())
+		})
+
+		It("reports the format and quality of lossy jobs", func() {
+			store.jobs["webp"] = model.SampleJob{ID: "webp", Status: model.SampleJobStatusPending, OutputFormat: model.OutputFormatWebP, OutputQuality: 80}
+
+			resp, err := sampleJobs.Show(ctx, &gensamplejobs.ShowPayload{ID: "webp"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Job.OutputFormat).To(Equal("webp"))
+			Expect(resp.Job.OutputQuality).To(HaveValue(Equal(80)))
+		})
+	})
+
+	Describe("ListItems", func() {
+		It("returns items with timing fields formatted as RFC3339", func() {
+			startedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+			completedAt := startedAt.Add(1500 * time.Millisecond)
+			durationMs := int64(1500)
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1"}
+			store.items["job-1"] = []model.SampleJobItem{
+				{
+					ID:                 "item-1",
+					JobID:              "job-1",
+					CheckpointFilename: "ckpt.safetensors",
+					PromptName:         "forest",
+					Steps:              20,
+					CFG:                7.5,
+					SamplerName:        "euler",
+					Scheduler:          "normal",
+					Seed:               42,
+					Width:              512,
+					Height:             512,
+					Status:             model.SampleJobItemStatusCompleted,
+					StartedAt:          &startedAt,
+					CompletedAt:        &completedAt,
+					DurationMs:         &durationMs,
+				},
+				{ID: "item-2", JobID: "job-1", Status: model.SampleJobItemStatusPending},
+			}
+
+			res, err := sampleJobs.ListItems(ctx, &gensamplejobs.ListItemsPayload{ID: "job-1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Total).To(Equal(2))
+			result := res.Items
+			Expect(result).To(HaveLen(2))
+			Expect(result[0].CheckpointFilename).To(Equal("ckpt.safetensors"))
+			Expect(result[0].Status).To(Equal("completed"))
+			Expect(*result[0].StartedAt).To(Equal("2025-01-01T12:00:00Z"))
+			Expect(*result[0].CompletedAt).To(Equal("2025-01-01T12:00:01.5Z"))
+			Expect(*result[0].DurationMs).To(Equal(int64(1500)))
+			Expect(result[1].StartedAt).To(BeNil())
+			Expect(result[1].CompletedAt).To(BeNil())
+			Expect(result[1].DurationMs).To(BeNil())
+		})
+
+		It("returns the error and captured ComfyUI log of failed items", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1"}
+			store.items["job-1"] = []model.SampleJobItem{
+				{
+					ID:           "item-1",
+					JobID:        "job-1",
+					Status:       model.SampleJobItemStatusFailed,
+					ErrorMessage: "[RuntimeError] KSampler: boom",
+					ComfyUILog:   "!!! Exception during processing !!! boom\nTraceback (most recent call last):",
+				},
+				{ID: "item-2", JobID: "job-1", Status: model.SampleJobItemStatusPending},
+			}
+
+			res, err := sampleJobs.ListItems(ctx, &gensamplejobs.ListItemsPayload{ID: "job-1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*res.Items[0].ErrorMessage).To(Equal("[RuntimeError] KSampler: boom"))
+			Expect(*res.Items[0].ComfyuiLog).To(Equal("!!! Exception during processing !!! boom\nTraceback (most recent call last):"))
+			Expect(res.Items[1].ComfyuiLog).To(BeNil())
+		})
+
+		It("returns the skip reason of skipped items", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1"}
+			store.items["job-1"] = []model.SampleJobItem{
+				{
+					ID:           "item-1",
+					JobID:        "job-1",
+					Status:       model.SampleJobItemStatusSkipped,
+					SkipReason:   model.SampleJobItemSkipReasonDuplicate,
+					ErrorMessage: model.SkippedOutputExistsMessage,
+				},
+				{ID: "item-2", JobID: "job-1", Status: model.SampleJobItemStatusPending},
+			}
+
+			res, err := sampleJobs.ListItems(ctx, &gensamplejobs.ListItemsPayload{ID: "job-1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Items[0].SkipReason).To(HaveValue(Equal("duplicate")))
+			Expect(res.Items[1].SkipReason).To(BeNil())
+		})
+
+		It("returns the workflow override of items sampled with one", func() {
+			store.jobs["job-1"] = model.SampleJob{
+				ID:                "job-1",
+				WorkflowName:      "sdxl.json",
+				WorkflowOverrides: []model.WorkflowOverride{{Pattern: "*-flux-*", Workflow: "flux-dev.json"}},
+			}
+			store.items["job-1"] = []model.SampleJobItem{
+				{ID: "item-1", JobID: "job-1", Status: model.SampleJobItemStatusPending, WorkflowName: "flux-dev.json"},
+				{ID: "item-2", JobID: "job-1", Status: model.SampleJobItemStatusPending},
+			}
+
+			res, err := sampleJobs.ListItems(ctx, &gensamplejobs.ListItemsPayload{ID: "job-1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Items[0].WorkflowName).To(HaveValue(Equal("flux-dev.json")))
+			Expect(res.Items[1].WorkflowName).To(BeNil())
+
+			detail, err := sampleJobs.Show(ctx, &gensamplejobs.ShowPayload{ID: "job-1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(detail.Job.WorkflowOverrides).To(Equal([]*gensamplejobs.WorkflowOverride{{Pattern: "*-flux-*", Workflow: "flux-dev.json"}}))
+		})
+
+		It("returns the requested page and the total item count", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", Status: model.SampleJobStatusRunning}
+			for _, id := range []string{"item-1", "item-2", "item-3"} {
+				store.items["job-1"] = append(store.items["job-1"], model.SampleJobItem{ID: id, JobID: "job-1", Status: model.SampleJobItemStatusPending})
+			}
+			limit := 1
+
+			res, err := sampleJobs.ListItems(ctx, &gensamplejobs.ListItemsPayload{ID: "job-1", Limit: &limit, Offset: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Total).To(Equal(3))
+			Expect(res.Items).To(HaveLen(1))
+			Expect(res.Items[0].ID).To(Equal("item-2"))
+		})
+
+		It("returns not_found ServiceError when job does not exist", func() {
+			_, err := sampleJobs.ListItems(ctx, &gensamplejobs.ListItemsPayload{ID: "nonexistent"})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+	})
+
+	Describe("Compare", func() {
+		It("returns both jobs with their paired and unmatched items", func() {
+			store.jobs["job-a"] = model.SampleJob{ID: "job-a", TrainingRunName: "run-a"}
+			store.jobs["job-b"] = model.SampleJob{ID: "job-b", TrainingRunName: "run-b"}
+			store.items["job-a"] = []model.SampleJobItem{
+				{ID: "a1", JobID: "job-a", PromptText: "a forest", Seed: 1, Status: model.SampleJobItemStatusCompleted, OutputPath: "/samples/Sweep/ckpt.safetensors/a1.png"},
+				{ID: "a2", JobID: "job-a", PromptText: "a city", Seed: 1, Status: model.SampleJobItemStatusCompleted},
+			}
+			store.items["job-b"] = []model.SampleJobItem{
+				{ID: "b1", JobID: "job-b", PromptText: "a forest", Seed: 1, Status: model.SampleJobItemStatusCompleted, OutputPath: "/samples/Sweep/ckpt.safetensors/b1.png"},
+			}
+
+			res, err := sampleJobs.Compare(ctx, &gensamplejobs.ComparePayload{A: "job-a", B: "job-b"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.A.TrainingRunName).To(Equal("run-a"))
+			Expect(res.B.TrainingRunName).To(Equal("run-b"))
+			Expect(res.Pairs).To(HaveLen(1))
+			Expect(*res.Pairs[0].A.ImagePath).To(Equal("Sweep/ckpt.safetensors/a1.png"))
+			Expect(*res.Pairs[0].B.ImagePath).To(Equal("Sweep/ckpt.safetensors/b1.png"))
+			Expect(res.UnmatchedA).To(HaveLen(1))
+			Expect(res.UnmatchedA[0].ID).To(Equal("a2"))
+			Expect(res.UnmatchedB).NotTo(BeNil())
+			Expect(res.UnmatchedB).To(BeEmpty())
+		})
+
+		It("returns not_found for an unknown job", func() {
+			store.jobs["job-a"] = model.SampleJob{ID: "job-a"}
+
+			_, err := sampleJobs.Compare(ctx, &gensamplejobs.ComparePayload{A: "job-a", B: "missing"})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+	})
+
+	Describe("Pagination, filtering, and sorting over HTTP", func() {
+		var ts *httptest.Server
+
+		BeforeEach(func() {
+			mux := goahttp.NewMuxer()
+			server := gensamplejobssvr.New(gensamplejobs.NewEndpoints(sampleJobs), mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, nil)
+			server.Mount(mux)
+			ts = httptest.NewServer(mux)
+			DeferCleanup(ts.Close)
+
+			for _, id := range []string{"job-1", "job-2", "job-3"} {
+				store.jobs[id] = model.SampleJob{ID: id, Status: model.SampleJobStatusCompleted}
+			}
+		})
+
+		It("returns the page as an array and the total in X-Total-Count", func() {
+			resp, err := http.Get(ts.URL + "/api/sample-jobs?limit=2&offset=0")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("X-Total-Count")).To(Equal("3"))
+
+			var jobs []map[string]any
+			Expect(json.NewDecoder(resp.Body).Decode(&jobs)).To(Succeed())
+			Expect(jobs).To(HaveLen(2))
+		})
+
+		It("maps the item filter and sort parameters to the store query", func() {
+			resp, err := http.Get(ts.URL + "/api/sample-jobs/job-1/items?status=skipped&skip_reason=checkpoint_not_found&checkpoint=a.safetensors&prompt_name=forest&sampler=euler&sort=duration&order=desc")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(store.lastItemQuery).To(Equal(model.SampleJobItemQuery{
+				Filter: model.SampleJobItemFilter{
+					Status:             model.SampleJobItemStatusSkipped,
+					CheckpointFilename: "a.safetensors",
+					PromptName:         "forest",
+					SamplerName:        "euler",
+					SkipReason:         model.SampleJobItemSkipReasonCheckpointNotFound,
+				},
+				Sort:       model.SampleJobItemSortDuration,
+				Descending: true,
+			}))
+		})
+
+		It("sorts items by creation, ascending, by default", func() {
+			resp, err := http.Get(ts.URL + "/api/sample-jobs/job-1/items")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(store.lastItemQuery).To(Equal(model.SampleJobItemQuery{Sort: model.SampleJobItemSortCreatedAt}))
+		})
+
+		It("rejects an unknown sort key", func() {
+			resp, err := http.Get(ts.URL + "/api/sample-jobs/job-1/items?sort=cfg")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("rejects a limit above the maximum", func() {
+			resp, err := http.Get(ts.URL + "/api/sample-jobs?limit=5000")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
 	Describe("Nil guard when ComfyUI is not configured (svc == nil)", func() {
 		var disabledSvc *api.SampleJobsService
 
@@ -256,10 +826,11 @@ var _ = Describe("SampleJobsService", func() {
 		})
 
 		It("List returns an empty slice without error", func() {
-			result, err := disabledSvc.List(ctx)
+			result, err := disabledSvc.List(ctx, &gensamplejobs.ListPayload{})
 			Expect(err).NotTo(HaveOccurred())
-			Expect(result).NotTo(BeNil())
-			Expect(result).To(BeEmpty())
+			Expect(result.Jobs).NotTo(BeNil())
+			Expect(result.Jobs).To(BeEmpty())
+			Expect(result.Total).To(BeZero())
 		})
 
 		It("Show returns service_unavailable ServiceError", func() {
@@ -281,6 +852,28 @@ var _ = Describe("SampleJobsService", func() {
 			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
 		})
 
+		It("Preview returns invalid_payload ServiceError", func() {
+			_, err := disabledSvc.Preview(ctx, &gensamplejobs.CreateSampleJobPayload{
+				TrainingRunName: "run-1",
+				StudyID:         "study-1",
+			})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+		})
+
+		It("CreateBulk returns invalid_payload ServiceError", func() {
+			_, err := disabledSvc.CreateBulk(ctx, &gensamplejobs.BulkCreateSampleJobsPayload{
+				TrainingRunName: "run-1",
+				StudyIds:        []string{"study-1"},
+			})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+		})
+
 		It("Start returns service_unavailable ServiceError", func() {
 			_, err := disabledSvc.Start(ctx, &gensamplejobs.StartPayload{ID: "any-id"})
 			Expect(err).To(HaveOccurred())
@@ -313,6 +906,14 @@ var _ = Describe("SampleJobsService", func() {
 			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
 		})
 
+		It("ListItems returns service_unavailable ServiceError", func() {
+			_, err := disabledSvc.ListItems(ctx, &gensamplejobs.ListItemsPayload{ID: "any-id"})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+		})
+
 		It("Delete returns internal_error ServiceError", func() {
 			err := disabledSvc.Delete(ctx, &gensamplejobs.DeletePayload{ID: "any-id"})
 			Expect(err).To(HaveOccurred())
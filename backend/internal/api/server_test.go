@@ -19,6 +19,7 @@ import (
 	genhealthsvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/health/server"
 	gentrainingrunssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/training_runs/server"
 	gentrainingruns "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/training_runs"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 	goahttp "goa.design/goa/v3/http"
 )
@@ -54,6 +55,10 @@ var _ = Describe("Server integration", func() {
 		docsSvc := api.NewDocsService(specJSON)
 		cpDiscovery := service.NewDiscoveryService(&fakeCheckpointFileSystem{}, []string{}, sampleDir, logger)
 		trainingRunsSvc := api.NewTrainingRunsService(viewerDiscovery, cpDiscovery, scanner, nil, nil, nil)
+		summaryStore := &fakeTrainingRunSummaryStore{stats: map[string]model.TrainingRunJobStats{
+			"qwen/lora": {JobStatusCounts: map[model.SampleJobStatus]int{model.SampleJobStatusCompleted: 1}},
+		}}
+		trainingRunsSvc.SetSummaryService(service.NewTrainingRunSummaryService(cpDiscovery, summaryStore, scanFS, viewerFS, fakeTrainingRunUsage{}, sampleDir, logger))
 
 		healthEndpoints := genhealth.NewEndpoints(healthSvc)
 		docsEndpoints := gendocs.NewEndpoints(docsSvc)
@@ -190,6 +195,28 @@ var _ = Describe("Server integration", func() {
 		})
 	})
 
+	Describe("GET /api/training-runs/{name}/summary", func() {
+		It("accepts a training run name with an escaped slash", func() {
+			resp, err := client.Get(server.URL + "/api/training-runs/qwen%2Flora/summary")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			var result map[string]any
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			Expect(result).To(HaveKeyWithValue("name", "qwen/lora"))
+			Expect(result).To(HaveKeyWithValue("job_count", BeNumerically("==", 1)))
+		})
+
+		It("returns 404 for an unknown training run", func() {
+			resp, err := client.Get(server.URL + "/api/training-runs/missing/summary")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
 	Describe("OPTIONS preflight", func() {
 		It("returns CORS headers for preflight requests", func() {
 			req, err := http.NewRequest(http.MethodOptions, server.URL+"/health", nil)
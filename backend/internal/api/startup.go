@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	goahttp "goa.design/goa/v3/http"
+
+	genhealth "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/health"
+	genhealthsvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/health/server"
+)
+
+// startupProbePaths are the health paths served while the server initializes.
+var startupProbePaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// StartupHandler is the HTTP server's handler from the moment it starts
+// listening. Until the API handler is set, it answers the liveness and
+// readiness probes and responds 503 with a Retry-After header to every other
+// request, so that clients and load balancers see a server that is starting
+// rather than failing. Once set, every request goes to the API handler.
+type StartupHandler struct {
+	probes  http.Handler
+	handler atomic.Pointer[http.Handler]
+	logger  *logrus.Entry
+}
+
+// NewStartupHandler returns a StartupHandler that answers probes with the
+// live and ready methods of healthEndpoints while the server initializes.
+func NewStartupHandler(healthEndpoints *genhealth.Endpoints, logger *logrus.Logger) *StartupHandler {
+	mux := goahttp.NewMuxer()
+	genhealthsvr.New(healthEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, errorHandler(logger), nil).Mount(mux)
+	return &StartupHandler{
+		probes: mux,
+		logger: logger.WithField("component", "startup_handler"),
+	}
+}
+
+// SetHandler installs the API handler, which serves every request from then
+// on.
+func (h *StartupHandler) SetHandler(handler http.Handler) {
+	h.handler.Store(&handler)
+	h.logger.Debug("API handler installed")
+}
+
+// ServeHTTP implements http.Handler.
+func (h *StartupHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler := h.handler.Load(); handler != nil {
+		(*handler).ServeHTTP(w, r)
+		return
+	}
+	if startupProbePaths[r.URL.Path] {
+		h.probes.ServeHTTP(w, r)
+		return
+	}
+	h.logger.WithField("path", r.URL.Path).Debug("request received before startup completed")
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "server starting, retry later", http.StatusServiceUnavailable)
+}
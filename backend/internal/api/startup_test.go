@@ -0,0 +1,97 @@
+package api_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	genhealth "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/health"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+var _ = Describe("StartupHandler", func() {
+	var (
+		readiness *fakeReadiness
+		handler   *api.StartupHandler
+	)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	BeforeEach(func() {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		readiness = &fakeReadiness{status: model.ReadinessStatus{
+			Checks: []model.ReadinessCheck{
+				{Name: model.ReadinessStepMigrations, Ready: true},
+				{Name: model.ReadinessStepAPI},
+			},
+		}}
+		healthSvc := api.NewHealthService()
+		healthSvc.SetReadiness(readiness)
+		handler = api.NewStartupHandler(genhealth.NewEndpoints(healthSvc), logger)
+	})
+
+	Context("before the API handler is set", func() {
+		It("answers the liveness probe", func() {
+			rec := request("/healthz")
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(ContainSubstring(`"status":"ok"`))
+		})
+
+		It("answers the readiness probe with 503 and the pending steps", func() {
+			rec := request("/readyz")
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+
+			var body struct {
+				Status string `json:"status"`
+				Checks []struct {
+					Name  string `json:"name"`
+					Ready bool   `json:"ready"`
+				} `json:"checks"`
+			}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+			Expect(body.Status).To(Equal("not_ready"))
+			Expect(body.Checks).To(HaveLen(2))
+			Expect(body.Checks[1].Name).To(Equal("api"))
+			Expect(body.Checks[1].Ready).To(BeFalse())
+		})
+
+		It("responds 503 with Retry-After to other requests", func() {
+			for _, path := range []string{"/api/training-runs", "/health/executor"} {
+				rec := request(path)
+				Expect(rec.Code).To(Equal(http.StatusServiceUnavailable), path)
+				Expect(rec.Header().Get("Retry-After")).To(Equal("1"), path)
+			}
+		})
+	})
+
+	Context("after the API handler is set", func() {
+		BeforeEach(func() {
+			handler.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))
+		})
+
+		It("passes every request to the API handler", func() {
+			Expect(request("/api/training-runs").Code).To(Equal(http.StatusTeapot))
+			Expect(request("/readyz").Code).To(Equal(http.StatusTeapot))
+		})
+	})
+
+	It("answers the readiness probe with 200 once every step has completed", func() {
+		readiness.status = model.ReadinessStatus{Ready: true, Checks: []model.ReadinessCheck{{Name: model.ReadinessStepAPI, Ready: true}}}
+		rec := request("/readyz")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"status":"ready"`))
+	})
+})
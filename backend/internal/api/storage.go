@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	genstorage "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/storage"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// StorageManager reports sample directory disk usage and applies the
+// retention policy.
+type StorageManager interface {
+	Usage() (model.StorageUsage, error)
+	RetentionPolicy() *model.RetentionConfig
+	ApplyRetention(ctx context.Context, dryRun bool) (model.RetentionResult, error)
+}
+
+// StorageService implements the generated storage service interface.
+type StorageService struct {
+	manager StorageManager
+}
+
+// NewStorageService returns a new StorageService.
+func NewStorageService(manager StorageManager) *StorageService {
+	return &StorageService{manager: manager}
+}
+
+// Usage reports the disk usage of the sample directory.
+func (s *StorageService) Usage(ctx context.Context) (*genstorage.StorageUsageResponse, error) {
+	usage, err := s.manager.Usage()
+	if err != nil {
+		return nil, genstorage.MakeInternalError(err)
+	}
+	runs := make([]*genstorage.TrainingRunStorageUsageResponse, len(usage.TrainingRuns))
+	for i, r := range usage.TrainingRuns {
+		checkpoints := make([]*genstorage.CheckpointStorageUsageResponse, len(r.Checkpoints))
+		for j, c := range r.Checkpoints {
+			checkpoints[j] = &genstorage.CheckpointStorageUsageResponse{
+				CheckpointFilename: c.CheckpointFilename,
+				Bytes:              c.Bytes,
+				FileCount:          c.FileCount,
+				StudyCount:         c.StudyCount,
+			}
+		}
+		runs[i] = &genstorage.TrainingRunStorageUsageResponse{
+			Directory:   r.Directory,
+			Bytes:       r.Bytes,
+			FileCount:   r.FileCount,
+			Checkpoints: checkpoints,
+		}
+	}
+	return &genstorage.StorageUsageResponse{
+		TotalBytes:   usage.TotalBytes,
+		FileCount:    usage.FileCount,
+		TrainingRuns: runs,
+	}, nil
+}
+
+// ApplyRetention applies the retention policy once, or lists what it would
+// delete in a dry run.
+func (s *StorageService) ApplyRetention(ctx context.Context, p *genstorage.ApplyRetentionPayload) (*genstorage.RetentionResultResponse, error) {
+	if s.manager.RetentionPolicy() == nil {
+		return nil, genstorage.MakeServiceUnavailable(fmt.Errorf("retention policy not configured"))
+	}
+	result, err := s.manager.ApplyRetention(ctx, p.DryRun)
+	if err != nil {
+		return nil, genstorage.MakeInternalError(err)
+	}
+	deleted := make([]*genstorage.RetentionCandidateResponse, len(result.Deleted))
+	for i, c := range result.Deleted {
+		deleted[i] = &genstorage.RetentionCandidateResponse{
+			Path:               c.Path,
+			TrainingRun:        c.TrainingRun,
+			StudyName:          c.StudyName,
+			CheckpointFilename: c.CheckpointFilename,
+			Bytes:              c.Bytes,
+			FileCount:          c.FileCount,
+		}
+	}
+	return &genstorage.RetentionResultResponse{
+		DryRun:     result.DryRun,
+		Deleted:    deleted,
+		FreedBytes: result.FreedBytes,
+		Errors:     result.Errors,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	genstorage "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/storage"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// fakeStorageManager implements api.StorageManager for testing.
+type fakeStorageManager struct {
+	usage     model.StorageUsage
+	usageErr  error
+	policy    *model.RetentionConfig
+	result    model.RetentionResult
+	dryRunArg *bool
+}
+
+func (f *fakeStorageManager) Usage() (model.StorageUsage, error) {
+	return f.usage, f.usageErr
+}
+
+func (f *fakeStorageManager) RetentionPolicy() *model.RetentionConfig {
+	return f.policy
+}
+
+func (f *fakeStorageManager) ApplyRetention(ctx context.Context, dryRun bool) (model.RetentionResult, error) {
+	f.dryRunArg = &dryRun
+	f.result.DryRun = dryRun
+	return f.result, nil
+}
+
+var _ = Describe("StorageService", func() {
+	var (
+		ctx     context.Context
+		manager *fakeStorageManager
+		svc     *api.StorageService
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		manager = &fakeStorageManager{}
+		svc = api.NewStorageService(manager)
+	})
+
+	Describe("Usage", func() {
+		It("maps usage per training run and checkpoint", func() {
+			manager.usage = model.StorageUsage{
+				TotalBytes: 300,
+				FileCount:  3,
+				TrainingRuns: []model.TrainingRunStorageUsage{{
+					Directory: "my-model",
+					Bytes:     300,
+					FileCount: 3,
+					Checkpoints: []model.CheckpointStorageUsage{
+						{CheckpointFilename: "my-model-step00001000.safetensors", Bytes: 300, FileCount: 3, StudyCount: 2},
+					},
+				}},
+			}
+
+			res, err := svc.Usage(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.TotalBytes).To(Equal(int64(300)))
+			Expect(res.TrainingRuns).To(HaveLen(1))
+			Expect(res.TrainingRuns[0].Directory).To(Equal("my-model"))
+			Expect(res.TrainingRuns[0].Checkpoints[0].StudyCount).To(Equal(2))
+		})
+
+		It("returns internal_error when listing fails", func() {
+			manager.usageErr = errors.New("permission denied")
+
+			_, err := svc.Usage(ctx)
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("ApplyRetention", func() {
+		It("applies the policy with the requested dry-run mode", func() {
+			manager.policy = &model.RetentionConfig{KeepLatest: 3}
+			manager.result = model.RetentionResult{
+				Deleted:    []model.RetentionCandidate{{Path: "my-model/forest/a.safetensors", Bytes: 42}},
+				FreedBytes: 42,
+				Errors:     []string{},
+			}
+
+			res, err := svc.ApplyRetention(ctx, &genstorage.ApplyRetentionPayload{DryRun: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*manager.dryRunArg).To(BeTrue())
+			Expect(res.DryRun).To(BeTrue())
+			Expect(res.Deleted).To(HaveLen(1))
+			Expect(res.Deleted[0].Path).To(Equal("my-model/forest/a.safetensors"))
+			Expect(res.FreedBytes).To(Equal(int64(42)))
+		})
+
+		It("returns service_unavailable without a policy", func() {
+			_, err := svc.ApplyRetention(ctx, &genstorage.ApplyRetentionPayload{DryRun: true})
+			Expect(err).To(HaveOccurred())
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("service_unavailable"))
+			Expect(manager.dryRunArg).To(BeNil())
+		})
+	})
+})
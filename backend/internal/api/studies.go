@@ -12,7 +12,7 @@ import (
 
 // TrainingRunDiscoverer returns training runs for a given discovery source.
 type TrainingRunDiscoverer interface {
-	Discover() ([]model.TrainingRun, error)
+	Discover(ctx context.Context) ([]model.TrainingRun, error)
 }
 
 // StudiesService implements the generated studies service interface.
@@ -27,10 +27,17 @@ func NewStudiesService(svc *service.StudyService, availability *service.StudyAva
 	return &StudiesService{svc: svc, availability: availability, discovery: discovery}
 }
 
-// List returns all saved studies.
-func (s *StudiesService) List(ctx context.Context) ([]*genstudies.StudyResponse, error) {
-	studies, err := s.svc.List()
+// List returns the saved studies of the requested owner.
+func (s *StudiesService) List(ctx context.Context, p *genstudies.ListPayload) ([]*genstudies.StudyResponse, error) {
+	var owner string
+	if p.Owner != nil {
+		owner = *p.Owner
+	}
+	studies, err := s.svc.ListByOwner(ctx, owner)
 	if err != nil {
+		if isForbidden(err) {
+			return nil, genstudies.MakeForbidden(err)
+		}
 		return nil, genstudies.MakeInternalError(fmt.Errorf("listing studies: %w", err))
 	}
 	result := make([]*genstudies.StudyResponse, len(studies))
@@ -42,42 +49,29 @@ func (s *StudiesService) List(ctx context.Context) ([]*genstudies.StudyResponse,
 
 // Create creates a new study.
 func (s *StudiesService) Create(ctx context.Context, p *genstudies.CreateStudyPayload) (*genstudies.StudyResponse, error) {
-	prompts := make([]model.NamedPrompt, len(p.Prompts))
-	for i, np := range p.Prompts {
-		prompts[i] = model.NamedPrompt{
-			Name: np.Name,
-			Text: np.Text,
-		}
-	}
-
-	pairs := make([]model.SamplerSchedulerPair, len(p.SamplerSchedulerPairs))
-	for i, pair := range p.SamplerSchedulerPairs {
-		pairs[i] = model.SamplerSchedulerPair{
-			Sampler:   pair.Sampler,
-			Scheduler: pair.Scheduler,
-		}
-	}
-
-	var shift *float64
-	if p.Shift != nil {
-		shift = p.Shift
-	}
-
+	st := createPayloadToStudy(p)
 	study, err := s.svc.Create(
-		p.Name,
-		p.PromptPrefix,
-		prompts,
-		p.NegativePrompt,
-		p.Steps,
-		p.Cfgs,
-		pairs,
-		p.Seeds,
-		p.Width,
-		p.Height,
-		p.WorkflowTemplate,
-		p.Vae,
-		p.TextEncoder,
-		shift,
+		ctx,
+		st.Name,
+		st.PromptPrefix,
+		st.Prompts,
+		st.NegativePrompt,
+		st.Steps,
+		st.CFGs,
+		st.SamplerSchedulerPairs,
+		st.Seeds,
+		st.Width,
+		st.Height,
+		st.WorkflowTemplate,
+		st.VAE,
+		st.TextEncoder,
+		st.Shift,
+		st.InputImage,
+		st.DenoiseStrengths,
+		st.Wildcards,
+		st.SeedMode,
+		st.RandomSeedCount,
+		st.Resolutions,
 	)
 	if err != nil {
 		return nil, genstudies.MakeInvalidPayload(fmt.Errorf("creating study: %w", err))
@@ -89,10 +83,7 @@ func (s *StudiesService) Create(ctx context.Context, p *genstudies.CreateStudyPa
 func (s *StudiesService) Update(ctx context.Context, p *genstudies.UpdateStudyPayload) (*genstudies.StudyResponse, error) {
 	prompts := make([]model.NamedPrompt, len(p.Prompts))
 	for i, np := range p.Prompts {
-		prompts[i] = model.NamedPrompt{
-			Name: np.Name,
-			Text: np.Text,
-		}
+		prompts[i] = namedPromptToModel(np)
 	}
 
 	pairs := make([]model.SamplerSchedulerPair, len(p.SamplerSchedulerPairs))
@@ -109,6 +100,7 @@ func (s *StudiesService) Update(ctx context.Context, p *genstudies.UpdateStudyPa
 	}
 
 	study, err := s.svc.Update(
+		ctx,
 		p.ID,
 		p.Name,
 		p.PromptPrefix,
@@ -124,11 +116,20 @@ func (s *StudiesService) Update(ctx context.Context, p *genstudies.UpdateStudyPa
 		p.Vae,
 		p.TextEncoder,
 		updateShift,
+		p.InputImage,
+		p.DenoiseStrengths,
+		wildcardsToModel(p.Wildcards),
+		model.SeedMode(p.SeedMode),
+		p.RandomSeedCount,
+		resolutionsToModel(p.Resolutions),
 	)
 	if err != nil {
 		if isNotFound(err) {
 			return nil, genstudies.MakeNotFound(err)
 		}
+		if isForbidden(err) {
+			return nil, genstudies.MakeForbidden(err)
+		}
 		return nil, genstudies.MakeInvalidPayload(fmt.Errorf("updating study: %w", err))
 	}
 	return studyToResponse(study), nil
@@ -138,10 +139,7 @@ func (s *StudiesService) Update(ctx context.Context, p *genstudies.UpdateStudyPa
 func (s *StudiesService) Fork(ctx context.Context, p *genstudies.ForkStudyPayload) (*genstudies.StudyResponse, error) {
 	prompts := make([]model.NamedPrompt, len(p.Prompts))
 	for i, np := range p.Prompts {
-		prompts[i] = model.NamedPrompt{
-			Name: np.Name,
-			Text: np.Text,
-		}
+		prompts[i] = namedPromptToModel(np)
 	}
 
 	pairs := make([]model.SamplerSchedulerPair, len(p.SamplerSchedulerPairs))
@@ -158,6 +156,7 @@ func (s *StudiesService) Fork(ctx context.Context, p *genstudies.ForkStudyPayloa
 	}
 
 	study, err := s.svc.Fork(
+		ctx,
 		p.SourceID,
 		p.Name,
 		p.PromptPrefix,
@@ -173,6 +172,12 @@ func (s *StudiesService) Fork(ctx context.Context, p *genstudies.ForkStudyPayloa
 		p.Vae,
 		p.TextEncoder,
 		forkShift,
+		p.InputImage,
+		p.DenoiseStrengths,
+		wildcardsToModel(p.Wildcards),
+		model.SeedMode(p.SeedMode),
+		p.RandomSeedCount,
+		resolutionsToModel(p.Resolutions),
 	)
 	if err != nil {
 		if isNotFound(err) {
@@ -183,9 +188,138 @@ func (s *StudiesService) Fork(ctx context.Context, p *genstudies.ForkStudyPayloa
 	return studyToResponse(study), nil
 }
 
+// Duplicate creates a copy of a study with all of its settings.
+func (s *StudiesService) Duplicate(ctx context.Context, p *genstudies.DuplicatePayload) (*genstudies.StudyResponse, error) {
+	var newName string
+	if p.Name != nil {
+		newName = *p.Name
+	}
+	study, err := s.svc.Duplicate(ctx, p.ID, newName)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genstudies.MakeNotFound(err)
+		}
+		return nil, genstudies.MakeInvalidPayload(fmt.Errorf("duplicating study: %w", err))
+	}
+	return studyToResponse(study), nil
+}
+
+// Versions returns the version history of a study, oldest first.
+func (s *StudiesService) Versions(ctx context.Context, p *genstudies.VersionsPayload) ([]*genstudies.StudyVersionResponse, error) {
+	versions, err := s.svc.Versions(ctx, p.ID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genstudies.MakeNotFound(err)
+		}
+		return nil, genstudies.MakeInternalError(fmt.Errorf("listing study versions: %w", err))
+	}
+	result := make([]*genstudies.StudyVersionResponse, len(versions))
+	for i, v := range versions {
+		resp := &genstudies.StudyVersionResponse{
+			Version: v.Version,
+			Current: i == len(versions)-1,
+			Study:   studyToResponse(v.Study),
+		}
+		if !v.CreatedAt.IsZero() {
+			archivedAt := v.CreatedAt.UTC().Format(time.RFC3339)
+			resp.ArchivedAt = &archivedAt
+		}
+		result[i] = resp
+	}
+	return result, nil
+}
+
+// ShowVersion returns a study as it was at a version.
+func (s *StudiesService) ShowVersion(ctx context.Context, p *genstudies.ShowVersionPayload) (*genstudies.StudyResponse, error) {
+	study, err := s.svc.Version(ctx, p.ID, p.Version)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genstudies.MakeNotFound(err)
+		}
+		return nil, genstudies.MakeInternalError(fmt.Errorf("fetching study version: %w", err))
+	}
+	return studyToResponse(study), nil
+}
+
+// Import replaces a study's parameter lists with those in an uploaded CSV or
+// JSON file, or creates a new study from it when a name is given.
+func (s *StudiesService) Import(ctx context.Context, p *genstudies.ImportPayload) (*genstudies.StudyResponse, error) {
+	imp, err := service.ParseStudyImport(model.StudyImportFormat(p.Format), []byte(p.Data))
+	if err != nil {
+		return nil, genstudies.MakeInvalidPayload(err)
+	}
+	var newName string
+	if p.Name != nil {
+		newName = *p.Name
+	}
+	study, err := s.svc.Import(ctx, p.ID, newName, imp)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genstudies.MakeNotFound(err)
+		}
+		if isForbidden(err) {
+			return nil, genstudies.MakeForbidden(err)
+		}
+		return nil, genstudies.MakeInvalidPayload(fmt.Errorf("importing study parameters: %w", err))
+	}
+	return studyToResponse(study), nil
+}
+
+// Export returns a study, or all studies, as a portable document.
+func (s *StudiesService) Export(ctx context.Context, p *genstudies.ExportPayload) (*genstudies.StudyExportDocument, error) {
+	var id string
+	if p.ID != nil {
+		id = *p.ID
+	}
+	studies, err := s.svc.Export(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, genstudies.MakeNotFound(err)
+		}
+		return nil, genstudies.MakeInternalError(fmt.Errorf("exporting studies: %w", err))
+	}
+	doc := &genstudies.StudyExportDocument{
+		FormatVersion: model.ExportFormatVersion,
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		Studies:       make([]*genstudies.CreateStudyPayload, len(studies)),
+	}
+	for i, study := range studies {
+		doc.Studies[i] = studyToCreatePayload(study)
+	}
+	return doc, nil
+}
+
+// ImportStudies creates the studies of an exported document.
+func (s *StudiesService) ImportStudies(ctx context.Context, p *genstudies.ImportStudiesPayload) ([]*genstudies.StudyImportResult, error) {
+	if err := checkExportFormatVersion(p.FormatVersion); err != nil {
+		return nil, genstudies.MakeInvalidPayload(err)
+	}
+	studies := make([]model.Study, len(p.Studies))
+	for i, study := range p.Studies {
+		studies[i] = createPayloadToStudy(study)
+	}
+	entries, err := s.svc.ImportStudies(ctx, studies, model.ImportConflictPolicy(p.OnConflict))
+	if err != nil {
+		if isForbidden(err) {
+			return nil, genstudies.MakeForbidden(err)
+		}
+		return nil, genstudies.MakeInvalidPayload(fmt.Errorf("importing studies: %w", err))
+	}
+	result := make([]*genstudies.StudyImportResult, len(entries))
+	for i, e := range entries {
+		result[i] = &genstudies.StudyImportResult{
+			SourceName: e.SourceName,
+			ID:         e.ID,
+			Name:       e.Name,
+			Action:     string(e.Action),
+		}
+	}
+	return result, nil
+}
+
 // HasSamples checks whether a study has generated samples on disk.
 func (s *StudiesService) HasSamples(ctx context.Context, p *genstudies.HasSamplesPayload) (*genstudies.HasSamplesResponse, error) {
-	hasSamples, err := s.svc.HasSamples(p.ID)
+	hasSamples, err := s.svc.HasSamples(ctx, p.ID)
 	if err != nil {
 		if isNotFound(err) {
 			return nil, genstudies.MakeNotFound(err)
@@ -199,11 +333,14 @@ func (s *StudiesService) HasSamples(ctx context.Context, p *genstudies.HasSample
 
 // Delete removes a study. When p.DeleteData is true, also removes the study's sample output directory.
 func (s *StudiesService) Delete(ctx context.Context, p *genstudies.DeletePayload) error {
-	err := s.svc.Delete(p.ID, p.DeleteData)
+	err := s.svc.Delete(ctx, p.ID, p.DeleteData)
 	if err != nil {
 		if isNotFound(err) {
 			return genstudies.MakeNotFound(err)
 		}
+		if isForbidden(err) {
+			return genstudies.MakeForbidden(err)
+		}
 		return genstudies.MakeInternalError(fmt.Errorf("deleting study: %w", err))
 	}
 	return nil
@@ -213,7 +350,7 @@ func (s *StudiesService) Delete(ctx context.Context, p *genstudies.DeletePayload
 // For each training run, it reports how many checkpoints have sample directories.
 func (s *StudiesService) AffectedRuns(ctx context.Context, p *genstudies.AffectedRunsPayload) ([]*genstudies.AffectedRunResponse, error) {
 	// Verify the study exists
-	study, err := s.svc.Get(p.ID)
+	study, err := s.svc.Get(ctx, p.ID)
 	if err != nil {
 		if isNotFound(err) {
 			return nil, genstudies.MakeNotFound(err)
@@ -222,7 +359,7 @@ func (s *StudiesService) AffectedRuns(ctx context.Context, p *genstudies.Affecte
 	}
 
 	// Discover all training runs
-	runs, err := s.discovery.Discover()
+	runs, err := s.discovery.Discover(ctx)
 	if err != nil {
 		return nil, genstudies.MakeInternalError(fmt.Errorf("discovering training runs: %w", err))
 	}
@@ -254,12 +391,12 @@ func (s *StudiesService) AffectedRuns(ctx context.Context, p *genstudies.Affecte
 
 // Availability returns per-study sample availability for a given training run.
 func (s *StudiesService) Availability(ctx context.Context, p *genstudies.AvailabilityPayload) ([]*genstudies.StudyAvailabilityResponse, error) {
-	studies, err := s.svc.List()
+	studies, err := s.svc.ListByOwner(ctx, "")
 	if err != nil {
 		return nil, genstudies.MakeInternalError(fmt.Errorf("listing studies: %w", err))
 	}
 
-	runs, err := s.discovery.Discover()
+	runs, err := s.discovery.Discover(ctx)
 	if err != nil {
 		return nil, genstudies.MakeInternalError(fmt.Errorf("discovering training runs: %w", err))
 	}
@@ -290,13 +427,115 @@ func (s *StudiesService) Availability(ctx context.Context, p *genstudies.Availab
 	return result, nil
 }
 
+// createPayloadToStudy converts the settings of a study payload, e.g. a study
+// of an import document.
+func createPayloadToStudy(p *genstudies.CreateStudyPayload) model.Study {
+	prompts := make([]model.NamedPrompt, len(p.Prompts))
+	for i, np := range p.Prompts {
+		prompts[i] = namedPromptToModel(np)
+	}
+
+	pairs := make([]model.SamplerSchedulerPair, len(p.SamplerSchedulerPairs))
+	for i, pair := range p.SamplerSchedulerPairs {
+		pairs[i] = model.SamplerSchedulerPair{
+			Sampler:   pair.Sampler,
+			Scheduler: pair.Scheduler,
+		}
+	}
+
+	return model.Study{
+		Name:                  p.Name,
+		PromptPrefix:          p.PromptPrefix,
+		Prompts:               prompts,
+		NegativePrompt:        p.NegativePrompt,
+		Steps:                 p.Steps,
+		CFGs:                  p.Cfgs,
+		SamplerSchedulerPairs: pairs,
+		Seeds:                 p.Seeds,
+		Width:                 p.Width,
+		Height:                p.Height,
+		WorkflowTemplate:      p.WorkflowTemplate,
+		VAE:                   p.Vae,
+		TextEncoder:           p.TextEncoder,
+		Shift:                 p.Shift,
+		InputImage:            p.InputImage,
+		DenoiseStrengths:      p.DenoiseStrengths,
+		Wildcards:             wildcardsToModel(p.Wildcards),
+		Resolutions:           resolutionsToModel(p.Resolutions),
+		SeedMode:              model.SeedMode(p.SeedMode),
+		RandomSeedCount:       p.RandomSeedCount,
+	}
+}
+
+// studyToCreatePayload converts the settings of a study for an export
+// document, leaving out its ID, version, and timestamps.
+func studyToCreatePayload(s model.Study) *genstudies.CreateStudyPayload {
+	resp := studyToResponse(s)
+	return &genstudies.CreateStudyPayload{
+		Name:                  resp.Name,
+		PromptPrefix:          resp.PromptPrefix,
+		Prompts:               resp.Prompts,
+		NegativePrompt:        resp.NegativePrompt,
+		Steps:                 resp.Steps,
+		Cfgs:                  resp.Cfgs,
+		SamplerSchedulerPairs: resp.SamplerSchedulerPairs,
+		Seeds:                 resp.Seeds,
+		SeedMode:              resp.SeedMode,
+		RandomSeedCount:       resp.RandomSeedCount,
+		Width:                 resp.Width,
+		Height:                resp.Height,
+		WorkflowTemplate:      resp.WorkflowTemplate,
+		Vae:                   resp.Vae,
+		TextEncoder:           resp.TextEncoder,
+		Shift:                 resp.Shift,
+		InputImage:            resp.InputImage,
+		DenoiseStrengths:      resp.DenoiseStrengths,
+		Wildcards:             resp.Wildcards,
+		Resolutions:           resp.Resolutions,
+	}
+}
+
+// namedPromptToModel converts a prompt of a study payload.
+func namedPromptToModel(np *genstudies.NamedPrompt) model.NamedPrompt {
+	prompt := model.NamedPrompt{
+		Name:  np.Name,
+		Text:  np.Text,
+		Steps: np.Steps,
+		CFGs:  np.Cfgs,
+		Seeds: np.Seeds,
+	}
+	if np.Width != nil {
+		prompt.Width = *np.Width
+	}
+	if np.Height != nil {
+		prompt.Height = *np.Height
+	}
+	return prompt
+}
+
+// namedPromptToResponse converts a prompt of a study, omitting the overrides
+// it does not set.
+func namedPromptToResponse(np model.NamedPrompt) *genstudies.NamedPrompt {
+	resp := &genstudies.NamedPrompt{
+		Name:  np.Name,
+		Text:  np.Text,
+		Steps: np.Steps,
+		Cfgs:  np.CFGs,
+		Seeds: np.Seeds,
+	}
+	if np.Width > 0 {
+		resp.Width = &np.Width
+	}
+	if np.Height > 0 {
+		resp.Height = &np.Height
+	}
+	return resp
+}
+
 func studyToResponse(s model.Study) *genstudies.StudyResponse {
 	prompts := make([]*genstudies.NamedPrompt, len(s.Prompts))
 	for i, np := range s.Prompts {
-		prompts[i] = &genstudies.NamedPrompt{
-			Name: np.Name,
-			Text: np.Text,
-		}
+		prompts[i] = namedPromptToResponse(np)
 	}
 
 	pairs := make([]*genstudies.SamplerSchedulerPair, len(s.SamplerSchedulerPairs))
@@ -307,24 +546,91 @@ func studyToResponse(s model.Study) *genstudies.StudyResponse {
 		}
 	}
 
-	return &genstudies.StudyResponse{
+	resp := &genstudies.StudyResponse{
 		ID:                    s.ID,
 		Name:                  s.Name,
+		Version:               s.Version,
 		PromptPrefix:          s.PromptPrefix,
 		Prompts:               prompts,
 		NegativePrompt:        s.NegativePrompt,
 		Steps:                 s.Steps,
 		Cfgs:                  s.CFGs,
 		SamplerSchedulerPairs: pairs,
-		Seeds:                 s.Seeds,
+		Seeds:                 seeds(s.Seeds),
+		SeedMode:              string(s.SeedMode),
+		RandomSeedCount:       s.RandomSeedCount,
 		Width:                 s.Width,
 		Height:                s.Height,
 		WorkflowTemplate:      s.WorkflowTemplate,
 		Vae:                   s.VAE,
 		TextEncoder:           s.TextEncoder,
 		Shift:                 s.Shift,
+		InputImage:            s.InputImage,
+		DenoiseStrengths:      denoiseStrengths(s.DenoiseStrengths),
+		Wildcards:             wildcardsToResponse(s.Wildcards),
+		Resolutions:           resolutionsToResponse(s.Resolutions),
 		ImagesPerCheckpoint:   s.ImagesPerCheckpoint(),
 		CreatedAt:             s.CreatedAt.UTC().Format(time.RFC3339),
 		UpdatedAt:             s.UpdatedAt.UTC().Format(time.RFC3339),
 	}
+	if s.Owner != "" {
+		resp.Owner = &s.Owner
+	}
+	return resp
+}
+
+// seeds returns the seed list, or an empty list if it is nil, since seeds is a
+// required response field and random seed modes have no seeds.
+func seeds(list []int64) []int64 {
+	if list == nil {
+		return []int64{}
+	}
+	return list
+}
+
+// denoiseStrengths returns strengths, or an empty list if it is nil, since
+// denoise_strengths is a required response field.
+func denoiseStrengths(strengths []float64) []float64 {
+	if strengths == nil {
+		return []float64{}
+	}
+	return strengths
+}
+
+// wildcardsToModel converts the wildcards of a study payload.
+func wildcardsToModel(wildcards []*genstudies.Wildcard) []model.Wildcard {
+	var result []model.Wildcard
+	for _, w := range wildcards {
+		result = append(result, model.Wildcard{Name: w.Name, Values: w.Values})
+	}
+	return result
+}
+
+// wildcardsToResponse converts the wildcards of a study; the list is empty
+// rather than nil since wildcards is a required response field.
+func wildcardsToResponse(wildcards []model.Wildcard) []*genstudies.Wildcard {
+	result := make([]*genstudies.Wildcard, len(wildcards))
+	for i, w := range wildcards {
+		result[i] = &genstudies.Wildcard{Name: w.Name, Values: w.Values}
+	}
+	return result
+}
+
+// resolutionsToModel converts the resolutions of a study payload.
+func resolutionsToModel(resolutions []*genstudies.Resolution) []model.Resolution {
+	var result []model.Resolution
+	for _, r := range resolutions {
+		result = append(result, model.Resolution{Width: r.Width, Height: r.Height})
+	}
+	return result
+}
+
+// resolutionsToResponse converts the resolutions of a study; the list is
+// empty rather than nil since resolutions is a required response field.
+func resolutionsToResponse(resolutions []model.Resolution) []*genstudies.Resolution {
+	result := make([]*genstudies.Resolution, len(resolutions))
+	for i, r := range resolutions {
+		result[i] = &genstudies.Resolution{Width: r.Width, Height: r.Height}
+	}
+	return result
 }
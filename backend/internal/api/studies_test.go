@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"io"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -29,6 +30,7 @@ func (f *fakeSampleCheckerAPI) StudyHasSamples(study model.Study) (bool, error)
 // fakeStudyStoreAPI is an in-memory test double for service.StudyStore.
 type fakeStudyStoreAPI struct {
 	studies   map[string]model.Study
+	versions  map[string][]model.StudyVersion
 	listErr   error
 	createErr error
 	updateErr error
@@ -39,7 +41,7 @@ func newFakeStudyStoreAPI() *fakeStudyStoreAPI {
 	return &fakeStudyStoreAPI{studies: make(map[string]model.Study)}
 }
 
-func (f *fakeStudyStoreAPI) ListStudies() ([]model.Study, error) {
+func (f *fakeStudyStoreAPI) ListStudies(ctx context.Context) ([]model.Study, error) {
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
@@ -50,7 +52,7 @@ func (f *fakeStudyStoreAPI) ListStudies() ([]model.Study, error) {
 	return result, nil
 }
 
-func (f *fakeStudyStoreAPI) GetStudy(id string) (model.Study, error) {
+func (f *fakeStudyStoreAPI) GetStudy(ctx context.Context, id string) (model.Study, error) {
 	p, ok := f.studies[id]
 	if !ok {
 		return model.Study{}, sql.ErrNoRows
@@ -58,7 +60,7 @@ func (f *fakeStudyStoreAPI) GetStudy(id string) (model.Study, error) {
 	return p, nil
 }
 
-func (f *fakeStudyStoreAPI) CreateStudy(p model.Study) error {
+func (f *fakeStudyStoreAPI) CreateStudy(ctx context.Context, p model.Study) error {
 	if f.createErr != nil {
 		return f.createErr
 	}
@@ -66,7 +68,7 @@ func (f *fakeStudyStoreAPI) CreateStudy(p model.Study) error {
 	return nil
 }
 
-func (f *fakeStudyStoreAPI) UpdateStudy(p model.Study) error {
+func (f *fakeStudyStoreAPI) UpdateStudy(ctx context.Context, p model.Study) error {
 	if f.updateErr != nil {
 		return f.updateErr
 	}
@@ -77,7 +79,7 @@ func (f *fakeStudyStoreAPI) UpdateStudy(p model.Study) error {
 	return nil
 }
 
-func (f *fakeStudyStoreAPI) GetStudyByName(name string, excludeID string) (model.Study, error) {
+func (f *fakeStudyStoreAPI) GetStudyByName(ctx context.Context, name string, excludeID string) (model.Study, error) {
 	for _, p := range f.studies {
 		if p.Name == name && p.ID != excludeID {
 			return p, nil
@@ -86,7 +88,7 @@ func (f *fakeStudyStoreAPI) GetStudyByName(name string, excludeID string) (model
 	return model.Study{}, sql.ErrNoRows
 }
 
-func (f *fakeStudyStoreAPI) DeleteStudy(id string) error {
+func (f *fakeStudyStoreAPI) DeleteStudy(ctx context.Context, id string) error {
 	if f.deleteErr != nil {
 		return f.deleteErr
 	}
@@ -97,13 +99,25 @@ func (f *fakeStudyStoreAPI) DeleteStudy(id string) error {
 	return nil
 }
 
+func (f *fakeStudyStoreAPI) ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error) {
+	return f.versions[studyID], nil
+}
+
+func (f *fakeStudyStoreAPI) CreateStudyVersion(ctx context.Context, v model.StudyVersion) error {
+	return nil
+}
+
+func (f *fakeStudyStoreAPI) StudyVersionInUse(ctx context.Context, studyID string, version int) (bool, error) {
+	return false, nil
+}
+
 // fakeDiscoverer implements api.TrainingRunDiscoverer for testing.
 type fakeDiscoverer struct {
 	runs []model.TrainingRun
 	err  error
 }
 
-func (f *fakeDiscoverer) Discover() ([]model.TrainingRun, error) {
+func (f *fakeDiscoverer) Discover(ctx context.Context) ([]model.TrainingRun, error) {
 	return f.runs, f.err
 }
 
@@ -153,7 +167,7 @@ var _ = Describe("StudiesService", func() {
 	Describe("Error responses include Goa ServiceError structure", func() {
 		It("List returns ServiceError with proper fields on store failure", func() {
 			store.listErr = errors.New("database connection failed")
-			_, err := studies.List(ctx)
+			_, err := studies.List(ctx, &genstudies.ListPayload{})
 			Expect(err).To(HaveOccurred())
 
 			// Verify it's a Goa ServiceError with proper structure
@@ -188,6 +202,205 @@ var _ = Describe("StudiesService", func() {
 		})
 	})
 
+	Describe("Import", func() {
+		BeforeEach(func() {
+			store.studies["study-1"] = model.Study{
+				ID:                    "study-1",
+				Name:                  "Base",
+				Prompts:               []model.NamedPrompt{{Name: "p", Text: "a cat"}},
+				Steps:                 []int{20},
+				CFGs:                  []float64{7},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}},
+				Seeds:                 []int64{1},
+				Width:                 1024,
+				Height:                1024,
+			}
+		})
+
+		It("updates the study from a CSV file", func() {
+			res, err := studies.Import(ctx, &genstudies.ImportPayload{
+				ID:     "study-1",
+				Format: "csv",
+				Data:   "seeds\n420\n421\n",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.ID).To(Equal("study-1"))
+			Expect(res.Seeds).To(Equal([]int64{420, 421}))
+			Expect(res.ImagesPerCheckpoint).To(Equal(2))
+		})
+
+		It("returns invalid_payload naming the offending row", func() {
+			_, err := studies.Import(ctx, &genstudies.ImportPayload{
+				ID:     "study-1",
+				Format: "csv",
+				Data:   "seeds\n420\nnope\n",
+			})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+			Expect(err.Error()).To(ContainSubstring("row 3"))
+		})
+
+		It("returns not_found for an unknown study", func() {
+			_, err := studies.Import(ctx, &genstudies.ImportPayload{
+				ID:     "missing",
+				Format: "json",
+				Data:   `[{"seeds": 1}]`,
+			})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+	})
+
+	Describe("versions", func() {
+		archivedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		BeforeEach(func() {
+			study := model.Study{
+				ID:                    "study-1",
+				Name:                  "Base",
+				Version:               2,
+				Prompts:               []model.NamedPrompt{{Name: "p", Text: "a cat"}},
+				Steps:                 []int{30},
+				CFGs:                  []float64{7},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}},
+				Seeds:                 []int64{1},
+				Width:                 1024,
+				Height:                1024,
+			}
+			store.studies["study-1"] = study
+			v1 := study
+			v1.Version = 1
+			v1.Steps = []int{20}
+			store.versions = map[string][]model.StudyVersion{
+				"study-1": {{StudyID: "study-1", Version: 1, Study: v1, CreatedAt: archivedAt}},
+			}
+		})
+
+		It("lists archived versions followed by the current one", func() {
+			res, err := studies.Versions(ctx, &genstudies.VersionsPayload{ID: "study-1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(2))
+			Expect(res[0].Version).To(Equal(1))
+			Expect(res[0].Current).To(BeFalse())
+			Expect(res[0].ArchivedAt).To(HaveValue(Equal("2025-01-02T03:04:05Z")))
+			Expect(res[0].Study.Steps).To(Equal([]int{20}))
+			Expect(res[1].Version).To(Equal(2))
+			Expect(res[1].Current).To(BeTrue())
+			Expect(res[1].ArchivedAt).To(BeNil())
+			Expect(res[1].Study.Version).To(Equal(2))
+		})
+
+		It("shows a study as it was at a version", func() {
+			res, err := studies.ShowVersion(ctx, &genstudies.ShowVersionPayload{ID: "study-1", Version: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Version).To(Equal(1))
+			Expect(res.Steps).To(Equal([]int{20}))
+		})
+
+		It("returns not_found for an unknown version", func() {
+			_, err := studies.ShowVersion(ctx, &genstudies.ShowVersionPayload{ID: "study-1", Version: 5})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("duplicates a study under a default name", func() {
+			res, err := studies.Duplicate(ctx, &genstudies.DuplicatePayload{ID: "study-1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.ID).NotTo(Equal("study-1"))
+			Expect(res.Name).To(Equal("Base copy"))
+			Expect(res.Version).To(Equal(1))
+			Expect(res.Steps).To(Equal([]int{30}))
+		})
+
+		It("returns not_found when duplicating an unknown study", func() {
+			_, err := studies.Duplicate(ctx, &genstudies.DuplicatePayload{ID: "missing"})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+	})
+
+	Describe("export and import", func() {
+		BeforeEach(func() {
+			shift := 3.0
+			store.studies["study-1"] = model.Study{
+				ID:                    "study-1",
+				Name:                  "Base",
+				Version:               4,
+				Prompts:               []model.NamedPrompt{{Name: "p", Text: "a cat", Steps: []int{8}}},
+				Steps:                 []int{30},
+				CFGs:                  []float64{7},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}},
+				SeedMode:              model.SeedModeRandomPerItem,
+				RandomSeedCount:       2,
+				Width:                 1024,
+				Height:                1024,
+				Shift:                 &shift,
+				Wildcards:             []model.Wildcard{{Name: "animal", Values: []string{"cat", "dog"}}},
+				Resolutions:           []model.Resolution{{Width: 832, Height: 1216}},
+			}
+		})
+
+		It("exports a study without its ID or version", func() {
+			id := "study-1"
+			doc, err := studies.Export(ctx, &genstudies.ExportPayload{ID: &id})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(doc.FormatVersion).To(Equal(model.ExportFormatVersion))
+			Expect(doc.ExportedAt).NotTo(BeEmpty())
+			Expect(doc.Studies).To(HaveLen(1))
+			Expect(doc.Studies[0].Name).To(Equal("Base"))
+			Expect(doc.Studies[0].SeedMode).To(Equal("random_per_item"))
+			Expect(doc.Studies[0].Wildcards).To(HaveLen(1))
+		})
+
+		It("imports an exported document as a renamed copy", func() {
+			doc, err := studies.Export(ctx, &genstudies.ExportPayload{})
+			Expect(err).NotTo(HaveOccurred())
+
+			res, err := studies.ImportStudies(ctx, &genstudies.ImportStudiesPayload{
+				FormatVersion: doc.FormatVersion,
+				Studies:       doc.Studies,
+				OnConflict:    "rename",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(1))
+			Expect(res[0].SourceName).To(Equal("Base"))
+			Expect(res[0].Name).To(Equal("Base 2"))
+			Expect(res[0].Action).To(Equal("renamed"))
+
+			imported := store.studies[res[0].ID]
+			original := store.studies["study-1"]
+			Expect(imported.Version).To(Equal(1))
+			Expect(imported.Prompts).To(Equal(original.Prompts))
+			Expect(imported.SeedMode).To(Equal(original.SeedMode))
+			Expect(imported.RandomSeedCount).To(Equal(original.RandomSeedCount))
+			Expect(imported.Shift).To(HaveValue(Equal(3.0)))
+			Expect(imported.Wildcards).To(Equal(original.Wildcards))
+			Expect(imported.Resolutions).To(Equal(original.Resolutions))
+		})
+
+		It("returns not_found when exporting an unknown study", func() {
+			id := "missing"
+			_, err := studies.Export(ctx, &genstudies.ExportPayload{ID: &id})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("returns invalid_payload for a newer format version", func() {
+			_, err := studies.ImportStudies(ctx, &genstudies.ImportStudiesPayload{
+				FormatVersion: model.ExportFormatVersion + 1,
+				OnConflict:    "rename",
+			})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue())
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+		})
+	})
+
 	Describe("Availability", func() {
 		var (
 			availStore   *fakeStudyStoreAPI
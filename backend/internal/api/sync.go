@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	gensync "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sync"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// syncDeletedEntities maps change log entities to the entity names of the
+// sync API.
+var syncDeletedEntities = map[model.ChangeEntity]string{
+	model.ChangeEntitySampleJob:      "job",
+	model.ChangeEntitySampleJobItems: "job_items",
+	model.ChangeEntityStudy:          "study",
+	model.ChangeEntityPreset:         "preset",
+	model.ChangeEntityImageDir:       "image_dir",
+}
+
+// SyncService implements the generated sync service interface.
+type SyncService struct {
+	svc *service.SyncService
+}
+
+// NewSyncService returns a new SyncService.
+func NewSyncService(svc *service.SyncService) *SyncService {
+	return &SyncService{svc: svc}
+}
+
+// Changes returns the entities that changed after the given cursor.
+func (s *SyncService) Changes(ctx context.Context, p *gensync.ChangesPayload) (*gensync.SyncResponse, error) {
+	limit := 0
+	if p.Limit != nil {
+		limit = *p.Limit
+	}
+	result, err := s.svc.Since(ctx, p.Since, limit)
+	if err != nil {
+		return nil, gensync.MakeInternalError(fmt.Errorf("syncing changes: %w", err))
+	}
+	return syncResultToResponse(result), nil
+}
+
+func syncResultToResponse(r model.SyncResult) *gensync.SyncResponse {
+	resp := &gensync.SyncResponse{
+		Cursor:    r.Cursor,
+		More:      r.More,
+		Jobs:      make([]*gensync.SyncSampleJob, len(r.Jobs)),
+		JobItems:  make([]*gensync.SyncJobItemCounts, 0, len(r.JobItemCounts)),
+		Studies:   make([]*gensync.SyncEntitySummary, len(r.Studies)),
+		Presets:   make([]*gensync.SyncEntitySummary, len(r.Presets)),
+		ImageDirs: make([]*gensync.SyncImageDir, len(r.ImageDirs)),
+		Deleted:   make([]*gensync.SyncDeletedEntity, len(r.Deleted)),
+	}
+	for i, j := range r.Jobs {
+		job := &gensync.SyncSampleJob{
+			ID:              j.ID,
+			TrainingRunName: j.TrainingRunName,
+			StudyID:         j.StudyID,
+			StudyName:       j.StudyName,
+			Status:          string(j.Status),
+			TotalItems:      j.TotalItems,
+			CompletedItems:  j.CompletedItems,
+			UpdatedAt:       j.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if j.ErrorMessage != "" {
+			msg := j.ErrorMessage
+			job.ErrorMessage = &msg
+		}
+		resp.Jobs[i] = job
+	}
+	for jobID, counts := range r.JobItemCounts {
+		resp.JobItems = append(resp.JobItems, &gensync.SyncJobItemCounts{
+			JobID:     jobID,
+			Completed: counts.Completed,
+			Failed:    counts.Failed,
+			Pending:   counts.Pending,
+		})
+	}
+	sort.Slice(resp.JobItems, func(a, b int) bool { return resp.JobItems[a].JobID < resp.JobItems[b].JobID })
+	for i, st := range r.Studies {
+		resp.Studies[i] = &gensync.SyncEntitySummary{
+			ID:        st.ID,
+			Name:      st.Name,
+			UpdatedAt: st.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	for i, p := range r.Presets {
+		resp.Presets[i] = &gensync.SyncEntitySummary{
+			ID:        p.ID,
+			Name:      p.Name,
+			UpdatedAt: p.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	for i, d := range r.ImageDirs {
+		resp.ImageDirs[i] = &gensync.SyncImageDir{
+			Dir:       d.Dir,
+			Filenames: d.Filenames,
+		}
+	}
+	for i, c := range r.Deleted {
+		resp.Deleted[i] = &gensync.SyncDeletedEntity{
+			Entity: syncDeletedEntities[c.Entity],
+			ID:     c.EntityID,
+		}
+	}
+	return resp
+}
@@ -0,0 +1,152 @@
+package api_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	gensync "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sync"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeSyncStoreAPI implements service.SyncStore for API tests.
+type fakeSyncStoreAPI struct {
+	changes []model.Change
+	jobs    map[string]model.SampleJob
+	studies map[string]model.Study
+	listErr error
+}
+
+func (f *fakeSyncStoreAPI) ListChanges(ctx context.Context, since int64, limit int) ([]model.Change, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	var out []model.Change
+	for _, c := range f.changes {
+		if c.Seq > since && len(out) < limit {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSyncStoreAPI) GetSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
+	j, ok := f.jobs[id]
+	if !ok {
+		return model.SampleJob{}, sql.ErrNoRows
+	}
+	return j, nil
+}
+
+func (f *fakeSyncStoreAPI) CountSampleJobItemsByStatus(ctx context.Context, jobID string) (model.ItemStatusCounts, error) {
+	return model.ItemStatusCounts{Completed: 2, Failed: 1, Pending: 3}, nil
+}
+
+func (f *fakeSyncStoreAPI) GetStudy(ctx context.Context, id string) (model.Study, error) {
+	st, ok := f.studies[id]
+	if !ok {
+		return model.Study{}, sql.ErrNoRows
+	}
+	return st, nil
+}
+
+func (f *fakeSyncStoreAPI) GetPreset(ctx context.Context, id string) (model.Preset, error) {
+	return model.Preset{}, sql.ErrNoRows
+}
+
+func (f *fakeSyncStoreAPI) GetIndexedImageDir(ctx context.Context, dir string) (model.IndexedImageDir, error) {
+	return model.IndexedImageDir{}, sql.ErrNoRows
+}
+
+var _ = Describe("SyncService", func() {
+	var (
+		store  *fakeSyncStoreAPI
+		syncer *api.SyncService
+	)
+
+	BeforeEach(func() {
+		store = &fakeSyncStoreAPI{
+			jobs:    make(map[string]model.SampleJob),
+			studies: make(map[string]model.Study),
+		}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		syncer = api.NewSyncService(service.NewSyncService(store, logger))
+	})
+
+	It("maps changed and deleted entities to the response", func() {
+		updated := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		store.jobs["j1"] = model.SampleJob{
+			ID:              "j1",
+			TrainingRunName: "run",
+			StudyID:         "s1",
+			StudyName:       "Study",
+			Status:          model.SampleJobStatusFailed,
+			TotalItems:      6,
+			CompletedItems:  2,
+			ErrorMessage:    "boom",
+			UpdatedAt:       updated,
+		}
+		store.studies["s1"] = model.Study{ID: "s1", Name: "Study", UpdatedAt: updated}
+		store.changes = []model.Change{
+			{Seq: 1, Entity: model.ChangeEntityStudy, EntityID: "s1"},
+			{Seq: 2, Entity: model.ChangeEntitySampleJob, EntityID: "j1"},
+			{Seq: 3, Entity: model.ChangeEntitySampleJobItems, EntityID: "j1"},
+			{Seq: 4, Entity: model.ChangeEntityPreset, EntityID: "p1"},
+			{Seq: 5, Entity: model.ChangeEntityImageDir, EntityID: "study/cp"},
+		}
+
+		resp, err := syncer.Changes(context.Background(), &gensync.ChangesPayload{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Cursor).To(Equal(int64(5)))
+		Expect(resp.More).To(BeFalse())
+		Expect(resp.Jobs).To(HaveLen(1))
+		Expect(resp.Jobs[0].ID).To(Equal("j1"))
+		Expect(resp.Jobs[0].Status).To(Equal("failed"))
+		Expect(resp.Jobs[0].ErrorMessage).To(HaveValue(Equal("boom")))
+		Expect(resp.Jobs[0].UpdatedAt).To(Equal("2025-01-01T12:00:00Z"))
+		Expect(resp.JobItems).To(Equal([]*gensync.SyncJobItemCounts{{JobID: "j1", Completed: 2, Failed: 1, Pending: 3}}))
+		Expect(resp.Studies).To(Equal([]*gensync.SyncEntitySummary{{ID: "s1", Name: "Study", UpdatedAt: "2025-01-01T12:00:00Z"}}))
+		Expect(resp.Presets).To(BeEmpty())
+		Expect(resp.ImageDirs).To(BeEmpty())
+		Expect(resp.Deleted).To(Equal([]*gensync.SyncDeletedEntity{
+			{Entity: "preset", ID: "p1"},
+			{Entity: "image_dir", ID: "study/cp"},
+		}))
+	})
+
+	It("passes the cursor and limit to the service", func() {
+		store.studies["s1"] = model.Study{ID: "s1"}
+		store.studies["s2"] = model.Study{ID: "s2"}
+		store.changes = []model.Change{
+			{Seq: 1, Entity: model.ChangeEntityStudy, EntityID: "s1"},
+			{Seq: 2, Entity: model.ChangeEntityStudy, EntityID: "s2"},
+			{Seq: 3, Entity: model.ChangeEntityStudy, EntityID: "s3"},
+		}
+		limit := 1
+
+		resp, err := syncer.Changes(context.Background(), &gensync.ChangesPayload{Since: 1, Limit: &limit})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Cursor).To(Equal(int64(2)))
+		Expect(resp.More).To(BeTrue())
+		Expect(resp.Studies).To(HaveLen(1))
+		Expect(resp.Studies[0].ID).To(Equal("s2"))
+	})
+
+	It("returns internal_error when the change log cannot be read", func() {
+		store.listErr = errors.New("database is locked")
+		_, err := syncer.Changes(context.Background(), &gensync.ChangesPayload{})
+		Expect(err).To(HaveOccurred())
+		serviceErr, ok := err.(errorNamer)
+		Expect(ok).To(BeTrue())
+		Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+	})
+})
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"os"
 
@@ -34,7 +35,7 @@ type SampleDirCleaner interface {
 // reset endpoint calls SeedFixtures() after CleanStudyDirs() to restore the
 // fixture state required by E2E tests (e.g. regen-confirmation.spec.ts).
 type FixtureSeeder interface {
-	SeedFixtures() error
+	SeedFixtures(ctx context.Context) error
 }
 
 // MountTestResetEndpoint conditionally registers DELETE /api/test/reset on the
@@ -90,7 +91,7 @@ func MountTestResetEndpoint(mux interface{ Handle(string, string, http.HandlerFu
 		// Seed deterministic fixture data (studies + sample dirs) so that
 		// E2E tests that rely on pre-existing samples start in known-good state.
 		if seeder != nil {
-			if err := seeder.SeedFixtures(); err != nil {
+			if err := seeder.SeedFixtures(r.Context()); err != nil {
 				logger.WithError(err).Error("fixture seeding failed")
 				http.Error(w, "fixture seeding failed", http.StatusInternalServerError)
 				return
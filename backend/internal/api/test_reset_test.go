@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -71,7 +72,7 @@ type fakeFixtureSeeder struct {
 	err    error
 }
 
-func (f *fakeFixtureSeeder) SeedFixtures() error {
+func (f *fakeFixtureSeeder) SeedFixtures(ctx context.Context) error {
 	f.called = true
 	return f.err
 }
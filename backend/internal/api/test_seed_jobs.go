@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -39,7 +40,7 @@ type SeedJobsResponse struct {
 // JobSeeder is the interface required by the seed jobs endpoint.
 // It creates sample jobs directly in the store, bypassing service-layer validation.
 type JobSeeder interface {
-	SeedSampleJobs(jobs []model.SampleJob) error
+	SeedSampleJobs(ctx context.Context, jobs []model.SampleJob) error
 }
 
 // MountTestSeedJobsEndpoint conditionally registers POST /api/test/seed-jobs on the
@@ -94,7 +95,7 @@ func MountTestSeedJobsEndpoint(mux interface{ Handle(string, string, http.Handle
 			jobIDs[i] = id
 		}
 
-		if err := seeder.SeedSampleJobs(jobs); err != nil {
+		if err := seeder.SeedSampleJobs(r.Context(), jobs); err != nil {
 			logger.WithError(err).Error("failed to seed sample jobs")
 			http.Error(w, "failed to seed sample jobs", http.StatusInternalServerError)
 			return
@@ -2,6 +2,7 @@ package api_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -24,7 +25,7 @@ type fakeJobSeeder struct {
 	err        error
 }
 
-func (f *fakeJobSeeder) SeedSampleJobs(jobs []model.SampleJob) error {
+func (f *fakeJobSeeder) SeedSampleJobs(ctx context.Context, jobs []model.SampleJob) error {
 	f.seededJobs = append(f.seededJobs, jobs...)
 	return f.err
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	gentrainingruns "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/training_runs"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
@@ -13,7 +15,12 @@ import (
 
 // StudyGetter defines the interface for fetching a study by ID, used by validation.
 type StudyGetter interface {
-	GetStudy(id string) (model.Study, error)
+	GetStudy(ctx context.Context, id string) (model.Study, error)
+}
+
+// PinSetProvider returns the current pins, used to flag pinned images in scan results.
+type PinSetProvider interface {
+	PinSet(ctx context.Context) (model.PinSet, error)
 }
 
 // TrainingRunsService implements the generated training_runs service interface.
@@ -24,6 +31,10 @@ type TrainingRunsService struct {
 	validator            *service.ValidationService
 	watcher              *service.Watcher
 	studyGetter          StudyGetter
+	pins                 PinSetProvider
+	configs              *service.TrainingRunConfigService
+	changeCurves         *service.ChangeCurveService
+	summaries            *service.TrainingRunSummaryService
 }
 
 // NewTrainingRunsService returns a new TrainingRunsService.
@@ -31,6 +42,30 @@ func NewTrainingRunsService(viewerDiscovery *service.ViewerDiscoveryService, che
 	return &TrainingRunsService{viewerDiscovery: viewerDiscovery, checkpointDiscovery: checkpointDiscovery, scanner: scanner, validator: validator, watcher: watcher, studyGetter: studyGetter}
 }
 
+// SetPinSetProvider sets the pin lookup used to populate the pinned flag on
+// scanned images. If not set, all images are reported as unpinned.
+func (s *TrainingRunsService) SetPinSetProvider(pins PinSetProvider) {
+	s.pins = pins
+}
+
+// SetConfigService sets the service used to manage user-defined training
+// runs. If not set, the config endpoints report an internal error.
+func (s *TrainingRunsService) SetConfigService(configs *service.TrainingRunConfigService) {
+	s.configs = configs
+}
+
+// SetChangeCurveService sets the service used to compute change curves. If
+// not set, the change curve endpoint reports an internal error.
+func (s *TrainingRunsService) SetChangeCurveService(changeCurves *service.ChangeCurveService) {
+	s.changeCurves = changeCurves
+}
+
+// SetSummaryService sets the service used to summarize training runs. If not
+// set, the summary endpoint reports an internal error.
+func (s *TrainingRunsService) SetSummaryService(summaries *service.TrainingRunSummaryService) {
+	s.summaries = summaries
+}
+
 // List returns training runs discovered from either sample output directories
 // (source=samples, the default for the viewer) or checkpoint files
 // (source=checkpoints, for the Generate Samples dialog).
@@ -39,7 +74,7 @@ func (s *TrainingRunsService) List(ctx context.Context, p *gentrainingruns.ListP
 	var err error
 
 	if p.Source == "checkpoints" {
-		runs, err = s.checkpointDiscovery.Discover()
+		runs, err = s.checkpointDiscovery.Discover(ctx)
 		if err != nil {
 			return nil, gentrainingruns.MakeDiscoveryFailed(fmt.Errorf("discovering checkpoint training runs: %w", err))
 		}
@@ -58,6 +93,7 @@ func (s *TrainingRunsService) List(ctx context.Context, p *gentrainingruns.ListP
 				Filename:   cp.Filename,
 				StepNumber: cp.StepNumber,
 				HasSamples: cp.HasSamples,
+				Dimensions: cp.Dimensions,
 			}
 		}
 
@@ -77,6 +113,9 @@ func (s *TrainingRunsService) List(ctx context.Context, p *gentrainingruns.ListP
 		if tr.StudyOutputDir != "" {
 			resp.StudyOutputDir = &tr.StudyOutputDir
 		}
+		if tr.ConfigID != "" {
+			resp.ConfigID = &tr.ConfigID
+		}
 		result = append(result, resp)
 	}
 
@@ -101,7 +140,7 @@ func (s *TrainingRunsService) Validate(ctx context.Context, p *gentrainingruns.V
 		//   2. After generation the run name embeds the study output dir
 		//      (e.g. "my-model/study-abc/my-model") and appending study.ID
 		//      would produce an incorrect double-nested path.
-		runs, err := s.checkpointDiscovery.Discover()
+		runs, err := s.checkpointDiscovery.Discover(ctx)
 		if err != nil {
 			return nil, gentrainingruns.MakeValidationFailed(fmt.Errorf("discovering checkpoint training runs: %w", err))
 		}
@@ -112,7 +151,7 @@ func (s *TrainingRunsService) Validate(ctx context.Context, p *gentrainingruns.V
 
 		tr := runs[p.ID]
 
-		study, err := s.studyGetter.GetStudy(*p.StudyID)
+		study, err := s.studyGetter.GetStudy(ctx, *p.StudyID)
 		if err == sql.ErrNoRows {
 			return nil, gentrainingruns.MakeNotFound(fmt.Errorf("study %s not found", *p.StudyID))
 		}
@@ -207,6 +246,12 @@ func (s *TrainingRunsService) Scan(ctx context.Context, p *gentrainingruns.ScanP
 		_ = s.watcher.WatchTrainingRun(tr)
 	}
 
+	// Load pins once per scan; a failure only hides the pinned flag.
+	var pinSet model.PinSet
+	if s.pins != nil {
+		pinSet, _ = s.pins.PinSet(ctx)
+	}
+
 	// Map model types to API response types
 	images := make([]*gentrainingruns.ImageResponse, len(scanResult.Images))
 	for i, img := range scanResult.Images {
@@ -214,20 +259,228 @@ func (s *TrainingRunsService) Scan(ctx context.Context, p *gentrainingruns.ScanP
 			RelativePath:  img.RelativePath,
 			Dimensions:    img.Dimensions,
 			ThumbnailPath: img.ThumbnailPath,
+			Pinned:        pinSet.IsPinned(img.RelativePath),
 		}
 	}
 
-	dimensions := make([]*gentrainingruns.DimensionResponse, len(scanResult.Dimensions))
-	for i, dim := range scanResult.Dimensions {
+	return &gentrainingruns.ScanResultResponse{
+		Images:     images,
+		Dimensions: dimensionResponses(scanResult.Dimensions),
+	}, nil
+}
+
+// Dimensions returns the dimensions discovered in a training run's sample
+// directories with their sorted values, without the image list. The study
+// name is derived as in Scan.
+func (s *TrainingRunsService) Dimensions(ctx context.Context, p *gentrainingruns.DimensionsPayload) (*gentrainingruns.DimensionIndexResponse, error) {
+	runs, err := s.viewerDiscovery.DiscoverViewable()
+	if err != nil {
+		return nil, gentrainingruns.MakeScanFailed(fmt.Errorf("discovering viewable training runs: %w", err))
+	}
+
+	if p.ID < 0 || p.ID >= len(runs) {
+		return nil, gentrainingruns.MakeNotFound(fmt.Errorf("training run %d not found", p.ID))
+	}
+
+	tr := runs[p.ID]
+
+	studyName := p.StudyName
+	if studyName == "" {
+		studyName = service.StudyNameForRun(tr.Name)
+	}
+
+	index, err := s.scanner.ScanDimensions(tr, studyName)
+	if err != nil {
+		return nil, gentrainingruns.MakeScanFailed(fmt.Errorf("scanning dimensions of training run %q: %w", tr.Name, err))
+	}
+
+	return &gentrainingruns.DimensionIndexResponse{
+		Dimensions: dimensionResponses(index.Dimensions),
+		ImageCount: index.ImageCount,
+	}, nil
+}
+
+func dimensionResponses(dims []model.Dimension) []*gentrainingruns.DimensionResponse {
+	dimensions := make([]*gentrainingruns.DimensionResponse, len(dims))
+	for i, dim := range dims {
 		dimensions[i] = &gentrainingruns.DimensionResponse{
 			Name:   dim.Name,
 			Type:   string(dim.Type),
 			Values: dim.Values,
 		}
 	}
+	return dimensions
+}
 
-	return &gentrainingruns.ScanResultResponse{
-		Images:     images,
-		Dimensions: dimensions,
-	}, nil
+// ChangeCurve returns how quickly a training run's images change between
+// consecutive checkpoints, with suggested sampling densities.
+func (s *TrainingRunsService) ChangeCurve(ctx context.Context, p *gentrainingruns.ChangeCurvePayload) (*gentrainingruns.ChangeCurveResponse, error) {
+	if s.changeCurves == nil {
+		return nil, gentrainingruns.MakeInternalError(fmt.Errorf("change curves are not available"))
+	}
+	curve, err := s.changeCurves.Curve(ctx, p.TrainingRun)
+	if err != nil {
+		switch {
+		case isNotFound(err):
+			return nil, gentrainingruns.MakeNotFound(err)
+		case strings.Contains(err.Error(), "must not be empty"):
+			return nil, gentrainingruns.MakeInvalidPayload(err)
+		}
+		return nil, gentrainingruns.MakeInternalError(err)
+	}
+	resp := &gentrainingruns.ChangeCurveResponse{
+		TrainingRun:          curve.TrainingRunName,
+		Transitions:          make([]*gentrainingruns.CheckpointTransitionResponse, len(curve.Transitions)),
+		SuggestedCheckpoints: curve.SuggestedCheckpoints,
+	}
+	for i, t := range curve.Transitions {
+		resp.Transitions[i] = &gentrainingruns.CheckpointTransitionResponse{
+			FromCheckpoint: t.FromCheckpoint,
+			ToCheckpoint:   t.ToCheckpoint,
+			FromStep:       t.FromStep,
+			ToStep:         t.ToStep,
+			Difference:     t.Difference,
+			Rate:           t.Rate,
+			Pairs:          t.Pairs,
+			Density:        string(t.Density),
+		}
+	}
+	return resp, nil
+}
+
+// Summary returns an overview of a training run: its checkpoints and which
+// have samples, its sample jobs and failures, and its disk usage.
+func (s *TrainingRunsService) Summary(ctx context.Context, p *gentrainingruns.SummaryPayload) (*gentrainingruns.TrainingRunSummaryResponse, error) {
+	if s.summaries == nil {
+		return nil, gentrainingruns.MakeInternalError(fmt.Errorf("training run summaries are not available"))
+	}
+	summary, err := s.summaries.Summary(ctx, p.Name)
+	if err != nil {
+		switch {
+		case isNotFound(err):
+			return nil, gentrainingruns.MakeNotFound(err)
+		case strings.Contains(err.Error(), "invalid"):
+			return nil, gentrainingruns.MakeInvalidPayload(err)
+		}
+		return nil, gentrainingruns.MakeInternalError(err)
+	}
+	resp := &gentrainingruns.TrainingRunSummaryResponse{
+		Name:                   summary.Name,
+		CheckpointCount:        len(summary.Checkpoints),
+		SampledCheckpointCount: summary.SampledCheckpointCount,
+		Checkpoints:            make([]*gentrainingruns.CheckpointSummaryResponse, len(summary.Checkpoints)),
+		JobCount:               summary.Jobs.Total,
+		JobStatusCounts:        make(map[string]int, len(summary.Jobs.ByStatus)),
+		FailedJobCount:         summary.Jobs.ByStatus[model.SampleJobStatusFailed],
+		FailedItemCount:        summary.Jobs.FailedItems,
+		DiskBytes:              summary.DiskBytes,
+		DiskFileCount:          summary.DiskFileCount,
+	}
+	for i, c := range summary.Checkpoints {
+		resp.Checkpoints[i] = &gentrainingruns.CheckpointSummaryResponse{
+			Filename:       c.Filename,
+			StepNumber:     c.StepNumber,
+			HasSamples:     c.HasSamples,
+			ImageCount:     c.ImageCount,
+			CompletedItems: c.CompletedItems,
+			FailedItems:    c.FailedItems,
+			Bytes:          c.Bytes,
+		}
+	}
+	for status, n := range summary.Jobs.ByStatus {
+		resp.JobStatusCounts[string(status)] = n
+	}
+	if summary.LastSampledAt != nil {
+		lastSampledAt := summary.LastSampledAt.UTC().Format(time.RFC3339)
+		resp.LastSampledAt = &lastSampledAt
+	}
+	return resp, nil
+}
+
+// ListConfigs returns all user-defined training runs.
+func (s *TrainingRunsService) ListConfigs(ctx context.Context) ([]*gentrainingruns.TrainingRunConfigResponse, error) {
+	if s.configs == nil {
+		return nil, gentrainingruns.MakeInternalError(fmt.Errorf("training run configs are not available"))
+	}
+	configs, err := s.configs.List(ctx)
+	if err != nil {
+		return nil, gentrainingruns.MakeInternalError(err)
+	}
+	result := make([]*gentrainingruns.TrainingRunConfigResponse, len(configs))
+	for i, c := range configs {
+		result[i] = trainingRunConfigToResponse(c)
+	}
+	return result, nil
+}
+
+// CreateConfig defines a new training run.
+func (s *TrainingRunsService) CreateConfig(ctx context.Context, p *gentrainingruns.CreateTrainingRunConfigPayload) (*gentrainingruns.TrainingRunConfigResponse, error) {
+	if s.configs == nil {
+		return nil, gentrainingruns.MakeInternalError(fmt.Errorf("training run configs are not available"))
+	}
+	c, err := s.configs.Create(ctx, p.Name, p.Pattern, payloadToDimensionConfigs(p.Dimensions))
+	if err != nil {
+		return nil, gentrainingruns.MakeInvalidPayload(err)
+	}
+	return trainingRunConfigToResponse(c), nil
+}
+
+// UpdateConfig modifies a user-defined training run.
+func (s *TrainingRunsService) UpdateConfig(ctx context.Context, p *gentrainingruns.UpdateTrainingRunConfigPayload) (*gentrainingruns.TrainingRunConfigResponse, error) {
+	if s.configs == nil {
+		return nil, gentrainingruns.MakeInternalError(fmt.Errorf("training run configs are not available"))
+	}
+	c, err := s.configs.Update(ctx, p.ConfigID, p.Name, p.Pattern, payloadToDimensionConfigs(p.Dimensions))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, gentrainingruns.MakeNotFound(err)
+		}
+		return nil, gentrainingruns.MakeInvalidPayload(err)
+	}
+	return trainingRunConfigToResponse(c), nil
+}
+
+// DeleteConfig removes a user-defined training run.
+func (s *TrainingRunsService) DeleteConfig(ctx context.Context, p *gentrainingruns.DeleteConfigPayload) error {
+	if s.configs == nil {
+		return gentrainingruns.MakeInternalError(fmt.Errorf("training run configs are not available"))
+	}
+	if err := s.configs.Delete(ctx, p.ConfigID); err != nil {
+		if isNotFound(err) {
+			return gentrainingruns.MakeNotFound(err)
+		}
+		return gentrainingruns.MakeInternalError(err)
+	}
+	return nil
+}
+
+func trainingRunConfigToResponse(c model.TrainingRunConfig) *gentrainingruns.TrainingRunConfigResponse {
+	dims := make([]*gentrainingruns.TrainingRunDimensionConfig, len(c.Dimensions))
+	for i, d := range c.Dimensions {
+		dims[i] = &gentrainingruns.TrainingRunDimensionConfig{
+			Name:    d.Name,
+			Type:    string(d.Type),
+			Pattern: d.Pattern,
+		}
+	}
+	return &gentrainingruns.TrainingRunConfigResponse{
+		ID:         c.ID,
+		Name:       c.Name,
+		Pattern:    c.Pattern,
+		Dimensions: dims,
+		CreatedAt:  c.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:  c.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func payloadToDimensionConfigs(dims []*gentrainingruns.TrainingRunDimensionConfig) []model.TrainingRunDimensionConfig {
+	result := make([]model.TrainingRunDimensionConfig, len(dims))
+	for i, d := range dims {
+		result[i] = model.TrainingRunDimensionConfig{
+			Name:    d.Name,
+			Type:    model.DimensionType(d.Type),
+			Pattern: d.Pattern,
+		}
+	}
+	return result
 }
@@ -2,9 +2,12 @@ package api_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -54,7 +57,7 @@ func newFakeScanFS() *fakeScanFS {
 	}
 }
 
-func (f *fakeScanFS) ListPNGFiles(dir string) ([]string, error) {
+func (f *fakeScanFS) ListImageFiles(dir string) ([]string, error) {
 	if err, ok := f.errs[dir]; ok {
 		return nil, err
 	}
@@ -105,7 +108,7 @@ func newFakeStudyGetter() *fakeStudyGetter {
 	return &fakeStudyGetter{studies: make(map[string]model.Study)}
 }
 
-func (f *fakeStudyGetter) GetStudy(id string) (model.Study, error) {
+func (f *fakeStudyGetter) GetStudy(ctx context.Context, id string) (model.Study, error) {
 	if f.err != nil {
 		return model.Study{}, f.err
 	}
@@ -116,6 +119,71 @@ func (f *fakeStudyGetter) GetStudy(id string) (model.Study, error) {
 	return s, nil
 }
 
+// fakeTrainingRunConfigStore is an in-memory test double for service.TrainingRunConfigStore.
+type fakeTrainingRunConfigStore struct {
+	configs map[string]model.TrainingRunConfig
+}
+
+func newFakeTrainingRunConfigStore() *fakeTrainingRunConfigStore {
+	return &fakeTrainingRunConfigStore{configs: make(map[string]model.TrainingRunConfig)}
+}
+
+func (f *fakeTrainingRunConfigStore) ListTrainingRunConfigs(ctx context.Context) ([]model.TrainingRunConfig, error) {
+	var result []model.TrainingRunConfig
+	for _, c := range f.configs {
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func (f *fakeTrainingRunConfigStore) GetTrainingRunConfig(ctx context.Context, id string) (model.TrainingRunConfig, error) {
+	c, ok := f.configs[id]
+	if !ok {
+		return model.TrainingRunConfig{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (f *fakeTrainingRunConfigStore) CreateTrainingRunConfig(ctx context.Context, c model.TrainingRunConfig) error {
+	f.configs[c.ID] = c
+	return nil
+}
+
+func (f *fakeTrainingRunConfigStore) UpdateTrainingRunConfig(ctx context.Context, c model.TrainingRunConfig) error {
+	if _, ok := f.configs[c.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	f.configs[c.ID] = c
+	return nil
+}
+
+func (f *fakeTrainingRunConfigStore) DeleteTrainingRunConfig(ctx context.Context, id string) error {
+	if _, ok := f.configs[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.configs, id)
+	return nil
+}
+
+// fakeTrainingRunSummaryStore implements service.TrainingRunSummaryStore for
+// testing.
+type fakeTrainingRunSummaryStore struct {
+	stats map[string]model.TrainingRunJobStats
+	err   error
+}
+
+func (f *fakeTrainingRunSummaryStore) SummarizeTrainingRunJobs(ctx context.Context, trainingRunName string) (model.TrainingRunJobStats, error) {
+	return f.stats[trainingRunName], f.err
+}
+
+// fakeTrainingRunUsage implements service.TrainingRunUsageReporter for
+// testing.
+type fakeTrainingRunUsage map[string]model.TrainingRunStorageUsage
+
+func (f fakeTrainingRunUsage) TrainingRunUsage(directory string) (model.TrainingRunStorageUsage, error) {
+	return f[directory], nil
+}
+
 var _ = Describe("TrainingRunsService", func() {
 	var (
 		viewerFS        *fakeViewerDiscoveryFS
@@ -423,6 +491,72 @@ var _ = Describe("TrainingRunsService", func() {
 		})
 	})
 
+	Describe("Dimensions", func() {
+		It("returns not_found for invalid training run ID", func() {
+			viewerFS.subdirs[sampleDir] = []string{
+				"model.safetensors",
+			}
+			viewerDiscovery = service.NewViewerDiscoveryService(viewerFS, sampleDir, logger)
+			cpDiscovery = service.NewDiscoveryService(cpFS, []string{}, sampleDir, logger)
+			scanner = service.NewScanner(scanFS, sampleDir, logger)
+			svc := makeSvc(nil, nil)
+
+			_, err := svc.Dimensions(context.Background(), &gentrainingruns.DimensionsPayload{ID: 5})
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not found"))
+		})
+
+		It("returns the dimensions and image count of a study-scoped run", func() {
+			viewerFS.subdirs[sampleDir] = []string{"my-study"}
+			viewerFS.subdirs[sampleDir+"/my-study"] = []string{
+				"model-step00001000.safetensors",
+				"model-step00002000.safetensors",
+			}
+			viewerDiscovery = service.NewViewerDiscoveryService(viewerFS, sampleDir, logger)
+			cpDiscovery = service.NewDiscoveryService(cpFS, []string{}, sampleDir, logger)
+			scanner = service.NewScanner(scanFS, sampleDir, logger)
+			svc := makeSvc(nil, nil)
+
+			scanFS.files[sampleDir+"/my-study/model-step00001000.safetensors"] = []string{
+				"seed=42&cfg=7&_00001_.png",
+				"seed=7&cfg=7&_00001_.png",
+			}
+			scanFS.files[sampleDir+"/my-study/model-step00002000.safetensors"] = []string{
+				"seed=42&cfg=7&_00001_.png",
+			}
+
+			result, err := svc.Dimensions(context.Background(), &gentrainingruns.DimensionsPayload{ID: 0})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ImageCount).To(Equal(3))
+			dimMap := make(map[string]*gentrainingruns.DimensionResponse)
+			for _, d := range result.Dimensions {
+				dimMap[d.Name] = d
+			}
+			Expect(dimMap["checkpoint"].Values).To(Equal([]string{"1000", "2000"}))
+			Expect(dimMap["seed"].Type).To(Equal("int"))
+			Expect(dimMap["seed"].Values).To(Equal([]string{"7", "42"}))
+			Expect(dimMap["cfg"].Values).To(Equal([]string{"7"}))
+		})
+
+		It("returns scan_failed when scanner encounters an error", func() {
+			viewerFS.subdirs[sampleDir] = []string{
+				"model-step00001000.safetensors",
+			}
+			viewerDiscovery = service.NewViewerDiscoveryService(viewerFS, sampleDir, logger)
+			cpDiscovery = service.NewDiscoveryService(cpFS, []string{}, sampleDir, logger)
+			scanner = service.NewScanner(scanFS, sampleDir, logger)
+			svc := makeSvc(nil, nil)
+
+			scanFS.errs[sampleDir+"/model-step00001000.safetensors"] = fmt.Errorf("disk error")
+
+			_, err := svc.Dimensions(context.Background(), &gentrainingruns.DimensionsPayload{ID: 0})
+
+			Expect(err).To(MatchError(ContainSubstring("disk error")))
+		})
+	})
+
 	Describe("Validate", func() {
 		// AC3: API endpoint to trigger validation of a selected sample set on demand
 		It("returns not_found for invalid training run ID", func() {
@@ -755,4 +889,222 @@ var _ = Describe("TrainingRunsService", func() {
 			})
 		})
 	})
+
+	Describe("training run configs", func() {
+		var (
+			configStore *fakeTrainingRunConfigStore
+			svc         *api.TrainingRunsService
+		)
+
+		stepDim := []*gentrainingruns.TrainingRunDimensionConfig{
+			{Name: "step", Type: "int", Pattern: `-steps-(\d+)-`},
+		}
+
+		BeforeEach(func() {
+			configStore = newFakeTrainingRunConfigStore()
+			cpDiscovery = service.NewDiscoveryService(cpFS, []string{"/checkpoints"}, sampleDir, logger)
+			cpDiscovery.SetConfigSource(configStore)
+			svc = makeSvc(nil, nil)
+			svc.SetConfigService(service.NewTrainingRunConfigService(configStore, logger))
+		})
+
+		It("creates a config and groups matching checkpoints into it", func() {
+			cpFS.safetensors["/checkpoints"] = []string{
+				"qwen/psai4rt-steps-4500-a.safetensors",
+				"qwen/psai4rt-steps-9000-b.safetensors",
+			}
+
+			created, err := svc.CreateConfig(context.Background(), &gentrainingruns.CreateTrainingRunConfigPayload{
+				Name:       "psai4rt",
+				Pattern:    `^qwen/psai4rt-`,
+				Dimensions: stepDim,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.Name).To(Equal("psai4rt"))
+			Expect(created.Dimensions).To(Equal(stepDim))
+
+			runs, err := svc.List(context.Background(), &gentrainingruns.ListPayload{Source: "checkpoints"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Name).To(Equal("psai4rt"))
+			Expect(runs[0].ConfigID).To(HaveValue(Equal(created.ID)))
+			Expect(runs[0].Checkpoints[0].StepNumber).To(Equal(4500))
+			Expect(runs[0].Checkpoints[0].Dimensions).To(Equal(map[string]string{"step": "4500"}))
+		})
+
+		It("lists configs", func() {
+			_, err := svc.CreateConfig(context.Background(), &gentrainingruns.CreateTrainingRunConfigPayload{Name: "a", Pattern: `^a`})
+			Expect(err).NotTo(HaveOccurred())
+
+			configs, err := svc.ListConfigs(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configs).To(HaveLen(1))
+			Expect(configs[0].Dimensions).To(BeEmpty())
+		})
+
+		It("returns invalid_payload for an invalid pattern", func() {
+			_, err := svc.CreateConfig(context.Background(), &gentrainingruns.CreateTrainingRunConfigPayload{Name: "a", Pattern: `(`})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+		})
+
+		It("updates a config", func() {
+			created, err := svc.CreateConfig(context.Background(), &gentrainingruns.CreateTrainingRunConfigPayload{Name: "a", Pattern: `^a`})
+			Expect(err).NotTo(HaveOccurred())
+
+			updated, err := svc.UpdateConfig(context.Background(), &gentrainingruns.UpdateTrainingRunConfigPayload{
+				ConfigID: created.ID, Name: "b", Pattern: `^b`, Dimensions: stepDim,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Name).To(Equal("b"))
+			Expect(updated.Pattern).To(Equal(`^b`))
+		})
+
+		It("deletes a config", func() {
+			created, err := svc.CreateConfig(context.Background(), &gentrainingruns.CreateTrainingRunConfigPayload{Name: "a", Pattern: `^a`})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(svc.DeleteConfig(context.Background(), &gentrainingruns.DeleteConfigPayload{ConfigID: created.ID})).To(Succeed())
+			Expect(configStore.configs).To(BeEmpty())
+		})
+
+		DescribeTable("returns not_found for an unknown config",
+			func(call func() error) {
+				serviceErr, ok := call().(errorNamer)
+				Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+				Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+			},
+			Entry("on update", func() error {
+				_, err := svc.UpdateConfig(context.Background(), &gentrainingruns.UpdateTrainingRunConfigPayload{ConfigID: "missing", Name: "a", Pattern: `^a`})
+				return err
+			}),
+			Entry("on delete", func() error {
+				return svc.DeleteConfig(context.Background(), &gentrainingruns.DeleteConfigPayload{ConfigID: "missing"})
+			}),
+		)
+	})
+
+	Describe("ChangeCurve", func() {
+		var (
+			voteStore *fakeVoteStoreAPI
+			svc       *api.TrainingRunsService
+		)
+
+		BeforeEach(func() {
+			voteStore = &fakeVoteStoreAPI{}
+			svc = makeSvc(nil, nil)
+			svc.SetChangeCurveService(service.NewChangeCurveService(voteStore, scanFS, logger))
+		})
+
+		It("returns not_found when the training run has too few images", func() {
+			_, err := svc.ChangeCurve(context.Background(), &gentrainingruns.ChangeCurvePayload{TrainingRun: "my-lora"})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("not_found"))
+		})
+
+		It("returns invalid_payload for an empty training run name", func() {
+			_, err := svc.ChangeCurve(context.Background(), &gentrainingruns.ChangeCurvePayload{})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("invalid_payload"))
+		})
+
+		It("returns internal_error when the store fails", func() {
+			voteStore.listErr = errors.New("database is locked")
+
+			_, err := svc.ChangeCurve(context.Background(), &gentrainingruns.ChangeCurvePayload{TrainingRun: "my-lora"})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+
+		It("returns internal_error when no change curve service is set", func() {
+			_, err := makeSvc(nil, nil).ChangeCurve(context.Background(), &gentrainingruns.ChangeCurvePayload{TrainingRun: "my-lora"})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("Summary", func() {
+		var (
+			summaryStore *fakeTrainingRunSummaryStore
+			svc          *api.TrainingRunsService
+		)
+
+		BeforeEach(func() {
+			sampledAt := time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+			cpFS.safetensors["/checkpoints"] = []string{
+				"qwen/lora-step00001000.safetensors",
+				"qwen/lora-step00002000.safetensors",
+			}
+			viewerFS.subdirs[sampleDir+"/qwen_lora"] = []string{"forest"}
+			viewerFS.subdirs[sampleDir+"/qwen_lora/forest"] = []string{"lora-step00001000.safetensors"}
+			scanFS.files[sampleDir+"/qwen_lora/forest/lora-step00001000.safetensors"] = []string{"a.png", "b.png"}
+			summaryStore = &fakeTrainingRunSummaryStore{stats: map[string]model.TrainingRunJobStats{
+				"qwen/lora": {
+					JobStatusCounts: map[model.SampleJobStatus]int{model.SampleJobStatusCompleted: 2, model.SampleJobStatusFailed: 1},
+					Checkpoints: map[string]model.CheckpointJobStats{
+						"lora-step00001000.safetensors": {CompletedItems: 2, FailedItems: 1, LastCompletedAt: &sampledAt},
+					},
+				},
+			}}
+			usage := fakeTrainingRunUsage{"qwen_lora": {Directory: "qwen_lora", Bytes: 2048, FileCount: 3, Checkpoints: []model.CheckpointStorageUsage{
+				{CheckpointFilename: "lora-step00001000.safetensors", Bytes: 2000, FileCount: 2, StudyCount: 1},
+			}}}
+			cpDiscovery = service.NewDiscoveryService(cpFS, []string{"/checkpoints"}, sampleDir, logger)
+			svc = makeSvc(nil, nil)
+			svc.SetSummaryService(service.NewTrainingRunSummaryService(cpDiscovery, summaryStore, scanFS, viewerFS, usage, sampleDir, logger))
+		})
+
+		It("summarizes a training run", func() {
+			result, err := svc.Summary(context.Background(), &gentrainingruns.SummaryPayload{Name: "qwen/lora"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Name).To(Equal("qwen/lora"))
+			Expect(result.CheckpointCount).To(Equal(2))
+			Expect(result.SampledCheckpointCount).To(Equal(1))
+			Expect(result.Checkpoints).To(HaveLen(2))
+			Expect(*result.Checkpoints[0]).To(Equal(gentrainingruns.CheckpointSummaryResponse{
+				Filename: "lora-step00001000.safetensors", StepNumber: 1000, HasSamples: true, ImageCount: 2, CompletedItems: 2, FailedItems: 1, Bytes: 2000,
+			}))
+			Expect(result.Checkpoints[1].HasSamples).To(BeFalse())
+			Expect(result.LastSampledAt).To(HaveValue(Equal("2026-05-06T07:08:09Z")))
+			Expect(result.JobCount).To(Equal(3))
+			Expect(result.JobStatusCounts).To(Equal(map[string]int{"completed": 2, "failed": 1}))
+			Expect(result.FailedJobCount).To(Equal(1))
+			Expect(result.FailedItemCount).To(Equal(1))
+			Expect(result.DiskBytes).To(Equal(int64(2048)))
+			Expect(result.DiskFileCount).To(Equal(3))
+		})
+
+		It("leaves last_sampled_at out when nothing was sampled", func() {
+			summaryStore.stats = nil
+			result, err := svc.Summary(context.Background(), &gentrainingruns.SummaryPayload{Name: "qwen/lora"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.LastSampledAt).To(BeNil())
+			Expect(result.JobCount).To(BeZero())
+			Expect(result.JobStatusCounts).To(BeEmpty())
+		})
+
+		DescribeTable("maps errors",
+			func(name string, storeErr error, expected string) {
+				summaryStore.err = storeErr
+				_, err := svc.Summary(context.Background(), &gentrainingruns.SummaryPayload{Name: name})
+				serviceErr, ok := err.(errorNamer)
+				Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+				Expect(serviceErr.ErrorName()).To(Equal(expected))
+			},
+			Entry("unknown training run", "missing", nil, "not_found"),
+			Entry("name that is not a directory", "..", nil, "invalid_payload"),
+			Entry("store failure", "qwen/lora", errors.New("database is locked"), "internal_error"),
+		)
+
+		It("returns internal_error when no summary service is set", func() {
+			_, err := makeSvc(nil, nil).Summary(context.Background(), &gentrainingruns.SummaryPayload{Name: "qwen/lora"})
+			serviceErr, ok := err.(errorNamer)
+			Expect(ok).To(BeTrue(), "error should implement ErrorNamer interface")
+			Expect(serviceErr.ErrorName()).To(Equal("internal_error"))
+		})
+	})
 })
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// userAuthenticator identifies the configured user a request acts for by the
+// bearer token in its Authorization header.
+type userAuthenticator struct {
+	users  []model.UserConfig
+	logger *logrus.Entry
+}
+
+func newUserAuthenticator(users []model.UserConfig, logger *logrus.Logger) *userAuthenticator {
+	return &userAuthenticator{users: users, logger: logger.WithField("component", "user_auth")}
+}
+
+// authenticate returns ctx carrying the user whose token authorization (the
+// value of an Authorization header) holds. A request without the header acts
+// for no user. ok is false for a header that is not a bearer token or holds
+// an unknown token.
+func (a *userAuthenticator) authenticate(ctx context.Context, authorization string) (_ context.Context, ok bool) {
+	if authorization == "" {
+		return ctx, true
+	}
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		a.logger.Debug("rejected request with a malformed Authorization header")
+		return ctx, false
+	}
+	// Compare with every token so the time taken does not tell which user's
+	// token a guess is close to.
+	var user *model.UserConfig
+	for i := range a.users {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.users[i].Token)) == 1 {
+			user = &a.users[i]
+		}
+	}
+	if user == nil {
+		a.logger.Debug("rejected request with an unknown token")
+		return ctx, false
+	}
+	a.logger.WithField("user", user.Name).Trace("request authenticated")
+	return service.WithUser(ctx, model.User{Name: user.Name, Admin: user.Admin}), true
+}
+
+// UserAuthMiddleware returns middleware that makes each request act for the
+// configured user whose token it sends as "Authorization: Bearer <token>".
+// Requests without the header act for no user; requests with an unknown
+// token or another scheme are answered 401. Tokens are never logged.
+func UserAuthMiddleware(users []model.UserConfig, logger *logrus.Logger) func(http.Handler) http.Handler {
+	auth := newUserAuthenticator(users, logger)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, ok := auth.authenticate(r.Context(), r.Header.Get("Authorization"))
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// grpcUserAuthInterceptor is UserAuthMiddleware for gRPC calls, which send
+// the token in their authorization metadata. Calls with an unknown token fail
+// with codes.Unauthenticated.
+func grpcUserAuthInterceptor(users []model.UserConfig, logger *logrus.Logger) grpc.UnaryServerInterceptor {
+	auth := newUserAuthenticator(users, logger)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var authorization string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				authorization = values[0]
+			}
+		}
+		ctx, ok := auth.authenticate(ctx, authorization)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("UserAuthMiddleware", func() {
+	var (
+		user    model.User
+		hasUser bool
+		called  bool
+		handler http.Handler
+	)
+
+	BeforeEach(func() {
+		user, hasUser, called = model.User{}, false, false
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		users := []model.UserConfig{
+			{Name: "alice", Token: "alice-token-0123456789"},
+			{Name: "bob", Token: "bob-token-0123456789", Admin: true},
+		}
+		handler = api.UserAuthMiddleware(users, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			user, hasUser = service.UserFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	serve := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/presets", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	It("acts for the user whose token the request sends", func() {
+		rec := serve("Bearer alice-token-0123456789")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(hasUser).To(BeTrue())
+		Expect(user).To(Equal(model.User{Name: "alice"}))
+	})
+
+	It("marks admin users", func() {
+		serve("bearer bob-token-0123456789")
+		Expect(user).To(Equal(model.User{Name: "bob", Admin: true}))
+	})
+
+	It("acts for no user without an Authorization header", func() {
+		rec := serve("")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(called).To(BeTrue())
+		Expect(hasUser).To(BeFalse())
+	})
+
+	DescribeTable("rejects invalid credentials with 401",
+		func(authorization string) {
+			rec := serve(authorization)
+			Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+			Expect(called).To(BeFalse())
+		},
+		Entry("unknown token", "Bearer not-a-configured-token"),
+		Entry("token of another scheme", "Basic alice-token-0123456789"),
+		Entry("empty token", "Bearer "),
+		Entry("missing scheme", "alice-token-0123456789"),
+	)
+})
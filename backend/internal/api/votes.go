@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	genvotes "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/votes"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// VotesService implements the generated votes service interface.
+type VotesService struct {
+	svc    *service.VoteService
+	scores *service.CheckpointScoreService
+}
+
+// NewVotesService returns a new VotesService.
+func NewVotesService(svc *service.VoteService) *VotesService {
+	return &VotesService{svc: svc}
+}
+
+// SetCheckpointScoreService sets the service backing the scores and
+// scores_csv methods. If not set, those methods return an internal_error.
+func (s *VotesService) SetCheckpointScoreService(scores *service.CheckpointScoreService) {
+	s.scores = scores
+}
+
+// Pair returns a random pair of comparable images to vote on.
+func (s *VotesService) Pair(ctx context.Context, p *genvotes.PairPayload) (*genvotes.VotePairResponse, error) {
+	promptName := ""
+	if p.PromptName != nil {
+		promptName = *p.PromptName
+	}
+	pair, err := s.svc.Pair(ctx, p.TrainingRun, promptName)
+	if err != nil {
+		switch {
+		case isNotFound(err):
+			return nil, genvotes.MakeNotFound(err)
+		case isVoteValidationError(err):
+			return nil, genvotes.MakeInvalidPayload(err)
+		}
+		return nil, genvotes.MakeInternalError(err)
+	}
+	return &genvotes.VotePairResponse{
+		TrainingRun: pair.TrainingRunName,
+		PromptName:  pair.PromptName,
+		Steps:       pair.Steps,
+		Cfg:         pair.CFG,
+		SamplerName: pair.SamplerName,
+		Scheduler:   pair.Scheduler,
+		Seed:        pair.Seed,
+		A:           votePairImageToResponse(pair.A),
+		B:           votePairImageToResponse(pair.B),
+	}, nil
+}
+
+// Vote records a vote on a pair of images.
+func (s *VotesService) Vote(ctx context.Context, p *genvotes.VotePayload) (*genvotes.VoteResponse, error) {
+	v, err := s.svc.Vote(ctx, p.TrainingRun, p.WinnerItemID, p.LoserItemID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not comparable"):
+			return nil, genvotes.MakeNotComparable(err)
+		case isNotFound(err):
+			return nil, genvotes.MakeNotFound(err)
+		case isVoteValidationError(err):
+			return nil, genvotes.MakeInvalidPayload(err)
+		}
+		return nil, genvotes.MakeInternalError(err)
+	}
+	return &genvotes.VoteResponse{
+		ID:               v.ID,
+		TrainingRun:      v.TrainingRunName,
+		PromptName:       v.PromptName,
+		WinnerCheckpoint: v.WinnerCheckpoint,
+		LoserCheckpoint:  v.LoserCheckpoint,
+		CreatedAt:        v.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// Rankings returns the Elo rankings of a training run's checkpoints.
+func (s *VotesService) Rankings(ctx context.Context, p *genvotes.RankingsPayload) (*genvotes.VoteRankingsResponse, error) {
+	rankings, err := s.svc.Rankings(ctx, p.TrainingRun)
+	if err != nil {
+		if isVoteValidationError(err) {
+			return nil, genvotes.MakeInvalidPayload(err)
+		}
+		return nil, genvotes.MakeInternalError(err)
+	}
+	resp := &genvotes.VoteRankingsResponse{
+		TrainingRun: rankings.TrainingRunName,
+		VoteCount:   rankings.VoteCount,
+		Overall:     checkpointRatingsToResponse(rankings.Overall),
+		Prompts:     make([]*genvotes.PromptRatingsResponse, len(rankings.Prompts)),
+	}
+	for i, pr := range rankings.Prompts {
+		resp.Prompts[i] = &genvotes.PromptRatingsResponse{
+			PromptName: pr.PromptName,
+			Ratings:    checkpointRatingsToResponse(pr.Ratings),
+		}
+	}
+	return resp, nil
+}
+
+// Scores returns the star rating scores of the checkpoints of a training run.
+func (s *VotesService) Scores(ctx context.Context, p *genvotes.ScoresPayload) (*genvotes.CheckpointScoreReportResponse, error) {
+	report, err := s.scoreReport(ctx, p.TrainingRun)
+	if err != nil {
+		return nil, err
+	}
+	resp := &genvotes.CheckpointScoreReportResponse{
+		TrainingRun: report.TrainingRunName,
+		ImageCount:  report.ImageCount,
+		RatedCount:  report.RatedCount,
+		Leaderboard: checkpointScoresToResponse(report.Leaderboard),
+		Prompts:     make([]*genvotes.PromptScoresResponse, len(report.Prompts)),
+	}
+	for i, ps := range report.Prompts {
+		resp.Prompts[i] = &genvotes.PromptScoresResponse{
+			PromptName: ps.PromptName,
+			Scores:     checkpointScoresToResponse(ps.Scores),
+		}
+	}
+	return resp, nil
+}
+
+// ScoresCsv returns the star rating scores of the checkpoints of a training
+// run as a CSV download.
+func (s *VotesService) ScoresCsv(ctx context.Context, p *genvotes.ScoresCsvPayload) (*genvotes.CSVDownloadResult, io.ReadCloser, error) {
+	report, err := s.scoreReport(ctx, p.TrainingRun)
+	if err != nil {
+		return nil, nil, err
+	}
+	var buf bytes.Buffer
+	if err := service.WriteCheckpointScoreCSV(&buf, report); err != nil {
+		return nil, nil, genvotes.MakeInternalError(err)
+	}
+	return &genvotes.CSVDownloadResult{
+		ContentType:        "text/csv; charset=utf-8",
+		ContentDisposition: fmt.Sprintf("attachment; filename=%q", service.CheckpointScoreCSVFilename(report.TrainingRunName)),
+	}, io.NopCloser(&buf), nil
+}
+
+// scoreReport computes the score report of a training run, mapping errors to
+// votes service errors.
+func (s *VotesService) scoreReport(ctx context.Context, trainingRun string) (model.CheckpointScoreReport, error) {
+	if s.scores == nil {
+		return model.CheckpointScoreReport{}, genvotes.MakeInternalError(fmt.Errorf("checkpoint scores are not configured"))
+	}
+	report, err := s.scores.Report(ctx, trainingRun)
+	if err != nil {
+		if isVoteValidationError(err) {
+			return model.CheckpointScoreReport{}, genvotes.MakeInvalidPayload(err)
+		}
+		return model.CheckpointScoreReport{}, genvotes.MakeInternalError(err)
+	}
+	return report, nil
+}
+
+// isVoteValidationError reports whether err rejects the request itself.
+func isVoteValidationError(err error) bool {
+	return strings.Contains(err.Error(), "must not be empty")
+}
+
+func votePairImageToResponse(img model.VotePairImage) *genvotes.VotePairImageResponse {
+	return &genvotes.VotePairImageResponse{
+		ItemID:             img.ItemID,
+		CheckpointFilename: img.CheckpointFilename,
+		ImagePath:          img.ImagePath,
+	}
+}
+
+func checkpointRatingsToResponse(ratings []model.CheckpointRating) []*genvotes.CheckpointRatingResponse {
+	out := make([]*genvotes.CheckpointRatingResponse, len(ratings))
+	for i, r := range ratings {
+		out[i] = &genvotes.CheckpointRatingResponse{
+			CheckpointFilename: r.CheckpointFilename,
+			Rating:             r.Rating,
+			Wins:               r.Wins,
+			Losses:             r.Losses,
+		}
+	}
+	return out
+}
+
+func checkpointScoresToResponse(scores []model.CheckpointScore) []*genvotes.CheckpointScoreResponse {
+	out := make([]*genvotes.CheckpointScoreResponse, len(scores))
+	for i, sc := range scores {
+		out[i] = &genvotes.CheckpointScoreResponse{
+			Rank:               sc.Rank,
+			CheckpointFilename: sc.CheckpointFilename,
+			StepNumber:         sc.StepNumber,
+			ImageCount:         sc.ImageCount,
+			RatedCount:         sc.RatedCount,
+			AverageRating:      sc.AverageRating,
+		}
+	}
+	return out
+}
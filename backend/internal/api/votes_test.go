@@ -0,0 +1,190 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	genvotes "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/votes"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeVoteStoreAPI implements service.VoteStore for API tests.
+type fakeVoteStoreAPI struct {
+	candidates  []model.VoteCandidate
+	votes       []model.Vote
+	annotations []model.ImageAnnotation
+	listErr     error
+}
+
+func (f *fakeVoteStoreAPI) ListVoteCandidates(ctx context.Context, trainingRunName string) ([]model.VoteCandidate, error) {
+	return f.candidates, f.listErr
+}
+
+func (f *fakeVoteStoreAPI) CreateVote(ctx context.Context, v model.Vote) error {
+	f.votes = append(f.votes, v)
+	return nil
+}
+
+func (f *fakeVoteStoreAPI) ListVotes(ctx context.Context, trainingRunName string) ([]model.Vote, error) {
+	return f.votes, f.listErr
+}
+
+func (f *fakeVoteStoreAPI) ListImageAnnotations(ctx context.Context, filter model.ImageAnnotationFilter) ([]model.ImageAnnotation, error) {
+	return f.annotations, nil
+}
+
+var _ = Describe("VotesService", func() {
+	var (
+		store *fakeVoteStoreAPI
+		votes *api.VotesService
+	)
+
+	candidate := func(id, checkpoint string, seed int64) model.VoteCandidate {
+		return model.VoteCandidate{
+			ItemID:             id,
+			CheckpointFilename: checkpoint,
+			PromptName:         "forest",
+			Steps:              20,
+			CFG:                7,
+			SamplerName:        "euler",
+			Scheduler:          "simple",
+			Seed:               seed,
+			OutputPath:         "/samples/run/" + checkpoint + "/" + id + ".png",
+		}
+	}
+
+	errorName := func(err error) string {
+		Expect(err).To(HaveOccurred())
+		serviceErr, ok := err.(errorNamer)
+		Expect(ok).To(BeTrue())
+		return serviceErr.ErrorName()
+	}
+
+	BeforeEach(func() {
+		store = &fakeVoteStoreAPI{candidates: []model.VoteCandidate{
+			candidate("i1", "a.safetensors", 42),
+			candidate("i2", "b.safetensors", 42),
+			candidate("i3", "b.safetensors", 7),
+		}}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		votes = api.NewVotesService(service.NewVoteService(store, "/samples", logger))
+		votes.SetCheckpointScoreService(service.NewCheckpointScoreService(store, "/samples", logger))
+	})
+
+	Describe("Pair", func() {
+		It("maps the pair to the response", func() {
+			resp, err := votes.Pair(context.Background(), &genvotes.PairPayload{TrainingRun: "run"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.TrainingRun).To(Equal("run"))
+			Expect(resp.PromptName).To(Equal("forest"))
+			Expect(resp.Steps).To(Equal(20))
+			Expect(resp.Cfg).To(Equal(7.0))
+			Expect(resp.Seed).To(Equal(int64(42)))
+			Expect([]string{resp.A.ImagePath, resp.B.ImagePath}).To(ConsistOf("run/a.safetensors/i1.png", "run/b.safetensors/i2.png"))
+		})
+
+		It("returns not_found without comparable images", func() {
+			prompt := "lake"
+			_, err := votes.Pair(context.Background(), &genvotes.PairPayload{TrainingRun: "run", PromptName: &prompt})
+			Expect(errorName(err)).To(Equal("not_found"))
+		})
+
+		It("returns internal_error when the images cannot be listed", func() {
+			store.listErr = errors.New("database is locked")
+			_, err := votes.Pair(context.Background(), &genvotes.PairPayload{TrainingRun: "run"})
+			Expect(errorName(err)).To(Equal("internal_error"))
+		})
+	})
+
+	Describe("Vote", func() {
+		It("records the vote", func() {
+			resp, err := votes.Vote(context.Background(), &genvotes.VotePayload{TrainingRun: "run", WinnerItemID: "i1", LoserItemID: "i2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.WinnerCheckpoint).To(Equal("a.safetensors"))
+			Expect(resp.LoserCheckpoint).To(Equal("b.safetensors"))
+			Expect(resp.CreatedAt).NotTo(BeEmpty())
+			Expect(store.votes).To(HaveLen(1))
+		})
+
+		It("returns not_comparable for images with different parameters", func() {
+			_, err := votes.Vote(context.Background(), &genvotes.VotePayload{TrainingRun: "run", WinnerItemID: "i1", LoserItemID: "i3"})
+			Expect(errorName(err)).To(Equal("not_comparable"))
+		})
+
+		It("returns not_found for an unknown image", func() {
+			_, err := votes.Vote(context.Background(), &genvotes.VotePayload{TrainingRun: "run", WinnerItemID: "i1", LoserItemID: "missing"})
+			Expect(errorName(err)).To(Equal("not_found"))
+		})
+	})
+
+	Describe("Rankings", func() {
+		It("maps the rankings to the response", func() {
+			store.votes = []model.Vote{{TrainingRunName: "run", PromptName: "forest", WinnerCheckpoint: "b", LoserCheckpoint: "a"}}
+
+			resp, err := votes.Rankings(context.Background(), &genvotes.RankingsPayload{TrainingRun: "run"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.VoteCount).To(Equal(1))
+			Expect(resp.Overall).To(Equal([]*genvotes.CheckpointRatingResponse{
+				{CheckpointFilename: "b", Rating: 1516, Wins: 1},
+				{CheckpointFilename: "a", Rating: 1484, Losses: 1},
+			}))
+			Expect(resp.Prompts).To(HaveLen(1))
+			Expect(resp.Prompts[0].PromptName).To(Equal("forest"))
+			Expect(resp.Prompts[0].Ratings).To(HaveLen(2))
+		})
+
+		It("returns an empty ranking without votes", func() {
+			resp, err := votes.Rankings(context.Background(), &genvotes.RankingsPayload{TrainingRun: "run"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Overall).To(BeEmpty())
+			Expect(resp.Prompts).NotTo(BeNil())
+		})
+	})
+
+	Describe("Scores", func() {
+		BeforeEach(func() {
+			store.annotations = []model.ImageAnnotation{
+				{Path: "run/a.safetensors/i1.png", Rating: 3},
+				{Path: "run/b.safetensors/i2.png", Rating: 5},
+			}
+		})
+
+		It("maps the score report to the response", func() {
+			resp, err := votes.Scores(context.Background(), &genvotes.ScoresPayload{TrainingRun: "run"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.ImageCount).To(Equal(3))
+			Expect(resp.RatedCount).To(Equal(2))
+			Expect(resp.Leaderboard).To(Equal([]*genvotes.CheckpointScoreResponse{
+				{Rank: 1, CheckpointFilename: "b.safetensors", StepNumber: -1, ImageCount: 2, RatedCount: 1, AverageRating: 5},
+				{Rank: 2, CheckpointFilename: "a.safetensors", StepNumber: -1, ImageCount: 1, RatedCount: 1, AverageRating: 3},
+			}))
+			Expect(resp.Prompts).To(HaveLen(1))
+			Expect(resp.Prompts[0].PromptName).To(Equal("forest"))
+		})
+
+		It("serves the report as a CSV attachment", func() {
+			res, body, err := votes.ScoresCsv(context.Background(), &genvotes.ScoresCsvPayload{TrainingRun: "qwen/run"})
+			Expect(err).NotTo(HaveOccurred())
+			defer body.Close()
+			Expect(res.ContentType).To(Equal("text/csv; charset=utf-8"))
+			Expect(res.ContentDisposition).To(Equal(`attachment; filename="qwen_run-checkpoint-scores.csv"`))
+			data, err := io.ReadAll(body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(HavePrefix("scope,prompt_name,rank,checkpoint_filename,step,image_count,rated_count,average_rating\n"))
+		})
+
+		It("returns internal_error when scores are not configured", func() {
+			unconfigured := api.NewVotesService(service.NewVoteService(store, "/samples", logrus.New()))
+			_, err := unconfigured.Scores(context.Background(), &genvotes.ScoresPayload{TrainingRun: "run"})
+			Expect(errorName(err)).To(Equal("internal_error"))
+		})
+	})
+})
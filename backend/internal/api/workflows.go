@@ -2,10 +2,13 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	genworkflows "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/workflows"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 )
 
 // WorkflowService implements the workflows Goa service interface.
@@ -14,12 +17,20 @@ type WorkflowService struct {
 	enabled bool
 }
 
-// WorkflowLoader defines the interface for loading workflow templates.
+// WorkflowLoader defines the interface for loading and managing workflow
+// templates.
 type WorkflowLoader interface {
 	List(ctx context.Context) ([]model.WorkflowTemplate, error)
 	Get(ctx context.Context, name string) (model.WorkflowTemplate, error)
+	Save(ctx context.Context, name string, workflow map[string]interface{}, overwrite bool) (model.WorkflowTemplate, error)
+	Rename(ctx context.Context, name string, newName string) (model.WorkflowTemplate, error)
+	Delete(ctx context.Context, name string) error
 }
 
+// errWorkflowsDisabled is returned by the write methods when ComfyUI is not
+// configured.
+var errWorkflowsDisabled = fmt.Errorf("workflow management not available: ComfyUI is not configured")
+
 // NewWorkflowService creates a new workflows service.
 // If loader is nil, the service is disabled.
 func NewWorkflowService(loader WorkflowLoader) *WorkflowService {
@@ -48,6 +59,7 @@ func (s *WorkflowService) List(ctx context.Context) ([]*genworkflows.WorkflowSum
 			ValidationState: string(tmpl.ValidationState),
 			Roles:           tmpl.Roles,
 			Warnings:        tmpl.Warnings,
+			Defaults:        workflowDefaultsToResponse(tmpl.Defaults),
 		}
 	}
 
@@ -65,11 +77,209 @@ func (s *WorkflowService) Show(ctx context.Context, payload *genworkflows.ShowPa
 		return nil, genworkflows.MakeNotFound(err)
 	}
 
+	return workflowDetailsToResponse(tmpl), nil
+}
+
+// Upload implements the upload endpoint.
+func (s *WorkflowService) Upload(ctx context.Context, payload *genworkflows.UploadPayload) (*genworkflows.WorkflowDetails, error) {
+	if !s.enabled {
+		return nil, genworkflows.MakeServiceUnavailable(errWorkflowsDisabled)
+	}
+
+	tmpl, err := s.loader.Save(ctx, payload.Name, payload.Workflow, payload.Overwrite)
+	if err != nil {
+		return nil, workflowWriteError(err)
+	}
+	return workflowDetailsToResponse(tmpl), nil
+}
+
+// Rename implements the rename endpoint.
+func (s *WorkflowService) Rename(ctx context.Context, payload *genworkflows.RenamePayload) (*genworkflows.WorkflowDetails, error) {
+	if !s.enabled {
+		return nil, genworkflows.MakeServiceUnavailable(errWorkflowsDisabled)
+	}
+
+	tmpl, err := s.loader.Rename(ctx, payload.Name, payload.NewName)
+	if err != nil {
+		return nil, workflowWriteError(err)
+	}
+	return workflowDetailsToResponse(tmpl), nil
+}
+
+// Delete implements the delete endpoint.
+func (s *WorkflowService) Delete(ctx context.Context, payload *genworkflows.DeletePayload) error {
+	if !s.enabled {
+		return genworkflows.MakeServiceUnavailable(errWorkflowsDisabled)
+	}
+
+	if err := s.loader.Delete(ctx, payload.Name); err != nil {
+		return workflowWriteError(err)
+	}
+	return nil
+}
+
+// workflowWriteError maps an error of a workflow write to the service error
+// of its status code.
+func workflowWriteError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrWorkflowNotFound):
+		return genworkflows.MakeNotFound(err)
+	case errors.Is(err, service.ErrWorkflowExists), errors.Is(err, service.ErrWorkflowInUse):
+		return genworkflows.MakeConflict(err)
+	case errors.Is(err, service.ErrInvalidWorkflow),
+		strings.HasPrefix(err.Error(), "invalid workflow name"),
+		strings.HasPrefix(err.Error(), "workflow name must not be empty"):
+		return genworkflows.MakeInvalidPayload(err)
+	}
+	return genworkflows.MakeInternalError(err)
+}
+
+// Graph implements the graph endpoint.
+func (s *WorkflowService) Graph(ctx context.Context, payload *genworkflows.GraphPayload) (*genworkflows.WorkflowGraph, error) {
+	if !s.enabled {
+		return nil, genworkflows.MakeNotFound(fmt.Errorf("workflow not found: %s", payload.Name))
+	}
+
+	tmpl, err := s.loader.Get(ctx, payload.Name)
+	if err != nil {
+		return nil, genworkflows.MakeNotFound(err)
+	}
+
+	return workflowGraphToResponse(service.BuildWorkflowGraph(tmpl)), nil
+}
+
+// Preview implements the preview endpoint.
+func (s *WorkflowService) Preview(ctx context.Context, payload *genworkflows.PreviewPayload) (*genworkflows.WorkflowPreview, error) {
+	if !s.enabled {
+		return nil, genworkflows.MakeServiceUnavailable(errWorkflowsDisabled)
+	}
+
+	tmpl, err := s.loader.Get(ctx, payload.Name)
+	if err != nil {
+		return nil, genworkflows.MakeNotFound(err)
+	}
+
+	job, item := previewSample(payload)
+	preview, err := service.PreviewWorkflow(tmpl, job, item)
+	if err != nil {
+		return nil, genworkflows.MakeInvalidWorkflow(err)
+	}
+
+	resp := &genworkflows.WorkflowPreview{
+		Name:          preview.Name,
+		Workflow:      preview.Workflow,
+		Substitutions: make([]*genworkflows.WorkflowSubstitution, len(preview.Substitutions)),
+	}
+	for i, sub := range preview.Substitutions {
+		resp.Substitutions[i] = &genworkflows.WorkflowSubstitution{
+			NodeID:   sub.NodeID,
+			Role:     sub.Role,
+			Input:    sub.Input,
+			Previous: sub.Previous,
+			Value:    sub.Value,
+		}
+	}
+	return resp, nil
+}
+
+// previewSample returns the sample job and item a preview payload describes.
+// ComfyUI model paths use the separator of ComfyUI's host, so the checkpoint
+// filename is taken after the last slash or backslash.
+func previewSample(p *genworkflows.PreviewPayload) (model.SampleJob, model.SampleJobItem) {
+	job := model.SampleJob{
+		Shift:              p.Shift,
+		ControlNetStrength: p.ControlnetStrength,
+	}
+	if p.Vae != nil {
+		job.VAE = *p.Vae
+	}
+	if p.Clip != nil {
+		job.CLIP = *p.Clip
+	}
+	if p.InputImage != nil {
+		job.InputImage = *p.InputImage
+	}
+	if p.ControlnetModel != nil {
+		job.ControlNetModel = *p.ControlnetModel
+	}
+	if p.ControlnetImage != nil {
+		job.ControlNetImage = *p.ControlnetImage
+	}
+
+	item := model.SampleJobItem{
+		CheckpointFilename: p.Checkpoint[strings.LastIndexAny(p.Checkpoint, `/\`)+1:],
+		ComfyUIModelPath:   p.Checkpoint,
+		PromptText:         p.Prompt,
+		Steps:              p.Steps,
+		CFG:                p.Cfg,
+		SamplerName:        p.SamplerName,
+		Scheduler:          p.Scheduler,
+		Seed:               p.Seed,
+		Width:              p.Width,
+		Height:             p.Height,
+		Denoise:            p.Denoise,
+	}
+	if p.NegativePrompt != nil {
+		item.NegativePrompt = *p.NegativePrompt
+	}
+	return job, item
+}
+
+func workflowGraphToResponse(g model.WorkflowGraph) *genworkflows.WorkflowGraph {
+	resp := &genworkflows.WorkflowGraph{
+		Name:     g.Name,
+		Nodes:    make([]*genworkflows.WorkflowGraphNode, len(g.Nodes)),
+		Links:    make([]*genworkflows.WorkflowGraphLink, len(g.Links)),
+		Warnings: g.Warnings,
+	}
+	for i, n := range g.Nodes {
+		inputs := make([]*genworkflows.WorkflowGraphInput, len(n.Inputs))
+		for j, in := range n.Inputs {
+			inputs[j] = &genworkflows.WorkflowGraphInput{
+				Name:        in.Name,
+				Value:       in.Value,
+				Linked:      in.Linked,
+				Substituted: in.Substituted,
+			}
+		}
+		resp.Nodes[i] = &genworkflows.WorkflowGraphNode{
+			ID:        n.ID,
+			ClassType: n.ClassType,
+			Title:     n.Title,
+			Role:      n.Role,
+			KnownRole: n.KnownRole,
+			Inputs:    inputs,
+		}
+	}
+	for i, l := range g.Links {
+		resp.Links[i] = &genworkflows.WorkflowGraphLink{
+			FromNode:   l.FromNode,
+			FromOutput: l.FromOutput,
+			ToNode:     l.ToNode,
+			ToInput:    l.ToInput,
+		}
+	}
+	return resp
+}
+
+func workflowDetailsToResponse(tmpl model.WorkflowTemplate) *genworkflows.WorkflowDetails {
 	return &genworkflows.WorkflowDetails{
 		Name:            tmpl.Name,
 		ValidationState: string(tmpl.ValidationState),
 		Roles:           tmpl.Roles,
 		Warnings:        tmpl.Warnings,
+		Defaults:        workflowDefaultsToResponse(tmpl.Defaults),
 		Workflow:        tmpl.Workflow,
-	}, nil
+	}
+}
+
+func workflowDefaultsToResponse(d model.WorkflowDefaults) *genworkflows.WorkflowDefaults {
+	resp := &genworkflows.WorkflowDefaults{Shift: d.Shift}
+	if d.VAE != "" {
+		resp.Vae = &d.VAE
+	}
+	if d.CLIP != "" {
+		resp.Clip = &d.CLIP
+	}
+	return resp
 }
@@ -11,12 +11,17 @@ import (
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
 	genworkflows "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/workflows"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 )
 
 // mockWorkflowLoader implements the WorkflowLoader interface for testing
 type mockWorkflowLoader struct {
 	listFunc func(ctx context.Context) ([]model.WorkflowTemplate, error)
 	getFunc  func(ctx context.Context, name string) (model.WorkflowTemplate, error)
+
+	saveFunc   func(ctx context.Context, name string, workflow map[string]interface{}, overwrite bool) (model.WorkflowTemplate, error)
+	renameFunc func(ctx context.Context, name string, newName string) (model.WorkflowTemplate, error)
+	deleteFunc func(ctx context.Context, name string) error
 }
 
 func (m *mockWorkflowLoader) List(ctx context.Context) ([]model.WorkflowTemplate, error) {
@@ -33,6 +38,27 @@ func (m *mockWorkflowLoader) Get(ctx context.Context, name string) (model.Workfl
 	return model.WorkflowTemplate{}, fmt.Errorf("workflow not found: %s", name)
 }
 
+func (m *mockWorkflowLoader) Save(ctx context.Context, name string, workflow map[string]interface{}, overwrite bool) (model.WorkflowTemplate, error) {
+	if m.saveFunc != nil {
+		return m.saveFunc(ctx, name, workflow, overwrite)
+	}
+	return model.WorkflowTemplate{Name: name, Workflow: workflow}, nil
+}
+
+func (m *mockWorkflowLoader) Rename(ctx context.Context, name string, newName string) (model.WorkflowTemplate, error) {
+	if m.renameFunc != nil {
+		return m.renameFunc(ctx, name, newName)
+	}
+	return model.WorkflowTemplate{Name: newName}, nil
+}
+
+func (m *mockWorkflowLoader) Delete(ctx context.Context, name string) error {
+	if m.deleteFunc != nil {
+		return m.deleteFunc(ctx, name)
+	}
+	return nil
+}
+
 var _ = Describe("WorkflowService", func() {
 	var (
 		ctx context.Context
@@ -165,6 +191,87 @@ var _ = Describe("WorkflowService", func() {
 				Expect(summary.Roles["checkpoint_load"]).To(Equal([]string{"1"}))
 				Expect(summary.Warnings).To(Equal([]string{"warning 1", "warning 2"}))
 			})
+
+			It("maps workflow defaults, omitting unset values", func() {
+				shift := 3.1
+				mockLoader := &mockWorkflowLoader{
+					listFunc: func(ctx context.Context) ([]model.WorkflowTemplate, error) {
+						return []model.WorkflowTemplate{
+							{
+								Name:            "flux.json",
+								ValidationState: model.ValidationStateValid,
+								Roles:           map[string][]string{"save_image": {"9"}},
+								Warnings:        []string{},
+								Defaults:        model.WorkflowDefaults{VAE: "ae.safetensors", Shift: &shift},
+							},
+						}, nil
+					},
+				}
+
+				svc := api.NewWorkflowService(mockLoader)
+				result, err := svc.List(ctx)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(HaveLen(1))
+				Expect(result[0].Defaults).NotTo(BeNil())
+				Expect(result[0].Defaults.Vae).To(HaveValue(Equal("ae.safetensors")))
+				Expect(result[0].Defaults.Clip).To(BeNil())
+				Expect(result[0].Defaults.Shift).To(HaveValue(Equal(3.1)))
+			})
+		})
+	})
+
+	Describe("Graph", func() {
+		It("returns not found error when the service is disabled", func() {
+			svc := api.NewWorkflowService(nil)
+			_, err := svc.Graph(ctx, &genworkflows.GraphPayload{Name: "flux.json"})
+			Expect(err).To(MatchError(ContainSubstring("workflow not found")))
+		})
+
+		It("returns not found error when the workflow does not exist", func() {
+			svc := api.NewWorkflowService(&mockWorkflowLoader{})
+			_, err := svc.Graph(ctx, &genworkflows.GraphPayload{Name: "missing.json"})
+			Expect(err).To(MatchError(ContainSubstring("workflow not found")))
+		})
+
+		It("maps the graph of the workflow", func() {
+			mockLoader := &mockWorkflowLoader{
+				getFunc: func(ctx context.Context, name string) (model.WorkflowTemplate, error) {
+					return model.WorkflowTemplate{
+						Name: "flux.json",
+						Workflow: map[string]interface{}{
+							"9": map[string]interface{}{
+								"class_type": "SaveImage",
+								"inputs":     map[string]interface{}{"filename_prefix": "ComfyUI", "images": []interface{}{"8", float64(0)}},
+								"_meta":      map[string]interface{}{"cs_role": "save_image"},
+							},
+							"8": map[string]interface{}{
+								"class_type": "VAEDecode",
+								"inputs":     map[string]interface{}{},
+							},
+						},
+					}, nil
+				},
+			}
+
+			svc := api.NewWorkflowService(mockLoader)
+			result, err := svc.Graph(ctx, &genworkflows.GraphPayload{Name: "flux.json"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Name).To(Equal("flux.json"))
+			Expect(result.Warnings).To(BeEmpty())
+			Expect(result.Nodes).To(HaveLen(2))
+			Expect(result.Nodes[1].ID).To(Equal("9"))
+			Expect(result.Nodes[1].Role).To(Equal("save_image"))
+			Expect(result.Nodes[1].KnownRole).To(BeTrue())
+			Expect(result.Nodes[1].Inputs).To(HaveLen(2))
+			Expect(result.Nodes[1].Inputs[0].Name).To(Equal("filename_prefix"))
+			Expect(result.Nodes[1].Inputs[0].Value).To(Equal("ComfyUI"))
+			Expect(result.Nodes[1].Inputs[0].Substituted).To(BeTrue())
+			Expect(result.Nodes[1].Inputs[1].Linked).To(BeTrue())
+			Expect(result.Links).To(HaveLen(1))
+			Expect(*result.Links[0]).To(Equal(genworkflows.WorkflowGraphLink{
+				FromNode: "8", FromOutput: 0, ToNode: "9", ToInput: "images",
+			}))
 		})
 	})
 
@@ -259,6 +366,7 @@ var _ = Describe("WorkflowService", func() {
 								"save_image": {"10"},
 							},
 							Warnings: []string{"some warning"},
+							Defaults: model.WorkflowDefaults{CLIP: "clip_l.safetensors"},
 							Workflow: workflowData,
 						}, nil
 					},
@@ -273,6 +381,8 @@ var _ = Describe("WorkflowService", func() {
 				Expect(result.ValidationState).To(Equal("valid"))
 				Expect(result.Roles).To(HaveKey("save_image"))
 				Expect(result.Warnings).To(Equal([]string{"some warning"}))
+				Expect(result.Defaults.Clip).To(HaveValue(Equal("clip_l.safetensors")))
+				Expect(result.Defaults.Vae).To(BeNil())
 				Expect(result.Workflow).To(Equal(workflowData))
 			})
 		})
@@ -309,6 +419,131 @@ var _ = Describe("WorkflowService", func() {
 		})
 	})
 
+	Describe("Upload, Rename, and Delete", func() {
+		It("return service_unavailable when the service is disabled", func() {
+			svc := api.NewWorkflowService(nil)
+
+			_, err := svc.Upload(ctx, &genworkflows.UploadPayload{Name: "a.json", Workflow: map[string]any{}})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("service_unavailable"))
+			_, err = svc.Rename(ctx, &genworkflows.RenamePayload{Name: "a.json", NewName: "b.json"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("service_unavailable"))
+			err = svc.Delete(ctx, &genworkflows.DeletePayload{Name: "a.json"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("service_unavailable"))
+		})
+
+		It("uploads a workflow and returns its details", func() {
+			workflow := map[string]any{"9": map[string]any{"class_type": "SaveImage"}}
+			var gotOverwrite bool
+			mockLoader := &mockWorkflowLoader{
+				saveFunc: func(ctx context.Context, name string, wf map[string]interface{}, overwrite bool) (model.WorkflowTemplate, error) {
+					gotOverwrite = overwrite
+					return model.WorkflowTemplate{
+						Name:            "new.json",
+						ValidationState: model.ValidationStateValid,
+						Roles:           map[string][]string{"save_image": {"9"}},
+						Warnings:        []string{},
+						Workflow:        wf,
+					}, nil
+				},
+			}
+			svc := api.NewWorkflowService(mockLoader)
+
+			result, err := svc.Upload(ctx, &genworkflows.UploadPayload{Name: "new", Workflow: workflow, Overwrite: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gotOverwrite).To(BeTrue())
+			Expect(result.Name).To(Equal("new.json"))
+			Expect(result.Roles).To(HaveKeyWithValue("save_image", []string{"9"}))
+			Expect(result.Workflow).To(Equal(workflow))
+		})
+
+		DescribeTable("maps loader errors to service errors",
+			func(loaderErr error, expected string) {
+				mockLoader := &mockWorkflowLoader{
+					saveFunc: func(context.Context, string, map[string]interface{}, bool) (model.WorkflowTemplate, error) {
+						return model.WorkflowTemplate{}, loaderErr
+					},
+					renameFunc: func(context.Context, string, string) (model.WorkflowTemplate, error) {
+						return model.WorkflowTemplate{}, loaderErr
+					},
+					deleteFunc: func(context.Context, string) error {
+						return loaderErr
+					},
+				}
+				svc := api.NewWorkflowService(mockLoader)
+
+				_, err := svc.Upload(ctx, &genworkflows.UploadPayload{Name: "a.json", Workflow: map[string]any{}})
+				Expect(err.(errorNamer).ErrorName()).To(Equal(expected))
+				_, err = svc.Rename(ctx, &genworkflows.RenamePayload{Name: "a.json", NewName: "b.json"})
+				Expect(err.(errorNamer).ErrorName()).To(Equal(expected))
+				err = svc.Delete(ctx, &genworkflows.DeletePayload{Name: "a.json"})
+				Expect(err.(errorNamer).ErrorName()).To(Equal(expected))
+			},
+			Entry("not found", fmt.Errorf("%w: a.json", service.ErrWorkflowNotFound), "not_found"),
+			Entry("name taken", fmt.Errorf("%w: b.json", service.ErrWorkflowExists), "conflict"),
+			Entry("used by studies", fmt.Errorf("%w: a.json is used by Sweep", service.ErrWorkflowInUse), "conflict"),
+			Entry("invalid workflow", fmt.Errorf("%w: workflow has no nodes", service.ErrInvalidWorkflow), "invalid_payload"),
+			Entry("invalid name", errors.New("invalid workflow name: ../a"), "invalid_payload"),
+			Entry("other errors", errors.New("disk full"), "internal_error"),
+		)
+	})
+
+	Describe("Preview", func() {
+		It("returns service_unavailable when the service is disabled", func() {
+			svc := api.NewWorkflowService(nil)
+			_, err := svc.Preview(ctx, &genworkflows.PreviewPayload{Name: "a.json", Checkpoint: "a.safetensors"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("service_unavailable"))
+		})
+
+		It("returns not_found for an unknown workflow", func() {
+			svc := api.NewWorkflowService(&mockWorkflowLoader{})
+			_, err := svc.Preview(ctx, &genworkflows.PreviewPayload{Name: "missing.json", Checkpoint: "a.safetensors"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("not_found"))
+		})
+
+		It("substitutes the payload into the workflow", func() {
+			mockLoader := &mockWorkflowLoader{
+				getFunc: func(ctx context.Context, name string) (model.WorkflowTemplate, error) {
+					return model.WorkflowTemplate{
+						Name: name,
+						Workflow: map[string]interface{}{
+							"6": map[string]interface{}{"class_type": "CLIPTextEncode", "inputs": map[string]interface{}{"text": ""}},
+							"9": map[string]interface{}{"class_type": "SaveImage", "inputs": map[string]interface{}{"filename_prefix": "ComfyUI"}},
+						},
+						Roles: map[string][]string{"positive_prompt": {"6"}, "save_image": {"9"}},
+					}, nil
+				},
+			}
+			svc := api.NewWorkflowService(mockLoader)
+
+			result, err := svc.Preview(ctx, &genworkflows.PreviewPayload{
+				Name:       "flux.json",
+				Checkpoint: `flux\lora-step00001000.safetensors`,
+				Prompt:     "a cat",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Name).To(Equal("flux.json"))
+			Expect(result.Substitutions).To(HaveLen(2))
+			Expect(*result.Substitutions[0]).To(Equal(genworkflows.WorkflowSubstitution{NodeID: "6", Input: "text", Previous: "", Value: "a cat"}))
+			Expect(result.Substitutions[1].Value).To(Equal("sample_lora-step00001000"))
+		})
+
+		It("returns invalid_workflow when the values cannot be substituted", func() {
+			mockLoader := &mockWorkflowLoader{
+				getFunc: func(ctx context.Context, name string) (model.WorkflowTemplate, error) {
+					return model.WorkflowTemplate{
+						Name:     name,
+						Workflow: map[string]interface{}{"9": map[string]interface{}{"class_type": "SaveImage"}},
+						Roles:    map[string][]string{"save_image": {"9"}},
+					}, nil
+				},
+			}
+			svc := api.NewWorkflowService(mockLoader)
+
+			_, err := svc.Preview(ctx, &genworkflows.PreviewPayload{Name: "flux.json", Checkpoint: "a.safetensors"})
+			Expect(err.(errorNamer).ErrorName()).To(Equal("invalid_workflow"))
+		})
+	})
+
 	Describe("Error responses include Goa ServiceError structure", func() {
 		It("List returns ServiceError with proper fields on loader failure", func() {
 			mockLoader := &mockWorkflowLoader{
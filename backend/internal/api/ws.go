@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -45,6 +46,8 @@ func NewWSServiceWithPing(hub *service.Hub, pingInterval time.Duration, logger *
 // NewWSConnConfigurer returns a goahttp.ConnConfigureFunc that installs a
 // WebSocket ping goroutine on each new connection. The goroutine runs until
 // the context is cancelled or the connection fails to respond to a ping.
+// Each connection must also answer pings: reads fail once no pong has
+// arrived for wsPongWaitIntervals ping intervals.
 //
 // It is used by NewHTTPHandler to wire the configurer into the generated
 // WebSocket server so that every upgraded connection gets a pinger.
@@ -53,11 +56,56 @@ func NewWSConnConfigurer(pingInterval time.Duration, logger *logrus.Logger) goah
 		if pingInterval <= 0 {
 			return conn
 		}
+		expectPongs(conn, pingInterval)
 		go runPingLoop(conn, pingInterval, cancel, logger)
 		return conn
 	}
 }
 
+// wsPongWaitIntervals is how many ping intervals a connection may go without
+// answering a ping before it is considered stalled and closed.
+const wsPongWaitIntervals = 2
+
+// NewWSPushConnConfigurer is NewWSConnConfigurer for streams the server never
+// reads from, such as Subscribe. Pongs are only processed while reading, so
+// it also starts a goroutine that reads and discards whatever the client
+// sends, and cancels the context when the connection stalls or closes.
+func NewWSPushConnConfigurer(pingInterval time.Duration, logger *logrus.Logger) goahttp.ConnConfigureFunc {
+	heartbeat := NewWSConnConfigurer(pingInterval, logger)
+	return func(conn *websocket.Conn, cancel context.CancelFunc) *websocket.Conn {
+		conn = heartbeat(conn, cancel)
+		if pingInterval > 0 {
+			go discardReads(conn, cancel, logger)
+		}
+		return conn
+	}
+}
+
+// expectPongs closes the read side of conn when no pong arrives within
+// wsPongWaitIntervals ping intervals, so that a stalled client is
+// disconnected instead of lingering until a write to it fails.
+func expectPongs(conn *websocket.Conn, pingInterval time.Duration) {
+	pongWait := wsPongWaitIntervals * pingInterval
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+}
+
+// discardReads reads and drops messages from conn until a read fails, e.g.
+// because the pong deadline passed, and then cancels the context.
+func discardReads(conn *websocket.Conn, cancel context.CancelFunc, logger *logrus.Logger) {
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			if logger != nil {
+				logger.WithError(err).Debug("websocket read failed, closing connection")
+			}
+			cancel()
+			return
+		}
+	}
+}
+
 // runPingLoop sends periodic ping frames on conn at the given interval.
 // It cancels the context (triggering client disconnection) when a ping fails,
 // which happens when the connection is already closed.
@@ -93,152 +141,250 @@ func (s *WSService) Subscribe(ctx context.Context, stream genws.SubscribeServerS
 		return err
 	}
 
-	c := newStreamClient(stream)
-	s.hub.Register(c)
+	c := &streamClient{stream: stream}
+	removed := s.hub.Register(c)
 	defer func() {
 		s.hub.Unregister(c)
-		c.Close()
+		// Closing the stream also unblocks a write stalled on a slow client.
 		stream.Close()
 	}()
 
-	// Block until context is cancelled (client disconnects or server shutdown).
-	<-ctx.Done()
+	// Block until the client disconnects, the hub evicts it, or the server
+	// shuts down.
+	select {
+	case <-ctx.Done():
+	case <-removed:
+	}
 	return nil
 }
 
+// Stats returns the number of connected WebSocket clients and how many
+// events and clients the hub dropped because clients could not keep up.
+func (s *WSService) Stats(ctx context.Context) (*genws.WSStatsResponse, error) {
+	stats := s.hub.Stats()
+	return &genws.WSStatsResponse{
+		ClientCount:     stats.ClientCount,
+		DroppedMessages: stats.DroppedMessages,
+		EvictedClients:  stats.EvictedClients,
+	}, nil
+}
+
 // streamClient adapts a Goa SubscribeServerStream to service.HubClient.
 type streamClient struct {
 	stream genws.SubscribeServerStream
-	events chan model.FSEvent
-	done   chan struct{}
 }
 
-func newStreamClient(stream genws.SubscribeServerStream) *streamClient {
-	c := &streamClient{
-		stream: stream,
-		events: make(chan model.FSEvent, 64),
-		done:   make(chan struct{}),
-	}
-	go c.writePump()
-	return c
+// SendEvent writes an FSEvent to the WebSocket client. The hub calls it from
+// a single goroutine per client.
+func (c *streamClient) SendEvent(event model.FSEvent) bool {
+	return c.stream.Send(fsEventResponse(event)) == nil
 }
 
-// SendEvent queues an FSEvent for delivery to the WebSocket client.
-// Returns false if the client's buffer is full (slow client).
-func (c *streamClient) SendEvent(event model.FSEvent) bool {
-	select {
-	case c.events <- event:
-		return true
-	default:
-		return false
+// fsEventResponse converts an FSEvent to the message sent to protocol v1
+// clients, which is the payload of protocol v2 messages.
+func fsEventResponse(event model.FSEvent) *genws.FSEventResponse {
+	resp := &genws.FSEventResponse{
+		Type: string(event.Type),
+		Path: event.Path,
 	}
-}
 
-func (c *streamClient) writePump() {
-	defer close(c.done)
-	for event := range c.events {
-		resp := &genws.FSEventResponse{
-			Type: string(event.Type),
-			Path: event.Path,
+	// Include inference progress data when present
+	if event.InferenceProgressData != nil {
+		d := event.InferenceProgressData
+		resp.PromptID = &d.PromptID
+		resp.CurrentValue = &d.CurrentValue
+		resp.MaxValue = &d.MaxValue
+		// Include per-sample ETA in inference progress events so the frontend
+		// can display live sample ETA based on step completion rate.
+		if d.SampleETASeconds > 0 {
+			resp.SampleEtaSeconds = &d.SampleETASeconds
 		}
+	}
 
-		// Include inference progress data when present
-		if event.InferenceProgressData != nil {
-			d := event.InferenceProgressData
-			resp.PromptID = &d.PromptID
-			resp.CurrentValue = &d.CurrentValue
-			resp.MaxValue = &d.MaxValue
-			// Include per-sample ETA in inference progress events so the frontend
-			// can display live sample ETA based on step completion rate.
-			if d.SampleETASeconds > 0 {
-				resp.SampleEtaSeconds = &d.SampleETASeconds
-			}
+	// Include job progress data when present
+	if event.JobProgressData != nil {
+		d := event.JobProgressData
+		resp.JobID = &d.JobID
+		resp.Status = &d.Status
+		resp.TotalItems = &d.TotalItems
+		resp.CompletedItems = &d.CompletedItems
+		resp.FailedItems = &d.FailedItems
+		resp.PendingItems = &d.PendingItems
+		resp.SkippedItems = skippedItems(d.SkippedItems)
+		resp.CheckpointsCompleted = &d.CheckpointsCompleted
+		resp.TotalCheckpoints = &d.TotalCheckpoints
+		if d.CurrentCheckpoint != "" {
+			resp.CurrentCheckpoint = &d.CurrentCheckpoint
 		}
-
-		// Include job progress data when present
-		if event.JobProgressData != nil {
-			d := event.JobProgressData
-			resp.JobID = &d.JobID
-			resp.Status = &d.Status
-			resp.TotalItems = &d.TotalItems
-			resp.CompletedItems = &d.CompletedItems
-			resp.FailedItems = &d.FailedItems
-			resp.PendingItems = &d.PendingItems
-			resp.CheckpointsCompleted = &d.CheckpointsCompleted
-			resp.TotalCheckpoints = &d.TotalCheckpoints
-			if d.CurrentCheckpoint != "" {
-				resp.CurrentCheckpoint = &d.CurrentCheckpoint
-			}
-			if d.CurrentCheckpointTotal > 0 {
-				resp.CurrentCheckpointProgress = &d.CurrentCheckpointProgress
-				resp.CurrentCheckpointTotal = &d.CurrentCheckpointTotal
-			}
-			// Map checkpoint completeness data
-			if len(d.CheckpointCompleteness) > 0 {
-				completeness := make([]*genws.CheckpointCompletenessInfo, len(d.CheckpointCompleteness))
-				for i, info := range d.CheckpointCompleteness {
-					completeness[i] = &genws.CheckpointCompletenessInfo{
-						Checkpoint: info.Checkpoint,
-						Expected:   info.Expected,
-						Verified:   info.Verified,
-						Missing:    info.Missing,
-					}
+		if d.CurrentCheckpointTotal > 0 {
+			resp.CurrentCheckpointProgress = &d.CurrentCheckpointProgress
+			resp.CurrentCheckpointTotal = &d.CurrentCheckpointTotal
+		}
+		// Map checkpoint completeness data
+		if len(d.CheckpointCompleteness) > 0 {
+			completeness := make([]*genws.CheckpointCompletenessInfo, len(d.CheckpointCompleteness))
+			for i, info := range d.CheckpointCompleteness {
+				completeness[i] = &genws.CheckpointCompletenessInfo{
+					Checkpoint: info.Checkpoint,
+					Expected:   info.Expected,
+					Verified:   info.Verified,
+					Missing:    info.Missing,
 				}
-				resp.CheckpointCompleteness = completeness
 			}
-			// Map ETA fields (only when non-zero, meaning an estimate is available)
-			if d.SampleETASeconds > 0 {
-				resp.SampleEtaSeconds = &d.SampleETASeconds
-			}
-			if d.JobETASeconds > 0 {
-				resp.JobEtaSeconds = &d.JobETASeconds
+			resp.CheckpointCompleteness = completeness
+		}
+		// Map ETA fields (only when non-zero, meaning an estimate is available)
+		if d.SampleETASeconds > 0 {
+			resp.SampleEtaSeconds = &d.SampleETASeconds
+		}
+		if d.JobETASeconds > 0 {
+			resp.JobEtaSeconds = &d.JobETASeconds
+		}
+		// Map current sample generation parameters (present only when actively running)
+		if d.CurrentSampleParams != nil {
+			p := d.CurrentSampleParams
+			resp.CurrentSampleParams = &genws.WSSampleParams{
+				CheckpointFilename: p.CheckpointFilename,
+				PromptName:         p.PromptName,
+				Cfg:                p.CFG,
+				Steps:              p.Steps,
+				SamplerName:        p.SamplerName,
+				Scheduler:          p.Scheduler,
+				Seed:               p.Seed,
+				Width:              p.Width,
+				Height:             p.Height,
 			}
-			// Map current sample generation parameters (present only when actively running)
-			if d.CurrentSampleParams != nil {
-				p := d.CurrentSampleParams
-				resp.CurrentSampleParams = &genws.WSSampleParams{
-					CheckpointFilename: p.CheckpointFilename,
-					PromptName:         p.PromptName,
-					Cfg:                p.CFG,
-					Steps:              p.Steps,
-					SamplerName:        p.SamplerName,
-					Scheduler:          p.Scheduler,
-					Seed:               p.Seed,
-					Width:              p.Width,
-					Height:             p.Height,
+		}
+		// Map failed item details with structured error info
+		if len(d.FailedItemDetails) > 0 {
+			details := make([]*genws.WSFailedItemDetail, len(d.FailedItemDetails))
+			for i, detail := range d.FailedItemDetails {
+				fd := &genws.WSFailedItemDetail{
+					CheckpointFilename: detail.CheckpointFilename,
+					ErrorMessage:       detail.ErrorMessage,
 				}
-			}
-			// Map failed item details with structured error info
-			if len(d.FailedItemDetails) > 0 {
-				details := make([]*genws.WSFailedItemDetail, len(d.FailedItemDetails))
-				for i, detail := range d.FailedItemDetails {
-					fd := &genws.WSFailedItemDetail{
-						CheckpointFilename: detail.CheckpointFilename,
-						ErrorMessage:       detail.ErrorMessage,
-					}
-					if detail.ExceptionType != "" {
-						fd.ExceptionType = &detail.ExceptionType
-					}
-					if detail.NodeType != "" {
-						fd.NodeType = &detail.NodeType
-					}
-					if detail.Traceback != "" {
-						fd.Traceback = &detail.Traceback
-					}
-					details[i] = fd
+				if detail.ExceptionType != "" {
+					fd.ExceptionType = &detail.ExceptionType
+				}
+				if detail.NodeType != "" {
+					fd.NodeType = &detail.NodeType
 				}
-				resp.FailedItemDetails = details
+				if detail.Traceback != "" {
+					fd.Traceback = &detail.Traceback
+				}
+				details[i] = fd
 			}
+			resp.FailedItemDetails = details
 		}
+	}
+	return resp
+}
 
-		if err := c.stream.Send(resp); err != nil {
-			return
+// wsProtocolVersion is the version of the protocol spoken on /api/ws/v2.
+const wsProtocolVersion = 2
+
+// SubscribeV2 registers the caller as a protocol v2 WebSocket client and
+// streams the events its subscription selects, each wrapped in a WSMessage
+// envelope, until the client disconnects. The initial subscription comes
+// from the query parameters; each WSSubscription the client sends replaces
+// it and is acknowledged with a subscribed message.
+//
+// As in Subscribe, a connected message is sent right away to trigger the
+// HTTP 101 upgrade.
+func (s *WSService) SubscribeV2(ctx context.Context, p *genws.SubscribeV2Payload, stream genws.SubscribeV2ServerStream) error {
+	sub := model.EventSubscription{
+		JobIDs:       p.JobID,
+		TrainingRuns: p.TrainingRun,
+		FSEvents:     p.FsEvents,
+	}
+	if err := stream.Send(subscriptionMessage("connected", sub)); err != nil {
+		return err
+	}
+
+	c := &v2StreamClient{stream: stream}
+	removed := s.hub.RegisterWithSubscription(c, sub)
+	defer func() {
+		s.hub.Unregister(c)
+		// Closing the stream also unblocks a write stalled on a slow client.
+		stream.Close()
+	}()
+
+	// Read subscription changes until the client disconnects.
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			sub := model.EventSubscription{
+				JobIDs:       msg.JobIds,
+				TrainingRuns: msg.TrainingRuns,
+				FSEvents:     msg.FsEvents,
+			}
+			s.hub.Subscribe(c, sub)
+			c.send(subscriptionMessage("subscribed", sub))
 		}
+	}()
+
+	select {
+	case <-ctx.Done():
+	case <-disconnected:
+	case <-removed:
+	}
+	return nil
+}
+
+// subscriptionMessage returns a protocol v2 message of type msgType carrying
+// sub.
+func subscriptionMessage(msgType string, sub model.EventSubscription) *genws.WSMessage {
+	return &genws.WSMessage{
+		Version: wsProtocolVersion,
+		Type:    msgType,
+		Subscription: &genws.WSSubscription{
+			JobIds:       sub.JobIDs,
+			TrainingRuns: sub.TrainingRuns,
+			FsEvents:     sub.FSEvents,
+		},
 	}
 }
 
-// Close stops the write pump.
-func (c *streamClient) Close() {
-	close(c.events)
-	<-c.done
+// eventMessage wraps an FSEvent in a protocol v2 message.
+func eventMessage(event model.FSEvent) *genws.WSMessage {
+	msg := &genws.WSMessage{
+		Version: wsProtocolVersion,
+		Type:    string(event.Type),
+		Payload: fsEventResponse(event),
+	}
+	if event.JobID != "" {
+		jobID := event.JobID
+		msg.JobID = &jobID
+	}
+	if event.TrainingRun != "" {
+		trainingRun := event.TrainingRun
+		msg.TrainingRun = &trainingRun
+	}
+	return msg
+}
+
+// v2StreamClient adapts a Goa SubscribeV2ServerStream to service.HubClient.
+// Writes are serialized, since the hub's event delivery and subscription
+// acknowledgements write from different goroutines and a WebSocket
+// connection allows only one concurrent writer.
+type v2StreamClient struct {
+	mu     sync.Mutex
+	stream genws.SubscribeV2ServerStream
+}
+
+// SendEvent writes an FSEvent to the WebSocket client.
+func (c *v2StreamClient) SendEvent(event model.FSEvent) bool {
+	return c.send(eventMessage(event))
+}
+
+// send writes a message to the WebSocket client.
+func (c *v2StreamClient) send(msg *genws.WSMessage) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stream.Send(msg) == nil
 }
@@ -3,6 +3,9 @@ package api_test
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -52,6 +55,57 @@ func (m *mockSubscribeServerStream) Sent() []*genws.FSEventResponse {
 	return result
 }
 
+// mockSubscribeV2ServerStream captures Send calls and replays queued
+// subscription messages on Recv. Recv fails once recv is closed.
+type mockSubscribeV2ServerStream struct {
+	mu      sync.Mutex
+	sent    []*genws.WSMessage
+	sendErr error
+	recv    chan *genws.WSSubscription
+}
+
+func newMockSubscribeV2ServerStream() *mockSubscribeV2ServerStream {
+	return &mockSubscribeV2ServerStream{recv: make(chan *genws.WSSubscription, 4)}
+}
+
+func (m *mockSubscribeV2ServerStream) Send(v *genws.WSMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sendErr != nil {
+		return m.sendErr
+	}
+	m.sent = append(m.sent, v)
+	return nil
+}
+
+func (m *mockSubscribeV2ServerStream) SendWithContext(_ context.Context, v *genws.WSMessage) error {
+	return m.Send(v)
+}
+
+func (m *mockSubscribeV2ServerStream) Recv() (*genws.WSSubscription, error) {
+	msg, ok := <-m.recv
+	if !ok {
+		return nil, errors.New("connection closed")
+	}
+	return msg, nil
+}
+
+func (m *mockSubscribeV2ServerStream) RecvWithContext(_ context.Context) (*genws.WSSubscription, error) {
+	return m.Recv()
+}
+
+func (m *mockSubscribeV2ServerStream) Close() error {
+	return nil
+}
+
+func (m *mockSubscribeV2ServerStream) Sent() []*genws.WSMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]*genws.WSMessage, len(m.sent))
+	copy(result, m.sent)
+	return result
+}
+
 // mockPingableConn implements api.PingableConn for unit testing runPingLoop
 // without a real WebSocket connection.
 type mockPingableConn struct {
@@ -212,6 +266,139 @@ var _ = Describe("WSService", func() {
 			cancel()
 			<-done
 		})
+
+		It("returns when the hub evicts the client", func() {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				svc.Subscribe(context.Background(), stream) //nolint:errcheck
+			}()
+			Eventually(hub.ClientCount).Should(Equal(1))
+
+			stream.mu.Lock()
+			stream.sendErr = errors.New("broken pipe")
+			stream.mu.Unlock()
+			hub.Broadcast(model.FSEvent{Type: "image_added", Path: "test.png"})
+
+			Eventually(done).Should(BeClosed())
+			Expect(hub.ClientCount()).To(Equal(0))
+		})
+	})
+
+	Describe("Stats", func() {
+		It("returns the hub's client count and dropped counters", func() {
+			hub.Register(&failingHubClient{})
+			hub.Register(&failingHubClient{})
+			hub.Broadcast(model.FSEvent{Type: "image_added", Path: "test.png"})
+			Eventually(hub.ClientCount).Should(Equal(0))
+			hub.Register(&failingHubClient{})
+
+			stats, err := svc.Stats(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats.ClientCount).To(Equal(1))
+			Expect(stats.DroppedMessages).To(Equal(int64(2)))
+			Expect(stats.EvictedClients).To(Equal(int64(2)))
+		})
+	})
+})
+
+// failingHubClient is a hub client whose writes always fail.
+type failingHubClient struct {
+	_ int // non-zero size, so that each client has its own address
+}
+
+func (c *failingHubClient) SendEvent(model.FSEvent) bool { return false }
+
+var _ = Describe("WSService protocol v2", func() {
+	var (
+		hub    *service.Hub
+		svc    *api.WSService
+		stream *mockSubscribeV2ServerStream
+	)
+
+	BeforeEach(func() {
+		logger := logrus.New()
+		logger.SetOutput(GinkgoWriter)
+		hub = service.NewHub(logger)
+		svc = api.NewWSService(hub)
+		stream = newMockSubscribeV2ServerStream()
+	})
+
+	It("sends a connected message with the subscription from the query parameters", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		Expect(svc.SubscribeV2(ctx, &genws.SubscribeV2Payload{JobID: []string{"job-1"}}, stream)).To(Succeed())
+
+		sent := stream.Sent()
+		Expect(sent).To(HaveLen(1))
+		Expect(sent[0].Version).To(Equal(2))
+		Expect(sent[0].Type).To(Equal("connected"))
+		Expect(sent[0].Subscription.JobIds).To(Equal([]string{"job-1"}))
+	})
+
+	It("delivers only subscribed events, wrapped in an envelope", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			svc.SubscribeV2(ctx, &genws.SubscribeV2Payload{JobID: []string{"job-1"}}, stream) //nolint:errcheck
+		}()
+		Eventually(hub.ClientCount).Should(Equal(1))
+
+		hub.Broadcast(model.FSEvent{Type: model.EventImageAdded, Path: "run/study/cp/a.png"})
+		hub.Broadcast(model.FSEvent{Type: model.EventJobProgress, Path: "job_progress/job-1", JobID: "job-1", TrainingRun: "run",
+			JobProgressData: &model.JobProgressEventData{JobID: "job-1", Status: "running"}})
+
+		Eventually(func() int { return len(stream.Sent()) }).Should(Equal(2))
+		msg := stream.Sent()[1]
+		Expect(msg.Type).To(Equal("job_progress"))
+		Expect(*msg.JobID).To(Equal("job-1"))
+		Expect(*msg.TrainingRun).To(Equal("run"))
+		Expect(msg.Payload.Path).To(Equal("job_progress/job-1"))
+		Expect(*msg.Payload.Status).To(Equal("running"))
+
+		cancel()
+		<-done
+	})
+
+	It("replaces the subscription when the client sends one", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			svc.SubscribeV2(ctx, &genws.SubscribeV2Payload{JobID: []string{"job-1"}}, stream) //nolint:errcheck
+		}()
+		Eventually(hub.ClientCount).Should(Equal(1))
+
+		stream.recv <- &genws.WSSubscription{FsEvents: true}
+		Eventually(func() int { return len(stream.Sent()) }).Should(Equal(2))
+		Expect(stream.Sent()[1].Type).To(Equal("subscribed"))
+		Expect(stream.Sent()[1].Subscription.FsEvents).To(BeTrue())
+
+		hub.Broadcast(model.FSEvent{Type: model.EventJobProgress, JobID: "job-1"})
+		hub.Broadcast(model.FSEvent{Type: model.EventImageAdded, Path: "run/study/cp/a.png"})
+		Eventually(func() int { return len(stream.Sent()) }).Should(Equal(3))
+		Expect(stream.Sent()[2].Type).To(Equal("image_added"))
+
+		cancel()
+		<-done
+	})
+
+	It("unregisters the client when it disconnects", func() {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			svc.SubscribeV2(context.Background(), &genws.SubscribeV2Payload{}, stream) //nolint:errcheck
+		}()
+		Eventually(hub.ClientCount).Should(Equal(1))
+
+		close(stream.recv)
+
+		Eventually(done).Should(BeClosed())
+		Expect(hub.ClientCount()).To(Equal(0))
 	})
 })
 
@@ -280,6 +467,30 @@ var _ = Describe("WebSocket ping loop", func() {
 		Eventually(cancelled.Load, 500*time.Millisecond, 5*time.Millisecond).Should(BeTrue())
 	})
 
+	It("closes a push connection whose client stops answering pings", func() {
+		cancelled := make(chan struct{})
+		configurer := api.NewWSPushConnConfigurer(10*time.Millisecond, logger)
+		upgrader := websocket.Upgrader{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			var once sync.Once
+			configurer(conn, func() { once.Do(func() { close(cancelled) }) })
+			<-cancelled
+		}))
+		defer server.Close()
+
+		// The client never reads, so it never answers the server's pings.
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		Eventually(cancelled, 500*time.Millisecond).Should(BeClosed())
+	})
+
 	// AC: Ping interval is configurable via config.yaml — configurer respects interval.
 	It("NewWSConnConfigurer returns a no-op when interval is zero", func() {
 		configurer := api.NewWSConnConfigurer(0, logger)
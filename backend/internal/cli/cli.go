@@ -0,0 +1,209 @@
+// Package cli implements cs-cli, a command-line client of the checkpoint
+// sampler API for scripted workflows such as nightly sweeps.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/pkg/client"
+)
+
+// defaultServer is the server used when neither -server nor CS_SERVER is set.
+const defaultServer = "http://localhost:8080"
+
+// tokenEnv names the environment variable holding the default -token.
+const tokenEnv = "CS_TOKEN"
+
+// errUsage is returned for invalid command lines, after the usage has been
+// written to stderr.
+var errUsage = errors.New("invalid usage")
+
+// command is a cs-cli subcommand, e.g. "jobs create".
+type command struct {
+	args  string // argument synopsis shown in the usage
+	short string // one-line description shown in the usage
+	run   func(ctx context.Context, env *env, args []string) error
+}
+
+// env is what a command runs with.
+type env struct {
+	name   string // command name, e.g. "jobs create"
+	args   string // argument synopsis of the command
+	client *client.Client
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// commands maps "<group> <action>" to its command.
+var commands = map[string]command{
+	"runs list":        {"[-source checkpoints|samples]", "List training runs", runsList},
+	"runs checkpoints": {"<run>", "List the checkpoints of a training run", runsCheckpoints},
+	"studies list":     {"[-owner <user>|all]", "List studies", studiesList},
+	"studies show":     {"<id|name>", "Print a study as JSON", studiesShow},
+	"studies create":   {"-f <file>", "Create a study from a JSON file in the POST /api/studies body format", studiesCreate},
+	"studies export":   {"[-o <file>] [<id|name>]", "Export one study, or all studies, as a portable JSON document", studiesExport},
+	"studies import":   {"-f <file> [-on-conflict rename|overwrite|skip]", "Import the studies of an exported document", studiesImport},
+	"jobs list":        {"[-limit <n>] [-owner <user>|all] [-label <key>[=<value>]]...", "List sample jobs, newest first", jobsList},
+	"jobs show":        {"<id>", "Print a sample job and its progress as JSON", jobsShow},
+	"jobs create":      {"-run <run> -study <id|name> [options]", "Create a sample job", jobsCreate},
+	"jobs tail":        {"<id>", "Follow the progress of a sample job until it finishes", jobsTail},
+	"jobs export":      {"-o <dir> <id>", "Write a sample job, its items, and its images to a directory", jobsExport},
+}
+
+// Run runs the cs-cli command line args (without the program name). Command
+// output is written to stdout, and usage to stderr.
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("cs-cli", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { usage(stderr) }
+	server := fs.String("server", serverFromEnv(), "Server URL (default $CS_SERVER, then "+defaultServer+")")
+	token := fs.String("token", "", "Token of the user to act as, for servers with users configured (default $"+tokenEnv+")")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+	if fs.NArg() < 2 {
+		usage(stderr)
+		return errUsage
+	}
+	name := fs.Arg(0) + " " + fs.Arg(1)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		usage(stderr)
+		return errUsage
+	}
+
+	if *token == "" {
+		*token = os.Getenv(tokenEnv)
+	}
+	c, err := client.New(client.Config{ServerURL: *server, Token: *token})
+	if err != nil {
+		return err
+	}
+	err = cmd.run(ctx, &env{name: name, args: cmd.args, client: c, stdout: stdout, stderr: stderr}, fs.Args()[2:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+// IsUsageError reports whether err was returned by Run for an invalid
+// command line.
+func IsUsageError(err error) bool {
+	return errors.Is(err, errUsage)
+}
+
+func serverFromEnv() string {
+	if s := os.Getenv("CS_SERVER"); s != "" {
+		return s
+	}
+	return defaultServer
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: cs-cli [-server <url>] [-token <token>] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(w, "  %s %s\n        %s\n", name, cmd.args, cmd.short)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run cs-cli <command> -h for the options of a command.")
+}
+
+// newFlagSet returns the flag set of env's command, writing its usage to
+// env's stderr.
+func newFlagSet(env *env) *flag.FlagSet {
+	fs := flag.NewFlagSet(env.name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "Usage: cs-cli %s %s\n", env.name, env.args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses a command's arguments and checks that nargs positional
+// arguments are left. It returns flag.ErrHelp when -h was given.
+func parseFlags(fs *flag.FlagSet, args []string, nargs int) error {
+	return parseFlagsRange(fs, args, nargs, nargs)
+}
+
+// parseFlagsRange is parseFlags for commands taking between min and max
+// positional arguments.
+func parseFlagsRange(fs *flag.FlagSet, args []string, min, max int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() < min || fs.NArg() > max {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// parseInts parses a comma-separated list of integers such as "20,30".
+func parseInts(s string) ([]int, error) {
+	var ints []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer list %q", s)
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeJSONOutput writes v as indented JSON to the named file, or to env's
+// stdout when name is empty.
+func writeJSONOutput(env *env, name string, v any) error {
+	if name == "" {
+		return writeJSON(env.stdout, v)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(f, v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readInput reads the named file, or stdin when name is "-".
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
@@ -0,0 +1,286 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/cli"
+)
+
+// study returns a study as the API serves it.
+func study(id, name string, steps []int) map[string]any {
+	return map[string]any{
+		"id": id, "name": name, "version": 1,
+		"prompt_prefix": "", "prompts": []map[string]any{{"name": "forest", "text": "a forest"}}, "negative_prompt": "",
+		"steps": steps, "cfgs": []float64{7}, "sampler_scheduler_pairs": []map[string]any{{"sampler": "euler", "scheduler": "simple"}},
+		"seeds": []int64{420}, "seed_mode": "explicit", "random_seed_count": 0, "width": 1024, "height": 1024,
+		"workflow_template": "qwen-image.json", "vae": "", "text_encoder": "", "input_image": "",
+		"denoise_strengths": []float64{}, "wildcards": []map[string]any{}, "resolutions": []map[string]any{}, "images_per_checkpoint": len(steps),
+		"created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-01T00:00:00Z",
+	}
+}
+
+// job returns a sample job as the API serves it.
+func job(id, status string, completed, total int) map[string]any {
+	return map[string]any{
+		"id": id, "training_run_name": "my-lora", "study_id": "s1", "study_name": "Sweep", "study_version": 1,
+		"workflow_name": "qwen-image.json", "input_image": "", "status": status,
+		"total_items": total, "completed_items": completed, "failed_items": 0, "pending_items": total - completed,
+		"checkpoint_filenames": []string{}, "append_new_checkpoints": false, "output_format": "png",
+		"created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-01T00:00:00Z",
+	}
+}
+
+// item returns a sample job item as the API serves it.
+func item(id string, imagePath string) map[string]any {
+	it := map[string]any{
+		"id": id, "checkpoint_filename": "my-lora-step00001000.safetensors", "prompt_name": "forest",
+		"steps": 20, "cfg": 7, "sampler_name": "euler", "scheduler": "simple", "seed": 420,
+		"width": 1024, "height": 1024, "status": "pending",
+	}
+	if imagePath != "" {
+		it["status"] = "completed"
+		it["image_path"] = imagePath
+	}
+	return it
+}
+
+func serveJSON(status int, v any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		Expect(json.NewEncoder(w).Encode(v)).To(Succeed())
+	}
+}
+
+var _ = Describe("cs-cli", func() {
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+		stdout *bytes.Buffer
+		stderr *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+	})
+
+	run := func(args ...string) error {
+		return cli.Run(context.Background(), append([]string{"-server", server.URL}, args...), stdout, stderr)
+	}
+
+	It("rejects unknown commands with a usage error", func() {
+		err := run("jobs", "frobnicate")
+		Expect(cli.IsUsageError(err)).To(BeTrue())
+		Expect(stderr.String()).To(ContainSubstring(`unknown command "jobs frobnicate"`))
+	})
+
+	It("lists the checkpoints of a training run", func() {
+		mux.HandleFunc("GET /api/training-runs", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("source")).To(Equal("checkpoints"))
+			serveJSON(http.StatusOK, []map[string]any{{
+				"id": 0, "name": "my-lora", "checkpoint_count": 2, "has_samples": true,
+				"checkpoints": []map[string]any{
+					{"filename": "my-lora-step00001000.safetensors", "step_number": 1000, "has_samples": true},
+					{"filename": "my-lora.safetensors", "step_number": -1, "has_samples": false},
+				},
+			}})(w, r)
+		})
+
+		Expect(run("runs", "checkpoints", "my-lora")).To(Succeed())
+		Expect(stdout.String()).To(MatchRegexp(`my-lora-step00001000\.safetensors\s+1000\s+yes`))
+		Expect(stdout.String()).To(MatchRegexp(`my-lora\.safetensors\s+-\s+no`))
+	})
+
+	It("lists the jobs carrying the given labels", func() {
+		mux.HandleFunc("GET /api/sample-jobs", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query()["label"]).To(Equal([]string{"lr=1e-5", "baseline"}))
+			w.Header().Set("X-Total-Count", "1")
+			serveJSON(http.StatusOK, []any{job("j1", "completed", 8, 8)})(w, r)
+		})
+
+		Expect(run("jobs", "list", "-label", "lr=1e-5", "-label", "baseline")).To(Succeed())
+		Expect(stdout.String()).To(MatchRegexp(`j1\s+completed`))
+	})
+
+	Describe("jobs create", func() {
+		var created map[string]any
+
+		BeforeEach(func() {
+			created = nil
+			mux.HandleFunc("POST /api/sample-jobs", func(w http.ResponseWriter, r *http.Request) {
+				Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+				serveJSON(http.StatusCreated, job("j1", "pending", 0, 8))(w, r)
+			})
+		})
+
+		It("creates a job of the named study with the given checkpoint selection", func() {
+			mux.HandleFunc("GET /api/studies", serveJSON(http.StatusOK, []any{study("s1", "Sweep", []int{20})}))
+
+			Expect(run("jobs", "create", "-run", "my-lora", "-study", "Sweep", "-every-nth", "4", "-min-step", "1000")).To(Succeed())
+			Expect(created).To(HaveKeyWithValue("training_run_name", "my-lora"))
+			Expect(created).To(HaveKeyWithValue("study_id", "s1"))
+			Expect(created).To(HaveKeyWithValue("every_nth", BeNumerically("==", 4)))
+			Expect(created).To(HaveKeyWithValue("min_step", BeNumerically("==", 1000)))
+			Expect(created).NotTo(HaveKey("max_step"))
+			Expect(stdout.String()).To(ContainSubstring("Created job j1: 8 items"))
+		})
+
+		It("passes repeated -workflow flags as workflow overrides", func() {
+			mux.HandleFunc("GET /api/studies", serveJSON(http.StatusOK, []any{study("s1", "Sweep", []int{20})}))
+
+			Expect(run("jobs", "create", "-run", "my-lora", "-study", "Sweep",
+				"-workflow", "*-flux-*=flux-dev.json", "-workflow", "*-sd3-*=sd3.json")).To(Succeed())
+			Expect(created).To(HaveKeyWithValue("workflow_overrides", []any{
+				map[string]any{"pattern": "*-flux-*", "workflow": "flux-dev.json"},
+				map[string]any{"pattern": "*-sd3-*", "workflow": "sd3.json"},
+			}))
+		})
+
+		It("records the -notes and repeated -label flags with the job", func() {
+			mux.HandleFunc("GET /api/studies", serveJSON(http.StatusOK, []any{study("s1", "Sweep", []int{20})}))
+
+			Expect(run("jobs", "create", "-run", "my-lora", "-study", "Sweep",
+				"-notes", "bad data mix", "-label", "lr=1e-5", "-label", "baseline=")).To(Succeed())
+			Expect(created).To(HaveKeyWithValue("notes", "bad data mix"))
+			Expect(created).To(HaveKeyWithValue("labels", map[string]any{"lr": "1e-5", "baseline": ""}))
+		})
+
+		It("samples other step counts with a derived study", func() {
+			mux.HandleFunc("GET /api/studies", serveJSON(http.StatusOK, []any{study("s1", "Sweep", []int{20})}))
+			var derived map[string]any
+			mux.HandleFunc("POST /api/studies", func(w http.ResponseWriter, r *http.Request) {
+				Expect(json.NewDecoder(r.Body).Decode(&derived)).To(Succeed())
+				serveJSON(http.StatusCreated, study("s2", "Sweep (steps 20,30)", []int{20, 30}))(w, r)
+			})
+
+			Expect(run("jobs", "create", "-run", "my-lora", "-study", "s1", "-steps", "20,30")).To(Succeed())
+			Expect(derived).To(HaveKeyWithValue("name", "Sweep (steps 20,30)"))
+			Expect(derived).To(HaveKeyWithValue("steps", ConsistOf(BeNumerically("==", 20), BeNumerically("==", 30))))
+			Expect(derived).To(HaveKeyWithValue("workflow_template", "qwen-image.json"))
+			Expect(created).To(HaveKeyWithValue("study_id", "s2"))
+		})
+
+		It("reuses an unchanged derived study", func() {
+			mux.HandleFunc("GET /api/studies", serveJSON(http.StatusOK, []any{
+				study("s1", "Sweep", []int{20}),
+				study("s2", "Sweep (steps 20,30)", []int{20, 30}),
+			}))
+			mux.HandleFunc("POST /api/studies", func(w http.ResponseWriter, r *http.Request) {
+				Fail("the derived study should not be created again")
+			})
+			mux.HandleFunc("PUT /api/studies/{id}", func(w http.ResponseWriter, r *http.Request) {
+				Fail("an unchanged derived study should not be updated")
+			})
+
+			Expect(run("jobs", "create", "-run", "my-lora", "-study", "Sweep", "-steps", "20,30")).To(Succeed())
+			Expect(created).To(HaveKeyWithValue("study_id", "s2"))
+		})
+	})
+
+	Describe("jobs tail", func() {
+		var events []map[string]any
+
+		BeforeEach(func() {
+			events = nil
+			mux.HandleFunc("GET /api/sample-jobs/{id}", serveJSON(http.StatusOK, map[string]any{
+				"job":      job("j1", "running", 1, 3),
+				"progress": map[string]any{"checkpoints_completed": 0, "total_checkpoints": 1},
+			}))
+			upgrader := websocket.Upgrader{}
+			mux.HandleFunc("GET /api/ws/v2", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query()["job_id"]).To(Equal([]string{"j1"}))
+				conn, err := upgrader.Upgrade(w, r, nil)
+				Expect(err).NotTo(HaveOccurred())
+				defer conn.Close()
+				for _, ev := range events {
+					Expect(conn.WriteJSON(ev)).To(Succeed())
+				}
+				// Wait for the client to hang up.
+				conn.ReadMessage() //nolint:errcheck
+			})
+		})
+
+		progress := func(status string, completed int) map[string]any {
+			return map[string]any{
+				"version": 2, "type": "job_progress", "job_id": "j1",
+				"payload": map[string]any{
+					"type": "job_progress", "path": "", "job_id": "j1", "status": status,
+					"total_items": 3, "completed_items": completed, "failed_items": 0,
+				},
+			}
+		}
+
+		It("prints progress events until the job completes", func() {
+			events = []map[string]any{
+				{"version": 2, "type": "connected", "subscription": map[string]any{"job_ids": []string{"j1"}}},
+				progress("running", 2),
+				progress("completed", 3),
+			}
+
+			Expect(run("jobs", "tail", "j1")).To(Succeed())
+			Expect(stdout.String()).To(Equal("running: 1/3 items, 0/1 checkpoints\nrunning: 2/3 items, 0/1 checkpoints\ncompleted: 3/3 items, 0/1 checkpoints\n"))
+		})
+
+		It("returns an error when the job does not complete", func() {
+			events = []map[string]any{progress("failed", 2)}
+
+			Expect(run("jobs", "tail", "j1")).To(MatchError("job j1 finished with status failed"))
+		})
+	})
+
+	Describe("jobs export", func() {
+		BeforeEach(func() {
+			mux.HandleFunc("GET /api/sample-jobs/{id}", serveJSON(http.StatusOK, map[string]any{
+				"job":      job("j1", "completed", 1, 2),
+				"progress": map[string]any{"checkpoints_completed": 1, "total_checkpoints": 1},
+			}))
+			mux.HandleFunc("GET /api/images/{path...}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "image/png")
+				w.Header().Set("Cache-Control", "no-cache")
+				w.Write([]byte("png:" + r.PathValue("path"))) //nolint:errcheck
+			})
+		})
+
+		It("writes the job, its items, and the images of its finished items", func() {
+			mux.HandleFunc("GET /api/sample-jobs/{id}/items", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Total-Count", "2")
+				serveJSON(http.StatusOK, []any{item("i1", "my-lora/step1000/forest.png"), item("i2", "")})(w, r)
+			})
+			dir := GinkgoT().TempDir()
+
+			Expect(run("jobs", "export", "-o", dir, "j1")).To(Succeed())
+			Expect(stdout.String()).To(ContainSubstring("Exported 2 items and 1 images"))
+			Expect(filepath.Join(dir, "job.json")).To(BeAnExistingFile())
+			items, err := os.ReadFile(filepath.Join(dir, "items.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(items)).To(ContainSubstring(`"image_path": "my-lora/step1000/forest.png"`))
+			image, err := os.ReadFile(filepath.Join(dir, "images", "my-lora", "step1000", "forest.png"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(image)).To(Equal("png:my-lora/step1000/forest.png"))
+		})
+
+		It("refuses to write images outside of the export directory", func() {
+			mux.HandleFunc("GET /api/sample-jobs/{id}/items", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Total-Count", "1")
+				serveJSON(http.StatusOK, []any{item("i1", "../escape.png")})(w, r)
+			})
+
+			err := run("jobs", "export", "-o", GinkgoT().TempDir(), "j1")
+			Expect(err).To(MatchError(ContainSubstring("refusing to write image outside of")))
+		})
+	})
+})
@@ -0,0 +1,348 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	gensamplejobssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/sample_jobs/server"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/pkg/client"
+)
+
+func jobsList(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	limit := fs.Int("limit", 20, "Maximum number of jobs to list")
+	owner := fs.String("owner", "", "Only list the jobs of this user, or of every user with \"all\"")
+	var labels labelFilters
+	fs.Var(&labels, "label", "Only list jobs carrying a label, as `key` or `key=value`; repeatable, every label must match")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	payload := &client.ListSampleJobsPayload{Limit: limit, Label: labels}
+	if *owner != "" {
+		payload.Owner = owner
+	}
+	res, err := env.client.SampleJobs.List(ctx, payload)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tTRAINING RUN\tSTUDY\tITEMS\tCREATED")
+	for _, j := range res.Jobs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d/%d\t%s\n", j.ID, j.Status, j.TrainingRunName, j.StudyName, j.CompletedItems, j.TotalItems, j.CreatedAt)
+	}
+	return tw.Flush()
+}
+
+func jobsShow(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	detail, err := env.client.SampleJobs.Show(ctx, &client.ShowSampleJobPayload{ID: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	return writeJSON(env.stdout, gensamplejobssvr.NewShowResponseBody(detail))
+}
+
+func jobsCreate(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	run := fs.String("run", "", "Name of the training run to sample")
+	studyName := fs.String("study", "", "ID or name of the study to sample")
+	checkpoints := fs.String("checkpoints", "", "Comma-separated checkpoint filenames to sample (default all)")
+	minStep := fs.Int("min-step", 0, "Only sample checkpoints whose step number is at least this")
+	maxStep := fs.Int("max-step", 0, "Only sample checkpoints whose step number is at most this")
+	everyNth := fs.Int("every-nth", 0, "Only sample every nth checkpoint within the step range")
+	steps := fs.String("steps", "", "Comma-separated sampling step counts overriding the study's, e.g. 20,30")
+	clearExisting := fs.Bool("clear-existing", false, "Delete existing samples of the selected checkpoints first")
+	missingOnly := fs.Bool("missing-only", false, "Only generate samples that are missing on disk")
+	skipExisting := fs.Bool("skip-existing", false, "Create items whose image exists as skipped")
+	appendNew := fs.Bool("append-new", false, "Add checkpoints of the training run that appear later to the job")
+	format := fs.String("format", "png", "Output format: png, webp, or jpeg")
+	quality := fs.Int("quality", 0, "Encoder quality for webp and jpeg output (default 90)")
+	follow := fs.Bool("follow", false, "Follow the job's progress until it finishes, as jobs tail does")
+	notes := fs.String("notes", "", "Free-text notes to record with the job")
+	var workflows workflowOverrides
+	fs.Var(&workflows, "workflow", "Sample the checkpoints matching a glob with another workflow, as `pattern=workflow`; repeatable, the first match applies")
+	labels := jobLabels{}
+	fs.Var(labels, "label", "Record a label with the job, as `key=value`; repeatable")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *run == "" || *studyName == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	study, err := findStudy(ctx, env.client, *studyName)
+	if err != nil {
+		return err
+	}
+	if *steps != "" {
+		stepCounts, err := parseInts(*steps)
+		if err != nil {
+			return err
+		}
+		if study, err = studyWithSteps(ctx, env.client, study, stepCounts); err != nil {
+			return err
+		}
+	}
+
+	payload := &client.CreateSampleJobPayload{
+		TrainingRunName:      *run,
+		StudyID:              study.ID,
+		ClearExisting:        *clearExisting,
+		MissingOnly:          *missingOnly,
+		SkipExisting:         *skipExisting,
+		AppendNewCheckpoints: *appendNew,
+		OutputFormat:         *format,
+		WorkflowOverrides:    workflows,
+	}
+	if *checkpoints != "" {
+		payload.CheckpointFilenames = strings.Split(*checkpoints, ",")
+	}
+	if *minStep > 0 {
+		payload.MinStep = minStep
+	}
+	if *maxStep > 0 {
+		payload.MaxStep = maxStep
+	}
+	if *everyNth > 0 {
+		payload.EveryNth = everyNth
+	}
+	if *quality > 0 {
+		payload.OutputQuality = quality
+	}
+	if *notes != "" {
+		payload.Notes = notes
+	}
+	if len(labels) > 0 {
+		payload.Labels = labels
+	}
+	job, err := env.client.SampleJobs.Create(ctx, payload)
+	if err != nil {
+		return err
+	}
+	for _, w := range job.Warnings {
+		fmt.Fprintf(env.stderr, "warning: %s\n", w)
+	}
+	fmt.Fprintf(env.stdout, "Created job %s: %d items (study %s)\n", job.ID, job.TotalItems, study.Name)
+
+	if *follow {
+		return followJob(ctx, env, job.ID)
+	}
+	return nil
+}
+
+// workflowOverrides collects the repeated -workflow flags of jobs create.
+type workflowOverrides []*client.WorkflowOverride
+
+func (w *workflowOverrides) String() string { return "" }
+
+func (w *workflowOverrides) Set(v string) error {
+	pattern, workflow, ok := strings.Cut(v, "=")
+	if !ok || pattern == "" || workflow == "" {
+		return fmt.Errorf("want pattern=workflow, got %q", v)
+	}
+	*w = append(*w, &client.WorkflowOverride{Pattern: pattern, Workflow: workflow})
+	return nil
+}
+
+// jobLabels collects the repeated -label flags of jobs create.
+type jobLabels map[string]string
+
+func (l jobLabels) String() string { return "" }
+
+func (l jobLabels) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("want key=value, got %q", v)
+	}
+	l[key] = value
+	return nil
+}
+
+// labelFilters collects the repeated -label flags of jobs list.
+type labelFilters []string
+
+func (l *labelFilters) String() string { return "" }
+
+func (l *labelFilters) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// studyWithSteps returns a study like study that samples the given step
+// counts. Sample jobs take their step counts from their study, so unless
+// study already has them, a derived study named after it is created, or
+// updated when study has changed since, and returned.
+func studyWithSteps(ctx context.Context, c *client.Client, study *client.Study, steps []int) (*client.Study, error) {
+	if reflect.DeepEqual(study.Steps, steps) {
+		return study, nil
+	}
+	stepStrs := make([]string, len(steps))
+	for i, s := range steps {
+		stepStrs[i] = fmt.Sprint(s)
+	}
+	name := fmt.Sprintf("%s (steps %s)", study.Name, strings.Join(stepStrs, ","))
+	want := newCreateStudyPayload(study, name)
+	want.Steps = steps
+
+	studies, err := c.Studies.List(ctx, &client.ListStudiesPayload{})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range studies {
+		if s.Name != name {
+			continue
+		}
+		// Updating a study used by a job archives a version, so leave an
+		// unchanged derived study alone.
+		if reflect.DeepEqual(newCreateStudyPayload(s, name), want) {
+			return s, nil
+		}
+		return c.Studies.Update(ctx, &client.UpdateStudyPayload{
+			ID:                    s.ID,
+			Name:                  want.Name,
+			PromptPrefix:          want.PromptPrefix,
+			Prompts:               want.Prompts,
+			NegativePrompt:        want.NegativePrompt,
+			Steps:                 want.Steps,
+			Cfgs:                  want.Cfgs,
+			SamplerSchedulerPairs: want.SamplerSchedulerPairs,
+			Seeds:                 want.Seeds,
+			SeedMode:              want.SeedMode,
+			RandomSeedCount:       want.RandomSeedCount,
+			Width:                 want.Width,
+			Height:                want.Height,
+			WorkflowTemplate:      want.WorkflowTemplate,
+			Vae:                   want.Vae,
+			TextEncoder:           want.TextEncoder,
+			Shift:                 want.Shift,
+			InputImage:            want.InputImage,
+			DenoiseStrengths:      want.DenoiseStrengths,
+			Wildcards:             want.Wildcards,
+		})
+	}
+	return c.Studies.Create(ctx, want)
+}
+
+func jobsTail(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	return followJob(ctx, env, fs.Arg(0))
+}
+
+// followJob prints the progress of a job as it changes until the job
+// finishes. It returns an error unless the job completed without errors, so
+// that scripts can check the exit status.
+func followJob(ctx context.Context, env *env, id string) error {
+	p, err := env.client.FollowJob(ctx, id, func(p client.JobProgress) {
+		fmt.Fprintln(env.stdout, formatProgress(p))
+	})
+	if err != nil {
+		return err
+	}
+	if p.Status != "completed" {
+		return fmt.Errorf("job %s finished with status %s", id, p.Status)
+	}
+	return nil
+}
+
+// formatProgress formats a job's progress as a line of jobs tail output.
+func formatProgress(p client.JobProgress) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d/%d items", p.Status, p.CompletedItems, p.TotalItems)
+	if p.FailedItems > 0 {
+		fmt.Fprintf(&b, ", %d failed", p.FailedItems)
+	}
+	if p.TotalCheckpoints > 0 {
+		fmt.Fprintf(&b, ", %d/%d checkpoints", p.CheckpointsCompleted, p.TotalCheckpoints)
+	}
+	if p.ETA > 0 {
+		fmt.Fprintf(&b, ", ETA %s", p.ETA)
+	}
+	return b.String()
+}
+
+func jobsExport(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	out := fs.String("o", "", "Directory to write to; created if missing")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	if *out == "" {
+		fs.Usage()
+		return errUsage
+	}
+	id := fs.Arg(0)
+
+	detail, err := env.client.SampleJobs.Show(ctx, &client.ShowSampleJobPayload{ID: id})
+	if err != nil {
+		return err
+	}
+	items, err := env.client.SampleJobs.ListItems(ctx, &client.ListSampleJobItemsPayload{ID: id, Sort: "created_at", Order: "asc"})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	if err := writeJSONOutput(env, filepath.Join(*out, "job.json"), gensamplejobssvr.NewShowResponseBody(detail)); err != nil {
+		return err
+	}
+	if err := writeJSONOutput(env, filepath.Join(*out, "items.json"), gensamplejobssvr.NewListItemsResponseBody(items)); err != nil {
+		return err
+	}
+
+	images := 0
+	for _, item := range items.Items {
+		if item.ImagePath == nil {
+			continue
+		}
+		if err := downloadImage(ctx, env.client, *item.ImagePath, filepath.Join(*out, "images")); err != nil {
+			return err
+		}
+		images++
+	}
+	fmt.Fprintf(env.stdout, "Exported %d items and %d images to %s\n", len(items.Items), images, *out)
+	return nil
+}
+
+// downloadImage saves the image at the given path relative to the sample
+// directory under the same path in dir.
+func downloadImage(ctx context.Context, c *client.Client, imagePath, dir string) error {
+	rel := filepath.FromSlash(imagePath)
+	if !filepath.IsLocal(rel) {
+		return fmt.Errorf("refusing to write image outside of %s: %q", dir, imagePath)
+	}
+	_, body, err := c.Images.Download(ctx, &client.DownloadImagePayload{Filepath: imagePath, Size: "full"})
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", imagePath, err)
+	}
+	defer body.Close()
+
+	dest := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return fmt.Errorf("downloading %s: %w", imagePath, err)
+	}
+	return f.Close()
+}
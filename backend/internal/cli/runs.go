@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/pkg/client"
+)
+
+func runsList(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	source := fs.String("source", "checkpoints", "Discovery source: checkpoints (runs that can be sampled) or samples (runs with sample images)")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	runs, err := env.client.TrainingRuns.List(ctx, &client.ListTrainingRunsPayload{Source: *source})
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCHECKPOINTS\tSAMPLES")
+	for _, r := range runs {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", r.Name, r.CheckpointCount, yesNo(r.HasSamples))
+	}
+	return tw.Flush()
+}
+
+func runsCheckpoints(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	run, err := findTrainingRun(ctx, env.client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILENAME\tSTEP\tSAMPLES")
+	for _, cp := range run.Checkpoints {
+		step := "-"
+		if cp.StepNumber >= 0 {
+			step = fmt.Sprint(cp.StepNumber)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", cp.Filename, step, yesNo(cp.HasSamples))
+	}
+	return tw.Flush()
+}
+
+// findTrainingRun returns the training run with the given name, discovered
+// from checkpoint files.
+func findTrainingRun(ctx context.Context, c *client.Client, name string) (*client.TrainingRun, error) {
+	runs, err := c.TrainingRuns.List(ctx, &client.ListTrainingRunsPayload{Source: "checkpoints"})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range runs {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("training run %q not found", name)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	genstudiessvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/studies/server"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/pkg/client"
+)
+
+func studiesList(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	owner := fs.String("owner", "", "Only list the studies of this user, or of every user with \"all\"")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	payload := &client.ListStudiesPayload{}
+	if *owner != "" {
+		payload.Owner = owner
+	}
+	studies, err := env.client.Studies.List(ctx, payload)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tVERSION\tIMAGES/CHECKPOINT")
+	for _, s := range studies {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", s.ID, s.Name, s.Version, s.ImagesPerCheckpoint)
+	}
+	return tw.Flush()
+}
+
+func studiesShow(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	study, err := findStudy(ctx, env.client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	// Print the study as the API serves it rather than with Go field names.
+	return writeJSON(env.stdout, genstudiessvr.NewCreateResponseBody(study))
+}
+
+func studiesCreate(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	file := fs.String("f", "", "JSON file of the study, or - for stdin")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	data, err := readInput(*file)
+	if err != nil {
+		return err
+	}
+	var body genstudiessvr.CreateRequestBody
+	if err := json.Unmarshal(data, &body); err != nil {
+		return fmt.Errorf("parsing %s: %w", *file, err)
+	}
+	if err := genstudiessvr.ValidateCreateRequestBody(&body); err != nil {
+		return fmt.Errorf("invalid study in %s: %w", *file, err)
+	}
+	study, err := env.client.Studies.Create(ctx, genstudiessvr.NewCreateStudyPayload(&body))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "Created study %s (%s)\n", study.Name, study.ID)
+	return nil
+}
+
+func studiesExport(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	out := fs.String("o", "", "File to write the document to (default stdout)")
+	if err := parseFlagsRange(fs, args, 0, 1); err != nil {
+		return err
+	}
+
+	payload := &client.ExportStudiesPayload{}
+	if fs.NArg() == 1 {
+		study, err := findStudy(ctx, env.client, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		payload.ID = &study.ID
+	}
+	doc, err := env.client.Studies.Export(ctx, payload)
+	if err != nil {
+		return err
+	}
+	return writeJSONOutput(env, *out, genstudiessvr.NewExportResponseBody(doc))
+}
+
+func studiesImport(ctx context.Context, env *env, args []string) error {
+	fs := newFlagSet(env)
+	file := fs.String("f", "", "Exported document, or - for stdin")
+	onConflict := fs.String("on-conflict", "rename", "What to do with a study whose name is taken: rename, overwrite, or skip")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	data, err := readInput(*file)
+	if err != nil {
+		return err
+	}
+	var body genstudiessvr.ImportStudiesRequestBody
+	if err := json.Unmarshal(data, &body); err != nil {
+		return fmt.Errorf("parsing %s: %w", *file, err)
+	}
+	if err := genstudiessvr.ValidateImportStudiesRequestBody(&body); err != nil {
+		return fmt.Errorf("invalid document in %s: %w", *file, err)
+	}
+	results, err := env.client.Studies.ImportStudies(ctx, genstudiessvr.NewImportStudiesPayload(&body, *onConflict))
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tACTION\tID")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Name, r.Action, r.ID)
+	}
+	return tw.Flush()
+}
+
+// findStudy returns the study with the given ID or, failing that, name.
+func findStudy(ctx context.Context, c *client.Client, idOrName string) (*client.Study, error) {
+	studies, err := c.Studies.List(ctx, &client.ListStudiesPayload{})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range studies {
+		if s.ID == idOrName {
+			return s, nil
+		}
+	}
+	for _, s := range studies {
+		if s.Name == idOrName {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("study %q not found", idOrName)
+}
+
+// newCreateStudyPayload returns a payload creating a copy of study under a
+// new name.
+func newCreateStudyPayload(study *client.Study, name string) *client.CreateStudyPayload {
+	return &client.CreateStudyPayload{
+		Name:                  name,
+		PromptPrefix:          study.PromptPrefix,
+		Prompts:               study.Prompts,
+		NegativePrompt:        study.NegativePrompt,
+		Steps:                 study.Steps,
+		Cfgs:                  study.Cfgs,
+		SamplerSchedulerPairs: study.SamplerSchedulerPairs,
+		Seeds:                 study.Seeds,
+		SeedMode:              study.SeedMode,
+		RandomSeedCount:       study.RandomSeedCount,
+		Width:                 study.Width,
+		Height:                study.Height,
+		WorkflowTemplate:      study.WorkflowTemplate,
+		Vae:                   study.Vae,
+		TextEncoder:           study.TextEncoder,
+		Shift:                 study.Shift,
+		InputImage:            study.InputImage,
+		DenoiseStrengths:      study.DenoiseStrengths,
+		Wildcards:             study.Wildcards,
+	}
+}
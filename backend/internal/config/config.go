@@ -5,22 +5,144 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 )
 
+// localePattern matches BCP 47 language tags such as "en", "en-US", and
+// "zh-Hant-TW".
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// userNamePattern matches the names users may be configured with.
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
 // yamlConfig is the raw YAML-tagged representation of the config file.
 type yamlConfig struct {
-	CheckpointDirs []string             `yaml:"checkpoint_dirs"`
-	SampleDir      string               `yaml:"sample_dir"`
-	Port           *int                 `yaml:"port"`
-	IPAddress      string               `yaml:"ip_address"`
-	DBPath         string               `yaml:"db_path"`
-	ComfyUI        *yamlComfyUIConfig   `yaml:"comfyui"`
-	Thumbnails     *yamlThumbnailConfig `yaml:"thumbnails"`
-	WsPingInterval *int                 `yaml:"ws_ping_interval"`
+	CheckpointDirs     []string                  `yaml:"checkpoint_dirs"`
+	SampleDir          string                    `yaml:"sample_dir"`
+	Port               *int                      `yaml:"port"`
+	GRPCPort           *int                      `yaml:"grpc_port"`
+	IPAddress          string                    `yaml:"ip_address"`
+	DBPath             string                    `yaml:"db_path"`
+	ComfyUI            *yamlComfyUIConfig        `yaml:"comfyui"`
+	Thumbnails         *yamlThumbnailConfig      `yaml:"thumbnails"`
+	WsPingInterval     *int                      `yaml:"ws_ping_interval"`
+	RequestTimeout     *int                      `yaml:"request_timeout"`
+	MultiProcess       *yamlMultiProcessConfig   `yaml:"multi_process"`
+	AutoSample         *yamlAutoSampleConfig     `yaml:"auto_sample"`
+	Heartbeat          *yamlHeartbeatConfig      `yaml:"heartbeat"`
+	Notifications      *yamlNotificationsConfig  `yaml:"notifications"`
+	Assets             *yamlAssetsConfig         `yaml:"assets"`
+	RateLimit          *yamlRateLimitConfig      `yaml:"rate_limit"`
+	SlowQueryMs        *int                      `yaml:"slow_query_ms"`
+	FaultInjection     *yamlFaultInjectionConfig `yaml:"fault_injection"`
+	Timezone           string                    `yaml:"timezone"`
+	Locale             string                    `yaml:"locale"`
+	Scoring            *yamlScoringConfig        `yaml:"scoring"`
+	Retention          *yamlRetentionConfig      `yaml:"retention"`
+	TrashRetentionDays *int                      `yaml:"trash_retention_days"`
+	OutputLayout       string                    `yaml:"output_layout"`
+	LogLevels          map[string]string         `yaml:"log_levels"`
+	Users              []yamlUserConfig          `yaml:"users"`
+}
+
+// yamlUserConfig is the raw YAML-tagged representation of one user.
+type yamlUserConfig struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	Admin bool   `yaml:"admin"`
+}
+
+// yamlFaultInjectionConfig is the raw YAML-tagged representation of fault injection config.
+type yamlFaultInjectionConfig struct {
+	Seed              *int64   `yaml:"seed"`
+	ComfyUIDisconnect *float64 `yaml:"comfyui_disconnect"`
+	DisconnectSeconds *int     `yaml:"disconnect_seconds"`
+	SlowDownload      *float64 `yaml:"slow_download"`
+	SlowDownloadMs    *int     `yaml:"slow_download_ms"`
+	DatabaseBusy      *float64 `yaml:"database_busy"`
+	FileWriteFailure  *float64 `yaml:"file_write_failure"`
+}
+
+// yamlRateLimitConfig is the raw YAML-tagged representation of rate limit config.
+type yamlRateLimitConfig struct {
+	RequestsPerSecond  *int `yaml:"requests_per_second"`
+	Burst              *int `yaml:"burst"`
+	MaxConcurrentHeavy *int `yaml:"max_concurrent_heavy"`
+	QueueTimeout       *int `yaml:"queue_timeout"`
+}
+
+// yamlAssetsConfig is the raw YAML-tagged representation of assets config.
+type yamlAssetsConfig struct {
+	Dir                string `yaml:"dir"`
+	MaxImageSizeMB     *int   `yaml:"max_image_size_mb"`
+	MaxWildcardsSizeMB *int   `yaml:"max_wildcards_size_mb"`
+	UploadTTL          *int   `yaml:"upload_ttl"`
+}
+
+// yamlScoringConfig is the raw YAML-tagged representation of scoring config.
+type yamlScoringConfig struct {
+	URL     string `yaml:"url"`
+	Timeout *int   `yaml:"timeout"`
+}
+
+// yamlRetentionConfig is the raw YAML-tagged representation of retention config.
+type yamlRetentionConfig struct {
+	KeepLatest   *int  `yaml:"keep_latest"`
+	KeepTopRated *int  `yaml:"keep_top_rated"`
+	MinAgeDays   *int  `yaml:"min_age_days"`
+	Interval     *int  `yaml:"interval"`
+	DryRun       *bool `yaml:"dry_run"`
+}
+
+// yamlHeartbeatConfig is the raw YAML-tagged representation of heartbeat config.
+type yamlHeartbeatConfig struct {
+	File         string `yaml:"file"`
+	URL          string `yaml:"url"`
+	Interval     *int   `yaml:"interval"`
+	StallTimeout *int   `yaml:"stall_timeout"`
+}
+
+// yamlNotificationsConfig is the raw YAML-tagged representation of
+// notifications config.
+type yamlNotificationsConfig struct {
+	UIURL    string              `yaml:"ui_url"`
+	Webhooks []yamlWebhookConfig `yaml:"webhooks"`
+}
+
+// yamlWebhookConfig is the raw YAML-tagged representation of one webhook.
+type yamlWebhookConfig struct {
+	URL    string   `yaml:"url"`
+	Format string   `yaml:"format"`
+	Events []string `yaml:"events"`
+}
+
+// yamlAutoSampleConfig is the raw YAML-tagged representation of auto-sample config.
+type yamlAutoSampleConfig struct {
+	SettleSeconds *int                 `yaml:"settle_seconds"`
+	TrainingRuns  []yamlAutoSampleRule `yaml:"training_runs"`
+}
+
+// yamlAutoSampleRule is the raw YAML-tagged representation of one auto-sample rule.
+type yamlAutoSampleRule struct {
+	TrainingRun string `yaml:"training_run"`
+	StudyID     string `yaml:"study_id"`
+	Workflow    string `yaml:"workflow"`
+	Enabled     *bool  `yaml:"enabled"`
+}
+
+// yamlMultiProcessConfig is the raw YAML-tagged representation of multi-process config.
+type yamlMultiProcessConfig struct {
+	Role       string `yaml:"role"`
+	InstanceID string `yaml:"instance_id"`
+	LeaseTTL   *int   `yaml:"lease_ttl"`
 }
 
 // yamlThumbnailConfig is the raw YAML-tagged representation of thumbnail config.
@@ -36,6 +158,41 @@ type yamlComfyUIConfig struct {
 	URL               string `yaml:"url"`
 	WorkflowDir       string `yaml:"workflow_dir"`
 	ReconnectInterval *int   `yaml:"reconnect_interval"`
+	VRAMHardLimitMB   *int   `yaml:"vram_hard_limit_mb"`
+	Prewarm           bool   `yaml:"prewarm"`
+	DrainTimeout      *int   `yaml:"drain_timeout"`
+	ItemTimeout       *int   `yaml:"item_timeout"`
+	MaxInFlight       *int   `yaml:"max_in_flight"`
+	ModelCacheTTL     *int   `yaml:"model_cache_ttl"`
+
+	FailureLog        *yamlComfyUIFailureLogConfig `yaml:"failure_log"`
+	CheckpointStaging *yamlCheckpointStagingConfig `yaml:"checkpoint_staging"`
+	PathMapping       []yamlCheckpointPathRule     `yaml:"path_mapping"`
+}
+
+// yamlCheckpointPathRule is the raw YAML-tagged representation of a
+// checkpoint path mapping rule.
+type yamlCheckpointPathRule struct {
+	StripPrefix       string `yaml:"strip_prefix"`
+	AddPrefix         string `yaml:"add_prefix"`
+	Pattern           string `yaml:"pattern"`
+	Replace           string `yaml:"replace"`
+	TrainingRunSubdir bool   `yaml:"training_run_subdir"`
+}
+
+// yamlCheckpointStagingConfig is the raw YAML-tagged representation of
+// checkpoint staging config.
+type yamlCheckpointStagingConfig struct {
+	Dir       string `yaml:"dir"`
+	ModelPath string `yaml:"model_path"`
+}
+
+// yamlComfyUIFailureLogConfig is the raw YAML-tagged representation of
+// ComfyUI failure log capture config.
+type yamlComfyUIFailureLogConfig struct {
+	Source string `yaml:"source"`
+	Path   string `yaml:"path"`
+	Lines  *int   `yaml:"lines"`
 }
 
 // DefaultConfigPath is the default path to the configuration file.
@@ -44,15 +201,20 @@ const DefaultConfigPath = "config.yaml"
 // ConfigPathEnvVar is the environment variable that overrides the config path.
 const ConfigPathEnvVar = "CONFIG_PATH"
 
-// Load reads the configuration file from the path specified by CONFIG_PATH
-// env var, or DefaultConfigPath if not set. It parses, validates, and returns
-// the domain config.
-func Load() (*model.Config, error) {
+// Path returns the configuration file path: the CONFIG_PATH env var, or
+// DefaultConfigPath if not set.
+func Path() string {
 	path := os.Getenv(ConfigPathEnvVar)
 	if path == "" {
 		path = DefaultConfigPath
 	}
-	return LoadFromPath(path)
+	return path
+}
+
+// Load reads the configuration file at Path. It parses, validates, and
+// returns the domain config.
+func Load() (*model.Config, error) {
+	return LoadFromPath(Path())
 }
 
 // LoadFromPath reads and parses a YAML config file at the given path.
@@ -79,6 +241,10 @@ func parseAndValidate(raw yamlConfig) (*model.Config, error) {
 	if raw.Port != nil {
 		port = *raw.Port
 	}
+	grpcPort := 0 // default: gRPC disabled
+	if raw.GRPCPort != nil {
+		grpcPort = *raw.GRPCPort
+	}
 	if raw.IPAddress == "" {
 		raw.IPAddress = "127.0.0.1"
 	}
@@ -89,6 +255,24 @@ func parseAndValidate(raw yamlConfig) (*model.Config, error) {
 	if raw.WsPingInterval != nil {
 		wsPingInterval = *raw.WsPingInterval
 	}
+	requestTimeout := 30 // default: 30 seconds
+	if raw.RequestTimeout != nil {
+		requestTimeout = *raw.RequestTimeout
+	}
+	slowQueryMs := 100 // default: 100 milliseconds
+	if raw.SlowQueryMs != nil {
+		slowQueryMs = *raw.SlowQueryMs
+	}
+	trashRetentionDays := 30 // default: 30 days
+	if raw.TrashRetentionDays != nil {
+		trashRetentionDays = *raw.TrashRetentionDays
+	}
+	if raw.Timezone == "" {
+		raw.Timezone = "UTC"
+	}
+	if raw.Locale == "" {
+		raw.Locale = "en-US"
+	}
 
 	// Validate checkpoint_dirs
 	if len(raw.CheckpointDirs) == 0 {
@@ -124,16 +308,69 @@ func parseAndValidate(raw yamlConfig) (*model.Config, error) {
 		return nil, fmt.Errorf("config: port must be between 1 and 65535, got %d", port)
 	}
 
+	// Validate grpc_port (0 disables the gRPC server)
+	if grpcPort < 0 || grpcPort > 65535 {
+		return nil, fmt.Errorf("config: grpc_port must be between 0 and 65535, got %d", grpcPort)
+	}
+	if grpcPort == port {
+		return nil, fmt.Errorf("config: grpc_port must differ from port %d", port)
+	}
+
 	// Validate ws_ping_interval (0 disables pings; negative values are invalid)
 	if wsPingInterval < 0 {
 		return nil, fmt.Errorf("config: ws_ping_interval must be >= 0, got %d", wsPingInterval)
 	}
 
+	// Validate request_timeout (0 disables the limit)
+	if requestTimeout < 0 {
+		return nil, fmt.Errorf("config: request_timeout must be >= 0, got %d", requestTimeout)
+	}
+
+	// Validate slow_query_ms
+	if slowQueryMs < 1 {
+		return nil, fmt.Errorf("config: slow_query_ms must be at least 1, got %d", slowQueryMs)
+	}
+
+	// Validate trash_retention_days (0 disables automatic purging)
+	if trashRetentionDays < 0 {
+		return nil, fmt.Errorf("config: trash_retention_days must be >= 0, got %d", trashRetentionDays)
+	}
+
+	// Validate timezone. "Local" is rejected so that schedules do not depend
+	// on the host or container time zone.
+	if raw.Timezone == "Local" {
+		return nil, fmt.Errorf("config: timezone must be an IANA time zone name such as \"Europe/Berlin\", got \"Local\"")
+	}
+	if _, err := time.LoadLocation(raw.Timezone); err != nil {
+		return nil, fmt.Errorf("config: invalid timezone %q: %w", raw.Timezone, err)
+	}
+
+	// Validate locale
+	if !localePattern.MatchString(raw.Locale) {
+		return nil, fmt.Errorf("config: invalid locale %q, expected a language tag such as \"en-US\"", raw.Locale)
+	}
+
+	// Validate log_levels
+	for component, level := range raw.LogLevels {
+		if component == "" {
+			return nil, fmt.Errorf("config: log_levels has an empty component name")
+		}
+		if _, err := logrus.ParseLevel(level); err != nil {
+			return nil, fmt.Errorf("config: log_levels.%s: invalid level %q", component, level)
+		}
+	}
+
 	// Validate IP address
 	if net.ParseIP(raw.IPAddress) == nil {
 		return nil, fmt.Errorf("config: invalid ip_address %q", raw.IPAddress)
 	}
 
+	// Validate output_layout
+	outputLayout, err := model.ParseOutputLayout(raw.OutputLayout)
+	if err != nil {
+		return nil, fmt.Errorf("config: invalid output_layout: %w", err)
+	}
+
 	// Parse and validate ComfyUI config if present
 	var comfyUI *model.ComfyUIConfig
 	if raw.ComfyUI != nil {
@@ -152,15 +389,120 @@ func parseAndValidate(raw yamlConfig) (*model.Config, error) {
 		}
 	}
 
+	// Parse and validate multi-process config if present
+	var multiProcess *model.MultiProcessConfig
+	if raw.MultiProcess != nil {
+		multiProcess, err = parseMultiProcessConfig(raw.MultiProcess)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse and validate auto-sample config if present
+	var autoSample *model.AutoSampleConfig
+	if raw.AutoSample != nil {
+		autoSample, err = parseAutoSampleConfig(raw.AutoSample)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse and validate heartbeat config if present
+	var heartbeat *model.HeartbeatConfig
+	if raw.Heartbeat != nil {
+		heartbeat, err = parseHeartbeatConfig(raw.Heartbeat)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse and validate notifications config if present
+	var notifications *model.NotificationsConfig
+	if raw.Notifications != nil {
+		notifications, err = parseNotificationsConfig(raw.Notifications)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse and validate assets config if present
+	var assets *model.AssetsConfig
+	if raw.Assets != nil {
+		assets, err = parseAssetsConfig(raw.Assets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse and validate rate limit config if present
+	var rateLimit *model.RateLimitConfig
+	if raw.RateLimit != nil {
+		rateLimit, err = parseRateLimitConfig(raw.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse and validate fault injection config if present
+	var faultInjection *model.FaultInjectionConfig
+	if raw.FaultInjection != nil {
+		faultInjection, err = parseFaultInjectionConfig(raw.FaultInjection)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse and validate scoring config if present
+	var scoring *model.ScoringConfig
+	if raw.Scoring != nil {
+		scoring, err = parseScoringConfig(raw.Scoring)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse and validate retention config if present
+	var retention *model.RetentionConfig
+	if raw.Retention != nil {
+		retention, err = parseRetentionConfig(raw.Retention)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse and validate users if present
+	users, err := parseUsersConfig(raw.Users)
+	if err != nil {
+		return nil, err
+	}
+
 	return &model.Config{
-		CheckpointDirs: raw.CheckpointDirs,
-		SampleDir:      raw.SampleDir,
-		Port:           port,
-		IPAddress:      raw.IPAddress,
-		DBPath:         raw.DBPath,
-		ComfyUI:        comfyUI,
-		Thumbnails:     thumbnails,
-		WsPingInterval: wsPingInterval,
+		CheckpointDirs:     raw.CheckpointDirs,
+		SampleDir:          raw.SampleDir,
+		Port:               port,
+		GRPCPort:           grpcPort,
+		IPAddress:          raw.IPAddress,
+		DBPath:             raw.DBPath,
+		ComfyUI:            comfyUI,
+		Thumbnails:         thumbnails,
+		WsPingInterval:     wsPingInterval,
+		RequestTimeout:     requestTimeout,
+		MultiProcess:       multiProcess,
+		AutoSample:         autoSample,
+		Heartbeat:          heartbeat,
+		Notifications:      notifications,
+		Assets:             assets,
+		RateLimit:          rateLimit,
+		SlowQueryMs:        slowQueryMs,
+		FaultInjection:     faultInjection,
+		Timezone:           raw.Timezone,
+		Locale:             raw.Locale,
+		Scoring:            scoring,
+		Retention:          retention,
+		TrashRetentionDays: trashRetentionDays,
+		OutputLayout:       outputLayout,
+		LogLevels:          raw.LogLevels,
+		Users:              users,
 	}, nil
 }
 
@@ -199,6 +541,358 @@ func parseThumbnailConfig(raw *yamlThumbnailConfig) (*model.ThumbnailConfig, err
 	}, nil
 }
 
+// parseMultiProcessConfig parses and validates the multi-process configuration section.
+func parseMultiProcessConfig(raw *yamlMultiProcessConfig) (*model.MultiProcessConfig, error) {
+	// Apply defaults
+	role := model.ProcessRoleAll
+	if raw.Role != "" {
+		role = model.ProcessRole(raw.Role)
+	}
+	leaseTTL := 30 // default: 30 seconds
+	if raw.LeaseTTL != nil {
+		leaseTTL = *raw.LeaseTTL
+	}
+
+	// Validate
+	switch role {
+	case model.ProcessRoleAll, model.ProcessRoleAPI, model.ProcessRoleExecutor:
+	default:
+		return nil, fmt.Errorf("config: multi_process.role must be one of all, api, executor, got %q", raw.Role)
+	}
+	if leaseTTL < 3 {
+		return nil, fmt.Errorf("config: multi_process.lease_ttl must be at least 3, got %d", leaseTTL)
+	}
+
+	return &model.MultiProcessConfig{
+		Role:       role,
+		InstanceID: raw.InstanceID,
+		LeaseTTL:   leaseTTL,
+	}, nil
+}
+
+// parseAutoSampleConfig parses and validates the auto-sample configuration section.
+func parseAutoSampleConfig(raw *yamlAutoSampleConfig) (*model.AutoSampleConfig, error) {
+	// Apply defaults
+	settleSeconds := 30
+	if raw.SettleSeconds != nil {
+		settleSeconds = *raw.SettleSeconds
+	}
+
+	// Validate
+	if settleSeconds < 1 {
+		return nil, fmt.Errorf("config: auto_sample.settle_seconds must be at least 1, got %d", settleSeconds)
+	}
+	seen := make(map[string]bool, len(raw.TrainingRuns))
+	rules := make([]model.AutoSampleRule, len(raw.TrainingRuns))
+	for i, r := range raw.TrainingRuns {
+		if r.TrainingRun == "" {
+			return nil, fmt.Errorf("config: auto_sample.training_runs[%d].training_run is required", i)
+		}
+		if r.StudyID == "" {
+			return nil, fmt.Errorf("config: auto_sample.training_runs[%d].study_id is required", i)
+		}
+		if seen[r.TrainingRun] {
+			return nil, fmt.Errorf("config: auto_sample.training_runs[%d] duplicates training run %q", i, r.TrainingRun)
+		}
+		seen[r.TrainingRun] = true
+
+		enabled := true
+		if r.Enabled != nil {
+			enabled = *r.Enabled
+		}
+		rules[i] = model.AutoSampleRule{
+			TrainingRun: r.TrainingRun,
+			StudyID:     r.StudyID,
+			Workflow:    r.Workflow,
+			Enabled:     enabled,
+		}
+	}
+
+	return &model.AutoSampleConfig{
+		SettleSeconds: settleSeconds,
+		TrainingRuns:  rules,
+	}, nil
+}
+
+// parseHeartbeatConfig parses and validates the heartbeat configuration section.
+func parseHeartbeatConfig(raw *yamlHeartbeatConfig) (*model.HeartbeatConfig, error) {
+	// Apply defaults
+	interval := 60 // default: 60 seconds
+	if raw.Interval != nil {
+		interval = *raw.Interval
+	}
+	stallTimeout := 1800 // default: 30 minutes
+	if raw.StallTimeout != nil {
+		stallTimeout = *raw.StallTimeout
+	}
+
+	// Validate
+	if raw.File == "" && raw.URL == "" {
+		return nil, fmt.Errorf("config: heartbeat requires file or url")
+	}
+	if raw.URL != "" {
+		if _, err := parseAndValidateURL(raw.URL); err != nil {
+			return nil, fmt.Errorf("config: heartbeat.url: %w", err)
+		}
+	}
+	if interval < 1 {
+		return nil, fmt.Errorf("config: heartbeat.interval must be at least 1, got %d", interval)
+	}
+	if stallTimeout < 0 {
+		return nil, fmt.Errorf("config: heartbeat.stall_timeout must be >= 0, got %d", stallTimeout)
+	}
+
+	return &model.HeartbeatConfig{
+		File:         raw.File,
+		URL:          raw.URL,
+		Interval:     interval,
+		StallTimeout: stallTimeout,
+	}, nil
+}
+
+// parseNotificationsConfig parses and validates the notifications
+// configuration section.
+func parseNotificationsConfig(raw *yamlNotificationsConfig) (*model.NotificationsConfig, error) {
+	if raw.UIURL != "" {
+		if _, err := parseAndValidateURL(raw.UIURL); err != nil {
+			return nil, fmt.Errorf("config: notifications.ui_url: %w", err)
+		}
+	}
+	if len(raw.Webhooks) == 0 {
+		return nil, fmt.Errorf("config: notifications.webhooks requires at least one webhook")
+	}
+
+	webhooks := make([]model.WebhookConfig, len(raw.Webhooks))
+	for i, w := range raw.Webhooks {
+		if _, err := parseAndValidateURL(w.URL); err != nil {
+			return nil, fmt.Errorf("config: notifications.webhooks[%d].url: %w", i, err)
+		}
+
+		// Apply defaults
+		format := model.WebhookFormatJSON
+		if w.Format != "" {
+			format = model.WebhookFormat(w.Format)
+		}
+		events := model.AllJobEvents
+		if len(w.Events) > 0 {
+			events = make([]model.JobEvent, len(w.Events))
+			for j, e := range w.Events {
+				events[j] = model.JobEvent(e)
+			}
+		}
+
+		// Validate
+		switch format {
+		case model.WebhookFormatJSON, model.WebhookFormatDiscord, model.WebhookFormatSlack:
+		default:
+			return nil, fmt.Errorf("config: notifications.webhooks[%d].format must be one of json, discord, slack, got %q", i, w.Format)
+		}
+		for _, e := range events {
+			switch e {
+			case model.JobEventCompleted, model.JobEventFailed, model.JobEventStopped:
+			default:
+				return nil, fmt.Errorf("config: notifications.webhooks[%d].events must contain only completed, failed, stopped, got %q", i, e)
+			}
+		}
+
+		webhooks[i] = model.WebhookConfig{
+			URL:    w.URL,
+			Format: format,
+			Events: events,
+		}
+	}
+
+	return &model.NotificationsConfig{
+		UIURL:    raw.UIURL,
+		Webhooks: webhooks,
+	}, nil
+}
+
+// minUserTokenLength is the shortest token a user may be configured with.
+const minUserTokenLength = 16
+
+// parseUsersConfig parses and validates the users of a shared server. Errors
+// name the offending user but never include its token.
+func parseUsersConfig(raw []yamlUserConfig) ([]model.UserConfig, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	users := make([]model.UserConfig, len(raw))
+	names := make(map[string]bool, len(raw))
+	tokens := make(map[string]bool, len(raw))
+	for i, u := range raw {
+		if !userNamePattern.MatchString(u.Name) {
+			return nil, fmt.Errorf("config: users[%d].name must be 1-64 letters, digits, '.', '_' or '-', got %q", i, u.Name)
+		}
+		if u.Name == model.OwnerAll {
+			return nil, fmt.Errorf("config: users[%d].name %q is reserved", i, u.Name)
+		}
+		if names[u.Name] {
+			return nil, fmt.Errorf("config: users[%d].name %q is used by another user", i, u.Name)
+		}
+		if len(u.Token) < minUserTokenLength {
+			return nil, fmt.Errorf("config: users[%d].token of user %q must be at least %d characters", i, u.Name, minUserTokenLength)
+		}
+		if tokens[u.Token] {
+			return nil, fmt.Errorf("config: users[%d].token of user %q is used by another user", i, u.Name)
+		}
+		names[u.Name] = true
+		tokens[u.Token] = true
+		users[i] = model.UserConfig{
+			Name:  u.Name,
+			Token: u.Token,
+			Admin: u.Admin,
+		}
+	}
+	return users, nil
+}
+
+// parseScoringConfig parses and validates the scoring configuration section.
+func parseScoringConfig(raw *yamlScoringConfig) (*model.ScoringConfig, error) {
+	// Apply defaults
+	timeout := 30 // default: 30 seconds
+	if raw.Timeout != nil {
+		timeout = *raw.Timeout
+	}
+
+	// Validate
+	if _, err := parseAndValidateURL(raw.URL); err != nil {
+		return nil, fmt.Errorf("config: scoring.url: %w", err)
+	}
+	if timeout < 1 {
+		return nil, fmt.Errorf("config: scoring.timeout must be at least 1, got %d", timeout)
+	}
+
+	return &model.ScoringConfig{
+		URL:     raw.URL,
+		Timeout: timeout,
+	}, nil
+}
+
+// parseRetentionConfig parses and validates the retention configuration section.
+func parseRetentionConfig(raw *yamlRetentionConfig) (*model.RetentionConfig, error) {
+	// Apply defaults
+	cfg := model.RetentionConfig{
+		MinAgeDays: 7,
+		Interval:   24,
+		DryRun:     true,
+	}
+	if raw.KeepLatest != nil {
+		cfg.KeepLatest = *raw.KeepLatest
+	}
+	if raw.KeepTopRated != nil {
+		cfg.KeepTopRated = *raw.KeepTopRated
+	}
+	if raw.MinAgeDays != nil {
+		cfg.MinAgeDays = *raw.MinAgeDays
+	}
+	if raw.Interval != nil {
+		cfg.Interval = *raw.Interval
+	}
+	if raw.DryRun != nil {
+		cfg.DryRun = *raw.DryRun
+	}
+
+	// Validate
+	if cfg.KeepLatest < 0 {
+		return nil, fmt.Errorf("config: retention.keep_latest must be >= 0, got %d", cfg.KeepLatest)
+	}
+	if cfg.KeepTopRated < 0 {
+		return nil, fmt.Errorf("config: retention.keep_top_rated must be >= 0, got %d", cfg.KeepTopRated)
+	}
+	if cfg.KeepLatest == 0 && cfg.KeepTopRated == 0 {
+		return nil, fmt.Errorf("config: retention requires keep_latest or keep_top_rated, otherwise every sample would be deleted")
+	}
+	if cfg.MinAgeDays < 0 {
+		return nil, fmt.Errorf("config: retention.min_age_days must be >= 0, got %d", cfg.MinAgeDays)
+	}
+	if cfg.Interval < 1 {
+		return nil, fmt.Errorf("config: retention.interval must be at least 1, got %d", cfg.Interval)
+	}
+
+	return &cfg, nil
+}
+
+// parseAssetsConfig parses and validates the assets configuration section.
+func parseAssetsConfig(raw *yamlAssetsConfig) (*model.AssetsConfig, error) {
+	// Apply defaults
+	dir := "./data/assets"
+	if raw.Dir != "" {
+		dir = raw.Dir
+	}
+	maxImage := 50 // default: 50 MB
+	if raw.MaxImageSizeMB != nil {
+		maxImage = *raw.MaxImageSizeMB
+	}
+	maxWildcards := 10 // default: 10 MB
+	if raw.MaxWildcardsSizeMB != nil {
+		maxWildcards = *raw.MaxWildcardsSizeMB
+	}
+	uploadTTL := 24 // default: 24 hours
+	if raw.UploadTTL != nil {
+		uploadTTL = *raw.UploadTTL
+	}
+
+	// Validate
+	if maxImage < 1 {
+		return nil, fmt.Errorf("config: assets.max_image_size_mb must be at least 1, got %d", maxImage)
+	}
+	if maxWildcards < 1 {
+		return nil, fmt.Errorf("config: assets.max_wildcards_size_mb must be at least 1, got %d", maxWildcards)
+	}
+	if uploadTTL < 1 {
+		return nil, fmt.Errorf("config: assets.upload_ttl must be at least 1, got %d", uploadTTL)
+	}
+
+	return &model.AssetsConfig{
+		Dir:                dir,
+		MaxImageSizeMB:     maxImage,
+		MaxWildcardsSizeMB: maxWildcards,
+		UploadTTL:          uploadTTL,
+	}, nil
+}
+
+func parseRateLimitConfig(raw *yamlRateLimitConfig) (*model.RateLimitConfig, error) {
+	// Apply defaults
+	rps := 100
+	if raw.RequestsPerSecond != nil {
+		rps = *raw.RequestsPerSecond
+	}
+	burst := 500
+	if raw.Burst != nil {
+		burst = *raw.Burst
+	}
+	maxHeavy := 8
+	if raw.MaxConcurrentHeavy != nil {
+		maxHeavy = *raw.MaxConcurrentHeavy
+	}
+	queueTimeout := 30 // default: 30 seconds
+	if raw.QueueTimeout != nil {
+		queueTimeout = *raw.QueueTimeout
+	}
+
+	// Validate
+	if rps < 0 {
+		return nil, fmt.Errorf("config: rate_limit.requests_per_second must be >= 0, got %d", rps)
+	}
+	if rps > 0 && burst < 1 {
+		return nil, fmt.Errorf("config: rate_limit.burst must be at least 1, got %d", burst)
+	}
+	if maxHeavy < 0 {
+		return nil, fmt.Errorf("config: rate_limit.max_concurrent_heavy must be >= 0, got %d", maxHeavy)
+	}
+	if queueTimeout < 0 {
+		return nil, fmt.Errorf("config: rate_limit.queue_timeout must be >= 0, got %d", queueTimeout)
+	}
+
+	return &model.RateLimitConfig{
+		RequestsPerSecond:  rps,
+		Burst:              burst,
+		MaxConcurrentHeavy: maxHeavy,
+		QueueTimeout:       queueTimeout,
+	}, nil
+}
+
 func parseComfyUIConfig(raw *yamlComfyUIConfig) (*model.ComfyUIConfig, error) {
 	// Apply defaults
 	rawURL := "http://localhost:8188"
@@ -225,10 +919,155 @@ func parseComfyUIConfig(raw *yamlComfyUIConfig) (*model.ComfyUIConfig, error) {
 		return nil, fmt.Errorf("config: comfyui.reconnect_interval must be at least 1, got %d", reconnectInterval)
 	}
 
+	vramHardLimitMB := 0 // default: no hard limit
+	if raw.VRAMHardLimitMB != nil {
+		vramHardLimitMB = *raw.VRAMHardLimitMB
+	}
+	if vramHardLimitMB < 0 {
+		return nil, fmt.Errorf("config: comfyui.vram_hard_limit_mb must be >= 0, got %d", vramHardLimitMB)
+	}
+
+	drainTimeout := 30 // default: 30 seconds
+	if raw.DrainTimeout != nil {
+		drainTimeout = *raw.DrainTimeout
+	}
+	if drainTimeout < 0 {
+		return nil, fmt.Errorf("config: comfyui.drain_timeout must be >= 0, got %d", drainTimeout)
+	}
+
+	itemTimeout := 900 // default: 15 minutes
+	if raw.ItemTimeout != nil {
+		itemTimeout = *raw.ItemTimeout
+	}
+	if itemTimeout < 0 {
+		return nil, fmt.Errorf("config: comfyui.item_timeout must be >= 0, got %d", itemTimeout)
+	}
+
+	maxInFlight := 1 // default: one prompt at a time
+	if raw.MaxInFlight != nil {
+		maxInFlight = *raw.MaxInFlight
+	}
+	if maxInFlight < 1 {
+		return nil, fmt.Errorf("config: comfyui.max_in_flight must be >= 1, got %d", maxInFlight)
+	}
+
+	modelCacheTTL := 60 // default: 60 seconds
+	if raw.ModelCacheTTL != nil {
+		modelCacheTTL = *raw.ModelCacheTTL
+	}
+	if modelCacheTTL < 0 {
+		return nil, fmt.Errorf("config: comfyui.model_cache_ttl must be >= 0, got %d", modelCacheTTL)
+	}
+
+	var failureLog *model.ComfyUIFailureLogConfig
+	if raw.FailureLog != nil {
+		failureLog, err = parseComfyUIFailureLogConfig(raw.FailureLog)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var checkpointStaging *model.CheckpointStagingConfig
+	if raw.CheckpointStaging != nil {
+		checkpointStaging, err = parseCheckpointStagingConfig(raw.CheckpointStaging)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pathMapping, err := parseCheckpointPathRules(raw.PathMapping)
+	if err != nil {
+		return nil, err
+	}
+
 	return &model.ComfyUIConfig{
 		URL:               parsedURL,
 		WorkflowDir:       workflowDir,
 		ReconnectInterval: reconnectInterval,
+		VRAMHardLimitMB:   vramHardLimitMB,
+		FailureLog:        failureLog,
+		Prewarm:           raw.Prewarm,
+		DrainTimeout:      drainTimeout,
+		ItemTimeout:       itemTimeout,
+		MaxInFlight:       maxInFlight,
+		ModelCacheTTL:     modelCacheTTL,
+		CheckpointStaging: checkpointStaging,
+		PathMapping:       pathMapping,
+	}, nil
+}
+
+func parseCheckpointPathRules(raw []yamlCheckpointPathRule) ([]model.CheckpointPathRule, error) {
+	var rules []model.CheckpointPathRule
+	for i, r := range raw {
+		set := 0
+		for _, isSet := range []bool{r.StripPrefix != "", r.AddPrefix != "", r.Pattern != "", r.TrainingRunSubdir} {
+			if isSet {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("config: comfyui.path_mapping[%d] must set exactly one of strip_prefix, add_prefix, pattern or training_run_subdir", i)
+		}
+		if r.Replace != "" && r.Pattern == "" {
+			return nil, fmt.Errorf("config: comfyui.path_mapping[%d].replace requires pattern", i)
+		}
+		if r.Pattern != "" {
+			if _, err := regexp.Compile(r.Pattern); err != nil {
+				return nil, fmt.Errorf("config: comfyui.path_mapping[%d].pattern is not a valid regular expression: %w", i, err)
+			}
+		}
+		rules = append(rules, model.CheckpointPathRule{
+			StripPrefix:       r.StripPrefix,
+			AddPrefix:         r.AddPrefix,
+			Pattern:           r.Pattern,
+			Replace:           r.Replace,
+			TrainingRunSubdir: r.TrainingRunSubdir,
+		})
+	}
+	return rules, nil
+}
+
+func parseCheckpointStagingConfig(raw *yamlCheckpointStagingConfig) (*model.CheckpointStagingConfig, error) {
+	if raw.Dir == "" {
+		return nil, fmt.Errorf("config: comfyui.checkpoint_staging.dir is required")
+	}
+	if raw.ModelPath == "" {
+		return nil, fmt.Errorf("config: comfyui.checkpoint_staging.model_path is required")
+	}
+	modelPath := path.Clean(strings.ReplaceAll(raw.ModelPath, "\\", "/"))
+	if path.IsAbs(modelPath) || modelPath == "." || modelPath == ".." || strings.HasPrefix(modelPath, "../") {
+		return nil, fmt.Errorf("config: comfyui.checkpoint_staging.model_path must be a relative path inside ComfyUI's model directory, got %q", raw.ModelPath)
+	}
+	return &model.CheckpointStagingConfig{
+		Dir:       raw.Dir,
+		ModelPath: modelPath,
+	}, nil
+}
+
+func parseComfyUIFailureLogConfig(raw *yamlComfyUIFailureLogConfig) (*model.ComfyUIFailureLogConfig, error) {
+	source := model.ComfyUILogSource(raw.Source)
+	switch source {
+	case model.ComfyUILogSourceAPI:
+	case model.ComfyUILogSourceFile:
+		if raw.Path == "" {
+			return nil, fmt.Errorf("config: comfyui.failure_log.path is required when source is %q", source)
+		}
+	default:
+		return nil, fmt.Errorf("config: comfyui.failure_log.source must be %q or %q, got %q", model.ComfyUILogSourceAPI, model.ComfyUILogSourceFile, raw.Source)
+	}
+
+	lines := 50
+	if raw.Lines != nil {
+		lines = *raw.Lines
+	}
+	if lines < 1 {
+		return nil, fmt.Errorf("config: comfyui.failure_log.lines must be at least 1, got %d", lines)
+	}
+
+	return &model.ComfyUIFailureLogConfig{
+		Source: source,
+		Path:   raw.Path,
+		Lines:  lines,
 	}, nil
 }
 
@@ -261,3 +1100,48 @@ func parseAndValidateURL(urlStr string) (string, error) {
 
 	return urlStr, nil
 }
+
+// parseFaultInjectionConfig parses and validates the fault injection configuration section.
+func parseFaultInjectionConfig(raw *yamlFaultInjectionConfig) (*model.FaultInjectionConfig, error) {
+	cfg := &model.FaultInjectionConfig{
+		DisconnectSeconds: 15,
+		SlowDownloadMs:    5000,
+	}
+	if raw.Seed != nil {
+		cfg.Seed = *raw.Seed
+	}
+	if raw.DisconnectSeconds != nil {
+		cfg.DisconnectSeconds = *raw.DisconnectSeconds
+	}
+	if raw.SlowDownloadMs != nil {
+		cfg.SlowDownloadMs = *raw.SlowDownloadMs
+	}
+
+	probabilities := []struct {
+		name string
+		raw  *float64
+		dst  *float64
+	}{
+		{"comfyui_disconnect", raw.ComfyUIDisconnect, &cfg.ComfyUIDisconnect},
+		{"slow_download", raw.SlowDownload, &cfg.SlowDownload},
+		{"database_busy", raw.DatabaseBusy, &cfg.DatabaseBusy},
+		{"file_write_failure", raw.FileWriteFailure, &cfg.FileWriteFailure},
+	}
+	for _, p := range probabilities {
+		if p.raw == nil {
+			continue
+		}
+		if *p.raw < 0 || *p.raw > 1 {
+			return nil, fmt.Errorf("config: fault_injection.%s must be between 0 and 1, got %g", p.name, *p.raw)
+		}
+		*p.dst = *p.raw
+	}
+
+	if cfg.DisconnectSeconds < 0 {
+		return nil, fmt.Errorf("config: fault_injection.disconnect_seconds must be >= 0, got %d", cfg.DisconnectSeconds)
+	}
+	if cfg.SlowDownloadMs < 0 {
+		return nil, fmt.Errorf("config: fault_injection.slow_download_ms must be >= 0, got %d", cfg.SlowDownloadMs)
+	}
+	return cfg, nil
+}
@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/config"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 )
 
 var _ = Describe("Config", func() {
@@ -385,6 +386,820 @@ sample_dir: "` + sampleDir + `"
 		})
 	})
 
+	Describe("request timeout configuration", func() {
+		load := func(extra string) (*model.Config, error) {
+			return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+` + extra)
+		}
+
+		It("defaults to 30 seconds", func() {
+			cfg, err := load("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RequestTimeout).To(Equal(30))
+		})
+
+		It("parses the value", func() {
+			cfg, err := load("request_timeout: 120\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RequestTimeout).To(Equal(120))
+		})
+
+		It("accepts 0 to disable the limit", func() {
+			cfg, err := load("request_timeout: 0\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RequestTimeout).To(Equal(0))
+		})
+
+		It("rejects a negative value", func() {
+			_, err := load("request_timeout: -1\n")
+			Expect(err).To(MatchError(ContainSubstring("request_timeout must be >= 0")))
+		})
+	})
+
+	Describe("log level configuration", func() {
+		load := func(extra string) (*model.Config, error) {
+			return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+` + extra)
+		}
+
+		It("has no overrides by default", func() {
+			cfg, err := load("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.LogLevels).To(BeEmpty())
+		})
+
+		It("parses per-component levels", func() {
+			cfg, err := load("log_levels:\n  comfyui_http: debug\n  store: warn\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.LogLevels).To(Equal(map[string]string{"comfyui_http": "debug", "store": "warn"}))
+		})
+
+		It("rejects an unknown level", func() {
+			_, err := load("log_levels:\n  store: loud\n")
+			Expect(err).To(MatchError(ContainSubstring(`log_levels.store: invalid level "loud"`)))
+		})
+	})
+
+	Describe("users configuration", func() {
+		load := func(extra string) (*model.Config, error) {
+			return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+` + extra)
+		}
+
+		It("has no users by default", func() {
+			cfg, err := load("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Users).To(BeEmpty())
+		})
+
+		It("parses users", func() {
+			cfg, err := load("users:\n  - name: alice\n    token: alice-token-0123456789\n    admin: true\n  - name: bob\n    token: bob-token-0123456789\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Users).To(Equal([]model.UserConfig{
+				{Name: "alice", Token: "alice-token-0123456789", Admin: true},
+				{Name: "bob", Token: "bob-token-0123456789"},
+			}))
+		})
+
+		DescribeTable("rejects invalid users without revealing tokens",
+			func(users, message string) {
+				_, err := load("users:\n" + users)
+				Expect(err).To(MatchError(ContainSubstring(message)))
+				Expect(err.Error()).NotTo(ContainSubstring("token-0123456789"))
+			},
+			Entry("empty name", "  - name: \"\"\n    token: alice-token-0123456789\n", "users[0].name must be"),
+			Entry("name with a space", "  - name: alice smith\n    token: alice-token-0123456789\n", "users[0].name must be"),
+			Entry("reserved name", "  - name: all\n    token: alice-token-0123456789\n", `users[0].name "all" is reserved`),
+			Entry("duplicate name", "  - name: alice\n    token: alice-token-0123456789\n  - name: alice\n    token: other-token-0123456789\n", `users[1].name "alice" is used by another user`),
+			Entry("short token", "  - name: alice\n    token: short\n", `users[0].token of user "alice" must be at least 16 characters`),
+			Entry("shared token", "  - name: alice\n    token: alice-token-0123456789\n  - name: bob\n    token: alice-token-0123456789\n", `users[1].token of user "bob" is used by another user`),
+		)
+	})
+
+	Describe("gRPC port configuration", func() {
+		load := func(extra string) (*model.Config, error) {
+			return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+` + extra)
+		}
+
+		It("parses the value correctly", func() {
+			cfg, err := load("grpc_port: 9090\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.GRPCPort).To(Equal(9090))
+		})
+
+		It("defaults to 0 (disabled)", func() {
+			cfg, err := load("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.GRPCPort).To(Equal(0))
+		})
+
+		It("rejects an out-of-range value", func() {
+			_, err := load("grpc_port: 70000\n")
+			Expect(err).To(MatchError(ContainSubstring("grpc_port must be between 0 and 65535, got 70000")))
+		})
+
+		It("rejects the HTTP port", func() {
+			_, err := load("port: 9090\ngrpc_port: 9090\n")
+			Expect(err).To(MatchError(ContainSubstring("grpc_port must differ from port 9090")))
+		})
+	})
+
+	Describe("Slow query threshold configuration", func() {
+		load := func(extra string) (*model.Config, error) {
+			return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+` + extra)
+		}
+
+		It("parses the value correctly", func() {
+			cfg, err := load("slow_query_ms: 250\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.SlowQueryMs).To(Equal(250))
+		})
+
+		It("defaults to 100 milliseconds", func() {
+			cfg, err := load("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.SlowQueryMs).To(Equal(100))
+		})
+
+		It("rejects a value below 1", func() {
+			_, err := load("slow_query_ms: 0\n")
+			Expect(err).To(MatchError(ContainSubstring("slow_query_ms must be at least 1, got 0")))
+		})
+	})
+
+	Describe("Trash retention configuration", func() {
+		load := func(extra string) (*model.Config, error) {
+			return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+` + extra)
+		}
+
+		It("parses the value correctly", func() {
+			cfg, err := load("trash_retention_days: 7\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.TrashRetentionDays).To(Equal(7))
+		})
+
+		It("defaults to 30 days", func() {
+			cfg, err := load("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.TrashRetentionDays).To(Equal(30))
+		})
+
+		It("accepts 0 to disable automatic purging", func() {
+			cfg, err := load("trash_retention_days: 0\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.TrashRetentionDays).To(Equal(0))
+		})
+
+		It("rejects a negative value", func() {
+			_, err := load("trash_retention_days: -1\n")
+			Expect(err).To(MatchError(ContainSubstring("trash_retention_days must be >= 0, got -1")))
+		})
+	})
+
+	Describe("Timezone and locale configuration", func() {
+		load := func(extra string) (*model.Config, error) {
+			return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+` + extra)
+		}
+
+		It("parses the values correctly", func() {
+			cfg, err := load("timezone: Europe/Berlin\nlocale: de-DE\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Timezone).To(Equal("Europe/Berlin"))
+			Expect(cfg.Locale).To(Equal("de-DE"))
+		})
+
+		It("defaults to UTC and en-US", func() {
+			cfg, err := load("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Timezone).To(Equal("UTC"))
+			Expect(cfg.Locale).To(Equal("en-US"))
+		})
+
+		It("rejects an unknown timezone", func() {
+			_, err := load("timezone: Mars/Olympus_Mons\n")
+			Expect(err).To(MatchError(ContainSubstring(`invalid timezone "Mars/Olympus_Mons"`)))
+		})
+
+		It("rejects the host's local timezone", func() {
+			_, err := load("timezone: Local\n")
+			Expect(err).To(MatchError(ContainSubstring("timezone must be an IANA time zone name")))
+		})
+
+		It("rejects a malformed locale", func() {
+			_, err := load("locale: en_US\n")
+			Expect(err).To(MatchError(ContainSubstring(`invalid locale "en_US"`)))
+		})
+	})
+
+	Describe("Output layout configuration", func() {
+		load := func(extra string) (*model.Config, error) {
+			return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+` + extra)
+		}
+
+		It("parses the template", func() {
+			cfg, err := load("output_layout: \"{checkpoint}/{prompt}/seed{seed}\"\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OutputLayout.String()).To(Equal("{checkpoint}/{prompt}/seed{seed}"))
+		})
+
+		It("defaults to query-encoded filenames", func() {
+			cfg, err := load("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OutputLayout.IsZero()).To(BeTrue())
+		})
+
+		It("rejects an invalid template", func() {
+			_, err := load("output_layout: \"{prompt}/{seed}\"\n")
+			Expect(err).To(MatchError(ContainSubstring("invalid output_layout")))
+		})
+	})
+
+	Describe("Multi-process configuration", func() {
+		It("parses all fields", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+multi_process:
+  role: executor
+  instance_id: worker-1
+  lease_ttl: 10
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.MultiProcess).NotTo(BeNil())
+			Expect(cfg.MultiProcess.Role).To(Equal(model.ProcessRoleExecutor))
+			Expect(cfg.MultiProcess.InstanceID).To(Equal("worker-1"))
+			Expect(cfg.MultiProcess.LeaseTTL).To(Equal(10))
+		})
+
+		It("applies defaults for an empty section", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+multi_process: {}
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.MultiProcess).NotTo(BeNil())
+			Expect(cfg.MultiProcess.Role).To(Equal(model.ProcessRoleAll))
+			Expect(cfg.MultiProcess.InstanceID).To(BeEmpty())
+			Expect(cfg.MultiProcess.LeaseTTL).To(Equal(30))
+		})
+
+		It("is nil when the section is absent", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.MultiProcess).To(BeNil())
+		})
+
+		DescribeTable("rejects invalid values",
+			func(section string, expected string) {
+				yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+multi_process:
+` + section
+				_, err := config.LoadFromString(yamlStr)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("unknown role", "  role: scheduler\n", "multi_process.role must be one of"),
+			Entry("lease_ttl too short", "  lease_ttl: 1\n", "multi_process.lease_ttl must be at least 3"),
+		)
+	})
+
+	Describe("Auto-sample configuration", func() {
+		It("parses all fields", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+auto_sample:
+  settle_seconds: 60
+  training_runs:
+    - training_run: my-lora
+      study_id: study-1
+      workflow: qwen-image.json
+      enabled: false
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.AutoSample).NotTo(BeNil())
+			Expect(cfg.AutoSample.SettleSeconds).To(Equal(60))
+			Expect(cfg.AutoSample.TrainingRuns).To(Equal([]model.AutoSampleRule{
+				{TrainingRun: "my-lora", StudyID: "study-1", Workflow: "qwen-image.json", Enabled: false},
+			}))
+		})
+
+		It("applies defaults", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+auto_sample:
+  training_runs:
+    - training_run: my-lora
+      study_id: study-1
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.AutoSample.SettleSeconds).To(Equal(30))
+			Expect(cfg.AutoSample.TrainingRuns[0].Enabled).To(BeTrue())
+			Expect(cfg.AutoSample.TrainingRuns[0].Workflow).To(BeEmpty())
+		})
+
+		It("is nil when the section is absent", func() {
+			cfg, err := config.LoadFromString(validConfig())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.AutoSample).To(BeNil())
+		})
+
+		DescribeTable("rejects invalid values",
+			func(section string, expected string) {
+				yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+auto_sample:
+` + section
+				_, err := config.LoadFromString(yamlStr)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("settle_seconds too short", "  settle_seconds: 0\n", "auto_sample.settle_seconds must be at least 1"),
+			Entry("missing training_run", "  training_runs:\n    - study_id: s\n", "auto_sample.training_runs[0].training_run is required"),
+			Entry("missing study_id", "  training_runs:\n    - training_run: r\n", "auto_sample.training_runs[0].study_id is required"),
+			Entry("duplicate training run", "  training_runs:\n    - training_run: r\n      study_id: a\n    - training_run: r\n      study_id: b\n", "auto_sample.training_runs[1] duplicates training run \"r\""),
+		)
+	})
+
+	Describe("Heartbeat configuration", func() {
+		It("parses all fields", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+heartbeat:
+  file: /data/heartbeat
+  url: https://hc-ping.example.com/abc
+  interval: 120
+  stall_timeout: 0
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Heartbeat).To(Equal(&model.HeartbeatConfig{
+				File:         "/data/heartbeat",
+				URL:          "https://hc-ping.example.com/abc",
+				Interval:     120,
+				StallTimeout: 0,
+			}))
+		})
+
+		It("applies defaults", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+heartbeat:
+  file: /data/heartbeat
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Heartbeat.Interval).To(Equal(60))
+			Expect(cfg.Heartbeat.StallTimeout).To(Equal(1800))
+		})
+
+		It("is nil when the section is absent", func() {
+			cfg, err := config.LoadFromString(validConfig())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Heartbeat).To(BeNil())
+		})
+
+		DescribeTable("rejects invalid values",
+			func(section string, expected string) {
+				yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+heartbeat:
+` + section
+				_, err := config.LoadFromString(yamlStr)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("neither file nor url", "  interval: 60\n", "heartbeat requires file or url"),
+			Entry("url without scheme", "  url: hc-ping.example.com/abc\n", "heartbeat.url"),
+			Entry("interval too short", "  file: /hb\n  interval: 0\n", "heartbeat.interval must be at least 1"),
+			Entry("negative stall_timeout", "  file: /hb\n  stall_timeout: -1\n", "heartbeat.stall_timeout must be >= 0"),
+		)
+	})
+
+	Describe("Scoring configuration", func() {
+		scoringConfig := func(section string) string {
+			return `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+scoring:
+` + section
+		}
+
+		It("parses all fields", func() {
+			cfg, err := config.LoadFromString(scoringConfig("  url: http://scorer:8000/score\n  timeout: 5\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Scoring).To(Equal(&model.ScoringConfig{
+				URL:     "http://scorer:8000/score",
+				Timeout: 5,
+			}))
+		})
+
+		It("applies defaults", func() {
+			cfg, err := config.LoadFromString(scoringConfig("  url: http://scorer:8000/score\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Scoring.Timeout).To(Equal(30))
+		})
+
+		It("is nil when the section is absent", func() {
+			cfg, err := config.LoadFromString(validConfig())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Scoring).To(BeNil())
+		})
+
+		DescribeTable("rejects invalid values",
+			func(section string, expected string) {
+				_, err := config.LoadFromString(scoringConfig(section))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("missing url", "  timeout: 5\n", "scoring.url"),
+			Entry("unsupported scheme", "  url: ftp://scorer/score\n", "scoring.url"),
+			Entry("timeout too short", "  url: http://scorer:8000/score\n  timeout: 0\n", "scoring.timeout must be at least 1"),
+		)
+	})
+
+	Describe("Retention configuration", func() {
+		retentionConfig := func(section string) string {
+			return `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+retention:
+` + section
+		}
+
+		It("parses all fields", func() {
+			cfg, err := config.LoadFromString(retentionConfig("  keep_latest: 5\n  keep_top_rated: 3\n  min_age_days: 0\n  interval: 6\n  dry_run: false\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Retention).To(Equal(&model.RetentionConfig{
+				KeepLatest:   5,
+				KeepTopRated: 3,
+				MinAgeDays:   0,
+				Interval:     6,
+				DryRun:       false,
+			}))
+		})
+
+		It("applies defaults", func() {
+			cfg, err := config.LoadFromString(retentionConfig("  keep_latest: 5\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Retention.MinAgeDays).To(Equal(7))
+			Expect(cfg.Retention.Interval).To(Equal(24))
+			Expect(cfg.Retention.DryRun).To(BeTrue())
+		})
+
+		It("is nil when the section is absent", func() {
+			cfg, err := config.LoadFromString(validConfig())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Retention).To(BeNil())
+		})
+
+		DescribeTable("rejects invalid values",
+			func(section string, expected string) {
+				_, err := config.LoadFromString(retentionConfig(section))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("no keep rule", "  min_age_days: 1\n", "retention requires keep_latest or keep_top_rated"),
+			Entry("negative keep_latest", "  keep_latest: -1\n", "retention.keep_latest must be >= 0"),
+			Entry("negative min_age_days", "  keep_latest: 1\n  min_age_days: -1\n", "retention.min_age_days must be >= 0"),
+			Entry("interval too short", "  keep_latest: 1\n  interval: 0\n", "retention.interval must be at least 1"),
+		)
+	})
+
+	Describe("Notifications configuration", func() {
+		It("parses all fields", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+notifications:
+  ui_url: http://sampler.local:8080
+  webhooks:
+    - url: https://discord.com/api/webhooks/1/abc
+      format: discord
+      events: [failed, stopped]
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Notifications).To(Equal(&model.NotificationsConfig{
+				UIURL: "http://sampler.local:8080",
+				Webhooks: []model.WebhookConfig{{
+					URL:    "https://discord.com/api/webhooks/1/abc",
+					Format: model.WebhookFormatDiscord,
+					Events: []model.JobEvent{model.JobEventFailed, model.JobEventStopped},
+				}},
+			}))
+		})
+
+		It("applies defaults", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+notifications:
+  webhooks:
+    - url: https://hooks.example.com/sampler
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Notifications.Webhooks[0].Format).To(Equal(model.WebhookFormatJSON))
+			Expect(cfg.Notifications.Webhooks[0].Events).To(Equal(model.AllJobEvents))
+		})
+
+		It("is nil when the section is absent", func() {
+			cfg, err := config.LoadFromString(validConfig())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Notifications).To(BeNil())
+		})
+
+		DescribeTable("rejects invalid values",
+			func(section string, expected string) {
+				yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+notifications:
+` + section
+				_, err := config.LoadFromString(yamlStr)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("no webhooks", "  ui_url: http://sampler.local\n", "notifications.webhooks requires at least one webhook"),
+			Entry("ui_url without scheme", "  ui_url: sampler.local\n  webhooks:\n    - url: http://hooks.local\n", "notifications.ui_url"),
+			Entry("url without scheme", "  webhooks:\n    - url: hooks.local/abc\n", "notifications.webhooks[0].url"),
+			Entry("unknown format", "  webhooks:\n    - url: http://hooks.local\n      format: teams\n", "notifications.webhooks[0].format must be one of json, discord, slack"),
+			Entry("unknown event", "  webhooks:\n    - url: http://hooks.local\n      events: [started]\n", "notifications.webhooks[0].events must contain only completed, failed, stopped"),
+		)
+	})
+
+	Describe("Assets configuration", func() {
+		It("parses all fields", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+assets:
+  dir: /data/assets
+  max_image_size_mb: 20
+  max_wildcards_size_mb: 2
+  upload_ttl: 6
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Assets).To(Equal(&model.AssetsConfig{
+				Dir:                "/data/assets",
+				MaxImageSizeMB:     20,
+				MaxWildcardsSizeMB: 2,
+				UploadTTL:          6,
+			}))
+		})
+
+		It("applies defaults", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+assets: {}
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Assets).To(Equal(&model.AssetsConfig{
+				Dir:                "./data/assets",
+				MaxImageSizeMB:     50,
+				MaxWildcardsSizeMB: 10,
+				UploadTTL:          24,
+			}))
+		})
+
+		It("is nil when the section is absent", func() {
+			cfg, err := config.LoadFromString(validConfig())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Assets).To(BeNil())
+		})
+
+		DescribeTable("rejects invalid values",
+			func(section string, expected string) {
+				yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+assets:
+` + section
+				_, err := config.LoadFromString(yamlStr)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("image size too small", "  max_image_size_mb: 0\n", "assets.max_image_size_mb must be at least 1"),
+			Entry("wildcards size too small", "  max_wildcards_size_mb: 0\n", "assets.max_wildcards_size_mb must be at least 1"),
+			Entry("upload_ttl too short", "  upload_ttl: 0\n", "assets.upload_ttl must be at least 1"),
+		)
+	})
+
+	Describe("Rate limit configuration", func() {
+		It("parses all fields", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+rate_limit:
+  requests_per_second: 20
+  burst: 40
+  max_concurrent_heavy: 4
+  queue_timeout: 10
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RateLimit).To(Equal(&model.RateLimitConfig{
+				RequestsPerSecond:  20,
+				Burst:              40,
+				MaxConcurrentHeavy: 4,
+				QueueTimeout:       10,
+			}))
+		})
+
+		It("applies defaults", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+rate_limit: {}
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RateLimit).To(Equal(&model.RateLimitConfig{
+				RequestsPerSecond:  100,
+				Burst:              500,
+				MaxConcurrentHeavy: 8,
+				QueueTimeout:       30,
+			}))
+		})
+
+		It("accepts zero to disable either limit", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+rate_limit:
+  requests_per_second: 0
+  burst: 0
+  max_concurrent_heavy: 0
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RateLimit.RequestsPerSecond).To(BeZero())
+			Expect(cfg.RateLimit.MaxConcurrentHeavy).To(BeZero())
+		})
+
+		It("is nil when the section is absent", func() {
+			cfg, err := config.LoadFromString(validConfig())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RateLimit).To(BeNil())
+		})
+
+		DescribeTable("rejects invalid values",
+			func(section string, expected string) {
+				yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+rate_limit:
+` + section
+				_, err := config.LoadFromString(yamlStr)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("negative requests_per_second", "  requests_per_second: -1\n", "rate_limit.requests_per_second must be >= 0"),
+			Entry("burst too small", "  burst: 0\n", "rate_limit.burst must be at least 1"),
+			Entry("negative max_concurrent_heavy", "  max_concurrent_heavy: -1\n", "rate_limit.max_concurrent_heavy must be >= 0"),
+			Entry("negative queue_timeout", "  queue_timeout: -1\n", "rate_limit.queue_timeout must be >= 0"),
+		)
+	})
+
+	Describe("Fault injection configuration", func() {
+		It("parses all fields", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+fault_injection:
+  seed: 42
+  comfyui_disconnect: 0.05
+  disconnect_seconds: 30
+  slow_download: 0.2
+  slow_download_ms: 1500
+  database_busy: 0.01
+  file_write_failure: 1
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.FaultInjection).To(Equal(&model.FaultInjectionConfig{
+				Seed:              42,
+				ComfyUIDisconnect: 0.05,
+				DisconnectSeconds: 30,
+				SlowDownload:      0.2,
+				SlowDownloadMs:    1500,
+				DatabaseBusy:      0.01,
+				FileWriteFailure:  1,
+			}))
+		})
+
+		It("applies defaults", func() {
+			yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+fault_injection: {}
+`
+			cfg, err := config.LoadFromString(yamlStr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.FaultInjection).To(Equal(&model.FaultInjectionConfig{
+				DisconnectSeconds: 15,
+				SlowDownloadMs:    5000,
+			}))
+		})
+
+		It("is nil when the section is absent", func() {
+			cfg, err := config.LoadFromString(validConfig())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.FaultInjection).To(BeNil())
+		})
+
+		DescribeTable("rejects invalid values",
+			func(section string, expected string) {
+				yamlStr := `
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+fault_injection:
+` + section
+				_, err := config.LoadFromString(yamlStr)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("negative probability", "  comfyui_disconnect: -0.1\n", "fault_injection.comfyui_disconnect must be between 0 and 1"),
+			Entry("probability above one", "  database_busy: 1.5\n", "fault_injection.database_busy must be between 0 and 1"),
+			Entry("negative disconnect_seconds", "  disconnect_seconds: -1\n", "fault_injection.disconnect_seconds must be >= 0"),
+			Entry("negative slow_download_ms", "  slow_download_ms: -1\n", "fault_injection.slow_download_ms must be >= 0"),
+		)
+	})
+
 	Describe("ComfyUI configuration", func() {
 		Context("when comfyui section is present", func() {
 			It("parses comfyui config with all fields", func() {
@@ -508,6 +1323,319 @@ comfyui:
 			)
 		})
 
+		Context("vram_hard_limit_mb configuration", func() {
+			load := func(extra string) (*model.Config, error) {
+				return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+comfyui:
+  url: "http://localhost:8188"
+` + extra)
+			}
+
+			It("defaults to no hard limit", func() {
+				cfg, err := load("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.VRAMHardLimitMB).To(Equal(0))
+			})
+
+			It("parses a hard limit", func() {
+				cfg, err := load("  vram_hard_limit_mb: 24000\n")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.VRAMHardLimitMB).To(Equal(24000))
+			})
+
+			It("rejects a negative hard limit", func() {
+				_, err := load("  vram_hard_limit_mb: -1\n")
+				Expect(err).To(MatchError(ContainSubstring("vram_hard_limit_mb must be >= 0")))
+			})
+		})
+
+		Context("prewarm configuration", func() {
+			load := func(extra string) (*model.Config, error) {
+				return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+comfyui:
+  url: "http://localhost:8188"
+` + extra)
+			}
+
+			It("defaults to off", func() {
+				cfg, err := load("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.Prewarm).To(BeFalse())
+			})
+
+			It("parses prewarm", func() {
+				cfg, err := load("  prewarm: true\n")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.Prewarm).To(BeTrue())
+			})
+		})
+
+		Context("drain_timeout configuration", func() {
+			load := func(extra string) (*model.Config, error) {
+				return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+comfyui:
+  url: "http://localhost:8188"
+` + extra)
+			}
+
+			It("defaults to 30 seconds", func() {
+				cfg, err := load("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.DrainTimeout).To(Equal(30))
+			})
+
+			It("parses drain_timeout, including zero", func() {
+				cfg, err := load("  drain_timeout: 0\n")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.DrainTimeout).To(Equal(0))
+			})
+
+			It("rejects a negative drain_timeout", func() {
+				_, err := load("  drain_timeout: -1\n")
+				Expect(err).To(MatchError(ContainSubstring("drain_timeout must be >= 0")))
+			})
+		})
+
+		Context("item_timeout configuration", func() {
+			load := func(extra string) (*model.Config, error) {
+				return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+comfyui:
+  url: "http://localhost:8188"
+` + extra)
+			}
+
+			It("defaults to 15 minutes", func() {
+				cfg, err := load("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.ItemTimeout).To(Equal(900))
+			})
+
+			It("parses item_timeout, including zero", func() {
+				cfg, err := load("  item_timeout: 0\n")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.ItemTimeout).To(Equal(0))
+			})
+
+			It("rejects a negative item_timeout", func() {
+				_, err := load("  item_timeout: -1\n")
+				Expect(err).To(MatchError(ContainSubstring("item_timeout must be >= 0")))
+			})
+		})
+
+		Context("max_in_flight configuration", func() {
+			load := func(extra string) (*model.Config, error) {
+				return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+comfyui:
+  url: "http://localhost:8188"
+` + extra)
+			}
+
+			It("defaults to one prompt at a time", func() {
+				cfg, err := load("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.MaxInFlight).To(Equal(1))
+			})
+
+			It("parses max_in_flight", func() {
+				cfg, err := load("  max_in_flight: 3\n")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.MaxInFlight).To(Equal(3))
+			})
+
+			It("rejects a max_in_flight below one", func() {
+				_, err := load("  max_in_flight: 0\n")
+				Expect(err).To(MatchError(ContainSubstring("max_in_flight must be >= 1")))
+			})
+		})
+
+		Context("model_cache_ttl configuration", func() {
+			load := func(extra string) (*model.Config, error) {
+				return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+comfyui:
+  url: "http://localhost:8188"
+` + extra)
+			}
+
+			It("defaults to 60 seconds", func() {
+				cfg, err := load("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.ModelCacheTTL).To(Equal(60))
+			})
+
+			It("parses model_cache_ttl, including zero", func() {
+				cfg, err := load("  model_cache_ttl: 0\n")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.ModelCacheTTL).To(Equal(0))
+			})
+
+			It("rejects a negative model_cache_ttl", func() {
+				_, err := load("  model_cache_ttl: -1\n")
+				Expect(err).To(MatchError(ContainSubstring("model_cache_ttl must be >= 0")))
+			})
+		})
+
+		Context("failure_log configuration", func() {
+			load := func(failureLog string) (*model.Config, error) {
+				return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+comfyui:
+  url: "http://localhost:8188"
+` + failureLog)
+			}
+
+			It("parses all fields", func() {
+				cfg, err := load(`  failure_log:
+    source: file
+    path: /var/log/comfyui.log
+    lines: 200
+`)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.FailureLog).To(Equal(&model.ComfyUIFailureLogConfig{
+					Source: model.ComfyUILogSourceFile,
+					Path:   "/var/log/comfyui.log",
+					Lines:  200,
+				}))
+			})
+
+			It("applies defaults", func() {
+				cfg, err := load(`  failure_log:
+    source: api
+`)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.FailureLog).To(Equal(&model.ComfyUIFailureLogConfig{
+					Source: model.ComfyUILogSourceAPI,
+					Lines:  50,
+				}))
+			})
+
+			It("is nil when the section is absent", func() {
+				cfg, err := load("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.FailureLog).To(BeNil())
+			})
+
+			DescribeTable("rejects invalid values",
+				func(failureLog string, expectedErr string) {
+					_, err := load(failureLog)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring(expectedErr))
+				},
+				Entry("missing source", "  failure_log:\n    lines: 10\n", `comfyui.failure_log.source must be "api" or "file"`),
+				Entry("unknown source", "  failure_log:\n    source: syslog\n", `comfyui.failure_log.source must be "api" or "file", got "syslog"`),
+				Entry("file source without path", "  failure_log:\n    source: file\n", "comfyui.failure_log.path is required"),
+				Entry("zero lines", "  failure_log:\n    source: api\n    lines: 0\n", "comfyui.failure_log.lines must be at least 1"),
+			)
+		})
+
+		Context("checkpoint_staging configuration", func() {
+			load := func(staging string) (*model.Config, error) {
+				return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+comfyui:
+  url: "http://localhost:8188"
+` + staging)
+			}
+
+			It("parses all fields", func() {
+				cfg, err := load(`  checkpoint_staging:
+    dir: /shared/models/diffusion_models/staging
+    model_path: staging/
+`)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.CheckpointStaging).To(Equal(&model.CheckpointStagingConfig{
+					Dir:       "/shared/models/diffusion_models/staging",
+					ModelPath: "staging",
+				}))
+			})
+
+			It("is nil when the section is absent", func() {
+				cfg, err := load("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.CheckpointStaging).To(BeNil())
+			})
+
+			DescribeTable("rejects invalid values",
+				func(staging string, expectedErr string) {
+					_, err := load(staging)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring(expectedErr))
+				},
+				Entry("missing dir", "  checkpoint_staging:\n    model_path: staging\n", "comfyui.checkpoint_staging.dir is required"),
+				Entry("missing model_path", "  checkpoint_staging:\n    dir: /staging\n", "comfyui.checkpoint_staging.model_path is required"),
+				Entry("absolute model_path", "  checkpoint_staging:\n    dir: /staging\n    model_path: /models/staging\n", "model_path must be a relative path"),
+				Entry("model_path outside the model directory", "  checkpoint_staging:\n    dir: /staging\n    model_path: ../staging\n", "model_path must be a relative path"),
+			)
+		})
+
+		Context("path_mapping configuration", func() {
+			load := func(mapping string) (*model.Config, error) {
+				return config.LoadFromString(`
+checkpoint_dirs:
+  - "` + filepath.Join(tmpDir, "checkpoints") + `"
+sample_dir: "` + sampleDir + `"
+comfyui:
+  url: "http://localhost:8188"
+` + mapping)
+			}
+
+			It("parses the rules in order", func() {
+				cfg, err := load(`  path_mapping:
+    - strip_prefix: "run-"
+    - pattern: '-step(\d+)'
+      replace: '_s$1'
+    - training_run_subdir: true
+    - add_prefix: "sweeps/"
+`)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.PathMapping).To(Equal([]model.CheckpointPathRule{
+					{StripPrefix: "run-"},
+					{Pattern: `-step(\d+)`, Replace: "_s$1"},
+					{TrainingRunSubdir: true},
+					{AddPrefix: "sweeps/"},
+				}))
+			})
+
+			It("is empty when the section is absent", func() {
+				cfg, err := load("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.ComfyUI.PathMapping).To(BeEmpty())
+			})
+
+			DescribeTable("rejects invalid rules",
+				func(mapping string, expectedErr string) {
+					_, err := load(mapping)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring(expectedErr))
+				},
+				Entry("empty rule", "  path_mapping:\n    - replace: x\n", "comfyui.path_mapping[0] must set exactly one of"),
+				Entry("two rewrites in one rule", "  path_mapping:\n    - strip_prefix: a\n      add_prefix: b\n", "comfyui.path_mapping[0] must set exactly one of"),
+				Entry("replace without pattern", "  path_mapping:\n    - add_prefix: a\n      replace: b\n", "comfyui.path_mapping[0].replace requires pattern"),
+				Entry("invalid pattern", "  path_mapping:\n    - strip_prefix: a\n    - pattern: '('\n", "comfyui.path_mapping[1].pattern is not a valid regular expression"),
+			)
+		})
+
 		Context("comfyui URL validation", func() {
 			DescribeTable("rejects invalid URLs",
 				func(url string, expectedErr string) {
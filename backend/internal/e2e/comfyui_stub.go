@@ -0,0 +1,285 @@
+// Package e2e runs the server's sample job pipeline in-process for black-box
+// tests: a scripted ComfyUI stub, and a harness that wires the real store,
+// services, job executor, and HTTP API to it in temporary directories.
+package e2e
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// stubPNG is a valid 1x1 white PNG, the image every successful prompt
+// produces. It is the same image comfyui-mock serves.
+var stubPNG, _ = base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAIAAACQd1PeAAAADElEQVR4nGP4//8/AAX+Av4N70a4AAAAAElFTkSuQmCC")
+
+// stubProgressSteps is the number of sampler progress events sent per prompt.
+const stubProgressSteps = 3
+
+// stubResponseGuard delays execution events so that the response to the
+// prompt submission arrives first, as it does with ComfyUI.
+const stubResponseGuard = 100 * time.Millisecond
+
+// ComfyUIStub is a scripted, in-process stand-in for the parts of the ComfyUI
+// API the server uses: model lists, prompt submission, history, image
+// download, and the WebSocket that reports execution. It is the Go
+// counterpart of comfyui-mock, for tests that run the server in-process.
+//
+// Every submitted prompt completes after the configured delay with a 1x1 PNG,
+// unless it was scripted to fail with FailPrompt.
+type ComfyUIStub struct {
+	server      *httptest.Server
+	checkpoints []string
+	upgrader    websocket.Upgrader
+	done        chan struct{}
+
+	mu       sync.Mutex
+	clients  map[string]*stubClient
+	prompts  []StubPrompt
+	outputs  map[string]string // prompt ID -> output filename; failed prompts have none
+	failures map[int]string    // prompt index -> exception message
+	delay    time.Duration
+}
+
+// StubPrompt is a prompt submitted to the stub, in submission order.
+type StubPrompt struct {
+	ID       string
+	Workflow map[string]interface{}
+}
+
+// stubClient is a WebSocket connection. Prompts complete concurrently, so
+// writes are serialized.
+type stubClient struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *stubClient) send(eventType string, data map[string]interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteJSON(map[string]interface{}{"type": eventType, "data": data})
+}
+
+// NewComfyUIStub starts a stub that reports checkpoints as its diffusion
+// models. Close it when done.
+func NewComfyUIStub(checkpoints ...string) *ComfyUIStub {
+	s := &ComfyUIStub{
+		checkpoints: checkpoints,
+		done:        make(chan struct{}),
+		clients:     make(map[string]*stubClient),
+		outputs:     make(map[string]string),
+		failures:    make(map[int]string),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /system_stats", s.handleSystemStats)
+	mux.HandleFunc("GET /object_info/{node}", s.handleObjectInfo)
+	mux.HandleFunc("POST /prompt", s.handlePrompt)
+	mux.HandleFunc("GET /history/{id}", s.handleHistory)
+	mux.HandleFunc("GET /view", s.handleView)
+	mux.HandleFunc("POST /queue", s.handleOK)
+	mux.HandleFunc("POST /interrupt", s.handleOK)
+	mux.HandleFunc("GET /ws", s.handleWS)
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// URL returns the base URL to configure as the ComfyUI URL.
+func (s *ComfyUIStub) URL() string {
+	return s.server.URL
+}
+
+// Close stops the stub and drops its WebSocket connections.
+func (s *ComfyUIStub) Close() {
+	close(s.done)
+	s.mu.Lock()
+	for _, c := range s.clients {
+		c.conn.Close()
+	}
+	s.mu.Unlock()
+	s.server.Close()
+}
+
+// SetDelay sets how long prompts submitted from now on take to execute.
+func (s *ComfyUIStub) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// FailPrompt scripts the index-th prompt submitted (0-based) to fail with an
+// execution_error carrying message, as a ComfyUI node exception would.
+func (s *ComfyUIStub) FailPrompt(index int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[index] = message
+}
+
+// Prompts returns the prompts submitted so far.
+func (s *ComfyUIStub) Prompts() []StubPrompt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StubPrompt(nil), s.prompts...)
+}
+
+func (s *ComfyUIStub) handleSystemStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"system": map[string]interface{}{}, "devices": []interface{}{}})
+}
+
+func (s *ComfyUIStub) handleObjectInfo(w http.ResponseWriter, r *http.Request) {
+	node := r.PathValue("node")
+	var required map[string]interface{}
+	switch node {
+	case "UNETLoader":
+		required = map[string]interface{}{"unet_name": []interface{}{s.checkpoints, map[string]interface{}{}}}
+	case "VAELoader":
+		required = map[string]interface{}{"vae_name": []interface{}{[]string{"test-vae.safetensors"}, map[string]interface{}{}}}
+	case "CLIPLoader":
+		required = map[string]interface{}{"clip_name": []interface{}{[]string{"test-clip.safetensors"}, map[string]interface{}{}}}
+	case "KSampler":
+		required = map[string]interface{}{
+			"sampler_name": []interface{}{[]string{"euler", "euler_ancestral", "dpmpp_2m"}, map[string]interface{}{}},
+			"scheduler":    []interface{}{[]string{"normal", "karras", "simple"}, map[string]interface{}{}},
+		}
+	default:
+		writeJSON(w, http.StatusOK, map[string]interface{}{})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		node: map[string]interface{}{
+			"input": map[string]interface{}{"required": required, "optional": map[string]interface{}{}},
+			"name":  node,
+		},
+	})
+}
+
+func (s *ComfyUIStub) handlePrompt(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Prompt   map[string]interface{} `json:"prompt"`
+		ClientID string                 `json:"client_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid JSON"})
+		return
+	}
+
+	id := uuid.New().String()
+	s.mu.Lock()
+	index := len(s.prompts)
+	s.prompts = append(s.prompts, StubPrompt{ID: id, Workflow: req.Prompt})
+	failure, fails := s.failures[index]
+	if !fails {
+		s.outputs[id] = fmt.Sprintf("ComfyUI_%s_00001_.png", strings.ReplaceAll(id, "-", "")[:8])
+	}
+	client := s.clients[req.ClientID]
+	delay := s.delay
+	s.mu.Unlock()
+
+	if client != nil {
+		go s.execute(client, id, delay, failure, fails)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"prompt_id": id, "number": index, "node_errors": map[string]interface{}{}})
+}
+
+// execute reports the execution of a prompt over the WebSocket the way
+// ComfyUI does: executing a node, sampler progress, then either an
+// executing event with a null node or an execution_error.
+func (s *ComfyUIStub) execute(client *stubClient, promptID string, delay time.Duration, failure string, fails bool) {
+	select {
+	case <-time.After(stubResponseGuard + delay):
+	case <-s.done:
+		return
+	}
+
+	if err := client.send("executing", map[string]interface{}{"prompt_id": promptID, "node": "1"}); err != nil {
+		return
+	}
+	for step := 1; step <= stubProgressSteps; step++ {
+		if err := client.send("progress", map[string]interface{}{"prompt_id": promptID, "value": step, "max": stubProgressSteps}); err != nil {
+			return
+		}
+	}
+	if fails {
+		_ = client.send("execution_error", map[string]interface{}{
+			"prompt_id":         promptID,
+			"node_type":         "KSampler",
+			"exception_type":    "RuntimeError",
+			"exception_message": failure,
+			"traceback":         []interface{}{},
+		})
+		return
+	}
+	_ = client.send("executing", map[string]interface{}{"prompt_id": promptID, "node": nil})
+}
+
+func (s *ComfyUIStub) handleHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	filename, ok := s.outputs[id]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		id: map[string]interface{}{
+			"prompt": []interface{}{},
+			"outputs": map[string]interface{}{
+				"save_image_node": map[string]interface{}{
+					"images": []interface{}{map[string]interface{}{"filename": filename, "subfolder": "", "type": "output"}},
+				},
+			},
+			"status": map[string]interface{}{"status_str": "success", "completed": true},
+		},
+	})
+}
+
+func (s *ComfyUIStub) handleView(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(stubPNG)
+}
+
+func (s *ComfyUIStub) handleOK(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (s *ComfyUIStub) handleWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	clientID := r.URL.Query().Get("clientId")
+	s.mu.Lock()
+	s.clients[clientID] = &stubClient{conn: conn}
+	s.mu.Unlock()
+
+	// Read until the client disconnects; ComfyUI ignores client messages.
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			if c := s.clients[clientID]; c != nil && c.conn == conn {
+				delete(s.clients, clientID)
+			}
+			s.mu.Unlock()
+			conn.Close()
+		}()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
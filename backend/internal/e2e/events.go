@@ -0,0 +1,69 @@
+package e2e
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Event is an event received on the server's WebSocket, e.g. a
+// job_progress event or an image_added event for a new sample.
+type Event struct {
+	Type           string         `json:"type"`
+	Path           string         `json:"path"`
+	JobID          string         `json:"job_id"`
+	Status         string         `json:"status"`
+	TotalItems     int            `json:"total_items"`
+	CompletedItems int            `json:"completed_items"`
+	FailedItems    int            `json:"failed_items"`
+	SkippedItems   map[string]int `json:"skipped_items"`
+}
+
+// EventStream receives the events the server broadcasts.
+type EventStream struct {
+	conn *websocket.Conn
+}
+
+// Events connects to the server's WebSocket. Events broadcast before it
+// connects are not received. Close the stream when done.
+func (h *Harness) Events() (*EventStream, error) {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(h.URL, "http")+"/api/ws", nil)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to websocket: %w", err)
+	}
+	return &EventStream{conn: conn}, nil
+}
+
+// Next returns the next event, failing if none arrives within timeout.
+func (s *EventStream) Next(timeout time.Duration) (Event, error) {
+	if err := s.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return Event{}, err
+	}
+	var event Event
+	if err := s.conn.ReadJSON(&event); err != nil {
+		return Event{}, fmt.Errorf("reading event: %w", err)
+	}
+	return event, nil
+}
+
+// WaitFor skips events until one matches, and returns it. It fails if none
+// does within timeout.
+func (s *EventStream) WaitFor(timeout time.Duration, match func(Event) bool) (Event, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		event, err := s.Next(time.Until(deadline))
+		if err != nil {
+			return Event{}, err
+		}
+		if match(event) {
+			return event, nil
+		}
+	}
+}
+
+// Close disconnects from the WebSocket.
+func (s *EventStream) Close() error {
+	return s.conn.Close()
+}
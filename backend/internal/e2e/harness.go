@@ -0,0 +1,361 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	goahttp "goa.design/goa/v3/http"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api"
+	genhealth "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/health"
+	genhealthsvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/health/server"
+	genjobtemplatessvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/job_templates/server"
+	gensamplejobssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/sample_jobs/server"
+	genstudiessvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/studies/server"
+	gentrainingrunssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/training_runs/server"
+	genworkflowssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/workflows/server"
+	genwssvr "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/http/ws/server"
+	genjobtemplates "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/job_templates"
+	gensamplejobs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sample_jobs"
+	genstudies "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/studies"
+	gentrainingruns "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/training_runs"
+	genworkflows "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/workflows"
+	genws "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/ws"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+// DefaultWorkflow is the name of the workflow template every harness
+// provides, a minimal text-to-image workflow with all cs_role tags.
+const DefaultWorkflow = "e2e-workflow.json"
+
+// defaultWorkflowJSON matches test-fixtures/workflows/test-workflow.json.
+const defaultWorkflowJSON = `{
+  "1": {"class_type": "UNETLoader", "inputs": {"unet_name": "placeholder.safetensors", "weight_dtype": "default"}, "_meta": {"title": "Load Diffusion Model", "cs_role": "unet_loader"}},
+  "2": {"class_type": "CLIPLoader", "inputs": {"clip_name": "test-clip.safetensors", "type": "flux"}, "_meta": {"title": "Load CLIP", "cs_role": "clip_loader"}},
+  "3": {"class_type": "VAELoader", "inputs": {"vae_name": "test-vae.safetensors"}, "_meta": {"title": "Load VAE", "cs_role": "vae_loader"}},
+  "4": {"class_type": "CLIPTextEncode", "inputs": {"text": "a test prompt", "clip": ["2", 0]}, "_meta": {"title": "Positive Prompt", "cs_role": "positive_prompt"}},
+  "5": {"class_type": "CLIPTextEncode", "inputs": {"text": "", "clip": ["2", 0]}, "_meta": {"title": "Negative Prompt", "cs_role": "negative_prompt"}},
+  "6": {"class_type": "EmptyLatentImage", "inputs": {"width": 512, "height": 512, "batch_size": 1}, "_meta": {"title": "Empty Latent Image", "cs_role": "latent_image"}},
+  "7": {"class_type": "KSampler", "inputs": {"seed": 42, "steps": 1, "cfg": 1.0, "sampler_name": "euler", "scheduler": "normal", "denoise": 1.0, "model": ["1", 0], "positive": ["4", 0], "negative": ["5", 0], "latent_image": ["6", 0]}, "_meta": {"title": "KSampler", "cs_role": "sampler"}},
+  "8": {"class_type": "SaveImage", "inputs": {"filename_prefix": "sample_test", "images": ["7", 0]}, "_meta": {"title": "Save Image", "cs_role": "save_image"}}
+}`
+
+// Options configures a Harness. The zero value is a training run "my-model"
+// with checkpoints at steps 1000 and 2000.
+type Options struct {
+	// TrainingRun names the training run whose checkpoints are created.
+	TrainingRun string
+	// Steps are the step numbers of the run's checkpoints.
+	Steps []int
+	// Workflows are extra workflow templates, by filename.
+	Workflows map[string]string
+	// Logger receives the server's logs; they are discarded when nil.
+	Logger *logrus.Logger
+}
+
+// Harness runs the sample job pipeline of the server in-process: the SQLite
+// store, the study and sample job services, the job executor, and the HTTP
+// and WebSocket API, wired as cmd/server wires them, against a ComfyUIStub.
+// Its database, checkpoint, sample, and workflow directories live in a
+// directory owned by the caller, typically GinkgoT().TempDir().
+type Harness struct {
+	URL           string // base URL of the server
+	ComfyUI       *ComfyUIStub
+	TrainingRun   string
+	Checkpoints   []string // checkpoint filenames, in step order
+	CheckpointDir string
+	SampleDir     string
+	WorkflowDir   string
+
+	server   *httptest.Server
+	executor *service.JobExecutor
+	store    *store.Store
+	client   *http.Client
+}
+
+// Start creates the harness directories under dir and starts the stub, the
+// job executor, and the server. Close the harness when done.
+func Start(dir string, opts Options) (*Harness, error) {
+	if opts.TrainingRun == "" {
+		opts.TrainingRun = "my-model"
+	}
+	if len(opts.Steps) == 0 {
+		opts.Steps = []int{1000, 2000}
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	}
+
+	h := &Harness{
+		TrainingRun:   opts.TrainingRun,
+		CheckpointDir: filepath.Join(dir, "checkpoints"),
+		SampleDir:     filepath.Join(dir, "samples"),
+		WorkflowDir:   filepath.Join(dir, "workflows"),
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, d := range []string{h.CheckpointDir, h.SampleDir, h.WorkflowDir} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return nil, fmt.Errorf("creating %s: %w", d, err)
+		}
+	}
+	for _, step := range opts.Steps {
+		name := fmt.Sprintf("%s-step%08d.safetensors", opts.TrainingRun, step)
+		if err := os.WriteFile(filepath.Join(h.CheckpointDir, name), []byte("checkpoint"), 0o644); err != nil {
+			return nil, fmt.Errorf("writing checkpoint %s: %w", name, err)
+		}
+		h.Checkpoints = append(h.Checkpoints, name)
+	}
+	workflows := map[string]string{DefaultWorkflow: defaultWorkflowJSON}
+	for name, content := range opts.Workflows {
+		workflows[name] = content
+	}
+	for name, content := range workflows {
+		if err := os.WriteFile(filepath.Join(h.WorkflowDir, name), []byte(content), 0o644); err != nil {
+			return nil, fmt.Errorf("writing workflow %s: %w", name, err)
+		}
+	}
+
+	db, err := store.OpenDB(filepath.Join(dir, "checkpoint-sampler.db"))
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	st, err := store.New(db, logger)
+	if err != nil {
+		return nil, fmt.Errorf("initializing store: %w", err)
+	}
+	h.store = st
+	h.ComfyUI = NewComfyUIStub(h.Checkpoints...)
+
+	fsys := store.NewFileSystem(logger)
+	discovery := service.NewDiscoveryService(fsys, []string{h.CheckpointDir}, h.SampleDir, logger)
+	viewerDiscovery := service.NewViewerDiscoveryService(fsys, h.SampleDir, logger)
+	scanner := service.NewScanner(fsys, h.SampleDir, logger)
+	hub := service.NewHub(logger)
+
+	httpClient := store.NewComfyUIHTTPClient(h.ComfyUI.URL(), logger)
+	wsClient := store.NewComfyUIWSClient(h.ComfyUI.URL(), logger)
+	modelDiscovery := service.NewComfyUIModelDiscovery(httpClient, logger)
+	workflowLoader := service.NewWorkflowLoader(h.WorkflowDir, logger)
+	h.executor = service.NewJobExecutorWithThumbnails(st, httpClient, wsClient, workflowLoader, hub, h.SampleDir, &service.RealFileSystemWriter{}, fsys, nil, time.Second, logger)
+
+	pathMatcher := service.NewCheckpointPathMatcher(modelDiscovery, logger)
+	dirRemover := store.NewCheckpointSampleDirRemover(fsys, h.SampleDir)
+	sampleJobSvc := service.NewSampleJobService(st, pathMatcher, dirRemover, h.SampleDir, logger)
+	sampleJobSvc.SetFileChecker(&service.RealOutputFileChecker{})
+	sampleJobSvc.SetJobDataRemover(store.NewJobSampleDirRemover(fsys, h.SampleDir))
+	sampleJobSvc.SetItemDurationSource(st)
+	sampleJobSvc.SetWorkflowSource(workflowLoader)
+	sampleJobSvc.SetTrainingRunSource(discovery)
+	sampleJobSvc.SetExecutor(h.executor)
+	h.executor.SetDirRemover(dirRemover)
+	h.executor.SetCheckpointAppender(sampleJobSvc)
+
+	studyAvailSvc := service.NewStudyAvailabilityService(fsys, h.SampleDir, logger)
+	studySvc := service.NewStudyService(st, studyAvailSvc, logger)
+	sampleJobsSvc := api.NewSampleJobsService(sampleJobSvc, discovery)
+	sampleJobsSvc.SetStudyService(studySvc)
+	jobTemplateSvc := service.NewJobTemplateService(st, logger)
+	jobTemplateSvc.SetJobCreator(sampleJobSvc)
+	sampleJobsSvc.SetJobTemplateService(jobTemplateSvc)
+
+	mux := goahttp.NewMuxer()
+	dec := goahttp.RequestDecoder
+	enc := goahttp.ResponseEncoder
+	genhealthsvr.New(genhealth.NewEndpoints(api.NewHealthService()), mux, dec, enc, nil, nil).Mount(mux)
+	gentrainingrunssvr.New(gentrainingruns.NewEndpoints(api.NewTrainingRunsService(viewerDiscovery, discovery, scanner, nil, nil, st)), mux, dec, enc, nil, nil).Mount(mux)
+	genstudiessvr.New(genstudies.NewEndpoints(api.NewStudiesService(studySvc, studyAvailSvc, discovery)), mux, dec, enc, nil, nil).Mount(mux)
+	gensamplejobssvr.New(gensamplejobs.NewEndpoints(sampleJobsSvc), mux, dec, enc, nil, nil).Mount(mux)
+	genjobtemplatessvr.New(genjobtemplates.NewEndpoints(api.NewJobTemplatesService(jobTemplateSvc)), mux, dec, enc, nil, nil).Mount(mux)
+	genworkflowssvr.New(genworkflows.NewEndpoints(api.NewWorkflowService(workflowLoader)), mux, dec, enc, nil, nil).Mount(mux)
+	upgrader := &websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	genwssvr.New(genws.NewEndpoints(api.NewWSServiceWithPing(hub, 0, logger)), mux, dec, enc, nil, nil, upgrader, nil).Mount(mux)
+
+	if err := h.executor.Start(); err != nil {
+		h.ComfyUI.Close()
+		st.Close()
+		return nil, fmt.Errorf("starting job executor: %w", err)
+	}
+	h.server = httptest.NewServer(mux)
+	h.URL = h.server.URL
+	return h, nil
+}
+
+// Close stops the server, the job executor, and the stub, and closes the
+// database. The caller removes the directory passed to Start.
+func (h *Harness) Close() {
+	h.server.Close()
+	h.executor.Stop()
+	h.ComfyUI.Close()
+	h.store.Close()
+}
+
+// StatusError is returned for API responses outside the 2xx range.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Body)
+}
+
+// Do sends a JSON request to the server and decodes the response into out,
+// unless out is nil.
+func (h *Harness) Do(method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, h.URL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// StudyPayload returns the body of a minimal valid study using the default
+// workflow: one prompt, one step count, CFG, sampler pair, and seed. Tests
+// change its fields before passing it to CreateStudy.
+func StudyPayload(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                    name,
+		"prompt_prefix":           "",
+		"prompts":                 []map[string]interface{}{{"name": "forest", "text": "a forest"}},
+		"negative_prompt":         "",
+		"steps":                   []int{1},
+		"cfgs":                    []float64{1},
+		"sampler_scheduler_pairs": []map[string]interface{}{{"sampler": "euler", "scheduler": "normal"}},
+		"seeds":                   []int64{42},
+		"width":                   512,
+		"height":                  512,
+		"workflow_template":       DefaultWorkflow,
+	}
+}
+
+// CreateStudy creates a study and returns its ID.
+func (h *Harness) CreateStudy(payload map[string]interface{}) (string, error) {
+	var study struct {
+		ID string `json:"id"`
+	}
+	if err := h.Do(http.MethodPost, "/api/studies", payload, &study); err != nil {
+		return "", fmt.Errorf("creating study: %w", err)
+	}
+	return study.ID, nil
+}
+
+// Job is the part of a sample job response tests assert on.
+type Job struct {
+	ID             string         `json:"id"`
+	Status         string         `json:"status"`
+	TotalItems     int            `json:"total_items"`
+	CompletedItems int            `json:"completed_items"`
+	FailedItems    int            `json:"failed_items"`
+	PendingItems   int            `json:"pending_items"`
+	SkippedItems   map[string]int `json:"skipped_items"`
+}
+
+// CreateJob creates a sample job of the harness's training run for a study,
+// limited to the given checkpoints if any, and returns it. The executor picks
+// it up on its own.
+func (h *Harness) CreateJob(studyID string, checkpoints ...string) (Job, error) {
+	body := map[string]interface{}{"training_run_name": h.TrainingRun, "study_id": studyID}
+	if len(checkpoints) > 0 {
+		body["checkpoint_filenames"] = checkpoints
+	}
+	var job Job
+	if err := h.Do(http.MethodPost, "/api/sample-jobs", body, &job); err != nil {
+		return Job{}, fmt.Errorf("creating sample job: %w", err)
+	}
+	return job, nil
+}
+
+// GetJob returns a sample job.
+func (h *Harness) GetJob(id string) (Job, error) {
+	var detail struct {
+		Job Job `json:"job"`
+	}
+	if err := h.Do(http.MethodGet, "/api/sample-jobs/"+id, nil, &detail); err != nil {
+		return Job{}, fmt.Errorf("getting sample job: %w", err)
+	}
+	return detail.Job, nil
+}
+
+// WaitForJob polls a sample job until it reaches one of statuses, and returns
+// it then. It fails after timeout.
+func (h *Harness) WaitForJob(id string, timeout time.Duration, statuses ...string) (Job, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		job, err := h.GetJob(id)
+		if err != nil {
+			return Job{}, err
+		}
+		if slices.Contains(statuses, job.Status) {
+			return job, nil
+		}
+		if time.Now().After(deadline) {
+			return job, fmt.Errorf("sample job %s is %s after %s, want one of %v", id, job.Status, timeout, statuses)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// SampleFiles returns the paths of the files under the sample directory,
+// relative to it and sorted.
+func (h *Harness) SampleFiles() ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(h.SampleDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(h.SampleDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking sample directory: %w", err)
+	}
+	slices.Sort(files)
+	return files, nil
+}
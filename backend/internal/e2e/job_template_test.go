@@ -0,0 +1,60 @@
+package e2e_test
+
+import (
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/e2e"
+)
+
+var _ = Describe("Job templates", func() {
+	var h *e2e.Harness
+
+	BeforeEach(func() {
+		var err error
+		h, err = e2e.Start(GinkgoT().TempDir(), e2e.Options{Steps: []int{1000, 2000, 3000}})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(h.Close)
+	})
+
+	createTemplate := func(studyID, filter string) string {
+		var template struct {
+			ID string `json:"id"`
+		}
+		Expect(h.Do(http.MethodPost, "/api/job-templates", map[string]interface{}{
+			"name":              "Nightly",
+			"study_id":          studyID,
+			"checkpoint_filter": filter,
+		}, &template)).To(Succeed())
+		return template.ID
+	}
+
+	It("samples the matching checkpoints of a training run in one call", func() {
+		studyID, err := h.CreateStudy(e2e.StudyPayload("Forest"))
+		Expect(err).NotTo(HaveOccurred())
+		templateID := createTemplate(studyID, "*-step0000[23]000.safetensors")
+
+		var job e2e.Job
+		Expect(h.Do(http.MethodPost, "/api/job-templates/"+templateID+"/run?training_run="+h.TrainingRun, nil, &job)).To(Succeed())
+		Expect(job.TotalItems).To(Equal(2))
+
+		job, err = h.WaitForJob(job.ID, jobTimeout, "completed", "completed_with_errors", "failed")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Status).To(Equal("completed"))
+		Expect(h.ComfyUI.Prompts()).To(HaveLen(2))
+	})
+
+	It("returns not found for an unknown training run", func() {
+		studyID, err := h.CreateStudy(e2e.StudyPayload("Forest"))
+		Expect(err).NotTo(HaveOccurred())
+		templateID := createTemplate(studyID, "")
+
+		err = h.Do(http.MethodPost, "/api/job-templates/"+templateID+"/run?training_run=other", nil, nil)
+		var statusErr *e2e.StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusNotFound))
+	})
+})
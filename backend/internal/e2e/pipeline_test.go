@@ -0,0 +1,123 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/e2e"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
+)
+
+// jobTimeout bounds a job of a few items; the executor polls once a second.
+const jobTimeout = 20 * time.Second
+
+var _ = Describe("Sample job pipeline", func() {
+	var h *e2e.Harness
+
+	BeforeEach(func() {
+		var err error
+		h, err = e2e.Start(GinkgoT().TempDir(), e2e.Options{})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(h.Close)
+	})
+
+	It("generates every item of a job into the sample directory", func() {
+		events, err := h.Events()
+		Expect(err).NotTo(HaveOccurred())
+		defer events.Close()
+
+		study := e2e.StudyPayload("Forest")
+		study["seeds"] = []int64{1, 2}
+		studyID, err := h.CreateStudy(study)
+		Expect(err).NotTo(HaveOccurred())
+
+		job, err := h.CreateJob(studyID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.TotalItems).To(Equal(4))
+
+		job, err = h.WaitForJob(job.ID, jobTimeout, "completed", "completed_with_errors", "failed")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Status).To(Equal("completed"))
+		Expect(job.CompletedItems).To(Equal(4))
+
+		// One prompt per item, with the item's checkpoint and seed substituted.
+		prompts := h.ComfyUI.Prompts()
+		Expect(prompts).To(HaveLen(4))
+		var seeds []float64
+		for _, p := range prompts {
+			sampler := p.Workflow["7"].(map[string]interface{})["inputs"].(map[string]interface{})
+			seeds = append(seeds, sampler["seed"].(float64))
+		}
+		Expect(seeds).To(ConsistOf(1.0, 2.0, 1.0, 2.0))
+
+		files, err := h.SampleFiles()
+		Expect(err).NotTo(HaveOccurred())
+		var images, sidecars []string
+		for _, f := range files {
+			switch filepath.Ext(f) {
+			case ".png":
+				images = append(images, f)
+			case ".json":
+				if !strings.HasSuffix(f, "manifest.json") {
+					sidecars = append(sidecars, f)
+				}
+			}
+		}
+		Expect(images).To(HaveLen(4))
+		Expect(sidecars).To(HaveLen(4))
+		for _, checkpoint := range h.Checkpoints {
+			Expect(images).To(ContainElement(HavePrefix(h.TrainingRun + "/Forest/" + checkpoint + "/")))
+		}
+
+		data, err := os.ReadFile(filepath.Join(h.SampleDir, sidecars[0]))
+		Expect(err).NotTo(HaveOccurred())
+		var meta fileformat.SidecarMetadata
+		Expect(json.Unmarshal(data, &meta)).To(Succeed())
+		Expect(meta.JobID).To(Equal(job.ID))
+		Expect(meta.PromptText).To(Equal("a forest"))
+
+		event, err := events.WaitFor(jobTimeout, func(e e2e.Event) bool {
+			return e.Type == "job_progress" && e.JobID == job.ID && e.Status == "completed"
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.CompletedItems).To(Equal(4))
+	})
+
+	It("records the failure of an item ComfyUI fails to execute", func() {
+		h.ComfyUI.FailPrompt(0, "CUDA out of memory")
+		studyID, err := h.CreateStudy(e2e.StudyPayload("Forest"))
+		Expect(err).NotTo(HaveOccurred())
+
+		job, err := h.CreateJob(studyID, h.Checkpoints[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.TotalItems).To(Equal(1))
+
+		job, err = h.WaitForJob(job.ID, jobTimeout, "completed", "completed_with_errors", "failed")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Status).To(Equal("completed_with_errors"))
+		Expect(job.FailedItems).To(Equal(1))
+
+		files, err := h.SampleFiles()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).NotTo(ContainElement(HaveSuffix(".png")))
+	})
+
+	It("rejects a study the server considers invalid", func() {
+		study := e2e.StudyPayload("Forest")
+		study["seeds"] = []int64{}
+		_, err := h.CreateStudy(study)
+
+		var statusErr *e2e.StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(statusErr.Body).To(ContainSubstring("at least one seed"))
+	})
+})
@@ -25,6 +25,7 @@ type JobManifest struct {
 	JobID          string   `json:"job_id"`
 	TrainingRunName string  `json:"training_run_name"`
 	WorkflowName   string   `json:"workflow_name"`
+	WorkflowOverrides []ManifestWorkflowOverride `json:"workflow_overrides,omitempty"`
 	VAE            string   `json:"vae,omitempty"`
 	CLIP           string   `json:"clip,omitempty"`
 	Shift          *float64 `json:"shift,omitempty"`
@@ -41,8 +42,12 @@ type JobManifest struct {
 	CFGs          []float64               `json:"cfgs"`
 	SamplerSchedulerPairs []ManifestSamplerSchedulerPair `json:"sampler_scheduler_pairs"`
 	Seeds         []int64                 `json:"seeds"`
+	SeedMode      string                  `json:"seed_mode,omitempty"`
+	RandomSeedCount int                   `json:"random_seed_count,omitempty"`
 	Width         int                     `json:"width"`
 	Height        int                     `json:"height"`
+	Resolutions   []ManifestResolution    `json:"resolutions,omitempty"`
+	Wildcards     []ManifestWildcard      `json:"wildcards,omitempty"`
 
 	// Checkpoint list
 	Checkpoints []string `json:"checkpoints"`
@@ -51,10 +56,38 @@ type JobManifest struct {
 	ImagesPerCheckpoint int `json:"images_per_checkpoint"`
 }
 
-// ManifestNamedPrompt represents a prompt with a name and text in the manifest format.
+// ManifestNamedPrompt represents a prompt with a name and text in the manifest
+// format, with the study values it overrides, if any.
 type ManifestNamedPrompt struct {
-	Name string `json:"name"`
-	Text string `json:"text"`
+	Name   string    `json:"name"`
+	Text   string    `json:"text"`
+	Steps  []int     `json:"steps,omitempty"`
+	CFGs   []float64 `json:"cfgs,omitempty"`
+	Seeds  []int64   `json:"seeds,omitempty"`
+	Width  int       `json:"width,omitempty"`
+	Height int       `json:"height,omitempty"`
+}
+
+// ManifestResolution represents an image size of the study's resolution
+// sweep in the manifest format.
+type ManifestResolution struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// ManifestWildcard represents a prompt wildcard and its values in the
+// manifest format.
+type ManifestWildcard struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// ManifestWorkflowOverride represents a workflow override of the job in the
+// manifest format: checkpoints matching the pattern were sampled with the
+// workflow instead of workflow_name.
+type ManifestWorkflowOverride struct {
+	Pattern  string `json:"pattern"`
+	Workflow string `json:"workflow"`
 }
 
 // ManifestSamplerSchedulerPair represents a sampler/scheduler combination in the manifest format.
@@ -68,8 +101,13 @@ func NewJobManifest(job model.SampleJob, study model.Study, checkpoints []string
 	prompts := make([]ManifestNamedPrompt, len(study.Prompts))
 	for i, p := range study.Prompts {
 		prompts[i] = ManifestNamedPrompt{
-			Name: p.Name,
-			Text: p.Text,
+			Name:   p.Name,
+			Text:   p.Text,
+			Steps:  p.Steps,
+			CFGs:   p.CFGs,
+			Seeds:  p.Seeds,
+			Width:  p.Width,
+			Height: p.Height,
 		}
 	}
 
@@ -81,10 +119,26 @@ func NewJobManifest(job model.SampleJob, study model.Study, checkpoints []string
 		}
 	}
 
+	var wildcards []ManifestWildcard
+	for _, w := range study.Wildcards {
+		wildcards = append(wildcards, ManifestWildcard{Name: w.Name, Values: w.Values})
+	}
+
+	var resolutions []ManifestResolution
+	for _, r := range study.Resolutions {
+		resolutions = append(resolutions, ManifestResolution{Width: r.Width, Height: r.Height})
+	}
+
+	var workflowOverrides []ManifestWorkflowOverride
+	for _, o := range job.WorkflowOverrides {
+		workflowOverrides = append(workflowOverrides, ManifestWorkflowOverride{Pattern: o.Pattern, Workflow: o.Workflow})
+	}
+
 	return JobManifest{
 		JobID:           job.ID,
 		TrainingRunName: job.TrainingRunName,
 		WorkflowName:    job.WorkflowName,
+		WorkflowOverrides: workflowOverrides,
 		VAE:             job.VAE,
 		CLIP:            job.CLIP,
 		Shift:           job.Shift,
@@ -100,8 +154,12 @@ func NewJobManifest(job model.SampleJob, study model.Study, checkpoints []string
 		CFGs:                  study.CFGs,
 		SamplerSchedulerPairs: pairs,
 		Seeds:                 study.Seeds,
+		SeedMode:              string(study.SeedMode),
+		RandomSeedCount:       study.RandomSeedCount,
 		Width:                 study.Width,
 		Height:                study.Height,
+		Resolutions:           resolutions,
+		Wildcards:             wildcards,
 
 		Checkpoints:         checkpoints,
 		ImagesPerCheckpoint: study.ImagesPerCheckpoint(),
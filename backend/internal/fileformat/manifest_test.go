@@ -119,6 +119,20 @@ var _ = Describe("JobManifest", func() {
 			Expect(m.ImagesPerCheckpoint).To(Equal(16))
 		})
 
+		It("maps the study's wildcards", func() {
+			study.Wildcards = []model.Wildcard{{Name: "color", Values: []string{"red", "blue"}}}
+			m := fileformat.NewJobManifest(job, study, checkpoints)
+
+			Expect(m.Wildcards).To(Equal([]fileformat.ManifestWildcard{{Name: "color", Values: []string{"red", "blue"}}}))
+		})
+
+		It("maps the study's resolutions", func() {
+			study.Resolutions = []model.Resolution{{Width: 832, Height: 1216}}
+			m := fileformat.NewJobManifest(job, study, checkpoints)
+
+			Expect(m.Resolutions).To(Equal([]fileformat.ManifestResolution{{Width: 832, Height: 1216}}))
+		})
+
 		It("omits shift when nil", func() {
 			job.Shift = nil
 			m := fileformat.NewJobManifest(job, study, checkpoints)
@@ -0,0 +1,213 @@
+package fileformat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ParametersKeyword is the keyword of the PNG text chunk holding the
+// generation parameters of an image, as A1111 and ComfyUI metadata tools
+// read them.
+const ParametersKeyword = "parameters"
+
+// PNGText is a text chunk of a PNG image.
+type PNGText struct {
+	Keyword string
+	Text    string
+}
+
+// pngSignature is the 8-byte signature every PNG file starts with.
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// EmbedPNGText returns the PNG image data with a text chunk for each of
+// texts, replacing the text chunks (tEXt, zTXt or iTXt) of the same
+// keywords. The chunks are inserted before the image data, where readers
+// that stop at the first IDAT chunk still find them. ASCII texts are written
+// as tEXt chunks, others as uncompressed UTF-8 iTXt chunks.
+func EmbedPNGText(data []byte, texts []PNGText) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG image")
+	}
+	replaced := make(map[string]bool, len(texts))
+	for _, t := range texts {
+		if err := validatePNGKeyword(t.Keyword); err != nil {
+			return nil, err
+		}
+		replaced[t.Keyword] = true
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)+256))
+	out.Write(pngSignature)
+	inserted := false
+	for pos := len(pngSignature); pos < len(data); {
+		if len(data)-pos < 12 {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		if length > len(data)-pos-12 {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		chunkData := data[pos+8 : pos+8+length]
+		chunk := data[pos : pos+12+length]
+		pos += 12 + length
+
+		if chunkType == "IDAT" && !inserted {
+			for _, t := range texts {
+				writePNGChunk(out, textChunk(t))
+			}
+			inserted = true
+		}
+		if isPNGTextChunk(chunkType) && replaced[pngTextKeyword(chunkData)] {
+			continue
+		}
+		out.Write(chunk)
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	if !inserted {
+		return nil, errors.New("PNG image has no IDAT chunk")
+	}
+	return out.Bytes(), nil
+}
+
+// validatePNGKeyword checks that keyword is a valid PNG text keyword: 1 to
+// 79 printable Latin-1 characters without leading, trailing or consecutive
+// spaces. Only ASCII is accepted, which covers every keyword in use.
+func validatePNGKeyword(keyword string) error {
+	if len(keyword) == 0 || len(keyword) > 79 {
+		return fmt.Errorf("PNG text keyword %q must be 1 to 79 characters long", keyword)
+	}
+	if strings.HasPrefix(keyword, " ") || strings.HasSuffix(keyword, " ") || strings.Contains(keyword, "  ") {
+		return fmt.Errorf("PNG text keyword %q must not have leading, trailing or consecutive spaces", keyword)
+	}
+	for i := 0; i < len(keyword); i++ {
+		if keyword[i] < 0x20 || keyword[i] > 0x7E {
+			return fmt.Errorf("PNG text keyword %q must contain only printable ASCII characters", keyword)
+		}
+	}
+	return nil
+}
+
+func isPNGTextChunk(chunkType string) bool {
+	return chunkType == "tEXt" || chunkType == "zTXt" || chunkType == "iTXt"
+}
+
+// pngTextKeyword returns the keyword of a text chunk's data, which all text
+// chunk types start with.
+func pngTextKeyword(chunkData []byte) string {
+	if i := bytes.IndexByte(chunkData, 0); i >= 0 {
+		return string(chunkData[:i])
+	}
+	return ""
+}
+
+// pngChunk is a PNG chunk to write.
+type pngChunk struct {
+	chunkType string
+	data      []byte
+}
+
+// textChunk returns the chunk holding t: tEXt for ASCII text, iTXt
+// otherwise, since tEXt is Latin-1.
+func textChunk(t PNGText) pngChunk {
+	var data bytes.Buffer
+	data.WriteString(t.Keyword)
+	data.WriteByte(0)
+	if isASCII(t.Text) {
+		data.WriteString(t.Text)
+		return pngChunk{chunkType: "tEXt", data: data.Bytes()}
+	}
+	// Compression flag and method, then empty language tag and translated
+	// keyword.
+	data.Write([]byte{0, 0, 0, 0})
+	data.WriteString(t.Text)
+	return pngChunk{chunkType: "iTXt", data: data.Bytes()}
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > 0x7F {
+			return false
+		}
+	}
+	return true
+}
+
+// writePNGChunk writes c with its length and CRC.
+func writePNGChunk(w *bytes.Buffer, c pngChunk) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(c.data)))
+	w.Write(length[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(c.chunkType))
+	crc.Write(c.data)
+	w.WriteString(c.chunkType)
+	w.Write(c.data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	w.Write(sum[:])
+}
+
+// FormatParameters formats the generation parameters of an image in the
+// "parameters" convention of A1111: the prompt, the negative prompt on a line
+// starting with "Negative prompt: ", and a line of comma-separated
+// "Key: value" settings, e.g.
+//
+//	a dense forest
+//	Negative prompt: blurry
+//	Steps: 20, Sampler: euler, Schedule type: normal, CFG scale: 7, Seed: 420, Size: 1024x1024, Model: my-model-step00001000
+func FormatParameters(meta SidecarMetadata) string {
+	var b strings.Builder
+	b.WriteString(meta.PromptText)
+	if meta.NegativePrompt != "" {
+		b.WriteString("\nNegative prompt: ")
+		b.WriteString(meta.NegativePrompt)
+	}
+
+	settings := []string{
+		"Steps: " + strconv.Itoa(meta.Steps),
+		"Sampler: " + quoteParameter(meta.SamplerName),
+		"Schedule type: " + quoteParameter(meta.Scheduler),
+		"CFG scale: " + strconv.FormatFloat(meta.CFG, 'f', -1, 64),
+		"Seed: " + strconv.FormatInt(meta.Seed, 10),
+		fmt.Sprintf("Size: %dx%d", meta.Width, meta.Height),
+	}
+	if meta.Denoise != nil {
+		settings = append(settings, "Denoising strength: "+strconv.FormatFloat(*meta.Denoise, 'f', -1, 64))
+	}
+	if meta.Checkpoint != "" {
+		name := path.Base(strings.ReplaceAll(meta.Checkpoint, "\\", "/"))
+		settings = append(settings, "Model: "+quoteParameter(strings.TrimSuffix(name, path.Ext(name))))
+	}
+	if meta.VAE != "" {
+		settings = append(settings, "VAE: "+quoteParameter(meta.VAE))
+	}
+	if meta.Shift != nil {
+		settings = append(settings, "Shift: "+strconv.FormatFloat(*meta.Shift, 'f', -1, 64))
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.Join(settings, ", "))
+	return b.String()
+}
+
+// quoteParameter quotes a settings value the way A1111 does when it would
+// otherwise break the "Key: value, ..." line apart.
+func quoteParameter(value string) string {
+	if !strings.ContainsAny(value, ",:\"\n") {
+		return value
+	}
+	quoted, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	return string(quoted)
+}
@@ -0,0 +1,128 @@
+package fileformat_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
+)
+
+// pngChunkTypes returns the type of each chunk of a PNG image in order.
+func pngChunkTypes(data []byte) []string {
+	var types []string
+	for pos := 8; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		types = append(types, string(data[pos+4:pos+8]))
+		pos += 12 + length
+	}
+	return types
+}
+
+var _ = Describe("EmbedPNGText", func() {
+	var pngData []byte
+
+	BeforeEach(func() {
+		var buf bytes.Buffer
+		Expect(png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)))).To(Succeed())
+		pngData = buf.Bytes()
+	})
+
+	It("inserts a tEXt chunk before the image data", func() {
+		data, err := fileformat.EmbedPNGText(pngData, []fileformat.PNGText{{Keyword: "parameters", Text: "a dense forest"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pngChunkTypes(data)).To(Equal([]string{"IHDR", "tEXt", "IDAT", "IEND"}))
+		Expect(string(data)).To(ContainSubstring("parameters\x00a dense forest"))
+		img, err := png.Decode(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(img.Bounds().Dx()).To(Equal(4))
+	})
+
+	It("replaces text chunks of the same keyword", func() {
+		data, err := fileformat.EmbedPNGText(pngData, []fileformat.PNGText{{Keyword: "parameters", Text: "first"}, {Keyword: "prompt", Text: "{}"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err = fileformat.EmbedPNGText(data, []fileformat.PNGText{{Keyword: "parameters", Text: "second"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pngChunkTypes(data)).To(Equal([]string{"IHDR", "tEXt", "tEXt", "IDAT", "IEND"}))
+		Expect(string(data)).NotTo(ContainSubstring("first"))
+		Expect(string(data)).To(ContainSubstring("parameters\x00second"))
+		Expect(string(data)).To(ContainSubstring("prompt\x00{}"))
+		_, err = png.Decode(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("writes non-ASCII text as a UTF-8 iTXt chunk", func() {
+		data, err := fileformat.EmbedPNGText(pngData, []fileformat.PNGText{{Keyword: "parameters", Text: "café"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pngChunkTypes(data)).To(Equal([]string{"IHDR", "iTXt", "IDAT", "IEND"}))
+		Expect(string(data)).To(ContainSubstring("parameters\x00\x00\x00\x00\x00café"))
+		_, err = png.Decode(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects data that is not a PNG image", func() {
+		_, err := fileformat.EmbedPNGText([]byte("fake-image-data"), []fileformat.PNGText{{Keyword: "parameters", Text: "x"}})
+		Expect(err).To(MatchError("not a PNG image"))
+	})
+
+	It("rejects invalid keywords", func() {
+		_, err := fileformat.EmbedPNGText(pngData, []fileformat.PNGText{{Keyword: "", Text: "x"}})
+		Expect(err).To(MatchError(ContainSubstring("must be 1 to 79 characters long")))
+		_, err = fileformat.EmbedPNGText(pngData, []fileformat.PNGText{{Keyword: " parameters", Text: "x"}})
+		Expect(err).To(MatchError(ContainSubstring("must not have leading, trailing or consecutive spaces")))
+	})
+
+	It("rejects truncated images", func() {
+		_, err := fileformat.EmbedPNGText(pngData[:len(pngData)-20], []fileformat.PNGText{{Keyword: "parameters", Text: "x"}})
+		Expect(err).To(MatchError("truncated PNG chunk"))
+	})
+})
+
+var _ = Describe("FormatParameters", func() {
+	It("formats the parameters in the A1111 convention", func() {
+		shift := 3.0
+		parameters := fileformat.FormatParameters(fileformat.SidecarMetadata{
+			Checkpoint:     "loras/my-model-step00001000.safetensors",
+			PromptText:     "high quality, a dense forest",
+			NegativePrompt: "blurry",
+			Seed:           420,
+			CFG:            3.5,
+			Steps:          20,
+			SamplerName:    "euler",
+			Scheduler:      "simple",
+			Width:          1024,
+			Height:         768,
+			VAE:            "ae.safetensors",
+			Shift:          &shift,
+		})
+
+		Expect(parameters).To(Equal("high quality, a dense forest\n" +
+			"Negative prompt: blurry\n" +
+			"Steps: 20, Sampler: euler, Schedule type: simple, CFG scale: 3.5, Seed: 420, Size: 1024x768, " +
+			"Model: my-model-step00001000, VAE: ae.safetensors, Shift: 3"))
+	})
+
+	It("leaves out the negative prompt line when it is empty and quotes values with separators", func() {
+		parameters := fileformat.FormatParameters(fileformat.SidecarMetadata{
+			PromptText:  "a dense forest",
+			Steps:       20,
+			SamplerName: "dpmpp_2m, karras",
+			Scheduler:   "normal",
+			CFG:         7,
+			Seed:        1,
+			Width:       512,
+			Height:      512,
+		})
+
+		Expect(parameters).To(Equal("a dense forest\n" +
+			`Steps: 20, Sampler: "dpmpp_2m, karras", Schedule type: normal, CFG scale: 7, Seed: 1, Size: 512x512`))
+	})
+})
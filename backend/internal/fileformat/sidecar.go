@@ -14,18 +14,37 @@ type SidecarMetadata struct {
 	PromptName     string  `json:"prompt_name"`
 	PromptText     string  `json:"prompt_text"`
 	Seed           int64   `json:"seed"`
+	// SeedMode is the study's seed mode: explicit, random_per_item, or
+	// random_per_checkpoint. Random seeds are only recorded here and in the
+	// item, so it tells readers the seed cannot be found in the study.
+	SeedMode       string  `json:"seed_mode,omitempty"`
 	CFG            float64 `json:"cfg"`
 	Steps          int     `json:"steps"`
 	SamplerName    string  `json:"sampler_name"`
 	Scheduler      string  `json:"scheduler"`
 	Width          int     `json:"width"`
 	Height         int     `json:"height"`
+	// Denoise is the denoise strength of an img2img image.
+	Denoise        *float64 `json:"denoise,omitempty"`
+	// Resolution is the size of an image of a study's resolution sweep, as
+	// "WxH". Empty for images at the study's or the prompt's size.
+	Resolution     string  `json:"resolution,omitempty"`
 	NegativePrompt string  `json:"negative_prompt"`
 	VAE            string  `json:"vae"`
 	CLIP           string  `json:"clip"`
 	Shift          *float64 `json:"shift,omitempty"`
+	// Wildcards holds the values of the prompt wildcards the prompt text
+	// was rendered with, keyed by wildcard name.
+	Wildcards      map[string]string `json:"wildcards,omitempty"`
+	// Scores holds the automatic quality scores of the image by name, when a
+	// scoring service is configured.
+	Scores         map[string]float64 `json:"scores,omitempty"`
 	WorkflowName   string  `json:"workflow_name"`
 	JobID          string  `json:"job_id"`
 	Timestamp      string  `json:"timestamp"` // RFC3339 UTC
 	CommitSHA      string  `json:"commit_sha,omitempty"`
+	// Backfilled marks sidecars reconstructed from the image filename for
+	// images generated before sidecars were written. Fields that cannot be
+	// recovered from the filename are left empty.
+	Backfilled bool `json:"backfilled,omitempty"`
 }
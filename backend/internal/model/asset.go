@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// AssetKind identifies what a managed asset is used for.
+type AssetKind string
+
+const (
+	// AssetKindImage is a reference image, e.g. the input of an img2img workflow.
+	AssetKindImage AssetKind = "image"
+	// AssetKindWildcards is a text file with one wildcard value per line.
+	AssetKindWildcards AssetKind = "wildcards"
+)
+
+// Asset is a file uploaded to the assets directory. Assets are deduplicated
+// by content: uploading the same bytes twice yields the same asset.
+type Asset struct {
+	ID          string
+	Kind        AssetKind
+	Filename    string // original filename given by the uploader
+	ContentType string
+	Size        int64
+	SHA256      string // hex-encoded
+	Path        string // relative to the assets directory, e.g. "image/<sha256>.png"
+	CreatedAt   time.Time
+}
+
+// AssetUpload is an upload in progress. Its content is sent in chunks that
+// are appended in order; an interrupted upload is resumed from Received.
+type AssetUpload struct {
+	ID          string
+	Kind        AssetKind
+	Filename    string
+	ContentType string
+	Size        int64 // declared total size in bytes
+	Received    int64 // bytes received so far
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// BulkSampleJobResult is the outcome of creating one sample job per study for
+// a single training run.
+type BulkSampleJobResult struct {
+	// Jobs are the created jobs, in the order the studies were requested.
+	// Jobs run in this order because the executor picks up pending jobs FIFO.
+	Jobs []SampleJob
+	// TotalItems is the sum of work items across all created jobs.
+	TotalItems int
+	// EstimatedDuration is TotalItems multiplied by the average duration of
+	// recently completed items. Nil when no timing history is available.
+	EstimatedDuration *time.Duration
+}
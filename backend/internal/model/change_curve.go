@@ -0,0 +1,50 @@
+package model
+
+// SamplingDensity suggests how densely checkpoints should be sampled over a
+// stretch of training.
+type SamplingDensity string
+
+const (
+	// SamplingDensityDense marks a stretch where images change quickly, so
+	// checkpoints saved closer together would show more of the progression.
+	SamplingDensityDense SamplingDensity = "dense"
+	// SamplingDensityNormal marks a stretch that changes at a typical rate.
+	SamplingDensityNormal SamplingDensity = "normal"
+	// SamplingDensitySparse marks a stretch where images barely change, e.g.
+	// once training has converged; fewer checkpoints show the same.
+	SamplingDensitySparse SamplingDensity = "sparse"
+)
+
+// CheckpointTransition is the change in images between two consecutive
+// checkpoints of a training run.
+type CheckpointTransition struct {
+	FromCheckpoint string
+	ToCheckpoint   string
+	// FromStep and ToStep are the checkpoints' step numbers, or -1 for a
+	// checkpoint without one.
+	FromStep int
+	ToStep   int
+	// Difference is the mean perceptual difference between the two
+	// checkpoints' images of the same parameters, from 0 (identical) to 1.
+	Difference float64
+	// Rate is Difference per 1000 training steps, or Difference itself when
+	// the step gap is unknown.
+	Rate float64
+	// Pairs is the number of image pairs compared. A transition without
+	// pairs has no Difference and a normal Density.
+	Pairs   int
+	Density SamplingDensity
+}
+
+// ChangeCurve describes how quickly a training run's images change from
+// checkpoint to checkpoint, with a suggested sampling density for each
+// stretch of training.
+type ChangeCurve struct {
+	TrainingRunName string
+	// Transitions are ordered by step.
+	Transitions []CheckpointTransition
+	// SuggestedCheckpoints are the checkpoints worth sampling, ordered by
+	// step: every checkpoint except every other one inside a run of sparse
+	// transitions. They can be passed as a sample job's checkpoint filenames.
+	SuggestedCheckpoints []string
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// CheckpointHash is the stored fast hash of a checkpoint file. The hash is
+// taken over the start of the file and its size, so it is reused only while
+// the file's size and modification time are unchanged.
+type CheckpointHash struct {
+	// Path is the absolute path of the checkpoint file.
+	Path    string
+	Size    int64
+	ModTime time.Time
+	// Hash is the hex-encoded 64-bit xxHash of the start of the file and its
+	// size.
+	Hash       string
+	ComputedAt time.Time
+}
+
+// HashedCheckpoint is a discovered checkpoint with its hash.
+type HashedCheckpoint struct {
+	TrainingRun        string
+	Filename           string
+	RelativePath       string
+	CheckpointDirIndex int
+	Size               int64
+	// Hash is empty until the checkpoint has been hashed, or while the
+	// stored hash is stale.
+	Hash string
+}
+
+// CheckpointDuplicate is a set of discovered checkpoints with the same hash
+// that appear under more than one training run or filename, which usually
+// means the same file was copied or renamed.
+type CheckpointDuplicate struct {
+	Hash        string
+	Checkpoints []HashedCheckpoint
+}
+
+// CheckpointHashReport lists the discovered checkpoints with their hashes and
+// the duplicates among them.
+type CheckpointHashReport struct {
+	Checkpoints  []HashedCheckpoint // ordered by training run, then as discovered
+	Duplicates   []CheckpointDuplicate
+	PendingCount int // checkpoints without a current hash
+}
@@ -0,0 +1,33 @@
+package model
+
+// CheckpointScore aggregates the star ratings of the images of one checkpoint.
+// Only rated images count towards AverageRating; ImageCount includes unrated
+// ones so that coverage can be judged.
+type CheckpointScore struct {
+	// Rank is the 1-based position in the leaderboard it belongs to.
+	Rank               int
+	CheckpointFilename string
+	// StepNumber is the training step parsed from the filename, or -1 for a
+	// final checkpoint without one.
+	StepNumber    int
+	ImageCount    int
+	RatedCount    int
+	AverageRating float64
+}
+
+// PromptScores are the checkpoint scores from the images of one prompt.
+type PromptScores struct {
+	PromptName string
+	Scores     []CheckpointScore
+}
+
+// CheckpointScoreReport ranks the checkpoints of a training run by the average
+// star rating of their images, across all prompts and per prompt. Scores are
+// sorted best first; checkpoints without rated images come last.
+type CheckpointScoreReport struct {
+	TrainingRunName string
+	ImageCount      int
+	RatedCount      int
+	Leaderboard     []CheckpointScore
+	Prompts         []PromptScores
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // PromptRequest represents a prompt submission request to ComfyUI.
 type PromptRequest struct {
 	Prompt     map[string]interface{}
@@ -33,3 +35,68 @@ type ComfyUIEvent struct {
 
 // ComfyUIEventHandler is a callback for ComfyUI events.
 type ComfyUIEventHandler func(event ComfyUIEvent)
+
+// ComfyUIQueue is ComfyUI's prompt queue as reported by its /queue endpoint.
+type ComfyUIQueue struct {
+	Running []ComfyUIQueueEntry
+	Pending []ComfyUIQueueEntry
+}
+
+// ComfyUIQueueEntry is a prompt in ComfyUI's queue. JobID and ItemID are set
+// when the prompt was submitted for a sample job item.
+type ComfyUIQueueEntry struct {
+	Number   int
+	PromptID string
+	JobID    string
+	ItemID   string
+}
+
+// ComfyUIDevice is a compute device reported by ComfyUI's /system_stats.
+type ComfyUIDevice struct {
+	Name      string
+	Type      string // e.g. "cuda", "mps", "cpu"
+	VRAMTotal int64  // bytes
+	VRAMFree  int64  // bytes
+}
+
+// ComfyUISystemStats is the subset of ComfyUI's /system_stats used by the
+// sampler.
+type ComfyUISystemStats struct {
+	Devices []ComfyUIDevice
+}
+
+// ComfyUIStatsSnapshot is the most recent reading of ComfyUI's devices and
+// queue length.
+type ComfyUIStatsSnapshot struct {
+	Devices      []ComfyUIDevice
+	QueueRunning int
+	QueuePending int
+	// UpdatedAt is when the devices and queue were last read; zero before the
+	// first successful read.
+	UpdatedAt time.Time
+	// Error describes why the last read failed; empty when it succeeded. The
+	// devices and queue from the last successful read are kept.
+	Error string
+}
+
+// VRAMEstimate is the estimated GPU memory needed to sample the images of a
+// sample job.
+type VRAMEstimate struct {
+	// ModelFamily is the family detected from the job's workflow, e.g.
+	// "flux" or "sdxl"; empty when unknown.
+	ModelFamily string
+	RequiredMB  int
+	// AvailableMB is the memory of ComfyUI's GPU; 0 when unknown.
+	AvailableMB int
+	// Warning is set when the job likely does not fit on the GPU.
+	Warning string
+}
+
+// CheckpointMatch is how a checkpoint filename resolves to a ComfyUI model
+// path. MappedPath is the filename after the path mapping rules; ModelPath
+// is empty when no ComfyUI model matches it.
+type CheckpointMatch struct {
+	Filename   string
+	MappedPath string
+	ModelPath  string
+}
@@ -0,0 +1,30 @@
+package model
+
+// ComparisonImage describes one side of an A/B image comparison.
+type ComparisonImage struct {
+	// Path is the image path relative to the sample directory.
+	Path string
+	// CheckpointFilename is the checkpoint that produced the image.
+	CheckpointFilename string
+	// StepNumber is the checkpoint's training step, or nil for a final checkpoint.
+	StepNumber *int
+	// Width and Height are the decoded pixel dimensions.
+	Width  int
+	Height int
+	// Parameters holds the normalized generation parameters, merged from the
+	// query-encoded filename and the sidecar or PNG metadata.
+	Parameters map[string]string
+	// Thumbnail is a JPEG thumbnail with the same dimensions as the other side's.
+	Thumbnail []byte
+}
+
+// ImageComparison is a verified pair of images that differ only by checkpoint.
+type ImageComparison struct {
+	A ComparisonImage
+	B ComparisonImage
+	// SharedParameters are the parameters verified identical on both sides.
+	SharedParameters map[string]string
+	// ThumbnailWidth and ThumbnailHeight are the dimensions of both thumbnails.
+	ThumbnailWidth  int
+	ThumbnailHeight int
+}
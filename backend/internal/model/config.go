@@ -5,11 +5,191 @@ type Config struct {
 	CheckpointDirs  []string
 	SampleDir       string
 	Port            int
+	GRPCPort        int // port of the gRPC server; 0 disables it
 	IPAddress       string
 	DBPath          string
 	ComfyUI         *ComfyUIConfig
 	Thumbnails      *ThumbnailConfig
 	WsPingInterval  int // seconds between WebSocket ping frames; 0 disables pings
+	RequestTimeout  int // seconds an API request may run before its context is canceled; 0 disables the limit
+	MultiProcess    *MultiProcessConfig
+	AutoSample      *AutoSampleConfig
+	Heartbeat       *HeartbeatConfig
+	Notifications   *NotificationsConfig
+	Assets          *AssetsConfig
+	RateLimit       *RateLimitConfig
+	SlowQueryMs     int // database statements taking at least this long are logged as slow; default 100
+	FaultInjection  *FaultInjectionConfig
+	Timezone        string // IANA time zone schedules are evaluated in; default "UTC"
+	Locale          string // BCP 47 language tag clients format dates and numbers with when the browser sends none; default "en-US"
+	Scoring         *ScoringConfig
+	Retention       *RetentionConfig
+	// TrashRetentionDays is how long deleted sample jobs stay in the trash
+	// before they are purged automatically; 0 keeps them until purged by hand.
+	TrashRetentionDays int
+	// OutputLayout is the template of the paths sample images are saved at.
+	// The zero layout saves them under query-encoded filenames.
+	OutputLayout OutputLayout
+	// LogLevels maps component names to the level their entries are logged
+	// at, overriding LOG_LEVEL for those components.
+	LogLevels map[string]string
+	// Users are the users of a shared server. Without users, every request
+	// acts for no one and presets, studies and sample jobs are unowned.
+	Users []UserConfig
+}
+
+// UserConfig configures one user of a shared server. A request acts for the
+// user whose token it sends as a bearer token in its Authorization header.
+type UserConfig struct {
+	Name  string
+	Token string
+	Admin bool // may list and change the presets, studies and sample jobs of every user
+}
+
+// ProcessRole selects which responsibilities a backend process takes on in
+// multi-process mode.
+type ProcessRole string
+
+const (
+	// ProcessRoleAll serves the API and competes for the executor lease.
+	ProcessRoleAll ProcessRole = "all"
+	// ProcessRoleAPI serves the API only and never runs the job executor.
+	ProcessRoleAPI ProcessRole = "api"
+	// ProcessRoleExecutor competes for the executor lease; it also serves the
+	// API so that health and election status can be queried.
+	ProcessRoleExecutor ProcessRole = "executor"
+)
+
+// RunsExecutor reports whether a process with this role may run the job executor.
+func (r ProcessRole) RunsExecutor() bool {
+	return r == ProcessRoleAll || r == ProcessRoleExecutor
+}
+
+// MultiProcessConfig enables safe sharing of one database and sample
+// directory between several backend processes.
+// This section is optional; if absent, the process assumes it is alone.
+type MultiProcessConfig struct {
+	Role       ProcessRole
+	InstanceID string // identifies this process in the executor lease; default hostname-pid
+	LeaseTTL   int    // seconds before an unrenewed executor lease expires; default 30
+}
+
+// AutoSampleConfig enables watch mode: when a new checkpoint file appears in
+// one of the checkpoint directories, a sample job is created for it
+// automatically. This section is optional; if absent, no jobs are created
+// automatically.
+type AutoSampleConfig struct {
+	SettleSeconds int // seconds a new checkpoint must go unmodified before sampling; default 30
+	TrainingRuns  []AutoSampleRule
+}
+
+// AutoSampleRule configures automatic sampling for one training run.
+type AutoSampleRule struct {
+	TrainingRun string // discovered training run name
+	StudyID     string // study (sample preset) whose settings are used for the job
+	Workflow    string // optional; when set, the study's workflow template must match
+	Enabled     bool
+}
+
+// HeartbeatConfig enables an external liveness signal for the job executor.
+// While the executor is healthy, File is touched and URL is requested every
+// Interval seconds, so that a cron monitor alerts when they stop.
+// This section is optional; if absent, no heartbeat is sent.
+type HeartbeatConfig struct {
+	File         string // optional; file whose modification time is updated
+	URL          string // optional; URL requested with GET, e.g. a healthchecks.io check
+	Interval     int    // seconds between heartbeats; default 60
+	StallTimeout int    // seconds a single sample may run before the executor is considered stalled; default 1800, 0 disables
+}
+
+// NotificationsConfig enables webhooks that are called when a sample job
+// finishes or is stopped.
+// This section is optional; if absent, no notifications are sent.
+type NotificationsConfig struct {
+	// UIURL is the externally reachable URL of the UI. When set,
+	// notifications link to the job's images in the comparison grid.
+	UIURL    string
+	Webhooks []WebhookConfig
+}
+
+// WebhookFormat selects the payload posted to a webhook.
+type WebhookFormat string
+
+const (
+	// WebhookFormatJSON posts the job summary as a JSON object.
+	WebhookFormatJSON WebhookFormat = "json"
+	// WebhookFormatDiscord posts a Discord webhook message with an embed.
+	WebhookFormatDiscord WebhookFormat = "discord"
+	// WebhookFormatSlack posts a Slack incoming webhook message.
+	WebhookFormatSlack WebhookFormat = "slack"
+)
+
+// WebhookConfig configures one webhook.
+type WebhookConfig struct {
+	URL    string
+	Format WebhookFormat // default json
+	Events []JobEvent    // events that trigger the webhook; default all
+}
+
+// ScoringConfig enables automatic scoring of sample images: after each image
+// is saved, it is posted to an external scoring service (e.g. an aesthetic
+// predictor or a CLIP similarity server) and the returned scores are stored
+// on the item and in its sidecar.
+// This section is optional; if absent, images are not scored.
+type ScoringConfig struct {
+	URL     string // endpoint the image is posted to
+	Timeout int    // seconds a scoring request may take; default 30
+}
+
+// RetentionConfig enables a policy that deletes the samples of checkpoints
+// that are no longer of interest. Within each training run, the samples of a
+// checkpoint are kept if it is one of the KeepLatest newest checkpoints (by
+// step number) or one of the KeepTopRated best-rated ones; the samples of
+// other checkpoints are deleted once they are MinAgeDays old. Pinned samples
+// are never deleted.
+// This section is optional; if absent, samples are never deleted
+// automatically.
+type RetentionConfig struct {
+	KeepLatest   int  // newest checkpoints kept per training run; 0 disables the rule
+	KeepTopRated int  // best-rated checkpoints kept per training run; 0 disables the rule
+	MinAgeDays   int  // days since a checkpoint's newest sample before it may be deleted; default 7
+	Interval     int  // hours between policy runs; default 24
+	DryRun       bool // only log what would be deleted; default true
+}
+
+// AssetsConfig enables uploads of managed assets (reference images and
+// wildcards files) used by presets and workflows.
+// This section is optional; if absent, the asset endpoints are disabled.
+type AssetsConfig struct {
+	Dir                string // directory that holds uploaded assets; default ./data/assets
+	MaxImageSizeMB     int    // largest accepted image, in megabytes; default 50
+	MaxWildcardsSizeMB int    // largest accepted wildcards file, in megabytes; default 10
+	UploadTTL          int    // hours an unfinished upload is kept before it is discarded; default 24
+}
+
+// RateLimitConfig protects the server from request floods, such as the grid
+// view requesting hundreds of images at once.
+// This section is optional; if absent, requests are not limited.
+type RateLimitConfig struct {
+	RequestsPerSecond  int // sustained requests per second allowed per client IP; default 100, 0 disables
+	Burst              int // requests a client IP may make at once before the rate applies; default 500
+	MaxConcurrentHeavy int // heavy requests (images, grids, comparisons) served at once; default 8, 0 disables
+	QueueTimeout       int // seconds a heavy request waits for a free slot before failing with 503; default 30
+}
+
+// FaultInjectionConfig makes the job executor's dependencies fail at random so
+// that its recovery paths can be exercised in integration tests and demos.
+// This section is optional and only takes effect when the
+// ENABLE_FAULT_INJECTION environment variable is "true"; it must never be
+// enabled in production. Probabilities range from 0 (never) to 1 (always).
+type FaultInjectionConfig struct {
+	Seed              int64   // random seed for reproducible runs; 0 picks a random seed
+	ComfyUIDisconnect float64 // probability that a prompt submission simulates a ComfyUI disconnect
+	DisconnectSeconds int     // seconds a simulated disconnect lasts; default 15
+	SlowDownload      float64 // probability that an image download is delayed
+	SlowDownloadMs    int     // milliseconds a slow download is delayed by; default 5000
+	DatabaseBusy      float64 // probability that an executor database call fails with SQLITE_BUSY
+	FileWriteFailure  float64 // probability that writing a sample image fails
 }
 
 // ComfyUIConfig represents the ComfyUI integration configuration.
@@ -18,6 +198,89 @@ type ComfyUIConfig struct {
 	URL                string
 	WorkflowDir        string
 	ReconnectInterval  int // seconds between WebSocket reconnect attempts; default 10
+	// VRAMHardLimitMB refuses sample jobs whose estimated VRAM requirement
+	// exceeds it. Zero, the default, only warns when a job likely does not
+	// fit on the GPU.
+	VRAMHardLimitMB int
+	// FailureLog configures capturing ComfyUI's log when an item fails.
+	// Nil disables log capture.
+	FailureLog *ComfyUIFailureLogConfig
+	// Prewarm queues a minimal prompt that loads the next checkpoint of a
+	// job as soon as the last item of the current checkpoint is submitted,
+	// when ComfyUI's GPU has room for both models.
+	Prewarm bool
+	// DrainTimeout is how long, in seconds, shutdown waits for the item in
+	// flight to finish before marking it interrupted. Default 30; zero does
+	// not wait.
+	DrainTimeout int
+	// ItemTimeout is how long, in seconds, the executor waits for an event
+	// of the prompt in flight before cancelling it and failing its item with
+	// a timeout. Default 900; zero waits indefinitely.
+	ItemTimeout int
+	// MaxInFlight is how many items of a job may have their prompts queued
+	// in ComfyUI at once. Default 1.
+	MaxInFlight int
+	// ModelCacheTTL is how long, in seconds, ComfyUI's model lists are
+	// cached. Default 60; zero queries ComfyUI every time.
+	ModelCacheTTL int
+	// CheckpointStaging configures copying checkpoints ComfyUI cannot see
+	// into a directory it shares with the sampler. Nil disables staging.
+	CheckpointStaging *CheckpointStagingConfig
+	// PathMapping rewrites checkpoint filenames, in order, before they are
+	// matched against ComfyUI's model list. Empty matches by filename.
+	PathMapping []CheckpointPathRule
+}
+
+// CheckpointPathRule is one step of rewriting a checkpoint filename into the
+// path ComfyUI lists it under. Exactly one of the rewrites is set.
+type CheckpointPathRule struct {
+	// StripPrefix removes this prefix from the filename when present.
+	StripPrefix string
+	// AddPrefix prepends this prefix, e.g. a subdirectory of ComfyUI's
+	// diffusion model directory.
+	AddPrefix string
+	// Pattern is a regular expression whose matches are replaced by Replace,
+	// which may refer to capture groups as $1 or ${name}.
+	Pattern string
+	Replace string
+	// TrainingRunSubdir prepends the training run's name as a subdirectory,
+	// for ComfyUI model directories that keep each run's checkpoints apart.
+	TrainingRunSubdir bool
+}
+
+// CheckpointStagingConfig holds the settings for staging checkpoints that
+// are not in ComfyUI's model directories, e.g. because the training output
+// directory is not mounted in ComfyUI's container.
+type CheckpointStagingConfig struct {
+	// Dir is the staging directory as the sampler sees it. It must be inside
+	// one of ComfyUI's diffusion model directories.
+	Dir string
+	// ModelPath is the staging directory as ComfyUI lists it among its
+	// diffusion models, e.g. "checkpoint-sampler" for
+	// models/diffusion_models/checkpoint-sampler.
+	ModelPath string
+}
+
+// ComfyUILogSource is where ComfyUI's log is read from.
+type ComfyUILogSource string
+
+const (
+	// ComfyUILogSourceAPI reads the log from ComfyUI's /internal/logs endpoint.
+	ComfyUILogSourceAPI ComfyUILogSource = "api"
+	// ComfyUILogSourceFile reads the log from a file ComfyUI writes to.
+	ComfyUILogSourceFile ComfyUILogSource = "file"
+)
+
+// ComfyUIFailureLogConfig holds the settings for capturing ComfyUI's log
+// when an item fails, since execution_error events often lack the traceback
+// of a failing custom node.
+type ComfyUIFailureLogConfig struct {
+	Source ComfyUILogSource
+	// Path is the log file to read when Source is ComfyUILogSourceFile.
+	Path string
+	// Lines is the maximum number of log lines attached to a failed item;
+	// default 50.
+	Lines int
 }
 
 // ThumbnailConfig holds thumbnail generation settings.
@@ -36,3 +299,10 @@ const (
 	DimensionTypeInt    DimensionType = "int"
 	DimensionTypeString DimensionType = "string"
 )
+
+// ConfigReloadResult reports the outcome of reloading the configuration file.
+// Settings are named by their config file keys, e.g. "comfyui.url".
+type ConfigReloadResult struct {
+	Applied         []string // changed settings that were applied at runtime
+	RestartRequired []string // changed settings that only take effect after a restart
+}
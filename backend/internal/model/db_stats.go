@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// DBStats summarizes the store's database activity since startup.
+type DBStats struct {
+	// Queries is the number of statements executed.
+	Queries int64
+	// Errors is the number of statements that failed.
+	Errors int64
+	// TotalDuration is the time spent executing statements.
+	TotalDuration time.Duration
+	// SlowQueries is the number of statements that took at least
+	// SlowQueryThreshold.
+	SlowQueries int64
+	// SlowQueryThreshold is the duration above which a statement is logged as
+	// slow.
+	SlowQueryThreshold time.Duration
+	// OpenConnections, InUse, and Idle describe the connection pool.
+	OpenConnections int
+	InUse           int
+	Idle            int
+	// WaitCount and WaitDuration report how often and how long callers
+	// waited for a free connection.
+	WaitCount    int64
+	WaitDuration time.Duration
+	// Statements holds per-statement totals, slowest total first.
+	Statements []DBStatementStats
+}
+
+// DBStatementStats holds the totals of one SQL statement.
+type DBStatementStats struct {
+	// Query is the statement's SQL with whitespace collapsed.
+	Query         string
+	Count         int64
+	Errors        int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
@@ -7,6 +7,8 @@ const (
 	EventImageAdded         EventType = "image_added"
 	EventImageRemoved       EventType = "image_removed"
 	EventDirectoryAdded     EventType = "directory_added"
+	EventCheckpointAdded    EventType = "checkpoint_added"
+	EventCheckpointRemoved  EventType = "checkpoint_removed"
 	EventJobProgress        EventType = "job_progress"
 	EventInferenceProgress  EventType = "inference_progress"
 )
@@ -15,13 +17,58 @@ const (
 type FSEvent struct {
 	// Type is the kind of event that occurred.
 	Type EventType
-	// Path is the path relative to the sample directory.
+	// Path is the path relative to the sample directory. For checkpoint events
+	// it is the checkpoint's path relative to its checkpoint directory.
 	Path string
 	// JobProgressData contains optional job progress data (only for job_progress events).
 	JobProgressData *JobProgressEventData
 	// InferenceProgressData contains optional per-node inference progress data
 	// (only for inference_progress events).
 	InferenceProgressData *InferenceProgressEventData
+	// JobID is the sample job the event belongs to, if any. It routes the
+	// event to WebSocket clients subscribed to the job.
+	JobID string
+	// TrainingRun is the training run the event belongs to, if known. Image
+	// and directory events leave it empty; their path starts with the
+	// training run's sample directory.
+	TrainingRun string
+}
+
+// IsFilesystem reports whether t is a change in the sample or checkpoint
+// directories, as opposed to job or inference progress.
+func (t EventType) IsFilesystem() bool {
+	switch t {
+	case EventImageAdded, EventImageRemoved, EventDirectoryAdded, EventCheckpointAdded, EventCheckpointRemoved:
+		return true
+	}
+	return false
+}
+
+// HubStats reports the WebSocket hub's clients and what it dropped since
+// startup.
+type HubStats struct {
+	ClientCount int
+	// DroppedMessages counts events that were not delivered because a
+	// client fell behind or could not be written to.
+	DroppedMessages int64
+	// EvictedClients counts clients disconnected for falling behind or
+	// failing a write.
+	EvictedClients int64
+}
+
+// EventSubscription selects the events a WebSocket client receives: events
+// of the listed jobs, events of the listed training runs, and, when
+// FSEvents is set, every filesystem event. The zero value selects every
+// event.
+type EventSubscription struct {
+	JobIDs       []string
+	TrainingRuns []string
+	FSEvents     bool
+}
+
+// IsZero reports whether s selects every event.
+func (s EventSubscription) IsZero() bool {
+	return len(s.JobIDs) == 0 && len(s.TrainingRuns) == 0 && !s.FSEvents
 }
 
 // CurrentSampleParams holds the generation parameters for the sample currently being generated.
@@ -54,6 +101,9 @@ type JobProgressEventData struct {
 	CompletedItems             int
 	FailedItems                int
 	PendingItems               int
+	// SkippedItems counts the skipped items, which FailedItems includes, by
+	// skip reason. Nil if no item was skipped.
+	SkippedItems               map[SampleJobItemSkipReason]int
 	CheckpointsCompleted       int
 	TotalCheckpoints           int
 	CurrentCheckpoint          string
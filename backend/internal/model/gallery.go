@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// Gallery is a published, read-only snapshot of images from a training run.
+// Public visitors address a gallery by its unguessable Slug and images by
+// their index in Images; the sample-relative paths are never exposed.
+type Gallery struct {
+	ID              string
+	Slug            string
+	Title           string
+	TrainingRunName string
+	StudyName       string
+	// Filters are the dimension values the images were selected by. A
+	// dimension without an entry was not filtered.
+	Filters map[string][]string
+	// Dimensions are the dimensions shown to visitors, with the values present
+	// among the published images.
+	Dimensions []Dimension
+	Images     []GalleryImage
+	CreatedAt  time.Time
+}
+
+// GalleryImage is a single image in a published gallery.
+type GalleryImage struct {
+	// RelativePath is the image path relative to the sample directory.
+	RelativePath string
+	// Dimensions holds the image's values for the gallery's dimensions only.
+	Dimensions map[string]string
+}
+
+// GalleryPublishRequest describes which images of a training run to publish.
+type GalleryPublishRequest struct {
+	TrainingRunID int
+	StudyName     string
+	Title         string
+	// Filters restricts the published images to those whose value for each
+	// listed dimension is one of the given values.
+	Filters map[string][]string
+	// Dimensions lists the dimensions shown to visitors. Empty means all
+	// dimensions that vary across the published images.
+	Dimensions []string
+}
@@ -0,0 +1,35 @@
+package model
+
+// GridSpec describes how scanned images are arranged into a comparison grid.
+type GridSpec struct {
+	// XAxis is the dimension whose values form the grid columns (e.g. "cfg").
+	XAxis string
+	// YAxis is the dimension whose values form the grid rows (e.g. "checkpoint").
+	YAxis string
+	// XValues optionally restricts and orders the column values. When empty,
+	// all discovered values of XAxis are used in their natural sort order.
+	XValues []string
+	// YValues optionally restricts and orders the row values. When empty,
+	// all discovered values of YAxis are used in their natural sort order.
+	YValues []string
+	// Filters pins the remaining dimensions to fixed values (e.g. prompt_name=forest).
+	// Images not matching every filter are excluded from the grid.
+	Filters map[string]string
+	// CellSize is the maximum width and height in pixels of each image cell.
+	CellSize int
+}
+
+// GridLink identifies a comparison grid view to open from a deep link.
+type GridLink struct {
+	// TrainingRun is the training run name.
+	TrainingRun string
+	// StudyName optionally selects the study whose samples are shown.
+	StudyName string
+	// XAxis, YAxis, and SliderAxis optionally assign dimensions to the grid
+	// columns, rows, and slider.
+	XAxis      string
+	YAxis      string
+	SliderAxis string
+	// Filters restricts dimensions to the listed values.
+	Filters map[string][]string
+}
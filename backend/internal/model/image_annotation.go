@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// MaxImageRating is the highest star rating an image can be given. A rating
+// of 0 means the image is unrated.
+const MaxImageRating = 5
+
+// ImageAnnotation is a user's star rating and tags of a single sample image.
+// Path is relative to the sample directory and uses the same canonical form
+// as pin paths (see NormalizePinPath). Tags are unique and sorted.
+type ImageAnnotation struct {
+	Path      string
+	Rating    int
+	Tags      []string
+	UpdatedAt time.Time
+}
+
+// IsEmpty reports whether the annotation carries neither a rating nor tags.
+// Empty annotations are not stored.
+func (a ImageAnnotation) IsEmpty() bool {
+	return a.Rating == 0 && len(a.Tags) == 0
+}
+
+// ImageAnnotationFilter selects annotations when listing. Zero-valued fields
+// do not filter.
+type ImageAnnotationFilter struct {
+	// PathPrefix selects annotations of images under a directory, e.g. a
+	// training run or checkpoint sample directory.
+	PathPrefix string
+	// MinRating selects annotations rated at least this many stars.
+	MinRating int
+	// Tag selects annotations carrying this tag.
+	Tag string
+}
+
+// ImageAnnotationChange is applied to many annotations at once by a bulk
+// update. A nil Rating keeps each image's rating; a rating of 0 clears it.
+// RemoveTags is applied after AddTags.
+type ImageAnnotationChange struct {
+	Rating     *int
+	AddTags    []string
+	RemoveTags []string
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// IndexedImageDir is a directory under the sample directory as recorded in
+// the image index: the sample images it held when it was last read.
+type IndexedImageDir struct {
+	// Dir is relative to the sample directory and uses forward slashes.
+	Dir string
+	// ModTime is the modification time the directory had when it was read.
+	// A directory whose modification time has changed since must be read
+	// again.
+	ModTime time.Time
+	// IndexedAt is when the directory was read.
+	IndexedAt time.Time
+	// Filenames are the names of the sample images in the directory.
+	Filenames []string
+}
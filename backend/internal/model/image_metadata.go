@@ -1,5 +1,20 @@
 package model
 
+// ImageMetadataSource identifies where an image's generation metadata was read from.
+type ImageMetadataSource string
+
+const (
+	// ImageMetadataSourceSidecar means the metadata came from the .json sidecar file.
+	ImageMetadataSourceSidecar ImageMetadataSource = "sidecar"
+	// ImageMetadataSourceFilename means the metadata was parsed from the
+	// query-encoded image filename (images without a sidecar).
+	ImageMetadataSourceFilename ImageMetadataSource = "filename"
+	// ImageMetadataSourcePNG means only PNG tEXt chunks were available.
+	ImageMetadataSourcePNG ImageMetadataSource = "png"
+	// ImageMetadataSourceNone means no metadata was found.
+	ImageMetadataSourceNone ImageMetadataSource = "none"
+)
+
 // ImageMetadataValues holds image metadata with fields classified by type.
 // String fields contain text values; numeric fields contain quantitative values
 // such as seed, steps, and cfg that benefit from numeric representation.
@@ -8,4 +23,6 @@ type ImageMetadataValues struct {
 	StringFields map[string]string
 	// NumericFields holds quantitative metadata entries (e.g. seed, steps, cfg).
 	NumericFields map[string]float64
+	// Source is where the metadata was read from.
+	Source ImageMetadataSource
 }
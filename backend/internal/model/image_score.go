@@ -0,0 +1,16 @@
+package model
+
+// ImageScoreRequest is a saved sample image sent to a scoring service,
+// together with the generation parameters a scorer may compare it against
+// (e.g. the prompt text for CLIP similarity).
+type ImageScoreRequest struct {
+	Image              []byte
+	Filename           string // output filename; its extension tells the image format
+	JobID              string
+	ItemID             string
+	TrainingRunName    string
+	CheckpointFilename string
+	PromptName         string
+	PromptText         string
+	NegativePrompt     string
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// JobTemplate is a saved sample job configuration that can be run against
+// any training run, e.g. each night's new one. The workflow template is the
+// study's, as for any job.
+type JobTemplate struct {
+	ID      string
+	Name    string
+	StudyID string
+
+	// CheckpointFilter is a glob (path/filepath.Match syntax) matched
+	// against the checkpoint filenames of the training run a template is
+	// run against; empty matches every checkpoint.
+	CheckpointFilter string
+
+	ClearExisting        bool
+	MissingOnly          bool
+	SkipExisting         bool
+	AppendNewCheckpoints bool
+	Output               ImageOutputOptions
+	Overrides            ModelOverrides
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// ExecutorLeaseName is the name of the lease that elects the single active
+// job executor when several backend processes share a database.
+const ExecutorLeaseName = "executor"
+
+// ProcessLease is an advisory, time-limited lock held by one backend process.
+type ProcessLease struct {
+	// Name identifies the lease (e.g. ExecutorLeaseName).
+	Name string
+	// Holder is the instance ID of the process holding the lease.
+	Holder string
+	// AcquiredAt is when the current holder first took the lease.
+	AcquiredAt time.Time
+	// RenewedAt is when the current holder last renewed the lease.
+	RenewedAt time.Time
+	// ExpiresAt is when the lease lapses unless renewed.
+	ExpiresAt time.Time
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// JobEvent is a sample job lifecycle event that triggers notifications.
+type JobEvent string
+
+const (
+	// JobEventCompleted is sent when every item of a job completed.
+	JobEventCompleted JobEvent = "completed"
+	// JobEventFailed is sent when a job finished with failed or skipped
+	// items (status completed_with_errors) or failed outright.
+	JobEventFailed JobEvent = "failed"
+	// JobEventStopped is sent when a job is stopped by the user.
+	JobEventStopped JobEvent = "stopped"
+)
+
+// AllJobEvents lists every JobEvent.
+var AllJobEvents = []JobEvent{JobEventCompleted, JobEventFailed, JobEventStopped}
+
+// JobEventForStatus returns the event sent when a job reaches status, and
+// false if the status does not end the job.
+func JobEventForStatus(status SampleJobStatus) (JobEvent, bool) {
+	switch status {
+	case SampleJobStatusCompleted:
+		return JobEventCompleted, true
+	case SampleJobStatusCompletedWithErrors, SampleJobStatusFailed:
+		return JobEventFailed, true
+	case SampleJobStatusStopped:
+		return JobEventStopped, true
+	}
+	return "", false
+}
+
+// JobSummary describes a sample job in a notification.
+type JobSummary struct {
+	Event           JobEvent
+	JobID           string
+	TrainingRunName string
+	StudyName       string
+	Status          SampleJobStatus
+	TotalItems      int
+	CompletedItems  int
+	FailedItems     int // failed and skipped items
+	PendingItems    int
+	// Duration is the time from the start of the job's first item to the
+	// end of its last; zero if no item ran.
+	Duration time.Duration
+	// GridURL opens the job's images in the comparison grid; empty unless
+	// the UI URL is configured.
+	GridURL string
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"path"
+	"strings"
+)
+
+// OutputFormat is the file format sample images are saved in.
+type OutputFormat string
+
+const (
+	// OutputFormatPNG saves the image exactly as produced by ComfyUI.
+	OutputFormatPNG OutputFormat = "png"
+	// OutputFormatWebP transcodes the image to lossy WebP.
+	OutputFormatWebP OutputFormat = "webp"
+	// OutputFormatJPEG transcodes the image to JPEG.
+	OutputFormatJPEG OutputFormat = "jpeg"
+)
+
+const (
+	// DefaultOutputQuality is the encoder quality used for lossy formats when
+	// the job does not specify one.
+	DefaultOutputQuality = 90
+	// MinOutputQuality and MaxOutputQuality bound the encoder quality setting.
+	MinOutputQuality = 1
+	MaxOutputQuality = 100
+)
+
+// ImageOutputOptions selects the format and encoder quality sample images are
+// saved in. The zero value saves PNGs.
+type ImageOutputOptions struct {
+	Format  OutputFormat
+	Quality int
+}
+
+// OutputFormats lists the supported output formats.
+var OutputFormats = []OutputFormat{OutputFormatPNG, OutputFormatWebP, OutputFormatJPEG}
+
+// IsValid reports whether f is a supported output format.
+func (f OutputFormat) IsValid() bool {
+	for _, known := range OutputFormats {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Extension returns the file extension (including the dot) for the format.
+// An empty format is treated as PNG.
+func (f OutputFormat) Extension() string {
+	switch f {
+	case OutputFormatWebP:
+		return ".webp"
+	case OutputFormatJPEG:
+		return ".jpg"
+	default:
+		return ".png"
+	}
+}
+
+// IsLossy reports whether the format uses the quality setting.
+func (f OutputFormat) IsLossy() bool {
+	return f == OutputFormatWebP || f == OutputFormatJPEG
+}
+
+// IsSampleImageFile reports whether name has the extension of a sample image
+// in any supported output format (.png, .webp, .jpg, .jpeg; case-insensitive).
+func IsSampleImageFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".webp", ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package model_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+var _ = Describe("OutputFormat", func() {
+	DescribeTable("Extension",
+		func(format model.OutputFormat, expected string) {
+			Expect(format.Extension()).To(Equal(expected))
+		},
+		Entry("png", model.OutputFormatPNG, ".png"),
+		Entry("webp", model.OutputFormatWebP, ".webp"),
+		Entry("jpeg", model.OutputFormatJPEG, ".jpg"),
+		Entry("empty defaults to png", model.OutputFormat(""), ".png"),
+	)
+
+	DescribeTable("IsValid",
+		func(format model.OutputFormat, expected bool) {
+			Expect(format.IsValid()).To(Equal(expected))
+		},
+		Entry("png", model.OutputFormatPNG, true),
+		Entry("webp", model.OutputFormatWebP, true),
+		Entry("jpeg", model.OutputFormatJPEG, true),
+		Entry("empty", model.OutputFormat(""), false),
+		Entry("unknown", model.OutputFormat("gif"), false),
+	)
+
+	DescribeTable("IsSampleImageFile",
+		func(name string, expected bool) {
+			Expect(model.IsSampleImageFile(name)).To(Equal(expected))
+		},
+		Entry("png", "seed=1.png", true),
+		Entry("upper-case png", "seed=1.PNG", true),
+		Entry("webp", "seed=1.webp", true),
+		Entry("jpg", "seed=1.jpg", true),
+		Entry("jpeg", "seed=1.jpeg", true),
+		Entry("sidecar", "seed=1.json", false),
+		Entry("no extension", "seed", false),
+	)
+})
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OutputLayout is a template for the paths sample images are saved at, e.g.
+// "{checkpoint}/{prompt}/{sampler}_{scheduler}/cfg{cfg}_steps{steps}_seed{seed}".
+// Placeholders name the dimensions of an image: prompt, steps, cfg, sampler,
+// scheduler, seed, denoise, resolution, and the wildcards of its study. The
+// first path segment must be {checkpoint}, the checkpoint's sample directory,
+// which training run discovery, validation and deletion work on. The file
+// extension is appended from the job's output format.
+//
+// The zero OutputLayout saves images under query-encoded filenames.
+type OutputLayout struct {
+	template string
+	// segments are the path segments below the checkpoint directory.
+	segments []layoutSegment
+}
+
+// layoutSegment is a path segment of a layout: literal text and placeholders
+// in order.
+type layoutSegment []layoutPart
+
+// layoutPart is literal text, or a placeholder if placeholder is set.
+type layoutPart struct {
+	literal     string
+	placeholder string
+}
+
+// CheckpointPlaceholder is the placeholder of the checkpoint directory, the
+// first segment of every output layout.
+const CheckpointPlaceholder = "{checkpoint}"
+
+var layoutPlaceholderName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ParseOutputLayout parses an output layout template. An empty template
+// returns the zero OutputLayout.
+func ParseOutputLayout(template string) (OutputLayout, error) {
+	if template == "" {
+		return OutputLayout{}, nil
+	}
+	parts := strings.Split(template, "/")
+	if parts[0] != CheckpointPlaceholder || len(parts) < 2 {
+		return OutputLayout{}, fmt.Errorf("output layout %q must start with %s/", template, CheckpointPlaceholder)
+	}
+	layout := OutputLayout{template: template}
+	for _, part := range parts[1:] {
+		if part == "" || part == "." || part == ".." {
+			return OutputLayout{}, fmt.Errorf("output layout %q has an empty, \".\" or \"..\" path segment", template)
+		}
+		segment, err := parseLayoutSegment(part)
+		if err != nil {
+			return OutputLayout{}, fmt.Errorf("output layout %q: %w", template, err)
+		}
+		layout.segments = append(layout.segments, segment)
+	}
+	if IsSampleImageFile(parts[len(parts)-1]) {
+		return OutputLayout{}, fmt.Errorf("output layout %q must not end with a file extension; it is taken from the job's output format", template)
+	}
+	return layout, nil
+}
+
+func parseLayoutSegment(s string) (layoutSegment, error) {
+	if strings.Contains(s, `\`) {
+		return nil, fmt.Errorf("path segment %q must not contain a backslash", s)
+	}
+	var segment layoutSegment
+	for s != "" {
+		open := strings.IndexAny(s, "{}")
+		if open < 0 {
+			segment = append(segment, layoutPart{literal: s})
+			break
+		}
+		if s[open] == '}' {
+			return nil, fmt.Errorf("path segment %q has an unmatched }", s)
+		}
+		if open > 0 {
+			segment = append(segment, layoutPart{literal: s[:open]})
+		}
+		end := strings.IndexByte(s[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("path segment %q has an unmatched {", s)
+		}
+		name := s[open+1 : open+end]
+		if name == "checkpoint" {
+			return nil, fmt.Errorf("%s may only be the first path segment", CheckpointPlaceholder)
+		}
+		if !layoutPlaceholderName.MatchString(name) {
+			return nil, fmt.Errorf("placeholder {%s} must be a dimension name of letters, digits and underscores", name)
+		}
+		segment = append(segment, layoutPart{placeholder: name})
+		s = s[open+end+1:]
+	}
+	return segment, nil
+}
+
+// IsZero reports whether l is the zero OutputLayout, which saves images under
+// query-encoded filenames.
+func (l OutputLayout) IsZero() bool {
+	return l.template == ""
+}
+
+// String returns the template l was parsed from.
+func (l OutputLayout) String() string {
+	return l.template
+}
+
+// Path returns the slash-separated path, without file extension, of an image
+// with the given dimensions inside its checkpoint's sample directory.
+// Placeholders of dimensions the image does not have expand to nothing, and
+// characters that are not allowed in file names are replaced with "_".
+func (l OutputLayout) Path(dims map[string]string) string {
+	segments := make([]string, len(l.segments))
+	for i, segment := range l.segments {
+		var b strings.Builder
+		for _, part := range segment {
+			if part.placeholder == "" {
+				b.WriteString(part.literal)
+				continue
+			}
+			b.WriteString(sanitizeLayoutValue(dims[part.placeholder]))
+		}
+		s := b.String()
+		if s == "" || s == "." || s == ".." {
+			s = "_"
+		}
+		segments[i] = s
+	}
+	return strings.Join(segments, "/")
+}
+
+// sanitizeLayoutValue replaces path separators, control characters and the
+// characters Windows does not allow in file names with "_".
+func sanitizeLayoutValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, value)
+}
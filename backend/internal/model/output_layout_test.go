@@ -0,0 +1,51 @@
+package model_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+var _ = Describe("OutputLayout", func() {
+	It("returns the zero layout for an empty template", func() {
+		layout, err := model.ParseOutputLayout("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layout.IsZero()).To(BeTrue())
+	})
+
+	It("expands the placeholders below the checkpoint directory", func() {
+		layout, err := model.ParseOutputLayout("{checkpoint}/{prompt}/{sampler}_{scheduler}/cfg{cfg}_steps{steps}_seed{seed}")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layout.IsZero()).To(BeFalse())
+		Expect(layout.String()).To(Equal("{checkpoint}/{prompt}/{sampler}_{scheduler}/cfg{cfg}_steps{steps}_seed{seed}"))
+
+		Expect(layout.Path(map[string]string{
+			"prompt": "forest", "sampler": "euler", "scheduler": "normal", "cfg": "7.0", "steps": "20", "seed": "42",
+		})).To(Equal("forest/euler_normal/cfg7.0_steps20_seed42"))
+	})
+
+	It("replaces characters that are not allowed in file names and fills empty segments", func() {
+		layout, err := model.ParseOutputLayout("{checkpoint}/{prompt}/{denoise}/{seed}")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layout.Path(map[string]string{"prompt": `a/b\c:d*?"<>|`, "seed": ".."})).To(Equal("a_b_c_d______/_/_"))
+	})
+
+	DescribeTable("rejects invalid templates",
+		func(template, message string) {
+			_, err := model.ParseOutputLayout(template)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("without the checkpoint directory", "{prompt}/{seed}", "must start with {checkpoint}/"),
+		Entry("with only the checkpoint directory", "{checkpoint}", "must start with {checkpoint}/"),
+		Entry("with an empty segment", "{checkpoint}//{seed}", `empty, "." or ".." path segment`),
+		Entry("with a parent segment", "{checkpoint}/../{seed}", `empty, "." or ".." path segment`),
+		Entry("with an unmatched {", "{checkpoint}/{seed", "unmatched {"),
+		Entry("with an unmatched }", "{checkpoint}/seed}", "unmatched }"),
+		Entry("with an invalid placeholder name", "{checkpoint}/{the seed}", "must be a dimension name"),
+		Entry("with the checkpoint below the first segment", "{checkpoint}/{checkpoint}_{seed}", "may only be the first path segment"),
+		Entry("with a backslash", `{checkpoint}/a\{seed}`, "must not contain a backslash"),
+		Entry("with a file extension", "{checkpoint}/{seed}.png", "must not end with a file extension"),
+	)
+})
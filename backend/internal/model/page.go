@@ -0,0 +1,8 @@
+package model
+
+// Page selects a window of a list ordered by the list's natural order.
+// A zero Limit means no limit.
+type Page struct {
+	Limit  int
+	Offset int
+}
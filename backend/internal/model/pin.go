@@ -0,0 +1,78 @@
+package model
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// PinKind identifies what a pin protects.
+type PinKind string
+
+const (
+	// PinKindImage protects a single sample image (and its sidecar/thumbnail).
+	PinKindImage PinKind = "image"
+	// PinKindCheckpoint protects a whole checkpoint sample directory.
+	PinKindCheckpoint PinKind = "checkpoint"
+)
+
+// Pin marks a path under the sample directory as protected from cleanup.
+// Path is relative to the sample directory and always uses forward slashes.
+type Pin struct {
+	Path      string
+	Kind      PinKind
+	CreatedAt time.Time
+}
+
+// PinSet is an in-memory lookup of pinned paths used by listings and cleanup
+// code to answer protection queries without a database round trip per path.
+type PinSet map[string]PinKind
+
+// NewPinSet builds a PinSet from a list of pins.
+func NewPinSet(pins []Pin) PinSet {
+	set := make(PinSet, len(pins))
+	for _, p := range pins {
+		set[NormalizePinPath(p.Path)] = p.Kind
+	}
+	return set
+}
+
+// IsPinned reports whether relPath itself is pinned or lies inside a pinned
+// directory (e.g. an image inside a pinned checkpoint sample set).
+func (s PinSet) IsPinned(relPath string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	p := NormalizePinPath(relPath)
+	for p != "." && p != "" {
+		if _, ok := s[p]; ok {
+			return true
+		}
+		p = path.Dir(p)
+	}
+	return false
+}
+
+// Protects reports whether removing relPath would destroy pinned content:
+// either relPath is pinned (directly or via an ancestor), or a pinned path
+// lies beneath it.
+func (s PinSet) Protects(relPath string) bool {
+	if s.IsPinned(relPath) {
+		return true
+	}
+	prefix := NormalizePinPath(relPath) + "/"
+	for p := range s {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizePinPath converts a relative path to the canonical form used as a
+// pin key: forward slashes, cleaned, without leading or trailing slashes.
+func NormalizePinPath(relPath string) string {
+	p := strings.ReplaceAll(relPath, "\\", "/")
+	p = path.Clean("/" + p)
+	return strings.TrimPrefix(p, "/")
+}
@@ -0,0 +1,47 @@
+package model_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+var _ = Describe("PinSet", func() {
+	var set model.PinSet
+
+	BeforeEach(func() {
+		set = model.NewPinSet([]model.Pin{
+			{Path: "run/study/ckpt-a.safetensors", Kind: model.PinKindCheckpoint},
+			{Path: "run/study/ckpt-b.safetensors/seed=1.png", Kind: model.PinKindImage},
+		})
+	})
+
+	DescribeTable("IsPinned",
+		func(relPath string, expected bool) {
+			Expect(set.IsPinned(relPath)).To(Equal(expected))
+		},
+		Entry("pinned checkpoint directory", "run/study/ckpt-a.safetensors", true),
+		Entry("image inside a pinned checkpoint", "run/study/ckpt-a.safetensors/seed=2.png", true),
+		Entry("pinned image", "run/study/ckpt-b.safetensors/seed=1.png", true),
+		Entry("unpinned sibling image", "run/study/ckpt-b.safetensors/seed=2.png", false),
+		Entry("parent of a pin is not itself pinned", "run/study", false),
+		Entry("non-normalized path", "run//study/./ckpt-a.safetensors/", true),
+	)
+
+	DescribeTable("Protects",
+		func(relPath string, expected bool) {
+			Expect(set.Protects(relPath)).To(Equal(expected))
+		},
+		Entry("directory containing a pinned image", "run/study/ckpt-b.safetensors", true),
+		Entry("study directory containing pins", "run/study", true),
+		Entry("unrelated checkpoint directory", "run/study/ckpt-c.safetensors", false),
+		Entry("prefix-sharing sibling is not protected", "run/study/ckpt-a", false),
+	)
+
+	It("reports nothing pinned for an empty set", func() {
+		empty := model.NewPinSet(nil)
+		Expect(empty.IsPinned("anything.png")).To(BeFalse())
+		Expect(empty.Protects("dir")).To(BeFalse())
+	})
+})
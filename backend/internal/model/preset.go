@@ -2,11 +2,23 @@ package model
 
 import "time"
 
-// Preset represents a saved dimension-to-role mapping configuration.
+// Preset represents a saved dimension-to-role mapping configuration,
+// optionally with the grid view state of a training run.
 type Preset struct {
-	ID        string
-	Name      string
-	Mapping   PresetMapping
+	ID      string
+	Name    string
+	Mapping PresetMapping
+	// TrainingRun is the training run the preset is a view of, or empty for a
+	// preset that applies to every training run.
+	TrainingRun string
+	// View is the filter and sort state restored with the preset.
+	View PresetView
+	// Default marks the preset as the view opened for its training run. At
+	// most one preset of a training run is the default.
+	Default bool
+	// Owner is the name of the user that created the preset, or empty for
+	// an unowned preset.
+	Owner     string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -20,3 +32,18 @@ type PresetMapping struct {
 	YSlider string
 	Combos  []string
 }
+
+// PresetView is the grid view state saved with a preset besides its mapping.
+type PresetView struct {
+	// Filters restricts dimensions to the listed values (e.g. seed=[420]).
+	Filters map[string][]string
+	// Sort orders the values of dimensions along their axis or slider.
+	// Dimensions without an entry keep their natural order.
+	Sort []PresetSort
+}
+
+// PresetSort is the sort order of one dimension's values.
+type PresetSort struct {
+	Dimension  string
+	Descending bool
+}
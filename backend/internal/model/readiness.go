@@ -0,0 +1,30 @@
+package model
+
+// Startup steps the server waits for before it reports ready.
+const (
+	// ReadinessStepMigrations completes once the database is open and its
+	// migrations are applied.
+	ReadinessStepMigrations = "migrations"
+	// ReadinessStepOpenAPISpec completes once the OpenAPI spec served at
+	// /docs is loaded.
+	ReadinessStepOpenAPISpec = "openapi_spec"
+	// ReadinessStepWatcher completes once the filesystem watcher is watching
+	// the checkpoint directories.
+	ReadinessStepWatcher = "watcher"
+	// ReadinessStepAPI completes once the API handlers are mounted and
+	// requests are no longer answered with 503 Service Unavailable.
+	ReadinessStepAPI = "api"
+)
+
+// ReadinessCheck is the state of one startup step.
+type ReadinessCheck struct {
+	Name  string
+	Ready bool
+}
+
+// ReadinessStatus reports whether every startup step has completed.
+type ReadinessStatus struct {
+	Ready bool
+	// Checks lists the startup steps in the order they run.
+	Checks []ReadinessCheck
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// SampleDeleteFilter selects the sample images of a training run to delete.
+// Every set field must match; unset fields match all images.
+type SampleDeleteFilter struct {
+	TrainingRunName string
+	// Checkpoints restricts the deletion to these checkpoint filenames.
+	Checkpoints []string
+	PromptName  string
+	Seed        *int64
+	// Before restricts the deletion to images last modified before this time.
+	Before *time.Time
+}
+
+// SampleDeleteResult is the outcome of a bulk sample deletion. In a dry run,
+// Deleted lists what would have been deleted and nothing is removed.
+type SampleDeleteResult struct {
+	DryRun bool
+	// Deleted holds the deleted image paths relative to the sample directory,
+	// in path order. Their sidecars and thumbnails are removed with them.
+	Deleted []string
+	// SkippedPinned counts matching images left in place because they are
+	// pinned.
+	SkippedPinned int
+	// Errors holds the images that could not be removed, with the reason.
+	Errors []string
+}
@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"path/filepath"
+	"time"
+)
 
 // SampleJob represents a job that generates sample images for a training run.
 type SampleJob struct {
@@ -8,18 +11,175 @@ type SampleJob struct {
 	TrainingRunName     string
 	StudyID             string
 	StudyName           string // denormalized for display and directory naming
+	StudyVersion        int    // version of the study the job was created from
 	WorkflowName        string
+	// WorkflowOverrides select another workflow than WorkflowName for the
+	// checkpoints they match. Empty when every item uses WorkflowName.
+	WorkflowOverrides   []WorkflowOverride
 	VAE                 string
 	CLIP                string
 	Shift               *float64 // nullable for workflows without shift role
 	CheckpointFilenames []string // list of checkpoint filenames selected at job creation
 	ClearExisting       bool    // when true, clear sample dirs on first transition to running
+	// AppendNewCheckpoints selects append mode: checkpoints of the training
+	// run that appear after the job is created are added to it, and the
+	// executor queues their items. When false the job samples the snapshot
+	// of checkpoints in CheckpointFilenames.
+	AppendNewCheckpoints bool
+	OutputFormat        OutputFormat // format images are saved in (png, webp, jpeg)
+	OutputQuality       int          // encoder quality (1-100) for lossy formats; unused for png
+	// InputImage is the path of the image an img2img job starts from, copied
+	// from the study at creation. Empty for text-to-image jobs.
+	InputImage          string
+	// ControlNetModel, ControlNetStrength and ControlNetImage guide every
+	// item of the job with the same ControlNet. Empty fields keep the values
+	// of the workflow's controlnet_loader and controlnet_apply nodes.
+	ControlNetModel     string
+	ControlNetStrength  *float64
+	ControlNetImage     string // server path of the conditioning image
 	Status              SampleJobStatus
 	TotalItems          int
 	CompletedItems      int
 	ErrorMessage        string
+	// CreatedByRequestID is the ID of the API request that created the job;
+	// empty for jobs created in the background, e.g. by the auto-sampler.
+	CreatedByRequestID  string
+	// Owner is the name of the user that created the job; empty for jobs
+	// created without a user.
+	Owner               string
+	// Notes is free text recorded with the job, e.g. what the sweep tests.
+	Notes string
+	// Labels are key/value annotations of the job, e.g. lr=1e-5, that the
+	// job list can be filtered by. Nil or empty when the job has none.
+	Labels map[string]string
+	// Version counts the saved changes of the job, starting at 1. An update
+	// is saved only if it carries the stored version, and increments it.
+	Version             int
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
+	// DeletedAt is when the job was moved to the trash; nil for jobs that
+	// are not in the trash. Trashed jobs are hidden from the job list and the
+	// executor until they are restored or purged.
+	DeletedAt *time.Time
+	// DeleteData selects whether purging the trashed job also removes its
+	// generated sample files.
+	DeleteData bool
+	// Warnings are reported when the job is created, e.g. when it likely
+	// does not fit in GPU memory. They are not stored.
+	Warnings []string
+}
+
+// SampleJobAnnotations are the notes and labels a user records with a sample
+// job to tell sweeps apart. They do not change how the job samples.
+type SampleJobAnnotations struct {
+	Notes  string
+	Labels map[string]string
+}
+
+// SampleJobFilter selects the sample jobs of a job listing.
+type SampleJobFilter struct {
+	Scope OwnerScope
+	// Labels selects the jobs that carry every label selector.
+	Labels []LabelSelector
+}
+
+// Includes reports whether a job owned by owner and carrying labels is
+// selected.
+func (f SampleJobFilter) Includes(owner string, labels map[string]string) bool {
+	if !f.Scope.Includes(owner) {
+		return false
+	}
+	for _, l := range f.Labels {
+		if !l.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// LabelSelector selects the sample jobs carrying label Key. A nil Value
+// selects them with any value, else only those with the label set to *Value.
+type LabelSelector struct {
+	Key   string
+	Value *string
+}
+
+// Matches reports whether a job with labels is selected.
+func (l LabelSelector) Matches(labels map[string]string) bool {
+	v, ok := labels[l.Key]
+	return ok && (l.Value == nil || v == *l.Value)
+}
+
+// SampleJobParameters is the snapshot of the study and workflow a sample job
+// runs with, taken when the job first starts running. The executor reads only
+// from the snapshot, so editing the study or the workflow file while the job
+// runs, or between stopping and resuming it, does not change its images.
+type SampleJobParameters struct {
+	JobID string
+	Study Study
+	// Workflow keeps the Name, Workflow and Roles of the job's template.
+	Workflow WorkflowTemplate
+	// OverrideWorkflows keeps the templates of the job's workflow overrides
+	// by name, like Workflow. Nil if the job has no overrides.
+	OverrideWorkflows map[string]WorkflowTemplate
+	CreatedAt         time.Time
+}
+
+// WorkflowOf returns the snapshotted template item is sampled with: its
+// override workflow if it has one, else the job's workflow. ok is false if
+// the override workflow is not part of the snapshot.
+func (p SampleJobParameters) WorkflowOf(item SampleJobItem) (workflow WorkflowTemplate, ok bool) {
+	if item.WorkflowName == "" {
+		return p.Workflow, true
+	}
+	workflow, ok = p.OverrideWorkflows[item.WorkflowName]
+	return workflow, ok
+}
+
+// WorkflowOf returns the name of the workflow item is sampled with: its
+// override workflow if it has one, else the job's workflow.
+func (j SampleJob) WorkflowOf(item SampleJobItem) string {
+	if item.WorkflowName != "" {
+		return item.WorkflowName
+	}
+	return j.WorkflowName
+}
+
+// WorkflowOverride samples the checkpoints of a job whose filename matches
+// Pattern, a path/filepath.Match glob, with Workflow instead of the study's
+// workflow, e.g. to sample the SDXL and Flux checkpoints of a run with the
+// workflows for each.
+type WorkflowOverride struct {
+	Pattern  string
+	Workflow string
+}
+
+// MatchWorkflowOverride returns the workflow of the first of overrides whose
+// pattern matches checkpointFilename, or "" if none does.
+func MatchWorkflowOverride(overrides []WorkflowOverride, checkpointFilename string) string {
+	for _, o := range overrides {
+		if ok, _ := filepath.Match(o.Pattern, checkpointFilename); ok {
+			return o.Workflow
+		}
+	}
+	return ""
+}
+
+// ModelOverrides selects the VAE, text encoder, and shift of a new sample job
+// explicitly. Empty fields fall back to the study's values, then to the
+// workflow's defaults. The ControlNet fields have no fallback; they are set
+// per job only. Workflows selects the workflow of some checkpoints; the
+// others use the study's workflow.
+type ModelOverrides struct {
+	VAE   string
+	CLIP  string
+	Shift *float64
+
+	Workflows []WorkflowOverride
+
+	ControlNetModel    string
+	ControlNetStrength *float64
+	ControlNetImage    string
 }
 
 // SampleJobStatus represents the state of a sample job.
@@ -40,6 +200,9 @@ type SampleJobItem struct {
 	JobID              string
 	CheckpointFilename string
 	ComfyUIModelPath   string
+	// WorkflowName is the workflow override the item is sampled with. Empty
+	// if the item uses the job's workflow.
+	WorkflowName       string
 	PromptName         string
 	PromptText         string
 	NegativePrompt     string
@@ -57,8 +220,44 @@ type SampleJobItem struct {
 	ExceptionType      string
 	NodeType           string
 	Traceback          string
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
+	// ComfyUILog holds the ComfyUI log lines captured when the item failed,
+	// which usually include the Python traceback of a failing custom node.
+	// Empty unless failure log capture is configured.
+	ComfyUILog string
+	// StartedAt is set when the item is submitted to ComfyUI. Nil if the item
+	// has not started yet.
+	StartedAt *time.Time
+	// CompletedAt is set when the item reaches a terminal state (completed or
+	// failed). Nil if the item has not finished.
+	CompletedAt *time.Time
+	// DurationMs is the wall-clock time in milliseconds between StartedAt and
+	// CompletedAt. Nil if the item has not finished.
+	DurationMs *int64
+	// Denoise is the denoise strength of an img2img item. Nil leaves the
+	// workflow's denoise strength unchanged.
+	Denoise    *float64
+	// Resolution is the size the item's study swept it at, as "WxH".
+	// Empty if the study does not sweep resolutions for the item's prompt.
+	Resolution string
+	// Wildcards holds the value of each wildcard of the study the item's
+	// prompt text was expanded with. Nil if the prompt uses no wildcards.
+	Wildcards  map[string]string
+	// Scores holds the automatic quality scores of the item's image by
+	// name (e.g. "aesthetic", "clip_similarity"). Nil unless a scoring
+	// service is configured and scored the image.
+	Scores     map[string]float64
+	// SkipReason tells why a skipped item was skipped. Empty unless the
+	// item's status is skipped.
+	SkipReason SampleJobItemSkipReason
+	// StagedCheckpoint is the copy of the item's checkpoint placed in the
+	// checkpoint staging directory for ComfyUI to load. Empty when the
+	// checkpoint was not staged.
+	StagedCheckpoint string
+	// Version counts the saved changes of the item, starting at 1. An update
+	// is saved only if it carries the stored version, and increments it.
+	Version    int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
 // SampleJobItemStatus represents the state of a sample job item.
@@ -70,13 +269,92 @@ const (
 	SampleJobItemStatusCompleted SampleJobItemStatus = "completed"
 	SampleJobItemStatusFailed    SampleJobItemStatus = "failed"
 	SampleJobItemStatusSkipped   SampleJobItemStatus = "skipped"
+	// SampleJobItemStatusInterrupted marks an item that was in flight when the
+	// executor shut down and did not finish within the drain timeout. The
+	// executor re-queues interrupted items as pending when it next starts.
+	SampleJobItemStatusInterrupted SampleJobItemStatus = "interrupted"
+)
+
+// SampleJobItemSkipReason is the machine-readable reason an item was skipped.
+// Its ErrorMessage carries the human-readable detail.
+type SampleJobItemSkipReason string
+
+const (
+	// SampleJobItemSkipReasonCheckpointNotFound marks items whose checkpoint
+	// could not be matched to a ComfyUI model path.
+	SampleJobItemSkipReasonCheckpointNotFound SampleJobItemSkipReason = "checkpoint_not_found"
+	// SampleJobItemSkipReasonBudgetExhausted marks items left unsampled
+	// because a job ran out of its sampling budget.
+	SampleJobItemSkipReasonBudgetExhausted SampleJobItemSkipReason = "budget_exhausted"
+	// SampleJobItemSkipReasonUserSkipped marks items a user chose to skip.
+	SampleJobItemSkipReasonUserSkipped SampleJobItemSkipReason = "user_skipped"
+	// SampleJobItemSkipReasonFiltered marks items excluded by a filter on
+	// the job.
+	SampleJobItemSkipReasonFiltered SampleJobItemSkipReason = "filtered"
+	// SampleJobItemSkipReasonDuplicate marks items whose output already
+	// exists.
+	SampleJobItemSkipReasonDuplicate SampleJobItemSkipReason = "duplicate"
+)
+
+// SkippedOutputExistsMessage is the error message of items skipped at job
+// creation because their output file already exists.
+const SkippedOutputExistsMessage = "output already exists"
+
+// SampleJobItemFilter restricts a sample job item listing. Empty fields match
+// every item.
+type SampleJobItemFilter struct {
+	Status             SampleJobItemStatus
+	CheckpointFilename string
+	PromptName         string
+	SamplerName        string
+	SkipReason         SampleJobItemSkipReason
+}
+
+// SampleJobItemSort selects the order of a sample job item listing.
+type SampleJobItemSort string
+
+const (
+	// SampleJobItemSortCreatedAt orders items by creation, the default.
+	SampleJobItemSortCreatedAt SampleJobItemSort = "created_at"
+	// SampleJobItemSortDuration orders items by execution time; items that
+	// have not finished come last in either direction.
+	SampleJobItemSortDuration SampleJobItemSort = "duration"
+	// SampleJobItemSortSeed orders items by seed.
+	SampleJobItemSortSeed SampleJobItemSort = "seed"
+	// SampleJobItemSortScore orders items by the automatic score named by
+	// SampleJobItemQuery.ScoreName; unscored items come last in either
+	// direction.
+	SampleJobItemSortScore SampleJobItemSort = "score"
 )
 
+// SampleJobItemQuery selects and orders the items of a sample job listing.
+type SampleJobItemQuery struct {
+	Filter     SampleJobItemFilter
+	Sort       SampleJobItemSort // empty means SampleJobItemSortCreatedAt
+	ScoreName  string            // required by SampleJobItemSortScore
+	Descending bool
+}
+
 // ItemStatusCounts contains counts of items grouped by status, computed on-the-fly.
 type ItemStatusCounts struct {
 	Completed int
-	Failed    int
+	Failed    int // failed and skipped items
 	Pending   int
+	// Skipped counts the skipped items by skip reason. Nil if no item was
+	// skipped.
+	Skipped map[SampleJobItemSkipReason]int
+}
+
+// AddSkipped counts n skipped items with the given reason. Items without a
+// reason are left out of Skipped.
+func (c *ItemStatusCounts) AddSkipped(reason SampleJobItemSkipReason, n int) {
+	if reason == "" {
+		return
+	}
+	if c.Skipped == nil {
+		c.Skipped = make(map[SampleJobItemSkipReason]int)
+	}
+	c.Skipped[reason] += n
 }
 
 // FailedItemDetail contains information about a failed checkpoint.
@@ -87,6 +365,9 @@ type FailedItemDetail struct {
 	ExceptionType      string
 	NodeType           string
 	Traceback          string
+	// ComfyUILog is the ComfyUI log captured for the failure. It is only
+	// set in job details, not in job_progress events.
+	ComfyUILog string
 }
 
 // JobProgress contains computed progress metrics for a sample job.
@@ -0,0 +1,21 @@
+package model
+
+// SampleJobComparison pairs up the items of two sample jobs that were
+// generated with the same parameters, for a side-by-side comparison.
+type SampleJobComparison struct {
+	A SampleJob
+	B SampleJob
+	// Pairs are the matched items, in the item order of job A.
+	Pairs []SampleJobItemPair
+	// UnmatchedA and UnmatchedB are the items of each job without a
+	// counterpart in the other, in item order.
+	UnmatchedA []SampleJobItem
+	UnmatchedB []SampleJobItem
+}
+
+// SampleJobItemPair is an item of job A and the item of job B generated with
+// the same prompt, seed, CFG, steps, sampler, and scheduler.
+type SampleJobItemPair struct {
+	A SampleJobItem
+	B SampleJobItem
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// SampleJobPreview describes the sample job that a create request would
+// produce, without the job being stored.
+type SampleJobPreview struct {
+	// TotalItems is the number of work items the job would have, including
+	// skipped items.
+	TotalItems int
+	// RunnableItems is TotalItems minus the skipped items: those of
+	// checkpoints that failed ComfyUI path matching and those whose output
+	// already exists.
+	RunnableItems int
+	// ExistingItems is the number of items that would be skipped because
+	// their output already exists (see skip_existing).
+	ExistingItems int
+	// Checkpoints lists each selected checkpoint with its item count, in the
+	// order the job would sample them.
+	Checkpoints []CheckpointPreview
+	// UnmatchedCheckpoints are the filenames of the selected checkpoints that
+	// could not be matched to a ComfyUI model path.
+	UnmatchedCheckpoints []string
+	// EstimatedDuration is RunnableItems multiplied by the average duration of
+	// recently completed items. Nil when no timing history is available.
+	EstimatedDuration *time.Duration
+	// Warnings are the warnings the created job would report.
+	Warnings []string
+}
+
+// CheckpointPreview is the share of a previewed sample job for one checkpoint.
+type CheckpointPreview struct {
+	Filename string
+	// Items is the number of work items for this checkpoint. It is zero when
+	// every output already exists and only missing items were requested.
+	Items int
+	// PathMatched is false if the checkpoint could not be matched to a
+	// ComfyUI model path; its items would be skipped.
+	PathMatched bool
+}
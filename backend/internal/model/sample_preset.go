@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -20,36 +22,265 @@ type SamplerSchedulerPair struct {
 // change the configuration of a study that has samples, they must either fork
 // it (creating a new study with modified settings) or regenerate all samples
 // with the new settings.
+//
+// Editing a study that a sample job was created from archives the edited
+// configuration as a StudyVersion and increments Version, so that every job
+// keeps referencing the exact configuration it sampled.
 type Study struct {
 	ID                    string
 	Name                  string
+	Version               int // starts at 1; incremented by edits after a job used the study
 	PromptPrefix          string
 	Prompts               []NamedPrompt
 	NegativePrompt        string
 	Steps                 []int
 	CFGs                  []float64
 	SamplerSchedulerPairs []SamplerSchedulerPair
-	Seeds                 []int64
+	Seeds                 []int64 // seeds of the explicit seed mode
+	SeedMode              SeedMode
+	RandomSeedCount       int // seeds drawn per combination in the random seed modes
 	Width                 int
 	Height                int
-	WorkflowTemplate      string   // ComfyUI workflow template filename (optional)
-	VAE                   string   // ComfyUI VAE model path (optional)
-	TextEncoder           string   // ComfyUI CLIP/text encoder model path (optional)
-	Shift                 *float64 // AuraFlow shift value (optional, nullable)
+	Resolutions           []Resolution // image sizes swept instead of Width x Height (optional)
+	WorkflowTemplate      string       // ComfyUI workflow template filename (optional)
+	VAE                   string       // ComfyUI VAE model path (optional)
+	TextEncoder           string       // ComfyUI CLIP/text encoder model path (optional)
+	Shift                 *float64     // AuraFlow shift value (optional, nullable)
+	InputImage            string       // path of the image img2img samples start from (optional)
+	DenoiseStrengths      []float64    // denoise strengths swept when InputImage is set (optional)
+	Wildcards             []Wildcard   // template variables of the prompt texts (optional)
+	Owner                 string       // name of the user that created the study; empty if unowned
 	CreatedAt             time.Time
 	UpdatedAt             time.Time
 }
 
-// NamedPrompt represents a prompt with a name and text.
+// StudyVersion is an archived configuration of a study: the study as it was
+// at Version, before an edit replaced it.
+type StudyVersion struct {
+	StudyID   string
+	Version   int
+	Study     Study
+	CreatedAt time.Time // when the version was archived
+}
+
+// Resolution is an image size in pixels.
+type Resolution struct {
+	Width  int
+	Height int
+}
+
+// String returns the resolution as it appears in output filenames, e.g.
+// "832x1216".
+func (r Resolution) String() string {
+	return fmt.Sprintf("%dx%d", r.Width, r.Height)
+}
+
+// SeedMode selects where the seeds of a study's items come from.
+type SeedMode string
+
+const (
+	// SeedModeExplicit samples every combination with each of the study's
+	// seeds. It is the mode of studies without one.
+	SeedModeExplicit SeedMode = "explicit"
+	// SeedModeRandomPerItem samples every combination RandomSeedCount times,
+	// each time with a newly drawn seed.
+	SeedModeRandomPerItem SeedMode = "random_per_item"
+	// SeedModeRandomPerCheckpoint draws RandomSeedCount seeds for each
+	// checkpoint and samples every combination of the checkpoint with each
+	// of them, so images of one checkpoint that differ in other settings
+	// share seeds.
+	SeedModeRandomPerCheckpoint SeedMode = "random_per_checkpoint"
+)
+
+// IsRandom reports whether seeds are drawn at random in mode m.
+func (m SeedMode) IsRandom() bool {
+	return m == SeedModeRandomPerItem || m == SeedModeRandomPerCheckpoint
+}
+
+// NamedPrompt represents a prompt with a name and text. A prompt may
+// override the study's steps, CFGs, seeds, and image size, e.g. to sample
+// portrait prompts at 832x1216 and landscape prompts at 1216x832 in one
+// study. Empty lists and zero sizes use the study's values.
 type NamedPrompt struct {
-	Name string
+	Name   string
+	Text   string
+	Steps  []int
+	CFGs   []float64
+	Seeds  []int64
+	Width  int
+	Height int
+}
+
+// PromptSettings holds the values the items of one prompt of a study are
+// generated with.
+type PromptSettings struct {
+	Steps  []int
+	CFGs   []float64
+	Seeds  []int64
+	Width  int
+	Height int
+	// Resolutions are the sizes the prompt's items are swept over instead
+	// of Width x Height; nil unless the study sweeps resolutions and the
+	// prompt does not override the size.
+	Resolutions []Resolution
+}
+
+// Sizes returns the image sizes the prompt's items are generated at, and
+// whether they are swept, i.e. whether items record their resolution.
+func (ps PromptSettings) Sizes() ([]Resolution, bool) {
+	if len(ps.Resolutions) > 0 {
+		return ps.Resolutions, true
+	}
+	return []Resolution{{Width: ps.Width, Height: ps.Height}}, false
+}
+
+// SettingsFor returns the values the items of prompt p are generated with:
+// the prompt's overrides, falling back to the study's values. A prompt that
+// overrides its width or height is not swept over the study's resolutions.
+func (s Study) SettingsFor(p NamedPrompt) PromptSettings {
+	settings := PromptSettings{
+		Steps:  s.Steps,
+		CFGs:   s.CFGs,
+		Seeds:  s.Seeds,
+		Width:  s.Width,
+		Height: s.Height,
+	}
+	if p.Width == 0 && p.Height == 0 {
+		settings.Resolutions = s.Resolutions
+	}
+	if len(p.Steps) > 0 {
+		settings.Steps = p.Steps
+	}
+	if len(p.CFGs) > 0 {
+		settings.CFGs = p.CFGs
+	}
+	if len(p.Seeds) > 0 {
+		settings.Seeds = p.Seeds
+	}
+	if p.Width > 0 {
+		settings.Width = p.Width
+	}
+	if p.Height > 0 {
+		settings.Height = p.Height
+	}
+	return settings
+}
+
+// Wildcard is a template variable of a study's prompts. A prompt whose text
+// contains {name} is sampled once per value, with the placeholder replaced by
+// the value, instead of being written out once per value by hand.
+type Wildcard struct {
+	Name   string
+	Values []string
+}
+
+// wildcardPlaceholder matches a {name} placeholder in a prompt text.
+var wildcardPlaceholder = regexp.MustCompile(`\{([a-z][a-z0-9_]*)\}`)
+
+// WildcardNamePattern is the pattern wildcard names must match. Names are
+// used as keys in output filenames, so they are restricted to lowercase
+// letters, digits and underscores.
+var WildcardNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ReservedWildcardNames are the keys output filenames and image dimensions
+// already use; a wildcard cannot take one of them as its name.
+var ReservedWildcardNames = []string{"checkpoint", "prompt", "steps", "cfg", "sampler", "scheduler", "seed", "denoise", "resolution"}
+
+// PromptVariant is one expansion of the wildcards of a prompt.
+type PromptVariant struct {
+	// Text is the prompt text with its placeholders replaced.
 	Text string
+	// Wildcards maps the name of each wildcard the text uses to its value
+	// in this variant. Nil if the text uses no wildcards.
+	Wildcards map[string]string
+}
+
+// VariantsOf returns the expansions of prompt p's text: one per combination
+// of the values of the study's wildcards the text uses, varying the last
+// wildcard fastest. A text that uses no wildcards has a single variant, the
+// text itself. Placeholders naming no wildcard are left as they are.
+func (s Study) VariantsOf(p NamedPrompt) []PromptVariant {
+	used := make(map[string]bool)
+	for _, m := range wildcardPlaceholder.FindAllStringSubmatch(p.Text, -1) {
+		used[m[1]] = true
+	}
+	variants := []PromptVariant{{Text: p.Text}}
+	for _, w := range s.Wildcards {
+		if !used[w.Name] || len(w.Values) == 0 {
+			continue
+		}
+		next := make([]PromptVariant, 0, len(variants)*len(w.Values))
+		for _, v := range variants {
+			for _, value := range w.Values {
+				values := make(map[string]string, len(v.Wildcards)+1)
+				for name, val := range v.Wildcards {
+					values[name] = val
+				}
+				values[w.Name] = value
+				next = append(next, PromptVariant{
+					Text:      strings.ReplaceAll(v.Text, "{"+w.Name+"}", value),
+					Wildcards: values,
+				})
+			}
+		}
+		variants = next
+	}
+	return variants
 }
 
 // ImagesPerCheckpoint calculates the total number of images that will be generated
 // per checkpoint using this study.
 func (s Study) ImagesPerCheckpoint() int {
-	return len(s.Prompts) * len(s.Steps) * len(s.CFGs) * len(s.SamplerSchedulerPairs) * len(s.Seeds)
+	total := 0
+	for _, p := range s.Prompts {
+		settings := s.SettingsFor(p)
+		sizes, _ := settings.Sizes()
+		total += len(s.VariantsOf(p)) * len(settings.Steps) * len(settings.CFGs) * len(sizes) * s.SeedCountFor(p)
+	}
+	return total * len(s.SamplerSchedulerPairs) * len(s.DenoiseValues())
+}
+
+// SeedCountFor returns how many seeds each combination of prompt p's other
+// settings is sampled with.
+func (s Study) SeedCountFor(p NamedPrompt) int {
+	if s.SeedMode.IsRandom() {
+		return s.RandomSeedCount
+	}
+	return len(s.SettingsFor(p).Seeds)
+}
+
+// LargestImageSize returns the width and height of the largest images the
+// study generates, by pixel count, taking per-prompt sizes and swept
+// resolutions into account. A study without prompts reports its own size.
+func (s Study) LargestImageSize() (int, int) {
+	if len(s.Prompts) == 0 {
+		return s.Width, s.Height
+	}
+	width, height := 0, 0
+	for _, p := range s.Prompts {
+		sizes, _ := s.SettingsFor(p).Sizes()
+		for _, size := range sizes {
+			if size.Width*size.Height > width*height {
+				width, height = size.Width, size.Height
+			}
+		}
+	}
+	return width, height
+}
+
+// DenoiseValues returns the denoise strengths items of this study are
+// generated with. A nil entry leaves the workflow's denoise strength
+// unchanged; it is the only entry unless the study is img2img and has
+// denoise strengths.
+func (s Study) DenoiseValues() []*float64 {
+	if s.InputImage == "" || len(s.DenoiseStrengths) == 0 {
+		return []*float64{nil}
+	}
+	values := make([]*float64, len(s.DenoiseStrengths))
+	for i := range s.DenoiseStrengths {
+		values[i] = &s.DenoiseStrengths[i]
+	}
+	return values
 }
 
 // OutputDirName returns the output directory name for this study.
@@ -99,3 +99,138 @@ var _ = Describe("JoinPromptPrefix", func() {
 			}),
 	)
 })
+
+var _ = Describe("Study per-prompt settings", func() {
+	study := model.Study{
+		Prompts: []model.NamedPrompt{
+			{Name: "portrait", Text: "a portrait", Width: 832, Height: 1216, Seeds: []int64{1, 2, 3}},
+			{Name: "landscape", Text: "a landscape", Width: 1216, Height: 832},
+			{Name: "square", Text: "a square"},
+		},
+		Steps:                 []int{20, 30},
+		CFGs:                  []float64{4},
+		SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+		Seeds:                 []int64{42},
+		Width:                 768,
+		Height:                768,
+	}
+
+	It("falls back to the study's values for those a prompt does not override", func() {
+		Expect(study.SettingsFor(study.Prompts[0])).To(Equal(model.PromptSettings{
+			Steps: []int{20, 30}, CFGs: []float64{4}, Seeds: []int64{1, 2, 3}, Width: 832, Height: 1216,
+		}))
+		Expect(study.SettingsFor(study.Prompts[2])).To(Equal(model.PromptSettings{
+			Steps: []int{20, 30}, CFGs: []float64{4}, Seeds: []int64{42}, Width: 768, Height: 768,
+		}))
+	})
+
+	It("counts each prompt's images with its own settings", func() {
+		// portrait: 2 steps × 3 seeds; landscape and square: 2 steps × 1 seed
+		Expect(study.ImagesPerCheckpoint()).To(Equal(10))
+	})
+
+	It("reports the largest image size across prompts", func() {
+		width, height := study.LargestImageSize()
+		Expect(width * height).To(Equal(1216 * 832))
+		Expect(width).To(Equal(832))
+	})
+})
+
+var _ = Describe("Study resolutions", func() {
+	study := model.Study{
+		Prompts: []model.NamedPrompt{
+			{Name: "swept", Text: "a forest"},
+			{Name: "portrait", Text: "a portrait", Width: 832, Height: 1216},
+		},
+		Steps:                 []int{20},
+		CFGs:                  []float64{4},
+		SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+		Seeds:                 []int64{42},
+		Width:                 1024,
+		Height:                1024,
+		Resolutions:           []model.Resolution{{Width: 768, Height: 768}, {Width: 1344, Height: 768}},
+	}
+
+	It("sweeps the prompts that do not set their own size", func() {
+		sizes, swept := study.SettingsFor(study.Prompts[0]).Sizes()
+		Expect(swept).To(BeTrue())
+		Expect(sizes).To(Equal(study.Resolutions))
+
+		sizes, swept = study.SettingsFor(study.Prompts[1]).Sizes()
+		Expect(swept).To(BeFalse())
+		Expect(sizes).To(Equal([]model.Resolution{{Width: 832, Height: 1216}}))
+	})
+
+	It("counts every resolution as an image", func() {
+		// swept: 2 resolutions; portrait: its own size
+		Expect(study.ImagesPerCheckpoint()).To(Equal(3))
+	})
+
+	It("reports the largest resolution as the largest image size", func() {
+		width, height := study.LargestImageSize()
+		Expect([]int{width, height}).To(Equal([]int{1344, 768}))
+	})
+
+	It("formats resolutions as in output filenames", func() {
+		Expect(study.Resolutions[1].String()).To(Equal("1344x768"))
+	})
+})
+
+var _ = Describe("Study seed modes", func() {
+	study := model.Study{
+		Prompts:               []model.NamedPrompt{{Name: "a", Text: "a"}, {Name: "b", Text: "b"}},
+		Steps:                 []int{20, 30},
+		CFGs:                  []float64{4},
+		SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+		SeedMode:              model.SeedModeRandomPerCheckpoint,
+		RandomSeedCount:       4,
+	}
+
+	It("counts the random seeds of random seed modes", func() {
+		Expect(study.SeedCountFor(study.Prompts[0])).To(Equal(4))
+		// 2 prompts × 2 steps × 4 seeds
+		Expect(study.ImagesPerCheckpoint()).To(Equal(16))
+	})
+
+	It("treats studies without a seed mode as explicit", func() {
+		Expect(model.SeedMode("").IsRandom()).To(BeFalse())
+		Expect(model.SeedModeExplicit.IsRandom()).To(BeFalse())
+		Expect(model.SeedModeRandomPerItem.IsRandom()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Study wildcards", func() {
+	study := model.Study{
+		Prompts: []model.NamedPrompt{
+			{Name: "car", Text: "a {color} car on a {road} road"},
+			{Name: "plain", Text: "a {unknown} house"},
+		},
+		Steps:                 []int{20},
+		CFGs:                  []float64{4},
+		SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+		Seeds:                 []int64{42},
+		Wildcards: []model.Wildcard{
+			{Name: "color", Values: []string{"red", "blue", "green"}},
+			{Name: "road", Values: []string{"dirt", "paved"}},
+			{Name: "unused", Values: []string{"x", "y"}},
+		},
+	}
+
+	It("expands each combination of the wildcards a prompt uses", func() {
+		variants := study.VariantsOf(study.Prompts[0])
+		Expect(variants).To(HaveLen(6))
+		Expect(variants[0]).To(Equal(model.PromptVariant{
+			Text:      "a red car on a dirt road",
+			Wildcards: map[string]string{"color": "red", "road": "dirt"},
+		}))
+		Expect(variants[5].Text).To(Equal("a green car on a paved road"))
+	})
+
+	It("leaves placeholders naming no wildcard as they are", func() {
+		Expect(study.VariantsOf(study.Prompts[1])).To(Equal([]model.PromptVariant{{Text: "a {unknown} house"}}))
+	})
+
+	It("counts every variant as an image", func() {
+		Expect(study.ImagesPerCheckpoint()).To(Equal(7))
+	})
+})
@@ -22,3 +22,11 @@ type ScanResult struct {
 	Images     []Image
 	Dimensions []Dimension
 }
+
+// DimensionIndex contains the dimensions of a training run's images without
+// the images themselves.
+type DimensionIndex struct {
+	Dimensions []Dimension
+	// ImageCount is the number of images the dimensions were collected from.
+	ImageCount int
+}
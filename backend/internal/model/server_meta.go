@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ServerMeta describes how the server evaluates times and which locale
+// clients should format dates and numbers with.
+type ServerMeta struct {
+	Timezone         string     // IANA name of the zone schedules are evaluated in
+	ZoneAbbreviation string     // abbreviation in effect now, e.g. "CEST"
+	UTCOffsetSeconds int        // offset from UTC in effect now
+	DST              bool       // whether daylight saving time is in effect now
+	NextTransition   *time.Time // when the zone's offset or abbreviation next changes; nil if never
+	ServerTime       time.Time  // current time in the server's zone
+	Locale           string     // best match from the request's Accept-Language, else DefaultLocale
+	DefaultLocale    string
+	AcceptedLocales  []string // language tags from Accept-Language, most preferred first
+}
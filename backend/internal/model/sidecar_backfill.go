@@ -0,0 +1,13 @@
+package model
+
+// SidecarBackfillResult summarizes a sidecar backfill run over the sample directory.
+type SidecarBackfillResult struct {
+	Scanned     int      // PNG files examined
+	Written     int      // sidecars created
+	Skipped     int      // images that already had a sidecar or have no query-encoded filename
+	Failed      int      // images whose sidecar could not be written
+	FailedPaths []string // relative paths of failed images, capped at MaxSidecarBackfillFailures
+}
+
+// MaxSidecarBackfillFailures caps the number of failed paths reported by a backfill run.
+const MaxSidecarBackfillFailures = 100
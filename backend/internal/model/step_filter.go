@@ -0,0 +1,42 @@
+package model
+
+// StepFilter selects checkpoints of a training run by step number. The zero
+// value selects every checkpoint.
+type StepFilter struct {
+	// MinStep and MaxStep bound the step numbers of selected checkpoints,
+	// inclusive; nil leaves that side unbounded.
+	MinStep *int
+	MaxStep *int
+	// EveryNth selects every nth checkpoint within the bounds, starting with
+	// the first; values below 2 select all of them.
+	EveryNth int
+}
+
+// IsZero reports whether f selects every checkpoint.
+func (f StepFilter) IsZero() bool {
+	return f.MinStep == nil && f.MaxStep == nil && f.EveryNth <= 1
+}
+
+// Apply returns the checkpoints f selects, in their order. Checkpoints are
+// expected in step order, as discovery returns them, for EveryNth to space
+// them evenly.
+func (f StepFilter) Apply(checkpoints []Checkpoint) []Checkpoint {
+	if f.IsZero() {
+		return checkpoints
+	}
+	selected := checkpoints[:0:0]
+	inRange := 0
+	for _, cp := range checkpoints {
+		if f.MinStep != nil && cp.StepNumber < *f.MinStep {
+			continue
+		}
+		if f.MaxStep != nil && cp.StepNumber > *f.MaxStep {
+			continue
+		}
+		if f.EveryNth <= 1 || inRange%f.EveryNth == 0 {
+			selected = append(selected, cp)
+		}
+		inRange++
+	}
+	return selected
+}
@@ -0,0 +1,58 @@
+package model_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+var _ = Describe("StepFilter", func() {
+	intPtr := func(v int) *int { return &v }
+
+	// Checkpoints every 5000 steps from 5000 to 60000.
+	checkpoints := func() []model.Checkpoint {
+		var cps []model.Checkpoint
+		for step := 5000; step <= 60000; step += 5000 {
+			cps = append(cps, model.Checkpoint{StepNumber: step})
+		}
+		return cps
+	}
+
+	steps := func(cps []model.Checkpoint) []int {
+		result := make([]int, len(cps))
+		for i, cp := range cps {
+			result[i] = cp.StepNumber
+		}
+		return result
+	}
+
+	DescribeTable("Apply",
+		func(filter model.StepFilter, expected []int) {
+			Expect(steps(filter.Apply(checkpoints()))).To(Equal(expected))
+		},
+		Entry("zero value selects all", model.StepFilter{},
+			[]int{5000, 10000, 15000, 20000, 25000, 30000, 35000, 40000, 45000, 50000, 55000, 60000}),
+		Entry("inclusive bounds", model.StepFilter{MinStep: intPtr(10000), MaxStep: intPtr(25000)},
+			[]int{10000, 15000, 20000, 25000}),
+		Entry("lower bound only", model.StepFilter{MinStep: intPtr(50000)},
+			[]int{50000, 55000, 60000}),
+		Entry("upper bound only", model.StepFilter{MaxStep: intPtr(12000)},
+			[]int{5000, 10000}),
+		Entry("every nth within bounds, starting with the first", model.StepFilter{MinStep: intPtr(10000), MaxStep: intPtr(50000), EveryNth: 4},
+			[]int{10000, 30000, 50000}),
+		Entry("every nth without bounds", model.StepFilter{EveryNth: 5},
+			[]int{5000, 30000, 55000}),
+		Entry("every 1st selects all in bounds", model.StepFilter{MinStep: intPtr(55000), EveryNth: 1},
+			[]int{55000, 60000}),
+		Entry("empty range", model.StepFilter{MinStep: intPtr(30000), MaxStep: intPtr(20000)},
+			[]int{}),
+	)
+
+	It("reports whether it selects every checkpoint", func() {
+		Expect(model.StepFilter{}.IsZero()).To(BeTrue())
+		Expect(model.StepFilter{EveryNth: 1}.IsZero()).To(BeTrue())
+		Expect(model.StepFilter{EveryNth: 2}.IsZero()).To(BeFalse())
+		Expect(model.StepFilter{MaxStep: intPtr(0)}.IsZero()).To(BeFalse())
+	})
+})
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// FileUsage is the size and modification time of one file under the sample
+// directory. Path is relative to the sample directory, with forward slashes.
+type FileUsage struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// StorageUsage is the disk usage of the sample directory, broken down by its
+// top-level directories. With the per-training-run layout
+// ({training_run}/{study}/{checkpoint}/) these are the training runs.
+type StorageUsage struct {
+	TotalBytes   int64
+	FileCount    int
+	TrainingRuns []TrainingRunStorageUsage // ordered by directory name
+}
+
+// TrainingRunStorageUsage is the disk usage of one top-level directory of the
+// sample directory. Bytes and FileCount include files outside checkpoint
+// directories, so they may exceed the sum of the checkpoints.
+type TrainingRunStorageUsage struct {
+	Directory   string // sanitized training run name
+	Bytes       int64
+	FileCount   int
+	Checkpoints []CheckpointStorageUsage // ordered by checkpoint filename
+}
+
+// CheckpointStorageUsage is the disk usage of the samples of one checkpoint,
+// summed across the studies it was sampled in. Thumbnails and sidecars count
+// towards it.
+type CheckpointStorageUsage struct {
+	CheckpointFilename string
+	Bytes              int64
+	FileCount          int
+	StudyCount         int
+}
+
+// RetentionCandidate is a checkpoint sample directory
+// ({training_run}/{study}/{checkpoint}) the retention policy deletes.
+type RetentionCandidate struct {
+	Path               string // relative to the sample directory
+	TrainingRun        string // sanitized training run directory name
+	StudyName          string
+	CheckpointFilename string
+	Bytes              int64
+	FileCount          int
+}
+
+// RetentionResult is the outcome of applying the retention policy once. In a
+// dry run, Deleted lists what would have been deleted and nothing is removed.
+type RetentionResult struct {
+	DryRun     bool
+	Deleted    []RetentionCandidate
+	FreedBytes int64
+	// Errors holds the directories that could not be removed, with the
+	// reason; the policy continues with the remaining candidates.
+	Errors []string
+}
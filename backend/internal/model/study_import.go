@@ -0,0 +1,31 @@
+package model
+
+// StudyImportFormat is the file format of a study parameter import.
+type StudyImportFormat string
+
+const (
+	// StudyImportFormatCSV is a CSV file with a header row naming the columns.
+	StudyImportFormatCSV StudyImportFormat = "csv"
+	// StudyImportFormatJSON is a JSON array of row objects keyed by column name.
+	StudyImportFormatJSON StudyImportFormat = "json"
+)
+
+// Study import column names. A sampler and scheduler on the same row form a
+// sampler/scheduler pair.
+const (
+	StudyImportColumnSeeds     = "seeds"
+	StudyImportColumnSteps     = "steps"
+	StudyImportColumnCFGs      = "cfgs"
+	StudyImportColumnSampler   = "sampler"
+	StudyImportColumnScheduler = "scheduler"
+)
+
+// StudyParameterImport holds the parameter lists read from an import file.
+// A nil list means the file had no such column, and the study's current
+// values are kept.
+type StudyParameterImport struct {
+	Seeds                 []int64
+	Steps                 []int
+	CFGs                  []float64
+	SamplerSchedulerPairs []SamplerSchedulerPair
+}
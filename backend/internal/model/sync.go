@@ -0,0 +1,42 @@
+package model
+
+// ChangeEntity names a kind of entity recorded in the change log.
+type ChangeEntity string
+
+const (
+	ChangeEntitySampleJob ChangeEntity = "sample_job"
+	// ChangeEntitySampleJobItems records a change to any item of a sample
+	// job. Its ID is the job's ID.
+	ChangeEntitySampleJobItems ChangeEntity = "sample_job_items"
+	ChangeEntityStudy          ChangeEntity = "study"
+	ChangeEntityPreset         ChangeEntity = "preset"
+	// ChangeEntityImageDir records a change to an image index directory or
+	// its images. Its ID is the directory relative to the sample directory.
+	ChangeEntityImageDir ChangeEntity = "image_dir"
+)
+
+// Change is a change log entry: the latest change to an entity, with the
+// sequence number it was recorded under. Sequence numbers increase with
+// every change, so they serve as sync cursors.
+type Change struct {
+	Seq      int64
+	Entity   ChangeEntity
+	EntityID string
+}
+
+// SyncResult holds the current state of the entities that changed after a
+// sync cursor. Entities that no longer exist are listed in Deleted.
+type SyncResult struct {
+	// Cursor is the sequence number of the last change included, to be
+	// passed as the cursor of the next sync. It is the given cursor when
+	// nothing changed.
+	Cursor int64
+	// More is set when changes remain after Cursor.
+	More          bool
+	Jobs          []SampleJob
+	JobItemCounts map[string]ItemStatusCounts // by job ID
+	Studies       []Study
+	Presets       []Preset
+	ImageDirs     []IndexedImageDir
+	Deleted       []Change
+}
@@ -0,0 +1,14 @@
+package model
+
+// TimelineSpec describes an animated clip of one image per checkpoint of a
+// training run, showing how the image evolves over training.
+type TimelineSpec struct {
+	// Filters pins the non-checkpoint dimensions to fixed values (e.g.
+	// prompt_name=forest, seed=420, cfg=7). Each frame holds the first image,
+	// by relative path, of its checkpoint that matches every filter.
+	Filters map[string]string
+	// FrameSize is the maximum width and height in pixels of each frame's image.
+	FrameSize int
+	// FrameDelayMs is how long each frame is shown, in milliseconds.
+	FrameDelayMs int
+}
@@ -17,6 +17,9 @@ type TrainingRun struct {
 	// StudyOutputDir is the full path prefix between sample_dir and checkpoint dirs.
 	// New layout: "my-model/My Study". Legacy study: "my-study". Legacy root: "".
 	StudyOutputDir string
+	// ConfigID is the ID of the TrainingRunConfig that defines this run, or
+	// empty for auto-discovered runs (checkpoint-discovery only).
+	ConfigID string
 }
 
 // Checkpoint represents a single .safetensors checkpoint file within a training run.
@@ -31,4 +34,8 @@ type Checkpoint struct {
 	StepNumber int
 	// HasSamples is true if a matching sample directory exists.
 	HasSamples bool
+	// Dimensions holds values extracted by the dimension configs of the
+	// training run's TrainingRunConfig, keyed by dimension name. Nil for
+	// auto-discovered runs.
+	Dimensions map[string]string
 }
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// TrainingRunConfig is a user-defined training run persisted in the database.
+// Checkpoints whose path matches Pattern are grouped into a training run
+// named Name instead of being grouped by auto-discovery.
+type TrainingRunConfig struct {
+	ID string
+	// Name is the display name, used as the training run name.
+	Name string
+	// Pattern is a regular expression matched against checkpoint paths
+	// relative to their checkpoint directory (slash-separated).
+	Pattern string
+	// Dimensions extract additional values from matching checkpoint paths.
+	Dimensions []TrainingRunDimensionConfig
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// TrainingRunStepDimension is the name of the dimension whose integer value,
+// when configured, replaces the step number parsed from checkpoint filenames.
+const TrainingRunStepDimension = "step"
+
+// TrainingRunDimensionConfig extracts a dimension value from a checkpoint
+// path. Pattern must contain exactly one capture group, whose match is the
+// value.
+type TrainingRunDimensionConfig struct {
+	Name    string
+	Type    DimensionType
+	Pattern string
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// TrainingRunSummary is an overview of a training run: its checkpoints, the
+// sample jobs run against it and the disk space its samples take.
+type TrainingRunSummary struct {
+	Name string
+	// Checkpoints lists the discovered checkpoints of the run, ordered by
+	// step number as in discovery.
+	Checkpoints []CheckpointSummary
+	// SampledCheckpointCount is the number of checkpoints with samples.
+	SampledCheckpointCount int
+	// LastSampledAt is when the most recent sample job item of the run
+	// completed. Nil if none has.
+	LastSampledAt *time.Time
+	Jobs          TrainingRunJobCounts
+	// DiskBytes and DiskFileCount are the disk usage of the run's directory
+	// under the sample directory, including thumbnails and sidecars.
+	DiskBytes     int64
+	DiskFileCount int
+}
+
+// CheckpointSummary is the sampling state of one checkpoint of a training run.
+type CheckpointSummary struct {
+	Filename   string
+	StepNumber int
+	// HasSamples is true if the checkpoint has sample images on disk.
+	HasSamples bool
+	// ImageCount is the number of sample images of the checkpoint, summed
+	// across studies.
+	ImageCount     int
+	CompletedItems int
+	FailedItems    int // failed and skipped items
+	Bytes          int64
+}
+
+// TrainingRunJobCounts counts the sample jobs of a training run that are not
+// in the trash, and their failures.
+type TrainingRunJobCounts struct {
+	Total int
+	// ByStatus counts the jobs by status. Statuses without jobs are left out.
+	ByStatus map[SampleJobStatus]int
+	// FailedItems counts the failed and skipped items across all jobs.
+	FailedItems int
+}
+
+// TrainingRunJobStats is the raw aggregate of the sample jobs of a training
+// run, as read from the store. Jobs in the trash are left out.
+type TrainingRunJobStats struct {
+	JobStatusCounts map[SampleJobStatus]int
+	// Checkpoints holds the item counts per checkpoint filename. Only
+	// checkpoints with completed, failed or skipped items are present.
+	Checkpoints map[string]CheckpointJobStats
+}
+
+// CheckpointJobStats counts the finished sample job items of one checkpoint.
+type CheckpointJobStats struct {
+	CompletedItems int
+	FailedItems    int // failed and skipped items
+	// LastCompletedAt is when the most recent completed item finished. Nil if
+	// no completed item recorded its completion time.
+	LastCompletedAt *time.Time
+}
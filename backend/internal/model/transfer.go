@@ -0,0 +1,40 @@
+package model
+
+// ExportFormatVersion is the version of the portable JSON documents presets
+// and studies are exported as. Importing a document of a newer version is
+// rejected, since it may carry settings this server would silently drop.
+const ExportFormatVersion = 1
+
+// ImportConflictPolicy decides what importing an entry does when a preset or
+// study of the same name already exists.
+type ImportConflictPolicy string
+
+const (
+	// ImportConflictRename imports the entry under the first free name of
+	// "<name> 2", "<name> 3", ...
+	ImportConflictRename ImportConflictPolicy = "rename"
+	// ImportConflictOverwrite replaces the settings of the existing entry,
+	// keeping its ID. An overwritten study archives its current version
+	// like any other edit.
+	ImportConflictOverwrite ImportConflictPolicy = "overwrite"
+	// ImportConflictSkip leaves the existing entry as it is.
+	ImportConflictSkip ImportConflictPolicy = "skip"
+)
+
+// ImportAction is what importing an entry did.
+type ImportAction string
+
+const (
+	ImportActionCreated     ImportAction = "created"
+	ImportActionRenamed     ImportAction = "renamed"
+	ImportActionOverwritten ImportAction = "overwritten"
+	ImportActionSkipped     ImportAction = "skipped"
+)
+
+// ImportedEntry is the outcome of importing one entry of a document.
+type ImportedEntry struct {
+	SourceName string       // name of the entry in the document
+	ID         string       // ID of the created, overwritten, or skipped existing entry
+	Name       string       // name the entry has now; differs from SourceName when renamed
+	Action     ImportAction // what the import did
+}
@@ -0,0 +1,26 @@
+package model
+
+// OwnerAll is the owner filter value that lists the items of every user.
+const OwnerAll = "all"
+
+// User is the configured user an API request acts for.
+type User struct {
+	Name string
+	// Admin users may list and change the items of every user.
+	Admin bool
+}
+
+// OwnerScope selects the items of a list by owner. The zero value selects
+// every item.
+type OwnerScope struct {
+	// Owner, when set, selects the items owned by this user.
+	Owner string
+	// IncludeUnowned also selects the items without an owner, which were
+	// created before users were configured or by requests without a token.
+	IncludeUnowned bool
+}
+
+// Includes reports whether an item owned by owner is in the scope.
+func (s OwnerScope) Includes(owner string) bool {
+	return s.Owner == "" || owner == s.Owner || (owner == "" && s.IncludeUnowned)
+}
@@ -0,0 +1,95 @@
+package model
+
+import "time"
+
+// VoteCandidate is a completed sample image that can be shown in a pairwise
+// vote. Two candidates are comparable when they share every generation
+// parameter but come from different checkpoints.
+type VoteCandidate struct {
+	ItemID             string
+	CheckpointFilename string
+	PromptName         string
+	Steps              int
+	CFG                float64
+	SamplerName        string
+	Scheduler          string
+	Seed               int64
+	Width              int
+	Height             int
+	// OutputPath is the absolute path of the image.
+	OutputPath string
+}
+
+// ComparableWith reports whether c and o were generated with the same
+// parameters by different checkpoints.
+func (c VoteCandidate) ComparableWith(o VoteCandidate) bool {
+	return c.CheckpointFilename != o.CheckpointFilename &&
+		c.PromptName == o.PromptName &&
+		c.Steps == o.Steps &&
+		c.CFG == o.CFG &&
+		c.SamplerName == o.SamplerName &&
+		c.Scheduler == o.Scheduler &&
+		c.Seed == o.Seed &&
+		c.Width == o.Width &&
+		c.Height == o.Height
+}
+
+// VotePairImage is one side of a vote pair.
+type VotePairImage struct {
+	ItemID             string
+	CheckpointFilename string
+	// ImagePath is the image path relative to the sample directory.
+	ImagePath string
+}
+
+// VotePair is a pair of images of a training run, generated with the same
+// parameters by different checkpoints, to be voted on.
+type VotePair struct {
+	TrainingRunName string
+	PromptName      string
+	Steps           int
+	CFG             float64
+	SamplerName     string
+	Scheduler       string
+	Seed            int64
+	A               VotePairImage
+	B               VotePairImage
+}
+
+// Vote records that the image of WinnerCheckpoint was preferred over the image
+// of LoserCheckpoint. Checkpoint filenames are stored with the item IDs so
+// that votes outlive the sample jobs whose images were voted on.
+type Vote struct {
+	ID               string
+	TrainingRunName  string
+	PromptName       string
+	WinnerItemID     string
+	LoserItemID      string
+	WinnerCheckpoint string
+	LoserCheckpoint  string
+	CreatedAt        time.Time
+}
+
+// CheckpointRating is the Elo rating of a checkpoint and the votes it took
+// part in.
+type CheckpointRating struct {
+	CheckpointFilename string
+	Rating             float64
+	Wins               int
+	Losses             int
+}
+
+// PromptRatings are the checkpoint ratings from the votes on one prompt.
+type PromptRatings struct {
+	PromptName string
+	Ratings    []CheckpointRating
+}
+
+// VoteRankings are the Elo rankings of the checkpoints of a training run,
+// across all prompts and per prompt. Ratings are sorted best first.
+type VoteRankings struct {
+	TrainingRunName string
+	VoteCount       int
+	Overall         []CheckpointRating
+	Prompts         []PromptRatings
+}
@@ -0,0 +1,17 @@
+package model
+
+// WatcherStats holds the filesystem watcher's event counters since startup.
+type WatcherStats struct {
+	// EventsReceived is the number of events read from the OS notifier.
+	EventsReceived int64
+	// EventsCoalesced is the number of received events dropped because they
+	// repeated the immediately preceding event (e.g. a burst of writes to the
+	// same file).
+	EventsCoalesced int64
+	// Overflows is the number of times the OS event queue overflowed and
+	// events were lost.
+	Overflows int64
+	// RescansTriggered is the number of rescans of the watched directories
+	// run to recover from overflows.
+	RescansTriggered int64
+}
@@ -8,6 +8,16 @@ type WorkflowTemplate struct {
 	Roles           map[string][]string
 	ValidationState ValidationState
 	Warnings        []string
+	Defaults        WorkflowDefaults
+}
+
+// WorkflowDefaults holds job-creation defaults declared by a workflow through
+// the cs_default key in the _meta of its vae_loader, clip_loader, and shift
+// nodes. Empty fields have no default.
+type WorkflowDefaults struct {
+	VAE   string
+	CLIP  string
+	Shift *float64
 }
 
 // ValidationState indicates whether a workflow is valid.
@@ -22,15 +32,19 @@ const (
 type CSRole string
 
 const (
-	CSRoleSaveImage      CSRole = "save_image"
-	CSRoleUNETLoader     CSRole = "unet_loader"
-	CSRoleCLIPLoader     CSRole = "clip_loader"
-	CSRoleVAELoader      CSRole = "vae_loader"
-	CSRoleSampler        CSRole = "sampler"
-	CSRolePositivePrompt CSRole = "positive_prompt"
-	CSRoleNegativePrompt CSRole = "negative_prompt"
-	CSRoleShift          CSRole = "shift"
-	CSRoleLatentImage    CSRole = "latent_image"
+	CSRoleSaveImage        CSRole = "save_image"
+	CSRoleUNETLoader       CSRole = "unet_loader"
+	CSRoleCLIPLoader       CSRole = "clip_loader"
+	CSRoleVAELoader        CSRole = "vae_loader"
+	CSRoleSampler          CSRole = "sampler"
+	CSRolePositivePrompt   CSRole = "positive_prompt"
+	CSRoleNegativePrompt   CSRole = "negative_prompt"
+	CSRoleShift            CSRole = "shift"
+	CSRoleLatentImage      CSRole = "latent_image"
+	CSRoleLoadImage        CSRole = "load_image"
+	CSRoleDenoise          CSRole = "denoise"
+	CSRoleControlNetLoader CSRole = "controlnet_loader"
+	CSRoleControlNetApply  CSRole = "controlnet_apply"
 )
 
 // KnownCSRoles returns all known cs_role values.
@@ -45,7 +59,47 @@ func KnownCSRoles() []CSRole {
 		CSRoleNegativePrompt,
 		CSRoleShift,
 		CSRoleLatentImage,
+		CSRoleLoadImage,
+		CSRoleDenoise,
+		CSRoleControlNetLoader,
+		CSRoleControlNetApply,
+	}
+}
+
+// SubstitutedInputs returns the node inputs Checkpoint Sampler writes on nodes
+// with this role when it submits a sample. Some are only written when the job
+// sets a value; the rest of the node is left as it is. Returns nil for
+// unknown roles.
+func (r CSRole) SubstitutedInputs() []string {
+	switch r {
+	case CSRoleSaveImage:
+		return []string{"filename_prefix"}
+	case CSRoleUNETLoader:
+		return []string{"unet_name"}
+	case CSRoleCLIPLoader:
+		return []string{"clip_name"}
+	case CSRoleVAELoader:
+		return []string{"vae_name"}
+	case CSRoleSampler:
+		return []string{"seed", "steps", "cfg", "sampler_name", "scheduler"}
+	case CSRolePositivePrompt, CSRoleNegativePrompt:
+		return []string{"text"}
+	case CSRoleShift:
+		return []string{"shift"}
+	case CSRoleLatentImage:
+		return []string{"width", "height", "batch_size"}
+	case CSRoleLoadImage:
+		return []string{"image"}
+	case CSRoleDenoise:
+		return []string{"denoise"}
+	case CSRoleControlNetLoader:
+		return []string{"control_net_name"}
+	case CSRoleControlNetApply:
+		// The conditioning image is written to the LoadImage node linked
+		// to the apply node's image input, not to the apply node itself.
+		return []string{"strength"}
 	}
+	return nil
 }
 
 // IsKnownRole checks if a role string is a known cs_role.
@@ -57,3 +111,58 @@ func IsKnownRole(role string) bool {
 	}
 	return false
 }
+
+// WorkflowGraph is a workflow template laid out as a graph for display: its
+// nodes, the links between them, and the inputs Checkpoint Sampler
+// substitutes.
+type WorkflowGraph struct {
+	Name     string
+	Nodes    []WorkflowGraphNode
+	Links    []WorkflowGraphLink
+	Warnings []string
+}
+
+// WorkflowGraphNode is a node of a WorkflowGraph.
+type WorkflowGraphNode struct {
+	ID        string
+	ClassType string
+	Title     string // _meta.title, empty if unset
+	Role      string // cs_role, empty if unset
+	KnownRole bool
+	Inputs    []WorkflowGraphInput
+}
+
+// WorkflowGraphInput is an input of a WorkflowGraphNode. Linked inputs take
+// their value from another node's output and have no Value.
+type WorkflowGraphInput struct {
+	Name        string
+	Value       interface{}
+	Linked      bool
+	Substituted bool // written by Checkpoint Sampler, replacing Value or the link
+}
+
+// WorkflowGraphLink connects an output of one node to an input of another.
+type WorkflowGraphLink struct {
+	FromNode   string
+	FromOutput int
+	ToNode     string
+	ToInput    string
+}
+
+// WorkflowPreview is a workflow template with the values of a sample
+// substituted, as it would be submitted to ComfyUI.
+type WorkflowPreview struct {
+	Name          string
+	Workflow      map[string]interface{}
+	Substitutions []WorkflowSubstitution // ordered by node ID, then input name
+}
+
+// WorkflowSubstitution is an input of a WorkflowPreview that differs from
+// the template.
+type WorkflowSubstitution struct {
+	NodeID   string
+	Role     string // cs_role of the node, empty if unset
+	Input    string
+	Previous interface{} // value in the template; a link, or nil if unset
+	Value    interface{}
+}
@@ -0,0 +1,547 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// assetUploadSubdir is the subdirectory of the assets directory that holds
+// the partial files of uploads in progress.
+const assetUploadSubdir = ".uploads"
+
+// assetContentTypes lists the accepted content types of each asset kind and
+// the file extension assets of that type are stored with.
+var assetContentTypes = map[model.AssetKind]map[string]string{
+	model.AssetKindImage: {
+		"image/png":  ".png",
+		"image/jpeg": ".jpg",
+		"image/webp": ".webp",
+	},
+	model.AssetKindWildcards: {
+		"text/plain": ".txt",
+	},
+}
+
+// ErrAssetUploadOffset is returned by UploadChunk when a chunk does not start
+// where the data received so far ends. The client should query the upload
+// and resume from its received offset.
+var ErrAssetUploadOffset = errors.New("chunk offset does not match the bytes received")
+
+// AssetStore defines the persistence operations needed by AssetService.
+type AssetStore interface {
+	ListAssets(ctx context.Context, kind model.AssetKind) ([]model.Asset, error)
+	GetAsset(ctx context.Context, id string) (model.Asset, error)
+	GetAssetBySHA256(ctx context.Context, kind model.AssetKind, sha256 string) (model.Asset, error)
+	CreateAsset(ctx context.Context, a model.Asset) error
+	DeleteAsset(ctx context.Context, id string) error
+	GetAssetUpload(ctx context.Context, id string) (model.AssetUpload, error)
+	ListAssetUploadsUpdatedBefore(ctx context.Context, cutoff time.Time) ([]model.AssetUpload, error)
+	CreateAssetUpload(ctx context.Context, u model.AssetUpload) error
+	TouchAssetUpload(ctx context.Context, id string, updatedAt time.Time) error
+	DeleteAssetUpload(ctx context.Context, id string) error
+}
+
+// AssetService manages uploaded assets: reference images and wildcards files
+// consumed by presets and workflows. Files are uploaded in chunks so that
+// large files can be sent over slow or unreliable connections, and an
+// interrupted upload can be resumed. Completed assets are stored under
+// <dir>/<kind>/<sha256><ext>, so identical uploads share one file.
+type AssetService struct {
+	store     AssetStore
+	dir       string
+	maxSize   map[model.AssetKind]int64
+	uploadTTL time.Duration
+	logger    *logrus.Entry
+
+	// mu guards upload records, upload files and writing. It is not held
+	// while a chunk body is read, so a slow client does not block other
+	// uploads.
+	mu sync.Mutex
+	// writing maps the IDs of uploads with a chunk being written to the
+	// offset that chunk starts at, so that concurrent chunks of the same
+	// upload cannot interleave.
+	writing map[string]int64
+
+	// timeNow is a function that returns the current time, injected for testability.
+	timeNow func() time.Time
+}
+
+// NewAssetService creates an AssetService that stores files under cfg.Dir.
+func NewAssetService(store AssetStore, cfg model.AssetsConfig, logger *logrus.Logger) *AssetService {
+	return &AssetService{
+		store: store,
+		dir:   cfg.Dir,
+		maxSize: map[model.AssetKind]int64{
+			model.AssetKindImage:     int64(cfg.MaxImageSizeMB) << 20,
+			model.AssetKindWildcards: int64(cfg.MaxWildcardsSizeMB) << 20,
+		},
+		uploadTTL: time.Duration(cfg.UploadTTL) * time.Hour,
+		writing:   make(map[string]int64),
+		logger:    logger.WithField("component", "asset"),
+		timeNow:   time.Now,
+	}
+}
+
+// EnsureDir creates the assets directory and its subdirectories if they do
+// not exist.
+func (s *AssetService) EnsureDir() error {
+	for _, sub := range []string{assetUploadSubdir, string(model.AssetKindImage), string(model.AssetKindWildcards)} {
+		if err := os.MkdirAll(filepath.Join(s.dir, sub), 0755); err != nil {
+			return fmt.Errorf("creating assets directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// List returns the assets of the given kind, newest first. An empty kind
+// returns assets of every kind.
+func (s *AssetService) List(ctx context.Context, kind model.AssetKind) ([]model.Asset, error) {
+	s.logger.WithField("kind", kind).Trace("entering List")
+	defer s.logger.Trace("returning from List")
+
+	if kind != "" {
+		if _, ok := assetContentTypes[kind]; !ok {
+			return nil, fmt.Errorf("invalid asset kind %q", kind)
+		}
+	}
+	assets, err := s.store.ListAssets(ctx, kind)
+	if err != nil {
+		return nil, fmt.Errorf("listing assets: %w", err)
+	}
+	if assets == nil {
+		assets = []model.Asset{}
+	}
+	s.logger.WithField("asset_count", len(assets)).Debug("listed assets")
+	return assets, nil
+}
+
+// Get returns an asset by ID.
+func (s *AssetService) Get(ctx context.Context, id string) (model.Asset, error) {
+	s.logger.WithField("asset_id", id).Trace("entering Get")
+	defer s.logger.Trace("returning from Get")
+
+	a, err := s.store.GetAsset(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Asset{}, fmt.Errorf("asset %s not found", id)
+	}
+	if err != nil {
+		return model.Asset{}, fmt.Errorf("getting asset: %w", err)
+	}
+	return a, nil
+}
+
+// FilePath returns the absolute path of an asset's file, for workflows and
+// presets that reference the asset.
+func (s *AssetService) FilePath(ctx context.Context, id string) (string, error) {
+	a, err := s.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(a.Path)), nil
+}
+
+// Delete removes an asset and its file.
+func (s *AssetService) Delete(ctx context.Context, id string) error {
+	s.logger.WithField("asset_id", id).Trace("entering Delete")
+	defer s.logger.Trace("returning from Delete")
+
+	a, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteAsset(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("asset %s not found", id)
+		}
+		return fmt.Errorf("deleting asset: %w", err)
+	}
+	if err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(a.Path))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.WithFields(logrus.Fields{
+			"asset_id": id,
+			"error":    err.Error(),
+		}).Warn("failed to remove asset file")
+	}
+	s.logger.WithFields(logrus.Fields{
+		"asset_id": id,
+		"kind":     a.Kind,
+	}).Info("asset deleted")
+	return nil
+}
+
+// StartUpload validates the declared kind, filename, content type, and size
+// of a new asset and creates an empty upload for its content. Uploads that
+// have not received data within the upload TTL are discarded first.
+func (s *AssetService) StartUpload(ctx context.Context, kind model.AssetKind, filename string, contentType string, size int64) (model.AssetUpload, error) {
+	s.logger.WithFields(logrus.Fields{
+		"kind":         kind,
+		"filename":     filename,
+		"content_type": contentType,
+		"size":         size,
+	}).Trace("entering StartUpload")
+	defer s.logger.Trace("returning from StartUpload")
+
+	types, ok := assetContentTypes[kind]
+	if !ok {
+		return model.AssetUpload{}, fmt.Errorf("invalid asset kind %q", kind)
+	}
+	if filename == "" || strings.ContainsAny(filename, `/\`) || filename == "." || filename == ".." {
+		return model.AssetUpload{}, fmt.Errorf("invalid filename %q", filename)
+	}
+	mediaType := normalizeContentType(contentType)
+	if _, ok := types[mediaType]; !ok {
+		return model.AssetUpload{}, fmt.Errorf("invalid content type %q for %s assets", contentType, kind)
+	}
+	if size <= 0 {
+		return model.AssetUpload{}, fmt.Errorf("invalid size %d: must be positive", size)
+	}
+	if max := s.maxSize[kind]; size > max {
+		return model.AssetUpload{}, fmt.Errorf("invalid size %d: %s assets are limited to %d bytes", size, kind, max)
+	}
+
+	s.purgeStaleUploads(ctx)
+
+	now := s.timeNow().UTC()
+	u := model.AssetUpload{
+		ID:          uuid.New().String(),
+		Kind:        kind,
+		Filename:    filename,
+		ContentType: mediaType,
+		Size:        size,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := os.WriteFile(s.uploadPath(u.ID), nil, 0644); err != nil {
+		return model.AssetUpload{}, fmt.Errorf("creating upload file: %w", err)
+	}
+	if err := s.store.CreateAssetUpload(ctx, u); err != nil {
+		os.Remove(s.uploadPath(u.ID))
+		return model.AssetUpload{}, fmt.Errorf("creating upload: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"upload_id": u.ID,
+		"kind":      kind,
+		"size":      size,
+	}).Info("asset upload started")
+	return u, nil
+}
+
+// GetUpload returns an upload with the number of bytes received so far.
+func (s *AssetService) GetUpload(ctx context.Context, id string) (model.AssetUpload, error) {
+	s.logger.WithField("upload_id", id).Trace("entering GetUpload")
+	defer s.logger.Trace("returning from GetUpload")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getUpload(ctx, id)
+}
+
+// UploadChunk appends the data read from r to an upload. offset must equal
+// the number of bytes received so far; otherwise ErrAssetUploadOffset is
+// returned and nothing is written. A chunk that would exceed the declared
+// size is rejected without changing the upload.
+func (s *AssetService) UploadChunk(ctx context.Context, id string, offset int64, r io.Reader) (model.AssetUpload, error) {
+	s.logger.WithFields(logrus.Fields{
+		"upload_id": id,
+		"offset":    offset,
+	}).Trace("entering UploadChunk")
+	defer s.logger.Trace("returning from UploadChunk")
+
+	u, f, err := s.reserveChunk(ctx, id, offset)
+	if err != nil {
+		return model.AssetUpload{}, err
+	}
+
+	// The body is copied without holding s.mu; the reservation keeps other
+	// chunks of this upload out until it is released below.
+	remaining := u.Size - u.Received
+	n, copyErr := io.Copy(f, io.LimitReader(r, remaining+1))
+	closeErr := f.Close()
+	if copyErr == nil && n > remaining {
+		copyErr = fmt.Errorf("invalid chunk: upload exceeds its declared size of %d bytes", u.Size)
+	}
+	if copyErr == nil {
+		copyErr = closeErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.writing[id]; !ok {
+		// The upload was aborted while the chunk was written.
+		return model.AssetUpload{}, fmt.Errorf("asset upload %s not found", id)
+	}
+	delete(s.writing, id)
+	if copyErr != nil {
+		// Drop the partial chunk so that the client can resend it.
+		if err := os.Truncate(s.uploadPath(id), u.Received); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"upload_id": id,
+				"error":     err.Error(),
+			}).Error("failed to truncate upload after a failed chunk")
+		}
+		if strings.HasPrefix(copyErr.Error(), "invalid") {
+			return model.AssetUpload{}, copyErr
+		}
+		return model.AssetUpload{}, fmt.Errorf("writing chunk: %w", copyErr)
+	}
+
+	u.Received += n
+	u.UpdatedAt = s.timeNow().UTC()
+	if err := s.store.TouchAssetUpload(ctx, id, u.UpdatedAt); err != nil {
+		return model.AssetUpload{}, fmt.Errorf("updating upload: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"upload_id":   id,
+		"chunk_bytes": n,
+		"received":    u.Received,
+		"size":        u.Size,
+	}).Debug("asset upload chunk received")
+	return u, nil
+}
+
+// CompleteUpload finishes an upload whose content has been received in
+// full. The content is hashed and, when expectedSHA256 is non-empty, checked
+// against it; its type is sniffed and must match the declared content type.
+// If an asset of the same kind with the same content already exists, that
+// asset is returned and the upload is discarded. Uploads whose content is
+// rejected are discarded too.
+func (s *AssetService) CompleteUpload(ctx context.Context, id string, expectedSHA256 string) (model.Asset, error) {
+	s.logger.WithField("upload_id", id).Trace("entering CompleteUpload")
+	defer s.logger.Trace("returning from CompleteUpload")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.getUpload(ctx, id)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	if u.Received != u.Size {
+		return model.Asset{}, fmt.Errorf("invalid upload: received %d of %d bytes", u.Received, u.Size)
+	}
+
+	p := s.uploadPath(id)
+	sum, err := s.checkContent(p, u)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			s.discardUpload(ctx, id)
+		}
+		return model.Asset{}, err
+	}
+	if expectedSHA256 != "" && !strings.EqualFold(expectedSHA256, sum) {
+		s.discardUpload(ctx, id)
+		return model.Asset{}, fmt.Errorf("invalid upload: sha256 is %s, expected %s", sum, strings.ToLower(expectedSHA256))
+	}
+
+	existing, err := s.store.GetAssetBySHA256(ctx, u.Kind, sum)
+	if err == nil {
+		s.discardUpload(ctx, id)
+		s.logger.WithFields(logrus.Fields{
+			"upload_id": id,
+			"asset_id":  existing.ID,
+		}).Info("asset upload matches an existing asset")
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.Asset{}, fmt.Errorf("looking up asset by hash: %w", err)
+	}
+
+	relPath := path.Join(string(u.Kind), sum+assetContentTypes[u.Kind][u.ContentType])
+	if err := os.Rename(p, filepath.Join(s.dir, filepath.FromSlash(relPath))); err != nil {
+		return model.Asset{}, fmt.Errorf("moving upload into place: %w", err)
+	}
+	a := model.Asset{
+		ID:          uuid.New().String(),
+		Kind:        u.Kind,
+		Filename:    u.Filename,
+		ContentType: u.ContentType,
+		Size:        u.Size,
+		SHA256:      sum,
+		Path:        relPath,
+		CreatedAt:   s.timeNow().UTC(),
+	}
+	if err := s.store.CreateAsset(ctx, a); err != nil {
+		return model.Asset{}, fmt.Errorf("creating asset: %w", err)
+	}
+	s.discardUpload(ctx, id)
+	s.logger.WithFields(logrus.Fields{
+		"asset_id": a.ID,
+		"kind":     a.Kind,
+		"size":     a.Size,
+	}).Info("asset created")
+	return a, nil
+}
+
+// AbortUpload discards an upload and the data received for it.
+func (s *AssetService) AbortUpload(ctx context.Context, id string) error {
+	s.logger.WithField("upload_id", id).Trace("entering AbortUpload")
+	defer s.logger.Trace("returning from AbortUpload")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.getUpload(ctx, id); err != nil {
+		return err
+	}
+	s.discardUpload(ctx, id)
+	s.logger.WithField("upload_id", id).Info("asset upload aborted")
+	return nil
+}
+
+// reserveChunk checks that a chunk of an upload may start at offset and
+// reserves the upload for it, returning the upload and its partial file
+// opened for appending. The caller must close the file and remove id from
+// s.writing under s.mu once the chunk is written.
+func (s *AssetService) reserveChunk(ctx context.Context, id string, offset int64) (model.AssetUpload, *os.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.getUpload(ctx, id)
+	if err != nil {
+		return model.AssetUpload{}, nil, err
+	}
+	if start, ok := s.writing[id]; ok {
+		return model.AssetUpload{}, nil, fmt.Errorf("%w: a chunk at offset %d is still being written", ErrAssetUploadOffset, start)
+	}
+	if offset != u.Received {
+		return model.AssetUpload{}, nil, fmt.Errorf("%w: offset %d, received %d", ErrAssetUploadOffset, offset, u.Received)
+	}
+	f, err := os.OpenFile(s.uploadPath(id), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return model.AssetUpload{}, nil, fmt.Errorf("opening upload file: %w", err)
+	}
+	s.writing[id] = u.Received
+	return u, f, nil
+}
+
+// getUpload loads an upload and sets Received from the size of its partial
+// file, or to the start of the chunk being written to it, if any. The caller
+// must hold s.mu.
+func (s *AssetService) getUpload(ctx context.Context, id string) (model.AssetUpload, error) {
+	u, err := s.store.GetAssetUpload(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.AssetUpload{}, fmt.Errorf("asset upload %s not found", id)
+	}
+	if err != nil {
+		return model.AssetUpload{}, fmt.Errorf("getting asset upload: %w", err)
+	}
+	if start, ok := s.writing[id]; ok {
+		u.Received = start
+		return u, nil
+	}
+	info, err := os.Stat(s.uploadPath(id))
+	if err != nil {
+		return model.AssetUpload{}, fmt.Errorf("reading upload file: %w", err)
+	}
+	u.Received = info.Size()
+	return u, nil
+}
+
+// checkContent hashes the upload file at p and verifies that its content
+// matches the declared content type. It returns the hex-encoded SHA256.
+func (s *AssetService) checkContent(p string, u model.AssetUpload) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", fmt.Errorf("opening upload file: %w", err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(512)
+	sniffed := normalizeContentType(http.DetectContentType(head))
+	if sniffed != u.ContentType {
+		return "", fmt.Errorf("invalid upload: content is %s, not %s", sniffed, u.ContentType)
+	}
+
+	h := sha256.New()
+	if u.Kind == model.AssetKindWildcards {
+		// Wildcards files are small and must be valid UTF-8 throughout,
+		// which sniffing the first bytes does not establish.
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return "", fmt.Errorf("reading upload file: %w", err)
+		}
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("invalid upload: wildcards file is not valid UTF-8")
+		}
+		h.Write(data)
+	} else if _, err := io.Copy(h, br); err != nil {
+		return "", fmt.Errorf("reading upload file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// discardUpload removes an upload's record and partial file. Failures are
+// logged; a leftover file is harmless. A chunk still being written to the
+// upload fails once it finishes. The caller must hold s.mu.
+func (s *AssetService) discardUpload(ctx context.Context, id string) {
+	delete(s.writing, id)
+	if err := s.store.DeleteAssetUpload(ctx, id); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.logger.WithFields(logrus.Fields{
+			"upload_id": id,
+			"error":     err.Error(),
+		}).Warn("failed to delete asset upload")
+	}
+	if err := os.Remove(s.uploadPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.WithFields(logrus.Fields{
+			"upload_id": id,
+			"error":     err.Error(),
+		}).Warn("failed to remove upload file")
+	}
+}
+
+// purgeStaleUploads discards uploads that have not received data within the
+// upload TTL.
+func (s *AssetService) purgeStaleUploads(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stale, err := s.store.ListAssetUploadsUpdatedBefore(ctx, s.timeNow().Add(-s.uploadTTL))
+	if err != nil {
+		s.logger.WithError(err).Warn("failed to list stale asset uploads")
+		return
+	}
+	for _, u := range stale {
+		if _, ok := s.writing[u.ID]; ok {
+			continue
+		}
+		s.discardUpload(ctx, u.ID)
+	}
+	if len(stale) > 0 {
+		s.logger.WithField("upload_count", len(stale)).Info("discarded stale asset uploads")
+	}
+}
+
+// uploadPath returns the path of an upload's partial file.
+func (s *AssetService) uploadPath(id string) string {
+	return filepath.Join(s.dir, assetUploadSubdir, id)
+}
+
+// normalizeContentType returns the lower-case media type of a Content-Type
+// value without parameters, e.g. "text/plain" for "text/plain; charset=utf-8".
+func normalizeContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
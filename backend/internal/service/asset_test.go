@@ -0,0 +1,439 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeAssetStore is an in-memory test double for service.AssetStore.
+type fakeAssetStore struct {
+	assets  map[string]model.Asset
+	uploads map[string]model.AssetUpload
+}
+
+func newFakeAssetStore() *fakeAssetStore {
+	return &fakeAssetStore{
+		assets:  make(map[string]model.Asset),
+		uploads: make(map[string]model.AssetUpload),
+	}
+}
+
+func (f *fakeAssetStore) ListAssets(ctx context.Context, kind model.AssetKind) ([]model.Asset, error) {
+	var result []model.Asset
+	for _, a := range f.assets {
+		if kind == "" || a.Kind == kind {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeAssetStore) GetAsset(ctx context.Context, id string) (model.Asset, error) {
+	a, ok := f.assets[id]
+	if !ok {
+		return model.Asset{}, sql.ErrNoRows
+	}
+	return a, nil
+}
+
+func (f *fakeAssetStore) GetAssetBySHA256(ctx context.Context, kind model.AssetKind, sum string) (model.Asset, error) {
+	for _, a := range f.assets {
+		if a.Kind == kind && a.SHA256 == sum {
+			return a, nil
+		}
+	}
+	return model.Asset{}, sql.ErrNoRows
+}
+
+func (f *fakeAssetStore) CreateAsset(ctx context.Context, a model.Asset) error {
+	f.assets[a.ID] = a
+	return nil
+}
+
+func (f *fakeAssetStore) DeleteAsset(ctx context.Context, id string) error {
+	if _, ok := f.assets[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.assets, id)
+	return nil
+}
+
+func (f *fakeAssetStore) GetAssetUpload(ctx context.Context, id string) (model.AssetUpload, error) {
+	u, ok := f.uploads[id]
+	if !ok {
+		return model.AssetUpload{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (f *fakeAssetStore) ListAssetUploadsUpdatedBefore(ctx context.Context, cutoff time.Time) ([]model.AssetUpload, error) {
+	var result []model.AssetUpload
+	for _, u := range f.uploads {
+		if u.UpdatedAt.Before(cutoff) {
+			result = append(result, u)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeAssetStore) CreateAssetUpload(ctx context.Context, u model.AssetUpload) error {
+	f.uploads[u.ID] = u
+	return nil
+}
+
+func (f *fakeAssetStore) TouchAssetUpload(ctx context.Context, id string, updatedAt time.Time) error {
+	u, ok := f.uploads[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	u.UpdatedAt = updatedAt
+	f.uploads[id] = u
+	return nil
+}
+
+func (f *fakeAssetStore) DeleteAssetUpload(ctx context.Context, id string) error {
+	if _, ok := f.uploads[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.uploads, id)
+	return nil
+}
+
+// testPNG returns the bytes of a small PNG image.
+func testPNG() []byte {
+	var buf bytes.Buffer
+	Expect(png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)))).To(Succeed())
+	return buf.Bytes()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var _ = Describe("AssetService", func() {
+	var (
+		store  *fakeAssetStore
+		svc    *service.AssetService
+		dir    string
+		logger *logrus.Logger
+	)
+
+	BeforeEach(func() {
+		store = newFakeAssetStore()
+		dir = GinkgoT().TempDir()
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewAssetService(store, model.AssetsConfig{
+			Dir:                dir,
+			MaxImageSizeMB:     1,
+			MaxWildcardsSizeMB: 1,
+			UploadTTL:          24,
+		}, logger)
+		Expect(svc.EnsureDir()).To(Succeed())
+	})
+
+	// upload sends data in chunks of chunkSize and completes the upload.
+	upload := func(kind model.AssetKind, filename, contentType string, data []byte, chunkSize int) (model.Asset, error) {
+		u, err := svc.StartUpload(ctx, kind, filename, contentType, int64(len(data)))
+		Expect(err).NotTo(HaveOccurred())
+		for off := 0; off < len(data); off += chunkSize {
+			end := off + chunkSize
+			if end > len(data) {
+				end = len(data)
+			}
+			_, err := svc.UploadChunk(ctx, u.ID, int64(off), bytes.NewReader(data[off:end]))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		return svc.CompleteUpload(ctx, u.ID, "")
+	}
+
+	Describe("StartUpload", func() {
+		It("creates an empty upload", func() {
+			u, err := svc.StartUpload(ctx, model.AssetKindImage, "ref.png", "image/png", 100)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.ID).NotTo(BeEmpty())
+			Expect(u.Received).To(BeZero())
+			Expect(store.uploads).To(HaveKey(u.ID))
+		})
+
+		It("normalizes the content type", func() {
+			u, err := svc.StartUpload(ctx, model.AssetKindWildcards, "colors.txt", "Text/Plain; charset=utf-8", 100)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.ContentType).To(Equal("text/plain"))
+		})
+
+		DescribeTable("rejects invalid uploads",
+			func(kind model.AssetKind, filename, contentType string, size int64, expected string) {
+				_, err := svc.StartUpload(ctx, kind, filename, contentType, size)
+				Expect(err).To(MatchError(ContainSubstring(expected)))
+				Expect(store.uploads).To(BeEmpty())
+			},
+			Entry("unknown kind", model.AssetKind("video"), "a.mp4", "video/mp4", int64(10), "invalid asset kind"),
+			Entry("empty filename", model.AssetKindImage, "", "image/png", int64(10), "invalid filename"),
+			Entry("filename with a path", model.AssetKindImage, "../a.png", "image/png", int64(10), "invalid filename"),
+			Entry("content type of another kind", model.AssetKindImage, "a.txt", "text/plain", int64(10), "invalid content type"),
+			Entry("unsupported image type", model.AssetKindImage, "a.gif", "image/gif", int64(10), "invalid content type"),
+			Entry("zero size", model.AssetKindImage, "a.png", "image/png", int64(0), "must be positive"),
+			Entry("size over the limit", model.AssetKindImage, "a.png", "image/png", int64(1<<20+1), "limited to 1048576 bytes"),
+		)
+
+		It("discards uploads that have not received data within the TTL", func() {
+			stale := model.AssetUpload{ID: "stale", Kind: model.AssetKindImage, UpdatedAt: time.Now().Add(-25 * time.Hour)}
+			store.uploads[stale.ID] = stale
+			Expect(os.WriteFile(filepath.Join(dir, ".uploads", "stale"), []byte("x"), 0644)).To(Succeed())
+
+			_, err := svc.StartUpload(ctx, model.AssetKindImage, "ref.png", "image/png", 100)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.uploads).NotTo(HaveKey("stale"))
+			Expect(filepath.Join(dir, ".uploads", "stale")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Describe("UploadChunk", func() {
+		var u model.AssetUpload
+
+		BeforeEach(func() {
+			var err error
+			u, err = svc.StartUpload(ctx, model.AssetKindWildcards, "colors.txt", "text/plain", 10)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("appends chunks and reports the bytes received", func() {
+			got, err := svc.UploadChunk(ctx, u.ID, 0, strings.NewReader("red\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Received).To(Equal(int64(4)))
+
+			got, err = svc.UploadChunk(ctx, u.ID, 4, strings.NewReader("blue\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Received).To(Equal(int64(9)))
+
+			resumed, err := svc.GetUpload(ctx, u.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resumed.Received).To(Equal(int64(9)))
+		})
+
+		It("rejects a chunk that does not start at the received offset", func() {
+			_, err := svc.UploadChunk(ctx, u.ID, 0, strings.NewReader("red\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.UploadChunk(ctx, u.ID, 0, strings.NewReader("red\n"))
+			Expect(errors.Is(err, service.ErrAssetUploadOffset)).To(BeTrue())
+
+			got, err := svc.GetUpload(ctx, u.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Received).To(Equal(int64(4)))
+		})
+
+		It("rejects a chunk past the declared size and keeps the data received before it", func() {
+			_, err := svc.UploadChunk(ctx, u.ID, 0, strings.NewReader("red\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.UploadChunk(ctx, u.ID, 4, strings.NewReader("yellow\n"))
+			Expect(err).To(MatchError(ContainSubstring("exceeds its declared size")))
+
+			got, err := svc.GetUpload(ctx, u.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Received).To(Equal(int64(4)))
+		})
+
+		It("returns not found for an unknown upload", func() {
+			_, err := svc.UploadChunk(ctx, "missing", 0, strings.NewReader("x"))
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+
+		Context("while a chunk is being written", func() {
+			var (
+				body   *io.PipeWriter
+				result chan error
+			)
+
+			BeforeEach(func() {
+				var r *io.PipeReader
+				r, body = io.Pipe()
+				result = make(chan error, 1)
+				go func() {
+					_, err := svc.UploadChunk(ctx, u.ID, 0, r)
+					result <- err
+				}()
+				// A pipe write returns once the chunk has read it.
+				_, err := body.Write([]byte("red\n"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("serves other requests and rejects another chunk of the upload", func() {
+				got, err := svc.GetUpload(ctx, u.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Received).To(BeZero())
+
+				_, err = svc.UploadChunk(ctx, u.ID, 0, strings.NewReader("red\n"))
+				Expect(errors.Is(err, service.ErrAssetUploadOffset)).To(BeTrue())
+
+				Expect(body.Close()).To(Succeed())
+				Eventually(result).Should(Receive(BeNil()))
+
+				got, err = svc.GetUpload(ctx, u.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Received).To(Equal(int64(4)))
+			})
+
+			It("fails the chunk when the upload is aborted", func() {
+				Expect(svc.AbortUpload(ctx, u.ID)).To(Succeed())
+
+				Expect(body.Close()).To(Succeed())
+				Eventually(result).Should(Receive(MatchError(ContainSubstring("not found"))))
+				Expect(store.uploads).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("CompleteUpload", func() {
+		It("stores the asset under its kind and content hash", func() {
+			data := testPNG()
+			a, err := upload(model.AssetKindImage, "ref.png", "image/png", data, 16)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.SHA256).To(Equal(sha256Hex(data)))
+			Expect(a.Size).To(Equal(int64(len(data))))
+			Expect(a.Filename).To(Equal("ref.png"))
+			Expect(a.Path).To(Equal("image/" + a.SHA256 + ".png"))
+
+			p, err := svc.FilePath(ctx, a.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.ReadFile(p)).To(Equal(data))
+			Expect(store.uploads).To(BeEmpty())
+		})
+
+		It("returns the existing asset for a duplicate upload", func() {
+			data := []byte("red\nblue\n")
+			first, err := upload(model.AssetKindWildcards, "colors.txt", "text/plain", data, 4)
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := upload(model.AssetKindWildcards, "colours.txt", "text/plain", data, 9)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.ID).To(Equal(first.ID))
+			Expect(store.assets).To(HaveLen(1))
+			Expect(os.ReadDir(filepath.Join(dir, ".uploads"))).To(BeEmpty())
+		})
+
+		It("verifies the expected hash", func() {
+			data := []byte("red\n")
+			u, err := svc.StartUpload(ctx, model.AssetKindWildcards, "colors.txt", "text/plain", int64(len(data)))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = svc.UploadChunk(ctx, u.ID, 0, bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.CompleteUpload(ctx, u.ID, strings.Repeat("0", 64))
+			Expect(err).To(MatchError(ContainSubstring("sha256")))
+			Expect(store.uploads).To(BeEmpty())
+		})
+
+		It("accepts a matching expected hash", func() {
+			data := []byte("red\n")
+			u, err := svc.StartUpload(ctx, model.AssetKindWildcards, "colors.txt", "text/plain", int64(len(data)))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = svc.UploadChunk(ctx, u.ID, 0, bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+
+			a, err := svc.CompleteUpload(ctx, u.ID, strings.ToUpper(sha256Hex(data)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.SHA256).To(Equal(sha256Hex(data)))
+		})
+
+		It("rejects an incomplete upload and keeps it for resuming", func() {
+			u, err := svc.StartUpload(ctx, model.AssetKindWildcards, "colors.txt", "text/plain", 10)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = svc.UploadChunk(ctx, u.ID, 0, strings.NewReader("red\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.CompleteUpload(ctx, u.ID, "")
+			Expect(err).To(MatchError(ContainSubstring("received 4 of 10 bytes")))
+			Expect(store.uploads).To(HaveKey(u.ID))
+		})
+
+		DescribeTable("rejects content that does not match the declared type",
+			func(kind model.AssetKind, contentType string, data []byte, expected string) {
+				_, err := upload(kind, "file", contentType, data, 1024)
+				Expect(err).To(MatchError(ContainSubstring(expected)))
+				Expect(store.assets).To(BeEmpty())
+				Expect(store.uploads).To(BeEmpty())
+			},
+			Entry("text declared as png", model.AssetKindImage, "image/png", []byte("not an image"), "content is text/plain, not image/png"),
+			Entry("png declared as jpeg", model.AssetKindImage, "image/jpeg", testPNG(), "content is image/png, not image/jpeg"),
+			Entry("binary wildcards file", model.AssetKindWildcards, "text/plain", testPNG(), "not text/plain"),
+			Entry("wildcards file with invalid UTF-8", model.AssetKindWildcards, "text/plain", append([]byte(strings.Repeat("a", 600)), 0xff, 0xfe), "not valid UTF-8"),
+		)
+	})
+
+	Describe("AbortUpload", func() {
+		It("discards the upload and its data", func() {
+			u, err := svc.StartUpload(ctx, model.AssetKindWildcards, "colors.txt", "text/plain", 10)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(svc.AbortUpload(ctx, u.ID)).To(Succeed())
+			Expect(store.uploads).To(BeEmpty())
+			Expect(os.ReadDir(filepath.Join(dir, ".uploads"))).To(BeEmpty())
+			Expect(svc.AbortUpload(ctx, u.ID)).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns an empty list when there are no assets", func() {
+			assets, err := svc.List(ctx, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(assets).NotTo(BeNil())
+			Expect(assets).To(BeEmpty())
+		})
+
+		It("filters by kind", func() {
+			_, err := upload(model.AssetKindImage, "ref.png", "image/png", testPNG(), 1024)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = upload(model.AssetKindWildcards, "colors.txt", "text/plain", []byte("red\n"), 1024)
+			Expect(err).NotTo(HaveOccurred())
+
+			images, err := svc.List(ctx, model.AssetKindImage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(images).To(HaveLen(1))
+			Expect(images[0].Kind).To(Equal(model.AssetKindImage))
+		})
+
+		It("rejects an unknown kind", func() {
+			_, err := svc.List(ctx, "video")
+			Expect(err).To(MatchError(ContainSubstring("invalid asset kind")))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes the asset and its file", func() {
+			a, err := upload(model.AssetKindWildcards, "colors.txt", "text/plain", []byte("red\n"), 1024)
+			Expect(err).NotTo(HaveOccurred())
+			p, err := svc.FilePath(ctx, a.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(svc.Delete(ctx, a.ID)).To(Succeed())
+			Expect(store.assets).To(BeEmpty())
+			Expect(p).NotTo(BeAnExistingFile())
+		})
+
+		It("returns not found for an unknown asset", func() {
+			Expect(svc.Delete(ctx, "missing")).To(MatchError(ContainSubstring("asset missing not found")))
+		})
+	})
+})
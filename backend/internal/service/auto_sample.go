@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// AutoSampleRunSource discovers the training runs in the checkpoint directories.
+type AutoSampleRunSource interface {
+	Discover(ctx context.Context) ([]model.TrainingRun, error)
+}
+
+// AutoSampleStudyReader reads the study an auto-sample rule refers to.
+type AutoSampleStudyReader interface {
+	GetStudy(ctx context.Context, id string) (model.Study, error)
+}
+
+// AutoSampleJobCreator creates sample jobs. It is satisfied by SampleJobService.
+type AutoSampleJobCreator interface {
+	Create(ctx context.Context, trainingRunName string, checkpoints []model.Checkpoint, studyID string, checkpointFilenames []string, clearExisting bool, missingOnly bool, output model.ImageOutputOptions) (model.SampleJob, error)
+}
+
+// AutoSampler watches the checkpoint directories and creates a sample job
+// when a new checkpoint of a configured training run appears. A checkpoint
+// is sampled once it has gone unmodified for the settle delay, so that jobs
+// are not created for files that are still being written.
+type AutoSampler struct {
+	mu             sync.Mutex
+	notifier       WatcherNotifier
+	runs           AutoSampleRunSource
+	studies        AutoSampleStudyReader
+	jobs           AutoSampleJobCreator
+	dirs           SubdirectoryLister
+	checkpointDirs []string
+	rules          map[string]model.AutoSampleRule
+	settle         time.Duration
+	isDir          IsDirFunc
+	gate           ExecutorGate // optional; when set, jobs are only created while the gate is held
+	logger         *logrus.Entry
+
+	timers   map[string]*time.Timer // pending checkpoints by absolute path
+	sampled  map[string]bool        // checkpoints already handled, by absolute path
+	inFlight sync.WaitGroup
+	done     chan struct{}
+	stopped  chan struct{}
+	running  bool
+}
+
+// NewAutoSampler creates an AutoSampler for the given checkpoint directories
+// and configuration.
+func NewAutoSampler(notifier WatcherNotifier, runs AutoSampleRunSource, studies AutoSampleStudyReader, jobs AutoSampleJobCreator, dirs SubdirectoryLister, checkpointDirs []string, cfg model.AutoSampleConfig, logger *logrus.Logger) *AutoSampler {
+	rules := make(map[string]model.AutoSampleRule, len(cfg.TrainingRuns))
+	for _, r := range cfg.TrainingRuns {
+		rules[r.TrainingRun] = r
+	}
+	return &AutoSampler{
+		notifier:       notifier,
+		runs:           runs,
+		studies:        studies,
+		jobs:           jobs,
+		dirs:           dirs,
+		checkpointDirs: checkpointDirs,
+		rules:          rules,
+		settle:         time.Duration(cfg.SettleSeconds) * time.Second,
+		isDir:          OSIsDir,
+		logger:         logger.WithField("component", "auto_sampler"),
+		timers:         make(map[string]*time.Timer),
+		sampled:        make(map[string]bool),
+	}
+}
+
+// SetIsDirFunc overrides the directory detection function (for testing).
+func (a *AutoSampler) SetIsDirFunc(fn IsDirFunc) {
+	a.isDir = fn
+}
+
+// SetSettleDelay overrides the settle delay from the configuration (for testing).
+func (a *AutoSampler) SetSettleDelay(d time.Duration) {
+	a.settle = d
+}
+
+// SetExecutorGate sets the gate consulted before a job is created. In
+// multi-process mode it is backed by the executor lease so that only one
+// process creates jobs for a new checkpoint.
+func (a *AutoSampler) SetExecutorGate(gate ExecutorGate) {
+	a.gate = gate
+}
+
+// SetCheckpointDirs replaces the watched checkpoint directories. It is called
+// when the configuration is reloaded. Directories that are no longer
+// configured stop producing jobs because their checkpoints no longer match a
+// discovered training run.
+func (a *AutoSampler) SetCheckpointDirs(dirs []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checkpointDirs = dirs
+	if a.running {
+		for _, dir := range dirs {
+			watchDirTree(a.notifier, a.dirs, dir, a.logger)
+		}
+	}
+	a.logger.WithField("dir_count", len(dirs)).Info("checkpoint directories updated")
+}
+
+// Start watches the checkpoint directories and their subdirectories and
+// begins processing events. Directories that cannot be watched are logged
+// and skipped.
+func (a *AutoSampler) Start() {
+	a.logger.Trace("entering Start")
+	defer a.logger.Trace("returning from Start")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.running {
+		return
+	}
+
+	for _, dir := range a.checkpointDirs {
+		watchDirTree(a.notifier, a.dirs, dir, a.logger)
+	}
+
+	a.done = make(chan struct{})
+	a.stopped = make(chan struct{})
+	a.running = true
+	go a.loop()
+
+	a.logger.WithFields(logrus.Fields{
+		"rule_count":     len(a.rules),
+		"settle_seconds": a.settle.Seconds(),
+	}).Info("auto-sampler started")
+}
+
+// Stop stops processing events, cancels pending checkpoints, and waits for
+// jobs that are being created to finish.
+func (a *AutoSampler) Stop() {
+	a.mu.Lock()
+	if !a.running {
+		a.mu.Unlock()
+		return
+	}
+	close(a.done)
+	<-a.stopped
+	a.running = false
+	for path, t := range a.timers {
+		t.Stop()
+		delete(a.timers, path)
+	}
+	a.mu.Unlock()
+
+	a.inFlight.Wait()
+	a.logger.Info("auto-sampler stopped")
+}
+
+// loop processes fsnotify events until Stop is called.
+func (a *AutoSampler) loop() {
+	a.logger.Trace("entering auto-sampler event loop")
+	defer close(a.stopped)
+	defer a.logger.Trace("exiting auto-sampler event loop")
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case ev, ok := <-a.notifier.Events():
+			if !ok {
+				return
+			}
+			a.handleEvent(ev)
+		case err, ok := <-a.notifier.Errors():
+			if !ok {
+				return
+			}
+			a.logger.WithError(err).Error("checkpoint watcher error")
+		}
+	}
+}
+
+// handleEvent tracks new and changing checkpoint files and watches new
+// subdirectories.
+func (a *AutoSampler) handleEvent(ev fsnotify.Event) {
+	a.logger.WithFields(logrus.Fields{
+		"event_name": ev.Name,
+		"event_op":   ev.Op.String(),
+	}).Trace("entering handleEvent")
+	defer a.logger.Trace("returning from handleEvent")
+
+	if !isCheckpointFile(ev.Name) {
+		if ev.Op.Has(fsnotify.Create) && a.isDir(ev.Name) {
+			a.mu.Lock()
+			watchDirTree(a.notifier, a.dirs, ev.Name, a.logger)
+			a.mu.Unlock()
+		}
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case ev.Op.Has(fsnotify.Create) || ev.Op.Has(fsnotify.Write):
+		if a.sampled[ev.Name] {
+			return
+		}
+		// Every write restarts the settle delay.
+		if t, ok := a.timers[ev.Name]; ok {
+			t.Reset(a.settle)
+			return
+		}
+		path := ev.Name
+		a.timers[path] = time.AfterFunc(a.settle, func() { a.settled(path) })
+		a.logger.WithField("checkpoint_path", path).Debug("new checkpoint detected, waiting for it to settle")
+	case ev.Op.Has(fsnotify.Remove) || ev.Op.Has(fsnotify.Rename):
+		if t, ok := a.timers[ev.Name]; ok {
+			t.Stop()
+			delete(a.timers, ev.Name)
+		}
+		delete(a.sampled, ev.Name)
+	}
+}
+
+// settled is called once a checkpoint has gone unmodified for the settle delay.
+func (a *AutoSampler) settled(path string) {
+	a.mu.Lock()
+	if !a.running || a.timers[path] == nil {
+		a.mu.Unlock()
+		return
+	}
+	delete(a.timers, path)
+	a.sampled[path] = true
+	a.inFlight.Add(1)
+	a.mu.Unlock()
+
+	defer a.inFlight.Done()
+	if err := a.sample(context.Background(), path); err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"checkpoint_path": path,
+			"error":           err.Error(),
+		}).Error("failed to auto-sample checkpoint")
+	}
+}
+
+// sample creates a sample job for the checkpoint at path if its training run
+// has an enabled auto-sample rule.
+func (a *AutoSampler) sample(ctx context.Context, path string) error {
+	a.logger.WithField("checkpoint_path", path).Trace("entering sample")
+	defer a.logger.Trace("returning from sample")
+
+	if a.gate != nil && !a.gate.Held() {
+		a.logger.WithField("checkpoint_path", path).Debug("executor lease not held, leaving checkpoint to the lease holder")
+		return nil
+	}
+
+	runs, err := a.runs.Discover(ctx)
+	if err != nil {
+		return fmt.Errorf("discovering training runs: %w", err)
+	}
+	a.mu.Lock()
+	checkpointDirs := a.checkpointDirs
+	a.mu.Unlock()
+	run, cp, ok := findCheckpoint(runs, checkpointDirs, path)
+	if !ok {
+		a.logger.WithField("checkpoint_path", path).Debug("checkpoint not found in any training run")
+		return nil
+	}
+
+	rule, ok := a.rules[run.Name]
+	if !ok || !rule.Enabled {
+		a.logger.WithFields(logrus.Fields{
+			"training_run": run.Name,
+			"checkpoint":   cp.Filename,
+		}).Debug("auto-sampling not enabled for training run")
+		return nil
+	}
+
+	if rule.Workflow != "" {
+		study, err := a.studies.GetStudy(ctx, rule.StudyID)
+		if err != nil {
+			return fmt.Errorf("fetching study %s: %w", rule.StudyID, err)
+		}
+		if study.WorkflowTemplate != rule.Workflow {
+			return fmt.Errorf("study %q uses workflow %q, but auto-sampling of %q is configured for workflow %q",
+				study.Name, study.WorkflowTemplate, run.Name, rule.Workflow)
+		}
+	}
+
+	job, err := a.jobs.Create(context.Background(), run.Name, run.Checkpoints, rule.StudyID, []string{cp.Filename}, false, true, model.ImageOutputOptions{})
+	if err != nil {
+		return fmt.Errorf("creating sample job: %w", err)
+	}
+	a.logger.WithFields(logrus.Fields{
+		"sample_job_id": job.ID,
+		"training_run":  run.Name,
+		"checkpoint":    cp.Filename,
+		"study_id":      rule.StudyID,
+	}).Info("auto-sample job created")
+	return nil
+}
+
+// findCheckpoint returns the training run and checkpoint whose file is at path.
+func findCheckpoint(runs []model.TrainingRun, checkpointDirs []string, path string) (model.TrainingRun, model.Checkpoint, bool) {
+	for _, run := range runs {
+		for _, cp := range run.Checkpoints {
+			if cp.CheckpointDirIndex < 0 || cp.CheckpointDirIndex >= len(checkpointDirs) {
+				continue
+			}
+			cpPath := filepath.Join(checkpointDirs[cp.CheckpointDirIndex], filepath.FromSlash(cp.RelativePath))
+			if cpPath == filepath.Clean(path) {
+				return run, cp, true
+			}
+		}
+	}
+	return model.TrainingRun{}, model.Checkpoint{}, false
+}
@@ -0,0 +1,228 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeAutoSampleRuns returns a fixed list of training runs.
+type fakeAutoSampleRuns struct {
+	runs []model.TrainingRun
+}
+
+func (f *fakeAutoSampleRuns) Discover(ctx context.Context) ([]model.TrainingRun, error) {
+	return f.runs, nil
+}
+
+// fakeAutoSampleStudies returns studies by ID.
+type fakeAutoSampleStudies struct {
+	studies map[string]model.Study
+}
+
+func (f *fakeAutoSampleStudies) GetStudy(ctx context.Context, id string) (model.Study, error) {
+	s, ok := f.studies[id]
+	if !ok {
+		return model.Study{}, errors.New("not found")
+	}
+	return s, nil
+}
+
+// autoSampleCall records the arguments of a Create call.
+type autoSampleCall struct {
+	trainingRunName     string
+	studyID             string
+	checkpointFilenames []string
+	clearExisting       bool
+	missingOnly         bool
+}
+
+// fakeAutoSampleJobs records Create calls.
+type fakeAutoSampleJobs struct {
+	mu    sync.Mutex
+	calls []autoSampleCall
+}
+
+func (f *fakeAutoSampleJobs) Create(ctx context.Context, trainingRunName string, checkpoints []model.Checkpoint, studyID string, checkpointFilenames []string, clearExisting bool, missingOnly bool, output model.ImageOutputOptions) (model.SampleJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, autoSampleCall{trainingRunName, studyID, checkpointFilenames, clearExisting, missingOnly})
+	return model.SampleJob{ID: "job-1"}, nil
+}
+
+func (f *fakeAutoSampleJobs) getCalls() []autoSampleCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cp := make([]autoSampleCall, len(f.calls))
+	copy(cp, f.calls)
+	return cp
+}
+
+// fakeAutoSampleDirs returns subdirectories from a map keyed by directory.
+type fakeAutoSampleDirs struct {
+	subdirs map[string][]string
+}
+
+func (f *fakeAutoSampleDirs) ListSubdirectories(root string) ([]string, error) {
+	return f.subdirs[root], nil
+}
+
+// fakeGate is an ExecutorGate with a fixed answer.
+type fakeGate struct {
+	held bool
+}
+
+func (g *fakeGate) Held() bool { return g.held }
+
+var _ = Describe("AutoSampler", func() {
+	const checkpointDir = "/checkpoints"
+
+	var (
+		notifier *fakeNotifier
+		runs     *fakeAutoSampleRuns
+		studies  *fakeAutoSampleStudies
+		jobs     *fakeAutoSampleJobs
+		dirs     *fakeAutoSampleDirs
+		cfg      model.AutoSampleConfig
+		sampler  *service.AutoSampler
+		logger   *logrus.Logger
+	)
+
+	newCheckpointPath := filepath.Join(checkpointDir, "my-lora-step00002000.safetensors")
+
+	BeforeEach(func() {
+		notifier = newFakeNotifier()
+		runs = &fakeAutoSampleRuns{runs: []model.TrainingRun{
+			{
+				Name: "my-lora",
+				Checkpoints: []model.Checkpoint{
+					{Filename: "my-lora-step00001000.safetensors", RelativePath: "my-lora-step00001000.safetensors", StepNumber: 1000},
+					{Filename: "my-lora-step00002000.safetensors", RelativePath: "my-lora-step00002000.safetensors", StepNumber: 2000},
+				},
+			},
+			{
+				Name: "other-lora",
+				Checkpoints: []model.Checkpoint{
+					{Filename: "other-lora.safetensors", RelativePath: "other-lora.safetensors", StepNumber: -1},
+				},
+			},
+		}}
+		studies = &fakeAutoSampleStudies{studies: map[string]model.Study{
+			"study-1": {ID: "study-1", Name: "quick", WorkflowTemplate: "qwen-image.json"},
+		}}
+		jobs = &fakeAutoSampleJobs{}
+		dirs = &fakeAutoSampleDirs{subdirs: map[string][]string{
+			checkpointDir: {"archive"},
+		}}
+		cfg = model.AutoSampleConfig{
+			SettleSeconds: 30,
+			TrainingRuns: []model.AutoSampleRule{
+				{TrainingRun: "my-lora", StudyID: "study-1", Enabled: true},
+				{TrainingRun: "other-lora", StudyID: "study-1", Enabled: false},
+			},
+		}
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	})
+
+	start := func() {
+		sampler = service.NewAutoSampler(notifier, runs, studies, jobs, dirs, []string{checkpointDir}, cfg, logger)
+		sampler.SetSettleDelay(20 * time.Millisecond)
+		sampler.SetIsDirFunc(func(path string) bool { return filepath.Ext(path) == "" })
+		sampler.Start()
+		DeferCleanup(sampler.Stop)
+	}
+
+	It("watches the checkpoint directories and their subdirectories", func() {
+		start()
+		Expect(notifier.getAdded()).To(Equal([]string{checkpointDir, filepath.Join(checkpointDir, "archive")}))
+	})
+
+	It("watches subdirectories created after start", func() {
+		start()
+		notifier.events <- fsnotify.Event{Name: filepath.Join(checkpointDir, "v2"), Op: fsnotify.Create}
+		Eventually(notifier.getAdded).Should(ContainElement(filepath.Join(checkpointDir, "v2")))
+	})
+
+	It("creates a job for only the new checkpoint once it has settled", func() {
+		start()
+		notifier.events <- fsnotify.Event{Name: newCheckpointPath, Op: fsnotify.Create}
+
+		Eventually(jobs.getCalls).Should(Equal([]autoSampleCall{{
+			trainingRunName:     "my-lora",
+			studyID:             "study-1",
+			checkpointFilenames: []string{"my-lora-step00002000.safetensors"},
+			clearExisting:       false,
+			missingOnly:         true,
+		}}))
+	})
+
+	It("creates a single job for a checkpoint written in several chunks", func() {
+		start()
+		notifier.events <- fsnotify.Event{Name: newCheckpointPath, Op: fsnotify.Create}
+		for i := 0; i < 5; i++ {
+			time.Sleep(5 * time.Millisecond)
+			notifier.events <- fsnotify.Event{Name: newCheckpointPath, Op: fsnotify.Write}
+		}
+
+		Eventually(jobs.getCalls).Should(HaveLen(1))
+		notifier.events <- fsnotify.Event{Name: newCheckpointPath, Op: fsnotify.Write}
+		Consistently(jobs.getCalls, 100*time.Millisecond).Should(HaveLen(1))
+	})
+
+	It("does not create a job for a checkpoint removed before it settled", func() {
+		start()
+		notifier.events <- fsnotify.Event{Name: newCheckpointPath, Op: fsnotify.Create}
+		notifier.events <- fsnotify.Event{Name: newCheckpointPath, Op: fsnotify.Remove}
+		Consistently(jobs.getCalls, 100*time.Millisecond).Should(BeEmpty())
+	})
+
+	DescribeTable("does not create a job",
+		func(setup func(), path string) {
+			setup()
+			start()
+			notifier.events <- fsnotify.Event{Name: path, Op: fsnotify.Create}
+			Consistently(jobs.getCalls, 100*time.Millisecond).Should(BeEmpty())
+		},
+		Entry("for files that are not checkpoints", func() {}, filepath.Join(checkpointDir, "notes.txt")),
+		Entry("for a training run without a rule", func() {
+			cfg.TrainingRuns = cfg.TrainingRuns[1:]
+		}, newCheckpointPath),
+		Entry("for a training run whose rule is disabled", func() {}, filepath.Join(checkpointDir, "other-lora.safetensors")),
+		Entry("when the study uses a different workflow", func() {
+			cfg.TrainingRuns[0].Workflow = "flux.json"
+		}, newCheckpointPath),
+		Entry("for a checkpoint that is not discovered", func() {}, filepath.Join(checkpointDir, "unknown.safetensors")),
+	)
+
+	It("creates a job when the configured workflow matches the study", func() {
+		cfg.TrainingRuns[0].Workflow = "qwen-image.json"
+		start()
+		notifier.events <- fsnotify.Event{Name: newCheckpointPath, Op: fsnotify.Create}
+		Eventually(jobs.getCalls).Should(HaveLen(1))
+	})
+
+	Context("with an executor gate", func() {
+		It("does not create jobs while the gate is not held", func() {
+			sampler = service.NewAutoSampler(notifier, runs, studies, jobs, dirs, []string{checkpointDir}, cfg, logger)
+			sampler.SetSettleDelay(20 * time.Millisecond)
+			sampler.SetExecutorGate(&fakeGate{held: false})
+			sampler.Start()
+			DeferCleanup(sampler.Stop)
+
+			notifier.events <- fsnotify.Event{Name: newCheckpointPath, Op: fsnotify.Create}
+			Consistently(jobs.getCalls, 100*time.Millisecond).Should(BeEmpty())
+		})
+	})
+})
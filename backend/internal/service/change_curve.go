@@ -0,0 +1,283 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+	"sort"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Difference hash size: images are reduced to (changeHashSize+1) x
+// changeHashSize grey cells, and each bit records whether a cell is brighter
+// than its right neighbour.
+const changeHashSize = 16
+
+// Density thresholds, relative to the median rate of change of a run.
+const (
+	denseRateFactor  = 2.0
+	sparseRateFactor = 0.5
+)
+
+// ChangeCurveStore lists the completed images of a training run.
+type ChangeCurveStore interface {
+	ListVoteCandidates(ctx context.Context, trainingRunName string) ([]model.VoteCandidate, error)
+}
+
+// ChangeCurveImageReader reads image files.
+type ChangeCurveImageReader interface {
+	ReadFile(path string) ([]byte, error)
+}
+
+// changeHash is a perceptual difference hash of an image.
+type changeHash [changeHashSize * changeHashSize / 64]uint64
+
+// ChangeCurveService measures how much a training run's images change between
+// consecutive checkpoints, comparing only images generated with the same
+// parameters, and suggests where checkpoints should be sampled densely or
+// sparsely.
+type ChangeCurveService struct {
+	store  ChangeCurveStore
+	reader ChangeCurveImageReader
+	logger *logrus.Entry
+}
+
+// NewChangeCurveService creates a ChangeCurveService.
+func NewChangeCurveService(store ChangeCurveStore, reader ChangeCurveImageReader, logger *logrus.Logger) *ChangeCurveService {
+	return &ChangeCurveService{
+		store:  store,
+		reader: reader,
+		logger: logger.WithField("component", "change_curve"),
+	}
+}
+
+// Curve computes the change curve of a training run. Every completed image of
+// the run is read, so the curve takes longer to compute the more images the
+// run has. Images that cannot be read or decoded are left out. Returns a "not
+// found" error if fewer than two checkpoints of the run have images.
+func (s *ChangeCurveService) Curve(ctx context.Context, trainingRunName string) (model.ChangeCurve, error) {
+	s.logger.WithField("training_run", trainingRunName).Trace("entering Curve")
+	defer s.logger.Trace("returning from Curve")
+
+	if trainingRunName == "" {
+		return model.ChangeCurve{}, fmt.Errorf("training run must not be empty")
+	}
+	candidates, err := s.store.ListVoteCandidates(ctx, trainingRunName)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list completed images")
+		return model.ChangeCurve{}, fmt.Errorf("listing completed images: %w", err)
+	}
+
+	checkpoints := orderCheckpointsByStep(candidates)
+	if len(checkpoints) < 2 {
+		s.logger.WithField("training_run", trainingRunName).Debug("too few checkpoints with images for a change curve")
+		return model.ChangeCurve{}, fmt.Errorf("images of two or more checkpoints not found for training run %s", trainingRunName)
+	}
+	position := make(map[string]int, len(checkpoints))
+	for i, cp := range checkpoints {
+		position[cp] = i
+	}
+
+	sums := make([]float64, len(checkpoints)-1)
+	pairs := make([]int, len(checkpoints)-1)
+	hashes := make(map[string]*changeHash)
+	for _, group := range groupVoteCandidates(candidates) {
+		byCheckpoint := make([]*model.VoteCandidate, len(checkpoints))
+		for i := range group {
+			c := &group[i]
+			if p := position[c.CheckpointFilename]; byCheckpoint[p] == nil {
+				byCheckpoint[p] = c
+			}
+		}
+		for i := 0; i+1 < len(checkpoints); i++ {
+			if byCheckpoint[i] == nil || byCheckpoint[i+1] == nil {
+				continue
+			}
+			a := s.hash(hashes, byCheckpoint[i].OutputPath)
+			b := s.hash(hashes, byCheckpoint[i+1].OutputPath)
+			if a == nil || b == nil {
+				continue
+			}
+			sums[i] += hashDistance(a, b)
+			pairs[i]++
+		}
+	}
+
+	curve := model.ChangeCurve{
+		TrainingRunName: trainingRunName,
+		Transitions:     make([]model.CheckpointTransition, len(checkpoints)-1),
+	}
+	var rates []float64
+	for i := range curve.Transitions {
+		t := model.CheckpointTransition{
+			FromCheckpoint: checkpoints[i],
+			ToCheckpoint:   checkpoints[i+1],
+			FromStep:       extractStepNumber(checkpoints[i]),
+			ToStep:         extractStepNumber(checkpoints[i+1]),
+			Pairs:          pairs[i],
+			Density:        model.SamplingDensityNormal,
+		}
+		if t.Pairs > 0 {
+			t.Difference = sums[i] / float64(t.Pairs)
+			t.Rate = t.Difference
+			if t.FromStep >= 0 && t.ToStep > t.FromStep {
+				t.Rate = t.Difference * 1000 / float64(t.ToStep-t.FromStep)
+			}
+			rates = append(rates, t.Rate)
+		}
+		curve.Transitions[i] = t
+	}
+
+	if len(rates) >= 2 {
+		sort.Float64s(rates)
+		median := rates[len(rates)/2]
+		if len(rates)%2 == 0 {
+			median = (rates[len(rates)/2-1] + rates[len(rates)/2]) / 2
+		}
+		for i := range curve.Transitions {
+			t := &curve.Transitions[i]
+			switch {
+			case t.Pairs == 0:
+			case t.Rate <= median*sparseRateFactor:
+				t.Density = model.SamplingDensitySparse
+			case t.Rate >= median*denseRateFactor:
+				t.Density = model.SamplingDensityDense
+			}
+		}
+	}
+	curve.SuggestedCheckpoints = suggestCheckpoints(checkpoints, curve.Transitions)
+
+	s.logger.WithFields(logrus.Fields{
+		"training_run":     trainingRunName,
+		"checkpoint_count": len(checkpoints),
+		"image_count":      len(hashes),
+		"suggested_count":  len(curve.SuggestedCheckpoints),
+	}).Debug("computed change curve")
+	return curve, nil
+}
+
+// hash returns the difference hash of the image at path, computing it on
+// first use. Returns nil if the image cannot be read or decoded.
+func (s *ChangeCurveService) hash(cache map[string]*changeHash, path string) *changeHash {
+	if h, ok := cache[path]; ok {
+		return h
+	}
+	cache[path] = nil
+	data, err := s.reader.ReadFile(path)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"output_path": path,
+			"error":       err.Error(),
+		}).Warn("failed to read image for change curve, skipping")
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"output_path": path,
+			"error":       err.Error(),
+		}).Warn("failed to decode image for change curve, skipping")
+		return nil
+	}
+	h := differenceHash(img)
+	cache[path] = &h
+	return &h
+}
+
+// differenceHash computes a difference hash of img: the image is averaged
+// down to a small grey grid and each bit records whether a cell is brighter
+// than its right neighbour. Similar-looking images have hashes that differ in
+// few bits, regardless of small pixel-level noise.
+func differenceHash(img image.Image) changeHash {
+	const cols, rows = changeHashSize + 1, changeHashSize
+	b := img.Bounds()
+	var sum [rows][cols]float64
+	var count [rows][cols]int
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		r := (y - b.Min.Y) * rows / b.Dy()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := (x - b.Min.X) * cols / b.Dx()
+			sum[r][c] += float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			count[r][c]++
+		}
+	}
+
+	var h changeHash
+	bit := 0
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols-1; c++ {
+			left, right := sum[r][c], sum[r][c+1]
+			if count[r][c] > 0 {
+				left /= float64(count[r][c])
+			}
+			if count[r][c+1] > 0 {
+				right /= float64(count[r][c+1])
+			}
+			if left > right {
+				h[bit/64] |= 1 << (bit % 64)
+			}
+			bit++
+		}
+	}
+	return h
+}
+
+// hashDistance is the fraction of bits that differ between two hashes.
+func hashDistance(a, b *changeHash) float64 {
+	differing := 0
+	for i := range a {
+		differing += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return float64(differing) / float64(len(a)*64)
+}
+
+// orderCheckpointsByStep returns the distinct checkpoints of candidates
+// ordered by step number. Checkpoints without a step number are final
+// checkpoints and come last; ties are ordered by filename.
+func orderCheckpointsByStep(candidates []model.VoteCandidate) []string {
+	seen := make(map[string]bool)
+	var checkpoints []string
+	for _, c := range candidates {
+		if !seen[c.CheckpointFilename] {
+			seen[c.CheckpointFilename] = true
+			checkpoints = append(checkpoints, c.CheckpointFilename)
+		}
+	}
+	sort.Slice(checkpoints, func(i, j int) bool {
+		si, sj := extractStepNumber(checkpoints[i]), extractStepNumber(checkpoints[j])
+		if (si < 0) != (sj < 0) {
+			return sj < 0
+		}
+		if si != sj {
+			return si < sj
+		}
+		return checkpoints[i] < checkpoints[j]
+	})
+	return checkpoints
+}
+
+// suggestCheckpoints keeps the first and last checkpoints and every
+// checkpoint that is not between two sparse transitions. Within a run of
+// sparse transitions every other checkpoint is kept, so that converged
+// stretches are thinned out rather than skipped.
+func suggestCheckpoints(checkpoints []string, transitions []model.CheckpointTransition) []string {
+	suggested := make([]string, 0, len(checkpoints))
+	keptPrevious := false
+	for i, cp := range checkpoints {
+		interior := i > 0 && i < len(checkpoints)-1
+		if interior && keptPrevious &&
+			transitions[i-1].Density == model.SamplingDensitySparse &&
+			transitions[i].Density == model.SamplingDensitySparse {
+			keptPrevious = false
+			continue
+		}
+		suggested = append(suggested, cp)
+		keptPrevious = true
+	}
+	return suggested
+}
@@ -0,0 +1,199 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand/v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeChangeCurveStore returns fixed completed images.
+type fakeChangeCurveStore struct {
+	candidates []model.VoteCandidate
+	err        error
+}
+
+func (f *fakeChangeCurveStore) ListVoteCandidates(ctx context.Context, trainingRunName string) ([]model.VoteCandidate, error) {
+	return f.candidates, f.err
+}
+
+// fakeImageFiles serves image files from memory.
+type fakeImageFiles map[string][]byte
+
+func (f fakeImageFiles) ReadFile(path string) ([]byte, error) {
+	data, ok := f[path]
+	if !ok {
+		return nil, errors.New("file does not exist")
+	}
+	return data, nil
+}
+
+// cellPattern is a grid of grey cells matching the change curve hash grid.
+type cellPattern [16][17]uint8
+
+// randomCellPattern returns a reproducible random pattern.
+func randomCellPattern(seed uint64) cellPattern {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	var p cellPattern
+	for r := range p {
+		for c := range p[r] {
+			p[r][c] = uint8(rng.IntN(256))
+		}
+	}
+	return p
+}
+
+// withNewRows returns p with its first n rows replaced by random cells.
+func (p cellPattern) withNewRows(n int, seed uint64) cellPattern {
+	fresh := randomCellPattern(seed)
+	for r := 0; r < n; r++ {
+		p[r] = fresh[r]
+	}
+	return p
+}
+
+// encodeCellPattern renders p as a PNG with 4x4 pixels per cell.
+func encodeCellPattern(p cellPattern) []byte {
+	img := image.NewGray(image.Rect(0, 0, 17*4, 16*4))
+	for y := 0; y < 16*4; y++ {
+		for x := 0; x < 17*4; x++ {
+			img.SetGray(x, y, color.Gray{Y: p[y/4][x/4]})
+		}
+	}
+	var buf bytes.Buffer
+	Expect(png.Encode(&buf, img)).To(Succeed())
+	return buf.Bytes()
+}
+
+var _ = Describe("ChangeCurveService", func() {
+	var (
+		store *fakeChangeCurveStore
+		files fakeImageFiles
+		svc   *service.ChangeCurveService
+	)
+
+	// addImage adds a completed image of checkpoint with the given seed.
+	addImage := func(checkpoint string, seed int64, p cellPattern) {
+		path := "/samples/" + checkpoint + "/" + string(rune('a'+seed)) + ".png"
+		store.candidates = append(store.candidates, model.VoteCandidate{
+			ItemID:             checkpoint + string(rune('a'+seed)),
+			CheckpointFilename: checkpoint,
+			PromptName:         "forest",
+			Steps:              20,
+			CFG:                4,
+			SamplerName:        "euler",
+			Scheduler:          "simple",
+			Seed:               seed,
+			Width:              1024,
+			Height:             1024,
+			OutputPath:         path,
+		})
+		files[path] = encodeCellPattern(p)
+	}
+
+	BeforeEach(func() {
+		store = &fakeChangeCurveStore{}
+		files = fakeImageFiles{}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewChangeCurveService(store, files, logger)
+	})
+
+	It("marks fast-changing stretches dense and converged stretches sparse", func() {
+		// Checkpoints are added out of order to check the step ordering;
+		// the final checkpoint has no step number and comes last.
+		for seed := int64(0); seed < 2; seed++ {
+			p1 := randomCellPattern(uint64(seed) + 1)
+			p2 := p1.withNewRows(10, uint64(seed)+100)
+			p3 := p2.withNewRows(2, uint64(seed)+200)
+			addImage("run-step00003000.safetensors", seed, p3)
+			addImage("run-step00001000.safetensors", seed, p1)
+			addImage("run.safetensors", seed, p3)
+			addImage("run-step00002000.safetensors", seed, p2)
+			addImage("run-step00004000.safetensors", seed, p3)
+		}
+
+		curve, err := svc.Curve(ctx, "run")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(curve.Transitions).To(HaveLen(4))
+
+		t := curve.Transitions
+		Expect(t[0].FromCheckpoint).To(Equal("run-step00001000.safetensors"))
+		Expect(t[0].FromStep).To(Equal(1000))
+		Expect(t[3].ToCheckpoint).To(Equal("run.safetensors"))
+		Expect(t[3].ToStep).To(Equal(-1))
+		for _, tr := range t {
+			Expect(tr.Pairs).To(Equal(2))
+		}
+
+		Expect(t[0].Difference).To(BeNumerically(">", t[1].Difference))
+		Expect(t[1].Difference).To(BeNumerically(">", 0))
+		Expect(t[2].Difference).To(BeZero())
+		Expect(t[3].Difference).To(BeZero())
+		Expect(t[0].Density).To(Equal(model.SamplingDensityDense))
+		Expect(t[2].Density).To(Equal(model.SamplingDensitySparse))
+		Expect(t[3].Density).To(Equal(model.SamplingDensitySparse))
+
+		// Inside the sparse stretch every other checkpoint is dropped.
+		Expect(curve.SuggestedCheckpoints).To(Equal([]string{
+			"run-step00001000.safetensors",
+			"run-step00002000.safetensors",
+			"run-step00003000.safetensors",
+			"run.safetensors",
+		}))
+	})
+
+	It("normalizes the difference by the step gap", func() {
+		p1 := randomCellPattern(1)
+		p2 := p1.withNewRows(8, 100)
+		p3 := p2.withNewRows(8, 200)
+		addImage("run-step00001000.safetensors", 0, p1)
+		addImage("run-step00002000.safetensors", 0, p2)
+		addImage("run-step00006000.safetensors", 0, p3)
+
+		curve, err := svc.Curve(ctx, "run")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(curve.Transitions[0].Rate).To(BeNumerically("~", curve.Transitions[0].Difference, 1e-9))
+		Expect(curve.Transitions[1].Rate).To(BeNumerically("~", curve.Transitions[1].Difference/4, 1e-9))
+	})
+
+	It("leaves out images that cannot be read", func() {
+		p := randomCellPattern(1)
+		addImage("run-step00001000.safetensors", 0, p)
+		addImage("run-step00002000.safetensors", 0, p)
+		addImage("run-step00001000.safetensors", 1, p)
+		addImage("run-step00002000.safetensors", 1, p)
+		delete(files, store.candidates[3].OutputPath)
+
+		curve, err := svc.Curve(ctx, "run")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(curve.Transitions).To(HaveLen(1))
+		Expect(curve.Transitions[0].Pairs).To(Equal(1))
+	})
+
+	It("returns a not found error when fewer than two checkpoints have images", func() {
+		addImage("run-step00001000.safetensors", 0, randomCellPattern(1))
+
+		_, err := svc.Curve(ctx, "run")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("not found"))
+	})
+
+	It("returns store errors", func() {
+		store.err = errors.New("database is locked")
+
+		_, err := svc.Curve(ctx, "run")
+		Expect(err).To(MatchError(ContainSubstring("database is locked")))
+	})
+})
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// CheckpointArchiveFileSystem lists and reads the files of a checkpoint's
+// sample directory.
+type CheckpointArchiveFileSystem interface {
+	ListFileUsage(root string) ([]model.FileUsage, error)
+	OpenFile(path string) (io.ReadCloser, error)
+}
+
+// CheckpointArchiveService streams the sample images of one checkpoint
+// directory, with their sidecars, as a ZIP archive.
+type CheckpointArchiveService struct {
+	fs        CheckpointArchiveFileSystem
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewCheckpointArchiveService creates a CheckpointArchiveService for the
+// sample directory.
+func NewCheckpointArchiveService(fs CheckpointArchiveFileSystem, sampleDir string, logger *logrus.Logger) *CheckpointArchiveService {
+	return &CheckpointArchiveService{
+		fs:        fs,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "checkpoint_archive"),
+	}
+}
+
+// Open returns a ZIP archive of the checkpoint directory named checkpoint
+// (e.g. "model-step00001000.safetensors") in dir, the directory relative to
+// the sample directory that holds it ("{training_run}/{study}" in the current
+// layout; empty for legacy root-level checkpoint directories). The archive
+// holds the sample images, including those in subdirectories written by an
+// output layout, and their sidecars, under a top-level directory named after
+// the checkpoint. Thumbnails are left out.
+//
+// The archive is written while it is read, so it is never held in memory. An
+// error reading a file after Open has returned is reported by Read. The
+// caller must close the reader, which stops the writing.
+func (s *CheckpointArchiveService) Open(dir, checkpoint string) (io.ReadCloser, error) {
+	s.logger.WithFields(logrus.Fields{
+		"dir":        dir,
+		"checkpoint": checkpoint,
+	}).Trace("entering Open")
+	defer s.logger.Trace("returning from Open")
+
+	if !isCheckpointDirName(checkpoint) || strings.ContainsAny(checkpoint, `/\`) {
+		return nil, fmt.Errorf("invalid checkpoint directory %q: expected a .safetensors filename", checkpoint)
+	}
+	if dir != "" {
+		for _, part := range strings.Split(dir, "/") {
+			if part == "" || part == "." || part == ".." || strings.Contains(part, `\`) {
+				return nil, fmt.Errorf("invalid checkpoint directory: dir %q must be a relative path within the sample directory", dir)
+			}
+		}
+	}
+	checkpointDir := filepath.Join(s.sampleDir, filepath.FromSlash(dir), checkpoint)
+
+	files, err := s.fs.ListFileUsage(checkpointDir)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list checkpoint sample files")
+		return nil, fmt.Errorf("listing sample files of %s: %w", checkpoint, err)
+	}
+	files = archiveFiles(files)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("checkpoint directory not found: no sample images in %s", path.Join(dir, checkpoint))
+	}
+	s.logger.WithFields(logrus.Fields{
+		"dir":        dir,
+		"checkpoint": checkpoint,
+		"file_count": len(files),
+	}).Debug("checkpoint archive files listed")
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.write(pw, checkpointDir, checkpoint, files))
+	}()
+	return pr, nil
+}
+
+// CheckpointArchiveFilename returns the download filename of the archive of
+// a checkpoint directory.
+func CheckpointArchiveFilename(checkpoint string) string {
+	name := strings.TrimSuffix(checkpoint, path.Ext(checkpoint))
+	name = strings.NewReplacer("/", "_", "\\", "_", `"`, "_").Replace(name)
+	return name + "-samples.zip"
+}
+
+// archiveFiles returns the sample images of files and the sidecars of those
+// images, in path order.
+func archiveFiles(files []model.FileUsage) []model.FileUsage {
+	images := make(map[string]bool)
+	for _, f := range files {
+		if model.IsSampleImageFile(f.Path) && !isThumbnailPath(f.Path) {
+			images[sidecarPathFor(f.Path)] = true
+		}
+	}
+	var result []model.FileUsage
+	for _, f := range files {
+		if isThumbnailPath(f.Path) {
+			continue
+		}
+		if model.IsSampleImageFile(f.Path) || images[f.Path] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// write writes the ZIP archive of files, relative to checkpointDir, to w.
+// Images are stored as they are since they are already compressed; sidecars
+// are deflated. Files removed since they were listed are skipped.
+func (s *CheckpointArchiveService) write(w io.Writer, checkpointDir, checkpoint string, files []model.FileUsage) error {
+	zw := zip.NewWriter(w)
+	written := 0
+	for _, f := range files {
+		src, err := s.fs.OpenFile(filepath.Join(checkpointDir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				s.logger.WithField("path", f.Path).Warn("sample file removed while archiving, skipping")
+				continue
+			}
+			s.logger.WithFields(logrus.Fields{
+				"path":  f.Path,
+				"error": err.Error(),
+			}).Error("failed to open sample file for archive")
+			return fmt.Errorf("opening %s: %w", f.Path, err)
+		}
+		method := zip.Store
+		if !model.IsSampleImageFile(f.Path) {
+			method = zip.Deflate
+		}
+		dst, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     checkpoint + "/" + f.Path,
+			Method:   method,
+			Modified: f.ModTime,
+		})
+		if err == nil {
+			_, err = io.Copy(dst, src)
+		}
+		src.Close()
+		if err != nil {
+			// The client usually went away; the pipe is closed.
+			s.logger.WithFields(logrus.Fields{
+				"path":  f.Path,
+				"error": err.Error(),
+			}).Debug("checkpoint archive aborted")
+			return fmt.Errorf("writing %s to archive: %w", f.Path, err)
+		}
+		written++
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finishing archive: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"checkpoint": checkpoint,
+		"file_count": written,
+	}).Debug("checkpoint archive written")
+	return nil
+}
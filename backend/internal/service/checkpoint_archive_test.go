@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeCheckpointArchiveFS is an in-memory test double for
+// service.CheckpointArchiveFileSystem. contents is keyed by absolute path.
+type fakeCheckpointArchiveFS struct {
+	files      []model.FileUsage
+	contents   map[string]string
+	listedRoot string
+}
+
+func (f *fakeCheckpointArchiveFS) ListFileUsage(root string) ([]model.FileUsage, error) {
+	f.listedRoot = root
+	return f.files, nil
+}
+
+func (f *fakeCheckpointArchiveFS) OpenFile(path string) (io.ReadCloser, error) {
+	data, ok := f.contents[path]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+var _ = Describe("CheckpointArchiveService", func() {
+	const checkpointDir = "/samples/run/study/model-step00001000.safetensors"
+
+	var (
+		archiveFS *fakeCheckpointArchiveFS
+		svc       *service.CheckpointArchiveService
+	)
+
+	modTime := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		archiveFS = &fakeCheckpointArchiveFS{
+			files: []model.FileUsage{
+				{Path: ".thumbnails/seed=1&_00001_.jpg", ModTime: modTime},
+				{Path: "forest/cfg7_seed2.png", ModTime: modTime},
+				{Path: "forest/cfg7_seed2.json", ModTime: modTime},
+				{Path: "notes.json", ModTime: modTime},
+				{Path: "seed=1&_00001_.json", ModTime: modTime},
+				{Path: "seed=1&_00001_.png", ModTime: modTime},
+				{Path: "thumbnails/seed=1&_00001_.jpg", ModTime: modTime},
+			},
+			contents: map[string]string{
+				checkpointDir + "/forest/cfg7_seed2.png":  "png-2",
+				checkpointDir + "/forest/cfg7_seed2.json": `{"seed":2}`,
+				checkpointDir + "/seed=1&_00001_.json":    `{"seed":1}`,
+				checkpointDir + "/seed=1&_00001_.png":     "png-1",
+			},
+		}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewCheckpointArchiveService(archiveFS, "/samples", logger)
+	})
+
+	readArchive := func(r io.ReadCloser) map[string]string {
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		Expect(err).NotTo(HaveOccurred())
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		Expect(err).NotTo(HaveOccurred())
+		entries := make(map[string]string)
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			Expect(err).NotTo(HaveOccurred())
+			content, err := io.ReadAll(rc)
+			Expect(err).NotTo(HaveOccurred())
+			rc.Close()
+			entries[f.Name] = string(content)
+			Expect(f.Modified.Equal(modTime)).To(BeTrue())
+		}
+		return entries
+	}
+
+	It("archives the images and their sidecars under the checkpoint's name", func() {
+		r, err := svc.Open("run/study", "model-step00001000.safetensors")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(readArchive(r)).To(Equal(map[string]string{
+			"model-step00001000.safetensors/forest/cfg7_seed2.png":  "png-2",
+			"model-step00001000.safetensors/forest/cfg7_seed2.json": `{"seed":2}`,
+			"model-step00001000.safetensors/seed=1&_00001_.json":    `{"seed":1}`,
+			"model-step00001000.safetensors/seed=1&_00001_.png":     "png-1",
+		}))
+		Expect(archiveFS.listedRoot).To(Equal(checkpointDir))
+	})
+
+	It("reads legacy root-level checkpoint directories", func() {
+		archiveFS.files = []model.FileUsage{{Path: "a.png", ModTime: modTime}}
+		archiveFS.contents = map[string]string{"/samples/model.safetensors/a.png": "png"}
+
+		r, err := svc.Open("", "model.safetensors")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readArchive(r)).To(Equal(map[string]string{"model.safetensors/a.png": "png"}))
+		Expect(archiveFS.listedRoot).To(Equal("/samples/model.safetensors"))
+	})
+
+	It("skips files removed after they were listed", func() {
+		delete(archiveFS.contents, checkpointDir+"/forest/cfg7_seed2.png")
+
+		r, err := svc.Open("run/study", "model-step00001000.safetensors")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readArchive(r)).NotTo(HaveKey("model-step00001000.safetensors/forest/cfg7_seed2.png"))
+	})
+
+	It("reports a checkpoint directory without images as not found", func() {
+		archiveFS.files = []model.FileUsage{{Path: "thumbnails/a.jpg", ModTime: modTime}}
+
+		_, err := svc.Open("run/study", "model-step00001000.safetensors")
+		Expect(err).To(MatchError(HavePrefix("checkpoint directory not found")))
+	})
+
+	DescribeTable("rejects invalid directories",
+		func(dir, checkpoint string) {
+			_, err := svc.Open(dir, checkpoint)
+			Expect(err).To(MatchError(HavePrefix("invalid checkpoint directory")))
+			Expect(archiveFS.listedRoot).To(BeEmpty())
+		},
+		Entry("checkpoint without the .safetensors extension", "run/study", "model"),
+		Entry("checkpoint with a path", "run", "study/model.safetensors"),
+		Entry("checkpoint traversing out", "run/study", "../model.safetensors"),
+		Entry("dir traversing out", "../outside", "model.safetensors"),
+		Entry("absolute dir", "/etc", "model.safetensors"),
+		Entry("dir with a backslash", `run\..\..`, "model.safetensors"),
+	)
+
+	It("stops writing when the reader is closed", func() {
+		r, err := svc.Open("run/study", "model-step00001000.safetensors")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Close()).To(Succeed())
+
+		_, err = r.Read(make([]byte, 1))
+		Expect(errors.Is(err, io.ErrClosedPipe)).To(BeTrue())
+	})
+
+	It("names the download after the checkpoint", func() {
+		Expect(service.CheckpointArchiveFilename("model-step00001000.safetensors")).To(Equal("model-step00001000-samples.zip"))
+	})
+})
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+const (
+	// checkpointHashPrefixBytes is how much of the start of a checkpoint file
+	// is hashed. Together with the file size it tells checkpoints apart
+	// without reading files of several gigabytes.
+	checkpointHashPrefixBytes = 16 << 20
+
+	// checkpointHashInterval is how often the background hashing looks for
+	// new or changed checkpoints.
+	checkpointHashInterval = 10 * time.Minute
+)
+
+// CheckpointHashDiscovery discovers the checkpoints to hash.
+type CheckpointHashDiscovery interface {
+	Discover(ctx context.Context) ([]model.TrainingRun, error)
+}
+
+// CheckpointHashStore persists checkpoint hashes.
+type CheckpointHashStore interface {
+	ListCheckpointHashes(ctx context.Context) ([]model.CheckpointHash, error)
+	SaveCheckpointHash(ctx context.Context, h model.CheckpointHash) error
+	DeleteCheckpointHash(ctx context.Context, path string) error
+}
+
+// CheckpointHashFileSystem reads checkpoint files.
+type CheckpointHashFileSystem interface {
+	StatFile(path string) (int64, time.Time, error)
+	OpenFile(path string) (io.ReadCloser, error)
+}
+
+// CheckpointHashService computes a fast hash of every discovered checkpoint
+// in the background and reports checkpoints that appear under more than one
+// training run or filename. A stored hash is reused while the checkpoint's
+// size and modification time are unchanged.
+type CheckpointHashService struct {
+	discovery      CheckpointHashDiscovery
+	store          CheckpointHashStore
+	fs             CheckpointHashFileSystem
+	mu             sync.RWMutex
+	checkpointDirs []string
+	// hashMu serializes passes of HashAll.
+	hashMu sync.Mutex
+	// warned holds the duplicate groups already logged, by hash, so that a
+	// duplicate is warned about once rather than on every pass.
+	warned map[string]string
+	logger *logrus.Entry
+}
+
+// NewCheckpointHashService creates a CheckpointHashService.
+func NewCheckpointHashService(discovery CheckpointHashDiscovery, store CheckpointHashStore, fs CheckpointHashFileSystem, checkpointDirs []string, logger *logrus.Logger) *CheckpointHashService {
+	return &CheckpointHashService{
+		discovery:      discovery,
+		store:          store,
+		fs:             fs,
+		checkpointDirs: checkpointDirs,
+		warned:         make(map[string]string),
+		logger:         logger.WithField("component", "checkpoint_hash"),
+	}
+}
+
+// SetCheckpointDirs replaces the directories that checkpoints are resolved
+// against. It is called when the configuration is reloaded.
+func (s *CheckpointHashService) SetCheckpointDirs(dirs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpointDirs = dirs
+}
+
+// HashAll hashes the discovered checkpoints that have no current hash, drops
+// the hashes of checkpoints that are no longer discovered, and logs a warning
+// for each new group of duplicates. A checkpoint that cannot be read is
+// logged and skipped.
+func (s *CheckpointHashService) HashAll(ctx context.Context) error {
+	s.logger.Trace("entering HashAll")
+	defer s.logger.Trace("returning from HashAll")
+
+	s.hashMu.Lock()
+	defer s.hashMu.Unlock()
+
+	checkpoints, err := s.discover(ctx)
+	if err != nil {
+		return err
+	}
+	stored, err := s.storedHashes(ctx)
+	if err != nil {
+		return err
+	}
+
+	hashed := 0
+	current := make(map[string]model.CheckpointHash, len(checkpoints))
+	for _, c := range checkpoints {
+		if _, ok := current[c.path]; ok {
+			continue // the same file under several training runs
+		}
+		size, modTime, err := s.fs.StatFile(c.path)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"path":  c.path,
+				"error": err.Error(),
+			}).Warn("failed to stat checkpoint")
+			continue
+		}
+		if h, ok := stored[c.path]; ok && h.Size == size && h.ModTime.Equal(modTime) {
+			current[c.path] = h
+			continue
+		}
+		hash, err := s.hashFile(c.path, size)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"path":  c.path,
+				"error": err.Error(),
+			}).Warn("failed to hash checkpoint")
+			continue
+		}
+		h := model.CheckpointHash{Path: c.path, Size: size, ModTime: modTime, Hash: hash, ComputedAt: time.Now().UTC()}
+		if err := s.store.SaveCheckpointHash(ctx, h); err != nil {
+			return fmt.Errorf("saving checkpoint hash: %w", err)
+		}
+		current[c.path] = h
+		hashed++
+	}
+
+	removed := 0
+	for path := range stored {
+		if _, ok := current[path]; ok {
+			continue
+		}
+		if err := s.store.DeleteCheckpointHash(ctx, path); err != nil {
+			return fmt.Errorf("deleting checkpoint hash: %w", err)
+		}
+		removed++
+	}
+
+	s.warnDuplicates(findCheckpointDuplicates(hashedCheckpoints(checkpoints, current)))
+	s.logger.WithFields(logrus.Fields{
+		"checkpoints": len(checkpoints),
+		"hashed":      hashed,
+		"removed":     removed,
+	}).Debug("checkpoint hashes updated")
+	return nil
+}
+
+// Report returns the discovered checkpoints with their hashes and the
+// duplicates among them. A checkpoint whose stored hash is missing or stale
+// has an empty hash and is counted as pending.
+func (s *CheckpointHashService) Report(ctx context.Context) (model.CheckpointHashReport, error) {
+	s.logger.Trace("entering Report")
+	defer s.logger.Trace("returning from Report")
+
+	checkpoints, err := s.discover(ctx)
+	if err != nil {
+		return model.CheckpointHashReport{}, err
+	}
+	stored, err := s.storedHashes(ctx)
+	if err != nil {
+		return model.CheckpointHashReport{}, err
+	}
+
+	current := make(map[string]model.CheckpointHash, len(checkpoints))
+	sizes := make(map[string]int64, len(checkpoints))
+	for _, c := range checkpoints {
+		if _, ok := sizes[c.path]; ok {
+			continue
+		}
+		size, modTime, err := s.fs.StatFile(c.path)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"path":  c.path,
+				"error": err.Error(),
+			}).Debug("failed to stat checkpoint")
+			continue
+		}
+		sizes[c.path] = size
+		if h, ok := stored[c.path]; ok && h.Size == size && h.ModTime.Equal(modTime) {
+			current[c.path] = h
+		}
+	}
+
+	report := model.CheckpointHashReport{
+		Checkpoints: hashedCheckpoints(checkpoints, current),
+		Duplicates:  []model.CheckpointDuplicate{},
+	}
+	for i := range report.Checkpoints {
+		report.Checkpoints[i].Size = sizes[checkpoints[i].path]
+		if report.Checkpoints[i].Hash == "" {
+			report.PendingCount++
+		}
+	}
+	report.Duplicates = append(report.Duplicates, findCheckpointDuplicates(report.Checkpoints)...)
+
+	s.logger.WithFields(logrus.Fields{
+		"checkpoints": len(report.Checkpoints),
+		"duplicates":  len(report.Duplicates),
+		"pending":     report.PendingCount,
+	}).Debug("checkpoint hash report built")
+	return report, nil
+}
+
+// Run hashes the checkpoints immediately and then at every
+// checkpointHashInterval until stop is closed.
+func (s *CheckpointHashService) Run(stop <-chan struct{}) {
+	s.logger.Trace("entering Run")
+	defer s.logger.Trace("returning from Run")
+
+	ticker := time.NewTicker(checkpointHashInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.HashAll(context.Background()); err != nil {
+			s.logger.WithError(err).Error("failed to hash checkpoints")
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// discoveredCheckpoint is a discovered checkpoint with its absolute path.
+type discoveredCheckpoint struct {
+	trainingRun string
+	checkpoint  model.Checkpoint
+	path        string
+}
+
+// discover returns the discovered checkpoints ordered by training run, then
+// as discovered. Checkpoints whose directory is no longer configured are
+// left out.
+func (s *CheckpointHashService) discover(ctx context.Context) ([]discoveredCheckpoint, error) {
+	runs, err := s.discovery.Discover(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to discover training runs")
+		return nil, fmt.Errorf("discovering training runs: %w", err)
+	}
+	runs = append([]model.TrainingRun(nil), runs...) // discovery may cache its result
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Name < runs[j].Name })
+
+	s.mu.RLock()
+	dirs := s.checkpointDirs
+	s.mu.RUnlock()
+
+	var checkpoints []discoveredCheckpoint
+	for _, run := range runs {
+		for _, cp := range run.Checkpoints {
+			if cp.CheckpointDirIndex < 0 || cp.CheckpointDirIndex >= len(dirs) {
+				continue
+			}
+			checkpoints = append(checkpoints, discoveredCheckpoint{
+				trainingRun: run.Name,
+				checkpoint:  cp,
+				path:        filepath.Join(dirs[cp.CheckpointDirIndex], filepath.FromSlash(cp.RelativePath)),
+			})
+		}
+	}
+	return checkpoints, nil
+}
+
+// storedHashes returns the stored hashes by path.
+func (s *CheckpointHashService) storedHashes(ctx context.Context) (map[string]model.CheckpointHash, error) {
+	hashes, err := s.store.ListCheckpointHashes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing checkpoint hashes: %w", err)
+	}
+	byPath := make(map[string]model.CheckpointHash, len(hashes))
+	for _, h := range hashes {
+		byPath[h.Path] = h
+	}
+	return byPath, nil
+}
+
+// hashFile returns the hex-encoded xxHash64 of the first
+// checkpointHashPrefixBytes of the file at path followed by its size.
+func (s *CheckpointHashService) hashFile(path string, size int64) (string, error) {
+	f, err := s.fs.OpenFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	d := xxhash.New()
+	if _, err := io.Copy(d, io.LimitReader(f, checkpointHashPrefixBytes)); err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	var sizeBytes [8]byte
+	binary.LittleEndian.PutUint64(sizeBytes[:], uint64(size))
+	d.Write(sizeBytes[:])
+	return fmt.Sprintf("%016x", d.Sum64()), nil
+}
+
+// warnDuplicates logs a warning for each duplicate group that has not been
+// logged in the same shape before.
+func (s *CheckpointHashService) warnDuplicates(duplicates []model.CheckpointDuplicate) {
+	seen := make(map[string]string, len(duplicates))
+	for _, d := range duplicates {
+		names := make([]string, len(d.Checkpoints))
+		for i, c := range d.Checkpoints {
+			names[i] = c.TrainingRun + "/" + c.Filename
+		}
+		key := strings.Join(names, ", ")
+		seen[d.Hash] = key
+		if s.warned[d.Hash] == key {
+			continue
+		}
+		s.logger.WithFields(logrus.Fields{
+			"hash":        d.Hash,
+			"checkpoints": key,
+		}).Warn("the same checkpoint appears under several training runs or filenames")
+	}
+	s.warned = seen
+}
+
+// hashedCheckpoints pairs the discovered checkpoints with their current
+// hashes, keyed by path.
+func hashedCheckpoints(checkpoints []discoveredCheckpoint, hashes map[string]model.CheckpointHash) []model.HashedCheckpoint {
+	hashed := make([]model.HashedCheckpoint, len(checkpoints))
+	for i, c := range checkpoints {
+		h := hashes[c.path]
+		hashed[i] = model.HashedCheckpoint{
+			TrainingRun:        c.trainingRun,
+			Filename:           c.checkpoint.Filename,
+			RelativePath:       c.checkpoint.RelativePath,
+			CheckpointDirIndex: c.checkpoint.CheckpointDirIndex,
+			Size:               h.Size,
+			Hash:               h.Hash,
+		}
+	}
+	return hashed
+}
+
+// findCheckpointDuplicates groups the hashed checkpoints by hash and returns,
+// ordered by hash, the groups that are more than one file and span more than
+// one training run or filename. The same file listed under two training runs
+// is not a duplicate.
+func findCheckpointDuplicates(checkpoints []model.HashedCheckpoint) []model.CheckpointDuplicate {
+	byHash := make(map[string][]model.HashedCheckpoint)
+	for _, c := range checkpoints {
+		if c.Hash != "" {
+			byHash[c.Hash] = append(byHash[c.Hash], c)
+		}
+	}
+
+	var duplicates []model.CheckpointDuplicate
+	for hash, group := range byHash {
+		files := make(map[string]bool)
+		runs := make(map[string]bool)
+		filenames := make(map[string]bool)
+		for _, c := range group {
+			files[fmt.Sprintf("%d:%s", c.CheckpointDirIndex, c.RelativePath)] = true
+			runs[c.TrainingRun] = true
+			filenames[c.Filename] = true
+		}
+		if len(files) < 2 || (len(runs) < 2 && len(filenames) < 2) {
+			continue
+		}
+		duplicates = append(duplicates, model.CheckpointDuplicate{Hash: hash, Checkpoints: group})
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Hash < duplicates[j].Hash })
+	return duplicates
+}
@@ -0,0 +1,204 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeHashStore keeps checkpoint hashes in memory.
+type fakeHashStore struct {
+	hashes map[string]model.CheckpointHash
+	saved  int
+}
+
+func (f *fakeHashStore) ListCheckpointHashes(ctx context.Context) ([]model.CheckpointHash, error) {
+	var hashes []model.CheckpointHash
+	for _, h := range f.hashes {
+		hashes = append(hashes, h)
+	}
+	return hashes, nil
+}
+
+func (f *fakeHashStore) SaveCheckpointHash(ctx context.Context, h model.CheckpointHash) error {
+	f.hashes[h.Path] = h
+	f.saved++
+	return nil
+}
+
+func (f *fakeHashStore) DeleteCheckpointHash(ctx context.Context, path string) error {
+	delete(f.hashes, path)
+	return nil
+}
+
+// fakeCheckpointFile is the content and modification time of a file.
+type fakeCheckpointFile struct {
+	content string
+	modTime time.Time
+}
+
+// fakeCheckpointFiles serves checkpoint files from memory, keyed by path.
+type fakeCheckpointFiles map[string]fakeCheckpointFile
+
+func (f fakeCheckpointFiles) StatFile(path string) (int64, time.Time, error) {
+	file, ok := f[path]
+	if !ok {
+		return 0, time.Time{}, os.ErrNotExist
+	}
+	return int64(len(file.content)), file.modTime, nil
+}
+
+func (f fakeCheckpointFiles) OpenFile(path string) (io.ReadCloser, error) {
+	file, ok := f[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(strings.NewReader(file.content)), nil
+}
+
+var _ = Describe("CheckpointHashService", func() {
+	var (
+		discovery *fakeSummaryDiscovery
+		st        *fakeHashStore
+		files     fakeCheckpointFiles
+		hook      *test.Hook
+		svc       *service.CheckpointHashService
+		modTime   time.Time
+	)
+
+	BeforeEach(func() {
+		modTime = time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+		discovery = &fakeSummaryDiscovery{runs: []model.TrainingRun{
+			{Name: "run-b", Checkpoints: []model.Checkpoint{
+				{Filename: "copy.safetensors", RelativePath: "run-b/copy.safetensors", CheckpointDirIndex: 1},
+			}},
+			{Name: "run-a", Checkpoints: []model.Checkpoint{
+				{Filename: "a-step00001000.safetensors", RelativePath: "run-a/a-step00001000.safetensors"},
+				{Filename: "a.safetensors", RelativePath: "run-a/a.safetensors"},
+			}},
+		}}
+		st = &fakeHashStore{hashes: map[string]model.CheckpointHash{}}
+		files = fakeCheckpointFiles{
+			"/ckpt/run-a/a-step00001000.safetensors": {content: "weights-1000", modTime: modTime},
+			"/ckpt/run-a/a.safetensors":              {content: "weights-final", modTime: modTime},
+			"/more/run-b/copy.safetensors":           {content: "weights-1000", modTime: modTime},
+		}
+		var logger *logrus.Logger
+		logger, hook = test.NewNullLogger()
+		svc = service.NewCheckpointHashService(discovery, st, files, []string{"/ckpt", "/more"}, logger)
+	})
+
+	warnings := func() int {
+		n := 0
+		for _, e := range hook.AllEntries() {
+			if e.Level == logrus.WarnLevel {
+				n++
+			}
+		}
+		return n
+	}
+
+	It("hashes the discovered checkpoints and reports duplicates across training runs", func() {
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		Expect(st.hashes).To(HaveLen(3))
+
+		report, err := svc.Report(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.PendingCount).To(BeZero())
+		Expect(report.Checkpoints).To(HaveLen(3))
+		Expect(report.Checkpoints[0].TrainingRun).To(Equal("run-a"))
+		Expect(report.Checkpoints[0].Size).To(Equal(int64(len("weights-1000"))))
+		Expect(report.Checkpoints[0].Hash).To(HaveLen(16))
+		Expect(report.Checkpoints[1].Hash).NotTo(Equal(report.Checkpoints[0].Hash))
+
+		Expect(report.Duplicates).To(HaveLen(1))
+		Expect(report.Duplicates[0].Hash).To(Equal(report.Checkpoints[0].Hash))
+		Expect(report.Duplicates[0].Checkpoints).To(HaveLen(2))
+		Expect(report.Duplicates[0].Checkpoints[0].Filename).To(Equal("a-step00001000.safetensors"))
+		Expect(report.Duplicates[0].Checkpoints[1].TrainingRun).To(Equal("run-b"))
+		Expect(warnings()).To(Equal(1))
+	})
+
+	It("warns about a duplicate only once", func() {
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		Expect(warnings()).To(Equal(1))
+	})
+
+	It("reuses stored hashes while size and modification time are unchanged", func() {
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		Expect(st.saved).To(Equal(3))
+
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		Expect(st.saved).To(Equal(3))
+
+		files["/ckpt/run-a/a.safetensors"] = fakeCheckpointFile{content: "weights-final", modTime: modTime.Add(time.Second)}
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		Expect(st.saved).To(Equal(4))
+	})
+
+	It("reports changed checkpoints as pending until they are hashed again", func() {
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		files["/more/run-b/copy.safetensors"] = fakeCheckpointFile{content: "retrained", modTime: modTime.Add(time.Second)}
+
+		report, err := svc.Report(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.PendingCount).To(Equal(1))
+		Expect(report.Checkpoints[2].Hash).To(BeEmpty())
+		Expect(report.Checkpoints[2].Size).To(Equal(int64(len("retrained"))))
+		Expect(report.Duplicates).To(BeEmpty())
+	})
+
+	It("does not treat the same file under two training runs as a duplicate", func() {
+		discovery.runs[0].Checkpoints[0] = model.Checkpoint{Filename: "a.safetensors", RelativePath: "run-a/a.safetensors"}
+		Expect(svc.HashAll(ctx)).To(Succeed())
+
+		report, err := svc.Report(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Checkpoints).To(HaveLen(3))
+		Expect(report.Duplicates).To(BeEmpty())
+		Expect(warnings()).To(BeZero())
+	})
+
+	It("drops the hashes of checkpoints that are no longer discovered", func() {
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		discovery.runs = discovery.runs[1:]
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		Expect(st.hashes).To(HaveLen(2))
+		Expect(st.hashes).NotTo(HaveKey("/more/run-b/copy.safetensors"))
+	})
+
+	It("skips checkpoints that cannot be read", func() {
+		delete(files, "/ckpt/run-a/a.safetensors")
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		Expect(st.hashes).To(HaveLen(2))
+
+		report, err := svc.Report(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.PendingCount).To(Equal(1))
+	})
+
+	It("resolves checkpoints against reloaded checkpoint directories", func() {
+		svc.SetCheckpointDirs([]string{"/ckpt"})
+		Expect(svc.HashAll(ctx)).To(Succeed())
+		Expect(st.hashes).To(HaveLen(2))
+	})
+
+	It("returns discovery errors", func() {
+		discovery.err = errors.New("disk gone")
+		Expect(svc.HashAll(ctx)).To(MatchError(ContainSubstring("disk gone")))
+		_, err := svc.Report(ctx)
+		Expect(err).To(MatchError(ContainSubstring("disk gone")))
+	})
+})
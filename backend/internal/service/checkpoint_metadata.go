@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
@@ -21,6 +22,7 @@ type CheckpointMetadataReader interface {
 // CheckpointMetadataService parses safetensors file headers to extract training metadata.
 type CheckpointMetadataService struct {
 	reader         CheckpointMetadataReader
+	mu             sync.RWMutex
 	checkpointDirs []string
 	logger         *logrus.Entry
 }
@@ -34,6 +36,14 @@ func NewCheckpointMetadataService(reader CheckpointMetadataReader, checkpointDir
 	}
 }
 
+// SetCheckpointDirs replaces the directories that filenames are resolved
+// against. It is called when the configuration is reloaded.
+func (s *CheckpointMetadataService) SetCheckpointDirs(dirs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpointDirs = dirs
+}
+
 // GetMetadata reads the safetensors header for the given filename and returns
 // ss_* metadata fields. The filename is resolved against checkpoint_dirs.
 // Returns an empty map (not an error) when no ss_* fields are present.
@@ -48,7 +58,7 @@ func (s *CheckpointMetadataService) GetMetadata(filename string) (map[string]str
 	}
 
 	// Find the file across checkpoint_dirs
-	filePath, err := s.resolveCheckpointFile(filename)
+	filePath, err := s.ResolveCheckpointFile(filename)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"filename": filename,
@@ -78,9 +88,18 @@ func (s *CheckpointMetadataService) GetMetadata(filename string) (map[string]str
 	return metadata, nil
 }
 
-// resolveCheckpointFile finds the first matching checkpoint file across all checkpoint_dirs.
-func (s *CheckpointMetadataService) resolveCheckpointFile(filename string) (string, error) {
-	for _, dir := range s.checkpointDirs {
+// ResolveCheckpointFile finds the first matching checkpoint file across all
+// checkpoint_dirs and returns its path.
+func (s *CheckpointMetadataService) ResolveCheckpointFile(filename string) (string, error) {
+	if !isFilenameSafe(filename) {
+		return "", fmt.Errorf("invalid filename: %q", filename)
+	}
+
+	s.mu.RLock()
+	checkpointDirs := s.checkpointDirs
+	s.mu.RUnlock()
+
+	for _, dir := range checkpointDirs {
 		// Walk the directory to find the file
 		var found string
 		_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
@@ -3,7 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
+	"path"
+	"regexp"
+	"strings"
 
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/sirupsen/logrus"
 )
 
@@ -12,9 +16,23 @@ type ComfyUIModelsProvider interface {
 	GetModels(ctx context.Context, modelType ComfyUIModelType) ([]string, error)
 }
 
+// ComfyUIModelsRefresher re-reads ComfyUI's model list, bypassing any cache.
+// It is satisfied by ComfyUIModelCache.
+type ComfyUIModelsRefresher interface {
+	RefreshModels(ctx context.Context, modelType ComfyUIModelType) ([]string, error)
+}
+
+// checkpointPathRule is a model.CheckpointPathRule with its pattern compiled.
+type checkpointPathRule struct {
+	model.CheckpointPathRule
+	pattern *regexp.Regexp
+}
+
 // CheckpointPathMatcher matches checkpoint filenames to ComfyUI model paths.
 type CheckpointPathMatcher struct {
 	modelsProvider ComfyUIModelsProvider
+	refresher      ComfyUIModelsRefresher // optional
+	rules          []checkpointPathRule
 	logger         *logrus.Entry
 }
 
@@ -26,45 +44,154 @@ func NewCheckpointPathMatcher(modelsProvider ComfyUIModelsProvider, logger *logr
 	}
 }
 
+// SetModelsRefresher sets the refresher used to re-read ComfyUI's model list
+// once when a checkpoint matches none of the models of a cached list, e.g.
+// because it was copied into ComfyUI's model directory since. This is
+// optional; if not set, unmatched checkpoints fail right away.
+func (m *CheckpointPathMatcher) SetModelsRefresher(refresher ComfyUIModelsRefresher) {
+	m.refresher = refresher
+}
+
+// SetMappingRules sets the rules that rewrite a checkpoint filename, in
+// order, before it is matched against ComfyUI's model list.
+func (m *CheckpointPathMatcher) SetMappingRules(rules []model.CheckpointPathRule) error {
+	compiled := make([]checkpointPathRule, 0, len(rules))
+	for i, r := range rules {
+		rule := checkpointPathRule{CheckpointPathRule: r}
+		if r.Pattern != "" {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return fmt.Errorf("compiling path mapping rule %d: %w", i, err)
+			}
+			rule.pattern = re
+		}
+		compiled = append(compiled, rule)
+	}
+	m.rules = compiled
+	return nil
+}
+
 // MatchCheckpointPath queries ComfyUI for available UNETs and finds a matching path by filename.
 // Returns the ComfyUI-relative model path, or an error if no match is found.
-func (m *CheckpointPathMatcher) MatchCheckpointPath(filename string) (string, error) {
+func (m *CheckpointPathMatcher) MatchCheckpointPath(trainingRunName, filename string) (string, error) {
 	m.logger.WithField("checkpoint_filename", filename).Trace("entering MatchCheckpointPath")
 	defer m.logger.Trace("returning from MatchCheckpointPath")
 
-	// Query ComfyUI for available UNET models
-	ctx := context.Background()
-	models, err := m.modelsProvider.GetModels(ctx, ComfyUIModelTypeUNET)
+	models, err := m.fetchModels(context.Background())
 	if err != nil {
-		m.logger.WithFields(logrus.Fields{
-			"checkpoint_filename": filename,
-			"error":               err.Error(),
-		}).Error("failed to query ComfyUI for UNET models")
-		return "", fmt.Errorf("querying ComfyUI models: %w", err)
+		return "", err
 	}
 	m.logger.WithFields(logrus.Fields{
 		"checkpoint_filename": filename,
 		"model_count":         len(models),
 	}).Debug("fetched UNET models from ComfyUI")
 
-	// Match by exact filename (ComfyUI paths may have directory prefixes)
-	for _, modelPath := range models {
-		// Check if the modelPath ends with the checkpoint filename
-		// (handles cases where ComfyUI returns "subdirectory/filename.safetensors")
-		if endsWithFilename(modelPath, filename) {
-			m.logger.WithFields(logrus.Fields{
-				"checkpoint_filename": filename,
-				"comfyui_path":        modelPath,
-			}).Debug("matched checkpoint to ComfyUI model path")
-			return modelPath, nil
+	match := m.match(models, trainingRunName, filename)
+	if match.ModelPath == "" && m.refresher != nil {
+		if models, err = m.refreshModels(context.Background()); err != nil {
+			return "", err
 		}
+		match = m.match(models, trainingRunName, filename)
+	}
+	if match.ModelPath == "" {
+		m.logger.WithFields(logrus.Fields{
+			"checkpoint_filename": filename,
+			"mapped_path":         match.MappedPath,
+			"model_count":         len(models),
+		}).Debug("no matching ComfyUI model found for checkpoint")
+		if match.MappedPath != filename {
+			return "", fmt.Errorf("checkpoint %s (mapped to %s) not found in ComfyUI UNET models", filename, match.MappedPath)
+		}
+		return "", fmt.Errorf("checkpoint %s not found in ComfyUI UNET models", filename)
 	}
-
 	m.logger.WithFields(logrus.Fields{
 		"checkpoint_filename": filename,
-		"model_count":         len(models),
-	}).Debug("no matching ComfyUI model found for checkpoint")
-	return "", fmt.Errorf("checkpoint %s not found in ComfyUI UNET models", filename)
+		"comfyui_path":        match.ModelPath,
+	}).Debug("matched checkpoint to ComfyUI model path")
+	return match.ModelPath, nil
+}
+
+// PreviewMatches resolves each of filenames as MatchCheckpointPath would,
+// querying ComfyUI's model list once. Unmatched checkpoints have an empty
+// ModelPath.
+func (m *CheckpointPathMatcher) PreviewMatches(ctx context.Context, trainingRunName string, filenames []string) ([]model.CheckpointMatch, error) {
+	m.logger.WithFields(logrus.Fields{
+		"training_run_name": trainingRunName,
+		"checkpoint_count":  len(filenames),
+	}).Trace("entering PreviewMatches")
+	defer m.logger.Trace("returning from PreviewMatches")
+
+	models, err := m.fetchModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]model.CheckpointMatch, 0, len(filenames))
+	refreshed := m.refresher == nil
+	for _, filename := range filenames {
+		match := m.match(models, trainingRunName, filename)
+		if match.ModelPath == "" && !refreshed {
+			if models, err = m.refreshModels(ctx); err != nil {
+				return nil, err
+			}
+			refreshed = true
+			match = m.match(models, trainingRunName, filename)
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// fetchModels queries ComfyUI for its UNET models.
+func (m *CheckpointPathMatcher) fetchModels(ctx context.Context) ([]string, error) {
+	models, err := m.modelsProvider.GetModels(ctx, ComfyUIModelTypeUNET)
+	if err != nil {
+		m.logger.WithError(err).Error("failed to query ComfyUI for UNET models")
+		return nil, fmt.Errorf("querying ComfyUI models: %w", err)
+	}
+	return models, nil
+}
+
+// refreshModels re-reads ComfyUI's UNET models through the refresher.
+func (m *CheckpointPathMatcher) refreshModels(ctx context.Context) ([]string, error) {
+	m.logger.Debug("no cached UNET model matched, re-reading ComfyUI's model list")
+	models, err := m.refresher.RefreshModels(ctx, ComfyUIModelTypeUNET)
+	if err != nil {
+		m.logger.WithError(err).Error("failed to re-read UNET models from ComfyUI")
+		return nil, fmt.Errorf("querying ComfyUI models: %w", err)
+	}
+	return models, nil
+}
+
+// match maps filename with the mapping rules and returns the first of models
+// that ends with the mapped path (ComfyUI paths may have directory prefixes).
+func (m *CheckpointPathMatcher) match(models []string, trainingRunName, filename string) model.CheckpointMatch {
+	mapped := m.mapPath(trainingRunName, filename)
+	match := model.CheckpointMatch{Filename: filename, MappedPath: mapped}
+	for _, modelPath := range models {
+		if endsWithFilename(modelPath, mapped) {
+			match.ModelPath = modelPath
+			break
+		}
+	}
+	return match
+}
+
+// mapPath applies the mapping rules to filename in order.
+func (m *CheckpointPathMatcher) mapPath(trainingRunName, filename string) string {
+	mapped := filename
+	for _, r := range m.rules {
+		switch {
+		case r.StripPrefix != "":
+			mapped = strings.TrimPrefix(mapped, r.StripPrefix)
+		case r.AddPrefix != "":
+			mapped = r.AddPrefix + mapped
+		case r.pattern != nil:
+			mapped = r.pattern.ReplaceAllString(mapped, r.Replace)
+		case r.TrainingRunSubdir && trainingRunName != "":
+			mapped = path.Join(trainingRunName, mapped)
+		}
+	}
+	return mapped
 }
 
 // endsWithFilename checks if path ends with filename, accounting for directory separators.
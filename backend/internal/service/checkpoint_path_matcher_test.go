@@ -4,18 +4,21 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
 
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 )
 
 // fakeComfyUIModelsProvider is a test double for service.ComfyUIModelsProvider.
 type fakeComfyUIModelsProvider struct {
-	models      map[service.ComfyUIModelType][]string
+	models       map[service.ComfyUIModelType][]string
 	getModelsErr error
+	calls        int
 }
 
 func newFakeComfyUIModelsProvider() *fakeComfyUIModelsProvider {
@@ -25,6 +28,7 @@ func newFakeComfyUIModelsProvider() *fakeComfyUIModelsProvider {
 }
 
 func (f *fakeComfyUIModelsProvider) GetModels(ctx context.Context, modelType service.ComfyUIModelType) ([]string, error) {
+	f.calls++
 	if f.getModelsErr != nil {
 		return nil, f.getModelsErr
 	}
@@ -52,7 +56,7 @@ var _ = Describe("CheckpointPathMatcher", func() {
 				"checkpoint2.safetensors",
 			}
 
-			path, err := matcher.MatchCheckpointPath("checkpoint1.safetensors")
+			path, err := matcher.MatchCheckpointPath("run", "checkpoint1.safetensors")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(path).To(Equal("checkpoint1.safetensors"))
 		})
@@ -63,7 +67,7 @@ var _ = Describe("CheckpointPathMatcher", func() {
 				"models/flux/checkpoint2.safetensors",
 			}
 
-			path, err := matcher.MatchCheckpointPath("checkpoint1.safetensors")
+			path, err := matcher.MatchCheckpointPath("run", "checkpoint1.safetensors")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(path).To(Equal("models/qwen/checkpoint1.safetensors"))
 		})
@@ -74,7 +78,7 @@ var _ = Describe("CheckpointPathMatcher", func() {
 				"other/checkpoint1.safetensors",
 			}
 
-			path, err := matcher.MatchCheckpointPath("checkpoint1.safetensors")
+			path, err := matcher.MatchCheckpointPath("run", "checkpoint1.safetensors")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(path).To(Equal("models/qwen/checkpoint1.safetensors"))
 		})
@@ -85,7 +89,7 @@ var _ = Describe("CheckpointPathMatcher", func() {
 				"checkpoint2.safetensors",
 			}
 
-			_, err := matcher.MatchCheckpointPath("nonexistent.safetensors")
+			_, err := matcher.MatchCheckpointPath("run", "nonexistent.safetensors")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found in ComfyUI"))
 		})
@@ -93,7 +97,7 @@ var _ = Describe("CheckpointPathMatcher", func() {
 		It("returns error when ComfyUI query fails", func() {
 			provider.getModelsErr = errors.New("connection failed")
 
-			_, err := matcher.MatchCheckpointPath("checkpoint1.safetensors")
+			_, err := matcher.MatchCheckpointPath("run", "checkpoint1.safetensors")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("querying ComfyUI"))
 		})
@@ -101,9 +105,91 @@ var _ = Describe("CheckpointPathMatcher", func() {
 		It("handles empty model list", func() {
 			provider.models[service.ComfyUIModelTypeUNET] = []string{}
 
-			_, err := matcher.MatchCheckpointPath("checkpoint1.safetensors")
+			_, err := matcher.MatchCheckpointPath("run", "checkpoint1.safetensors")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found in ComfyUI"))
 		})
 	})
+
+	Describe("mapping rules", func() {
+		BeforeEach(func() {
+			provider.models[service.ComfyUIModelTypeUNET] = []string{
+				"sweeps/qwen-lora/lora_s1000.safetensors",
+				"other/lora_s1000.safetensors",
+				"flat-lora_s2000.safetensors",
+			}
+		})
+
+		It("applies the rules in order before matching", func() {
+			Expect(matcher.SetMappingRules([]model.CheckpointPathRule{
+				{StripPrefix: "run-"},
+				{Pattern: `-step0*(\d+)`, Replace: "_s$1"},
+				{TrainingRunSubdir: true},
+				{AddPrefix: "sweeps/"},
+			})).To(Succeed())
+
+			path, err := matcher.MatchCheckpointPath("qwen-lora", "run-lora-step00001000.safetensors")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("sweeps/qwen-lora/lora_s1000.safetensors"))
+		})
+
+		It("reports the mapped path when it matches nothing", func() {
+			Expect(matcher.SetMappingRules([]model.CheckpointPathRule{{AddPrefix: "missing/"}})).To(Succeed())
+
+			_, err := matcher.MatchCheckpointPath("qwen-lora", "lora_s1000.safetensors")
+			Expect(err).To(MatchError(ContainSubstring("mapped to missing/lora_s1000.safetensors")))
+		})
+
+		It("rejects an invalid pattern", func() {
+			Expect(matcher.SetMappingRules([]model.CheckpointPathRule{{Pattern: "("}})).NotTo(Succeed())
+		})
+	})
+
+	Describe("PreviewMatches", func() {
+		It("resolves each filename with one query to ComfyUI", func() {
+			provider.models[service.ComfyUIModelTypeUNET] = []string{"qwen-lora/a.safetensors"}
+			Expect(matcher.SetMappingRules([]model.CheckpointPathRule{{TrainingRunSubdir: true}})).To(Succeed())
+
+			matches, err := matcher.PreviewMatches(context.Background(), "qwen-lora", []string{"a.safetensors", "b.safetensors"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matches).To(Equal([]model.CheckpointMatch{
+				{Filename: "a.safetensors", MappedPath: "qwen-lora/a.safetensors", ModelPath: "qwen-lora/a.safetensors"},
+				{Filename: "b.safetensors", MappedPath: "qwen-lora/b.safetensors"},
+			}))
+		})
+
+		It("returns an error when ComfyUI cannot be queried", func() {
+			provider.getModelsErr = errors.New("connection failed")
+
+			_, err := matcher.PreviewMatches(context.Background(), "qwen-lora", []string{"a.safetensors"})
+			Expect(err).To(MatchError(ContainSubstring("querying ComfyUI")))
+		})
+	})
+
+	Describe("with a model cache", func() {
+		var cache *service.ComfyUIModelCache
+
+		BeforeEach(func() {
+			provider.models[service.ComfyUIModelTypeUNET] = []string{"old.safetensors"}
+			cache = service.NewComfyUIModelCache(provider, time.Hour, logger)
+			matcher = service.NewCheckpointPathMatcher(cache, logger)
+			matcher.SetModelsRefresher(cache)
+		})
+
+		It("re-reads the model list when a checkpoint matches no cached model", func() {
+			Expect(matcher.MatchCheckpointPath("run", "old.safetensors")).To(Equal("old.safetensors"))
+			provider.models[service.ComfyUIModelTypeUNET] = []string{"old.safetensors", "unet/new.safetensors"}
+
+			Expect(matcher.MatchCheckpointPath("run", "new.safetensors")).To(Equal("unet/new.safetensors"))
+			Expect(matcher.MatchCheckpointPath("run", "new.safetensors")).To(Equal("unet/new.safetensors"))
+			Expect(provider.calls).To(Equal(2))
+		})
+
+		It("re-reads the model list once per preview", func() {
+			matches, err := matcher.PreviewMatches(context.Background(), "run", []string{"a.safetensors", "b.safetensors"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matches).To(HaveLen(2))
+			Expect(provider.calls).To(Equal(2))
+		})
+	})
 })
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// CheckpointScoreStore defines the persistence operations the checkpoint
+// score service needs.
+type CheckpointScoreStore interface {
+	ListVoteCandidates(ctx context.Context, trainingRunName string) ([]model.VoteCandidate, error)
+	ListImageAnnotations(ctx context.Context, filter model.ImageAnnotationFilter) ([]model.ImageAnnotation, error)
+}
+
+// CheckpointScoreService ranks the checkpoints of a training run by the star
+// ratings given to their sample images (see ImageAnnotationService).
+type CheckpointScoreService struct {
+	store     CheckpointScoreStore
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewCheckpointScoreService creates a CheckpointScoreService for images under
+// sampleDir.
+func NewCheckpointScoreService(store CheckpointScoreStore, sampleDir string, logger *logrus.Logger) *CheckpointScoreService {
+	return &CheckpointScoreService{
+		store:     store,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "checkpoint_score"),
+	}
+}
+
+// Report computes the score report of a training run from the ratings of its
+// completed images.
+func (s *CheckpointScoreService) Report(ctx context.Context, trainingRunName string) (model.CheckpointScoreReport, error) {
+	s.logger.WithField("training_run", trainingRunName).Trace("entering Report")
+	defer s.logger.Trace("returning from Report")
+
+	if trainingRunName == "" {
+		return model.CheckpointScoreReport{}, fmt.Errorf("training run must not be empty")
+	}
+	images, err := s.store.ListVoteCandidates(ctx, trainingRunName)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list images of training run")
+		return model.CheckpointScoreReport{}, fmt.Errorf("listing images of training run %s: %w", trainingRunName, err)
+	}
+	annotations, err := s.store.ListImageAnnotations(ctx, model.ImageAnnotationFilter{MinRating: 1})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list image annotations")
+		return model.CheckpointScoreReport{}, fmt.Errorf("listing image annotations: %w", err)
+	}
+	ratings := make(map[string]int, len(annotations))
+	for _, a := range annotations {
+		ratings[a.Path] = a.Rating
+	}
+
+	report := model.CheckpointScoreReport{TrainingRunName: trainingRunName}
+	overall := newScoreTable()
+	perPrompt := make(map[string]*scoreTable)
+	for _, img := range images {
+		rating := 0
+		if rel, err := filepath.Rel(s.sampleDir, img.OutputPath); err == nil {
+			rating = ratings[model.NormalizePinPath(filepath.ToSlash(rel))]
+		}
+		report.ImageCount++
+		if rating > 0 {
+			report.RatedCount++
+		}
+		overall.add(img.CheckpointFilename, rating)
+		t, ok := perPrompt[img.PromptName]
+		if !ok {
+			t = newScoreTable()
+			perPrompt[img.PromptName] = t
+		}
+		t.add(img.CheckpointFilename, rating)
+	}
+
+	report.Leaderboard = overall.scores()
+	report.Prompts = []model.PromptScores{}
+	for name, t := range perPrompt {
+		report.Prompts = append(report.Prompts, model.PromptScores{PromptName: name, Scores: t.scores()})
+	}
+	sort.Slice(report.Prompts, func(i, j int) bool {
+		return report.Prompts[i].PromptName < report.Prompts[j].PromptName
+	})
+	s.logger.WithFields(logrus.Fields{
+		"training_run": trainingRunName,
+		"image_count":  report.ImageCount,
+		"rated_count":  report.RatedCount,
+	}).Debug("computed checkpoint score report")
+	return report, nil
+}
+
+// checkpointScoreCSVHeader is the header row of WriteCheckpointScoreCSV.
+var checkpointScoreCSVHeader = []string{"scope", "prompt_name", "rank", "checkpoint_filename", "step", "image_count", "rated_count", "average_rating"}
+
+// WriteCheckpointScoreCSV writes report as CSV: the leaderboard rows (scope
+// "overall", empty prompt_name) followed by the rows of each prompt (scope
+// "prompt"). Averages of checkpoints without rated images are left empty.
+func WriteCheckpointScoreCSV(w io.Writer, report model.CheckpointScoreReport) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(checkpointScoreCSVHeader); err != nil {
+		return fmt.Errorf("writing CSV header: %w", err)
+	}
+	writeRows := func(scope, promptName string, scores []model.CheckpointScore) error {
+		for _, sc := range scores {
+			average := ""
+			if sc.RatedCount > 0 {
+				average = strconv.FormatFloat(sc.AverageRating, 'f', 3, 64)
+			}
+			row := []string{
+				scope,
+				promptName,
+				strconv.Itoa(sc.Rank),
+				sc.CheckpointFilename,
+				strconv.Itoa(sc.StepNumber),
+				strconv.Itoa(sc.ImageCount),
+				strconv.Itoa(sc.RatedCount),
+				average,
+			}
+			if err := cw.Write(row); err != nil {
+				return fmt.Errorf("writing CSV row: %w", err)
+			}
+		}
+		return nil
+	}
+	if err := writeRows("overall", "", report.Leaderboard); err != nil {
+		return err
+	}
+	for _, p := range report.Prompts {
+		if err := writeRows("prompt", p.PromptName, p.Scores); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	return nil
+}
+
+// CheckpointScoreCSVFilename returns the download filename of the CSV report of
+// a training run. Path separators in the name are replaced.
+func CheckpointScoreCSVFilename(trainingRunName string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", `"`, "_").Replace(trainingRunName)
+	return name + "-checkpoint-scores.csv"
+}
+
+// scoreTable accumulates the ratings of the images of each checkpoint.
+type scoreTable struct {
+	entries map[string]*scoreEntry
+}
+
+type scoreEntry struct {
+	images, rated, sum int
+}
+
+func newScoreTable() *scoreTable {
+	return &scoreTable{entries: make(map[string]*scoreEntry)}
+}
+
+// add records an image of checkpoint with the given rating (0 if unrated).
+func (t *scoreTable) add(checkpoint string, rating int) {
+	e, ok := t.entries[checkpoint]
+	if !ok {
+		e = &scoreEntry{}
+		t.entries[checkpoint] = e
+	}
+	e.images++
+	if rating > 0 {
+		e.rated++
+		e.sum += rating
+	}
+}
+
+// scores returns the ranked scores: highest average first, then most rated
+// images, then by checkpoint filename. Checkpoints without rated images have
+// an average of 0 and so come last.
+func (t *scoreTable) scores() []model.CheckpointScore {
+	out := make([]model.CheckpointScore, 0, len(t.entries))
+	for checkpoint, e := range t.entries {
+		sc := model.CheckpointScore{
+			CheckpointFilename: checkpoint,
+			StepNumber:         extractStepNumber(checkpoint),
+			ImageCount:         e.images,
+			RatedCount:         e.rated,
+		}
+		if e.rated > 0 {
+			sc.AverageRating = float64(e.sum) / float64(e.rated)
+		}
+		out = append(out, sc)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AverageRating != out[j].AverageRating {
+			return out[i].AverageRating > out[j].AverageRating
+		}
+		if out[i].RatedCount != out[j].RatedCount {
+			return out[i].RatedCount > out[j].RatedCount
+		}
+		return out[i].CheckpointFilename < out[j].CheckpointFilename
+	})
+	for i := range out {
+		out[i].Rank = i + 1
+	}
+	return out
+}
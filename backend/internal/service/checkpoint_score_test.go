@@ -0,0 +1,147 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeCheckpointScoreStore is an in-memory test double for
+// service.CheckpointScoreStore.
+type fakeCheckpointScoreStore struct {
+	images      []model.VoteCandidate
+	annotations []model.ImageAnnotation
+	listErr     error
+}
+
+func (f *fakeCheckpointScoreStore) ListVoteCandidates(ctx context.Context, trainingRunName string) ([]model.VoteCandidate, error) {
+	return f.images, f.listErr
+}
+
+func (f *fakeCheckpointScoreStore) ListImageAnnotations(ctx context.Context, filter model.ImageAnnotationFilter) ([]model.ImageAnnotation, error) {
+	return f.annotations, nil
+}
+
+var _ = Describe("CheckpointScoreService", func() {
+	var (
+		store *fakeCheckpointScoreStore
+		svc   *service.CheckpointScoreService
+	)
+
+	image := func(checkpoint, prompt, name string) model.VoteCandidate {
+		return model.VoteCandidate{
+			CheckpointFilename: checkpoint,
+			PromptName:         prompt,
+			OutputPath:         "/samples/run/study/" + checkpoint + "/" + name,
+		}
+	}
+	rated := func(checkpoint, name string, rating int) model.ImageAnnotation {
+		return model.ImageAnnotation{Path: "run/study/" + checkpoint + "/" + name, Rating: rating}
+	}
+
+	BeforeEach(func() {
+		store = &fakeCheckpointScoreStore{
+			images: []model.VoteCandidate{
+				image("m-step00001000.safetensors", "forest", "1.png"),
+				image("m-step00001000.safetensors", "lake", "2.png"),
+				image("m-step00002000.safetensors", "forest", "3.png"),
+				image("m-step00002000.safetensors", "lake", "4.png"),
+				image("m.safetensors", "forest", "5.png"),
+			},
+			annotations: []model.ImageAnnotation{
+				rated("m-step00001000.safetensors", "1.png", 2),
+				rated("m-step00001000.safetensors", "2.png", 4),
+				rated("m-step00002000.safetensors", "3.png", 5),
+				rated("other.safetensors", "9.png", 1),
+			},
+		}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewCheckpointScoreService(store, "/samples", logger)
+	})
+
+	Describe("Report", func() {
+		It("ranks checkpoints by average rating and counts unrated images", func() {
+			report, err := svc.Report(ctx, "run")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.ImageCount).To(Equal(5))
+			Expect(report.RatedCount).To(Equal(3))
+			Expect(report.Leaderboard).To(Equal([]model.CheckpointScore{
+				{Rank: 1, CheckpointFilename: "m-step00002000.safetensors", StepNumber: 2000, ImageCount: 2, RatedCount: 1, AverageRating: 5},
+				{Rank: 2, CheckpointFilename: "m-step00001000.safetensors", StepNumber: 1000, ImageCount: 2, RatedCount: 2, AverageRating: 3},
+				{Rank: 3, CheckpointFilename: "m.safetensors", StepNumber: -1, ImageCount: 1, RatedCount: 0, AverageRating: 0},
+			}))
+		})
+
+		It("scores each prompt separately, ordered by prompt name", func() {
+			report, err := svc.Report(ctx, "run")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Prompts).To(HaveLen(2))
+			Expect(report.Prompts[0].PromptName).To(Equal("forest"))
+			Expect(report.Prompts[0].Scores[0].CheckpointFilename).To(Equal("m-step00002000.safetensors"))
+			Expect(report.Prompts[1].PromptName).To(Equal("lake"))
+			Expect(report.Prompts[1].Scores[0].CheckpointFilename).To(Equal("m-step00001000.safetensors"))
+			Expect(report.Prompts[1].Scores[0].AverageRating).To(Equal(4.0))
+		})
+
+		It("breaks ties in average rating by the number of rated images", func() {
+			store.annotations[0].Rating = 4 // m-step00001000: 4 and 4
+			store.annotations[2].Rating = 4 // m-step00002000: 4
+
+			report, err := svc.Report(ctx, "run")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Leaderboard[0].CheckpointFilename).To(Equal("m-step00001000.safetensors"))
+			Expect(report.Leaderboard[1].CheckpointFilename).To(Equal("m-step00002000.safetensors"))
+		})
+
+		It("returns an empty report for a training run without images", func() {
+			store.images = nil
+			report, err := svc.Report(ctx, "run")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Leaderboard).To(BeEmpty())
+			Expect(report.Prompts).NotTo(BeNil())
+		})
+
+		It("rejects an empty training run", func() {
+			_, err := svc.Report(ctx, "")
+			Expect(err).To(MatchError(ContainSubstring("must not be empty")))
+		})
+
+		It("wraps store errors", func() {
+			store.listErr = errors.New("database is locked")
+			_, err := svc.Report(ctx, "run")
+			Expect(err).To(MatchError(ContainSubstring("database is locked")))
+		})
+	})
+
+	Describe("WriteCheckpointScoreCSV", func() {
+		It("writes the leaderboard followed by the rows of each prompt", func() {
+			report, err := svc.Report(ctx, "run")
+			Expect(err).NotTo(HaveOccurred())
+
+			var buf bytes.Buffer
+			Expect(service.WriteCheckpointScoreCSV(&buf, report)).To(Succeed())
+			Expect(buf.String()).To(Equal("scope,prompt_name,rank,checkpoint_filename,step,image_count,rated_count,average_rating\n" +
+				"overall,,1,m-step00002000.safetensors,2000,2,1,5.000\n" +
+				"overall,,2,m-step00001000.safetensors,1000,2,2,3.000\n" +
+				"overall,,3,m.safetensors,-1,1,0,\n" +
+				"prompt,forest,1,m-step00002000.safetensors,2000,1,1,5.000\n" +
+				"prompt,forest,2,m-step00001000.safetensors,1000,1,1,2.000\n" +
+				"prompt,forest,3,m.safetensors,-1,1,0,\n" +
+				"prompt,lake,1,m-step00001000.safetensors,1000,1,1,4.000\n" +
+				"prompt,lake,2,m-step00002000.safetensors,2000,1,0,\n"))
+		})
+	})
+
+	It("derives a CSV filename without path separators", func() {
+		Expect(service.CheckpointScoreCSVFilename("qwen/run")).To(Equal("qwen_run-checkpoint-scores.csv"))
+	})
+})
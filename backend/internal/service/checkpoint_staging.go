@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// CheckpointFileResolver finds a checkpoint file in the checkpoint
+// directories. It is satisfied by CheckpointMetadataService.
+type CheckpointFileResolver interface {
+	ResolveCheckpointFile(filename string) (string, error)
+}
+
+// CheckpointStagingFileSystem copies checkpoints into the staging directory
+// and removes them.
+type CheckpointStagingFileSystem interface {
+	FileExists(path string) bool
+	CopyFile(src, dst string) error
+	RemoveFile(path string) error
+}
+
+// CheckpointStaging lets ComfyUI sample checkpoints it cannot see, e.g.
+// because the training output directory is not mounted in its container, by
+// copying them into a staging directory that is inside one of ComfyUI's
+// diffusion model directories.
+//
+// It is a PathMatcher: checkpoints ComfyUI does not list are matched to their
+// model path in the staging directory when the sampler can find the file.
+// The job executor copies them there before sampling (Stage) and removes them
+// when the job finishes (Unstage).
+type CheckpointStaging struct {
+	matcher  PathMatcher
+	resolver CheckpointFileResolver
+	fs       CheckpointStagingFileSystem
+	cfg      model.CheckpointStagingConfig
+	logger   *logrus.Entry
+
+	mu sync.Mutex // serializes copies, so that a checkpoint is copied once
+}
+
+// NewCheckpointStaging creates a CheckpointStaging that falls back to the
+// staging directory for checkpoints matcher cannot match.
+func NewCheckpointStaging(matcher PathMatcher, resolver CheckpointFileResolver, fs CheckpointStagingFileSystem, cfg model.CheckpointStagingConfig, logger *logrus.Logger) *CheckpointStaging {
+	return &CheckpointStaging{
+		matcher:  matcher,
+		resolver: resolver,
+		fs:       fs,
+		cfg:      cfg,
+		logger:   logger.WithField("component", "checkpoint_staging"),
+	}
+}
+
+// MatchCheckpointPath returns the ComfyUI model path of filename. A
+// checkpoint ComfyUI does not list is matched to its path in the staging
+// directory if the file is in one of the checkpoint directories; otherwise
+// the original matching error is returned.
+func (s *CheckpointStaging) MatchCheckpointPath(trainingRunName, filename string) (string, error) {
+	s.logger.WithField("checkpoint_filename", filename).Trace("entering MatchCheckpointPath")
+	defer s.logger.Trace("returning from MatchCheckpointPath")
+
+	modelPath, err := s.matcher.MatchCheckpointPath(trainingRunName, filename)
+	if err == nil {
+		return modelPath, nil
+	}
+	if _, resolveErr := s.resolver.ResolveCheckpointFile(filename); resolveErr != nil {
+		return "", err
+	}
+	modelPath = path.Join(s.cfg.ModelPath, filename)
+	s.logger.WithFields(logrus.Fields{
+		"checkpoint_filename": filename,
+		"comfyui_path":        modelPath,
+	}).Debug("checkpoint not in ComfyUI, matched to the staging directory")
+	return modelPath, nil
+}
+
+// Stage copies the checkpoint filename into the staging directory if
+// modelPath is in the staging directory and the checkpoint is not there yet.
+// Returns the staged file's path, or "" when modelPath is not in the staging
+// directory.
+func (s *CheckpointStaging) Stage(modelPath, filename string) (string, error) {
+	s.logger.WithFields(logrus.Fields{
+		"comfyui_path":        modelPath,
+		"checkpoint_filename": filename,
+	}).Trace("entering Stage")
+	defer s.logger.Trace("returning from Stage")
+
+	if !strings.HasPrefix(strings.ReplaceAll(modelPath, "\\", "/"), s.cfg.ModelPath+"/") {
+		return "", nil
+	}
+	if !isFilenameSafe(filename) {
+		return "", fmt.Errorf("invalid checkpoint filename: %q", filename)
+	}
+	dst := filepath.Join(s.cfg.Dir, filename)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fs.FileExists(dst) {
+		return dst, nil
+	}
+	src, err := s.resolver.ResolveCheckpointFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("finding checkpoint %s: %w", filename, err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"checkpoint_filename": filename,
+		"src":                 src,
+		"dst":                 dst,
+	}).Info("staging checkpoint for ComfyUI")
+	if err := s.fs.CopyFile(src, dst); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"checkpoint_filename": filename,
+			"error":               err.Error(),
+		}).Error("failed to stage checkpoint")
+		return "", fmt.Errorf("staging checkpoint %s: %w", filename, err)
+	}
+	return dst, nil
+}
+
+// Unstage removes a checkpoint copied into the staging directory by Stage.
+// Paths outside the staging directory are refused.
+func (s *CheckpointStaging) Unstage(stagedPath string) error {
+	s.logger.WithField("path", stagedPath).Trace("entering Unstage")
+	defer s.logger.Trace("returning from Unstage")
+
+	if filepath.Dir(filepath.Clean(stagedPath)) != filepath.Clean(s.cfg.Dir) {
+		return fmt.Errorf("refusing to remove %s: not in the staging directory", stagedPath)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.fs.RemoveFile(stagedPath); err != nil {
+		return err
+	}
+	s.logger.WithField("path", stagedPath).Info("removed staged checkpoint")
+	return nil
+}
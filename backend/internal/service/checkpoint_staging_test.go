@@ -0,0 +1,135 @@
+package service_test
+
+import (
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeStagingPathMatcher matches the checkpoints ComfyUI lists.
+type fakeStagingPathMatcher struct {
+	paths map[string]string
+}
+
+func (f *fakeStagingPathMatcher) MatchCheckpointPath(trainingRunName, filename string) (string, error) {
+	if p, ok := f.paths[filename]; ok {
+		return p, nil
+	}
+	return "", errors.New("checkpoint " + filename + " not found in ComfyUI UNET models")
+}
+
+// fakeCheckpointFileResolver resolves the checkpoints in its files map.
+type fakeCheckpointFileResolver struct {
+	files map[string]string
+}
+
+func (f *fakeCheckpointFileResolver) ResolveCheckpointFile(filename string) (string, error) {
+	if p, ok := f.files[filename]; ok {
+		return p, nil
+	}
+	return "", errors.New("checkpoint file not found")
+}
+
+// fakeStagingFS records copies and removals in an in-memory file set.
+type fakeStagingFS struct {
+	files   map[string]bool
+	copies  [][2]string
+	removed []string
+	copyErr error
+}
+
+func (f *fakeStagingFS) FileExists(path string) bool { return f.files[path] }
+
+func (f *fakeStagingFS) CopyFile(src, dst string) error {
+	if f.copyErr != nil {
+		return f.copyErr
+	}
+	f.copies = append(f.copies, [2]string{src, dst})
+	f.files[dst] = true
+	return nil
+}
+
+func (f *fakeStagingFS) RemoveFile(path string) error {
+	f.removed = append(f.removed, path)
+	delete(f.files, path)
+	return nil
+}
+
+var _ = Describe("CheckpointStaging", func() {
+	var (
+		fs      *fakeStagingFS
+		staging *service.CheckpointStaging
+	)
+
+	BeforeEach(func() {
+		fs = &fakeStagingFS{files: map[string]bool{}}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		staging = service.NewCheckpointStaging(
+			&fakeStagingPathMatcher{paths: map[string]string{"mounted.safetensors": "runs/mounted.safetensors"}},
+			&fakeCheckpointFileResolver{files: map[string]string{"local.safetensors": "/train/run/local.safetensors"}},
+			fs,
+			model.CheckpointStagingConfig{Dir: "/shared/diffusion_models/staging", ModelPath: "staging"},
+			logger,
+		)
+	})
+
+	Describe("MatchCheckpointPath", func() {
+		It("returns the path ComfyUI lists", func() {
+			Expect(staging.MatchCheckpointPath("run", "mounted.safetensors")).To(Equal("runs/mounted.safetensors"))
+		})
+
+		It("falls back to the staging directory for a local checkpoint", func() {
+			Expect(staging.MatchCheckpointPath("run", "local.safetensors")).To(Equal("staging/local.safetensors"))
+		})
+
+		It("returns the matching error for a checkpoint found nowhere", func() {
+			_, err := staging.MatchCheckpointPath("run", "missing.safetensors")
+			Expect(err).To(MatchError(ContainSubstring("not found in ComfyUI")))
+		})
+	})
+
+	Describe("Stage", func() {
+		It("copies the checkpoint into the staging directory once", func() {
+			staged, err := staging.Stage("staging/local.safetensors", "local.safetensors")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged).To(Equal("/shared/diffusion_models/staging/local.safetensors"))
+
+			_, err = staging.Stage("staging/local.safetensors", "local.safetensors")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.copies).To(Equal([][2]string{{"/train/run/local.safetensors", staged}}))
+		})
+
+		It("does nothing for a checkpoint outside the staging directory", func() {
+			staged, err := staging.Stage("runs/mounted.safetensors", "mounted.safetensors")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged).To(BeEmpty())
+			Expect(fs.copies).To(BeEmpty())
+		})
+
+		It("returns an error when the copy fails", func() {
+			fs.copyErr = errors.New("no space left on device")
+
+			_, err := staging.Stage("staging/local.safetensors", "local.safetensors")
+			Expect(err).To(MatchError(ContainSubstring("no space left on device")))
+		})
+	})
+
+	Describe("Unstage", func() {
+		It("removes a staged checkpoint", func() {
+			Expect(staging.Unstage("/shared/diffusion_models/staging/local.safetensors")).To(Succeed())
+			Expect(fs.removed).To(Equal([]string{"/shared/diffusion_models/staging/local.safetensors"}))
+		})
+
+		It("refuses a path outside the staging directory", func() {
+			Expect(staging.Unstage("/train/run/local.safetensors")).NotTo(Succeed())
+			Expect(fs.removed).To(BeEmpty())
+		})
+	})
+})
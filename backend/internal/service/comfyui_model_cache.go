@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// modelCacheEntry is a cached model list and when it was read.
+type modelCacheEntry struct {
+	models    []string
+	fetchedAt time.Time
+}
+
+// ComfyUIModelCache caches ComfyUI's model lists, so that matching every
+// checkpoint of a job does not query ComfyUI for each one. Lists are read
+// again once they are older than the TTL or after Refresh.
+type ComfyUIModelCache struct {
+	provider ComfyUIModelsProvider
+	ttl      time.Duration
+	logger   *logrus.Entry
+
+	mu      sync.Mutex
+	entries map[ComfyUIModelType]modelCacheEntry
+}
+
+// NewComfyUIModelCache creates a ComfyUIModelCache in front of provider. A
+// zero ttl disables caching.
+func NewComfyUIModelCache(provider ComfyUIModelsProvider, ttl time.Duration, logger *logrus.Logger) *ComfyUIModelCache {
+	return &ComfyUIModelCache{
+		provider: provider,
+		ttl:      ttl,
+		logger:   logger.WithField("component", "comfyui_model_cache"),
+		entries:  make(map[ComfyUIModelType]modelCacheEntry),
+	}
+}
+
+// GetModels returns the cached models of modelType, reading them from
+// ComfyUI when they are not cached or have expired. Failures are not cached.
+func (c *ComfyUIModelCache) GetModels(ctx context.Context, modelType ComfyUIModelType) ([]string, error) {
+	c.logger.WithField("model_type", modelType).Trace("entering GetModels")
+	defer c.logger.Trace("returning from GetModels")
+
+	c.mu.Lock()
+	entry, ok := c.entries[modelType]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < c.ttl {
+		return entry.models, nil
+	}
+	return c.RefreshModels(ctx, modelType)
+}
+
+// RefreshModels reads the models of modelType from ComfyUI and caches them.
+func (c *ComfyUIModelCache) RefreshModels(ctx context.Context, modelType ComfyUIModelType) ([]string, error) {
+	c.logger.WithField("model_type", modelType).Trace("entering RefreshModels")
+	defer c.logger.Trace("returning from RefreshModels")
+
+	models, err := c.provider.GetModels(ctx, modelType)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[modelType] = modelCacheEntry{models: models, fetchedAt: time.Now()}
+	c.logger.WithFields(logrus.Fields{
+		"model_type":  modelType,
+		"model_count": len(models),
+	}).Debug("cached ComfyUI models")
+	return models, nil
+}
+
+// Refresh drops every cached model list, so that the next GetModels of each
+// type reads it from ComfyUI.
+func (c *ComfyUIModelCache) Refresh() {
+	c.logger.Trace("entering Refresh")
+	defer c.logger.Trace("returning from Refresh")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[ComfyUIModelType]modelCacheEntry)
+	c.logger.Info("cleared ComfyUI model cache")
+}
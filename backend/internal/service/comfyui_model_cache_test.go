@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("ComfyUIModelCache", func() {
+	var (
+		provider *fakeComfyUIModelsProvider
+		logger   *logrus.Logger
+	)
+
+	BeforeEach(func() {
+		provider = newFakeComfyUIModelsProvider()
+		provider.models[service.ComfyUIModelTypeUNET] = []string{"a.safetensors"}
+		provider.models[service.ComfyUIModelTypeVAE] = []string{"ae.safetensors"}
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	})
+
+	It("serves cached models until they expire", func() {
+		cache := service.NewComfyUIModelCache(provider, time.Hour, logger)
+
+		Expect(cache.GetModels(context.Background(), service.ComfyUIModelTypeUNET)).To(Equal([]string{"a.safetensors"}))
+		provider.models[service.ComfyUIModelTypeUNET] = []string{"a.safetensors", "b.safetensors"}
+		Expect(cache.GetModels(context.Background(), service.ComfyUIModelTypeUNET)).To(Equal([]string{"a.safetensors"}))
+		Expect(provider.calls).To(Equal(1))
+	})
+
+	It("caches each model type separately", func() {
+		cache := service.NewComfyUIModelCache(provider, time.Hour, logger)
+
+		Expect(cache.GetModels(context.Background(), service.ComfyUIModelTypeUNET)).To(Equal([]string{"a.safetensors"}))
+		Expect(cache.GetModels(context.Background(), service.ComfyUIModelTypeVAE)).To(Equal([]string{"ae.safetensors"}))
+		Expect(provider.calls).To(Equal(2))
+	})
+
+	It("reads the models again after Refresh", func() {
+		cache := service.NewComfyUIModelCache(provider, time.Hour, logger)
+		_, err := cache.GetModels(context.Background(), service.ComfyUIModelTypeUNET)
+		Expect(err).NotTo(HaveOccurred())
+		provider.models[service.ComfyUIModelTypeUNET] = []string{"a.safetensors", "b.safetensors"}
+
+		cache.Refresh()
+
+		Expect(cache.GetModels(context.Background(), service.ComfyUIModelTypeUNET)).To(HaveLen(2))
+	})
+
+	It("does not cache with a zero TTL", func() {
+		cache := service.NewComfyUIModelCache(provider, 0, logger)
+
+		_, _ = cache.GetModels(context.Background(), service.ComfyUIModelTypeUNET)
+		_, _ = cache.GetModels(context.Background(), service.ComfyUIModelTypeUNET)
+		Expect(provider.calls).To(Equal(2))
+	})
+
+	It("does not cache failures", func() {
+		cache := service.NewComfyUIModelCache(provider, time.Hour, logger)
+		provider.getModelsErr = errors.New("connection refused")
+		_, err := cache.GetModels(context.Background(), service.ComfyUIModelTypeUNET)
+		Expect(err).To(MatchError(ContainSubstring("connection refused")))
+
+		provider.getModelsErr = nil
+		Expect(cache.GetModels(context.Background(), service.ComfyUIModelTypeUNET)).To(Equal([]string{"a.safetensors"}))
+	})
+})
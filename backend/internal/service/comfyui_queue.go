@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ComfyUIQueueSource reads ComfyUI's prompt queue. It is satisfied by
+// store.ComfyUIHTTPClient.
+type ComfyUIQueueSource interface {
+	GetQueueStatus(ctx context.Context) (*model.ComfyUIQueue, error)
+}
+
+// PromptItemLookup finds the sample job items submitted to ComfyUI under a
+// set of prompt IDs.
+type PromptItemLookup interface {
+	ListSampleJobItemsByPromptIDs(ctx context.Context, promptIDs []string) ([]model.SampleJobItem, error)
+}
+
+// ComfyUIQueueService reports what ComfyUI is running and has queued, with
+// the sample job items behind the prompts it recognizes.
+type ComfyUIQueueService struct {
+	source ComfyUIQueueSource
+	items  PromptItemLookup
+	logger *logrus.Entry
+}
+
+// NewComfyUIQueueService creates a ComfyUIQueueService.
+func NewComfyUIQueueService(source ComfyUIQueueSource, items PromptItemLookup, logger *logrus.Logger) *ComfyUIQueueService {
+	return &ComfyUIQueueService{
+		source: source,
+		items:  items,
+		logger: logger.WithField("component", "comfyui_queue"),
+	}
+}
+
+// Queue returns ComfyUI's running and pending prompts. Prompts submitted for a
+// sample job item carry the item's job and item IDs; others, such as
+// pre-warm prompts or prompts queued outside the sampler, carry neither. If
+// the items cannot be looked up, the queue is returned without them.
+func (s *ComfyUIQueueService) Queue(ctx context.Context) (model.ComfyUIQueue, error) {
+	s.logger.Trace("entering Queue")
+	defer s.logger.Trace("returning from Queue")
+
+	queue, err := s.source.GetQueueStatus(ctx)
+	if err != nil {
+		s.logger.WithError(err).Warn("failed to read the ComfyUI queue")
+		return model.ComfyUIQueue{}, fmt.Errorf("reading ComfyUI queue: %w", err)
+	}
+
+	var promptIDs []string
+	for _, entries := range [][]model.ComfyUIQueueEntry{queue.Running, queue.Pending} {
+		for _, e := range entries {
+			promptIDs = append(promptIDs, e.PromptID)
+		}
+	}
+	items, err := s.items.ListSampleJobItemsByPromptIDs(ctx, promptIDs)
+	if err != nil {
+		s.logger.WithError(err).Warn("failed to look up the sample job items of queued prompts")
+		return *queue, nil
+	}
+	byPromptID := make(map[string]model.SampleJobItem, len(items))
+	for _, item := range items {
+		byPromptID[item.ComfyUIPromptID] = item
+	}
+	for _, entries := range [][]model.ComfyUIQueueEntry{queue.Running, queue.Pending} {
+		for i := range entries {
+			if item, ok := byPromptID[entries[i].PromptID]; ok {
+				entries[i].JobID = item.JobID
+				entries[i].ItemID = item.ID
+			}
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"running":    len(queue.Running),
+		"pending":    len(queue.Pending),
+		"recognized": len(items),
+	}).Debug("read the ComfyUI queue")
+	return *queue, nil
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeComfyUIQueueSource returns a fixed ComfyUI queue.
+type fakeComfyUIQueueSource struct {
+	queue model.ComfyUIQueue
+	err   error
+}
+
+func (f *fakeComfyUIQueueSource) GetQueueStatus(ctx context.Context) (*model.ComfyUIQueue, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	queue := f.queue
+	return &queue, nil
+}
+
+// fakePromptItemLookup returns the items whose prompt IDs were asked for.
+type fakePromptItemLookup struct {
+	items     []model.SampleJobItem
+	err       error
+	promptIDs []string
+}
+
+func (f *fakePromptItemLookup) ListSampleJobItemsByPromptIDs(ctx context.Context, promptIDs []string) ([]model.SampleJobItem, error) {
+	f.promptIDs = promptIDs
+	if f.err != nil {
+		return nil, f.err
+	}
+	var found []model.SampleJobItem
+	for _, item := range f.items {
+		for _, id := range promptIDs {
+			if item.ComfyUIPromptID == id {
+				found = append(found, item)
+			}
+		}
+	}
+	return found, nil
+}
+
+var _ = Describe("ComfyUIQueueService", func() {
+	var (
+		source *fakeComfyUIQueueSource
+		lookup *fakePromptItemLookup
+		svc    *service.ComfyUIQueueService
+	)
+
+	BeforeEach(func() {
+		source = &fakeComfyUIQueueSource{queue: model.ComfyUIQueue{
+			Running: []model.ComfyUIQueueEntry{{Number: 7, PromptID: "prompt-running"}},
+			Pending: []model.ComfyUIQueueEntry{
+				{Number: 8, PromptID: "prompt-prewarm"},
+				{Number: 9, PromptID: "prompt-pending"},
+			},
+		}}
+		lookup = &fakePromptItemLookup{items: []model.SampleJobItem{
+			{ID: "item-1", JobID: "job-1", ComfyUIPromptID: "prompt-running"},
+			{ID: "item-2", JobID: "job-1", ComfyUIPromptID: "prompt-pending"},
+		}}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewComfyUIQueueService(source, lookup, logger)
+	})
+
+	It("attaches the job and item IDs of recognized prompts", func() {
+		queue, err := svc.Queue(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lookup.promptIDs).To(Equal([]string{"prompt-running", "prompt-prewarm", "prompt-pending"}))
+		Expect(queue.Running).To(Equal([]model.ComfyUIQueueEntry{
+			{Number: 7, PromptID: "prompt-running", JobID: "job-1", ItemID: "item-1"},
+		}))
+		Expect(queue.Pending).To(Equal([]model.ComfyUIQueueEntry{
+			{Number: 8, PromptID: "prompt-prewarm"},
+			{Number: 9, PromptID: "prompt-pending", JobID: "job-1", ItemID: "item-2"},
+		}))
+	})
+
+	It("returns the queue without IDs when the items cannot be looked up", func() {
+		lookup.err = errors.New("database is locked")
+
+		queue, err := svc.Queue(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queue.Running).To(HaveLen(1))
+		Expect(queue.Running[0].JobID).To(BeEmpty())
+	})
+
+	It("returns an error when ComfyUI cannot be reached", func() {
+		source.err = errors.New("connection refused")
+
+		_, err := svc.Queue(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("connection refused")))
+	})
+})
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// comfyUIStatsInterval is how often ComfyUIStatsMonitor re-reads ComfyUI's
+// system stats and queue.
+const comfyUIStatsInterval = 10 * time.Second
+
+// ComfyUIStatsMonitor periodically reads ComfyUI's devices and queue length
+// and caches the result, so that clients polling for GPU headroom do not each
+// query ComfyUI.
+type ComfyUIStatsMonitor struct {
+	stats    SystemStatsSource
+	queue    ComfyUIQueueSource
+	interval time.Duration
+	logger   *logrus.Entry
+
+	mu       sync.Mutex
+	snapshot model.ComfyUIStatsSnapshot
+}
+
+// NewComfyUIStatsMonitor creates a ComfyUIStatsMonitor. Nothing is read
+// until Refresh or Run is called.
+func NewComfyUIStatsMonitor(stats SystemStatsSource, queue ComfyUIQueueSource, logger *logrus.Logger) *ComfyUIStatsMonitor {
+	return &ComfyUIStatsMonitor{
+		stats:    stats,
+		queue:    queue,
+		interval: comfyUIStatsInterval,
+		logger:   logger.WithField("component", "comfyui_stats"),
+	}
+}
+
+// Snapshot returns the cached devices and queue length.
+func (m *ComfyUIStatsMonitor) Snapshot() model.ComfyUIStatsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshot
+}
+
+// Refresh reads ComfyUI's system stats and queue once and updates the cached
+// snapshot. On failure the previous readings are kept and the error is
+// recorded in the snapshot.
+func (m *ComfyUIStatsMonitor) Refresh(ctx context.Context) {
+	m.logger.Trace("entering Refresh")
+	defer m.logger.Trace("returning from Refresh")
+
+	stats, err := m.stats.SystemStats(ctx)
+	if err != nil {
+		m.recordError(err)
+		return
+	}
+	queue, err := m.queue.GetQueueStatus(ctx)
+	if err != nil {
+		m.recordError(err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.snapshot.Error != "" {
+		m.logger.Info("ComfyUI system stats readable again")
+	}
+	m.snapshot = model.ComfyUIStatsSnapshot{
+		Devices:      stats.Devices,
+		QueueRunning: len(queue.Running),
+		QueuePending: len(queue.Pending),
+		UpdatedAt:    time.Now().UTC(),
+	}
+	m.logger.WithFields(logrus.Fields{
+		"device_count":  len(stats.Devices),
+		"queue_running": len(queue.Running),
+		"queue_pending": len(queue.Pending),
+	}).Debug("refreshed ComfyUI system stats")
+}
+
+// recordError records a failed read, logging only the first of a series of
+// failures so that an unreachable ComfyUI does not flood the log.
+func (m *ComfyUIStatsMonitor) recordError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.snapshot.Error == "" {
+		m.logger.WithError(err).Warn("failed to read ComfyUI system stats")
+	}
+	m.snapshot.Error = err.Error()
+}
+
+// Run refreshes the snapshot immediately and then at every interval until
+// stop is closed.
+func (m *ComfyUIStatsMonitor) Run(stop <-chan struct{}) {
+	m.logger.Trace("entering Run")
+	defer m.logger.Trace("returning from Run")
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), m.interval)
+		m.Refresh(ctx)
+		cancel()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
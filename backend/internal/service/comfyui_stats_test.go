@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("ComfyUIStatsMonitor", func() {
+	var (
+		stats   *fakeSystemStatsSource
+		queue   *fakeComfyUIQueueSource
+		monitor *service.ComfyUIStatsMonitor
+	)
+
+	BeforeEach(func() {
+		stats = &fakeSystemStatsSource{stats: model.ComfyUISystemStats{Devices: []model.ComfyUIDevice{
+			{Name: "cuda:0 NVIDIA GeForce RTX 4090", Type: "cuda", VRAMTotal: 24 << 30, VRAMFree: 20 << 30},
+		}}}
+		queue = &fakeComfyUIQueueSource{queue: model.ComfyUIQueue{
+			Running: []model.ComfyUIQueueEntry{{Number: 1, PromptID: "p1"}},
+			Pending: []model.ComfyUIQueueEntry{{Number: 2, PromptID: "p2"}, {Number: 3, PromptID: "p3"}},
+		}}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		monitor = service.NewComfyUIStatsMonitor(stats, queue, logger)
+	})
+
+	It("is empty before the first refresh", func() {
+		snapshot := monitor.Snapshot()
+		Expect(snapshot.Devices).To(BeEmpty())
+		Expect(snapshot.UpdatedAt.IsZero()).To(BeTrue())
+	})
+
+	It("caches the devices and queue length", func() {
+		monitor.Refresh(context.Background())
+
+		snapshot := monitor.Snapshot()
+		Expect(snapshot.Devices).To(Equal(stats.stats.Devices))
+		Expect(snapshot.QueueRunning).To(Equal(1))
+		Expect(snapshot.QueuePending).To(Equal(2))
+		Expect(snapshot.UpdatedAt.IsZero()).To(BeFalse())
+		Expect(snapshot.Error).To(BeEmpty())
+	})
+
+	It("keeps the last readings and records the error when a refresh fails", func() {
+		monitor.Refresh(context.Background())
+		updatedAt := monitor.Snapshot().UpdatedAt
+		stats.err = errors.New("connection refused")
+
+		monitor.Refresh(context.Background())
+
+		snapshot := monitor.Snapshot()
+		Expect(snapshot.Error).To(ContainSubstring("connection refused"))
+		Expect(snapshot.Devices).To(HaveLen(1))
+		Expect(snapshot.UpdatedAt).To(Equal(updatedAt))
+	})
+
+	It("clears the error once a refresh succeeds again", func() {
+		queue.err = errors.New("timeout")
+		monitor.Refresh(context.Background())
+		Expect(monitor.Snapshot().Error).To(ContainSubstring("timeout"))
+
+		queue.err = nil
+		monitor.Refresh(context.Background())
+		Expect(monitor.Snapshot().Error).To(BeEmpty())
+	})
+
+	It("refreshes until stopped", func() {
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			monitor.Run(stop)
+		}()
+
+		Eventually(func() int { return monitor.Snapshot().QueuePending }).Should(Equal(2))
+		close(stop)
+		Eventually(done).Should(BeClosed())
+	})
+})
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Comparison thumbnail settings.
+const (
+	comparisonThumbnailMax     = 512
+	comparisonThumbnailQuality = 85
+)
+
+// comparisonIgnoredParameters are metadata keys that identify the image or its
+// provenance rather than how it was generated, and so may differ between the
+// two sides of a comparison.
+var comparisonIgnoredParameters = map[string]bool{
+	"checkpoint": true,
+	"index":      true,
+	"job_id":     true,
+	"timestamp":  true,
+	"commit_sha": true,
+}
+
+// comparisonRequiredParameters must be present on both sides so that the
+// prompt and seed can be verified identical.
+var comparisonRequiredParameters = []string{"prompt_name", "seed"}
+
+// ComparisonImageReader defines the filesystem operations needed to read images.
+type ComparisonImageReader interface {
+	ReadFile(path string) ([]byte, error)
+}
+
+// ComparisonMetadataSource reads sidecar or PNG metadata for an image.
+type ComparisonMetadataSource interface {
+	GetMetadata(relPath string) (*model.ImageMetadataValues, error)
+}
+
+// ComparisonService prepares pairs of images for the A/B comparison slider.
+// It only accepts pairs whose generation parameters match in everything but
+// the checkpoint, so that a comparison never attributes a prompt, seed, or
+// sampler difference to training progress.
+type ComparisonService struct {
+	reader    ComparisonImageReader
+	metadata  ComparisonMetadataSource
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewComparisonService creates a ComparisonService for images under sampleDir.
+func NewComparisonService(reader ComparisonImageReader, metadata ComparisonMetadataSource, sampleDir string, logger *logrus.Logger) *ComparisonService {
+	return &ComparisonService{
+		reader:    reader,
+		metadata:  metadata,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "comparison"),
+	}
+}
+
+// Compare loads both images, verifies that they are comparable, and returns
+// their normalized metadata with aligned thumbnails.
+// Errors contain "invalid path" for unsafe paths, "not found" for missing
+// images, and "not comparable" for pairs that differ in more than the checkpoint.
+func (s *ComparisonService) Compare(pathA string, pathB string) (*model.ImageComparison, error) {
+	s.logger.WithFields(logrus.Fields{
+		"path_a": pathA,
+		"path_b": pathB,
+	}).Trace("entering Compare")
+	defer s.logger.Trace("returning from Compare")
+
+	a, imgA, err := s.loadSide(pathA)
+	if err != nil {
+		return nil, err
+	}
+	b, imgB, err := s.loadSide(pathB)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Width != b.Width || a.Height != b.Height {
+		return nil, fmt.Errorf("images are not comparable: dimensions differ (%dx%d vs %dx%d)", a.Width, a.Height, b.Width, b.Height)
+	}
+
+	for _, key := range comparisonRequiredParameters {
+		if _, ok := a.Parameters[key]; !ok {
+			return nil, fmt.Errorf("images are not comparable: %s is unknown for %s", key, a.Path)
+		}
+		if _, ok := b.Parameters[key]; !ok {
+			return nil, fmt.Errorf("images are not comparable: %s is unknown for %s", key, b.Path)
+		}
+	}
+
+	shared := make(map[string]string)
+	var diffs []string
+	for key, va := range a.Parameters {
+		vb, ok := b.Parameters[key]
+		if !ok {
+			continue
+		}
+		if va != vb {
+			diffs = append(diffs, fmt.Sprintf("%s (%q vs %q)", key, va, vb))
+			continue
+		}
+		shared[key] = va
+	}
+	if len(diffs) > 0 {
+		sort.Strings(diffs)
+		s.logger.WithFields(logrus.Fields{
+			"path_a":      a.Path,
+			"path_b":      b.Path,
+			"differences": diffs,
+		}).Debug("rejected comparison of differing images")
+		return nil, fmt.Errorf("images are not comparable: parameters differ: %s", strings.Join(diffs, ", "))
+	}
+
+	thumbW, thumbH := computeThumbnailDimensions(a.Width, a.Height, comparisonThumbnailMax, comparisonThumbnailMax)
+	if a.Thumbnail, err = encodeComparisonThumbnail(imgA, thumbW, thumbH); err != nil {
+		return nil, err
+	}
+	if b.Thumbnail, err = encodeComparisonThumbnail(imgB, thumbW, thumbH); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"path_a":       a.Path,
+		"path_b":       b.Path,
+		"shared_count": len(shared),
+		"thumb_width":  thumbW,
+		"thumb_height": thumbH,
+	}).Debug("comparison prepared")
+
+	return &model.ImageComparison{
+		A:                a,
+		B:                b,
+		SharedParameters: shared,
+		ThumbnailWidth:   thumbW,
+		ThumbnailHeight:  thumbH,
+	}, nil
+}
+
+// loadSide reads and decodes one image and collects its normalized parameters.
+func (s *ComparisonService) loadSide(relPath string) (model.ComparisonImage, image.Image, error) {
+	if !isPathSafe(relPath) {
+		return model.ComparisonImage{}, nil, fmt.Errorf("invalid path: %q", relPath)
+	}
+	absPath := filepath.Join(s.sampleDir, filepath.FromSlash(relPath))
+	cleanRoot := filepath.Clean(s.sampleDir)
+	if !strings.HasPrefix(filepath.Clean(absPath), cleanRoot+string(filepath.Separator)) {
+		return model.ComparisonImage{}, nil, fmt.Errorf("invalid path: %q", relPath)
+	}
+
+	data, err := s.reader.ReadFile(absPath)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"relative_path": relPath,
+			"error":         err.Error(),
+		}).Debug("comparison image not found")
+		return model.ComparisonImage{}, nil, fmt.Errorf("image %s not found", relPath)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return model.ComparisonImage{}, nil, fmt.Errorf("decoding image %s: %w", relPath, err)
+	}
+
+	params := make(map[string]string)
+	if dims, _ := parseFilename(filepath.Base(absPath)); dims != nil {
+		for k, v := range dims {
+			params[k] = v
+		}
+	}
+	checkpoint := filepath.Base(filepath.Dir(absPath))
+	meta, err := s.metadata.GetMetadata(relPath)
+	if err != nil {
+		// Metadata is best-effort: the filename still carries the key dimensions.
+		s.logger.WithFields(logrus.Fields{
+			"relative_path": relPath,
+			"error":         err.Error(),
+		}).Warn("failed to read image metadata for comparison, using filename only")
+	} else {
+		for k, v := range meta.StringFields {
+			params[k] = v
+		}
+		for k, v := range meta.NumericFields {
+			params[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	if cp := params["checkpoint"]; cp != "" {
+		checkpoint = cp
+	}
+	for key := range comparisonIgnoredParameters {
+		delete(params, key)
+	}
+
+	side := model.ComparisonImage{
+		Path:               relPath,
+		CheckpointFilename: checkpoint,
+		Width:              img.Bounds().Dx(),
+		Height:             img.Bounds().Dy(),
+		Parameters:         params,
+	}
+	if step := extractStepNumber(checkpoint); step >= 0 {
+		side.StepNumber = &step
+	}
+	return side, img, nil
+}
+
+// encodeComparisonThumbnail resizes img to exactly w x h and encodes it as JPEG.
+func encodeComparisonThumbnail(img image.Image, w, h int) ([]byte, error) {
+	var resized image.Image = img
+	if b := img.Bounds(); b.Dx() != w || b.Dy() != h {
+		resized = resizeBilinear(img, w, h)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: comparisonThumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("encoding comparison thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeComparisonMetadata returns canned metadata keyed by relative path.
+type fakeComparisonMetadata struct {
+	values map[string]*model.ImageMetadataValues
+}
+
+func (f *fakeComparisonMetadata) GetMetadata(relPath string) (*model.ImageMetadataValues, error) {
+	v, ok := f.values[relPath]
+	if !ok {
+		return nil, errors.New("opening file: no sidecar")
+	}
+	return v, nil
+}
+
+var _ = Describe("ComparisonService", func() {
+	const (
+		sampleDir = "/samples"
+		pathA     = "run/study/model-step00001000.safetensors/index=0&prompt_name=forest&seed=420&cfg=7&_00001_.png"
+		pathB     = "run/study/model-step00002000.safetensors/index=0&prompt_name=forest&seed=420&cfg=7&_00001_.png"
+		pathFinal = "run/study/model.safetensors/index=0&prompt_name=forest&seed=420&cfg=7&_00001_.png"
+	)
+
+	var (
+		reader   *fakeGridReader
+		metadata *fakeComparisonMetadata
+		svc      *service.ComparisonService
+		gray     = color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xFF}
+	)
+
+	addImage := func(relPath string, w, h int) {
+		reader.files[filepath.Join(sampleDir, relPath)] = solidPNG(w, h, gray)
+	}
+
+	BeforeEach(func() {
+		reader = &fakeGridReader{files: make(map[string][]byte)}
+		metadata = &fakeComparisonMetadata{values: make(map[string]*model.ImageMetadataValues)}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewComparisonService(reader, metadata, sampleDir, logger)
+	})
+
+	It("returns normalized metadata and identically sized thumbnails", func() {
+		addImage(pathA, 1024, 768)
+		addImage(pathB, 1024, 768)
+		metadata.values[pathA] = &model.ImageMetadataValues{
+			StringFields:  map[string]string{"sampler_name": "euler", "checkpoint": "model-step00001000.safetensors", "job_id": "j1"},
+			NumericFields: map[string]float64{"steps": 30},
+		}
+		metadata.values[pathB] = &model.ImageMetadataValues{
+			StringFields:  map[string]string{"sampler_name": "euler", "checkpoint": "model-step00002000.safetensors", "job_id": "j2"},
+			NumericFields: map[string]float64{"steps": 30},
+		}
+
+		cmp, err := svc.Compare(pathA, pathB)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(cmp.A.CheckpointFilename).To(Equal("model-step00001000.safetensors"))
+		Expect(cmp.A.StepNumber).To(HaveValue(Equal(1000)))
+		Expect(cmp.B.StepNumber).To(HaveValue(Equal(2000)))
+		Expect(cmp.A.Width).To(Equal(1024))
+		Expect(cmp.A.Height).To(Equal(768))
+		Expect(cmp.SharedParameters).To(Equal(map[string]string{
+			"prompt_name":  "forest",
+			"seed":         "420",
+			"cfg":          "7",
+			"sampler_name": "euler",
+			"steps":        "30",
+		}))
+		Expect(cmp.A.Parameters).NotTo(HaveKey("job_id"))
+
+		Expect(cmp.ThumbnailWidth).To(Equal(512))
+		Expect(cmp.ThumbnailHeight).To(Equal(384))
+		for _, thumb := range [][]byte{cmp.A.Thumbnail, cmp.B.Thumbnail} {
+			img, err := jpeg.Decode(bytes.NewReader(thumb))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(img.Bounds().Dx()).To(Equal(512))
+			Expect(img.Bounds().Dy()).To(Equal(384))
+		}
+	})
+
+	It("falls back to the filename when metadata is unavailable", func() {
+		addImage(pathA, 64, 64)
+		addImage(pathFinal, 64, 64)
+
+		cmp, err := svc.Compare(pathA, pathFinal)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cmp.B.CheckpointFilename).To(Equal("model.safetensors"))
+		Expect(cmp.B.StepNumber).To(BeNil())
+		Expect(cmp.ThumbnailWidth).To(Equal(64))
+	})
+
+	It("rejects pairs whose generation parameters differ", func() {
+		other := "run/study/model-step00002000.safetensors/index=0&prompt_name=forest&seed=421&cfg=7&_00001_.png"
+		addImage(pathA, 64, 64)
+		addImage(other, 64, 64)
+
+		_, err := svc.Compare(pathA, other)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("not comparable"))
+		Expect(err.Error()).To(ContainSubstring("seed"))
+	})
+
+	It("rejects pairs with differing dimensions", func() {
+		addImage(pathA, 64, 64)
+		addImage(pathB, 64, 32)
+
+		_, err := svc.Compare(pathA, pathB)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("not comparable"))
+	})
+
+	It("rejects images whose seed cannot be verified", func() {
+		noSeed := "run/study/model-step00002000.safetensors/image.png"
+		addImage(pathA, 64, 64)
+		addImage(noSeed, 64, 64)
+
+		_, err := svc.Compare(pathA, noSeed)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("not comparable"))
+	})
+
+	It("reports a missing image as not found", func() {
+		addImage(pathA, 64, 64)
+
+		_, err := svc.Compare(pathA, pathB)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("not found"))
+	})
+
+	DescribeTable("rejects unsafe paths",
+		func(path string) {
+			_, err := svc.Compare(path, pathB)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid path"))
+		},
+		Entry("parent traversal", "../etc/passwd"),
+		Entry("absolute path", "/etc/passwd"),
+		Entry("empty path", ""),
+	)
+})
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ConfigLoader reads and validates the configuration file.
+type ConfigLoader func() (*model.Config, error)
+
+// defaultConfigReloadSettle is how long the config file must go unmodified
+// before a change is reloaded, so that an editor's save (often several
+// writes or a rename) triggers a single reload.
+const defaultConfigReloadSettle = 500 * time.Millisecond
+
+// ConfigReloader re-reads the configuration file at runtime and applies the
+// settings that can change without a restart: checkpoint_dirs,
+// comfyui.url, comfyui.workflow_dir, and log_levels. Components register a handler for
+// each setting they depend on. Other changed settings are reported as
+// requiring a restart and are reported again on every reload until then.
+type ConfigReloader struct {
+	mu      sync.Mutex
+	load    ConfigLoader
+	current model.Config // the running configuration
+	settle  time.Duration
+	logger  *logrus.Entry
+
+	checkpointDirsHandlers []func(dirs []string)
+	comfyUIURLHandlers     []func(url string)
+	workflowDirHandlers    []func(dir string)
+	logLevelsHandlers      []func(levels map[string]string)
+}
+
+// NewConfigReloader creates a ConfigReloader for the running configuration
+// current, reading changes with load.
+func NewConfigReloader(load ConfigLoader, current model.Config, logger *logrus.Logger) *ConfigReloader {
+	if current.ComfyUI != nil {
+		comfyUI := *current.ComfyUI
+		current.ComfyUI = &comfyUI
+	}
+	return &ConfigReloader{
+		load:    load,
+		current: current,
+		settle:  defaultConfigReloadSettle,
+		logger:  logger.WithField("component", "config_reloader"),
+	}
+}
+
+// SetSettleDelay overrides the delay between a config file change and the
+// reload (for testing).
+func (r *ConfigReloader) SetSettleDelay(d time.Duration) {
+	r.settle = d
+}
+
+// OnCheckpointDirs registers a handler called with the new checkpoint
+// directories when checkpoint_dirs changes.
+func (r *ConfigReloader) OnCheckpointDirs(fn func(dirs []string)) {
+	r.checkpointDirsHandlers = append(r.checkpointDirsHandlers, fn)
+}
+
+// OnComfyUIURL registers a handler called with the new URL when comfyui.url
+// changes.
+func (r *ConfigReloader) OnComfyUIURL(fn func(url string)) {
+	r.comfyUIURLHandlers = append(r.comfyUIURLHandlers, fn)
+}
+
+// OnWorkflowDir registers a handler called with the new directory when
+// comfyui.workflow_dir changes.
+func (r *ConfigReloader) OnWorkflowDir(fn func(dir string)) {
+	r.workflowDirHandlers = append(r.workflowDirHandlers, fn)
+}
+
+// OnLogLevels registers a handler called with the new per-component log
+// levels when log_levels changes.
+func (r *ConfigReloader) OnLogLevels(fn func(levels map[string]string)) {
+	r.logLevelsHandlers = append(r.logLevelsHandlers, fn)
+}
+
+// Reload reads the configuration file and applies the settings that changed.
+// If the file cannot be read or is invalid, nothing is applied and an error
+// prefixed with "invalid config" is returned.
+func (r *ConfigReloader) Reload() (model.ConfigReloadResult, error) {
+	r.logger.Trace("entering Reload")
+	defer r.logger.Trace("returning from Reload")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		r.logger.WithError(err).Warn("config reload rejected")
+		return model.ConfigReloadResult{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	result := model.ConfigReloadResult{
+		Applied:         []string{},
+		RestartRequired: []string{},
+	}
+	cur := &r.current
+
+	if !reflect.DeepEqual(cur.CheckpointDirs, next.CheckpointDirs) {
+		for _, fn := range r.checkpointDirsHandlers {
+			fn(next.CheckpointDirs)
+		}
+		cur.CheckpointDirs = next.CheckpointDirs
+		result.Applied = append(result.Applied, "checkpoint_dirs")
+	}
+
+	if !reflect.DeepEqual(cur.LogLevels, next.LogLevels) {
+		for _, fn := range r.logLevelsHandlers {
+			fn(next.LogLevels)
+		}
+		cur.LogLevels = next.LogLevels
+		result.Applied = append(result.Applied, "log_levels")
+	}
+
+	switch {
+	case (cur.ComfyUI == nil) != (next.ComfyUI == nil):
+		// Enabling or disabling ComfyUI changes which services exist.
+		result.RestartRequired = append(result.RestartRequired, "comfyui")
+	case cur.ComfyUI != nil:
+		if cur.ComfyUI.URL != next.ComfyUI.URL {
+			for _, fn := range r.comfyUIURLHandlers {
+				fn(next.ComfyUI.URL)
+			}
+			cur.ComfyUI.URL = next.ComfyUI.URL
+			result.Applied = append(result.Applied, "comfyui.url")
+		}
+		if cur.ComfyUI.WorkflowDir != next.ComfyUI.WorkflowDir {
+			for _, fn := range r.workflowDirHandlers {
+				fn(next.ComfyUI.WorkflowDir)
+			}
+			cur.ComfyUI.WorkflowDir = next.ComfyUI.WorkflowDir
+			result.Applied = append(result.Applied, "comfyui.workflow_dir")
+		}
+		if cur.ComfyUI.ReconnectInterval != next.ComfyUI.ReconnectInterval {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.reconnect_interval")
+		}
+		if cur.ComfyUI.VRAMHardLimitMB != next.ComfyUI.VRAMHardLimitMB {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.vram_hard_limit_mb")
+		}
+		if !reflect.DeepEqual(cur.ComfyUI.FailureLog, next.ComfyUI.FailureLog) {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.failure_log")
+		}
+		if cur.ComfyUI.Prewarm != next.ComfyUI.Prewarm {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.prewarm")
+		}
+		if cur.ComfyUI.DrainTimeout != next.ComfyUI.DrainTimeout {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.drain_timeout")
+		}
+		if cur.ComfyUI.ItemTimeout != next.ComfyUI.ItemTimeout {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.item_timeout")
+		}
+		if cur.ComfyUI.MaxInFlight != next.ComfyUI.MaxInFlight {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.max_in_flight")
+		}
+		if cur.ComfyUI.ModelCacheTTL != next.ComfyUI.ModelCacheTTL {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.model_cache_ttl")
+		}
+		if !reflect.DeepEqual(cur.ComfyUI.CheckpointStaging, next.ComfyUI.CheckpointStaging) {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.checkpoint_staging")
+		}
+		if !reflect.DeepEqual(cur.ComfyUI.PathMapping, next.ComfyUI.PathMapping) {
+			result.RestartRequired = append(result.RestartRequired, "comfyui.path_mapping")
+		}
+	}
+
+	restartOnly := []struct {
+		key       string
+		cur, next interface{}
+	}{
+		{"sample_dir", cur.SampleDir, next.SampleDir},
+		{"port", cur.Port, next.Port},
+		{"grpc_port", cur.GRPCPort, next.GRPCPort},
+		{"ip_address", cur.IPAddress, next.IPAddress},
+		{"db_path", cur.DBPath, next.DBPath},
+		{"thumbnails", cur.Thumbnails, next.Thumbnails},
+		{"ws_ping_interval", cur.WsPingInterval, next.WsPingInterval},
+		{"request_timeout", cur.RequestTimeout, next.RequestTimeout},
+		{"multi_process", cur.MultiProcess, next.MultiProcess},
+		{"auto_sample", cur.AutoSample, next.AutoSample},
+		{"heartbeat", cur.Heartbeat, next.Heartbeat},
+		{"notifications", cur.Notifications, next.Notifications},
+		{"assets", cur.Assets, next.Assets},
+		{"rate_limit", cur.RateLimit, next.RateLimit},
+		{"slow_query_ms", cur.SlowQueryMs, next.SlowQueryMs},
+		{"fault_injection", cur.FaultInjection, next.FaultInjection},
+		{"timezone", cur.Timezone, next.Timezone},
+		{"locale", cur.Locale, next.Locale},
+		{"scoring", cur.Scoring, next.Scoring},
+		{"retention", cur.Retention, next.Retention},
+		{"trash_retention_days", cur.TrashRetentionDays, next.TrashRetentionDays},
+		{"output_layout", cur.OutputLayout, next.OutputLayout},
+		{"users", cur.Users, next.Users},
+	}
+	for _, s := range restartOnly {
+		if !reflect.DeepEqual(s.cur, s.next) {
+			result.RestartRequired = append(result.RestartRequired, s.key)
+		}
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"applied":          result.Applied,
+		"restart_required": result.RestartRequired,
+	}).Info("configuration reloaded")
+	return result, nil
+}
+
+// Watch reloads the configuration whenever the file at path changes, until
+// stop is closed. The file's directory is watched rather than the file
+// itself so that editors which save by replacing the file are followed.
+func (r *ConfigReloader) Watch(notifier WatcherNotifier, path string, stop <-chan struct{}) {
+	r.logger.WithField("config_path", path).Trace("entering Watch")
+	defer r.logger.Trace("returning from Watch")
+
+	path = filepath.Clean(path)
+	if err := notifier.Add(filepath.Dir(path)); err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"config_path": path,
+			"error":       err.Error(),
+		}).Error("failed to watch config file, reload it with POST /api/admin/reload-config instead")
+		return
+	}
+	r.logger.WithField("config_path", path).Info("watching config file for changes")
+
+	var timer *time.Timer
+	var fire <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case ev, ok := <-notifier.Events():
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != path || !ev.Op.Has(fsnotify.Write) && !ev.Op.Has(fsnotify.Create) && !ev.Op.Has(fsnotify.Rename) {
+				continue
+			}
+			// Every change restarts the settle delay.
+			if timer == nil {
+				timer = time.NewTimer(r.settle)
+			} else {
+				timer.Reset(r.settle)
+			}
+			fire = timer.C
+		case err, ok := <-notifier.Errors():
+			if !ok {
+				return
+			}
+			r.logger.WithError(err).Error("config watcher error")
+		case <-fire:
+			fire = nil
+			// Errors are logged by Reload; the previous configuration stays
+			// in effect.
+			_, _ = r.Reload()
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package service_test
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("ConfigReloader", func() {
+	var (
+		logger   *logrus.Logger
+		current  model.Config
+		mu       sync.Mutex
+		next     *model.Config
+		loadErr  error
+		loads    int
+		reloader *service.ConfigReloader
+	)
+
+	load := func() (*model.Config, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		loads++
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		cfg := *next
+		return &cfg, nil
+	}
+
+	setNext := func(fn func(cfg *model.Config)) {
+		mu.Lock()
+		defer mu.Unlock()
+		cfg := current
+		comfyUI := *current.ComfyUI
+		cfg.ComfyUI = &comfyUI
+		fn(&cfg)
+		next = &cfg
+	}
+
+	BeforeEach(func() {
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+		current = model.Config{
+			CheckpointDirs: []string{"/checkpoints"},
+			SampleDir:      "/samples",
+			Port:           8080,
+			ComfyUI: &model.ComfyUIConfig{
+				URL:               "http://comfyui-a:8188",
+				WorkflowDir:       "/workflows",
+				ReconnectInterval: 10,
+			},
+		}
+		loadErr = nil
+		loads = 0
+		setNext(func(cfg *model.Config) {})
+		reloader = service.NewConfigReloader(load, current, logger)
+	})
+
+	Describe("Reload", func() {
+		It("applies nothing when the file is unchanged", func() {
+			result, err := reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Applied).To(BeEmpty())
+			Expect(result.RestartRequired).To(BeEmpty())
+		})
+
+		It("passes changed checkpoint directories to every handler", func() {
+			var first, second []string
+			reloader.OnCheckpointDirs(func(dirs []string) { first = dirs })
+			reloader.OnCheckpointDirs(func(dirs []string) { second = dirs })
+			setNext(func(cfg *model.Config) { cfg.CheckpointDirs = []string{"/checkpoints", "/more"} })
+
+			result, err := reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Applied).To(Equal([]string{"checkpoint_dirs"}))
+			Expect(first).To(Equal([]string{"/checkpoints", "/more"}))
+			Expect(second).To(Equal([]string{"/checkpoints", "/more"}))
+		})
+
+		It("applies the ComfyUI URL and workflow directory", func() {
+			var url, dir string
+			reloader.OnComfyUIURL(func(u string) { url = u })
+			reloader.OnWorkflowDir(func(d string) { dir = d })
+			setNext(func(cfg *model.Config) {
+				cfg.ComfyUI.URL = "http://comfyui-b:8188"
+				cfg.ComfyUI.WorkflowDir = "/other-workflows"
+			})
+
+			result, err := reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Applied).To(Equal([]string{"comfyui.url", "comfyui.workflow_dir"}))
+			Expect(url).To(Equal("http://comfyui-b:8188"))
+			Expect(dir).To(Equal("/other-workflows"))
+		})
+
+		It("applies changed log levels", func() {
+			var levels map[string]string
+			reloader.OnLogLevels(func(l map[string]string) { levels = l })
+			setNext(func(cfg *model.Config) { cfg.LogLevels = map[string]string{"job_executor": "trace"} })
+
+			result, err := reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Applied).To(Equal([]string{"log_levels"}))
+			Expect(levels).To(Equal(map[string]string{"job_executor": "trace"}))
+		})
+
+		It("does not call handlers again for settings already applied", func() {
+			calls := 0
+			reloader.OnComfyUIURL(func(string) { calls++ })
+			setNext(func(cfg *model.Config) { cfg.ComfyUI.URL = "http://comfyui-b:8188" })
+
+			_, err := reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			result, err := reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Applied).To(BeEmpty())
+			Expect(calls).To(Equal(1))
+		})
+
+		DescribeTable("reports settings that need a restart",
+			func(change func(cfg *model.Config), key string) {
+				setNext(change)
+				result, err := reloader.Reload()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Applied).To(BeEmpty())
+				Expect(result.RestartRequired).To(Equal([]string{key}))
+
+				// The running configuration is unchanged, so the setting is
+				// reported again until the server restarts.
+				result, err = reloader.Reload()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RestartRequired).To(Equal([]string{key}))
+			},
+			Entry("sample_dir", func(cfg *model.Config) { cfg.SampleDir = "/elsewhere" }, "sample_dir"),
+			Entry("port", func(cfg *model.Config) { cfg.Port = 9090 }, "port"),
+			Entry("grpc_port", func(cfg *model.Config) { cfg.GRPCPort = 9090 }, "grpc_port"),
+			Entry("comfyui removed", func(cfg *model.Config) { cfg.ComfyUI = nil }, "comfyui"),
+			Entry("comfyui.reconnect_interval", func(cfg *model.Config) { cfg.ComfyUI.ReconnectInterval = 30 }, "comfyui.reconnect_interval"),
+			Entry("comfyui.drain_timeout", func(cfg *model.Config) { cfg.ComfyUI.DrainTimeout = 60 }, "comfyui.drain_timeout"),
+			Entry("comfyui.model_cache_ttl", func(cfg *model.Config) { cfg.ComfyUI.ModelCacheTTL = 300 }, "comfyui.model_cache_ttl"),
+			Entry("comfyui.vram_hard_limit_mb", func(cfg *model.Config) { cfg.ComfyUI.VRAMHardLimitMB = 24000 }, "comfyui.vram_hard_limit_mb"),
+			Entry("comfyui.failure_log", func(cfg *model.Config) {
+				cfg.ComfyUI.FailureLog = &model.ComfyUIFailureLogConfig{Source: model.ComfyUILogSourceAPI, Lines: 50}
+			}, "comfyui.failure_log"),
+			Entry("comfyui.prewarm", func(cfg *model.Config) { cfg.ComfyUI.Prewarm = true }, "comfyui.prewarm"),
+			Entry("comfyui.checkpoint_staging", func(cfg *model.Config) {
+				cfg.ComfyUI.CheckpointStaging = &model.CheckpointStagingConfig{Dir: "/staging", ModelPath: "staging"}
+			}, "comfyui.checkpoint_staging"),
+			Entry("comfyui.path_mapping", func(cfg *model.Config) {
+				cfg.ComfyUI.PathMapping = []model.CheckpointPathRule{{StripPrefix: "run-"}}
+			}, "comfyui.path_mapping"),
+			Entry("thumbnails", func(cfg *model.Config) { cfg.Thumbnails = &model.ThumbnailConfig{Enabled: true} }, "thumbnails"),
+			Entry("assets", func(cfg *model.Config) { cfg.Assets = &model.AssetsConfig{Dir: "/assets"} }, "assets"),
+			Entry("rate_limit", func(cfg *model.Config) { cfg.RateLimit = &model.RateLimitConfig{RequestsPerSecond: 10} }, "rate_limit"),
+			Entry("slow_query_ms", func(cfg *model.Config) { cfg.SlowQueryMs = 500 }, "slow_query_ms"),
+			Entry("notifications", func(cfg *model.Config) {
+				cfg.Notifications = &model.NotificationsConfig{Webhooks: []model.WebhookConfig{{URL: "http://hooks.local", Format: model.WebhookFormatJSON}}}
+			}, "notifications"),
+			Entry("fault_injection", func(cfg *model.Config) { cfg.FaultInjection = &model.FaultInjectionConfig{DatabaseBusy: 0.1} }, "fault_injection"),
+			Entry("timezone", func(cfg *model.Config) { cfg.Timezone = "Europe/Berlin" }, "timezone"),
+			Entry("locale", func(cfg *model.Config) { cfg.Locale = "de-DE" }, "locale"),
+			Entry("scoring", func(cfg *model.Config) { cfg.Scoring = &model.ScoringConfig{URL: "http://scorer.local", Timeout: 30} }, "scoring"),
+			Entry("retention", func(cfg *model.Config) { cfg.Retention = &model.RetentionConfig{KeepLatest: 3, Interval: 24} }, "retention"),
+			Entry("users", func(cfg *model.Config) {
+				cfg.Users = []model.UserConfig{{Name: "alice", Token: "alice-token-0123456789"}}
+			}, "users"),
+		)
+
+		It("applies nothing when the file is invalid", func() {
+			called := false
+			reloader.OnCheckpointDirs(func([]string) { called = true })
+			setNext(func(cfg *model.Config) { cfg.CheckpointDirs = []string{"/more"} })
+			loadErr = errors.New("config: sample_dir is required")
+
+			_, err := reloader.Reload()
+			Expect(err).To(MatchError("invalid config: config: sample_dir is required"))
+			Expect(called).To(BeFalse())
+		})
+	})
+
+	Describe("Watch", func() {
+		var (
+			notifier *fakeNotifier
+			stop     chan struct{}
+			done     chan struct{}
+		)
+
+		BeforeEach(func() {
+			notifier = newFakeNotifier()
+			reloader.SetSettleDelay(20 * time.Millisecond)
+			stop = make(chan struct{})
+			done = make(chan struct{})
+			go func() {
+				defer close(done)
+				reloader.Watch(notifier, "/etc/cs/config.yaml", stop)
+			}()
+		})
+
+		AfterEach(func() {
+			close(stop)
+			Eventually(done).Should(BeClosed())
+		})
+
+		loadCount := func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return loads
+		}
+
+		It("watches the config file's directory", func() {
+			Eventually(notifier.getAdded).Should(Equal([]string{"/etc/cs"}))
+		})
+
+		It("reloads once after a burst of writes settles", func() {
+			Eventually(notifier.getAdded).Should(HaveLen(1))
+			for i := 0; i < 3; i++ {
+				notifier.events <- fsnotify.Event{Name: "/etc/cs/config.yaml", Op: fsnotify.Write}
+			}
+			Eventually(loadCount).Should(Equal(1))
+			Consistently(loadCount, 100*time.Millisecond).Should(Equal(1))
+		})
+
+		It("ignores other files in the directory", func() {
+			Eventually(notifier.getAdded).Should(HaveLen(1))
+			notifier.events <- fsnotify.Event{Name: "/etc/cs/other.yaml", Op: fsnotify.Write}
+			notifier.events <- fsnotify.Event{Name: "/etc/cs/config.yaml", Op: fsnotify.Chmod}
+			Consistently(loadCount, 100*time.Millisecond).Should(BeZero())
+		})
+	})
+})
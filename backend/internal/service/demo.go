@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"bytes"
 	"fmt"
 	"image"
@@ -23,9 +24,9 @@ type DemoFileSystem interface {
 
 // DemoPresetStore defines persistence operations for demo preset management.
 type DemoPresetStore interface {
-	ListPresets() ([]model.Preset, error)
-	CreatePreset(p model.Preset) error
-	DeletePreset(id string) error
+	ListPresets(ctx context.Context) ([]model.Preset, error)
+	CreatePreset(ctx context.Context, p model.Preset) error
+	DeletePreset(ctx context.Context, id string) error
 }
 
 // DemoService manages the demo dataset lifecycle (install, uninstall, status).
@@ -73,19 +74,20 @@ func (s *DemoService) Status() model.DemoStatus {
 // If the demo data already exists, it is a no-op.
 //
 // New layout: sample_dir/demo-model/demo-study/{checkpoint}/
-func (s *DemoService) Install() error {
-	s.logger.Trace("entering Install")
-	defer s.logger.Trace("returning from Install")
+func (s *DemoService) Install(ctx context.Context) error {
+	log := requestLogger(ctx, s.logger)
+	log.Trace("entering Install")
+	defer log.Trace("returning from Install")
 
 	demoDir := s.demoStudyDir()
 	if s.fs.DirectoryExists(demoDir) {
-		s.logger.Debug("demo dataset already installed, skipping")
+		log.Debug("demo dataset already installed, skipping")
 		return nil
 	}
 
 	// Create the demo study output directory (and training run dir as parent)
 	if err := os.MkdirAll(demoDir, 0755); err != nil {
-		s.logger.WithError(err).Error("failed to create demo study directory")
+		log.WithError(err).Error("failed to create demo study directory")
 		return fmt.Errorf("creating demo study directory: %w", err)
 	}
 
@@ -97,35 +99,36 @@ func (s *DemoService) Install() error {
 	}
 
 	// Seed the demo dimension preset
-	if err := s.seedDemoPreset(); err != nil {
-		s.logger.WithError(err).Warn("failed to seed demo preset, demo images still installed")
+	if err := s.seedDemoPreset(ctx); err != nil {
+		log.WithError(err).Warn("failed to seed demo preset, demo images still installed")
 		// Don't fail — images are installed even if preset fails
 	}
 
-	s.logger.Info("demo dataset installed")
+	log.Info("demo dataset installed")
 	return nil
 }
 
 // Uninstall removes the demo dataset and the demo preset.
-func (s *DemoService) Uninstall() error {
-	s.logger.Trace("entering Uninstall")
-	defer s.logger.Trace("returning from Uninstall")
+func (s *DemoService) Uninstall(ctx context.Context) error {
+	log := requestLogger(ctx, s.logger)
+	log.Trace("entering Uninstall")
+	defer log.Trace("returning from Uninstall")
 
 	// Remove the entire training run directory (includes demo-study subdirectory)
 	demoRunDir := s.demoTrainingRunDir()
 	if err := os.RemoveAll(demoRunDir); err != nil {
-		s.logger.WithError(err).Error("failed to remove demo training run directory")
+		log.WithError(err).Error("failed to remove demo training run directory")
 		return fmt.Errorf("removing demo training run directory: %w", err)
 	}
-	s.logger.Debug("demo training run directory removed")
+	log.Debug("demo training run directory removed")
 
 	// Remove demo preset
-	if err := s.removeDemoPreset(); err != nil {
-		s.logger.WithError(err).Warn("failed to remove demo preset")
+	if err := s.removeDemoPreset(ctx); err != nil {
+		log.WithError(err).Warn("failed to remove demo preset")
 		// Don't fail — directory is removed even if preset removal fails
 	}
 
-	s.logger.Info("demo dataset uninstalled")
+	log.Info("demo dataset uninstalled")
 	return nil
 }
 
@@ -188,8 +191,9 @@ func (s *DemoService) generateDemoImages(demoDir string) error {
 }
 
 // seedDemoPreset creates a demo dimension mapping preset if one doesn't already exist.
-func (s *DemoService) seedDemoPreset() error {
-	presets, err := s.presetStore.ListPresets()
+func (s *DemoService) seedDemoPreset(ctx context.Context) error {
+	log := requestLogger(ctx, s.logger)
+	presets, err := s.presetStore.ListPresets(ctx)
 	if err != nil {
 		return fmt.Errorf("listing presets: %w", err)
 	}
@@ -197,7 +201,7 @@ func (s *DemoService) seedDemoPreset() error {
 	// Check if demo preset already exists
 	for _, p := range presets {
 		if p.Name == model.DemoPresetName {
-			s.logger.Debug("demo preset already exists, skipping seed")
+			log.Debug("demo preset already exists, skipping seed")
 			return nil
 		}
 	}
@@ -216,31 +220,32 @@ func (s *DemoService) seedDemoPreset() error {
 		UpdatedAt: now,
 	}
 
-	if err := s.presetStore.CreatePreset(preset); err != nil {
+	if err := s.presetStore.CreatePreset(ctx, preset); err != nil {
 		return fmt.Errorf("creating demo preset: %w", err)
 	}
-	s.logger.WithField("preset_id", preset.ID).Info("demo preset seeded")
+	log.WithField("preset_id", preset.ID).Info("demo preset seeded")
 	return nil
 }
 
 // removeDemoPreset deletes the demo preset by name.
-func (s *DemoService) removeDemoPreset() error {
-	presets, err := s.presetStore.ListPresets()
+func (s *DemoService) removeDemoPreset(ctx context.Context) error {
+	log := requestLogger(ctx, s.logger)
+	presets, err := s.presetStore.ListPresets(ctx)
 	if err != nil {
 		return fmt.Errorf("listing presets: %w", err)
 	}
 
 	for _, p := range presets {
 		if p.Name == model.DemoPresetName {
-			if err := s.presetStore.DeletePreset(p.ID); err != nil {
+			if err := s.presetStore.DeletePreset(ctx, p.ID); err != nil {
 				return fmt.Errorf("deleting demo preset: %w", err)
 			}
-			s.logger.WithField("preset_id", p.ID).Info("demo preset removed")
+			log.WithField("preset_id", p.ID).Info("demo preset removed")
 			return nil
 		}
 	}
 
-	s.logger.Debug("no demo preset found to remove")
+	log.Debug("no demo preset found to remove")
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package service_test
 
 import (
+	"context"
 	"image/png"
 	"os"
 	"path/filepath"
@@ -27,16 +28,16 @@ type fakeDemoPresetStore struct {
 	presets []model.Preset
 }
 
-func (f *fakeDemoPresetStore) ListPresets() ([]model.Preset, error) {
+func (f *fakeDemoPresetStore) ListPresets(ctx context.Context) ([]model.Preset, error) {
 	return f.presets, nil
 }
 
-func (f *fakeDemoPresetStore) CreatePreset(p model.Preset) error {
+func (f *fakeDemoPresetStore) CreatePreset(ctx context.Context, p model.Preset) error {
 	f.presets = append(f.presets, p)
 	return nil
 }
 
-func (f *fakeDemoPresetStore) DeletePreset(id string) error {
+func (f *fakeDemoPresetStore) DeletePreset(ctx context.Context, id string) error {
 	for i, p := range f.presets {
 		if p.ID == id {
 			f.presets = append(f.presets[:i], f.presets[i+1:]...)
@@ -90,7 +91,7 @@ var _ = Describe("DemoService", func() {
 	Describe("Install", func() {
 		// AC: Application ships with a bundled demo dataset (small set of pre-generated sample images)
 		It("creates demo directory structure with training run and study subdirectories", func() {
-			err := svc.Install()
+			err := svc.Install(ctx)
 			Expect(err).NotTo(HaveOccurred())
 
 			// Verify training run dir exists: sample_dir/demo-model/
@@ -120,7 +121,7 @@ var _ = Describe("DemoService", func() {
 		})
 
 		It("creates valid PNG images with query-encoded filenames", func() {
-			err := svc.Install()
+			err := svc.Install(ctx)
 			Expect(err).NotTo(HaveOccurred())
 
 			// Check a specific image exists and is a valid PNG (new layout)
@@ -139,7 +140,7 @@ var _ = Describe("DemoService", func() {
 		})
 
 		It("generates images across all dimension combinations", func() {
-			err := svc.Install()
+			err := svc.Install(ctx)
 			Expect(err).NotTo(HaveOccurred())
 
 			// 3 checkpoints x 2 prompts x 2 seeds x 2 cfgs = 24 images
@@ -158,7 +159,7 @@ var _ = Describe("DemoService", func() {
 
 		// AC: Demo dimension preset is seeded into the database on first run
 		It("seeds the demo dimension preset", func() {
-			err := svc.Install()
+			err := svc.Install(ctx)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(presetStore.presets).To(HaveLen(1))
@@ -178,7 +179,7 @@ var _ = Describe("DemoService", func() {
 			// Update fake fs to reflect the directory exists
 			fs.dirs[demoStudyDir] = true
 
-			err = svc.Install()
+			err = svc.Install(ctx)
 			Expect(err).NotTo(HaveOccurred())
 
 			// Should not have created presets (skipped entirely)
@@ -186,14 +187,14 @@ var _ = Describe("DemoService", func() {
 		})
 
 		It("does not duplicate the preset on repeated installs", func() {
-			err := svc.Install()
+			err := svc.Install(ctx)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(presetStore.presets).To(HaveLen(1))
 
 			// Remove the training run directory to allow reinstall
 			os.RemoveAll(filepath.Join(sampleDir, model.DemoTrainingRunName))
 
-			err = svc.Install()
+			err = svc.Install(ctx)
 			Expect(err).NotTo(HaveOccurred())
 			// Preset should still be just 1 (seed detects existing by name)
 			Expect(presetStore.presets).To(HaveLen(1))
@@ -203,13 +204,13 @@ var _ = Describe("DemoService", func() {
 	Describe("Uninstall", func() {
 		BeforeEach(func() {
 			// Install first
-			err := svc.Install()
+			err := svc.Install(ctx)
 			Expect(err).NotTo(HaveOccurred())
 		})
 
 		// AC: Demo dataset is deletable from the UI
 		It("removes the demo training run directory (and study subdirectory)", func() {
-			err := svc.Uninstall()
+			err := svc.Uninstall(ctx)
 			Expect(err).NotTo(HaveOccurred())
 
 			// Training run directory should be gone
@@ -220,7 +221,7 @@ var _ = Describe("DemoService", func() {
 		It("removes the demo preset", func() {
 			Expect(presetStore.presets).To(HaveLen(1))
 
-			err := svc.Uninstall()
+			err := svc.Uninstall(ctx)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(presetStore.presets).To(HaveLen(0))
@@ -228,11 +229,11 @@ var _ = Describe("DemoService", func() {
 
 		It("does not error when demo directory does not exist", func() {
 			// Uninstall once
-			err := svc.Uninstall()
+			err := svc.Uninstall(ctx)
 			Expect(err).NotTo(HaveOccurred())
 
 			// Uninstall again - should not error
-			err = svc.Uninstall()
+			err = svc.Uninstall(ctx)
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
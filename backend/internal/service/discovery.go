@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/sirupsen/logrus"
@@ -25,14 +27,28 @@ type CheckpointFileSystem interface {
 	DirectoryExists(path string) bool
 }
 
+// TrainingRunConfigSource lists the user-defined training runs.
+type TrainingRunConfigSource interface {
+	ListTrainingRunConfigs(ctx context.Context) ([]model.TrainingRunConfig, error)
+}
+
 // DiscoveryService discovers training runs by scanning checkpoint directories.
 type DiscoveryService struct {
 	fs             CheckpointFileSystem
+	mu             sync.RWMutex
 	checkpointDirs []string
 	sampleDir      string
+	configs        TrainingRunConfigSource // optional; user-defined training runs
 	logger         *logrus.Entry
 }
 
+// compiledRunConfig is a TrainingRunConfig with its patterns compiled.
+type compiledRunConfig struct {
+	config     model.TrainingRunConfig
+	pattern    *regexp.Regexp
+	dimensions []*regexp.Regexp
+}
+
 // NewDiscoveryService creates a discovery service.
 func NewDiscoveryService(fs CheckpointFileSystem, checkpointDirs []string, sampleDir string, logger *logrus.Logger) *DiscoveryService {
 	return &DiscoveryService{
@@ -43,15 +59,43 @@ func NewDiscoveryService(fs CheckpointFileSystem, checkpointDirs []string, sampl
 	}
 }
 
-// Discover scans all checkpoint directories and returns auto-discovered training runs.
-func (d *DiscoveryService) Discover() ([]model.TrainingRun, error) {
+// SetConfigSource sets the source of user-defined training runs. Checkpoints
+// matching a config's pattern are grouped into that training run instead of
+// being grouped by filename.
+func (d *DiscoveryService) SetConfigSource(configs TrainingRunConfigSource) {
+	d.configs = configs
+}
+
+// SetCheckpointDirs replaces the checkpoint directories scanned by Discover.
+// It is called when the configuration is reloaded.
+func (d *DiscoveryService) SetCheckpointDirs(dirs []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkpointDirs = dirs
+}
+
+// Discover scans all checkpoint directories and returns the training runs.
+// Checkpoints matching a user-defined training run belong to that run; the
+// rest are grouped automatically by base filename.
+func (d *DiscoveryService) Discover(ctx context.Context) ([]model.TrainingRun, error) {
 	d.logger.Trace("entering Discover")
 	defer d.logger.Trace("returning from Discover")
 
+	runConfigs, err := d.loadRunConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Map: training run name → list of checkpoints
 	runMap := make(map[string][]model.Checkpoint)
+	// Map: training run name → ID of the config defining it
+	configIDs := make(map[string]string)
 
-	for dirIdx, checkpointDir := range d.checkpointDirs {
+	d.mu.RLock()
+	checkpointDirs := d.checkpointDirs
+	d.mu.RUnlock()
+
+	for dirIdx, checkpointDir := range checkpointDirs {
 		d.logger.WithFields(logrus.Fields{
 			"dir_index": dirIdx,
 			"path":      checkpointDir,
@@ -85,6 +129,18 @@ func (d *DiscoveryService) Discover() ([]model.TrainingRun, error) {
 
 			stepNum := extractStepNumber(filename)
 
+			var dims map[string]string
+			if rc := matchRunConfig(runConfigs, relPath); rc != nil {
+				runName = rc.config.Name
+				configIDs[runName] = rc.config.ID
+				dims = rc.extractDimensions(relPath)
+				if step, ok := dims[model.TrainingRunStepDimension]; ok {
+					if n, err := strconv.Atoi(step); err == nil {
+						stepNum = n
+					}
+				}
+			}
+
 			// Check if sample directory exists
 			sampleDirPath := filepath.Join(d.sampleDir, filename)
 			hasSamples := d.fs.DirectoryExists(sampleDirPath)
@@ -95,6 +151,7 @@ func (d *DiscoveryService) Discover() ([]model.TrainingRun, error) {
 				CheckpointDirIndex: dirIdx,
 				StepNumber:         stepNum,
 				HasSamples:         hasSamples,
+				Dimensions:         dims,
 			}
 
 			runMap[runName] = append(runMap[runName], checkpoint)
@@ -134,6 +191,7 @@ func (d *DiscoveryService) Discover() ([]model.TrainingRun, error) {
 			Name:        name,
 			Checkpoints: checkpoints,
 			HasSamples:  hasSamples,
+			ConfigID:    configIDs[name],
 		})
 	}
 
@@ -146,6 +204,80 @@ func (d *DiscoveryService) Discover() ([]model.TrainingRun, error) {
 	return runs, nil
 }
 
+// loadRunConfigs fetches and compiles the user-defined training runs. Configs
+// with patterns that no longer compile are logged and skipped.
+func (d *DiscoveryService) loadRunConfigs(ctx context.Context) ([]compiledRunConfig, error) {
+	if d.configs == nil {
+		return nil, nil
+	}
+	configs, err := d.configs.ListTrainingRunConfigs(ctx)
+	if err != nil {
+		d.logger.WithError(err).Error("failed to list training run configs")
+		return nil, fmt.Errorf("listing training run configs: %w", err)
+	}
+
+	compiled := make([]compiledRunConfig, 0, len(configs))
+	for _, c := range configs {
+		rc, err := compileRunConfig(c)
+		if err != nil {
+			d.logger.WithFields(logrus.Fields{
+				"config_id": c.ID,
+				"error":     err.Error(),
+			}).Warn("skipping training run config with invalid pattern")
+			continue
+		}
+		compiled = append(compiled, rc)
+	}
+	d.logger.WithField("config_count", len(compiled)).Debug("loaded training run configs")
+	return compiled, nil
+}
+
+// compileRunConfig compiles the run and dimension patterns of c.
+func compileRunConfig(c model.TrainingRunConfig) (compiledRunConfig, error) {
+	pattern, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return compiledRunConfig{}, fmt.Errorf("compiling pattern: %w", err)
+	}
+	dims := make([]*regexp.Regexp, len(c.Dimensions))
+	for i, dim := range c.Dimensions {
+		if dims[i], err = regexp.Compile(dim.Pattern); err != nil {
+			return compiledRunConfig{}, fmt.Errorf("compiling pattern of dimension %q: %w", dim.Name, err)
+		}
+	}
+	return compiledRunConfig{config: c, pattern: pattern, dimensions: dims}, nil
+}
+
+// matchRunConfig returns the first config whose pattern matches relPath, or
+// nil if none does.
+func matchRunConfig(configs []compiledRunConfig, relPath string) *compiledRunConfig {
+	for i := range configs {
+		if configs[i].pattern.MatchString(relPath) {
+			return &configs[i]
+		}
+	}
+	return nil
+}
+
+// extractDimensions returns the value of each dimension whose pattern
+// matches relPath. Int dimensions whose value is not an integer are omitted.
+func (rc *compiledRunConfig) extractDimensions(relPath string) map[string]string {
+	dims := make(map[string]string, len(rc.dimensions))
+	for i, re := range rc.dimensions {
+		m := re.FindStringSubmatch(relPath)
+		if len(m) < 2 {
+			continue
+		}
+		dim := rc.config.Dimensions[i]
+		if dim.Type == model.DimensionTypeInt {
+			if _, err := strconv.Atoi(m[1]); err != nil {
+				continue
+			}
+		}
+		dims[dim.Name] = m[1]
+	}
+	return dims
+}
+
 // stripCheckpointSuffixes removes .safetensors extension and step/epoch suffixes.
 func stripCheckpointSuffixes(filename string) string {
 	// Remove .safetensors extension
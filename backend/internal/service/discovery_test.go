@@ -1,12 +1,15 @@
 package service_test
 
 import (
+	"context"
+	"errors"
 	"io"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
 
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 )
 
@@ -31,6 +34,16 @@ func (f *fakeCheckpointFS) DirectoryExists(path string) bool {
 	return f.dirs[path]
 }
 
+// fakeRunConfigSource implements service.TrainingRunConfigSource for testing.
+type fakeRunConfigSource struct {
+	configs []model.TrainingRunConfig
+	err     error
+}
+
+func (f *fakeRunConfigSource) ListTrainingRunConfigs(ctx context.Context) ([]model.TrainingRunConfig, error) {
+	return f.configs, f.err
+}
+
 var _ = Describe("DiscoveryService", func() {
 	var (
 		fs        *fakeCheckpointFS
@@ -44,6 +57,21 @@ var _ = Describe("DiscoveryService", func() {
 		logger.SetOutput(io.Discard)
 	})
 
+	Describe("SetCheckpointDirs", func() {
+		It("scans the new directories on the next discovery", func() {
+			fs.files["/checkpoints"] = []string{"old-model.safetensors"}
+			fs.files["/reloaded"] = []string{"new-model.safetensors"}
+			discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
+
+			discovery.SetCheckpointDirs([]string{"/reloaded"})
+			runs, err := discovery.Discover(ctx)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Name).To(Equal("new-model"))
+		})
+	})
+
 	Describe("Discover", func() {
 		Context("suffix stripping and grouping", func() {
 			It("groups checkpoint files by base name after stripping step suffix", func() {
@@ -54,7 +82,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs).To(HaveLen(1))
@@ -70,7 +98,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs).To(HaveLen(1))
@@ -86,7 +114,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs).To(HaveLen(2))
@@ -105,7 +133,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs[0].Checkpoints[0].StepNumber).To(Equal(4500))
@@ -117,7 +145,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs[0].Checkpoints[0].StepNumber).To(Equal(104))
@@ -129,7 +157,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs[0].Checkpoints[0].StepNumber).To(Equal(-1))
@@ -143,7 +171,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				cps := runs[0].Checkpoints
@@ -165,7 +193,7 @@ var _ = Describe("DiscoveryService", func() {
 				// model-step00002000.safetensors has no sample dir
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs[0].HasSamples).To(BeTrue())
@@ -184,7 +212,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs[0].HasSamples).To(BeFalse())
@@ -201,7 +229,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints1", "/checkpoints2"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs).To(HaveLen(2))
@@ -216,7 +244,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints1", "/checkpoints2"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				for _, r := range runs {
@@ -234,7 +262,7 @@ var _ = Describe("DiscoveryService", func() {
 				fs.files["/checkpoints"] = []string{}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs).To(BeEmpty())
@@ -250,7 +278,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs).To(HaveLen(3))
@@ -268,7 +296,7 @@ var _ = Describe("DiscoveryService", func() {
 				}
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs).To(HaveLen(1))
@@ -285,11 +313,74 @@ var _ = Describe("DiscoveryService", func() {
 				fs.dirs["/samples/model-step00001000.safetensors"] = true
 				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
 
-				runs, err := discovery.Discover()
+				runs, err := discovery.Discover(ctx)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runs[0].Checkpoints[0].HasSamples).To(BeTrue())
 			})
 		})
+
+		Context("with user-defined training runs", func() {
+			var configs *fakeRunConfigSource
+
+			BeforeEach(func() {
+				fs.files["/checkpoints"] = []string{
+					"qwen/psai4rt-v0.3.0-steps-4500-lr1e4.safetensors",
+					"qwen/psai4rt-v0.3.0-steps-9000-lr1e4.safetensors",
+					"other/model-step00001000.safetensors",
+				}
+				configs = &fakeRunConfigSource{configs: []model.TrainingRunConfig{{
+					ID:      "config-1",
+					Name:    "psai4rt v0.3.0",
+					Pattern: `^qwen/psai4rt-v0\.3\.0-`,
+					Dimensions: []model.TrainingRunDimensionConfig{
+						{Name: "step", Type: model.DimensionTypeInt, Pattern: `-steps-(\d+)-`},
+						{Name: "lr", Type: model.DimensionTypeString, Pattern: `-lr([^.]+)\.`},
+					},
+				}}}
+				discovery = service.NewDiscoveryService(fs, []string{"/checkpoints"}, "/samples", logger)
+				discovery.SetConfigSource(configs)
+			})
+
+			It("groups matching checkpoints into the configured run", func() {
+				runs, err := discovery.Discover(ctx)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs).To(HaveLen(2))
+				Expect(runs[0].Name).To(Equal("other/model"))
+				Expect(runs[0].ConfigID).To(BeEmpty())
+				Expect(runs[1].Name).To(Equal("psai4rt v0.3.0"))
+				Expect(runs[1].ConfigID).To(Equal("config-1"))
+				Expect(runs[1].Checkpoints).To(HaveLen(2))
+			})
+
+			It("extracts dimensions and uses the step dimension as the step number", func() {
+				runs, err := discovery.Discover(ctx)
+
+				Expect(err).NotTo(HaveOccurred())
+				cps := runs[1].Checkpoints
+				Expect(cps[0].StepNumber).To(Equal(4500))
+				Expect(cps[0].Dimensions).To(Equal(map[string]string{"step": "4500", "lr": "1e4"}))
+				Expect(cps[1].StepNumber).To(Equal(9000))
+				Expect(runs[0].Checkpoints[0].Dimensions).To(BeNil())
+			})
+
+			It("skips configs whose pattern does not compile", func() {
+				configs.configs[0].Pattern = "("
+
+				runs, err := discovery.Discover(ctx)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runs).To(HaveLen(3))
+			})
+
+			It("returns an error when the configs cannot be listed", func() {
+				configs.err = errors.New("database locked")
+
+				_, err := discovery.Discover(ctx)
+
+				Expect(err).To(MatchError(ContainSubstring("database locked")))
+			})
+		})
 	})
 })
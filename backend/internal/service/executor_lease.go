@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// LeaseStore defines the persistence operations needed for process leases.
+type LeaseStore interface {
+	AcquireLease(ctx context.Context, name string, holder string, now time.Time, ttl time.Duration) (model.ProcessLease, bool, error)
+	GetLease(ctx context.Context, name string) (model.ProcessLease, error)
+	ReleaseLease(ctx context.Context, name string, holder string) error
+}
+
+// ExecutorLease elects a single active job executor among backend processes
+// sharing one database. The lease is advisory: it is renewed periodically by
+// Run, and a process only considers itself the executor while its most
+// recent successful renewal has not expired.
+type ExecutorLease struct {
+	store      LeaseStore
+	instanceID string
+	ttl        time.Duration
+	logger     *logrus.Entry
+
+	mu        sync.Mutex
+	heldUntil time.Time // zero when the lease is not held
+
+	// timeNow is a function that returns the current time, injected for testability.
+	timeNow func() time.Time
+}
+
+// NewExecutorLease creates an ExecutorLease for the given instance. ttl is how
+// long a renewal keeps the lease before another instance may take it over.
+func NewExecutorLease(store LeaseStore, instanceID string, ttl time.Duration, logger *logrus.Logger) *ExecutorLease {
+	return &ExecutorLease{
+		store:      store,
+		instanceID: instanceID,
+		ttl:        ttl,
+		logger:     logger.WithField("component", "executor_lease"),
+		timeNow:    time.Now,
+	}
+}
+
+// InstanceID returns the identifier this process uses as the lease holder.
+func (l *ExecutorLease) InstanceID() string {
+	return l.instanceID
+}
+
+// Held reports whether this process currently holds an unexpired executor lease.
+func (l *ExecutorLease) Held() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return !l.heldUntil.IsZero() && l.timeNow().Before(l.heldUntil)
+}
+
+// TryAcquire takes or renews the executor lease. Returns whether this process
+// holds the lease afterwards. On a store error the lease is treated as lost
+// once the previous renewal expires, so two executors never run at once.
+func (l *ExecutorLease) TryAcquire(ctx context.Context) (bool, error) {
+	now := l.timeNow()
+	lease, acquired, err := l.store.AcquireLease(ctx, model.ExecutorLeaseName, l.instanceID, now, l.ttl)
+	if err != nil {
+		return l.Held(), fmt.Errorf("acquiring executor lease: %w", err)
+	}
+
+	l.mu.Lock()
+	wasHeld := !l.heldUntil.IsZero() && now.Before(l.heldUntil)
+	if acquired {
+		l.heldUntil = lease.ExpiresAt
+	} else {
+		l.heldUntil = time.Time{}
+	}
+	l.mu.Unlock()
+
+	switch {
+	case acquired && !wasHeld:
+		l.logger.WithField("instance_id", l.instanceID).Info("acquired executor lease")
+	case !acquired && wasHeld:
+		l.logger.WithFields(logrus.Fields{
+			"instance_id": l.instanceID,
+			"holder":      lease.Holder,
+		}).Warn("lost executor lease")
+	case !acquired:
+		l.logger.WithFields(logrus.Fields{
+			"instance_id": l.instanceID,
+			"holder":      lease.Holder,
+			"expires_at":  lease.ExpiresAt,
+		}).Debug("executor lease held by another instance")
+	}
+	return acquired, nil
+}
+
+// Release gives up the executor lease so that a standby process can take over
+// without waiting for it to expire.
+func (l *ExecutorLease) Release(ctx context.Context) error {
+	l.mu.Lock()
+	l.heldUntil = time.Time{}
+	l.mu.Unlock()
+	if err := l.store.ReleaseLease(ctx, model.ExecutorLeaseName, l.instanceID); err != nil {
+		return fmt.Errorf("releasing executor lease: %w", err)
+	}
+	return nil
+}
+
+// Current returns the executor lease as recorded in the database, or nil if
+// no process has taken it yet.
+func (l *ExecutorLease) Current(ctx context.Context) (*model.ProcessLease, error) {
+	lease, err := l.store.GetLease(ctx, model.ExecutorLeaseName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching executor lease: %w", err)
+	}
+	return &lease, nil
+}
+
+// Run renews the lease every third of its TTL until stop is closed, then
+// releases it. The first attempt is made immediately.
+func (l *ExecutorLease) Run(stop <-chan struct{}) {
+	l.logger.Trace("entering Run")
+	defer l.logger.Trace("returning from Run")
+
+	interval := l.ttl / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := l.TryAcquire(context.Background()); err != nil {
+			l.logger.WithError(err).Error("failed to renew executor lease")
+		}
+		select {
+		case <-stop:
+			if err := l.Release(context.Background()); err != nil {
+				l.logger.WithError(err).Error("failed to release executor lease")
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
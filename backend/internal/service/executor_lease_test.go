@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeLeaseStore is an in-memory test double for service.LeaseStore that
+// applies the same grant rules as the SQLite store.
+type fakeLeaseStore struct {
+	lease      *model.ProcessLease
+	acquireErr error
+	released   []string
+}
+
+func (f *fakeLeaseStore) AcquireLease(ctx context.Context, name string, holder string, now time.Time, ttl time.Duration) (model.ProcessLease, bool, error) {
+	if f.acquireErr != nil {
+		return model.ProcessLease{}, false, f.acquireErr
+	}
+	if f.lease == nil || f.lease.Holder == holder || !now.Before(f.lease.ExpiresAt) {
+		acquiredAt := now
+		if f.lease != nil && f.lease.Holder == holder {
+			acquiredAt = f.lease.AcquiredAt
+		}
+		f.lease = &model.ProcessLease{Name: name, Holder: holder, AcquiredAt: acquiredAt, RenewedAt: now, ExpiresAt: now.Add(ttl)}
+	}
+	return *f.lease, f.lease.Holder == holder, nil
+}
+
+func (f *fakeLeaseStore) GetLease(ctx context.Context, name string) (model.ProcessLease, error) {
+	if f.lease == nil {
+		return model.ProcessLease{}, sql.ErrNoRows
+	}
+	return *f.lease, nil
+}
+
+func (f *fakeLeaseStore) ReleaseLease(ctx context.Context, name string, holder string) error {
+	f.released = append(f.released, holder)
+	if f.lease != nil && f.lease.Holder == holder {
+		f.lease = nil
+	}
+	return nil
+}
+
+var _ = Describe("ExecutorLease", func() {
+	var (
+		store  *fakeLeaseStore
+		logger *logrus.Logger
+	)
+
+	BeforeEach(func() {
+		store = &fakeLeaseStore{}
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	})
+
+	It("acquires a free lease and reports it as held", func() {
+		lease := service.NewExecutorLease(store, "a", time.Minute, logger)
+		Expect(lease.Held()).To(BeFalse())
+
+		acquired, err := lease.TryAcquire(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+		Expect(lease.Held()).To(BeTrue())
+
+		current, err := lease.Current(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(current.Holder).To(Equal("a"))
+	})
+
+	It("does not grant a lease held by another instance", func() {
+		first := service.NewExecutorLease(store, "a", time.Minute, logger)
+		second := service.NewExecutorLease(store, "b", time.Minute, logger)
+
+		_, err := first.TryAcquire(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		acquired, err := second.TryAcquire(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acquired).To(BeFalse())
+		Expect(second.Held()).To(BeFalse())
+	})
+
+	It("hands the lease over after release", func() {
+		first := service.NewExecutorLease(store, "a", time.Minute, logger)
+		second := service.NewExecutorLease(store, "b", time.Minute, logger)
+
+		_, err := first.TryAcquire(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first.Release(ctx)).To(Succeed())
+		Expect(first.Held()).To(BeFalse())
+
+		acquired, err := second.TryAcquire(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+	})
+
+	It("keeps the previous renewal on a store error", func() {
+		lease := service.NewExecutorLease(store, "a", time.Minute, logger)
+		_, err := lease.TryAcquire(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		store.acquireErr = errors.New("database is locked")
+		held, err := lease.TryAcquire(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(held).To(BeTrue())
+	})
+
+	It("reports no current lease before any instance takes it", func() {
+		lease := service.NewExecutorLease(store, "a", time.Minute, logger)
+		current, err := lease.Current(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(current).To(BeNil())
+	})
+
+	It("acquires immediately and releases on stop when run", func() {
+		lease := service.NewExecutorLease(store, "a", time.Minute, logger)
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			lease.Run(stop)
+		}()
+
+		Eventually(lease.Held).Should(BeTrue())
+		close(stop)
+		Eventually(done).Should(BeClosed())
+		Expect(lease.Held()).To(BeFalse())
+		Expect(store.released).To(ConsistOf("a"))
+	})
+})
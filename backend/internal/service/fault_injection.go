@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// errInjectedConnectionRefused is returned while a simulated ComfyUI
+// disconnect lasts. Its message is recognised by isConnectionError.
+var errInjectedConnectionRefused = errors.New("injected fault: dial comfyui: connection refused")
+
+// errInjectedDatabaseBusy mimics the error SQLite returns when the database
+// is locked by another connection.
+var errInjectedDatabaseBusy = errors.New("injected fault: database is locked (SQLITE_BUSY)")
+
+// errInjectedWriteFailure mimics a full or read-only disk.
+var errInjectedWriteFailure = errors.New("injected fault: no space left on device")
+
+// FaultInjector wraps the job executor's dependencies so that they fail at
+// random, exercising the executor's recovery paths. It is a development aid
+// for integration tests and demos and must never be enabled in production.
+//
+// A simulated ComfyUI disconnect drops the WebSocket event being delivered,
+// calls the executor's disconnect handler and makes every ComfyUI call fail
+// for DisconnectSeconds. The real connection stays open throughout, so once
+// the outage is over the next Connect succeeds without reconnecting, unless
+// the real connection was lost too.
+type FaultInjector struct {
+	cfg    model.FaultInjectionConfig
+	logger *logrus.Entry
+
+	mu                sync.Mutex
+	rng               *rand.Rand
+	downUntil         time.Time
+	disconnected      bool // a simulated disconnect has not been reconnected from
+	realDisconnect    bool // the real connection was lost since the last Connect
+	disconnectHandler func()
+}
+
+// NewFaultInjector creates a FaultInjector. A zero seed picks a random seed.
+func NewFaultInjector(cfg model.FaultInjectionConfig, logger *logrus.Logger) *FaultInjector {
+	seed := uint64(cfg.Seed)
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &FaultInjector{
+		cfg:    cfg,
+		logger: logger.WithField("component", "fault_injection"),
+		rng:    rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// roll reports whether a fault with the given probability occurs.
+func (f *FaultInjector) roll(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rng.Float64() < probability
+}
+
+// comfyUIDown reports whether a simulated ComfyUI disconnect is in progress.
+func (f *FaultInjector) comfyUIDown() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return time.Now().Before(f.downUntil)
+}
+
+// maybeDisconnect starts a simulated ComfyUI disconnect if one is rolled and
+// none is in progress, calling the disconnect handler. Reports whether
+// ComfyUI is down afterwards.
+func (f *FaultInjector) maybeDisconnect() bool {
+	f.mu.Lock()
+	if time.Now().Before(f.downUntil) {
+		f.mu.Unlock()
+		return true
+	}
+	if f.cfg.ComfyUIDisconnect <= 0 || f.rng.Float64() >= f.cfg.ComfyUIDisconnect {
+		f.mu.Unlock()
+		return false
+	}
+	f.downUntil = time.Now().Add(time.Duration(f.cfg.DisconnectSeconds) * time.Second)
+	f.disconnected = true
+	handler := f.disconnectHandler
+	f.mu.Unlock()
+
+	f.logger.WithField("disconnect_seconds", f.cfg.DisconnectSeconds).Warn("injecting ComfyUI disconnect")
+	if handler != nil {
+		handler()
+	}
+	return true
+}
+
+// ComfyUIClient wraps client so that its calls fail while a simulated
+// disconnect lasts and image downloads are slowed at random.
+func (f *FaultInjector) ComfyUIClient(client ComfyUIClient) ComfyUIClient {
+	return &faultyComfyUIClient{inner: client, faults: f}
+}
+
+// ComfyUIWS wraps ws so that it can be disconnected at random.
+func (f *FaultInjector) ComfyUIWS(ws ComfyUIWS) ComfyUIWS {
+	return &faultyComfyUIWS{ComfyUIWS: ws, faults: f}
+}
+
+// Store wraps store so that its calls fail at random with SQLITE_BUSY.
+func (f *FaultInjector) Store(store JobExecutorStore) JobExecutorStore {
+	return &faultyJobExecutorStore{inner: store, faults: f}
+}
+
+// FileSystem wraps fs so that writing files fails at random.
+func (f *FaultInjector) FileSystem(fs FileSystemWriter) FileSystemWriter {
+	return &faultyFileSystemWriter{FileSystemWriter: fs, faults: f}
+}
+
+// databaseBusy returns an SQLITE_BUSY error if one is rolled for op.
+func (f *FaultInjector) databaseBusy(op string) error {
+	if !f.roll(f.cfg.DatabaseBusy) {
+		return nil
+	}
+	f.logger.WithField("operation", op).Warn("injecting database busy error")
+	return fmt.Errorf("%s: %w", op, errInjectedDatabaseBusy)
+}
+
+// writeFailure returns a write error if one is rolled for path.
+func (f *FaultInjector) writeFailure(path string) error {
+	if !f.roll(f.cfg.FileWriteFailure) {
+		return nil
+	}
+	f.logger.WithField("path", path).Warn("injecting file write failure")
+	return fmt.Errorf("writing %s: %w", path, errInjectedWriteFailure)
+}
+
+// faultyComfyUIClient is the ComfyUIClient returned by FaultInjector.ComfyUIClient.
+type faultyComfyUIClient struct {
+	inner  ComfyUIClient
+	faults *FaultInjector
+}
+
+func (c *faultyComfyUIClient) SubmitPrompt(ctx context.Context, req model.PromptRequest) (*model.PromptResponse, error) {
+	if c.faults.comfyUIDown() {
+		return nil, errInjectedConnectionRefused
+	}
+	return c.inner.SubmitPrompt(ctx, req)
+}
+
+func (c *faultyComfyUIClient) GetHistory(ctx context.Context, promptID string) (model.HistoryResponse, error) {
+	if c.faults.comfyUIDown() {
+		return nil, errInjectedConnectionRefused
+	}
+	return c.inner.GetHistory(ctx, promptID)
+}
+
+func (c *faultyComfyUIClient) DownloadImage(ctx context.Context, filename string, subfolder string, folderType string) ([]byte, error) {
+	if c.faults.comfyUIDown() {
+		return nil, errInjectedConnectionRefused
+	}
+	if c.faults.roll(c.faults.cfg.SlowDownload) {
+		delay := time.Duration(c.faults.cfg.SlowDownloadMs) * time.Millisecond
+		c.faults.logger.WithFields(logrus.Fields{
+			"filename": filename,
+			"delay_ms": c.faults.cfg.SlowDownloadMs,
+		}).Warn("injecting slow image download")
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return c.inner.DownloadImage(ctx, filename, subfolder, folderType)
+}
+
+func (c *faultyComfyUIClient) CancelPrompt(ctx context.Context, promptID string) error {
+	if c.faults.comfyUIDown() {
+		return errInjectedConnectionRefused
+	}
+	return c.inner.CancelPrompt(ctx, promptID)
+}
+
+func (c *faultyComfyUIClient) InterruptPrompt(ctx context.Context, promptID string) error {
+	if c.faults.comfyUIDown() {
+		return errInjectedConnectionRefused
+	}
+	return c.inner.InterruptPrompt(ctx, promptID)
+}
+
+func (c *faultyComfyUIClient) GetQueueStatus(ctx context.Context) (*model.ComfyUIQueue, error) {
+	if c.faults.comfyUIDown() {
+		return nil, errInjectedConnectionRefused
+	}
+	return c.inner.GetQueueStatus(ctx)
+}
+
+// faultyComfyUIWS is the ComfyUIWS returned by FaultInjector.ComfyUIWS.
+type faultyComfyUIWS struct {
+	ComfyUIWS
+	faults *FaultInjector
+}
+
+// AddHandler registers handler so that events are dropped while a simulated
+// disconnect lasts. Each delivered event may start a disconnect.
+func (w *faultyComfyUIWS) AddHandler(handler model.ComfyUIEventHandler) {
+	w.ComfyUIWS.AddHandler(func(event model.ComfyUIEvent) {
+		if w.faults.maybeDisconnect() {
+			return
+		}
+		handler(event)
+	})
+}
+
+// SetDisconnectHandler registers handler for both real and simulated disconnects.
+func (w *faultyComfyUIWS) SetDisconnectHandler(handler func()) {
+	w.faults.mu.Lock()
+	w.faults.disconnectHandler = handler
+	w.faults.mu.Unlock()
+	w.ComfyUIWS.SetDisconnectHandler(func() {
+		w.faults.mu.Lock()
+		w.faults.realDisconnect = true
+		w.faults.mu.Unlock()
+		handler()
+	})
+}
+
+// Connect fails while a simulated disconnect lasts. After one, it succeeds
+// without reconnecting unless the real connection was lost in the meantime.
+func (w *faultyComfyUIWS) Connect(ctx context.Context) error {
+	w.faults.mu.Lock()
+	down := time.Now().Before(w.faults.downUntil)
+	resumed := !down && w.faults.disconnected && !w.faults.realDisconnect
+	if !down {
+		w.faults.disconnected = false
+		w.faults.realDisconnect = false
+	}
+	w.faults.mu.Unlock()
+
+	if down {
+		return errInjectedConnectionRefused
+	}
+	if resumed {
+		w.faults.logger.Info("simulated ComfyUI disconnect over")
+		return nil
+	}
+	return w.ComfyUIWS.Connect(ctx)
+}
+
+// faultyJobExecutorStore is the JobExecutorStore returned by FaultInjector.Store.
+type faultyJobExecutorStore struct {
+	inner  JobExecutorStore
+	faults *FaultInjector
+}
+
+func (s *faultyJobExecutorStore) GetSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
+	if err := s.faults.databaseBusy("getting sample job"); err != nil {
+		return model.SampleJob{}, err
+	}
+	return s.inner.GetSampleJob(ctx, id)
+}
+
+func (s *faultyJobExecutorStore) UpdateSampleJob(ctx context.Context, j *model.SampleJob) error {
+	if err := s.faults.databaseBusy("updating sample job"); err != nil {
+		return err
+	}
+	return s.inner.UpdateSampleJob(ctx, j)
+}
+
+func (s *faultyJobExecutorStore) TransitionSampleJob(ctx context.Context, j *model.SampleJob, from model.SampleJobStatus) error {
+	if err := s.faults.databaseBusy("transitioning sample job"); err != nil {
+		return err
+	}
+	return s.inner.TransitionSampleJob(ctx, j, from)
+}
+
+func (s *faultyJobExecutorStore) ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error) {
+	if err := s.faults.databaseBusy("listing sample job items"); err != nil {
+		return nil, err
+	}
+	return s.inner.ListSampleJobItems(ctx, jobID)
+}
+
+func (s *faultyJobExecutorStore) UpdateSampleJobItem(ctx context.Context, i *model.SampleJobItem) error {
+	if err := s.faults.databaseBusy("updating sample job item"); err != nil {
+		return err
+	}
+	return s.inner.UpdateSampleJobItem(ctx, i)
+}
+
+func (s *faultyJobExecutorStore) ListSampleJobs(ctx context.Context) ([]model.SampleJob, error) {
+	if err := s.faults.databaseBusy("listing sample jobs"); err != nil {
+		return nil, err
+	}
+	return s.inner.ListSampleJobs(ctx)
+}
+
+func (s *faultyJobExecutorStore) GetStudy(ctx context.Context, id string) (model.Study, error) {
+	if err := s.faults.databaseBusy("getting study"); err != nil {
+		return model.Study{}, err
+	}
+	return s.inner.GetStudy(ctx, id)
+}
+
+func (s *faultyJobExecutorStore) ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error) {
+	if err := s.faults.databaseBusy("listing study versions"); err != nil {
+		return nil, err
+	}
+	return s.inner.ListStudyVersions(ctx, studyID)
+}
+
+func (s *faultyJobExecutorStore) GetSampleJobParameters(ctx context.Context, jobID string) (model.SampleJobParameters, error) {
+	if err := s.faults.databaseBusy("getting sample job parameters"); err != nil {
+		return model.SampleJobParameters{}, err
+	}
+	return s.inner.GetSampleJobParameters(ctx, jobID)
+}
+
+func (s *faultyJobExecutorStore) CreateSampleJobParameters(ctx context.Context, p model.SampleJobParameters) error {
+	if err := s.faults.databaseBusy("storing sample job parameters"); err != nil {
+		return err
+	}
+	return s.inner.CreateSampleJobParameters(ctx, p)
+}
+
+// faultyFileSystemWriter is the FileSystemWriter returned by FaultInjector.FileSystem.
+type faultyFileSystemWriter struct {
+	FileSystemWriter
+	faults *FaultInjector
+}
+
+func (w *faultyFileSystemWriter) WriteFile(path string, data []byte, perm uint32) error {
+	if err := w.faults.writeFailure(path); err != nil {
+		return err
+	}
+	return w.FileSystemWriter.WriteFile(path, data, perm)
+}
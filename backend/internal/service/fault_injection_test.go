@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+var _ = Describe("FaultInjector", func() {
+	var logger *logrus.Logger
+
+	BeforeEach(func() {
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	})
+
+	Describe("Store", func() {
+		var inner *mockJobExecutorStore
+		ctx := context.Background()
+
+		BeforeEach(func() {
+			inner = newMockJobExecutorStore()
+			inner.jobs["job-1"] = model.SampleJob{ID: "job-1"}
+		})
+
+		It("fails every call with SQLITE_BUSY at probability 1", func() {
+			st := NewFaultInjector(model.FaultInjectionConfig{DatabaseBusy: 1}, logger).Store(inner)
+
+			_, err := st.GetSampleJob(ctx, "job-1")
+			Expect(err).To(MatchError(errInjectedDatabaseBusy))
+			Expect(err.Error()).To(ContainSubstring("database is locked"))
+			Expect(st.UpdateSampleJob(ctx, &model.SampleJob{ID: "job-2"})).To(MatchError(errInjectedDatabaseBusy))
+			Expect(inner.jobs).NotTo(HaveKey("job-2"))
+			_, err = st.ListSampleJobs(ctx)
+			Expect(err).To(MatchError(errInjectedDatabaseBusy))
+		})
+
+		It("passes calls through at probability 0", func() {
+			st := NewFaultInjector(model.FaultInjectionConfig{}, logger).Store(inner)
+
+			job, err := st.GetSampleJob(ctx, "job-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.ID).To(Equal("job-1"))
+		})
+	})
+
+	Describe("FileSystem", func() {
+		It("fails file writes but not other operations", func() {
+			inner := newMockFileSystemWriter()
+			fs := NewFaultInjector(model.FaultInjectionConfig{FileWriteFailure: 1}, logger).FileSystem(inner)
+
+			Expect(fs.WriteFile("/samples/a.png", []byte("png"), 0644)).To(MatchError(errInjectedWriteFailure))
+			Expect(inner.writtenFiles).To(BeEmpty())
+			Expect(fs.MkdirAll("/samples", 0755)).To(Succeed())
+			Expect(fs.RenameFile("/samples/a.tmp", "/samples/a.png")).To(Succeed())
+		})
+	})
+
+	Describe("ComfyUI disconnects", func() {
+		var (
+			innerWS     *mockComfyUIWS
+			innerClient *mockComfyUIClient
+			ws          ComfyUIWS
+			client      ComfyUIClient
+			events      []model.ComfyUIEvent
+			disconnects int
+		)
+
+		setup := func(cfg model.FaultInjectionConfig) {
+			faults := NewFaultInjector(cfg, logger)
+			innerWS = &mockComfyUIWS{}
+			innerClient = &mockComfyUIClient{promptResponse: &model.PromptResponse{PromptID: "p-1"}}
+			ws = faults.ComfyUIWS(innerWS)
+			client = faults.ComfyUIClient(innerClient)
+			events = nil
+			disconnects = 0
+			ws.AddHandler(func(event model.ComfyUIEvent) { events = append(events, event) })
+			ws.SetDisconnectHandler(func() { disconnects++ })
+		}
+
+		It("drops events and fails ComfyUI calls while the disconnect lasts", func() {
+			setup(model.FaultInjectionConfig{ComfyUIDisconnect: 1, DisconnectSeconds: 3600})
+
+			innerWS.SendEvent(model.ComfyUIEvent{Type: "executing"})
+			innerWS.SendEvent(model.ComfyUIEvent{Type: "executed"})
+			Expect(events).To(BeEmpty())
+			Expect(disconnects).To(Equal(1))
+
+			_, err := client.SubmitPrompt(context.Background(), model.PromptRequest{})
+			Expect(err).To(HaveOccurred())
+			Expect(isConnectionError(err)).To(BeTrue())
+			Expect(innerClient.lastSubmittedReq).To(BeNil())
+			_, err = client.GetHistory(context.Background(), "p-1")
+			Expect(err).To(MatchError(errInjectedConnectionRefused))
+			Expect(ws.Connect(context.Background())).To(MatchError(errInjectedConnectionRefused))
+		})
+
+		It("resumes on the open connection once the disconnect is over", func() {
+			setup(model.FaultInjectionConfig{ComfyUIDisconnect: 1, DisconnectSeconds: 0})
+			innerWS.connectErr = errors.New("already connected")
+
+			innerWS.SendEvent(model.ComfyUIEvent{Type: "executing"})
+			Expect(disconnects).To(Equal(1))
+
+			Expect(ws.Connect(context.Background())).To(Succeed())
+			_, err := client.SubmitPrompt(context.Background(), model.PromptRequest{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reconnects for real if the real connection was lost during the disconnect", func() {
+			setup(model.FaultInjectionConfig{ComfyUIDisconnect: 1, DisconnectSeconds: 0})
+			innerWS.connectErr = errors.New("connection refused")
+
+			innerWS.SendEvent(model.ComfyUIEvent{Type: "executing"})
+			innerWS.SimulateDisconnect()
+			Expect(disconnects).To(Equal(2))
+
+			Expect(ws.Connect(context.Background())).To(MatchError("connection refused"))
+		})
+
+		It("delivers events at probability 0", func() {
+			setup(model.FaultInjectionConfig{})
+
+			innerWS.SendEvent(model.ComfyUIEvent{Type: "executing"})
+			Expect(events).To(HaveLen(1))
+			Expect(disconnects).To(BeZero())
+		})
+	})
+
+	Describe("slow downloads", func() {
+		It("delays the download until the context is done", func() {
+			faults := NewFaultInjector(model.FaultInjectionConfig{SlowDownload: 1, SlowDownloadMs: 60000}, logger)
+			client := faults.ComfyUIClient(&mockComfyUIClient{downloadData: []byte("png")})
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := client.DownloadImage(ctx, "a.png", "", "output")
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("downloads after the delay", func() {
+			faults := NewFaultInjector(model.FaultInjectionConfig{SlowDownload: 1, SlowDownloadMs: 1}, logger)
+			client := faults.ComfyUIClient(&mockComfyUIClient{downloadData: []byte("png")})
+
+			data, err := client.DownloadImage(context.Background(), "a.png", "", "output")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(Equal([]byte("png")))
+		})
+	})
+})
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// gallerySlugBytes is the number of random bytes in a gallery slug. The slug
+// is the only thing guarding a published gallery, so it must be unguessable.
+const gallerySlugBytes = 16
+
+// GalleryStore defines the persistence operations the gallery service needs.
+type GalleryStore interface {
+	ListGalleries(ctx context.Context) ([]model.Gallery, error)
+	GetGalleryBySlug(ctx context.Context, slug string) (model.Gallery, error)
+	CreateGallery(ctx context.Context, g model.Gallery) error
+	DeleteGallery(ctx context.Context, id string) error
+}
+
+// GalleryRunSource discovers the training runs that can be published.
+type GalleryRunSource interface {
+	DiscoverViewable() ([]model.TrainingRun, error)
+}
+
+// GalleryScanner scans a training run's sample images.
+type GalleryScanner interface {
+	ScanTrainingRun(tr model.TrainingRun, studyName string) (*model.ScanResult, error)
+}
+
+// GalleryService publishes read-only snapshots of a training run's images as
+// public galleries.
+type GalleryService struct {
+	store   GalleryStore
+	runs    GalleryRunSource
+	scanner GalleryScanner
+	logger  *logrus.Entry
+}
+
+// NewGalleryService creates a GalleryService.
+func NewGalleryService(store GalleryStore, runs GalleryRunSource, scanner GalleryScanner, logger *logrus.Logger) *GalleryService {
+	return &GalleryService{
+		store:   store,
+		runs:    runs,
+		scanner: scanner,
+		logger:  logger.WithField("component", "gallery"),
+	}
+}
+
+// List returns all published galleries, newest first.
+func (s *GalleryService) List(ctx context.Context) ([]model.Gallery, error) {
+	s.logger.Trace("entering List")
+	defer s.logger.Trace("returning from List")
+
+	galleries, err := s.store.ListGalleries(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list galleries")
+		return nil, fmt.Errorf("listing galleries: %w", err)
+	}
+	s.logger.WithField("gallery_count", len(galleries)).Debug("galleries retrieved from store")
+	if galleries == nil {
+		galleries = []model.Gallery{}
+	}
+	return galleries, nil
+}
+
+// Publish snapshots the images of a training run that match the request's
+// filters into a new gallery with a random slug. The training run is
+// resolved the same way as the training runs scan endpoint.
+func (s *GalleryService) Publish(ctx context.Context, req model.GalleryPublishRequest) (model.Gallery, error) {
+	s.logger.WithFields(logrus.Fields{
+		"training_run_id": req.TrainingRunID,
+		"study_name":      req.StudyName,
+	}).Trace("entering Publish")
+	defer s.logger.Trace("returning from Publish")
+
+	runs, err := s.runs.DiscoverViewable()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to discover training runs")
+		return model.Gallery{}, fmt.Errorf("discovering training runs: %w", err)
+	}
+	if req.TrainingRunID < 0 || req.TrainingRunID >= len(runs) {
+		return model.Gallery{}, fmt.Errorf("training run %d not found", req.TrainingRunID)
+	}
+	tr := runs[req.TrainingRunID]
+
+	studyName := req.StudyName
+	if studyName == "" {
+		studyName = StudyNameForRun(tr.Name)
+	}
+
+	scan, err := s.scanner.ScanTrainingRun(tr, studyName)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"training_run": tr.Name,
+			"error":        err.Error(),
+		}).Error("failed to scan training run")
+		return model.Gallery{}, fmt.Errorf("scanning training run %q: %w", tr.Name, err)
+	}
+
+	known := make(map[string]model.Dimension, len(scan.Dimensions))
+	for _, d := range scan.Dimensions {
+		known[d.Name] = d
+	}
+	for name := range req.Filters {
+		if _, ok := known[name]; !ok {
+			return model.Gallery{}, fmt.Errorf("invalid filter: unknown dimension %q", name)
+		}
+	}
+	for _, name := range req.Dimensions {
+		if _, ok := known[name]; !ok {
+			return model.Gallery{}, fmt.Errorf("invalid dimension %q", name)
+		}
+	}
+
+	var selected []model.Image
+	for _, img := range scan.Images {
+		if matchesGalleryFilters(img, req.Filters) {
+			selected = append(selected, img)
+		}
+	}
+	if len(selected) == 0 {
+		return model.Gallery{}, fmt.Errorf("invalid gallery: no images match the selected filters")
+	}
+
+	dims := galleryDimensions(scan.Dimensions, selected, req.Dimensions)
+	images := make([]model.GalleryImage, len(selected))
+	for i, img := range selected {
+		values := make(map[string]string, len(dims))
+		for _, d := range dims {
+			values[d.Name] = img.Dimensions[d.Name]
+		}
+		images[i] = model.GalleryImage{RelativePath: img.RelativePath, Dimensions: values}
+	}
+
+	slug, err := newGallerySlug()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to generate gallery slug")
+		return model.Gallery{}, err
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = tr.Name
+	}
+
+	g := model.Gallery{
+		ID:              uuid.New().String(),
+		Slug:            slug,
+		Title:           title,
+		TrainingRunName: tr.Name,
+		StudyName:       studyName,
+		Filters:         req.Filters,
+		Dimensions:      dims,
+		Images:          images,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if err := s.store.CreateGallery(ctx, g); err != nil {
+		s.logger.WithError(err).Error("failed to create gallery")
+		return model.Gallery{}, fmt.Errorf("creating gallery: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"gallery_id":   g.ID,
+		"training_run": tr.Name,
+		"image_count":  len(images),
+	}).Info("gallery published")
+	return g, nil
+}
+
+// Unpublish removes a gallery. Its public URLs stop working immediately,
+// although caches may keep serving them until their max-age expires.
+func (s *GalleryService) Unpublish(ctx context.Context, id string) error {
+	s.logger.WithField("gallery_id", id).Trace("entering Unpublish")
+	defer s.logger.Trace("returning from Unpublish")
+
+	if err := s.store.DeleteGallery(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("gallery %s not found", id)
+		}
+		s.logger.WithFields(logrus.Fields{
+			"gallery_id": id,
+			"error":      err.Error(),
+		}).Error("failed to delete gallery")
+		return fmt.Errorf("deleting gallery: %w", err)
+	}
+	s.logger.WithField("gallery_id", id).Info("gallery unpublished")
+	return nil
+}
+
+// GetBySlug returns the published gallery with the given slug.
+func (s *GalleryService) GetBySlug(ctx context.Context, slug string) (model.Gallery, error) {
+	s.logger.WithField("slug", slug).Trace("entering GetBySlug")
+	defer s.logger.Trace("returning from GetBySlug")
+
+	g, err := s.store.GetGalleryBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Gallery{}, fmt.Errorf("gallery not found")
+		}
+		s.logger.WithError(err).Error("failed to get gallery")
+		return model.Gallery{}, fmt.Errorf("getting gallery: %w", err)
+	}
+	return g, nil
+}
+
+// ImagePath returns the sample-relative path of the image at index in the
+// published gallery with the given slug.
+func (s *GalleryService) ImagePath(ctx context.Context, slug string, index int) (string, error) {
+	s.logger.WithFields(logrus.Fields{
+		"slug":  slug,
+		"index": index,
+	}).Trace("entering ImagePath")
+	defer s.logger.Trace("returning from ImagePath")
+
+	g, err := s.GetBySlug(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+	if index < 0 || index >= len(g.Images) {
+		return "", fmt.Errorf("gallery image %d not found", index)
+	}
+	return g.Images[index].RelativePath, nil
+}
+
+// matchesGalleryFilters reports whether img has one of the allowed values for
+// every filtered dimension.
+func matchesGalleryFilters(img model.Image, filters map[string][]string) bool {
+	for name, allowed := range filters {
+		value, ok := img.Dimensions[name]
+		if !ok {
+			return false
+		}
+		found := false
+		for _, v := range allowed {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// galleryDimensions returns the dimensions to show for the selected images,
+// in scan order, with their values narrowed to those present among the
+// images. When names is empty, only dimensions that vary are returned.
+func galleryDimensions(scanned []model.Dimension, images []model.Image, names []string) []model.Dimension {
+	wanted := make(map[string]bool, len(names))
+	for _, n := range names {
+		wanted[n] = true
+	}
+
+	dims := []model.Dimension{}
+	for _, d := range scanned {
+		if len(names) > 0 && !wanted[d.Name] {
+			continue
+		}
+		present := make(map[string]bool)
+		for _, img := range images {
+			if v, ok := img.Dimensions[d.Name]; ok {
+				present[v] = true
+			}
+		}
+		if len(names) == 0 && len(present) < 2 {
+			continue
+		}
+		var values []string
+		for _, v := range d.Values {
+			if present[v] {
+				values = append(values, v)
+			}
+		}
+		dims = append(dims, model.Dimension{Name: d.Name, Type: d.Type, Values: values})
+	}
+	return dims
+}
+
+// newGallerySlug returns a random URL-safe slug.
+func newGallerySlug() (string, error) {
+	b := make([]byte, gallerySlugBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating gallery slug: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,229 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeGalleryStore is an in-memory test double for service.GalleryStore.
+type fakeGalleryStore struct {
+	galleries map[string]model.Gallery
+	createErr error
+}
+
+func newFakeGalleryStore() *fakeGalleryStore {
+	return &fakeGalleryStore{galleries: make(map[string]model.Gallery)}
+}
+
+func (f *fakeGalleryStore) ListGalleries(ctx context.Context) ([]model.Gallery, error) {
+	var result []model.Gallery
+	for _, g := range f.galleries {
+		result = append(result, g)
+	}
+	return result, nil
+}
+
+func (f *fakeGalleryStore) GetGalleryBySlug(ctx context.Context, slug string) (model.Gallery, error) {
+	for _, g := range f.galleries {
+		if g.Slug == slug {
+			return g, nil
+		}
+	}
+	return model.Gallery{}, sql.ErrNoRows
+}
+
+func (f *fakeGalleryStore) CreateGallery(ctx context.Context, g model.Gallery) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.galleries[g.ID] = g
+	return nil
+}
+
+func (f *fakeGalleryStore) DeleteGallery(ctx context.Context, id string) error {
+	if _, ok := f.galleries[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.galleries, id)
+	return nil
+}
+
+// fakeGalleryRuns is a test double for service.GalleryRunSource.
+type fakeGalleryRuns struct {
+	runs []model.TrainingRun
+}
+
+func (f *fakeGalleryRuns) DiscoverViewable() ([]model.TrainingRun, error) {
+	return f.runs, nil
+}
+
+// fakeGalleryScanner returns a fixed scan result and records the study it
+// was asked to scan.
+type fakeGalleryScanner struct {
+	result    *model.ScanResult
+	err       error
+	studyName string
+}
+
+func (f *fakeGalleryScanner) ScanTrainingRun(tr model.TrainingRun, studyName string) (*model.ScanResult, error) {
+	f.studyName = studyName
+	return f.result, f.err
+}
+
+var _ = Describe("GalleryService", func() {
+	var (
+		store   *fakeGalleryStore
+		scanner *fakeGalleryScanner
+		svc     *service.GalleryService
+	)
+
+	image := func(ckpt, seed, prompt string) model.Image {
+		return model.Image{
+			RelativePath: "my-lora/study/" + ckpt + "/seed=" + seed + "&prompt_name=" + prompt + "&_00001_.png",
+			Dimensions:   map[string]string{"checkpoint": ckpt, "seed": seed, "prompt_name": prompt},
+		}
+	}
+
+	BeforeEach(func() {
+		store = newFakeGalleryStore()
+		scanner = &fakeGalleryScanner{result: &model.ScanResult{
+			Images: []model.Image{
+				image("a.safetensors", "1", "forest"),
+				image("a.safetensors", "2", "forest"),
+				image("b.safetensors", "1", "forest"),
+				image("b.safetensors", "2", "forest"),
+			},
+			Dimensions: []model.Dimension{
+				{Name: "checkpoint", Type: model.DimensionTypeString, Values: []string{"a.safetensors", "b.safetensors"}},
+				{Name: "prompt_name", Type: model.DimensionTypeString, Values: []string{"forest"}},
+				{Name: "seed", Type: model.DimensionTypeInt, Values: []string{"1", "2"}},
+			},
+		}}
+		runs := &fakeGalleryRuns{runs: []model.TrainingRun{{Name: "my-lora/study/my-lora"}}}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewGalleryService(store, runs, scanner, logger)
+	})
+
+	Describe("Publish", func() {
+		It("snapshots the images matching the filters", func() {
+			g, err := svc.Publish(ctx, model.GalleryPublishRequest{
+				TrainingRunID: 0,
+				Title:         "  My LoRA  ",
+				Filters:       map[string][]string{"seed": {"1"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(g.Title).To(Equal("My LoRA"))
+			Expect(g.TrainingRunName).To(Equal("my-lora/study/my-lora"))
+			Expect(g.StudyName).To(Equal("my-lora/study"))
+			Expect(scanner.studyName).To(Equal("my-lora/study"))
+			Expect(g.Images).To(HaveLen(2))
+			Expect(g.Images[0].Dimensions).To(Equal(map[string]string{"checkpoint": "a.safetensors"}))
+			Expect(store.galleries).To(HaveKey(g.ID))
+		})
+
+		It("shows only the dimensions that vary by default", func() {
+			g, err := svc.Publish(ctx, model.GalleryPublishRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(g.Dimensions).To(HaveLen(2))
+			Expect(g.Dimensions[0].Name).To(Equal("checkpoint"))
+			Expect(g.Dimensions[1].Name).To(Equal("seed"))
+		})
+
+		It("shows the requested dimensions with values narrowed to the published images", func() {
+			g, err := svc.Publish(ctx, model.GalleryPublishRequest{
+				Filters:    map[string][]string{"checkpoint": {"b.safetensors"}},
+				Dimensions: []string{"checkpoint", "prompt_name"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(g.Dimensions).To(Equal([]model.Dimension{
+				{Name: "checkpoint", Type: model.DimensionTypeString, Values: []string{"b.safetensors"}},
+				{Name: "prompt_name", Type: model.DimensionTypeString, Values: []string{"forest"}},
+			}))
+		})
+
+		It("defaults the title to the training run name", func() {
+			g, err := svc.Publish(ctx, model.GalleryPublishRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(g.Title).To(Equal("my-lora/study/my-lora"))
+		})
+
+		It("generates distinct unguessable slugs", func() {
+			g1, err := svc.Publish(ctx, model.GalleryPublishRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			g2, err := svc.Publish(ctx, model.GalleryPublishRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(g1.Slug).To(HaveLen(22))
+			Expect(g1.Slug).NotTo(Equal(g2.Slug))
+		})
+
+		DescribeTable("rejects invalid requests",
+			func(req model.GalleryPublishRequest, msg string) {
+				_, err := svc.Publish(ctx, req)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(msg))
+				Expect(store.galleries).To(BeEmpty())
+			},
+			Entry("unknown training run", model.GalleryPublishRequest{TrainingRunID: 3}, "not found"),
+			Entry("unknown filter dimension", model.GalleryPublishRequest{Filters: map[string][]string{"cfg": {"7"}}}, "invalid filter"),
+			Entry("unknown shown dimension", model.GalleryPublishRequest{Dimensions: []string{"cfg"}}, "invalid dimension"),
+			Entry("no matching images", model.GalleryPublishRequest{Filters: map[string][]string{"seed": {"9"}}}, "invalid gallery"),
+		)
+
+		It("propagates scan errors", func() {
+			scanner.err = errors.New("disk gone")
+			_, err := svc.Publish(ctx, model.GalleryPublishRequest{})
+			Expect(err).To(MatchError(ContainSubstring("disk gone")))
+		})
+	})
+
+	Describe("ImagePath", func() {
+		It("resolves an image by index", func() {
+			g, err := svc.Publish(ctx, model.GalleryPublishRequest{})
+			Expect(err).NotTo(HaveOccurred())
+
+			relPath, err := svc.ImagePath(ctx, g.Slug, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(relPath).To(Equal(g.Images[1].RelativePath))
+		})
+
+		DescribeTable("reports not found",
+			func(slug string, index int) {
+				g, err := svc.Publish(ctx, model.GalleryPublishRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				if slug == "" {
+					slug = g.Slug
+				}
+				_, err = svc.ImagePath(ctx, slug, index)
+				Expect(err).To(MatchError(ContainSubstring("not found")))
+			},
+			Entry("unknown slug", "nope", 0),
+			Entry("negative index", "", -1),
+			Entry("index past the end", "", 4),
+		)
+	})
+
+	Describe("Unpublish", func() {
+		It("removes the gallery", func() {
+			g, err := svc.Publish(ctx, model.GalleryPublishRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(svc.Unpublish(ctx, g.ID)).To(Succeed())
+
+			_, err = svc.GetBySlug(ctx, g.Slug)
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+
+		It("reports not found for an unknown gallery", func() {
+			Expect(svc.Unpublish(ctx, "missing")).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+})
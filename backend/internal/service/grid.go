@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"path/filepath"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Grid layout defaults and limits.
+const (
+	// DefaultGridCellSize is the cell size used when the spec does not set one.
+	DefaultGridCellSize = 256
+	// MinGridCellSize and MaxGridCellSize bound the per-cell size in pixels.
+	MinGridCellSize = 32
+	MaxGridCellSize = 1024
+	// MaxGridCells bounds the number of cells in a single grid so that one
+	// request cannot allocate an arbitrarily large canvas.
+	MaxGridCells = 400
+
+	gridGap        = 4
+	gridPadding    = 8
+	gridLabelScale = 2
+	// gridMaxRowLabelChars caps the row label column width.
+	gridMaxRowLabelChars = 32
+)
+
+var (
+	gridBackground = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	gridEmptyCell  = color.RGBA{R: 0xE0, G: 0xE0, B: 0xE0, A: 0xFF}
+	gridLabelColor = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xFF}
+)
+
+// GridImageReader defines the filesystem operations needed to read source images.
+type GridImageReader interface {
+	ReadFile(path string) ([]byte, error)
+}
+
+// GridRenderer composites scanned sample images into a single labeled PNG,
+// with one dimension on each axis.
+type GridRenderer struct {
+	reader    GridImageReader
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewGridRenderer creates a GridRenderer that reads images relative to sampleDir.
+func NewGridRenderer(reader GridImageReader, sampleDir string, logger *logrus.Logger) *GridRenderer {
+	return &GridRenderer{
+		reader:    reader,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "grid_renderer"),
+	}
+}
+
+// Render lays out the images from scan according to spec and returns the
+// encoded PNG. Columns are the values of spec.XAxis and rows the values of
+// spec.YAxis; each cell holds the first image (by relative path) matching the
+// cell's axis values and all filters. Cells without a matching image, or whose
+// image cannot be read, are left blank. Spec problems are returned as errors
+// containing "invalid grid spec".
+func (r *GridRenderer) Render(scan *model.ScanResult, spec model.GridSpec) ([]byte, error) {
+	r.logger.WithFields(logrus.Fields{
+		"x_axis": spec.XAxis,
+		"y_axis": spec.YAxis,
+	}).Trace("entering Render")
+	defer r.logger.Trace("returning from Render")
+
+	cellSize := spec.CellSize
+	if cellSize == 0 {
+		cellSize = DefaultGridCellSize
+	}
+	if cellSize < MinGridCellSize || cellSize > MaxGridCellSize {
+		return nil, fmt.Errorf("invalid grid spec: cell size must be between %d and %d", MinGridCellSize, MaxGridCellSize)
+	}
+
+	xValues, err := gridAxisValues(scan, spec.XAxis, spec.XValues)
+	if err != nil {
+		return nil, err
+	}
+	yValues, err := gridAxisValues(scan, spec.YAxis, spec.YValues)
+	if err != nil {
+		return nil, err
+	}
+	if spec.XAxis == spec.YAxis {
+		return nil, fmt.Errorf("invalid grid spec: x and y axes must differ")
+	}
+	if len(xValues)*len(yValues) > MaxGridCells {
+		return nil, fmt.Errorf("invalid grid spec: %d cells exceeds the maximum of %d", len(xValues)*len(yValues), MaxGridCells)
+	}
+
+	cells := selectGridCells(scan.Images, spec, xValues, yValues)
+
+	// Labels read "dim=value" so the axis mapping is visible in the artifact.
+	colLabels := make([]string, len(xValues))
+	maxColChars := (cellSize - gridPadding) / (glyphAdvance * gridLabelScale)
+	for i, v := range xValues {
+		colLabels[i] = truncateLabel(spec.XAxis+"="+v, maxColChars)
+	}
+	rowLabels := make([]string, len(yValues))
+	rowLabelWidth := 0
+	for i, v := range yValues {
+		rowLabels[i] = truncateLabel(spec.YAxis+"="+v, gridMaxRowLabelChars)
+		if w := textWidth(rowLabels[i], gridLabelScale); w > rowLabelWidth {
+			rowLabelWidth = w
+		}
+	}
+	rowLabelWidth += 2 * gridPadding
+	headerHeight := glyphHeight*gridLabelScale + 2*gridPadding
+
+	width := rowLabelWidth + len(xValues)*(cellSize+gridGap) + gridGap
+	height := headerHeight + len(yValues)*(cellSize+gridGap) + gridGap
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: gridBackground}, image.Point{}, draw.Src)
+
+	for col, label := range colLabels {
+		cellX := rowLabelWidth + col*(cellSize+gridGap)
+		drawText(canvas, cellX+(cellSize-textWidth(label, gridLabelScale))/2, gridPadding, label, gridLabelScale, gridLabelColor)
+	}
+
+	placed := 0
+	for row, label := range rowLabels {
+		cellY := headerHeight + row*(cellSize+gridGap)
+		drawText(canvas, gridPadding, cellY+(cellSize-glyphHeight*gridLabelScale)/2, label, gridLabelScale, gridLabelColor)
+
+		for col := range xValues {
+			cellX := rowLabelWidth + col*(cellSize+gridGap)
+			cellRect := image.Rect(cellX, cellY, cellX+cellSize, cellY+cellSize)
+
+			img, ok := cells[[2]int{row, col}]
+			if !ok {
+				draw.Draw(canvas, cellRect, &image.Uniform{C: gridEmptyCell}, image.Point{}, draw.Src)
+				continue
+			}
+			src, err := r.loadCellImage(img.RelativePath, cellSize)
+			if err != nil {
+				r.logger.WithFields(logrus.Fields{
+					"relative_path": img.RelativePath,
+					"error":         err.Error(),
+				}).Warn("failed to load grid cell image, leaving cell blank")
+				draw.Draw(canvas, cellRect, &image.Uniform{C: gridEmptyCell}, image.Point{}, draw.Src)
+				continue
+			}
+			b := src.Bounds()
+			offset := image.Pt(cellX+(cellSize-b.Dx())/2, cellY+(cellSize-b.Dy())/2)
+			draw.Draw(canvas, image.Rectangle{Min: offset, Max: offset.Add(b.Size())}, src, b.Min, draw.Over)
+			placed++
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("encoding grid PNG: %w", err)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"columns":    len(xValues),
+		"rows":       len(yValues),
+		"placed":     placed,
+		"width":      width,
+		"height":     height,
+		"size_bytes": buf.Len(),
+	}).Debug("grid rendered")
+
+	return buf.Bytes(), nil
+}
+
+// loadCellImage reads and decodes the image at relPath, downscaling it to fit
+// within a cellSize x cellSize box while preserving the aspect ratio.
+func (r *GridRenderer) loadCellImage(relPath string, cellSize int) (image.Image, error) {
+	data, err := r.reader.ReadFile(filepath.Join(r.sampleDir, filepath.FromSlash(relPath)))
+	if err != nil {
+		return nil, fmt.Errorf("reading image: %w", err)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	b := src.Bounds()
+	w, h := computeThumbnailDimensions(b.Dx(), b.Dy(), cellSize, cellSize)
+	if w == b.Dx() && h == b.Dy() {
+		return src, nil
+	}
+	return resizeBilinear(src, w, h), nil
+}
+
+// gridAxisValues returns the values to lay out along an axis. The dimension
+// must exist in the scan; requested values must be a subset of its values.
+func gridAxisValues(scan *model.ScanResult, dimName string, requested []string) ([]string, error) {
+	if dimName == "" {
+		return nil, fmt.Errorf("invalid grid spec: axis dimension is required")
+	}
+	var dim *model.Dimension
+	for i := range scan.Dimensions {
+		if scan.Dimensions[i].Name == dimName {
+			dim = &scan.Dimensions[i]
+			break
+		}
+	}
+	if dim == nil {
+		return nil, fmt.Errorf("invalid grid spec: unknown dimension %q", dimName)
+	}
+	if len(requested) == 0 {
+		return dim.Values, nil
+	}
+	known := make(map[string]struct{}, len(dim.Values))
+	for _, v := range dim.Values {
+		known[v] = struct{}{}
+	}
+	for _, v := range requested {
+		if _, ok := known[v]; !ok {
+			return nil, fmt.Errorf("invalid grid spec: dimension %q has no value %q", dimName, v)
+		}
+	}
+	return requested, nil
+}
+
+// selectGridCells maps each (row, col) cell to the first image matching the
+// cell's axis values and all filters. Images are expected in relative-path order.
+func selectGridCells(images []model.Image, spec model.GridSpec, xValues, yValues []string) map[[2]int]model.Image {
+	colIndex := make(map[string]int, len(xValues))
+	for i, v := range xValues {
+		colIndex[v] = i
+	}
+	rowIndex := make(map[string]int, len(yValues))
+	for i, v := range yValues {
+		rowIndex[v] = i
+	}
+
+	cells := make(map[[2]int]model.Image)
+	for _, img := range images {
+		if !matchesFilters(img, spec.Filters) {
+			continue
+		}
+		col, ok := colIndex[img.Dimensions[spec.XAxis]]
+		if !ok {
+			continue
+		}
+		row, ok := rowIndex[img.Dimensions[spec.YAxis]]
+		if !ok {
+			continue
+		}
+		key := [2]int{row, col}
+		if _, taken := cells[key]; !taken {
+			cells[key] = img
+		}
+	}
+	return cells
+}
+
+// matchesFilters reports whether the image has every filtered dimension value.
+func matchesFilters(img model.Image, filters map[string]string) bool {
+	for name, want := range filters {
+		if img.Dimensions[name] != want {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateLabel shortens s to at most maxChars runes, marking the cut with "..".
+func truncateLabel(s string, maxChars int) string {
+	runes := []rune(s)
+	if maxChars <= 0 {
+		return ""
+	}
+	if len(runes) <= maxChars {
+		return s
+	}
+	if maxChars <= 2 {
+		return string(runes[:maxChars])
+	}
+	return string(runes[:maxChars-2]) + ".."
+}
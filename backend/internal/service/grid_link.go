@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// GridLinkVersion is the version of the grid deep link format written by
+// GridLinkService. Bump it when a parameter changes meaning, and keep
+// decoding older versions so that links already sent remain valid.
+const GridLinkVersion = 1
+
+// gridLinkFilterPrefix prefixes the query parameters holding filter values.
+const gridLinkFilterPrefix = "f."
+
+// GridLinkService builds deep links that open the comparison grid for a
+// training run with a given axis mapping and filters, so that every link
+// sent outside the browser (e.g. in a job notification) uses one format.
+//
+// Format (version 1), as query parameters of the UI URL:
+//
+//	v=1                  format version
+//	run=<name>           training run name
+//	study=<name>         optional study name
+//	x=<dimension>        optional dimension on the X axis
+//	y=<dimension>        optional dimension on the Y axis
+//	slider=<dimension>   optional dimension on the slider
+//	f.<dimension>=<value> one parameter per allowed value of a filtered dimension
+type GridLinkService struct {
+	baseURL string
+}
+
+// NewGridLinkService creates a GridLinkService. baseURL is the externally
+// reachable URL of the UI (e.g. "http://studio.lan:8080"); when empty, links
+// are relative to the UI root.
+func NewGridLinkService(baseURL string) *GridLinkService {
+	return &GridLinkService{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// URL returns the deep link for link. Parameters are sorted so that equal
+// links produce identical URLs.
+func (s *GridLinkService) URL(link model.GridLink) string {
+	q := url.Values{}
+	q.Set("v", strconv.Itoa(GridLinkVersion))
+	q.Set("run", link.TrainingRun)
+	setIfNotEmpty(q, "study", link.StudyName)
+	setIfNotEmpty(q, "x", link.XAxis)
+	setIfNotEmpty(q, "y", link.YAxis)
+	setIfNotEmpty(q, "slider", link.SliderAxis)
+	for dim, values := range link.Filters {
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		for _, v := range sorted {
+			q.Add(gridLinkFilterPrefix+dim, v)
+		}
+	}
+	return s.baseURL + "/?" + q.Encode()
+}
+
+// ParseGridLink decodes the query string of a grid deep link. Returns an
+// error prefixed with "invalid grid link" if the version is missing or
+// unsupported, or the training run is missing.
+func ParseGridLink(rawQuery string) (model.GridLink, error) {
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return model.GridLink{}, fmt.Errorf("invalid grid link: %w", err)
+	}
+	version, err := strconv.Atoi(q.Get("v"))
+	if err != nil || version < 1 || version > GridLinkVersion {
+		return model.GridLink{}, fmt.Errorf("invalid grid link: unsupported version %q", q.Get("v"))
+	}
+	link := model.GridLink{
+		TrainingRun: q.Get("run"),
+		StudyName:   q.Get("study"),
+		XAxis:       q.Get("x"),
+		YAxis:       q.Get("y"),
+		SliderAxis:  q.Get("slider"),
+	}
+	if link.TrainingRun == "" {
+		return model.GridLink{}, fmt.Errorf("invalid grid link: missing training run")
+	}
+	for key, values := range q {
+		dim, ok := strings.CutPrefix(key, gridLinkFilterPrefix)
+		if !ok || dim == "" {
+			continue
+		}
+		if link.Filters == nil {
+			link.Filters = make(map[string][]string)
+		}
+		link.Filters[dim] = values
+	}
+	return link, nil
+}
+
+func setIfNotEmpty(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
@@ -0,0 +1,58 @@
+package service_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("GridLinkService", func() {
+	Describe("URL", func() {
+		It("encodes the training run, axes, and filters in a stable order", func() {
+			svc := service.NewGridLinkService("http://studio.lan:8080/")
+
+			link := svc.URL(model.GridLink{
+				TrainingRun: "qwen/model-v1",
+				StudyName:   "portraits",
+				XAxis:       "cfg",
+				YAxis:       "checkpoint",
+				Filters:     map[string][]string{"seed": {"42", "7"}, "prompt_name": {"forest"}},
+			})
+
+			Expect(link).To(Equal("http://studio.lan:8080/?f.prompt_name=forest&f.seed=42&f.seed=7&run=qwen%2Fmodel-v1&study=portraits&v=1&x=cfg&y=checkpoint"))
+		})
+
+		It("builds relative links without a base URL", func() {
+			svc := service.NewGridLinkService("")
+			Expect(svc.URL(model.GridLink{TrainingRun: "run"})).To(Equal("/?run=run&v=1"))
+		})
+	})
+
+	Describe("ParseGridLink", func() {
+		It("round-trips a link built by URL", func() {
+			want := model.GridLink{
+				TrainingRun: "qwen/model-v1",
+				XAxis:       "cfg",
+				SliderAxis:  "step",
+				Filters:     map[string][]string{"seed": {"42", "7"}},
+			}
+			link := service.NewGridLinkService("").URL(want)
+
+			got, err := service.ParseGridLink(link[len("/?"):])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(Equal(want))
+		})
+
+		DescribeTable("rejects invalid links",
+			func(query, msg string) {
+				_, err := service.ParseGridLink(query)
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+			},
+			Entry("missing version", "run=a", "unsupported version"),
+			Entry("future version", "v=2&run=a", "unsupported version"),
+			Entry("missing training run", "v=1&x=cfg", "missing training run"),
+		)
+	})
+})
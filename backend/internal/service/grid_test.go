@@ -0,0 +1,182 @@
+package service_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeGridReader serves image bytes from memory keyed by absolute path.
+type fakeGridReader struct {
+	files map[string][]byte
+}
+
+func (f *fakeGridReader) ReadFile(path string) ([]byte, error) {
+	data, ok := f.files[path]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	return data, nil
+}
+
+// solidPNG returns an encoded w x h PNG filled with c.
+func solidPNG(w, h int, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	Expect(png.Encode(&buf, img)).To(Succeed())
+	return buf.Bytes()
+}
+
+var _ = Describe("GridRenderer", func() {
+	const sampleDir = "/samples"
+
+	var (
+		reader   *fakeGridReader
+		renderer *service.GridRenderer
+		scan     *model.ScanResult
+		red      = color.RGBA{R: 0xFF, A: 0xFF}
+		blue     = color.RGBA{B: 0xFF, A: 0xFF}
+		green    = color.RGBA{G: 0xFF, A: 0xFF}
+	)
+
+	addImage := func(relPath string, dims map[string]string, c color.Color) {
+		reader.files[filepath.Join(sampleDir, relPath)] = solidPNG(64, 64, c)
+		scan.Images = append(scan.Images, model.Image{RelativePath: relPath, Dimensions: dims})
+	}
+
+	decode := func(data []byte) image.Image {
+		img, err := png.Decode(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		return img
+	}
+
+	BeforeEach(func() {
+		reader = &fakeGridReader{files: make(map[string][]byte)}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		renderer = service.NewGridRenderer(reader, sampleDir, logger)
+		scan = &model.ScanResult{
+			Dimensions: []model.Dimension{
+				{Name: "checkpoint", Type: model.DimensionTypeInt, Values: []string{"1000", "2000"}},
+				{Name: "cfg", Type: model.DimensionTypeInt, Values: []string{"3", "7"}},
+				{Name: "prompt_name", Type: model.DimensionTypeString, Values: []string{"city", "forest"}},
+			},
+		}
+		addImage("s/a.safetensors/cfg=3&prompt_name=forest.png", map[string]string{"checkpoint": "1000", "cfg": "3", "prompt_name": "forest"}, red)
+		addImage("s/a.safetensors/cfg=7&prompt_name=forest.png", map[string]string{"checkpoint": "1000", "cfg": "7", "prompt_name": "forest"}, blue)
+		addImage("s/b.safetensors/cfg=3&prompt_name=city.png", map[string]string{"checkpoint": "2000", "cfg": "3", "prompt_name": "city"}, green)
+		addImage("s/b.safetensors/cfg=3&prompt_name=forest.png", map[string]string{"checkpoint": "2000", "cfg": "3", "prompt_name": "forest"}, blue)
+	})
+
+	It("places each matching image in its axis cell and leaves missing cells blank", func() {
+		data, err := renderer.Render(scan, model.GridSpec{
+			XAxis:    "cfg",
+			YAxis:    "checkpoint",
+			Filters:  map[string]string{"prompt_name": "forest"},
+			CellSize: 64,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		img := decode(data)
+		b := img.Bounds()
+		// Two columns and two rows of 64px cells plus labels and gaps.
+		Expect(b.Dx()).To(BeNumerically(">", 2*64))
+		Expect(b.Dy()).To(BeNumerically(">", 2*64))
+
+		// Cells are laid out right-aligned against the bottom edge, separated by 4px gaps.
+		cellCenter := func(row, col int) color.Color {
+			x := b.Dx() - 4 - (2-col)*(64+4) + 32
+			y := b.Dy() - 4 - (2-row)*(64+4) + 32
+			return img.At(x, y)
+		}
+		Expect(color.RGBAModel.Convert(cellCenter(0, 0))).To(Equal(red))
+		Expect(color.RGBAModel.Convert(cellCenter(0, 1))).To(Equal(blue))
+		Expect(color.RGBAModel.Convert(cellCenter(1, 0))).To(Equal(blue))
+		Expect(color.RGBAModel.Convert(cellCenter(1, 1))).To(Equal(color.RGBA{R: 0xE0, G: 0xE0, B: 0xE0, A: 0xFF}))
+	})
+
+	It("honors explicit axis values and their order", func() {
+		data, err := renderer.Render(scan, model.GridSpec{
+			XAxis:    "cfg",
+			YAxis:    "checkpoint",
+			XValues:  []string{"7"},
+			YValues:  []string{"1000"},
+			CellSize: 64,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		img := decode(data)
+		b := img.Bounds()
+		center := img.At(b.Dx()-4-32, b.Dy()-4-32)
+		Expect(color.RGBAModel.Convert(center)).To(Equal(blue))
+	})
+
+	It("downscales images larger than the cell", func() {
+		reader.files[filepath.Join(sampleDir, scan.Images[0].RelativePath)] = solidPNG(256, 128, red)
+		data, err := renderer.Render(scan, model.GridSpec{
+			XAxis:    "cfg",
+			YAxis:    "checkpoint",
+			XValues:  []string{"3"},
+			YValues:  []string{"1000"},
+			Filters:  map[string]string{"prompt_name": "forest"},
+			CellSize: 64,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		img := decode(data)
+		b := img.Bounds()
+		// 256x128 scales to 64x32, centered vertically in the cell.
+		cellTop := b.Dy() - 4 - 64
+		Expect(color.RGBAModel.Convert(img.At(b.Dx()-4-32, cellTop+32))).To(Equal(red))
+		Expect(color.RGBAModel.Convert(img.At(b.Dx()-4-32, cellTop+4))).To(Equal(color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}))
+	})
+
+	It("leaves a cell blank when its image cannot be read", func() {
+		delete(reader.files, filepath.Join(sampleDir, scan.Images[0].RelativePath))
+		_, err := renderer.Render(scan, model.GridSpec{XAxis: "cfg", YAxis: "checkpoint"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("rejects invalid specs",
+		func(spec model.GridSpec, expected string) {
+			_, err := renderer.Render(scan, spec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid grid spec"))
+			Expect(err.Error()).To(ContainSubstring(expected))
+		},
+		Entry("missing axis", model.GridSpec{XAxis: "cfg"}, "axis dimension is required"),
+		Entry("unknown dimension", model.GridSpec{XAxis: "cfg", YAxis: "lora"}, "unknown dimension"),
+		Entry("same axis twice", model.GridSpec{XAxis: "cfg", YAxis: "cfg"}, "axes must differ"),
+		Entry("unknown axis value", model.GridSpec{XAxis: "cfg", YAxis: "checkpoint", XValues: []string{"12"}}, `has no value "12"`),
+		Entry("cell size too small", model.GridSpec{XAxis: "cfg", YAxis: "checkpoint", CellSize: 8}, "cell size"),
+		Entry("cell size too large", model.GridSpec{XAxis: "cfg", YAxis: "checkpoint", CellSize: 4096}, "cell size"),
+	)
+
+	It("rejects grids with too many cells", func() {
+		values := make([]string, 21)
+		for i := range values {
+			values[i] = fmt.Sprintf("%d", i)
+		}
+		scan.Dimensions[0].Values = values
+		scan.Dimensions[1].Values = values
+		_, err := renderer.Render(scan, model.GridSpec{XAxis: "cfg", YAxis: "checkpoint"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("exceeds the maximum"))
+	})
+})
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// HeartbeatHealth reports whether the job executor is healthy. It is
+// satisfied by JobExecutor.
+type HeartbeatHealth interface {
+	Healthy(stallTimeout time.Duration) (bool, string)
+}
+
+// HeartbeatTarget receives a heartbeat, e.g. by touching a file or requesting
+// a monitoring URL.
+type HeartbeatTarget interface {
+	Beat(ctx context.Context) error
+}
+
+// Heartbeat periodically signals external monitoring while the job executor
+// is healthy. When the executor stalls or disconnects no heartbeat is sent,
+// so a cron monitor watching the targets raises an alert.
+type Heartbeat struct {
+	health       HeartbeatHealth
+	targets      []HeartbeatTarget
+	interval     time.Duration
+	stallTimeout time.Duration
+	logger       *logrus.Entry
+
+	unhealthy bool // whether the previous check found the executor unhealthy
+}
+
+// NewHeartbeat creates a Heartbeat that checks health and signals targets at
+// the interval from cfg.
+func NewHeartbeat(health HeartbeatHealth, targets []HeartbeatTarget, cfg model.HeartbeatConfig, logger *logrus.Logger) *Heartbeat {
+	return &Heartbeat{
+		health:       health,
+		targets:      targets,
+		interval:     time.Duration(cfg.Interval) * time.Second,
+		stallTimeout: time.Duration(cfg.StallTimeout) * time.Second,
+		logger:       logger.WithField("component", "heartbeat"),
+	}
+}
+
+// Beat checks the executor once and signals every target if it is healthy.
+// Returns whether the executor was healthy. Target errors are logged; one
+// failing target does not prevent the others from being signalled.
+func (h *Heartbeat) Beat(ctx context.Context) bool {
+	h.logger.Trace("entering Beat")
+	defer h.logger.Trace("returning from Beat")
+
+	healthy, reason := h.health.Healthy(h.stallTimeout)
+	if !healthy {
+		if !h.unhealthy {
+			h.logger.WithField("reason", reason).Warn("job executor unhealthy, heartbeat suspended")
+		}
+		h.unhealthy = true
+		return false
+	}
+	if h.unhealthy {
+		h.logger.Info("job executor healthy again, heartbeat resumed")
+		h.unhealthy = false
+	}
+
+	for _, t := range h.targets {
+		if err := t.Beat(ctx); err != nil {
+			h.logger.WithError(err).Error("failed to send heartbeat")
+		}
+	}
+	h.logger.Debug("heartbeat sent")
+	return true
+}
+
+// Run sends a heartbeat immediately and then at every interval until stop is
+// closed.
+func (h *Heartbeat) Run(stop <-chan struct{}) {
+	h.logger.Trace("entering Run")
+	defer h.logger.Trace("returning from Run")
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), h.interval)
+		h.Beat(ctx)
+		cancel()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeHeartbeatHealth returns a fixed health status and records the stall
+// timeout it was asked about.
+type fakeHeartbeatHealth struct {
+	healthy      bool
+	reason       string
+	stallTimeout time.Duration
+}
+
+func (f *fakeHeartbeatHealth) Healthy(stallTimeout time.Duration) (bool, string) {
+	f.stallTimeout = stallTimeout
+	return f.healthy, f.reason
+}
+
+// fakeHeartbeatTarget counts beats and optionally fails.
+type fakeHeartbeatTarget struct {
+	beats int
+	err   error
+}
+
+func (f *fakeHeartbeatTarget) Beat(ctx context.Context) error {
+	f.beats++
+	return f.err
+}
+
+var _ = Describe("Heartbeat", func() {
+	var (
+		health *fakeHeartbeatHealth
+		file   *fakeHeartbeatTarget
+		url    *fakeHeartbeatTarget
+		hb     *service.Heartbeat
+		logger *logrus.Logger
+		cfg    model.HeartbeatConfig
+	)
+
+	BeforeEach(func() {
+		health = &fakeHeartbeatHealth{healthy: true}
+		file = &fakeHeartbeatTarget{}
+		url = &fakeHeartbeatTarget{}
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+		cfg = model.HeartbeatConfig{Interval: 60, StallTimeout: 1800}
+		hb = service.NewHeartbeat(health, []service.HeartbeatTarget{file, url}, cfg, logger)
+	})
+
+	It("signals every target while the executor is healthy", func() {
+		Expect(hb.Beat(context.Background())).To(BeTrue())
+		Expect(file.beats).To(Equal(1))
+		Expect(url.beats).To(Equal(1))
+		Expect(health.stallTimeout).To(Equal(30 * time.Minute))
+	})
+
+	It("does not signal targets while the executor is unhealthy", func() {
+		health.healthy = false
+		health.reason = "not connected to ComfyUI"
+		Expect(hb.Beat(context.Background())).To(BeFalse())
+		Expect(file.beats).To(Equal(0))
+		Expect(url.beats).To(Equal(0))
+	})
+
+	It("resumes signalling when the executor recovers", func() {
+		health.healthy = false
+		hb.Beat(context.Background())
+		health.healthy = true
+		Expect(hb.Beat(context.Background())).To(BeTrue())
+		Expect(file.beats).To(Equal(1))
+	})
+
+	It("signals the remaining targets when one fails", func() {
+		file.err = errors.New("permission denied")
+		Expect(hb.Beat(context.Background())).To(BeTrue())
+		Expect(url.beats).To(Equal(1))
+	})
+
+	It("beats immediately when run and stops when signalled", func() {
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			hb.Run(stop)
+		}()
+		close(stop)
+		Eventually(done).Should(BeClosed())
+		Expect(file.beats).To(Equal(1))
+	})
+})
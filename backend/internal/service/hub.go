@@ -1,6 +1,7 @@
 package service
 
 import (
+	"strings"
 	"sync"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
@@ -9,35 +10,98 @@ import (
 
 // HubClient is a connected WebSocket client that can receive events.
 type HubClient interface {
-	// SendEvent sends an event to the client. Returns false if the client
-	// is no longer writable and should be removed.
+	// SendEvent writes an event to the client, blocking until it is written.
+	// Returns false if the client is no longer writable and should be
+	// removed.
 	SendEvent(event model.FSEvent) bool
 }
 
+// hubClientQueueSize is how many events may wait for delivery to a client.
+// A client that falls this far behind is evicted.
+const hubClientQueueSize = 64
+
+// hubClientState is the hub's record of a registered client.
+type hubClientState struct {
+	sub model.EventSubscription
+	// queue holds the events waiting to be written by the client's delivery
+	// goroutine, so that a slow client never blocks a broadcast.
+	queue chan model.FSEvent
+	// removed is closed when the client is unregistered or evicted.
+	removed chan struct{}
+}
+
 // Hub manages connected WebSocket clients and broadcasts filesystem events.
+// Each client receives the events its subscription selects, through a
+// bounded queue; clients that cannot keep up are evicted.
 type Hub struct {
 	mu      sync.RWMutex
-	clients map[HubClient]struct{}
-	logger  *logrus.Entry
+	clients map[HubClient]*hubClientState
+	// jobRuns maps the ID of each unfinished job to its training run, learned
+	// from job_progress events, so that inference_progress events, which
+	// carry only the job ID, reach the training run's subscribers.
+	jobRuns         map[string]string
+	droppedMessages int64
+	evictedClients  int64
+	logger          *logrus.Entry
 }
 
 // NewHub creates a new Hub.
 func NewHub(logger *logrus.Logger) *Hub {
 	return &Hub{
-		clients: make(map[HubClient]struct{}),
+		clients: make(map[HubClient]*hubClientState),
+		jobRuns: make(map[string]string),
 		logger:  logger.WithField("component", "hub"),
 	}
 }
 
-// Register adds a client to the hub.
-func (h *Hub) Register(c HubClient) {
-	h.logger.Trace("entering Register")
-	defer h.logger.Trace("returning from Register")
+// Register adds a client to the hub that receives every event. The returned
+// channel is closed when the client is unregistered or evicted, upon which
+// the caller should close the client's connection.
+func (h *Hub) Register(c HubClient) <-chan struct{} {
+	return h.RegisterWithSubscription(c, model.EventSubscription{})
+}
+
+// RegisterWithSubscription adds a client to the hub that receives the events
+// sub selects. The returned channel is closed when the client is
+// unregistered or evicted.
+func (h *Hub) RegisterWithSubscription(c HubClient, sub model.EventSubscription) <-chan struct{} {
+	h.logger.Trace("entering RegisterWithSubscription")
+	defer h.logger.Trace("returning from RegisterWithSubscription")
 
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.clients[c] = struct{}{}
+	if old, ok := h.clients[c]; ok {
+		h.remove(c, old)
+	}
+	state := &hubClientState{
+		sub:     sub,
+		queue:   make(chan model.FSEvent, hubClientQueueSize),
+		removed: make(chan struct{}),
+	}
+	h.clients[c] = state
+	go h.deliver(c, state)
 	h.logger.WithField("client_count", len(h.clients)).Debug("client registered")
+	return state.removed
+}
+
+// Subscribe replaces the subscription of a registered client. Clients that
+// are not registered are ignored.
+func (h *Hub) Subscribe(c HubClient, sub model.EventSubscription) {
+	h.logger.Trace("entering Subscribe")
+	defer h.logger.Trace("returning from Subscribe")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state, ok := h.clients[c]
+	if !ok {
+		return
+	}
+	state.sub = sub
+	h.logger.WithFields(logrus.Fields{
+		"job_ids":       sub.JobIDs,
+		"training_runs": sub.TrainingRuns,
+		"fs_events":     sub.FSEvents,
+	}).Debug("client subscription updated")
 }
 
 // Unregister removes a client from the hub.
@@ -47,7 +111,9 @@ func (h *Hub) Unregister(c HubClient) {
 
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	delete(h.clients, c)
+	if state, ok := h.clients[c]; ok {
+		h.remove(c, state)
+	}
 	h.logger.WithField("client_count", len(h.clients)).Debug("client unregistered")
 }
 
@@ -58,8 +124,20 @@ func (h *Hub) ClientCount() int {
 	return len(h.clients)
 }
 
-// Broadcast sends an FSEvent to all connected clients.
-// Clients that fail to receive are removed.
+// Stats returns the number of connected clients and how many events and
+// clients were dropped since startup.
+func (h *Hub) Stats() model.HubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return model.HubStats{
+		ClientCount:     len(h.clients),
+		DroppedMessages: h.droppedMessages,
+		EvictedClients:  h.evictedClients,
+	}
+}
+
+// Broadcast queues an FSEvent for the connected clients subscribed to it.
+// It never blocks on a client: a client whose queue is full is evicted.
 func (h *Hub) Broadcast(event model.FSEvent) {
 	h.logger.WithFields(logrus.Fields{
 		"event_type": event.Type,
@@ -69,15 +147,124 @@ func (h *Hub) Broadcast(event model.FSEvent) {
 
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	for c := range h.clients {
-		if !c.SendEvent(event) {
-			delete(h.clients, c)
-			h.logger.WithField("client_count", len(h.clients)).Info("removed unresponsive websocket client")
+	trainingRun := h.trainingRunOf(event)
+	queued := 0
+	for c, state := range h.clients {
+		if !subscriptionSelects(state.sub, event, trainingRun) {
+			continue
+		}
+		select {
+		case state.queue <- event:
+			queued++
+		default:
+			h.droppedMessages++
+			h.evict(c, state, "send queue full")
 		}
 	}
 	h.logger.WithFields(logrus.Fields{
 		"event_type":   event.Type,
 		"event_path":   event.Path,
 		"client_count": len(h.clients),
+		"queued_count": queued,
 	}).Debug("broadcasted event to clients")
 }
+
+// deliver writes the events queued for a client until the client is removed
+// or a write fails, in which case the client is evicted.
+func (h *Hub) deliver(c HubClient, state *hubClientState) {
+	for event := range state.queue {
+		select {
+		case <-state.removed:
+			return
+		default:
+		}
+		if c.SendEvent(event) {
+			continue
+		}
+		h.mu.Lock()
+		if h.clients[c] == state {
+			h.droppedMessages += int64(1 + len(state.queue))
+			h.evict(c, state, "write failed")
+		}
+		h.mu.Unlock()
+		return
+	}
+}
+
+// evict removes a client that cannot keep up. Must be called with h.mu held.
+func (h *Hub) evict(c HubClient, state *hubClientState, reason string) {
+	h.remove(c, state)
+	h.evictedClients++
+	h.logger.WithFields(logrus.Fields{
+		"reason":       reason,
+		"client_count": len(h.clients),
+	}).Info("removed unresponsive websocket client")
+}
+
+// remove deletes a client and stops its delivery. Must be called with h.mu
+// held.
+func (h *Hub) remove(c HubClient, state *hubClientState) {
+	delete(h.clients, c)
+	close(state.queue)
+	close(state.removed)
+}
+
+// trainingRunOf returns the training run of a job event, remembering the
+// training run of each unfinished job. Must be called with h.mu held.
+func (h *Hub) trainingRunOf(event model.FSEvent) string {
+	if event.JobID == "" {
+		return event.TrainingRun
+	}
+	if event.TrainingRun == "" {
+		return h.jobRuns[event.JobID]
+	}
+	if event.JobProgressData != nil && isTerminalJobStatus(event.JobProgressData.Status) {
+		delete(h.jobRuns, event.JobID)
+	} else {
+		h.jobRuns[event.JobID] = event.TrainingRun
+	}
+	return event.TrainingRun
+}
+
+// isTerminalJobStatus reports whether a job in status will not run again
+// without being resumed or retried.
+func isTerminalJobStatus(status string) bool {
+	switch model.SampleJobStatus(status) {
+	case model.SampleJobStatusCompleted, model.SampleJobStatusCompletedWithErrors,
+		model.SampleJobStatusFailed, model.SampleJobStatusStopped:
+		return true
+	}
+	return false
+}
+
+// subscriptionSelects reports whether sub selects event, whose training run
+// is trainingRun (empty when unknown).
+func subscriptionSelects(sub model.EventSubscription, event model.FSEvent, trainingRun string) bool {
+	if sub.IsZero() {
+		return true
+	}
+	if sub.FSEvents && event.Type.IsFilesystem() {
+		return true
+	}
+	if event.JobID != "" {
+		for _, id := range sub.JobIDs {
+			if id == event.JobID {
+				return true
+			}
+		}
+	}
+	// Image and directory paths start with the training run's sample
+	// directory. Checkpoint paths are relative to the checkpoint directory
+	// and are not attributed to a training run.
+	samplePath := trainingRun == "" &&
+		(event.Type == model.EventImageAdded || event.Type == model.EventImageRemoved || event.Type == model.EventDirectoryAdded)
+	for _, run := range sub.TrainingRuns {
+		if trainingRun == run {
+			return true
+		}
+		if samplePath && (event.Path == run || strings.HasPrefix(event.Path, run+"/")) {
+			return true
+		}
+	}
+	return false
+}
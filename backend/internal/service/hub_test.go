@@ -38,6 +38,16 @@ func (c *fakeHubClient) getEvents() []model.FSEvent {
 	return cp
 }
 
+// blockingHubClient is a client whose writes block until release is closed.
+type blockingHubClient struct {
+	release chan struct{}
+}
+
+func (c *blockingHubClient) SendEvent(event model.FSEvent) bool {
+	<-c.release
+	return true
+}
+
 var _ = Describe("Hub", func() {
 	var (
 		hub    *service.Hub
@@ -99,10 +109,10 @@ var _ = Describe("Hub", func() {
 			}
 			hub.Broadcast(event)
 
-			Expect(c1.getEvents()).To(HaveLen(1))
+			Eventually(c1.getEvents).Should(HaveLen(1))
 			Expect(c1.getEvents()[0].Type).To(Equal(model.EventImageAdded))
 			Expect(c1.getEvents()[0].Path).To(Equal("checkpoint.safetensors/image.png"))
-			Expect(c2.getEvents()).To(HaveLen(1))
+			Eventually(c2.getEvents).Should(HaveLen(1))
 		})
 
 		It("does nothing when no clients are registered", func() {
@@ -126,9 +136,9 @@ var _ = Describe("Hub", func() {
 			})
 
 			// Bad client should be removed
-			Expect(hub.ClientCount()).To(Equal(1))
+			Eventually(hub.ClientCount).Should(Equal(1))
 			// Good client should still receive
-			Expect(good.getEvents()).To(HaveLen(1))
+			Eventually(good.getEvents).Should(HaveLen(1))
 		})
 
 		It("does not send to unregistered clients", func() {
@@ -141,7 +151,7 @@ var _ = Describe("Hub", func() {
 				Path: "test.png",
 			})
 
-			Expect(c.getEvents()).To(BeEmpty())
+			Consistently(c.getEvents, "50ms").Should(BeEmpty())
 		})
 
 		It("broadcasts multiple events in sequence", func() {
@@ -152,11 +162,137 @@ var _ = Describe("Hub", func() {
 			hub.Broadcast(model.FSEvent{Type: model.EventImageRemoved, Path: "b.png"})
 			hub.Broadcast(model.FSEvent{Type: model.EventDirectoryAdded, Path: "newdir"})
 
+			Eventually(c.getEvents).Should(HaveLen(3))
 			events := c.getEvents()
-			Expect(events).To(HaveLen(3))
 			Expect(events[0].Type).To(Equal(model.EventImageAdded))
 			Expect(events[1].Type).To(Equal(model.EventImageRemoved))
 			Expect(events[2].Type).To(Equal(model.EventDirectoryAdded))
 		})
 	})
+
+	Describe("Subscriptions", func() {
+		var c *fakeHubClient
+
+		BeforeEach(func() {
+			c = newFakeHubClient(true)
+		})
+
+		eventTypes := func() []model.EventType {
+			var types []model.EventType
+			for _, e := range c.getEvents() {
+				types = append(types, e.Type)
+			}
+			return types
+		}
+
+		It("delivers only the subscribed job's events", func() {
+			hub.RegisterWithSubscription(c, model.EventSubscription{JobIDs: []string{"job-1"}})
+
+			hub.Broadcast(model.FSEvent{Type: model.EventJobProgress, JobID: "job-2"})
+			hub.Broadcast(model.FSEvent{Type: model.EventImageAdded, Path: "run/study/cp/a.png"})
+			hub.Broadcast(model.FSEvent{Type: model.EventInferenceProgress, JobID: "job-1"})
+
+			Eventually(eventTypes).Should(Equal([]model.EventType{model.EventInferenceProgress}))
+		})
+
+		It("delivers the events of the subscribed training run", func() {
+			hub.RegisterWithSubscription(c, model.EventSubscription{TrainingRuns: []string{"run"}})
+
+			hub.Broadcast(model.FSEvent{Type: model.EventJobProgress, JobID: "job-1", TrainingRun: "run",
+				JobProgressData: &model.JobProgressEventData{JobID: "job-1", Status: "running"}})
+			hub.Broadcast(model.FSEvent{Type: model.EventInferenceProgress, JobID: "job-1"})
+			hub.Broadcast(model.FSEvent{Type: model.EventImageAdded, Path: "run/study/cp/a.png"})
+			hub.Broadcast(model.FSEvent{Type: model.EventImageAdded, Path: "run-2/study/cp/a.png"})
+			hub.Broadcast(model.FSEvent{Type: model.EventInferenceProgress, JobID: "job-2"})
+
+			Eventually(eventTypes).Should(Equal([]model.EventType{
+				model.EventJobProgress, model.EventInferenceProgress, model.EventImageAdded,
+			}))
+		})
+
+		It("forgets the training run of a finished job", func() {
+			hub.RegisterWithSubscription(c, model.EventSubscription{TrainingRuns: []string{"run"}})
+
+			hub.Broadcast(model.FSEvent{Type: model.EventJobProgress, JobID: "job-1", TrainingRun: "run",
+				JobProgressData: &model.JobProgressEventData{JobID: "job-1", Status: "completed"}})
+			hub.Broadcast(model.FSEvent{Type: model.EventInferenceProgress, JobID: "job-1"})
+
+			Eventually(eventTypes).Should(HaveLen(1))
+			Consistently(eventTypes, "50ms").Should(Equal([]model.EventType{model.EventJobProgress}))
+		})
+
+		It("delivers only filesystem events to fs_events subscribers", func() {
+			hub.RegisterWithSubscription(c, model.EventSubscription{FSEvents: true})
+
+			hub.Broadcast(model.FSEvent{Type: model.EventJobProgress, JobID: "job-1"})
+			hub.Broadcast(model.FSEvent{Type: model.EventCheckpointAdded, Path: "a.safetensors"})
+			hub.Broadcast(model.FSEvent{Type: model.EventImageRemoved, Path: "run/study/cp/a.png"})
+
+			Eventually(eventTypes).Should(Equal([]model.EventType{model.EventCheckpointAdded, model.EventImageRemoved}))
+		})
+
+		It("replaces the subscription of a registered client", func() {
+			hub.RegisterWithSubscription(c, model.EventSubscription{JobIDs: []string{"job-1"}})
+			hub.Subscribe(c, model.EventSubscription{})
+
+			hub.Broadcast(model.FSEvent{Type: model.EventJobProgress, JobID: "job-2"})
+
+			Eventually(c.getEvents).Should(HaveLen(1))
+		})
+
+		It("ignores subscriptions of unregistered clients", func() {
+			hub.Subscribe(c, model.EventSubscription{FSEvents: true})
+
+			Expect(hub.ClientCount()).To(Equal(0))
+		})
+	})
+
+	Describe("Backpressure", func() {
+		It("closes the removed channel on unregister", func() {
+			c := newFakeHubClient(true)
+			removed := hub.Register(c)
+
+			hub.Unregister(c)
+
+			Expect(removed).To(BeClosed())
+		})
+
+		It("evicts a client that fails a write", func() {
+			c := newFakeHubClient(false)
+			removed := hub.Register(c)
+
+			hub.Broadcast(model.FSEvent{Type: model.EventImageAdded, Path: "test.png"})
+
+			Eventually(removed).Should(BeClosed())
+			Expect(hub.Stats().EvictedClients).To(Equal(int64(1)))
+		})
+
+		It("evicts a stalled client without blocking the other clients", func() {
+			stalled := &blockingHubClient{release: make(chan struct{})}
+			defer close(stalled.release)
+			good := newFakeHubClient(true)
+			removed := hub.Register(stalled)
+			hub.Register(good)
+
+			// The stalled client's queue overflows while the good client keeps
+			// up with every event.
+			for i := 0; i < 70; i++ {
+				hub.Broadcast(model.FSEvent{Type: model.EventImageAdded, Path: "test.png"})
+				Eventually(good.getEvents).Should(HaveLen(i + 1))
+			}
+
+			Eventually(removed).Should(BeClosed())
+			stats := hub.Stats()
+			Expect(stats.ClientCount).To(Equal(1))
+			Expect(stats.EvictedClients).To(Equal(int64(1)))
+			Expect(stats.DroppedMessages).To(BeNumerically(">=", 1))
+		})
+
+		It("reports the client count in the stats", func() {
+			hub.Register(newFakeHubClient(true))
+			hub.Register(newFakeHubClient(true))
+
+			Expect(hub.Stats()).To(Equal(model.HubStats{ClientCount: 2}))
+		})
+	})
 })
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// maxImageTagLength bounds the length of a single tag.
+const maxImageTagLength = 64
+
+// MaxBulkAnnotationImages bounds the number of images of one bulk update, so
+// that a single request cannot hold the database for long.
+const MaxBulkAnnotationImages = 10000
+
+// ImageAnnotationStore defines the persistence operations the image
+// annotation service needs.
+type ImageAnnotationStore interface {
+	ListImageAnnotations(ctx context.Context, filter model.ImageAnnotationFilter) ([]model.ImageAnnotation, error)
+	GetImageAnnotation(ctx context.Context, path string) (model.ImageAnnotation, error)
+	SaveImageAnnotations(ctx context.Context, annotations []model.ImageAnnotation) error
+	DeleteImageAnnotation(ctx context.Context, path string) error
+	GetSampleJobItemOutputPath(ctx context.Context, itemID string) (string, error)
+}
+
+// ImageRef identifies a sample image either by its path relative to the
+// sample directory or by the ID of the sample job item that generated it.
+// Exactly one of the two must be set.
+type ImageRef struct {
+	Path   string
+	ItemID string
+}
+
+// ImageAnnotationService manages star ratings and tags of sample images.
+type ImageAnnotationService struct {
+	store     ImageAnnotationStore
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewImageAnnotationService creates an ImageAnnotationService backed by the
+// given store. sampleDir is used to resolve item IDs to image paths.
+func NewImageAnnotationService(store ImageAnnotationStore, sampleDir string, logger *logrus.Logger) *ImageAnnotationService {
+	return &ImageAnnotationService{
+		store:     store,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "image_annotation"),
+	}
+}
+
+// List returns the annotations matching filter ordered by path.
+func (s *ImageAnnotationService) List(ctx context.Context, filter model.ImageAnnotationFilter) ([]model.ImageAnnotation, error) {
+	s.logger.Trace("entering List")
+	defer s.logger.Trace("returning from List")
+
+	if filter.MinRating < 0 || filter.MinRating > model.MaxImageRating {
+		return nil, fmt.Errorf("invalid min_rating %d: must be between 0 and %d", filter.MinRating, model.MaxImageRating)
+	}
+	if filter.PathPrefix != "" {
+		prefix, err := validatePinPath(filter.PathPrefix)
+		if err != nil {
+			return nil, err
+		}
+		filter.PathPrefix = prefix
+	}
+	annotations, err := s.store.ListImageAnnotations(ctx, filter)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list image annotations")
+		return nil, fmt.Errorf("listing image annotations: %w", err)
+	}
+	if annotations == nil {
+		annotations = []model.ImageAnnotation{}
+	}
+	return annotations, nil
+}
+
+// Get returns the annotation of relPath. Images without an annotation get an
+// empty one rather than an error.
+func (s *ImageAnnotationService) Get(ctx context.Context, relPath string) (model.ImageAnnotation, error) {
+	s.logger.WithField("path", relPath).Trace("entering Get")
+	defer s.logger.Trace("returning from Get")
+
+	path, err := validatePinPath(relPath)
+	if err != nil {
+		return model.ImageAnnotation{}, err
+	}
+	return s.get(ctx, path)
+}
+
+// Set replaces the rating and tags of an image. Setting a rating of 0 and no
+// tags clears the annotation.
+func (s *ImageAnnotationService) Set(ctx context.Context, ref ImageRef, rating int, tags []string) (model.ImageAnnotation, error) {
+	s.logger.WithFields(logrus.Fields{
+		"path":    ref.Path,
+		"item_id": ref.ItemID,
+		"rating":  rating,
+	}).Trace("entering Set")
+	defer s.logger.Trace("returning from Set")
+
+	if err := validateImageRating(rating); err != nil {
+		return model.ImageAnnotation{}, err
+	}
+	normTags, err := normalizeImageTags(tags)
+	if err != nil {
+		return model.ImageAnnotation{}, err
+	}
+	path, err := s.resolve(ctx, ref)
+	if err != nil {
+		return model.ImageAnnotation{}, err
+	}
+
+	a := model.ImageAnnotation{
+		Path:      path,
+		Rating:    rating,
+		Tags:      normTags,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := s.store.SaveImageAnnotations(ctx, []model.ImageAnnotation{a}); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to save image annotation")
+		return model.ImageAnnotation{}, fmt.Errorf("saving image annotation: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"path":   path,
+		"rating": rating,
+		"tags":   normTags,
+	}).Info("image annotated")
+	return a, nil
+}
+
+// Delete removes the annotation of an image. Returns a not-found error if the
+// image is not annotated.
+func (s *ImageAnnotationService) Delete(ctx context.Context, ref ImageRef) error {
+	s.logger.WithFields(logrus.Fields{
+		"path":    ref.Path,
+		"item_id": ref.ItemID,
+	}).Trace("entering Delete")
+	defer s.logger.Trace("returning from Delete")
+
+	path, err := s.resolve(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteImageAnnotation(ctx, path); err == sql.ErrNoRows {
+		s.logger.WithField("path", path).Debug("image annotation not found")
+		return fmt.Errorf("annotation of %s not found", path)
+	} else if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to delete image annotation")
+		return fmt.Errorf("deleting image annotation: %w", err)
+	}
+	s.logger.WithField("path", path).Info("image annotation deleted")
+	return nil
+}
+
+// BulkUpdate applies change to the annotations of all referenced images in
+// one transaction and returns the resulting annotations in the order of refs.
+// Referencing an image twice applies the change once.
+func (s *ImageAnnotationService) BulkUpdate(ctx context.Context, refs []ImageRef, change model.ImageAnnotationChange) ([]model.ImageAnnotation, error) {
+	s.logger.WithField("image_count", len(refs)).Trace("entering BulkUpdate")
+	defer s.logger.Trace("returning from BulkUpdate")
+
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("invalid bulk update: no images given")
+	}
+	if len(refs) > MaxBulkAnnotationImages {
+		return nil, fmt.Errorf("invalid bulk update: %d images given, at most %d are allowed", len(refs), MaxBulkAnnotationImages)
+	}
+	if change.Rating != nil {
+		if err := validateImageRating(*change.Rating); err != nil {
+			return nil, err
+		}
+	}
+	addTags, err := normalizeImageTags(change.AddTags)
+	if err != nil {
+		return nil, err
+	}
+	removeTags, err := normalizeImageTags(change.RemoveTags)
+	if err != nil {
+		return nil, err
+	}
+	if change.Rating == nil && len(addTags) == 0 && len(removeTags) == 0 {
+		return nil, fmt.Errorf("invalid bulk update: no rating or tag change given")
+	}
+
+	now := time.Now().UTC()
+	seen := make(map[string]bool, len(refs))
+	var annotations []model.ImageAnnotation
+	for _, ref := range refs {
+		path, err := s.resolve(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if seen[path] {
+			continue
+		}
+		a, err := s.get(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if change.Rating != nil {
+			a.Rating = *change.Rating
+		}
+		a.Tags = applyTagChange(a.Tags, addTags, removeTags)
+		a.UpdatedAt = now
+		seen[path] = true
+		annotations = append(annotations, a)
+	}
+
+	if err := s.store.SaveImageAnnotations(ctx, annotations); err != nil {
+		s.logger.WithError(err).Error("failed to save image annotations")
+		return nil, fmt.Errorf("saving image annotations: %w", err)
+	}
+	s.logger.WithField("image_count", len(annotations)).Info("images annotated in bulk")
+	return annotations, nil
+}
+
+// get returns the annotation of a normalized path, or an empty one.
+func (s *ImageAnnotationService) get(ctx context.Context, path string) (model.ImageAnnotation, error) {
+	a, err := s.store.GetImageAnnotation(ctx, path)
+	if err == sql.ErrNoRows {
+		return model.ImageAnnotation{Path: path, Tags: []string{}}, nil
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to get image annotation")
+		return model.ImageAnnotation{}, fmt.Errorf("getting image annotation: %w", err)
+	}
+	return a, nil
+}
+
+// resolve returns the normalized image path of ref. Item IDs resolve to the
+// output path of the item, which must be a completed image in the sample
+// directory.
+func (s *ImageAnnotationService) resolve(ctx context.Context, ref ImageRef) (string, error) {
+	if (ref.Path == "") == (ref.ItemID == "") {
+		return "", fmt.Errorf("invalid image reference: exactly one of path and item_id must be given")
+	}
+	if ref.Path != "" {
+		return validatePinPath(ref.Path)
+	}
+
+	outputPath, err := s.store.GetSampleJobItemOutputPath(ctx, ref.ItemID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("sample job item %s not found", ref.ItemID)
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"item_id": ref.ItemID,
+			"error":   err.Error(),
+		}).Error("failed to get sample job item output path")
+		return "", fmt.Errorf("getting sample job item output path: %w", err)
+	}
+	if outputPath == "" {
+		return "", fmt.Errorf("invalid image reference: sample job item %s has no image", ref.ItemID)
+	}
+	rel, err := filepath.Rel(s.sampleDir, outputPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid image reference: image of sample job item %s is outside the sample directory", ref.ItemID)
+	}
+	return model.NormalizePinPath(filepath.ToSlash(rel)), nil
+}
+
+// validateImageRating rejects ratings outside 0 (unrated) to MaxImageRating.
+func validateImageRating(rating int) error {
+	if rating < 0 || rating > model.MaxImageRating {
+		return fmt.Errorf("invalid rating %d: must be between 0 and %d", rating, model.MaxImageRating)
+	}
+	return nil
+}
+
+// normalizeImageTags trims tags, rejects empty and overlong ones, and returns
+// them deduplicated and sorted.
+func normalizeImageTags(tags []string) ([]string, error) {
+	set := make(map[string]struct{}, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return nil, fmt.Errorf("invalid tag: must not be empty")
+		}
+		if len(t) > maxImageTagLength {
+			return nil, fmt.Errorf("invalid tag %q: must be at most %d characters", t, maxImageTagLength)
+		}
+		set[t] = struct{}{}
+	}
+	out := make([]string, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// applyTagChange returns tags with add added and remove removed, sorted.
+func applyTagChange(tags, add, remove []string) []string {
+	set := make(map[string]struct{}, len(tags)+len(add))
+	for _, t := range tags {
+		set[t] = struct{}{}
+	}
+	for _, t := range add {
+		set[t] = struct{}{}
+	}
+	for _, t := range remove {
+		delete(set, t)
+	}
+	out := make([]string, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
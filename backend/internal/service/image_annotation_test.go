@@ -0,0 +1,247 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeImageAnnotationStore is an in-memory test double for
+// service.ImageAnnotationStore.
+type fakeImageAnnotationStore struct {
+	annotations map[string]model.ImageAnnotation
+	outputPaths map[string]string
+	listFilter  model.ImageAnnotationFilter
+	saveErr     error
+	saveCalls   int
+}
+
+func newFakeImageAnnotationStore() *fakeImageAnnotationStore {
+	return &fakeImageAnnotationStore{
+		annotations: make(map[string]model.ImageAnnotation),
+		outputPaths: make(map[string]string),
+	}
+}
+
+func (f *fakeImageAnnotationStore) ListImageAnnotations(ctx context.Context, filter model.ImageAnnotationFilter) ([]model.ImageAnnotation, error) {
+	f.listFilter = filter
+	var result []model.ImageAnnotation
+	for _, a := range f.annotations {
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+func (f *fakeImageAnnotationStore) GetImageAnnotation(ctx context.Context, path string) (model.ImageAnnotation, error) {
+	a, ok := f.annotations[path]
+	if !ok {
+		return model.ImageAnnotation{}, sql.ErrNoRows
+	}
+	return a, nil
+}
+
+func (f *fakeImageAnnotationStore) SaveImageAnnotations(ctx context.Context, annotations []model.ImageAnnotation) error {
+	f.saveCalls++
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	for _, a := range annotations {
+		if a.IsEmpty() {
+			delete(f.annotations, a.Path)
+			continue
+		}
+		f.annotations[a.Path] = a
+	}
+	return nil
+}
+
+func (f *fakeImageAnnotationStore) DeleteImageAnnotation(ctx context.Context, path string) error {
+	if _, ok := f.annotations[path]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.annotations, path)
+	return nil
+}
+
+func (f *fakeImageAnnotationStore) GetSampleJobItemOutputPath(ctx context.Context, itemID string) (string, error) {
+	p, ok := f.outputPaths[itemID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return p, nil
+}
+
+var _ = Describe("ImageAnnotationService", func() {
+	var (
+		store *fakeImageAnnotationStore
+		svc   *service.ImageAnnotationService
+	)
+
+	BeforeEach(func() {
+		store = newFakeImageAnnotationStore()
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewImageAnnotationService(store, "/samples", logger)
+	})
+
+	Describe("Set", func() {
+		It("stores a normalized path with deduplicated, sorted tags", func() {
+			a, err := svc.Set(ctx, service.ImageRef{Path: "run//study/ckpt.safetensors/x.png"}, 4, []string{" keeper", "hands", "keeper"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Path).To(Equal("run/study/ckpt.safetensors/x.png"))
+			Expect(a.Tags).To(Equal([]string{"hands", "keeper"}))
+			Expect(store.annotations).To(HaveKey("run/study/ckpt.safetensors/x.png"))
+		})
+
+		It("resolves a sample job item ID to its image path", func() {
+			store.outputPaths["item-1"] = "/samples/run/study/ckpt.safetensors/x.png"
+
+			a, err := svc.Set(ctx, service.ImageRef{ItemID: "item-1"}, 5, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Path).To(Equal("run/study/ckpt.safetensors/x.png"))
+		})
+
+		It("clears the annotation when given no rating and no tags", func() {
+			_, err := svc.Set(ctx, service.ImageRef{Path: "x.png"}, 3, nil)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = svc.Set(ctx, service.ImageRef{Path: "x.png"}, 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.annotations).To(BeEmpty())
+		})
+
+		DescribeTable("rejects invalid input without saving",
+			func(ref service.ImageRef, rating int, tags []string, msg string) {
+				_, err := svc.Set(ctx, ref, rating, tags)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(msg))
+				Expect(store.saveCalls).To(Equal(0))
+			},
+			Entry("rating above 5", service.ImageRef{Path: "x.png"}, 6, nil, "invalid rating"),
+			Entry("negative rating", service.ImageRef{Path: "x.png"}, -1, nil, "invalid rating"),
+			Entry("empty tag", service.ImageRef{Path: "x.png"}, 1, []string{" "}, "invalid tag"),
+			Entry("path traversal", service.ImageRef{Path: "../x.png"}, 1, nil, "invalid path"),
+			Entry("no reference", service.ImageRef{}, 1, nil, "invalid image reference"),
+			Entry("both path and item ID", service.ImageRef{Path: "x.png", ItemID: "item-1"}, 1, nil, "invalid image reference"),
+		)
+
+		It("returns a not-found error for an unknown item ID", func() {
+			_, err := svc.Set(ctx, service.ImageRef{ItemID: "missing"}, 1, nil)
+			Expect(err).To(MatchError(ContainSubstring("sample job item missing not found")))
+		})
+
+		It("rejects an item whose image is outside the sample directory", func() {
+			store.outputPaths["item-1"] = "/elsewhere/x.png"
+			_, err := svc.Set(ctx, service.ImageRef{ItemID: "item-1"}, 1, nil)
+			Expect(err).To(MatchError(ContainSubstring("outside the sample directory")))
+		})
+
+		It("rejects an item without an image", func() {
+			store.outputPaths["item-1"] = ""
+			_, err := svc.Set(ctx, service.ImageRef{ItemID: "item-1"}, 1, nil)
+			Expect(err).To(MatchError(ContainSubstring("has no image")))
+		})
+
+		It("wraps store errors", func() {
+			store.saveErr = errors.New("disk full")
+			_, err := svc.Set(ctx, service.ImageRef{Path: "x.png"}, 1, nil)
+			Expect(err).To(MatchError(ContainSubstring("saving image annotation: disk full")))
+		})
+	})
+
+	Describe("Get", func() {
+		It("returns an empty annotation for an unannotated image", func() {
+			a, err := svc.Get(ctx, "x.png")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Path).To(Equal("x.png"))
+			Expect(a.IsEmpty()).To(BeTrue())
+			Expect(a.Tags).To(BeEmpty())
+		})
+	})
+
+	Describe("List", func() {
+		It("normalizes the path prefix", func() {
+			_, err := svc.List(ctx, model.ImageAnnotationFilter{PathPrefix: "run/study/"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.listFilter.PathPrefix).To(Equal("run/study"))
+		})
+
+		It("rejects an out-of-range minimum rating", func() {
+			_, err := svc.List(ctx, model.ImageAnnotationFilter{MinRating: 6})
+			Expect(err).To(MatchError(ContainSubstring("invalid min_rating")))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("returns a not-found error for an unannotated image", func() {
+			err := svc.Delete(ctx, service.ImageRef{Path: "x.png"})
+			Expect(err).To(MatchError("annotation of x.png not found"))
+		})
+	})
+
+	Describe("BulkUpdate", func() {
+		BeforeEach(func() {
+			store.annotations["a.png"] = model.ImageAnnotation{Path: "a.png", Rating: 2, Tags: []string{"hands", "reject"}}
+		})
+
+		It("adds and removes tags and keeps ratings when no rating is given", func() {
+			store.outputPaths["item-b"] = "/samples/b.png"
+
+			annotations, err := svc.BulkUpdate(ctx,
+				[]service.ImageRef{{Path: "a.png"}, {ItemID: "item-b"}, {Path: "./a.png"}},
+				model.ImageAnnotationChange{AddTags: []string{"keeper"}, RemoveTags: []string{"reject"}},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(annotations).To(HaveLen(2))
+			Expect(annotations[0].Path).To(Equal("a.png"))
+			Expect(annotations[0].Rating).To(Equal(2))
+			Expect(annotations[0].Tags).To(Equal([]string{"hands", "keeper"}))
+			Expect(annotations[1].Path).To(Equal("b.png"))
+			Expect(annotations[1].Tags).To(Equal([]string{"keeper"}))
+			Expect(store.saveCalls).To(Equal(1))
+		})
+
+		It("sets the rating of every image", func() {
+			rating := 5
+			annotations, err := svc.BulkUpdate(ctx,
+				[]service.ImageRef{{Path: "a.png"}, {Path: "b.png"}},
+				model.ImageAnnotationChange{Rating: &rating},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(annotations[0].Rating).To(Equal(5))
+			Expect(annotations[1].Rating).To(Equal(5))
+			Expect(store.annotations["a.png"].Tags).To(Equal([]string{"hands", "reject"}))
+		})
+
+		It("saves nothing if any reference is invalid", func() {
+			rating := 5
+			_, err := svc.BulkUpdate(ctx,
+				[]service.ImageRef{{Path: "b.png"}, {ItemID: "missing"}},
+				model.ImageAnnotationChange{Rating: &rating},
+			)
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+			Expect(store.saveCalls).To(Equal(0))
+		})
+
+		It("rejects an update without images or without a change", func() {
+			_, err := svc.BulkUpdate(ctx, nil, model.ImageAnnotationChange{AddTags: []string{"keeper"}})
+			Expect(err).To(MatchError(ContainSubstring("no images given")))
+
+			_, err = svc.BulkUpdate(ctx, []service.ImageRef{{Path: "a.png"}}, model.ImageAnnotationChange{})
+			Expect(err).To(MatchError(ContainSubstring("no rating or tag change given")))
+		})
+
+		It("rejects more images than the limit", func() {
+			refs := make([]service.ImageRef, service.MaxBulkAnnotationImages+1)
+			_, err := svc.BulkUpdate(ctx, refs, model.ImageAnnotationChange{AddTags: []string{"keeper"}})
+			Expect(err).To(MatchError(ContainSubstring("at most 10000")))
+		})
+	})
+})
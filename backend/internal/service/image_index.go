@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// imageIndexSettle is how long after its last modification a directory must
+// have been read for its index entry to be trusted. Some filesystems record
+// modification times with a granularity of a second or more, so a file
+// created just after a read can leave the modification time unchanged.
+const imageIndexSettle = 2 * time.Second
+
+// ImageIndexStore persists the image index.
+type ImageIndexStore interface {
+	GetIndexedImageDir(ctx context.Context, dir string) (model.IndexedImageDir, error)
+	ReplaceIndexedImageDir(ctx context.Context, d model.IndexedImageDir) error
+	AddIndexedImage(ctx context.Context, dir, filename string, modTime, indexedAt time.Time) error
+	RemoveIndexedImage(ctx context.Context, dir, filename string, modTime, indexedAt time.Time) error
+	ListIndexedImageDirs(ctx context.Context) ([]string, error)
+	DeleteIndexedImageDir(ctx context.Context, dir string) error
+}
+
+// ImageIndexFileSystem defines the filesystem operations the image index
+// needs.
+type ImageIndexFileSystem interface {
+	ListImageFiles(dir string) ([]string, error)
+	ListSubdirectories(root string) ([]string, error)
+	DirModTime(path string) (time.Time, error)
+}
+
+// ImageIndex serves the sample image listings of directories under the
+// sample directory from the database, so that a training run with tens of
+// thousands of images is listed without reading every checkpoint directory.
+//
+// Each indexed directory records its modification time when it was read. A
+// listing costs one stat of the directory: while the modification time is
+// unchanged the indexed images are returned, otherwise the directory is read
+// again and its entry replaced. Changes made behind the server's back are
+// therefore picked up on the next listing. Reconcile indexes the whole sample
+// directory ahead of the first listings, and the job executor and watcher
+// record images as they are written and removed.
+type ImageIndex struct {
+	store     ImageIndexStore
+	fs        ImageIndexFileSystem
+	sampleDir string
+	logger    *logrus.Entry
+	// timeNow is a function that returns the current time, injected for testability.
+	timeNow func() time.Time
+}
+
+// NewImageIndex creates an ImageIndex for the sample directory sampleDir.
+func NewImageIndex(store ImageIndexStore, fs ImageIndexFileSystem, sampleDir string, logger *logrus.Logger) *ImageIndex {
+	return &ImageIndex{
+		store:     store,
+		fs:        fs,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "image_index"),
+		timeNow:   time.Now,
+	}
+}
+
+// ListImageFiles returns the names of the sample images in dir, an absolute
+// directory under the sample directory. Directories outside it, and
+// directories that cannot be stat'ed, are listed from the filesystem.
+func (x *ImageIndex) ListImageFiles(dir string) ([]string, error) {
+	x.logger.WithField("directory", dir).Trace("entering ListImageFiles")
+	defer x.logger.Trace("returning from ListImageFiles")
+
+	// ListImageFiles stands in for the filesystem's, which takes no context.
+	ctx := context.Background()
+
+	rel, ok := x.relDir(dir)
+	if !ok {
+		return x.fs.ListImageFiles(dir)
+	}
+	modTime, err := x.fs.DirModTime(dir)
+	if err != nil {
+		return x.fs.ListImageFiles(dir)
+	}
+
+	indexed, err := x.store.GetIndexedImageDir(ctx, rel)
+	if err == nil && indexed.ModTime.Equal(modTime) && indexed.IndexedAt.Sub(modTime) >= imageIndexSettle {
+		x.logger.WithFields(logrus.Fields{
+			"dir":         rel,
+			"image_count": len(indexed.Filenames),
+		}).Debug("listed images from index")
+		return indexed.Filenames, nil
+	}
+	if err != nil && err != sql.ErrNoRows {
+		x.logger.WithFields(logrus.Fields{
+			"dir":   rel,
+			"error": err.Error(),
+		}).Warn("failed to read image index, listing directory")
+	}
+	return x.index(ctx, dir, rel, modTime)
+}
+
+// index reads dir from the filesystem and replaces its index entry.
+// modTime must have been taken before the read.
+func (x *ImageIndex) index(ctx context.Context, dir, rel string, modTime time.Time) ([]string, error) {
+	indexedAt := x.timeNow()
+	files, err := x.fs.ListImageFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := x.store.ReplaceIndexedImageDir(ctx, model.IndexedImageDir{
+		Dir:       rel,
+		ModTime:   modTime,
+		IndexedAt: indexedAt,
+		Filenames: files,
+	}); err != nil {
+		x.logger.WithFields(logrus.Fields{
+			"dir":   rel,
+			"error": err.Error(),
+		}).Warn("failed to update image index")
+	}
+	return files, nil
+}
+
+// AddImage records the sample image at relPath, relative to the sample
+// directory, in the index. Images in directories that have not been indexed
+// yet are left to the directory's first listing.
+func (x *ImageIndex) AddImage(relPath string) {
+	x.updateImage(relPath, x.store.AddIndexedImage)
+}
+
+// RemoveImage removes the sample image at relPath, relative to the sample
+// directory, from the index.
+func (x *ImageIndex) RemoveImage(relPath string) {
+	x.updateImage(relPath, x.store.RemoveIndexedImage)
+}
+
+func (x *ImageIndex) updateImage(relPath string, update func(ctx context.Context, dir, filename string, modTime, indexedAt time.Time) error) {
+	dir, filename := path.Split(path.Clean(filepath.ToSlash(relPath)))
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" {
+		return
+	}
+	// The entry is recorded as read now, just after the modification, so
+	// it is only trusted after the directory has been read once more (see
+	// imageIndexSettle). Changes made at the same time by someone else
+	// cannot be lost that way.
+	indexedAt := x.timeNow()
+	modTime, err := x.fs.DirModTime(filepath.Join(x.sampleDir, filepath.FromSlash(dir)))
+	if err != nil {
+		x.logger.WithFields(logrus.Fields{
+			"dir":   dir,
+			"error": err.Error(),
+		}).Debug("image directory not found, not updating index")
+		return
+	}
+	err = update(context.Background(), dir, filename, modTime, indexedAt)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		x.logger.WithFields(logrus.Fields{
+			"image_path": relPath,
+			"error":      err.Error(),
+		}).Warn("failed to update image index")
+	}
+}
+
+// Reconcile brings the index in line with the sample directory: every
+// directory under it that changed since it was indexed is read again, and
+// directories that no longer exist are dropped. Thumbnail directories are
+// skipped. It is meant to run in the background at startup.
+func (x *ImageIndex) Reconcile(ctx context.Context) error {
+	x.logger.Trace("entering Reconcile")
+	defer x.logger.Trace("returning from Reconcile")
+
+	start := x.timeNow()
+	seen := make(map[string]struct{})
+	imageCount := 0
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		subdirs, err := x.fs.ListSubdirectories(dir)
+		if err != nil {
+			return err
+		}
+		for _, name := range subdirs {
+			childRel := path.Join(rel, name)
+			if isThumbnailDir(childRel) {
+				continue
+			}
+			child := filepath.Join(dir, name)
+			seen[childRel] = struct{}{}
+			files, err := x.ListImageFiles(child)
+			if err != nil {
+				x.logger.WithFields(logrus.Fields{
+					"dir":   childRel,
+					"error": err.Error(),
+				}).Warn("failed to index image directory")
+				continue
+			}
+			imageCount += len(files)
+			if err := walk(child, childRel); err != nil {
+				x.logger.WithFields(logrus.Fields{
+					"dir":   childRel,
+					"error": err.Error(),
+				}).Warn("failed to list subdirectories")
+			}
+		}
+		return nil
+	}
+	if err := walk(x.sampleDir, ""); err != nil {
+		x.logger.WithError(err).Error("failed to reconcile image index")
+		return err
+	}
+
+	indexed, err := x.store.ListIndexedImageDirs(ctx)
+	if err != nil {
+		x.logger.WithError(err).Error("failed to list indexed image directories")
+		return err
+	}
+	removed := 0
+	for _, dir := range indexed {
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+		if err := x.store.DeleteIndexedImageDir(ctx, dir); err != nil {
+			x.logger.WithFields(logrus.Fields{
+				"dir":   dir,
+				"error": err.Error(),
+			}).Warn("failed to drop removed directory from image index")
+			continue
+		}
+		removed++
+	}
+
+	x.logger.WithFields(logrus.Fields{
+		"dir_count":     len(seen),
+		"image_count":   imageCount,
+		"removed_count": removed,
+		"duration_ms":   x.timeNow().Sub(start).Milliseconds(),
+	}).Info("image index reconciled")
+	return nil
+}
+
+// relDir returns dir relative to the sample directory, with forward
+// slashes. ok is false for the sample directory itself and for directories
+// outside it.
+func (x *ImageIndex) relDir(dir string) (string, bool) {
+	rel, err := filepath.Rel(x.sampleDir, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
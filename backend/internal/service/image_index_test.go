@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// fakeImageIndexStore is an in-memory ImageIndexStore.
+type fakeImageIndexStore struct {
+	dirs   map[string]model.IndexedImageDir
+	getErr error
+}
+
+func newFakeImageIndexStore() *fakeImageIndexStore {
+	return &fakeImageIndexStore{dirs: make(map[string]model.IndexedImageDir)}
+}
+
+func (f *fakeImageIndexStore) GetIndexedImageDir(ctx context.Context, dir string) (model.IndexedImageDir, error) {
+	if f.getErr != nil {
+		return model.IndexedImageDir{}, f.getErr
+	}
+	d, ok := f.dirs[dir]
+	if !ok {
+		return model.IndexedImageDir{}, sql.ErrNoRows
+	}
+	return d, nil
+}
+
+func (f *fakeImageIndexStore) ReplaceIndexedImageDir(ctx context.Context, d model.IndexedImageDir) error {
+	d.Filenames = append([]string(nil), d.Filenames...)
+	f.dirs[d.Dir] = d
+	return nil
+}
+
+func (f *fakeImageIndexStore) AddIndexedImage(ctx context.Context, dir, filename string, modTime, indexedAt time.Time) error {
+	d, ok := f.dirs[dir]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	d.Filenames = append(d.Filenames, filename)
+	sort.Strings(d.Filenames)
+	d.ModTime, d.IndexedAt = modTime, indexedAt
+	f.dirs[dir] = d
+	return nil
+}
+
+func (f *fakeImageIndexStore) RemoveIndexedImage(ctx context.Context, dir, filename string, modTime, indexedAt time.Time) error {
+	d, ok := f.dirs[dir]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	var kept []string
+	for _, name := range d.Filenames {
+		if name != filename {
+			kept = append(kept, name)
+		}
+	}
+	d.Filenames = kept
+	d.ModTime, d.IndexedAt = modTime, indexedAt
+	f.dirs[dir] = d
+	return nil
+}
+
+func (f *fakeImageIndexStore) ListIndexedImageDirs(ctx context.Context) ([]string, error) {
+	var dirs []string
+	for dir := range f.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func (f *fakeImageIndexStore) DeleteIndexedImageDir(ctx context.Context, dir string) error {
+	delete(f.dirs, dir)
+	return nil
+}
+
+// fakeImageIndexFS implements ImageIndexFileSystem and counts the
+// directories it reads.
+type fakeImageIndexFS struct {
+	files    map[string][]string  // abs dir → image filenames
+	subdirs  map[string][]string  // abs dir → subdirectory names
+	modTimes map[string]time.Time // abs dir → modification time
+	reads    map[string]int       // abs dir → ListImageFiles calls
+}
+
+func newFakeImageIndexFS() *fakeImageIndexFS {
+	return &fakeImageIndexFS{
+		files:    make(map[string][]string),
+		subdirs:  make(map[string][]string),
+		modTimes: make(map[string]time.Time),
+		reads:    make(map[string]int),
+	}
+}
+
+func (f *fakeImageIndexFS) ListImageFiles(dir string) ([]string, error) {
+	f.reads[dir]++
+	if _, ok := f.modTimes[dir]; !ok {
+		return nil, errors.New("no such directory")
+	}
+	return f.files[dir], nil
+}
+
+func (f *fakeImageIndexFS) ListSubdirectories(root string) ([]string, error) {
+	return f.subdirs[root], nil
+}
+
+func (f *fakeImageIndexFS) DirModTime(path string) (time.Time, error) {
+	t, ok := f.modTimes[path]
+	if !ok {
+		return time.Time{}, errors.New("no such directory")
+	}
+	return t, nil
+}
+
+var _ = Describe("ImageIndex", func() {
+	var (
+		store *fakeImageIndexStore
+		fs    *fakeImageIndexFS
+		index *ImageIndex
+		now   time.Time
+	)
+
+	const cpDir = "/samples/study/cp.safetensors"
+
+	BeforeEach(func() {
+		store = newFakeImageIndexStore()
+		fs = newFakeImageIndexFS()
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		index = NewImageIndex(store, fs, "/samples", logger)
+		now = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		index.timeNow = func() time.Time { return now }
+
+		fs.modTimes[cpDir] = now.Add(-time.Hour)
+		fs.files[cpDir] = []string{"a.png", "b.png"}
+	})
+
+	Describe("ListImageFiles", func() {
+		It("reads a directory that has not been indexed and indexes it", func() {
+			files, err := index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(Equal([]string{"a.png", "b.png"}))
+			Expect(store.dirs).To(HaveKey("study/cp.safetensors"))
+			Expect(store.dirs["study/cp.safetensors"].ModTime).To(Equal(now.Add(-time.Hour)))
+		})
+
+		It("serves an unchanged directory from the index", func() {
+			_, err := index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			files, err := index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(Equal([]string{"a.png", "b.png"}))
+			Expect(fs.reads[cpDir]).To(Equal(1))
+		})
+
+		It("reads a directory again when its modification time changed", func() {
+			_, err := index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			fs.files[cpDir] = []string{"a.png", "b.png", "c.png"}
+			fs.modTimes[cpDir] = now.Add(-time.Minute)
+
+			files, err := index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(Equal([]string{"a.png", "b.png", "c.png"}))
+			Expect(fs.reads[cpDir]).To(Equal(2))
+		})
+
+		It("reads a directory again when it was indexed right after it changed", func() {
+			fs.modTimes[cpDir] = now.Add(-time.Second)
+			_, err := index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			now = now.Add(time.Minute)
+			_, err = index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.reads[cpDir]).To(Equal(2))
+
+			_, err = index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.reads[cpDir]).To(Equal(2))
+		})
+
+		It("falls back to the filesystem when the index cannot be read", func() {
+			store.getErr = errors.New("database is locked")
+
+			files, err := index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(Equal([]string{"a.png", "b.png"}))
+		})
+
+		It("lists directories outside the sample directory from the filesystem", func() {
+			fs.modTimes["/elsewhere"] = now
+			fs.files["/elsewhere"] = []string{"x.png"}
+
+			files, err := index.ListImageFiles("/elsewhere")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(Equal([]string{"x.png"}))
+			Expect(store.dirs).To(BeEmpty())
+		})
+
+		It("returns the filesystem error for a missing directory", func() {
+			_, err := index.ListImageFiles("/samples/study/missing.safetensors")
+			Expect(err).To(HaveOccurred())
+			Expect(store.dirs).To(BeEmpty())
+		})
+	})
+
+	Describe("AddImage and RemoveImage", func() {
+		BeforeEach(func() {
+			_, err := index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("records an added image and has the directory read once more", func() {
+			fs.files[cpDir] = []string{"a.png", "b.png", "c.png"}
+			fs.modTimes[cpDir] = now
+			index.AddImage("study/cp.safetensors/c.png")
+
+			Expect(store.dirs["study/cp.safetensors"].Filenames).To(ContainElement("c.png"))
+
+			now = now.Add(time.Minute)
+			files, err := index.ListImageFiles(cpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(Equal([]string{"a.png", "b.png", "c.png"}))
+			Expect(fs.reads[cpDir]).To(Equal(2))
+		})
+
+		It("removes an image", func() {
+			index.RemoveImage("study/cp.safetensors/a.png")
+			Expect(store.dirs["study/cp.safetensors"].Filenames).To(Equal([]string{"b.png"}))
+		})
+
+		It("ignores images in directories that have not been indexed", func() {
+			fs.modTimes["/samples/study/other.safetensors"] = now
+			index.AddImage("study/other.safetensors/a.png")
+			Expect(store.dirs).NotTo(HaveKey("study/other.safetensors"))
+		})
+	})
+
+	Describe("Reconcile", func() {
+		It("indexes every directory except thumbnails and drops removed ones", func() {
+			fs.subdirs["/samples"] = []string{"study"}
+			fs.subdirs["/samples/study"] = []string{"cp.safetensors"}
+			fs.subdirs[cpDir] = []string{"thumbnails"}
+			fs.modTimes["/samples/study"] = now.Add(-time.Hour)
+			store.dirs["study/removed.safetensors"] = model.IndexedImageDir{Dir: "study/removed.safetensors"}
+
+			Expect(index.Reconcile(context.Background())).To(Succeed())
+
+			Expect(store.dirs).To(HaveKey("study"))
+			Expect(store.dirs).To(HaveKey("study/cp.safetensors"))
+			Expect(store.dirs).NotTo(HaveKey("study/cp.safetensors/thumbnails"))
+			Expect(store.dirs).NotTo(HaveKey("study/removed.safetensors"))
+		})
+
+		It("does not read directories that are up to date", func() {
+			fs.subdirs["/samples"] = []string{"study"}
+			fs.subdirs["/samples/study"] = []string{"cp.safetensors"}
+			fs.modTimes["/samples/study"] = now.Add(-time.Hour)
+
+			Expect(index.Reconcile(context.Background())).To(Succeed())
+			Expect(index.Reconcile(context.Background())).To(Succeed())
+			Expect(fs.reads[cpDir]).To(Equal(1))
+		})
+	})
+})
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
@@ -40,8 +42,10 @@ func NewImageMetadataService(reader ImageMetadataReader, sampleDir string, logge
 // GetMetadata reads metadata for the image at the given relative path (within sampleDir).
 // It first checks for a JSON sidecar file (same base name, .json extension). If found,
 // the sidecar is parsed and returned with typed fields. If no sidecar exists, it falls
-// back to parsing PNG tEXt chunks (all values are strings in that case).
-// Returns an empty ImageMetadataValues (not error) when no metadata is available.
+// back to the query-encoded filename, supplemented by PNG tEXt chunks for keys the
+// filename does not carry. Filename keys are normalized to sidecar names (prompt →
+// prompt_name, sampler → sampler_name) and the checkpoint is taken from the parent
+// directory. Returns an empty ImageMetadataValues (not error) when no metadata is available.
 func (s *ImageMetadataService) GetMetadata(relPath string) (*model.ImageMetadataValues, error) {
 	s.logger.WithField("relative_path", relPath).Trace("entering GetMetadata")
 	defer s.logger.Trace("returning from GetMetadata")
@@ -79,6 +83,7 @@ func (s *ImageMetadataService) GetMetadata(relPath string) (*model.ImageMetadata
 			"string_field_count":   len(sidecarMeta.StringFields),
 			"numeric_field_count":  len(sidecarMeta.NumericFields),
 		}).Debug("metadata read from sidecar")
+		sidecarMeta.Source = model.ImageMetadataSourceSidecar
 		return sidecarMeta, nil
 	}
 
@@ -92,6 +97,16 @@ func (s *ImageMetadataService) GetMetadata(relPath string) (*model.ImageMetadata
 		s.logger.WithField("sidecar_path", sidecarPath).Debug("no sidecar found, reading PNG metadata")
 	}
 
+	// WebP and JPEG outputs carry no tEXt chunks; only confirm the image exists.
+	if !strings.EqualFold(ext, ".png") {
+		f, err := s.reader.OpenFile(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("opening file: %w", err)
+		}
+		f.Close()
+		return filenameMetadata(relPath), nil
+	}
+
 	pngFields, err := parsePNGTextChunks(s.reader, absPath)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
@@ -105,21 +120,72 @@ func (s *ImageMetadataService) GetMetadata(relPath string) (*model.ImageMetadata
 		"metadata_count": len(pngFields),
 	}).Debug("PNG metadata extracted")
 
-	return &model.ImageMetadataValues{
-		StringFields:  pngFields,
-		NumericFields: map[string]float64{},
-	}, nil
+	result := filenameMetadata(relPath)
+	for k, v := range pngFields {
+		if _, ok := result.StringFields[k]; ok {
+			continue
+		}
+		if _, ok := result.NumericFields[k]; ok {
+			continue
+		}
+		result.StringFields[k] = v
+	}
+	if result.Source == model.ImageMetadataSourceNone && len(pngFields) > 0 {
+		result.Source = model.ImageMetadataSourcePNG
+	}
+	return result, nil
+}
+
+// filenameMetadataAliases maps query-encoded filename keys to their sidecar names.
+var filenameMetadataAliases = map[string]string{
+	"prompt":  "prompt_name",
+	"sampler": "sampler_name",
+}
+
+// filenameMetadata parses generation parameters from the query-encoded filename
+// of the image at relPath. Keys are normalized to sidecar names and values of
+// numeric sidecar keys are parsed as numbers. Source is ImageMetadataSourceNone
+// when the filename carries no parameters.
+func filenameMetadata(relPath string) *model.ImageMetadataValues {
+	result := &model.ImageMetadataValues{
+		StringFields:  make(map[string]string),
+		NumericFields: make(map[string]float64),
+		Source:        model.ImageMetadataSourceNone,
+	}
+	dims, _ := parseFilename(path.Base(relPath))
+	if !hasDimensionValues(dims) {
+		return result
+	}
+	result.Source = model.ImageMetadataSourceFilename
+	for k, v := range dims {
+		if alias, ok := filenameMetadataAliases[k]; ok {
+			k = alias
+		}
+		if numericSidecarFields[k] {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				result.NumericFields[k] = f
+				continue
+			}
+		}
+		result.StringFields[k] = v
+	}
+	if dir := path.Dir(relPath); dir != "." {
+		result.StringFields["checkpoint"] = path.Base(dir)
+	}
+	return result
 }
 
 // numericSidecarFields is the set of sidecar JSON keys that are treated as
 // numeric (float64) values. All other keys are treated as strings.
 var numericSidecarFields = map[string]bool{
-	"seed":   true,
-	"steps":  true,
-	"cfg":    true,
-	"width":  true,
-	"height": true,
-	"index":  true,
+	"seed":    true,
+	"steps":   true,
+	"cfg":     true,
+	"width":   true,
+	"height":  true,
+	"index":   true,
+	"shift":   true,
+	"denoise": true,
 }
 
 // parseSidecarJSON reads a JSON sidecar file and returns its contents with
@@ -12,6 +12,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
 
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
 )
 
@@ -339,6 +340,74 @@ var _ = Describe("ImageMetadataService", func() {
 				Expect(result.StringFields).To(HaveKeyWithValue("job_id", "job-42"))
 			})
 
+			It("reports the sidecar as the metadata source", func() {
+				subDir := filepath.Join(tmpDir, "checkpoint.safetensors")
+				Expect(os.MkdirAll(subDir, 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(subDir, "prompt=city&seed=1.png"), buildMinimalPNG(), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(subDir, "prompt=city&seed=1.json"), []byte(`{"prompt_name":"forest"}`), 0644)).To(Succeed())
+
+				svc = service.NewImageMetadataService(&realFileOpener{}, tmpDir, logger)
+				result, err := svc.GetMetadata("checkpoint.safetensors/prompt=city&seed=1.png")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Source).To(Equal(model.ImageMetadataSourceSidecar))
+				// The sidecar wins over the filename
+				Expect(result.StringFields).To(HaveKeyWithValue("prompt_name", "forest"))
+				Expect(result.NumericFields).NotTo(HaveKey("seed"))
+			})
+
+			It("falls back to the query-encoded filename when no sidecar exists", func() {
+				subDir := filepath.Join(tmpDir, "model-step00001000.safetensors")
+				Expect(os.MkdirAll(subDir, 0755)).To(Succeed())
+				filename := "cfg=7.0&prompt=forest&sampler=euler&scheduler=normal&seed=420&steps=20.png"
+				pngData := buildPNGWithTextChunks(map[string]string{
+					"workflow": `{"nodes": []}`,
+					"seed":     "999",
+				})
+				Expect(os.WriteFile(filepath.Join(subDir, filename), pngData, 0644)).To(Succeed())
+
+				svc = service.NewImageMetadataService(&realFileOpener{}, tmpDir, logger)
+				result, err := svc.GetMetadata("model-step00001000.safetensors/" + filename)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Source).To(Equal(model.ImageMetadataSourceFilename))
+				Expect(result.StringFields).To(HaveKeyWithValue("prompt_name", "forest"))
+				Expect(result.StringFields).To(HaveKeyWithValue("sampler_name", "euler"))
+				Expect(result.StringFields).To(HaveKeyWithValue("scheduler", "normal"))
+				Expect(result.StringFields).To(HaveKeyWithValue("checkpoint", "model-step00001000.safetensors"))
+				Expect(result.NumericFields).To(HaveKeyWithValue("seed", BeNumerically("==", 420)))
+				Expect(result.NumericFields).To(HaveKeyWithValue("steps", BeNumerically("==", 20)))
+				Expect(result.NumericFields).To(HaveKeyWithValue("cfg", BeNumerically("==", 7)))
+				// PNG chunks fill in keys the filename does not carry, but never override it
+				Expect(result.StringFields).To(HaveKey("workflow"))
+				Expect(result.StringFields).NotTo(HaveKey("seed"))
+			})
+
+			It("reads WebP and JPEG images from the filename without parsing PNG chunks", func() {
+				Expect(os.WriteFile(filepath.Join(tmpDir, "prompt=forest&seed=420.webp"), []byte("RIFF....WEBP"), 0644)).To(Succeed())
+
+				svc = service.NewImageMetadataService(&realFileOpener{}, tmpDir, logger)
+				result, err := svc.GetMetadata("prompt=forest&seed=420.webp")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Source).To(Equal(model.ImageMetadataSourceFilename))
+				Expect(result.StringFields).To(HaveKeyWithValue("prompt_name", "forest"))
+
+				_, err = svc.GetMetadata("missing.jpg")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("reports PNG as the source when only tEXt chunks are available", func() {
+				Expect(os.WriteFile(filepath.Join(tmpDir, "image.png"), buildPNGWithTextChunks(map[string]string{"prompt": "{}"}), 0644)).To(Succeed())
+
+				svc = service.NewImageMetadataService(&realFileOpener{}, tmpDir, logger)
+				result, err := svc.GetMetadata("image.png")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Source).To(Equal(model.ImageMetadataSourcePNG))
+				Expect(result.StringFields).NotTo(HaveKey("checkpoint"))
+			})
+
 			It("returns empty fields when sidecar is empty JSON object", func() {
 				subDir := filepath.Join(tmpDir, "checkpoint.safetensors")
 				Expect(os.MkdirAll(subDir, 0755)).To(Succeed())
@@ -397,5 +466,9 @@ var _ = Describe("ImageMetadataService", func() {
 		Entry("sampler_name is string", "sampler_name", "euler", false, 0.0, "euler"),
 		Entry("workflow_name is string", "workflow_name", "flux.json", false, 0.0, "flux.json"),
 		Entry("job_id is string", "job_id", "job-99", false, 0.0, "job-99"),
+		Entry("shift is numeric", "shift", 3.1, true, 3.1, ""),
+		Entry("vae is string", "vae", "ae.safetensors", false, 0.0, "ae.safetensors"),
+		Entry("clip is string", "clip", "t5xxl.safetensors", false, 0.0, "t5xxl.safetensors"),
+		Entry("timestamp is string", "timestamp", "2026-02-25T12:00:00Z", false, 0.0, "2026-02-25T12:00:00Z"),
 	)
 })
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// WebPEncoder encodes PNG image data as lossy WebP.
+type WebPEncoder interface {
+	EncodeWebP(pngData []byte, quality int) ([]byte, error)
+}
+
+// ImageTranscoder converts the PNG images downloaded from ComfyUI into a
+// job's output format. JPEG is encoded in-process; WebP needs a WebPEncoder.
+type ImageTranscoder struct {
+	webp   WebPEncoder // nil when no WebP encoder is available
+	logger *logrus.Entry
+}
+
+// NewImageTranscoder creates an ImageTranscoder. Pass nil for webp to disable
+// WebP output.
+func NewImageTranscoder(webp WebPEncoder, logger *logrus.Logger) *ImageTranscoder {
+	return &ImageTranscoder{
+		webp:   webp,
+		logger: logger.WithField("component", "image_transcoder"),
+	}
+}
+
+// Supports reports whether images can be saved in the given format.
+func (t *ImageTranscoder) Supports(format model.OutputFormat) bool {
+	switch format {
+	case model.OutputFormatPNG, model.OutputFormatJPEG:
+		return true
+	case model.OutputFormatWebP:
+		return t.webp != nil
+	default:
+		return false
+	}
+}
+
+// Transcode converts pngData to the given format. PNG data is returned
+// unchanged; quality is ignored for PNG.
+func (t *ImageTranscoder) Transcode(pngData []byte, format model.OutputFormat, quality int) ([]byte, error) {
+	t.logger.WithFields(logrus.Fields{
+		"format":  format,
+		"quality": quality,
+	}).Trace("entering Transcode")
+	defer t.logger.Trace("returning from Transcode")
+
+	switch format {
+	case model.OutputFormatPNG, "":
+		return pngData, nil
+	case model.OutputFormatJPEG:
+		img, _, err := image.Decode(bytes.NewReader(pngData))
+		if err != nil {
+			return nil, fmt.Errorf("decoding source image: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("encoding JPEG: %w", err)
+		}
+		return buf.Bytes(), nil
+	case model.OutputFormatWebP:
+		if t.webp == nil {
+			return nil, fmt.Errorf("webp output is not available: no WebP encoder configured")
+		}
+		data, err := t.webp.EncodeWebP(pngData, quality)
+		if err != nil {
+			return nil, fmt.Errorf("encoding WebP: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
@@ -0,0 +1,92 @@
+package service_test
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeWebPEncoder records the quality it was called with.
+type fakeWebPEncoder struct {
+	quality int
+	err     error
+}
+
+func (f *fakeWebPEncoder) EncodeWebP(pngData []byte, quality int) ([]byte, error) {
+	f.quality = quality
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte("RIFF....WEBP"), nil
+}
+
+var _ = Describe("ImageTranscoder", func() {
+	var (
+		logger  *logrus.Logger
+		pngData []byte
+	)
+
+	BeforeEach(func() {
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+		pngData = solidPNG(32, 16, color.White)
+	})
+
+	It("returns PNG data unchanged", func() {
+		t := service.NewImageTranscoder(nil, logger)
+		data, err := t.Transcode(pngData, model.OutputFormatPNG, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal(pngData))
+	})
+
+	It("encodes JPEG in-process", func() {
+		t := service.NewImageTranscoder(nil, logger)
+		data, err := t.Transcode(pngData, model.OutputFormatJPEG, 80)
+		Expect(err).NotTo(HaveOccurred())
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(img.Bounds().Dx()).To(Equal(32))
+		Expect(img.Bounds().Dy()).To(Equal(16))
+	})
+
+	It("delegates WebP to the encoder with the requested quality", func() {
+		encoder := &fakeWebPEncoder{}
+		t := service.NewImageTranscoder(encoder, logger)
+		data, err := t.Transcode(pngData, model.OutputFormatWebP, 75)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal([]byte("RIFF....WEBP")))
+		Expect(encoder.quality).To(Equal(75))
+	})
+
+	It("returns encoder errors", func() {
+		t := service.NewImageTranscoder(&fakeWebPEncoder{err: errors.New("boom")}, logger)
+		_, err := t.Transcode(pngData, model.OutputFormatWebP, 75)
+		Expect(err).To(MatchError(ContainSubstring("boom")))
+	})
+
+	It("fails WebP when no encoder is configured", func() {
+		t := service.NewImageTranscoder(nil, logger)
+		_, err := t.Transcode(pngData, model.OutputFormatWebP, 75)
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("Supports",
+		func(encoder service.WebPEncoder, format model.OutputFormat, expected bool) {
+			Expect(service.NewImageTranscoder(encoder, logger).Supports(format)).To(Equal(expected))
+		},
+		Entry("png", nil, model.OutputFormatPNG, true),
+		Entry("jpeg", nil, model.OutputFormatJPEG, true),
+		Entry("webp without encoder", nil, model.OutputFormatWebP, false),
+		Entry("webp with encoder", &fakeWebPEncoder{}, model.OutputFormatWebP, true),
+		Entry("unknown format", nil, model.OutputFormat("gif"), false),
+	)
+})
@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -13,17 +19,22 @@ import (
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/buildinfo"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
 	"github.com/sirupsen/logrus"
 )
 
 // JobExecutorStore defines the persistence operations the job executor needs.
 type JobExecutorStore interface {
-	GetSampleJob(id string) (model.SampleJob, error)
-	UpdateSampleJob(j model.SampleJob) error
-	ListSampleJobItems(jobID string) ([]model.SampleJobItem, error)
-	UpdateSampleJobItem(i model.SampleJobItem) error
-	ListSampleJobs() ([]model.SampleJob, error)
-	GetStudy(id string) (model.Study, error)
+	GetSampleJob(ctx context.Context, id string) (model.SampleJob, error)
+	UpdateSampleJob(ctx context.Context, j *model.SampleJob) error
+	JobTransitionStore
+	ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error)
+	UpdateSampleJobItem(ctx context.Context, i *model.SampleJobItem) error
+	ListSampleJobs(ctx context.Context) ([]model.SampleJob, error)
+	GetStudy(ctx context.Context, id string) (model.Study, error)
+	ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error)
+	GetSampleJobParameters(ctx context.Context, jobID string) (model.SampleJobParameters, error)
+	CreateSampleJobParameters(ctx context.Context, p model.SampleJobParameters) error
 }
 
 // ComfyUIClient defines the interface for ComfyUI HTTP operations.
@@ -32,6 +43,12 @@ type ComfyUIClient interface {
 	GetHistory(ctx context.Context, promptID string) (model.HistoryResponse, error)
 	DownloadImage(ctx context.Context, filename string, subfolder string, folderType string) ([]byte, error)
 	CancelPrompt(ctx context.Context, promptID string) error
+	// InterruptPrompt stops a prompt ComfyUI is executing; CancelPrompt only
+	// removes queued prompts.
+	InterruptPrompt(ctx context.Context, promptID string) error
+	// GetQueueStatus is used after a reconnect to tell prompts that are still
+	// queued or running from prompts ComfyUI has lost.
+	GetQueueStatus(ctx context.Context) (*model.ComfyUIQueue, error)
 }
 
 // ComfyUIWS defines the interface for ComfyUI WebSocket operations.
@@ -60,13 +77,120 @@ type EventHub interface {
 	Broadcast(event model.FSEvent)
 }
 
+// ExecutorGate reports whether this process may execute jobs. In
+// multi-process mode it is backed by the executor lease so that only one
+// process submits work to ComfyUI.
+type ExecutorGate interface {
+	Held() bool
+}
+
+// OutputTranscoder converts a downloaded PNG into a job's output format.
+type OutputTranscoder interface {
+	Transcode(pngData []byte, format model.OutputFormat, quality int) ([]byte, error)
+}
+
+// ComfyUILogReader reads ComfyUI's recent log output.
+type ComfyUILogReader interface {
+	RecentLog(ctx context.Context) (string, error)
+}
+
+// CheckpointAppender adds items for checkpoints that appeared after an
+// append-mode job was created. It is satisfied by SampleJobService.
+type CheckpointAppender interface {
+	AppendNewCheckpoints(ctx context.Context, jobID string) (int, error)
+}
+
+// JobNotifier is told when a job completes or is stopped. It is satisfied by
+// WebhookNotifier.
+type JobNotifier interface {
+	JobFinished(job model.SampleJob)
+}
+
+// InputImageReader reads the input images of img2img jobs.
+type InputImageReader interface {
+	ReadFile(path string) ([]byte, error)
+}
+
+// ComfyUIImageUploader uploads images to ComfyUI's input directory.
+type ComfyUIImageUploader interface {
+	UploadImage(ctx context.Context, filename string, data []byte) (string, error)
+}
+
+// ImageScorer computes automatic quality scores, such as an aesthetic score
+// or the CLIP similarity to the prompt, of a saved sample image. It is
+// satisfied by store.ScoringClient.
+type ImageScorer interface {
+	ScoreImage(ctx context.Context, req model.ImageScoreRequest) (map[string]float64, error)
+}
+
+// scoreNamePattern matches the score names the executor stores. Names are
+// used as JSON paths when sorting items by score, so they are restricted to
+// identifier characters.
+var scoreNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+
+// CheckpointStager copies checkpoints ComfyUI cannot see into a staging
+// directory it shares with the sampler, and removes them again. It is
+// satisfied by CheckpointStaging.
+type CheckpointStager interface {
+	Stage(modelPath, filename string) (string, error)
+	Unstage(stagedPath string) error
+}
+
+// PrewarmVRAMChecker decides whether ComfyUI's GPU has room to load the
+// next checkpoint of a job next to the current one. It is satisfied by
+// VRAMGuard.
+type PrewarmVRAMChecker interface {
+	HasRoomForModel(workflowName string) (bool, error)
+}
+
+// prewarmLatentSize is the width and height of the latent a pre-warm prompt
+// samples; the smallest size every model family accepts.
+const prewarmLatentSize = 64
+
+// appendCheckInterval is how often finished append-mode jobs are checked for
+// new checkpoints while the executor is idle.
+const appendCheckInterval = 30 * time.Second
+
+// comfyUIExceptionMarker starts the lines ComfyUI logs when a node raises.
+// They are followed by the Python traceback.
+const comfyUIExceptionMarker = "!!! Exception during processing !!!"
+
+// failureLogTimeout bounds how long reading ComfyUI's log may delay marking
+// a failed item.
+const failureLogTimeout = 5 * time.Second
+
 // sampleTimingWindowSize is the number of recent sample durations used for
 // the moving average ETA calculation.
 const sampleTimingWindowSize = 10
 
+// defaultDrainTimeout is how long Stop waits for the item in flight to finish
+// unless SetDrainTimeout is called.
+const defaultDrainTimeout = 30 * time.Second
+
+// drainPollInterval is how often Stop checks whether the item in flight has
+// finished while draining.
+const drainPollInterval = 100 * time.Millisecond
+
+// defaultItemTimeout is how long the executor waits for an event of the
+// prompt in flight unless SetItemTimeout is called.
+const defaultItemTimeout = 15 * time.Minute
+
+// defaultHistoryPollInterval is how often the history of a prompt that was
+// still running when the executor reconnected is polled, in case its
+// completion event is missed again.
+const defaultHistoryPollInterval = 2 * time.Second
+
+// queuedPrompt is an item of the active job whose prompt waits in ComfyUI's
+// queue behind the prompt of the active item.
+type queuedPrompt struct {
+	itemID   string
+	promptID string
+}
+
 // JobExecutor executes sample jobs in the background.
 type JobExecutor struct {
 	store             JobExecutorStore
+	states            *JobStateMachine
 	comfyuiClient     ComfyUIClient
 	comfyuiWS         ComfyUIWS
 	workflowLoader    WorkflowLoaderService
@@ -79,15 +203,43 @@ type JobExecutor struct {
 	logger            *logrus.Entry
 
 	dirRemover        SampleDirRemover // optional; used for clear-existing at job start
+	pinGuard          PinGuard         // optional; protects pinned content from clear-existing
+	gate              ExecutorGate     // optional; when set, jobs are only processed while the gate is held
+	transcoder        OutputTranscoder // optional; converts downloaded PNGs to the job's output format
+	failureLog        ComfyUILogReader // optional; read when an item fails with an execution error
+	failureLogLines   int
+	appender          CheckpointAppender // optional; extends append-mode jobs with new checkpoints
+	images            ImageIndexUpdater  // optional; records saved images in the image index
+	notifier          JobNotifier        // optional; told when a job completes or is stopped
+	inputReader       InputImageReader     // optional; reads the input images of img2img jobs
+	inputUploader     ComfyUIImageUploader // optional; uploads input images to ComfyUI
+	prewarm           PrewarmVRAMChecker   // optional; enables pre-warming the next checkpoint
+	scorer            ImageScorer          // optional; scores saved images
+	scoreTimeout      time.Duration
+	stager            CheckpointStager // optional; stages checkpoints ComfyUI cannot see
+	drainTimeout      time.Duration
+	itemTimeout       time.Duration // zero waits for the prompt in flight indefinitely
+	maxInFlight       int           // prompts of the active job submitted to ComfyUI at once
+	historyPollInterval time.Duration
+	outputLayout      model.OutputLayout // zero saves images under query-encoded filenames
 
 	mu                       sync.Mutex
 	activeJobID              string
 	activeItemID             string
 	activePromptID           string
+	lastItemEvent            time.Time // when the active prompt was submitted or last reported an event; read by checkItemTimeout
+	queued                   []queuedPrompt // items of the active job submitted behind the active item, in submission order
+	prewarmPromptID          string // pre-warm prompt queued behind the active one; its events are ignored
+	prewarmedCheckpoint      string // job ID and checkpoint of the last pre-warm, so each is pre-warmed once
 	stopRequested            bool
+	draining                 bool // set by Stop; no new item is started
 	connected                bool
 	everConnected            bool // true after the first successful connection; distinguishes reconnects from the initial connect
 	paused                   bool
+	gateOpen                 bool // gate was held on the previous tick; only meaningful when gate is set
+	lastTick                 time.Time // when the processing loop last ticked; read by Healthy
+	idleFuncs                []func()  // queued by RunWhenIdle; run once no item is in flight
+	lastAppendCheck          time.Time // when finished append-mode jobs were last checked for new checkpoints
 	checkpointCompleteness   map[string]model.CheckpointCompletenessInfo
 	ctx                      context.Context
 	cancel                   context.CancelFunc
@@ -138,6 +290,7 @@ func NewJobExecutorWithThumbnails(
 	ctx, cancel := context.WithCancel(context.Background())
 	return &JobExecutor{
 		store:                    store,
+		states:                   NewJobStateMachine(store, logger),
 		comfyuiClient:            comfyuiClient,
 		comfyuiWS:                comfyuiWS,
 		workflowLoader:           workflowLoader,
@@ -154,6 +307,10 @@ func NewJobExecutorWithThumbnails(
 		shutdownCh:               make(chan struct{}),
 		shutdownComplete:         make(chan struct{}),
 		sampleTiming:             NewMovingAverage(sampleTimingWindowSize),
+		drainTimeout:             defaultDrainTimeout,
+		itemTimeout:              defaultItemTimeout,
+		maxInFlight:              1,
+		historyPollInterval:      defaultHistoryPollInterval,
 		timeNow:                  time.Now,
 	}
 }
@@ -164,6 +321,136 @@ func (e *JobExecutor) SetDirRemover(remover SampleDirRemover) {
 	e.dirRemover = remover
 }
 
+// SetPinGuard sets the guard consulted before sample directories are cleared.
+func (e *JobExecutor) SetPinGuard(guard PinGuard) {
+	e.pinGuard = guard
+}
+
+// SetExecutorGate sets the gate consulted before each processing tick. While
+// the gate is not held the executor stays connected but idle; when it becomes
+// held the executor adopts any running job left by the previous holder.
+func (e *JobExecutor) SetExecutorGate(gate ExecutorGate) {
+	e.gate = gate
+}
+
+// SetTranscoder sets the transcoder used for jobs whose output format is not
+// PNG. This is optional; if not set, such items fail when their image is saved.
+func (e *JobExecutor) SetTranscoder(transcoder OutputTranscoder) {
+	e.transcoder = transcoder
+}
+
+// SetFailureLogReader sets where ComfyUI's log is read from when an item
+// fails with an execution error. At most lines lines, starting at the last
+// exception report if there is one, are attached to the item. This is
+// optional; if not set, no log is captured.
+func (e *JobExecutor) SetFailureLogReader(reader ComfyUILogReader, lines int) {
+	e.failureLog = reader
+	e.failureLogLines = lines
+}
+
+// SetCheckpointAppender sets the appender that adds items for late-arriving
+// checkpoints to append-mode jobs. The running job is extended before it
+// would complete, and finished jobs are checked every appendCheckInterval
+// while the executor is idle. This is optional; if not set, append-mode jobs
+// behave like snapshot jobs.
+func (e *JobExecutor) SetCheckpointAppender(appender CheckpointAppender) {
+	e.appender = appender
+}
+
+// SetImageIndex sets the image index that saved sample images are recorded
+// in. This is optional; if not set, the index picks up new images when it
+// next lists their directory.
+func (e *JobExecutor) SetImageIndex(images ImageIndexUpdater) {
+	e.images = images
+}
+
+// SetJobNotifier sets the notifier that is told when a job completes, with
+// or without errors, or is stopped. It is only called once the job's terminal
+// status has been saved. This is optional; if not set, no notifications are
+// sent.
+func (e *JobExecutor) SetJobNotifier(notifier JobNotifier) {
+	e.notifier = notifier
+}
+
+// SetPrewarm enables pre-warming: when the last item of a checkpoint is
+// submitted, a minimal prompt that loads the job's next checkpoint is queued
+// behind it, so that the model is loaded while the current item's image is
+// downloaded and saved. vram decides whether the GPU has room for the next
+// model. This is optional; if not set, checkpoints are loaded by the first
+// item that uses them.
+func (e *JobExecutor) SetPrewarm(vram PrewarmVRAMChecker) {
+	e.prewarm = vram
+}
+
+// SetInputImageUploader sets where the input images of img2img jobs are read
+// from and how they are uploaded to ComfyUI. This is optional; if not set,
+// items of jobs with an input image fail when their workflow is substituted.
+func (e *JobExecutor) SetInputImageUploader(reader InputImageReader, uploader ComfyUIImageUploader) {
+	e.inputReader = reader
+	e.inputUploader = uploader
+}
+
+// SetImageScorer sets the scorer that saved images are scored with. Scoring
+// is bounded by timeout and never fails an item: images the scorer rejects
+// are saved without scores. This is optional; if not set, images are not
+// scored.
+func (e *JobExecutor) SetImageScorer(scorer ImageScorer, timeout time.Duration) {
+	e.scorer = scorer
+	e.scoreTimeout = timeout
+}
+
+// SetCheckpointStager sets the stager that checkpoints are copied into the
+// staging directory with before an item in the staging directory is sampled.
+// Staged checkpoints are removed when their job finishes. This is optional.
+func (e *JobExecutor) SetCheckpointStager(stager CheckpointStager) {
+	e.stager = stager
+}
+
+// SetDrainTimeout sets how long Stop waits for the item in flight to finish
+// before marking it interrupted. Zero does not wait.
+func (e *JobExecutor) SetDrainTimeout(timeout time.Duration) {
+	e.drainTimeout = timeout
+}
+
+// SetItemTimeout sets how long the executor waits for an event of the prompt
+// in flight before cancelling it and failing its item. Zero waits
+// indefinitely.
+func (e *JobExecutor) SetItemTimeout(timeout time.Duration) {
+	e.itemTimeout = timeout
+}
+
+// SetMaxInFlight sets how many items of the active job may have a prompt in
+// ComfyUI's queue at once. With more than one, the next prompts run while
+// the images of the previous ones are downloaded and saved. Values below one
+// are treated as one.
+func (e *JobExecutor) SetMaxInFlight(n int) {
+	if n < 1 {
+		n = 1
+	}
+	e.maxInFlight = n
+}
+
+// SetOutputLayout sets the layout images are saved in. If not set, they are
+// saved under query-encoded filenames.
+func (e *JobExecutor) SetOutputLayout(layout model.OutputLayout) {
+	e.outputLayout = layout
+}
+
+// RunWhenIdle calls fn once no item is in flight, so that a change to the
+// ComfyUI connection never interrupts a sample. If the executor has not been
+// started, fn runs immediately; otherwise it runs on the next processing tick
+// without an in-flight item, before another item is submitted. fn runs with
+// the executor's lock held and must not call back into the executor.
+func (e *JobExecutor) RunWhenIdle(fn func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.started {
+		fn()
+		return
+	}
+	e.idleFuncs = append(e.idleFuncs, fn)
+}
+
 // Start begins the background executor goroutine and resumes any running jobs.
 // It attempts to connect to ComfyUI but does not fail if the connection is unavailable.
 // The executor will retry the connection in the background.
@@ -248,7 +535,7 @@ func (e *JobExecutor) recoverStuckItems() {
 	e.logger.Trace("entering recoverStuckItems")
 	defer e.logger.Trace("returning from recoverStuckItems")
 
-	jobs, err := e.store.ListSampleJobs()
+	jobs, err := e.store.ListSampleJobs(e.ctx)
 	if err != nil {
 		e.logger.WithError(err).Error("recoverStuckItems: failed to list jobs")
 		return
@@ -259,7 +546,7 @@ func (e *JobExecutor) recoverStuckItems() {
 			continue
 		}
 
-		items, err := e.store.ListSampleJobItems(job.ID)
+		items, err := e.store.ListSampleJobItems(e.ctx, job.ID)
 		if err != nil {
 			e.logger.WithFields(logrus.Fields{
 				"job_id": job.ID,
@@ -285,71 +572,325 @@ func (e *JobExecutor) recoverStuckItems() {
 				continue
 			}
 
-			// Query ComfyUI history to see if the prompt already completed.
-			history, err := e.comfyuiClient.GetHistory(e.ctx, item.ComfyUIPromptID)
-			if err != nil {
-				e.logger.WithFields(logrus.Fields{
-					"job_id":    job.ID,
-					"item_id":   item.ID,
-					"prompt_id": item.ComfyUIPromptID,
-					"error":     err.Error(),
-				}).Warn("recoverStuckItems: failed to query history, resetting item to pending")
-				e.resetItemToPending(item)
-				continue
-			}
+			e.recoverStuckItem(job.ID, *item)
+		}
+	}
+}
 
-			entry, found := history[item.ComfyUIPromptID]
-			if !found {
-				// Prompt not in history — either it never ran or ComfyUI evicted it.
-				// Reset to pending so it is re-submitted.
-				e.logger.WithFields(logrus.Fields{
-					"job_id":    job.ID,
-					"item_id":   item.ID,
-					"prompt_id": item.ComfyUIPromptID,
-				}).Warn("recoverStuckItems: prompt not found in ComfyUI history, resetting item to pending")
-				e.resetItemToPending(item)
-				continue
-			}
+// recoverStuckItem settles a running item whose prompt was submitted before
+// the WebSocket connection dropped, using the prompt's ComfyUI history:
+//   - a prompt that finished with output images is completed;
+//   - a prompt that failed with an execution error fails the item with the
+//     error's details;
+//   - a prompt still queued or running in ComfyUI is adopted as the active
+//     prompt, and its history is polled until it finishes in case its
+//     completion event is missed again;
+//   - otherwise the item is reset to pending so that it is re-submitted.
+func (e *JobExecutor) recoverStuckItem(jobID string, item model.SampleJobItem) {
+	fields := logrus.Fields{
+		"job_id":    jobID,
+		"item_id":   item.ID,
+		"prompt_id": item.ComfyUIPromptID,
+	}
+
+	history, err := e.comfyuiClient.GetHistory(e.ctx, item.ComfyUIPromptID)
+	if err != nil {
+		e.logger.WithFields(fields).WithField("error", err.Error()).Warn("recoverStuckItems: failed to query history, resetting item to pending")
+		e.resetItemToPending(&item)
+		return
+	}
 
-			// Check if the entry has output images (indicates successful completion).
-			if !historyEntryHasOutputImages(entry) {
-				e.logger.WithFields(logrus.Fields{
-					"job_id":    job.ID,
-					"item_id":   item.ID,
-					"prompt_id": item.ComfyUIPromptID,
-				}).Warn("recoverStuckItems: prompt found in history but has no output images, resetting item to pending")
-				e.resetItemToPending(item)
+	entry, found := history[item.ComfyUIPromptID]
+	if !found {
+		if queued, _ := e.promptQueued(item.ComfyUIPromptID); !queued {
+			// Prompt neither in history nor queued: it never ran, ComfyUI
+			// restarted, or the history entry was evicted.
+			e.logger.WithFields(fields).Warn("recoverStuckItems: prompt not found in ComfyUI history or queue, resetting item to pending")
+			e.resetItemToPending(&item)
+			return
+		}
+		if !e.claimActiveSlot(jobID, item.ID, item.ComfyUIPromptID) {
+			return
+		}
+		e.logger.WithFields(fields).Info("recoverStuckItems: prompt still queued in ComfyUI, waiting for it to finish")
+		go e.awaitRecoveredPrompt(jobID, item)
+		return
+	}
+
+	if !historyEntryFailed(entry) && !historyEntryHasOutputImages(entry) {
+		e.logger.WithFields(fields).Warn("recoverStuckItems: prompt found in history but has no output images, resetting item to pending")
+		e.resetItemToPending(&item)
+		return
+	}
+	if !e.claimActiveSlot(jobID, item.ID, item.ComfyUIPromptID) {
+		return
+	}
+	e.logger.WithFields(fields).Info("recoverStuckItems: recovering finished prompt from history")
+	e.finishFromHistory(jobID, item, entry)
+}
+
+// claimActiveSlot makes a recovered item the active one, or queues it behind
+// the active item if that belongs to the same job. If another job's item
+// already holds the slot (e.g. claimed by processNextItem), it returns false
+// and the item is left running; it will be handled naturally by the executor
+// loop.
+func (e *JobExecutor) claimActiveSlot(jobID, itemID, promptID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.activeItemID != "" {
+		if _, queued := e.queuedItemOf(promptID); e.activeJobID == jobID && e.activeItemID != itemID && !queued {
+			e.queued = append(e.queued, queuedPrompt{itemID: itemID, promptID: promptID})
+			return true
+		}
+		e.logger.WithFields(logrus.Fields{
+			"job_id":         jobID,
+			"item_id":        itemID,
+			"active_item_id": e.activeItemID,
+		}).Warn("recoverStuckItems: active slot already taken, skipping recovery for this item")
+		return false
+	}
+	e.activeJobID = jobID
+	e.activeItemID = itemID
+	e.activePromptID = promptID
+	e.lastItemEvent = e.timeNow()
+	return true
+}
+
+// finishFromHistory completes or fails an item in flight from the history
+// entry of its finished prompt. The caller must have claimed the item with
+// claimActiveSlot.
+func (e *JobExecutor) finishFromHistory(jobID string, item model.SampleJobItem, entry model.HistoryEntry) {
+	if data, failed := historyExecutionError(entry); failed {
+		e.failExecution(item.ID, item.ComfyUIPromptID, data)
+		return
+	}
+	if historyEntryHasOutputImages(entry) {
+		e.handleItemCompletionAsync(jobID, item.ID, item.ComfyUIPromptID)
+		return
+	}
+	// Finished without output images, e.g. interrupted: run it again.
+	e.mu.Lock()
+	e.releaseItem(item.ID)
+	e.mu.Unlock()
+	e.resetItemToPending(&item)
+}
+
+// awaitRecoveredPrompt polls the history of a recovered item's prompt, which
+// was still queued in ComfyUI on reconnect, until the prompt finishes. It
+// returns as soon as the prompt is no longer in flight, i.e. once a
+// WebSocket event has reported its completion or failure, or the connection
+// dropped again. A prompt that leaves ComfyUI's queue without a history entry
+// is lost, and the item is reset to pending.
+func (e *JobExecutor) awaitRecoveredPrompt(jobID string, item model.SampleJobItem) {
+	e.logger.WithField("prompt_id", item.ComfyUIPromptID).Trace("entering awaitRecoveredPrompt")
+	defer e.logger.Trace("returning from awaitRecoveredPrompt")
+
+	ticker := time.NewTicker(e.historyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !e.isInFlightPrompt(item.ComfyUIPromptID) {
+			return
+		}
+		history, err := e.comfyuiClient.GetHistory(e.ctx, item.ComfyUIPromptID)
+		if err != nil {
+			e.logger.WithFields(logrus.Fields{
+				"prompt_id": item.ComfyUIPromptID,
+				"error":     err.Error(),
+			}).Debug("failed to poll history of recovered prompt, will retry")
+			continue
+		}
+		entry, found := history[item.ComfyUIPromptID]
+		if !found {
+			if queued, _ := e.promptQueued(item.ComfyUIPromptID); queued {
 				continue
 			}
+		}
 
-			// Prompt completed with output images — process the completion.
+		// Take the prompt over from the WebSocket event handler, which
+		// ignores events of prompts that are neither active nor queued.
+		e.mu.Lock()
+		if e.activePromptID == item.ComfyUIPromptID {
+			e.activePromptID = ""
+		} else if !e.dequeue(item.ComfyUIPromptID) {
+			e.mu.Unlock()
+			return
+		}
+		e.mu.Unlock()
+
+		if !found {
 			e.logger.WithFields(logrus.Fields{
-				"job_id":    job.ID,
+				"job_id":    jobID,
 				"item_id":   item.ID,
 				"prompt_id": item.ComfyUIPromptID,
-			}).Info("recoverStuckItems: recovering completed prompt from history")
-
-			// Claim the active slot before calling handleItemCompletionAsync.
-			// If another goroutine (e.g. processNextItem) already claimed the slot,
-			// skip recovery for this item — it will be handled naturally by the executor loop.
+			}).Warn("recovered prompt left the ComfyUI queue without a history entry, resetting item to pending")
 			e.mu.Lock()
-			if e.activeItemID != "" {
-				e.logger.WithFields(logrus.Fields{
-					"job_id":           job.ID,
-					"item_id":          item.ID,
-					"active_item_id":   e.activeItemID,
-				}).Warn("recoverStuckItems: active slot already taken, skipping recovery for this item")
-				e.mu.Unlock()
-				continue
-			}
-			e.activeJobID = job.ID
-			e.activeItemID = item.ID
-			e.activePromptID = item.ComfyUIPromptID
+			e.releaseItem(item.ID)
 			e.mu.Unlock()
+			e.resetItemToPending(&item)
+			return
+		}
+		e.logger.WithFields(logrus.Fields{
+			"job_id":    jobID,
+			"item_id":   item.ID,
+			"prompt_id": item.ComfyUIPromptID,
+		}).Info("recovered prompt finished, processing it from history")
+		e.finishFromHistory(jobID, item, entry)
+		return
+	}
+}
+
+// isInFlightPrompt reports whether promptID is the prompt of the active item
+// or of a queued one.
+func (e *JobExecutor) isInFlightPrompt(promptID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.activePromptID == promptID {
+		return true
+	}
+	_, queued := e.queuedItemOf(promptID)
+	return queued
+}
+
+// queuedItemOf returns the queued item whose prompt is promptID (called with
+// mutex held).
+func (e *JobExecutor) queuedItemOf(promptID string) (queuedPrompt, bool) {
+	for _, q := range e.queued {
+		if q.promptID == promptID {
+			return q, true
+		}
+	}
+	return queuedPrompt{}, false
+}
+
+// dequeue removes the queued item whose prompt is promptID and reports
+// whether there was one (called with mutex held). The event handler ignores
+// the prompt's events afterwards.
+func (e *JobExecutor) dequeue(promptID string) bool {
+	for i, q := range e.queued {
+		if q.promptID == promptID {
+			e.queued = append(e.queued[:i:i], e.queued[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// releaseItem removes a finished item from the items in flight (called with
+// mutex held). When it is the active item, the first queued item becomes the
+// active one: ComfyUI runs prompts in submission order, so its prompt is the
+// one running now.
+func (e *JobExecutor) releaseItem(itemID string) {
+	if itemID == "" || itemID != e.activeItemID {
+		for i, q := range e.queued {
+			if q.itemID == itemID {
+				e.queued = append(e.queued[:i:i], e.queued[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	e.activeItemID = ""
+	e.activePromptID = ""
+	if len(e.queued) == 0 {
+		return
+	}
+	next := e.queued[0]
+	e.queued = e.queued[1:]
+	e.activeItemID = next.itemID
+	e.activePromptID = next.promptID
+	e.lastItemEvent = e.timeNow()
+	e.sampleStartTime = e.timeNow()
+	e.logger.WithFields(logrus.Fields{
+		"item_id":   next.itemID,
+		"prompt_id": next.promptID,
+	}).Debug("queued item became the active item")
+}
+
+// dropQueued forgets the queued items and returns the IDs of their prompts
+// (called with mutex held).
+func (e *JobExecutor) dropQueued() []string {
+	promptIDs := make([]string, 0, len(e.queued))
+	for _, q := range e.queued {
+		promptIDs = append(promptIDs, q.promptID)
+	}
+	e.queued = nil
+	return promptIDs
+}
+
+// cancelPrompts removes prompts from ComfyUI's queue (called without holding
+// mutex). Failures are only logged.
+func (e *JobExecutor) cancelPrompts(promptIDs []string) {
+	for _, promptID := range promptIDs {
+		if err := e.comfyuiClient.CancelPrompt(e.ctx, promptID); err != nil {
+			e.logger.WithFields(logrus.Fields{
+				"prompt_id": promptID,
+				"error":     err.Error(),
+			}).Warn("failed to cancel queued ComfyUI prompt")
+		}
+	}
+}
 
-			e.handleItemCompletionAsync(job.ID, item.ID, item.ComfyUIPromptID)
+// promptQueued reports whether a prompt is pending or running in ComfyUI's
+// queue, and whether it is running. A queue that cannot be read counts as not
+// holding the prompt.
+func (e *JobExecutor) promptQueued(promptID string) (queued bool, running bool) {
+	queue, err := e.comfyuiClient.GetQueueStatus(e.ctx)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"prompt_id": promptID,
+			"error":     err.Error(),
+		}).Warn("failed to read ComfyUI queue")
+		return false, false
+	}
+	for _, entry := range queue.Running {
+		if entry.PromptID == promptID {
+			return true, true
+		}
+	}
+	for _, entry := range queue.Pending {
+		if entry.PromptID == promptID {
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// historyEntryFailed reports whether a ComfyUI history entry is of a prompt
+// that failed with an execution error.
+func historyEntryFailed(entry model.HistoryEntry) bool {
+	_, failed := historyExecutionError(entry)
+	return failed
+}
+
+// historyExecutionError returns the data of the execution_error message of a
+// failed prompt's history entry. ComfyUI records the prompt's status as
+// status_str "error" and its messages as [name, data] pairs; the data is empty
+// when the entry has no execution_error message.
+func historyExecutionError(entry model.HistoryEntry) (map[string]interface{}, bool) {
+	if status, _ := entry.Status["status_str"].(string); status != "error" {
+		return nil, false
+	}
+	messages, _ := entry.Status["messages"].([]interface{})
+	for _, message := range messages {
+		pair, ok := message.([]interface{})
+		if !ok || len(pair) != 2 {
+			continue
+		}
+		if name, _ := pair[0].(string); name == "execution_error" {
+			if data, ok := pair[1].(map[string]interface{}); ok {
+				return data, true
+			}
 		}
 	}
+	return map[string]interface{}{}, true
 }
 
 // historyEntryHasOutputImages returns true if the ComfyUI history entry contains
@@ -379,7 +920,7 @@ func (e *JobExecutor) resetItemToPending(item *model.SampleJobItem) {
 	item.Status = model.SampleJobItemStatusPending
 	item.ComfyUIPromptID = ""
 	item.UpdatedAt = time.Now().UTC()
-	if err := e.store.UpdateSampleJobItem(*item); err != nil {
+	if err := e.store.UpdateSampleJobItem(e.ctx, item); err != nil {
 		e.logger.WithFields(logrus.Fields{
 			"item_id": item.ID,
 			"error":   err.Error(),
@@ -403,21 +944,27 @@ func (e *JobExecutor) handleDisconnect() {
 	e.logger.Warn("ComfyUI WebSocket connection lost, marking as disconnected")
 	e.connected = false
 
-	// If an item was in-flight (submitted to ComfyUI, waiting for WS completion event),
-	// the event will never arrive. Clear the active prompt/item so the executor can
-	// retry on the next tick once reconnected. The job remains tracked (activeJobID is
-	// preserved) so the executor will resume from where it left off.
+	// If items were in-flight (submitted to ComfyUI, waiting for WS completion events),
+	// the events will never arrive. Clear the active prompt/item and the queued items so
+	// the executor can retry on the next tick once reconnected. The job remains tracked
+	// (activeJobID is preserved) so the executor will resume from where it left off.
 	if e.activeItemID != "" {
 		e.logger.WithFields(logrus.Fields{
 			"active_job_id":  e.activeJobID,
 			"active_item_id": e.activeItemID,
+			"queued_items":   len(e.queued),
 		}).Warn("clearing stale in-flight item due to disconnect")
 		e.activeItemID = ""
 		e.activePromptID = ""
+		e.queued = nil
 	}
+	e.prewarmPromptID = ""
 }
 
-// Stop gracefully shuts down the executor.
+// Stop gracefully shuts down the executor. It first drains: no new item is
+// started, and the item in flight is given up to the drain timeout to finish.
+// An item still in flight after that is marked interrupted, and re-queued as
+// pending when the executor next starts.
 // Safe to call even if Start() was not called or failed.
 func (e *JobExecutor) Stop() {
 	e.logger.Trace("entering Stop")
@@ -429,8 +976,11 @@ func (e *JobExecutor) Stop() {
 		e.logger.Debug("job executor not started, nothing to stop")
 		return
 	}
+	e.draining = true
 	e.mu.Unlock()
 
+	e.drain()
+
 	close(e.shutdownCh)
 	e.cancel()
 
@@ -445,6 +995,122 @@ func (e *JobExecutor) Stop() {
 	e.logger.Info("job executor stopped")
 }
 
+// drain waits up to the drain timeout for the items in flight to finish.
+// Items still in flight afterwards are marked interrupted and released, so
+// that late ComfyUI events for them are ignored; the prompts of queued items
+// are removed from ComfyUI's queue.
+func (e *JobExecutor) drain() {
+	deadline := e.timeNow().Add(e.drainTimeout)
+	for {
+		e.mu.Lock()
+		jobID, itemID := e.activeJobID, e.activeItemID
+		if itemID == "" {
+			e.mu.Unlock()
+			return
+		}
+		if !e.connected || !e.timeNow().Before(deadline) {
+			// Without a connection the completion event cannot arrive.
+			itemIDs := []string{itemID}
+			for _, q := range e.queued {
+				itemIDs = append(itemIDs, q.itemID)
+			}
+			queuedPromptIDs := e.dropQueued()
+			connected := e.connected
+			e.activeItemID = ""
+			e.activePromptID = ""
+			e.mu.Unlock()
+			if connected {
+				e.cancelPrompts(queuedPromptIDs)
+			}
+			for _, id := range itemIDs {
+				e.interruptItem(jobID, id)
+			}
+			return
+		}
+		e.mu.Unlock()
+		e.logger.WithField("item_id", itemID).Debug("waiting for the item in flight before shutting down")
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// abandonItems gives up the items in flight when the executor gate is lost:
+// their prompts are removed from ComfyUI's queue, or interrupted if running,
+// and the items are reset to pending for the new gate holder. Otherwise the
+// prompts would still run here while the new holder resubmits the items.
+// Items the new holder has already resubmitted carry another prompt ID and
+// are left alone.
+func (e *JobExecutor) abandonItems(jobID string, inFlight []queuedPrompt, connected bool) {
+	if len(inFlight) == 0 {
+		return
+	}
+	promptIDs := make(map[string]string, len(inFlight))
+	for _, q := range inFlight {
+		promptIDs[q.itemID] = q.promptID
+		if !connected || q.promptID == "" {
+			continue
+		}
+		_, running := e.promptQueued(q.promptID)
+		e.cancelPrompts([]string{q.promptID})
+		if running {
+			if err := e.comfyuiClient.InterruptPrompt(e.ctx, q.promptID); err != nil {
+				e.logger.WithFields(logrus.Fields{
+					"prompt_id": q.promptID,
+					"error":     err.Error(),
+				}).Warn("failed to interrupt prompt after losing the executor gate")
+			}
+		}
+	}
+
+	items, err := e.store.ListSampleJobItems(e.ctx, jobID)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"job_id": jobID,
+			"error":  err.Error(),
+		}).Error("failed to list items to give up the items in flight")
+		return
+	}
+	for i := range items {
+		promptID, ok := promptIDs[items[i].ID]
+		if !ok || items[i].Status != model.SampleJobItemStatusRunning || items[i].ComfyUIPromptID != promptID {
+			continue
+		}
+		e.resetItemToPending(&items[i])
+	}
+}
+
+// interruptItem persists the interrupted status of an item that was in
+// flight at shutdown.
+func (e *JobExecutor) interruptItem(jobID, itemID string) {
+	items, err := e.store.ListSampleJobItems(e.ctx, jobID)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"job_id":  jobID,
+			"item_id": itemID,
+			"error":   err.Error(),
+		}).Error("failed to list items to mark the item in flight interrupted")
+		return
+	}
+	for _, item := range items {
+		if item.ID != itemID || item.Status != model.SampleJobItemStatusRunning {
+			continue
+		}
+		item.Status = model.SampleJobItemStatusInterrupted
+		item.UpdatedAt = e.timeNow().UTC()
+		if err := e.store.UpdateSampleJobItem(e.ctx, &item); err != nil {
+			e.logger.WithFields(logrus.Fields{
+				"job_id":  jobID,
+				"item_id": itemID,
+				"error":   err.Error(),
+			}).Error("failed to mark the item in flight interrupted")
+			return
+		}
+		e.logger.WithFields(logrus.Fields{
+			"job_id":  jobID,
+			"item_id": itemID,
+		}).Warn("item still in flight at shutdown marked interrupted")
+	}
+}
+
 // Pause temporarily suspends the executor's database polling loop.
 // While paused, processNextItem returns immediately without querying the
 // database. WebSocket event handling also skips completion processing while
@@ -480,6 +1146,8 @@ func (e *JobExecutor) Pause() {
 	e.activeJobID = ""
 	e.activeItemID = ""
 	e.activePromptID = ""
+	e.queued = nil
+	e.prewarmPromptID = ""
 	e.checkpointCompleteness = make(map[string]model.CheckpointCompletenessInfo)
 	e.sampleTiming.Reset()
 	e.sampleStartTime = time.Time{}
@@ -503,8 +1171,9 @@ func (e *JobExecutor) Resume() {
 	e.logger.Info("job executor resumed")
 }
 
-// resumeRunningJobs adopts the first running job found in the database on startup
-// by setting activeJobID so the executor loop picks it up. Only the first running
+// resumeRunningJobs re-queues the interrupted items of running jobs and adopts
+// the first running job found in the database on startup by setting
+// activeJobID so the executor loop picks it up. Only the first running
 // job is adopted (the executor processes one job at a time). Additional running
 // jobs (which should not normally exist) remain in their current state and would
 // require a restart to be adopted.
@@ -517,7 +1186,7 @@ func (e *JobExecutor) resumeRunningJobs() error {
 	isConnected := e.connected
 	e.mu.Unlock()
 
-	jobs, err := e.store.ListSampleJobs()
+	jobs, err := e.store.ListSampleJobs(e.ctx)
 	if err != nil {
 		e.logger.WithError(err).Error("failed to list jobs")
 		return fmt.Errorf("listing jobs: %w", err)
@@ -526,6 +1195,7 @@ func (e *JobExecutor) resumeRunningJobs() error {
 	var adopted bool
 	for _, job := range jobs {
 		if job.Status == model.SampleJobStatusRunning {
+			e.requeueInterruptedItems(job.ID)
 			if !adopted {
 				e.mu.Lock()
 				e.activeJobID = job.ID
@@ -544,6 +1214,24 @@ func (e *JobExecutor) resumeRunningJobs() error {
 	return nil
 }
 
+// requeueInterruptedItems resets the items of a job that were interrupted by
+// a shutdown to pending, so that they are sampled again.
+func (e *JobExecutor) requeueInterruptedItems(jobID string) {
+	items, err := e.store.ListSampleJobItems(e.ctx, jobID)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"job_id": jobID,
+			"error":  err.Error(),
+		}).Error("failed to list items to re-queue interrupted items")
+		return
+	}
+	for i := range items {
+		if items[i].Status == model.SampleJobItemStatusInterrupted {
+			e.resetItemToPending(&items[i])
+		}
+	}
+}
+
 // autoStartJob transitions a pending job to running status.
 // It performs blocking I/O (a store write) without holding the mutex.
 // Returns an error if the transition fails; on success the job's Status field is updated in place.
@@ -555,6 +1243,13 @@ func (e *JobExecutor) autoStartJob(job *model.SampleJob) error {
 	// that is later resumed will not re-clear.
 	if job.ClearExisting && e.dirRemover != nil {
 		for _, cpFilename := range job.CheckpointFilenames {
+			if e.pinGuard != nil && e.pinGuard.IsProtected(e.ctx, cpFilename) {
+				e.logger.WithFields(logrus.Fields{
+					"job_id":              job.ID,
+					"checkpoint_filename": cpFilename,
+				}).Info("sample dir contains pinned content, not clearing during auto-start")
+				continue
+			}
 			if err := e.dirRemover.RemoveSampleDir(cpFilename); err != nil {
 				e.logger.WithFields(logrus.Fields{
 					"job_id":              job.ID,
@@ -570,10 +1265,9 @@ func (e *JobExecutor) autoStartJob(job *model.SampleJob) error {
 		}
 	}
 
-	job.Status = model.SampleJobStatusRunning
 	job.ClearExisting = false // reset so resume never re-clears
-	job.UpdatedAt = time.Now().UTC()
-	if err := e.store.UpdateSampleJob(*job); err != nil {
+	started, err := e.states.Transition(e.ctx, *job, model.SampleJobStatusRunning)
+	if err != nil {
 		// sql.ErrNoRows means the row was deleted between the poll and the update
 		// (e.g. database reset during E2E teardown). This is a benign race — log at
 		// WARN rather than ERROR to avoid spurious noise in test output.
@@ -587,10 +1281,88 @@ func (e *JobExecutor) autoStartJob(job *model.SampleJob) error {
 		}
 		return fmt.Errorf("auto-starting job: %w", err)
 	}
+	*job = started
 	e.logger.WithField("job_id", job.ID).Info("pending job transitioned to running")
+
+	// Lock the job's parameters now; processItem retries if this fails.
+	if _, err := e.jobParameters(*job); err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"job_id": job.ID,
+			"error":  err.Error(),
+		}).Warn("failed to snapshot job parameters at start")
+	}
 	return nil
 }
 
+// jobParameters returns the parameter snapshot of job. A job without one
+// (on its first start, or started before snapshots existed) is snapshotted
+// from the version of its study it was created from and its workflow
+// template as it is now; from then on, edits to either no longer affect the
+// job.
+func (e *JobExecutor) jobParameters(job model.SampleJob) (model.SampleJobParameters, error) {
+	e.logger.WithField("job_id", job.ID).Trace("entering jobParameters")
+	defer e.logger.Trace("returning from jobParameters")
+
+	params, err := e.store.GetSampleJobParameters(e.ctx, job.ID)
+	if err == nil {
+		return params, nil
+	}
+	if err != sql.ErrNoRows {
+		return model.SampleJobParameters{}, fmt.Errorf("fetching job parameters: %w", err)
+	}
+
+	study, err := e.store.GetStudy(e.ctx, job.StudyID)
+	if err != nil {
+		return model.SampleJobParameters{}, fmt.Errorf("fetching study %s: %w", job.StudyID, err)
+	}
+	if study, err = studyAtVersion(e.ctx, e.store, study, job.StudyVersion); err != nil {
+		return model.SampleJobParameters{}, err
+	}
+	workflow, err := e.workflowLoader.Get(e.ctx, job.WorkflowName)
+	if err != nil {
+		return model.SampleJobParameters{}, fmt.Errorf("loading workflow %s: %w", job.WorkflowName, err)
+	}
+	params = model.SampleJobParameters{
+		JobID: job.ID,
+		Study: study,
+		Workflow: model.WorkflowTemplate{
+			Name:     workflow.Name,
+			Workflow: workflow.Workflow,
+			Roles:    workflow.Roles,
+		},
+		CreatedAt: e.timeNow().UTC(),
+	}
+	for _, o := range job.WorkflowOverrides {
+		if _, ok := params.OverrideWorkflows[o.Workflow]; ok {
+			continue
+		}
+		override, err := e.workflowLoader.Get(e.ctx, o.Workflow)
+		if err != nil {
+			return model.SampleJobParameters{}, fmt.Errorf("loading workflow %s: %w", o.Workflow, err)
+		}
+		if params.OverrideWorkflows == nil {
+			params.OverrideWorkflows = make(map[string]model.WorkflowTemplate)
+		}
+		params.OverrideWorkflows[o.Workflow] = model.WorkflowTemplate{
+			Name:     override.Name,
+			Workflow: override.Workflow,
+			Roles:    override.Roles,
+		}
+	}
+	if err := e.store.CreateSampleJobParameters(e.ctx, params); err != nil {
+		return model.SampleJobParameters{}, fmt.Errorf("storing job parameters: %w", err)
+	}
+	e.logger.WithFields(logrus.Fields{
+		"job_id":             job.ID,
+		"study_id":           job.StudyID,
+		"workflow":           job.WorkflowName,
+		"override_workflows": len(params.OverrideWorkflows),
+	}).Info("locked job parameters")
+
+	// Another process may have stored its snapshot first; the first one wins.
+	return e.store.GetSampleJobParameters(e.ctx, job.ID)
+}
+
 // run is the main executor loop.
 func (e *JobExecutor) run() {
 	defer close(e.shutdownComplete)
@@ -619,11 +1391,58 @@ func (e *JobExecutor) run() {
 				}
 			}
 		case <-ticker.C:
+			e.mu.Lock()
+			e.lastTick = e.timeNow()
+			e.mu.Unlock()
+			e.checkItemTimeout()
 			e.processNextItem()
 		}
 	}
 }
 
+// checkItemTimeout fails the item in flight when ComfyUI has reported no
+// event of its prompt for the item timeout, e.g. because a node hangs. The
+// prompt is removed from ComfyUI's queue, or interrupted if it is running, so
+// that the job can continue with its next item.
+func (e *JobExecutor) checkItemTimeout() {
+	e.mu.Lock()
+	if e.itemTimeout <= 0 || e.paused || e.activePromptID == "" || e.lastItemEvent.IsZero() {
+		e.mu.Unlock()
+		return
+	}
+	silent := e.timeNow().Sub(e.lastItemEvent)
+	if silent < e.itemTimeout {
+		e.mu.Unlock()
+		return
+	}
+	jobID, itemID, promptID := e.activeJobID, e.activeItemID, e.activePromptID
+	// Clearing the active prompt makes the event handler ignore the prompt's
+	// late events, so the item is not completed after it has failed.
+	e.activePromptID = ""
+	e.sampleStartTime = time.Time{}
+	e.mu.Unlock()
+
+	e.logger.WithFields(logrus.Fields{
+		"job_id":       jobID,
+		"item_id":      itemID,
+		"prompt_id":    promptID,
+		"item_timeout": e.itemTimeout.String(),
+	}).Warn("no event from ComfyUI for the item in flight within the item timeout, cancelling its prompt")
+
+	// Only interrupt the prompt if it is the one running: ComfyUI versions
+	// that ignore the prompt ID interrupt whatever runs.
+	_, running := e.promptQueued(promptID)
+	if err := e.comfyuiClient.CancelPrompt(e.ctx, promptID); err != nil {
+		e.logger.WithError(err).Warn("failed to remove timed-out prompt from the ComfyUI queue")
+	}
+	if running {
+		if err := e.comfyuiClient.InterruptPrompt(e.ctx, promptID); err != nil {
+			e.logger.WithError(err).Warn("failed to interrupt timed-out prompt")
+		}
+	}
+	e.failItem(itemID, fmt.Sprintf("timeout: no progress from ComfyUI for %s", e.itemTimeout))
+}
+
 // processNextItem finds the next pending item in a running job and processes it.
 // If no running job exists, it auto-starts the first pending job (pending → running).
 //
@@ -634,6 +1453,20 @@ func (e *JobExecutor) run() {
 func (e *JobExecutor) processNextItem() {
 	e.mu.Lock()
 
+	// Apply deferred changes (e.g. a reloaded ComfyUI URL) between items.
+	if e.activeItemID == "" && len(e.idleFuncs) > 0 {
+		for _, fn := range e.idleFuncs {
+			fn()
+		}
+		e.idleFuncs = nil
+	}
+
+	// While shutting down, let the item in flight finish but start no other
+	if e.draining {
+		e.mu.Unlock()
+		return
+	}
+
 	// If stop was requested, don't start new items
 	if e.stopRequested {
 		e.mu.Unlock()
@@ -648,21 +1481,61 @@ func (e *JobExecutor) processNextItem() {
 		return
 	}
 
+	// In multi-process mode only the gate holder processes jobs. On losing the
+	// gate, drop active state and give up the items in flight so the new
+	// holder owns the job exclusively; on gaining it, adopt any running job
+	// left behind by the previous holder.
+	if e.gate != nil {
+		if !e.gate.Held() {
+			if !e.gateOpen {
+				e.mu.Unlock()
+				return
+			}
+			e.logger.WithField("active_job_id", e.activeJobID).Warn("executor gate lost, standing by")
+			e.gateOpen = false
+			jobID, connected := e.activeJobID, e.connected
+			var inFlight []queuedPrompt
+			if e.activeItemID != "" {
+				inFlight = append(inFlight, queuedPrompt{itemID: e.activeItemID, promptID: e.activePromptID})
+			}
+			inFlight = append(inFlight, e.queued...)
+			e.activeJobID = ""
+			e.activeItemID = ""
+			e.activePromptID = ""
+			e.queued = nil
+			e.sampleStartTime = time.Time{}
+			e.mu.Unlock()
+			e.abandonItems(jobID, inFlight, connected)
+			return
+		}
+		if !e.gateOpen {
+			e.gateOpen = true
+			e.activeJobID = ""
+			e.mu.Unlock()
+			e.logger.Info("executor gate acquired, adopting running jobs")
+			if err := e.resumeRunningJobs(); err != nil {
+				e.logger.WithError(err).Warn("failed to adopt running jobs")
+			}
+			return
+		}
+	}
+
 	// If not connected to ComfyUI, skip processing
 	if !e.connected {
 		e.mu.Unlock()
 		return
 	}
 
-	// If an item is currently in-flight (submitted to ComfyUI, awaiting WS completion),
-	// don't start another one. This is the primary guard against double-submission.
-	if e.activeItemID != "" {
+	// If as many items as allowed are in flight (submitted to ComfyUI, awaiting WS
+	// completion), don't start another one. This is the primary guard against
+	// double-submission.
+	if e.activeItemID != "" && 1+len(e.queued) >= e.maxInFlight {
 		e.mu.Unlock()
 		return
 	}
 
 	// Fetch all jobs to determine what to work on next.
-	jobs, err := e.store.ListSampleJobs()
+	jobs, err := e.store.ListSampleJobs(e.ctx)
 	if err != nil {
 		e.mu.Unlock()
 		e.logger.WithError(err).Error("failed to list jobs")
@@ -685,7 +1558,9 @@ func (e *JobExecutor) processNextItem() {
 			// operation). Clear stale state and bail out for this tick.
 			e.logger.WithField("job_id", e.activeJobID).Warn("tracked job not found in store, clearing active state")
 			e.activeJobID = ""
+			e.activeItemID = ""
 			e.activePromptID = ""
+			e.queued = nil
 			e.mu.Unlock()
 			return
 		}
@@ -706,6 +1581,7 @@ func (e *JobExecutor) processNextItem() {
 		}
 		if runningJob == nil {
 			e.mu.Unlock()
+			e.extendFinishedAppendJobs(jobs)
 			return
 		}
 
@@ -719,7 +1595,7 @@ func (e *JobExecutor) processNextItem() {
 	}
 
 	// Find the next pending item for the running job
-	items, err := e.store.ListSampleJobItems(runningJob.ID)
+	items, err := e.store.ListSampleJobItems(e.ctx, runningJob.ID)
 	if err != nil {
 		e.mu.Unlock()
 		e.logger.WithError(err).Error("failed to list job items")
@@ -734,15 +1610,24 @@ func (e *JobExecutor) processNextItem() {
 		}
 	}
 
+	// Items of the job are still in flight: wait for them to finish before
+	// looking for orphaned items or completing the job.
+	if nextItem == nil && e.activeItemID != "" {
+		e.mu.Unlock()
+		return
+	}
+
 	// If no pending items, check for orphaned running items. An item is orphaned
 	// when it has status=running in the DB but activeItemID is empty (i.e. no item
 	// is genuinely in-flight). This happens after stop/resume, server restart, or
 	// any crash that leaves an item stuck in running status. The activeItemID guard
 	// at the top of processNextItem ensures we only reach here when no item is
 	// genuinely in-flight, so it is safe to reset the orphaned item to pending.
+	// Interrupted items are picked up the same way, in case one was marked by
+	// a process shutting down after this one adopted the job.
 	if nextItem == nil {
 		for i := range items {
-			if items[i].Status == model.SampleJobItemStatusRunning {
+			if items[i].Status == model.SampleJobItemStatusRunning || items[i].Status == model.SampleJobItemStatusInterrupted {
 				e.logger.WithFields(logrus.Fields{
 					"job_id":  runningJob.ID,
 					"item_id": items[i].ID,
@@ -751,7 +1636,7 @@ func (e *JobExecutor) processNextItem() {
 				items[i].UpdatedAt = time.Now().UTC()
 				// Release lock before I/O, re-acquire after
 				e.mu.Unlock()
-				if err := e.store.UpdateSampleJobItem(items[i]); err != nil {
+				if err := e.store.UpdateSampleJobItem(e.ctx, &items[i]); err != nil {
 					e.logger.WithFields(logrus.Fields{
 						"item_id": items[i].ID,
 						"error":   err.Error(),
@@ -766,22 +1651,232 @@ func (e *JobExecutor) processNextItem() {
 	}
 
 	if nextItem == nil {
-		// No pending or orphaned running items — mark job as completed
+		// No pending or orphaned running items — mark job as completed, unless
+		// it is in append mode and new checkpoints have arrived.
 		jobID := runningJob.ID
 		e.mu.Unlock()
+		if runningJob.AppendNewCheckpoints && e.appendNewCheckpoints(jobID) > 0 {
+			return
+		}
 		e.completeJob(jobID)
 		return
 	}
 
-	// Set active state before releasing the lock
+	// The item after this one, a candidate for pre-warming its checkpoint
+	var following *model.SampleJobItem
+	for i := range items {
+		if items[i].Status == model.SampleJobItemStatusPending && items[i].ID != nextItem.ID {
+			following = &items[i]
+			break
+		}
+	}
+
+	// Set active state before releasing the lock. With an item already in
+	// flight, the next one is queued behind it once its prompt is submitted.
 	e.activeJobID = runningJob.ID
-	e.activeItemID = nextItem.ID
+	if e.activeItemID == "" {
+		e.activeItemID = nextItem.ID
+	}
 
 	// Release the lock before performing blocking I/O
 	e.mu.Unlock()
 
 	// Process the item (this does blocking I/O: workflow load, ComfyUI submit)
 	e.processItem(*runningJob, *nextItem)
+
+	if following != nil {
+		e.prewarmNextCheckpoint(*runningJob, *nextItem, *following)
+	}
+}
+
+// prewarmNextCheckpoint queues a minimal prompt that loads the checkpoint of
+// following behind current's prompt, when following starts a new checkpoint
+// and the GPU has room for it. ComfyUI runs one prompt at a time, so the
+// load starts once current has finished sampling and overlaps with
+// downloading and saving its image; ComfyUI's cache then serves the loaded
+// model to following's prompt. Failures only skip the pre-warm.
+func (e *JobExecutor) prewarmNextCheckpoint(job model.SampleJob, current, following model.SampleJobItem) {
+	if e.prewarm == nil || following.CheckpointFilename == current.CheckpointFilename {
+		return
+	}
+	e.logger.WithFields(logrus.Fields{
+		"job_id":              job.ID,
+		"checkpoint_filename": following.CheckpointFilename,
+	}).Trace("entering prewarmNextCheckpoint")
+	defer e.logger.Trace("returning from prewarmNextCheckpoint")
+
+	key := job.ID + "/" + following.CheckpointFilename
+	e.mu.Lock()
+	skip := e.activeItemID != current.ID || e.activePromptID == "" ||
+		e.prewarmPromptID != "" || e.prewarmedCheckpoint == key
+	e.mu.Unlock()
+	if skip {
+		return
+	}
+
+	log := e.logger.WithFields(logrus.Fields{
+		"job_id":              job.ID,
+		"checkpoint_filename": following.CheckpointFilename,
+	})
+	room, err := e.prewarm.HasRoomForModel(job.WorkflowOf(following))
+	if err != nil {
+		log.WithError(err).Debug("skipping pre-warm, free VRAM unknown")
+		return
+	}
+	if !room {
+		log.Info("skipping pre-warm, not enough free VRAM for a second model")
+		return
+	}
+
+	params, err := e.jobParameters(job)
+	if err != nil {
+		log.WithError(err).Warn("skipping pre-warm, failed to load job parameters")
+		return
+	}
+	workflow, ok := params.WorkflowOf(following)
+	if !ok {
+		log.Warn("skipping pre-warm, workflow of the next checkpoint is not in the job parameters")
+		return
+	}
+	if len(workflow.Roles[string(model.CSRoleUNETLoader)]) == 0 {
+		log.Debug("skipping pre-warm, workflow has no unet_loader node")
+		return
+	}
+	substituted, err := e.substituteWorkflow(workflow, job, following)
+	if err != nil {
+		log.WithError(err).Warn("skipping pre-warm, workflow substitution failed")
+		return
+	}
+	minimizePrewarmWorkflow(substituted, workflow.Roles)
+
+	promptResp, err := e.comfyuiClient.SubmitPrompt(e.ctx, model.PromptRequest{
+		Prompt:    substituted,
+		ClientID:  e.comfyuiWS.GetClientID(),
+		ExtraData: promptExtraData(job, ""),
+	})
+	if err != nil {
+		log.WithError(err).Warn("failed to submit pre-warm prompt to ComfyUI")
+		return
+	}
+
+	e.mu.Lock()
+	e.prewarmPromptID = promptResp.PromptID
+	e.prewarmedCheckpoint = key
+	e.mu.Unlock()
+	log.WithField("prompt_id", promptResp.PromptID).Info("pre-warm prompt submitted to ComfyUI")
+}
+
+// minimizePrewarmWorkflow makes a substituted workflow as cheap as possible
+// while keeping its model loaders: one sampler step on the smallest latent,
+// with the result previewed instead of saved. ComfyUI refuses prompts without
+// an output node, so save_image nodes become PreviewImage nodes.
+func minimizePrewarmWorkflow(workflow map[string]interface{}, roles map[string][]string) {
+	for _, nodeID := range roles[string(model.CSRoleSampler)] {
+		if inputs := nodeInputs(workflow, nodeID); inputs != nil {
+			node := workflow[nodeID].(map[string]interface{})
+			if key, ok := inputKey(node, "steps"); ok {
+				if _, ok := inputs[key]; ok {
+					inputs[key] = 1
+				}
+			}
+		}
+	}
+	for _, nodeID := range roles[string(model.CSRoleLatentImage)] {
+		if inputs := nodeInputs(workflow, nodeID); inputs != nil {
+			node := workflow[nodeID].(map[string]interface{})
+			for name, value := range map[string]interface{}{"width": prewarmLatentSize, "height": prewarmLatentSize, "batch_size": 1} {
+				if key, ok := inputKey(node, name); ok {
+					inputs[key] = value
+				}
+			}
+		}
+	}
+	for _, nodeID := range roles[string(model.CSRoleSaveImage)] {
+		if inputs := nodeInputs(workflow, nodeID); inputs != nil {
+			workflow[nodeID] = map[string]interface{}{
+				"class_type": "PreviewImage",
+				"inputs":     map[string]interface{}{"images": inputs["images"]},
+			}
+		}
+	}
+}
+
+// nodeInputs returns the inputs map of a workflow node, or nil if the node
+// or its inputs are missing.
+func nodeInputs(workflow map[string]interface{}, nodeID string) map[string]interface{} {
+	node, ok := workflow[nodeID].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	inputs, _ := node["inputs"].(map[string]interface{})
+	return inputs
+}
+
+// extendFinishedAppendJobs appends items for new checkpoints to completed
+// append-mode jobs, at most once per appendCheckInterval. A job that gains
+// items is pending again and is started on a later tick.
+func (e *JobExecutor) extendFinishedAppendJobs(jobs []model.SampleJob) {
+	if e.appender == nil {
+		return
+	}
+	e.mu.Lock()
+	now := e.timeNow()
+	if now.Sub(e.lastAppendCheck) < appendCheckInterval {
+		e.mu.Unlock()
+		return
+	}
+	e.lastAppendCheck = now
+	e.mu.Unlock()
+
+	for _, job := range jobs {
+		if !job.AppendNewCheckpoints {
+			continue
+		}
+		if job.Status != model.SampleJobStatusCompleted && job.Status != model.SampleJobStatusCompletedWithErrors {
+			continue
+		}
+		e.appendNewCheckpoints(job.ID)
+	}
+}
+
+// appendNewCheckpoints asks the appender for items for new checkpoints of
+// job jobID and returns how many were added. Errors are logged and count as
+// no items, so that a failing check never blocks the job from completing.
+func (e *JobExecutor) appendNewCheckpoints(jobID string) int {
+	if e.appender == nil {
+		return 0
+	}
+	added, err := e.appender.AppendNewCheckpoints(e.ctx, jobID)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"job_id": jobID,
+			"error":  err.Error(),
+		}).Error("failed to append items for new checkpoints")
+		return 0
+	}
+	if added > 0 {
+		e.logger.WithFields(logrus.Fields{
+			"job_id":     jobID,
+			"item_count": added,
+		}).Info("queued items for new checkpoints")
+		e.broadcastJobProgress(jobID)
+	}
+	return added
+}
+
+// promptExtraData returns the extra_data submitted with a prompt of job, so
+// that ComfyUI's queue and history can be correlated with the job, the item
+// and the API request that created the job. itemID is empty for prompts that
+// belong to no item, such as pre-warm prompts.
+func promptExtraData(job model.SampleJob, itemID string) map[string]interface{} {
+	data := map[string]interface{}{"sample_job_id": job.ID}
+	if itemID != "" {
+		data["sample_job_item_id"] = itemID
+	}
+	if job.CreatedByRequestID != "" {
+		data["request_id"] = job.CreatedByRequestID
+	}
+	return map[string]interface{}{"checkpoint_sampler": data}
 }
 
 // processItem processes a single work item.
@@ -792,22 +1887,29 @@ func (e *JobExecutor) processItem(job model.SampleJob, item model.SampleJobItem)
 		"checkpoint_filename": item.CheckpointFilename,
 	}).Info("processing job item")
 
-	// Record sample start time for ETA calculation
+	// Record sample start time for ETA calculation. A queued item's sample
+	// starts once it becomes the active item.
+	startedAt := e.timeNow().UTC()
 	e.mu.Lock()
-	e.sampleStartTime = e.timeNow()
+	if e.activeItemID == item.ID {
+		e.sampleStartTime = startedAt
+	}
 	e.mu.Unlock()
 
-	// Update item status to running
+	// Update item status to running and record per-item timing. Any timing left
+	// over from a previous attempt (retry or orphan recovery) is cleared.
 	item.Status = model.SampleJobItemStatusRunning
+	item.StartedAt = &startedAt
+	item.CompletedAt = nil
+	item.DurationMs = nil
 	item.UpdatedAt = time.Now().UTC()
-	if err := e.store.UpdateSampleJobItem(item); err != nil {
+	if err := e.store.UpdateSampleJobItem(e.ctx, &item); err != nil {
 		if err == sql.ErrNoRows {
 			// Item was deleted between poll and update (e.g. job cancelled during E2E teardown).
 			// This is a benign race — clear active state and return without error.
 			e.logger.WithField("item_id", item.ID).Warn("item row not found during status-to-running update (job likely cancelled)")
 			e.mu.Lock()
-			e.activeItemID = ""
-			e.activePromptID = ""
+			e.releaseItem(item.ID)
 			e.mu.Unlock()
 		} else {
 			e.logger.WithError(err).Error("failed to update item status to running")
@@ -816,15 +1918,34 @@ func (e *JobExecutor) processItem(job model.SampleJob, item model.SampleJobItem)
 		return
 	}
 
-	// Load workflow template
-	workflow, err := e.workflowLoader.Get(e.ctx, job.WorkflowName)
+	// Load the workflow template from the job's parameter snapshot
+	params, err := e.jobParameters(job)
 	if err != nil {
-		e.logger.WithError(err).Error("failed to load workflow template")
-		e.failItem(item.ID, fmt.Sprintf("failed to load workflow: %v", err))
+		e.logger.WithError(err).Error("failed to load job parameters")
+		e.failItem(item.ID, fmt.Sprintf("failed to load job parameters: %v", err))
 		return
 	}
 
-	// Clone and substitute workflow
+	// Copy the checkpoint into the staging directory if ComfyUI loads it
+	// from there. The staged file is recorded on the item with its prompt ID
+	// and removed when the job finishes.
+	if e.stager != nil {
+		stagedPath, err := e.stager.Stage(item.ComfyUIModelPath, item.CheckpointFilename)
+		if err != nil {
+			e.logger.WithError(err).Error("failed to stage checkpoint")
+			e.failItem(item.ID, fmt.Sprintf("checkpoint staging failed: %v", err))
+			return
+		}
+		item.StagedCheckpoint = stagedPath
+	}
+
+	// Clone and substitute the item's workflow
+	workflow, ok := params.WorkflowOf(item)
+	if !ok {
+		e.logger.WithField("workflow", item.WorkflowName).Error("item workflow missing from job parameters")
+		e.failItem(item.ID, fmt.Sprintf("workflow %s is not part of the job parameters", item.WorkflowName))
+		return
+	}
 	substituted, err := e.substituteWorkflow(workflow, job, item)
 	if err != nil {
 		e.logger.WithError(err).Error("failed to substitute workflow")
@@ -835,8 +1956,9 @@ func (e *JobExecutor) processItem(job model.SampleJob, item model.SampleJobItem)
 	// Submit to ComfyUI with the WebSocket client_id so that ComfyUI routes
 	// prompt-specific events (executing, executed, execution_error) to our WS connection.
 	promptReq := model.PromptRequest{
-		Prompt:   substituted,
-		ClientID: e.comfyuiWS.GetClientID(),
+		Prompt:    substituted,
+		ClientID:  e.comfyuiWS.GetClientID(),
+		ExtraData: promptExtraData(job, item.ID),
 	}
 	promptResp, err := e.comfyuiClient.SubmitPrompt(e.ctx, promptReq)
 	if err != nil {
@@ -854,14 +1976,33 @@ func (e *JobExecutor) processItem(job model.SampleJob, item model.SampleJobItem)
 
 	e.logger.WithField("prompt_id", promptResp.PromptID).Info("prompt submitted to ComfyUI")
 
-	// Store the prompt ID (acquire mutex for write)
+	// Store the prompt ID (acquire mutex for write). The items ahead of a
+	// queued item may have finished while its prompt was submitted.
 	e.mu.Lock()
-	e.activePromptID = promptResp.PromptID
+	switch {
+	case e.activeJobID != job.ID:
+		e.mu.Unlock()
+		e.logger.WithField("prompt_id", promptResp.PromptID).Warn("job no longer active after prompt submission, cancelling prompt")
+		e.cancelPrompts([]string{promptResp.PromptID})
+		return
+	case e.activeItemID == item.ID || e.activeItemID == "":
+		e.activeItemID = item.ID
+		e.activePromptID = promptResp.PromptID
+		e.lastItemEvent = e.timeNow()
+		if e.sampleStartTime.IsZero() {
+			e.sampleStartTime = startedAt
+		}
+	default:
+		e.queued = append(e.queued, queuedPrompt{itemID: item.ID, promptID: promptResp.PromptID})
+		e.logger.WithFields(logrus.Fields{
+			"item_id":        item.ID,
+			"prompt_id":      promptResp.PromptID,
+			"active_item_id": e.activeItemID,
+		}).Debug("prompt queued behind the active item")
+	}
 	e.mu.Unlock()
 
-	item.ComfyUIPromptID = promptResp.PromptID
-	item.UpdatedAt = time.Now().UTC()
-	if err := e.store.UpdateSampleJobItem(item); err != nil {
+	if err := e.saveItemPromptID(item, promptResp.PromptID); err != nil {
 		if err == sql.ErrNoRows {
 			// Item was deleted between prompt submission and prompt-ID update (job cancelled).
 			// This is a benign race — log at warn, not error.
@@ -897,12 +2038,65 @@ func (e *JobExecutor) handleComfyUIEvent(event model.ComfyUIEvent) {
 		return
 	}
 
+	// Events of a pre-warm prompt are neither progress nor completion of the
+	// active item; they only tell us that the pre-warm has finished.
+	if e.prewarmPromptID != "" {
+		if promptID, _ := event.Data["prompt_id"].(string); promptID == e.prewarmPromptID {
+			nodeID, _ := event.Data["node"].(string)
+			if (event.Type == "executing" && nodeID == "") || event.Type == "execution_success" ||
+				event.Type == "execution_error" || event.Type == "execution_interrupted" {
+				e.logger.WithFields(logrus.Fields{
+					"prompt_id":  promptID,
+					"event_type": event.Type,
+				}).Debug("pre-warm prompt finished")
+				e.prewarmPromptID = ""
+			}
+			e.mu.Unlock()
+			return
+		}
+	}
+
+	// Prompts run in submission order, so a queued prompt normally only
+	// reports events once it is the active one. It finishes while queued if
+	// the prompt ahead of it was removed without an event, e.g. on timeout.
+	if promptID, _ := event.Data["prompt_id"].(string); promptID != "" && promptID != e.activePromptID {
+		if q, ok := e.queuedItemOf(promptID); ok {
+			nodeID, hasNode := event.Data["node"].(string)
+			finished := (event.Type == "executing" && (!hasNode || nodeID == "")) || event.Type == "execution_success"
+			if !finished && event.Type != "execution_error" {
+				e.mu.Unlock()
+				return
+			}
+			// Dequeue under the lock so that a second completion event is ignored
+			e.dequeue(promptID)
+			jobID := e.activeJobID
+			e.mu.Unlock()
+
+			if event.Type == "execution_error" {
+				e.failExecution(q.itemID, promptID, event.Data)
+				return
+			}
+			e.logger.WithFields(logrus.Fields{
+				"prompt_id":  promptID,
+				"event_type": event.Type,
+			}).Info("ComfyUI execution of queued prompt completed")
+			e.handleItemCompletionAsync(jobID, q.itemID, promptID)
+			return
+		}
+	}
+
 	// Only handle events for the active prompt
 	if e.activePromptID == "" {
 		e.mu.Unlock()
 		return
 	}
 
+	// Any event of the active prompt shows that ComfyUI is still working on
+	// it; the item timeout counts from the latest one.
+	if promptID, _ := event.Data["prompt_id"].(string); promptID == e.activePromptID || (promptID == "" && event.Type == "progress") {
+		e.lastItemEvent = e.timeNow()
+	}
+
 	// Forward per-node inference progress events to WebSocket clients.
 	// ComfyUI sends "progress" events with value/max as sampler steps complete.
 	if event.Type == "progress" {
@@ -921,6 +2115,7 @@ func (e *JobExecutor) handleComfyUIEvent(event model.ComfyUIEvent) {
 			// Compute per-sample ETA from step-based progress while the lock is held.
 			// ETA = elapsed * (remaining_steps / completed_steps)
 			startTime := e.sampleStartTime
+			jobID := e.activeJobID
 			e.mu.Unlock()
 
 			var sampleETASeconds float64
@@ -931,8 +2126,9 @@ func (e *JobExecutor) handleComfyUIEvent(event model.ComfyUIEvent) {
 			}
 
 			progressEvent := model.FSEvent{
-				Type: model.EventInferenceProgress,
-				Path: fmt.Sprintf("inference_progress/%s", promptID),
+				Type:  model.EventInferenceProgress,
+				Path:  fmt.Sprintf("inference_progress/%s", promptID),
+				JobID: jobID,
 				InferenceProgressData: &model.InferenceProgressEventData{
 					PromptID:         promptID,
 					CurrentValue:     value,
@@ -1013,34 +2209,7 @@ func (e *JobExecutor) handleComfyUIEvent(event model.ComfyUIEvent) {
 			capturedItemID := e.activeItemID
 			e.mu.Unlock()
 
-			// AC: BE: Parse execution_error fields from ComfyUI event
-			exceptionMessage, _ := data["exception_message"].(string)
-			exceptionType, _ := data["exception_type"].(string)
-			nodeType, _ := data["node_type"].(string)
-
-			// Build traceback string from the array
-			var traceback string
-			if tbArray, ok := data["traceback"].([]interface{}); ok {
-				var lines []string
-				for _, line := range tbArray {
-					if s, ok := line.(string); ok {
-						lines = append(lines, s)
-					}
-				}
-				traceback = strings.Join(lines, "")
-			}
-
-			// Compose a rich error message from the structured fields
-			errMsg := composeExecutionErrorMessage(exceptionType, nodeType, exceptionMessage)
-
-			e.logger.WithFields(logrus.Fields{
-				"prompt_id":         promptID,
-				"exception_type":    exceptionType,
-				"exception_message": exceptionMessage,
-				"node_type":         nodeType,
-			}).Error("ComfyUI execution error")
-
-			e.failItemWithDetails(capturedItemID, errMsg, exceptionType, nodeType, traceback)
+			e.failExecution(capturedItemID, promptID, data)
 			return
 		}
 	}
@@ -1048,6 +2217,39 @@ func (e *JobExecutor) handleComfyUIEvent(event model.ComfyUIEvent) {
 	e.mu.Unlock()
 }
 
+// failExecution fails the active item with the details of the data of an
+// execution_error event or history message (called without holding mutex).
+func (e *JobExecutor) failExecution(itemID, promptID string, data map[string]interface{}) {
+	// Parse the fields of the execution_error event
+	exceptionMessage, _ := data["exception_message"].(string)
+	exceptionType, _ := data["exception_type"].(string)
+	nodeType, _ := data["node_type"].(string)
+
+	// Build traceback string from the array
+	var traceback string
+	if tbArray, ok := data["traceback"].([]interface{}); ok {
+		var lines []string
+		for _, line := range tbArray {
+			if s, ok := line.(string); ok {
+				lines = append(lines, s)
+			}
+		}
+		traceback = strings.Join(lines, "")
+	}
+
+	// Compose a rich error message from the structured fields
+	errMsg := composeExecutionErrorMessage(exceptionType, nodeType, exceptionMessage)
+
+	e.logger.WithFields(logrus.Fields{
+		"prompt_id":         promptID,
+		"exception_type":    exceptionType,
+		"exception_message": exceptionMessage,
+		"node_type":         nodeType,
+	}).Error("ComfyUI execution error")
+
+	e.failItemWithDetails(itemID, errMsg, exceptionType, nodeType, traceback, e.captureFailureLog())
+}
+
 // handleItemCompletionAsync processes the completion of a job item without holding the mutex.
 // It performs blocking I/O operations and then re-acquires the lock to update active state.
 func (e *JobExecutor) handleItemCompletionAsync(jobID, itemID, promptID string) {
@@ -1058,7 +2260,7 @@ func (e *JobExecutor) handleItemCompletionAsync(jobID, itemID, promptID string)
 	e.logger.WithField("item_id", itemID).Debug("handling item completion")
 
 	// Fetch the item
-	items, err := e.store.ListSampleJobItems(jobID)
+	items, err := e.store.ListSampleJobItems(e.ctx, jobID)
 	if err != nil {
 		e.logger.WithError(err).Error("failed to list job items")
 		e.failItem(itemID, "failed to fetch item for completion")
@@ -1083,8 +2285,7 @@ func (e *JobExecutor) handleItemCompletionAsync(jobID, itemID, promptID string)
 
 		// Clear active state so the executor is free to pick up new work.
 		e.mu.Lock()
-		e.activeItemID = ""
-		e.activePromptID = ""
+		e.releaseItem(itemID)
 		e.mu.Unlock()
 		return
 	}
@@ -1098,7 +2299,7 @@ func (e *JobExecutor) handleItemCompletionAsync(jobID, itemID, promptID string)
 	}
 
 	// Fetch the job and study for the output path
-	job, err := e.store.GetSampleJob(jobID)
+	job, err := e.store.GetSampleJob(e.ctx, jobID)
 	if err != nil {
 		e.logger.WithError(err).Error("failed to fetch job for output path")
 		e.failItem(itemID, fmt.Sprintf("failed to fetch job: %v", err))
@@ -1115,22 +2316,37 @@ func (e *JobExecutor) handleItemCompletionAsync(jobID, itemID, promptID string)
 	studyOutputDir := fileformat.SanitizeTrainingRunName(job.TrainingRunName) + "/" + job.StudyName
 
 	// Generate output filename
-	filename := e.generateOutputFilename(*item)
-	outputPath, err := e.getOutputPath(studyOutputDir, item.CheckpointFilename, filename)
+	filename := e.outputRelPath(*item, job.OutputFormat)
+	outputPath, err := e.getOutputPath(studyOutputDir, item.CheckpointFilename, filepath.FromSlash(filename))
 	if err != nil {
 		e.logger.WithError(err).Error("invalid output path")
 		e.failItem(itemID, fmt.Sprintf("invalid output path: %v", err))
 		return
 	}
 
+	// Transcode to the job's output format. The thumbnail below is still
+	// generated from the original PNG.
+	outputData, err := e.transcodeImage(job, imageData)
+	if err != nil {
+		e.logger.WithError(err).Error("failed to transcode image")
+		e.failItem(itemID, fmt.Sprintf("failed to transcode image: %v", err))
+		return
+	}
+
+	// Embed the generation parameters in PNG images (non-fatal if it fails)
+	if job.OutputFormat == "" || job.OutputFormat == model.OutputFormatPNG {
+		outputData = e.embedParameters(job, *item, outputData)
+	}
+
 	// Save image to disk
-	if err := e.saveImage(outputPath, imageData); err != nil {
+	if err := e.saveImage(outputPath, outputData); err != nil {
 		e.logger.WithError(err).Error("failed to save image")
 		e.failItem(itemID, fmt.Sprintf("failed to save image: %v", err))
 		return
 	}
 
 	e.logger.WithField("output_path", outputPath).Info("image saved successfully")
+	e.indexSavedImage(outputPath)
 
 	// Generate thumbnail if enabled (non-fatal if it fails)
 	if e.thumbGen != nil {
@@ -1139,16 +2355,16 @@ func (e *JobExecutor) handleItemCompletionAsync(jobID, itemID, promptID string)
 		}
 	}
 
+	// Score the image if a scorer is configured (non-fatal if it fails)
+	item.Scores = e.scoreImage(job, *item, outputData, filepath.Base(outputPath))
+
 	// Write sidecar JSON alongside the image (non-fatal if it fails)
 	if sidecarErr := e.writeSidecar(outputPath, job, *item); sidecarErr != nil {
 		e.logger.WithError(sidecarErr).Warn("failed to write sidecar, image saved but metadata sidecar missing")
 	}
 
 	// Update item status to completed
-	item.Status = model.SampleJobItemStatusCompleted
-	item.OutputPath = outputPath
-	item.UpdatedAt = time.Now().UTC()
-	if err := e.store.UpdateSampleJobItem(*item); err != nil {
+	if err := e.saveItemCompletion(*item, outputPath); err != nil {
 		if err == sql.ErrNoRows {
 			// Item was deleted between image download and status update (job cancelled during E2E teardown).
 			// This is the primary benign race condition — log at warn, not error.
@@ -1160,7 +2376,7 @@ func (e *JobExecutor) handleItemCompletionAsync(jobID, itemID, promptID string)
 
 	// Record sample duration for ETA calculation
 	e.mu.Lock()
-	if !e.sampleStartTime.IsZero() {
+	if !e.sampleStartTime.IsZero() && itemID == e.activeItemID {
 		duration := e.timeNow().Sub(e.sampleStartTime)
 		e.sampleTiming.Add(duration)
 		e.sampleStartTime = time.Time{}
@@ -1179,13 +2395,61 @@ func (e *JobExecutor) handleItemCompletionAsync(jobID, itemID, promptID string)
 	// Broadcast progress event to WebSocket clients
 	e.broadcastJobProgress(jobID)
 
-	// Clear active state
+	// Clear active state, making the next queued item the active one
 	e.mu.Lock()
-	e.activeItemID = ""
-	e.activePromptID = ""
+	e.releaseItem(itemID)
 	e.mu.Unlock()
 }
 
+// scoreImage scores a saved image with the configured scorer. It returns nil
+// if no scorer is configured or scoring fails; scores with names that do not
+// match scoreNamePattern are dropped.
+func (e *JobExecutor) scoreImage(job model.SampleJob, item model.SampleJobItem, data []byte, filename string) map[string]float64 {
+	if e.scorer == nil {
+		return nil
+	}
+	e.logger.WithField("item_id", item.ID).Trace("entering scoreImage")
+	defer e.logger.Trace("returning from scoreImage")
+
+	ctx, cancel := context.WithTimeout(e.ctx, e.scoreTimeout)
+	defer cancel()
+	scores, err := e.scorer.ScoreImage(ctx, model.ImageScoreRequest{
+		Image:              data,
+		Filename:           filename,
+		JobID:              job.ID,
+		ItemID:             item.ID,
+		TrainingRunName:    job.TrainingRunName,
+		CheckpointFilename: item.CheckpointFilename,
+		PromptName:         item.PromptName,
+		PromptText:         item.PromptText,
+		NegativePrompt:     item.NegativePrompt,
+	})
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"item_id": item.ID,
+			"error":   err.Error(),
+		}).Warn("failed to score image, image saved without scores")
+		return nil
+	}
+	for name := range scores {
+		if !scoreNamePattern.MatchString(name) {
+			e.logger.WithFields(logrus.Fields{
+				"item_id": item.ID,
+				"score":   name,
+			}).Warn("dropping score with invalid name")
+			delete(scores, name)
+		}
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+	e.logger.WithFields(logrus.Fields{
+		"item_id": item.ID,
+		"scores":  scores,
+	}).Debug("scored image")
+	return scores
+}
+
 // substituteWorkflow clones a workflow and substitutes tagged node values.
 func (e *JobExecutor) substituteWorkflow(template model.WorkflowTemplate, job model.SampleJob, item model.SampleJobItem) (map[string]interface{}, error) {
 	e.logger.Trace("entering substituteWorkflow")
@@ -1197,20 +2461,94 @@ func (e *JobExecutor) substituteWorkflow(template model.WorkflowTemplate, job mo
 		return nil, fmt.Errorf("cloning workflow: %w", err)
 	}
 
-	// Substitute values for each cs_role
-	for role, nodeIDs := range template.Roles {
+	// Upload the job's images before any node references them
+	var images uploadedImages
+	if job.InputImage != "" && len(template.Roles[string(model.CSRoleLoadImage)]) > 0 {
+		images.Input, err = e.uploadImage("input", job.InputImage)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if job.ControlNetImage != "" && len(template.Roles[string(model.CSRoleControlNetApply)]) > 0 {
+		images.ControlNet, err = e.uploadImage("controlnet", job.ControlNetImage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := substituteRoles(cloned, template.Roles, job, item, images); err != nil {
+		return nil, err
+	}
+	return cloned, nil
+}
+
+// substituteRoles substitutes the values of job and item into the nodes of
+// workflow tagged with each cs_role. Nodes with unknown roles are left as they
+// are.
+func substituteRoles(workflow map[string]interface{}, roles map[string][]string, job model.SampleJob, item model.SampleJobItem, images uploadedImages) error {
+	for role, nodeIDs := range roles {
 		for _, nodeID := range nodeIDs {
-			if err := e.substituteNode(cloned, nodeID, role, job, item); err != nil {
-				return nil, fmt.Errorf("substituting node %s (role %s): %w", nodeID, role, err)
+			if err := substituteNode(workflow, nodeID, role, job, item, images); err != nil {
+				return fmt.Errorf("substituting node %s (role %s): %w", nodeID, role, err)
 			}
 		}
 	}
+	return nil
+}
 
-	return cloned, nil
+// uploadedImages holds the names ComfyUI knows a job's images by once
+// uploaded. Empty names mean the job has no such image.
+type uploadedImages struct {
+	Input      string // img2img input image, for load_image nodes
+	ControlNet string // conditioning image, for the LoadImage node feeding controlnet_apply
+}
+
+// uploadImage uploads the kind ("input" or "controlnet") image at path to
+// ComfyUI and returns the name LoadImage nodes reference it by. The upload is
+// named after a hash of the image's contents, so that re-uploading it for
+// every item overwrites the same file and a changed image never reuses a
+// stale upload.
+func (e *JobExecutor) uploadImage(kind string, path string) (string, error) {
+	e.logger.WithFields(logrus.Fields{
+		"kind": kind,
+		"path": path,
+	}).Trace("entering uploadImage")
+	defer e.logger.Trace("returning from uploadImage")
+
+	if e.inputReader == nil || e.inputUploader == nil {
+		return "", fmt.Errorf("%s images are not supported by this executor", kind)
+	}
+	data, err := e.inputReader.ReadFile(path)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"kind":  kind,
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to read image for upload")
+		return "", fmt.Errorf("reading %s image %s: %w", kind, path, err)
+	}
+	sum := sha256.Sum256(data)
+	uploadName := "cs-" + kind + "-" + hex.EncodeToString(sum[:8]) + strings.ToLower(filepath.Ext(path))
+	name, err := e.inputUploader.UploadImage(e.ctx, uploadName, data)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"kind":  kind,
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to upload image")
+		return "", fmt.Errorf("uploading %s image %s: %w", kind, path, err)
+	}
+	e.logger.WithFields(logrus.Fields{
+		"kind":        kind,
+		"path":        path,
+		"upload_name": name,
+	}).Debug("uploaded image to ComfyUI")
+	return name, nil
 }
 
 // substituteNode substitutes values in a workflow node based on its cs_role.
-func (e *JobExecutor) substituteNode(workflow map[string]interface{}, nodeID string, role string, job model.SampleJob, item model.SampleJobItem) error {
+// images holds the uploaded names of the job's images.
+func substituteNode(workflow map[string]interface{}, nodeID string, role string, job model.SampleJob, item model.SampleJobItem, images uploadedImages) error {
 	node, ok := workflow[nodeID].(map[string]interface{})
 	if !ok {
 		return fmt.Errorf("node %s is not a map", nodeID)
@@ -1221,64 +2559,155 @@ func (e *JobExecutor) substituteNode(workflow map[string]interface{}, nodeID str
 		return fmt.Errorf("node %s has no inputs", nodeID)
 	}
 
+	// set writes an input under the key the node's cs_inputs give it
+	set := func(name string, value interface{}) {
+		if key, ok := inputKey(node, name); ok {
+			inputs[key] = value
+		}
+	}
+
 	switch model.CSRole(role) {
 	case model.CSRoleUNETLoader:
-		inputs["unet_name"] = item.ComfyUIModelPath
+		set("unet_name", item.ComfyUIModelPath)
 	case model.CSRoleCLIPLoader:
 		if job.CLIP != "" {
-			inputs["clip_name"] = job.CLIP
+			set("clip_name", job.CLIP)
 		}
 	case model.CSRoleVAELoader:
 		if job.VAE != "" {
-			inputs["vae_name"] = job.VAE
+			set("vae_name", job.VAE)
 		}
 	case model.CSRoleSampler:
-		inputs["seed"] = item.Seed
-		inputs["steps"] = item.Steps
-		inputs["cfg"] = item.CFG
-		inputs["sampler_name"] = item.SamplerName
-		inputs["scheduler"] = item.Scheduler
+		set("seed", item.Seed)
+		set("steps", item.Steps)
+		set("cfg", item.CFG)
+		set("sampler_name", item.SamplerName)
+		set("scheduler", item.Scheduler)
 	case model.CSRolePositivePrompt:
-		inputs["text"] = item.PromptText
+		set("text", item.PromptText)
 	case model.CSRoleNegativePrompt:
 		// Inject negative prompt text when present; keep node default otherwise
 		if item.NegativePrompt != "" {
-			inputs["text"] = item.NegativePrompt
+			set("text", item.NegativePrompt)
 		}
 	case model.CSRoleShift:
 		if job.Shift != nil {
-			inputs["shift"] = *job.Shift
+			set("shift", *job.Shift)
 		}
 	case model.CSRoleLatentImage:
-		inputs["width"] = item.Width
-		inputs["height"] = item.Height
-		inputs["batch_size"] = 1
+		set("width", item.Width)
+		set("height", item.Height)
+		set("batch_size", 1)
+	case model.CSRoleLoadImage:
+		// Keep the node's own image when the job has no input image
+		if images.Input != "" {
+			set("image", images.Input)
+		}
+	case model.CSRoleDenoise:
+		if item.Denoise != nil {
+			set("denoise", *item.Denoise)
+		}
+	case model.CSRoleControlNetLoader:
+		if job.ControlNetModel != "" {
+			set("control_net_name", job.ControlNetModel)
+		}
+	case model.CSRoleControlNetApply:
+		if job.ControlNetStrength != nil {
+			set("strength", *job.ControlNetStrength)
+		}
+		if images.ControlNet != "" {
+			if err := setLinkedImage(workflow, nodeID, node, images.ControlNet); err != nil {
+				return err
+			}
+		}
 	case model.CSRoleSaveImage:
 		// Generate a prefix for the output filename
-		prefix := e.generateFilenamePrefix(item)
-		inputs["filename_prefix"] = prefix
+		set("filename_prefix", generateFilenamePrefix(item))
 	default:
-		e.logger.WithFields(logrus.Fields{
-			"node_id": nodeID,
-			"role":    role,
-		}).Debug("unknown cs_role, skipping substitution")
+		// Unknown roles are reported as warnings when the workflow is
+		// loaded; their nodes are submitted unchanged.
 	}
 
 	return nil
 }
 
+// setLinkedImage sets the image of the LoadImage node linked to the image
+// input of a controlnet_apply node. The apply node takes the conditioning
+// image from another node, so the image cannot be set on the node itself.
+func setLinkedImage(workflow map[string]interface{}, nodeID string, node map[string]interface{}, image string) error {
+	inputs, _ := node["inputs"].(map[string]interface{})
+	key, _ := inputKey(node, "image")
+	from, _, ok := parseNodeLink(inputs[key])
+	if !ok {
+		return fmt.Errorf("node %s has no linked image input for the controlnet image", nodeID)
+	}
+	source, ok := workflow[from].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("node %s links its image input to missing node %s", nodeID, from)
+	}
+	sourceInputs, ok := source["inputs"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("node %s has no inputs", from)
+	}
+	sourceKey, _ := inputKey(source, "image")
+	if _, ok := sourceInputs[sourceKey]; !ok {
+		return fmt.Errorf("node %s linked to the image input of node %s is not an image loader", from, nodeID)
+	}
+	sourceInputs[sourceKey] = image
+	return nil
+}
+
 // generateFilenamePrefix generates a prefix for ComfyUI's save_image node.
-func (e *JobExecutor) generateFilenamePrefix(item model.SampleJobItem) string {
+func generateFilenamePrefix(item model.SampleJobItem) string {
 	// Use a simple prefix that includes the checkpoint filename
 	// ComfyUI will append a counter and timestamp
 	checkpointBase := strings.TrimSuffix(item.CheckpointFilename, filepath.Ext(item.CheckpointFilename))
 	return fmt.Sprintf("sample_%s", checkpointBase)
 }
 
-// generateOutputFilename generates the query-encoded output filename.
-// Delegates to the shared GenerateOutputFilename function.
-func (e *JobExecutor) generateOutputFilename(item model.SampleJobItem) string {
-	return GenerateOutputFilename(item)
+// outputRelPath returns the path of item's image inside its checkpoint's
+// sample directory under the executor's output layout.
+// Delegates to the shared OutputRelPath function.
+func (e *JobExecutor) outputRelPath(item model.SampleJobItem, format model.OutputFormat) string {
+	return OutputRelPath(e.outputLayout, item, format)
+}
+
+// transcodeImage converts the downloaded PNG into the job's output format.
+// PNG jobs are saved as downloaded.
+func (e *JobExecutor) transcodeImage(job model.SampleJob, pngData []byte) ([]byte, error) {
+	if job.OutputFormat == "" || job.OutputFormat == model.OutputFormatPNG {
+		return pngData, nil
+	}
+	if e.transcoder == nil {
+		return nil, fmt.Errorf("output format %q requires a transcoder, none is configured", job.OutputFormat)
+	}
+	data, err := e.transcoder.Transcode(pngData, job.OutputFormat, job.OutputQuality)
+	if err != nil {
+		return nil, err
+	}
+	e.logger.WithFields(logrus.Fields{
+		"sample_job_id": job.ID,
+		"output_format": job.OutputFormat,
+		"png_bytes":     len(pngData),
+		"output_bytes":  len(data),
+	}).Debug("transcoded image")
+	return data, nil
+}
+
+// embedParameters returns the PNG image with item's generation parameters
+// embedded in a "parameters" text chunk, which A1111, ComfyUI, and other
+// tools read to show where an image came from. It returns pngData unchanged
+// if the chunk cannot be embedded.
+func (e *JobExecutor) embedParameters(job model.SampleJob, item model.SampleJobItem, pngData []byte) []byte {
+	parameters := fileformat.FormatParameters(e.sidecarMetadata(job, item))
+	data, err := fileformat.EmbedPNGText(pngData, []fileformat.PNGText{
+		{Keyword: fileformat.ParametersKeyword, Text: parameters},
+	})
+	if err != nil {
+		e.logger.WithError(err).Warn("failed to embed parameters in image, saving it without them")
+		return pngData
+	}
+	return data
 }
 
 // getOutputPath constructs the full output path for an image.
@@ -1358,6 +2787,22 @@ func (e *JobExecutor) downloadOutputImage(promptID string) ([]byte, error) {
 	return e.comfyuiClient.DownloadImage(e.ctx, filename, subfolder, folderType)
 }
 
+// indexSavedImage records the image saved at outputPath in the image index.
+func (e *JobExecutor) indexSavedImage(outputPath string) {
+	if e.images == nil {
+		return
+	}
+	relPath, err := filepath.Rel(e.sampleDir, outputPath)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"output_path": outputPath,
+			"error":       err.Error(),
+		}).Warn("failed to compute relative path of saved image")
+		return
+	}
+	e.images.AddImage(filepath.ToSlash(relPath))
+}
+
 // saveImage saves image data to disk.
 func (e *JobExecutor) saveImage(path string, data []byte) error {
 	e.logger.WithField("path", path).Trace("entering saveImage")
@@ -1393,10 +2838,41 @@ func (e *JobExecutor) writeSidecar(imagePath string, job model.SampleJob, item m
 	dir := filepath.Dir(imagePath)
 	tempPath := sidecarPath + ".tmp"
 
-	// Look up the prompt_prefix from the study (best-effort; empty on error)
+	data, err := json.Marshal(e.sidecarMetadata(job, item))
+	if err != nil {
+		return fmt.Errorf("marshaling sidecar metadata: %w", err)
+	}
+
+	// Ensure directory exists (should already exist from saveImage, but be safe)
+	if err := e.ensureDir(dir); err != nil {
+		return fmt.Errorf("ensuring sidecar directory: %w", err)
+	}
+
+	// Write to temp file first
+	if err := e.fsWriter.WriteFile(tempPath, data, 0644); err != nil {
+		e.logger.WithError(err).Error("failed to write sidecar temp file")
+		return fmt.Errorf("writing sidecar temp file: %w", err)
+	}
+
+	// Atomically rename temp file to final destination
+	if err := e.fsWriter.RenameFile(tempPath, sidecarPath); err != nil {
+		e.logger.WithError(err).Error("failed to rename sidecar temp file")
+		return fmt.Errorf("renaming sidecar file: %w", err)
+	}
+
+	e.logger.WithField("sidecar_path", sidecarPath).Info("sidecar file written")
+	return nil
+}
+
+// sidecarMetadata returns the generation metadata of item's image, as written
+// to its sidecar and embedded in PNG images.
+func (e *JobExecutor) sidecarMetadata(job model.SampleJob, item model.SampleJobItem) fileformat.SidecarMetadata {
+	// Look up the prompt_prefix and seed mode from the job's study snapshot (best-effort; empty on error)
 	var promptPrefix string
-	if study, err := e.store.GetStudy(job.StudyID); err == nil {
-		promptPrefix = study.PromptPrefix
+	var seedMode model.SeedMode
+	if params, err := e.jobParameters(job); err == nil {
+		promptPrefix = params.Study.PromptPrefix
+		seedMode = params.Study.SeedMode
 	} else {
 		e.logger.WithFields(logrus.Fields{
 			"study_id": job.StudyID,
@@ -1404,52 +2880,32 @@ func (e *JobExecutor) writeSidecar(imagePath string, job model.SampleJob, item m
 		}).Warn("failed to fetch study for sidecar prompt_prefix, continuing without it")
 	}
 
-	meta := fileformat.SidecarMetadata{
+	return fileformat.SidecarMetadata{
 		Checkpoint:     item.CheckpointFilename,
 		PromptPrefix:   promptPrefix,
 		PromptName:     item.PromptName,
 		PromptText:     item.PromptText,
 		Seed:           item.Seed,
+		SeedMode:       string(seedMode),
 		CFG:            item.CFG,
 		Steps:          item.Steps,
 		SamplerName:    item.SamplerName,
 		Scheduler:      item.Scheduler,
 		Width:          item.Width,
 		Height:         item.Height,
+		Denoise:        item.Denoise,
+		Resolution:     item.Resolution,
 		NegativePrompt: item.NegativePrompt,
 		VAE:            job.VAE,
 		CLIP:           job.CLIP,
 		Shift:          job.Shift,
-		WorkflowName:   job.WorkflowName,
+		Wildcards:      item.Wildcards,
+		Scores:         item.Scores,
+		WorkflowName:   job.WorkflowOf(item),
 		JobID:          job.ID,
 		Timestamp:      time.Now().UTC().Format(time.RFC3339),
 		CommitSHA:      buildinfo.CommitSHA,
 	}
-
-	data, err := json.Marshal(meta)
-	if err != nil {
-		return fmt.Errorf("marshaling sidecar metadata: %w", err)
-	}
-
-	// Ensure directory exists (should already exist from saveImage, but be safe)
-	if err := e.ensureDir(dir); err != nil {
-		return fmt.Errorf("ensuring sidecar directory: %w", err)
-	}
-
-	// Write to temp file first
-	if err := e.fsWriter.WriteFile(tempPath, data, 0644); err != nil {
-		e.logger.WithError(err).Error("failed to write sidecar temp file")
-		return fmt.Errorf("writing sidecar temp file: %w", err)
-	}
-
-	// Atomically rename temp file to final destination
-	if err := e.fsWriter.RenameFile(tempPath, sidecarPath); err != nil {
-		e.logger.WithError(err).Error("failed to rename sidecar temp file")
-		return fmt.Errorf("renaming sidecar file: %w", err)
-	}
-
-	e.logger.WithField("sidecar_path", sidecarPath).Info("sidecar file written")
-	return nil
 }
 
 // writeManifest writes a JSON manifest file to the study version directory.
@@ -1461,11 +2917,12 @@ func (e *JobExecutor) writeManifest(job model.SampleJob, items []model.SampleJob
 	e.logger.WithField("job_id", job.ID).Trace("entering writeManifest")
 	defer e.logger.Trace("returning from writeManifest")
 
-	// Fetch the study for the full config snapshot
-	study, err := e.store.GetStudy(job.StudyID)
+	// Fetch the job's study snapshot for the full config
+	params, err := e.jobParameters(job)
 	if err != nil {
 		return fmt.Errorf("fetching study for manifest: %w", err)
 	}
+	study := params.Study
 
 	// Extract unique checkpoint filenames from the job items (preserving order)
 	seen := make(map[string]struct{})
@@ -1527,12 +2984,13 @@ func (e *JobExecutor) ensureDir(path string) error {
 // failItem marks an item as failed with an error message (called without holding mutex).
 // It performs blocking I/O and then re-acquires the lock to clear active state.
 func (e *JobExecutor) failItem(itemID string, errorMsg string) {
-	e.failItemWithDetails(itemID, errorMsg, "", "", "")
+	e.failItemWithDetails(itemID, errorMsg, "", "", "", "")
 }
 
 // failItemWithDetails marks an item as failed with structured error details
-// from ComfyUI execution_error events (called without holding mutex).
-func (e *JobExecutor) failItemWithDetails(itemID string, errorMsg string, exceptionType string, nodeType string, traceback string) {
+// from ComfyUI execution_error events and the captured ComfyUI log (called
+// without holding mutex).
+func (e *JobExecutor) failItemWithDetails(itemID string, errorMsg string, exceptionType string, nodeType string, traceback string, comfyUILog string) {
 	e.logger.WithFields(logrus.Fields{
 		"item_id": itemID,
 		"error":   errorMsg,
@@ -1543,7 +3001,7 @@ func (e *JobExecutor) failItemWithDetails(itemID string, errorMsg string, except
 	jobID := e.activeJobID
 	e.mu.Unlock()
 
-	items, err := e.store.ListSampleJobItems(jobID)
+	items, err := e.store.ListSampleJobItems(e.ctx, jobID)
 	if err != nil {
 		e.logger.WithError(err).Error("failed to list job items")
 		return
@@ -1556,8 +3014,10 @@ func (e *JobExecutor) failItemWithDetails(itemID string, errorMsg string, except
 			items[i].ExceptionType = exceptionType
 			items[i].NodeType = nodeType
 			items[i].Traceback = traceback
+			items[i].ComfyUILog = comfyUILog
+			e.recordItemCompletion(&items[i])
 			items[i].UpdatedAt = time.Now().UTC()
-			if err := e.store.UpdateSampleJobItem(items[i]); err != nil {
+			if err := e.store.UpdateSampleJobItem(e.ctx, &items[i]); err != nil {
 				if err == sql.ErrNoRows {
 					// Item was deleted between list and update (job cancelled during E2E teardown).
 					// This is a benign race — log at warn, not error.
@@ -1578,14 +3038,117 @@ func (e *JobExecutor) failItemWithDetails(itemID string, errorMsg string, except
 
 	// Clear active state so we can move to the next item
 	e.mu.Lock()
-	e.activeItemID = ""
-	e.activePromptID = ""
+	e.releaseItem(itemID)
 	e.mu.Unlock()
 }
 
-// updateJobProgress updates the completed items count for a job.
+// saveItemCompletion marks item completed with its image at outputPath. The
+// item was read before its image was downloaded and saved, so when the item
+// was updated in the meantime (e.g. its prompt ID was saved late, or the
+// watchdog touched it) it is read again and the completion re-applied, rather
+// than leaving an item whose image is on disk running.
+func (e *JobExecutor) saveItemCompletion(item model.SampleJobItem, outputPath string) error {
+	reread := false
+	return retryOnConflict(func() error {
+		if reread {
+			items, err := e.store.ListSampleJobItems(e.ctx, item.JobID)
+			if err != nil {
+				return err
+			}
+			idx := slices.IndexFunc(items, func(i model.SampleJobItem) bool { return i.ID == item.ID })
+			if idx < 0 {
+				return sql.ErrNoRows
+			}
+			scores := item.Scores
+			item = items[idx]
+			item.Scores = scores
+			e.logger.WithField("item_id", item.ID).Debug("item updated while its image was saved, re-applying completion")
+		}
+		reread = true
+		item.Status = model.SampleJobItemStatusCompleted
+		item.OutputPath = outputPath
+		e.recordItemCompletion(&item)
+		item.UpdatedAt = time.Now().UTC()
+		return e.store.UpdateSampleJobItem(e.ctx, &item)
+	})
+}
+
+// saveItemPromptID records the prompt ID of a submitted item, together with
+// its staged checkpoint. If the item was updated meanwhile, e.g. it already
+// finished, it is re-read and the prompt ID is applied to the stored row,
+// keeping the newer status; an item that was reset to pending is left as is,
+// since it is submitted again.
+func (e *JobExecutor) saveItemPromptID(item model.SampleJobItem, promptID string) error {
+	reread := false
+	return retryOnConflict(func() error {
+		if reread {
+			items, err := e.store.ListSampleJobItems(e.ctx, item.JobID)
+			if err != nil {
+				return err
+			}
+			idx := slices.IndexFunc(items, func(i model.SampleJobItem) bool { return i.ID == item.ID })
+			if idx < 0 {
+				return sql.ErrNoRows
+			}
+			if items[idx].Status == model.SampleJobItemStatusPending {
+				e.logger.WithField("item_id", item.ID).Debug("item was reset before its prompt ID was saved, keeping its stored state")
+				return nil
+			}
+			stagedCheckpoint := item.StagedCheckpoint
+			item = items[idx]
+			item.StagedCheckpoint = stagedCheckpoint
+			e.logger.WithField("item_id", item.ID).Debug("item updated before its prompt ID was saved, re-applying prompt ID")
+		}
+		reread = true
+		item.ComfyUIPromptID = promptID
+		item.UpdatedAt = time.Now().UTC()
+		return e.store.UpdateSampleJobItem(e.ctx, &item)
+	})
+}
+
+// recordItemCompletion sets CompletedAt on an item that reached a terminal state
+// and derives DurationMs from StartedAt. Items that never started (e.g. failed
+// before submission) get a CompletedAt but no duration.
+func (e *JobExecutor) recordItemCompletion(item *model.SampleJobItem) {
+	completedAt := e.timeNow().UTC()
+	item.CompletedAt = &completedAt
+	item.DurationMs = nil
+	if item.StartedAt != nil {
+		durationMs := completedAt.Sub(*item.StartedAt).Milliseconds()
+		if durationMs < 0 {
+			durationMs = 0
+		}
+		item.DurationMs = &durationMs
+	}
+}
+
+// updateJobProgress updates the completed items count for a job, re-reading
+// the job when it was updated concurrently (e.g. stopped).
 func (e *JobExecutor) updateJobProgress(jobID string) {
-	job, err := e.store.GetSampleJob(jobID)
+	var (
+		job       model.SampleJob
+		completed int
+	)
+	err := retryOnConflict(func() error {
+		var err error
+		job, completed, err = e.saveJobProgress(jobID)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"job_id":          jobID,
+		"completed_items": completed,
+		"total_items":     job.TotalItems,
+	}).Debug("job progress updated")
+}
+
+// saveJobProgress counts the completed items of a job and saves the count on
+// the job, returning the job and the count.
+func (e *JobExecutor) saveJobProgress(jobID string) (model.SampleJob, int, error) {
+	job, err := e.store.GetSampleJob(e.ctx, jobID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Job was deleted between item completion and progress update (job cancelled during E2E teardown).
@@ -1594,13 +3157,13 @@ func (e *JobExecutor) updateJobProgress(jobID string) {
 		} else {
 			e.logger.WithError(err).Error("failed to get job for progress update")
 		}
-		return
+		return job, 0, err
 	}
 
-	items, err := e.store.ListSampleJobItems(jobID)
+	items, err := e.store.ListSampleJobItems(e.ctx, jobID)
 	if err != nil {
 		e.logger.WithError(err).Error("failed to list items for progress update")
-		return
+		return job, 0, err
 	}
 
 	completed := 0
@@ -1612,21 +3175,20 @@ func (e *JobExecutor) updateJobProgress(jobID string) {
 
 	job.CompletedItems = completed
 	job.UpdatedAt = time.Now().UTC()
-	if err := e.store.UpdateSampleJob(job); err != nil {
-		if err == sql.ErrNoRows {
+	if err := e.store.UpdateSampleJob(e.ctx, &job); err != nil {
+		switch {
+		case err == sql.ErrNoRows:
 			// Job was deleted between get and update (job cancelled during E2E teardown).
 			// This is a benign race — log at warn, not error.
 			e.logger.WithField("job_id", jobID).Warn("job row not found during progress update write (job likely cancelled)")
-		} else {
+		case errors.Is(err, store.ErrVersionConflict):
+			e.logger.WithField("job_id", jobID).Debug("job was updated concurrently during progress update")
+		default:
 			e.logger.WithError(err).Error("failed to update job progress")
 		}
+		return job, completed, err
 	}
-
-	e.logger.WithFields(logrus.Fields{
-		"job_id":          jobID,
-		"completed_items": completed,
-		"total_items":     job.TotalItems,
-	}).Debug("job progress updated")
+	return job, completed, nil
 }
 
 // completeJob marks a job as completed (or completed_with_errors) when all items are done.
@@ -1636,7 +3198,7 @@ func (e *JobExecutor) updateJobProgress(jobID string) {
 func (e *JobExecutor) completeJob(jobID string) {
 	e.logger.WithField("job_id", jobID).Info("completing job")
 
-	job, err := e.store.GetSampleJob(jobID)
+	job, err := e.store.GetSampleJob(e.ctx, jobID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Job was deleted between all-items-done detection and completion (job cancelled during E2E teardown).
@@ -1659,11 +3221,11 @@ func (e *JobExecutor) completeJob(jobID string) {
 	// Check if all items completed successfully to determine terminal status.
 	// Any non-completed item (failed, skipped, stuck in running) means the job
 	// should be marked as completed_with_errors.
-	items, err := e.store.ListSampleJobItems(jobID)
+	status := model.SampleJobStatusCompleted
+	items, err := e.store.ListSampleJobItems(e.ctx, jobID)
 	if err != nil {
 		e.logger.WithError(err).Error("failed to list items for completion check")
 		// Fall back to completed status
-		job.Status = model.SampleJobStatusCompleted
 	} else {
 		allCompleted := true
 		for _, item := range items {
@@ -1673,19 +3235,21 @@ func (e *JobExecutor) completeJob(jobID string) {
 			}
 		}
 		if !allCompleted {
-			job.Status = model.SampleJobStatusCompletedWithErrors
+			status = model.SampleJobStatusCompletedWithErrors
 			e.logger.WithField("job_id", jobID).Info("job has non-completed items, transitioning to completed_with_errors")
-		} else {
-			job.Status = model.SampleJobStatusCompleted
 		}
 	}
 
-	job.UpdatedAt = time.Now().UTC()
-	if err := e.store.UpdateSampleJob(job); err != nil {
-		if err == sql.ErrNoRows {
-			// Job was deleted between get and update during completion (job cancelled during E2E teardown).
-			// Clear active state and return without error.
-			e.logger.WithField("job_id", jobID).Warn("job row not found during completion update (job likely cancelled)")
+	job, err = e.states.TransitionWithRetry(e.ctx, job, status)
+	if err != nil {
+		if err == sql.ErrNoRows || errors.Is(err, store.ErrSampleJobStatusChanged) {
+			// Job was deleted, or stopped, between get and update during completion
+			// (job cancelled during E2E teardown). Clear active state and return
+			// without error.
+			e.logger.WithFields(logrus.Fields{
+				"job_id": jobID,
+				"error":  err.Error(),
+			}).Warn("job row not found or no longer running during completion update (job likely cancelled)")
 			e.mu.Lock()
 			e.activeJobID = ""
 			e.activeItemID = ""
@@ -1708,9 +3272,15 @@ func (e *JobExecutor) completeJob(jobID string) {
 		}).Warn("failed to write manifest, job completed but manifest missing")
 	}
 
+	e.unstageCheckpoints(jobID, items)
+
 	// Broadcast completion event
 	e.broadcastJobProgress(jobID)
 
+	if e.notifier != nil {
+		e.notifier.JobFinished(job)
+	}
+
 	// Clear active state, completeness data, and timing data for the finished job
 	e.mu.Lock()
 	e.activeJobID = ""
@@ -1727,11 +3297,65 @@ func (e *JobExecutor) completeJob(jobID string) {
 	}).Info("job completed")
 }
 
+// unstageCheckpoints removes the staged checkpoints the items of a finished
+// job were sampled from. A checkpoint that an unfinished job still has items
+// to sample is kept for that job.
+func (e *JobExecutor) unstageCheckpoints(jobID string, items []model.SampleJobItem) {
+	if e.stager == nil {
+		return
+	}
+	staged := make(map[string]string) // checkpoint filename -> staged path
+	for _, item := range items {
+		if item.StagedCheckpoint != "" {
+			staged[item.CheckpointFilename] = item.StagedCheckpoint
+		}
+	}
+	if len(staged) == 0 {
+		return
+	}
+
+	jobs, err := e.store.ListSampleJobs(e.ctx)
+	if err != nil {
+		e.logger.WithError(err).Warn("failed to list jobs, leaving staged checkpoints in place")
+		return
+	}
+	for _, other := range jobs {
+		switch other.Status {
+		case model.SampleJobStatusCompleted, model.SampleJobStatusCompletedWithErrors, model.SampleJobStatusFailed:
+			continue
+		}
+		if other.ID == jobID {
+			continue
+		}
+		otherItems, err := e.store.ListSampleJobItems(e.ctx, other.ID)
+		if err != nil {
+			e.logger.WithError(err).Warn("failed to list job items, leaving staged checkpoints in place")
+			return
+		}
+		for _, item := range otherItems {
+			if item.Status != model.SampleJobItemStatusCompleted && item.Status != model.SampleJobItemStatusSkipped {
+				delete(staged, item.CheckpointFilename)
+			}
+		}
+	}
+
+	for filename, stagedPath := range staged {
+		if err := e.stager.Unstage(stagedPath); err != nil {
+			e.logger.WithFields(logrus.Fields{
+				"job_id":              jobID,
+				"checkpoint_filename": filename,
+				"error":               err.Error(),
+			}).Warn("failed to remove staged checkpoint")
+		}
+	}
+}
+
 // verifyCheckpointCompleteness validates that all expected images exist on disk for a completed checkpoint.
-// It compares expected filenames (derived from the completed items) against actual PNG files in the checkpoint's
+// It compares expected filenames (derived from the completed items) against actual image files in the checkpoint's
 // sample directory. Results are stored in e.checkpointCompleteness and reported as warnings (not failures).
-// studyOutputDir is the versioned study output directory (e.g. "My Study/v1").
-func (e *JobExecutor) verifyCheckpointCompleteness(jobID string, studyOutputDir string, checkpoint string, items []model.SampleJobItem) {
+// studyOutputDir is the versioned study output directory (e.g. "My Study/v1") and
+// format the job's output format, which determines the expected file extension.
+func (e *JobExecutor) verifyCheckpointCompleteness(jobID string, studyOutputDir string, format model.OutputFormat, checkpoint string, items []model.SampleJobItem) {
 	e.logger.WithFields(logrus.Fields{
 		"job_id":     jobID,
 		"checkpoint": checkpoint,
@@ -1742,7 +3366,7 @@ func (e *JobExecutor) verifyCheckpointCompleteness(jobID string, studyOutputDir
 	var expectedFiles []string
 	for _, item := range items {
 		if item.CheckpointFilename == checkpoint && item.Status == model.SampleJobItemStatusCompleted {
-			filename := e.generateOutputFilename(item)
+			filename := e.outputRelPath(item, format)
 			expectedFiles = append(expectedFiles, filename)
 		}
 	}
@@ -1773,30 +3397,32 @@ func (e *JobExecutor) verifyCheckpointCompleteness(jobID string, studyOutputDir
 		return
 	}
 
-	actualFiles, err := e.fsReader.ListPNGFiles(checkpointDir)
-	if err != nil {
-		e.logger.WithFields(logrus.Fields{
-			"job_id":         jobID,
-			"checkpoint":     checkpoint,
-			"checkpoint_dir": checkpointDir,
-			"error":          err.Error(),
-		}).Warn("failed to list PNG files during completeness check")
-
-		e.mu.Lock()
-		e.checkpointCompleteness[checkpoint] = model.CheckpointCompletenessInfo{
-			Checkpoint: checkpoint,
-			Expected:   expected,
-			Verified:   0,
-			Missing:    expected,
+	// Build a set of actual files on disk for O(1) lookup. Output layouts
+	// save images in subdirectories, so each directory an expected file is
+	// in is listed.
+	actualSet := make(map[string]struct{}, len(expectedFiles))
+	listed := make(map[string]bool)
+	for _, expectedFile := range expectedFiles {
+		dir := path.Dir(expectedFile)
+		if listed[dir] {
+			continue
+		}
+		listed[dir] = true
+		actualFiles, err := e.fsReader.ListImageFiles(filepath.Join(checkpointDir, filepath.FromSlash(dir)))
+		if err != nil {
+			// The directory's files are counted as missing.
+			e.logger.WithFields(logrus.Fields{
+				"job_id":         jobID,
+				"checkpoint":     checkpoint,
+				"checkpoint_dir": checkpointDir,
+				"directory":      dir,
+				"error":          err.Error(),
+			}).Warn("failed to list image files during completeness check")
+			continue
+		}
+		for _, f := range actualFiles {
+			actualSet[path.Join(dir, f)] = struct{}{}
 		}
-		e.mu.Unlock()
-		return
-	}
-
-	// Build a set of actual files on disk for O(1) lookup
-	actualSet := make(map[string]struct{}, len(actualFiles))
-	for _, f := range actualFiles {
-		actualSet[f] = struct{}{}
 	}
 
 	// Count how many expected files are present on disk
@@ -1874,7 +3500,7 @@ func (e *JobExecutor) currentSampleParams(items []model.SampleJobItem) *model.Cu
 // as a structured job_progress event. When a checkpoint batch completes,
 // it also runs a completeness check to verify expected images exist on disk.
 func (e *JobExecutor) broadcastJobProgress(jobID string) {
-	job, err := e.store.GetSampleJob(jobID)
+	job, err := e.store.GetSampleJob(e.ctx, jobID)
 	if err != nil {
 		// sql.ErrNoRows means the job was deleted between the trigger and this
 		// broadcast (e.g. during E2E teardown). This is a benign race — log at
@@ -1887,7 +3513,7 @@ func (e *JobExecutor) broadcastJobProgress(jobID string) {
 		return
 	}
 
-	items, err := e.store.ListSampleJobItems(jobID)
+	items, err := e.store.ListSampleJobItems(e.ctx, jobID)
 	if err != nil {
 		e.logger.WithError(err).Error("failed to list items for progress broadcast")
 		return
@@ -1911,6 +3537,7 @@ func (e *JobExecutor) broadcastJobProgress(jobID string) {
 		errors    map[string]errorDetailInfo
 	}
 	var completed, failed, pending int
+	var skipped model.ItemStatusCounts
 	checkpointStatsMap := make(map[string]*cpStats)
 
 	for _, item := range items {
@@ -1927,6 +3554,9 @@ func (e *JobExecutor) broadcastJobProgress(jobID string) {
 		case model.SampleJobItemStatusFailed, model.SampleJobItemStatusSkipped:
 			failed++
 			stats.failed++
+			if item.Status == model.SampleJobItemStatusSkipped {
+				skipped.AddSkipped(item.SkipReason, 1)
+			}
 			if item.ErrorMessage != "" {
 				stats.errors[item.ErrorMessage] = errorDetailInfo{
 					exceptionType: item.ExceptionType,
@@ -1958,7 +3588,7 @@ func (e *JobExecutor) broadcastJobProgress(jobID string) {
 			e.mu.Unlock()
 
 			if !alreadyChecked {
-				e.verifyCheckpointCompleteness(jobID, studyOutputDir, checkpoint, items)
+				e.verifyCheckpointCompleteness(jobID, studyOutputDir, job.OutputFormat, checkpoint, items)
 			}
 		} else if currentCheckpoint == "" && stats.completed+stats.failed < stats.total {
 			currentCheckpoint = checkpoint
@@ -2031,8 +3661,10 @@ func (e *JobExecutor) broadcastJobProgress(jobID string) {
 	currentParams := e.currentSampleParams(items)
 
 	event := model.FSEvent{
-		Type: model.EventJobProgress,
-		Path: fmt.Sprintf("job_progress/%s", jobID),
+		Type:        model.EventJobProgress,
+		Path:        fmt.Sprintf("job_progress/%s", jobID),
+		JobID:       jobID,
+		TrainingRun: job.TrainingRunName,
 		JobProgressData: &model.JobProgressEventData{
 			JobID:                     jobID,
 			Status:                    string(job.Status),
@@ -2040,6 +3672,7 @@ func (e *JobExecutor) broadcastJobProgress(jobID string) {
 			CompletedItems:            completed,
 			FailedItems:               failed,
 			PendingItems:              pending,
+			SkippedItems:              skipped.Skipped,
 			CheckpointsCompleted:      checkpointsCompleted,
 			TotalCheckpoints:          totalCheckpoints,
 			CurrentCheckpoint:         currentCheckpoint,
@@ -2062,7 +3695,8 @@ func (e *JobExecutor) broadcastJobProgress(jobID string) {
 }
 
 // RequestStop requests the executor to stop the given job immediately.
-// If there is an active ComfyUI prompt, it is canceled.
+// If there is an active ComfyUI prompt, it is canceled, as are the prompts
+// of queued items.
 // The executor owns the DB status update to stopped (mirroring how completeJob owns
 // the completed transition), so there is no window where the DB and executor state
 // diverge. After this call the executor state is cleared so that pending jobs can
@@ -2077,10 +3711,24 @@ func (e *JobExecutor) RequestStop(jobID string) error {
 		return fmt.Errorf("job %s is not currently running", jobID)
 	}
 
-	// Capture prompt ID under lock, then release before blocking call
+	// Capture prompt IDs under lock, then release before blocking call
 	promptID := e.activePromptID
+	queuedPromptIDs := e.dropQueued()
+	prewarmPromptID := e.prewarmPromptID
+	e.prewarmPromptID = ""
 	e.mu.Unlock()
 
+	// Remove the prompts of queued items first, so that none of them starts
+	// once the active prompt is canceled
+	e.cancelPrompts(queuedPromptIDs)
+
+	// Remove a queued pre-warm of the job's next checkpoint (outside the lock)
+	if prewarmPromptID != "" {
+		if err := e.comfyuiClient.CancelPrompt(e.ctx, prewarmPromptID); err != nil {
+			e.logger.WithError(err).Warn("failed to cancel ComfyUI pre-warm prompt")
+		}
+	}
+
 	// Cancel the active ComfyUI prompt if there is one (outside the lock)
 	if promptID != "" {
 		e.logger.WithField("prompt_id", promptID).Info("canceling active ComfyUI prompt")
@@ -2093,7 +3741,7 @@ func (e *JobExecutor) RequestStop(jobID string) error {
 	// Update the DB status to stopped before clearing executor state.
 	// This ensures the DB and executor state are never out of sync: the DB is updated
 	// to stopped atomically with the executor clearing its active tracking state.
-	job, err := e.store.GetSampleJob(jobID)
+	job, err := e.store.GetSampleJob(e.ctx, jobID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			e.logger.WithField("job_id", jobID).Warn("job row not found during stop (job likely deleted)")
@@ -2105,9 +3753,7 @@ func (e *JobExecutor) RequestStop(jobID string) error {
 		}
 		// Even if the DB update fails, clear executor state so we don't stay stuck.
 	} else {
-		job.Status = model.SampleJobStatusStopped
-		job.UpdatedAt = time.Now().UTC()
-		if err := e.store.UpdateSampleJob(job); err != nil {
+		if job, err = e.states.TransitionWithRetry(e.ctx, job, model.SampleJobStatusStopped); err != nil {
 			if err == sql.ErrNoRows {
 				e.logger.WithField("job_id", jobID).Warn("job row not found during stop update (job likely deleted)")
 			} else {
@@ -2119,6 +3765,9 @@ func (e *JobExecutor) RequestStop(jobID string) error {
 			// Even if the DB update fails, clear executor state so we don't stay stuck.
 		} else {
 			e.logger.WithField("job_id", jobID).Info("job status updated to stopped in DB")
+			if e.notifier != nil {
+				e.notifier.JobFinished(job)
+			}
 		}
 	}
 
@@ -2163,6 +3812,36 @@ func (e *JobExecutor) RequestResume(jobID string) error {
 	return nil
 }
 
+// executorTickStaleAfter is how long the processing loop may go without
+// ticking before the executor is reported unhealthy. The loop ticks every
+// second but blocks while an item is being submitted to ComfyUI.
+const executorTickStaleAfter = time.Minute
+
+// Healthy reports whether the executor is making progress: it is connected to
+// ComfyUI, holds the executor gate (if any), its processing loop is running,
+// and the sample being generated (if any) started less than stallTimeout ago.
+// A zero stallTimeout disables the stall check. When unhealthy, the returned
+// string explains why.
+func (e *JobExecutor) Healthy(stallTimeout time.Duration) (bool, string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.timeNow()
+	switch {
+	case !e.started:
+		return false, "executor not started"
+	case !e.connected:
+		return false, "not connected to ComfyUI"
+	case e.gate != nil && !e.gate.Held():
+		return false, "executor lease not held"
+	case e.lastTick.IsZero() || now.Sub(e.lastTick) > executorTickStaleAfter:
+		return false, "processing loop not running"
+	case stallTimeout > 0 && e.activeItemID != "" && !e.sampleStartTime.IsZero() && now.Sub(e.sampleStartTime) > stallTimeout:
+		return false, fmt.Sprintf("sample running for %s", now.Sub(e.sampleStartTime).Round(time.Second))
+	}
+	return true, ""
+}
+
 // IsConnected returns whether the executor is currently connected to ComfyUI.
 func (e *JobExecutor) IsConnected() bool {
 	e.mu.Lock()
@@ -2199,6 +3878,45 @@ func toInt(v interface{}) (int, bool) {
 	}
 }
 
+// captureFailureLog returns the relevant lines of ComfyUI's log, or an
+// empty string if no failure log reader is set or the log cannot be read.
+// Called without holding mutex.
+func (e *JobExecutor) captureFailureLog() string {
+	if e.failureLog == nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(e.ctx, failureLogTimeout)
+	defer cancel()
+	log, err := e.failureLog.RecentLog(ctx)
+	if err != nil {
+		e.logger.WithError(err).Warn("failed to read ComfyUI log for failed item")
+		return ""
+	}
+	return relevantLogLines(log, e.failureLogLines)
+}
+
+// relevantLogLines returns the last maxLines lines of log, starting instead
+// at the last ComfyUI exception report within them, which holds the
+// traceback of the failing node.
+func relevantLogLines(log string, maxLines int) string {
+	log = strings.TrimRight(log, "\n")
+	if log == "" {
+		return ""
+	}
+	lines := strings.Split(log, "\n")
+	start := len(lines) - maxLines
+	if start < 0 {
+		start = 0
+	}
+	for i := len(lines) - 1; i > start; i-- {
+		if strings.Contains(lines[i], comfyUIExceptionMarker) {
+			start = i
+			break
+		}
+	}
+	return strings.Join(lines[start:], "\n")
+}
+
 // composeExecutionErrorMessage builds a human-readable error summary from ComfyUI
 // execution_error event fields. Format: "[ExceptionType] NodeType: message"
 func composeExecutionErrorMessage(exceptionType, nodeType, exceptionMessage string) string {
@@ -19,7 +19,7 @@ type FileSystemWriter interface {
 
 // FileSystemReader defines the interface for reading filesystem state (used for completeness checks).
 type FileSystemReader interface {
-	ListPNGFiles(dir string) ([]string, error)
+	ListImageFiles(dir string) ([]string, error)
 	DirectoryExists(path string) bool
 }
 
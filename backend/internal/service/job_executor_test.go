@@ -1,14 +1,20 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"strings"
 	"time"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/testutil"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -18,10 +24,50 @@ import (
 
 // Mock implementations
 
+// fakeExecutorGate is a test double for ExecutorGate.
+type fakeExecutorGate struct {
+	held bool
+}
+
+func (g *fakeExecutorGate) Held() bool {
+	return g.held
+}
+
+// fakeComfyUILogReader is a test double for ComfyUILogReader.
+type fakeComfyUILogReader struct {
+	log string
+	err error
+}
+
+func (r *fakeComfyUILogReader) RecentLog(ctx context.Context) (string, error) {
+	return r.log, r.err
+}
+
+// fakeCheckpointAppender is a test double for CheckpointAppender. Each call
+// runs onAppend, if set, and returns its result.
+type fakeCheckpointAppender struct {
+	calls    []string
+	onAppend func(jobID string) int
+	err      error
+}
+
+func (a *fakeCheckpointAppender) AppendNewCheckpoints(ctx context.Context, jobID string) (int, error) {
+	a.calls = append(a.calls, jobID)
+	if a.err != nil {
+		return 0, a.err
+	}
+	if a.onAppend == nil {
+		return 0, nil
+	}
+	return a.onAppend(jobID), nil
+}
+
 type mockJobExecutorStore struct {
 	jobs             map[string]model.SampleJob
 	items            map[string][]model.SampleJobItem
 	studies          map[string]model.Study
+	versions         map[string][]model.StudyVersion
+	params           map[string]model.SampleJobParameters
 	updateJobError   error
 	updateItemError  error
 	// onUpdateJob is an optional callback invoked during UpdateSampleJob (before the write).
@@ -33,11 +79,14 @@ func newMockJobExecutorStore() *mockJobExecutorStore {
 	return &mockJobExecutorStore{
 		jobs:    make(map[string]model.SampleJob),
 		items:   make(map[string][]model.SampleJobItem),
-		studies: make(map[string]model.Study),
+		// Jobs built without a StudyID snapshot this empty study when they
+		// lock their parameters.
+		studies: map[string]model.Study{"": {}},
+		params:  make(map[string]model.SampleJobParameters),
 	}
 }
 
-func (m *mockJobExecutorStore) GetSampleJob(id string) (model.SampleJob, error) {
+func (m *mockJobExecutorStore) GetSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
 	job, ok := m.jobs[id]
 	if !ok {
 		return model.SampleJob{}, sql.ErrNoRows
@@ -45,33 +94,48 @@ func (m *mockJobExecutorStore) GetSampleJob(id string) (model.SampleJob, error)
 	return job, nil
 }
 
-func (m *mockJobExecutorStore) UpdateSampleJob(j model.SampleJob) error {
+func (m *mockJobExecutorStore) UpdateSampleJob(ctx context.Context, j *model.SampleJob) error {
 	if m.onUpdateJob != nil {
-		m.onUpdateJob(j)
+		m.onUpdateJob(*j)
 	}
 	if m.updateJobError != nil {
 		return m.updateJobError
 	}
-	m.jobs[j.ID] = j
+	j.Version++
+	m.jobs[j.ID] = *j
 	return nil
 }
 
-func (m *mockJobExecutorStore) ListSampleJobItems(jobID string) ([]model.SampleJobItem, error) {
+func (m *mockJobExecutorStore) TransitionSampleJob(ctx context.Context, j *model.SampleJob, from model.SampleJobStatus) error {
+	if current, ok := m.jobs[j.ID]; ok && current.Status != from {
+		return fmt.Errorf("%w: job %s is %s", store.ErrSampleJobStatusChanged, j.ID, current.Status)
+	}
+	return m.UpdateSampleJob(ctx, j)
+}
+
+func (m *mockJobExecutorStore) ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error) {
 	items, ok := m.items[jobID]
 	if !ok {
 		return []model.SampleJobItem{}, nil
 	}
-	return items, nil
+	// Return a copy, like the real store, so callers cannot change the stored
+	// items without saving them.
+	return append([]model.SampleJobItem(nil), items...), nil
 }
 
-func (m *mockJobExecutorStore) UpdateSampleJobItem(i model.SampleJobItem) error {
+func (m *mockJobExecutorStore) UpdateSampleJobItem(ctx context.Context, i *model.SampleJobItem) error {
 	if m.updateItemError != nil {
 		return m.updateItemError
 	}
 	items := m.items[i.JobID]
 	for idx := range items {
 		if items[idx].ID == i.ID {
-			items[idx] = i
+			// Enforce optimistic versions like the real store.
+			if items[idx].Version != i.Version {
+				return fmt.Errorf("%w: item %s", store.ErrVersionConflict, i.ID)
+			}
+			i.Version++
+			items[idx] = *i
 			m.items[i.JobID] = items
 			return nil
 		}
@@ -79,7 +143,7 @@ func (m *mockJobExecutorStore) UpdateSampleJobItem(i model.SampleJobItem) error
 	return errors.New("item not found")
 }
 
-func (m *mockJobExecutorStore) ListSampleJobs() ([]model.SampleJob, error) {
+func (m *mockJobExecutorStore) ListSampleJobs(ctx context.Context) ([]model.SampleJob, error) {
 	var result []model.SampleJob
 	for _, j := range m.jobs {
 		result = append(result, j)
@@ -87,7 +151,7 @@ func (m *mockJobExecutorStore) ListSampleJobs() ([]model.SampleJob, error) {
 	return result, nil
 }
 
-func (m *mockJobExecutorStore) GetStudy(id string) (model.Study, error) {
+func (m *mockJobExecutorStore) GetStudy(ctx context.Context, id string) (model.Study, error) {
 	s, ok := m.studies[id]
 	if !ok {
 		return model.Study{}, errors.New("study not found")
@@ -95,22 +159,58 @@ func (m *mockJobExecutorStore) GetStudy(id string) (model.Study, error) {
 	return s, nil
 }
 
+func (m *mockJobExecutorStore) ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error) {
+	return m.versions[studyID], nil
+}
+
+func (m *mockJobExecutorStore) GetSampleJobParameters(ctx context.Context, jobID string) (model.SampleJobParameters, error) {
+	p, ok := m.params[jobID]
+	if !ok {
+		return model.SampleJobParameters{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (m *mockJobExecutorStore) CreateSampleJobParameters(ctx context.Context, p model.SampleJobParameters) error {
+	if _, ok := m.params[p.JobID]; !ok {
+		m.params[p.JobID] = p
+	}
+	return nil
+}
+
 type mockComfyUIClient struct {
 	submitErr         error
 	promptResponse    *model.PromptResponse
 	lastSubmittedReq  *model.PromptRequest
+	submittedReqs     []model.PromptRequest
 	historyResponse   model.HistoryResponse
 	historyErr        error
 	downloadData      []byte
 	downloadErr       error
 	cancelErr         error
+	canceledPrompts   []string
+	queue             model.ComfyUIQueue
+	queueErr          error
+	interruptedPrompts []string
+	promptIDs          []string // returned in turn by SubmitPrompt before promptResponse
+	onDownload         func()   // called by DownloadImage, e.g. to update an item concurrently
+	onSubmit           func()   // called by SubmitPrompt, e.g. to update an item concurrently
 }
 
 func (m *mockComfyUIClient) SubmitPrompt(ctx context.Context, req model.PromptRequest) (*model.PromptResponse, error) {
 	m.lastSubmittedReq = &req
+	m.submittedReqs = append(m.submittedReqs, req)
+	if m.onSubmit != nil {
+		m.onSubmit()
+	}
 	if m.submitErr != nil {
 		return nil, m.submitErr
 	}
+	if len(m.promptIDs) > 0 {
+		promptID := m.promptIDs[0]
+		m.promptIDs = m.promptIDs[1:]
+		return &model.PromptResponse{PromptID: promptID}, nil
+	}
 	return m.promptResponse, nil
 }
 
@@ -122,6 +222,9 @@ func (m *mockComfyUIClient) GetHistory(ctx context.Context, promptID string) (mo
 }
 
 func (m *mockComfyUIClient) DownloadImage(ctx context.Context, filename string, subfolder string, folderType string) ([]byte, error) {
+	if m.onDownload != nil {
+		m.onDownload()
+	}
 	if m.downloadErr != nil {
 		return nil, m.downloadErr
 	}
@@ -129,12 +232,26 @@ func (m *mockComfyUIClient) DownloadImage(ctx context.Context, filename string,
 }
 
 func (m *mockComfyUIClient) CancelPrompt(ctx context.Context, promptID string) error {
+	m.canceledPrompts = append(m.canceledPrompts, promptID)
 	if m.cancelErr != nil {
 		return m.cancelErr
 	}
 	return nil
 }
 
+func (m *mockComfyUIClient) InterruptPrompt(ctx context.Context, promptID string) error {
+	m.interruptedPrompts = append(m.interruptedPrompts, promptID)
+	return nil
+}
+
+func (m *mockComfyUIClient) GetQueueStatus(ctx context.Context) (*model.ComfyUIQueue, error) {
+	if m.queueErr != nil {
+		return nil, m.queueErr
+	}
+	queue := m.queue
+	return &queue, nil
+}
+
 type mockComfyUIWS struct {
 	handlers            []model.ComfyUIEventHandler
 	disconnectHandler   func()
@@ -185,16 +302,31 @@ func (m *mockComfyUIWS) SimulateDisconnect() {
 
 type mockWorkflowLoader struct {
 	workflow model.WorkflowTemplate
-	err      error
+	// named are returned instead of workflow for their names.
+	named map[string]model.WorkflowTemplate
+	err   error
 }
 
 func (m *mockWorkflowLoader) Get(ctx context.Context, name string) (model.WorkflowTemplate, error) {
 	if m.err != nil {
 		return model.WorkflowTemplate{}, m.err
 	}
+	if w, ok := m.named[name]; ok {
+		return w, nil
+	}
 	return m.workflow, nil
 }
 
+// mockPrewarmVRAMChecker reports a fixed answer for pre-warm VRAM checks.
+type mockPrewarmVRAMChecker struct {
+	room bool
+	err  error
+}
+
+func (m *mockPrewarmVRAMChecker) HasRoomForModel(workflowName string) (bool, error) {
+	return m.room, m.err
+}
+
 type mockEventHub struct {
 	events []model.FSEvent
 }
@@ -264,7 +396,7 @@ func newMockFileSystemReader() *mockFileSystemReader {
 	}
 }
 
-func (m *mockFileSystemReader) ListPNGFiles(dir string) ([]string, error) {
+func (m *mockFileSystemReader) ListImageFiles(dir string) ([]string, error) {
 	if m.listErr != nil {
 		return nil, m.listErr
 	}
@@ -279,6 +411,36 @@ func (m *mockFileSystemReader) DirectoryExists(path string) bool {
 	return m.dirs[path]
 }
 
+// mockInputImages reads input images from memory and records uploads.
+type mockInputImages struct {
+	files     map[string][]byte
+	uploadErr error
+	uploads   map[string][]byte
+}
+
+func newMockInputImages() *mockInputImages {
+	return &mockInputImages{
+		files:   make(map[string][]byte),
+		uploads: make(map[string][]byte),
+	}
+}
+
+func (m *mockInputImages) ReadFile(path string) ([]byte, error) {
+	data, ok := m.files[path]
+	if !ok {
+		return nil, errors.New("open " + path + ": no such file or directory")
+	}
+	return data, nil
+}
+
+func (m *mockInputImages) UploadImage(ctx context.Context, filename string, data []byte) (string, error) {
+	if m.uploadErr != nil {
+		return "", m.uploadErr
+	}
+	m.uploads[filename] = data
+	return filename, nil
+}
+
 var _ = Describe("JobExecutor", func() {
 	var (
 		executor    *JobExecutor
@@ -492,6 +654,38 @@ var _ = Describe("JobExecutor", func() {
 			Expect(items[0].ComfyUIPromptID).To(Equal("test-prompt-id"))
 		})
 
+		It("saves the prompt ID of an item that finished while its prompt was submitted", func() {
+			job := model.SampleJob{
+				ID:           "job-late-prompt-id",
+				Status:       model.SampleJobStatusPending,
+				WorkflowName: "test-workflow.json",
+			}
+			item := model.SampleJobItem{
+				ID:               "item-late-prompt-id",
+				JobID:            job.ID,
+				Status:           model.SampleJobItemStatusPending,
+				ComfyUIModelPath: "models/test.safetensors",
+				SamplerName:      "euler",
+				Scheduler:        "normal",
+				Width:            512,
+				Height:           512,
+			}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			mockClient.onSubmit = func() {
+				mockClient.onSubmit = nil
+				stored := mockStore.items[job.ID][0]
+				stored.Status = model.SampleJobItemStatusCompleted
+				Expect(mockStore.UpdateSampleJobItem(context.Background(), &stored)).To(Succeed())
+			}
+
+			executor.processNextItem()
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusCompleted))
+			Expect(items[0].ComfyUIPromptID).To(Equal("test-prompt-id"))
+		})
+
 		// AC: BE: prompt submissions include the WebSocket client_id so ComfyUI routes
 		// prompt-specific events (executing, executed, execution_error) to the WS connection.
 		It("includes the WebSocket client_id in the ComfyUI prompt submission", func() {
@@ -524,6 +718,41 @@ var _ = Describe("JobExecutor", func() {
 			Expect(mockClient.lastSubmittedReq.ClientID).To(Equal("test-client-id"))
 		})
 
+		It("identifies the job, item and creating request in the prompt's extra_data", func() {
+			job := model.SampleJob{
+				ID:                 "job-extra-data",
+				Status:             model.SampleJobStatusPending,
+				WorkflowName:       "test-workflow.json",
+				CreatedByRequestID: "req-123",
+			}
+			item := model.SampleJobItem{
+				ID:               "item-extra-data-1",
+				JobID:            job.ID,
+				Status:           model.SampleJobItemStatusPending,
+				ComfyUIModelPath: "models/test.safetensors",
+				SamplerName:      "euler",
+				Scheduler:        "normal",
+				Seed:             1,
+				Steps:            1,
+				CFG:              1.0,
+				Width:            64,
+				Height:           64,
+			}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+
+			executor.processNextItem()
+
+			Expect(mockClient.lastSubmittedReq).NotTo(BeNil())
+			Expect(mockClient.lastSubmittedReq.ExtraData).To(Equal(map[string]interface{}{
+				"checkpoint_sampler": map[string]interface{}{
+					"sample_job_id":      "job-extra-data",
+					"sample_job_item_id": "item-extra-data-1",
+					"request_id":         "req-123",
+				},
+			}))
+		})
+
 		It("picks up a pending job after becoming connected", func() {
 			// AC: BE: If ComfyUI is unreachable when a job is created, the executor retries
 			// once ComfyUI becomes available
@@ -749,142 +978,659 @@ var _ = Describe("JobExecutor", func() {
 		})
 	})
 
-	Describe("substituteWorkflow", func() {
-		It("substitutes unet_loader with checkpoint path", func() {
-			job := model.SampleJob{
-				ID: "job-1",
-			}
-			item := model.SampleJobItem{
-				ComfyUIModelPath: "models/checkpoints/test.safetensors",
-			}
+	Describe("item timeout", func() {
+		var (
+			now  time.Time
+			job  model.SampleJob
+			item model.SampleJobItem
+		)
 
-			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
-			Expect(err).ToNot(HaveOccurred())
+		BeforeEach(func() {
+			now = time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+			executor.timeNow = func() time.Time { return now }
+			job = model.SampleJob{ID: "job-timeout", Status: model.SampleJobStatusRunning}
+			item = model.SampleJobItem{
+				ID:              "item-timeout",
+				JobID:           job.ID,
+				Status:          model.SampleJobItemStatusRunning,
+				ComfyUIPromptID: "prompt-timeout",
+			}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
 
-			node1 := result["1"].(map[string]interface{})
-			inputs := node1["inputs"].(map[string]interface{})
-			Expect(inputs["unet_name"]).To(Equal("models/checkpoints/test.safetensors"))
+			executor.mu.Lock()
+			executor.activeJobID = job.ID
+			executor.activeItemID = item.ID
+			executor.activePromptID = item.ComfyUIPromptID
+			executor.lastItemEvent = now.Add(-16 * time.Minute)
+			executor.mu.Unlock()
 		})
 
-		It("substitutes sampler parameters", func() {
-			job := model.SampleJob{ID: "job-1"}
-			item := model.SampleJobItem{
-				Seed:        12345,
-				Steps:       20,
-				CFG:         7.5,
-				SamplerName: "euler",
-				Scheduler:   "normal",
+		It("interrupts a running prompt without events for the timeout and fails its item", func() {
+			mockClient.queue = model.ComfyUIQueue{
+				Running: []model.ComfyUIQueueEntry{{Number: 1, PromptID: "prompt-timeout"}},
 			}
 
-			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
-			Expect(err).ToNot(HaveOccurred())
+			executor.checkItemTimeout()
 
-			node2 := result["2"].(map[string]interface{})
-			inputs := node2["inputs"].(map[string]interface{})
-			Expect(inputs["seed"]).To(Equal(int64(12345)))
-			Expect(inputs["steps"]).To(Equal(20))
-			Expect(inputs["cfg"]).To(Equal(7.5))
-			Expect(inputs["sampler_name"]).To(Equal("euler"))
-			Expect(inputs["scheduler"]).To(Equal("normal"))
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusFailed))
+			Expect(items[0].ErrorMessage).To(Equal("timeout: no progress from ComfyUI for 15m0s"))
+			Expect(mockClient.canceledPrompts).To(Equal([]string{"prompt-timeout"}))
+			Expect(mockClient.interruptedPrompts).To(Equal([]string{"prompt-timeout"}))
+
+			// The job continues: it stays tracked with no item in flight.
+			executor.mu.Lock()
+			Expect(executor.activeJobID).To(Equal(job.ID))
+			Expect(executor.activeItemID).To(BeEmpty())
+			Expect(executor.activePromptID).To(BeEmpty())
+			executor.mu.Unlock()
 		})
 
-		It("substitutes VAE and CLIP when provided", func() {
-			job := model.SampleJob{
-				ID:   "job-1",
-				VAE:  "ae.safetensors",
-				CLIP: "clip_l.safetensors",
+		It("only removes a prompt that is still waiting in the queue", func() {
+			mockClient.queue = model.ComfyUIQueue{
+				Running: []model.ComfyUIQueueEntry{{Number: 1, PromptID: "someone-elses-prompt"}},
+				Pending: []model.ComfyUIQueueEntry{{Number: 2, PromptID: "prompt-timeout"}},
 			}
-			item := model.SampleJobItem{}
 
-			// Add VAE and CLIP loader nodes
-			mockLoader.workflow.Workflow["4"] = map[string]interface{}{
-				"inputs": map[string]interface{}{},
-				"_meta": map[string]interface{}{
-					"cs_role": "vae_loader",
-				},
-			}
-			mockLoader.workflow.Workflow["5"] = map[string]interface{}{
-				"inputs": map[string]interface{}{},
-				"_meta": map[string]interface{}{
-					"cs_role": "clip_loader",
-				},
-			}
-			mockLoader.workflow.Roles["vae_loader"] = []string{"4"}
-			mockLoader.workflow.Roles["clip_loader"] = []string{"5"}
+			executor.checkItemTimeout()
 
-			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
-			Expect(err).ToNot(HaveOccurred())
+			Expect(mockStore.items[job.ID][0].Status).To(Equal(model.SampleJobItemStatusFailed))
+			Expect(mockClient.canceledPrompts).To(Equal([]string{"prompt-timeout"}))
+			Expect(mockClient.interruptedPrompts).To(BeEmpty())
+		})
 
-			node4 := result["4"].(map[string]interface{})
-			inputs4 := node4["inputs"].(map[string]interface{})
-			Expect(inputs4["vae_name"]).To(Equal("ae.safetensors"))
+		It("counts from the latest event of the active prompt", func() {
+			executor.handleComfyUIEvent(model.ComfyUIEvent{
+				Type: "executing",
+				Data: map[string]interface{}{"prompt_id": "prompt-timeout", "node": "3"},
+			})
 
-			node5 := result["5"].(map[string]interface{})
-			inputs5 := node5["inputs"].(map[string]interface{})
-			Expect(inputs5["clip_name"]).To(Equal("clip_l.safetensors"))
+			executor.checkItemTimeout()
+
+			Expect(mockStore.items[job.ID][0].Status).To(Equal(model.SampleJobItemStatusRunning))
+			executor.mu.Lock()
+			Expect(executor.lastItemEvent).To(Equal(now))
+			executor.mu.Unlock()
 		})
 
-		It("substitutes shift when provided", func() {
-			shift := 3.0
-			job := model.SampleJob{
-				ID:    "job-1",
-				Shift: &shift,
-			}
-			item := model.SampleJobItem{}
+		It("ignores events of other prompts", func() {
+			executor.handleComfyUIEvent(model.ComfyUIEvent{
+				Type: "executing",
+				Data: map[string]interface{}{"prompt_id": "other-prompt", "node": "3"},
+			})
 
-			// Add shift node
-			mockLoader.workflow.Workflow["6"] = map[string]interface{}{
-				"inputs": map[string]interface{}{},
-				"_meta": map[string]interface{}{
-					"cs_role": "shift",
-				},
-			}
-			mockLoader.workflow.Roles["shift"] = []string{"6"}
+			executor.checkItemTimeout()
 
-			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
-			Expect(err).ToNot(HaveOccurred())
+			Expect(mockStore.items[job.ID][0].Status).To(Equal(model.SampleJobItemStatusFailed))
+		})
 
-			node6 := result["6"].(map[string]interface{})
-			inputs6 := node6["inputs"].(map[string]interface{})
-			Expect(inputs6["shift"]).To(Equal(3.0))
+		It("waits indefinitely when the timeout is zero", func() {
+			executor.SetItemTimeout(0)
+
+			executor.checkItemTimeout()
+
+			Expect(mockStore.items[job.ID][0].Status).To(Equal(model.SampleJobItemStatusRunning))
+			Expect(mockClient.canceledPrompts).To(BeEmpty())
 		})
+	})
 
-		It("substitutes positive_prompt with prompt text", func() {
-			job := model.SampleJob{ID: "job-1"}
-			item := model.SampleJobItem{
-				PromptText: "a beautiful landscape",
-			}
+	Describe("parallel submission", func() {
+		var job model.SampleJob
 
-			// Add positive_prompt node
-			mockLoader.workflow.Workflow["7"] = map[string]interface{}{
-				"inputs": map[string]interface{}{},
-				"_meta": map[string]interface{}{
-					"cs_role": "positive_prompt",
+		outputEntry := model.HistoryEntry{
+			Outputs: map[string]interface{}{
+				"save_image": map[string]interface{}{
+					"images": []interface{}{
+						map[string]interface{}{"filename": "output.png", "subfolder": "", "type": "output"},
+					},
 				},
-			}
-			mockLoader.workflow.Roles["positive_prompt"] = []string{"7"}
+			},
+		}
 
-			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
-			Expect(err).ToNot(HaveOccurred())
+		BeforeEach(func() {
+			executor.SetMaxInFlight(2)
+			job = model.SampleJob{ID: "job-parallel", Status: model.SampleJobStatusRunning, WorkflowName: "test-workflow.json"}
+			mockStore.jobs[job.ID] = job
+			for _, id := range []string{"item-1", "item-2", "item-3"} {
+				mockStore.items[job.ID] = append(mockStore.items[job.ID], model.SampleJobItem{
+					ID:               id,
+					JobID:            job.ID,
+					Status:           model.SampleJobItemStatusPending,
+					ComfyUIModelPath: "models/test.safetensors",
+				})
+			}
+			mockClient.promptIDs = []string{"prompt-1", "prompt-2", "prompt-3"}
+			mockClient.historyResponse = model.HistoryResponse{"prompt-1": outputEntry, "prompt-2": outputEntry, "prompt-3": outputEntry}
 
-			node7 := result["7"].(map[string]interface{})
-			inputs7 := node7["inputs"].(map[string]interface{})
-			Expect(inputs7["text"]).To(Equal("a beautiful landscape"))
+			executor.mu.Lock()
+			executor.connected = true
+			executor.activeJobID = job.ID
+			executor.mu.Unlock()
 		})
 
-		// AC: BE: negative_prompt cs_role injects preset's negative prompt into workflow
-		DescribeTable("negative_prompt role substitution",
-			func(negativePrompt string, existingDefault string, expectedText string) {
-				job := model.SampleJob{ID: "job-1"}
-				item := model.SampleJobItem{
-					NegativePrompt: negativePrompt,
-				}
+		itemStatus := func(index int) model.SampleJobItemStatus {
+			return mockStore.items[job.ID][index].Status
+		}
 
-				inputs := map[string]interface{}{}
-				if existingDefault != "" {
-					inputs["text"] = existingDefault
-				}
-				mockLoader.workflow.Workflow["9"] = map[string]interface{}{
+		It("submits up to max_in_flight items, queueing them behind the active item", func() {
+			executor.processNextItem()
+			executor.processNextItem()
+			executor.processNextItem()
+
+			Expect(mockClient.submittedReqs).To(HaveLen(2))
+			Expect(itemStatus(0)).To(Equal(model.SampleJobItemStatusRunning))
+			Expect(itemStatus(1)).To(Equal(model.SampleJobItemStatusRunning))
+			Expect(itemStatus(2)).To(Equal(model.SampleJobItemStatusPending))
+			executor.mu.Lock()
+			Expect(executor.activeItemID).To(Equal("item-1"))
+			Expect(executor.activePromptID).To(Equal("prompt-1"))
+			Expect(executor.queued).To(Equal([]queuedPrompt{{itemID: "item-2", promptID: "prompt-2"}}))
+			executor.mu.Unlock()
+		})
+
+		It("makes the queued item active once the active item completes", func() {
+			executor.processNextItem()
+			executor.processNextItem()
+
+			executor.handleComfyUIEvent(model.ComfyUIEvent{
+				Type: "execution_success",
+				Data: map[string]interface{}{"prompt_id": "prompt-1"},
+			})
+
+			Expect(itemStatus(0)).To(Equal(model.SampleJobItemStatusCompleted))
+			executor.mu.Lock()
+			Expect(executor.activeItemID).To(Equal("item-2"))
+			Expect(executor.activePromptID).To(Equal("prompt-2"))
+			Expect(executor.queued).To(BeEmpty())
+			executor.mu.Unlock()
+
+			// The freed slot is filled on the next tick.
+			executor.processNextItem()
+			Expect(mockClient.submittedReqs).To(HaveLen(3))
+			Expect(itemStatus(2)).To(Equal(model.SampleJobItemStatusRunning))
+		})
+
+		It("completes a queued item whose prompt finishes while it is queued", func() {
+			executor.processNextItem()
+			executor.processNextItem()
+
+			executor.handleComfyUIEvent(model.ComfyUIEvent{
+				Type: "execution_success",
+				Data: map[string]interface{}{"prompt_id": "prompt-2"},
+			})
+
+			Expect(itemStatus(1)).To(Equal(model.SampleJobItemStatusCompleted))
+			Expect(itemStatus(0)).To(Equal(model.SampleJobItemStatusRunning))
+			executor.mu.Lock()
+			Expect(executor.activeItemID).To(Equal("item-1"))
+			Expect(executor.activePromptID).To(Equal("prompt-1"))
+			Expect(executor.queued).To(BeEmpty())
+			executor.mu.Unlock()
+		})
+
+		It("fails a queued item whose prompt reports an execution error", func() {
+			executor.processNextItem()
+			executor.processNextItem()
+
+			executor.handleComfyUIEvent(model.ComfyUIEvent{
+				Type: "execution_error",
+				Data: map[string]interface{}{"prompt_id": "prompt-2", "exception_message": "boom"},
+			})
+
+			Expect(itemStatus(1)).To(Equal(model.SampleJobItemStatusFailed))
+			Expect(itemStatus(0)).To(Equal(model.SampleJobItemStatusRunning))
+		})
+
+		It("does not complete the job while items are in flight", func() {
+			mockStore.items[job.ID] = mockStore.items[job.ID][:2]
+			executor.processNextItem()
+			executor.processNextItem()
+			executor.processNextItem()
+
+			Expect(mockStore.jobs[job.ID].Status).To(Equal(model.SampleJobStatusRunning))
+			Expect(itemStatus(0)).To(Equal(model.SampleJobItemStatusRunning))
+			Expect(itemStatus(1)).To(Equal(model.SampleJobItemStatusRunning))
+		})
+
+		It("cancels the queued prompts when the job is stopped", func() {
+			executor.processNextItem()
+			executor.processNextItem()
+
+			Expect(executor.RequestStop(job.ID)).To(Succeed())
+
+			Expect(mockClient.canceledPrompts).To(ConsistOf("prompt-1", "prompt-2"))
+			executor.mu.Lock()
+			Expect(executor.queued).To(BeEmpty())
+			executor.mu.Unlock()
+		})
+
+		It("submits one item at a time by default", func() {
+			executor.SetMaxInFlight(1)
+			executor.processNextItem()
+			executor.processNextItem()
+
+			Expect(mockClient.submittedReqs).To(HaveLen(1))
+		})
+	})
+
+	Describe("Healthy", func() {
+		var now time.Time
+
+		BeforeEach(func() {
+			now = time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+			executor.timeNow = func() time.Time { return now }
+			executor.mu.Lock()
+			executor.started = true
+			executor.connected = true
+			executor.lastTick = now.Add(-2 * time.Second)
+			executor.mu.Unlock()
+		})
+
+		It("is healthy when started, connected, and processing", func() {
+			healthy, reason := executor.Healthy(30 * time.Minute)
+			Expect(healthy).To(BeTrue())
+			Expect(reason).To(BeEmpty())
+		})
+
+		DescribeTable("is unhealthy",
+			func(setup func(), expectedReason string) {
+				executor.mu.Lock()
+				setup()
+				executor.mu.Unlock()
+				healthy, reason := executor.Healthy(30 * time.Minute)
+				Expect(healthy).To(BeFalse())
+				Expect(reason).To(Equal(expectedReason))
+			},
+			Entry("when not started", func() { executor.started = false }, "executor not started"),
+			Entry("when disconnected from ComfyUI", func() { executor.connected = false }, "not connected to ComfyUI"),
+			Entry("when the executor lease is not held", func() { executor.gate = &fakeExecutorGate{held: false} }, "executor lease not held"),
+			Entry("when the processing loop has stopped ticking", func() { executor.lastTick = now.Add(-2 * time.Minute) }, "processing loop not running"),
+			Entry("when a sample has run longer than the stall timeout", func() {
+				executor.activeItemID = "item-1"
+				executor.sampleStartTime = now.Add(-45 * time.Minute)
+			}, "sample running for 45m0s"),
+		)
+
+		It("ignores long-running samples when the stall timeout is zero", func() {
+			executor.mu.Lock()
+			executor.activeItemID = "item-1"
+			executor.sampleStartTime = now.Add(-45 * time.Minute)
+			executor.mu.Unlock()
+			healthy, _ := executor.Healthy(0)
+			Expect(healthy).To(BeTrue())
+		})
+	})
+
+	Describe("Executor gate (multi-process mode)", func() {
+		var gate *fakeExecutorGate
+
+		BeforeEach(func() {
+			gate = &fakeExecutorGate{}
+			executor.SetExecutorGate(gate)
+			executor.mu.Lock()
+			executor.connected = true
+			executor.mu.Unlock()
+		})
+
+		It("does not auto-start pending jobs while the gate is not held", func() {
+			job := model.SampleJob{ID: "job-pending", Status: model.SampleJobStatusPending}
+			mockStore.jobs[job.ID] = job
+
+			executor.processNextItem()
+			executor.processNextItem()
+
+			Expect(mockStore.jobs[job.ID].Status).To(Equal(model.SampleJobStatusPending))
+		})
+
+		It("adopts a running job left by the previous holder when the gate is acquired", func() {
+			job := model.SampleJob{ID: "job-running", Status: model.SampleJobStatusRunning}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{}
+
+			gate.held = true
+			executor.processNextItem()
+
+			executor.mu.Lock()
+			defer executor.mu.Unlock()
+			Expect(executor.activeJobID).To(Equal(job.ID))
+		})
+
+		It("drops active state when the gate is lost", func() {
+			gate.held = true
+			executor.processNextItem()
+
+			executor.mu.Lock()
+			executor.activeJobID = "job-1"
+			executor.activeItemID = "item-1"
+			executor.activePromptID = "prompt-1"
+			executor.mu.Unlock()
+
+			gate.held = false
+			executor.processNextItem()
+
+			executor.mu.Lock()
+			defer executor.mu.Unlock()
+			Expect(executor.activeJobID).To(BeEmpty())
+			Expect(executor.activeItemID).To(BeEmpty())
+			Expect(executor.activePromptID).To(BeEmpty())
+		})
+
+		It("gives up the items in flight when the gate is lost", func() {
+			job := model.SampleJob{ID: "job-1", Status: model.SampleJobStatusRunning}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{
+				{ID: "item-1", JobID: job.ID, Status: model.SampleJobItemStatusRunning, ComfyUIPromptID: "prompt-1"},
+				{ID: "item-2", JobID: job.ID, Status: model.SampleJobItemStatusRunning, ComfyUIPromptID: "prompt-2"},
+				// Already resubmitted by the new holder.
+				{ID: "item-3", JobID: job.ID, Status: model.SampleJobItemStatusRunning, ComfyUIPromptID: "prompt-new"},
+			}
+			mockClient.queue = model.ComfyUIQueue{
+				Running: []model.ComfyUIQueueEntry{{PromptID: "prompt-1"}},
+				Pending: []model.ComfyUIQueueEntry{{PromptID: "prompt-2"}, {PromptID: "prompt-new"}},
+			}
+
+			gate.held = true
+			executor.processNextItem()
+
+			executor.mu.Lock()
+			executor.activeJobID = job.ID
+			executor.activeItemID = "item-1"
+			executor.activePromptID = "prompt-1"
+			executor.queued = []queuedPrompt{{itemID: "item-2", promptID: "prompt-2"}, {itemID: "item-3", promptID: "prompt-3"}}
+			executor.mu.Unlock()
+
+			gate.held = false
+			executor.processNextItem()
+
+			Expect(mockClient.canceledPrompts).To(ConsistOf("prompt-1", "prompt-2", "prompt-3"))
+			Expect(mockClient.interruptedPrompts).To(ConsistOf("prompt-1"))
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusPending))
+			Expect(items[0].ComfyUIPromptID).To(BeEmpty())
+			Expect(items[1].Status).To(Equal(model.SampleJobItemStatusPending))
+			Expect(items[2].Status).To(Equal(model.SampleJobItemStatusRunning))
+			Expect(items[2].ComfyUIPromptID).To(Equal("prompt-new"))
+
+			// Nothing is given up twice while standing by.
+			executor.processNextItem()
+			Expect(mockClient.canceledPrompts).To(HaveLen(3))
+		})
+	})
+
+	Describe("Append-mode jobs", func() {
+		var (
+			appender *fakeCheckpointAppender
+			now      time.Time
+		)
+
+		BeforeEach(func() {
+			appender = &fakeCheckpointAppender{}
+			executor.SetCheckpointAppender(appender)
+			now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			executor.timeNow = func() time.Time { return now }
+			executor.mu.Lock()
+			executor.connected = true
+			executor.mu.Unlock()
+		})
+
+		runningJobWithoutPendingItems := func(appendNew bool) model.SampleJob {
+			job := model.SampleJob{ID: "job-1", Status: model.SampleJobStatusRunning, AppendNewCheckpoints: appendNew}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{
+				{ID: "item-1", JobID: job.ID, Status: model.SampleJobItemStatusCompleted},
+			}
+			executor.mu.Lock()
+			executor.activeJobID = job.ID
+			executor.mu.Unlock()
+			return job
+		}
+
+		It("keeps the running job open when new checkpoints add items", func() {
+			runningJobWithoutPendingItems(true)
+			appender.onAppend = func(jobID string) int {
+				mockStore.items[jobID] = append(mockStore.items[jobID], model.SampleJobItem{
+					ID: "item-2", JobID: jobID, Status: model.SampleJobItemStatusPending,
+				})
+				return 1
+			}
+
+			executor.processNextItem()
+
+			Expect(appender.calls).To(Equal([]string{"job-1"}))
+			Expect(mockStore.jobs["job-1"].Status).To(Equal(model.SampleJobStatusRunning))
+		})
+
+		It("completes the running job when no new checkpoints arrived", func() {
+			runningJobWithoutPendingItems(true)
+
+			executor.processNextItem()
+
+			Expect(appender.calls).To(Equal([]string{"job-1"}))
+			Expect(mockStore.jobs["job-1"].Status).To(Equal(model.SampleJobStatusCompleted))
+		})
+
+		It("completes the running job when the appender fails", func() {
+			runningJobWithoutPendingItems(true)
+			appender.err = errors.New("discovery failed")
+
+			executor.processNextItem()
+
+			Expect(mockStore.jobs["job-1"].Status).To(Equal(model.SampleJobStatusCompleted))
+		})
+
+		It("does not consult the appender for snapshot jobs", func() {
+			runningJobWithoutPendingItems(false)
+
+			executor.processNextItem()
+
+			Expect(appender.calls).To(BeEmpty())
+			Expect(mockStore.jobs["job-1"].Status).To(Equal(model.SampleJobStatusCompleted))
+		})
+
+		It("checks finished append-mode jobs at most once per interval while idle", func() {
+			mockStore.jobs["job-done"] = model.SampleJob{ID: "job-done", Status: model.SampleJobStatusCompleted, AppendNewCheckpoints: true}
+			mockStore.jobs["job-errors"] = model.SampleJob{ID: "job-errors", Status: model.SampleJobStatusCompletedWithErrors, AppendNewCheckpoints: true}
+			mockStore.jobs["job-snapshot"] = model.SampleJob{ID: "job-snapshot", Status: model.SampleJobStatusCompleted}
+			mockStore.jobs["job-stopped"] = model.SampleJob{ID: "job-stopped", Status: model.SampleJobStatusStopped, AppendNewCheckpoints: true}
+
+			executor.processNextItem()
+			Expect(appender.calls).To(ConsistOf("job-done", "job-errors"))
+
+			now = now.Add(appendCheckInterval - time.Second)
+			executor.processNextItem()
+			Expect(appender.calls).To(HaveLen(2))
+
+			now = now.Add(time.Second)
+			executor.processNextItem()
+			Expect(appender.calls).To(HaveLen(4))
+		})
+
+		It("starts a finished job that was requeued with new items", func() {
+			mockStore.jobs["job-done"] = model.SampleJob{ID: "job-done", Status: model.SampleJobStatusCompleted, AppendNewCheckpoints: true}
+			appender.onAppend = func(jobID string) int {
+				job := mockStore.jobs[jobID]
+				job.Status = model.SampleJobStatusPending
+				mockStore.jobs[jobID] = job
+				mockStore.items[jobID] = []model.SampleJobItem{
+					{ID: "item-new", JobID: jobID, Status: model.SampleJobItemStatusPending, ComfyUIModelPath: "models/new.safetensors"},
+				}
+				return 1
+			}
+
+			executor.processNextItem()
+			Expect(mockStore.jobs["job-done"].Status).To(Equal(model.SampleJobStatusPending))
+
+			executor.processNextItem()
+			Expect(mockStore.jobs["job-done"].Status).To(Equal(model.SampleJobStatusRunning))
+		})
+	})
+
+	Describe("RunWhenIdle", func() {
+		It("runs immediately when the executor is not started", func() {
+			ran := false
+			executor.RunWhenIdle(func() { ran = true })
+			Expect(ran).To(BeTrue())
+		})
+
+		It("defers until the in-flight item finishes", func() {
+			executor.mu.Lock()
+			executor.started = true
+			executor.connected = true
+			executor.activeJobID = "job-1"
+			executor.activeItemID = "item-1"
+			executor.mu.Unlock()
+
+			ran := false
+			executor.RunWhenIdle(func() { ran = true })
+			executor.processNextItem()
+			Expect(ran).To(BeFalse())
+
+			executor.mu.Lock()
+			executor.activeItemID = ""
+			executor.activeJobID = ""
+			executor.mu.Unlock()
+			executor.processNextItem()
+			Expect(ran).To(BeTrue())
+		})
+	})
+
+	Describe("substituteWorkflow", func() {
+		It("substitutes unet_loader with checkpoint path", func() {
+			job := model.SampleJob{
+				ID: "job-1",
+			}
+			item := model.SampleJobItem{
+				ComfyUIModelPath: "models/checkpoints/test.safetensors",
+			}
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
+			Expect(err).ToNot(HaveOccurred())
+
+			node1 := result["1"].(map[string]interface{})
+			inputs := node1["inputs"].(map[string]interface{})
+			Expect(inputs["unet_name"]).To(Equal("models/checkpoints/test.safetensors"))
+		})
+
+		It("substitutes sampler parameters", func() {
+			job := model.SampleJob{ID: "job-1"}
+			item := model.SampleJobItem{
+				Seed:        12345,
+				Steps:       20,
+				CFG:         7.5,
+				SamplerName: "euler",
+				Scheduler:   "normal",
+			}
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
+			Expect(err).ToNot(HaveOccurred())
+
+			node2 := result["2"].(map[string]interface{})
+			inputs := node2["inputs"].(map[string]interface{})
+			Expect(inputs["seed"]).To(Equal(int64(12345)))
+			Expect(inputs["steps"]).To(Equal(20))
+			Expect(inputs["cfg"]).To(Equal(7.5))
+			Expect(inputs["sampler_name"]).To(Equal("euler"))
+			Expect(inputs["scheduler"]).To(Equal("normal"))
+		})
+
+		It("substitutes VAE and CLIP when provided", func() {
+			job := model.SampleJob{
+				ID:   "job-1",
+				VAE:  "ae.safetensors",
+				CLIP: "clip_l.safetensors",
+			}
+			item := model.SampleJobItem{}
+
+			// Add VAE and CLIP loader nodes
+			mockLoader.workflow.Workflow["4"] = map[string]interface{}{
+				"inputs": map[string]interface{}{},
+				"_meta": map[string]interface{}{
+					"cs_role": "vae_loader",
+				},
+			}
+			mockLoader.workflow.Workflow["5"] = map[string]interface{}{
+				"inputs": map[string]interface{}{},
+				"_meta": map[string]interface{}{
+					"cs_role": "clip_loader",
+				},
+			}
+			mockLoader.workflow.Roles["vae_loader"] = []string{"4"}
+			mockLoader.workflow.Roles["clip_loader"] = []string{"5"}
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
+			Expect(err).ToNot(HaveOccurred())
+
+			node4 := result["4"].(map[string]interface{})
+			inputs4 := node4["inputs"].(map[string]interface{})
+			Expect(inputs4["vae_name"]).To(Equal("ae.safetensors"))
+
+			node5 := result["5"].(map[string]interface{})
+			inputs5 := node5["inputs"].(map[string]interface{})
+			Expect(inputs5["clip_name"]).To(Equal("clip_l.safetensors"))
+		})
+
+		It("substitutes shift when provided", func() {
+			shift := 3.0
+			job := model.SampleJob{
+				ID:    "job-1",
+				Shift: &shift,
+			}
+			item := model.SampleJobItem{}
+
+			// Add shift node
+			mockLoader.workflow.Workflow["6"] = map[string]interface{}{
+				"inputs": map[string]interface{}{},
+				"_meta": map[string]interface{}{
+					"cs_role": "shift",
+				},
+			}
+			mockLoader.workflow.Roles["shift"] = []string{"6"}
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
+			Expect(err).ToNot(HaveOccurred())
+
+			node6 := result["6"].(map[string]interface{})
+			inputs6 := node6["inputs"].(map[string]interface{})
+			Expect(inputs6["shift"]).To(Equal(3.0))
+		})
+
+		It("substitutes positive_prompt with prompt text", func() {
+			job := model.SampleJob{ID: "job-1"}
+			item := model.SampleJobItem{
+				PromptText: "a beautiful landscape",
+			}
+
+			// Add positive_prompt node
+			mockLoader.workflow.Workflow["7"] = map[string]interface{}{
+				"inputs": map[string]interface{}{},
+				"_meta": map[string]interface{}{
+					"cs_role": "positive_prompt",
+				},
+			}
+			mockLoader.workflow.Roles["positive_prompt"] = []string{"7"}
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
+			Expect(err).ToNot(HaveOccurred())
+
+			node7 := result["7"].(map[string]interface{})
+			inputs7 := node7["inputs"].(map[string]interface{})
+			Expect(inputs7["text"]).To(Equal("a beautiful landscape"))
+		})
+
+		// AC: BE: negative_prompt cs_role injects preset's negative prompt into workflow
+		DescribeTable("negative_prompt role substitution",
+			func(negativePrompt string, existingDefault string, expectedText string) {
+				job := model.SampleJob{ID: "job-1"}
+				item := model.SampleJobItem{
+					NegativePrompt: negativePrompt,
+				}
+
+				inputs := map[string]interface{}{}
+				if existingDefault != "" {
+					inputs["text"] = existingDefault
+				}
+				mockLoader.workflow.Workflow["9"] = map[string]interface{}{
 					"inputs": inputs,
 					"_meta": map[string]interface{}{
 						"cs_role": "negative_prompt",
@@ -916,49 +1662,524 @@ var _ = Describe("JobExecutor", func() {
 			Entry("no substitution when negative prompt is empty and no default", "", "", ""),
 		)
 
-		// AC: BE: When the workflow has no negative_prompt role, no error occurs
-		It("does not error when workflow has no negative_prompt node", func() {
-			job := model.SampleJob{ID: "job-1"}
+		// AC: BE: When the workflow has no negative_prompt role, no error occurs
+		It("does not error when workflow has no negative_prompt node", func() {
+			job := model.SampleJob{ID: "job-1"}
+			item := model.SampleJobItem{
+				NegativePrompt: "blurry",
+			}
+
+			// Ensure negative_prompt role is not in the workflow
+			delete(mockLoader.workflow.Roles, "negative_prompt")
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).NotTo(BeNil())
+		})
+
+		It("substitutes latent_image with width and height", func() {
+			job := model.SampleJob{ID: "job-1"}
+			item := model.SampleJobItem{
+				Width:  1024,
+				Height: 768,
+			}
+
+			// Add latent_image node
+			mockLoader.workflow.Workflow["8"] = map[string]interface{}{
+				"inputs": map[string]interface{}{},
+				"_meta": map[string]interface{}{
+					"cs_role": "latent_image",
+				},
+			}
+			mockLoader.workflow.Roles["latent_image"] = []string{"8"}
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
+			Expect(err).ToNot(HaveOccurred())
+
+			node8 := result["8"].(map[string]interface{})
+			inputs8 := node8["inputs"].(map[string]interface{})
+			Expect(inputs8["width"]).To(Equal(1024))
+			Expect(inputs8["height"]).To(Equal(768))
+			Expect(inputs8["batch_size"]).To(Equal(1))
+		})
+	})
+
+	Describe("substituteWorkflow img2img roles", func() {
+		var images *mockInputImages
+		denoise := 0.6
+
+		BeforeEach(func() {
+			images = newMockInputImages()
+			images.files["/refs/portrait.PNG"] = []byte("reference")
+			mockLoader.workflow.Workflow["9"] = map[string]interface{}{
+				"inputs": map[string]interface{}{"image": "example.png"},
+				"_meta":  map[string]interface{}{"cs_role": "load_image"},
+			}
+			mockLoader.workflow.Workflow["10"] = map[string]interface{}{
+				"inputs": map[string]interface{}{"denoise": 1.0},
+				"_meta":  map[string]interface{}{"cs_role": "denoise"},
+			}
+			mockLoader.workflow.Roles["load_image"] = []string{"9"}
+			mockLoader.workflow.Roles["denoise"] = []string{"10"}
+		})
+
+		It("uploads the input image and wires it and the denoise strength into the workflow", func() {
+			executor.SetInputImageUploader(images, images)
+			job := model.SampleJob{ID: "job-1", InputImage: "/refs/portrait.PNG"}
+			item := model.SampleJobItem{Denoise: &denoise}
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(images.uploads).To(HaveLen(1))
+			var uploadName string
+			for name, data := range images.uploads {
+				uploadName = name
+				Expect(data).To(Equal([]byte("reference")))
+			}
+			Expect(uploadName).To(MatchRegexp(`^cs-input-[0-9a-f]{16}\.png$`))
+
+			inputs9 := result["9"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs9["image"]).To(Equal(uploadName))
+			inputs10 := result["10"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs10["denoise"]).To(Equal(0.6))
+		})
+
+		It("keeps the node defaults when the job has no input image", func() {
+			executor.SetInputImageUploader(images, images)
+			job := model.SampleJob{ID: "job-1"}
+			item := model.SampleJobItem{}
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(images.uploads).To(BeEmpty())
+
+			inputs9 := result["9"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs9["image"]).To(Equal("example.png"))
+			inputs10 := result["10"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs10["denoise"]).To(Equal(1.0))
+		})
+
+		It("fails when the input image cannot be read", func() {
+			executor.SetInputImageUploader(images, images)
+			job := model.SampleJob{ID: "job-1", InputImage: "/refs/missing.png"}
+
+			_, err := executor.substituteWorkflow(mockLoader.workflow, job, model.SampleJobItem{})
+			Expect(err).To(MatchError(ContainSubstring("reading input image /refs/missing.png")))
+		})
+
+		It("fails when the upload fails", func() {
+			images.uploadErr = errors.New("status 500")
+			executor.SetInputImageUploader(images, images)
+			job := model.SampleJob{ID: "job-1", InputImage: "/refs/portrait.PNG"}
+
+			_, err := executor.substituteWorkflow(mockLoader.workflow, job, model.SampleJobItem{})
+			Expect(err).To(MatchError(ContainSubstring("uploading input image")))
+		})
+
+		It("fails when no uploader is set", func() {
+			job := model.SampleJob{ID: "job-1", InputImage: "/refs/portrait.PNG"}
+
+			_, err := executor.substituteWorkflow(mockLoader.workflow, job, model.SampleJobItem{})
+			Expect(err).To(MatchError(ContainSubstring("input images are not supported")))
+		})
+	})
+
+	Describe("substituteWorkflow ControlNet roles", func() {
+		var images *mockInputImages
+		strength := 0.75
+
+		BeforeEach(func() {
+			images = newMockInputImages()
+			images.files["/refs/edges.png"] = []byte("edges")
+			mockLoader.workflow.Workflow["11"] = map[string]interface{}{
+				"inputs": map[string]interface{}{"control_net_name": "default_cn.safetensors"},
+				"_meta":  map[string]interface{}{"cs_role": "controlnet_loader"},
+			}
+			mockLoader.workflow.Workflow["12"] = map[string]interface{}{
+				"class_type": "LoadImage",
+				"inputs":     map[string]interface{}{"image": "pose.png"},
+			}
+			mockLoader.workflow.Workflow["13"] = map[string]interface{}{
+				"inputs": map[string]interface{}{
+					"strength":    1.0,
+					"control_net": []interface{}{"11", float64(0)},
+					"image":       []interface{}{"12", float64(0)},
+				},
+				"_meta": map[string]interface{}{"cs_role": "controlnet_apply"},
+			}
+			mockLoader.workflow.Roles["controlnet_loader"] = []string{"11"}
+			mockLoader.workflow.Roles["controlnet_apply"] = []string{"13"}
+		})
+
+		It("substitutes the model and strength and wires the uploaded image into the linked loader", func() {
+			executor.SetInputImageUploader(images, images)
+			job := model.SampleJob{
+				ID:                 "job-1",
+				ControlNetModel:    "control_canny.safetensors",
+				ControlNetStrength: &strength,
+				ControlNetImage:    "/refs/edges.png",
+			}
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, job, model.SampleJobItem{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(images.uploads).To(HaveLen(1))
+			var uploadName string
+			for name := range images.uploads {
+				uploadName = name
+			}
+			Expect(uploadName).To(MatchRegexp(`^cs-controlnet-[0-9a-f]{16}\.png$`))
+
+			inputs11 := result["11"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs11["control_net_name"]).To(Equal("control_canny.safetensors"))
+			inputs12 := result["12"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs12["image"]).To(Equal(uploadName))
+			inputs13 := result["13"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs13["strength"]).To(Equal(0.75))
+			Expect(inputs13["image"]).To(Equal([]interface{}{"12", float64(0)}))
+		})
+
+		It("keeps the node defaults when the job has no ControlNet settings", func() {
+			executor.SetInputImageUploader(images, images)
+
+			result, err := executor.substituteWorkflow(mockLoader.workflow, model.SampleJob{ID: "job-1"}, model.SampleJobItem{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(images.uploads).To(BeEmpty())
+
+			inputs11 := result["11"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs11["control_net_name"]).To(Equal("default_cn.safetensors"))
+			inputs12 := result["12"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs12["image"]).To(Equal("pose.png"))
+			inputs13 := result["13"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(inputs13["strength"]).To(Equal(1.0))
+		})
+
+		It("fails when the apply node's image input is not linked", func() {
+			executor.SetInputImageUploader(images, images)
+			mockLoader.workflow.Workflow["13"].(map[string]interface{})["inputs"].(map[string]interface{})["image"] = "pose.png"
+			job := model.SampleJob{ID: "job-1", ControlNetImage: "/refs/edges.png"}
+
+			_, err := executor.substituteWorkflow(mockLoader.workflow, job, model.SampleJobItem{})
+			Expect(err).To(MatchError(ContainSubstring("node 13 has no linked image input")))
+		})
+
+		It("fails when the conditioning image cannot be read", func() {
+			executor.SetInputImageUploader(images, images)
+			job := model.SampleJob{ID: "job-1", ControlNetImage: "/refs/missing.png"}
+
+			_, err := executor.substituteWorkflow(mockLoader.workflow, job, model.SampleJobItem{})
+			Expect(err).To(MatchError(ContainSubstring("reading controlnet image /refs/missing.png")))
+		})
+	})
+
+	Describe("pre-warming the next checkpoint", func() {
+		var (
+			vram      *mockPrewarmVRAMChecker
+			job       model.SampleJob
+			current   model.SampleJobItem
+			following model.SampleJobItem
+		)
+
+		BeforeEach(func() {
+			vram = &mockPrewarmVRAMChecker{room: true}
+			executor.SetPrewarm(vram)
+			mockClient.promptResponse = &model.PromptResponse{PromptID: "prewarm-prompt-id"}
+			mockLoader.workflow.Workflow["4"] = map[string]interface{}{
+				"inputs": map[string]interface{}{},
+				"_meta":  map[string]interface{}{"cs_role": "latent_image"},
+			}
+			mockLoader.workflow.Roles["latent_image"] = []string{"4"}
+			job = model.SampleJob{ID: "job-1", WorkflowName: "test-workflow.json"}
+			current = model.SampleJobItem{ID: "item-1", CheckpointFilename: "a.safetensors", ComfyUIModelPath: "a.safetensors", Steps: 30}
+			following = model.SampleJobItem{ID: "item-2", CheckpointFilename: "b.safetensors", ComfyUIModelPath: "b.safetensors", Steps: 30}
+			executor.activeItemID = current.ID
+			executor.activePromptID = "active-prompt-id"
+		})
+
+		It("queues a minimal prompt that loads the next checkpoint", func() {
+			executor.prewarmNextCheckpoint(job, current, following)
+
+			Expect(mockClient.submittedReqs).To(HaveLen(1))
+			req := mockClient.submittedReqs[0]
+			Expect(req.ClientID).To(Equal("test-client-id"))
+			Expect(req.Prompt["1"].(map[string]interface{})["inputs"].(map[string]interface{})["unet_name"]).To(Equal("b.safetensors"))
+			Expect(req.Prompt["2"].(map[string]interface{})["inputs"].(map[string]interface{})["steps"]).To(Equal(1))
+			latent := req.Prompt["4"].(map[string]interface{})["inputs"].(map[string]interface{})
+			Expect(latent["width"]).To(Equal(64))
+			Expect(latent["height"]).To(Equal(64))
+			Expect(req.Prompt["3"].(map[string]interface{})["class_type"]).To(Equal("PreviewImage"))
+			Expect(executor.prewarmPromptID).To(Equal("prewarm-prompt-id"))
+
+			// The same checkpoint is not pre-warmed twice in a job.
+			executor.prewarmPromptID = ""
+			executor.prewarmNextCheckpoint(job, current, following)
+			Expect(mockClient.submittedReqs).To(HaveLen(1))
+		})
+
+		It("skips items of the same checkpoint", func() {
+			following.CheckpointFilename = current.CheckpointFilename
+			executor.prewarmNextCheckpoint(job, current, following)
+			Expect(mockClient.submittedReqs).To(BeEmpty())
+		})
+
+		It("skips when the GPU lacks room for a second model", func() {
+			vram.room = false
+			executor.prewarmNextCheckpoint(job, current, following)
+			Expect(mockClient.submittedReqs).To(BeEmpty())
+		})
+
+		It("skips when free VRAM is unknown", func() {
+			vram.err = errors.New("system stats unavailable")
+			executor.prewarmNextCheckpoint(job, current, following)
+			Expect(mockClient.submittedReqs).To(BeEmpty())
+		})
+
+		It("skips when the current item was not submitted", func() {
+			executor.activePromptID = ""
+			executor.prewarmNextCheckpoint(job, current, following)
+			Expect(mockClient.submittedReqs).To(BeEmpty())
+		})
+
+		It("ignores the events of the pre-warm prompt", func() {
+			executor.prewarmPromptID = "prewarm-prompt-id"
+
+			executor.handleComfyUIEvent(model.ComfyUIEvent{Type: "progress", Data: map[string]interface{}{
+				"prompt_id": "prewarm-prompt-id", "value": float64(1), "max": float64(1),
+			}})
+			Expect(mockHub.events).To(BeEmpty())
+
+			executor.handleComfyUIEvent(model.ComfyUIEvent{Type: "executing", Data: map[string]interface{}{
+				"prompt_id": "prewarm-prompt-id", "node": nil,
+			}})
+			Expect(executor.prewarmPromptID).To(BeEmpty())
+			Expect(executor.activePromptID).To(Equal("active-prompt-id"))
+			Expect(executor.activeItemID).To(Equal(current.ID))
+		})
+	})
+
+	// The sidecar records the item's negative prompt, so the submitted
+	// workflow must carry it too.
+	Describe("negative prompt", func() {
+		var job model.SampleJob
+
+		BeforeEach(func() {
+			mockLoader.workflow.Workflow["7"] = map[string]interface{}{
+				"inputs": map[string]interface{}{"text": "ugly, deformed"},
+				"_meta":  map[string]interface{}{"cs_role": "negative_prompt"},
+			}
+			mockLoader.workflow.Roles["negative_prompt"] = []string{"7"}
+			mockStore.studies["study-neg"] = model.Study{ID: "study-neg", Name: "Neg", NegativePrompt: "blurry, artifacts"}
+			job = model.SampleJob{ID: "job-neg", StudyID: "study-neg", Status: model.SampleJobStatusPending, WorkflowName: "test-workflow.json", TotalItems: 1}
+			mockStore.jobs[job.ID] = job
+		})
+
+		// runItem submits an item with the negative prompt, completes it, and
+		// returns the inputs of the submitted negative_prompt node and the
+		// sidecar written for the image.
+		runItem := func(negativePrompt string) (map[string]interface{}, fileformat.SidecarMetadata) {
 			item := model.SampleJobItem{
-				NegativePrompt: "blurry",
+				ID: "item-neg", JobID: job.ID, Status: model.SampleJobItemStatusPending,
+				CheckpointFilename: "a.safetensors", ComfyUIModelPath: "a.safetensors", PromptName: "cat",
+				NegativePrompt: negativePrompt,
 			}
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			Expect(executor.autoStartJob(&job)).To(Succeed())
 
-			// Ensure negative_prompt role is not in the workflow
-			delete(mockLoader.workflow.Roles, "negative_prompt")
+			executor.activeJobID = job.ID
+			executor.activeItemID = item.ID
+			executor.processItem(job, item)
+			Expect(mockClient.lastSubmittedReq).NotTo(BeNil())
+			inputs := mockClient.lastSubmittedReq.Prompt["7"].(map[string]interface{})["inputs"].(map[string]interface{})
 
-			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(result).NotTo(BeNil())
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusCompleted))
+			var meta fileformat.SidecarMetadata
+			Expect(json.Unmarshal(mockFS.writtenFiles[strings.TrimSuffix(items[0].OutputPath, ".png")+".json"], &meta)).To(Succeed())
+			return inputs, meta
+		}
+
+		It("submits the item's negative prompt, matching the sidecar", func() {
+			inputs, meta := runItem("blurry, artifacts")
+
+			Expect(inputs).To(HaveKeyWithValue("text", "blurry, artifacts"))
+			Expect(meta.NegativePrompt).To(Equal("blurry, artifacts"))
 		})
 
-		It("substitutes latent_image with width and height", func() {
-			job := model.SampleJob{ID: "job-1"}
-			item := model.SampleJobItem{
-				Width:  1024,
-				Height: 768,
+		It("keeps the node's text when the item has no negative prompt", func() {
+			inputs, meta := runItem("")
+
+			Expect(inputs).To(HaveKeyWithValue("text", "ugly, deformed"))
+			Expect(meta.NegativePrompt).To(BeEmpty())
+		})
+	})
+
+	Describe("job parameter locking", func() {
+		var job model.SampleJob
+
+		BeforeEach(func() {
+			mockStore.studies["study-lock"] = model.Study{ID: "study-lock", PromptPrefix: "photo, "}
+			job = model.SampleJob{ID: "job-lock", StudyID: "study-lock", Status: model.SampleJobStatusPending, WorkflowName: "test-workflow.json"}
+			mockStore.jobs[job.ID] = job
+		})
+
+		It("snapshots the study and workflow when the job starts", func() {
+			Expect(executor.autoStartJob(&job)).To(Succeed())
+
+			params, ok := mockStore.params[job.ID]
+			Expect(ok).To(BeTrue())
+			Expect(params.Study.PromptPrefix).To(Equal("photo, "))
+			Expect(params.Workflow.Roles).To(HaveKey("sampler"))
+		})
+
+		It("submits the snapshotted workflow after the workflow file is edited", func() {
+			item := model.SampleJobItem{ID: "item-lock", JobID: job.ID, Status: model.SampleJobItemStatusPending, ComfyUIModelPath: "a.safetensors"}
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			Expect(executor.autoStartJob(&job)).To(Succeed())
+
+			mockLoader.workflow = model.WorkflowTemplate{
+				Name:     "test-workflow.json",
+				Workflow: map[string]interface{}{"9": map[string]interface{}{"inputs": map[string]interface{}{}}},
+				Roles:    map[string][]string{},
 			}
+			mockStore.studies["study-lock"] = model.Study{ID: "study-lock", PromptPrefix: "painting, "}
 
-			// Add latent_image node
-			mockLoader.workflow.Workflow["8"] = map[string]interface{}{
-				"inputs": map[string]interface{}{},
-				"_meta": map[string]interface{}{
-					"cs_role": "latent_image",
+			executor.activeJobID = job.ID
+			executor.activeItemID = item.ID
+			executor.processItem(job, item)
+
+			Expect(mockClient.lastSubmittedReq).NotTo(BeNil())
+			Expect(mockClient.lastSubmittedReq.Prompt).To(HaveKey("1"))
+			Expect(mockClient.lastSubmittedReq.Prompt).NotTo(HaveKey("9"))
+			Expect(mockStore.params[job.ID].Study.PromptPrefix).To(Equal("photo, "))
+		})
+
+		It("submits each item with the snapshot of its override workflow", func() {
+			job.WorkflowOverrides = []model.WorkflowOverride{{Pattern: "*-flux-*", Workflow: "flux-dev.json"}}
+			mockStore.jobs[job.ID] = job
+			mockLoader.named = map[string]model.WorkflowTemplate{
+				"flux-dev.json": {
+					Name:     "flux-dev.json",
+					Workflow: map[string]interface{}{"9": map[string]interface{}{"inputs": map[string]interface{}{}}},
+					Roles:    map[string][]string{},
 				},
 			}
-			mockLoader.workflow.Roles["latent_image"] = []string{"8"}
+			item := model.SampleJobItem{ID: "item-flux", JobID: job.ID, Status: model.SampleJobItemStatusPending, ComfyUIModelPath: "run-flux-1.safetensors", WorkflowName: "flux-dev.json"}
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			Expect(executor.autoStartJob(&job)).To(Succeed())
+			Expect(mockStore.params[job.ID].OverrideWorkflows).To(HaveKey("flux-dev.json"))
 
-			result, err := executor.substituteWorkflow(mockLoader.workflow, job, item)
-			Expect(err).ToNot(HaveOccurred())
+			executor.activeJobID = job.ID
+			executor.activeItemID = item.ID
+			executor.processItem(job, item)
 
-			node8 := result["8"].(map[string]interface{})
-			inputs8 := node8["inputs"].(map[string]interface{})
-			Expect(inputs8["width"]).To(Equal(1024))
-			Expect(inputs8["height"]).To(Equal(768))
-			Expect(inputs8["batch_size"]).To(Equal(1))
+			Expect(mockClient.lastSubmittedReq).NotTo(BeNil())
+			Expect(mockClient.lastSubmittedReq.Prompt).To(HaveKey("9"))
+			Expect(mockClient.lastSubmittedReq.Prompt).NotTo(HaveKey("1"))
+		})
+
+		It("snapshots the version of the study the job was created from", func() {
+			job.StudyVersion = 1
+			mockStore.studies["study-lock"] = model.Study{ID: "study-lock", Version: 2, PromptPrefix: "painting, "}
+			mockStore.versions = map[string][]model.StudyVersion{
+				"study-lock": {{StudyID: "study-lock", Version: 1, Study: model.Study{ID: "study-lock", Version: 1, PromptPrefix: "photo, "}}},
+			}
+			Expect(executor.autoStartJob(&job)).To(Succeed())
+
+			Expect(mockStore.params[job.ID].Study.Version).To(Equal(1))
+			Expect(mockStore.params[job.ID].Study.PromptPrefix).To(Equal("photo, "))
+		})
+
+		It("fails the item when the job's parameters cannot be snapshotted", func() {
+			delete(mockStore.studies, "study-lock")
+			item := model.SampleJobItem{ID: "item-lock", JobID: job.ID, Status: model.SampleJobItemStatusPending}
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+
+			executor.activeJobID = job.ID
+			executor.activeItemID = item.ID
+			executor.processItem(job, item)
+
+			Expect(mockStore.items[job.ID][0].Status).To(Equal(model.SampleJobItemStatusFailed))
+			Expect(mockStore.items[job.ID][0].ErrorMessage).To(ContainSubstring("failed to load job parameters"))
+		})
+	})
+
+	Describe("checkpoint staging", func() {
+		var (
+			stager *fakeCheckpointStager
+			job    model.SampleJob
+		)
+
+		BeforeEach(func() {
+			stager = &fakeCheckpointStager{}
+			executor.SetCheckpointStager(stager)
+			mockStore.studies["study-stage"] = model.Study{ID: "study-stage"}
+			job = model.SampleJob{ID: "job-stage", StudyID: "study-stage", Status: model.SampleJobStatusRunning, WorkflowName: "test-workflow.json"}
+			mockStore.jobs[job.ID] = job
+		})
+
+		It("stages the checkpoint before submitting and records it on the item", func() {
+			item := model.SampleJobItem{ID: "item-stage", JobID: job.ID, CheckpointFilename: "a.safetensors", ComfyUIModelPath: "staging/a.safetensors", Status: model.SampleJobItemStatusPending}
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			job.Status = model.SampleJobStatusPending
+			mockStore.jobs[job.ID] = job
+			Expect(executor.autoStartJob(&job)).To(Succeed())
+
+			executor.activeJobID = job.ID
+			executor.activeItemID = item.ID
+			executor.processItem(job, item)
+
+			Expect(stager.staged).To(Equal([]string{"staging/a.safetensors"}))
+			Expect(mockClient.lastSubmittedReq).NotTo(BeNil())
+			Expect(mockStore.items[job.ID][0].StagedCheckpoint).To(Equal("/staging/a.safetensors"))
+		})
+
+		It("fails the item when the checkpoint cannot be staged", func() {
+			stager.err = errors.New("no space left on device")
+			item := model.SampleJobItem{ID: "item-stage", JobID: job.ID, CheckpointFilename: "a.safetensors", ComfyUIModelPath: "staging/a.safetensors", Status: model.SampleJobItemStatusPending}
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			job.Status = model.SampleJobStatusPending
+			mockStore.jobs[job.ID] = job
+			Expect(executor.autoStartJob(&job)).To(Succeed())
+
+			executor.activeJobID = job.ID
+			executor.activeItemID = item.ID
+			executor.processItem(job, item)
+
+			Expect(mockClient.lastSubmittedReq).To(BeNil())
+			Expect(mockStore.items[job.ID][0].Status).To(Equal(model.SampleJobItemStatusFailed))
+			Expect(mockStore.items[job.ID][0].ErrorMessage).To(ContainSubstring("checkpoint staging failed"))
+		})
+
+		It("removes the staged checkpoints when the job completes", func() {
+			mockStore.items[job.ID] = []model.SampleJobItem{
+				{ID: "item-1", JobID: job.ID, CheckpointFilename: "a.safetensors", Status: model.SampleJobItemStatusCompleted, StagedCheckpoint: "/staging/a.safetensors"},
+				{ID: "item-2", JobID: job.ID, CheckpointFilename: "a.safetensors", Status: model.SampleJobItemStatusCompleted, StagedCheckpoint: "/staging/a.safetensors"},
+				{ID: "item-3", JobID: job.ID, CheckpointFilename: "b.safetensors", Status: model.SampleJobItemStatusCompleted},
+			}
+
+			executor.completeJob(job.ID)
+
+			Expect(stager.unstaged).To(Equal([]string{"/staging/a.safetensors"}))
+		})
+
+		It("keeps a staged checkpoint that another unfinished job still needs", func() {
+			mockStore.items[job.ID] = []model.SampleJobItem{
+				{ID: "item-1", JobID: job.ID, CheckpointFilename: "a.safetensors", Status: model.SampleJobItemStatusCompleted, StagedCheckpoint: "/staging/a.safetensors"},
+			}
+			mockStore.jobs["job-next"] = model.SampleJob{ID: "job-next", Status: model.SampleJobStatusPending}
+			mockStore.items["job-next"] = []model.SampleJobItem{
+				{ID: "item-next", JobID: "job-next", CheckpointFilename: "a.safetensors", Status: model.SampleJobItemStatusPending},
+			}
+
+			executor.completeJob(job.ID)
+
+			Expect(stager.unstaged).To(BeEmpty())
 		})
 	})
 
-	Describe("generateOutputFilename", func() {
+	Describe("outputRelPath", func() {
 		It("generates query-encoded filename with all parameters", func() {
 			item := model.SampleJobItem{
 				PromptName:  "test-prompt",
@@ -969,7 +2190,7 @@ var _ = Describe("JobExecutor", func() {
 				Seed:        12345,
 			}
 
-			filename := executor.generateOutputFilename(item)
+			filename := executor.outputRelPath(item, model.OutputFormatPNG)
 			Expect(filename).To(ContainSubstring("prompt=test-prompt"))
 			Expect(filename).To(ContainSubstring("steps=20"))
 			Expect(filename).To(ContainSubstring("cfg=7.5"))
@@ -1043,6 +2264,66 @@ var _ = Describe("JobExecutor", func() {
 			Expect(mockHub.events[0].JobProgressData.JobID).To(Equal("job-1"))
 		})
 
+		It("completes the item when it was updated while its image was saved", func() {
+			// The prompt ID is saved after submission and can land after the
+			// item was read for completion, moving the stored version ahead.
+			mockClient.onDownload = func() {
+				mockClient.onDownload = nil
+				stored := mockStore.items[job.ID][0]
+				stored.ComfyUIPromptID = "test-prompt-id"
+				Expect(mockStore.UpdateSampleJobItem(context.Background(), &stored)).To(Succeed())
+			}
+
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusCompleted))
+			Expect(items[0].OutputPath).To(ContainSubstring("test.safetensors"))
+			Expect(items[0].ComfyUIPromptID).To(Equal("test-prompt-id"))
+			Expect(items[0].CompletedAt).NotTo(BeNil())
+			Expect(mockStore.jobs[job.ID].CompletedItems).To(Equal(1))
+		})
+
+		It("records the saved image in the image index", func() {
+			images := &recordingImageIndex{}
+			executor.SetImageIndex(images)
+
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+
+			Expect(images.added).To(HaveLen(1))
+			Expect(images.added[0]).To(HavePrefix("test.safetensors/"))
+			Expect(images.added[0]).To(HaveSuffix(".png"))
+		})
+
+		It("stores the image's scores on the item and in the sidecar", func() {
+			scorer := &fakeImageScorer{scores: map[string]float64{"aesthetic": 6.5, "bad-name!": 1}}
+			executor.SetImageScorer(scorer, time.Second)
+
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+
+			Expect(scorer.requests).To(HaveLen(1))
+			Expect(scorer.requests[0].ItemID).To(Equal("item-1"))
+			Expect(scorer.requests[0].Image).NotTo(BeEmpty())
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusCompleted))
+			Expect(items[0].Scores).To(Equal(map[string]float64{"aesthetic": 6.5}))
+
+			sidecarPath := strings.TrimSuffix(items[0].OutputPath, ".png") + ".json"
+			var meta fileformat.SidecarMetadata
+			Expect(json.Unmarshal(mockFS.writtenFiles[sidecarPath], &meta)).To(Succeed())
+			Expect(meta.Scores).To(Equal(map[string]float64{"aesthetic": 6.5}))
+		})
+
+		It("completes the item without scores when scoring fails", func() {
+			executor.SetImageScorer(&fakeImageScorer{err: errors.New("scorer unavailable")}, time.Second)
+
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusCompleted))
+			Expect(items[0].Scores).To(BeNil())
+		})
+
 		It("handles download errors gracefully", func() {
 			mockClient.downloadErr = errors.New("download failed")
 
@@ -1137,6 +2418,20 @@ var _ = Describe("JobExecutor", func() {
 			Expect(updatedJob.Status).To(Equal(model.SampleJobStatusStopped))
 		})
 
+		It("notifies the job notifier once the job is stopped", func() {
+			notifier := &recordingJobNotifier{}
+			executor.SetJobNotifier(notifier)
+			mockStore.jobs["job-notify-stop"] = model.SampleJob{ID: "job-notify-stop", Status: model.SampleJobStatusRunning}
+
+			executor.mu.Lock()
+			executor.activeJobID = "job-notify-stop"
+			executor.mu.Unlock()
+
+			Expect(executor.RequestStop("job-notify-stop")).To(Succeed())
+			Expect(notifier.jobs).To(HaveLen(1))
+			Expect(notifier.jobs[0].Status).To(Equal(model.SampleJobStatusStopped))
+		})
+
 		// AC2: No window where DB and executor state diverge during stop
 		It("writes stopped status to DB before clearing executor active state", func() {
 			// This test verifies the ordering: DB update happens before state clear.
@@ -1355,6 +2650,109 @@ var _ = Describe("JobExecutor", func() {
 		})
 	})
 
+	// AC: started_at, completed_at and duration_ms are recorded per item so slow
+	// checkpoints or samplers can be identified.
+	Describe("Per-item timing", func() {
+		var (
+			job       model.SampleJob
+			item      model.SampleJobItem
+			clock     time.Time
+			startTime time.Time
+		)
+
+		BeforeEach(func() {
+			startTime = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+			clock = startTime
+			executor.timeNow = func() time.Time { return clock }
+
+			job = model.SampleJob{
+				ID:           "job-1",
+				Status:       model.SampleJobStatusRunning,
+				WorkflowName: "test.json",
+				TotalItems:   1,
+			}
+			item = model.SampleJobItem{
+				ID:                 "item-1",
+				JobID:              "job-1",
+				CheckpointFilename: "test.safetensors",
+				ComfyUIModelPath:   "test.safetensors",
+				PromptName:         "test-prompt",
+				Steps:              20,
+				CFG:                7.5,
+				SamplerName:        "euler",
+				Scheduler:          "normal",
+				Seed:               12345,
+				Status:             model.SampleJobItemStatusPending,
+			}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			executor.activeJobID = job.ID
+			executor.activeItemID = item.ID
+		})
+
+		It("sets started_at when the item starts running", func() {
+			executor.processItem(job, item)
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusRunning))
+			Expect(items[0].StartedAt).NotTo(BeNil())
+			Expect(items[0].StartedAt.Equal(startTime)).To(BeTrue())
+			Expect(items[0].CompletedAt).To(BeNil())
+			Expect(items[0].DurationMs).To(BeNil())
+		})
+
+		It("clears timing left over from a previous attempt when the item restarts", func() {
+			oldStart := startTime.Add(-time.Hour)
+			oldDuration := int64(999)
+			item.StartedAt = &oldStart
+			item.CompletedAt = &oldStart
+			item.DurationMs = &oldDuration
+
+			executor.processItem(job, item)
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].StartedAt.Equal(startTime)).To(BeTrue())
+			Expect(items[0].CompletedAt).To(BeNil())
+			Expect(items[0].DurationMs).To(BeNil())
+		})
+
+		It("sets completed_at and duration_ms when the item completes", func() {
+			executor.processItem(job, item)
+			clock = startTime.Add(4500 * time.Millisecond)
+
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusCompleted))
+			Expect(items[0].CompletedAt).NotTo(BeNil())
+			Expect(items[0].CompletedAt.Equal(clock)).To(BeTrue())
+			Expect(items[0].DurationMs).NotTo(BeNil())
+			Expect(*items[0].DurationMs).To(Equal(int64(4500)))
+		})
+
+		It("sets completed_at and duration_ms when the item fails", func() {
+			executor.processItem(job, item)
+			clock = startTime.Add(2 * time.Second)
+
+			executor.failItemWithDetails(item.ID, "boom", "RuntimeError", "KSampler", "", "")
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusFailed))
+			Expect(items[0].CompletedAt).NotTo(BeNil())
+			Expect(items[0].DurationMs).NotTo(BeNil())
+			Expect(*items[0].DurationMs).To(Equal(int64(2000)))
+		})
+
+		It("records completed_at without a duration for an item that never started", func() {
+			executor.failItem(item.ID, "failed before submission")
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusFailed))
+			Expect(items[0].CompletedAt).NotTo(BeNil())
+			Expect(items[0].DurationMs).To(BeNil())
+		})
+	})
+
 	// S-102 (UAT rework): processItem must broadcast job_progress with current_sample_params
 	// immediately after submitting the prompt to ComfyUI so that the first sample's params are
 	// visible to WebSocket clients from the very start of generation, not only after the first
@@ -1542,11 +2940,42 @@ var _ = Describe("JobExecutor", func() {
 
 			localExecutor.updateJobProgress(job.ID)
 
-			Expect(lc.EntriesAtLevel(logrus.ErrorLevel)).To(BeEmpty(), "should not log ERROR when job deleted during cancel")
-			Expect(lc.EntriesAtLevel(logrus.WarnLevel)).NotTo(BeEmpty(), "should log WARN when job deleted during cancel")
+			Expect(lc.EntriesAtLevel(logrus.ErrorLevel)).To(BeEmpty(), "should not log ERROR when job deleted during cancel")
+			Expect(lc.EntriesAtLevel(logrus.WarnLevel)).NotTo(BeEmpty(), "should log WARN when job deleted during cancel")
+
+			// Restore
+			mockStore.updateJobError = nil
+		})
+
+		It("re-reads the job and retries the progress update after a version conflict", func() {
+			job := model.SampleJob{
+				ID:         "job-conflict-progress",
+				Status:     model.SampleJobStatusRunning,
+				TotalItems: 1,
+			}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{
+				{ID: "item-done", JobID: job.ID, Status: model.SampleJobItemStatusCompleted},
+			}
+			// The first write loses a race with a concurrent update of the job
+			updates := 0
+			mockStore.onUpdateJob = func(model.SampleJob) {
+				updates++
+				if updates == 1 {
+					mockStore.updateJobError = fmt.Errorf("%w: job updated concurrently", store.ErrVersionConflict)
+				} else {
+					mockStore.updateJobError = nil
+				}
+			}
+
+			localExecutor.updateJobProgress(job.ID)
+
+			Expect(updates).To(Equal(2))
+			Expect(mockStore.jobs[job.ID].CompletedItems).To(Equal(1))
+			Expect(lc.EntriesAtLevel(logrus.ErrorLevel)).To(BeEmpty())
 
 			// Restore
-			mockStore.updateJobError = nil
+			mockStore.onUpdateJob = nil
 		})
 
 		It("logs at WARN (not ERROR) and clears active state when GetSampleJob returns sql.ErrNoRows during completeJob", func() {
@@ -1795,6 +3224,49 @@ var _ = Describe("JobExecutor", func() {
 			))
 		})
 
+		Context("with a failure log reader", func() {
+			var logReader *fakeComfyUILogReader
+
+			BeforeEach(func() {
+				logReader = &fakeComfyUILogReader{log: "Prompt queued\n" +
+					"!!! Exception during processing !!! sizes must match\n" +
+					"Traceback (most recent call last):\n" +
+					"  File \"/comfyui/custom_nodes/node.py\", line 12, in run\n" +
+					"RuntimeError: sizes must match\n"}
+				executor.SetFailureLogReader(logReader, 10)
+			})
+
+			executionError := model.ComfyUIEvent{
+				Type: "execution_error",
+				Data: map[string]interface{}{
+					"prompt_id":         "test-prompt-id",
+					"exception_message": "sizes must match",
+					"exception_type":    "RuntimeError",
+				},
+			}
+
+			It("attaches the ComfyUI log from the last exception report to the failed item", func() {
+				executor.handleComfyUIEvent(executionError)
+
+				items := mockStore.items["job-1"]
+				Expect(items[0].Status).To(Equal(model.SampleJobItemStatusFailed))
+				Expect(items[0].ComfyUILog).To(Equal("!!! Exception during processing !!! sizes must match\n" +
+					"Traceback (most recent call last):\n" +
+					"  File \"/comfyui/custom_nodes/node.py\", line 12, in run\n" +
+					"RuntimeError: sizes must match"))
+			})
+
+			It("still fails the item when the log cannot be read", func() {
+				logReader.err = errors.New("connection refused")
+
+				executor.handleComfyUIEvent(executionError)
+
+				items := mockStore.items["job-1"]
+				Expect(items[0].Status).To(Equal(model.SampleJobItemStatusFailed))
+				Expect(items[0].ComfyUILog).To(BeEmpty())
+			})
+		})
+
 		// AC: execution_error with missing optional fields gracefully degrades
 		It("handles execution_error with missing optional fields", func() {
 			event := model.ComfyUIEvent{
@@ -2576,6 +4048,22 @@ var _ = Describe("JobExecutor", func() {
 		})
 	})
 
+	Describe("relevantLogLines", func() {
+		DescribeTable("selects the log lines attached to a failed item",
+			func(log string, maxLines int, expected string) {
+				Expect(relevantLogLines(log, maxLines)).To(Equal(expected))
+			},
+			Entry("keeps the last lines", "a\nb\nc\nd\n", 2, "c\nd"),
+			Entry("keeps a short log whole", "a\nb\n", 10, "a\nb"),
+			Entry("starts at the last exception report",
+				"a\n!!! Exception during processing !!! old\nb\n!!! Exception during processing !!! new\nTraceback\n", 10,
+				"!!! Exception during processing !!! new\nTraceback"),
+			Entry("ignores an exception report before the last lines",
+				"!!! Exception during processing !!! old\na\nb\nc\n", 2, "b\nc"),
+			Entry("returns nothing for an empty log", "", 10, ""),
+		)
+	})
+
 	Describe("writeSidecar", func() {
 		var job model.SampleJob
 		var item model.SampleJobItem
@@ -2655,6 +4143,16 @@ var _ = Describe("JobExecutor", func() {
 			Expect(meta.CommitSHA).To(Equal("unknown"))
 		})
 
+		It("records the wildcard values of the item", func() {
+			item.Wildcards = map[string]string{"color": "red"}
+			err := executor.writeSidecar("/test/samples/model-step00001000.safetensors/image.png", job, item)
+			Expect(err).ToNot(HaveOccurred())
+
+			var meta fileformat.SidecarMetadata
+			Expect(json.Unmarshal(mockFS.writtenFiles["/test/samples/model-step00001000.safetensors/image.json"], &meta)).To(Succeed())
+			Expect(meta.Wildcards).To(Equal(map[string]string{"color": "red"}))
+		})
+
 		It("uses atomic write: writes to temp file first then renames", func() {
 			imagePath := "/test/samples/model-step00001000.safetensors/image.png"
 			tempPath := "/test/samples/model-step00001000.safetensors/image.json.tmp"
@@ -2872,6 +4370,108 @@ var _ = Describe("JobExecutor", func() {
 		})
 	})
 
+	Describe("handleItemCompletionAsync output format", func() {
+		var job model.SampleJob
+		var item model.SampleJobItem
+
+		BeforeEach(func() {
+			mockClient.downloadData = makePNGBytes(64, 64)
+
+			job = model.SampleJob{
+				ID:              "job-format-1",
+				TrainingRunName: "my-model",
+				StudyID:         "study-format-1",
+				StudyName:       "Format Study",
+				Status:          model.SampleJobStatusRunning,
+				WorkflowName:    "flux_dev.json",
+				OutputFormat:    model.OutputFormatJPEG,
+				OutputQuality:   80,
+				TotalItems:      1,
+			}
+			item = model.SampleJobItem{
+				ID:                 "item-format-1",
+				JobID:              "job-format-1",
+				CheckpointFilename: "my-model-step00001000.safetensors",
+				PromptName:         "test-prompt",
+				Steps:              20,
+				CFG:                7.0,
+				SamplerName:        "euler",
+				Scheduler:          "normal",
+				Seed:               42,
+				Status:             model.SampleJobItemStatusRunning,
+			}
+
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			mockStore.studies["study-format-1"] = model.Study{ID: "study-format-1"}
+		})
+
+		AfterEach(func() {
+			mockClient.downloadData = []byte("fake-image-data")
+		})
+
+		It("transcodes the image and saves it with the format's extension", func() {
+			executor.SetTranscoder(NewImageTranscoder(nil, logger))
+
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+
+			completed := mockStore.items[job.ID][0]
+			Expect(completed.Status).To(Equal(model.SampleJobItemStatusCompleted))
+			Expect(completed.OutputPath).To(HaveSuffix(".jpg"))
+
+			data, ok := mockFS.writtenFiles[completed.OutputPath]
+			Expect(ok).To(BeTrue())
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(img.Bounds().Dx()).To(Equal(64))
+			Expect(mockFS.writtenFiles).To(HaveKey(strings.TrimSuffix(completed.OutputPath, ".jpg") + ".json"))
+		})
+
+		It("fails the item when no transcoder is configured", func() {
+			executor.mu.Lock()
+			executor.activeJobID = job.ID
+			executor.mu.Unlock()
+
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+
+			failed := mockStore.items[job.ID][0]
+			Expect(failed.Status).To(Equal(model.SampleJobItemStatusFailed))
+			Expect(failed.ErrorMessage).To(ContainSubstring("transcode"))
+		})
+
+		It("embeds the generation parameters in PNG images", func() {
+			job.OutputFormat = model.OutputFormatPNG
+			mockStore.jobs[job.ID] = job
+
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+
+			completed := mockStore.items[job.ID][0]
+			Expect(completed.Status).To(Equal(model.SampleJobItemStatusCompleted))
+			data := mockFS.writtenFiles[completed.OutputPath]
+			Expect(string(data)).To(ContainSubstring("tEXtparameters\x00"))
+			Expect(string(data)).To(ContainSubstring("Steps: 20, Sampler: euler, Schedule type: normal, CFG scale: 7, Seed: 42"))
+			img, err := png.Decode(bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(img.Bounds().Dx()).To(Equal(64))
+		})
+
+		It("saves the image and its sidecar at the output layout's path", func() {
+			layout, err := model.ParseOutputLayout("{checkpoint}/{prompt}/{sampler}_{scheduler}/cfg{cfg}_steps{steps}_seed{seed}")
+			Expect(err).NotTo(HaveOccurred())
+			executor.SetOutputLayout(layout)
+			job.OutputFormat = model.OutputFormatPNG
+			mockStore.jobs[job.ID] = job
+
+			executor.handleItemCompletionAsync(job.ID, item.ID, "test-prompt-id")
+
+			completed := mockStore.items[job.ID][0]
+			Expect(completed.Status).To(Equal(model.SampleJobItemStatusCompleted))
+			Expect(completed.OutputPath).To(HaveSuffix("/my-model-step00001000.safetensors/test-prompt/euler_normal/cfg7.0_steps20_seed42.png"))
+			Expect(mockFS.writtenFiles).To(HaveKey(completed.OutputPath))
+			Expect(mockFS.writtenFiles).To(HaveKey(strings.TrimSuffix(completed.OutputPath, ".png") + ".json"))
+		})
+	})
+
 	// AC: S-075 — Completeness check for generated sample datasets
 	Describe("verifyCheckpointCompleteness", func() {
 		var job model.SampleJob
@@ -2903,14 +4503,14 @@ var _ = Describe("JobExecutor", func() {
 			}
 
 			// Build the expected filenames
-			file1 := executor.generateOutputFilename(items[0])
-			file2 := executor.generateOutputFilename(items[1])
+			file1 := executor.outputRelPath(items[0], model.OutputFormatPNG)
+			file2 := executor.outputRelPath(items[1], model.OutputFormatPNG)
 
 			checkpointDir := "/test/samples/TestStudy/ckpt1.safetensors"
 			mockFSRead.dirs[checkpointDir] = true
 			mockFSRead.files[checkpointDir] = []string{file1, file2}
 
-			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, "ckpt1.safetensors", items)
+			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, model.OutputFormatPNG, "ckpt1.safetensors", items)
 
 			executor.mu.Lock()
 			info, ok := executor.checkpointCompleteness["ckpt1.safetensors"]
@@ -2941,13 +4541,13 @@ var _ = Describe("JobExecutor", func() {
 			}
 
 			// Only the first file exists on disk
-			file1 := executor.generateOutputFilename(items[0])
+			file1 := executor.outputRelPath(items[0], model.OutputFormatPNG)
 
 			checkpointDir := "/test/samples/TestStudy/ckpt1.safetensors"
 			mockFSRead.dirs[checkpointDir] = true
 			mockFSRead.files[checkpointDir] = []string{file1}
 
-			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, "ckpt1.safetensors", items)
+			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, model.OutputFormatPNG, "ckpt1.safetensors", items)
 
 			executor.mu.Lock()
 			info := executor.checkpointCompleteness["ckpt1.safetensors"]
@@ -2970,7 +4570,7 @@ var _ = Describe("JobExecutor", func() {
 			}
 
 			// Directory does not exist (not in mockFSRead.dirs)
-			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, "ckpt-missing.safetensors", items)
+			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, model.OutputFormatPNG, "ckpt-missing.safetensors", items)
 
 			executor.mu.Lock()
 			info := executor.checkpointCompleteness["ckpt-missing.safetensors"]
@@ -2989,7 +4589,7 @@ var _ = Describe("JobExecutor", func() {
 				},
 			}
 
-			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, "ckpt1.safetensors", items)
+			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, model.OutputFormatPNG, "ckpt1.safetensors", items)
 
 			executor.mu.Lock()
 			_, ok := executor.checkpointCompleteness["ckpt1.safetensors"]
@@ -2999,7 +4599,7 @@ var _ = Describe("JobExecutor", func() {
 			Expect(ok).To(BeFalse())
 		})
 
-		It("handles ListPNGFiles error gracefully", func() {
+		It("handles ListImageFiles error gracefully", func() {
 			items := []model.SampleJobItem{
 				{
 					ID: "i1", JobID: job.ID, CheckpointFilename: "ckpt-err.safetensors",
@@ -3013,7 +4613,7 @@ var _ = Describe("JobExecutor", func() {
 			mockFSRead.dirs[checkpointDir] = true
 			mockFSRead.listErr = errors.New("permission denied")
 
-			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, "ckpt-err.safetensors", items)
+			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, model.OutputFormatPNG, "ckpt-err.safetensors", items)
 
 			executor.mu.Lock()
 			info := executor.checkpointCompleteness["ckpt-err.safetensors"]
@@ -3044,12 +4644,12 @@ var _ = Describe("JobExecutor", func() {
 				},
 			}
 
-			file1 := executor.generateOutputFilename(items[0])
+			file1 := executor.outputRelPath(items[0], model.OutputFormatPNG)
 			checkpointDir := "/test/samples/TestStudy/ckpt1.safetensors"
 			mockFSRead.dirs[checkpointDir] = true
 			mockFSRead.files[checkpointDir] = []string{file1}
 
-			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, "ckpt1.safetensors", items)
+			executor.verifyCheckpointCompleteness(job.ID, job.StudyName, model.OutputFormatPNG, "ckpt1.safetensors", items)
 
 			executor.mu.Lock()
 			info := executor.checkpointCompleteness["ckpt1.safetensors"]
@@ -3098,8 +4698,8 @@ var _ = Describe("JobExecutor", func() {
 
 			// Set up filesystem mock so completeness check succeeds
 			// Path uses new layout: {sampleDir}/{trainingRunName}/{studyName}/{checkpoint}/
-			file1 := executor.generateOutputFilename(items[0])
-			file2 := executor.generateOutputFilename(items[1])
+			file1 := executor.outputRelPath(items[0], model.OutputFormatPNG)
+			file2 := executor.outputRelPath(items[1], model.OutputFormatPNG)
 			checkpointDir := "/test/samples/test-model/TestStudy/ckpt1.safetensors"
 			mockFSRead.dirs[checkpointDir] = true
 			mockFSRead.files[checkpointDir] = []string{file1, file2}
@@ -3640,6 +5240,27 @@ var _ = Describe("JobExecutor", func() {
 			Expect(manifest.Checkpoints).To(Equal([]string{"chk1.safetensors"}))
 		})
 
+		It("notifies the job notifier with the terminal status", func() {
+			notifier := &recordingJobNotifier{}
+			executor.SetJobNotifier(notifier)
+			job := model.SampleJob{ID: "job-notify", StudyName: "Notify Study", Status: model.SampleJobStatusRunning, TotalItems: 2}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{
+				{ID: "i1", JobID: job.ID, Status: model.SampleJobItemStatusCompleted},
+				{ID: "i2", JobID: job.ID, Status: model.SampleJobItemStatusFailed},
+			}
+
+			executor.mu.Lock()
+			executor.activeJobID = job.ID
+			executor.mu.Unlock()
+
+			executor.processNextItem()
+
+			Expect(notifier.jobs).To(HaveLen(1))
+			Expect(notifier.jobs[0].ID).To(Equal("job-notify"))
+			Expect(notifier.jobs[0].Status).To(Equal(model.SampleJobStatusCompletedWithErrors))
+		})
+
 		It("still completes the job when manifest write fails (non-fatal)", func() {
 			job := model.SampleJob{
 				ID:         "job-manifest-fail",
@@ -3883,6 +5504,203 @@ var _ = Describe("JobExecutor", func() {
 			Expect(items[0].OutputPath).NotTo(BeEmpty())
 		})
 
+		It("fails a stuck item whose prompt failed in ComfyUI", func() {
+			job := model.SampleJob{
+				ID:     "job-recover-failed",
+				Status: model.SampleJobStatusRunning,
+			}
+			item := model.SampleJobItem{
+				ID:              "item-recover-failed",
+				JobID:           job.ID,
+				Status:          model.SampleJobItemStatusRunning,
+				ComfyUIPromptID: "prompt-failed",
+			}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+
+			// ComfyUI records a failed prompt with status_str "error" and the
+			// execution_error event among its status messages.
+			mockClient.historyResponse = model.HistoryResponse{
+				"prompt-failed": model.HistoryEntry{
+					Outputs: map[string]interface{}{},
+					Status: map[string]interface{}{
+						"status_str": "error",
+						"completed":  false,
+						"messages": []interface{}{
+							[]interface{}{"execution_start", map[string]interface{}{"prompt_id": "prompt-failed"}},
+							[]interface{}{"execution_error", map[string]interface{}{
+								"prompt_id":         "prompt-failed",
+								"node_type":         "KSampler",
+								"exception_type":    "torch.OutOfMemoryError",
+								"exception_message": "CUDA out of memory",
+								"traceback":         []interface{}{"line 1\n", "line 2\n"},
+							}},
+						},
+					},
+				},
+			}
+
+			executor.recoverStuckItems()
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusFailed))
+			Expect(items[0].ErrorMessage).To(Equal("[torch.OutOfMemoryError] KSampler: CUDA out of memory"))
+			Expect(items[0].ExceptionType).To(Equal("torch.OutOfMemoryError"))
+			Expect(items[0].NodeType).To(Equal("KSampler"))
+			Expect(items[0].Traceback).To(Equal("line 1\nline 2\n"))
+			Expect(mockClient.submittedReqs).To(BeEmpty())
+
+			executor.mu.Lock()
+			Expect(executor.activeItemID).To(BeEmpty())
+			executor.mu.Unlock()
+		})
+
+		It("waits for a stuck item whose prompt is still queued in ComfyUI instead of re-submitting it", func() {
+			// Keep the poller from ticking during the test.
+			executor.historyPollInterval = time.Hour
+			job := model.SampleJob{
+				ID:     "job-recover-queued",
+				Status: model.SampleJobStatusRunning,
+			}
+			item := model.SampleJobItem{
+				ID:              "item-recover-queued",
+				JobID:           job.ID,
+				Status:          model.SampleJobItemStatusRunning,
+				ComfyUIPromptID: "prompt-queued",
+			}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			mockClient.historyResponse = model.HistoryResponse{}
+			mockClient.queue = model.ComfyUIQueue{
+				Running: []model.ComfyUIQueueEntry{{Number: 3, PromptID: "prompt-queued"}},
+			}
+
+			executor.recoverStuckItems()
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusRunning))
+			Expect(items[0].ComfyUIPromptID).To(Equal("prompt-queued"))
+
+			// The prompt is active again, so its WebSocket events complete it.
+			executor.mu.Lock()
+			Expect(executor.activeJobID).To(Equal(job.ID))
+			Expect(executor.activeItemID).To(Equal(item.ID))
+			Expect(executor.activePromptID).To(Equal("prompt-queued"))
+			executor.mu.Unlock()
+		})
+
+		It("resets a stuck item to pending when the ComfyUI queue cannot be read", func() {
+			job := model.SampleJob{
+				ID:     "job-recover-queue-err",
+				Status: model.SampleJobStatusRunning,
+			}
+			item := model.SampleJobItem{
+				ID:              "item-recover-queue-err",
+				JobID:           job.ID,
+				Status:          model.SampleJobItemStatusRunning,
+				ComfyUIPromptID: "prompt-queue-err",
+			}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{item}
+			mockClient.historyResponse = model.HistoryResponse{}
+			mockClient.queueErr = errors.New("ComfyUI unreachable")
+
+			executor.recoverStuckItems()
+
+			items := mockStore.items[job.ID]
+			Expect(items[0].Status).To(Equal(model.SampleJobItemStatusPending))
+		})
+
+		Describe("awaitRecoveredPrompt", func() {
+			var (
+				job  model.SampleJob
+				item model.SampleJobItem
+			)
+
+			BeforeEach(func() {
+				executor.historyPollInterval = time.Millisecond
+				job = model.SampleJob{
+					ID:           "job-await",
+					Status:       model.SampleJobStatusRunning,
+					WorkflowName: "test-workflow.json",
+				}
+				item = model.SampleJobItem{
+					ID:                 "item-await",
+					JobID:              job.ID,
+					CheckpointFilename: "test-checkpoint.safetensors",
+					ComfyUIModelPath:   "models/test-checkpoint.safetensors",
+					Status:             model.SampleJobItemStatusRunning,
+					ComfyUIPromptID:    "prompt-await",
+					PromptName:         "test-prompt",
+					PromptText:         "a photo",
+					SamplerName:        "euler",
+					Scheduler:          "normal",
+					Seed:               42,
+					Steps:              20,
+					CFG:                7.0,
+					Width:              512,
+					Height:             512,
+				}
+				mockStore.jobs[job.ID] = job
+				mockStore.items[job.ID] = []model.SampleJobItem{item}
+
+				executor.mu.Lock()
+				executor.activeJobID = job.ID
+				executor.activeItemID = item.ID
+				executor.activePromptID = item.ComfyUIPromptID
+				executor.mu.Unlock()
+			})
+
+			It("completes the item once the prompt's history shows output images", func() {
+				mockClient.historyResponse = model.HistoryResponse{
+					"prompt-await": model.HistoryEntry{
+						Outputs: map[string]interface{}{
+							"save_image": map[string]interface{}{
+								"images": []interface{}{
+									map[string]interface{}{"filename": "output_await.png", "subfolder": "", "type": "output"},
+								},
+							},
+						},
+					},
+				}
+
+				executor.awaitRecoveredPrompt(job.ID, item)
+
+				items := mockStore.items[job.ID]
+				Expect(items[0].Status).To(Equal(model.SampleJobItemStatusCompleted))
+				executor.mu.Lock()
+				Expect(executor.activeItemID).To(BeEmpty())
+				Expect(executor.activePromptID).To(BeEmpty())
+				executor.mu.Unlock()
+			})
+
+			It("resets the item to pending when the prompt leaves the queue without a history entry", func() {
+				mockClient.historyResponse = model.HistoryResponse{}
+
+				executor.awaitRecoveredPrompt(job.ID, item)
+
+				items := mockStore.items[job.ID]
+				Expect(items[0].Status).To(Equal(model.SampleJobItemStatusPending))
+				Expect(items[0].ComfyUIPromptID).To(BeEmpty())
+				executor.mu.Lock()
+				Expect(executor.activeItemID).To(BeEmpty())
+				executor.mu.Unlock()
+			})
+
+			It("stops once a WebSocket event has handled the prompt", func() {
+				executor.mu.Lock()
+				executor.activeItemID = ""
+				executor.activePromptID = ""
+				executor.mu.Unlock()
+				mockClient.historyResponse = model.HistoryResponse{}
+
+				executor.awaitRecoveredPrompt(job.ID, item)
+
+				items := mockStore.items[job.ID]
+				Expect(items[0].Status).To(Equal(model.SampleJobItemStatusRunning))
+			})
+		})
+
 		It("skips items in non-running status during recovery", func() {
 			// Only items in running status should be examined; pending, completed, failed are skipped.
 			job := model.SampleJob{
@@ -4359,4 +6177,128 @@ var _ = Describe("JobExecutor", func() {
 			executor.mu.Unlock()
 		})
 	})
+
+	Describe("Graceful drain on shutdown", func() {
+		var job model.SampleJob
+
+		BeforeEach(func() {
+			job = model.SampleJob{ID: "job-drain", Status: model.SampleJobStatusRunning}
+			mockStore.jobs[job.ID] = job
+			mockStore.items[job.ID] = []model.SampleJobItem{
+				{ID: "item-drain", JobID: job.ID, Status: model.SampleJobItemStatusRunning, ComfyUIPromptID: "prompt-drain"},
+			}
+		})
+
+		// setInFlight marks the running item as in flight, as processItem
+		// does after submitting it.
+		setInFlight := func() {
+			executor.mu.Lock()
+			executor.activeJobID = job.ID
+			executor.activeItemID = "item-drain"
+			executor.activePromptID = "prompt-drain"
+			executor.mu.Unlock()
+		}
+
+		It("marks the item in flight interrupted when it does not finish in time", func() {
+			executor.SetDrainTimeout(0)
+			Expect(executor.Start()).To(Succeed())
+			setInFlight()
+
+			executor.Stop()
+
+			Expect(mockStore.items[job.ID][0].Status).To(Equal(model.SampleJobItemStatusInterrupted))
+			executor.mu.Lock()
+			defer executor.mu.Unlock()
+			Expect(executor.activeItemID).To(BeEmpty())
+			Expect(executor.activePromptID).To(BeEmpty())
+		})
+
+		It("waits for the item in flight to finish", func() {
+			executor.SetDrainTimeout(5 * time.Second)
+			Expect(executor.Start()).To(Succeed())
+			setInFlight()
+			go func() {
+				defer GinkgoRecover()
+				time.Sleep(200 * time.Millisecond)
+				executor.mu.Lock()
+				executor.activeItemID = ""
+				executor.activePromptID = ""
+				executor.mu.Unlock()
+			}()
+
+			executor.Stop()
+
+			Expect(mockStore.items[job.ID][0].Status).To(Equal(model.SampleJobItemStatusRunning))
+		})
+
+		It("starts no new item while draining", func() {
+			mockStore.items[job.ID][0].Status = model.SampleJobItemStatusPending
+			executor.mu.Lock()
+			executor.connected = true
+			executor.draining = true
+			executor.mu.Unlock()
+
+			executor.processNextItem()
+
+			Expect(mockStore.items[job.ID][0].Status).To(Equal(model.SampleJobItemStatusPending))
+			Expect(mockClient.submittedReqs).To(BeEmpty())
+		})
+
+		It("re-queues interrupted items as pending on start", func() {
+			mockStore.items[job.ID][0].Status = model.SampleJobItemStatusInterrupted
+
+			Expect(executor.Start()).To(Succeed())
+			executor.Stop()
+
+			item := mockStore.items[job.ID][0]
+			Expect(item.Status).To(Equal(model.SampleJobItemStatusPending))
+			Expect(item.ComfyUIPromptID).To(BeEmpty())
+		})
+	})
 })
+
+// fakeCheckpointStager stages checkpoints in the staging directory under
+// "/staging" and records what was staged and removed.
+type fakeCheckpointStager struct {
+	staged   []string
+	unstaged []string
+	err      error
+}
+
+func (f *fakeCheckpointStager) Stage(modelPath, filename string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.staged = append(f.staged, modelPath)
+	return "/staging/" + filename, nil
+}
+
+func (f *fakeCheckpointStager) Unstage(stagedPath string) error {
+	f.unstaged = append(f.unstaged, stagedPath)
+	return nil
+}
+
+// recordingImageIndex records the images added to the image index.
+type recordingImageIndex struct {
+	added []string
+}
+
+func (r *recordingImageIndex) AddImage(relPath string)    { r.added = append(r.added, relPath) }
+func (r *recordingImageIndex) RemoveImage(relPath string) {}
+
+type fakeImageScorer struct {
+	scores   map[string]float64
+	err      error
+	requests []model.ImageScoreRequest
+}
+
+func (f *fakeImageScorer) ScoreImage(ctx context.Context, req model.ImageScoreRequest) (map[string]float64, error) {
+	f.requests = append(f.requests, req)
+	return f.scores, f.err
+}
+
+type recordingJobNotifier struct {
+	jobs []model.SampleJob
+}
+
+func (r *recordingJobNotifier) JobFinished(job model.SampleJob) { r.jobs = append(r.jobs, job) }
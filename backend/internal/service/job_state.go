@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+	"github.com/sirupsen/logrus"
+)
+
+// JobTransitionStore defines the persistence operations the job state machine
+// needs.
+type JobTransitionStore interface {
+	// TransitionSampleJob saves j only while the job's stored status is
+	// from and its version is j.Version, returning
+	// store.ErrSampleJobStatusChanged or store.ErrVersionConflict otherwise.
+	TransitionSampleJob(ctx context.Context, j *model.SampleJob, from model.SampleJobStatus) error
+	GetSampleJob(ctx context.Context, id string) (model.SampleJob, error)
+}
+
+// jobTransitions lists the statuses a job may move to from each status.
+// Jobs are created pending; failed is final.
+var jobTransitions = map[model.SampleJobStatus][]model.SampleJobStatus{
+	model.SampleJobStatusPending: {model.SampleJobStatusRunning},
+	model.SampleJobStatusRunning: {
+		model.SampleJobStatusStopped,
+		model.SampleJobStatusCompleted,
+		model.SampleJobStatusCompletedWithErrors,
+		model.SampleJobStatusFailed,
+	},
+	model.SampleJobStatusStopped: {model.SampleJobStatusRunning},
+	// Append mode re-queues finished jobs that gain items.
+	model.SampleJobStatusCompleted: {model.SampleJobStatusPending},
+	// Retrying failed items resumes the job; append mode re-queues it.
+	model.SampleJobStatusCompletedWithErrors: {model.SampleJobStatusRunning, model.SampleJobStatusPending},
+}
+
+// maxConflictRetries bounds how often an update that lost a race with a
+// concurrent update of the same job or item is re-read and retried.
+const maxConflictRetries = 3
+
+// retryOnConflict calls update until it does not fail with
+// store.ErrVersionConflict, at most maxConflictRetries times. update must
+// re-read what it saves on every call.
+func retryOnConflict(update func() error) error {
+	var err error
+	for range maxConflictRetries {
+		if err = update(); !errors.Is(err, store.ErrVersionConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// CanTransitionJob reports whether a job may move from one status to another.
+func CanTransitionJob(from, to model.SampleJobStatus) bool {
+	return slices.Contains(jobTransitions[from], to)
+}
+
+// JobStateMachine owns the status transitions of sample jobs for the sample
+// job service and the job executor. It rejects transitions the job lifecycle
+// does not allow, and saves a transition only if the job's status has not
+// changed since the job was read, so that concurrent transitions (e.g. a
+// stop racing the job's completion) cannot overwrite each other.
+type JobStateMachine struct {
+	store  JobTransitionStore
+	logger *logrus.Entry
+}
+
+// NewJobStateMachine creates a JobStateMachine backed by the given store.
+func NewJobStateMachine(store JobTransitionStore, logger *logrus.Logger) *JobStateMachine {
+	return &JobStateMachine{
+		store:  store,
+		logger: logger.WithField("component", "job_state"),
+	}
+}
+
+// Transition moves job from its status to status to, saving the job's other
+// fields with it, and returns the job as saved. It returns sql.ErrNoRows if
+// the job no longer exists, and an error wrapping store.ErrVersionConflict if
+// the job was updated since it was read; the caller may re-read and retry.
+func (m *JobStateMachine) Transition(ctx context.Context, job model.SampleJob, to model.SampleJobStatus) (model.SampleJob, error) {
+	from := job.Status
+	log := requestLogger(ctx, m.logger).WithFields(logrus.Fields{
+		"sample_job_id": job.ID,
+		"from_status":   from,
+		"to_status":     to,
+	})
+	log.Trace("entering Transition")
+	defer m.logger.Trace("returning from Transition")
+
+	if !CanTransitionJob(from, to) {
+		log.Warn("rejected invalid job status transition")
+		return job, fmt.Errorf("invalid job status transition from %s to %s", from, to)
+	}
+
+	job.Status = to
+	job.UpdatedAt = time.Now().UTC()
+	if err := m.store.TransitionSampleJob(ctx, &job, from); err != nil {
+		if err == sql.ErrNoRows {
+			log.Debug("job not found for status transition")
+			return job, err
+		}
+		log.WithField("error", err.Error()).Warn("failed to save job status transition")
+		return job, fmt.Errorf("transitioning job from %s to %s: %w", from, to, err)
+	}
+	log.Info("job status changed")
+	return job, nil
+}
+
+// TransitionWithRetry is Transition for transitions that change nothing but
+// the job's status: when the job was updated concurrently, it re-reads the job
+// and transitions the stored job instead, as long as its status is unchanged.
+func (m *JobStateMachine) TransitionWithRetry(ctx context.Context, job model.SampleJob, to model.SampleJobStatus) (model.SampleJob, error) {
+	from := job.Status
+	saved := job
+	err := retryOnConflict(func() error {
+		var err error
+		if saved, err = m.Transition(ctx, job, to); !errors.Is(err, store.ErrVersionConflict) {
+			return err
+		}
+		m.logger.WithField("sample_job_id", job.ID).Debug("job was updated concurrently, re-reading it")
+		current, getErr := m.store.GetSampleJob(ctx, job.ID)
+		if getErr != nil {
+			return getErr
+		}
+		if current.Status != from {
+			return fmt.Errorf("%w: job %s is %s, not %s", store.ErrSampleJobStatusChanged, job.ID, current.Status, from)
+		}
+		job = current
+		return err
+	})
+	return saved, err
+}
@@ -0,0 +1,158 @@
+package service_test
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("JobStateMachine", func() {
+	var (
+		memStore *store.MemoryStore
+		states   *service.JobStateMachine
+		job      model.SampleJob
+	)
+
+	BeforeEach(func() {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		memStore = store.NewMemoryStore(logger)
+		states = service.NewJobStateMachine(memStore, logger)
+
+		Expect(memStore.CreateStudy(ctx, model.Study{
+			ID:                    "study-1",
+			Name:                  "Study",
+			Version:               1,
+			Prompts:               []model.NamedPrompt{{Name: "forest", Text: "a forest"}},
+			Steps:                 []int{20},
+			CFGs:                  []float64{7},
+			SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+			Seeds:                 []int64{42},
+			Width:                 512,
+			Height:                512,
+		})).To(Succeed())
+
+		created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		job = model.SampleJob{
+			ID:         "job-1",
+			StudyID:    "study-1",
+			StudyName:  "Study",
+			Status:     model.SampleJobStatusPending,
+			TotalItems: 1,
+			Version:    1,
+			CreatedAt:  created,
+			UpdatedAt:  created,
+		}
+		Expect(memStore.CreateSampleJob(ctx, job)).To(Succeed())
+	})
+
+	DescribeTable("CanTransitionJob",
+		func(from, to model.SampleJobStatus, allowed bool) {
+			Expect(service.CanTransitionJob(from, to)).To(Equal(allowed))
+		},
+		Entry("pending to running", model.SampleJobStatusPending, model.SampleJobStatusRunning, true),
+		Entry("running to stopped", model.SampleJobStatusRunning, model.SampleJobStatusStopped, true),
+		Entry("running to completed", model.SampleJobStatusRunning, model.SampleJobStatusCompleted, true),
+		Entry("stopped to running", model.SampleJobStatusStopped, model.SampleJobStatusRunning, true),
+		Entry("completed with errors to running", model.SampleJobStatusCompletedWithErrors, model.SampleJobStatusRunning, true),
+		Entry("completed to pending", model.SampleJobStatusCompleted, model.SampleJobStatusPending, true),
+		Entry("pending to completed", model.SampleJobStatusPending, model.SampleJobStatusCompleted, false),
+		Entry("stopped to completed", model.SampleJobStatusStopped, model.SampleJobStatusCompleted, false),
+		Entry("running to running", model.SampleJobStatusRunning, model.SampleJobStatusRunning, false),
+		Entry("failed to running", model.SampleJobStatusFailed, model.SampleJobStatusRunning, false),
+	)
+
+	It("saves a valid transition", func() {
+		started, err := states.Transition(ctx, job, model.SampleJobStatusRunning)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(started.Status).To(Equal(model.SampleJobStatusRunning))
+		Expect(started.UpdatedAt).To(BeTemporally(">", job.UpdatedAt))
+
+		saved, err := memStore.GetSampleJob(ctx, job.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(saved.Status).To(Equal(model.SampleJobStatusRunning))
+		Expect(saved.Version).To(Equal(started.Version))
+	})
+
+	It("rejects an invalid transition without saving it", func() {
+		_, err := states.Transition(ctx, job, model.SampleJobStatusCompleted)
+		Expect(err).To(MatchError(ContainSubstring("invalid job status transition from pending to completed")))
+
+		saved, err := memStore.GetSampleJob(ctx, job.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(saved.Status).To(Equal(model.SampleJobStatusPending))
+	})
+
+	It("does not overwrite a status changed since the job was read", func() {
+		running, err := states.Transition(ctx, job, model.SampleJobStatusRunning)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = states.Transition(ctx, running, model.SampleJobStatusStopped)
+		Expect(err).NotTo(HaveOccurred())
+
+		// running is now stale: the stored job is stopped.
+		_, err = states.Transition(ctx, running, model.SampleJobStatusCompleted)
+		Expect(errors.Is(err, store.ErrSampleJobStatusChanged)).To(BeTrue())
+
+		saved, err := memStore.GetSampleJob(ctx, job.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(saved.Status).To(Equal(model.SampleJobStatusStopped))
+	})
+
+	It("reports a version conflict when the job was updated since it was read", func() {
+		running, err := states.Transition(ctx, job, model.SampleJobStatusRunning)
+		Expect(err).NotTo(HaveOccurred())
+		progressed := running
+		progressed.CompletedItems = 1
+		Expect(memStore.UpdateSampleJob(ctx, &progressed)).To(Succeed())
+
+		_, err = states.Transition(ctx, running, model.SampleJobStatusStopped)
+		Expect(errors.Is(err, store.ErrVersionConflict)).To(BeTrue())
+	})
+
+	Describe("TransitionWithRetry", func() {
+		It("transitions the re-read job when it was updated since it was read", func() {
+			running, err := states.Transition(ctx, job, model.SampleJobStatusRunning)
+			Expect(err).NotTo(HaveOccurred())
+			progressed := running
+			progressed.CompletedItems = 1
+			Expect(memStore.UpdateSampleJob(ctx, &progressed)).To(Succeed())
+
+			stopped, err := states.TransitionWithRetry(ctx, running, model.SampleJobStatusStopped)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stopped.Status).To(Equal(model.SampleJobStatusStopped))
+			Expect(stopped.CompletedItems).To(Equal(1))
+
+			saved, err := memStore.GetSampleJob(ctx, job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(saved.Status).To(Equal(model.SampleJobStatusStopped))
+			Expect(saved.CompletedItems).To(Equal(1))
+			Expect(saved.Version).To(Equal(stopped.Version))
+		})
+
+		It("does not retry when the job's status changed", func() {
+			running, err := states.Transition(ctx, job, model.SampleJobStatusRunning)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = states.Transition(ctx, running, model.SampleJobStatusStopped)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = states.TransitionWithRetry(ctx, running, model.SampleJobStatusCompleted)
+			Expect(errors.Is(err, store.ErrSampleJobStatusChanged)).To(BeTrue())
+			Expect(memStore.GetSampleJob(ctx, job.ID)).To(HaveField("Status", model.SampleJobStatusStopped))
+		})
+	})
+
+	It("returns sql.ErrNoRows when the job does not exist", func() {
+		job.ID = "missing"
+		_, err := states.Transition(ctx, job, model.SampleJobStatusRunning)
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+})
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// JobTemplateStore defines the persistence operations the job template
+// service needs.
+type JobTemplateStore interface {
+	ListJobTemplates(ctx context.Context) ([]model.JobTemplate, error)
+	GetJobTemplate(ctx context.Context, id string) (model.JobTemplate, error)
+	CreateJobTemplate(ctx context.Context, t model.JobTemplate) error
+	UpdateJobTemplate(ctx context.Context, t model.JobTemplate) error
+	DeleteJobTemplate(ctx context.Context, id string) error
+	GetStudy(ctx context.Context, id string) (model.Study, error)
+}
+
+// JobTemplateJobCreator creates the sample job of a run template. It is
+// satisfied by SampleJobService.
+type JobTemplateJobCreator interface {
+	CreateWithOverrides(ctx context.Context, trainingRunName string, checkpoints []model.Checkpoint, studyID string, checkpointFilenames []string, steps model.StepFilter, clearExisting bool, missingOnly bool, skipExisting bool, output model.ImageOutputOptions, overrides model.ModelOverrides, appendNewCheckpoints bool, annotations model.SampleJobAnnotations) (model.SampleJob, error)
+}
+
+// JobTemplateService manages job templates and creates sample jobs from them.
+type JobTemplateService struct {
+	store  JobTemplateStore
+	jobs   JobTemplateJobCreator
+	logger *logrus.Entry
+}
+
+// NewJobTemplateService creates a JobTemplateService. Templates can be run
+// once a job creator is set with SetJobCreator.
+func NewJobTemplateService(store JobTemplateStore, logger *logrus.Logger) *JobTemplateService {
+	return &JobTemplateService{
+		store:  store,
+		logger: logger.WithField("component", "job_template"),
+	}
+}
+
+// SetJobCreator sets what creates the sample jobs of run templates. It is
+// not set when sample jobs are not available.
+func (s *JobTemplateService) SetJobCreator(jobs JobTemplateJobCreator) {
+	s.jobs = jobs
+}
+
+// List returns all job templates ordered by name.
+func (s *JobTemplateService) List(ctx context.Context) ([]model.JobTemplate, error) {
+	s.logger.Trace("entering List")
+	defer s.logger.Trace("returning from List")
+
+	templates, err := s.store.ListJobTemplates(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list job templates")
+		return nil, fmt.Errorf("listing job templates: %w", err)
+	}
+	s.logger.WithField("job_template_count", len(templates)).Debug("job templates retrieved from store")
+	if templates == nil {
+		templates = []model.JobTemplate{}
+	}
+	return templates, nil
+}
+
+// Get returns a job template by ID.
+func (s *JobTemplateService) Get(ctx context.Context, id string) (model.JobTemplate, error) {
+	s.logger.WithField("job_template_id", id).Trace("entering Get")
+	defer s.logger.Trace("returning from Get")
+
+	t, err := s.store.GetJobTemplate(ctx, id)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("job_template_id", id).Debug("job template not found")
+		return model.JobTemplate{}, fmt.Errorf("job template %s not found", id)
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_template_id": id,
+			"error":           err.Error(),
+		}).Error("failed to fetch job template")
+		return model.JobTemplate{}, fmt.Errorf("fetching job template: %w", err)
+	}
+	return t, nil
+}
+
+// Create validates and persists a new job template with the configuration
+// of t; its ID and timestamps are assigned.
+func (s *JobTemplateService) Create(ctx context.Context, t model.JobTemplate) (model.JobTemplate, error) {
+	s.logger.WithField("job_template_name", t.Name).Trace("entering Create")
+	defer s.logger.Trace("returning from Create")
+
+	if err := s.validate(ctx, t); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_template_name": t.Name,
+			"error":             err.Error(),
+		}).Warn("job template validation failed")
+		return model.JobTemplate{}, err
+	}
+	now := time.Now().UTC()
+	t.ID = uuid.New().String()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	if err := s.store.CreateJobTemplate(ctx, t); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_template_id": t.ID,
+			"error":           err.Error(),
+		}).Error("failed to create job template")
+		return model.JobTemplate{}, fmt.Errorf("creating job template: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"job_template_id":   t.ID,
+		"job_template_name": t.Name,
+	}).Info("job template created")
+	return t, nil
+}
+
+// Update replaces the configuration of an existing job template with that
+// of t.
+func (s *JobTemplateService) Update(ctx context.Context, t model.JobTemplate) (model.JobTemplate, error) {
+	s.logger.WithField("job_template_id", t.ID).Trace("entering Update")
+	defer s.logger.Trace("returning from Update")
+
+	existing, err := s.Get(ctx, t.ID)
+	if err != nil {
+		return model.JobTemplate{}, err
+	}
+	if err := s.validate(ctx, t); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_template_id": t.ID,
+			"error":           err.Error(),
+		}).Warn("job template validation failed")
+		return model.JobTemplate{}, err
+	}
+	t.CreatedAt = existing.CreatedAt
+	t.UpdatedAt = time.Now().UTC()
+	if err := s.store.UpdateJobTemplate(ctx, t); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_template_id": t.ID,
+			"error":           err.Error(),
+		}).Error("failed to update job template")
+		return model.JobTemplate{}, fmt.Errorf("updating job template: %w", err)
+	}
+	s.logger.WithField("job_template_id", t.ID).Info("job template updated")
+	return t, nil
+}
+
+// Delete removes a job template by ID. Jobs created from it are kept.
+func (s *JobTemplateService) Delete(ctx context.Context, id string) error {
+	s.logger.WithField("job_template_id", id).Trace("entering Delete")
+	defer s.logger.Trace("returning from Delete")
+
+	err := s.store.DeleteJobTemplate(ctx, id)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("job_template_id", id).Debug("job template not found for deletion")
+		return fmt.Errorf("job template %s not found", id)
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_template_id": id,
+			"error":           err.Error(),
+		}).Error("failed to delete job template")
+		return fmt.Errorf("deleting job template: %w", err)
+	}
+	s.logger.WithField("job_template_id", id).Info("job template deleted")
+	return nil
+}
+
+// Run creates a sample job of a training run from a job template. Only the
+// run's checkpoints that match the template's checkpoint filter are sampled;
+// a filter that matches none is an error. The job is queued like any other
+// and starts when the executor picks it up.
+func (s *JobTemplateService) Run(ctx context.Context, id string, run model.TrainingRun) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"job_template_id":   id,
+		"training_run_name": run.Name,
+	}).Trace("entering Run")
+	defer log.Trace("returning from Run")
+
+	if s.jobs == nil {
+		return model.SampleJob{}, fmt.Errorf("sample jobs not available: ComfyUI is not configured")
+	}
+	t, err := s.Get(ctx, id)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+
+	checkpoints := run.Checkpoints
+	if t.CheckpointFilter != "" {
+		checkpoints = nil
+		for _, cp := range run.Checkpoints {
+			if ok, _ := filepath.Match(t.CheckpointFilter, cp.Filename); ok {
+				checkpoints = append(checkpoints, cp)
+			}
+		}
+		log.WithFields(logrus.Fields{
+			"job_template_id":   id,
+			"checkpoint_filter": t.CheckpointFilter,
+			"matched_count":     len(checkpoints),
+		}).Debug("filtered checkpoints by template filter")
+		if len(checkpoints) == 0 {
+			return model.SampleJob{}, fmt.Errorf("no checkpoint of training run %s matches the filter %q", run.Name, t.CheckpointFilter)
+		}
+	}
+
+	job, err := s.jobs.CreateWithOverrides(ctx, run.Name, checkpoints, t.StudyID, nil, model.StepFilter{}, t.ClearExisting, t.MissingOnly, t.SkipExisting, t.Output, t.Overrides, t.AppendNewCheckpoints, model.SampleJobAnnotations{})
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+	log.WithFields(logrus.Fields{
+		"job_template_id":   id,
+		"sample_job_id":     job.ID,
+		"training_run_name": run.Name,
+	}).Info("sample job created from job template")
+	return job, nil
+}
+
+// validate checks a job template's configuration.
+func (s *JobTemplateService) validate(ctx context.Context, t model.JobTemplate) error {
+	if t.Name == "" {
+		return fmt.Errorf("job template name must not be empty")
+	}
+	if _, err := filepath.Match(t.CheckpointFilter, ""); err != nil {
+		return fmt.Errorf("invalid checkpoint filter %q: %w", t.CheckpointFilter, err)
+	}
+	if t.Output.Format != "" && !t.Output.Format.IsValid() {
+		return fmt.Errorf("unsupported output format %q", t.Output.Format)
+	}
+	// Not worded "not found", which the API reports for the template itself.
+	if _, err := s.store.GetStudy(ctx, t.StudyID); err == sql.ErrNoRows {
+		return fmt.Errorf("unknown study %s", t.StudyID)
+	} else if err != nil {
+		return fmt.Errorf("fetching study: %w", err)
+	}
+	return nil
+}
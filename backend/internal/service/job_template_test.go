@@ -0,0 +1,240 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeJobTemplateStore is an in-memory test double for service.JobTemplateStore.
+type fakeJobTemplateStore struct {
+	templates map[string]model.JobTemplate
+	studies   map[string]model.Study
+}
+
+func newFakeJobTemplateStore() *fakeJobTemplateStore {
+	return &fakeJobTemplateStore{
+		templates: make(map[string]model.JobTemplate),
+		studies:   map[string]model.Study{"study-1": {ID: "study-1", Name: "Sweep"}},
+	}
+}
+
+func (f *fakeJobTemplateStore) ListJobTemplates(ctx context.Context) ([]model.JobTemplate, error) {
+	var result []model.JobTemplate
+	for _, t := range f.templates {
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (f *fakeJobTemplateStore) GetJobTemplate(ctx context.Context, id string) (model.JobTemplate, error) {
+	t, ok := f.templates[id]
+	if !ok {
+		return model.JobTemplate{}, sql.ErrNoRows
+	}
+	return t, nil
+}
+
+func (f *fakeJobTemplateStore) CreateJobTemplate(ctx context.Context, t model.JobTemplate) error {
+	f.templates[t.ID] = t
+	return nil
+}
+
+func (f *fakeJobTemplateStore) UpdateJobTemplate(ctx context.Context, t model.JobTemplate) error {
+	if _, ok := f.templates[t.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	f.templates[t.ID] = t
+	return nil
+}
+
+func (f *fakeJobTemplateStore) DeleteJobTemplate(ctx context.Context, id string) error {
+	if _, ok := f.templates[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.templates, id)
+	return nil
+}
+
+func (f *fakeJobTemplateStore) GetStudy(ctx context.Context, id string) (model.Study, error) {
+	s, ok := f.studies[id]
+	if !ok {
+		return model.Study{}, sql.ErrNoRows
+	}
+	return s, nil
+}
+
+// fakeJobTemplateJobCreator records the sample job a template run creates.
+type fakeJobTemplateJobCreator struct {
+	trainingRunName string
+	checkpoints     []model.Checkpoint
+	studyID         string
+	clearExisting   bool
+	output          model.ImageOutputOptions
+	overrides       model.ModelOverrides
+	err             error
+}
+
+func (f *fakeJobTemplateJobCreator) CreateWithOverrides(ctx context.Context, trainingRunName string, checkpoints []model.Checkpoint, studyID string, checkpointFilenames []string, steps model.StepFilter, clearExisting bool, missingOnly bool, skipExisting bool, output model.ImageOutputOptions, overrides model.ModelOverrides, appendNewCheckpoints bool, annotations model.SampleJobAnnotations) (model.SampleJob, error) {
+	if f.err != nil {
+		return model.SampleJob{}, f.err
+	}
+	f.trainingRunName = trainingRunName
+	f.checkpoints = checkpoints
+	f.studyID = studyID
+	f.clearExisting = clearExisting
+	f.output = output
+	f.overrides = overrides
+	return model.SampleJob{ID: "job-1", TotalItems: len(checkpoints)}, nil
+}
+
+var _ = Describe("JobTemplateService", func() {
+	var (
+		st   *fakeJobTemplateStore
+		jobs *fakeJobTemplateJobCreator
+		svc  *service.JobTemplateService
+		run  model.TrainingRun
+	)
+
+	BeforeEach(func() {
+		st = newFakeJobTemplateStore()
+		jobs = &fakeJobTemplateJobCreator{}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewJobTemplateService(st, logger)
+		svc.SetJobCreator(jobs)
+		run = model.TrainingRun{
+			Name: "my-model",
+			Checkpoints: []model.Checkpoint{
+				{Filename: "my-model-step00001000.safetensors", StepNumber: 1000},
+				{Filename: "my-model-step00002000.safetensors", StepNumber: 2000},
+				{Filename: "my-model.safetensors", StepNumber: 3000},
+			},
+		}
+	})
+
+	newTemplate := func() model.JobTemplate {
+		shift := 3.1
+		return model.JobTemplate{
+			Name:          "Nightly",
+			StudyID:       "study-1",
+			ClearExisting: true,
+			Output:        model.ImageOutputOptions{Format: model.OutputFormatWebP, Quality: 80},
+			Overrides:     model.ModelOverrides{VAE: "ae.safetensors", Shift: &shift},
+		}
+	}
+
+	Describe("Create", func() {
+		It("assigns an ID and timestamps", func() {
+			t, err := svc.Create(ctx, newTemplate())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.ID).NotTo(BeEmpty())
+			Expect(t.CreatedAt).NotTo(BeZero())
+			Expect(t.UpdatedAt).To(Equal(t.CreatedAt))
+			Expect(st.templates).To(HaveKey(t.ID))
+		})
+
+		DescribeTable("rejects an invalid template",
+			func(mutate func(*model.JobTemplate), msg string) {
+				t := newTemplate()
+				mutate(&t)
+				_, err := svc.Create(ctx, t)
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+				Expect(st.templates).To(BeEmpty())
+			},
+			Entry("empty name", func(t *model.JobTemplate) { t.Name = "" }, "name must not be empty"),
+			Entry("malformed filter", func(t *model.JobTemplate) { t.CheckpointFilter = "[" }, "invalid checkpoint filter"),
+			Entry("unknown output format", func(t *model.JobTemplate) { t.Output.Format = "bmp" }, "unsupported output format"),
+			Entry("unknown study", func(t *model.JobTemplate) { t.StudyID = "missing" }, "unknown study missing"),
+		)
+	})
+
+	Describe("Update", func() {
+		It("keeps the creation time", func() {
+			created, err := svc.Create(ctx, newTemplate())
+			Expect(err).NotTo(HaveOccurred())
+
+			t := newTemplate()
+			t.ID = created.ID
+			t.Name = "Weekly"
+			updated, err := svc.Update(ctx, t)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Name).To(Equal("Weekly"))
+			Expect(updated.CreatedAt).To(Equal(created.CreatedAt))
+		})
+
+		It("reports an unknown template as not found", func() {
+			t := newTemplate()
+			t.ID = "missing"
+			_, err := svc.Update(ctx, t)
+			Expect(err).To(MatchError("job template missing not found"))
+		})
+	})
+
+	Describe("Run", func() {
+		It("creates a job of every checkpoint when the filter is empty", func() {
+			t, err := svc.Create(ctx, newTemplate())
+			Expect(err).NotTo(HaveOccurred())
+
+			job, err := svc.Run(ctx, t.ID, run)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.ID).To(Equal("job-1"))
+			Expect(jobs.trainingRunName).To(Equal("my-model"))
+			Expect(jobs.checkpoints).To(Equal(run.Checkpoints))
+			Expect(jobs.studyID).To(Equal("study-1"))
+			Expect(jobs.clearExisting).To(BeTrue())
+			Expect(jobs.output).To(Equal(t.Output))
+			Expect(jobs.overrides).To(Equal(t.Overrides))
+		})
+
+		It("samples only the checkpoints matching the filter", func() {
+			tmpl := newTemplate()
+			tmpl.CheckpointFilter = "*-step*.safetensors"
+			t, err := svc.Create(ctx, tmpl)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.Run(ctx, t.ID, run)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobs.checkpoints).To(Equal(run.Checkpoints[:2]))
+		})
+
+		It("rejects a filter that matches no checkpoint", func() {
+			tmpl := newTemplate()
+			tmpl.CheckpointFilter = "other-*"
+			t, err := svc.Create(ctx, tmpl)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.Run(ctx, t.ID, run)
+			Expect(err).To(MatchError(`no checkpoint of training run my-model matches the filter "other-*"`))
+		})
+
+		It("returns the error of the job creator", func() {
+			t, err := svc.Create(ctx, newTemplate())
+			Expect(err).NotTo(HaveOccurred())
+			jobs.err = errors.New("study study-1 not found")
+
+			_, err = svc.Run(ctx, t.ID, run)
+			Expect(err).To(MatchError("study study-1 not found"))
+		})
+
+		It("reports an unknown template as not found", func() {
+			_, err := svc.Run(ctx, "missing", run)
+			Expect(err).To(MatchError("job template missing not found"))
+		})
+
+		It("fails when sample jobs are not available", func() {
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			_, err := service.NewJobTemplateService(st, logger).Run(ctx, "t-1", run)
+			Expect(err).To(MatchError(ContainSubstring("ComfyUI is not configured")))
+		})
+	})
+})
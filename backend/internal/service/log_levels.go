@@ -0,0 +1,84 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ComponentLogLevels applies per-component log level overrides to a logger
+// shared by all components. Entries are matched to a component by their
+// "component" field; entries of components without an override, and entries
+// without a component, are logged at the base level.
+//
+// logrus decides whether to log an entry from the logger's level alone, so
+// ComponentLogLevels lowers the logger's level to the most verbose of the base
+// level and the overrides, and wraps the logger's formatter to discard the
+// entries above their component's level.
+type ComponentLogLevels struct {
+	mu        sync.RWMutex
+	logger    *logrus.Logger
+	formatter logrus.Formatter
+	base      logrus.Level
+	overrides map[string]logrus.Level
+}
+
+// NewComponentLogLevels installs ComponentLogLevels on logger. The logger's
+// current level becomes the base level and its current formatter formats the
+// entries that are logged.
+func NewComponentLogLevels(logger *logrus.Logger) *ComponentLogLevels {
+	l := &ComponentLogLevels{
+		logger:    logger,
+		formatter: logger.Formatter,
+		base:      logger.GetLevel(),
+	}
+	logger.SetFormatter(l)
+	return l
+}
+
+// SetOverrides replaces the overrides with levels, a map from component name
+// to level name. An invalid level name is reported without changing the
+// overrides.
+func (l *ComponentLogLevels) SetOverrides(levels map[string]string) error {
+	overrides := make(map[string]logrus.Level, len(levels))
+	for component, name := range levels {
+		level, err := logrus.ParseLevel(name)
+		if err != nil {
+			return err
+		}
+		overrides[component] = level
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides = overrides
+	lowest := l.base
+	for _, level := range overrides {
+		if level > lowest {
+			lowest = level
+		}
+	}
+	l.logger.SetLevel(lowest)
+	return nil
+}
+
+// Format formats entry with the wrapped formatter, or returns no output if
+// entry is above its component's level.
+func (l *ComponentLogLevels) Format(entry *logrus.Entry) ([]byte, error) {
+	if !l.enabled(entry) {
+		return nil, nil
+	}
+	return l.formatter.Format(entry)
+}
+
+func (l *ComponentLogLevels) enabled(entry *logrus.Entry) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	level := l.base
+	if component, ok := entry.Data["component"].(string); ok {
+		if override, ok := l.overrides[component]; ok {
+			level = override
+		}
+	}
+	return entry.Level <= level
+}
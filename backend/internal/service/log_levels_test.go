@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("ComponentLogLevels", func() {
+	var (
+		out    *bytes.Buffer
+		logger *logrus.Logger
+		levels *service.ComponentLogLevels
+	)
+
+	BeforeEach(func() {
+		out = &bytes.Buffer{}
+		logger = logrus.New()
+		logger.SetOutput(out)
+		logger.SetFormatter(&logrus.JSONFormatter{})
+		logger.SetLevel(logrus.InfoLevel)
+		levels = service.NewComponentLogLevels(logger)
+	})
+
+	It("logs at the base level without overrides", func() {
+		logger.WithField("component", "store").Debug("store debug")
+		logger.WithField("component", "store").Info("store info")
+
+		Expect(out.String()).NotTo(ContainSubstring("store debug"))
+		Expect(out.String()).To(ContainSubstring("store info"))
+	})
+
+	It("logs a component more verbosely than the base level", func() {
+		Expect(levels.SetOverrides(map[string]string{"comfyui_http": "debug"})).To(Succeed())
+
+		logger.WithField("component", "comfyui_http").Debug("http debug")
+		logger.WithField("component", "comfyui_http").Trace("http trace")
+		logger.WithField("component", "store").Debug("store debug")
+		logger.Debug("plain debug")
+
+		Expect(out.String()).To(ContainSubstring("http debug"))
+		Expect(out.String()).NotTo(ContainSubstring("http trace"))
+		Expect(out.String()).NotTo(ContainSubstring("store debug"))
+		Expect(out.String()).NotTo(ContainSubstring("plain debug"))
+	})
+
+	It("logs a component less verbosely than the base level", func() {
+		Expect(levels.SetOverrides(map[string]string{"store": "warn"})).To(Succeed())
+
+		logger.WithField("component", "store").Info("store info")
+		logger.WithField("component", "store").Warn("store warn")
+		logger.WithField("component", "preset").Info("preset info")
+
+		Expect(out.String()).NotTo(ContainSubstring("store info"))
+		Expect(out.String()).To(ContainSubstring("store warn"))
+		Expect(out.String()).To(ContainSubstring("preset info"))
+	})
+
+	It("formats entries with the logger's formatter", func() {
+		logger.WithField("component", "store").Info("store info")
+
+		Expect(out.String()).To(ContainSubstring(`"component":"store"`))
+		Expect(out.String()).To(ContainSubstring(`"msg":"store info"`))
+	})
+
+	It("restores the base level when overrides are removed", func() {
+		Expect(levels.SetOverrides(map[string]string{"job_executor": "trace"})).To(Succeed())
+		Expect(logger.GetLevel()).To(Equal(logrus.TraceLevel))
+
+		Expect(levels.SetOverrides(nil)).To(Succeed())
+		Expect(logger.GetLevel()).To(Equal(logrus.InfoLevel))
+		logger.WithField("component", "job_executor").Debug("executor debug")
+		Expect(out.String()).NotTo(ContainSubstring("executor debug"))
+	})
+
+	It("rejects an invalid level and keeps the overrides", func() {
+		Expect(levels.SetOverrides(map[string]string{"store": "warn"})).To(Succeed())
+		Expect(levels.SetOverrides(map[string]string{"store": "loud"})).NotTo(Succeed())
+
+		logger.WithField("component", "store").Info("store info")
+		Expect(out.String()).NotTo(ContainSubstring("store info"))
+	})
+})
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// PinStore defines the persistence operations the pin service needs.
+type PinStore interface {
+	ListPins(ctx context.Context) ([]model.Pin, error)
+	GetPin(ctx context.Context, path string) (model.Pin, error)
+	CreatePin(ctx context.Context, p model.Pin) error
+	DeletePin(ctx context.Context, path string) error
+}
+
+// PinGuard is consulted by cleanup code before removing anything under the
+// sample directory. IsProtected reports whether removing relPath (a file or a
+// directory, relative to the sample directory) would destroy pinned content.
+type PinGuard interface {
+	IsProtected(ctx context.Context, relPath string) bool
+}
+
+// PinService manages pins that protect sample images and checkpoint sample
+// sets from cleanup.
+type PinService struct {
+	store  PinStore
+	logger *logrus.Entry
+}
+
+// NewPinService creates a PinService backed by the given store.
+func NewPinService(store PinStore, logger *logrus.Logger) *PinService {
+	return &PinService{
+		store:  store,
+		logger: logger.WithField("component", "pin"),
+	}
+}
+
+// List returns all pins ordered by path.
+func (s *PinService) List(ctx context.Context) ([]model.Pin, error) {
+	s.logger.Trace("entering List")
+	defer s.logger.Trace("returning from List")
+
+	pins, err := s.store.ListPins(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list pins")
+		return nil, fmt.Errorf("listing pins: %w", err)
+	}
+	s.logger.WithField("pin_count", len(pins)).Debug("pins retrieved from store")
+	if pins == nil {
+		pins = []model.Pin{}
+	}
+	return pins, nil
+}
+
+// PinSet returns the current pins as an in-memory lookup.
+func (s *PinService) PinSet(ctx context.Context) (model.PinSet, error) {
+	pins, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewPinSet(pins), nil
+}
+
+// Pin protects relPath from cleanup and returns the stored pin. Pinning an
+// already-pinned path is a no-op that returns the existing pin.
+func (s *PinService) Pin(ctx context.Context, relPath string, kind model.PinKind) (model.Pin, error) {
+	s.logger.WithFields(logrus.Fields{
+		"path": relPath,
+		"kind": kind,
+	}).Trace("entering Pin")
+	defer s.logger.Trace("returning from Pin")
+
+	path, err := validatePinPath(relPath)
+	if err != nil {
+		s.logger.WithField("path", relPath).Warn("pin path validation failed")
+		return model.Pin{}, err
+	}
+	if kind != model.PinKindImage && kind != model.PinKindCheckpoint {
+		s.logger.WithField("kind", kind).Warn("pin kind validation failed")
+		return model.Pin{}, fmt.Errorf("invalid pin kind %q: must be %q or %q", kind, model.PinKindImage, model.PinKindCheckpoint)
+	}
+
+	p := model.Pin{
+		Path:      path,
+		Kind:      kind,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.store.CreatePin(ctx, p); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to create pin")
+		return model.Pin{}, fmt.Errorf("creating pin: %w", err)
+	}
+	// Pinning an already-pinned path keeps the existing pin, so return the
+	// stored row rather than p.
+	p, err = s.store.GetPin(ctx, path)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to read pin")
+		return model.Pin{}, fmt.Errorf("reading pin: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"path": path,
+		"kind": kind,
+	}).Info("path pinned")
+	return p, nil
+}
+
+// Unpin removes the pin on relPath. Returns a not-found error if the path is
+// not pinned.
+func (s *PinService) Unpin(ctx context.Context, relPath string) error {
+	s.logger.WithField("path", relPath).Trace("entering Unpin")
+	defer s.logger.Trace("returning from Unpin")
+
+	path, err := validatePinPath(relPath)
+	if err != nil {
+		s.logger.WithField("path", relPath).Warn("pin path validation failed")
+		return err
+	}
+	if err := s.store.DeletePin(ctx, path); err == sql.ErrNoRows {
+		s.logger.WithField("path", path).Debug("pin not found")
+		return fmt.Errorf("pin %s not found", path)
+	} else if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to delete pin")
+		return fmt.Errorf("deleting pin: %w", err)
+	}
+	s.logger.WithField("path", path).Info("path unpinned")
+	return nil
+}
+
+// IsProtected implements PinGuard. If the pins cannot be loaded the path is
+// reported as protected, so a database failure never causes pinned content to
+// be deleted.
+func (s *PinService) IsProtected(ctx context.Context, relPath string) bool {
+	set, err := s.PinSet(ctx)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  relPath,
+			"error": err.Error(),
+		}).Warn("failed to load pins, treating path as protected")
+		return true
+	}
+	return set.Protects(relPath)
+}
+
+// validatePinPath rejects empty, absolute, and traversal paths and returns the
+// normalized pin key.
+func validatePinPath(relPath string) (string, error) {
+	if relPath == "" {
+		return "", fmt.Errorf("invalid path: must not be empty")
+	}
+	if strings.HasPrefix(relPath, "/") || strings.HasPrefix(relPath, "\\") {
+		return "", fmt.Errorf("invalid path: must be relative to the sample directory")
+	}
+	for _, part := range strings.Split(strings.ReplaceAll(relPath, "\\", "/"), "/") {
+		if part == ".." {
+			return "", fmt.Errorf("invalid path: must not contain '..'")
+		}
+	}
+	return model.NormalizePinPath(relPath), nil
+}
@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakePinStore is an in-memory test double for service.PinStore.
+type fakePinStore struct {
+	pins    map[string]model.Pin
+	listErr error
+}
+
+func newFakePinStore() *fakePinStore {
+	return &fakePinStore{pins: make(map[string]model.Pin)}
+}
+
+func (f *fakePinStore) ListPins(ctx context.Context) ([]model.Pin, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	var result []model.Pin
+	for _, p := range f.pins {
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (f *fakePinStore) GetPin(ctx context.Context, path string) (model.Pin, error) {
+	p, ok := f.pins[path]
+	if !ok {
+		return model.Pin{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (f *fakePinStore) CreatePin(ctx context.Context, p model.Pin) error {
+	if _, ok := f.pins[p.Path]; !ok {
+		f.pins[p.Path] = p
+	}
+	return nil
+}
+
+func (f *fakePinStore) DeletePin(ctx context.Context, path string) error {
+	if _, ok := f.pins[path]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.pins, path)
+	return nil
+}
+
+var _ = Describe("PinService", func() {
+	var (
+		store *fakePinStore
+		svc   *service.PinService
+	)
+
+	BeforeEach(func() {
+		store = newFakePinStore()
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewPinService(store, logger)
+	})
+
+	Describe("Pin", func() {
+		It("stores a normalized path", func() {
+			pin, err := svc.Pin(ctx, "run//study/ckpt.safetensors/", model.PinKindCheckpoint)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pin.Path).To(Equal("run/study/ckpt.safetensors"))
+			Expect(store.pins).To(HaveKey("run/study/ckpt.safetensors"))
+		})
+
+		It("returns the existing pin when the path is already pinned", func() {
+			existing := model.Pin{Path: "a.png", Kind: model.PinKindImage, CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+			store.pins[existing.Path] = existing
+
+			pin, err := svc.Pin(ctx, "a.png", model.PinKindImage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pin).To(Equal(existing))
+		})
+
+		DescribeTable("rejects invalid paths",
+			func(path string) {
+				_, err := svc.Pin(ctx, path, model.PinKindImage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid path"))
+			},
+			Entry("empty", ""),
+			Entry("absolute", "/etc/passwd"),
+			Entry("traversal", "run/../../etc/passwd"),
+		)
+
+		It("rejects an unknown kind", func() {
+			_, err := svc.Pin(ctx, "a.png", model.PinKind("folder"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid pin kind"))
+		})
+	})
+
+	Describe("Unpin", func() {
+		It("removes an existing pin", func() {
+			_, err := svc.Pin(ctx, "a.png", model.PinKindImage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(svc.Unpin(ctx, "a.png")).To(Succeed())
+			Expect(store.pins).To(BeEmpty())
+		})
+
+		It("returns a not found error when the path is not pinned", func() {
+			err := svc.Unpin(ctx, "a.png")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not found"))
+		})
+	})
+
+	Describe("IsProtected", func() {
+		It("protects pinned images and directories containing them", func() {
+			_, err := svc.Pin(ctx, "run/study/ckpt.safetensors/a.png", model.PinKindImage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(svc.IsProtected(ctx, "run/study/ckpt.safetensors/a.png")).To(BeTrue())
+			Expect(svc.IsProtected(ctx, "run/study/ckpt.safetensors")).To(BeTrue())
+			Expect(svc.IsProtected(ctx, "run/study/other.safetensors")).To(BeFalse())
+		})
+
+		It("fails safe and reports protected when pins cannot be loaded", func() {
+			store.listErr = errors.New("db error")
+			Expect(svc.IsProtected(ctx, "anything")).To(BeTrue())
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -12,11 +13,11 @@ import (
 
 // PresetStore defines the persistence operations the preset service needs.
 type PresetStore interface {
-	ListPresets() ([]model.Preset, error)
-	GetPreset(id string) (model.Preset, error)
-	CreatePreset(p model.Preset) error
-	UpdatePreset(p model.Preset) error
-	DeletePreset(id string) error
+	ListPresets(ctx context.Context) ([]model.Preset, error)
+	GetPreset(ctx context.Context, id string) (model.Preset, error)
+	CreatePreset(ctx context.Context, p model.Preset) error
+	UpdatePreset(ctx context.Context, p model.Preset) error
+	DeletePreset(ctx context.Context, id string) error
 }
 
 // PresetService manages preset CRUD operations.
@@ -33,115 +34,346 @@ func NewPresetService(store PresetStore, logger *logrus.Logger) *PresetService {
 	}
 }
 
-// List returns all presets.
-func (s *PresetService) List() ([]model.Preset, error) {
-	s.logger.Trace("entering List")
-	defer s.logger.Trace("returning from List")
+// List returns the presets of owner. An empty owner lists the presets of the
+// request's user and the unowned presets, or every preset for requests without
+// a user; model.OwnerAll lists every preset. Only admin users may list the
+// presets of other users.
+func (s *PresetService) List(ctx context.Context, owner string) ([]model.Preset, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("owner", owner).Trace("entering List")
+	defer log.Trace("returning from List")
 
-	presets, err := s.store.ListPresets()
+	scope, err := ownerScope(ctx, owner)
 	if err != nil {
-		s.logger.WithError(err).Error("failed to list presets")
+		log.WithError(err).Warn("preset list denied")
+		return nil, err
+	}
+	presets, err := s.store.ListPresets(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to list presets")
 		return nil, fmt.Errorf("listing presets: %w", err)
 	}
-	s.logger.WithField("preset_count", len(presets)).Debug("presets retrieved from store")
-	if presets == nil {
-		presets = []model.Preset{}
+	log.WithField("preset_count", len(presets)).Debug("presets retrieved from store")
+	result := []model.Preset{}
+	for _, p := range presets {
+		if scope.Includes(p.Owner) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// ListForTrainingRun returns the presets of owner, as List selects them, of a
+// training run together with the presets that apply to every training run.
+func (s *PresetService) ListForTrainingRun(ctx context.Context, trainingRun, owner string) ([]model.Preset, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("training_run", trainingRun).Trace("entering ListForTrainingRun")
+	defer log.Trace("returning from ListForTrainingRun")
+
+	presets, err := s.List(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	result := []model.Preset{}
+	for _, p := range presets {
+		if p.TrainingRun == "" || p.TrainingRun == trainingRun {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// Default returns the default preset of a training run. Each user has their
+// own default; a user without one gets the unowned default.
+func (s *PresetService) Default(ctx context.Context, trainingRun string) (model.Preset, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("training_run", trainingRun).Trace("entering Default")
+	defer log.Trace("returning from Default")
+
+	presets, err := s.store.ListPresets(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to list presets")
+		return model.Preset{}, fmt.Errorf("listing presets: %w", err)
+	}
+	owner := ownerOf(ctx)
+	var unowned *model.Preset
+	for i, p := range presets {
+		if !p.Default || p.TrainingRun != trainingRun {
+			continue
+		}
+		if p.Owner == owner {
+			return p, nil
+		}
+		if p.Owner == "" && unowned == nil {
+			unowned = &presets[i]
+		}
+	}
+	if unowned != nil {
+		return *unowned, nil
 	}
-	return presets, nil
+	log.WithField("training_run", trainingRun).Debug("training run has no default preset")
+	return model.Preset{}, fmt.Errorf("default preset of training run %q not found", trainingRun)
 }
 
-// Create validates and persists a new preset, returning the created preset.
-func (s *PresetService) Create(name string, mapping model.PresetMapping) (model.Preset, error) {
-	s.logger.WithField("preset_name", name).Trace("entering Create")
-	defer s.logger.Trace("returning from Create")
+// Create validates and persists a new preset from the name, mapping, training
+// run, view and default flag of p, returning the created preset. The preset is
+// owned by the request's user. When it is its training run's default, the
+// owner's previous default of the run stops being one.
+func (s *PresetService) Create(ctx context.Context, p model.Preset) (model.Preset, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("preset_name", p.Name).Trace("entering Create")
+	defer log.Trace("returning from Create")
 
-	if name == "" {
-		s.logger.Warn("preset name validation failed: name is empty")
-		return model.Preset{}, fmt.Errorf("preset name must not be empty")
+	if err := validatePreset(p); err != nil {
+		log.WithField("error", err.Error()).Warn("preset validation failed")
+		return model.Preset{}, err
 	}
 	now := time.Now().UTC()
-	p := model.Preset{
-		ID:        uuid.New().String(),
-		Name:      name,
-		Mapping:   mapping,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-	if err := s.store.CreatePreset(p); err != nil {
-		s.logger.WithFields(logrus.Fields{
+	p = model.Preset{
+		ID:          uuid.New().String(),
+		Name:        p.Name,
+		Mapping:     p.Mapping,
+		TrainingRun: p.TrainingRun,
+		View:        p.View,
+		Default:     p.Default,
+		Owner:       ownerOf(ctx),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.store.CreatePreset(ctx, p); err != nil {
+		log.WithFields(logrus.Fields{
 			"preset_id":   p.ID,
-			"preset_name": name,
+			"preset_name": p.Name,
 			"error":       err.Error(),
 		}).Error("failed to create preset")
 		return model.Preset{}, fmt.Errorf("creating preset: %w", err)
 	}
-	s.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"preset_id":   p.ID,
-		"preset_name": name,
+		"preset_name": p.Name,
 	}).Info("preset created")
 	return p, nil
 }
 
-// Update modifies an existing preset's name and mapping.
-func (s *PresetService) Update(id string, name string, mapping model.PresetMapping) (model.Preset, error) {
-	s.logger.WithFields(logrus.Fields{
+// Update replaces an existing preset's name, mapping, training run, view and
+// default flag with those of p. A preset owned by a user may only be changed
+// by that user and admin users.
+func (s *PresetService) Update(ctx context.Context, id string, p model.Preset) (model.Preset, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
 		"preset_id":   id,
-		"preset_name": name,
+		"preset_name": p.Name,
 	}).Trace("entering Update")
-	defer s.logger.Trace("returning from Update")
+	defer log.Trace("returning from Update")
 
-	if name == "" {
-		s.logger.WithField("preset_id", id).Warn("preset name validation failed: name is empty")
-		return model.Preset{}, fmt.Errorf("preset name must not be empty")
+	if err := validatePreset(p); err != nil {
+		log.WithFields(logrus.Fields{
+			"preset_id": id,
+			"error":     err.Error(),
+		}).Warn("preset validation failed")
+		return model.Preset{}, err
 	}
-	existing, err := s.store.GetPreset(id)
+	existing, err := s.store.GetPreset(ctx, id)
 	if err == sql.ErrNoRows {
-		s.logger.WithField("preset_id", id).Debug("preset not found")
+		log.WithField("preset_id", id).Debug("preset not found")
 		return model.Preset{}, fmt.Errorf("preset %s not found", id)
 	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"preset_id": id,
 			"error":     err.Error(),
 		}).Error("failed to fetch preset for update")
 		return model.Preset{}, fmt.Errorf("fetching preset: %w", err)
 	}
-	s.logger.WithField("preset_id", id).Debug("fetched existing preset from store")
-	existing.Name = name
-	existing.Mapping = mapping
+	log.WithField("preset_id", id).Debug("fetched existing preset from store")
+	if err := checkOwner(ctx, "preset", id, existing.Owner); err != nil {
+		log.WithError(err).Warn("preset change denied")
+		return model.Preset{}, err
+	}
+	existing.Name = p.Name
+	existing.Mapping = p.Mapping
+	existing.TrainingRun = p.TrainingRun
+	existing.View = p.View
+	existing.Default = p.Default
 	existing.UpdatedAt = time.Now().UTC()
-	if err := s.store.UpdatePreset(existing); err != nil {
-		s.logger.WithFields(logrus.Fields{
+	if err := s.store.UpdatePreset(ctx, existing); err != nil {
+		log.WithFields(logrus.Fields{
 			"preset_id":   id,
-			"preset_name": name,
+			"preset_name": p.Name,
 			"error":       err.Error(),
 		}).Error("failed to update preset")
 		return model.Preset{}, fmt.Errorf("updating preset: %w", err)
 	}
-	s.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"preset_id":   id,
-		"preset_name": name,
+		"preset_name": p.Name,
 	}).Info("preset updated")
 	return existing, nil
 }
 
-// Delete removes a preset by ID.
-func (s *PresetService) Delete(id string) error {
-	s.logger.WithField("preset_id", id).Trace("entering Delete")
-	defer s.logger.Trace("returning from Delete")
+// validatePreset checks the fields of a preset that Create and Update take.
+func validatePreset(p model.Preset) error {
+	if p.Name == "" {
+		return fmt.Errorf("preset name must not be empty")
+	}
+	if p.Default && p.TrainingRun == "" {
+		return fmt.Errorf("a default preset must name its training run")
+	}
+	sorted := make(map[string]bool, len(p.View.Sort))
+	for i, sort := range p.View.Sort {
+		if sort.Dimension == "" {
+			return fmt.Errorf("view sort[%d]: dimension must not be empty", i)
+		}
+		if sorted[sort.Dimension] {
+			return fmt.Errorf("view sort[%d]: dimension %q is sorted more than once", i, sort.Dimension)
+		}
+		sorted[sort.Dimension] = true
+	}
+	return nil
+}
+
+// Delete removes a preset by ID. A preset owned by a user may only be
+// removed by that user and admin users.
+func (s *PresetService) Delete(ctx context.Context, id string) error {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("preset_id", id).Trace("entering Delete")
+	defer log.Trace("returning from Delete")
 
-	err := s.store.DeletePreset(id)
+	existing, err := s.store.GetPreset(ctx, id)
 	if err == sql.ErrNoRows {
-		s.logger.WithField("preset_id", id).Debug("preset not found for deletion")
+		log.WithField("preset_id", id).Debug("preset not found for deletion")
 		return fmt.Errorf("preset %s not found", id)
 	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
+			"preset_id": id,
+			"error":     err.Error(),
+		}).Error("failed to fetch preset for deletion")
+		return fmt.Errorf("fetching preset: %w", err)
+	}
+	if err := checkOwner(ctx, "preset", id, existing.Owner); err != nil {
+		log.WithError(err).Warn("preset deletion denied")
+		return err
+	}
+	err = s.store.DeletePreset(ctx, id)
+	if err == sql.ErrNoRows {
+		log.WithField("preset_id", id).Debug("preset not found for deletion")
+		return fmt.Errorf("preset %s not found", id)
+	}
+	if err != nil {
+		log.WithFields(logrus.Fields{
 			"preset_id": id,
 			"error":     err.Error(),
 		}).Error("failed to delete preset")
 		return fmt.Errorf("deleting preset: %w", err)
 	}
-	s.logger.WithField("preset_id", id).Info("preset deleted")
+	log.WithField("preset_id", id).Info("preset deleted")
 	return nil
 }
+
+// Export returns the preset with the given ID, or the presets List returns by
+// default when id is empty, for export as a portable document.
+func (s *PresetService) Export(ctx context.Context, id string) ([]model.Preset, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("preset_id", id).Trace("entering Export")
+	defer log.Trace("returning from Export")
+
+	if id == "" {
+		return s.List(ctx, "")
+	}
+	p, err := s.store.GetPreset(ctx, id)
+	if err == sql.ErrNoRows {
+		log.WithField("preset_id", id).Debug("preset not found for export")
+		return nil, fmt.Errorf("preset %s not found", id)
+	}
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"preset_id": id,
+			"error":     err.Error(),
+		}).Error("failed to fetch preset for export")
+		return nil, fmt.Errorf("fetching preset: %w", err)
+	}
+	return []model.Preset{p}, nil
+}
+
+// Import creates the presets of an exported document. Preset names need not
+// be unique, but an imported preset is matched to the first existing preset
+// of the same name among those List returns by default, and the conflict is
+// resolved by policy. Only the names, mappings, training runs, views and
+// default flags of the given presets are used. Every preset is validated
+// before any is imported, so an invalid document imports nothing.
+func (s *PresetService) Import(ctx context.Context, presets []model.Preset, policy model.ImportConflictPolicy) ([]model.ImportedEntry, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"preset_count": len(presets),
+		"on_conflict":  policy,
+	}).Trace("entering Import")
+	defer log.Trace("returning from Import")
+
+	if err := validateImportConflictPolicy(policy); err != nil {
+		return nil, err
+	}
+	for i, p := range presets {
+		if err := validatePreset(p); err != nil {
+			log.WithFields(logrus.Fields{
+				"index": i,
+				"error": err.Error(),
+			}).Warn("imported preset validation failed")
+			return nil, fmt.Errorf("presets[%d]: %w", i, err)
+		}
+	}
+
+	existing, err := s.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]string, len(existing)) // name -> ID of the first preset so named
+	for _, p := range existing {
+		if _, ok := byName[p.Name]; !ok {
+			byName[p.Name] = p.ID
+		}
+	}
+
+	entries := make([]model.ImportedEntry, 0, len(presets))
+	for i, p := range presets {
+		entry := model.ImportedEntry{SourceName: p.Name, Name: p.Name, Action: model.ImportActionCreated}
+		if id, ok := byName[p.Name]; ok {
+			switch policy {
+			case model.ImportConflictSkip:
+				entry.ID, entry.Action = id, model.ImportActionSkipped
+				entries = append(entries, entry)
+				continue
+			case model.ImportConflictOverwrite:
+				if _, err := s.Update(ctx, id, p); err != nil {
+					return entries, fmt.Errorf("presets[%d] %q: %w", i, p.Name, err)
+				}
+				entry.ID, entry.Action = id, model.ImportActionOverwritten
+				entries = append(entries, entry)
+				continue
+			default:
+				entry.Name, entry.Action = freePresetName(byName, p.Name), model.ImportActionRenamed
+			}
+		}
+		p.Name = entry.Name
+		created, err := s.Create(ctx, p)
+		if err != nil {
+			return entries, fmt.Errorf("presets[%d] %q: %w", i, p.Name, err)
+		}
+		byName[created.Name] = created.ID
+		entry.ID = created.ID
+		entries = append(entries, entry)
+	}
+	log.WithField("preset_count", len(entries)).Info("presets imported")
+	return entries, nil
+}
+
+// freePresetName returns the first of "<name> 2", "<name> 3", ... that is
+// not a key of byName.
+func freePresetName(byName map[string]string, name string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s %d", name, n)
+		if _, taken := byName[candidate]; !taken {
+			return candidate
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package service_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"io"
@@ -11,73 +12,35 @@ import (
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/testutil"
 )
 
-// fakePresetStore is an in-memory test double for service.PresetStore.
+// fakePresetStore is the in-memory store with injectable errors.
 type fakePresetStore struct {
-	presets   map[string]model.Preset
+	*store.MemoryStore
 	listErr   error
-	getErr    error
 	createErr error
-	updateErr error
-	deleteErr error
 }
 
 func newFakePresetStore() *fakePresetStore {
-	return &fakePresetStore{presets: make(map[string]model.Preset)}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &fakePresetStore{MemoryStore: store.NewMemoryStore(logger)}
 }
 
-func (f *fakePresetStore) ListPresets() ([]model.Preset, error) {
+func (f *fakePresetStore) ListPresets(ctx context.Context) ([]model.Preset, error) {
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
-	var result []model.Preset
-	for _, p := range f.presets {
-		result = append(result, p)
-	}
-	return result, nil
-}
-
-func (f *fakePresetStore) GetPreset(id string) (model.Preset, error) {
-	if f.getErr != nil {
-		return model.Preset{}, f.getErr
-	}
-	p, ok := f.presets[id]
-	if !ok {
-		return model.Preset{}, sql.ErrNoRows
-	}
-	return p, nil
+	return f.MemoryStore.ListPresets(ctx)
 }
 
-func (f *fakePresetStore) CreatePreset(p model.Preset) error {
+func (f *fakePresetStore) CreatePreset(ctx context.Context, p model.Preset) error {
 	if f.createErr != nil {
 		return f.createErr
 	}
-	f.presets[p.ID] = p
-	return nil
-}
-
-func (f *fakePresetStore) UpdatePreset(p model.Preset) error {
-	if f.updateErr != nil {
-		return f.updateErr
-	}
-	if _, ok := f.presets[p.ID]; !ok {
-		return sql.ErrNoRows
-	}
-	f.presets[p.ID] = p
-	return nil
-}
-
-func (f *fakePresetStore) DeletePreset(id string) error {
-	if f.deleteErr != nil {
-		return f.deleteErr
-	}
-	if _, ok := f.presets[id]; !ok {
-		return sql.ErrNoRows
-	}
-	delete(f.presets, id)
-	return nil
+	return f.MemoryStore.CreatePreset(ctx, p)
 }
 
 var _ = Describe("PresetService", func() {
@@ -96,23 +59,23 @@ var _ = Describe("PresetService", func() {
 
 	Describe("List", func() {
 		It("returns empty slice when no presets exist", func() {
-			result, err := svc.List()
+			result, err := svc.List(ctx, "")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(HaveLen(0))
 		})
 
 		It("returns all presets from the store", func() {
-			store.presets["p1"] = model.Preset{ID: "p1", Name: "One"}
-			store.presets["p2"] = model.Preset{ID: "p2", Name: "Two"}
+			Expect(store.CreatePreset(ctx, model.Preset{ID: "p1", Name: "One"})).To(Succeed())
+			Expect(store.CreatePreset(ctx, model.Preset{ID: "p2", Name: "Two"})).To(Succeed())
 
-			result, err := svc.List()
+			result, err := svc.List(ctx, "")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(HaveLen(2))
 		})
 
 		It("returns error when store fails", func() {
 			store.listErr = errors.New("db error")
-			_, err := svc.List()
+			_, err := svc.List(ctx, "")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("db error"))
 		})
@@ -125,7 +88,7 @@ var _ = Describe("PresetService", func() {
 				Combos: []string{"seed"},
 			}
 
-			result, err := svc.Create("Test", mapping)
+			result, err := svc.Create(ctx, model.Preset{Name: "Test", Mapping: mapping})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.ID).NotTo(BeEmpty())
 			Expect(result.Name).To(Equal("Test"))
@@ -136,20 +99,20 @@ var _ = Describe("PresetService", func() {
 		})
 
 		It("persists the preset in the store", func() {
-			_, err := svc.Create("Stored", model.PresetMapping{Combos: []string{}})
+			_, err := svc.Create(ctx, model.Preset{Name: "Stored", Mapping: model.PresetMapping{Combos: []string{}}})
 			Expect(err).NotTo(HaveOccurred())
-			Expect(store.presets).To(HaveLen(1))
+			Expect(store.ListPresets(ctx)).To(HaveLen(1))
 		})
 
 		It("rejects empty name", func() {
-			_, err := svc.Create("", model.PresetMapping{Combos: []string{}})
+			_, err := svc.Create(ctx, model.Preset{Name: "", Mapping: model.PresetMapping{Combos: []string{}}})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("name must not be empty"))
 		})
 
 		It("returns error when store fails", func() {
 			store.createErr = errors.New("insert failed")
-			_, err := svc.Create("Test", model.PresetMapping{Combos: []string{}})
+			_, err := svc.Create(ctx, model.Preset{Name: "Test", Mapping: model.PresetMapping{Combos: []string{}}})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("insert failed"))
 		})
@@ -157,14 +120,14 @@ var _ = Describe("PresetService", func() {
 
 	Describe("Update", func() {
 		BeforeEach(func() {
-			store.presets["existing"] = model.Preset{
+			Expect(store.CreatePreset(ctx, model.Preset{
 				ID:   "existing",
 				Name: "Original",
 				Mapping: model.PresetMapping{
 					X:      "cfg",
 					Combos: []string{"seed"},
 				},
-			}
+			})).To(Succeed())
 		})
 
 		It("updates name and mapping", func() {
@@ -172,7 +135,7 @@ var _ = Describe("PresetService", func() {
 				Y:      "prompt",
 				Combos: []string{"cfg"},
 			}
-			result, err := svc.Update("existing", "Renamed", newMapping)
+			result, err := svc.Update(ctx, "existing", model.Preset{Name: "Renamed", Mapping: newMapping})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Name).To(Equal("Renamed"))
 			Expect(result.Mapping.Y).To(Equal("prompt"))
@@ -180,36 +143,292 @@ var _ = Describe("PresetService", func() {
 		})
 
 		It("returns error for non-existent preset", func() {
-			_, err := svc.Update("missing", "Name", model.PresetMapping{Combos: []string{}})
+			_, err := svc.Update(ctx, "missing", model.Preset{Name: "Name", Mapping: model.PresetMapping{Combos: []string{}}})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
 
 		It("rejects empty name", func() {
-			_, err := svc.Update("existing", "", model.PresetMapping{Combos: []string{}})
+			_, err := svc.Update(ctx, "existing", model.Preset{Name: "", Mapping: model.PresetMapping{Combos: []string{}}})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("name must not be empty"))
 		})
 	})
 
+	Describe("training run views", func() {
+		It("stores the view of a training run and makes it the run's only default", func() {
+			view := model.PresetView{
+				Filters: map[string][]string{"seed": {"420"}},
+				Sort:    []model.PresetSort{{Dimension: "checkpoint", Descending: true}},
+			}
+			first, err := svc.Create(ctx, model.Preset{Name: "First", Mapping: model.PresetMapping{X: "cfg", Combos: []string{}}, TrainingRun: "my-lora", Default: true})
+			Expect(err).NotTo(HaveOccurred())
+			second, err := svc.Create(ctx, model.Preset{Name: "Second", Mapping: model.PresetMapping{X: "seed", Combos: []string{}}, TrainingRun: "my-lora", View: view, Default: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			def, err := svc.Default(ctx, "my-lora")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(def.ID).To(Equal(second.ID))
+			Expect(def.View).To(Equal(view))
+			p, err := store.GetPreset(ctx, first.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Default).To(BeFalse())
+		})
+
+		It("returns not found when a training run has no default", func() {
+			_, err := svc.Create(ctx, model.Preset{Name: "View", Mapping: model.PresetMapping{Combos: []string{}}, TrainingRun: "my-lora"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.Default(ctx, "my-lora")
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+
+		It("lists the presets of a training run together with unscoped presets", func() {
+			for _, p := range []model.Preset{
+				{Name: "Global", TrainingRun: ""},
+				{Name: "Mine", TrainingRun: "my-lora"},
+				{Name: "Other", TrainingRun: "other-lora"},
+			} {
+				p.Mapping = model.PresetMapping{Combos: []string{}}
+				_, err := svc.Create(ctx, p)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			presets, err := svc.ListForTrainingRun(ctx, "my-lora", "")
+			Expect(err).NotTo(HaveOccurred())
+			names := make([]string, len(presets))
+			for i, p := range presets {
+				names[i] = p.Name
+			}
+			Expect(names).To(Equal([]string{"Global", "Mine"}))
+		})
+
+		DescribeTable("rejects invalid views",
+			func(p model.Preset, message string) {
+				p.Name = "View"
+				_, err := svc.Create(ctx, p)
+				Expect(err).To(MatchError(ContainSubstring(message)))
+			},
+			Entry("a default without a training run", model.Preset{Default: true}, "default preset must name its training run"),
+			Entry("a sort without a dimension", model.Preset{View: model.PresetView{Sort: []model.PresetSort{{}}}}, "sort[0]: dimension must not be empty"),
+			Entry("a dimension sorted twice", model.Preset{View: model.PresetView{Sort: []model.PresetSort{{Dimension: "cfg"}, {Dimension: "cfg", Descending: true}}}}, "sorted more than once"),
+		)
+	})
+
+	Describe("Export", func() {
+		BeforeEach(func() {
+			Expect(store.CreatePreset(ctx, model.Preset{ID: "a", Name: "A", Mapping: model.PresetMapping{X: "cfg", Combos: []string{}}})).To(Succeed())
+			Expect(store.CreatePreset(ctx, model.Preset{ID: "b", Name: "B", Mapping: model.PresetMapping{Y: "seed", Combos: []string{}}})).To(Succeed())
+		})
+
+		It("exports all presets when no ID is given", func() {
+			presets, err := svc.Export(ctx, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(presets).To(HaveLen(2))
+		})
+
+		It("exports the preset with the given ID", func() {
+			presets, err := svc.Export(ctx, "b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(presets).To(HaveLen(1))
+			Expect(presets[0].Name).To(Equal("B"))
+			Expect(presets[0].Mapping.Y).To(Equal("seed"))
+		})
+
+		It("returns error for non-existent preset", func() {
+			_, err := svc.Export(ctx, "nonexistent")
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+
+	Describe("Import", func() {
+		var imported []model.Preset
+
+		BeforeEach(func() {
+			Expect(store.CreatePreset(ctx, model.Preset{ID: "existing", Name: "Config", Mapping: model.PresetMapping{X: "cfg", Combos: []string{}}})).To(Succeed())
+			imported = []model.Preset{
+				{Name: "Config", Mapping: model.PresetMapping{X: "steps", Combos: []string{"seed"}}},
+				{Name: "Other", Mapping: model.PresetMapping{Combos: []string{}}},
+			}
+		})
+
+		It("creates presets whose names are free", func() {
+			entries, err := svc.Import(ctx, imported[1:], model.ImportConflictRename)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Action).To(Equal(model.ImportActionCreated))
+			Expect(entries[0].Name).To(Equal("Other"))
+			p, err := store.GetPreset(ctx, entries[0].ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Name).To(Equal("Other"))
+		})
+
+		It("renames a preset whose name is taken", func() {
+			entries, err := svc.Import(ctx, imported, model.ImportConflictRename)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries[0].SourceName).To(Equal("Config"))
+			Expect(entries[0].Name).To(Equal("Config 2"))
+			Expect(entries[0].Action).To(Equal(model.ImportActionRenamed))
+			Expect(entries[0].ID).NotTo(Equal("existing"))
+
+			// A second import of the same document takes the next free name.
+			entries, err = svc.Import(ctx, imported[:1], model.ImportConflictRename)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries[0].Name).To(Equal("Config 3"))
+		})
+
+		It("overwrites the mapping of a preset whose name is taken", func() {
+			entries, err := svc.Import(ctx, imported[:1], model.ImportConflictOverwrite)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries[0].ID).To(Equal("existing"))
+			Expect(entries[0].Action).To(Equal(model.ImportActionOverwritten))
+			p, err := store.GetPreset(ctx, "existing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Mapping.X).To(Equal("steps"))
+			Expect(p.Mapping.Combos).To(Equal([]string{"seed"}))
+		})
+
+		It("skips a preset whose name is taken", func() {
+			entries, err := svc.Import(ctx, imported[:1], model.ImportConflictSkip)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries[0].ID).To(Equal("existing"))
+			Expect(entries[0].Action).To(Equal(model.ImportActionSkipped))
+			p, err := store.GetPreset(ctx, "existing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Mapping.X).To(Equal("cfg"))
+		})
+
+		It("imports nothing when a preset is invalid", func() {
+			imported[1].Name = ""
+			_, err := svc.Import(ctx, imported, model.ImportConflictRename)
+			Expect(err).To(MatchError(ContainSubstring("presets[1]")))
+			presets, err := store.ListPresets(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(presets).To(HaveLen(1))
+		})
+
+		It("rejects an unknown conflict policy", func() {
+			_, err := svc.Import(ctx, imported, model.ImportConflictPolicy("merge"))
+			Expect(err).To(MatchError(ContainSubstring("unknown conflict policy")))
+		})
+	})
+
 	Describe("Delete", func() {
 		BeforeEach(func() {
-			store.presets["to-delete"] = model.Preset{ID: "to-delete", Name: "Remove Me"}
+			Expect(store.CreatePreset(ctx, model.Preset{ID: "to-delete", Name: "Remove Me"})).To(Succeed())
 		})
 
 		It("deletes an existing preset", func() {
-			err := svc.Delete("to-delete")
+			err := svc.Delete(ctx, "to-delete")
 			Expect(err).NotTo(HaveOccurred())
-			Expect(store.presets).NotTo(HaveKey("to-delete"))
+			_, err = store.GetPreset(ctx, "to-delete")
+			Expect(err).To(Equal(sql.ErrNoRows))
 		})
 
 		It("returns error for non-existent preset", func() {
-			err := svc.Delete("nonexistent")
+			err := svc.Delete(ctx, "nonexistent")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
 	})
 
+	Describe("owners", func() {
+		var (
+			alice = service.WithUser(ctx, model.User{Name: "alice"})
+			bob   = service.WithUser(ctx, model.User{Name: "bob"})
+			admin = service.WithUser(ctx, model.User{Name: "root", Admin: true})
+		)
+
+		create := func(ctx context.Context, name string, def bool) model.Preset {
+			p, err := svc.Create(ctx, model.Preset{Name: name, Mapping: model.PresetMapping{Combos: []string{}}, TrainingRun: "my-lora", Default: def})
+			Expect(err).NotTo(HaveOccurred())
+			return p
+		}
+
+		names := func(presets []model.Preset) []string {
+			result := make([]string, len(presets))
+			for i, p := range presets {
+				result[i] = p.Name
+			}
+			return result
+		}
+
+		BeforeEach(func() {
+			create(ctx, "Shared", false)
+			create(alice, "Alice's", false)
+			create(bob, "Bob's", false)
+		})
+
+		It("records the request's user as the owner", func() {
+			presets, err := svc.List(ctx, model.OwnerAll)
+			Expect(err).NotTo(HaveOccurred())
+			owners := map[string]string{}
+			for _, p := range presets {
+				owners[p.Name] = p.Owner
+			}
+			Expect(owners).To(Equal(map[string]string{"Shared": "", "Alice's": "alice", "Bob's": "bob"}))
+		})
+
+		It("lists a user's own and the unowned presets by default", func() {
+			presets, err := svc.List(alice, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(presets)).To(ConsistOf("Shared", "Alice's"))
+		})
+
+		It("lets admin users list every user's presets or another user's", func() {
+			presets, err := svc.List(admin, model.OwnerAll)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(presets)).To(ConsistOf("Shared", "Alice's", "Bob's"))
+
+			presets, err = svc.List(admin, "bob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(presets)).To(ConsistOf("Bob's"))
+		})
+
+		It("forbids other users to list every user's presets", func() {
+			_, err := svc.List(alice, model.OwnerAll)
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+			_, err = svc.ListForTrainingRun(alice, "my-lora", "bob")
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+		})
+
+		It("forbids changing another user's preset", func() {
+			presets, err := svc.List(bob, "bob")
+			Expect(err).NotTo(HaveOccurred())
+			id := presets[0].ID
+
+			_, err = svc.Update(alice, id, model.Preset{Name: "Taken", Mapping: model.PresetMapping{Combos: []string{}}})
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+			Expect(svc.Delete(alice, id)).To(MatchError(HavePrefix("forbidden:")))
+			Expect(svc.Delete(ctx, id)).To(MatchError(HavePrefix("forbidden:")))
+
+			Expect(svc.Delete(admin, id)).To(Succeed())
+		})
+
+		It("lets every user change unowned presets", func() {
+			presets, err := svc.List(ctx, "")
+			Expect(err).NotTo(HaveOccurred())
+			for _, p := range presets {
+				if p.Owner == "" {
+					Expect(svc.Delete(alice, p.ID)).To(Succeed())
+				}
+			}
+		})
+
+		It("keeps a default per user, falling back to the unowned default", func() {
+			create(ctx, "Shared default", true)
+			aliceDefault := create(alice, "Alice's default", true)
+
+			def, err := svc.Default(alice, "my-lora")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(def.ID).To(Equal(aliceDefault.ID))
+
+			def, err = svc.Default(bob, "my-lora")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(def.Name).To(Equal("Shared default"))
+		})
+	})
+
 	Describe("Logging", func() {
 		var lc *testutil.LogCapture
 
@@ -221,41 +440,41 @@ var _ = Describe("PresetService", func() {
 		})
 
 		It("logs trace entry/exit for List", func() {
-			_, _ = svc.List()
+			_, _ = svc.List(ctx, "")
 
 			Expect(lc.MessagesAtLevel(logrus.TraceLevel)).To(ContainElement("entering List"))
 			Expect(lc.MessagesAtLevel(logrus.TraceLevel)).To(ContainElement("returning from List"))
 		})
 
 		It("logs info on successful create", func() {
-			_, _ = svc.Create("Test Preset", model.PresetMapping{Combos: []string{}})
+			_, _ = svc.Create(ctx, model.Preset{Name: "Test Preset", Mapping: model.PresetMapping{Combos: []string{}}})
 
 			Expect(lc.MessagesAtLevel(logrus.InfoLevel)).To(ContainElement("preset created"))
 		})
 
 		It("logs error when store fails", func() {
 			store.listErr = errors.New("database error")
-			_, _ = svc.List()
+			_, _ = svc.List(ctx, "")
 
 			Expect(lc.MessagesAtLevel(logrus.ErrorLevel)).To(ContainElement("failed to list presets"))
 		})
 
 		It("logs debug for intermediate values", func() {
-			store.presets["test-id"] = model.Preset{ID: "test-id", Name: "Test"}
-			_, _ = svc.Update("test-id", "New Name", model.PresetMapping{Combos: []string{}})
+			Expect(store.CreatePreset(ctx, model.Preset{ID: "test-id", Name: "Test"})).To(Succeed())
+			_, _ = svc.Update(ctx, "test-id", model.Preset{Name: "New Name", Mapping: model.PresetMapping{Combos: []string{}}})
 
 			Expect(lc.MessagesAtLevel(logrus.DebugLevel)).To(ContainElement("fetched existing preset from store"))
 		})
 
 		It("logs validation failures at warn level, not error", func() {
-			_, _ = svc.Create("", model.PresetMapping{Combos: []string{}})
+			_, _ = svc.Create(ctx, model.Preset{Name: "", Mapping: model.PresetMapping{Combos: []string{}}})
 
-			Expect(lc.MessagesAtLevel(logrus.WarnLevel)).To(ContainElement("preset name validation failed: name is empty"))
-			Expect(lc.MessagesAtLevel(logrus.ErrorLevel)).NotTo(ContainElement("preset name validation failed: name is empty"))
+			Expect(lc.MessagesAtLevel(logrus.WarnLevel)).To(ContainElement("preset validation failed"))
+			Expect(lc.MessagesAtLevel(logrus.ErrorLevel)).NotTo(ContainElement("preset validation failed"))
 		})
 
 		It("logs not found conditions at debug level, not error", func() {
-			_, _ = svc.Update("nonexistent", "Test", model.PresetMapping{Combos: []string{}})
+			_, _ = svc.Update(ctx, "nonexistent", model.Preset{Name: "Test", Mapping: model.PresetMapping{Combos: []string{}}})
 
 			Expect(lc.MessagesAtLevel(logrus.DebugLevel)).To(ContainElement("preset not found"))
 			Expect(lc.MessagesAtLevel(logrus.ErrorLevel)).NotTo(ContainElement("preset not found"))
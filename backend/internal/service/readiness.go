@@ -0,0 +1,79 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Readiness tracks the startup steps that must complete before the server is
+// ready to serve requests. The HTTP server is started before initialization
+// so that container orchestration can probe it; readiness tells the probes
+// when initialization is done.
+type Readiness struct {
+	mu     sync.RWMutex
+	checks []model.ReadinessCheck
+	logger *logrus.Entry
+}
+
+// NewReadiness creates a Readiness waiting for the given steps, in the order
+// they run.
+func NewReadiness(logger *logrus.Logger, steps ...string) *Readiness {
+	checks := make([]model.ReadinessCheck, len(steps))
+	for i, step := range steps {
+		checks[i] = model.ReadinessCheck{Name: step}
+	}
+	return &Readiness{
+		checks: checks,
+		logger: logger.WithField("component", "readiness"),
+	}
+}
+
+// MarkReady records that a startup step has completed. Steps the Readiness
+// does not wait for are logged and ignored.
+func (r *Readiness) MarkReady(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.checks {
+		if r.checks[i].Name != step {
+			continue
+		}
+		if !r.checks[i].Ready {
+			r.checks[i].Ready = true
+			r.logger.WithField("step", step).Debug("startup step completed")
+			if r.readyLocked() {
+				r.logger.Info("server ready")
+			}
+		}
+		return
+	}
+	r.logger.WithField("step", step).Warn("unknown startup step marked ready")
+}
+
+// Status returns the state of every startup step.
+func (r *Readiness) Status() model.ReadinessStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	checks := make([]model.ReadinessCheck, len(r.checks))
+	copy(checks, r.checks)
+	return model.ReadinessStatus{Ready: r.readyLocked(), Checks: checks}
+}
+
+// Ready reports whether every startup step has completed.
+func (r *Readiness) Ready() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.readyLocked()
+}
+
+func (r *Readiness) readyLocked() bool {
+	for _, c := range r.checks {
+		if !c.Ready {
+			return false
+		}
+	}
+	return true
+}
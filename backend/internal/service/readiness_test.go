@@ -0,0 +1,50 @@
+package service_test
+
+import (
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("Readiness", func() {
+	var readiness *service.Readiness
+
+	BeforeEach(func() {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		readiness = service.NewReadiness(logger, model.ReadinessStepMigrations, model.ReadinessStepWatcher)
+	})
+
+	It("is not ready until every step has completed", func() {
+		Expect(readiness.Ready()).To(BeFalse())
+
+		readiness.MarkReady(model.ReadinessStepWatcher)
+		status := readiness.Status()
+		Expect(status.Ready).To(BeFalse())
+		Expect(status.Checks).To(Equal([]model.ReadinessCheck{
+			{Name: model.ReadinessStepMigrations},
+			{Name: model.ReadinessStepWatcher, Ready: true},
+		}))
+
+		readiness.MarkReady(model.ReadinessStepMigrations)
+		Expect(readiness.Ready()).To(BeTrue())
+		Expect(readiness.Status().Ready).To(BeTrue())
+	})
+
+	It("ignores steps it does not wait for", func() {
+		readiness.MarkReady(model.ReadinessStepAPI)
+		Expect(readiness.Status().Checks).To(HaveLen(2))
+		Expect(readiness.Ready()).To(BeFalse())
+	})
+
+	It("returns a copy of the checks", func() {
+		status := readiness.Status()
+		status.Checks[0].Ready = true
+		Expect(readiness.Status().Checks[0].Ready).To(BeFalse())
+	})
+})
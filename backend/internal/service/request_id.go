@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx that carries the ID of the API request
+// it belongs to.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID ctx carries, or "" if ctx does
+// not belong to an API request.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLogger returns logger with the request ID of ctx as the request_id
+// field, so that the entries logged on behalf of a request can be found by
+// its ID. logger is returned unchanged if ctx carries no request ID.
+func requestLogger(ctx context.Context, logger *logrus.Entry) *logrus.Entry {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return logger.WithField("request_id", id)
+	}
+	return logger
+}
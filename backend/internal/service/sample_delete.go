@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// SampleDeleteFileSystem lists and removes sample files.
+type SampleDeleteFileSystem interface {
+	ListFileUsage(root string) ([]model.FileUsage, error)
+	RemoveFile(path string) error
+}
+
+// SampleDeleteService deletes the sample images of a training run that match
+// a filter, together with their sidecars and thumbnails.
+type SampleDeleteService struct {
+	fs        SampleDeleteFileSystem
+	hub       EventHub
+	pinGuard  PinGuard          // optional; nil means nothing is pinned
+	images    ImageIndexUpdater // optional; nil when no image index is configured
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewSampleDeleteService creates a SampleDeleteService for the sample
+// directory. Removed images are broadcast on hub as image_removed events.
+func NewSampleDeleteService(fs SampleDeleteFileSystem, hub EventHub, sampleDir string, logger *logrus.Logger) *SampleDeleteService {
+	return &SampleDeleteService{
+		fs:        fs,
+		hub:       hub,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "sample_delete"),
+	}
+}
+
+// SetPinGuard sets the guard consulted before deleting an image. Pinned
+// images are skipped. This is optional; if not set, nothing is protected.
+func (s *SampleDeleteService) SetPinGuard(pinGuard PinGuard) {
+	s.pinGuard = pinGuard
+}
+
+// SetImageIndex sets the image index deleted images are removed from. This
+// is optional.
+func (s *SampleDeleteService) SetImageIndex(images ImageIndexUpdater) {
+	s.images = images
+}
+
+// Delete removes the sample images of filter's training run that match the
+// filter. Only images in {training_run}/{study}/{checkpoint}/ directories and
+// their subdirectories are considered. The prompt name and seed are read from the query-encoded
+// filename, so images without one never match those fields. A failure to
+// remove one image is recorded and the deletion continues. In a dry run
+// nothing is removed.
+func (s *SampleDeleteService) Delete(ctx context.Context, filter model.SampleDeleteFilter, dryRun bool) (model.SampleDeleteResult, error) {
+	s.logger.WithFields(logrus.Fields{
+		"training_run": filter.TrainingRunName,
+		"dry_run":      dryRun,
+	}).Trace("entering Delete")
+	defer s.logger.Trace("returning from Delete")
+
+	if filter.TrainingRunName == "" {
+		return model.SampleDeleteResult{}, fmt.Errorf("invalid filter: training_run is required")
+	}
+	runDir := fileformat.SanitizeTrainingRunName(filter.TrainingRunName)
+	if runDir == "." || runDir == ".." {
+		return model.SampleDeleteResult{}, fmt.Errorf("invalid filter: training_run %q", filter.TrainingRunName)
+	}
+	checkpoints := make(map[string]bool, len(filter.Checkpoints))
+	for _, c := range filter.Checkpoints {
+		checkpoints[c] = true
+	}
+
+	files, err := s.fs.ListFileUsage(filepath.Join(s.sampleDir, runDir))
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list training run sample files")
+		return model.SampleDeleteResult{}, fmt.Errorf("listing sample files of %s: %w", filter.TrainingRunName, err)
+	}
+
+	result := model.SampleDeleteResult{DryRun: dryRun, Deleted: []string{}, Errors: []string{}}
+	for _, f := range files {
+		// Images saved under an output layout are in subdirectories of the
+		// checkpoint directory.
+		parts := strings.Split(f.Path, "/")
+		if len(parts) < 3 || !model.IsSampleImageFile(parts[len(parts)-1]) || isThumbnailPath(f.Path) {
+			continue // thumbnails, sidecars, manifests, and legacy layouts
+		}
+		if len(checkpoints) > 0 && !checkpoints[parts[1]] {
+			continue
+		}
+		if filter.Before != nil && !f.ModTime.Before(*filter.Before) {
+			continue
+		}
+		if filter.PromptName != "" || filter.Seed != nil {
+			dims, _ := parseFilename(parts[len(parts)-1])
+			if filter.PromptName != "" && dims["prompt_name"] != filter.PromptName {
+				continue
+			}
+			if filter.Seed != nil && dims["seed"] != strconv.FormatInt(*filter.Seed, 10) {
+				continue
+			}
+		}
+
+		relPath := runDir + "/" + f.Path
+		if s.pinGuard != nil && s.pinGuard.IsProtected(ctx, relPath) {
+			result.SkippedPinned++
+			continue
+		}
+		if !dryRun {
+			if err := s.removeImage(relPath); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", relPath, err))
+				continue
+			}
+		}
+		result.Deleted = append(result.Deleted, relPath)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"training_run":   filter.TrainingRunName,
+		"dry_run":        dryRun,
+		"deleted":        len(result.Deleted),
+		"skipped_pinned": result.SkippedPinned,
+		"errors":         len(result.Errors),
+	}).Info("bulk sample deletion finished")
+	return result, nil
+}
+
+// removeImage removes the image at relPath, then its sidecar and thumbnails,
+// and announces the removal. Only a failure to remove the image itself is
+// returned; leftover sidecars and thumbnails are logged.
+func (s *SampleDeleteService) removeImage(relPath string) error {
+	imagePath := filepath.Join(s.sampleDir, filepath.FromSlash(relPath))
+	if err := s.fs.RemoveFile(imagePath); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  relPath,
+			"error": err.Error(),
+		}).Error("failed to delete sample image")
+		return err
+	}
+	for _, extra := range []string{
+		sidecarPathFor(imagePath),
+		filepath.Join(s.sampleDir, filepath.FromSlash(ThumbnailCacheRelativePath(relPath))),
+		ThumbnailPath(imagePath),
+	} {
+		if err := s.fs.RemoveFile(extra); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"path":  extra,
+				"error": err.Error(),
+			}).Warn("failed to delete sample image companion file")
+		}
+	}
+	if s.images != nil {
+		s.images.RemoveImage(relPath)
+	}
+	s.hub.Broadcast(model.FSEvent{
+		Type: model.EventImageRemoved,
+		Path: relPath,
+	})
+	return nil
+}
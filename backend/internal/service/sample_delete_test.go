@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeSampleDeleteFS is an in-memory test double for
+// service.SampleDeleteFileSystem.
+type fakeSampleDeleteFS struct {
+	files      []model.FileUsage
+	listedRoot string
+	removed    []string
+	failPath   string
+}
+
+func (f *fakeSampleDeleteFS) ListFileUsage(root string) ([]model.FileUsage, error) {
+	f.listedRoot = root
+	return f.files, nil
+}
+
+func (f *fakeSampleDeleteFS) RemoveFile(path string) error {
+	if f.failPath != "" && strings.HasSuffix(path, f.failPath) {
+		return errors.New("permission denied")
+	}
+	f.removed = append(f.removed, path)
+	return nil
+}
+
+var _ = Describe("SampleDeleteService", func() {
+	var (
+		fs   *fakeSampleDeleteFS
+		hub  *fakeEventSink
+		pins *fakePinGuard
+		svc  *service.SampleDeleteService
+	)
+
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+
+	BeforeEach(func() {
+		fs = &fakeSampleDeleteFS{files: []model.FileUsage{
+			{Path: "forest/a.safetensors/prompt_name=forest&seed=1&_00001_.png", ModTime: old},
+			{Path: "forest/a.safetensors/prompt_name=forest&seed=1&_00001_.json", ModTime: old},
+			{Path: "forest/a.safetensors/.thumbnails/prompt_name=forest&seed=1&_00001_.jpg", ModTime: old},
+			{Path: "forest/a.safetensors/prompt_name=city&seed=2&_00001_.png", ModTime: now},
+			{Path: "forest/b.safetensors/prompt_name=forest&seed=2&_00001_.png", ModTime: old},
+			{Path: "forest/manifest.json", ModTime: old},
+		}}
+		hub = newFakeEventSink()
+		pins = &fakePinGuard{protected: map[string]bool{}}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewSampleDeleteService(fs, hub, "/samples", logger)
+		svc.SetPinGuard(pins)
+	})
+
+	It("deletes the matching images with their sidecars and thumbnails and broadcasts the removals", func() {
+		result, err := svc.Delete(ctx, model.SampleDeleteFilter{TrainingRunName: "org/model", PromptName: "forest"}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fs.listedRoot).To(Equal("/samples/org_model"))
+		Expect(result.Deleted).To(Equal([]string{
+			"org_model/forest/a.safetensors/prompt_name=forest&seed=1&_00001_.png",
+			"org_model/forest/b.safetensors/prompt_name=forest&seed=2&_00001_.png",
+		}))
+		Expect(fs.removed).To(ContainElements(
+			"/samples/org_model/forest/a.safetensors/prompt_name=forest&seed=1&_00001_.png",
+			"/samples/org_model/forest/a.safetensors/prompt_name=forest&seed=1&_00001_.json",
+			"/samples/org_model/forest/a.safetensors/.thumbnails/prompt_name=forest&seed=1&_00001_.jpg",
+		))
+		Expect(hub.getEvents()).To(HaveLen(2))
+		Expect(hub.getEvents()[0]).To(Equal(model.FSEvent{
+			Type: model.EventImageRemoved,
+			Path: "org_model/forest/a.safetensors/prompt_name=forest&seed=1&_00001_.png",
+		}))
+	})
+
+	DescribeTable("matches images by filter",
+		func(filter model.SampleDeleteFilter, expected []string) {
+			filter.TrainingRunName = "run"
+			result, err := svc.Delete(ctx, filter, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Deleted).To(Equal(expected))
+		},
+		Entry("checkpoint list", model.SampleDeleteFilter{Checkpoints: []string{"b.safetensors"}},
+			[]string{"run/forest/b.safetensors/prompt_name=forest&seed=2&_00001_.png"}),
+		Entry("seed", model.SampleDeleteFilter{Seed: func() *int64 { v := int64(2); return &v }()},
+			[]string{
+				"run/forest/a.safetensors/prompt_name=city&seed=2&_00001_.png",
+				"run/forest/b.safetensors/prompt_name=forest&seed=2&_00001_.png",
+			}),
+		Entry("before date", model.SampleDeleteFilter{Before: &now, Checkpoints: []string{"a.safetensors"}},
+			[]string{"run/forest/a.safetensors/prompt_name=forest&seed=1&_00001_.png"}),
+	)
+
+	It("deletes nothing in a dry run", func() {
+		result, err := svc.Delete(ctx, model.SampleDeleteFilter{TrainingRunName: "run"}, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.DryRun).To(BeTrue())
+		Expect(result.Deleted).To(HaveLen(3))
+		Expect(fs.removed).To(BeEmpty())
+		Expect(hub.getEvents()).To(BeEmpty())
+	})
+
+	It("skips pinned images", func() {
+		pins.protected["run/forest/b.safetensors/prompt_name=forest&seed=2&_00001_.png"] = true
+
+		result, err := svc.Delete(ctx, model.SampleDeleteFilter{TrainingRunName: "run", PromptName: "forest"}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Deleted).To(HaveLen(1))
+		Expect(result.SkippedPinned).To(Equal(1))
+	})
+
+	It("records images that could not be removed and continues", func() {
+		fs.failPath = "b.safetensors/prompt_name=forest&seed=2&_00001_.png"
+
+		result, err := svc.Delete(ctx, model.SampleDeleteFilter{TrainingRunName: "run", PromptName: "forest"}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Deleted).To(HaveLen(1))
+		Expect(result.Errors).To(HaveLen(1))
+		Expect(result.Errors[0]).To(ContainSubstring("permission denied"))
+		Expect(hub.getEvents()).To(HaveLen(1))
+	})
+
+	It("rejects a filter without a training run", func() {
+		_, err := svc.Delete(ctx, model.SampleDeleteFilter{PromptName: "forest"}, false)
+		Expect(err).To(MatchError(ContainSubstring("invalid filter")))
+	})
+})
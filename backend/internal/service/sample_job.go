@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"math/rand/v2"
 	"net/url"
 	"path/filepath"
+	"slices"
 	"sort"
 	"time"
 
@@ -15,17 +18,34 @@ import (
 
 // SampleJobStore defines the persistence operations the sample job service needs.
 type SampleJobStore interface {
-	ListSampleJobs() ([]model.SampleJob, error)
-	ListSampleJobsDesc() ([]model.SampleJob, error)
-	GetSampleJob(id string) (model.SampleJob, error)
-	HasRunningJob() (bool, error)
-	CreateSampleJob(j model.SampleJob) error
-	UpdateSampleJob(j model.SampleJob) error
-	DeleteSampleJob(id string) error
-	ListSampleJobItems(jobID string) ([]model.SampleJobItem, error)
-	CreateSampleJobItem(i model.SampleJobItem) error
-	UpdateSampleJobItem(i model.SampleJobItem) error
-	GetStudy(id string) (model.Study, error)
+	ListSampleJobs(ctx context.Context) ([]model.SampleJob, error)
+	// ListSampleJobsDesc returns the sample jobs matching filter in page,
+	// newest first.
+	ListSampleJobsDesc(ctx context.Context, filter model.SampleJobFilter, page model.Page) ([]model.SampleJob, error)
+	// CountSampleJobs returns the number of sample jobs matching filter.
+	CountSampleJobs(ctx context.Context, filter model.SampleJobFilter) (int, error)
+	GetSampleJob(ctx context.Context, id string) (model.SampleJob, error)
+	HasRunningJob(ctx context.Context) (bool, error)
+	CreateSampleJobWithItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error
+	CreateStudyWithSampleJob(ctx context.Context, st model.Study, j model.SampleJob, items []model.SampleJobItem) error
+	UpdateSampleJob(ctx context.Context, j *model.SampleJob) error
+	JobTransitionStore
+	AppendSampleJobItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error
+	DeleteSampleJob(ctx context.Context, id string) error
+	// ListTrashedSampleJobs returns the sample jobs in scope that are in the
+	// trash, most recently deleted first.
+	ListTrashedSampleJobs(ctx context.Context, scope model.OwnerScope) ([]model.SampleJob, error)
+	// GetTrashedSampleJob returns a sample job in the trash, or sql.ErrNoRows.
+	GetTrashedSampleJob(ctx context.Context, id string) (model.SampleJob, error)
+	TrashSampleJob(ctx context.Context, id string, deletedAt time.Time, deleteData bool) error
+	RestoreSampleJob(ctx context.Context, id string) error
+	ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error)
+	ListSampleJobItemsPage(ctx context.Context, jobID string, query model.SampleJobItemQuery, page model.Page) ([]model.SampleJobItem, error)
+	CountSampleJobItems(ctx context.Context, jobID string, filter model.SampleJobItemFilter) (int, error)
+	UpdateSampleJobItem(ctx context.Context, i *model.SampleJobItem) error
+	GetStudy(ctx context.Context, id string) (model.Study, error)
+	ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error)
+	GetSampleJobParameters(ctx context.Context, jobID string) (model.SampleJobParameters, error)
 }
 
 // JobSampleDataRemover defines the interface for removing generated sample files for a job.
@@ -36,7 +56,7 @@ type JobSampleDataRemover interface {
 
 // PathMatcher defines the interface for matching checkpoint filenames to ComfyUI model paths.
 type PathMatcher interface {
-	MatchCheckpointPath(filename string) (string, error)
+	MatchCheckpointPath(trainingRunName, filename string) (string, error)
 }
 
 // SampleDirRemover defines the interface for removing sample directories for a checkpoint.
@@ -56,32 +76,80 @@ type OutputFileChecker interface {
 	FileExists(path string) bool
 }
 
+// ItemDurationSource reports how long recently completed sample job items took.
+type ItemDurationSource interface {
+	AverageItemDuration(ctx context.Context, limit int) (avg time.Duration, ok bool, err error)
+}
+
+// OutputFormatSupport reports which output formats images can be saved in.
+type OutputFormatSupport interface {
+	Supports(format model.OutputFormat) bool
+}
+
+// WorkflowTemplateSource loads workflow templates by name. It is satisfied by
+// WorkflowLoader.
+type WorkflowTemplateSource interface {
+	Get(ctx context.Context, name string) (model.WorkflowTemplate, error)
+}
+
+// TrainingRunSource discovers the training runs in the checkpoint
+// directories. It is satisfied by DiscoveryService.
+type TrainingRunSource interface {
+	Discover(ctx context.Context) ([]model.TrainingRun, error)
+}
+
+// VRAMChecker estimates whether the images of a new job fit in GPU memory.
+// It is satisfied by VRAMGuard.
+type VRAMChecker interface {
+	Check(workflowName string, width, height int) (model.VRAMEstimate, error)
+}
+
+// bulkEstimateWindow is the number of recently completed items averaged when
+// estimating the duration of a bulk job creation or a job preview.
+const bulkEstimateWindow = 200
+
 // SampleJobService manages sample job creation, state transitions, and progress tracking.
 type SampleJobService struct {
 	store              SampleJobStore
+	states             *JobStateMachine
 	pathMatcher        PathMatcher
 	dirRemover         SampleDirRemover
 	jobDataRemover     JobSampleDataRemover
 	fileChecker        OutputFileChecker
+	pinGuard           PinGuard
+	durations          ItemDurationSource
+	formats            OutputFormatSupport
+	workflows          WorkflowTemplateSource
+	runs               TrainingRunSource
+	vram               VRAMChecker
 	sampleDir          string
+	outputLayout       model.OutputLayout
 	executor           SampleJobExecutor
+	trashRetention     time.Duration // trashed jobs older than this are purged by RunTrashPurge; 0 disables
+	randomSeed         func() int64 // draws seeds for the random seed modes
 	logger             *logrus.Entry
 }
 
+// maxRandomSeed bounds the seeds drawn by the random seed modes. Seeds stay
+// below 2^53 so that they survive a round trip through JSON in JavaScript.
+const maxRandomSeed = 1 << 53
+
 // NewSampleJobService creates a SampleJobService backed by the given store.
 func NewSampleJobService(store SampleJobStore, pathMatcher PathMatcher, dirRemover SampleDirRemover, sampleDir string, logger *logrus.Logger) *SampleJobService {
 	return &SampleJobService{
 		store:       store,
+		states:      NewJobStateMachine(store, logger),
 		pathMatcher: pathMatcher,
 		dirRemover:  dirRemover,
 		sampleDir:   sampleDir,
 		executor:    nil, // Set later via SetExecutor
+		randomSeed:  func() int64 { return rand.Int64N(maxRandomSeed) },
 		logger:      logger.WithField("component", "sample_job"),
 	}
 }
 
-// SetJobDataRemover sets the remover used by Delete(deleteData=true).
-// This is optional; if not set, Delete with deleteData=true will skip filesystem cleanup.
+// SetJobDataRemover sets the remover used to purge jobs deleted with deleteData.
+// This is optional; if not set, purging such jobs skips filesystem cleanup.
 func (s *SampleJobService) SetJobDataRemover(remover JobSampleDataRemover) {
 	s.jobDataRemover = remover
 }
@@ -91,6 +159,52 @@ func (s *SampleJobService) SetFileChecker(checker OutputFileChecker) {
 	s.fileChecker = checker
 }
 
+// SetPinGuard sets the guard consulted before sample directories are removed.
+// This is optional; if not set, pins are not enforced.
+func (s *SampleJobService) SetPinGuard(guard PinGuard) {
+	s.pinGuard = guard
+}
+
+// SetItemDurationSource sets the source of item timing history used to
+// estimate bulk job durations. This is optional; if not set, no estimate is made.
+func (s *SampleJobService) SetItemDurationSource(source ItemDurationSource) {
+	s.durations = source
+}
+
+// SetOutputFormatSupport sets the check used to reject output formats that
+// cannot be encoded (e.g. WebP without an encoder). This is optional; if not
+// set, every known format is accepted.
+func (s *SampleJobService) SetOutputFormatSupport(formats OutputFormatSupport) {
+	s.formats = formats
+}
+
+// SetWorkflowSource sets the loader used to look up the VAE, text encoder,
+// and shift defaults declared by a study's workflow. This is optional; if not
+// set, jobs only use the study's values and explicit overrides.
+func (s *SampleJobService) SetWorkflowSource(workflows WorkflowTemplateSource) {
+	s.workflows = workflows
+}
+
+// SetTrainingRunSource sets the source of the training runs consulted by
+// AppendNewCheckpoints. This is optional; if not set, no checkpoints are
+// appended to jobs.
+func (s *SampleJobService) SetTrainingRunSource(runs TrainingRunSource) {
+	s.runs = runs
+}
+
+// SetVRAMChecker sets the check run on the resolution of new jobs. This is
+// optional; if not set, jobs are created without a VRAM estimate.
+func (s *SampleJobService) SetVRAMChecker(vram VRAMChecker) {
+	s.vram = vram
+}
+
+// SetOutputLayout sets the layout sample images are saved in, which
+// missing-only and skip-existing jobs look for existing images in. This is
+// optional; if not set, images are looked for under query-encoded filenames.
+func (s *SampleJobService) SetOutputLayout(layout model.OutputLayout) {
+	s.outputLayout = layout
+}
+
 // SetExecutor sets the job executor (called after construction to avoid circular dependencies).
 func (s *SampleJobService) SetExecutor(executor SampleJobExecutor) {
 	s.executor = executor
@@ -98,8 +212,12 @@ func (s *SampleJobService) SetExecutor(executor SampleJobExecutor) {
 
 // clearSampleDirsForJob removes the sample directories for each checkpoint in the job.
 // This is called once when a job first transitions from pending to running.
-func (s *SampleJobService) clearSampleDirsForJob(job model.SampleJob) {
+func (s *SampleJobService) clearSampleDirsForJob(ctx context.Context, job model.SampleJob) {
 	for _, cpFilename := range job.CheckpointFilenames {
+		if s.pinGuard != nil && s.pinGuard.IsProtected(ctx, cpFilename) {
+			s.logger.WithField("checkpoint_filename", cpFilename).Info("sample dir contains pinned content, not clearing")
+			continue
+		}
 		if err := s.dirRemover.RemoveSampleDir(cpFilename); err != nil {
 			s.logger.WithFields(logrus.Fields{
 				"checkpoint_filename": cpFilename,
@@ -114,59 +232,366 @@ func (s *SampleJobService) clearSampleDirsForJob(job model.SampleJob) {
 	}
 }
 
-// List returns all sample jobs ordered by creation time (newest first) for UI display.
-func (s *SampleJobService) List() ([]model.SampleJob, error) {
-	s.logger.Trace("entering List")
-	defer s.logger.Trace("returning from List")
-
-	jobs, err := s.store.ListSampleJobsDesc()
+// List returns the sample jobs of owner in page, ordered by creation time
+// (newest first) for UI display, and the number of jobs of owner. An empty
+// owner lists the jobs of the request's user and the unowned jobs, or every
+// job for requests without a user; model.OwnerAll lists every job. Only admin
+// users may list the jobs of other users. labels further selects the jobs
+// carrying every label selector, each "key" or "key=value" (see
+// ParseLabelSelector).
+func (s *SampleJobService) List(ctx context.Context, owner string, labels []string, page model.Page) ([]model.SampleJob, int, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"owner":  owner,
+		"labels": labels,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	}).Trace("entering List")
+	defer log.Trace("returning from List")
+
+	scope, err := ownerScope(ctx, owner)
+	if err != nil {
+		log.WithError(err).Warn("sample job list denied")
+		return nil, 0, err
+	}
+	filter := model.SampleJobFilter{Scope: scope}
+	for _, l := range labels {
+		selector, err := ParseLabelSelector(l)
+		if err != nil {
+			log.WithError(err).Debug("rejected sample job label filter")
+			return nil, 0, err
+		}
+		filter.Labels = append(filter.Labels, selector)
+	}
+	total, err := s.store.CountSampleJobs(ctx, filter)
 	if err != nil {
-		s.logger.WithError(err).Error("failed to list sample jobs")
-		return nil, fmt.Errorf("listing sample jobs: %w", err)
+		log.WithError(err).Error("failed to count sample jobs")
+		return nil, 0, fmt.Errorf("counting sample jobs: %w", err)
 	}
-	s.logger.WithField("job_count", len(jobs)).Debug("sample jobs retrieved from store")
+	jobs, err := s.store.ListSampleJobsDesc(ctx, filter, page)
+	if err != nil {
+		log.WithError(err).Error("failed to list sample jobs")
+		return nil, 0, fmt.Errorf("listing sample jobs: %w", err)
+	}
+	log.WithFields(logrus.Fields{
+		"job_count": len(jobs),
+		"total":     total,
+	}).Debug("sample jobs retrieved from store")
 	if jobs == nil {
 		jobs = []model.SampleJob{}
 	}
-	return jobs, nil
+	return jobs, total, nil
 }
 
 // Get returns a sample job by ID, or an error if not found.
-func (s *SampleJobService) Get(id string) (model.SampleJob, error) {
-	s.logger.WithField("sample_job_id", id).Trace("entering Get")
-	defer s.logger.Trace("returning from Get")
+func (s *SampleJobService) Get(ctx context.Context, id string) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", id).Trace("entering Get")
+	defer log.Trace("returning from Get")
 
-	job, err := s.store.GetSampleJob(id)
+	job, err := s.store.GetSampleJob(ctx, id)
 	if err == sql.ErrNoRows {
-		s.logger.WithField("sample_job_id", id).Debug("sample job not found")
+		log.WithField("sample_job_id", id).Debug("sample job not found")
 		return model.SampleJob{}, fmt.Errorf("sample job %s not found", id)
 	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to fetch sample job")
 		return model.SampleJob{}, fmt.Errorf("fetching sample job: %w", err)
 	}
-	s.logger.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	log.WithField("sample_job_id", id).Debug("fetched sample job from store")
 	return job, nil
 }
 
+// ListItems returns the items of a sample job that match query, in query's
+// order and limited to page, including per-item timing, and the number of
+// matching items. Returns a not-found error if the job does not exist.
+func (s *SampleJobService) ListItems(ctx context.Context, id string, query model.SampleJobItemQuery, page model.Page) ([]model.SampleJobItem, int, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"sample_job_id": id,
+		"sort":          query.Sort,
+		"descending":    query.Descending,
+		"limit":         page.Limit,
+		"offset":        page.Offset,
+	}).Trace("entering ListItems")
+	defer log.Trace("returning from ListItems")
+
+	if query.Sort == model.SampleJobItemSortScore && !scoreNamePattern.MatchString(query.ScoreName) {
+		return nil, 0, fmt.Errorf("invalid score name %q: sorting by score requires a name of letters, digits, and underscores", query.ScoreName)
+	}
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.store.CountSampleJobItems(ctx, id, query.Filter)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"sample_job_id": id,
+			"error":         err.Error(),
+		}).Error("failed to count sample job items")
+		return nil, 0, fmt.Errorf("counting sample job items: %w", err)
+	}
+	items, err := s.store.ListSampleJobItemsPage(ctx, id, query, page)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"sample_job_id": id,
+			"error":         err.Error(),
+		}).Error("failed to list sample job items")
+		return nil, 0, fmt.Errorf("listing sample job items: %w", err)
+	}
+	if items == nil {
+		items = []model.SampleJobItem{}
+	}
+	log.WithFields(logrus.Fields{
+		"sample_job_id": id,
+		"item_count":    len(items),
+		"total":         total,
+	}).Debug("sample job items retrieved from store")
+	return items, total, nil
+}
+
 // Create creates a new sample job by expanding study parameters across training run checkpoints.
 // checkpointFilenames is an optional filter: when non-empty, only the listed checkpoints are included.
+// Checkpoints can also be selected by step number with CreateWithOverrides.
 // clearExisting: when true, the sample directory for each selected checkpoint is removed before creating job items.
 // missingOnly: when true, only items whose output file does not already exist on disk are included.
-// Workflow template, VAE, text encoder, and shift are read from the study definition.
-func (s *SampleJobService) Create(trainingRunName string, checkpoints []model.Checkpoint, studyID string, checkpointFilenames []string, clearExisting bool, missingOnly bool) (model.SampleJob, error) {
-	s.logger.WithFields(logrus.Fields{
+// The workflow template is read from the study definition, and the VAE, text
+// encoder, and shift default to the study's values, then to the workflow's.
+func (s *SampleJobService) Create(ctx context.Context, trainingRunName string, checkpoints []model.Checkpoint, studyID string, checkpointFilenames []string, clearExisting bool, missingOnly bool, output model.ImageOutputOptions) (model.SampleJob, error) {
+	return s.CreateWithOverrides(ctx, trainingRunName, checkpoints, studyID, checkpointFilenames, model.StepFilter{}, clearExisting, missingOnly, false, output, model.ModelOverrides{}, false, model.SampleJobAnnotations{})
+}
+
+// CreateWithOverrides is like Create, but uses the non-empty fields of
+// overrides instead of the study's and workflow's VAE, text encoder, and shift.
+// steps further selects the checkpoints by step number; a filter that selects
+// none of them is an error.
+// skipExisting: when true, items whose output file already exists on disk are
+// created as skipped, so that only the missing combinations are generated.
+// appendNewCheckpoints: when true, checkpoints of the training run that appear
+// later are added to the job by AppendNewCheckpoints.
+// annotations are the notes and labels recorded with the job; see Annotate.
+func (s *SampleJobService) CreateWithOverrides(ctx context.Context, trainingRunName string, checkpoints []model.Checkpoint, studyID string, checkpointFilenames []string, steps model.StepFilter, clearExisting bool, missingOnly bool, skipExisting bool, output model.ImageOutputOptions, overrides model.ModelOverrides, appendNewCheckpoints bool, annotations model.SampleJobAnnotations) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
 		"training_run_name":     trainingRunName,
 		"study_id":              studyID,
 		"checkpoint_filter_len": len(checkpointFilenames),
 		"clear_existing":        clearExisting,
 		"missing_only":          missingOnly,
+		"skip_existing":         skipExisting,
+		"append_new":            appendNewCheckpoints,
+		"output_format":         output.Format,
 	}).Trace("entering Create")
-	defer s.logger.Trace("returning from Create")
+	defer log.Trace("returning from Create")
+
+	output, err := s.resolveOutputOptions(output)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+	annotations, err = normalizeJobAnnotations(annotations)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+
+	study, err := s.fetchStudy(ctx, studyID)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+
+	job, items, err := s.buildJob(trainingRunName, checkpoints, study, checkpointFilenames, steps, clearExisting, missingOnly, skipExisting, output, overrides, appendNewCheckpoints)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+	job.CreatedByRequestID = RequestIDFromContext(ctx)
+	job.Owner = ownerOf(ctx)
+	job.Notes = annotations.Notes
+	job.Labels = annotations.Labels
+
+	// Insert the job and its items in one transaction so that a failure
+	// leaves no partially-created job behind.
+	if err := s.store.CreateSampleJobWithItems(ctx, job, items); err != nil {
+		log.WithFields(logrus.Fields{
+			"sample_job_id":     job.ID,
+			"training_run_name": trainingRunName,
+			"error":             err.Error(),
+		}).Error("failed to create sample job")
+		return model.SampleJob{}, fmt.Errorf("creating sample job: %w", err)
+	}
+	log.WithFields(logrus.Fields{
+		"sample_job_id":     job.ID,
+		"training_run_name": trainingRunName,
+		"total_items":       job.TotalItems,
+	}).Info("sample job created")
+
+	return job, nil
+}
+
+// fetchStudy returns the study with the given ID, or an error mentioning
+// "not found" if there is none.
+func (s *SampleJobService) fetchStudy(ctx context.Context, studyID string) (model.Study, error) {
+	study, err := s.store.GetStudy(ctx, studyID)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("study_id", studyID).Debug("study not found")
+		return model.Study{}, fmt.Errorf("study %s not found", studyID)
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"study_id": studyID,
+			"error":    err.Error(),
+		}).Error("failed to fetch study")
+		return model.Study{}, fmt.Errorf("fetching study: %w", err)
+	}
+	s.logger.WithField("study_id", studyID).Debug("fetched study from store")
+	return study, nil
+}
+
+// Preview expands a sample job exactly as CreateWithOverrides would, but
+// stores nothing and does not clear existing samples. It reports the item
+// counts per checkpoint, the checkpoints that failed ComfyUI path matching,
+// and an estimated runtime based on recently completed items.
+func (s *SampleJobService) Preview(ctx context.Context, trainingRunName string, checkpoints []model.Checkpoint, studyID string, checkpointFilenames []string, steps model.StepFilter, missingOnly bool, skipExisting bool, output model.ImageOutputOptions, overrides model.ModelOverrides) (model.SampleJobPreview, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"training_run_name":     trainingRunName,
+		"study_id":              studyID,
+		"checkpoint_filter_len": len(checkpointFilenames),
+		"missing_only":          missingOnly,
+		"skip_existing":         skipExisting,
+		"output_format":         output.Format,
+	}).Trace("entering Preview")
+	defer log.Trace("returning from Preview")
+
+	output, err := s.resolveOutputOptions(output)
+	if err != nil {
+		return model.SampleJobPreview{}, err
+	}
+
+	study, err := s.fetchStudy(ctx, studyID)
+	if err != nil {
+		return model.SampleJobPreview{}, err
+	}
+
+	job, items, err := s.buildJob(trainingRunName, checkpoints, study, checkpointFilenames, steps, false, missingOnly, skipExisting, output, overrides, false)
+	if err != nil {
+		return model.SampleJobPreview{}, err
+	}
+
+	counts := make(map[string]int, len(job.CheckpointFilenames))
+	matchedItems := make(map[string]bool)
+	unmatched := make(map[string]bool)
+	preview := model.SampleJobPreview{
+		TotalItems:           len(items),
+		Checkpoints:          make([]model.CheckpointPreview, 0, len(job.CheckpointFilenames)),
+		UnmatchedCheckpoints: []string{},
+		Warnings:             job.Warnings,
+	}
+	for _, item := range items {
+		counts[item.CheckpointFilename]++
+		switch {
+		case item.Status != model.SampleJobItemStatusSkipped:
+			matchedItems[item.CheckpointFilename] = true
+			preview.RunnableItems++
+		case item.SkipReason == model.SampleJobItemSkipReasonDuplicate:
+			preview.ExistingItems++
+		default:
+			unmatched[item.CheckpointFilename] = true
+		}
+	}
+	for _, filename := range job.CheckpointFilenames {
+		matched := matchedItems[filename]
+		if !matched && !unmatched[filename] {
+			// buildJob only matches checkpoints that have items left to
+			// generate; match the others here so the preview reports them too.
+			_, matchErr := s.pathMatcher.MatchCheckpointPath(trainingRunName, filename)
+			matched = matchErr == nil
+		}
+		preview.Checkpoints = append(preview.Checkpoints, model.CheckpointPreview{
+			Filename:    filename,
+			Items:       counts[filename],
+			PathMatched: matched,
+		})
+		if !matched {
+			preview.UnmatchedCheckpoints = append(preview.UnmatchedCheckpoints, filename)
+		}
+	}
+
+	if s.durations != nil {
+		avg, ok, err := s.durations.AverageItemDuration(ctx, bulkEstimateWindow)
+		if err != nil {
+			// The estimate is informational; the rest of the preview stands.
+			log.WithError(err).Warn("failed to estimate sample job duration")
+		} else if ok {
+			estimate := avg * time.Duration(preview.RunnableItems)
+			preview.EstimatedDuration = &estimate
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"training_run_name": trainingRunName,
+		"study_id":          studyID,
+		"total_items":       preview.TotalItems,
+		"unmatched_count":   len(preview.UnmatchedCheckpoints),
+	}).Debug("previewed sample job")
+	return preview, nil
+}
 
+// CreateWithStudy creates a sample job of study, a new study that is not yet
+// stored (see StudyService.NewStudy), and stores both in one transaction so
+// that a failure leaves no orphaned study behind. The other parameters are as
+// for CreateWithOverrides.
+func (s *SampleJobService) CreateWithStudy(ctx context.Context, trainingRunName string, checkpoints []model.Checkpoint, study model.Study, checkpointFilenames []string, clearExisting bool, missingOnly bool, skipExisting bool, output model.ImageOutputOptions, appendNewCheckpoints bool) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"training_run_name":     trainingRunName,
+		"study_name":            study.Name,
+		"checkpoint_filter_len": len(checkpointFilenames),
+		"clear_existing":        clearExisting,
+		"missing_only":          missingOnly,
+		"skip_existing":         skipExisting,
+		"append_new":            appendNewCheckpoints,
+		"output_format":         output.Format,
+	}).Trace("entering CreateWithStudy")
+	defer log.Trace("returning from CreateWithStudy")
+
+	output, err := s.resolveOutputOptions(output)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+
+	job, items, err := s.buildJob(trainingRunName, checkpoints, study, checkpointFilenames, model.StepFilter{}, clearExisting, missingOnly, skipExisting, output, model.ModelOverrides{}, appendNewCheckpoints)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+	job.CreatedByRequestID = RequestIDFromContext(ctx)
+	job.Owner = ownerOf(ctx)
+	study.Owner = job.Owner
+
+	if err := s.store.CreateStudyWithSampleJob(ctx, study, job, items); err != nil {
+		log.WithFields(logrus.Fields{
+			"study_id":          study.ID,
+			"sample_job_id":     job.ID,
+			"training_run_name": trainingRunName,
+			"error":             err.Error(),
+		}).Error("failed to create study and sample job")
+		return model.SampleJob{}, fmt.Errorf("creating study and sample job: %w", err)
+	}
+	log.WithFields(logrus.Fields{
+		"study_id":          study.ID,
+		"study_name":        study.Name,
+		"sample_job_id":     job.ID,
+		"training_run_name": trainingRunName,
+		"total_items":       job.TotalItems,
+	}).Info("study and sample job created")
+
+	return job, nil
+}
+
+// buildJob builds a pending job of study over checkpoints and expands its
+// items, without storing either. See CreateWithOverrides for the parameters.
+func (s *SampleJobService) buildJob(trainingRunName string, checkpoints []model.Checkpoint, study model.Study, checkpointFilenames []string, steps model.StepFilter, clearExisting bool, missingOnly bool, skipExisting bool, output model.ImageOutputOptions, overrides model.ModelOverrides, appendNewCheckpoints bool) (model.SampleJob, []model.SampleJobItem, error) {
 	// Filter checkpoints when a specific list is provided
 	if len(checkpointFilenames) > 0 {
 		filterSet := make(map[string]struct{}, len(checkpointFilenames))
@@ -186,29 +611,57 @@ func (s *SampleJobService) Create(trainingRunName string, checkpoints []model.Ch
 		}).Debug("filtered checkpoints by filename list")
 	}
 
-	// Fetch the study
-	study, err := s.store.GetStudy(studyID)
-	if err == sql.ErrNoRows {
-		s.logger.WithField("study_id", studyID).Debug("study not found")
-		return model.SampleJob{}, fmt.Errorf("study %s not found", studyID)
-	}
-	if err != nil {
+	// Filter checkpoints by step number when a range or interval is provided
+	if !steps.IsZero() {
+		if steps.MinStep != nil && steps.MaxStep != nil && *steps.MinStep > *steps.MaxStep {
+			return model.SampleJob{}, nil, fmt.Errorf("min_step %d is greater than max_step %d", *steps.MinStep, *steps.MaxStep)
+		}
+		checkpoints = steps.Apply(checkpoints)
 		s.logger.WithFields(logrus.Fields{
-			"study_id": studyID,
-			"error":    err.Error(),
-		}).Error("failed to fetch study")
-		return model.SampleJob{}, fmt.Errorf("fetching study: %w", err)
+			"training_run_name": trainingRunName,
+			"filtered_count":    len(checkpoints),
+		}).Debug("filtered checkpoints by step number")
+		if len(checkpoints) == 0 {
+			return model.SampleJob{}, nil, fmt.Errorf("no checkpoint of training run %s is selected by the step filter", trainingRunName)
+		}
 	}
-	s.logger.WithField("study_id", studyID).Debug("fetched study from store")
 
 	// B-104: Validate that the study has a workflow template configured.
 	// Without a workflow template, the job executor cannot load a ComfyUI workflow,
 	// resulting in every item failing with "workflow not found: .json".
 	if study.WorkflowTemplate == "" {
-		s.logger.WithField("study_id", studyID).Warn("study has no workflow template configured")
-		return model.SampleJob{}, fmt.Errorf("study %q has no workflow template configured", study.Name)
+		s.logger.WithField("study_id", study.ID).Warn("study has no workflow template configured")
+		return model.SampleJob{}, nil, fmt.Errorf("study %q has no workflow template configured", study.Name)
+	}
+
+	if err := s.validateWorkflowOverrides(overrides.Workflows); err != nil {
+		return model.SampleJob{}, nil, err
 	}
 
+	var warnings []string
+	if s.vram != nil {
+		width, height := study.LargestImageSize()
+		for _, workflow := range jobWorkflows(study.WorkflowTemplate, overrides.Workflows) {
+			est, err := s.vram.Check(workflow, width, height)
+			if err != nil {
+				s.logger.WithFields(logrus.Fields{
+					"study_id": study.ID,
+					"workflow": workflow,
+					"error":    err.Error(),
+				}).Info("sample job refused by VRAM check")
+				return model.SampleJob{}, nil, err
+			}
+			if est.Warning != "" {
+				warnings = append(warnings, est.Warning)
+			}
+		}
+	}
+
+	if err := s.validateControlNet(study, overrides); err != nil {
+		return model.SampleJob{}, nil, err
+	}
+	models := s.resolveModels(study, overrides)
+
 	// Calculate total items: checkpoints × images per checkpoint
 	imagesPerCheckpoint := study.ImagesPerCheckpoint()
 	totalItems := len(checkpoints) * imagesPerCheckpoint
@@ -219,55 +672,55 @@ func (s *SampleJobService) Create(trainingRunName string, checkpoints []model.Ch
 		selectedFilenames[i] = cp.Filename
 	}
 
-	// Create the job — workflow, VAE, text encoder, and shift come from the study definition.
+	// Create the job — the workflow comes from the study definition.
 	now := time.Now().UTC()
 	jobID := uuid.New().String()
 	job := model.SampleJob{
-		ID:                  jobID,
-		TrainingRunName:     trainingRunName,
-		StudyID:             studyID,
-		StudyName:           study.Name,
-		WorkflowName:        study.WorkflowTemplate,
-		VAE:                 study.VAE,
-		CLIP:                study.TextEncoder,
-		Shift:               study.Shift,
-		CheckpointFilenames: selectedFilenames,
-		ClearExisting:       clearExisting,
-		Status:              model.SampleJobStatusPending,
-		TotalItems:          totalItems,
-		CompletedItems:      0,
-		CreatedAt:           now,
-		UpdatedAt:           now,
-	}
-
-	if err := s.store.CreateSampleJob(job); err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"sample_job_id":     jobID,
-			"training_run_name": trainingRunName,
-			"error":             err.Error(),
-		}).Error("failed to create sample job")
-		return model.SampleJob{}, fmt.Errorf("creating sample job: %w", err)
+		ID:                   jobID,
+		TrainingRunName:      trainingRunName,
+		StudyID:              study.ID,
+		StudyName:            study.Name,
+		StudyVersion:         study.Version,
+		WorkflowName:         study.WorkflowTemplate,
+		WorkflowOverrides:    overrides.Workflows,
+		VAE:                  models.VAE,
+		CLIP:                 models.CLIP,
+		Shift:                models.Shift,
+		CheckpointFilenames:  selectedFilenames,
+		ClearExisting:        clearExisting,
+		AppendNewCheckpoints: appendNewCheckpoints,
+		OutputFormat:         output.Format,
+		OutputQuality:        output.Quality,
+		InputImage:           study.InputImage,
+		ControlNetModel:      models.ControlNetModel,
+		ControlNetStrength:   models.ControlNetStrength,
+		ControlNetImage:      models.ControlNetImage,
+		Status:               model.SampleJobStatusPending,
+		TotalItems:           totalItems,
+		CompletedItems:       0,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+		Warnings:             warnings,
 	}
-	s.logger.WithFields(logrus.Fields{
-		"sample_job_id":     jobID,
-		"training_run_name": trainingRunName,
-		"total_items":       totalItems,
-	}).Info("sample job created")
 
 	// Expand items: for each checkpoint, iterate over all parameter combinations
 	items := s.expandJobItems(jobID, checkpoints, study)
+	applyWorkflowOverrides(items, overrides.Workflows)
 	s.logger.WithFields(logrus.Fields{
 		"sample_job_id": jobID,
 		"item_count":    len(items),
 	}).Debug("expanded job items")
+	if err := s.checkOutputPathsUnique(items, job.OutputFormat); err != nil {
+		return model.SampleJob{}, nil, err
+	}
 
 	// When missingOnly is true, filter out items whose output file already exists on disk
 	if missingOnly && s.fileChecker != nil {
 		var filtered []model.SampleJobItem
 		skipped := 0
 		for _, item := range items {
-			outputFilename := GenerateOutputFilename(item)
-			outputPath := filepath.Join(s.sampleDir, study.Name, item.CheckpointFilename, outputFilename)
+			outputFilename := OutputRelPath(s.outputLayout, item, job.OutputFormat)
+			outputPath := filepath.Join(s.sampleDir, study.Name, item.CheckpointFilename, filepath.FromSlash(outputFilename))
 			if s.fileChecker.FileExists(outputPath) {
 				skipped++
 				continue
@@ -281,22 +734,41 @@ func (s *SampleJobService) Create(trainingRunName string, checkpoints []model.Ch
 			"remaining":        len(filtered),
 		}).Info("filtered items for missing-only job")
 		items = filtered
-		// Update total items on the job to reflect the filtered count
+		// Total items on the job reflect the filtered count
 		totalItems = len(items)
 		job.TotalItems = totalItems
-		if err := s.store.UpdateSampleJob(job); err != nil {
-			s.logger.WithFields(logrus.Fields{
-				"sample_job_id": jobID,
-				"error":         err.Error(),
-			}).Error("failed to update sample job total items")
-			_ = s.store.DeleteSampleJob(jobID)
-			return model.SampleJob{}, fmt.Errorf("updating sample job total items: %w", err)
+	}
+
+	// When skipExisting is true, mark items whose output file already exists
+	// on disk as skipped, so that only the missing combinations are generated
+	// while the job still lists every combination.
+	if skipExisting && s.fileChecker != nil {
+		skipped := 0
+		for idx := range items {
+			item := &items[idx]
+			outputPath := filepath.Join(s.sampleDir, study.Name, item.CheckpointFilename, filepath.FromSlash(OutputRelPath(s.outputLayout, *item, job.OutputFormat)))
+			if s.fileChecker.FileExists(outputPath) {
+				item.Status = model.SampleJobItemStatusSkipped
+				item.SkipReason = model.SampleJobItemSkipReasonDuplicate
+				item.ErrorMessage = model.SkippedOutputExistsMessage
+				item.OutputPath = outputPath
+				skipped++
+			}
 		}
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id":    jobID,
+			"total_expanded":   len(items),
+			"skipped_existing": skipped,
+		}).Info("skipped items with existing output")
 	}
 
-	// Match checkpoint filenames to ComfyUI model paths and create job items
-	for _, item := range items {
-		comfyuiPath, err := s.pathMatcher.MatchCheckpointPath(item.CheckpointFilename)
+	// Match checkpoint filenames to ComfyUI model paths
+	for idx := range items {
+		item := &items[idx]
+		if item.Status == model.SampleJobItemStatusSkipped {
+			continue
+		}
+		comfyuiPath, err := s.pathMatcher.MatchCheckpointPath(trainingRunName, item.CheckpointFilename)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
 				"sample_job_id":        jobID,
@@ -305,6 +777,7 @@ func (s *SampleJobService) Create(trainingRunName string, checkpoints []model.Ch
 			}).Warn("failed to match checkpoint to ComfyUI path, marking item as skipped")
 			// Mark item as skipped if path matching fails
 			item.Status = model.SampleJobItemStatusSkipped
+			item.SkipReason = model.SampleJobItemSkipReasonCheckpointNotFound
 			item.ErrorMessage = fmt.Sprintf("checkpoint not found in ComfyUI: %v", err)
 			item.ComfyUIModelPath = ""
 		} else {
@@ -314,62 +787,331 @@ func (s *SampleJobService) Create(trainingRunName string, checkpoints []model.Ch
 				"comfyui_path":        comfyuiPath,
 			}).Debug("matched checkpoint to ComfyUI path")
 		}
+	}
 
-		if err := s.store.CreateSampleJobItem(item); err != nil {
-			s.logger.WithFields(logrus.Fields{
-				"sample_job_id":       jobID,
-				"sample_job_item_id":  item.ID,
-				"checkpoint_filename": item.CheckpointFilename,
-				"error":               err.Error(),
-			}).Error("failed to create sample job item")
-			// Clean up: delete the job since item creation failed
-			_ = s.store.DeleteSampleJob(jobID)
-			return model.SampleJob{}, fmt.Errorf("creating sample job item: %w", err)
+	return job, items, nil
+}
+
+// checkOutputPathsUnique rejects items the output layout saves at the same
+// path, where each image would overwrite the one before it. Query-encoded
+// filenames carry every dimension, so only layouts can collide.
+func (s *SampleJobService) checkOutputPathsUnique(items []model.SampleJobItem, format model.OutputFormat) error {
+	if s.outputLayout.IsZero() {
+		return nil
+	}
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		p := item.CheckpointFilename + "/" + OutputRelPath(s.outputLayout, item, format)
+		if seen[p] {
+			return fmt.Errorf("output layout %q saves more than one image of the job at %s; add placeholders for the dimensions the study varies", s.outputLayout, p)
 		}
+		seen[p] = true
+	}
+	return nil
+}
+
+// resolveModels picks the VAE, text encoder, and shift of a new job. Each is
+// taken from overrides if set, else from the study, else from the defaults
+// declared by the study's workflow. A workflow that cannot be loaded is
+// logged and contributes no defaults.
+func (s *SampleJobService) resolveModels(study model.Study, overrides model.ModelOverrides) model.ModelOverrides {
+	models := overrides
+	if models.VAE == "" {
+		models.VAE = study.VAE
+	}
+	if models.CLIP == "" {
+		models.CLIP = study.TextEncoder
+	}
+	if models.Shift == nil {
+		models.Shift = study.Shift
+	}
+	if s.workflows == nil || (models.VAE != "" && models.CLIP != "" && models.Shift != nil) {
+		return models
 	}
 
+	workflow, err := s.workflows.Get(context.Background(), study.WorkflowTemplate)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"workflow": study.WorkflowTemplate,
+			"error":    err.Error(),
+		}).Warn("failed to load workflow defaults")
+		return models
+	}
+	if models.VAE == "" {
+		models.VAE = workflow.Defaults.VAE
+	}
+	if models.CLIP == "" {
+		models.CLIP = workflow.Defaults.CLIP
+	}
+	if models.Shift == nil {
+		models.Shift = workflow.Defaults.Shift
+	}
 	s.logger.WithFields(logrus.Fields{
-		"sample_job_id": jobID,
-		"item_count":    len(items),
-	}).Info("sample job items created")
+		"workflow": study.WorkflowTemplate,
+		"vae":      models.VAE,
+		"clip":     models.CLIP,
+	}).Debug("applied workflow defaults")
+	return models
+}
 
-	return job, nil
+// validateWorkflowOverrides checks the workflow overrides of a new job. Each
+// needs a valid checkpoint pattern and a workflow; the workflow must load when
+// a workflow source is set, so that no item of the job fails for lack of it.
+func (s *SampleJobService) validateWorkflowOverrides(overrides []model.WorkflowOverride) error {
+	for _, o := range overrides {
+		if o.Pattern == "" {
+			return fmt.Errorf("workflow override for %q has no checkpoint pattern", o.Workflow)
+		}
+		if _, err := filepath.Match(o.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid checkpoint pattern %q: %w", o.Pattern, err)
+		}
+		if o.Workflow == "" {
+			return fmt.Errorf("workflow override for checkpoint pattern %q has no workflow", o.Pattern)
+		}
+		if s.workflows == nil {
+			continue
+		}
+		if _, err := s.workflows.Get(context.Background(), o.Workflow); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"workflow": o.Workflow,
+				"pattern":  o.Pattern,
+				"error":    err.Error(),
+			}).Info("sample job refused, override workflow cannot be loaded")
+			return fmt.Errorf("workflow override for checkpoint pattern %q: %w", o.Pattern, err)
+		}
+	}
+	return nil
+}
+
+// jobWorkflows returns the distinct workflows a job with the given workflow
+// and overrides may sample with, its own first.
+func jobWorkflows(workflow string, overrides []model.WorkflowOverride) []string {
+	workflows := []string{workflow}
+	for _, o := range overrides {
+		if !slices.Contains(workflows, o.Workflow) {
+			workflows = append(workflows, o.Workflow)
+		}
+	}
+	return workflows
+}
+
+// applyWorkflowOverrides sets the workflow of each item whose checkpoint
+// matches one of overrides. Items matching none use the job's workflow.
+func applyWorkflowOverrides(items []model.SampleJobItem, overrides []model.WorkflowOverride) {
+	if len(overrides) == 0 {
+		return
+	}
+	for i := range items {
+		items[i].WorkflowName = model.MatchWorkflowOverride(overrides, items[i].CheckpointFilename)
+	}
+}
+
+// maxControlNetStrength is the largest strength ComfyUI's ControlNet apply
+// nodes accept.
+const maxControlNetStrength = 10
+
+// validateControlNet checks the ControlNet settings of a new job. A ControlNet
+// model needs a controlnet_loader node in the study's workflow and a strength
+// or conditioning image needs a controlnet_apply node; otherwise they would be
+// silently ignored. The workflow is only checked when it can be loaded.
+func (s *SampleJobService) validateControlNet(study model.Study, overrides model.ModelOverrides) error {
+	if overrides.ControlNetStrength != nil {
+		strength := *overrides.ControlNetStrength
+		if strength < 0 || strength > maxControlNetStrength {
+			return fmt.Errorf("controlnet strength must be between 0 and %d", maxControlNetStrength)
+		}
+	}
+	needLoader := overrides.ControlNetModel != ""
+	needApply := overrides.ControlNetStrength != nil || overrides.ControlNetImage != ""
+	if (!needLoader && !needApply) || s.workflows == nil {
+		return nil
+	}
+
+	workflow, err := s.workflows.Get(context.Background(), study.WorkflowTemplate)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"workflow": study.WorkflowTemplate,
+			"error":    err.Error(),
+		}).Warn("failed to load workflow to check controlnet roles")
+		return nil
+	}
+	if needLoader && len(workflow.Roles[string(model.CSRoleControlNetLoader)]) == 0 {
+		return fmt.Errorf("workflow %q has no controlnet_loader node for the controlnet model", study.WorkflowTemplate)
+	}
+	if needApply && len(workflow.Roles[string(model.CSRoleControlNetApply)]) == 0 {
+		return fmt.Errorf("workflow %q has no controlnet_apply node for the controlnet strength and image", study.WorkflowTemplate)
+	}
+	return nil
+}
+
+// CreateBulk creates one sample job per study for the same training run. Jobs
+// are created in the order of studyIDs and, because the executor picks up
+// pending jobs FIFO, run in that order. All studies are validated before any
+// job is created; if a job fails to be created part-way through, the jobs
+// already created by this call are deleted again.
+func (s *SampleJobService) CreateBulk(ctx context.Context, trainingRunName string, checkpoints []model.Checkpoint, studyIDs []string, checkpointFilenames []string, clearExisting bool, missingOnly bool, skipExisting bool, output model.ImageOutputOptions) (model.BulkSampleJobResult, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"training_run_name": trainingRunName,
+		"study_count":       len(studyIDs),
+	}).Trace("entering CreateBulk")
+	defer log.Trace("returning from CreateBulk")
+
+	if len(studyIDs) == 0 {
+		return model.BulkSampleJobResult{}, fmt.Errorf("invalid bulk job request: at least one study is required")
+	}
+	if _, err := s.resolveOutputOptions(output); err != nil {
+		return model.BulkSampleJobResult{}, err
+	}
+	seen := make(map[string]bool, len(studyIDs))
+	for _, id := range studyIDs {
+		if seen[id] {
+			return model.BulkSampleJobResult{}, fmt.Errorf("invalid bulk job request: study %s listed more than once", id)
+		}
+		seen[id] = true
+
+		study, err := s.store.GetStudy(ctx, id)
+		if err == sql.ErrNoRows {
+			return model.BulkSampleJobResult{}, fmt.Errorf("study %s not found", id)
+		}
+		if err != nil {
+			return model.BulkSampleJobResult{}, fmt.Errorf("fetching study: %w", err)
+		}
+		if study.WorkflowTemplate == "" {
+			return model.BulkSampleJobResult{}, fmt.Errorf("study %q has no workflow template configured", study.Name)
+		}
+	}
+
+	result := model.BulkSampleJobResult{Jobs: make([]model.SampleJob, 0, len(studyIDs))}
+	for _, id := range studyIDs {
+		job, err := s.CreateWithOverrides(ctx, trainingRunName, checkpoints, id, checkpointFilenames, model.StepFilter{}, clearExisting, missingOnly, skipExisting, output, model.ModelOverrides{}, false, model.SampleJobAnnotations{})
+		if err != nil {
+			for _, created := range result.Jobs {
+				if delErr := s.store.DeleteSampleJob(ctx, created.ID); delErr != nil {
+					log.WithFields(logrus.Fields{
+						"sample_job_id": created.ID,
+						"error":         delErr.Error(),
+					}).Warn("failed to roll back bulk-created sample job")
+				}
+			}
+			return model.BulkSampleJobResult{}, err
+		}
+		result.Jobs = append(result.Jobs, job)
+		result.TotalItems += job.TotalItems
+	}
+
+	if s.durations != nil {
+		avg, ok, err := s.durations.AverageItemDuration(ctx, bulkEstimateWindow)
+		if err != nil {
+			// The estimate is informational; the jobs are already created.
+			log.WithError(err).Warn("failed to estimate bulk job duration")
+		} else if ok {
+			estimate := avg * time.Duration(result.TotalItems)
+			result.EstimatedDuration = &estimate
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"training_run_name": trainingRunName,
+		"job_count":         len(result.Jobs),
+		"total_items":       result.TotalItems,
+	}).Info("bulk sample jobs created")
+	return result, nil
+}
+
+// resolveOutputOptions validates the requested output format and quality and
+// fills in defaults: an empty format means PNG, and lossy formats without a
+// quality use model.DefaultOutputQuality. Quality is cleared for PNG.
+func (s *SampleJobService) resolveOutputOptions(output model.ImageOutputOptions) (model.ImageOutputOptions, error) {
+	if output.Format == "" {
+		output.Format = model.OutputFormatPNG
+	}
+	if !output.Format.IsValid() {
+		return model.ImageOutputOptions{}, fmt.Errorf("invalid output format %q", output.Format)
+	}
+	if s.formats != nil && !s.formats.Supports(output.Format) {
+		return model.ImageOutputOptions{}, fmt.Errorf("invalid output format %q: no encoder is available on this server", output.Format)
+	}
+	if !output.Format.IsLossy() {
+		output.Quality = 0
+		return output, nil
+	}
+	if output.Quality == 0 {
+		output.Quality = model.DefaultOutputQuality
+	}
+	if output.Quality < model.MinOutputQuality || output.Quality > model.MaxOutputQuality {
+		return model.ImageOutputOptions{}, fmt.Errorf("invalid output quality %d: must be between %d and %d", output.Quality, model.MinOutputQuality, model.MaxOutputQuality)
+	}
+	return output, nil
 }
 
 // expandJobItems generates all work items for a job by expanding the study parameters across checkpoints.
+// In the random seed modes the seeds are drawn here: once per checkpoint and
+// shared by all of its combinations, or afresh for every item.
 func (s *SampleJobService) expandJobItems(jobID string, checkpoints []model.Checkpoint, study model.Study) []model.SampleJobItem {
 	var items []model.SampleJobItem
 	now := time.Now().UTC()
 
 	for _, checkpoint := range checkpoints {
+		var checkpointSeeds []int64
+		if study.SeedMode == model.SeedModeRandomPerCheckpoint {
+			checkpointSeeds = make([]int64, study.RandomSeedCount)
+			for i := range checkpointSeeds {
+				checkpointSeeds[i] = s.randomSeed()
+			}
+		}
+
 		// Iterate over all parameter combinations using sampler/scheduler pairs
 		for _, prompt := range study.Prompts {
-			// Apply prompt prefix using smart separator logic
-			promptText := model.JoinPromptPrefix(study.PromptPrefix, prompt.Text)
-			for _, steps := range study.Steps {
-				for _, cfg := range study.CFGs {
-					for _, pair := range study.SamplerSchedulerPairs {
-						for _, seed := range study.Seeds {
-							item := model.SampleJobItem{
-								ID:                 uuid.New().String(),
-								JobID:              jobID,
-								CheckpointFilename: checkpoint.Filename,
-								ComfyUIModelPath:   "", // Will be filled by path matching
-								PromptName:         prompt.Name,
-								PromptText:         promptText,
-								NegativePrompt:     study.NegativePrompt,
-								Steps:              steps,
-								CFG:                cfg,
-								SamplerName:        pair.Sampler,
-								Scheduler:          pair.Scheduler,
-								Seed:               seed,
-								Width:              study.Width,
-								Height:             study.Height,
-								Status:             model.SampleJobItemStatusPending,
-								CreatedAt:          now,
-								UpdatedAt:          now,
+			settings := study.SettingsFor(prompt)
+			sizes, swept := settings.Sizes()
+			switch study.SeedMode {
+			case model.SeedModeRandomPerCheckpoint:
+				settings.Seeds = checkpointSeeds
+			case model.SeedModeRandomPerItem:
+				// Placeholders, replaced by a new seed in the loop below.
+				settings.Seeds = make([]int64, study.RandomSeedCount)
+			}
+			for _, variant := range study.VariantsOf(prompt) {
+				// Apply prompt prefix using smart separator logic
+				promptText := model.JoinPromptPrefix(study.PromptPrefix, variant.Text)
+				for _, steps := range settings.Steps {
+					for _, cfg := range settings.CFGs {
+						for _, pair := range study.SamplerSchedulerPairs {
+							for _, seed := range settings.Seeds {
+								if study.SeedMode == model.SeedModeRandomPerItem {
+									seed = s.randomSeed()
+								}
+								for _, size := range sizes {
+									for _, denoise := range study.DenoiseValues() {
+										item := model.SampleJobItem{
+											ID:                 uuid.New().String(),
+											JobID:              jobID,
+											CheckpointFilename: checkpoint.Filename,
+											ComfyUIModelPath:   "", // Will be filled by path matching
+											PromptName:         prompt.Name,
+											PromptText:         promptText,
+											NegativePrompt:     study.NegativePrompt,
+											Steps:              steps,
+											CFG:                cfg,
+											SamplerName:        pair.Sampler,
+											Scheduler:          pair.Scheduler,
+											Seed:               seed,
+											Width:              size.Width,
+											Height:             size.Height,
+											Denoise:            denoise,
+											Wildcards:          variant.Wildcards,
+											Status:             model.SampleJobItemStatusPending,
+											CreatedAt:          now,
+											UpdatedAt:          now,
+										}
+										if swept {
+											item.Resolution = size.String()
+										}
+										items = append(items, item)
+									}
+								}
 							}
-							items = append(items, item)
 						}
 					}
 				}
@@ -380,48 +1122,171 @@ func (s *SampleJobService) expandJobItems(jobID string, checkpoints []model.Chec
 	return items
 }
 
+// AppendNewCheckpoints adds items for the checkpoints of an append-mode job's
+// training run that are not part of the job yet, and returns the number of
+// items added. A checkpoint ComfyUI cannot see yet is left for a later call
+// rather than added as skipped, since a new checkpoint may still be being
+// written or indexed. A completed job that gains items goes back to pending so
+// that the executor picks it up again; stopped and failed jobs are left alone.
+func (s *SampleJobService) AppendNewCheckpoints(ctx context.Context, jobID string) (int, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", jobID).Trace("entering AppendNewCheckpoints")
+	defer log.Trace("returning from AppendNewCheckpoints")
+
+	if s.runs == nil {
+		return 0, nil
+	}
+
+	job, err := s.store.GetSampleJob(ctx, jobID)
+	if err != nil {
+		return 0, fmt.Errorf("fetching sample job: %w", err)
+	}
+	if !job.AppendNewCheckpoints {
+		return 0, nil
+	}
+	switch job.Status {
+	case model.SampleJobStatusPending, model.SampleJobStatusRunning,
+		model.SampleJobStatusCompleted, model.SampleJobStatusCompletedWithErrors:
+	default:
+		return 0, nil
+	}
+
+	runs, err := s.runs.Discover(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("discovering training runs: %w", err)
+	}
+	var run *model.TrainingRun
+	for i := range runs {
+		if runs[i].Name == job.TrainingRunName {
+			run = &runs[i]
+			break
+		}
+	}
+	if run == nil {
+		log.WithFields(logrus.Fields{
+			"sample_job_id":     jobID,
+			"training_run_name": job.TrainingRunName,
+		}).Debug("training run of append-mode job not found")
+		return 0, nil
+	}
+
+	known := make(map[string]bool, len(job.CheckpointFilenames))
+	for _, fn := range job.CheckpointFilenames {
+		known[fn] = true
+	}
+	var newCheckpoints []model.Checkpoint
+	modelPaths := make(map[string]string)
+	for _, cp := range run.Checkpoints {
+		if known[cp.Filename] {
+			continue
+		}
+		comfyuiPath, err := s.pathMatcher.MatchCheckpointPath(job.TrainingRunName, cp.Filename)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"sample_job_id":       jobID,
+				"checkpoint_filename": cp.Filename,
+				"error":               err.Error(),
+			}).Debug("new checkpoint not available in ComfyUI yet, leaving it for a later check")
+			continue
+		}
+		newCheckpoints = append(newCheckpoints, cp)
+		modelPaths[cp.Filename] = comfyuiPath
+	}
+	if len(newCheckpoints) == 0 {
+		return 0, nil
+	}
+
+	// New checkpoints are sampled like the others: with the study as it was
+	// when the job started, or before it starts, with the version of the
+	// study the job was created from, not as it has been edited since.
+	var study model.Study
+	if params, err := s.store.GetSampleJobParameters(ctx, job.ID); err == nil {
+		study = params.Study
+	} else if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("fetching job parameters: %w", err)
+	} else if study, err = s.store.GetStudy(ctx, job.StudyID); err != nil {
+		return 0, fmt.Errorf("fetching study %s: %w", job.StudyID, err)
+	} else if study, err = studyAtVersion(ctx, s.store, study, job.StudyVersion); err != nil {
+		return 0, err
+	}
+	items := s.expandJobItems(job.ID, newCheckpoints, study)
+	applyWorkflowOverrides(items, job.WorkflowOverrides)
+	for i := range items {
+		items[i].ComfyUIModelPath = modelPaths[items[i].CheckpointFilename]
+	}
+
+	for _, cp := range newCheckpoints {
+		job.CheckpointFilenames = append(job.CheckpointFilenames, cp.Filename)
+	}
+	job.TotalItems += len(items)
+	if job.Status != model.SampleJobStatusPending && CanTransitionJob(job.Status, model.SampleJobStatusPending) {
+		job.Status = model.SampleJobStatusPending
+	}
+	job.UpdatedAt = time.Now().UTC()
+
+	if err := s.store.AppendSampleJobItems(ctx, job, items); err != nil {
+		log.WithFields(logrus.Fields{
+			"sample_job_id": jobID,
+			"error":         err.Error(),
+		}).Error("failed to append items for new checkpoints")
+		return 0, fmt.Errorf("appending sample job items: %w", err)
+	}
+	log.WithFields(logrus.Fields{
+		"sample_job_id":    jobID,
+		"checkpoint_count": len(newCheckpoints),
+		"item_count":       len(items),
+		"status":           job.Status,
+	}).Info("appended items for new checkpoints to sample job")
+	return len(items), nil
+}
+
 // Start transitions a pending job to running status.
-func (s *SampleJobService) Start(id string) (model.SampleJob, error) {
-	s.logger.WithField("sample_job_id", id).Trace("entering Start")
-	defer s.logger.Trace("returning from Start")
+func (s *SampleJobService) Start(ctx context.Context, id string) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", id).Trace("entering Start")
+	defer log.Trace("returning from Start")
 
 	// Check if executor is available and connected
 	if s.executor == nil || !s.executor.IsConnected() {
-		s.logger.Warn("cannot start job: ComfyUI not connected")
+		log.Warn("cannot start job: ComfyUI not connected")
 		return model.SampleJob{}, fmt.Errorf("ComfyUI not connected")
 	}
 
 	// Guard: reject if another job is already running
-	hasRunning, err := s.store.HasRunningJob()
+	hasRunning, err := s.store.HasRunningJob(ctx)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to check for running jobs")
 		return model.SampleJob{}, fmt.Errorf("checking for running jobs: %w", err)
 	}
 	if hasRunning {
-		s.logger.WithField("sample_job_id", id).Warn("cannot start job: another job is already running")
+		log.WithField("sample_job_id", id).Warn("cannot start job: another job is already running")
 		return model.SampleJob{}, fmt.Errorf("another job is already running")
 	}
 
-	job, err := s.store.GetSampleJob(id)
+	job, err := s.store.GetSampleJob(ctx, id)
 	if err == sql.ErrNoRows {
-		s.logger.WithField("sample_job_id", id).Debug("sample job not found")
+		log.WithField("sample_job_id", id).Debug("sample job not found")
 		return model.SampleJob{}, fmt.Errorf("sample job %s not found", id)
 	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to fetch sample job")
 		return model.SampleJob{}, fmt.Errorf("fetching sample job: %w", err)
 	}
-	s.logger.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	log.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	if err := checkOwner(ctx, "sample job", id, job.Owner); err != nil {
+		log.WithError(err).Warn("sample job change denied")
+		return model.SampleJob{}, err
+	}
 
 	// Validate state transition
 	if job.Status != model.SampleJobStatusPending {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id":  id,
 			"current_status": job.Status,
 		}).Warn("cannot start job: job is not pending")
@@ -432,22 +1297,20 @@ func (s *SampleJobService) Start(id string) (model.SampleJob, error) {
 	// to running (not at queue time). After clearing, reset the flag so that
 	// resuming a stopped/failed job does not re-clear.
 	if job.ClearExisting && s.dirRemover != nil {
-		s.clearSampleDirsForJob(job)
+		s.clearSampleDirsForJob(ctx, job)
 	}
 
 	// Update status to running; reset ClearExisting so resume never re-clears
-	job.Status = model.SampleJobStatusRunning
 	job.ClearExisting = false
-	job.UpdatedAt = time.Now().UTC()
-
-	if err := s.store.UpdateSampleJob(job); err != nil {
-		s.logger.WithFields(logrus.Fields{
+	job, err = s.states.Transition(ctx, job, model.SampleJobStatusRunning)
+	if err != nil {
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to update sample job status")
 		return model.SampleJob{}, fmt.Errorf("updating sample job: %w", err)
 	}
-	s.logger.WithField("sample_job_id", id).Info("sample job started")
+	log.WithField("sample_job_id", id).Info("sample job started")
 	return job, nil
 }
 
@@ -455,27 +1318,32 @@ func (s *SampleJobService) Start(id string) (model.SampleJob, error) {
 // The executor owns the DB status update to stopped (via RequestStop), so the service
 // layer fetches the job for validation only and delegates the actual transition to
 // the executor. This eliminates the window where the DB and executor state diverge.
-func (s *SampleJobService) Stop(id string) (model.SampleJob, error) {
-	s.logger.WithField("sample_job_id", id).Trace("entering Stop")
-	defer s.logger.Trace("returning from Stop")
+func (s *SampleJobService) Stop(ctx context.Context, id string) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", id).Trace("entering Stop")
+	defer log.Trace("returning from Stop")
 
-	job, err := s.store.GetSampleJob(id)
+	job, err := s.store.GetSampleJob(ctx, id)
 	if err == sql.ErrNoRows {
-		s.logger.WithField("sample_job_id", id).Debug("sample job not found")
+		log.WithField("sample_job_id", id).Debug("sample job not found")
 		return model.SampleJob{}, fmt.Errorf("sample job %s not found", id)
 	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to fetch sample job")
 		return model.SampleJob{}, fmt.Errorf("fetching sample job: %w", err)
 	}
-	s.logger.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	log.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	if err := checkOwner(ctx, "sample job", id, job.Owner); err != nil {
+		log.WithError(err).Warn("sample job change denied")
+		return model.SampleJob{}, err
+	}
 
 	// Validate state transition
 	if job.Status != model.SampleJobStatusRunning {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id":  id,
 			"current_status": job.Status,
 		}).Warn("cannot stop job: job is not running")
@@ -489,12 +1357,10 @@ func (s *SampleJobService) Stop(id string) (model.SampleJob, error) {
 	// to a direct DB update so the user can always stop a running job.
 	if s.executor != nil {
 		if err := s.executor.RequestStop(id); err != nil {
-			s.logger.WithError(err).Warn("executor stop request failed, falling back to direct DB update")
+			log.WithError(err).Warn("executor stop request failed, falling back to direct DB update")
 			// Fall through to direct DB update below
-			job.Status = model.SampleJobStatusStopped
-			job.UpdatedAt = time.Now().UTC()
-			if err := s.store.UpdateSampleJob(job); err != nil {
-				s.logger.WithFields(logrus.Fields{
+			if _, err := s.states.TransitionWithRetry(ctx, job, model.SampleJobStatusStopped); err != nil {
+				log.WithFields(logrus.Fields{
 					"sample_job_id": id,
 					"error":         err.Error(),
 				}).Error("failed to update sample job status")
@@ -503,10 +1369,8 @@ func (s *SampleJobService) Stop(id string) (model.SampleJob, error) {
 		}
 	} else {
 		// No executor configured (e.g. tests without executor); update DB directly as fallback.
-		job.Status = model.SampleJobStatusStopped
-		job.UpdatedAt = time.Now().UTC()
-		if err := s.store.UpdateSampleJob(job); err != nil {
-			s.logger.WithFields(logrus.Fields{
+		if _, err := s.states.TransitionWithRetry(ctx, job, model.SampleJobStatusStopped); err != nil {
+			log.WithFields(logrus.Fields{
 				"sample_job_id": id,
 				"error":         err.Error(),
 			}).Error("failed to update sample job status")
@@ -515,65 +1379,70 @@ func (s *SampleJobService) Stop(id string) (model.SampleJob, error) {
 	}
 
 	// Re-fetch the job so the caller gets the post-stop state that the executor wrote.
-	updatedJob, err := s.store.GetSampleJob(id)
+	updatedJob, err := s.store.GetSampleJob(ctx, id)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Warn("failed to re-fetch job after stop, returning pre-stop snapshot")
 		// Return a best-effort snapshot with the expected stopped status.
 		job.Status = model.SampleJobStatusStopped
-		s.logger.WithField("sample_job_id", id).Info("sample job stopped")
+		log.WithField("sample_job_id", id).Info("sample job stopped")
 		return job, nil
 	}
 
-	s.logger.WithField("sample_job_id", id).Info("sample job stopped")
+	log.WithField("sample_job_id", id).Info("sample job stopped")
 	return updatedJob, nil
 }
 
 // RetryFailed re-queues all failed and skipped items in a completed_with_errors job,
 // resets the job status to running, and requests the executor to resume processing.
-func (s *SampleJobService) RetryFailed(id string) (model.SampleJob, error) {
-	s.logger.WithField("sample_job_id", id).Trace("entering RetryFailed")
-	defer s.logger.Trace("returning from RetryFailed")
+func (s *SampleJobService) RetryFailed(ctx context.Context, id string) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", id).Trace("entering RetryFailed")
+	defer log.Trace("returning from RetryFailed")
 
 	// Check if executor is available and connected
 	if s.executor == nil || !s.executor.IsConnected() {
-		s.logger.Warn("cannot retry job: ComfyUI not connected")
+		log.Warn("cannot retry job: ComfyUI not connected")
 		return model.SampleJob{}, fmt.Errorf("ComfyUI not connected")
 	}
 
 	// Guard: reject if another job is already running
-	hasRunning, err := s.store.HasRunningJob()
+	hasRunning, err := s.store.HasRunningJob(ctx)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to check for running jobs")
 		return model.SampleJob{}, fmt.Errorf("checking for running jobs: %w", err)
 	}
 	if hasRunning {
-		s.logger.WithField("sample_job_id", id).Warn("cannot retry job: another job is already running")
+		log.WithField("sample_job_id", id).Warn("cannot retry job: another job is already running")
 		return model.SampleJob{}, fmt.Errorf("another job is already running")
 	}
 
-	job, err := s.store.GetSampleJob(id)
+	job, err := s.store.GetSampleJob(ctx, id)
 	if err == sql.ErrNoRows {
-		s.logger.WithField("sample_job_id", id).Debug("sample job not found")
+		log.WithField("sample_job_id", id).Debug("sample job not found")
 		return model.SampleJob{}, fmt.Errorf("sample job %s not found", id)
 	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to fetch sample job")
 		return model.SampleJob{}, fmt.Errorf("fetching sample job: %w", err)
 	}
-	s.logger.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	log.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	if err := checkOwner(ctx, "sample job", id, job.Owner); err != nil {
+		log.WithError(err).Warn("sample job change denied")
+		return model.SampleJob{}, err
+	}
 
 	// Validate state transition: only completed_with_errors jobs can be retried
 	if job.Status != model.SampleJobStatusCompletedWithErrors {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id":  id,
 			"current_status": job.Status,
 		}).Warn("cannot retry job: job is not completed_with_errors")
@@ -581,9 +1450,9 @@ func (s *SampleJobService) RetryFailed(id string) (model.SampleJob, error) {
 	}
 
 	// Fetch all items and reset failed/skipped ones to pending
-	items, err := s.store.ListSampleJobItems(id)
+	items, err := s.store.ListSampleJobItems(ctx, id)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to list sample job items")
@@ -595,14 +1464,18 @@ func (s *SampleJobService) RetryFailed(id string) (model.SampleJob, error) {
 	for _, item := range items {
 		if item.Status == model.SampleJobItemStatusFailed || item.Status == model.SampleJobItemStatusSkipped {
 			item.Status = model.SampleJobItemStatusPending
+			item.SkipReason = ""
 			item.ErrorMessage = ""
 			item.ExceptionType = ""
 			item.NodeType = ""
 			item.Traceback = ""
 			item.ComfyUIPromptID = ""
+			item.StartedAt = nil
+			item.CompletedAt = nil
+			item.DurationMs = nil
 			item.UpdatedAt = now
-			if updateErr := s.store.UpdateSampleJobItem(item); updateErr != nil {
-				s.logger.WithFields(logrus.Fields{
+			if updateErr := s.store.UpdateSampleJobItem(ctx, &item); updateErr != nil {
+				log.WithFields(logrus.Fields{
 					"sample_job_id":      id,
 					"sample_job_item_id": item.ID,
 					"error":              updateErr.Error(),
@@ -613,17 +1486,15 @@ func (s *SampleJobService) RetryFailed(id string) (model.SampleJob, error) {
 		}
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"sample_job_id": id,
 		"retried_count": retriedCount,
 	}).Info("reset failed/skipped items to pending")
 
 	// Update job status to running
-	job.Status = model.SampleJobStatusRunning
-	job.UpdatedAt = now
-
-	if err := s.store.UpdateSampleJob(job); err != nil {
-		s.logger.WithFields(logrus.Fields{
+	job, err = s.states.Transition(ctx, job, model.SampleJobStatusRunning)
+	if err != nil {
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to update sample job status")
@@ -633,42 +1504,47 @@ func (s *SampleJobService) RetryFailed(id string) (model.SampleJob, error) {
 	// Request the executor to resume
 	if s.executor != nil {
 		if err := s.executor.RequestResume(id); err != nil {
-			s.logger.WithError(err).Warn("executor resume request failed")
+			log.WithError(err).Warn("executor resume request failed")
 		}
 	}
 
-	s.logger.WithField("sample_job_id", id).Info("sample job retry started")
+	log.WithField("sample_job_id", id).Info("sample job retry started")
 	return job, nil
 }
 
 // Resume transitions a stopped job back to running status.
-func (s *SampleJobService) Resume(id string) (model.SampleJob, error) {
-	s.logger.WithField("sample_job_id", id).Trace("entering Resume")
-	defer s.logger.Trace("returning from Resume")
+func (s *SampleJobService) Resume(ctx context.Context, id string) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", id).Trace("entering Resume")
+	defer log.Trace("returning from Resume")
 
 	// Check if executor is available and connected
 	if s.executor == nil || !s.executor.IsConnected() {
-		s.logger.Warn("cannot resume job: ComfyUI not connected")
+		log.Warn("cannot resume job: ComfyUI not connected")
 		return model.SampleJob{}, fmt.Errorf("ComfyUI not connected")
 	}
 
-	job, err := s.store.GetSampleJob(id)
+	job, err := s.store.GetSampleJob(ctx, id)
 	if err == sql.ErrNoRows {
-		s.logger.WithField("sample_job_id", id).Debug("sample job not found")
+		log.WithField("sample_job_id", id).Debug("sample job not found")
 		return model.SampleJob{}, fmt.Errorf("sample job %s not found", id)
 	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to fetch sample job")
 		return model.SampleJob{}, fmt.Errorf("fetching sample job: %w", err)
 	}
-	s.logger.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	log.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	if err := checkOwner(ctx, "sample job", id, job.Owner); err != nil {
+		log.WithError(err).Warn("sample job change denied")
+		return model.SampleJob{}, err
+	}
 
 	// Validate state transition
 	if job.Status != model.SampleJobStatusStopped {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id":  id,
 			"current_status": job.Status,
 		}).Warn("cannot resume job: job is not stopped")
@@ -676,11 +1552,9 @@ func (s *SampleJobService) Resume(id string) (model.SampleJob, error) {
 	}
 
 	// Update status to running
-	job.Status = model.SampleJobStatusRunning
-	job.UpdatedAt = time.Now().UTC()
-
-	if err := s.store.UpdateSampleJob(job); err != nil {
-		s.logger.WithFields(logrus.Fields{
+	job, err = s.states.Transition(ctx, job, model.SampleJobStatusRunning)
+	if err != nil {
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to update sample job status")
@@ -690,46 +1564,272 @@ func (s *SampleJobService) Resume(id string) (model.SampleJob, error) {
 	// Request the executor to resume
 	if s.executor != nil {
 		if err := s.executor.RequestResume(id); err != nil {
-			s.logger.WithError(err).Warn("executor resume request failed")
+			log.WithError(err).Warn("executor resume request failed")
 		}
 	}
 
-	s.logger.WithField("sample_job_id", id).Info("sample job resumed")
+	log.WithField("sample_job_id", id).Info("sample job resumed")
 	return job, nil
 }
 
-// Delete removes a sample job and all its items. When deleteData is true, also
-// removes the generated sample files for each checkpoint covered by the job
-// (if a JobSampleDataRemover has been configured).
-func (s *SampleJobService) Delete(id string, deleteData bool) error {
-	s.logger.WithFields(logrus.Fields{
+// Delete moves a sample job to the trash, stopping it first if it is running.
+// When deleteData is true, purging the job later also removes the generated
+// sample files for each checkpoint covered by the job (if a
+// JobSampleDataRemover has been configured).
+func (s *SampleJobService) Delete(ctx context.Context, id string, deleteData bool) error {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
 		"sample_job_id": id,
 		"delete_data":   deleteData,
 	}).Trace("entering Delete")
-	defer s.logger.Trace("returning from Delete")
+	defer log.Trace("returning from Delete")
 
-	// Fetch the job first so we know its study name and can list its items for
-	// filesystem cleanup before removing the database record.
-	job, err := s.store.GetSampleJob(id)
+	job, err := s.store.GetSampleJob(ctx, id)
 	if err == sql.ErrNoRows {
-		s.logger.WithField("sample_job_id", id).Debug("sample job not found for deletion")
+		log.WithField("sample_job_id", id).Debug("sample job not found for deletion")
 		return fmt.Errorf("sample job %s not found", id)
 	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to fetch sample job for deletion")
 		return fmt.Errorf("fetching sample job: %w", err)
 	}
+	if err := checkOwner(ctx, "sample job", id, job.Owner); err != nil {
+		log.WithError(err).Warn("sample job deletion denied")
+		return err
+	}
+
+	// The executor stops tracking a job once it is in the trash, so stop a
+	// running job first; otherwise it would be restored as running with no
+	// executor working on it.
+	if job.Status == model.SampleJobStatusRunning {
+		if _, err := s.Stop(ctx, id); err != nil {
+			log.WithFields(logrus.Fields{
+				"sample_job_id": id,
+				"error":         err.Error(),
+			}).Error("failed to stop sample job before deletion")
+			return fmt.Errorf("stopping sample job: %w", err)
+		}
+	}
+
+	if err := s.store.TrashSampleJob(ctx, id, time.Now().UTC(), deleteData); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("sample job %s not found", id)
+		}
+		log.WithFields(logrus.Fields{
+			"sample_job_id": id,
+			"error":         err.Error(),
+		}).Error("failed to move sample job to the trash")
+		return fmt.Errorf("deleting sample job: %w", err)
+	}
+	log.WithField("sample_job_id", id).Info("sample job moved to the trash")
+	return nil
+}
+
+// ListTrash returns the requested owner's sample jobs in the trash, most
+// recently deleted first. owner is resolved like in List.
+func (s *SampleJobService) ListTrash(ctx context.Context, owner string) ([]model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("owner", owner).Trace("entering ListTrash")
+	defer log.Trace("returning from ListTrash")
+
+	scope, err := ownerScope(ctx, owner)
+	if err != nil {
+		log.WithError(err).Warn("sample job trash listing denied")
+		return nil, err
+	}
+	jobs, err := s.store.ListTrashedSampleJobs(ctx, scope)
+	if err != nil {
+		log.WithError(err).Error("failed to list trashed sample jobs")
+		return nil, fmt.Errorf("listing trashed sample jobs: %w", err)
+	}
+	if jobs == nil {
+		jobs = []model.SampleJob{}
+	}
+	log.WithField("job_count", len(jobs)).Debug("listed trashed sample jobs")
+	return jobs, nil
+}
+
+// Restore moves a sample job out of the trash and returns it.
+func (s *SampleJobService) Restore(ctx context.Context, id string) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", id).Trace("entering Restore")
+	defer log.Trace("returning from Restore")
+
+	job, err := s.getTrashed(ctx, id)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+	if err := s.store.RestoreSampleJob(ctx, id); err != nil {
+		if err == sql.ErrNoRows {
+			return model.SampleJob{}, fmt.Errorf("sample job %s not found in the trash", id)
+		}
+		log.WithFields(logrus.Fields{
+			"sample_job_id": id,
+			"error":         err.Error(),
+		}).Error("failed to restore sample job")
+		return model.SampleJob{}, fmt.Errorf("restoring sample job: %w", err)
+	}
+	job, err = s.store.GetSampleJob(ctx, job.ID)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"sample_job_id": id,
+			"error":         err.Error(),
+		}).Error("failed to fetch restored sample job")
+		return model.SampleJob{}, fmt.Errorf("fetching sample job: %w", err)
+	}
+	log.WithField("sample_job_id", id).Info("sample job restored from the trash")
+	return job, nil
+}
+
+// Purge permanently removes a sample job in the trash and all its items.
+// When the job was deleted with deleteData, also removes its generated sample
+// files.
+func (s *SampleJobService) Purge(ctx context.Context, id string) error {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", id).Trace("entering Purge")
+	defer log.Trace("returning from Purge")
+
+	job, err := s.getTrashed(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.purge(ctx, job)
+}
+
+// EmptyTrash purges the sample jobs in the trash that List would list by
+// default, and returns how many were purged. It stops at the first job that
+// fails to purge.
+func (s *SampleJobService) EmptyTrash(ctx context.Context) (int, error) {
+	log := requestLogger(ctx, s.logger)
+	log.Trace("entering EmptyTrash")
+	defer log.Trace("returning from EmptyTrash")
+
+	scope, err := ownerScope(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	jobs, err := s.store.ListTrashedSampleJobs(ctx, scope)
+	if err != nil {
+		log.WithError(err).Error("failed to list trashed sample jobs")
+		return 0, fmt.Errorf("listing trashed sample jobs: %w", err)
+	}
+	purged := 0
+	for _, job := range jobs {
+		if err := s.purge(ctx, job); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	log.WithField("purged", purged).Info("trash emptied")
+	return purged, nil
+}
+
+// SetTrashRetention sets how long deleted jobs stay in the trash before
+// RunTrashPurge purges them. This is optional; if not set or 0, trashed jobs
+// are kept until they are purged by hand.
+func (s *SampleJobService) SetTrashRetention(retention time.Duration) {
+	s.trashRetention = retention
+}
+
+// trashPurgeInterval is how often RunTrashPurge looks for expired jobs.
+const trashPurgeInterval = time.Hour
+
+// PurgeExpired purges the sample jobs that have been in the trash for longer
+// than the trash retention, and returns how many were purged. Jobs that fail
+// to purge are logged and left for the next run.
+func (s *SampleJobService) PurgeExpired(ctx context.Context) (int, error) {
+	s.logger.Trace("entering PurgeExpired")
+	defer s.logger.Trace("returning from PurgeExpired")
+
+	if s.trashRetention <= 0 {
+		return 0, nil
+	}
+	jobs, err := s.store.ListTrashedSampleJobs(ctx, model.OwnerScope{})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list trashed sample jobs")
+		return 0, fmt.Errorf("listing trashed sample jobs: %w", err)
+	}
+	cutoff := time.Now().Add(-s.trashRetention)
+	purged := 0
+	for _, job := range jobs {
+		if job.DeletedAt == nil || job.DeletedAt.After(cutoff) {
+			continue
+		}
+		if err := s.purge(ctx, job); err != nil {
+			continue // purge logs the failure
+		}
+		purged++
+	}
+	if purged > 0 {
+		s.logger.WithField("purged", purged).Info("purged expired sample jobs from the trash")
+	}
+	return purged, nil
+}
+
+// RunTrashPurge purges expired jobs from the trash immediately and then every
+// trashPurgeInterval until stop is closed. It returns at once if no trash
+// retention is set.
+func (s *SampleJobService) RunTrashPurge(stop <-chan struct{}) {
+	s.logger.Trace("entering RunTrashPurge")
+	defer s.logger.Trace("returning from RunTrashPurge")
+
+	if s.trashRetention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeExpired(context.Background()); err != nil {
+			s.logger.WithError(err).Error("failed to purge expired sample jobs")
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// getTrashed fetches a sample job in the trash that the request of ctx may
+// change.
+func (s *SampleJobService) getTrashed(ctx context.Context, id string) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	job, err := s.store.GetTrashedSampleJob(ctx, id)
+	if err == sql.ErrNoRows {
+		log.WithField("sample_job_id", id).Debug("sample job not found in the trash")
+		return model.SampleJob{}, fmt.Errorf("sample job %s not found in the trash", id)
+	}
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"sample_job_id": id,
+			"error":         err.Error(),
+		}).Error("failed to fetch trashed sample job")
+		return model.SampleJob{}, fmt.Errorf("fetching sample job: %w", err)
+	}
+	if err := checkOwner(ctx, "sample job", id, job.Owner); err != nil {
+		log.WithError(err).Warn("sample job change denied")
+		return model.SampleJob{}, err
+	}
+	return job, nil
+}
+
+// purge removes the generated sample files of a trashed job deleted with
+// deleteData, then the job and its items.
+func (s *SampleJobService) purge(ctx context.Context, job model.SampleJob) error {
+	log := requestLogger(ctx, s.logger)
+	id := job.ID
 
 	// Remove generated sample files before deleting the database record so
 	// a filesystem error does not leave a dangling database entry.
-	if deleteData && s.jobDataRemover != nil {
+	if job.DeleteData && s.jobDataRemover != nil {
 		// Collect unique checkpoint filenames from job items
-		items, err := s.store.ListSampleJobItems(id)
+		items, err := s.store.ListSampleJobItems(ctx, id)
 		if err != nil {
-			s.logger.WithFields(logrus.Fields{
+			log.WithFields(logrus.Fields{
 				"sample_job_id": id,
 				"error":         err.Error(),
 			}).Error("failed to list job items for data deletion")
@@ -743,8 +1843,17 @@ func (s *SampleJobService) Delete(id string, deleteData bool) error {
 			}
 			seen[item.CheckpointFilename] = struct{}{}
 
+			if s.pinGuard != nil && s.pinGuard.IsProtected(ctx, job.StudyName+"/"+item.CheckpointFilename) {
+				log.WithFields(logrus.Fields{
+					"sample_job_id":       id,
+					"study_name":          job.StudyName,
+					"checkpoint_filename": item.CheckpointFilename,
+				}).Info("job sample directory contains pinned content, not removing")
+				continue
+			}
+
 			if removeErr := s.jobDataRemover.RemoveJobSampleDir(job.StudyName, item.CheckpointFilename); removeErr != nil {
-				s.logger.WithFields(logrus.Fields{
+				log.WithFields(logrus.Fields{
 					"sample_job_id":       id,
 					"study_name":          job.StudyName,
 					"checkpoint_filename": item.CheckpointFilename,
@@ -752,7 +1861,7 @@ func (s *SampleJobService) Delete(id string, deleteData bool) error {
 				}).Error("failed to remove job sample directory")
 				return fmt.Errorf("removing job sample directory: %w", removeErr)
 			}
-			s.logger.WithFields(logrus.Fields{
+			log.WithFields(logrus.Fields{
 				"sample_job_id":       id,
 				"study_name":          job.StudyName,
 				"checkpoint_filename": item.CheckpointFilename,
@@ -760,25 +1869,26 @@ func (s *SampleJobService) Delete(id string, deleteData bool) error {
 		}
 	}
 
-	if err := s.store.DeleteSampleJob(id); err != nil {
-		s.logger.WithFields(logrus.Fields{
+	if err := s.store.DeleteSampleJob(ctx, id); err != nil {
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to delete sample job")
 		return fmt.Errorf("deleting sample job: %w", err)
 	}
-	s.logger.WithField("sample_job_id", id).Info("sample job deleted")
+	log.WithField("sample_job_id", id).Info("sample job purged")
 	return nil
 }
 
 // GetItemCounts computes item status counts for a job on-the-fly.
-func (s *SampleJobService) GetItemCounts(id string) (model.ItemStatusCounts, error) {
-	s.logger.WithField("sample_job_id", id).Trace("entering GetItemCounts")
-	defer s.logger.Trace("returning from GetItemCounts")
+func (s *SampleJobService) GetItemCounts(ctx context.Context, id string) (model.ItemStatusCounts, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", id).Trace("entering GetItemCounts")
+	defer log.Trace("returning from GetItemCounts")
 
-	items, err := s.store.ListSampleJobItems(id)
+	items, err := s.store.ListSampleJobItems(ctx, id)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to list sample job items")
@@ -792,12 +1902,15 @@ func (s *SampleJobService) GetItemCounts(id string) (model.ItemStatusCounts, err
 			counts.Completed++
 		case model.SampleJobItemStatusFailed, model.SampleJobItemStatusSkipped:
 			counts.Failed++
+			if item.Status == model.SampleJobItemStatusSkipped {
+				counts.AddSkipped(item.SkipReason, 1)
+			}
 		case model.SampleJobItemStatusPending:
 			counts.Pending++
 		}
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"sample_job_id": id,
 		"completed":     counts.Completed,
 		"failed":        counts.Failed,
@@ -808,33 +1921,34 @@ func (s *SampleJobService) GetItemCounts(id string) (model.ItemStatusCounts, err
 }
 
 // GetProgress computes the current progress metrics for a job.
-func (s *SampleJobService) GetProgress(id string) (model.JobProgress, error) {
-	s.logger.WithField("sample_job_id", id).Trace("entering GetProgress")
-	defer s.logger.Trace("returning from GetProgress")
+func (s *SampleJobService) GetProgress(ctx context.Context, id string) (model.JobProgress, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("sample_job_id", id).Trace("entering GetProgress")
+	defer log.Trace("returning from GetProgress")
 
-	_, err := s.store.GetSampleJob(id)
+	_, err := s.store.GetSampleJob(ctx, id)
 	if err == sql.ErrNoRows {
-		s.logger.WithField("sample_job_id", id).Debug("sample job not found")
+		log.WithField("sample_job_id", id).Debug("sample job not found")
 		return model.JobProgress{}, fmt.Errorf("sample job %s not found", id)
 	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to fetch sample job")
 		return model.JobProgress{}, fmt.Errorf("fetching sample job: %w", err)
 	}
-	s.logger.WithField("sample_job_id", id).Debug("fetched sample job from store")
+	log.WithField("sample_job_id", id).Debug("fetched sample job from store")
 
-	items, err := s.store.ListSampleJobItems(id)
+	items, err := s.store.ListSampleJobItems(ctx, id)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		log.WithFields(logrus.Fields{
 			"sample_job_id": id,
 			"error":         err.Error(),
 		}).Error("failed to list sample job items")
 		return model.JobProgress{}, fmt.Errorf("listing sample job items: %w", err)
 	}
-	s.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"sample_job_id": id,
 		"item_count":    len(items),
 	}).Debug("fetched sample job items from store")
@@ -844,6 +1958,7 @@ func (s *SampleJobService) GetProgress(id string) (model.JobProgress, error) {
 		exceptionType string
 		nodeType      string
 		traceback     string
+		comfyUILog    string
 	}
 	type checkpointStats struct {
 		total     int
@@ -871,11 +1986,15 @@ func (s *SampleJobService) GetProgress(id string) (model.JobProgress, error) {
 		case model.SampleJobItemStatusFailed, model.SampleJobItemStatusSkipped:
 			stats.failed++
 			itemCounts.Failed++
+			if item.Status == model.SampleJobItemStatusSkipped {
+				itemCounts.AddSkipped(item.SkipReason, 1)
+			}
 			if item.ErrorMessage != "" {
 				stats.errors[item.ErrorMessage] = errorDetail{
 					exceptionType: item.ExceptionType,
 					nodeType:      item.NodeType,
 					traceback:     item.Traceback,
+					comfyUILog:    item.ComfyUILog,
 				}
 			}
 		case model.SampleJobItemStatusPending:
@@ -921,6 +2040,7 @@ func (s *SampleJobService) GetProgress(id string) (model.JobProgress, error) {
 					ExceptionType:      detail.exceptionType,
 					NodeType:           detail.nodeType,
 					Traceback:          detail.traceback,
+					ComfyUILog:         detail.comfyUILog,
 				})
 			}
 			// If there are failed items but no error messages recorded, still include the checkpoint
@@ -952,7 +2072,7 @@ func (s *SampleJobService) GetProgress(id string) (model.JobProgress, error) {
 		FailedItemDetails:         failedItemDetails,
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"sample_job_id":         id,
 		"checkpoints_completed": checkpointsCompleted,
 		"total_checkpoints":     totalCheckpoints,
@@ -964,16 +2084,47 @@ func (s *SampleJobService) GetProgress(id string) (model.JobProgress, error) {
 	return progress, nil
 }
 
-// GenerateOutputFilename generates the query-encoded output filename for a sample job item.
+// GenerateOutputFilename generates the query-encoded output filename for a sample job item,
+// with the extension of the job's output format.
 // This is the canonical filename format used both during job execution and for
 // missing-sample detection. The format matches what the job executor writes to disk.
-func GenerateOutputFilename(item model.SampleJobItem) string {
+func GenerateOutputFilename(item model.SampleJobItem, format model.OutputFormat) string {
 	params := url.Values{}
-	params.Set("prompt", item.PromptName)
-	params.Set("steps", fmt.Sprintf("%d", item.Steps))
-	params.Set("cfg", fmt.Sprintf("%.1f", item.CFG))
-	params.Set("sampler", item.SamplerName)
-	params.Set("scheduler", item.Scheduler)
-	params.Set("seed", fmt.Sprintf("%d", item.Seed))
-	return fmt.Sprintf("%s.png", params.Encode())
+	for name, value := range outputDimensions(item) {
+		params.Set(name, value)
+	}
+	return params.Encode() + format.Extension()
+}
+
+// OutputRelPath returns the slash-separated path of the image of item inside
+// its checkpoint's sample directory: its path under layout, or its
+// query-encoded filename if layout is the zero layout.
+func OutputRelPath(layout model.OutputLayout, item model.SampleJobItem, format model.OutputFormat) string {
+	if layout.IsZero() {
+		return GenerateOutputFilename(item, format)
+	}
+	return layout.Path(outputDimensions(item)) + format.Extension()
+}
+
+// outputDimensions returns the dimensions the image of item is told apart
+// by, keyed as in its query-encoded filename.
+func outputDimensions(item model.SampleJobItem) map[string]string {
+	dims := map[string]string{
+		"prompt":    item.PromptName,
+		"steps":     fmt.Sprintf("%d", item.Steps),
+		"cfg":       fmt.Sprintf("%.1f", item.CFG),
+		"sampler":   item.SamplerName,
+		"scheduler": item.Scheduler,
+		"seed":      fmt.Sprintf("%d", item.Seed),
+	}
+	if item.Denoise != nil {
+		dims["denoise"] = fmt.Sprintf("%.2f", *item.Denoise)
+	}
+	if item.Resolution != "" {
+		dims["resolution"] = item.Resolution
+	}
+	for name, value := range item.Wildcards {
+		dims[name] = value
+	}
+	return dims
 }
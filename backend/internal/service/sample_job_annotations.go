@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// Bounds of the notes and labels of a sample job.
+const (
+	maxJobNotesLength      = 10000
+	maxJobLabels           = 32
+	maxJobLabelKeyLength   = 64
+	maxJobLabelValueLength = 256
+)
+
+// Annotate replaces the notes and labels of a sample job and returns the
+// updated job. Annotations may be changed in any job status, including while
+// the job runs. Returns a not-found error if the job does not exist.
+func (s *SampleJobService) Annotate(ctx context.Context, id string, annotations model.SampleJobAnnotations) (model.SampleJob, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"sample_job_id": id,
+		"label_count":   len(annotations.Labels),
+	}).Trace("entering Annotate")
+	defer log.Trace("returning from Annotate")
+
+	annotations, err := normalizeJobAnnotations(annotations)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+
+	// The executor updates the job's progress concurrently; re-read the job
+	// and apply the annotations again when it wins the race.
+	var job model.SampleJob
+	err = retryOnConflict(func() error {
+		var err error
+		job, err = s.store.GetSampleJob(ctx, id)
+		if err == sql.ErrNoRows {
+			log.WithField("sample_job_id", id).Debug("sample job not found")
+			return fmt.Errorf("sample job %s not found", id)
+		}
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"sample_job_id": id,
+				"error":         err.Error(),
+			}).Error("failed to fetch sample job")
+			return fmt.Errorf("fetching sample job: %w", err)
+		}
+		if err := checkOwner(ctx, "sample job", id, job.Owner); err != nil {
+			log.WithError(err).Warn("sample job change denied")
+			return err
+		}
+		job.Notes = annotations.Notes
+		job.Labels = annotations.Labels
+		job.UpdatedAt = time.Now().UTC()
+		if err := s.store.UpdateSampleJob(ctx, &job); err != nil {
+			log.WithFields(logrus.Fields{
+				"sample_job_id": id,
+				"error":         err.Error(),
+			}).Warn("failed to save sample job annotations")
+			return fmt.Errorf("updating sample job: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+	log.WithFields(logrus.Fields{
+		"sample_job_id": id,
+		"label_count":   len(job.Labels),
+	}).Info("sample job annotated")
+	return job, nil
+}
+
+// ParseLabelSelector parses a job list label filter: "key" selects the jobs
+// carrying label key with any value, "key=value" those with label key set to
+// value.
+func ParseLabelSelector(s string) (model.LabelSelector, error) {
+	key, value, hasValue := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return model.LabelSelector{}, fmt.Errorf("invalid label filter %q: key must not be empty", s)
+	}
+	selector := model.LabelSelector{Key: key}
+	if hasValue {
+		value = strings.TrimSpace(value)
+		selector.Value = &value
+	}
+	return selector, nil
+}
+
+// normalizeJobAnnotations trims the notes and the label keys and values, and
+// rejects empty or overlong keys, keys containing '=' (which separates keys
+// from values in label filters), overlong values and notes, and too many
+// labels. An empty label set becomes nil.
+func normalizeJobAnnotations(a model.SampleJobAnnotations) (model.SampleJobAnnotations, error) {
+	notes := strings.TrimSpace(a.Notes)
+	if len(notes) > maxJobNotesLength {
+		return model.SampleJobAnnotations{}, fmt.Errorf("invalid notes: must be at most %d characters", maxJobNotesLength)
+	}
+	if len(a.Labels) > maxJobLabels {
+		return model.SampleJobAnnotations{}, fmt.Errorf("invalid labels: at most %d labels are allowed, got %d", maxJobLabels, len(a.Labels))
+	}
+	var labels map[string]string
+	for k, v := range a.Labels {
+		key := strings.TrimSpace(k)
+		switch {
+		case key == "":
+			return model.SampleJobAnnotations{}, fmt.Errorf("invalid label: key must not be empty")
+		case len(key) > maxJobLabelKeyLength:
+			return model.SampleJobAnnotations{}, fmt.Errorf("invalid label %q: key must be at most %d characters", key, maxJobLabelKeyLength)
+		case strings.Contains(key, "="):
+			return model.SampleJobAnnotations{}, fmt.Errorf("invalid label %q: key must not contain '='", key)
+		}
+		value := strings.TrimSpace(v)
+		if len(value) > maxJobLabelValueLength {
+			return model.SampleJobAnnotations{}, fmt.Errorf("invalid label %q: value must be at most %d characters", key, maxJobLabelValueLength)
+		}
+		if _, dup := labels[key]; dup {
+			return model.SampleJobAnnotations{}, fmt.Errorf("invalid label %q: key is given more than once", key)
+		}
+		if labels == nil {
+			labels = make(map[string]string, len(a.Labels))
+		}
+		labels[key] = value
+	}
+	return model.SampleJobAnnotations{Notes: notes, Labels: labels}, nil
+}
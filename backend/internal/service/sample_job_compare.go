@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// comparisonKey holds the parameters two items must share to be compared.
+type comparisonKey struct {
+	promptText  string
+	seed        int64
+	cfg         float64
+	steps       int
+	samplerName string
+	scheduler   string
+}
+
+func comparisonKeyOf(item model.SampleJobItem) comparisonKey {
+	return comparisonKey{
+		promptText:  item.PromptText,
+		seed:        item.Seed,
+		cfg:         item.CFG,
+		steps:       item.Steps,
+		samplerName: item.SamplerName,
+		scheduler:   item.Scheduler,
+	}
+}
+
+// Compare pairs up the items of sample jobs a and b that share their prompt
+// text, seed, CFG, steps, sampler, and scheduler. A job has one item per
+// checkpoint for each combination, so items sharing parameters are paired in
+// item order: the first of a with the first of b, and so on. Items left over
+// on either side are returned unmatched. Returns a not-found error if either
+// job does not exist.
+func (s *SampleJobService) Compare(ctx context.Context, a string, b string) (model.SampleJobComparison, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"sample_job_a": a,
+		"sample_job_b": b,
+	}).Trace("entering Compare")
+	defer log.Trace("returning from Compare")
+
+	jobA, err := s.Get(ctx, a)
+	if err != nil {
+		return model.SampleJobComparison{}, err
+	}
+	jobB, err := s.Get(ctx, b)
+	if err != nil {
+		return model.SampleJobComparison{}, err
+	}
+	itemsA, err := s.store.ListSampleJobItems(ctx, a)
+	if err != nil {
+		return model.SampleJobComparison{}, fmt.Errorf("listing items of sample job %s: %w", a, err)
+	}
+	itemsB, err := s.store.ListSampleJobItems(ctx, b)
+	if err != nil {
+		return model.SampleJobComparison{}, fmt.Errorf("listing items of sample job %s: %w", b, err)
+	}
+
+	pending := make(map[comparisonKey][]int)
+	for i, item := range itemsB {
+		key := comparisonKeyOf(item)
+		pending[key] = append(pending[key], i)
+	}
+	result := model.SampleJobComparison{
+		A:          jobA,
+		B:          jobB,
+		Pairs:      []model.SampleJobItemPair{},
+		UnmatchedA: []model.SampleJobItem{},
+		UnmatchedB: []model.SampleJobItem{},
+	}
+	matchedB := make([]bool, len(itemsB))
+	for _, item := range itemsA {
+		key := comparisonKeyOf(item)
+		candidates := pending[key]
+		if len(candidates) == 0 {
+			result.UnmatchedA = append(result.UnmatchedA, item)
+			continue
+		}
+		pending[key] = candidates[1:]
+		matchedB[candidates[0]] = true
+		result.Pairs = append(result.Pairs, model.SampleJobItemPair{A: item, B: itemsB[candidates[0]]})
+	}
+	for i, item := range itemsB {
+		if !matchedB[i] {
+			result.UnmatchedB = append(result.UnmatchedB, item)
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"sample_job_a": a,
+		"sample_job_b": b,
+		"pairs":        len(result.Pairs),
+		"unmatched_a":  len(result.UnmatchedA),
+		"unmatched_b":  len(result.UnmatchedB),
+	}).Debug("compared sample jobs")
+	return result, nil
+}
+
+// ImagePath returns the path of an item's image relative to the sample
+// directory, with forward slashes, or "" if the item has no image there.
+func (s *SampleJobService) ImagePath(item model.SampleJobItem) string {
+	if item.OutputPath == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(s.sampleDir, item.OutputPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
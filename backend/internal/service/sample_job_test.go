@@ -1,10 +1,14 @@
 package service_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -12,34 +16,44 @@ import (
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
 )
 
 // fakeSampleJobStore is an in-memory test double for service.SampleJobStore.
 type fakeSampleJobStore struct {
 	jobs             map[string]model.SampleJob
+	trash             map[string]model.SampleJob // jobs moved to the trash
 	items            map[string][]model.SampleJobItem
 	studies          map[string]model.Study
+	versions         map[string][]model.StudyVersion
+	params           map[string]model.SampleJobParameters
 	listJobsErr      error
 	getJobErr        error
 	hasRunningJobErr error
 	createJobErr     error
+	maxJobs          int // when > 0, CreateSampleJobWithItems fails once this many jobs exist
 	updateJobErr     error
+	updateJobConflicts int // UpdateSampleJob fails with store.ErrVersionConflict this many times
+	appendItemsErr   error
 	deleteJobErr     error
 	listItemsErr     error
-	createItemErr    error
 	updateItemErr    error
 	getStudyErr      error
+	lastItemQuery    model.SampleJobItemQuery
+	lastCountFilter  model.SampleJobItemFilter
 }
 
 func newFakeSampleJobStore() *fakeSampleJobStore {
 	return &fakeSampleJobStore{
 		jobs:    make(map[string]model.SampleJob),
+		trash:   make(map[string]model.SampleJob),
 		items:   make(map[string][]model.SampleJobItem),
 		studies: make(map[string]model.Study),
+		params:  make(map[string]model.SampleJobParameters),
 	}
 }
 
-func (f *fakeSampleJobStore) ListSampleJobs() ([]model.SampleJob, error) {
+func (f *fakeSampleJobStore) ListSampleJobs(ctx context.Context) ([]model.SampleJob, error) {
 	if f.listJobsErr != nil {
 		return nil, f.listJobsErr
 	}
@@ -50,18 +64,34 @@ func (f *fakeSampleJobStore) ListSampleJobs() ([]model.SampleJob, error) {
 	return result, nil
 }
 
-func (f *fakeSampleJobStore) ListSampleJobsDesc() ([]model.SampleJob, error) {
+func (f *fakeSampleJobStore) ListSampleJobsDesc(ctx context.Context, filter model.SampleJobFilter, page model.Page) ([]model.SampleJob, error) {
 	if f.listJobsErr != nil {
 		return nil, f.listJobsErr
 	}
 	var result []model.SampleJob
 	for _, j := range f.jobs {
-		result = append(result, j)
+		if filter.Includes(j.Owner, j.Labels) {
+			result = append(result, j)
+		}
 	}
-	return result, nil
+	sort.Slice(result, func(a, b int) bool { return result[a].ID > result[b].ID })
+	return pageOf(result, page), nil
+}
+
+func (f *fakeSampleJobStore) CountSampleJobs(ctx context.Context, filter model.SampleJobFilter) (int, error) {
+	if f.listJobsErr != nil {
+		return 0, f.listJobsErr
+	}
+	count := 0
+	for _, j := range f.jobs {
+		if filter.Includes(j.Owner, j.Labels) {
+			count++
+		}
+	}
+	return count, nil
 }
 
-func (f *fakeSampleJobStore) GetSampleJob(id string) (model.SampleJob, error) {
+func (f *fakeSampleJobStore) GetSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
 	if f.getJobErr != nil {
 		return model.SampleJob{}, f.getJobErr
 	}
@@ -72,7 +102,7 @@ func (f *fakeSampleJobStore) GetSampleJob(id string) (model.SampleJob, error) {
 	return j, nil
 }
 
-func (f *fakeSampleJobStore) HasRunningJob() (bool, error) {
+func (f *fakeSampleJobStore) HasRunningJob(ctx context.Context) (bool, error) {
 	if f.hasRunningJobErr != nil {
 		return false, f.hasRunningJobErr
 	}
@@ -84,60 +114,181 @@ func (f *fakeSampleJobStore) HasRunningJob() (bool, error) {
 	return false, nil
 }
 
-func (f *fakeSampleJobStore) CreateSampleJob(j model.SampleJob) error {
+func (f *fakeSampleJobStore) CreateSampleJobWithItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error {
+	if f.createJobErr != nil {
+		return f.createJobErr
+	}
+	if f.maxJobs > 0 && len(f.jobs) >= f.maxJobs {
+		return errors.New("database is full")
+	}
+	f.jobs[j.ID] = j
+	if len(items) > 0 {
+		f.items[j.ID] = append([]model.SampleJobItem(nil), items...)
+	}
+	return nil
+}
+
+func (f *fakeSampleJobStore) CreateStudyWithSampleJob(ctx context.Context, st model.Study, j model.SampleJob, items []model.SampleJobItem) error {
 	if f.createJobErr != nil {
 		return f.createJobErr
 	}
+	f.studies[st.ID] = st
 	f.jobs[j.ID] = j
+	if len(items) > 0 {
+		f.items[j.ID] = append([]model.SampleJobItem(nil), items...)
+	}
 	return nil
 }
 
-func (f *fakeSampleJobStore) UpdateSampleJob(j model.SampleJob) error {
+func (f *fakeSampleJobStore) UpdateSampleJob(ctx context.Context, j *model.SampleJob) error {
 	if f.updateJobErr != nil {
 		return f.updateJobErr
 	}
+	if f.updateJobConflicts > 0 {
+		f.updateJobConflicts--
+		return store.ErrVersionConflict
+	}
+	if _, ok := f.jobs[j.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	j.Version++
+	f.jobs[j.ID] = *j
+	return nil
+}
+
+func (f *fakeSampleJobStore) TransitionSampleJob(ctx context.Context, j *model.SampleJob, from model.SampleJobStatus) error {
+	if current, ok := f.jobs[j.ID]; ok && current.Status != from {
+		return fmt.Errorf("%w: job %s is %s", store.ErrSampleJobStatusChanged, j.ID, current.Status)
+	}
+	return f.UpdateSampleJob(ctx, j)
+}
+
+func (f *fakeSampleJobStore) AppendSampleJobItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error {
+	if f.appendItemsErr != nil {
+		return f.appendItemsErr
+	}
 	if _, ok := f.jobs[j.ID]; !ok {
 		return sql.ErrNoRows
 	}
 	f.jobs[j.ID] = j
+	f.items[j.ID] = append(f.items[j.ID], items...)
 	return nil
 }
 
-func (f *fakeSampleJobStore) DeleteSampleJob(id string) error {
+func (f *fakeSampleJobStore) DeleteSampleJob(ctx context.Context, id string) error {
 	if f.deleteJobErr != nil {
 		return f.deleteJobErr
 	}
-	if _, ok := f.jobs[id]; !ok {
+	_, inJobs := f.jobs[id]
+	_, inTrash := f.trash[id]
+	if !inJobs && !inTrash {
 		return sql.ErrNoRows
 	}
 	delete(f.jobs, id)
+	delete(f.trash, id)
 	delete(f.items, id) // Cascade delete items
 	return nil
 }
 
-func (f *fakeSampleJobStore) ListSampleJobItems(jobID string) ([]model.SampleJobItem, error) {
+func (f *fakeSampleJobStore) ListTrashedSampleJobs(ctx context.Context, scope model.OwnerScope) ([]model.SampleJob, error) {
+	var result []model.SampleJob
+	for _, j := range f.trash {
+		if scope.Includes(j.Owner) {
+			result = append(result, j)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].ID < result[b].ID })
+	return result, nil
+}
+
+func (f *fakeSampleJobStore) GetTrashedSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
+	j, ok := f.trash[id]
+	if !ok {
+		return model.SampleJob{}, sql.ErrNoRows
+	}
+	return j, nil
+}
+
+func (f *fakeSampleJobStore) TrashSampleJob(ctx context.Context, id string, deletedAt time.Time, deleteData bool) error {
+	j, ok := f.jobs[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	j.DeletedAt = &deletedAt
+	j.DeleteData = deleteData
+	f.trash[id] = j
+	delete(f.jobs, id)
+	return nil
+}
+
+func (f *fakeSampleJobStore) RestoreSampleJob(ctx context.Context, id string) error {
+	j, ok := f.trash[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	j.DeletedAt = nil
+	j.DeleteData = false
+	f.jobs[id] = j
+	delete(f.trash, id)
+	return nil
+}
+
+func (f *fakeSampleJobStore) ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error) {
 	if f.listItemsErr != nil {
 		return nil, f.listItemsErr
 	}
 	return f.items[jobID], nil
 }
 
-func (f *fakeSampleJobStore) CreateSampleJobItem(i model.SampleJobItem) error {
-	if f.createItemErr != nil {
-		return f.createItemErr
+// ListSampleJobItemsPage applies only the status filter; the SQL filtering
+// and sorting are covered by the store tests.
+func (f *fakeSampleJobStore) ListSampleJobItemsPage(ctx context.Context, jobID string, query model.SampleJobItemQuery, page model.Page) ([]model.SampleJobItem, error) {
+	f.lastItemQuery = query
+	if f.listItemsErr != nil {
+		return nil, f.listItemsErr
 	}
-	f.items[i.JobID] = append(f.items[i.JobID], i)
-	return nil
+	return pageOf(f.itemsWithStatus(jobID, query.Filter.Status), page), nil
+}
+
+func (f *fakeSampleJobStore) CountSampleJobItems(ctx context.Context, jobID string, filter model.SampleJobItemFilter) (int, error) {
+	f.lastCountFilter = filter
+	return len(f.itemsWithStatus(jobID, filter.Status)), nil
+}
+
+func (f *fakeSampleJobStore) itemsWithStatus(jobID string, status model.SampleJobItemStatus) []model.SampleJobItem {
+	if status == "" {
+		return f.items[jobID]
+	}
+	var result []model.SampleJobItem
+	for _, item := range f.items[jobID] {
+		if item.Status == status {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// pageOf returns the window of all selected by page, like LIMIT/OFFSET.
+func pageOf[T any](all []T, page model.Page) []T {
+	if page.Offset >= len(all) {
+		return nil
+	}
+	all = all[page.Offset:]
+	if page.Limit > 0 && page.Limit < len(all) {
+		all = all[:page.Limit]
+	}
+	return all
 }
 
-func (f *fakeSampleJobStore) UpdateSampleJobItem(i model.SampleJobItem) error {
+func (f *fakeSampleJobStore) UpdateSampleJobItem(ctx context.Context, i *model.SampleJobItem) error {
 	if f.updateItemErr != nil {
 		return f.updateItemErr
 	}
 	items := f.items[i.JobID]
 	for idx := range items {
 		if items[idx].ID == i.ID {
-			items[idx] = i
+			i.Version++
+			items[idx] = *i
 			f.items[i.JobID] = items
 			return nil
 		}
@@ -145,7 +296,7 @@ func (f *fakeSampleJobStore) UpdateSampleJobItem(i model.SampleJobItem) error {
 	return sql.ErrNoRows
 }
 
-func (f *fakeSampleJobStore) GetStudy(id string) (model.Study, error) {
+func (f *fakeSampleJobStore) GetStudy(ctx context.Context, id string) (model.Study, error) {
 	if f.getStudyErr != nil {
 		return model.Study{}, f.getStudyErr
 	}
@@ -156,6 +307,18 @@ func (f *fakeSampleJobStore) GetStudy(id string) (model.Study, error) {
 	return s, nil
 }
 
+func (f *fakeSampleJobStore) ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error) {
+	return f.versions[studyID], nil
+}
+
+func (f *fakeSampleJobStore) GetSampleJobParameters(ctx context.Context, jobID string) (model.SampleJobParameters, error) {
+	p, ok := f.params[jobID]
+	if !ok {
+		return model.SampleJobParameters{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
 // fakePathMatcher is a test double for service.PathMatcher.
 type fakePathMatcher struct {
 	paths     map[string]string
@@ -166,7 +329,7 @@ func newFakePathMatcher() *fakePathMatcher {
 	return &fakePathMatcher{paths: make(map[string]string)}
 }
 
-func (f *fakePathMatcher) MatchCheckpointPath(filename string) (string, error) {
+func (f *fakePathMatcher) MatchCheckpointPath(trainingRunName, filename string) (string, error) {
 	if f.matchErr != nil {
 		return "", f.matchErr
 	}
@@ -205,6 +368,15 @@ func (f *fakeJobSampleDataRemover) RemoveJobSampleDir(studyName string, checkpoi
 	return nil
 }
 
+// fakePinGuard is a test double for service.PinGuard.
+type fakePinGuard struct {
+	protected map[string]bool
+}
+
+func (f *fakePinGuard) IsProtected(ctx context.Context, relPath string) bool {
+	return f.protected[relPath]
+}
+
 // fakeOutputFileChecker is a test double for service.OutputFileChecker.
 type fakeOutputFileChecker struct {
 	existingFiles map[string]bool
@@ -262,6 +434,50 @@ func (f *fakeSampleJobExecutor) IsConnected() bool {
 	return f.connected
 }
 
+// fakeItemDurationSource returns a fixed average item duration.
+type fakeItemDurationSource struct {
+	avg time.Duration
+	ok  bool
+	err error
+}
+
+func (f *fakeItemDurationSource) AverageItemDuration(ctx context.Context, limit int) (time.Duration, bool, error) {
+	return f.avg, f.ok, f.err
+}
+
+// fakeWorkflowTemplateSource returns workflow templates by name.
+type fakeWorkflowTemplateSource struct {
+	templates map[string]model.WorkflowTemplate
+	err       error
+	calls     int
+}
+
+func (f *fakeWorkflowTemplateSource) Get(ctx context.Context, name string) (model.WorkflowTemplate, error) {
+	f.calls++
+	if f.err != nil {
+		return model.WorkflowTemplate{}, f.err
+	}
+	tmpl, ok := f.templates[name]
+	if !ok {
+		return model.WorkflowTemplate{}, fmt.Errorf("workflow not found: %s", name)
+	}
+	return tmpl, nil
+}
+
+// fakeVRAMChecker records the last check and returns a fixed estimate.
+type fakeVRAMChecker struct {
+	estimate model.VRAMEstimate
+	err      error
+	workflow string
+	width    int
+	height   int
+}
+
+func (f *fakeVRAMChecker) Check(workflowName string, width, height int) (model.VRAMEstimate, error) {
+	f.workflow, f.width, f.height = workflowName, width, height
+	return f.estimate, f.err
+}
+
 var _ = Describe("GenerateOutputFilename", func() {
 	It("produces a consistent query-encoded filename", func() {
 		item := model.SampleJobItem{
@@ -272,7 +488,7 @@ var _ = Describe("GenerateOutputFilename", func() {
 			Scheduler:   "simple",
 			Seed:        420,
 		}
-		result := service.GenerateOutputFilename(item)
+		result := service.GenerateOutputFilename(item, model.OutputFormatPNG)
 		// url.Values.Encode() sorts by key alphabetically
 		Expect(result).To(Equal("cfg=7.0&prompt=forest&sampler=euler&scheduler=simple&seed=420&steps=20.png"))
 	})
@@ -286,9 +502,78 @@ var _ = Describe("GenerateOutputFilename", func() {
 			Scheduler:   "normal",
 			Seed:        0,
 		}
-		result := service.GenerateOutputFilename(item)
+		result := service.GenerateOutputFilename(item, model.OutputFormatPNG)
 		Expect(result).To(ContainSubstring("cfg=3.5"))
 	})
+
+	It("includes the denoise strength of img2img items", func() {
+		denoise := 0.55
+		item := model.SampleJobItem{
+			PromptName:  "forest",
+			Steps:       20,
+			CFG:         7.0,
+			SamplerName: "euler",
+			Scheduler:   "simple",
+			Seed:        420,
+			Denoise:     &denoise,
+		}
+		result := service.GenerateOutputFilename(item, model.OutputFormatPNG)
+		Expect(result).To(Equal("cfg=7.0&denoise=0.55&prompt=forest&sampler=euler&scheduler=simple&seed=420&steps=20.png"))
+	})
+
+	It("includes the resolution of items of a resolution sweep", func() {
+		item := model.SampleJobItem{
+			PromptName:  "forest",
+			Steps:       20,
+			CFG:         7.0,
+			SamplerName: "euler",
+			Scheduler:   "simple",
+			Seed:        420,
+			Width:       832,
+			Height:      1216,
+			Resolution:  "832x1216",
+		}
+		result := service.GenerateOutputFilename(item, model.OutputFormatPNG)
+		Expect(result).To(Equal("cfg=7.0&prompt=forest&resolution=832x1216&sampler=euler&scheduler=simple&seed=420&steps=20.png"))
+	})
+
+	It("includes the wildcard values of an item as keys", func() {
+		item := model.SampleJobItem{
+			PromptName:  "car",
+			Steps:       20,
+			CFG:         7.0,
+			SamplerName: "euler",
+			Scheduler:   "simple",
+			Seed:        420,
+			Wildcards:   map[string]string{"color": "dark red"},
+		}
+		result := service.GenerateOutputFilename(item, model.OutputFormatPNG)
+		Expect(result).To(Equal("cfg=7.0&color=dark+red&prompt=car&sampler=euler&scheduler=simple&seed=420&steps=20.png"))
+	})
+})
+
+var _ = Describe("OutputRelPath", func() {
+	item := model.SampleJobItem{
+		PromptName:  "car",
+		Steps:       20,
+		CFG:         7.0,
+		SamplerName: "euler",
+		Scheduler:   "simple",
+		Seed:        420,
+		Resolution:  "832x1216",
+		Wildcards:   map[string]string{"color": "dark red"},
+	}
+
+	It("returns the query-encoded filename without a layout", func() {
+		Expect(service.OutputRelPath(model.OutputLayout{}, item, model.OutputFormatPNG)).To(Equal(service.GenerateOutputFilename(item, model.OutputFormatPNG)))
+	})
+
+	It("expands the layout with the item's dimensions and the format's extension", func() {
+		layout, err := model.ParseOutputLayout("{checkpoint}/{prompt}/{color}/{resolution}_cfg{cfg}_steps{steps}_seed{seed}")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(service.OutputRelPath(layout, item, model.OutputFormatWebP)).To(Equal("car/dark red/832x1216_cfg7.0_steps20_seed420.webp"))
+	})
 })
 
 var _ = Describe("SampleJobService", func() {
@@ -350,7 +635,7 @@ var _ = Describe("SampleJobService", func() {
 		})
 
 		It("creates a job and expands items correctly", func() {
-			job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(job.ID).NotTo(BeEmpty())
 			Expect(job.TrainingRunName).To(Equal("test-run"))
@@ -377,16 +662,77 @@ var _ = Describe("SampleJobService", func() {
 			}
 		})
 
+		It("records the ID of the request that created the job", func() {
+			job, err := svc.Create(service.WithRequestID(ctx, "req-123"), "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.CreatedByRequestID).To(Equal("req-123"))
+			Expect(store.jobs[job.ID].CreatedByRequestID).To(Equal("req-123"))
+		})
+
+		It("records the request's user as the owner of the job", func() {
+			job, err := svc.Create(service.WithUser(ctx, model.User{Name: "alice"}), "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.jobs[job.ID].Owner).To(Equal("alice"))
+		})
+
+		It("records the version of the study the job is created from", func() {
+			study.Version = 3
+			store.studies[study.ID] = study
+
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.StudyVersion).To(Equal(3))
+			Expect(store.jobs[job.ID].StudyVersion).To(Equal(3))
+		})
+
 		It("calculates total items correctly", func() {
-			job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 
 			// 2 checkpoints × 2 prompts × 2 steps × 2 cfgs × 1 pair × 1 seed = 16
 			Expect(job.TotalItems).To(Equal(16))
 		})
 
+		Context("with a step filter", func() {
+			intPtr := func(v int) *int { return &v }
+
+			BeforeEach(func() {
+				checkpoints = append(checkpoints,
+					model.Checkpoint{Filename: "checkpoint3.safetensors", StepNumber: 3000},
+					model.Checkpoint{Filename: "checkpoint4.safetensors", StepNumber: 4000},
+				)
+				pathMatcher.paths["checkpoint3.safetensors"] = "models/checkpoint3.safetensors"
+				pathMatcher.paths["checkpoint4.safetensors"] = "models/checkpoint4.safetensors"
+			})
+
+			It("includes only the checkpoints the filter selects", func() {
+				job, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{MinStep: intPtr(2000), EveryNth: 2}, false, false, false, model.ImageOutputOptions{}, model.ModelOverrides{}, false, model.SampleJobAnnotations{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.CheckpointFilenames).To(Equal([]string{"checkpoint2.safetensors", "checkpoint4.safetensors"}))
+				Expect(job.TotalItems).To(Equal(16))
+			})
+
+			It("applies after the filename list", func() {
+				job, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", []string{"checkpoint1.safetensors", "checkpoint3.safetensors", "checkpoint4.safetensors"}, model.StepFilter{MaxStep: intPtr(3000)}, false, false, false, model.ImageOutputOptions{}, model.ModelOverrides{}, false, model.SampleJobAnnotations{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.CheckpointFilenames).To(Equal([]string{"checkpoint1.safetensors", "checkpoint3.safetensors"}))
+			})
+
+			It("rejects a minimum above the maximum", func() {
+				_, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{MinStep: intPtr(3000), MaxStep: intPtr(2000)}, false, false, false, model.ImageOutputOptions{}, model.ModelOverrides{}, false, model.SampleJobAnnotations{})
+				Expect(err).To(MatchError("min_step 3000 is greater than max_step 2000"))
+				Expect(store.jobs).To(BeEmpty())
+			})
+
+			It("rejects a filter that selects no checkpoint", func() {
+				_, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{MinStep: intPtr(5000)}, false, false, false, model.ImageOutputOptions{}, model.ModelOverrides{}, false, model.SampleJobAnnotations{})
+				Expect(err).To(MatchError("no checkpoint of training run test-run is selected by the step filter"))
+				Expect(store.jobs).To(BeEmpty())
+			})
+		})
+
 		It("returns error when study not found", func() {
-			_, err := svc.Create("test-run", checkpoints, "nonexistent", nil, false, false)
+			_, err := svc.Create(ctx, "test-run", checkpoints, "nonexistent", nil, false, false, model.ImageOutputOptions{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
@@ -409,28 +755,39 @@ var _ = Describe("SampleJobService", func() {
 			}
 			store.studies[noWorkflowStudy.ID] = noWorkflowStudy
 
-			_, err := svc.Create("test-run", checkpoints, "study-no-wf", nil, false, false)
+			_, err := svc.Create(ctx, "test-run", checkpoints, "study-no-wf", nil, false, false, model.ImageOutputOptions{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("no workflow template configured"))
 		})
 
+		It("returns error and stores nothing when the job cannot be saved", func() {
+			store.createJobErr = errors.New("disk I/O error")
+
+			_, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("creating sample job"))
+			Expect(store.jobs).To(BeEmpty())
+			Expect(store.items).To(BeEmpty())
+		})
+
 		It("marks items as skipped when checkpoint path matching fails", func() {
 			pathMatcher.paths = make(map[string]string) // Clear paths to simulate no matches
 
-			job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 
 			items := store.items[job.ID]
 			Expect(items).To(HaveLen(16))
 			for _, item := range items {
 				Expect(item.Status).To(Equal(model.SampleJobItemStatusSkipped))
+				Expect(item.SkipReason).To(Equal(model.SampleJobItemSkipReasonCheckpointNotFound))
 				Expect(item.ErrorMessage).To(ContainSubstring("checkpoint not found in ComfyUI"))
 			}
 		})
 
 		It("uses shift from study when study has a shift value", func() {
 			// The study set up in BeforeEach has Shift = &1.5
-			job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(job.Shift).NotTo(BeNil())
 			Expect(*job.Shift).To(Equal(1.5))
@@ -441,14 +798,174 @@ var _ = Describe("SampleJobService", func() {
 			studyNoShift := store.studies["study-1"]
 			studyNoShift.Shift = nil
 			store.studies["study-1"] = studyNoShift
-			job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(job.Shift).To(BeNil())
 		})
 
+		It("expands one item per denoise strength and copies the input image to the job", func() {
+			img2img := store.studies["study-1"]
+			img2img.InputImage = "/refs/portrait.png"
+			img2img.DenoiseStrengths = []float64{0.3, 0.6}
+			store.studies["study-1"] = img2img
+
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.InputImage).To(Equal("/refs/portrait.png"))
+			Expect(job.TotalItems).To(Equal(32))
+
+			denoiseCounts := map[float64]int{}
+			for _, item := range store.items[job.ID] {
+				Expect(item.Denoise).NotTo(BeNil())
+				denoiseCounts[*item.Denoise]++
+			}
+			Expect(denoiseCounts).To(Equal(map[float64]int{0.3: 16, 0.6: 16}))
+		})
+
+		It("expands one item per resolution for the prompts without their own size", func() {
+			swept := store.studies["study-1"]
+			swept.Prompts = []model.NamedPrompt{
+				{Name: "forest", Text: "a forest"},
+				{Name: "portrait", Text: "a portrait", Width: 832, Height: 1216},
+			}
+			swept.Resolutions = []model.Resolution{{Width: 768, Height: 768}, {Width: 1344, Height: 768}}
+			store.studies["study-1"] = swept
+
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", []string{"checkpoint1.safetensors"}, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			type size struct {
+				width, height int
+				resolution    string
+			}
+			sizes := map[string]map[size]int{}
+			for _, item := range store.items[job.ID] {
+				if sizes[item.PromptName] == nil {
+					sizes[item.PromptName] = map[size]int{}
+				}
+				sizes[item.PromptName][size{item.Width, item.Height, item.Resolution}]++
+			}
+			// 2 steps × 2 CFGs × 1 seed per size
+			Expect(sizes["forest"]).To(Equal(map[size]int{
+				{768, 768, "768x768"}:   4,
+				{1344, 768, "1344x768"}: 4,
+			}))
+			Expect(sizes["portrait"]).To(Equal(map[size]int{{832, 1216, ""}: 4}))
+			Expect(job.TotalItems).To(Equal(12))
+		})
+
+		It("expands each prompt with its own overrides, falling back to the study's values", func() {
+			mixed := store.studies["study-1"]
+			mixed.Prompts = []model.NamedPrompt{
+				{Name: "portrait", Text: "a portrait", Width: 832, Height: 1216, Steps: []int{25}},
+				{Name: "landscape", Text: "a landscape", Width: 1216, Height: 832, Seeds: []int64{1, 2}},
+			}
+			store.studies["study-1"] = mixed
+
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", []string{"checkpoint1.safetensors"}, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			type shape struct {
+				width, height, steps int
+				seed                 int64
+			}
+			shapes := map[string][]shape{}
+			for _, item := range store.items[job.ID] {
+				shapes[item.PromptName] = append(shapes[item.PromptName], shape{item.Width, item.Height, item.Steps, item.Seed})
+			}
+			// portrait: 1 step × 2 CFGs × 1 seed; landscape: 2 steps × 2 CFGs × 2 seeds
+			Expect(shapes["portrait"]).To(HaveLen(2))
+			Expect(shapes["portrait"]).To(HaveEach(shape{832, 1216, 25, mixed.Seeds[0]}))
+			Expect(shapes["landscape"]).To(HaveLen(8))
+			for _, sh := range shapes["landscape"] {
+				Expect(sh.width).To(Equal(1216))
+				Expect(sh.height).To(Equal(832))
+				Expect(mixed.Steps).To(ContainElement(sh.steps))
+				Expect(sh.seed).To(BeElementOf(int64(1), int64(2)))
+			}
+			Expect(job.TotalItems).To(Equal(10))
+		})
+
+		It("expands one item per wildcard value and renders it into the prompt text", func() {
+			wild := store.studies["study-1"]
+			wild.Prompts = []model.NamedPrompt{{Name: "car", Text: "a {color} car"}}
+			wild.Wildcards = []model.Wildcard{{Name: "color", Values: []string{"red", "blue"}}}
+			store.studies["study-1"] = wild
+
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", []string{"checkpoint1.safetensors"}, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			// 2 colors × 2 steps × 2 CFGs
+			Expect(job.TotalItems).To(Equal(8))
+
+			texts := map[string]string{}
+			for _, item := range store.items[job.ID] {
+				texts[item.Wildcards["color"]] = item.PromptText
+			}
+			Expect(texts).To(HaveLen(2))
+			Expect(texts["red"]).To(HaveSuffix("a red car"))
+			Expect(texts["blue"]).To(HaveSuffix("a blue car"))
+		})
+
+		It("draws random seeds per checkpoint and shares them across its combinations", func() {
+			random := store.studies["study-1"]
+			random.Seeds = nil
+			random.SeedMode = model.SeedModeRandomPerCheckpoint
+			random.RandomSeedCount = 3
+			store.studies["study-1"] = random
+
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			// 2 checkpoints × 2 prompts × 2 steps × 2 CFGs × 3 seeds
+			Expect(job.TotalItems).To(Equal(48))
+
+			seeds := map[string]map[int64]int{}
+			for _, item := range store.items[job.ID] {
+				Expect(item.Seed).To(BeNumerically(">=", 0))
+				Expect(item.Seed).To(BeNumerically("<", int64(1)<<53))
+				if seeds[item.CheckpointFilename] == nil {
+					seeds[item.CheckpointFilename] = map[int64]int{}
+				}
+				seeds[item.CheckpointFilename][item.Seed]++
+			}
+			Expect(seeds).To(HaveLen(2))
+			for _, counts := range seeds {
+				// Every seed is used by each of the 8 prompt × step × CFG combinations.
+				Expect(counts).To(HaveLen(3))
+				Expect(counts).To(HaveEach(8))
+			}
+		})
+
+		It("draws a new random seed for every item", func() {
+			random := store.studies["study-1"]
+			random.Seeds = nil
+			random.SeedMode = model.SeedModeRandomPerItem
+			random.RandomSeedCount = 2
+			store.studies["study-1"] = random
+
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", []string{"checkpoint1.safetensors"}, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			// 2 prompts × 2 steps × 2 CFGs × 2 seeds
+			Expect(job.TotalItems).To(Equal(16))
+
+			seeds := map[int64]bool{}
+			for _, item := range store.items[job.ID] {
+				seeds[item.Seed] = true
+			}
+			Expect(seeds).To(HaveLen(16))
+		})
+
+		It("leaves denoise unset for text-to-image studies", func() {
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.InputImage).To(BeEmpty())
+			for _, item := range store.items[job.ID] {
+				Expect(item.Denoise).To(BeNil())
+			}
+		})
+
 		DescribeTable("filters checkpoints by checkpoint_filenames when provided",
 			func(filenames []string, expectedCount int) {
-				job, err := svc.Create("test-run", checkpoints, "study-1", filenames, false, false)
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", filenames, false, false, model.ImageOutputOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				// Each checkpoint produces 8 items (2 prompts × 2 steps × 2 cfgs × 1 pair × 1 seed)
 				Expect(job.TotalItems).To(Equal(expectedCount * 8))
@@ -463,19 +980,19 @@ var _ = Describe("SampleJobService", func() {
 		)
 
 		It("stores all checkpoint filenames in the job when no filter is provided", func() {
-			job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(job.CheckpointFilenames).To(ConsistOf("checkpoint1.safetensors", "checkpoint2.safetensors"))
 		})
 
 		It("stores only filtered checkpoint filenames when a filter is provided", func() {
-			job, err := svc.Create("test-run", checkpoints, "study-1", []string{"checkpoint1.safetensors"}, false, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", []string{"checkpoint1.safetensors"}, false, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(job.CheckpointFilenames).To(ConsistOf("checkpoint1.safetensors"))
 		})
 
 		It("stores empty checkpoint filenames list when filter matches no checkpoints", func() {
-			job, err := svc.Create("test-run", checkpoints, "study-1", []string{"nonexistent.safetensors"}, false, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", []string{"nonexistent.safetensors"}, false, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(job.CheckpointFilenames).To(BeEmpty())
 		})
@@ -483,7 +1000,7 @@ var _ = Describe("SampleJobService", func() {
 		// B-114: clear_existing is stored as a job parameter, not executed at queue time
 		It("stores clear_existing flag on the job but does NOT clear directories at queue time", func() {
 			dirRemover.removed = nil
-			job, err := svc.Create("test-run", checkpoints, "study-1", nil, true, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, true, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			// Directories should NOT be cleared during Create
 			Expect(dirRemover.removed).To(BeEmpty())
@@ -492,7 +1009,7 @@ var _ = Describe("SampleJobService", func() {
 		})
 
 		It("stores clear_existing=false when not requested", func() {
-			job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, false)
+			job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(job.ClearExisting).To(BeFalse())
 		})
@@ -503,7 +1020,7 @@ var _ = Describe("SampleJobService", func() {
 		Context("regeneration job creation (B-106)", func() {
 			It("creates a job with clear_existing flag stored (clearing deferred to start)", func() {
 				dirRemover.removed = nil
-				job, err := svc.Create("test-run", checkpoints, "study-1", nil, true, false)
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, true, false, model.ImageOutputOptions{})
 				Expect(err).NotTo(HaveOccurred())
 
 				// AC1: Job is created with correct study and training run
@@ -531,7 +1048,7 @@ var _ = Describe("SampleJobService", func() {
 				updatedStudy.TextEncoder = "new-clip.safetensors"
 				store.studies["study-1"] = updatedStudy
 
-				job, err := svc.Create("test-run", checkpoints, "study-1", nil, true, false)
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, true, false, model.ImageOutputOptions{})
 				Expect(err).NotTo(HaveOccurred())
 
 				// Job uses the updated study settings
@@ -541,6 +1058,41 @@ var _ = Describe("SampleJobService", func() {
 			})
 		})
 
+		Context("output format", func() {
+			DescribeTable("resolves the output format and quality",
+				func(output model.ImageOutputOptions, expectedFormat model.OutputFormat, expectedQuality int) {
+					job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, output)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(job.OutputFormat).To(Equal(expectedFormat))
+					Expect(job.OutputQuality).To(Equal(expectedQuality))
+				},
+				Entry("defaults to png", model.ImageOutputOptions{}, model.OutputFormatPNG, 0),
+				Entry("ignores quality for png", model.ImageOutputOptions{Format: model.OutputFormatPNG, Quality: 50}, model.OutputFormatPNG, 0),
+				Entry("defaults quality for jpeg", model.ImageOutputOptions{Format: model.OutputFormatJPEG}, model.OutputFormatJPEG, model.DefaultOutputQuality),
+				Entry("keeps explicit webp quality", model.ImageOutputOptions{Format: model.OutputFormatWebP, Quality: 75}, model.OutputFormatWebP, 75),
+			)
+
+			DescribeTable("rejects invalid output options",
+				func(output model.ImageOutputOptions) {
+					_, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, output)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("invalid output"))
+					Expect(store.jobs).To(BeEmpty())
+				},
+				Entry("unknown format", model.ImageOutputOptions{Format: "gif"}),
+				Entry("quality above 100", model.ImageOutputOptions{Format: model.OutputFormatJPEG, Quality: 101}),
+				Entry("negative quality", model.ImageOutputOptions{Format: model.OutputFormatWebP, Quality: -1}),
+			)
+
+			It("rejects formats the server cannot encode", func() {
+				svc.SetOutputFormatSupport(service.NewImageTranscoder(nil, logger))
+
+				_, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{Format: model.OutputFormatWebP})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("no encoder is available"))
+			})
+		})
+
 		// AC5: missing-only generation logic
 		Context("with missing_only=true", func() {
 			var fileChecker *fakeOutputFileChecker
@@ -560,12 +1112,12 @@ var _ = Describe("SampleJobService", func() {
 					SamplerName: "euler",
 					Scheduler:   "simple",
 					Seed:        420,
-				})
+				}, model.OutputFormatPNG)
 
 				// Mark this file as existing for checkpoint1 only
 				fileChecker.existingFiles["/samples/Test Study/checkpoint1.safetensors/"+expectedFilename] = true
 
-				job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, true)
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, true, model.ImageOutputOptions{})
 				Expect(err).NotTo(HaveOccurred())
 
 				// Total items should be 16 - 1 = 15 (one item skipped)
@@ -576,7 +1128,7 @@ var _ = Describe("SampleJobService", func() {
 
 			It("creates all items when no output files exist", func() {
 				// No files marked as existing
-				job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, true)
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, true, model.ImageOutputOptions{})
 				Expect(err).NotTo(HaveOccurred())
 
 				// All 16 items should be created
@@ -600,7 +1152,7 @@ var _ = Describe("SampleJobService", func() {
 											SamplerName: pair.Sampler,
 											Scheduler:   pair.Scheduler,
 											Seed:        seed,
-										})
+										}, model.OutputFormatPNG)
 										fileChecker.existingFiles["/samples/Test Study/"+cp.Filename+"/"+fn] = true
 									}
 								}
@@ -609,58 +1161,789 @@ var _ = Describe("SampleJobService", func() {
 					}
 				}
 
-				job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, true)
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, true, model.ImageOutputOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(job.TotalItems).To(Equal(0))
 			})
 
+			It("checks for files with the extension of the requested output format", func() {
+				expectedFilename := service.GenerateOutputFilename(model.SampleJobItem{
+					PromptName:  "prompt1",
+					Steps:       1,
+					CFG:         1.0,
+					SamplerName: "euler",
+					Scheduler:   "simple",
+					Seed:        420,
+				}, model.OutputFormatWebP)
+				fileChecker.existingFiles["/samples/Test Study/checkpoint1.safetensors/"+expectedFilename] = true
+
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, true, model.ImageOutputOptions{Format: model.OutputFormatWebP})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.TotalItems).To(Equal(15))
+			})
+
 			It("does not filter when fileChecker is nil", func() {
 				svc.SetFileChecker(nil)
 
-				job, err := svc.Create("test-run", checkpoints, "study-1", nil, false, true)
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, true, model.ImageOutputOptions{})
 				Expect(err).NotTo(HaveOccurred())
 
 				// All items should be created since no file checker is set
 				Expect(job.TotalItems).To(Equal(16))
 			})
 		})
-	})
-
-	Describe("Get", func() {
-		It("returns a job by ID", func() {
-			job := model.SampleJob{
-				ID:              "job-1",
-				TrainingRunName: "test-run",
-				Status:          model.SampleJobStatusPending,
-			}
-			store.jobs[job.ID] = job
-
-			result, err := svc.Get("job-1")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(result.ID).To(Equal("job-1"))
-		})
 
-		It("returns error when job not found", func() {
-			_, err := svc.Get("nonexistent")
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("not found"))
-		})
-	})
+		Context("with skip_existing=true", func() {
+			var (
+				fileChecker    *fakeOutputFileChecker
+				existingPath   string
+				existingItemOf = func(item model.SampleJobItem) bool {
+					return item.CheckpointFilename == "checkpoint1.safetensors" &&
+						item.PromptName == "prompt1" && item.Steps == 1 && item.CFG == 1.0
+				}
+			)
 
-	Describe("Start", func() {
-		It("transitions pending job to running", func() {
+			BeforeEach(func() {
+				fileChecker = newFakeOutputFileChecker()
+				svc.SetFileChecker(fileChecker)
+				existingPath = "/samples/Test Study/checkpoint1.safetensors/" + service.GenerateOutputFilename(model.SampleJobItem{
+					PromptName:  "prompt1",
+					Steps:       1,
+					CFG:         1.0,
+					SamplerName: "euler",
+					Scheduler:   "simple",
+					Seed:        420,
+				}, model.OutputFormatPNG)
+				fileChecker.existingFiles[existingPath] = true
+			})
+
+			It("keeps every item and marks those with existing output as skipped", func() {
+				job, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, true, model.ImageOutputOptions{}, model.ModelOverrides{}, false, model.SampleJobAnnotations{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.TotalItems).To(Equal(16))
+
+				items := store.items[job.ID]
+				Expect(items).To(HaveLen(16))
+				for _, item := range items {
+					if existingItemOf(item) {
+						Expect(item.Status).To(Equal(model.SampleJobItemStatusSkipped))
+						Expect(item.SkipReason).To(Equal(model.SampleJobItemSkipReasonDuplicate))
+						Expect(item.ErrorMessage).To(Equal(model.SkippedOutputExistsMessage))
+						Expect(item.OutputPath).To(Equal(existingPath))
+						continue
+					}
+					Expect(item.Status).To(Equal(model.SampleJobItemStatusPending))
+					Expect(item.ComfyUIModelPath).NotTo(BeEmpty())
+				}
+			})
+
+			It("counts the existing items in a preview", func() {
+				preview, err := svc.Preview(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, true, model.ImageOutputOptions{}, model.ModelOverrides{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(preview.TotalItems).To(Equal(16))
+				Expect(preview.ExistingItems).To(Equal(1))
+				Expect(preview.RunnableItems).To(Equal(15))
+				Expect(preview.UnmatchedCheckpoints).To(BeEmpty())
+			})
+		})
+
+		Context("with an output layout", func() {
+			It("looks for existing output at the layout's paths", func() {
+				layout, err := model.ParseOutputLayout("{checkpoint}/{prompt}/{sampler}_{scheduler}/cfg{cfg}_steps{steps}_seed{seed}")
+				Expect(err).NotTo(HaveOccurred())
+				svc.SetOutputLayout(layout)
+				fileChecker := newFakeOutputFileChecker()
+				svc.SetFileChecker(fileChecker)
+				fileChecker.existingFiles["/samples/Test Study/checkpoint1.safetensors/prompt1/euler_simple/cfg1.0_steps1_seed420.png"] = true
+
+				job, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, true, model.ImageOutputOptions{}, model.ModelOverrides{}, false, model.SampleJobAnnotations{})
+				Expect(err).NotTo(HaveOccurred())
+
+				skipped := 0
+				for _, item := range store.items[job.ID] {
+					if item.Status == model.SampleJobItemStatusSkipped {
+						skipped++
+						Expect(item.OutputPath).To(Equal("/samples/Test Study/checkpoint1.safetensors/prompt1/euler_simple/cfg1.0_steps1_seed420.png"))
+					}
+				}
+				Expect(skipped).To(Equal(1))
+			})
+
+			It("rejects jobs whose images the layout saves at the same path", func() {
+				layout, err := model.ParseOutputLayout("{checkpoint}/{prompt}")
+				Expect(err).NotTo(HaveOccurred())
+				svc.SetOutputLayout(layout)
+
+				_, err = svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+				Expect(err).To(MatchError(ContainSubstring(`output layout "{checkpoint}/{prompt}" saves more than one image of the job at checkpoint1.safetensors/prompt1.png`)))
+			})
+		})
+
+		Context("with workflow defaults", func() {
+			var workflows *fakeWorkflowTemplateSource
+
+			BeforeEach(func() {
+				workflowShift := 3.1
+				workflows = &fakeWorkflowTemplateSource{templates: map[string]model.WorkflowTemplate{
+					"workflow.json": {
+						Name: "workflow.json",
+						Defaults: model.WorkflowDefaults{
+							VAE:   "ae.safetensors",
+							CLIP:  "t5xxl.safetensors",
+							Shift: &workflowShift,
+						},
+					},
+				}}
+				svc.SetWorkflowSource(workflows)
+			})
+
+			It("prefers the study's values over the workflow defaults", func() {
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.VAE).To(Equal("vae.safetensors"))
+				Expect(job.CLIP).To(Equal("clip.safetensors"))
+				Expect(job.Shift).To(HaveValue(Equal(1.5)))
+				Expect(workflows.calls).To(Equal(0))
+			})
+
+			It("uses the workflow defaults for values the study leaves unset", func() {
+				study.VAE = ""
+				study.Shift = nil
+				store.studies[study.ID] = study
+
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.VAE).To(Equal("ae.safetensors"))
+				Expect(job.CLIP).To(Equal("clip.safetensors"))
+				Expect(job.Shift).To(HaveValue(Equal(3.1)))
+			})
+
+			It("prefers explicit overrides over the study and workflow", func() {
+				study.VAE = ""
+				store.studies[study.ID] = study
+				overrideShift := 2.0
+
+				job, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{},
+					model.ModelOverrides{CLIP: "override-clip.safetensors", Shift: &overrideShift}, false, model.SampleJobAnnotations{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.VAE).To(Equal("ae.safetensors"))
+				Expect(job.CLIP).To(Equal("override-clip.safetensors"))
+				Expect(job.Shift).To(HaveValue(Equal(2.0)))
+			})
+
+			It("creates the job without defaults when the workflow cannot be loaded", func() {
+				workflows.err = errors.New("workflow not found")
+				study.VAE = ""
+				store.studies[study.ID] = study
+
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.VAE).To(BeEmpty())
+				Expect(job.CLIP).To(Equal("clip.safetensors"))
+			})
+		})
+
+		Context("with ControlNet settings", func() {
+			var workflows *fakeWorkflowTemplateSource
+			strength := 0.8
+
+			BeforeEach(func() {
+				workflows = &fakeWorkflowTemplateSource{templates: map[string]model.WorkflowTemplate{
+					"workflow.json": {
+						Name: "workflow.json",
+						Roles: map[string][]string{
+							"controlnet_loader": {"11"},
+							"controlnet_apply":  {"13"},
+						},
+					},
+				}}
+				svc.SetWorkflowSource(workflows)
+			})
+
+			It("stores the settings on the job", func() {
+				job, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{},
+					model.ModelOverrides{
+						ControlNetModel:    "control_canny.safetensors",
+						ControlNetStrength: &strength,
+						ControlNetImage:    "/refs/edges.png",
+					}, false, model.SampleJobAnnotations{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.ControlNetModel).To(Equal("control_canny.safetensors"))
+				Expect(job.ControlNetStrength).To(HaveValue(Equal(0.8)))
+				Expect(job.ControlNetImage).To(Equal("/refs/edges.png"))
+				Expect(store.jobs[job.ID].ControlNetImage).To(Equal("/refs/edges.png"))
+			})
+
+			DescribeTable("rejects strengths outside [0, 10]",
+				func(value float64) {
+					_, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{},
+						model.ModelOverrides{ControlNetStrength: &value}, false, model.SampleJobAnnotations{})
+					Expect(err).To(MatchError(ContainSubstring("controlnet strength must be between 0 and 10")))
+				},
+				Entry("negative", -0.1),
+				Entry("too large", 10.5),
+			)
+
+			It("rejects settings the workflow has no node for", func() {
+				workflows.templates["workflow.json"] = model.WorkflowTemplate{
+					Name:  "workflow.json",
+					Roles: map[string][]string{"controlnet_loader": {"11"}},
+				}
+
+				_, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{},
+					model.ModelOverrides{ControlNetModel: "control_canny.safetensors"}, false, model.SampleJobAnnotations{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{},
+					model.ModelOverrides{ControlNetImage: "/refs/edges.png"}, false, model.SampleJobAnnotations{})
+				Expect(err).To(MatchError(ContainSubstring("has no controlnet_apply node")))
+			})
+		})
+
+		Context("with annotations", func() {
+			It("records the trimmed notes and labels with the job", func() {
+				annotations := model.SampleJobAnnotations{
+					Notes:  " lr=1e-5 experiment, bad data mix\n",
+					Labels: map[string]string{" lr ": "1e-5 ", "baseline": ""},
+				}
+				job, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{}, model.ModelOverrides{}, false, annotations)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.Notes).To(Equal("lr=1e-5 experiment, bad data mix"))
+				Expect(job.Labels).To(Equal(map[string]string{"lr": "1e-5", "baseline": ""}))
+				Expect(store.jobs[job.ID].Labels).To(Equal(job.Labels))
+			})
+
+			DescribeTable("rejects invalid labels",
+				func(labels map[string]string, message string) {
+					_, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{}, model.ModelOverrides{}, false, model.SampleJobAnnotations{Labels: labels})
+					Expect(err).To(MatchError(ContainSubstring(message)))
+					Expect(store.jobs).To(BeEmpty())
+				},
+				Entry("empty key", map[string]string{" ": "x"}, "key must not be empty"),
+				Entry("key with '='", map[string]string{"lr=1": "x"}, "must not contain '='"),
+				Entry("overlong key", map[string]string{strings.Repeat("k", 65): "x"}, "key must be at most 64 characters"),
+				Entry("overlong value", map[string]string{"k": strings.Repeat("v", 257)}, "value must be at most 256 characters"),
+				Entry("keys equal after trimming", map[string]string{"lr": "1", "lr ": "2"}, "given more than once"),
+			)
+		})
+
+		Context("with workflow overrides", func() {
+			var workflows *fakeWorkflowTemplateSource
+
+			BeforeEach(func() {
+				workflows = &fakeWorkflowTemplateSource{templates: map[string]model.WorkflowTemplate{
+					"workflow.json": {Name: "workflow.json"},
+					"flux-dev.json": {Name: "flux-dev.json"},
+				}}
+				svc.SetWorkflowSource(workflows)
+			})
+
+			It("samples the checkpoints matching a pattern with its workflow", func() {
+				overrides := []model.WorkflowOverride{
+					{Pattern: "checkpoint2.*", Workflow: "flux-dev.json"},
+					{Pattern: "*", Workflow: "workflow.json"},
+				}
+				job, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{},
+					model.ModelOverrides{Workflows: overrides}, false, model.SampleJobAnnotations{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(store.jobs[job.ID].WorkflowOverrides).To(Equal(overrides))
+
+				workflowsByCheckpoint := map[string]string{}
+				for _, item := range store.items[job.ID] {
+					workflowsByCheckpoint[item.CheckpointFilename] = job.WorkflowOf(item)
+				}
+				Expect(workflowsByCheckpoint).To(Equal(map[string]string{
+					"checkpoint1.safetensors": "workflow.json",
+					"checkpoint2.safetensors": "flux-dev.json",
+				}))
+			})
+
+			It("rejects an override whose workflow cannot be loaded", func() {
+				_, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{},
+					model.ModelOverrides{Workflows: []model.WorkflowOverride{{Pattern: "*", Workflow: "missing.json"}}}, false, model.SampleJobAnnotations{})
+				Expect(err).To(MatchError(ContainSubstring("workflow not found: missing.json")))
+				Expect(store.jobs).To(BeEmpty())
+			})
+
+			DescribeTable("rejects invalid overrides",
+				func(override model.WorkflowOverride, message string) {
+					_, err := svc.CreateWithOverrides(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, false, model.ImageOutputOptions{},
+						model.ModelOverrides{Workflows: []model.WorkflowOverride{override}}, false, model.SampleJobAnnotations{})
+					Expect(err).To(MatchError(ContainSubstring(message)))
+				},
+				Entry("empty pattern", model.WorkflowOverride{Workflow: "flux-dev.json"}, "has no checkpoint pattern"),
+				Entry("malformed pattern", model.WorkflowOverride{Pattern: "[", Workflow: "flux-dev.json"}, "invalid checkpoint pattern"),
+				Entry("empty workflow", model.WorkflowOverride{Pattern: "*"}, "has no workflow"),
+			)
+		})
+
+		Context("with a VRAM checker", func() {
+			var checker *fakeVRAMChecker
+
+			BeforeEach(func() {
+				checker = &fakeVRAMChecker{}
+				svc.SetVRAMChecker(checker)
+			})
+
+			It("checks the study's workflow and resolution", func() {
+				_, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checker.workflow).To(Equal(study.WorkflowTemplate))
+				Expect(checker.width).To(Equal(study.Width))
+				Expect(checker.height).To(Equal(study.Height))
+			})
+
+			It("checks the largest per-prompt resolution", func() {
+				study.Prompts[1].Width, study.Prompts[1].Height = 1216, 1664
+				store.studies[study.ID] = study
+
+				_, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checker.width).To(Equal(1216))
+				Expect(checker.height).To(Equal(1664))
+			})
+
+			It("reports the warning on the created job", func() {
+				checker.estimate = model.VRAMEstimate{Warning: "flux at 2048x2048 needs about 26000 MB of VRAM"}
+
+				job, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.Warnings).To(Equal([]string{"flux at 2048x2048 needs about 26000 MB of VRAM"}))
+				Expect(store.jobs).To(HaveKey(job.ID))
+			})
+
+			It("refuses the job when the checker returns an error", func() {
+				checker.err = service.ErrVRAMLimitExceeded
+
+				_, err := svc.Create(ctx, "test-run", checkpoints, "study-1", nil, false, false, model.ImageOutputOptions{})
+				Expect(err).To(MatchError(service.ErrVRAMLimitExceeded))
+				Expect(store.jobs).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("CreateWithStudy", func() {
+		var (
+			study       model.Study
+			checkpoints []model.Checkpoint
+		)
+
+		BeforeEach(func() {
+			study = model.Study{
+				ID:                    "study-new",
+				Name:                  "Inline Study",
+				Prompts:               []model.NamedPrompt{{Name: "prompt1", Text: "text1"}},
+				Steps:                 []int{4},
+				CFGs:                  []float64{1.0},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+				Seeds:                 []int64{420},
+				WorkflowTemplate:      "workflow.json",
+			}
+			checkpoints = []model.Checkpoint{
+				{Filename: "checkpoint1.safetensors", StepNumber: 1000},
+				{Filename: "checkpoint2.safetensors", StepNumber: 2000},
+			}
+			pathMatcher.paths["checkpoint1.safetensors"] = "models/checkpoint1.safetensors"
+			pathMatcher.paths["checkpoint2.safetensors"] = "models/checkpoint2.safetensors"
+		})
+
+		It("stores the study together with the job and its items", func() {
+			job, err := svc.CreateWithStudy(ctx, "test-run", checkpoints, study, nil, false, false, false, model.ImageOutputOptions{}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.StudyID).To(Equal("study-new"))
+			Expect(job.StudyName).To(Equal("Inline Study"))
+			Expect(job.TotalItems).To(Equal(2))
+
+			Expect(store.studies).To(HaveKey("study-new"))
+			Expect(store.jobs).To(HaveKey(job.ID))
+			Expect(store.items[job.ID]).To(HaveLen(2))
+		})
+
+		It("stores nothing when the study has no workflow template", func() {
+			study.WorkflowTemplate = ""
+
+			_, err := svc.CreateWithStudy(ctx, "test-run", checkpoints, study, nil, false, false, false, model.ImageOutputOptions{}, false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no workflow template configured"))
+			Expect(store.studies).NotTo(HaveKey("study-new"))
+			Expect(store.jobs).To(BeEmpty())
+		})
+
+		It("returns error when the study and job cannot be saved", func() {
+			store.createJobErr = errors.New("disk I/O error")
+
+			_, err := svc.CreateWithStudy(ctx, "test-run", checkpoints, study, nil, false, false, false, model.ImageOutputOptions{}, false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("creating study and sample job"))
+			Expect(store.studies).NotTo(HaveKey("study-new"))
+			Expect(store.jobs).To(BeEmpty())
+		})
+	})
+
+	Describe("Preview", func() {
+		var checkpoints []model.Checkpoint
+
+		BeforeEach(func() {
+			store.studies["study-1"] = model.Study{
+				ID:                    "study-1",
+				Name:                  "Test Study",
+				Prompts:               []model.NamedPrompt{{Name: "p1", Text: "a"}, {Name: "p2", Text: "b"}},
+				Steps:                 []int{4},
+				CFGs:                  []float64{7.0},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+				Seeds:                 []int64{42, 43},
+				WorkflowTemplate:      "workflow.json",
+			}
+			checkpoints = []model.Checkpoint{
+				{Filename: "checkpoint1.safetensors", StepNumber: 1000},
+				{Filename: "checkpoint2.safetensors", StepNumber: 2000},
+				{Filename: "checkpoint3.safetensors", StepNumber: 3000},
+			}
+			pathMatcher.paths["checkpoint1.safetensors"] = "models/checkpoint1.safetensors"
+			pathMatcher.paths["checkpoint3.safetensors"] = "models/checkpoint3.safetensors"
+		})
+
+		It("reports the expansion without storing anything", func() {
+			preview, err := svc.Preview(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, model.ImageOutputOptions{}, model.ModelOverrides{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(preview.TotalItems).To(Equal(12))
+			Expect(preview.RunnableItems).To(Equal(8))
+			Expect(preview.Checkpoints).To(Equal([]model.CheckpointPreview{
+				{Filename: "checkpoint1.safetensors", Items: 4, PathMatched: true},
+				{Filename: "checkpoint2.safetensors", Items: 4, PathMatched: false},
+				{Filename: "checkpoint3.safetensors", Items: 4, PathMatched: true},
+			}))
+			Expect(preview.UnmatchedCheckpoints).To(Equal([]string{"checkpoint2.safetensors"}))
+			Expect(preview.EstimatedDuration).To(BeNil())
+			Expect(store.jobs).To(BeEmpty())
+			Expect(store.items).To(BeEmpty())
+		})
+
+		It("estimates the runtime of the runnable items", func() {
+			svc.SetItemDurationSource(&fakeItemDurationSource{avg: 3 * time.Second, ok: true})
+
+			preview, err := svc.Preview(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, model.ImageOutputOptions{}, model.ModelOverrides{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(preview.EstimatedDuration).To(HaveValue(Equal(24 * time.Second)))
+		})
+
+		It("still previews when the estimate cannot be computed", func() {
+			svc.SetItemDurationSource(&fakeItemDurationSource{err: errors.New("db locked")})
+
+			preview, err := svc.Preview(ctx, "test-run", checkpoints, "study-1", nil, model.StepFilter{}, false, false, model.ImageOutputOptions{}, model.ModelOverrides{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(preview.TotalItems).To(Equal(12))
+			Expect(preview.EstimatedDuration).To(BeNil())
+		})
+
+		It("reports checkpoints left without items by missing-only filtering", func() {
+			fileChecker := newFakeOutputFileChecker()
+			svc.SetFileChecker(fileChecker)
+			for _, prompt := range []string{"p1", "p2"} {
+				for _, seed := range []int64{42, 43} {
+					fn := service.GenerateOutputFilename(model.SampleJobItem{
+						PromptName:  prompt,
+						Steps:       4,
+						CFG:         7.0,
+						SamplerName: "euler",
+						Scheduler:   "simple",
+						Seed:        seed,
+					}, model.OutputFormatPNG)
+					fileChecker.existingFiles["/samples/Test Study/checkpoint2.safetensors/"+fn] = true
+				}
+			}
+
+			preview, err := svc.Preview(ctx, "test-run", checkpoints, "study-1", []string{"checkpoint1.safetensors", "checkpoint2.safetensors"}, model.StepFilter{}, true, false, model.ImageOutputOptions{}, model.ModelOverrides{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(preview.TotalItems).To(Equal(4))
+			Expect(preview.Checkpoints).To(Equal([]model.CheckpointPreview{
+				{Filename: "checkpoint1.safetensors", Items: 4, PathMatched: true},
+				{Filename: "checkpoint2.safetensors", Items: 0, PathMatched: false},
+			}))
+			Expect(preview.UnmatchedCheckpoints).To(Equal([]string{"checkpoint2.safetensors"}))
+		})
+
+		It("returns a not found error for an unknown study", func() {
+			_, err := svc.Preview(ctx, "test-run", checkpoints, "missing", nil, model.StepFilter{}, false, false, model.ImageOutputOptions{}, model.ModelOverrides{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not found"))
+		})
+	})
+
+	Describe("CreateBulk", func() {
+		var checkpoints []model.Checkpoint
+
+		addStudy := func(id string, prompts int) {
+			var named []model.NamedPrompt
+			for i := 0; i < prompts; i++ {
+				named = append(named, model.NamedPrompt{Name: fmt.Sprintf("p%d", i), Text: "text"})
+			}
+			store.studies[id] = model.Study{
+				ID:                    id,
+				Name:                  "Study " + id,
+				Prompts:               named,
+				Steps:                 []int{4},
+				CFGs:                  []float64{7.0},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+				Seeds:                 []int64{42},
+				WorkflowTemplate:      "workflow.json",
+			}
+		}
+
+		BeforeEach(func() {
+			addStudy("portrait", 2)
+			addStudy("landscape", 3)
+			checkpoints = []model.Checkpoint{
+				{Filename: "checkpoint1.safetensors", StepNumber: 1000},
+				{Filename: "checkpoint2.safetensors", StepNumber: 2000},
+			}
+			pathMatcher.paths["checkpoint1.safetensors"] = "models/checkpoint1.safetensors"
+			pathMatcher.paths["checkpoint2.safetensors"] = "models/checkpoint2.safetensors"
+		})
+
+		It("creates one job per study in the requested order with aggregate totals", func() {
+			result, err := svc.CreateBulk(ctx, "test-run", checkpoints, []string{"landscape", "portrait"}, nil, false, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Jobs).To(HaveLen(2))
+			Expect(result.Jobs[0].StudyID).To(Equal("landscape"))
+			Expect(result.Jobs[1].StudyID).To(Equal("portrait"))
+			Expect(result.Jobs[0].TotalItems).To(Equal(6))
+			Expect(result.Jobs[1].TotalItems).To(Equal(4))
+			Expect(result.TotalItems).To(Equal(10))
+			Expect(result.EstimatedDuration).To(BeNil())
+		})
+
+		It("estimates the duration from recent item timings", func() {
+			svc.SetItemDurationSource(&fakeItemDurationSource{avg: 3 * time.Second, ok: true})
+
+			result, err := svc.CreateBulk(ctx, "test-run", checkpoints, []string{"portrait", "landscape"}, nil, false, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.EstimatedDuration).To(HaveValue(Equal(30 * time.Second)))
+		})
+
+		It("still creates the jobs when the estimate cannot be computed", func() {
+			svc.SetItemDurationSource(&fakeItemDurationSource{err: errors.New("db locked")})
+
+			result, err := svc.CreateBulk(ctx, "test-run", checkpoints, []string{"portrait"}, nil, false, false, false, model.ImageOutputOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Jobs).To(HaveLen(1))
+			Expect(result.EstimatedDuration).To(BeNil())
+		})
+
+		It("creates no jobs when any study is unknown", func() {
+			_, err := svc.CreateBulk(ctx, "test-run", checkpoints, []string{"portrait", "missing"}, nil, false, false, false, model.ImageOutputOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not found"))
+			Expect(store.jobs).To(BeEmpty())
+		})
+
+		DescribeTable("rejects invalid study lists",
+			func(studyIDs []string) {
+				_, err := svc.CreateBulk(ctx, "test-run", checkpoints, studyIDs, nil, false, false, false, model.ImageOutputOptions{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid"))
+				Expect(store.jobs).To(BeEmpty())
+			},
+			Entry("empty list", []string{}),
+			Entry("duplicate study", []string{"portrait", "portrait"}),
+		)
+
+		It("applies the output format to every job", func() {
+			result, err := svc.CreateBulk(ctx, "test-run", checkpoints, []string{"portrait", "landscape"}, nil, false, false, false, model.ImageOutputOptions{Format: model.OutputFormatJPEG, Quality: 80})
+			Expect(err).NotTo(HaveOccurred())
+			for _, job := range result.Jobs {
+				Expect(job.OutputFormat).To(Equal(model.OutputFormatJPEG))
+				Expect(job.OutputQuality).To(Equal(80))
+			}
+		})
+
+		It("rolls back created jobs when a later job fails", func() {
+			store.maxJobs = 1
+
+			_, err := svc.CreateBulk(ctx, "test-run", checkpoints, []string{"portrait", "landscape"}, nil, false, false, false, model.ImageOutputOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(store.jobs).To(BeEmpty())
+			Expect(store.items).To(BeEmpty())
+		})
+	})
+
+	Describe("AppendNewCheckpoints", func() {
+		var (
+			runs *fakeAutoSampleRuns
+			job  model.SampleJob
+		)
+
+		BeforeEach(func() {
+			store.studies["study-1"] = model.Study{
+				ID:                    "study-1",
+				Name:                  "Test Study",
+				Prompts:               []model.NamedPrompt{{Name: "prompt1", Text: "text1"}},
+				Steps:                 []int{4},
+				CFGs:                  []float64{1.0, 3.0},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+				Seeds:                 []int64{42},
+				WorkflowTemplate:      "workflow.json",
+			}
+			runs = &fakeAutoSampleRuns{runs: []model.TrainingRun{{
+				Name: "test-run",
+				Checkpoints: []model.Checkpoint{
+					{Filename: "checkpoint1.safetensors", StepNumber: 1000},
+					{Filename: "checkpoint2.safetensors", StepNumber: 2000},
+				},
+			}}}
+			svc.SetTrainingRunSource(runs)
+			pathMatcher.paths["checkpoint1.safetensors"] = "models/checkpoint1.safetensors"
+			pathMatcher.paths["checkpoint2.safetensors"] = "models/checkpoint2.safetensors"
+
+			job = model.SampleJob{
+				ID:                   "job-1",
+				TrainingRunName:      "test-run",
+				StudyID:              "study-1",
+				CheckpointFilenames:  []string{"checkpoint1.safetensors"},
+				AppendNewCheckpoints: true,
+				Status:               model.SampleJobStatusRunning,
+				TotalItems:           2,
+				CompletedItems:       1,
+			}
+			store.jobs[job.ID] = job
+		})
+
+		It("adds items for checkpoints that are not part of the job yet", func() {
+			added, err := svc.AppendNewCheckpoints(ctx, "job-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(added).To(Equal(2))
+
+			updated := store.jobs["job-1"]
+			Expect(updated.CheckpointFilenames).To(Equal([]string{"checkpoint1.safetensors", "checkpoint2.safetensors"}))
+			Expect(updated.TotalItems).To(Equal(4))
+			Expect(updated.CompletedItems).To(Equal(1))
+			Expect(updated.Status).To(Equal(model.SampleJobStatusRunning))
+			Expect(store.items["job-1"]).To(HaveLen(2))
+			for _, item := range store.items["job-1"] {
+				Expect(item.CheckpointFilename).To(Equal("checkpoint2.safetensors"))
+				Expect(item.ComfyUIModelPath).To(Equal("models/checkpoint2.safetensors"))
+				Expect(item.Status).To(Equal(model.SampleJobItemStatusPending))
+			}
+		})
+
+		It("samples new checkpoints with the job's locked study, not the edited one", func() {
+			locked := store.studies["study-1"]
+			store.params["job-1"] = model.SampleJobParameters{JobID: "job-1", Study: locked}
+			edited := locked
+			edited.CFGs = []float64{7.0}
+			store.studies["study-1"] = edited
+
+			added, err := svc.AppendNewCheckpoints(ctx, "job-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(added).To(Equal(2))
+			var cfgs []float64
+			for _, item := range store.items["job-1"] {
+				cfgs = append(cfgs, item.CFG)
+			}
+			Expect(cfgs).To(ConsistOf(1.0, 3.0))
+		})
+
+		It("adds nothing on a second call", func() {
+			_, err := svc.AppendNewCheckpoints(ctx, "job-1")
+			Expect(err).NotTo(HaveOccurred())
+
+			added, err := svc.AppendNewCheckpoints(ctx, "job-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(added).To(BeZero())
+			Expect(store.items["job-1"]).To(HaveLen(2))
+		})
+
+		DescribeTable("requeues a finished job that gains items",
+			func(status model.SampleJobStatus) {
+				job.Status = status
+				store.jobs[job.ID] = job
+
+				added, err := svc.AppendNewCheckpoints(ctx, "job-1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(added).To(Equal(2))
+				Expect(store.jobs["job-1"].Status).To(Equal(model.SampleJobStatusPending))
+			},
+			Entry("completed", model.SampleJobStatusCompleted),
+			Entry("completed with errors", model.SampleJobStatusCompletedWithErrors),
+		)
+
+		DescribeTable("leaves the job alone",
+			func(mutate func()) {
+				mutate()
+
+				added, err := svc.AppendNewCheckpoints(ctx, "job-1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(added).To(BeZero())
+				Expect(store.items["job-1"]).To(BeEmpty())
+				Expect(store.jobs["job-1"].TotalItems).To(Equal(2))
+			},
+			Entry("in snapshot mode", func() {
+				job.AppendNewCheckpoints = false
+				store.jobs[job.ID] = job
+			}),
+			Entry("when stopped", func() {
+				job.Status = model.SampleJobStatusStopped
+				store.jobs[job.ID] = job
+			}),
+			Entry("when failed", func() {
+				job.Status = model.SampleJobStatusFailed
+				store.jobs[job.ID] = job
+			}),
+			Entry("when the training run no longer exists", func() {
+				runs.runs = nil
+			}),
+			Entry("when ComfyUI cannot see the new checkpoint yet", func() {
+				delete(pathMatcher.paths, "checkpoint2.safetensors")
+			}),
+			Entry("without a training run source", func() {
+				svc.SetTrainingRunSource(nil)
+			}),
+		)
+
+		It("returns an error when the items cannot be stored", func() {
+			store.appendItemsErr = errors.New("disk I/O error")
+
+			_, err := svc.AppendNewCheckpoints(ctx, "job-1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("appending sample job items"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("returns a job by ID", func() {
+			job := model.SampleJob{
+				ID:              "job-1",
+				TrainingRunName: "test-run",
+				Status:          model.SampleJobStatusPending,
+			}
+			store.jobs[job.ID] = job
+
+			result, err := svc.Get(ctx, "job-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ID).To(Equal("job-1"))
+		})
+
+		It("returns error when job not found", func() {
+			_, err := svc.Get(ctx, "nonexistent")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not found"))
+		})
+	})
+
+	Describe("Start", func() {
+		It("transitions pending job to running", func() {
 			job := model.SampleJob{
 				ID:     "job-1",
 				Status: model.SampleJobStatusPending,
 			}
 			store.jobs[job.ID] = job
 
-			result, err := svc.Start("job-1")
+			result, err := svc.Start(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Status).To(Equal(model.SampleJobStatusRunning))
 
 			// Verify the job was updated in the store
-			updated, err := svc.Get("job-1")
+			updated, err := svc.Get(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(updated.Status).To(Equal(model.SampleJobStatusRunning))
 		})
@@ -673,13 +1956,13 @@ var _ = Describe("SampleJobService", func() {
 			}
 			store.jobs[job.ID] = job
 
-			_, err := svc.Start("job-1")
+			_, err := svc.Start(ctx, "job-1")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("ComfyUI not connected"))
 		})
 
 		It("returns error when job not found", func() {
-			_, err := svc.Start("nonexistent")
+			_, err := svc.Start(ctx, "nonexistent")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
@@ -692,7 +1975,7 @@ var _ = Describe("SampleJobService", func() {
 			}
 			store.jobs[job.ID] = job
 
-			_, err := svc.Start("job-1")
+			_, err := svc.Start(ctx, "job-1")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cannot start job"))
 		})
@@ -709,7 +1992,7 @@ var _ = Describe("SampleJobService", func() {
 				Status: model.SampleJobStatusPending,
 			}
 
-			_, err := svc.Start("job-1")
+			_, err := svc.Start(ctx, "job-1")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("another job is already running"))
 
@@ -725,7 +2008,7 @@ var _ = Describe("SampleJobService", func() {
 				Status: model.SampleJobStatusPending,
 			}
 
-			_, err := svc.Start("job-1")
+			_, err := svc.Start(ctx, "job-1")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("checking for running jobs"))
 		})
@@ -737,7 +2020,7 @@ var _ = Describe("SampleJobService", func() {
 				Status: model.SampleJobStatusPending,
 			}
 
-			result, err := svc.Start("job-1")
+			result, err := svc.Start(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Status).To(Equal(model.SampleJobStatusRunning))
 		})
@@ -754,7 +2037,7 @@ var _ = Describe("SampleJobService", func() {
 				}
 				store.jobs[job.ID] = job
 
-				result, err := svc.Start("job-clear")
+				result, err := svc.Start(ctx, "job-clear")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result.Status).To(Equal(model.SampleJobStatusRunning))
 
@@ -767,6 +2050,22 @@ var _ = Describe("SampleJobService", func() {
 				Expect(storedJob.ClearExisting).To(BeFalse())
 			})
 
+			It("does not clear directories that contain pinned content", func() {
+				dirRemover.removed = nil
+				svc.SetPinGuard(&fakePinGuard{protected: map[string]bool{"cp1.safetensors": true}})
+				job := model.SampleJob{
+					ID:                  "job-clear-pinned",
+					Status:              model.SampleJobStatusPending,
+					ClearExisting:       true,
+					CheckpointFilenames: []string{"cp1.safetensors", "cp2.safetensors"},
+				}
+				store.jobs[job.ID] = job
+
+				_, err := svc.Start(ctx, "job-clear-pinned")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dirRemover.removed).To(ConsistOf("cp2.safetensors"))
+			})
+
 			It("does not clear directories when ClearExisting=false", func() {
 				dirRemover.removed = nil
 				job := model.SampleJob{
@@ -777,7 +2076,7 @@ var _ = Describe("SampleJobService", func() {
 				}
 				store.jobs[job.ID] = job
 
-				_, err := svc.Start("job-no-clear")
+				_, err := svc.Start(ctx, "job-no-clear")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(dirRemover.removed).To(BeEmpty())
 			})
@@ -789,15 +2088,126 @@ var _ = Describe("SampleJobService", func() {
 			store.jobs["job-1"] = model.SampleJob{ID: "job-1"}
 			store.jobs["job-2"] = model.SampleJob{ID: "job-2"}
 
-			result, err := svc.List()
+			result, total, err := svc.List(ctx, "", nil, model.Page{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(HaveLen(2))
+			Expect(total).To(Equal(2))
 		})
 
 		It("returns empty slice when no jobs exist", func() {
-			result, err := svc.List()
+			result, total, err := svc.List(ctx, "", nil, model.Page{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(HaveLen(0))
+			Expect(total).To(BeZero())
+		})
+
+		It("returns the requested page with the total of all jobs", func() {
+			for _, id := range []string{"job-1", "job-2", "job-3"} {
+				store.jobs[id] = model.SampleJob{ID: id}
+			}
+
+			result, total, err := svc.List(ctx, "", nil, model.Page{Limit: 2, Offset: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(HaveLen(1))
+			Expect(total).To(Equal(3))
+		})
+
+		It("lists a user's own and the unowned jobs by default", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1"}
+			store.jobs["job-2"] = model.SampleJob{ID: "job-2", Owner: "alice"}
+			store.jobs["job-3"] = model.SampleJob{ID: "job-3", Owner: "bob"}
+
+			result, total, err := svc.List(service.WithUser(ctx, model.User{Name: "alice"}), "", nil, model.Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(total).To(Equal(2))
+			ids := []string{result[0].ID, result[1].ID}
+			Expect(ids).To(ConsistOf("job-1", "job-2"))
+		})
+
+		It("lists every user's jobs for admin users only", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", Owner: "alice"}
+			store.jobs["job-2"] = model.SampleJob{ID: "job-2", Owner: "bob"}
+
+			_, _, err := svc.List(service.WithUser(ctx, model.User{Name: "alice"}), model.OwnerAll, nil, model.Page{})
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+
+			_, total, err := svc.List(service.WithUser(ctx, model.User{Name: "root", Admin: true}), model.OwnerAll, nil, model.Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(total).To(Equal(2))
+		})
+
+		It("lists the jobs carrying every requested label", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", Labels: map[string]string{"lr": "1e-5", "data": "mix-b"}}
+			store.jobs["job-2"] = model.SampleJob{ID: "job-2", Labels: map[string]string{"lr": "3e-5"}}
+			store.jobs["job-3"] = model.SampleJob{ID: "job-3"}
+
+			result, total, err := svc.List(ctx, "", []string{"lr"}, model.Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(total).To(Equal(2))
+			Expect(result).To(HaveLen(2))
+
+			result, total, err = svc.List(ctx, "", []string{"lr", " data = mix-b "}, model.Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(total).To(Equal(1))
+			Expect(result[0].ID).To(Equal("job-1"))
+		})
+
+		It("rejects a label filter without a key", func() {
+			_, _, err := svc.List(ctx, "", []string{"=1e-5"}, model.Page{})
+			Expect(err).To(MatchError(ContainSubstring("invalid label filter")))
+		})
+	})
+
+	Describe("Annotate", func() {
+		It("replaces the notes and labels of a job", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", Status: model.SampleJobStatusRunning, Notes: "old", Labels: map[string]string{"stale": "1"}, Version: 3}
+
+			job, err := svc.Annotate(ctx, "job-1", model.SampleJobAnnotations{Notes: "bad data mix", Labels: map[string]string{"lr": "1e-5"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Notes).To(Equal("bad data mix"))
+			Expect(job.Labels).To(Equal(map[string]string{"lr": "1e-5"}))
+			Expect(job.Status).To(Equal(model.SampleJobStatusRunning))
+			Expect(job.Version).To(Equal(4))
+			Expect(store.jobs["job-1"].Labels).To(Equal(map[string]string{"lr": "1e-5"}))
+		})
+
+		It("clears the annotations when none are given", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", Notes: "old", Labels: map[string]string{"lr": "1e-5"}}
+
+			job, err := svc.Annotate(ctx, "job-1", model.SampleJobAnnotations{Labels: map[string]string{}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Notes).To(BeEmpty())
+			Expect(job.Labels).To(BeNil())
+		})
+
+		It("retries after a concurrent update of the job", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1"}
+			store.updateJobConflicts = 1
+
+			job, err := svc.Annotate(ctx, "job-1", model.SampleJobAnnotations{Notes: "n"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Notes).To(Equal("n"))
+			Expect(store.updateJobConflicts).To(BeZero())
+		})
+
+		It("returns a not-found error for an unknown job", func() {
+			_, err := svc.Annotate(ctx, "missing", model.SampleJobAnnotations{Notes: "n"})
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+
+		It("refuses to annotate another user's job", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", Owner: "bob"}
+
+			_, err := svc.Annotate(service.WithUser(ctx, model.User{Name: "alice"}), "job-1", model.SampleJobAnnotations{Notes: "n"})
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+			Expect(store.jobs["job-1"].Notes).To(BeEmpty())
+		})
+
+		It("rejects overlong notes", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1"}
+
+			_, err := svc.Annotate(ctx, "job-1", model.SampleJobAnnotations{Notes: strings.Repeat("n", 10001)})
+			Expect(err).To(MatchError(ContainSubstring("invalid notes")))
 		})
 	})
 
@@ -809,7 +2219,7 @@ var _ = Describe("SampleJobService", func() {
 			}
 			store.jobs[job.ID] = job
 
-			result, err := svc.Stop("job-1")
+			result, err := svc.Stop(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Status).To(Equal(model.SampleJobStatusStopped))
 		})
@@ -821,16 +2231,27 @@ var _ = Describe("SampleJobService", func() {
 			}
 			store.jobs[job.ID] = job
 
-			_, err := svc.Stop("job-1")
+			_, err := svc.Stop(ctx, "job-1")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cannot stop job"))
 		})
 
 		It("returns error when job not found", func() {
-			_, err := svc.Stop("nonexistent")
+			_, err := svc.Stop(ctx, "nonexistent")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
+
+		It("forbids stopping another user's job", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", Status: model.SampleJobStatusRunning, Owner: "bob"}
+
+			_, err := svc.Stop(service.WithUser(ctx, model.User{Name: "alice"}), "job-1")
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+			Expect(store.jobs["job-1"].Status).To(Equal(model.SampleJobStatusRunning))
+
+			_, err = svc.Stop(service.WithUser(ctx, model.User{Name: "bob"}), "job-1")
+			Expect(err).NotTo(HaveOccurred())
+		})
 	})
 
 	// AC4: BE: Unit tests for stop+restart state transitions
@@ -843,13 +2264,13 @@ var _ = Describe("SampleJobService", func() {
 			store.jobs[job.ID] = job
 
 			// Stop the job (executor simulates the DB update to stopped)
-			result, err := svc.Stop("job-1")
+			result, err := svc.Stop(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Status).To(Equal(model.SampleJobStatusStopped))
 			Expect(executor.stopCalled).To(BeTrue())
 
 			// Resume the stopped job
-			resumed, err := svc.Resume("job-1")
+			resumed, err := svc.Resume(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(resumed.Status).To(Equal(model.SampleJobStatusRunning))
 			Expect(executor.resumeCalled).To(BeTrue())
@@ -866,7 +2287,7 @@ var _ = Describe("SampleJobService", func() {
 			executor.stopErr = fmt.Errorf("job job-1 is not currently running")
 
 			// Stop should still succeed via fallback DB update
-			result, err := svc.Stop("job-1")
+			result, err := svc.Stop(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Status).To(Equal(model.SampleJobStatusStopped))
 			Expect(executor.stopCalled).To(BeTrue())
@@ -876,7 +2297,7 @@ var _ = Describe("SampleJobService", func() {
 			Expect(stored.Status).To(Equal(model.SampleJobStatusStopped))
 
 			// Resume should work because the job is now properly stopped in the DB
-			resumed, err := svc.Resume("job-1")
+			resumed, err := svc.Resume(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(resumed.Status).To(Equal(model.SampleJobStatusRunning))
 		})
@@ -891,7 +2312,7 @@ var _ = Describe("SampleJobService", func() {
 			// Executor rejects because it doesn't think the job is active
 			executor.stopErr = fmt.Errorf("job job-1 is not currently running")
 
-			result, err := svc.Stop("job-1")
+			result, err := svc.Stop(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Status).To(Equal(model.SampleJobStatusStopped))
 
@@ -909,7 +2330,7 @@ var _ = Describe("SampleJobService", func() {
 			}
 			store.jobs[job.ID] = job
 
-			result, err := svc.Resume("job-1")
+			result, err := svc.Resume(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Status).To(Equal(model.SampleJobStatusRunning))
 		})
@@ -921,13 +2342,13 @@ var _ = Describe("SampleJobService", func() {
 			}
 			store.jobs[job.ID] = job
 
-			_, err := svc.Resume("job-1")
+			_, err := svc.Resume(ctx, "job-1")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cannot resume job"))
 		})
 
 		It("returns error when job not found", func() {
-			_, err := svc.Resume("nonexistent")
+			_, err := svc.Resume(ctx, "nonexistent")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
@@ -944,7 +2365,7 @@ var _ = Describe("SampleJobService", func() {
 			}
 			store.jobs[job.ID] = job
 
-			_, err := svc.Resume("job-resume")
+			_, err := svc.Resume(ctx, "job-resume")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(dirRemover.removed).To(BeEmpty())
 		})
@@ -960,16 +2381,16 @@ var _ = Describe("SampleJobService", func() {
 			store.items[job.ID] = []model.SampleJobItem{
 				{ID: "i1", JobID: job.ID, Status: model.SampleJobItemStatusCompleted},
 				{ID: "i2", JobID: job.ID, Status: model.SampleJobItemStatusFailed, ErrorMessage: "VRAM error", ExceptionType: "RuntimeError"},
-				{ID: "i3", JobID: job.ID, Status: model.SampleJobItemStatusSkipped, ErrorMessage: "checkpoint not found in ComfyUI"},
+				{ID: "i3", JobID: job.ID, Status: model.SampleJobItemStatusSkipped, SkipReason: model.SampleJobItemSkipReasonCheckpointNotFound, ErrorMessage: "checkpoint not found in ComfyUI"},
 				{ID: "i4", JobID: job.ID, Status: model.SampleJobItemStatusCompleted},
 			}
 
-			result, err := svc.RetryFailed("job-1")
+			result, err := svc.RetryFailed(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Status).To(Equal(model.SampleJobStatusRunning))
 
 			// Verify the job status was updated in the store
-			updated, err := svc.Get("job-1")
+			updated, err := svc.Get(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(updated.Status).To(Equal(model.SampleJobStatusRunning))
 
@@ -980,6 +2401,7 @@ var _ = Describe("SampleJobService", func() {
 			Expect(items[1].ErrorMessage).To(BeEmpty())
 			Expect(items[1].ExceptionType).To(BeEmpty())
 			Expect(items[2].Status).To(Equal(model.SampleJobItemStatusPending))   // was skipped
+			Expect(items[2].SkipReason).To(BeEmpty())
 			Expect(items[2].ErrorMessage).To(BeEmpty())
 			Expect(items[3].Status).To(Equal(model.SampleJobItemStatusCompleted)) // unchanged
 		})
@@ -994,7 +2416,7 @@ var _ = Describe("SampleJobService", func() {
 				{ID: "i1", JobID: job.ID, Status: model.SampleJobItemStatusFailed},
 			}
 
-			_, err := svc.RetryFailed("job-1")
+			_, err := svc.RetryFailed(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(executor.resumeCalled).To(BeTrue())
 		})
@@ -1006,7 +2428,7 @@ var _ = Describe("SampleJobService", func() {
 					Status: status,
 				}
 
-				_, err := svc.RetryFailed("job-1")
+				_, err := svc.RetryFailed(ctx, "job-1")
 				Expect(err).To(HaveOccurred(), "expected error for status %s", status)
 				Expect(err.Error()).To(ContainSubstring("cannot retry job"), "unexpected error for status %s", status)
 			},
@@ -1024,13 +2446,13 @@ var _ = Describe("SampleJobService", func() {
 				Status: model.SampleJobStatusRunning,
 			}
 
-			_, err := svc.RetryFailed("job-1")
+			_, err := svc.RetryFailed(ctx, "job-1")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("another job is already running"))
 		})
 
 		It("returns error when job not found", func() {
-			_, err := svc.RetryFailed("nonexistent")
+			_, err := svc.RetryFailed(ctx, "nonexistent")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
@@ -1043,7 +2465,7 @@ var _ = Describe("SampleJobService", func() {
 			}
 			store.jobs[job.ID] = job
 
-			_, err := svc.RetryFailed("job-1")
+			_, err := svc.RetryFailed(ctx, "job-1")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("ComfyUI not connected"))
 		})
@@ -1058,7 +2480,7 @@ var _ = Describe("SampleJobService", func() {
 				Status: model.SampleJobStatusCompletedWithErrors,
 			}
 
-			_, err := svc.RetryFailed("job-1")
+			_, err := svc.RetryFailed(ctx, "job-1")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("another job is already running"))
 		})
@@ -1074,7 +2496,7 @@ var _ = Describe("SampleJobService", func() {
 				{ID: "i2", JobID: job.ID, Status: model.SampleJobItemStatusFailed},
 			}
 
-			_, err := svc.RetryFailed("job-1")
+			_, err := svc.RetryFailed(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 
 			// Completed item should be unchanged
@@ -1085,8 +2507,19 @@ var _ = Describe("SampleJobService", func() {
 	})
 
 	Describe("Delete", func() {
-		// AC3: BE: Deleting a job without the data flag removes only the database record
-		It("deletes a job without removing sample data when deleteData=false", func() {
+		It("forbids deleting another user's job unless the user is an admin", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", StudyName: "My Study", Owner: "bob"}
+
+			Expect(svc.Delete(service.WithUser(ctx, model.User{Name: "alice"}), "job-1", false)).To(MatchError(HavePrefix("forbidden:")))
+			Expect(svc.Delete(ctx, "job-1", false)).To(MatchError(HavePrefix("forbidden:")))
+			Expect(store.jobs).To(HaveKey("job-1"))
+
+			Expect(svc.Delete(service.WithUser(ctx, model.User{Name: "root", Admin: true}), "job-1", false)).To(Succeed())
+			Expect(store.jobs).NotTo(HaveKey("job-1"))
+			Expect(store.trash).To(HaveKey("job-1"))
+		})
+
+		It("moves a job to the trash without removing sample data", func() {
 			jobDataRemover := &fakeJobSampleDataRemover{}
 			svc.SetJobDataRemover(jobDataRemover)
 
@@ -1096,29 +2529,110 @@ var _ = Describe("SampleJobService", func() {
 				{ID: "i1", JobID: job.ID, CheckpointFilename: "checkpoint1.safetensors", Status: model.SampleJobItemStatusCompleted},
 			}
 
-			err := svc.Delete("job-1", false)
-			Expect(err).NotTo(HaveOccurred())
+			Expect(svc.Delete(ctx, "job-1", true)).To(Succeed())
 			Expect(store.jobs).NotTo(HaveKey("job-1"))
-			// No filesystem removal should have occurred
+			Expect(store.trash["job-1"].DeletedAt).NotTo(BeNil())
+			Expect(store.trash["job-1"].DeleteData).To(BeTrue())
+			Expect(store.items).To(HaveKey("job-1"))
 			Expect(jobDataRemover.removed).To(BeEmpty())
 		})
 
-		// AC4: BE: Deleting a job with the data flag also removes generated sample files
-		It("deletes a job and removes sample data when deleteData=true", func() {
-			jobDataRemover := &fakeJobSampleDataRemover{}
+		It("stops a running job before moving it to the trash", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1", Status: model.SampleJobStatusRunning}
+
+			Expect(svc.Delete(ctx, "job-1", false)).To(Succeed())
+			Expect(executor.stopCalled).To(BeTrue())
+			Expect(store.trash["job-1"].Status).To(Equal(model.SampleJobStatusStopped))
+		})
+
+		It("returns error when job not found", func() {
+			err := svc.Delete(ctx, "nonexistent", false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not found"))
+		})
+	})
+
+	Describe("trash", func() {
+		var jobDataRemover *fakeJobSampleDataRemover
+
+		BeforeEach(func() {
+			jobDataRemover = &fakeJobSampleDataRemover{}
 			svc.SetJobDataRemover(jobDataRemover)
+		})
 
-			job := model.SampleJob{ID: "job-1", StudyName: "My Study"}
+		trash := func(job model.SampleJob, deleteData bool) {
 			store.jobs[job.ID] = job
-			store.items[job.ID] = []model.SampleJobItem{
-				{ID: "i1", JobID: job.ID, CheckpointFilename: "checkpoint1.safetensors", Status: model.SampleJobItemStatusCompleted},
-				{ID: "i2", JobID: job.ID, CheckpointFilename: "checkpoint1.safetensors", Status: model.SampleJobItemStatusCompleted},
-				{ID: "i3", JobID: job.ID, CheckpointFilename: "checkpoint2.safetensors", Status: model.SampleJobItemStatusCompleted},
-			}
+			Expect(svc.Delete(service.WithUser(ctx, model.User{Name: "root", Admin: true}), job.ID, deleteData)).To(Succeed())
+		}
+
+		It("lists the trashed jobs in the requested owner's scope", func() {
+			trash(model.SampleJob{ID: "job-1"}, false)
+			trash(model.SampleJob{ID: "job-2", Owner: "bob"}, false)
+			store.jobs["job-3"] = model.SampleJob{ID: "job-3"}
 
-			err := svc.Delete("job-1", true)
+			jobs, err := svc.ListTrash(ctx, "")
 			Expect(err).NotTo(HaveOccurred())
-			Expect(store.jobs).NotTo(HaveKey("job-1"))
+			Expect(jobs).To(HaveLen(2))
+
+			jobs, err = svc.ListTrash(ctx, "bob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobs).To(HaveLen(1))
+			Expect(jobs[0].ID).To(Equal("job-2"))
+
+			_, err = svc.ListTrash(service.WithUser(ctx, model.User{Name: "alice"}), "bob")
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+		})
+
+		It("restores a trashed job", func() {
+			trash(model.SampleJob{ID: "job-1", Status: model.SampleJobStatusCompleted}, true)
+
+			job, err := svc.Restore(ctx, "job-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.ID).To(Equal("job-1"))
+			Expect(job.DeletedAt).To(BeNil())
+			Expect(store.jobs).To(HaveKey("job-1"))
+			Expect(store.trash).To(BeEmpty())
+		})
+
+		It("restores and purges only jobs in the trash", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1"}
+
+			_, err := svc.Restore(ctx, "job-1")
+			Expect(err).To(MatchError(ContainSubstring("not found in the trash")))
+			Expect(svc.Purge(ctx, "job-1")).To(MatchError(ContainSubstring("not found in the trash")))
+			Expect(store.jobs).To(HaveKey("job-1"))
+		})
+
+		It("forbids restoring or purging another user's job", func() {
+			trash(model.SampleJob{ID: "job-1", Owner: "bob"}, false)
+			alice := service.WithUser(ctx, model.User{Name: "alice"})
+
+			_, err := svc.Restore(alice, "job-1")
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+			Expect(svc.Purge(alice, "job-1")).To(MatchError(HavePrefix("forbidden:")))
+			Expect(store.trash).To(HaveKey("job-1"))
+		})
+
+		It("purges a job without removing sample data when it was deleted without data", func() {
+			store.items["job-1"] = []model.SampleJobItem{{ID: "i1", JobID: "job-1", CheckpointFilename: "checkpoint1.safetensors"}}
+			trash(model.SampleJob{ID: "job-1", StudyName: "My Study"}, false)
+
+			Expect(svc.Purge(ctx, "job-1")).To(Succeed())
+			Expect(store.trash).To(BeEmpty())
+			Expect(store.items).NotTo(HaveKey("job-1"))
+			Expect(jobDataRemover.removed).To(BeEmpty())
+		})
+
+		It("purges a job and removes sample data when it was deleted with data", func() {
+			store.items["job-1"] = []model.SampleJobItem{
+				{ID: "i1", JobID: "job-1", CheckpointFilename: "checkpoint1.safetensors", Status: model.SampleJobItemStatusCompleted},
+				{ID: "i2", JobID: "job-1", CheckpointFilename: "checkpoint1.safetensors", Status: model.SampleJobItemStatusCompleted},
+				{ID: "i3", JobID: "job-1", CheckpointFilename: "checkpoint2.safetensors", Status: model.SampleJobItemStatusCompleted},
+			}
+			trash(model.SampleJob{ID: "job-1", StudyName: "My Study"}, true)
+
+			Expect(svc.Purge(ctx, "job-1")).To(Succeed())
+			Expect(store.trash).To(BeEmpty())
 			// Each unique checkpoint should have been removed once
 			Expect(jobDataRemover.removed).To(HaveLen(2))
 			removedCheckpoints := []string{}
@@ -1129,41 +2643,237 @@ var _ = Describe("SampleJobService", func() {
 			Expect(removedCheckpoints).To(ConsistOf("checkpoint1.safetensors", "checkpoint2.safetensors"))
 		})
 
-		It("does not call remover when deleteData=true but no remover is set", func() {
-			svc.SetJobDataRemover(nil) // explicitly nil
+		It("skips checkpoint directories that contain pinned content", func() {
+			svc.SetPinGuard(&fakePinGuard{protected: map[string]bool{"My Study/checkpoint1.safetensors": true}})
+			store.items["job-1"] = []model.SampleJobItem{
+				{ID: "i1", JobID: "job-1", CheckpointFilename: "checkpoint1.safetensors"},
+				{ID: "i2", JobID: "job-1", CheckpointFilename: "checkpoint2.safetensors"},
+			}
+			trash(model.SampleJob{ID: "job-1", StudyName: "My Study"}, true)
+
+			Expect(svc.Purge(ctx, "job-1")).To(Succeed())
+			Expect(jobDataRemover.removed).To(HaveLen(1))
+			Expect(jobDataRemover.removed[0].checkpointFilename).To(Equal("checkpoint2.safetensors"))
+		})
+
+		It("purges a job deleted with data when no remover is set", func() {
+			svc.SetJobDataRemover(nil)
+			store.items["job-1"] = []model.SampleJobItem{{ID: "i1", JobID: "job-1", CheckpointFilename: "checkpoint1.safetensors"}}
+			trash(model.SampleJob{ID: "job-1", StudyName: "My Study"}, true)
 
-			job := model.SampleJob{ID: "job-1", StudyName: "My Study"}
+			Expect(svc.Purge(ctx, "job-1")).To(Succeed())
+			Expect(store.trash).To(BeEmpty())
+		})
+
+		It("keeps the job in the trash when sample data removal fails", func() {
+			jobDataRemover.err = errors.New("disk error")
+			store.items["job-1"] = []model.SampleJobItem{{ID: "i1", JobID: "job-1", CheckpointFilename: "checkpoint1.safetensors"}}
+			trash(model.SampleJob{ID: "job-1", StudyName: "My Study"}, true)
+
+			err := svc.Purge(ctx, "job-1")
+			Expect(err).To(MatchError(ContainSubstring("removing job sample directory")))
+			// DB record should NOT have been deleted (filesystem cleanup runs first)
+			Expect(store.trash).To(HaveKey("job-1"))
+		})
+
+		It("empties the trash", func() {
+			trash(model.SampleJob{ID: "job-1"}, false)
+			trash(model.SampleJob{ID: "job-2"}, false)
+			store.jobs["job-3"] = model.SampleJob{ID: "job-3"}
+
+			purged, err := svc.EmptyTrash(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(purged).To(Equal(2))
+			Expect(store.trash).To(BeEmpty())
+			Expect(store.jobs).To(HaveKey("job-3"))
+		})
+
+		It("purges only jobs in the trash for longer than the retention", func() {
+			old := time.Now().Add(-31 * 24 * time.Hour)
+			recent := time.Now().Add(-time.Hour)
+			store.trash["job-old"] = model.SampleJob{ID: "job-old", DeletedAt: &old}
+			store.trash["job-recent"] = model.SampleJob{ID: "job-recent", DeletedAt: &recent}
+
+			purged, err := svc.PurgeExpired(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(purged).To(BeZero(), "no retention set")
+
+			svc.SetTrashRetention(30 * 24 * time.Hour)
+			purged, err = svc.PurgeExpired(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(purged).To(Equal(1))
+			Expect(store.trash).To(HaveKey("job-recent"))
+			Expect(store.trash).NotTo(HaveKey("job-old"))
+		})
+	})
+
+	Describe("ListItems", func() {
+		It("returns all items for a job including timing fields", func() {
+			startedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+			completedAt := startedAt.Add(3 * time.Second)
+			durationMs := int64(3000)
+			job := model.SampleJob{ID: "job-items", TotalItems: 2}
 			store.jobs[job.ID] = job
 			store.items[job.ID] = []model.SampleJobItem{
-				{ID: "i1", JobID: job.ID, CheckpointFilename: "checkpoint1.safetensors"},
+				{ID: "i1", JobID: job.ID, Status: model.SampleJobItemStatusCompleted, StartedAt: &startedAt, CompletedAt: &completedAt, DurationMs: &durationMs},
+				{ID: "i2", JobID: job.ID, Status: model.SampleJobItemStatusPending},
 			}
 
-			err := svc.Delete("job-1", true)
+			items, total, err := svc.ListItems(ctx, "job-items", model.SampleJobItemQuery{}, model.Page{})
 			Expect(err).NotTo(HaveOccurred())
-			Expect(store.jobs).NotTo(HaveKey("job-1"))
+			Expect(items).To(HaveLen(2))
+			Expect(total).To(Equal(2))
+			Expect(items[0].DurationMs).NotTo(BeNil())
+			Expect(*items[0].DurationMs).To(Equal(int64(3000)))
+			Expect(items[1].StartedAt).To(BeNil())
+		})
+
+		It("returns an empty slice for a job with no items", func() {
+			store.jobs["job-empty"] = model.SampleJob{ID: "job-empty"}
+
+			items, _, err := svc.ListItems(ctx, "job-empty", model.SampleJobItemQuery{}, model.Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(items).NotTo(BeNil())
+			Expect(items).To(BeEmpty())
 		})
 
 		It("returns error when job not found", func() {
-			err := svc.Delete("nonexistent", false)
+			_, _, err := svc.ListItems(ctx, "nonexistent", model.SampleJobItemQuery{}, model.Page{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
 
-		It("returns error when sample data removal fails", func() {
-			jobDataRemover := &fakeJobSampleDataRemover{err: errors.New("disk error")}
-			svc.SetJobDataRemover(jobDataRemover)
+		It("returns error when list items fails", func() {
+			store.jobs["job-1"] = model.SampleJob{ID: "job-1"}
+			store.listItemsErr = errors.New("db error")
+			_, _, err := svc.ListItems(ctx, "job-1", model.SampleJobItemQuery{}, model.Page{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("listing sample job items"))
+		})
 
-			job := model.SampleJob{ID: "job-1", StudyName: "My Study"}
-			store.jobs[job.ID] = job
-			store.items[job.ID] = []model.SampleJobItem{
-				{ID: "i1", JobID: job.ID, CheckpointFilename: "checkpoint1.safetensors"},
+		It("returns the requested page with the total of the job's items", func() {
+			store.jobs["job-paged"] = model.SampleJob{ID: "job-paged"}
+			for i := 0; i < 5; i++ {
+				store.items["job-paged"] = append(store.items["job-paged"], model.SampleJobItem{ID: fmt.Sprintf("i%d", i), JobID: "job-paged"})
 			}
 
-			err := svc.Delete("job-1", true)
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("removing job sample directory"))
-			// DB record should NOT have been deleted (filesystem cleanup runs first)
-			Expect(store.jobs).To(HaveKey("job-1"))
+			items, total, err := svc.ListItems(ctx, "job-paged", model.SampleJobItemQuery{}, model.Page{Limit: 2, Offset: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(total).To(Equal(5))
+			Expect(items).To(HaveLen(2))
+			Expect(items[0].ID).To(Equal("i1"))
+			Expect(items[1].ID).To(Equal("i2"))
+		})
+
+		It("passes the filter and sort to the store and counts only matching items", func() {
+			store.jobs["job-q"] = model.SampleJob{ID: "job-q"}
+			store.items["job-q"] = []model.SampleJobItem{
+				{ID: "i1", JobID: "job-q", Status: model.SampleJobItemStatusFailed},
+				{ID: "i2", JobID: "job-q", Status: model.SampleJobItemStatusCompleted},
+				{ID: "i3", JobID: "job-q", Status: model.SampleJobItemStatusFailed},
+			}
+			query := model.SampleJobItemQuery{
+				Filter:     model.SampleJobItemFilter{Status: model.SampleJobItemStatusFailed, SamplerName: "euler"},
+				Sort:       model.SampleJobItemSortDuration,
+				Descending: true,
+			}
+
+			items, total, err := svc.ListItems(ctx, "job-q", query, model.Page{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(total).To(Equal(2))
+			Expect(items).To(HaveLen(2))
+			Expect(store.lastItemQuery).To(Equal(query))
+			Expect(store.lastCountFilter).To(Equal(query.Filter))
+		})
+
+		DescribeTable("rejects sorting by an invalid score name",
+			func(name string) {
+				store.jobs["job-q"] = model.SampleJob{ID: "job-q"}
+				query := model.SampleJobItemQuery{Sort: model.SampleJobItemSortScore, ScoreName: name}
+				_, _, err := svc.ListItems(ctx, "job-q", query, model.Page{})
+				Expect(err).To(MatchError(ContainSubstring("invalid score name")))
+			},
+			Entry("missing", ""),
+			Entry("JSON path characters", "aesthetic.value"),
+			Entry("quote", `a"b`),
+		)
+	})
+
+	Describe("Compare", func() {
+		item := func(id, jobID, prompt string, seed int64) model.SampleJobItem {
+			return model.SampleJobItem{ID: id, JobID: jobID, PromptText: prompt, Seed: seed, Steps: 20, CFG: 7, SamplerName: "euler", Scheduler: "simple"}
+		}
+
+		BeforeEach(func() {
+			store.jobs["job-a"] = model.SampleJob{ID: "job-a"}
+			store.jobs["job-b"] = model.SampleJob{ID: "job-b"}
+		})
+
+		It("pairs items with the same parameters and returns the rest unmatched", func() {
+			store.items["job-a"] = []model.SampleJobItem{
+				item("a1", "job-a", "a forest", 1),
+				item("a2", "job-a", "a forest", 2),
+				item("a3", "job-a", "a city", 1),
+			}
+			cfg9 := item("b3", "job-b", "a city", 1)
+			cfg9.CFG = 9
+			store.items["job-b"] = []model.SampleJobItem{
+				item("b1", "job-b", "a forest", 2),
+				item("b2", "job-b", "a forest", 1),
+				cfg9,
+			}
+
+			cmp, err := svc.Compare(ctx, "job-a", "job-b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmp.A.ID).To(Equal("job-a"))
+			Expect(cmp.B.ID).To(Equal("job-b"))
+			Expect(cmp.Pairs).To(HaveLen(2))
+			Expect(cmp.Pairs[0].A.ID).To(Equal("a1"))
+			Expect(cmp.Pairs[0].B.ID).To(Equal("b2"))
+			Expect(cmp.Pairs[1].A.ID).To(Equal("a2"))
+			Expect(cmp.Pairs[1].B.ID).To(Equal("b1"))
+			Expect(cmp.UnmatchedA).To(ConsistOf(HaveField("ID", "a3")))
+			Expect(cmp.UnmatchedB).To(ConsistOf(HaveField("ID", "b3")))
+		})
+
+		It("pairs items sharing parameters in item order", func() {
+			store.items["job-a"] = []model.SampleJobItem{
+				item("a1", "job-a", "a forest", 1),
+				item("a2", "job-a", "a forest", 1),
+			}
+			store.items["job-b"] = []model.SampleJobItem{
+				item("b1", "job-b", "a forest", 1),
+				item("b2", "job-b", "a forest", 1),
+				item("b3", "job-b", "a forest", 1),
+			}
+
+			cmp, err := svc.Compare(ctx, "job-a", "job-b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmp.Pairs).To(HaveLen(2))
+			Expect(cmp.Pairs[0].B.ID).To(Equal("b1"))
+			Expect(cmp.Pairs[1].B.ID).To(Equal("b2"))
+			Expect(cmp.UnmatchedA).To(BeEmpty())
+			Expect(cmp.UnmatchedB).To(ConsistOf(HaveField("ID", "b3")))
+		})
+
+		It("returns a not-found error for an unknown job", func() {
+			_, err := svc.Compare(ctx, "job-a", "missing")
+			Expect(err).To(MatchError("sample job missing not found"))
+		})
+
+		DescribeTable("ImagePath returns the image path relative to the sample directory",
+			func(outputPath, expected string) {
+				Expect(svc.ImagePath(model.SampleJobItem{OutputPath: outputPath})).To(Equal(expected))
+			},
+			Entry("image in the sample directory", "/samples/Sweep/ckpt.safetensors/a.png", "Sweep/ckpt.safetensors/a.png"),
+			Entry("no image yet", "", ""),
+			Entry("image outside the sample directory", "/elsewhere/a.png", ""),
+		)
+
+		It("returns the error of the store", func() {
+			store.listItemsErr = errors.New("db error")
+			_, err := svc.Compare(ctx, "job-a", "job-b")
+			Expect(err).To(MatchError(ContainSubstring("listing items of sample job job-a")))
 		})
 	})
 
@@ -1180,7 +2890,7 @@ var _ = Describe("SampleJobService", func() {
 				{ID: "i6", JobID: job.ID, Status: model.SampleJobItemStatusRunning}, // running items are not counted in any bucket
 			}
 
-			counts, err := svc.GetItemCounts("job-counts")
+			counts, err := svc.GetItemCounts(ctx, "job-counts")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(counts.Completed).To(Equal(2))
 			Expect(counts.Failed).To(Equal(1))
@@ -1195,22 +2905,23 @@ var _ = Describe("SampleJobService", func() {
 			store.items[job.ID] = []model.SampleJobItem{
 				{ID: "i1", JobID: job.ID, Status: model.SampleJobItemStatusCompleted},
 				{ID: "i2", JobID: job.ID, Status: model.SampleJobItemStatusCompleted},
-				{ID: "i3", JobID: job.ID, Status: model.SampleJobItemStatusSkipped},
+				{ID: "i3", JobID: job.ID, Status: model.SampleJobItemStatusSkipped, SkipReason: model.SampleJobItemSkipReasonDuplicate},
 				{ID: "i4", JobID: job.ID, Status: model.SampleJobItemStatusFailed},
 			}
 
-			counts, err := svc.GetItemCounts("job-skipped")
+			counts, err := svc.GetItemCounts(ctx, "job-skipped")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(counts.Completed).To(Equal(2))
 			Expect(counts.Failed).To(Equal(2)) // both failed and skipped counted as failed
 			Expect(counts.Pending).To(Equal(0))
+			Expect(counts.Skipped).To(Equal(map[model.SampleJobItemSkipReason]int{model.SampleJobItemSkipReasonDuplicate: 1}))
 		})
 
 		It("returns zero counts for a job with no items", func() {
 			job := model.SampleJob{ID: "job-empty", TotalItems: 0}
 			store.jobs[job.ID] = job
 
-			counts, err := svc.GetItemCounts("job-empty")
+			counts, err := svc.GetItemCounts(ctx, "job-empty")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(counts.Completed).To(Equal(0))
 			Expect(counts.Failed).To(Equal(0))
@@ -1219,7 +2930,7 @@ var _ = Describe("SampleJobService", func() {
 
 		It("returns error when list items fails", func() {
 			store.listItemsErr = errors.New("db error")
-			_, err := svc.GetItemCounts("any-id")
+			_, err := svc.GetItemCounts(ctx, "any-id")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("listing sample job items"))
 		})
@@ -1257,7 +2968,7 @@ var _ = Describe("SampleJobService", func() {
 				})
 			}
 
-			progress, err := svc.GetProgress("job-1")
+			progress, err := svc.GetProgress(ctx, "job-1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(progress.TotalCheckpoints).To(Equal(2))
 			Expect(progress.CheckpointsCompleted).To(Equal(1))
@@ -1267,7 +2978,7 @@ var _ = Describe("SampleJobService", func() {
 		})
 
 		It("returns error when job not found", func() {
-			_, err := svc.GetProgress("nonexistent")
+			_, err := svc.GetProgress(ctx, "nonexistent")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
@@ -1282,7 +2993,7 @@ var _ = Describe("SampleJobService", func() {
 				{ID: "i4", JobID: job.ID, CheckpointFilename: "chk2.safetensors", Status: model.SampleJobItemStatusPending},
 			}
 
-			progress, err := svc.GetProgress("job-counts")
+			progress, err := svc.GetProgress(ctx, "job-counts")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(progress.ItemCounts.Completed).To(Equal(1))
 			Expect(progress.ItemCounts.Failed).To(Equal(1))
@@ -1299,7 +3010,7 @@ var _ = Describe("SampleJobService", func() {
 				{ID: "i4", JobID: job.ID, CheckpointFilename: "chk-c.safetensors", Status: model.SampleJobItemStatusCompleted},
 			}
 
-			progress, err := svc.GetProgress("job-details")
+			progress, err := svc.GetProgress(ctx, "job-details")
 			Expect(err).NotTo(HaveOccurred())
 
 			// Should have 2 failed item details (chk-a with VRAM overflow, chk-b with timeout)
@@ -1327,7 +3038,7 @@ var _ = Describe("SampleJobService", func() {
 				{ID: "i2", JobID: job.ID, CheckpointFilename: "chk1.safetensors", Status: model.SampleJobItemStatusCompleted},
 			}
 
-			progress, err := svc.GetProgress("job-no-fail")
+			progress, err := svc.GetProgress(ctx, "job-no-fail")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(progress.FailedItemDetails).To(BeEmpty())
 			Expect(progress.FailedItemDetails).NotTo(BeNil())
@@ -1340,7 +3051,7 @@ var _ = Describe("SampleJobService", func() {
 				{ID: "i1", JobID: job.ID, CheckpointFilename: "chk1.safetensors", Status: model.SampleJobItemStatusFailed, ErrorMessage: ""},
 			}
 
-			progress, err := svc.GetProgress("job-no-msg")
+			progress, err := svc.GetProgress(ctx, "job-no-msg")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(progress.FailedItemDetails).To(HaveLen(1))
 			Expect(progress.FailedItemDetails[0].CheckpointFilename).To(Equal("chk1.safetensors"))
@@ -1353,15 +3064,16 @@ var _ = Describe("SampleJobService", func() {
 			store.jobs[job.ID] = job
 			store.items[job.ID] = []model.SampleJobItem{
 				{ID: "i1", JobID: job.ID, CheckpointFilename: "chk1.safetensors", Status: model.SampleJobItemStatusCompleted},
-				{ID: "i2", JobID: job.ID, CheckpointFilename: "chk2.safetensors", Status: model.SampleJobItemStatusSkipped, ErrorMessage: "checkpoint not found in ComfyUI"},
+				{ID: "i2", JobID: job.ID, CheckpointFilename: "chk2.safetensors", Status: model.SampleJobItemStatusSkipped, SkipReason: model.SampleJobItemSkipReasonCheckpointNotFound, ErrorMessage: "checkpoint not found in ComfyUI"},
 				{ID: "i3", JobID: job.ID, CheckpointFilename: "chk2.safetensors", Status: model.SampleJobItemStatusCompleted},
 			}
 
-			progress, err := svc.GetProgress("job-skip-progress")
+			progress, err := svc.GetProgress(ctx, "job-skip-progress")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(progress.ItemCounts.Completed).To(Equal(2))
 			Expect(progress.ItemCounts.Failed).To(Equal(1)) // skipped counted as failed
 			Expect(progress.ItemCounts.Pending).To(Equal(0))
+			Expect(progress.ItemCounts.Skipped).To(Equal(map[model.SampleJobItemSkipReason]int{model.SampleJobItemSkipReasonCheckpointNotFound: 1}))
 
 			// Skipped items with error messages should appear in failed item details
 			Expect(progress.FailedItemDetails).To(HaveLen(1))
@@ -1,14 +1,17 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/sirupsen/logrus"
 )
@@ -18,18 +21,39 @@ var batchPattern = regexp.MustCompile(`_(\d+)_$`)
 
 // ScannerFileSystem defines the operations the scanner needs from the filesystem.
 type ScannerFileSystem interface {
-	ListPNGFiles(dir string) ([]string, error)
+	ListImageFiles(dir string) ([]string, error)
 	DirectoryExists(path string) bool
 	FileExists(path string) bool
 }
 
+// SidecarScanFileSystem defines the operations the scanner needs to find the
+// images of an output layout, which are saved in subdirectories of the
+// checkpoint directories and named without their dimensions.
+type SidecarScanFileSystem interface {
+	RecursiveImageLister
+	ReadFile(path string) ([]byte, error)
+}
+
+// RecursiveImageLister lists the sample images below a directory, with
+// slash-separated paths relative to it.
+type RecursiveImageLister interface {
+	ListImageFilesRecursive(root string) ([]string, error)
+}
+
+// ImageLister lists the sample images in a directory.
+type ImageLister interface {
+	ListImageFiles(dir string) ([]string, error)
+}
+
 // Scanner scans sample directories for a training run's checkpoints and discovers
 // images with dimensions.
 type Scanner struct {
-	fs                  ScannerFileSystem
-	sampleDir           string
-	thumbnailsEnabled   bool
-	logger              *logrus.Entry
+	fs                ScannerFileSystem
+	sampleDir         string
+	thumbnailsEnabled bool
+	images            ImageLister           // optional; lists sample directories from the image index
+	sidecars          SidecarScanFileSystem // optional; finds images saved under an output layout
+	logger            *logrus.Entry
 }
 
 // NewScanner creates a Scanner backed by the given filesystem and sample directory.
@@ -51,6 +75,21 @@ func NewScannerWithThumbnails(fs ScannerFileSystem, sampleDir string, thumbnails
 	}
 }
 
+// SetImageLister sets the image index that sample directory listings are
+// served from. If not set, every scan reads the directories from disk.
+func (s *Scanner) SetImageLister(images ImageLister) {
+	s.images = images
+}
+
+// SetSidecarScan makes the scanner find images in subdirectories of the
+// checkpoint directories and read the dimensions of images whose filenames
+// are not query-encoded from their sidecars, as images saved under an output
+// layout require. If not set, only the images directly in the checkpoint
+// directories are scanned, by filename.
+func (s *Scanner) SetSidecarScan(fs SidecarScanFileSystem) {
+	s.sidecars = fs
+}
+
 // ScanTrainingRun discovers images and dimensions for a training run by scanning
 // the sample directories for each checkpoint that has samples.
 // When studyName is non-empty, images are scanned from {sampleDir}/{studyName}/{checkpoint}/;
@@ -62,6 +101,32 @@ func (s *Scanner) ScanTrainingRun(tr model.TrainingRun, studyName string) (*mode
 	}).Trace("entering ScanTrainingRun")
 	defer s.logger.Trace("returning from ScanTrainingRun")
 
+	return s.scan(tr, studyName, s.thumbnailsEnabled)
+}
+
+// ScanDimensions returns the dimensions of a training run's images and their
+// sorted values, and the number of images, without the image list. It scans
+// the same directories as ScanTrainingRun but skips the thumbnail lookups.
+func (s *Scanner) ScanDimensions(tr model.TrainingRun, studyName string) (*model.DimensionIndex, error) {
+	s.logger.WithFields(logrus.Fields{
+		"training_run": tr.Name,
+		"study_name":   studyName,
+	}).Trace("entering ScanDimensions")
+	defer s.logger.Trace("returning from ScanDimensions")
+
+	result, err := s.scan(tr, studyName, false)
+	if err != nil {
+		return nil, err
+	}
+	return &model.DimensionIndex{
+		Dimensions: result.Dimensions,
+		ImageCount: len(result.Images),
+	}, nil
+}
+
+// scan scans the sample directories of tr's checkpoints. Thumbnail paths are
+// looked up only when withThumbnails is set.
+func (s *Scanner) scan(tr model.TrainingRun, studyName string, withThumbnails bool) (*model.ScanResult, error) {
 	// Track unique dimension values: dimName → set of values
 	dimValues := make(map[string]map[string]struct{})
 	dimTypes := make(map[string]model.DimensionType)
@@ -101,21 +166,21 @@ func (s *Scanner) ScanTrainingRun(tr model.TrainingRun, studyName string) (*mode
 			"checkpoint": cp.Filename,
 			"path":       sampleDirPath,
 		}).Debug("scanning checkpoint sample directory")
-		files, err := s.fs.ListPNGFiles(sampleDirPath)
+		files, err := s.listImageFiles(sampleDirPath)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
 				"checkpoint": cp.Filename,
 				"error":      err.Error(),
-			}).Error("failed to list PNG files")
-			return nil, fmt.Errorf("listing PNG files for checkpoint %q: %w", cp.Filename, err)
+			}).Error("failed to list image files")
+			return nil, fmt.Errorf("listing image files for checkpoint %q: %w", cp.Filename, err)
 		}
 		s.logger.WithFields(logrus.Fields{
 			"checkpoint": cp.Filename,
 			"file_count": len(files),
-		}).Debug("found PNG files")
+		}).Debug("found image files")
 
 		for _, filename := range files {
-			fileDims, batchNum := parseFilename(filename)
+			fileDims, batchNum := s.imageDimensions(sampleDirPath, filename)
 			if fileDims == nil {
 				continue
 			}
@@ -150,14 +215,14 @@ func (s *Scanner) ScanTrainingRun(tr model.TrainingRun, studyName string) (*mode
 			// path matches the actual filesystem layout under sampleDir.
 			var relPath string
 			if studyName != "" {
-				relPath = filepath.Join(studyName, cp.Filename, filename)
+				relPath = filepath.Join(studyName, cp.Filename, filepath.FromSlash(filename))
 			} else {
-				relPath = filepath.Join(cp.Filename, filename)
+				relPath = filepath.Join(cp.Filename, filepath.FromSlash(filename))
 			}
 
 			// Check for an existing thumbnail
 			var thumbRelPath string
-			if s.thumbnailsEnabled {
+			if withThumbnails {
 				thumbRelPath = ThumbnailRelativePathURLSafe(relPath)
 				thumbAbsPath := filepath.Join(s.sampleDir, filepath.FromSlash(thumbRelPath))
 				if !s.fs.FileExists(thumbAbsPath) {
@@ -202,15 +267,89 @@ func (s *Scanner) ScanTrainingRun(tr model.TrainingRun, studyName string) (*mode
 	}, nil
 }
 
+// listImageFiles returns the images in the checkpoint directory dir, with
+// slash-separated paths relative to it. Thumbnails are left out.
+func (s *Scanner) listImageFiles(dir string) ([]string, error) {
+	if s.sidecars != nil {
+		return listImagesRecursive(s.sidecars, dir)
+	}
+	if s.images != nil {
+		return s.images.ListImageFiles(dir)
+	}
+	return s.fs.ListImageFiles(dir)
+}
+
+// listImagesRecursive returns the sample images below dir, with
+// slash-separated paths relative to it, leaving out thumbnails.
+func listImagesRecursive(lister RecursiveImageLister, dir string) ([]string, error) {
+	files, err := lister.ListImageFilesRecursive(dir)
+	if err != nil {
+		return nil, err
+	}
+	images := files[:0]
+	for _, f := range files {
+		if !isThumbnailPath(f) {
+			images = append(images, f)
+		}
+	}
+	return images, nil
+}
+
+// imageDimensions returns the dimensions and batch number of the image at
+// relPath in the checkpoint directory dir, parsed from its filename. With a
+// sidecar scan, images whose filenames are not query-encoded take their
+// dimensions from their sidecars instead.
+func (s *Scanner) imageDimensions(dir, relPath string) (map[string]string, int) {
+	dims, batchNum := parseFilename(path.Base(relPath))
+	if s.sidecars == nil || hasDimensionValues(dims) || !model.IsSampleImageFile(relPath) {
+		return dims, batchNum
+	}
+	sidecarPath := sidecarPathFor(filepath.Join(dir, filepath.FromSlash(relPath)))
+	data, err := s.sidecars.ReadFile(sidecarPath)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sidecar_path": sidecarPath,
+			"error":        err.Error(),
+		}).Debug("image has neither a query-encoded filename nor a sidecar, skipping")
+		return nil, 0
+	}
+	var meta fileformat.SidecarMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sidecar_path": sidecarPath,
+			"error":        err.Error(),
+		}).Warn("failed to parse sidecar, skipping image")
+		return nil, 0
+	}
+	return sidecarDimensions(meta), 0
+}
+
+// sidecarDimensions returns the dimensions of the image a sidecar describes,
+// keyed as in its query-encoded filename.
+func sidecarDimensions(meta fileformat.SidecarMetadata) map[string]string {
+	return outputDimensions(model.SampleJobItem{
+		PromptName:  meta.PromptName,
+		Steps:       meta.Steps,
+		CFG:         meta.CFG,
+		SamplerName: meta.SamplerName,
+		Scheduler:   meta.Scheduler,
+		Seed:        meta.Seed,
+		Denoise:     meta.Denoise,
+		Resolution:  meta.Resolution,
+		Wildcards:   meta.Wildcards,
+	})
+}
+
 // parseFilename parses a query-encoded filename like
 // "index=5&prompt_name=portal_hub&seed=422&cfg=3&_00001_.png"
 // Returns the dimension key-value pairs and the batch number.
-// The _NNNNN_ batch suffix is not treated as a dimension.
+// The _NNNNN_ batch suffix is not treated as a dimension. Images in any
+// supported output format (.png, .webp, .jpg, .jpeg) are accepted.
 func parseFilename(filename string) (dims map[string]string, batchNum int) {
-	ext := filepath.Ext(filename)
-	if !strings.EqualFold(ext, ".png") {
+	if !model.IsSampleImageFile(filename) {
 		return nil, 0
 	}
+	ext := filepath.Ext(filename)
 
 	name := strings.TrimSuffix(filename, ext)
 
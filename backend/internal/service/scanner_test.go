@@ -41,13 +41,31 @@ func (f *fakeScannerFS) FileExists(path string) bool {
 	return false
 }
 
-func (f *fakeScannerFS) ListPNGFiles(dir string) ([]string, error) {
+func (f *fakeScannerFS) ListImageFiles(dir string) ([]string, error) {
 	if err, ok := f.errs[dir]; ok {
 		return nil, err
 	}
 	return f.files[dir], nil
 }
 
+// fakeSidecarScanFS implements service.SidecarScanFileSystem for testing.
+type fakeSidecarScanFS struct {
+	files    map[string][]string // abs dir → slash-separated image paths below it
+	contents map[string]string   // abs path → file contents
+}
+
+func (f *fakeSidecarScanFS) ListImageFilesRecursive(root string) ([]string, error) {
+	return f.files[root], nil
+}
+
+func (f *fakeSidecarScanFS) ReadFile(path string) ([]byte, error) {
+	data, ok := f.contents[path]
+	if !ok {
+		return nil, fmt.Errorf("open %s: no such file", path)
+	}
+	return []byte(data), nil
+}
+
 var _ = Describe("Scanner", func() {
 	var (
 		fs        *fakeScannerFS
@@ -64,6 +82,106 @@ var _ = Describe("Scanner", func() {
 		scanner = service.NewScanner(fs, sampleDir, logger)
 	})
 
+	Describe("SetImageLister", func() {
+		It("lists sample directories through the image lister instead of the filesystem", func() {
+			lister := newFakeScannerFS()
+			lister.files["/samples/model-step00001000.safetensors"] = []string{"seed=1&_00001_.png"}
+			scanner.SetImageLister(lister)
+			tr := model.TrainingRun{
+				Name: "model",
+				Checkpoints: []model.Checkpoint{
+					{Filename: "model-step00001000.safetensors", StepNumber: 1000, HasSamples: true},
+				},
+			}
+
+			result, err := scanner.ScanTrainingRun(tr, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Images).To(HaveLen(1))
+			Expect(result.Images[0].RelativePath).To(Equal("model-step00001000.safetensors/seed=1&_00001_.png"))
+		})
+	})
+
+	Describe("SetSidecarScan", func() {
+		It("finds images in subdirectories and reads the dimensions of layout images from their sidecars", func() {
+			dir := "/samples/Study/model-step00001000.safetensors"
+			sidecars := &fakeSidecarScanFS{
+				files: map[string][]string{dir: {
+					"forest/euler_normal/cfg7.0_steps20_seed42.png",
+					"forest/thumbnails/cfg7.0_steps20_seed42.jpg",
+					"prompt=ocean&seed=7.png",
+					"forest/orphan.png",
+				}},
+				contents: map[string]string{
+					dir + "/forest/euler_normal/cfg7.0_steps20_seed42.json": `{"prompt_name":"forest","steps":20,"cfg":7,"sampler_name":"euler","scheduler":"normal","seed":42,"wildcards":{"color":"red"}}`,
+				},
+			}
+			scanner.SetSidecarScan(sidecars)
+			tr := model.TrainingRun{
+				Name: "model",
+				Checkpoints: []model.Checkpoint{
+					{Filename: "model-step00001000.safetensors", StepNumber: 1000, HasSamples: true},
+				},
+			}
+
+			result, err := scanner.ScanTrainingRun(tr, "Study")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Images).To(HaveLen(2))
+			Expect(result.Images[0].RelativePath).To(Equal("Study/model-step00001000.safetensors/forest/euler_normal/cfg7.0_steps20_seed42.png"))
+			Expect(result.Images[0].Dimensions).To(Equal(map[string]string{
+				"checkpoint": "1000", "prompt": "forest", "steps": "20", "cfg": "7.0",
+				"sampler": "euler", "scheduler": "normal", "seed": "42", "color": "red",
+			}))
+			Expect(result.Images[1].RelativePath).To(Equal("Study/model-step00001000.safetensors/prompt=ocean&seed=7.png"))
+			Expect(result.Images[1].Dimensions).To(Equal(map[string]string{"checkpoint": "1000", "prompt": "ocean", "seed": "7"}))
+		})
+	})
+
+	Describe("ScanDimensions", func() {
+		It("returns the sorted dimension values and the deduplicated image count", func() {
+			tr := model.TrainingRun{
+				Name: "model",
+				Checkpoints: []model.Checkpoint{
+					{Filename: "model-step00002000.safetensors", StepNumber: 2000, HasSamples: true},
+					{Filename: "model-step00001000.safetensors", StepNumber: 1000, HasSamples: true},
+					{Filename: "model-step00003000.safetensors", StepNumber: 3000, HasSamples: false},
+				},
+			}
+			fs.files["/samples/Study/model-step00001000.safetensors"] = []string{
+				"prompt_name=forest&seed=42&cfg=7&_00001_.png",
+				"prompt_name=forest&seed=42&cfg=7&_00002_.png",
+				"prompt_name=city&seed=7&cfg=3&_00001_.png",
+			}
+			fs.files["/samples/Study/model-step00002000.safetensors"] = []string{
+				"prompt_name=forest&seed=42&cfg=7&_00001_.png",
+			}
+
+			index, err := scanner.ScanDimensions(tr, "Study")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(index.ImageCount).To(Equal(3))
+			Expect(index.Dimensions).To(Equal([]model.Dimension{
+				{Name: "cfg", Type: model.DimensionTypeInt, Values: []string{"3", "7"}},
+				{Name: "checkpoint", Type: model.DimensionTypeInt, Values: []string{"1000", "2000"}},
+				{Name: "prompt_name", Type: model.DimensionTypeString, Values: []string{"city", "forest"}},
+				{Name: "seed", Type: model.DimensionTypeInt, Values: []string{"7", "42"}},
+			}))
+		})
+
+		It("returns the error of a failed directory listing", func() {
+			tr := model.TrainingRun{
+				Name: "model",
+				Checkpoints: []model.Checkpoint{
+					{Filename: "model-step00001000.safetensors", StepNumber: 1000, HasSamples: true},
+				},
+			}
+			fs.errs["/samples/model-step00001000.safetensors"] = fmt.Errorf("permission denied")
+
+			_, err := scanner.ScanDimensions(tr, "")
+
+			Expect(err).To(MatchError(ContainSubstring("permission denied")))
+		})
+	})
+
 	Describe("ScanTrainingRun", func() {
 		Context("with checkpoints that have samples", func() {
 			It("parses query-encoded filenames and adds checkpoint dimension", func() {
@@ -307,11 +425,11 @@ var _ = Describe("Scanner", func() {
 						{Filename: "model-step00001000.safetensors", StepNumber: 1000, HasSamples: true},
 					},
 				}
-				// The fake FS returns only .png files (ListPNGFiles contract), but
+				// The fake FS returns only .png files (ListImageFiles contract), but
 				// we verify the scanner correctly ignores any non-PNG names.
 				fs.files["/samples/model-step00001000.safetensors"] = []string{
 					"seed=1&cfg=3&_00001_.png",
-					// .json files should never appear here because ListPNGFiles filters them,
+					// .json files should never appear here because ListImageFiles filters them,
 					// but parseFilename also guards against non-.png extensions:
 					"seed=1&cfg=3&_00001_.json",
 				}
@@ -347,6 +465,26 @@ var _ = Describe("Scanner", func() {
 		})
 
 		Context("edge cases", func() {
+			It("parses WebP and JPEG sample images", func() {
+				tr := model.TrainingRun{
+					Name: "model",
+					Checkpoints: []model.Checkpoint{
+						{Filename: "model-step00001000.safetensors", StepNumber: 1000, HasSamples: true},
+					},
+				}
+				fs.files["/samples/model-step00001000.safetensors"] = []string{
+					"prompt=forest&seed=1.webp",
+					"prompt=city&seed=1.jpg",
+				}
+
+				result, err := scanner.ScanTrainingRun(tr, "")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Images).To(HaveLen(2))
+				Expect(result.Images[0].RelativePath).To(Equal("model-step00001000.safetensors/prompt=city&seed=1.jpg"))
+				Expect(result.Images[1].Dimensions).To(HaveKeyWithValue("prompt", "forest"))
+			})
+
 			It("handles filenames without batch suffix", func() {
 				tr := model.TrainingRun{
 					Name: "model",
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// ServerClock is the server's configured time zone and default locale.
+// Schedules are evaluated in its zone rather than the host's, so that they
+// fire at the same wall-clock time on either side of a DST change wherever
+// the server runs.
+type ServerClock struct {
+	location *time.Location
+	locale   string
+	// timeNow is a function that returns the current time, injected for testability.
+	timeNow func() time.Time
+}
+
+// NewServerClock creates a ServerClock for an IANA time zone name and a
+// default BCP 47 locale.
+func NewServerClock(timezone string, locale string) (*ServerClock, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("loading timezone %q: %w", timezone, err)
+	}
+	return &ServerClock{
+		location: location,
+		locale:   locale,
+		timeNow:  time.Now,
+	}, nil
+}
+
+// Location returns the zone schedules are evaluated in.
+func (c *ServerClock) Location() *time.Location {
+	return c.location
+}
+
+// Now returns the current time in the server's zone.
+func (c *ServerClock) Now() time.Time {
+	return c.timeNow().In(c.location)
+}
+
+// Meta describes the server's zone as of now and picks the locale for a
+// request with the given Accept-Language header.
+func (c *ServerClock) Meta(acceptLanguage string) model.ServerMeta {
+	now := c.Now()
+	abbreviation, offset := now.Zone()
+	accepted := parseAcceptLanguage(acceptLanguage)
+	meta := model.ServerMeta{
+		Timezone:         c.location.String(),
+		ZoneAbbreviation: abbreviation,
+		UTCOffsetSeconds: offset,
+		DST:              now.IsDST(),
+		ServerTime:       now,
+		Locale:           c.locale,
+		DefaultLocale:    c.locale,
+		AcceptedLocales:  accepted,
+	}
+	if len(accepted) > 0 {
+		meta.Locale = accepted[0]
+	}
+	if _, end := now.ZoneBounds(); !end.IsZero() {
+		meta.NextTransition = &end
+	}
+	return meta
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language
+// header ordered by quality, most preferred first. The wildcard, tags with
+// quality 0, and malformed entries are left out.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" || !isLanguageTag(tag) {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(name) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || q < 0 || q > 1 {
+				quality = 0
+			} else {
+				quality = q
+			}
+		}
+		if quality > 0 {
+			entries = append(entries, weighted{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].quality > entries[j].quality })
+
+	tags := make([]string, len(entries))
+	for i, e := range entries {
+		tags[i] = e.tag
+	}
+	return tags
+}
+
+// isLanguageTag reports whether tag looks like a BCP 47 language tag: a
+// primary subtag of letters followed by alphanumeric subtags.
+func isLanguageTag(tag string) bool {
+	for i, subtag := range strings.Split(tag, "-") {
+		if len(subtag) == 0 || len(subtag) > 8 {
+			return false
+		}
+		for _, r := range subtag {
+			isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+			isDigit := r >= '0' && r <= '9'
+			if !isLetter && (i == 0 || !isDigit) {
+				return false
+			}
+		}
+	}
+	return true
+}
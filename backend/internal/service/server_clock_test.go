@@ -0,0 +1,88 @@
+package service
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ServerClock", func() {
+	newClock := func(timezone string, now time.Time) *ServerClock {
+		clock, err := NewServerClock(timezone, "en-US")
+		Expect(err).NotTo(HaveOccurred())
+		clock.timeNow = func() time.Time { return now }
+		return clock
+	}
+
+	It("rejects unknown time zones", func() {
+		_, err := NewServerClock("Mars/Olympus_Mons", "en-US")
+		Expect(err).To(MatchError(ContainSubstring(`loading timezone "Mars/Olympus_Mons"`)))
+	})
+
+	It("returns the current time in the server's zone", func() {
+		clock := newClock("Europe/Berlin", time.Date(2026, 7, 1, 10, 0, 0, 0, time.UTC))
+		Expect(clock.Now().Hour()).To(Equal(12))
+		Expect(clock.Location().String()).To(Equal("Europe/Berlin"))
+	})
+
+	It("describes standard time and the next DST change", func() {
+		clock := newClock("Europe/Berlin", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+		meta := clock.Meta("")
+		Expect(meta.Timezone).To(Equal("Europe/Berlin"))
+		Expect(meta.ZoneAbbreviation).To(Equal("CET"))
+		Expect(meta.UTCOffsetSeconds).To(Equal(3600))
+		Expect(meta.DST).To(BeFalse())
+		Expect(meta.NextTransition).NotTo(BeNil())
+		Expect(meta.NextTransition.UTC()).To(Equal(time.Date(2026, 3, 29, 1, 0, 0, 0, time.UTC)))
+		Expect(meta.ServerTime.Hour()).To(Equal(13))
+	})
+
+	It("describes daylight saving time", func() {
+		clock := newClock("Europe/Berlin", time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC))
+
+		meta := clock.Meta("")
+		Expect(meta.ZoneAbbreviation).To(Equal("CEST"))
+		Expect(meta.UTCOffsetSeconds).To(Equal(7200))
+		Expect(meta.DST).To(BeTrue())
+	})
+
+	It("has no next transition in UTC", func() {
+		meta := newClock("UTC", time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)).Meta("")
+		Expect(meta.UTCOffsetSeconds).To(BeZero())
+		Expect(meta.NextTransition).To(BeNil())
+	})
+
+	Describe("locale", func() {
+		var clock *ServerClock
+
+		BeforeEach(func() {
+			clock = newClock("UTC", time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC))
+		})
+
+		It("uses the default locale without an Accept-Language header", func() {
+			meta := clock.Meta("")
+			Expect(meta.Locale).To(Equal("en-US"))
+			Expect(meta.DefaultLocale).To(Equal("en-US"))
+			Expect(meta.AcceptedLocales).To(BeEmpty())
+		})
+
+		It("prefers the language with the highest quality", func() {
+			meta := clock.Meta("fr;q=0.5, de-DE, en;q=0.8")
+			Expect(meta.Locale).To(Equal("de-DE"))
+			Expect(meta.AcceptedLocales).To(Equal([]string{"de-DE", "en", "fr"}))
+		})
+
+		It("keeps header order for equal qualities", func() {
+			meta := clock.Meta("nl-BE, nl")
+			Expect(meta.AcceptedLocales).To(Equal([]string{"nl-BE", "nl"}))
+		})
+
+		It("leaves out wildcards, refused languages and malformed entries", func() {
+			meta := clock.Meta("*, es;q=0, ja;q=abc, en_US, <script>, pt-BR;q=0.3")
+			Expect(meta.AcceptedLocales).To(Equal([]string{"pt-BR"}))
+			Expect(meta.Locale).To(Equal("pt-BR"))
+		})
+	})
+})
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// SidecarBackfillFS defines the filesystem operations needed to find images
+// that lack a sidecar.
+type SidecarBackfillFS interface {
+	ListImageFilesRecursive(root string) ([]string, error)
+	FileExists(path string) bool
+	OpenFile(path string) (io.ReadCloser, error)
+}
+
+// SidecarBackfillService writes JSON sidecar files for images generated before
+// sidecars existed, reconstructing the metadata from the query-encoded filename
+// and the checkpoint directory the image lives in.
+type SidecarBackfillService struct {
+	fs        SidecarBackfillFS
+	writer    FileSystemWriter
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewSidecarBackfillService creates a SidecarBackfillService for sampleDir.
+func NewSidecarBackfillService(fs SidecarBackfillFS, writer FileSystemWriter, sampleDir string, logger *logrus.Logger) *SidecarBackfillService {
+	return &SidecarBackfillService{
+		fs:        fs,
+		writer:    writer,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "sidecar_backfill"),
+	}
+}
+
+// Backfill walks the sample directory and writes a sidecar for every image
+// that has a query-encoded filename but no sidecar. Existing sidecars are
+// never overwritten. A failure on one image is counted and the walk continues.
+func (s *SidecarBackfillService) Backfill() (model.SidecarBackfillResult, error) {
+	s.logger.Trace("entering Backfill")
+	defer s.logger.Trace("returning from Backfill")
+
+	var result model.SidecarBackfillResult
+
+	files, err := s.fs.ListImageFilesRecursive(s.sampleDir)
+	if err != nil {
+		return result, fmt.Errorf("listing images: %w", err)
+	}
+
+	for _, relPath := range files {
+		if isThumbnailPath(relPath) {
+			continue
+		}
+		result.Scanned++
+
+		imagePath := filepath.Join(s.sampleDir, filepath.FromSlash(relPath))
+		sidecarPath := sidecarPathFor(imagePath)
+		if s.fs.FileExists(sidecarPath) {
+			result.Skipped++
+			continue
+		}
+
+		dims, _ := parseFilename(path.Base(relPath))
+		if !hasDimensionValues(dims) {
+			s.logger.WithField("relative_path", relPath).Debug("image filename is not query-encoded, skipping")
+			result.Skipped++
+			continue
+		}
+
+		meta := s.sidecarFromFilename(imagePath, dims)
+		if err := s.writeSidecar(sidecarPath, meta); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"relative_path": relPath,
+				"error":         err.Error(),
+			}).Error("failed to backfill sidecar")
+			result.Failed++
+			if len(result.FailedPaths) < model.MaxSidecarBackfillFailures {
+				result.FailedPaths = append(result.FailedPaths, relPath)
+			}
+			continue
+		}
+		result.Written++
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"scanned": result.Scanned,
+		"written": result.Written,
+		"skipped": result.Skipped,
+		"failed":  result.Failed,
+	}).Info("sidecar backfill completed")
+	return result, nil
+}
+
+// sidecarFromFilename builds sidecar metadata from the parsed filename
+// dimensions. Both the current filename keys (prompt, sampler) and the
+// sidecar-style keys (prompt_name, sampler_name) are accepted. Image
+// dimensions are read from the image header when possible.
+func (s *SidecarBackfillService) sidecarFromFilename(imagePath string, dims map[string]string) fileformat.SidecarMetadata {
+	meta := fileformat.SidecarMetadata{
+		Checkpoint:  filepath.Base(filepath.Dir(imagePath)),
+		PromptName:  firstNonEmpty(dims["prompt_name"], dims["prompt"]),
+		SamplerName: firstNonEmpty(dims["sampler_name"], dims["sampler"]),
+		Scheduler:   dims["scheduler"],
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Backfilled:  true,
+	}
+	if v, err := strconv.ParseInt(dims["seed"], 10, 64); err == nil {
+		meta.Seed = v
+	}
+	if v, err := strconv.Atoi(dims["steps"]); err == nil {
+		meta.Steps = v
+	}
+	if v, err := strconv.ParseFloat(dims["cfg"], 64); err == nil {
+		meta.CFG = v
+	}
+
+	if f, err := s.fs.OpenFile(imagePath); err == nil {
+		if cfg, _, err := image.DecodeConfig(f); err == nil {
+			meta.Width = cfg.Width
+			meta.Height = cfg.Height
+		}
+		f.Close()
+	}
+	return meta
+}
+
+// writeSidecar writes the sidecar atomically (temp file + rename), matching
+// the job executor's sidecar writes.
+func (s *SidecarBackfillService) writeSidecar(sidecarPath string, meta fileformat.SidecarMetadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("marshaling sidecar metadata: %w", err)
+	}
+	tempPath := sidecarPath + ".tmp"
+	if err := s.writer.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("writing sidecar temp file: %w", err)
+	}
+	if err := s.writer.RenameFile(tempPath, sidecarPath); err != nil {
+		return fmt.Errorf("renaming sidecar file: %w", err)
+	}
+	return nil
+}
+
+// sidecarPathFor returns the sidecar path for an image: the image path with
+// its extension replaced by .json.
+func sidecarPathFor(imagePath string) string {
+	ext := filepath.Ext(imagePath)
+	return imagePath[:len(imagePath)-len(ext)] + ".json"
+}
+
+// isThumbnailPath reports whether relPath lies inside a thumbnails directory
+// or the on-demand thumbnail cache.
+func isThumbnailPath(relPath string) bool {
+	return isThumbnailDir(path.Dir(relPath))
+}
+
+// isThumbnailDir reports whether relPath is a thumbnails directory or the
+// on-demand thumbnail cache.
+func isThumbnailDir(relPath string) bool {
+	base := path.Base(relPath)
+	return base == ThumbnailSubdir || base == ThumbnailCacheSubdir
+}
+
+// hasDimensionValues reports whether dims holds at least one key=value pair.
+// A plain filename such as "cover.png" parses as a single key with no value.
+func hasDimensionValues(dims map[string]string) bool {
+	for _, v := range dims {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// firstNonEmpty returns the first non-empty string in values.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+// failingSidecarWriter fails every write whose path contains failOn.
+type failingSidecarWriter struct {
+	*service.RealFileSystemWriter
+	failOn string
+}
+
+func (w *failingSidecarWriter) WriteFile(path string, data []byte, perm uint32) error {
+	if filepath.Base(filepath.Dir(path)) == w.failOn {
+		return errors.New("disk full")
+	}
+	return w.RealFileSystemWriter.WriteFile(path, data, perm)
+}
+
+var _ = Describe("SidecarBackfillService", func() {
+	var (
+		sampleDir string
+		logger    *logrus.Logger
+		writer    *failingSidecarWriter
+		svc       *service.SidecarBackfillService
+	)
+
+	writeFile := func(relPath string, data []byte) {
+		absPath := filepath.Join(sampleDir, relPath)
+		Expect(os.MkdirAll(filepath.Dir(absPath), 0755)).To(Succeed())
+		Expect(os.WriteFile(absPath, data, 0644)).To(Succeed())
+	}
+
+	readSidecar := func(relPath string) fileformat.SidecarMetadata {
+		data, err := os.ReadFile(filepath.Join(sampleDir, relPath))
+		Expect(err).NotTo(HaveOccurred())
+		var meta fileformat.SidecarMetadata
+		Expect(json.Unmarshal(data, &meta)).To(Succeed())
+		return meta
+	}
+
+	BeforeEach(func() {
+		var err error
+		sampleDir, err = os.MkdirTemp("", "sidecar-backfill-test-*")
+		Expect(err).NotTo(HaveOccurred())
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+		writer = &failingSidecarWriter{RealFileSystemWriter: &service.RealFileSystemWriter{}}
+		svc = service.NewSidecarBackfillService(store.NewFileSystem(logger), writer, sampleDir, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(sampleDir)
+	})
+
+	It("writes sidecars reconstructed from the filename and checkpoint directory", func() {
+		writeFile("study/v1/model-step00001000.safetensors/cfg=7.0&prompt=forest&sampler=euler&scheduler=normal&seed=420&steps=30.png",
+			solidPNG(48, 32, color.Black))
+
+		result, err := svc.Backfill()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Scanned).To(Equal(1))
+		Expect(result.Written).To(Equal(1))
+
+		meta := readSidecar("study/v1/model-step00001000.safetensors/cfg=7.0&prompt=forest&sampler=euler&scheduler=normal&seed=420&steps=30.json")
+		Expect(meta.Checkpoint).To(Equal("model-step00001000.safetensors"))
+		Expect(meta.PromptName).To(Equal("forest"))
+		Expect(meta.SamplerName).To(Equal("euler"))
+		Expect(meta.Scheduler).To(Equal("normal"))
+		Expect(meta.Seed).To(Equal(int64(420)))
+		Expect(meta.Steps).To(Equal(30))
+		Expect(meta.CFG).To(Equal(7.0))
+		Expect(meta.Width).To(Equal(48))
+		Expect(meta.Height).To(Equal(32))
+		Expect(meta.Backfilled).To(BeTrue())
+	})
+
+	It("accepts sidecar-style keys in older filenames", func() {
+		writeFile("model.safetensors/index=0&prompt_name=city&seed=7&_00001_.png", solidPNG(8, 8, color.Black))
+
+		_, err := svc.Backfill()
+		Expect(err).NotTo(HaveOccurred())
+		meta := readSidecar("model.safetensors/index=0&prompt_name=city&seed=7&_00001_.json")
+		Expect(meta.PromptName).To(Equal("city"))
+		Expect(meta.Seed).To(Equal(int64(7)))
+	})
+
+	It("skips images that already have a sidecar or are not query-encoded", func() {
+		writeFile("model.safetensors/prompt=a&seed=1.png", solidPNG(8, 8, color.Black))
+		writeFile("model.safetensors/prompt=a&seed=1.json", []byte(`{"checkpoint":"original"}`))
+		writeFile("model.safetensors/cover.png", solidPNG(8, 8, color.Black))
+
+		result, err := svc.Backfill()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Scanned).To(Equal(2))
+		Expect(result.Skipped).To(Equal(2))
+		Expect(result.Written).To(Equal(0))
+		Expect(readSidecar("model.safetensors/prompt=a&seed=1.json").Checkpoint).To(Equal("original"))
+	})
+
+	It("ignores thumbnails", func() {
+		writeFile("model.safetensors/"+service.ThumbnailSubdir+"/prompt=a&seed=1.png", solidPNG(8, 8, color.Black))
+
+		result, err := svc.Backfill()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Scanned).To(Equal(0))
+	})
+
+	It("counts failed writes and continues with the remaining images", func() {
+		writer.failOn = "broken.safetensors"
+		writeFile("broken.safetensors/prompt=a&seed=1.png", solidPNG(8, 8, color.Black))
+		writeFile("ok.safetensors/prompt=a&seed=1.png", solidPNG(8, 8, color.Black))
+
+		result, err := svc.Backfill()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Written).To(Equal(1))
+		Expect(result.Failed).To(Equal(1))
+		Expect(result.FailedPaths).To(ConsistOf("broken.safetensors/prompt=a&seed=1.png"))
+	})
+
+	It("returns an error when the sample directory cannot be read", func() {
+		svc = service.NewSidecarBackfillService(store.NewFileSystem(logger), writer, filepath.Join(sampleDir, "missing"), logger)
+		_, err := svc.Backfill()
+		Expect(err).To(HaveOccurred())
+	})
+})
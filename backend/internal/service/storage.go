@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// StorageFileSystem lists and removes files under the sample directory.
+type StorageFileSystem interface {
+	ListFileUsage(root string) ([]model.FileUsage, error)
+	RemoveDirectory(path string) error
+}
+
+// StorageRatingStore lists image ratings, used to find the best-rated
+// checkpoints of a training run.
+type StorageRatingStore interface {
+	ListImageAnnotations(ctx context.Context, filter model.ImageAnnotationFilter) ([]model.ImageAnnotation, error)
+}
+
+// StorageService reports the disk usage of the sample directory and applies
+// the retention policy.
+type StorageService struct {
+	fs        StorageFileSystem
+	ratings   StorageRatingStore
+	pinGuard  PinGuard
+	sampleDir string
+	logger    *logrus.Entry
+
+	policy *model.RetentionConfig // optional; nil disables retention
+
+	// timeNow is a function that returns the current time, injected for testability.
+	timeNow func() time.Time
+}
+
+// NewStorageService creates a StorageService for the sample directory.
+// pinGuard protects pinned samples from the retention policy.
+func NewStorageService(fs StorageFileSystem, ratings StorageRatingStore, pinGuard PinGuard, sampleDir string, logger *logrus.Logger) *StorageService {
+	return &StorageService{
+		fs:        fs,
+		ratings:   ratings,
+		pinGuard:  pinGuard,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "storage"),
+		timeNow:   time.Now,
+	}
+}
+
+// SetRetentionPolicy sets the policy ApplyRetention and RunRetention apply.
+// This is optional; if not set, ApplyRetention returns an error.
+func (s *StorageService) SetRetentionPolicy(policy model.RetentionConfig) {
+	s.policy = &policy
+}
+
+// RetentionPolicy returns the configured retention policy, or nil.
+func (s *StorageService) RetentionPolicy() *model.RetentionConfig {
+	return s.policy
+}
+
+// Usage reports the disk usage of the sample directory per training run and
+// per checkpoint.
+func (s *StorageService) Usage() (model.StorageUsage, error) {
+	s.logger.Trace("entering Usage")
+	defer s.logger.Trace("returning from Usage")
+
+	files, err := s.fs.ListFileUsage(s.sampleDir)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list sample directory files")
+		return model.StorageUsage{}, fmt.Errorf("listing sample directory files: %w", err)
+	}
+
+	usage := summarizeFileUsage(files)
+	s.logger.WithFields(logrus.Fields{
+		"total_bytes":   usage.TotalBytes,
+		"file_count":    usage.FileCount,
+		"training_runs": len(usage.TrainingRuns),
+	}).Debug("computed storage usage")
+	return usage, nil
+}
+
+// TrainingRunUsage reports the disk usage of one top-level directory of the
+// sample directory, the sanitized name of a training run, without walking
+// the rest of the sample directory. A missing directory uses no space.
+func (s *StorageService) TrainingRunUsage(directory string) (model.TrainingRunStorageUsage, error) {
+	s.logger.WithField("directory", directory).Trace("entering TrainingRunUsage")
+	defer s.logger.Trace("returning from TrainingRunUsage")
+
+	if directory == "" || directory == "." || directory == ".." || strings.ContainsAny(directory, `/\`) {
+		return model.TrainingRunStorageUsage{}, fmt.Errorf("invalid training run directory %q", directory)
+	}
+	files, err := s.fs.ListFileUsage(filepath.Join(s.sampleDir, directory))
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"directory": directory,
+			"error":     err.Error(),
+		}).Error("failed to list training run directory files")
+		return model.TrainingRunStorageUsage{}, fmt.Errorf("listing training run directory files: %w", err)
+	}
+	for i := range files {
+		files[i].Path = directory + "/" + files[i].Path
+	}
+	usage := summarizeFileUsage(files)
+	if len(usage.TrainingRuns) == 0 {
+		return model.TrainingRunStorageUsage{Directory: directory, Checkpoints: []model.CheckpointStorageUsage{}}, nil
+	}
+	return usage.TrainingRuns[0], nil
+}
+
+// summarizeFileUsage breaks the files of the sample directory down by
+// top-level directory and checkpoint.
+func summarizeFileUsage(files []model.FileUsage) model.StorageUsage {
+	usage := model.StorageUsage{TrainingRuns: []model.TrainingRunStorageUsage{}}
+	runs := make(map[string]*model.TrainingRunStorageUsage)
+	checkpoints := make(map[string]map[string]*model.CheckpointStorageUsage)
+	studies := make(map[string]map[string]bool) // "run/checkpoint" -> studies
+	for _, f := range files {
+		usage.TotalBytes += f.Size
+		usage.FileCount++
+		parts := strings.Split(f.Path, "/")
+		if len(parts) < 2 {
+			continue // a file at the top level belongs to no training run
+		}
+		run, ok := runs[parts[0]]
+		if !ok {
+			run = &model.TrainingRunStorageUsage{Directory: parts[0]}
+			runs[parts[0]] = run
+			checkpoints[parts[0]] = make(map[string]*model.CheckpointStorageUsage)
+		}
+		run.Bytes += f.Size
+		run.FileCount++
+		if len(parts) < 4 {
+			continue // not inside a {study}/{checkpoint} directory
+		}
+		ckpt, ok := checkpoints[parts[0]][parts[2]]
+		if !ok {
+			ckpt = &model.CheckpointStorageUsage{CheckpointFilename: parts[2]}
+			checkpoints[parts[0]][parts[2]] = ckpt
+		}
+		ckpt.Bytes += f.Size
+		ckpt.FileCount++
+		key := parts[0] + "/" + parts[2]
+		if studies[key] == nil {
+			studies[key] = make(map[string]bool)
+		}
+		if !studies[key][parts[1]] {
+			studies[key][parts[1]] = true
+			ckpt.StudyCount++
+		}
+	}
+
+	for name, run := range runs {
+		run.Checkpoints = []model.CheckpointStorageUsage{}
+		for _, c := range checkpoints[name] {
+			run.Checkpoints = append(run.Checkpoints, *c)
+		}
+		sort.Slice(run.Checkpoints, func(i, j int) bool {
+			return run.Checkpoints[i].CheckpointFilename < run.Checkpoints[j].CheckpointFilename
+		})
+		usage.TrainingRuns = append(usage.TrainingRuns, *run)
+	}
+	sort.Slice(usage.TrainingRuns, func(i, j int) bool {
+		return usage.TrainingRuns[i].Directory < usage.TrainingRuns[j].Directory
+	})
+	return usage
+}
+
+// ApplyRetention applies the retention policy once. Within each training run
+// directory, the checkpoints kept are the policy's KeepLatest newest ones (a
+// checkpoint without a step number is the final one and counts as newest) and
+// its KeepTopRated best-rated ones. The {study}/{checkpoint} sample
+// directories of every other checkpoint are deleted, unless one of their
+// files is younger than MinAgeDays or they hold pinned content. In a dry run
+// nothing is deleted. Returns an error if no policy is set.
+func (s *StorageService) ApplyRetention(ctx context.Context, dryRun bool) (model.RetentionResult, error) {
+	s.logger.WithField("dry_run", dryRun).Trace("entering ApplyRetention")
+	defer s.logger.Trace("returning from ApplyRetention")
+
+	if s.policy == nil {
+		return model.RetentionResult{}, fmt.Errorf("retention policy not configured")
+	}
+	files, err := s.fs.ListFileUsage(s.sampleDir)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list sample directory files")
+		return model.RetentionResult{}, fmt.Errorf("listing sample directory files: %w", err)
+	}
+
+	// Group the files into {run}/{study}/{checkpoint} sample directories.
+	dirs := make(map[string]*retentionDir)
+	runCheckpoints := make(map[string]map[string]bool)
+	for _, f := range files {
+		parts := strings.Split(f.Path, "/")
+		if len(parts) < 4 {
+			continue
+		}
+		path := strings.Join(parts[:3], "/")
+		d, ok := dirs[path]
+		if !ok {
+			d = &retentionDir{RetentionCandidate: model.RetentionCandidate{
+				Path:               path,
+				TrainingRun:        parts[0],
+				StudyName:          parts[1],
+				CheckpointFilename: parts[2],
+			}}
+			dirs[path] = d
+			if runCheckpoints[parts[0]] == nil {
+				runCheckpoints[parts[0]] = make(map[string]bool)
+			}
+			runCheckpoints[parts[0]][parts[2]] = true
+		}
+		d.Bytes += f.Size
+		d.FileCount++
+		if f.ModTime.After(d.newest) {
+			d.newest = f.ModTime
+		}
+	}
+
+	kept := make(map[string]bool) // "run/checkpoint"
+	for run, ckpts := range runCheckpoints {
+		names := make([]string, 0, len(ckpts))
+		for name := range ckpts {
+			names = append(names, name)
+		}
+		for _, name := range s.latestCheckpoints(names) {
+			kept[run+"/"+name] = true
+		}
+		best, err := s.topRatedCheckpoints(ctx, run)
+		if err != nil {
+			return model.RetentionResult{}, err
+		}
+		for _, name := range best {
+			kept[run+"/"+name] = true
+		}
+	}
+
+	result := model.RetentionResult{DryRun: dryRun, Deleted: []model.RetentionCandidate{}, Errors: []string{}}
+	cutoff := s.timeNow().Add(-time.Duration(s.policy.MinAgeDays) * 24 * time.Hour)
+	paths := make([]string, 0, len(dirs))
+	for path := range dirs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		d := dirs[path]
+		if kept[d.TrainingRun+"/"+d.CheckpointFilename] || d.newest.After(cutoff) {
+			continue
+		}
+		if s.pinGuard != nil && s.pinGuard.IsProtected(ctx, path) {
+			s.logger.WithField("path", path).Debug("retention skips pinned checkpoint samples")
+			continue
+		}
+		if !dryRun {
+			if err := s.fs.RemoveDirectory(filepath.Join(s.sampleDir, filepath.FromSlash(path))); err != nil {
+				s.logger.WithFields(logrus.Fields{
+					"path":  path,
+					"error": err.Error(),
+				}).Error("failed to delete checkpoint samples")
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+				continue
+			}
+		}
+		result.Deleted = append(result.Deleted, d.RetentionCandidate)
+		result.FreedBytes += d.Bytes
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"dry_run":     dryRun,
+		"deleted":     len(result.Deleted),
+		"freed_bytes": result.FreedBytes,
+		"errors":      len(result.Errors),
+	}).Info("retention policy applied")
+	return result, nil
+}
+
+// RunRetention applies the retention policy, in the policy's dry-run mode,
+// immediately and then at every policy interval until stop is closed. It
+// returns at once if no policy is set.
+func (s *StorageService) RunRetention(stop <-chan struct{}) {
+	s.logger.Trace("entering RunRetention")
+	defer s.logger.Trace("returning from RunRetention")
+
+	if s.policy == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(s.policy.Interval) * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		result, err := s.ApplyRetention(context.Background(), s.policy.DryRun)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to apply retention policy")
+		} else if result.DryRun {
+			for _, c := range result.Deleted {
+				s.logger.WithFields(logrus.Fields{
+					"path":  c.Path,
+					"bytes": c.Bytes,
+				}).Info("retention dry run: would delete checkpoint samples")
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// retentionDir is a checkpoint sample directory with the modification time
+// of its newest file.
+type retentionDir struct {
+	model.RetentionCandidate
+	newest time.Time
+}
+
+// latestCheckpoints returns the policy's KeepLatest newest checkpoints among
+// names, by step number.
+func (s *StorageService) latestCheckpoints(names []string) []string {
+	if s.policy.KeepLatest == 0 {
+		return nil
+	}
+	newest := func(name string) int {
+		step := extractStepNumber(name)
+		if step < 0 {
+			return math.MaxInt // the final checkpoint has no step number
+		}
+		return step
+	}
+	sort.Slice(names, func(i, j int) bool {
+		si, sj := newest(names[i]), newest(names[j])
+		if si != sj {
+			return si > sj
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > s.policy.KeepLatest {
+		names = names[:s.policy.KeepLatest]
+	}
+	return names
+}
+
+// topRatedCheckpoints returns the policy's KeepTopRated checkpoints of the
+// training run directory with the highest average image rating. Checkpoints
+// without rated images are never among them.
+func (s *StorageService) topRatedCheckpoints(ctx context.Context, run string) ([]string, error) {
+	if s.policy.KeepTopRated == 0 {
+		return nil, nil
+	}
+	annotations, err := s.ratings.ListImageAnnotations(ctx, model.ImageAnnotationFilter{PathPrefix: run, MinRating: 1})
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"training_run": run,
+			"error":        err.Error(),
+		}).Error("failed to list image ratings")
+		return nil, fmt.Errorf("listing image ratings of %s: %w", run, err)
+	}
+	table := newScoreTable()
+	for _, a := range annotations {
+		parts := strings.Split(a.Path, "/")
+		if len(parts) < 4 {
+			continue
+		}
+		table.add(parts[2], a.Rating)
+	}
+	var best []string
+	for _, sc := range table.scores() {
+		if len(best) == s.policy.KeepTopRated {
+			break
+		}
+		best = append(best, sc.CheckpointFilename)
+	}
+	return best, nil
+}
@@ -0,0 +1,226 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeStorageFileSystem is an in-memory test double for
+// service.StorageFileSystem.
+type fakeStorageFileSystem struct {
+	files     []model.FileUsage
+	removed   []string
+	removeErr error
+}
+
+func (f *fakeStorageFileSystem) ListFileUsage(root string) ([]model.FileUsage, error) {
+	// The files are relative to /samples; roots below it list their subtree.
+	prefix := strings.TrimPrefix(strings.TrimPrefix(root, "/samples"), "/")
+	if prefix == "" {
+		return f.files, nil
+	}
+	var files []model.FileUsage
+	for _, file := range f.files {
+		if rel, ok := strings.CutPrefix(file.Path, prefix+"/"); ok {
+			file.Path = rel
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func (f *fakeStorageFileSystem) RemoveDirectory(path string) error {
+	if f.removeErr != nil {
+		return f.removeErr
+	}
+	f.removed = append(f.removed, path)
+	return nil
+}
+
+type fakeStorageRatingStore struct {
+	annotations []model.ImageAnnotation
+}
+
+func (f *fakeStorageRatingStore) ListImageAnnotations(ctx context.Context, filter model.ImageAnnotationFilter) ([]model.ImageAnnotation, error) {
+	var result []model.ImageAnnotation
+	for _, a := range f.annotations {
+		if strings.HasPrefix(a.Path, filter.PathPrefix+"/") {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+var _ = Describe("StorageService", func() {
+	var (
+		fs      *fakeStorageFileSystem
+		ratings *fakeStorageRatingStore
+		pins    *fakePinGuard
+		svc     *service.StorageService
+	)
+
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	file := func(path string, size int64, modTime time.Time) model.FileUsage {
+		return model.FileUsage{Path: path, Size: size, ModTime: modTime}
+	}
+	deletedPaths := func(result model.RetentionResult) []string {
+		paths := []string{}
+		for _, c := range result.Deleted {
+			paths = append(paths, c.Path)
+		}
+		return paths
+	}
+
+	BeforeEach(func() {
+		fs = &fakeStorageFileSystem{files: []model.FileUsage{
+			file("run/forest/m-step00001000.safetensors/a.png", 100, old),
+			file("run/forest/m-step00001000.safetensors/.thumbnails/a.jpg", 10, old),
+			file("run/forest/m-step00002000.safetensors/a.png", 200, old),
+			file("run/city/m-step00002000.safetensors/a.png", 300, old),
+			file("run/forest/m-step00003000.safetensors/a.png", 400, old),
+			file("run/forest/m.safetensors/a.png", 500, old),
+			file("run/notes.txt", 5, old),
+			file("other/forest/x-step00000100.safetensors/a.png", 1000, old),
+		}}
+		ratings = &fakeStorageRatingStore{}
+		pins = &fakePinGuard{protected: map[string]bool{}}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewStorageService(fs, ratings, pins, "/samples", logger)
+	})
+
+	Describe("Usage", func() {
+		It("sums file sizes per training run and per checkpoint across studies", func() {
+			usage, err := svc.Usage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage.TotalBytes).To(Equal(int64(2515)))
+			Expect(usage.FileCount).To(Equal(8))
+			Expect(usage.TrainingRuns).To(HaveLen(2))
+			Expect(usage.TrainingRuns[0].Directory).To(Equal("other"))
+
+			run := usage.TrainingRuns[1]
+			Expect(run.Directory).To(Equal("run"))
+			Expect(run.Bytes).To(Equal(int64(1515)))
+			Expect(run.FileCount).To(Equal(7))
+			Expect(run.Checkpoints).To(Equal([]model.CheckpointStorageUsage{
+				{CheckpointFilename: "m-step00001000.safetensors", Bytes: 110, FileCount: 2, StudyCount: 1},
+				{CheckpointFilename: "m-step00002000.safetensors", Bytes: 500, FileCount: 2, StudyCount: 2},
+				{CheckpointFilename: "m-step00003000.safetensors", Bytes: 400, FileCount: 1, StudyCount: 1},
+				{CheckpointFilename: "m.safetensors", Bytes: 500, FileCount: 1, StudyCount: 1},
+			}))
+		})
+
+		It("reports an empty sample directory", func() {
+			fs.files = nil
+			usage, err := svc.Usage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage.TotalBytes).To(BeZero())
+			Expect(usage.TrainingRuns).To(BeEmpty())
+		})
+	})
+
+	Describe("TrainingRunUsage", func() {
+		It("sums file sizes of one training run directory", func() {
+			run, err := svc.TrainingRunUsage("run")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(run.Directory).To(Equal("run"))
+			Expect(run.Bytes).To(Equal(int64(1515)))
+			Expect(run.FileCount).To(Equal(7))
+			Expect(run.Checkpoints).To(HaveLen(4))
+			Expect(run.Checkpoints[1]).To(Equal(model.CheckpointStorageUsage{CheckpointFilename: "m-step00002000.safetensors", Bytes: 500, FileCount: 2, StudyCount: 2}))
+		})
+
+		It("reports a missing directory as empty", func() {
+			run, err := svc.TrainingRunUsage("missing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(run.Directory).To(Equal("missing"))
+			Expect(run.Bytes).To(BeZero())
+			Expect(run.Checkpoints).To(BeEmpty())
+		})
+
+		It("rejects directories that are not a single path element", func() {
+			for _, dir := range []string{"", ".", "..", "run/forest"} {
+				_, err := svc.TrainingRunUsage(dir)
+				Expect(err).To(MatchError(ContainSubstring("invalid training run directory")))
+			}
+		})
+	})
+
+	Describe("ApplyRetention", func() {
+		It("keeps the newest checkpoints, counting the final checkpoint as newest", func() {
+			svc.SetRetentionPolicy(model.RetentionConfig{KeepLatest: 2})
+
+			result, err := svc.ApplyRetention(ctx, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deletedPaths(result)).To(Equal([]string{
+				"run/city/m-step00002000.safetensors",
+				"run/forest/m-step00001000.safetensors",
+				"run/forest/m-step00002000.safetensors",
+			}))
+			Expect(result.FreedBytes).To(Equal(int64(610)))
+			Expect(fs.removed).To(ContainElement("/samples/run/forest/m-step00001000.safetensors"))
+			Expect(fs.removed).To(HaveLen(3))
+		})
+
+		It("also keeps the best-rated checkpoints", func() {
+			ratings.annotations = []model.ImageAnnotation{
+				{Path: "run/forest/m-step00001000.safetensors/a.png", Rating: 5},
+				{Path: "run/city/m-step00002000.safetensors/a.png", Rating: 2},
+			}
+			svc.SetRetentionPolicy(model.RetentionConfig{KeepLatest: 2, KeepTopRated: 1})
+
+			result, err := svc.ApplyRetention(ctx, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deletedPaths(result)).To(Equal([]string{
+				"run/city/m-step00002000.safetensors",
+				"run/forest/m-step00002000.safetensors",
+			}))
+		})
+
+		It("deletes nothing in a dry run", func() {
+			svc.SetRetentionPolicy(model.RetentionConfig{KeepLatest: 2})
+
+			result, err := svc.ApplyRetention(ctx, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.DryRun).To(BeTrue())
+			Expect(result.Deleted).To(HaveLen(3))
+			Expect(fs.removed).To(BeEmpty())
+		})
+
+		It("skips samples younger than the minimum age and pinned samples", func() {
+			fs.files = append(fs.files, file("run/city/m-step00002000.safetensors/b.png", 1, time.Now()))
+			pins.protected["run/forest/m-step00001000.safetensors"] = true
+			svc.SetRetentionPolicy(model.RetentionConfig{KeepLatest: 2, MinAgeDays: 7})
+
+			result, err := svc.ApplyRetention(ctx, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deletedPaths(result)).To(Equal([]string{"run/forest/m-step00002000.safetensors"}))
+		})
+
+		It("records failed deletions and continues", func() {
+			fs.removeErr = errors.New("permission denied")
+			svc.SetRetentionPolicy(model.RetentionConfig{KeepLatest: 2})
+
+			result, err := svc.ApplyRetention(ctx, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Deleted).To(BeEmpty())
+			Expect(result.Errors).To(HaveLen(3))
+			Expect(result.Errors[0]).To(ContainSubstring("permission denied"))
+		})
+
+		It("returns an error without a policy", func() {
+			_, err := svc.ApplyRetention(ctx, true)
+			Expect(err).To(MatchError("retention policy not configured"))
+		})
+	})
+})
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,15 +19,24 @@ const disallowedNameChars = `()/\:*?<>|"`
 
 // StudyStore defines the persistence operations the study service needs.
 type StudyStore interface {
-	ListStudies() ([]model.Study, error)
-	GetStudy(id string) (model.Study, error)
+	ListStudies(ctx context.Context) ([]model.Study, error)
+	GetStudy(ctx context.Context, id string) (model.Study, error)
 	// GetStudyByName returns the first study with the given name, excluding the
 	// study with excludeID (pass "" to include all studies). Returns
 	// sql.ErrNoRows if no matching study is found.
-	GetStudyByName(name string, excludeID string) (model.Study, error)
-	CreateStudy(s model.Study) error
-	UpdateStudy(s model.Study) error
-	DeleteStudy(id string) error
+	GetStudyByName(ctx context.Context, name string, excludeID string) (model.Study, error)
+	CreateStudy(ctx context.Context, s model.Study) error
+	UpdateStudy(ctx context.Context, s model.Study) error
+	DeleteStudy(ctx context.Context, id string) error
+	// ListStudyVersions returns the archived versions of a study, oldest
+	// first.
+	ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error)
+	// CreateStudyVersion archives a version of a study; archiving an
+	// existing version is a no-op.
+	CreateStudyVersion(ctx context.Context, v model.StudyVersion) error
+	// StudyVersionInUse reports whether any sample job was created from the
+	// given version of a study.
+	StudyVersionInUse(ctx context.Context, studyID string, version int) (bool, error)
 }
 
 // StudySampleChecker checks whether a study has generated samples on disk.
@@ -43,6 +54,7 @@ type StudyService struct {
 	store          StudyStore
 	sampleChecker  StudySampleChecker
 	sampleRemover  StudySampleDirRemover
+	pinGuard       PinGuard
 	logger         *logrus.Entry
 }
 
@@ -62,12 +74,19 @@ func (s *StudyService) WithSampleRemover(remover StudySampleDirRemover) *StudySe
 	return s
 }
 
+// WithPinGuard sets the guard consulted before the study sample directory is
+// removed. This is optional; if not set, pins are not enforced.
+func (s *StudyService) WithPinGuard(guard PinGuard) *StudyService {
+	s.pinGuard = guard
+	return s
+}
+
 // List returns all studies.
-func (s *StudyService) List() ([]model.Study, error) {
+func (s *StudyService) List(ctx context.Context) ([]model.Study, error) {
 	s.logger.Trace("entering List")
 	defer s.logger.Trace("returning from List")
 
-	studies, err := s.store.ListStudies()
+	studies, err := s.store.ListStudies(ctx)
 	if err != nil {
 		s.logger.WithError(err).Error("failed to list studies")
 		return nil, fmt.Errorf("listing studies: %w", err)
@@ -79,12 +98,38 @@ func (s *StudyService) List() ([]model.Study, error) {
 	return studies, nil
 }
 
+// ListByOwner returns the studies of owner. An empty owner lists the studies
+// of the request's user and the unowned studies, or every study for requests
+// without a user; model.OwnerAll lists every study. Only admin users may list
+// the studies of other users.
+func (s *StudyService) ListByOwner(ctx context.Context, owner string) ([]model.Study, error) {
+	s.logger.WithField("owner", owner).Trace("entering ListByOwner")
+	defer s.logger.Trace("returning from ListByOwner")
+
+	scope, err := ownerScope(ctx, owner)
+	if err != nil {
+		s.logger.WithError(err).Warn("study list denied")
+		return nil, err
+	}
+	studies, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := []model.Study{}
+	for _, st := range studies {
+		if scope.Includes(st.Owner) {
+			result = append(result, st)
+		}
+	}
+	return result, nil
+}
+
 // Get returns a single study by ID.
-func (s *StudyService) Get(id string) (model.Study, error) {
+func (s *StudyService) Get(ctx context.Context, id string) (model.Study, error) {
 	s.logger.WithField("study_id", id).Trace("entering Get")
 	defer s.logger.Trace("returning from Get")
 
-	study, err := s.store.GetStudy(id)
+	study, err := s.store.GetStudy(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			s.logger.WithField("study_id", id).Debug("study not found")
@@ -99,12 +144,38 @@ func (s *StudyService) Get(id string) (model.Study, error) {
 	return study, nil
 }
 
-// Create validates and persists a new study, returning the created study.
-func (s *StudyService) Create(name string, promptPrefix string, prompts []model.NamedPrompt, negativePrompt string, steps []int, cfgs []float64, pairs []model.SamplerSchedulerPair, seeds []int64, width int, height int, workflowTemplate string, vae string, textEncoder string, shift *float64) (model.Study, error) {
+// Create validates and persists a new study owned by the request's user,
+// returning the created study.
+func (s *StudyService) Create(ctx context.Context, name string, promptPrefix string, prompts []model.NamedPrompt, negativePrompt string, steps []int, cfgs []float64, pairs []model.SamplerSchedulerPair, seeds []int64, width int, height int, workflowTemplate string, vae string, textEncoder string, shift *float64, inputImage string, denoiseStrengths []float64, wildcards []model.Wildcard, seedMode model.SeedMode, randomSeedCount int, resolutions []model.Resolution) (model.Study, error) {
 	s.logger.WithField("study_name", name).Trace("entering Create")
 	defer s.logger.Trace("returning from Create")
 
-	if err := s.validate(name, prompts, steps, cfgs, pairs, seeds, width, height); err != nil {
+	st, err := s.NewStudy(ctx, name, promptPrefix, prompts, negativePrompt, steps, cfgs, pairs, seeds, width, height, workflowTemplate, vae, textEncoder, shift, inputImage, denoiseStrengths, wildcards, seedMode, randomSeedCount, resolutions)
+	if err != nil {
+		return model.Study{}, err
+	}
+	st.Owner = ownerOf(ctx)
+	if err := s.store.CreateStudy(ctx, st); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"study_id":   st.ID,
+			"study_name": name,
+			"error":      err.Error(),
+		}).Error("failed to create study")
+		return model.Study{}, fmt.Errorf("creating study: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"study_id":              st.ID,
+		"study_name":            name,
+		"images_per_checkpoint": st.ImagesPerCheckpoint(),
+	}).Info("study created")
+	return st, nil
+}
+
+// NewStudy validates a new study and checks that its name is not taken,
+// returning the study with a fresh ID without persisting it. It lets callers
+// store the study together with other records in one transaction.
+func (s *StudyService) NewStudy(ctx context.Context, name string, promptPrefix string, prompts []model.NamedPrompt, negativePrompt string, steps []int, cfgs []float64, pairs []model.SamplerSchedulerPair, seeds []int64, width int, height int, workflowTemplate string, vae string, textEncoder string, shift *float64, inputImage string, denoiseStrengths []float64, wildcards []model.Wildcard, seedMode model.SeedMode, randomSeedCount int, resolutions []model.Resolution) (model.Study, error) {
+	if err := s.validate(name, prompts, steps, cfgs, pairs, seeds, width, height, inputImage, denoiseStrengths, wildcards, seedMode, randomSeedCount, resolutions); err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"study_name": name,
 			"error":      err.Error(),
@@ -112,8 +183,12 @@ func (s *StudyService) Create(name string, promptPrefix string, prompts []model.
 		return model.Study{}, err
 	}
 
+	if seedMode == "" {
+		seedMode = model.SeedModeExplicit
+	}
+
 	// Check for duplicate study name.
-	if _, err := s.store.GetStudyByName(name, ""); err == nil {
+	if _, err := s.store.GetStudyByName(ctx, name, ""); err == nil {
 		s.logger.WithField("study_name", name).Warn("duplicate study name rejected")
 		return model.Study{}, fmt.Errorf("a study named %q already exists", name)
 	} else if err != sql.ErrNoRows {
@@ -128,6 +203,7 @@ func (s *StudyService) Create(name string, promptPrefix string, prompts []model.
 	st := model.Study{
 		ID:                    uuid.New().String(),
 		Name:                  name,
+		Version:               1,
 		PromptPrefix:          promptPrefix,
 		Prompts:               prompts,
 		NegativePrompt:        negativePrompt,
@@ -137,38 +213,35 @@ func (s *StudyService) Create(name string, promptPrefix string, prompts []model.
 		Seeds:                 seeds,
 		Width:                 width,
 		Height:                height,
+		Resolutions:           resolutions,
 		WorkflowTemplate:      workflowTemplate,
 		VAE:                   vae,
 		TextEncoder:           textEncoder,
 		Shift:                 shift,
+		InputImage:            inputImage,
+		DenoiseStrengths:      denoiseStrengths,
+		Wildcards:             wildcards,
+		SeedMode:              seedMode,
+		RandomSeedCount:       randomSeedCount,
 		CreatedAt:             now,
 		UpdatedAt:             now,
 	}
-	if err := s.store.CreateStudy(st); err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"study_id":   st.ID,
-			"study_name": name,
-			"error":      err.Error(),
-		}).Error("failed to create study")
-		return model.Study{}, fmt.Errorf("creating study: %w", err)
-	}
-	s.logger.WithFields(logrus.Fields{
-		"study_id":              st.ID,
-		"study_name":            name,
-		"images_per_checkpoint": st.ImagesPerCheckpoint(),
-	}).Info("study created")
 	return st, nil
 }
 
-// Update modifies an existing study.
-func (s *StudyService) Update(id string, name string, promptPrefix string, prompts []model.NamedPrompt, negativePrompt string, steps []int, cfgs []float64, pairs []model.SamplerSchedulerPair, seeds []int64, width int, height int, workflowTemplate string, vae string, textEncoder string, shift *float64) (model.Study, error) {
+// Update modifies an existing study. If a sample job was created from the
+// study's current version, that version is archived first and the study
+// moves to the next version, so the job keeps referencing the configuration
+// it sampled. A study owned by a user may only be changed by that user and
+// admin users.
+func (s *StudyService) Update(ctx context.Context, id string, name string, promptPrefix string, prompts []model.NamedPrompt, negativePrompt string, steps []int, cfgs []float64, pairs []model.SamplerSchedulerPair, seeds []int64, width int, height int, workflowTemplate string, vae string, textEncoder string, shift *float64, inputImage string, denoiseStrengths []float64, wildcards []model.Wildcard, seedMode model.SeedMode, randomSeedCount int, resolutions []model.Resolution) (model.Study, error) {
 	s.logger.WithFields(logrus.Fields{
 		"study_id":   id,
 		"study_name": name,
 	}).Trace("entering Update")
 	defer s.logger.Trace("returning from Update")
 
-	if err := s.validate(name, prompts, steps, cfgs, pairs, seeds, width, height); err != nil {
+	if err := s.validate(name, prompts, steps, cfgs, pairs, seeds, width, height, inputImage, denoiseStrengths, wildcards, seedMode, randomSeedCount, resolutions); err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"study_id": id,
 			"error":    err.Error(),
@@ -177,7 +250,7 @@ func (s *StudyService) Update(id string, name string, promptPrefix string, promp
 	}
 
 	// Check for duplicate study name, excluding the study being updated.
-	if _, err := s.store.GetStudyByName(name, id); err == nil {
+	if _, err := s.store.GetStudyByName(ctx, name, id); err == nil {
 		s.logger.WithFields(logrus.Fields{
 			"study_id":   id,
 			"study_name": name,
@@ -192,7 +265,7 @@ func (s *StudyService) Update(id string, name string, promptPrefix string, promp
 		return model.Study{}, fmt.Errorf("checking study name uniqueness: %w", err)
 	}
 
-	existing, err := s.store.GetStudy(id)
+	existing, err := s.store.GetStudy(ctx, id)
 	if err == sql.ErrNoRows {
 		s.logger.WithField("study_id", id).Debug("study not found")
 		return model.Study{}, fmt.Errorf("study %s not found", id)
@@ -205,6 +278,43 @@ func (s *StudyService) Update(id string, name string, promptPrefix string, promp
 		return model.Study{}, fmt.Errorf("fetching study: %w", err)
 	}
 	s.logger.WithField("study_id", id).Debug("fetched existing study from store")
+	if err := checkOwner(ctx, "study", id, existing.Owner); err != nil {
+		s.logger.WithError(err).Warn("study change denied")
+		return model.Study{}, err
+	}
+
+	inUse, err := s.store.StudyVersionInUse(ctx, id, existing.Version)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"study_id": id,
+			"version":  existing.Version,
+			"error":    err.Error(),
+		}).Error("failed to check whether study version is in use")
+		return model.Study{}, fmt.Errorf("checking study version use: %w", err)
+	}
+	if inUse {
+		archived := model.StudyVersion{
+			StudyID:   id,
+			Version:   existing.Version,
+			Study:     existing,
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := s.store.CreateStudyVersion(ctx, archived); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"study_id": id,
+				"version":  existing.Version,
+				"error":    err.Error(),
+			}).Error("failed to archive study version")
+			return model.Study{}, fmt.Errorf("archiving study version: %w", err)
+		}
+		s.logger.WithFields(logrus.Fields{
+			"study_id": id,
+			"version":  existing.Version,
+		}).Info("archived study version used by sample jobs")
+		// The edit starts the study's next revision. This is the study's
+		// configuration version, which UpdateStudy stores as given.
+		existing.Version++
+	}
 
 	existing.Name = name
 	existing.PromptPrefix = promptPrefix
@@ -216,13 +326,22 @@ func (s *StudyService) Update(id string, name string, promptPrefix string, promp
 	existing.Seeds = seeds
 	existing.Width = width
 	existing.Height = height
+	existing.Resolutions = resolutions
 	existing.WorkflowTemplate = workflowTemplate
 	existing.VAE = vae
 	existing.TextEncoder = textEncoder
 	existing.Shift = shift
+	existing.InputImage = inputImage
+	existing.DenoiseStrengths = denoiseStrengths
+	existing.Wildcards = wildcards
+	existing.SeedMode = seedMode
+	if existing.SeedMode == "" {
+		existing.SeedMode = model.SeedModeExplicit
+	}
+	existing.RandomSeedCount = randomSeedCount
 	existing.UpdatedAt = time.Now().UTC()
 
-	if err := s.store.UpdateStudy(existing); err != nil {
+	if err := s.store.UpdateStudy(ctx, existing); err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"study_id":   id,
 			"study_name": name,
@@ -233,14 +352,16 @@ func (s *StudyService) Update(id string, name string, promptPrefix string, promp
 	s.logger.WithFields(logrus.Fields{
 		"study_id":              id,
 		"study_name":            name,
+		"version":               existing.Version,
 		"images_per_checkpoint": existing.ImagesPerCheckpoint(),
 	}).Info("study updated")
 	return existing, nil
 }
 
 // Fork creates a new study by copying an existing study's settings with
-// modifications. The new study gets a new ID and name.
-func (s *StudyService) Fork(sourceID string, newName string, promptPrefix string, prompts []model.NamedPrompt, negativePrompt string, steps []int, cfgs []float64, pairs []model.SamplerSchedulerPair, seeds []int64, width int, height int, workflowTemplate string, vae string, textEncoder string, shift *float64) (model.Study, error) {
+// modifications. The new study gets a new ID and name and is owned by the
+// request's user.
+func (s *StudyService) Fork(ctx context.Context, sourceID string, newName string, promptPrefix string, prompts []model.NamedPrompt, negativePrompt string, steps []int, cfgs []float64, pairs []model.SamplerSchedulerPair, seeds []int64, width int, height int, workflowTemplate string, vae string, textEncoder string, shift *float64, inputImage string, denoiseStrengths []float64, wildcards []model.Wildcard, seedMode model.SeedMode, randomSeedCount int, resolutions []model.Resolution) (model.Study, error) {
 	s.logger.WithFields(logrus.Fields{
 		"source_id": sourceID,
 		"new_name":  newName,
@@ -248,7 +369,7 @@ func (s *StudyService) Fork(sourceID string, newName string, promptPrefix string
 	defer s.logger.Trace("returning from Fork")
 
 	// Verify source study exists
-	_, err := s.store.GetStudy(sourceID)
+	_, err := s.store.GetStudy(ctx, sourceID)
 	if err == sql.ErrNoRows {
 		s.logger.WithField("source_id", sourceID).Debug("source study not found for fork")
 		return model.Study{}, fmt.Errorf("source study %s not found", sourceID)
@@ -262,15 +383,75 @@ func (s *StudyService) Fork(sourceID string, newName string, promptPrefix string
 	}
 
 	// Create the forked study using the standard Create flow (validates, checks name uniqueness)
-	return s.Create(newName, promptPrefix, prompts, negativePrompt, steps, cfgs, pairs, seeds, width, height, workflowTemplate, vae, textEncoder, shift)
+	return s.Create(ctx, newName, promptPrefix, prompts, negativePrompt, steps, cfgs, pairs, seeds, width, height, workflowTemplate, vae, textEncoder, shift, inputImage, denoiseStrengths, wildcards, seedMode, randomSeedCount, resolutions)
+}
+
+// Duplicate creates a copy of an existing study under a new name, with all of
+// its settings. An empty name names the copy after the source, e.g.
+// "Sweep copy", or "Sweep copy 2" when that name is taken. The copy starts
+// at version 1, has no jobs, and is owned by the request's user.
+func (s *StudyService) Duplicate(ctx context.Context, sourceID string, newName string) (model.Study, error) {
+	s.logger.WithFields(logrus.Fields{
+		"source_id": sourceID,
+		"new_name":  newName,
+	}).Trace("entering Duplicate")
+	defer s.logger.Trace("returning from Duplicate")
+
+	source, err := s.store.GetStudy(ctx, sourceID)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("source_id", sourceID).Debug("source study not found for duplicate")
+		return model.Study{}, fmt.Errorf("source study %s not found", sourceID)
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"source_id": sourceID,
+			"error":     err.Error(),
+		}).Error("failed to fetch source study for duplicate")
+		return model.Study{}, fmt.Errorf("fetching source study: %w", err)
+	}
+
+	if newName == "" {
+		if newName, err = s.copyName(ctx, source.Name); err != nil {
+			return model.Study{}, err
+		}
+	}
+	return s.Create(ctx, newName, source.PromptPrefix, source.Prompts, source.NegativePrompt, source.Steps, source.CFGs, source.SamplerSchedulerPairs, source.Seeds, source.Width, source.Height, source.WorkflowTemplate, source.VAE, source.TextEncoder, source.Shift, source.InputImage, source.DenoiseStrengths, source.Wildcards, source.SeedMode, source.RandomSeedCount, source.Resolutions)
+}
+
+// copyName returns the first of "<name> copy", "<name> copy 2", ... that no
+// study is named.
+func (s *StudyService) copyName(ctx context.Context, name string) (string, error) {
+	return s.freeName(ctx, name + " copy")
+}
+
+// freeName returns the first of "<name>", "<name> 2", "<name> 3", ... that no
+// study is named.
+func (s *StudyService) freeName(ctx context.Context, name string) (string, error) {
+	for n := 1; ; n++ {
+		candidate := name
+		if n > 1 {
+			candidate = fmt.Sprintf("%s %d", name, n)
+		}
+		_, err := s.store.GetStudyByName(ctx, candidate, "")
+		if err == sql.ErrNoRows {
+			return candidate, nil
+		}
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"study_name": candidate,
+				"error":      err.Error(),
+			}).Error("failed to check for duplicate study name")
+			return "", fmt.Errorf("checking study name uniqueness: %w", err)
+		}
+	}
 }
 
 // HasSamples checks whether a study has any generated samples on disk.
-func (s *StudyService) HasSamples(id string) (bool, error) {
+func (s *StudyService) HasSamples(ctx context.Context, id string) (bool, error) {
 	s.logger.WithField("study_id", id).Trace("entering HasSamples")
 	defer s.logger.Trace("returning from HasSamples")
 
-	study, err := s.store.GetStudy(id)
+	study, err := s.store.GetStudy(ctx, id)
 	if err == sql.ErrNoRows {
 		s.logger.WithField("study_id", id).Debug("study not found for has-samples check")
 		return false, fmt.Errorf("study %s not found", id)
@@ -302,7 +483,8 @@ func (s *StudyService) HasSamples(id string) (bool, error) {
 
 // Delete removes a study by ID. When deleteData is true, also removes the
 // study's sample output directory from disk (if a remover has been configured).
-func (s *StudyService) Delete(id string, deleteData bool) error {
+// A study owned by a user may only be removed by that user and admin users.
+func (s *StudyService) Delete(ctx context.Context, id string, deleteData bool) error {
 	s.logger.WithFields(logrus.Fields{
 		"study_id":    id,
 		"delete_data": deleteData,
@@ -310,7 +492,7 @@ func (s *StudyService) Delete(id string, deleteData bool) error {
 	defer s.logger.Trace("returning from Delete")
 
 	// Fetch the study first so we know its name (needed for directory removal).
-	study, err := s.store.GetStudy(id)
+	study, err := s.store.GetStudy(ctx, id)
 	if err == sql.ErrNoRows {
 		s.logger.WithField("study_id", id).Debug("study not found for deletion")
 		return fmt.Errorf("study %s not found", id)
@@ -322,10 +504,19 @@ func (s *StudyService) Delete(id string, deleteData bool) error {
 		}).Error("failed to fetch study for deletion")
 		return fmt.Errorf("fetching study: %w", err)
 	}
+	if err := checkOwner(ctx, "study", id, study.Owner); err != nil {
+		s.logger.WithError(err).Warn("study deletion denied")
+		return err
+	}
 
 	// Remove the sample output directory before deleting the DB record so that
 	// a filesystem error does not leave a dangling database entry.
-	if deleteData && s.sampleRemover != nil {
+	if deleteData && s.sampleRemover != nil && s.pinGuard != nil && s.pinGuard.IsProtected(ctx, study.Name) {
+		s.logger.WithFields(logrus.Fields{
+			"study_id":   id,
+			"study_name": study.Name,
+		}).Info("study sample directory contains pinned content, not removing")
+	} else if deleteData && s.sampleRemover != nil {
 		s.logger.WithFields(logrus.Fields{
 			"study_id":   id,
 			"study_name": study.Name,
@@ -344,7 +535,7 @@ func (s *StudyService) Delete(id string, deleteData bool) error {
 		}).Info("study sample directory removed")
 	}
 
-	if err := s.store.DeleteStudy(id); err != nil {
+	if err := s.store.DeleteStudy(ctx, id); err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"study_id": id,
 			"error":    err.Error(),
@@ -356,7 +547,7 @@ func (s *StudyService) Delete(id string, deleteData bool) error {
 }
 
 // validate checks that a study's fields meet the requirements.
-func (s *StudyService) validate(name string, prompts []model.NamedPrompt, steps []int, cfgs []float64, pairs []model.SamplerSchedulerPair, seeds []int64, width int, height int) error {
+func (s *StudyService) validate(name string, prompts []model.NamedPrompt, steps []int, cfgs []float64, pairs []model.SamplerSchedulerPair, seeds []int64, width int, height int, inputImage string, denoiseStrengths []float64, wildcards []model.Wildcard, seedMode model.SeedMode, randomSeedCount int, resolutions []model.Resolution) error {
 	if name == "" {
 		return fmt.Errorf("study name must not be empty")
 	}
@@ -378,6 +569,9 @@ func (s *StudyService) validate(name string, prompts []model.NamedPrompt, steps
 			return fmt.Errorf("duplicate prompt name %q", p.Name)
 		}
 		seenPromptNames[p.Name] = true
+		if err := validatePromptOverrides(p); err != nil {
+			return fmt.Errorf("prompt %q: %w", p.Name, err)
+		}
 	}
 	if len(steps) == 0 {
 		return fmt.Errorf("at least one step count is required")
@@ -423,10 +617,30 @@ func (s *StudyService) validate(name string, prompts []model.NamedPrompt, steps
 		}
 		seenPairs[key] = true
 	}
-	seenSeeds := make(map[int64]bool, len(seeds))
-	if len(seeds) == 0 {
-		return fmt.Errorf("at least one seed is required")
+	switch seedMode {
+	case "", model.SeedModeExplicit:
+		if len(seeds) == 0 {
+			return fmt.Errorf("at least one seed is required")
+		}
+		if randomSeedCount != 0 {
+			return fmt.Errorf("random seed count requires a random seed mode")
+		}
+	case model.SeedModeRandomPerItem, model.SeedModeRandomPerCheckpoint:
+		if len(seeds) > 0 {
+			return fmt.Errorf("seed mode %s draws its seeds; the seed list must be empty", seedMode)
+		}
+		if randomSeedCount < 1 {
+			return fmt.Errorf("seed mode %s requires a random seed count of at least 1", seedMode)
+		}
+		for _, p := range prompts {
+			if len(p.Seeds) > 0 {
+				return fmt.Errorf("prompt %q: seed mode %s draws its seeds; prompts cannot override them", p.Name, seedMode)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown seed mode %q", seedMode)
 	}
+	seenSeeds := make(map[int64]bool, len(seeds))
 	for _, seed := range seeds {
 		if seenSeeds[seed] {
 			return fmt.Errorf("duplicate seed value %d", seed)
@@ -439,5 +653,94 @@ func (s *StudyService) validate(name string, prompts []model.NamedPrompt, steps
 	if height <= 0 {
 		return fmt.Errorf("height must be positive")
 	}
+	seenResolutions := make(map[model.Resolution]bool, len(resolutions))
+	for i, r := range resolutions {
+		if r.Width <= 0 || r.Height <= 0 {
+			return fmt.Errorf("resolution %d width and height must be positive", i)
+		}
+		if seenResolutions[r] {
+			return fmt.Errorf("duplicate resolution %s", r)
+		}
+		seenResolutions[r] = true
+	}
+	if len(denoiseStrengths) > 0 && inputImage == "" {
+		return fmt.Errorf("denoise strengths require an input image")
+	}
+	seenDenoise := make(map[float64]bool, len(denoiseStrengths))
+	for i, denoise := range denoiseStrengths {
+		if denoise <= 0 || denoise > 1 {
+			return fmt.Errorf("denoise strength %d must be greater than 0 and at most 1", i)
+		}
+		if seenDenoise[denoise] {
+			return fmt.Errorf("duplicate denoise strength %g", denoise)
+		}
+		seenDenoise[denoise] = true
+	}
+	seenWildcards := make(map[string]bool, len(wildcards))
+	for i, w := range wildcards {
+		if !model.WildcardNamePattern.MatchString(w.Name) {
+			return fmt.Errorf("wildcard %d name %q must start with a lowercase letter and contain only lowercase letters, digits and underscores", i, w.Name)
+		}
+		if slices.Contains(model.ReservedWildcardNames, w.Name) {
+			return fmt.Errorf("wildcard name %q is reserved", w.Name)
+		}
+		if seenWildcards[w.Name] {
+			return fmt.Errorf("duplicate wildcard name %q", w.Name)
+		}
+		seenWildcards[w.Name] = true
+		if len(w.Values) == 0 {
+			return fmt.Errorf("wildcard %q needs at least one value", w.Name)
+		}
+		seenValues := make(map[string]bool, len(w.Values))
+		for j, value := range w.Values {
+			if value == "" {
+				return fmt.Errorf("wildcard %q value %d must not be empty", w.Name, j)
+			}
+			if seenValues[value] {
+				return fmt.Errorf("duplicate value %q of wildcard %q", value, w.Name)
+			}
+			seenValues[value] = true
+		}
+	}
+	return nil
+}
+
+// validatePromptOverrides checks the values a prompt overrides. Lists left
+// empty and sizes left zero use the study's values, so only the values that
+// are set are checked, by the same rules as the study's.
+func validatePromptOverrides(p model.NamedPrompt) error {
+	seenSteps := make(map[int]bool, len(p.Steps))
+	for i, step := range p.Steps {
+		if step <= 0 {
+			return fmt.Errorf("step %d must be positive", i)
+		}
+		if seenSteps[step] {
+			return fmt.Errorf("duplicate step value %d", step)
+		}
+		seenSteps[step] = true
+	}
+	seenCFGs := make(map[float64]bool, len(p.CFGs))
+	for i, cfg := range p.CFGs {
+		if cfg <= 0 {
+			return fmt.Errorf("CFG %d must be positive", i)
+		}
+		if seenCFGs[cfg] {
+			return fmt.Errorf("duplicate CFG value %g", cfg)
+		}
+		seenCFGs[cfg] = true
+	}
+	seenSeeds := make(map[int64]bool, len(p.Seeds))
+	for _, seed := range p.Seeds {
+		if seenSeeds[seed] {
+			return fmt.Errorf("duplicate seed value %d", seed)
+		}
+		seenSeeds[seed] = true
+	}
+	if p.Width < 0 {
+		return fmt.Errorf("width must not be negative")
+	}
+	if p.Height < 0 {
+		return fmt.Errorf("height must not be negative")
+	}
 	return nil
 }
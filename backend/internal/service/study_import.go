@@ -0,0 +1,279 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// studyImportRow is one row of an import file, with cells keyed by column
+// name. Empty cells are omitted.
+type studyImportRow struct {
+	num   int // row number reported in validation errors
+	cells map[string]string
+}
+
+// ParseStudyImport parses seeds, steps, CFGs, and sampler/scheduler pairs
+// from a CSV or JSON import file. Columns may appear in any order and each
+// row may leave cells empty, so lists of different lengths fit in one file.
+//
+// Validation errors name the offending row: for CSV this is the line number
+// in the file (the header is row 1), for JSON the 1-based position in the
+// array.
+func ParseStudyImport(format model.StudyImportFormat, data []byte) (model.StudyParameterImport, error) {
+	var (
+		columns []string
+		rows    []studyImportRow
+		err     error
+	)
+	switch format {
+	case model.StudyImportFormatCSV:
+		columns, rows, err = readStudyImportCSV(data)
+	case model.StudyImportFormatJSON:
+		columns, rows, err = readStudyImportJSON(data)
+	default:
+		return model.StudyParameterImport{}, fmt.Errorf("invalid import: unsupported format %q", format)
+	}
+	if err != nil {
+		return model.StudyParameterImport{}, err
+	}
+	if len(columns) == 0 {
+		return model.StudyParameterImport{}, fmt.Errorf("invalid import: no columns")
+	}
+
+	present := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		switch c {
+		case model.StudyImportColumnSeeds, model.StudyImportColumnSteps, model.StudyImportColumnCFGs,
+			model.StudyImportColumnSampler, model.StudyImportColumnScheduler:
+		default:
+			return model.StudyParameterImport{}, fmt.Errorf("invalid import: unknown column %q", c)
+		}
+		present[c] = true
+	}
+	if present[model.StudyImportColumnSampler] != present[model.StudyImportColumnScheduler] {
+		return model.StudyParameterImport{}, fmt.Errorf("invalid import: sampler and scheduler columns must be used together")
+	}
+
+	var imp model.StudyParameterImport
+	seenSeeds := make(map[int64]int)
+	seenSteps := make(map[int]int)
+	seenCFGs := make(map[float64]int)
+	seenPairs := make(map[model.SamplerSchedulerPair]int)
+	for _, row := range rows {
+		if v, ok := row.cells[model.StudyImportColumnSeeds]; ok {
+			seed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: seed %q is not an integer", row.num, v)
+			}
+			if first, dup := seenSeeds[seed]; dup {
+				return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: duplicate seed %d (first on row %d)", row.num, seed, first)
+			}
+			seenSeeds[seed] = row.num
+			imp.Seeds = append(imp.Seeds, seed)
+		}
+		if v, ok := row.cells[model.StudyImportColumnSteps]; ok {
+			step, err := strconv.Atoi(v)
+			if err != nil {
+				return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: steps %q is not an integer", row.num, v)
+			}
+			if step <= 0 {
+				return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: steps must be positive, got %d", row.num, step)
+			}
+			if first, dup := seenSteps[step]; dup {
+				return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: duplicate steps %d (first on row %d)", row.num, step, first)
+			}
+			seenSteps[step] = row.num
+			imp.Steps = append(imp.Steps, step)
+		}
+		if v, ok := row.cells[model.StudyImportColumnCFGs]; ok {
+			cfg, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: cfg %q is not a number", row.num, v)
+			}
+			if cfg <= 0 {
+				return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: cfg must be positive, got %g", row.num, cfg)
+			}
+			if first, dup := seenCFGs[cfg]; dup {
+				return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: duplicate cfg %g (first on row %d)", row.num, cfg, first)
+			}
+			seenCFGs[cfg] = row.num
+			imp.CFGs = append(imp.CFGs, cfg)
+		}
+		sampler, hasSampler := row.cells[model.StudyImportColumnSampler]
+		scheduler, hasScheduler := row.cells[model.StudyImportColumnScheduler]
+		if hasSampler != hasScheduler {
+			return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: sampler and scheduler must both be set", row.num)
+		}
+		if hasSampler {
+			pair := model.SamplerSchedulerPair{Sampler: sampler, Scheduler: scheduler}
+			if first, dup := seenPairs[pair]; dup {
+				return model.StudyParameterImport{}, fmt.Errorf("invalid import: row %d: duplicate sampler/scheduler pair %q/%q (first on row %d)", row.num, sampler, scheduler, first)
+			}
+			seenPairs[pair] = row.num
+			imp.SamplerSchedulerPairs = append(imp.SamplerSchedulerPairs, pair)
+		}
+	}
+
+	counts := map[string]int{
+		model.StudyImportColumnSeeds:   len(imp.Seeds),
+		model.StudyImportColumnSteps:   len(imp.Steps),
+		model.StudyImportColumnCFGs:    len(imp.CFGs),
+		model.StudyImportColumnSampler: len(imp.SamplerSchedulerPairs),
+	}
+	for _, c := range columns {
+		if n, ok := counts[c]; ok && n == 0 {
+			return model.StudyParameterImport{}, fmt.Errorf("invalid import: column %q has no values", c)
+		}
+	}
+	return imp, nil
+}
+
+// readStudyImportCSV reads the header and rows of a CSV import file.
+func readStudyImportCSV(data []byte) ([]string, []studyImportRow, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("invalid import: empty file")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid import: %w", err)
+	}
+	// Spreadsheet exports often start with a UTF-8 byte order mark.
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	columns := make([]string, len(header))
+	for i, h := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(h))
+	}
+
+	var rows []studyImportRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid import: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		row := studyImportRow{num: line, cells: make(map[string]string, len(record))}
+		for i, v := range record {
+			if v = strings.TrimSpace(v); v != "" {
+				row.cells[columns[i]] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, nil
+}
+
+// readStudyImportJSON reads a JSON array of row objects. Cell values may be
+// numbers or strings; null and empty strings are treated as empty cells.
+func readStudyImportJSON(data []byte) ([]string, []studyImportRow, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid import: expected a JSON array of row objects: %w", err)
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	rows := make([]studyImportRow, len(raw))
+	for i, obj := range raw {
+		row := studyImportRow{num: i + 1, cells: make(map[string]string, len(obj))}
+		for key, value := range obj {
+			column := strings.ToLower(key)
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+			cell, err := jsonImportCell(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid import: row %d: %s: %w", row.num, key, err)
+			}
+			if cell != "" {
+				row.cells[column] = cell
+			}
+		}
+		rows[i] = row
+	}
+	sort.Strings(columns)
+	return columns, rows, nil
+}
+
+// jsonImportCell returns the text of a JSON number or string cell.
+func jsonImportCell(value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return strings.TrimSpace(s), nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(value, &n); err == nil {
+		return n.String(), nil
+	}
+	if string(value) == "null" {
+		return "", nil
+	}
+	return "", errors.New("value must be a number or a string")
+}
+
+// Import replaces the parameter lists of study id with those in imp. Lists
+// that imp leaves nil keep their current values. When newName is non-empty
+// the study is left unchanged and a new study named newName is created from
+// it with the imported lists instead.
+func (s *StudyService) Import(ctx context.Context, id string, newName string, imp model.StudyParameterImport) (model.Study, error) {
+	s.logger.WithFields(logrus.Fields{
+		"study_id": id,
+		"new_name": newName,
+	}).Trace("entering Import")
+	defer s.logger.Trace("returning from Import")
+
+	existing, err := s.Get(ctx, id)
+	if err != nil {
+		return model.Study{}, err
+	}
+
+	seeds, steps, cfgs, pairs := existing.Seeds, existing.Steps, existing.CFGs, existing.SamplerSchedulerPairs
+	seedMode, randomSeedCount := existing.SeedMode, existing.RandomSeedCount
+	if imp.Seeds != nil {
+		// Imported seeds are explicit, replacing random seeds.
+		seeds = imp.Seeds
+		seedMode, randomSeedCount = model.SeedModeExplicit, 0
+	}
+	if imp.Steps != nil {
+		steps = imp.Steps
+	}
+	if imp.CFGs != nil {
+		cfgs = imp.CFGs
+	}
+	if imp.SamplerSchedulerPairs != nil {
+		pairs = imp.SamplerSchedulerPairs
+	}
+	s.logger.WithFields(logrus.Fields{
+		"study_id":   id,
+		"seed_count": len(seeds),
+		"step_count": len(steps),
+		"cfg_count":  len(cfgs),
+		"pair_count": len(pairs),
+	}).Debug("merged imported parameter lists")
+
+	if newName != "" {
+		return s.Fork(ctx, id, newName, existing.PromptPrefix, existing.Prompts, existing.NegativePrompt, steps, cfgs, pairs, seeds,
+			existing.Width, existing.Height, existing.WorkflowTemplate, existing.VAE, existing.TextEncoder, existing.Shift, existing.InputImage, existing.DenoiseStrengths, existing.Wildcards, seedMode, randomSeedCount, existing.Resolutions)
+	}
+	return s.Update(ctx, id, existing.Name, existing.PromptPrefix, existing.Prompts, existing.NegativePrompt, steps, cfgs, pairs, seeds,
+		existing.Width, existing.Height, existing.WorkflowTemplate, existing.VAE, existing.TextEncoder, existing.Shift, existing.InputImage, existing.DenoiseStrengths, existing.Wildcards, seedMode, randomSeedCount, existing.Resolutions)
+}
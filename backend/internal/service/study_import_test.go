@@ -0,0 +1,131 @@
+package service_test
+
+import (
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("ParseStudyImport", func() {
+	It("parses a CSV file with lists of different lengths", func() {
+		data := "seeds,steps,cfgs,sampler,scheduler\n" +
+			"420,20,3.5,euler,simple\n" +
+			"421,30,7,dpmpp_2m,karras\n" +
+			"422,,,,\n"
+		imp, err := service.ParseStudyImport(model.StudyImportFormatCSV, []byte(data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imp).To(Equal(model.StudyParameterImport{
+			Seeds: []int64{420, 421, 422},
+			Steps: []int{20, 30},
+			CFGs:  []float64{3.5, 7},
+			SamplerSchedulerPairs: []model.SamplerSchedulerPair{
+				{Sampler: "euler", Scheduler: "simple"},
+				{Sampler: "dpmpp_2m", Scheduler: "karras"},
+			},
+		}))
+	})
+
+	It("leaves lists for absent columns nil", func() {
+		data := "\ufeffSeeds\n1\n2\n"
+		imp, err := service.ParseStudyImport(model.StudyImportFormatCSV, []byte(data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imp.Seeds).To(Equal([]int64{1, 2}))
+		Expect(imp.Steps).To(BeNil())
+		Expect(imp.CFGs).To(BeNil())
+		Expect(imp.SamplerSchedulerPairs).To(BeNil())
+	})
+
+	It("parses a JSON array of rows with numeric and string cells", func() {
+		data := `[
+			{"seeds": 420, "steps": 20, "cfgs": 3.5, "sampler": "euler", "scheduler": "simple"},
+			{"seeds": "421", "steps": null}
+		]`
+		imp, err := service.ParseStudyImport(model.StudyImportFormatJSON, []byte(data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imp.Seeds).To(Equal([]int64{420, 421}))
+		Expect(imp.Steps).To(Equal([]int{20}))
+		Expect(imp.CFGs).To(Equal([]float64{3.5}))
+		Expect(imp.SamplerSchedulerPairs).To(Equal([]model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}}))
+	})
+
+	DescribeTable("rejects invalid files with the offending row",
+		func(format model.StudyImportFormat, data string, expected string) {
+			_, err := service.ParseStudyImport(format, []byte(data))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("invalid import"))
+			Expect(err.Error()).To(ContainSubstring(expected))
+		},
+		Entry("non-integer seed", model.StudyImportFormatCSV, "seeds\n1\nabc\n", `row 3: seed "abc" is not an integer`),
+		Entry("duplicate seed", model.StudyImportFormatCSV, "seeds\n1\n2\n1\n", "row 4: duplicate seed 1 (first on row 2)"),
+		Entry("zero steps", model.StudyImportFormatCSV, "steps\n0\n", "row 2: steps must be positive"),
+		Entry("non-numeric cfg", model.StudyImportFormatCSV, "cfgs\nhigh\n", `row 2: cfg "high" is not a number`),
+		Entry("duplicate pair", model.StudyImportFormatCSV, "sampler,scheduler\neuler,simple\neuler,simple\n", "row 3: duplicate sampler/scheduler pair"),
+		Entry("sampler without scheduler", model.StudyImportFormatCSV, "sampler,scheduler\neuler,\n", "row 2: sampler and scheduler must both be set"),
+		Entry("line number after a quoted multi-line cell", model.StudyImportFormatCSV, "sampler,scheduler\n\"eul\ner\",simple\nx,\n", "row 4:"),
+		Entry("wrong number of fields", model.StudyImportFormatCSV, "seeds,steps\n1,2,3\n", "line 2"),
+		Entry("unknown column", model.StudyImportFormatCSV, "seeds,denoise\n1,0.5\n", `unknown column "denoise"`),
+		Entry("sampler column without scheduler column", model.StudyImportFormatCSV, "sampler\neuler\n", "sampler and scheduler columns must be used together"),
+		Entry("column without values", model.StudyImportFormatCSV, "seeds,steps\n1,\n", `column "steps" has no values`),
+		Entry("empty CSV", model.StudyImportFormatCSV, "", "empty file"),
+		Entry("JSON that is not an array", model.StudyImportFormatJSON, `{"seeds": [1, 2]}`, "expected a JSON array of row objects"),
+		Entry("JSON cell that is an array", model.StudyImportFormatJSON, `[{"seeds": 1}, {"seeds": [2]}]`, "row 2: seeds: value must be a number or a string"),
+		Entry("JSON duplicate steps", model.StudyImportFormatJSON, `[{"steps": 20}, {"steps": 20}]`, "row 2: duplicate steps 20 (first on row 1)"),
+		Entry("unsupported format", model.StudyImportFormat("xlsx"), "seeds\n1\n", `unsupported format "xlsx"`),
+	)
+})
+
+var _ = Describe("StudyService Import", func() {
+	var (
+		store *fakeStudyStore
+		svc   *service.StudyService
+	)
+
+	BeforeEach(func() {
+		store = newFakeStudyStore()
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewStudyService(store, newFakeSampleChecker(), logger)
+		store.studies["study-1"] = model.Study{
+			ID:                    "study-1",
+			Name:                  "Base",
+			Prompts:               []model.NamedPrompt{{Name: "p", Text: "a cat"}},
+			Steps:                 []int{20},
+			CFGs:                  []float64{7},
+			SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}},
+			Seeds:                 []int64{1},
+			Width:                 1024,
+			Height:                1024,
+			WorkflowTemplate:      "qwen-image.json",
+		}
+	})
+
+	It("replaces only the imported lists of the study", func() {
+		study, err := svc.Import(ctx, "study-1", "", model.StudyParameterImport{Seeds: []int64{420, 421}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(study.ID).To(Equal("study-1"))
+		Expect(study.Seeds).To(Equal([]int64{420, 421}))
+		Expect(study.Steps).To(Equal([]int{20}))
+		Expect(study.WorkflowTemplate).To(Equal("qwen-image.json"))
+		Expect(store.studies["study-1"].Seeds).To(Equal([]int64{420, 421}))
+	})
+
+	It("creates a new study when a name is given", func() {
+		study, err := svc.Import(ctx, "study-1", "Imported", model.StudyParameterImport{Steps: []int{10, 30}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(study.ID).NotTo(Equal("study-1"))
+		Expect(study.Name).To(Equal("Imported"))
+		Expect(study.Steps).To(Equal([]int{10, 30}))
+		Expect(study.Prompts).To(Equal(store.studies["study-1"].Prompts))
+		Expect(store.studies["study-1"].Steps).To(Equal([]int{20}))
+	})
+
+	It("returns not found for an unknown study", func() {
+		_, err := svc.Import(ctx, "missing", "", model.StudyParameterImport{Seeds: []int64{1}})
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+	})
+})
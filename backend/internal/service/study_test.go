@@ -1,9 +1,11 @@
 package service_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"io"
+	"slices"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -16,6 +18,8 @@ import (
 // fakeStudyStore is an in-memory test double for service.StudyStore.
 type fakeStudyStore struct {
 	studies      map[string]model.Study
+	versions     map[string][]model.StudyVersion
+	usedVersions map[string][]int // study ID -> versions sample jobs were created from
 	listErr      error
 	getErr       error
 	getByNameErr error
@@ -28,7 +32,7 @@ func newFakeStudyStore() *fakeStudyStore {
 	return &fakeStudyStore{studies: make(map[string]model.Study)}
 }
 
-func (f *fakeStudyStore) ListStudies() ([]model.Study, error) {
+func (f *fakeStudyStore) ListStudies(ctx context.Context) ([]model.Study, error) {
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
@@ -39,7 +43,7 @@ func (f *fakeStudyStore) ListStudies() ([]model.Study, error) {
 	return result, nil
 }
 
-func (f *fakeStudyStore) GetStudy(id string) (model.Study, error) {
+func (f *fakeStudyStore) GetStudy(ctx context.Context, id string) (model.Study, error) {
 	if f.getErr != nil {
 		return model.Study{}, f.getErr
 	}
@@ -50,7 +54,7 @@ func (f *fakeStudyStore) GetStudy(id string) (model.Study, error) {
 	return p, nil
 }
 
-func (f *fakeStudyStore) GetStudyByName(name string, excludeID string) (model.Study, error) {
+func (f *fakeStudyStore) GetStudyByName(ctx context.Context, name string, excludeID string) (model.Study, error) {
 	if f.getByNameErr != nil {
 		return model.Study{}, f.getByNameErr
 	}
@@ -62,7 +66,7 @@ func (f *fakeStudyStore) GetStudyByName(name string, excludeID string) (model.St
 	return model.Study{}, sql.ErrNoRows
 }
 
-func (f *fakeStudyStore) CreateStudy(p model.Study) error {
+func (f *fakeStudyStore) CreateStudy(ctx context.Context, p model.Study) error {
 	if f.createErr != nil {
 		return f.createErr
 	}
@@ -70,7 +74,7 @@ func (f *fakeStudyStore) CreateStudy(p model.Study) error {
 	return nil
 }
 
-func (f *fakeStudyStore) UpdateStudy(p model.Study) error {
+func (f *fakeStudyStore) UpdateStudy(ctx context.Context, p model.Study) error {
 	if f.updateErr != nil {
 		return f.updateErr
 	}
@@ -81,7 +85,7 @@ func (f *fakeStudyStore) UpdateStudy(p model.Study) error {
 	return nil
 }
 
-func (f *fakeStudyStore) DeleteStudy(id string) error {
+func (f *fakeStudyStore) DeleteStudy(ctx context.Context, id string) error {
 	if f.deleteErr != nil {
 		return f.deleteErr
 	}
@@ -92,6 +96,22 @@ func (f *fakeStudyStore) DeleteStudy(id string) error {
 	return nil
 }
 
+func (f *fakeStudyStore) ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error) {
+	return f.versions[studyID], nil
+}
+
+func (f *fakeStudyStore) CreateStudyVersion(ctx context.Context, v model.StudyVersion) error {
+	if f.versions == nil {
+		f.versions = make(map[string][]model.StudyVersion)
+	}
+	f.versions[v.StudyID] = append(f.versions[v.StudyID], v)
+	return nil
+}
+
+func (f *fakeStudyStore) StudyVersionInUse(ctx context.Context, studyID string, version int) (bool, error) {
+	return slices.Contains(f.usedVersions[studyID], version), nil
+}
+
 // fakeSampleChecker is a test double for service.StudySampleChecker.
 type fakeSampleChecker struct {
 	results map[string]bool
@@ -145,7 +165,7 @@ var _ = Describe("StudyService", func() {
 
 	Describe("List", func() {
 		It("returns empty slice when no studies exist", func() {
-			result, err := svc.List()
+			result, err := svc.List(ctx)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(HaveLen(0))
 		})
@@ -154,19 +174,56 @@ var _ = Describe("StudyService", func() {
 			store.studies["p1"] = model.Study{ID: "p1", Name: "One"}
 			store.studies["p2"] = model.Study{ID: "p2", Name: "Two"}
 
-			result, err := svc.List()
+			result, err := svc.List(ctx)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(HaveLen(2))
 		})
 
 		It("returns error when store fails", func() {
 			store.listErr = errors.New("db error")
-			_, err := svc.List()
+			_, err := svc.List(ctx)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("db error"))
 		})
 	})
 
+	Describe("ListByOwner", func() {
+		BeforeEach(func() {
+			store.studies["p1"] = model.Study{ID: "p1", Name: "Shared"}
+			store.studies["p2"] = model.Study{ID: "p2", Name: "Alice's", Owner: "alice"}
+			store.studies["p3"] = model.Study{ID: "p3", Name: "Bob's", Owner: "bob"}
+		})
+
+		names := func(studies []model.Study) []string {
+			result := make([]string, len(studies))
+			for i, st := range studies {
+				result[i] = st.Name
+			}
+			return result
+		}
+
+		It("lists every study for requests without a user", func() {
+			result, err := svc.ListByOwner(ctx, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(result)).To(ConsistOf("Shared", "Alice's", "Bob's"))
+		})
+
+		It("lists a user's own and the unowned studies by default", func() {
+			result, err := svc.ListByOwner(service.WithUser(ctx, model.User{Name: "alice"}), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(result)).To(ConsistOf("Shared", "Alice's"))
+		})
+
+		It("lists another user's studies for admin users only", func() {
+			_, err := svc.ListByOwner(service.WithUser(ctx, model.User{Name: "alice"}), "bob")
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+
+			result, err := svc.ListByOwner(service.WithUser(ctx, model.User{Name: "root", Admin: true}), "bob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(result)).To(ConsistOf("Bob's"))
+		})
+	})
+
 	Describe("Create", func() {
 		var validPrompts []model.NamedPrompt
 		var validSteps []int
@@ -187,7 +244,7 @@ var _ = Describe("StudyService", func() {
 		})
 
 		It("creates a study with valid inputs", func() {
-			result, err := svc.Create("Test", "", validPrompts, "negative", validSteps, validCFGs, validPairs, validSeeds, 1344, 1344, "", "", "", nil)
+			result, err := svc.Create(ctx, "Test", "", validPrompts, "negative", validSteps, validCFGs, validPairs, validSeeds, 1344, 1344, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.ID).NotTo(BeEmpty())
 			Expect(result.Name).To(Equal("Test"))
@@ -204,26 +261,41 @@ var _ = Describe("StudyService", func() {
 		})
 
 		It("uses study name as output dir name", func() {
-			result, err := svc.Create("OutputTest", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			result, err := svc.Create(ctx, "OutputTest", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.OutputDirName()).To(Equal("OutputTest"))
 		})
 
 		It("persists the study in the store", func() {
-			_, err := svc.Create("Stored", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Create(ctx, "Stored", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(store.studies).To(HaveLen(1))
 		})
 
+		It("records the request's user as the owner", func() {
+			result, err := svc.Create(service.WithUser(ctx, model.User{Name: "alice"}), "Owned", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Owner).To(Equal("alice"))
+			Expect(store.studies[result.ID].Owner).To(Equal("alice"))
+		})
+
+		It("builds the study without persisting it with NewStudy", func() {
+			result, err := svc.NewStudy(ctx, "Unsaved", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ID).NotTo(BeEmpty())
+			Expect(result.Name).To(Equal("Unsaved"))
+			Expect(store.studies).To(BeEmpty())
+		})
+
 		It("rejects empty name", func() {
-			_, err := svc.Create("", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Create(ctx, "", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("name must not be empty"))
 		})
 
 		It("returns error when store fails", func() {
 			store.createErr = errors.New("insert failed")
-			_, err := svc.Create("Test", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Create(ctx, "Test", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("insert failed"))
 		})
@@ -244,7 +316,7 @@ var _ = Describe("StudyService", func() {
 
 		DescribeTable("validates required fields and constraints",
 			func(tc validationTestCase) {
-				_, err := svc.Create(tc.name, "", tc.prompts, "", tc.steps, tc.cfgs, tc.pairs, tc.seeds, tc.width, tc.height, "", "", "", nil)
+				_, err := svc.Create(ctx, tc.name, "", tc.prompts, "", tc.steps, tc.cfgs, tc.pairs, tc.seeds, tc.width, tc.height, "", "", "", nil, "", nil, nil, "", 0, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring(tc.expectedError))
 			},
@@ -550,7 +622,7 @@ var _ = Describe("StudyService", func() {
 
 		// AC: BE: Disallowed characters are surfaced in the API error response
 		It("error message contains the disallowed character set after the sentinel phrase", func() {
-			_, err := svc.Create(`bad/name`, "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Create(ctx, `bad/name`, "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).To(HaveOccurred())
 			// The error message must contain the sentinel phrase followed by the characters,
 			// so the frontend can parse them without maintaining a duplicate constant.
@@ -565,7 +637,7 @@ var _ = Describe("StudyService", func() {
 
 		DescribeTable("validates study name filesystem safety",
 			func(tc filenameTestCase) {
-				_, err := svc.Create(tc.name, "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+				_, err := svc.Create(ctx, tc.name, "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 				if tc.expectError {
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring(tc.expectedError))
@@ -636,13 +708,19 @@ var _ = Describe("StudyService", func() {
 		})
 
 		It("rejects Create when a study with the same name already exists", func() {
-			_, err := svc.Create("Existing", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Create(ctx, "Existing", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("already exists"))
+		})
+
+		It("rejects NewStudy when a study with the same name already exists", func() {
+			_, err := svc.NewStudy(ctx, "Existing", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("already exists"))
 		})
 
 		It("allows Create when no study with that name exists", func() {
-			_, err := svc.Create("New Name", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Create(ctx, "New Name", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 		})
 
@@ -660,7 +738,7 @@ var _ = Describe("StudyService", func() {
 				Height:                512,
 			}
 			// Try to rename "Other" to "Existing" — should be rejected
-			_, err := svc.Update("other-id", "Existing", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Update(ctx, "other-id", "Existing", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("already exists"))
 		})
@@ -679,7 +757,7 @@ var _ = Describe("StudyService", func() {
 				Height:                512,
 			}
 			// Saving with the same name should succeed (self-exclusion)
-			_, err := svc.Update("self-id", "Self", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Update(ctx, "self-id", "Self", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
@@ -725,7 +803,7 @@ var _ = Describe("StudyService", func() {
 			newPairs := []model.SamplerSchedulerPair{
 				{Sampler: "dpmpp_2m", Scheduler: "sgm_uniform"},
 			}
-			result, err := svc.Update("existing", "Renamed", "", newPrompts, "new negative", validSteps, validCFGs, newPairs, validSeeds, 1344, 1344, "", "", "", nil)
+			result, err := svc.Update(ctx, "existing", "Renamed", "", newPrompts, "new negative", validSteps, validCFGs, newPairs, validSeeds, 1344, 1344, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.Name).To(Equal("Renamed"))
 			Expect(result.Prompts).To(Equal(newPrompts))
@@ -738,19 +816,19 @@ var _ = Describe("StudyService", func() {
 		})
 
 		It("does not change output directory structure on update", func() {
-			result, err := svc.Update("existing", "Original", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			result, err := svc.Update(ctx, "existing", "Original", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.OutputDirName()).To(Equal("Original"))
 		})
 
 		It("returns error for non-existent study", func() {
-			_, err := svc.Update("missing", "Name", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Update(ctx, "missing", "Name", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
 
 		It("rejects invalid inputs during update", func() {
-			_, err := svc.Update("existing", "", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Update(ctx, "existing", "", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("name must not be empty"))
 		})
@@ -791,7 +869,7 @@ var _ = Describe("StudyService", func() {
 			newPrompts := []model.NamedPrompt{
 				{Name: "new_prompt", Text: "forked prompt"},
 			}
-			result, err := svc.Fork("source", "Forked Study", "", newPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 1024, 1024, "", "", "", nil)
+			result, err := svc.Fork(ctx, "source", "Forked Study", "", newPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 1024, 1024, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result.ID).NotTo(Equal("source"))
 			Expect(result.Name).To(Equal("Forked Study"))
@@ -801,18 +879,260 @@ var _ = Describe("StudyService", func() {
 		})
 
 		It("returns error when source study does not exist", func() {
-			_, err := svc.Fork("nonexistent", "Forked", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Fork(ctx, "nonexistent", "Forked", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
 
 		It("rejects fork when new name already exists", func() {
-			_, err := svc.Fork("source", "Source Study", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil)
+			_, err := svc.Fork(ctx, "source", "Source Study", "", validPrompts, "", validSteps, validCFGs, validPairs, validSeeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("already exists"))
 		})
 	})
 
+	Describe("versions", func() {
+		var (
+			prompts []model.NamedPrompt
+			pairs   []model.SamplerSchedulerPair
+		)
+
+		BeforeEach(func() {
+			prompts = []model.NamedPrompt{{Name: "prompt1", Text: "a test prompt"}}
+			pairs = []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}}
+			store.studies["existing"] = model.Study{
+				ID:                    "existing",
+				Name:                  "Original",
+				Version:               1,
+				Prompts:               prompts,
+				Steps:                 []int{20},
+				CFGs:                  []float64{7},
+				SamplerSchedulerPairs: pairs,
+				Seeds:                 []int64{100},
+				SeedMode:              model.SeedModeExplicit,
+				Width:                 512,
+				Height:                512,
+			}
+		})
+
+		It("starts new studies at version 1", func() {
+			result, err := svc.Create(ctx, "New", "", prompts, "", []int{20}, []float64{7}, pairs, []int64{1}, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Version).To(Equal(1))
+		})
+
+		It("edits a version no job used in place", func() {
+			result, err := svc.Update(ctx, "existing", "Original", "", prompts, "", []int{30}, []float64{7}, pairs, []int64{100}, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Version).To(Equal(1))
+			Expect(store.versions["existing"]).To(BeEmpty())
+		})
+
+		It("archives a version a job used before editing it", func() {
+			store.usedVersions = map[string][]int{"existing": {1}}
+
+			result, err := svc.Update(ctx, "existing", "Original", "", prompts, "", []int{30}, []float64{7}, pairs, []int64{100}, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Version).To(Equal(2))
+			Expect(result.Steps).To(Equal([]int{30}))
+			Expect(store.studies["existing"].Version).To(Equal(2))
+
+			Expect(store.versions["existing"]).To(HaveLen(1))
+			archived := store.versions["existing"][0]
+			Expect(archived.Version).To(Equal(1))
+			Expect(archived.Study.Version).To(Equal(1))
+			Expect(archived.Study.Steps).To(Equal([]int{20}))
+			Expect(archived.CreatedAt).NotTo(BeZero())
+		})
+
+		It("returns the archived versions followed by the current one", func() {
+			store.usedVersions = map[string][]int{"existing": {1}}
+			_, err := svc.Update(ctx, "existing", "Original", "", prompts, "", []int{30}, []float64{7}, pairs, []int64{100}, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			versions, err := svc.Versions(ctx, "existing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(HaveLen(2))
+			Expect(versions[0].Version).To(Equal(1))
+			Expect(versions[1].Version).To(Equal(2))
+			Expect(versions[1].CreatedAt).To(BeZero())
+			Expect(versions[1].Study.Steps).To(Equal([]int{30}))
+		})
+
+		It("returns a study as it was at a version", func() {
+			store.usedVersions = map[string][]int{"existing": {1}}
+			_, err := svc.Update(ctx, "existing", "Original", "", prompts, "", []int{30}, []float64{7}, pairs, []int64{100}, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			v1, err := svc.Version(ctx, "existing", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v1.Steps).To(Equal([]int{20}))
+			v2, err := svc.Version(ctx, "existing", 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v2.Steps).To(Equal([]int{30}))
+			_, err = svc.Version(ctx, "existing", 3)
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+			_, err = svc.Version(ctx, "missing", 1)
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+
+	Describe("Duplicate", func() {
+		BeforeEach(func() {
+			store.studies["source"] = model.Study{
+				ID:                    "source",
+				Name:                  "Sweep",
+				Version:               3,
+				PromptPrefix:          "photo, ",
+				Prompts:               []model.NamedPrompt{{Name: "forest", Text: "a forest"}},
+				Steps:                 []int{20},
+				CFGs:                  []float64{7},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+				SeedMode:              model.SeedModeRandomPerItem,
+				RandomSeedCount:       2,
+				Width:                 512,
+				Height:                512,
+				WorkflowTemplate:      "flow.json",
+			}
+		})
+
+		It("copies the settings of the source under a new name at version 1", func() {
+			result, err := svc.Duplicate(ctx, "source", "Sweep again")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ID).NotTo(Equal("source"))
+			Expect(result.Name).To(Equal("Sweep again"))
+			Expect(result.Version).To(Equal(1))
+			Expect(result.PromptPrefix).To(Equal("photo, "))
+			Expect(result.SeedMode).To(Equal(model.SeedModeRandomPerItem))
+			Expect(result.RandomSeedCount).To(Equal(2))
+			Expect(result.WorkflowTemplate).To(Equal("flow.json"))
+			Expect(store.studies).To(HaveKey(result.ID))
+		})
+
+		It("names the copy after the source when no name is given", func() {
+			first, err := svc.Duplicate(ctx, "source", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(first.Name).To(Equal("Sweep copy"))
+
+			second, err := svc.Duplicate(ctx, "source", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.Name).To(Equal("Sweep copy 2"))
+		})
+
+		It("rejects a name another study has", func() {
+			_, err := svc.Duplicate(ctx, "source", "Sweep")
+			Expect(err).To(MatchError(ContainSubstring("already exists")))
+		})
+
+		It("returns error when source study does not exist", func() {
+			_, err := svc.Duplicate(ctx, "missing", "")
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+
+	Describe("Export", func() {
+		BeforeEach(func() {
+			store.studies["a"] = model.Study{ID: "a", Name: "A"}
+			store.studies["b"] = model.Study{ID: "b", Name: "B"}
+		})
+
+		It("exports all studies when no ID is given", func() {
+			studies, err := svc.Export(ctx, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(studies).To(HaveLen(2))
+		})
+
+		It("exports the study with the given ID", func() {
+			studies, err := svc.Export(ctx, "b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(studies).To(HaveLen(1))
+			Expect(studies[0].Name).To(Equal("B"))
+		})
+
+		It("returns error for non-existent study", func() {
+			_, err := svc.Export(ctx, "missing")
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+
+	Describe("ImportStudies", func() {
+		var imported model.Study
+
+		BeforeEach(func() {
+			store.studies["existing"] = model.Study{
+				ID:                    "existing",
+				Name:                  "Sweep",
+				Version:               1,
+				Prompts:               []model.NamedPrompt{{Name: "forest", Text: "a forest"}},
+				Steps:                 []int{20},
+				CFGs:                  []float64{7},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+				Seeds:                 []int64{1},
+				Width:                 512,
+				Height:                512,
+			}
+			imported = model.Study{
+				Name:                  "Sweep",
+				Prompts:               []model.NamedPrompt{{Name: "city", Text: "a city"}},
+				Steps:                 []int{30},
+				CFGs:                  []float64{3.5},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+				Seeds:                 []int64{42},
+				Width:                 1024,
+				Height:                1024,
+			}
+		})
+
+		It("creates a study whose name is free", func() {
+			imported.Name = "Fresh"
+			entries, err := svc.ImportStudies(ctx, []model.Study{imported}, model.ImportConflictRename)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Action).To(Equal(model.ImportActionCreated))
+			Expect(store.studies[entries[0].ID].Name).To(Equal("Fresh"))
+			Expect(store.studies[entries[0].ID].Version).To(Equal(1))
+		})
+
+		It("renames a study whose name is taken", func() {
+			entries, err := svc.ImportStudies(ctx, []model.Study{imported, imported}, model.ImportConflictRename)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries[0].Name).To(Equal("Sweep 2"))
+			Expect(entries[0].Action).To(Equal(model.ImportActionRenamed))
+			Expect(entries[1].Name).To(Equal("Sweep 3"))
+			Expect(store.studies[entries[0].ID].Steps).To(Equal([]int{30}))
+			Expect(store.studies["existing"].Steps).To(Equal([]int{20}))
+		})
+
+		It("overwrites a study whose name is taken, archiving a version jobs used", func() {
+			store.usedVersions = map[string][]int{"existing": {1}}
+			entries, err := svc.ImportStudies(ctx, []model.Study{imported}, model.ImportConflictOverwrite)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries[0].ID).To(Equal("existing"))
+			Expect(entries[0].Action).To(Equal(model.ImportActionOverwritten))
+			Expect(store.studies["existing"].Steps).To(Equal([]int{30}))
+			Expect(store.studies["existing"].Version).To(Equal(2))
+			Expect(store.versions["existing"]).To(HaveLen(1))
+		})
+
+		It("skips a study whose name is taken", func() {
+			entries, err := svc.ImportStudies(ctx, []model.Study{imported}, model.ImportConflictSkip)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries[0].ID).To(Equal("existing"))
+			Expect(entries[0].Action).To(Equal(model.ImportActionSkipped))
+			Expect(store.studies["existing"].Steps).To(Equal([]int{20}))
+		})
+
+		It("imports nothing when a study is invalid", func() {
+			invalid := imported
+			invalid.Name = "Broken"
+			invalid.Steps = nil
+			imported.Name = "Fresh"
+			_, err := svc.ImportStudies(ctx, []model.Study{imported, invalid}, model.ImportConflictRename)
+			Expect(err).To(MatchError(ContainSubstring(`studies[1] "Broken"`)))
+			Expect(store.studies).To(HaveLen(1))
+		})
+	})
+
 	Describe("HasSamples", func() {
 		BeforeEach(func() {
 			store.studies["with-samples"] = model.Study{ID: "with-samples", Name: "Has Samples"}
@@ -823,26 +1143,26 @@ var _ = Describe("StudyService", func() {
 		})
 
 		It("returns true when study has samples on disk", func() {
-			hasSamples, err := svc.HasSamples("with-samples")
+			hasSamples, err := svc.HasSamples(ctx, "with-samples")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(hasSamples).To(BeTrue())
 		})
 
 		It("returns false when study has no samples", func() {
-			hasSamples, err := svc.HasSamples("no-samples")
+			hasSamples, err := svc.HasSamples(ctx, "no-samples")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(hasSamples).To(BeFalse())
 		})
 
 		It("returns error for non-existent study", func() {
-			_, err := svc.HasSamples("nonexistent")
+			_, err := svc.HasSamples(ctx, "nonexistent")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
 
 		It("returns error when sample checker fails", func() {
 			sampleChecker.err = errors.New("fs error")
-			_, err := svc.HasSamples("with-samples")
+			_, err := svc.HasSamples(ctx, "with-samples")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fs error"))
 		})
@@ -855,17 +1175,27 @@ var _ = Describe("StudyService", func() {
 
 		// AC: BE: Deleting a study without the data flag removes only the database record
 		It("deletes an existing study without removing sample data when deleteData=false", func() {
-			err := svc.Delete("to-delete", false)
+			err := svc.Delete(ctx, "to-delete", false)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(store.studies).NotTo(HaveKey("to-delete"))
 		})
 
 		// AC: BE: Deleting a study without the data flag removes only the database record
 		It("returns error for non-existent study", func() {
-			err := svc.Delete("nonexistent", false)
+			err := svc.Delete(ctx, "nonexistent", false)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 		})
+
+		It("forbids deleting another user's study", func() {
+			store.studies["owned"] = model.Study{ID: "owned", Name: "Owned", Owner: "bob"}
+
+			err := svc.Delete(service.WithUser(ctx, model.User{Name: "alice"}), "owned", false)
+			Expect(err).To(MatchError(HavePrefix("forbidden:")))
+			Expect(store.studies).To(HaveKey("owned"))
+
+			Expect(svc.Delete(service.WithUser(ctx, model.User{Name: "bob"}), "owned", false)).To(Succeed())
+		})
 	})
 
 	Describe("Delete with sample data removal", func() {
@@ -880,7 +1210,7 @@ var _ = Describe("StudyService", func() {
 
 		// AC: BE: Deleting a study with the data flag also removes the study's sample output directory
 		It("removes the sample directory when deleteData=true", func() {
-			err := svc.Delete("study-with-data", true)
+			err := svc.Delete(ctx, "study-with-data", true)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(store.studies).NotTo(HaveKey("study-with-data"))
 			Expect(remover.removed).To(ConsistOf("My Study"))
@@ -888,7 +1218,7 @@ var _ = Describe("StudyService", func() {
 
 		// AC: BE: Deleting a study without the data flag removes only the database record
 		It("does not remove the sample directory when deleteData=false", func() {
-			err := svc.Delete("study-no-data", false)
+			err := svc.Delete(ctx, "study-no-data", false)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(store.studies).NotTo(HaveKey("study-no-data"))
 			Expect(remover.removed).To(BeEmpty())
@@ -896,14 +1226,22 @@ var _ = Describe("StudyService", func() {
 
 		// AC: BE: Test delete of study with no sample data (should work gracefully)
 		It("succeeds when deleteData=true and study has no sample directory", func() {
-			err := svc.Delete("study-with-data", true)
+			err := svc.Delete(ctx, "study-with-data", true)
 			Expect(err).NotTo(HaveOccurred())
 			// No-op removal should not cause an error
 		})
 
+		It("keeps the sample directory when it contains pinned content", func() {
+			svc.WithPinGuard(&fakePinGuard{protected: map[string]bool{"My Study": true}})
+			err := svc.Delete(ctx, "study-with-data", true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.studies).NotTo(HaveKey("study-with-data"))
+			Expect(remover.removed).To(BeEmpty())
+		})
+
 		It("returns error when sample directory removal fails", func() {
 			remover.err = errors.New("permission denied")
-			err := svc.Delete("study-with-data", true)
+			err := svc.Delete(ctx, "study-with-data", true)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("permission denied"))
 			// DB record should NOT be deleted since filesystem removal failed
@@ -911,7 +1249,7 @@ var _ = Describe("StudyService", func() {
 		})
 
 		It("returns error for non-existent study even with deleteData=true", func() {
-			err := svc.Delete("nonexistent", true)
+			err := svc.Delete(ctx, "nonexistent", true)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("not found"))
 			Expect(remover.removed).To(BeEmpty())
@@ -934,7 +1272,7 @@ var _ = Describe("StudyService", func() {
 			}
 			seeds := []int64{420, 421}
 
-			result, err := svc.Create("Test", "", prompts, "", steps, cfgs, pairs, seeds, 512, 512, "", "", "", nil)
+			result, err := svc.Create(ctx, "Test", "", prompts, "", steps, cfgs, pairs, seeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 			// 2 prompts * 2 steps * 2 cfgs * 2 pairs * 2 seeds = 32
 			Expect(result.ImagesPerCheckpoint()).To(Equal(32))
@@ -951,10 +1289,158 @@ var _ = Describe("StudyService", func() {
 			}
 			seeds := []int64{420}
 
-			result, err := svc.Create("Test", "", prompts, "", steps, cfgs, pairs, seeds, 512, 512, "", "", "", nil)
+			result, err := svc.Create(ctx, "Test", "", prompts, "", steps, cfgs, pairs, seeds, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
 			Expect(err).NotTo(HaveOccurred())
 			// 1 prompt * 1 step * 1 cfg * 1 pair * 1 seed = 1
 			Expect(result.ImagesPerCheckpoint()).To(Equal(1))
 		})
 	})
+
+	Describe("per-prompt overrides", func() {
+		pairs := []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}}
+
+		It("stores the overrides and counts each prompt's images with them", func() {
+			prompts := []model.NamedPrompt{
+				{Name: "portrait", Text: "a portrait", Width: 832, Height: 1216, Steps: []int{20, 30}},
+				{Name: "landscape", Text: "a landscape", Width: 1216, Height: 832},
+			}
+			result, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, []int64{420}, 1024, 1024, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Prompts).To(Equal(prompts))
+			Expect(result.ImagesPerCheckpoint()).To(Equal(3))
+		})
+
+		DescribeTable("rejects invalid overrides",
+			func(prompt model.NamedPrompt, expectedError string) {
+				prompt.Name, prompt.Text = "p1", "text1"
+				_, err := svc.Create(ctx, "Test", "", []model.NamedPrompt{prompt}, "", []int{4}, []float64{1.0}, pairs, []int64{420}, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+				Expect(err).To(MatchError(ContainSubstring(expectedError)))
+			},
+			Entry("non-positive step", model.NamedPrompt{Steps: []int{0}}, `prompt "p1": step 0 must be positive`),
+			Entry("duplicate CFG", model.NamedPrompt{CFGs: []float64{3, 3}}, `prompt "p1": duplicate CFG value 3`),
+			Entry("duplicate seed", model.NamedPrompt{Seeds: []int64{7, 7}}, `prompt "p1": duplicate seed value 7`),
+			Entry("negative width", model.NamedPrompt{Width: -1}, `prompt "p1": width must not be negative`),
+		)
+	})
+
+	Describe("img2img settings", func() {
+		var (
+			prompts = []model.NamedPrompt{{Name: "p1", Text: "text1"}}
+			pairs   = []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}}
+		)
+
+		It("stores the input image and multiplies images per checkpoint by the denoise strengths", func() {
+			result, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, []int64{420}, 512, 512, "", "", "", nil, "/refs/portrait.png", []float64{0.4, 0.7}, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.InputImage).To(Equal("/refs/portrait.png"))
+			Expect(result.DenoiseStrengths).To(Equal([]float64{0.4, 0.7}))
+			Expect(result.ImagesPerCheckpoint()).To(Equal(2))
+		})
+
+		It("keeps the workflow's denoise when an input image has no strengths", func() {
+			result, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, []int64{420}, 512, 512, "", "", "", nil, "/refs/portrait.png", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ImagesPerCheckpoint()).To(Equal(1))
+		})
+
+		DescribeTable("rejects invalid denoise strengths",
+			func(inputImage string, strengths []float64, expectedError string) {
+				_, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, []int64{420}, 512, 512, "", "", "", nil, inputImage, strengths, nil, "", 0, nil)
+				Expect(err).To(MatchError(ContainSubstring(expectedError)))
+			},
+			Entry("without an input image", "", []float64{0.5}, "denoise strengths require an input image"),
+			Entry("zero", "/refs/portrait.png", []float64{0}, "denoise strength 0 must be greater than 0 and at most 1"),
+			Entry("above one", "/refs/portrait.png", []float64{0.5, 1.5}, "denoise strength 1 must be greater than 0 and at most 1"),
+			Entry("duplicates", "/refs/portrait.png", []float64{0.5, 0.5}, "duplicate denoise strength 0.5"),
+		)
+	})
+
+	Describe("resolutions", func() {
+		var (
+			prompts = []model.NamedPrompt{{Name: "p1", Text: "text1"}}
+			pairs   = []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}}
+		)
+
+		It("stores the resolutions and multiplies images per checkpoint by them", func() {
+			resolutions := []model.Resolution{{Width: 832, Height: 1216}, {Width: 1216, Height: 832}}
+			result, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, []int64{420}, 512, 512, "", "", "", nil, "", nil, nil, "", 0, resolutions)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Resolutions).To(Equal(resolutions))
+			Expect(result.ImagesPerCheckpoint()).To(Equal(2))
+		})
+
+		DescribeTable("rejects invalid resolutions",
+			func(resolutions []model.Resolution, expectedError string) {
+				_, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, []int64{420}, 512, 512, "", "", "", nil, "", nil, nil, "", 0, resolutions)
+				Expect(err).To(MatchError(ContainSubstring(expectedError)))
+			},
+			Entry("zero width", []model.Resolution{{Width: 0, Height: 512}}, "resolution 0 width and height must be positive"),
+			Entry("negative height", []model.Resolution{{Width: 512, Height: 512}, {Width: 512, Height: -1}}, "resolution 1 width and height must be positive"),
+			Entry("duplicates", []model.Resolution{{Width: 512, Height: 768}, {Width: 512, Height: 768}}, "duplicate resolution 512x768"),
+		)
+	})
+
+	Describe("wildcards", func() {
+		var (
+			prompts = []model.NamedPrompt{{Name: "p1", Text: "a {color} car"}}
+			pairs   = []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}}
+		)
+
+		It("stores the wildcards and multiplies images per checkpoint by their values", func() {
+			wildcards := []model.Wildcard{{Name: "color", Values: []string{"red", "blue"}}}
+			result, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, []int64{420}, 512, 512, "", "", "", nil, "", nil, wildcards, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Wildcards).To(Equal(wildcards))
+			Expect(result.ImagesPerCheckpoint()).To(Equal(2))
+		})
+
+		DescribeTable("rejects invalid wildcards",
+			func(wildcards []model.Wildcard, expectedError string) {
+				_, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, []int64{420}, 512, 512, "", "", "", nil, "", nil, wildcards, "", 0, nil)
+				Expect(err).To(MatchError(ContainSubstring(expectedError)))
+			},
+			Entry("uppercase name", []model.Wildcard{{Name: "Color", Values: []string{"red"}}}, `wildcard 0 name "Color" must start with a lowercase letter`),
+			Entry("reserved name", []model.Wildcard{{Name: "seed", Values: []string{"1"}}}, `wildcard name "seed" is reserved`),
+			Entry("duplicate name", []model.Wildcard{{Name: "color", Values: []string{"red"}}, {Name: "color", Values: []string{"blue"}}}, `duplicate wildcard name "color"`),
+			Entry("no values", []model.Wildcard{{Name: "color"}}, `wildcard "color" needs at least one value`),
+			Entry("empty value", []model.Wildcard{{Name: "color", Values: []string{"red", ""}}}, `wildcard "color" value 1 must not be empty`),
+			Entry("duplicate value", []model.Wildcard{{Name: "color", Values: []string{"red", "red"}}}, `duplicate value "red" of wildcard "color"`),
+		)
+	})
+
+	Describe("seed modes", func() {
+		var (
+			prompts = []model.NamedPrompt{{Name: "p1", Text: "a car"}}
+			pairs   = []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}}
+		)
+
+		It("defaults to explicit seeds", func() {
+			result, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, []int64{420, 421}, 512, 512, "", "", "", nil, "", nil, nil, "", 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.SeedMode).To(Equal(model.SeedModeExplicit))
+			Expect(result.ImagesPerCheckpoint()).To(Equal(2))
+		})
+
+		It("counts the random seed count in images per checkpoint", func() {
+			result, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, nil, 512, 512, "", "", "", nil, "", nil, nil, model.SeedModeRandomPerCheckpoint, 4, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.SeedMode).To(Equal(model.SeedModeRandomPerCheckpoint))
+			Expect(result.RandomSeedCount).To(Equal(4))
+			Expect(result.Seeds).To(BeEmpty())
+			Expect(result.ImagesPerCheckpoint()).To(Equal(4))
+		})
+
+		DescribeTable("rejects inconsistent seed settings",
+			func(prompts []model.NamedPrompt, seeds []int64, mode model.SeedMode, count int, expectedError string) {
+				_, err := svc.Create(ctx, "Test", "", prompts, "", []int{4}, []float64{1.0}, pairs, seeds, 512, 512, "", "", "", nil, "", nil, nil, mode, count, nil)
+				Expect(err).To(MatchError(ContainSubstring(expectedError)))
+			},
+			Entry("explicit without seeds", prompts, nil, model.SeedModeExplicit, 0, "at least one seed"),
+			Entry("explicit with a count", prompts, []int64{420}, model.SeedModeExplicit, 2, "random seed count requires a random seed mode"),
+			Entry("random with seeds", prompts, []int64{420}, model.SeedModeRandomPerItem, 2, "seed mode random_per_item draws its seeds; the seed list must be empty"),
+			Entry("random without a count", prompts, nil, model.SeedModeRandomPerCheckpoint, 0, "requires a random seed count of at least 1"),
+			Entry("random with prompt seeds", []model.NamedPrompt{{Name: "p1", Text: "a car", Seeds: []int64{1}}}, nil, model.SeedModeRandomPerItem, 2, `prompt "p1": seed mode random_per_item draws its seeds; prompts cannot override them`),
+			Entry("unknown mode", prompts, nil, model.SeedMode("sometimes"), 2, `unknown seed mode "sometimes"`),
+		)
+	})
 })
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Export returns the study with the given ID, or the studies ListByOwner
+// returns by default when id is empty, for export as a portable document.
+func (s *StudyService) Export(ctx context.Context, id string) ([]model.Study, error) {
+	s.logger.WithField("study_id", id).Trace("entering Export")
+	defer s.logger.Trace("returning from Export")
+
+	if id == "" {
+		return s.ListByOwner(ctx, "")
+	}
+	study, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return []model.Study{study}, nil
+}
+
+// ImportStudies creates the studies of an exported document, matching them
+// to existing studies by name and resolving a name conflict by policy. Only
+// the settings of the given studies are used; IDs, versions, and timestamps
+// are those of the server, and the created studies are owned by the
+// request's user. Every study is validated before any is imported,
+// so an invalid document imports nothing.
+func (s *StudyService) ImportStudies(ctx context.Context, studies []model.Study, policy model.ImportConflictPolicy) ([]model.ImportedEntry, error) {
+	s.logger.WithFields(logrus.Fields{
+		"study_count": len(studies),
+		"on_conflict": policy,
+	}).Trace("entering ImportStudies")
+	defer s.logger.Trace("returning from ImportStudies")
+
+	if err := validateImportConflictPolicy(policy); err != nil {
+		return nil, err
+	}
+	for i, st := range studies {
+		if err := s.validate(st.Name, st.Prompts, st.Steps, st.CFGs, st.SamplerSchedulerPairs, st.Seeds, st.Width, st.Height, st.InputImage, st.DenoiseStrengths, st.Wildcards, st.SeedMode, st.RandomSeedCount, st.Resolutions); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"index":      i,
+				"study_name": st.Name,
+				"error":      err.Error(),
+			}).Warn("imported study validation failed")
+			return nil, fmt.Errorf("studies[%d] %q: %w", i, st.Name, err)
+		}
+	}
+
+	entries := make([]model.ImportedEntry, 0, len(studies))
+	for i, st := range studies {
+		entry, err := s.importStudy(ctx, st, policy)
+		if err != nil {
+			return entries, fmt.Errorf("studies[%d] %q: %w", i, st.Name, err)
+		}
+		entries = append(entries, entry)
+	}
+	s.logger.WithField("study_count", len(entries)).Info("studies imported")
+	return entries, nil
+}
+
+// importStudy imports one validated study.
+func (s *StudyService) importStudy(ctx context.Context, st model.Study, policy model.ImportConflictPolicy) (model.ImportedEntry, error) {
+	entry := model.ImportedEntry{SourceName: st.Name, Name: st.Name, Action: model.ImportActionCreated}
+	existing, err := s.store.GetStudyByName(ctx, st.Name, "")
+	if err != nil && err != sql.ErrNoRows {
+		s.logger.WithFields(logrus.Fields{
+			"study_name": st.Name,
+			"error":      err.Error(),
+		}).Error("failed to look up study by name")
+		return model.ImportedEntry{}, fmt.Errorf("looking up study by name: %w", err)
+	}
+	if err == nil {
+		switch policy {
+		case model.ImportConflictSkip:
+			entry.ID, entry.Action = existing.ID, model.ImportActionSkipped
+			s.logger.WithField("study_name", st.Name).Debug("skipped importing existing study")
+			return entry, nil
+		case model.ImportConflictOverwrite:
+			updated, err := s.Update(ctx, existing.ID, st.Name, st.PromptPrefix, st.Prompts, st.NegativePrompt, st.Steps, st.CFGs, st.SamplerSchedulerPairs, st.Seeds, st.Width, st.Height, st.WorkflowTemplate, st.VAE, st.TextEncoder, st.Shift, st.InputImage, st.DenoiseStrengths, st.Wildcards, st.SeedMode, st.RandomSeedCount, st.Resolutions)
+			if err != nil {
+				return model.ImportedEntry{}, err
+			}
+			entry.ID, entry.Action = updated.ID, model.ImportActionOverwritten
+			return entry, nil
+		default:
+			if entry.Name, err = s.freeName(ctx, st.Name); err != nil {
+				return model.ImportedEntry{}, err
+			}
+			entry.Action = model.ImportActionRenamed
+		}
+	}
+	created, err := s.Create(ctx, entry.Name, st.PromptPrefix, st.Prompts, st.NegativePrompt, st.Steps, st.CFGs, st.SamplerSchedulerPairs, st.Seeds, st.Width, st.Height, st.WorkflowTemplate, st.VAE, st.TextEncoder, st.Shift, st.InputImage, st.DenoiseStrengths, st.Wildcards, st.SeedMode, st.RandomSeedCount, st.Resolutions)
+	if err != nil {
+		return model.ImportedEntry{}, err
+	}
+	entry.ID = created.ID
+	return entry, nil
+}
+
+// validateImportConflictPolicy rejects an unknown conflict policy.
+func validateImportConflictPolicy(policy model.ImportConflictPolicy) error {
+	switch policy {
+	case model.ImportConflictRename, model.ImportConflictOverwrite, model.ImportConflictSkip:
+		return nil
+	}
+	return fmt.Errorf("unknown conflict policy %q; expected rename, overwrite, or skip", policy)
+}
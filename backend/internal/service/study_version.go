@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// StudyVersionLister lists the archived versions of a study, oldest first.
+type StudyVersionLister interface {
+	ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error)
+}
+
+// Versions returns the version history of a study, oldest first: its
+// archived versions followed by its current version, which has a zero
+// CreatedAt since it has not been archived.
+func (s *StudyService) Versions(ctx context.Context, id string) ([]model.StudyVersion, error) {
+	s.logger.WithField("study_id", id).Trace("entering Versions")
+	defer s.logger.Trace("returning from Versions")
+
+	study, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.store.ListStudyVersions(ctx, id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"study_id": id,
+			"error":    err.Error(),
+		}).Error("failed to list study versions")
+		return nil, fmt.Errorf("listing study versions: %w", err)
+	}
+	versions = append(versions, model.StudyVersion{StudyID: id, Version: study.Version, Study: study})
+	s.logger.WithFields(logrus.Fields{
+		"study_id":      id,
+		"version_count": len(versions),
+	}).Debug("study versions retrieved from store")
+	return versions, nil
+}
+
+// Version returns a study as it was at version, e.g. the version a sample
+// job was created from.
+func (s *StudyService) Version(ctx context.Context, id string, version int) (model.Study, error) {
+	s.logger.WithFields(logrus.Fields{
+		"study_id": id,
+		"version":  version,
+	}).Trace("entering Version")
+	defer s.logger.Trace("returning from Version")
+
+	study, err := s.Get(ctx, id)
+	if err != nil {
+		return model.Study{}, err
+	}
+	return studyAtVersion(ctx, s.store, study, version)
+}
+
+// studyAtVersion returns study as it was at version: study itself at its
+// current version, otherwise the archived version. A version that neither
+// is, e.g. one the study has not reached, is reported as not found.
+func studyAtVersion(ctx context.Context, lister StudyVersionLister, study model.Study, version int) (model.Study, error) {
+	if version == study.Version {
+		return study, nil
+	}
+	versions, err := lister.ListStudyVersions(ctx, study.ID)
+	if err != nil {
+		return model.Study{}, fmt.Errorf("listing study versions: %w", err)
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			return v.Study, nil
+		}
+	}
+	return model.Study{}, fmt.Errorf("version %d of study %s not found", version, study.ID)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultSyncLimit is the number of changes a sync returns when no limit
+	// is given.
+	DefaultSyncLimit = 500
+	// MaxSyncLimit is the largest number of changes a sync returns.
+	MaxSyncLimit = 5000
+)
+
+// SyncStore defines the persistence operations the sync service needs.
+type SyncStore interface {
+	ListChanges(ctx context.Context, since int64, limit int) ([]model.Change, error)
+	GetSampleJob(ctx context.Context, id string) (model.SampleJob, error)
+	CountSampleJobItemsByStatus(ctx context.Context, jobID string) (model.ItemStatusCounts, error)
+	GetStudy(ctx context.Context, id string) (model.Study, error)
+	GetPreset(ctx context.Context, id string) (model.Preset, error)
+	GetIndexedImageDir(ctx context.Context, dir string) (model.IndexedImageDir, error)
+}
+
+// SyncService reports what changed since a cursor, so that a client that
+// was away (a browser tab waking from sleep, say) can bring its state up to
+// date without fetching everything again.
+//
+// Changes are read from the store's change log, which keeps only the latest
+// change of each entity, and the entities are returned as they are now. An
+// entity changed again after it was read is reported once more by the next
+// sync, so applying a sync result is idempotent.
+type SyncService struct {
+	store  SyncStore
+	logger *logrus.Entry
+}
+
+// NewSyncService creates a SyncService backed by the given store.
+func NewSyncService(store SyncStore, logger *logrus.Logger) *SyncService {
+	return &SyncService{
+		store:  store,
+		logger: logger.WithField("component", "sync"),
+	}
+}
+
+// Since returns the entities that changed after cursor since, up to limit
+// changes. A since of 0 returns every entity. A limit outside 1 to
+// MaxSyncLimit falls back to DefaultSyncLimit or MaxSyncLimit.
+func (s *SyncService) Since(ctx context.Context, since int64, limit int) (model.SyncResult, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithFields(logrus.Fields{
+		"since": since,
+		"limit": limit,
+	}).Trace("entering Since")
+	defer log.Trace("returning from Since")
+
+	if limit <= 0 {
+		limit = DefaultSyncLimit
+	}
+	if limit > MaxSyncLimit {
+		limit = MaxSyncLimit
+	}
+
+	// One extra change tells whether more remain.
+	changes, err := s.store.ListChanges(ctx, since, limit+1)
+	if err != nil {
+		log.WithError(err).Error("failed to list changes")
+		return model.SyncResult{}, fmt.Errorf("listing changes: %w", err)
+	}
+	result := model.SyncResult{
+		Cursor:        since,
+		Jobs:          []model.SampleJob{},
+		JobItemCounts: map[string]model.ItemStatusCounts{},
+		Studies:       []model.Study{},
+		Presets:       []model.Preset{},
+		ImageDirs:     []model.IndexedImageDir{},
+		Deleted:       []model.Change{},
+	}
+	if len(changes) > limit {
+		changes = changes[:limit]
+		result.More = true
+	}
+
+	for _, c := range changes {
+		result.Cursor = c.Seq
+		found, err := s.load(ctx, &result, c)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"entity":    c.Entity,
+				"entity_id": c.EntityID,
+				"error":     err.Error(),
+			}).Error("failed to load changed entity")
+			return model.SyncResult{}, fmt.Errorf("loading %s %s: %w", c.Entity, c.EntityID, err)
+		}
+		if !found {
+			result.Deleted = append(result.Deleted, c)
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"since":         since,
+		"cursor":        result.Cursor,
+		"change_count":  len(changes),
+		"deleted_count": len(result.Deleted),
+		"more":          result.More,
+	}).Debug("computed sync result")
+	return result, nil
+}
+
+// load adds the current state of the entity changed by c to result. found
+// is false if the entity no longer exists. Changes to unknown entities are
+// ignored.
+func (s *SyncService) load(ctx context.Context, result *model.SyncResult, c model.Change) (found bool, err error) {
+	log := requestLogger(ctx, s.logger)
+	switch c.Entity {
+	case model.ChangeEntitySampleJob:
+		j, err := s.store.GetSampleJob(ctx, c.EntityID)
+		if err != nil {
+			return false, ignoreNotFound(err)
+		}
+		result.Jobs = append(result.Jobs, j)
+	case model.ChangeEntitySampleJobItems:
+		// Items are deleted with their job, so the job tells whether they
+		// still exist.
+		if _, err := s.store.GetSampleJob(ctx, c.EntityID); err != nil {
+			return false, ignoreNotFound(err)
+		}
+		counts, err := s.store.CountSampleJobItemsByStatus(ctx, c.EntityID)
+		if err != nil {
+			return false, err
+		}
+		result.JobItemCounts[c.EntityID] = counts
+	case model.ChangeEntityStudy:
+		st, err := s.store.GetStudy(ctx, c.EntityID)
+		if err != nil {
+			return false, ignoreNotFound(err)
+		}
+		result.Studies = append(result.Studies, st)
+	case model.ChangeEntityPreset:
+		p, err := s.store.GetPreset(ctx, c.EntityID)
+		if err != nil {
+			return false, ignoreNotFound(err)
+		}
+		result.Presets = append(result.Presets, p)
+	case model.ChangeEntityImageDir:
+		d, err := s.store.GetIndexedImageDir(ctx, c.EntityID)
+		if err != nil {
+			return false, ignoreNotFound(err)
+		}
+		result.ImageDirs = append(result.ImageDirs, d)
+	default:
+		log.WithField("entity", c.Entity).Warn("ignoring change to unknown entity")
+	}
+	return true, nil
+}
+
+// ignoreNotFound returns nil for sql.ErrNoRows, which load reports as a
+// deleted entity, and err otherwise.
+func ignoreNotFound(err error) error {
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeSyncStore is an in-memory SyncStore.
+type fakeSyncStore struct {
+	changes    []model.Change
+	jobs       map[string]model.SampleJob
+	itemCounts map[string]model.ItemStatusCounts
+	studies    map[string]model.Study
+	presets    map[string]model.Preset
+	imageDirs  map[string]model.IndexedImageDir
+	listErr    error
+	getJobErr  error
+	lastLimit  int
+}
+
+func newFakeSyncStore() *fakeSyncStore {
+	return &fakeSyncStore{
+		jobs:       make(map[string]model.SampleJob),
+		itemCounts: make(map[string]model.ItemStatusCounts),
+		studies:    make(map[string]model.Study),
+		presets:    make(map[string]model.Preset),
+		imageDirs:  make(map[string]model.IndexedImageDir),
+	}
+}
+
+func (f *fakeSyncStore) record(entity model.ChangeEntity, id string) {
+	f.changes = append(f.changes, model.Change{Seq: int64(len(f.changes) + 1), Entity: entity, EntityID: id})
+}
+
+func (f *fakeSyncStore) ListChanges(ctx context.Context, since int64, limit int) ([]model.Change, error) {
+	f.lastLimit = limit
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	var out []model.Change
+	for _, c := range f.changes {
+		if c.Seq > since && len(out) < limit {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSyncStore) GetSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
+	if f.getJobErr != nil {
+		return model.SampleJob{}, f.getJobErr
+	}
+	j, ok := f.jobs[id]
+	if !ok {
+		return model.SampleJob{}, sql.ErrNoRows
+	}
+	return j, nil
+}
+
+func (f *fakeSyncStore) CountSampleJobItemsByStatus(ctx context.Context, jobID string) (model.ItemStatusCounts, error) {
+	return f.itemCounts[jobID], nil
+}
+
+func (f *fakeSyncStore) GetStudy(ctx context.Context, id string) (model.Study, error) {
+	st, ok := f.studies[id]
+	if !ok {
+		return model.Study{}, sql.ErrNoRows
+	}
+	return st, nil
+}
+
+func (f *fakeSyncStore) GetPreset(ctx context.Context, id string) (model.Preset, error) {
+	p, ok := f.presets[id]
+	if !ok {
+		return model.Preset{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (f *fakeSyncStore) GetIndexedImageDir(ctx context.Context, dir string) (model.IndexedImageDir, error) {
+	d, ok := f.imageDirs[dir]
+	if !ok {
+		return model.IndexedImageDir{}, sql.ErrNoRows
+	}
+	return d, nil
+}
+
+var _ = Describe("SyncService", func() {
+	var (
+		store *fakeSyncStore
+		svc   *service.SyncService
+	)
+
+	BeforeEach(func() {
+		store = newFakeSyncStore()
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewSyncService(store, logger)
+	})
+
+	It("returns the current state of every changed entity", func() {
+		store.jobs["j1"] = model.SampleJob{ID: "j1", Status: model.SampleJobStatusRunning}
+		store.itemCounts["j1"] = model.ItemStatusCounts{Completed: 3, Pending: 1}
+		store.studies["s1"] = model.Study{ID: "s1", Name: "Study"}
+		store.presets["p1"] = model.Preset{ID: "p1", Name: "Preset"}
+		store.imageDirs["study/cp"] = model.IndexedImageDir{Dir: "study/cp", Filenames: []string{"a.png"}}
+		store.record(model.ChangeEntityStudy, "s1")
+		store.record(model.ChangeEntitySampleJob, "j1")
+		store.record(model.ChangeEntitySampleJobItems, "j1")
+		store.record(model.ChangeEntityPreset, "p1")
+		store.record(model.ChangeEntityImageDir, "study/cp")
+
+		result, err := svc.Since(ctx, 0, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Cursor).To(Equal(int64(5)))
+		Expect(result.More).To(BeFalse())
+		Expect(result.Jobs).To(Equal([]model.SampleJob{store.jobs["j1"]}))
+		Expect(result.JobItemCounts).To(Equal(map[string]model.ItemStatusCounts{"j1": {Completed: 3, Pending: 1}}))
+		Expect(result.Studies).To(Equal([]model.Study{store.studies["s1"]}))
+		Expect(result.Presets).To(Equal([]model.Preset{store.presets["p1"]}))
+		Expect(result.ImageDirs).To(Equal([]model.IndexedImageDir{store.imageDirs["study/cp"]}))
+		Expect(result.Deleted).To(BeEmpty())
+	})
+
+	It("reports entities that no longer exist as deleted", func() {
+		store.record(model.ChangeEntitySampleJob, "j1")
+		store.record(model.ChangeEntitySampleJobItems, "j1")
+		store.record(model.ChangeEntityImageDir, "study/cp")
+
+		result, err := svc.Since(ctx, 0, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Jobs).To(BeEmpty())
+		Expect(result.JobItemCounts).To(BeEmpty())
+		Expect(result.Deleted).To(Equal(store.changes))
+	})
+
+	It("returns only changes after the cursor", func() {
+		store.studies["s1"] = model.Study{ID: "s1"}
+		store.studies["s2"] = model.Study{ID: "s2"}
+		store.record(model.ChangeEntityStudy, "s1")
+		store.record(model.ChangeEntityStudy, "s2")
+
+		result, err := svc.Since(ctx, 1, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Studies).To(Equal([]model.Study{store.studies["s2"]}))
+		Expect(result.Cursor).To(Equal(int64(2)))
+	})
+
+	It("keeps the cursor when nothing changed", func() {
+		result, err := svc.Since(ctx, 7, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Cursor).To(Equal(int64(7)))
+		Expect(result.More).To(BeFalse())
+	})
+
+	It("stops at the limit and reports that more changes remain", func() {
+		for _, id := range []string{"a", "b", "c"} {
+			store.presets[id] = model.Preset{ID: id}
+			store.record(model.ChangeEntityPreset, id)
+		}
+
+		result, err := svc.Since(ctx, 0, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Presets).To(HaveLen(2))
+		Expect(result.Cursor).To(Equal(int64(2)))
+		Expect(result.More).To(BeTrue())
+
+		result, err = svc.Since(ctx, result.Cursor, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Presets).To(HaveLen(1))
+		Expect(result.More).To(BeFalse())
+	})
+
+	It("clamps the limit", func() {
+		_, err := svc.Since(ctx, 0, service.MaxSyncLimit+100)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.lastLimit).To(Equal(service.MaxSyncLimit + 1))
+	})
+
+	It("returns an error when the change log cannot be read", func() {
+		store.listErr = errors.New("database is locked")
+		_, err := svc.Since(ctx, 0, 0)
+		Expect(err).To(MatchError(ContainSubstring("listing changes")))
+	})
+
+	It("returns an error when a changed entity cannot be loaded", func() {
+		store.record(model.ChangeEntitySampleJob, "j1")
+		store.getJobErr = errors.New("database is locked")
+		_, err := svc.Since(ctx, 0, 0)
+		Expect(err).To(MatchError(ContainSubstring("loading sample_job j1")))
+	})
+})
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ThumbnailCacheSubdir is the subdirectory name within each checkpoint sample
+// directory where on-demand thumbnails are cached. It is separate from
+// ThumbnailSubdir, which holds the thumbnails written by the job executor.
+const ThumbnailCacheSubdir = ".thumbnails"
+
+// DefaultThumbnailCacheConfig is used for on-demand thumbnails when no
+// thumbnails section is configured.
+var DefaultThumbnailCacheConfig = model.ThumbnailConfig{
+	MaxResolutionX: 256,
+	MaxResolutionY: 256,
+	JPEGQuality:    85,
+}
+
+// ThumbnailCacheFS defines the filesystem operations the thumbnail cache needs.
+type ThumbnailCacheFS interface {
+	Stat(path string) (os.FileInfo, error)
+	ReadFile(path string) ([]byte, error)
+	MkdirAll(path string, perm os.FileMode) error
+	WriteFile(path string, data []byte, perm os.FileMode) error
+	WriteTempFile(dir, pattern string, data []byte, perm os.FileMode) (string, error)
+	Rename(oldPath, newPath string) error
+	Remove(path string) error
+}
+
+// RealThumbnailCacheFS provides real filesystem operations for the thumbnail cache.
+type RealThumbnailCacheFS struct{}
+
+// Stat returns file information.
+func (RealThumbnailCacheFS) Stat(path string) (os.FileInfo, error) { return os.Stat(path) }
+
+// ReadFile reads a whole file.
+func (RealThumbnailCacheFS) ReadFile(path string) ([]byte, error) { return os.ReadFile(path) }
+
+// MkdirAll creates a directory tree.
+func (RealThumbnailCacheFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// WriteFile writes data to a file.
+func (RealThumbnailCacheFS) WriteFile(path string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(path, data, perm)
+}
+
+// WriteTempFile writes data to a new file in dir, named by pattern as in
+// os.CreateTemp, and returns its path.
+func (RealThumbnailCacheFS) WriteTempFile(dir, pattern string, data []byte, perm os.FileMode) (string, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Rename atomically renames oldPath to newPath.
+func (RealThumbnailCacheFS) Rename(oldPath, newPath string) error { return os.Rename(oldPath, newPath) }
+
+// Remove removes a file.
+func (RealThumbnailCacheFS) Remove(path string) error { return os.Remove(path) }
+
+// ThumbnailCache generates resized JPEG copies of sample images on first
+// request and caches them on disk. A cached thumbnail is regenerated when its
+// source image is newer; the Watcher also invalidates entries eagerly when a
+// source image changes.
+type ThumbnailCache struct {
+	fs        ThumbnailCacheFS
+	generator *ThumbnailGenerator
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewThumbnailCache creates a ThumbnailCache that resizes images according to
+// cfg. Only the resolution and JPEG quality of cfg are used.
+func NewThumbnailCache(fs ThumbnailCacheFS, cfg model.ThumbnailConfig, sampleDir string, logger *logrus.Logger) *ThumbnailCache {
+	return &ThumbnailCache{
+		fs:        fs,
+		generator: NewThumbnailGenerator(cfg, logger),
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "thumbnail_cache"),
+	}
+}
+
+// ThumbnailCacheRelativePath returns the relative path (within sample_dir) of
+// the cached thumbnail for the given source image.
+//
+// Example:
+//
+//	source relative:    run/study/ckpt.safetensors/image.png
+//	thumbnail relative: run/study/ckpt.safetensors/.thumbnails/image.jpg
+func ThumbnailCacheRelativePath(sourceRelPath string) string {
+	dir, file := path.Split(filepath.ToSlash(sourceRelPath))
+	return dir + ThumbnailCacheSubdir + "/" + strings.TrimSuffix(file, path.Ext(file)) + ".jpg"
+}
+
+// Get returns the relative path (within sample_dir) of an up-to-date
+// thumbnail for the image at relPath, generating it first if it is missing
+// or older than the image.
+func (c *ThumbnailCache) Get(relPath string) (string, error) {
+	c.logger.WithField("relative_path", relPath).Trace("entering Get")
+	defer c.logger.Trace("returning from Get")
+
+	srcPath, err := c.resolve(relPath)
+	if err != nil {
+		return "", err
+	}
+	srcInfo, err := c.fs.Stat(srcPath)
+	if err != nil || srcInfo.IsDir() {
+		return "", fmt.Errorf("image not found: %s", relPath)
+	}
+
+	thumbRelPath := ThumbnailCacheRelativePath(relPath)
+	thumbPath := filepath.Join(c.sampleDir, filepath.FromSlash(thumbRelPath))
+	if thumbInfo, err := c.fs.Stat(thumbPath); err == nil && !thumbInfo.ModTime().Before(srcInfo.ModTime()) {
+		c.logger.WithField("relative_path", relPath).Debug("thumbnail cache hit")
+		return thumbRelPath, nil
+	}
+
+	data, err := c.fs.ReadFile(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("reading image %s: %w", relPath, err)
+	}
+	thumbData, err := c.generator.Generate(data)
+	if err != nil {
+		return "", fmt.Errorf("generating thumbnail for %s: %w", relPath, err)
+	}
+
+	// Write to a temporary file and rename so that concurrent requests never
+	// serve a partially written thumbnail. Each request writes its own
+	// temporary file, as several may generate the same thumbnail at once.
+	if err := c.fs.MkdirAll(filepath.Dir(thumbPath), 0755); err != nil {
+		return "", fmt.Errorf("creating thumbnail cache directory: %w", err)
+	}
+	tmpPath, err := c.fs.WriteTempFile(filepath.Dir(thumbPath), ".thumb-*", thumbData, 0644)
+	if err != nil {
+		return "", fmt.Errorf("writing thumbnail: %w", err)
+	}
+	if err := c.fs.Rename(tmpPath, thumbPath); err != nil {
+		c.fs.Remove(tmpPath)
+		return "", fmt.Errorf("renaming thumbnail: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"relative_path": relPath,
+		"size_bytes":    len(thumbData),
+	}).Debug("thumbnail cached")
+	return thumbRelPath, nil
+}
+
+// Invalidate removes the cached thumbnail for the image at relPath, if any.
+// Errors are logged rather than returned; a stale entry is also detected by
+// Get when the source image is newer.
+func (c *ThumbnailCache) Invalidate(relPath string) {
+	c.logger.WithField("relative_path", relPath).Trace("entering Invalidate")
+	defer c.logger.Trace("returning from Invalidate")
+
+	if _, err := c.resolve(relPath); err != nil {
+		return
+	}
+	thumbPath := filepath.Join(c.sampleDir, filepath.FromSlash(ThumbnailCacheRelativePath(relPath)))
+	if err := c.fs.Remove(thumbPath); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.logger.WithFields(logrus.Fields{
+				"relative_path": relPath,
+				"error":         err.Error(),
+			}).Warn("failed to remove cached thumbnail")
+		}
+		return
+	}
+	c.logger.WithField("relative_path", relPath).Debug("cached thumbnail invalidated")
+}
+
+// resolve validates relPath and returns the absolute path of the image.
+func (c *ThumbnailCache) resolve(relPath string) (string, error) {
+	if !isPathSafe(relPath) {
+		return "", fmt.Errorf("invalid path: %q", relPath)
+	}
+	absPath := filepath.Join(c.sampleDir, filepath.FromSlash(relPath))
+	cleanRoot := filepath.Clean(c.sampleDir)
+	if !strings.HasPrefix(filepath.Clean(absPath), cleanRoot+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path: %q", relPath)
+	}
+	return absPath, nil
+}
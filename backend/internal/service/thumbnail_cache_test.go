@@ -0,0 +1,154 @@
+package service_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("ThumbnailCache", func() {
+	const relPath = "run/study/ckpt.safetensors/seed=1&_00001_.png"
+
+	var (
+		sampleDir string
+		cache     *service.ThumbnailCache
+		srcPath   string
+	)
+
+	BeforeEach(func() {
+		var err error
+		sampleDir, err = os.MkdirTemp("", "thumbnail-cache-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		srcPath = filepath.Join(sampleDir, filepath.FromSlash(relPath))
+		Expect(os.MkdirAll(filepath.Dir(srcPath), 0755)).To(Succeed())
+		Expect(os.WriteFile(srcPath, solidPNG(1024, 512, color.RGBA{R: 255, A: 255}), 0644)).To(Succeed())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		cache = service.NewThumbnailCache(service.RealThumbnailCacheFS{}, model.ThumbnailConfig{
+			MaxResolutionX: 256,
+			MaxResolutionY: 256,
+			JPEGQuality:    80,
+		}, sampleDir, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(sampleDir)
+	})
+
+	decodeThumb := func(thumbRelPath string) image.Config {
+		data, err := os.ReadFile(filepath.Join(sampleDir, filepath.FromSlash(thumbRelPath)))
+		Expect(err).NotTo(HaveOccurred())
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		return cfg
+	}
+
+	It("places thumbnails in a .thumbnails subdirectory with a .jpg extension", func() {
+		Expect(service.ThumbnailCacheRelativePath(relPath)).To(Equal("run/study/ckpt.safetensors/.thumbnails/seed=1&_00001_.jpg"))
+		Expect(service.ThumbnailCacheRelativePath("image.webp")).To(Equal(".thumbnails/image.jpg"))
+	})
+
+	It("generates a resized JPEG on first request", func() {
+		thumbRelPath, err := cache.Get(relPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(thumbRelPath).To(Equal(service.ThumbnailCacheRelativePath(relPath)))
+
+		cfg := decodeThumb(thumbRelPath)
+		Expect(cfg.Width).To(Equal(256))
+		Expect(cfg.Height).To(Equal(128))
+	})
+
+	It("serves the cached thumbnail while the source is unchanged", func() {
+		thumbRelPath, err := cache.Get(relPath)
+		Expect(err).NotTo(HaveOccurred())
+		thumbPath := filepath.Join(sampleDir, filepath.FromSlash(thumbRelPath))
+		Expect(os.WriteFile(thumbPath, []byte("cached"), 0644)).To(Succeed())
+
+		_, err = cache.Get(relPath)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := os.ReadFile(thumbPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("cached"))
+	})
+
+	It("regenerates the thumbnail when the source is newer", func() {
+		thumbRelPath, err := cache.Get(relPath)
+		Expect(err).NotTo(HaveOccurred())
+		thumbPath := filepath.Join(sampleDir, filepath.FromSlash(thumbRelPath))
+		old := time.Now().Add(-time.Hour)
+		Expect(os.Chtimes(thumbPath, old, old)).To(Succeed())
+		Expect(os.WriteFile(srcPath, solidPNG(100, 200, color.RGBA{B: 255, A: 255}), 0644)).To(Succeed())
+
+		_, err = cache.Get(relPath)
+		Expect(err).NotTo(HaveOccurred())
+		cfg := decodeThumb(thumbRelPath)
+		Expect(cfg.Width).To(Equal(100))
+		Expect(cfg.Height).To(Equal(200))
+	})
+
+	It("generates the same thumbnail for concurrent requests", func() {
+		var wg sync.WaitGroup
+		errs := make([]error, 8)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+				_, errs[i] = cache.Get(relPath)
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		thumbRelPath := service.ThumbnailCacheRelativePath(relPath)
+		Expect(decodeThumb(thumbRelPath).Width).To(Equal(256))
+		entries, err := os.ReadDir(filepath.Dir(filepath.Join(sampleDir, filepath.FromSlash(thumbRelPath))))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1), "temporary files are renamed into place")
+	})
+
+	It("removes the cached thumbnail on Invalidate", func() {
+		thumbRelPath, err := cache.Get(relPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		cache.Invalidate(relPath)
+		_, err = os.Stat(filepath.Join(sampleDir, filepath.FromSlash(thumbRelPath)))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		// Invalidating a path without a cached thumbnail is a no-op
+		cache.Invalidate(relPath)
+	})
+
+	DescribeTable("rejects requests it cannot serve",
+		func(path, msg string) {
+			_, err := cache.Get(path)
+			Expect(err).To(MatchError(ContainSubstring(msg)))
+		},
+		Entry("path traversal", "../outside.png", "invalid path"),
+		Entry("absolute path", "/etc/passwd", "invalid path"),
+		Entry("missing image", "run/study/ckpt.safetensors/missing.png", "not found"),
+	)
+
+	It("fails for images it cannot decode", func() {
+		Expect(os.WriteFile(srcPath, []byte("not an image"), 0644)).To(Succeed())
+		_, err := cache.Get(relPath)
+		Expect(err).To(MatchError(ContainSubstring("generating thumbnail")))
+	})
+})
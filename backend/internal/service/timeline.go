@@ -0,0 +1,295 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Timeline defaults and limits.
+const (
+	// DefaultTimelineFrameSize is the frame size used when the spec does not set one.
+	DefaultTimelineFrameSize = 512
+	// MinTimelineFrameSize and MaxTimelineFrameSize bound the frame size in pixels.
+	MinTimelineFrameSize = 32
+	MaxTimelineFrameSize = 1024
+	// DefaultTimelineFrameDelayMs is the frame delay used when the spec does not set one.
+	DefaultTimelineFrameDelayMs = 500
+	// MinTimelineFrameDelayMs and MaxTimelineFrameDelayMs bound the frame delay.
+	// GIF delays are stored in hundredths of a second.
+	MinTimelineFrameDelayMs = 20
+	MaxTimelineFrameDelayMs = 10000
+	// MaxTimelineFrames bounds the number of frames, and so checkpoints, in a
+	// single clip.
+	MaxTimelineFrames = 200
+
+	// TimelineCacheSubdir is the subdirectory of sample_dir where rendered
+	// timelines are cached.
+	TimelineCacheSubdir = ".timelines"
+)
+
+// TimelineFS defines the filesystem operations the timeline renderer needs to
+// read source images and cache rendered clips.
+type TimelineFS interface {
+	Stat(path string) (os.FileInfo, error)
+	ReadFile(path string) ([]byte, error)
+	MkdirAll(path string, perm os.FileMode) error
+	WriteFile(path string, data []byte, perm os.FileMode) error
+	Rename(oldPath, newPath string) error
+}
+
+// TimelineRenderer assembles the images of one set of generation parameters
+// across a training run's checkpoints into an animated GIF, with each frame
+// labeled with its checkpoint step. Rendered clips are cached on disk under
+// {sample_dir}/.timelines/ and reused until one of their images changes.
+type TimelineRenderer struct {
+	fs        TimelineFS
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewTimelineRenderer creates a TimelineRenderer that reads images relative to
+// sampleDir.
+func NewTimelineRenderer(fs TimelineFS, sampleDir string, logger *logrus.Logger) *TimelineRenderer {
+	return &TimelineRenderer{
+		fs:        fs,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "timeline_renderer"),
+	}
+}
+
+// timelineFrame is the image shown for one checkpoint.
+type timelineFrame struct {
+	checkpoint string
+	image      model.Image
+}
+
+// Render returns the encoded GIF of the images from scan that match
+// spec.Filters, one frame per checkpoint in step order. Images that cannot be
+// read are left out of the clip. Spec problems are returned as errors
+// containing "invalid timeline spec"; when no image matches, the error
+// contains "no images".
+func (r *TimelineRenderer) Render(scan *model.ScanResult, spec model.TimelineSpec) ([]byte, error) {
+	r.logger.WithField("filters", spec.Filters).Trace("entering Render")
+	defer r.logger.Trace("returning from Render")
+
+	if spec.FrameSize == 0 {
+		spec.FrameSize = DefaultTimelineFrameSize
+	}
+	if spec.FrameSize < MinTimelineFrameSize || spec.FrameSize > MaxTimelineFrameSize {
+		return nil, fmt.Errorf("invalid timeline spec: frame size must be between %d and %d", MinTimelineFrameSize, MaxTimelineFrameSize)
+	}
+	if spec.FrameDelayMs == 0 {
+		spec.FrameDelayMs = DefaultTimelineFrameDelayMs
+	}
+	if spec.FrameDelayMs < MinTimelineFrameDelayMs || spec.FrameDelayMs > MaxTimelineFrameDelayMs {
+		return nil, fmt.Errorf("invalid timeline spec: frame delay must be between %d and %d ms", MinTimelineFrameDelayMs, MaxTimelineFrameDelayMs)
+	}
+	if _, ok := spec.Filters["checkpoint"]; ok {
+		return nil, fmt.Errorf("invalid timeline spec: checkpoint cannot be filtered; frames are the checkpoints")
+	}
+
+	frames := selectTimelineFrames(scan, spec.Filters)
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no images match the timeline filters")
+	}
+	if len(frames) > MaxTimelineFrames {
+		return nil, fmt.Errorf("invalid timeline spec: %d frames exceeds the maximum of %d", len(frames), MaxTimelineFrames)
+	}
+
+	cachePath := filepath.Join(r.sampleDir, TimelineCacheSubdir, timelineCacheKey(frames, spec)+".gif")
+	if data, ok := r.readCached(cachePath, frames); ok {
+		r.logger.WithField("frames", len(frames)).Debug("timeline cache hit")
+		return data, nil
+	}
+
+	data, err := r.render(frames, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write to a temporary file and rename so that concurrent requests never
+	// read a partially written clip. A failed write only costs a re-render.
+	if err := r.writeCached(cachePath, data); err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"path":  cachePath,
+			"error": err.Error(),
+		}).Warn("failed to cache timeline")
+	}
+	return data, nil
+}
+
+// render decodes the frame images and encodes them as a looping GIF. Every
+// frame has the size of the largest downscaled image, with the image centered
+// below a label band.
+func (r *TimelineRenderer) render(frames []timelineFrame, spec model.TimelineSpec) ([]byte, error) {
+	type loaded struct {
+		checkpoint string
+		img        image.Image
+	}
+	images := make([]loaded, 0, len(frames))
+	width, height := 0, 0
+	for _, f := range frames {
+		img, err := r.loadFrameImage(f.image.RelativePath, spec.FrameSize)
+		if err != nil {
+			r.logger.WithFields(logrus.Fields{
+				"relative_path": f.image.RelativePath,
+				"error":         err.Error(),
+			}).Warn("failed to load timeline frame image, leaving frame out")
+			continue
+		}
+		b := img.Bounds()
+		width = max(width, b.Dx())
+		height = max(height, b.Dy())
+		images = append(images, loaded{checkpoint: f.checkpoint, img: img})
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("rendering timeline: none of the %d frame images could be read", len(frames))
+	}
+
+	bandHeight := glyphHeight*gridLabelScale + 2*gridPadding
+	canvasRect := image.Rect(0, 0, width, height+bandHeight)
+	delay := spec.FrameDelayMs / 10
+	anim := &gif.GIF{}
+	for _, l := range images {
+		canvas := image.NewRGBA(canvasRect)
+		draw.Draw(canvas, canvasRect, &image.Uniform{C: gridBackground}, image.Point{}, draw.Src)
+		label := truncateLabel("step "+l.checkpoint, (width-gridPadding)/(glyphAdvance*gridLabelScale))
+		drawText(canvas, (width-textWidth(label, gridLabelScale))/2, gridPadding, label, gridLabelScale, gridLabelColor)
+
+		b := l.img.Bounds()
+		offset := image.Pt((width-b.Dx())/2, bandHeight+(height-b.Dy())/2)
+		draw.Draw(canvas, image.Rectangle{Min: offset, Max: offset.Add(b.Size())}, l.img, b.Min, draw.Over)
+
+		frame := image.NewPaletted(canvasRect, palette.Plan9)
+		draw.FloydSteinberg.Draw(frame, canvasRect, canvas, image.Point{})
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, fmt.Errorf("encoding timeline GIF: %w", err)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"frames":     len(anim.Image),
+		"width":      canvasRect.Dx(),
+		"height":     canvasRect.Dy(),
+		"size_bytes": buf.Len(),
+	}).Debug("timeline rendered")
+
+	return buf.Bytes(), nil
+}
+
+// loadFrameImage reads and decodes the image at relPath, downscaling it to
+// fit within a frameSize x frameSize box while preserving the aspect ratio.
+func (r *TimelineRenderer) loadFrameImage(relPath string, frameSize int) (image.Image, error) {
+	data, err := r.fs.ReadFile(filepath.Join(r.sampleDir, filepath.FromSlash(relPath)))
+	if err != nil {
+		return nil, fmt.Errorf("reading image: %w", err)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	b := src.Bounds()
+	w, h := computeThumbnailDimensions(b.Dx(), b.Dy(), frameSize, frameSize)
+	if w == b.Dx() && h == b.Dy() {
+		return src, nil
+	}
+	return resizeBilinear(src, w, h), nil
+}
+
+// readCached returns the cached clip at cachePath if it is at least as new as
+// every frame image.
+func (r *TimelineRenderer) readCached(cachePath string, frames []timelineFrame) ([]byte, bool) {
+	info, err := r.fs.Stat(cachePath)
+	if err != nil {
+		return nil, false
+	}
+	var newest time.Time
+	for _, f := range frames {
+		srcInfo, err := r.fs.Stat(filepath.Join(r.sampleDir, filepath.FromSlash(f.image.RelativePath)))
+		if err != nil {
+			return nil, false
+		}
+		if srcInfo.ModTime().After(newest) {
+			newest = srcInfo.ModTime()
+		}
+	}
+	if info.ModTime().Before(newest) {
+		return nil, false
+	}
+	data, err := r.fs.ReadFile(cachePath)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (r *TimelineRenderer) writeCached(cachePath string, data []byte) error {
+	if err := r.fs.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return fmt.Errorf("creating timeline cache directory: %w", err)
+	}
+	tmpPath := cachePath + ".tmp"
+	if err := r.fs.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("writing timeline: %w", err)
+	}
+	if err := r.fs.Rename(tmpPath, cachePath); err != nil {
+		return fmt.Errorf("renaming timeline: %w", err)
+	}
+	return nil
+}
+
+// selectTimelineFrames returns, for each checkpoint in the scan's checkpoint
+// order, the first image matching all filters. Checkpoints without a matching
+// image get no frame. Images are expected in relative-path order.
+func selectTimelineFrames(scan *model.ScanResult, filters map[string]string) []timelineFrame {
+	byCheckpoint := make(map[string]model.Image)
+	for _, img := range scan.Images {
+		if !matchesFilters(img, filters) {
+			continue
+		}
+		cp := img.Dimensions["checkpoint"]
+		if _, taken := byCheckpoint[cp]; !taken {
+			byCheckpoint[cp] = img
+		}
+	}
+
+	var frames []timelineFrame
+	for _, dim := range scan.Dimensions {
+		if dim.Name != "checkpoint" {
+			continue
+		}
+		for _, cp := range dim.Values {
+			if img, ok := byCheckpoint[cp]; ok {
+				frames = append(frames, timelineFrame{checkpoint: cp, image: img})
+			}
+		}
+	}
+	return frames
+}
+
+// timelineCacheKey identifies a clip by its frame images and rendering
+// options, so that any change to either renders a new clip.
+func timelineCacheKey(frames []timelineFrame, spec model.TimelineSpec) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.Itoa(spec.FrameSize) + "\n" + strconv.Itoa(spec.FrameDelayMs) + "\n"))
+	for _, f := range frames {
+		h.Write([]byte(f.checkpoint + "\t" + f.image.RelativePath + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("TimelineRenderer", func() {
+	var (
+		sampleDir string
+		renderer  *service.TimelineRenderer
+		scan      *model.ScanResult
+		red       = color.RGBA{R: 0xFF, A: 0xFF}
+		blue      = color.RGBA{B: 0xFF, A: 0xFF}
+	)
+
+	addImage := func(relPath string, dims map[string]string, w, h int, c color.Color) {
+		absPath := filepath.Join(sampleDir, filepath.FromSlash(relPath))
+		Expect(os.MkdirAll(filepath.Dir(absPath), 0755)).To(Succeed())
+		Expect(os.WriteFile(absPath, solidPNG(w, h, c), 0644)).To(Succeed())
+		scan.Images = append(scan.Images, model.Image{RelativePath: relPath, Dimensions: dims})
+	}
+
+	decode := func(data []byte) *gif.GIF {
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		return anim
+	}
+
+	BeforeEach(func() {
+		var err error
+		sampleDir, err = os.MkdirTemp("", "timeline-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		renderer = service.NewTimelineRenderer(service.RealThumbnailCacheFS{}, sampleDir, logger)
+		scan = &model.ScanResult{
+			Dimensions: []model.Dimension{
+				{Name: "checkpoint", Type: model.DimensionTypeInt, Values: []string{"500", "1000", "2000"}},
+				{Name: "prompt_name", Type: model.DimensionTypeString, Values: []string{"city", "forest"}},
+			},
+		}
+		addImage("s/c.safetensors/prompt_name=forest.png", map[string]string{"checkpoint": "2000", "prompt_name": "forest"}, 64, 64, blue)
+		addImage("s/a.safetensors/prompt_name=forest.png", map[string]string{"checkpoint": "500", "prompt_name": "forest"}, 64, 32, red)
+		addImage("s/b.safetensors/prompt_name=city.png", map[string]string{"checkpoint": "1000", "prompt_name": "city"}, 64, 64, red)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(sampleDir)
+	})
+
+	It("renders one labeled frame per checkpoint with a matching image, in step order", func() {
+		data, err := renderer.Render(scan, model.TimelineSpec{
+			Filters:      map[string]string{"prompt_name": "forest"},
+			FrameSize:    32,
+			FrameDelayMs: 250,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		anim := decode(data)
+		Expect(anim.Image).To(HaveLen(2))
+		Expect(anim.Delay).To(Equal([]int{25, 25}))
+		// Both frames share the size of the largest downscaled image plus the
+		// label band.
+		b := anim.Image[0].Bounds()
+		Expect(b.Dx()).To(Equal(32))
+		Expect(b.Dy()).To(BeNumerically(">", 32))
+		Expect(anim.Image[1].Bounds()).To(Equal(b))
+
+		// The first frame is checkpoint 500 (red), the second 2000 (blue).
+		r, _, bl, _ := anim.Image[0].At(16, b.Dy()-16).RGBA()
+		Expect(r).To(BeNumerically(">", bl))
+		r, _, bl, _ = anim.Image[1].At(16, b.Dy()-16).RGBA()
+		Expect(bl).To(BeNumerically(">", r))
+	})
+
+	It("serves the cached clip while its images are unchanged and re-renders when one is newer", func() {
+		spec := model.TimelineSpec{Filters: map[string]string{"prompt_name": "forest"}, FrameSize: 32}
+		_, err := renderer.Render(scan, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		cached, err := filepath.Glob(filepath.Join(sampleDir, service.TimelineCacheSubdir, "*.gif"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached).To(HaveLen(1))
+		Expect(os.WriteFile(cached[0], []byte("cached"), 0644)).To(Succeed())
+
+		data, err := renderer.Render(scan, spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("cached"))
+
+		future := time.Now().Add(time.Hour)
+		Expect(os.Chtimes(filepath.Join(sampleDir, "s", "a.safetensors", "prompt_name=forest.png"), future, future)).To(Succeed())
+
+		data, err = renderer.Render(scan, spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decode(data).Image).To(HaveLen(2))
+	})
+
+	It("leaves out frames whose image cannot be read", func() {
+		Expect(os.Remove(filepath.Join(sampleDir, "s", "c.safetensors", "prompt_name=forest.png"))).To(Succeed())
+
+		data, err := renderer.Render(scan, model.TimelineSpec{Filters: map[string]string{"prompt_name": "forest"}, FrameSize: 32})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decode(data).Image).To(HaveLen(1))
+	})
+
+	It("returns a no images error when nothing matches the filters", func() {
+		_, err := renderer.Render(scan, model.TimelineSpec{Filters: map[string]string{"prompt_name": "ocean"}})
+		Expect(err).To(MatchError(ContainSubstring("no images")))
+	})
+
+	DescribeTable("rejects invalid specs",
+		func(spec model.TimelineSpec, message string) {
+			_, err := renderer.Render(scan, spec)
+			Expect(err).To(MatchError(ContainSubstring("invalid timeline spec: " + message)))
+		},
+		Entry("with a frame size below the minimum", model.TimelineSpec{FrameSize: 16}, "frame size"),
+		Entry("with a frame delay above the maximum", model.TimelineSpec{FrameDelayMs: 60000}, "frame delay"),
+		Entry("with a checkpoint filter", model.TimelineSpec{Filters: map[string]string{"checkpoint": "500"}}, "checkpoint cannot be filtered"),
+	)
+})
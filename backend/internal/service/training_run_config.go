@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// TrainingRunConfigStore defines the persistence operations the training run
+// config service needs.
+type TrainingRunConfigStore interface {
+	ListTrainingRunConfigs(ctx context.Context) ([]model.TrainingRunConfig, error)
+	GetTrainingRunConfig(ctx context.Context, id string) (model.TrainingRunConfig, error)
+	CreateTrainingRunConfig(ctx context.Context, c model.TrainingRunConfig) error
+	UpdateTrainingRunConfig(ctx context.Context, c model.TrainingRunConfig) error
+	DeleteTrainingRunConfig(ctx context.Context, id string) error
+}
+
+// TrainingRunConfigService manages user-defined training runs. Changes take
+// effect on the next discovery; no restart is needed.
+type TrainingRunConfigService struct {
+	store  TrainingRunConfigStore
+	logger *logrus.Entry
+}
+
+// NewTrainingRunConfigService creates a TrainingRunConfigService backed by the given store.
+func NewTrainingRunConfigService(store TrainingRunConfigStore, logger *logrus.Logger) *TrainingRunConfigService {
+	return &TrainingRunConfigService{
+		store:  store,
+		logger: logger.WithField("component", "training_run_config"),
+	}
+}
+
+// List returns all training run configs ordered by name.
+func (s *TrainingRunConfigService) List(ctx context.Context) ([]model.TrainingRunConfig, error) {
+	s.logger.Trace("entering List")
+	defer s.logger.Trace("returning from List")
+
+	configs, err := s.store.ListTrainingRunConfigs(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list training run configs")
+		return nil, fmt.Errorf("listing training run configs: %w", err)
+	}
+	s.logger.WithField("config_count", len(configs)).Debug("training run configs retrieved from store")
+	if configs == nil {
+		configs = []model.TrainingRunConfig{}
+	}
+	return configs, nil
+}
+
+// Create validates and persists a new training run config.
+func (s *TrainingRunConfigService) Create(ctx context.Context, name string, pattern string, dimensions []model.TrainingRunDimensionConfig) (model.TrainingRunConfig, error) {
+	s.logger.WithField("config_name", name).Trace("entering Create")
+	defer s.logger.Trace("returning from Create")
+
+	name = strings.TrimSpace(name)
+	if err := s.validate(ctx, "", name, pattern, dimensions); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_name": name,
+			"error":       err.Error(),
+		}).Warn("training run config validation failed")
+		return model.TrainingRunConfig{}, err
+	}
+
+	now := time.Now().UTC()
+	c := model.TrainingRunConfig{
+		ID:         uuid.New().String(),
+		Name:       name,
+		Pattern:    pattern,
+		Dimensions: dimensions,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.store.CreateTrainingRunConfig(ctx, c); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id":   c.ID,
+			"config_name": name,
+			"error":       err.Error(),
+		}).Error("failed to create training run config")
+		return model.TrainingRunConfig{}, fmt.Errorf("creating training run config: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"config_id":   c.ID,
+		"config_name": name,
+	}).Info("training run config created")
+	return c, nil
+}
+
+// Update replaces the name, pattern, and dimensions of an existing training
+// run config.
+func (s *TrainingRunConfigService) Update(ctx context.Context, id string, name string, pattern string, dimensions []model.TrainingRunDimensionConfig) (model.TrainingRunConfig, error) {
+	s.logger.WithFields(logrus.Fields{
+		"config_id":   id,
+		"config_name": name,
+	}).Trace("entering Update")
+	defer s.logger.Trace("returning from Update")
+
+	existing, err := s.store.GetTrainingRunConfig(ctx, id)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("config_id", id).Debug("training run config not found")
+		return model.TrainingRunConfig{}, fmt.Errorf("training run config %s not found", id)
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": id,
+			"error":     err.Error(),
+		}).Error("failed to fetch training run config for update")
+		return model.TrainingRunConfig{}, fmt.Errorf("fetching training run config: %w", err)
+	}
+
+	name = strings.TrimSpace(name)
+	if err := s.validate(ctx, id, name, pattern, dimensions); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": id,
+			"error":     err.Error(),
+		}).Warn("training run config validation failed")
+		return model.TrainingRunConfig{}, err
+	}
+
+	existing.Name = name
+	existing.Pattern = pattern
+	existing.Dimensions = dimensions
+	existing.UpdatedAt = time.Now().UTC()
+	if err := s.store.UpdateTrainingRunConfig(ctx, existing); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id":   id,
+			"config_name": name,
+			"error":       err.Error(),
+		}).Error("failed to update training run config")
+		return model.TrainingRunConfig{}, fmt.Errorf("updating training run config: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"config_id":   id,
+		"config_name": name,
+	}).Info("training run config updated")
+	return existing, nil
+}
+
+// Delete removes a training run config by ID. Its checkpoints return to
+// auto-discovered training runs; sample images are not touched.
+func (s *TrainingRunConfigService) Delete(ctx context.Context, id string) error {
+	s.logger.WithField("config_id", id).Trace("entering Delete")
+	defer s.logger.Trace("returning from Delete")
+
+	err := s.store.DeleteTrainingRunConfig(ctx, id)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("config_id", id).Debug("training run config not found for deletion")
+		return fmt.Errorf("training run config %s not found", id)
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": id,
+			"error":     err.Error(),
+		}).Error("failed to delete training run config")
+		return fmt.Errorf("deleting training run config: %w", err)
+	}
+	s.logger.WithField("config_id", id).Info("training run config deleted")
+	return nil
+}
+
+// validate checks a training run config. excludeID is the ID of the config
+// being updated, which may keep its own name.
+func (s *TrainingRunConfigService) validate(ctx context.Context, excludeID string, name string, pattern string, dimensions []model.TrainingRunDimensionConfig) error {
+	if name == "" {
+		return fmt.Errorf("training run name must not be empty")
+	}
+	if pattern == "" {
+		return fmt.Errorf("training run pattern must not be empty")
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid training run pattern: %w", err)
+	}
+
+	seen := make(map[string]bool, len(dimensions))
+	for i, d := range dimensions {
+		if d.Name == "" {
+			return fmt.Errorf("dimensions[%d]: name must not be empty", i)
+		}
+		if seen[d.Name] {
+			return fmt.Errorf("dimensions[%d]: duplicate dimension %q", i, d.Name)
+		}
+		seen[d.Name] = true
+		if d.Type != model.DimensionTypeInt && d.Type != model.DimensionTypeString {
+			return fmt.Errorf("dimensions[%d]: type must be %q or %q, got %q", i, model.DimensionTypeInt, model.DimensionTypeString, d.Type)
+		}
+		re, err := regexp.Compile(d.Pattern)
+		if err != nil {
+			return fmt.Errorf("dimensions[%d]: invalid pattern: %w", i, err)
+		}
+		if re.NumSubexp() != 1 {
+			return fmt.Errorf("dimensions[%d]: pattern must have exactly one capture group, got %d", i, re.NumSubexp())
+		}
+	}
+
+	existing, err := s.store.ListTrainingRunConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("checking training run name uniqueness: %w", err)
+	}
+	for _, c := range existing {
+		if c.Name == name && c.ID != excludeID {
+			return fmt.Errorf("a training run named %q already exists", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeTrainingRunConfigStore is an in-memory test double for service.TrainingRunConfigStore.
+type fakeTrainingRunConfigStore struct {
+	configs   map[string]model.TrainingRunConfig
+	createErr error
+}
+
+func newFakeTrainingRunConfigStore() *fakeTrainingRunConfigStore {
+	return &fakeTrainingRunConfigStore{configs: make(map[string]model.TrainingRunConfig)}
+}
+
+func (f *fakeTrainingRunConfigStore) ListTrainingRunConfigs(ctx context.Context) ([]model.TrainingRunConfig, error) {
+	var result []model.TrainingRunConfig
+	for _, c := range f.configs {
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func (f *fakeTrainingRunConfigStore) GetTrainingRunConfig(ctx context.Context, id string) (model.TrainingRunConfig, error) {
+	c, ok := f.configs[id]
+	if !ok {
+		return model.TrainingRunConfig{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (f *fakeTrainingRunConfigStore) CreateTrainingRunConfig(ctx context.Context, c model.TrainingRunConfig) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.configs[c.ID] = c
+	return nil
+}
+
+func (f *fakeTrainingRunConfigStore) UpdateTrainingRunConfig(ctx context.Context, c model.TrainingRunConfig) error {
+	if _, ok := f.configs[c.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	f.configs[c.ID] = c
+	return nil
+}
+
+func (f *fakeTrainingRunConfigStore) DeleteTrainingRunConfig(ctx context.Context, id string) error {
+	if _, ok := f.configs[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.configs, id)
+	return nil
+}
+
+var _ = Describe("TrainingRunConfigService", func() {
+	var (
+		store *fakeTrainingRunConfigStore
+		svc   *service.TrainingRunConfigService
+	)
+
+	stepDim := []model.TrainingRunDimensionConfig{
+		{Name: "step", Type: model.DimensionTypeInt, Pattern: `-steps-(\d+)-`},
+	}
+
+	BeforeEach(func() {
+		store = newFakeTrainingRunConfigStore()
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewTrainingRunConfigService(store, logger)
+	})
+
+	Describe("List", func() {
+		It("returns an empty slice when there are no configs", func() {
+			configs, err := svc.List(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configs).To(Equal([]model.TrainingRunConfig{}))
+		})
+	})
+
+	Describe("Create", func() {
+		It("persists a config with a generated ID and trimmed name", func() {
+			c, err := svc.Create(ctx, "  psai4rt  ", `^qwen/psai4rt-`, stepDim)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.ID).NotTo(BeEmpty())
+			Expect(c.Name).To(Equal("psai4rt"))
+			Expect(c.Dimensions).To(Equal(stepDim))
+			Expect(store.configs).To(HaveKey(c.ID))
+		})
+
+		It("rejects a duplicate name", func() {
+			_, err := svc.Create(ctx, "psai4rt", `^a`, nil)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = svc.Create(ctx, "psai4rt", `^b`, nil)
+			Expect(err).To(MatchError(ContainSubstring("already exists")))
+		})
+
+		It("wraps store errors", func() {
+			store.createErr = errors.New("disk full")
+			_, err := svc.Create(ctx, "psai4rt", `^a`, nil)
+			Expect(err).To(MatchError(ContainSubstring("creating training run config")))
+		})
+
+		DescribeTable("rejects invalid configs",
+			func(name string, pattern string, dims []model.TrainingRunDimensionConfig, expected string) {
+				_, err := svc.Create(ctx, name, pattern, dims)
+				Expect(err).To(MatchError(ContainSubstring(expected)))
+				Expect(store.configs).To(BeEmpty())
+			},
+			Entry("empty name", " ", `^a`, nil, "name must not be empty"),
+			Entry("empty pattern", "run", "", nil, "pattern must not be empty"),
+			Entry("invalid pattern", "run", "(", nil, "invalid training run pattern"),
+			Entry("unnamed dimension", "run", `^a`, []model.TrainingRunDimensionConfig{
+				{Type: model.DimensionTypeInt, Pattern: `(\d+)`},
+			}, "dimensions[0]: name must not be empty"),
+			Entry("duplicate dimension", "run", `^a`, []model.TrainingRunDimensionConfig{
+				{Name: "step", Type: model.DimensionTypeInt, Pattern: `(\d+)`},
+				{Name: "step", Type: model.DimensionTypeInt, Pattern: `(\d+)`},
+			}, `dimensions[1]: duplicate dimension "step"`),
+			Entry("unknown dimension type", "run", `^a`, []model.TrainingRunDimensionConfig{
+				{Name: "step", Type: "float", Pattern: `(\d+)`},
+			}, "dimensions[0]: type must be"),
+			Entry("invalid dimension pattern", "run", `^a`, []model.TrainingRunDimensionConfig{
+				{Name: "step", Type: model.DimensionTypeInt, Pattern: `(`},
+			}, "dimensions[0]: invalid pattern"),
+			Entry("dimension pattern without a capture group", "run", `^a`, []model.TrainingRunDimensionConfig{
+				{Name: "step", Type: model.DimensionTypeInt, Pattern: `\d+`},
+			}, "exactly one capture group, got 0"),
+		)
+	})
+
+	Describe("Update", func() {
+		It("replaces the name, pattern, and dimensions", func() {
+			created, err := svc.Create(ctx, "before", `^a`, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			updated, err := svc.Update(ctx, created.ID, "after", `^b`, stepDim)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Name).To(Equal("after"))
+			Expect(updated.Pattern).To(Equal(`^b`))
+			Expect(updated.Dimensions).To(Equal(stepDim))
+			Expect(updated.CreatedAt).To(Equal(created.CreatedAt))
+			Expect(store.configs[created.ID].Name).To(Equal("after"))
+		})
+
+		It("allows keeping the same name", func() {
+			created, err := svc.Create(ctx, "same", `^a`, nil)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = svc.Update(ctx, created.ID, "same", `^b`, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects renaming to another config's name", func() {
+			_, err := svc.Create(ctx, "taken", `^a`, nil)
+			Expect(err).NotTo(HaveOccurred())
+			other, err := svc.Create(ctx, "other", `^b`, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.Update(ctx, other.ID, "taken", `^b`, nil)
+			Expect(err).To(MatchError(ContainSubstring("already exists")))
+		})
+
+		It("returns not found for an unknown ID", func() {
+			_, err := svc.Update(ctx, "missing", "run", `^a`, nil)
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes the config", func() {
+			created, err := svc.Create(ctx, "run", `^a`, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(svc.Delete(ctx, created.ID)).To(Succeed())
+			Expect(store.configs).To(BeEmpty())
+		})
+
+		It("returns not found for an unknown ID", func() {
+			Expect(svc.Delete(ctx, "missing")).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+})
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/fileformat"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// TrainingRunSummaryDiscovery discovers the training runs of the checkpoint
+// directories.
+type TrainingRunSummaryDiscovery interface {
+	Discover(ctx context.Context) ([]model.TrainingRun, error)
+}
+
+// TrainingRunSummaryStore aggregates the sample jobs of a training run.
+type TrainingRunSummaryStore interface {
+	SummarizeTrainingRunJobs(ctx context.Context, trainingRunName string) (model.TrainingRunJobStats, error)
+}
+
+// TrainingRunSummaryFileSystem lists the study and checkpoint directories of
+// a training run's sample directory.
+type TrainingRunSummaryFileSystem interface {
+	ListSubdirectories(root string) ([]string, error)
+}
+
+// TrainingRunUsageReporter reports the disk usage of a training run's sample
+// directory.
+type TrainingRunUsageReporter interface {
+	TrainingRunUsage(directory string) (model.TrainingRunStorageUsage, error)
+}
+
+// TrainingRunSummaryService gathers an overview of a training run from
+// checkpoint discovery, the sample jobs in the store, the image index and the
+// disk usage of its sample directory ({training_run}/{study}/{checkpoint}/).
+type TrainingRunSummaryService struct {
+	discovery TrainingRunSummaryDiscovery
+	store     TrainingRunSummaryStore
+	images    ImageLister
+	fs        TrainingRunSummaryFileSystem
+	usage     TrainingRunUsageReporter
+	sampleDir string
+	logger    *logrus.Entry
+}
+
+// NewTrainingRunSummaryService creates a TrainingRunSummaryService. images is
+// normally the image index, so that counting the images of a run does not
+// read every checkpoint directory.
+func NewTrainingRunSummaryService(discovery TrainingRunSummaryDiscovery, store TrainingRunSummaryStore, images ImageLister, fs TrainingRunSummaryFileSystem, usage TrainingRunUsageReporter, sampleDir string, logger *logrus.Logger) *TrainingRunSummaryService {
+	return &TrainingRunSummaryService{
+		discovery: discovery,
+		store:     store,
+		images:    images,
+		fs:        fs,
+		usage:     usage,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "training_run_summary"),
+	}
+}
+
+// Summary returns the overview of the named training run. Its checkpoints are
+// those discovered in the checkpoint directories; a checkpoint has samples if
+// it has sample images in the run's sample directory, or a sample directory
+// in the legacy layout. Jobs in the trash are not counted. Returns a "not
+// found" error if the run has no discovered checkpoints, no sample jobs and
+// no samples on disk.
+func (s *TrainingRunSummaryService) Summary(ctx context.Context, name string) (model.TrainingRunSummary, error) {
+	log := requestLogger(ctx, s.logger)
+	log.WithField("training_run", name).Trace("entering Summary")
+	defer log.Trace("returning from Summary")
+
+	dir := fileformat.SanitizeTrainingRunName(name)
+	if dir == "" || dir == "." || dir == ".." {
+		return model.TrainingRunSummary{}, fmt.Errorf("invalid training run name %q", name)
+	}
+
+	runs, err := s.discovery.Discover(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to discover training runs")
+		return model.TrainingRunSummary{}, fmt.Errorf("discovering training runs: %w", err)
+	}
+	var run *model.TrainingRun
+	for i := range runs {
+		if runs[i].Name == name {
+			run = &runs[i]
+			break
+		}
+	}
+
+	stats, err := s.store.SummarizeTrainingRunJobs(ctx, name)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"training_run": name,
+			"error":        err.Error(),
+		}).Error("failed to summarize sample jobs")
+		return model.TrainingRunSummary{}, fmt.Errorf("summarizing sample jobs: %w", err)
+	}
+	imageCounts, err := s.countImages(dir)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"training_run": name,
+			"error":        err.Error(),
+		}).Error("failed to count sample images")
+		return model.TrainingRunSummary{}, fmt.Errorf("counting sample images: %w", err)
+	}
+	usage, err := s.usage.TrainingRunUsage(dir)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"training_run": name,
+			"error":        err.Error(),
+		}).Error("failed to compute disk usage")
+		return model.TrainingRunSummary{}, fmt.Errorf("computing disk usage: %w", err)
+	}
+
+	if run == nil && len(stats.JobStatusCounts) == 0 && usage.FileCount == 0 {
+		log.WithField("training_run", name).Debug("training run not found")
+		return model.TrainingRunSummary{}, fmt.Errorf("training run %s not found", name)
+	}
+
+	summary := model.TrainingRunSummary{
+		Name:          name,
+		Checkpoints:   []model.CheckpointSummary{},
+		Jobs:          model.TrainingRunJobCounts{ByStatus: stats.JobStatusCounts},
+		DiskBytes:     usage.Bytes,
+		DiskFileCount: usage.FileCount,
+	}
+	for _, n := range stats.JobStatusCounts {
+		summary.Jobs.Total += n
+	}
+	for _, c := range stats.Checkpoints {
+		summary.Jobs.FailedItems += c.FailedItems
+		if c.LastCompletedAt != nil && (summary.LastSampledAt == nil || c.LastCompletedAt.After(*summary.LastSampledAt)) {
+			summary.LastSampledAt = c.LastCompletedAt
+		}
+	}
+	checkpointBytes := make(map[string]int64, len(usage.Checkpoints))
+	for _, c := range usage.Checkpoints {
+		checkpointBytes[c.CheckpointFilename] = c.Bytes
+	}
+	if run != nil {
+		for _, cp := range run.Checkpoints {
+			c := model.CheckpointSummary{
+				Filename:       cp.Filename,
+				StepNumber:     cp.StepNumber,
+				HasSamples:     imageCounts[cp.Filename] > 0 || cp.HasSamples,
+				ImageCount:     imageCounts[cp.Filename],
+				CompletedItems: stats.Checkpoints[cp.Filename].CompletedItems,
+				FailedItems:    stats.Checkpoints[cp.Filename].FailedItems,
+				Bytes:          checkpointBytes[cp.Filename],
+			}
+			if c.HasSamples {
+				summary.SampledCheckpointCount++
+			}
+			summary.Checkpoints = append(summary.Checkpoints, c)
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"training_run":        name,
+		"checkpoint_count":    len(summary.Checkpoints),
+		"sampled_checkpoints": summary.SampledCheckpointCount,
+		"job_count":           summary.Jobs.Total,
+	}).Debug("summarized training run")
+	return summary, nil
+}
+
+// countImages returns the number of sample images per checkpoint filename in
+// the training run directory dir, summed across its study directories.
+func (s *TrainingRunSummaryService) countImages(dir string) (map[string]int, error) {
+	counts := make(map[string]int)
+	runDir := filepath.Join(s.sampleDir, dir)
+	studies, err := s.fs.ListSubdirectories(runDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, study := range studies {
+		if isThumbnailDir(study) {
+			continue
+		}
+		studyDir := filepath.Join(runDir, study)
+		checkpoints, err := s.fs.ListSubdirectories(studyDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, checkpoint := range checkpoints {
+			if !isCheckpointDirName(checkpoint) {
+				continue
+			}
+			files, err := s.images.ListImageFiles(filepath.Join(studyDir, checkpoint))
+			if err != nil {
+				return nil, fmt.Errorf("listing images of %s: %w", path.Join(dir, study, checkpoint), err)
+			}
+			counts[checkpoint] += len(files)
+		}
+	}
+	return counts, nil
+}
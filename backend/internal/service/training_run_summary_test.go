@@ -0,0 +1,173 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeSummaryDiscovery returns fixed training runs.
+type fakeSummaryDiscovery struct {
+	runs []model.TrainingRun
+	err  error
+}
+
+func (f *fakeSummaryDiscovery) Discover(ctx context.Context) ([]model.TrainingRun, error) {
+	return f.runs, f.err
+}
+
+// fakeSummaryStore returns fixed job stats per training run.
+type fakeSummaryStore struct {
+	stats map[string]model.TrainingRunJobStats
+}
+
+func (f *fakeSummaryStore) SummarizeTrainingRunJobs(ctx context.Context, trainingRunName string) (model.TrainingRunJobStats, error) {
+	return f.stats[trainingRunName], nil
+}
+
+// fakeSampleTree serves directories and their images from memory, keyed by
+// absolute path.
+type fakeSampleTree struct {
+	subdirs map[string][]string
+	images  map[string][]string
+}
+
+func (f *fakeSampleTree) ListSubdirectories(root string) ([]string, error) {
+	return f.subdirs[root], nil
+}
+
+func (f *fakeSampleTree) ListImageFiles(dir string) ([]string, error) {
+	return f.images[dir], nil
+}
+
+// fakeUsageReporter returns fixed disk usage per directory.
+type fakeUsageReporter map[string]model.TrainingRunStorageUsage
+
+func (f fakeUsageReporter) TrainingRunUsage(directory string) (model.TrainingRunStorageUsage, error) {
+	return f[directory], nil
+}
+
+var _ = Describe("TrainingRunSummaryService", func() {
+	var (
+		discovery *fakeSummaryDiscovery
+		st        *fakeSummaryStore
+		tree      *fakeSampleTree
+		usage     fakeUsageReporter
+		svc       *service.TrainingRunSummaryService
+		sampledAt time.Time
+	)
+
+	BeforeEach(func() {
+		sampledAt = time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+		earlier := sampledAt.Add(-time.Hour)
+		discovery = &fakeSummaryDiscovery{runs: []model.TrainingRun{
+			{Name: "other", Checkpoints: []model.Checkpoint{{Filename: "o.safetensors"}}},
+			{Name: "qwen/lora", Checkpoints: []model.Checkpoint{
+				{Filename: "lora-step00001000.safetensors", StepNumber: 1000},
+				{Filename: "lora-step00002000.safetensors", StepNumber: 2000},
+				{Filename: "lora.safetensors", StepNumber: 3000},
+			}},
+		}}
+		st = &fakeSummaryStore{stats: map[string]model.TrainingRunJobStats{
+			"qwen/lora": {
+				JobStatusCounts: map[model.SampleJobStatus]int{
+					model.SampleJobStatusCompleted:           2,
+					model.SampleJobStatusCompletedWithErrors: 1,
+					model.SampleJobStatusFailed:              1,
+				},
+				Checkpoints: map[string]model.CheckpointJobStats{
+					"lora-step00001000.safetensors": {CompletedItems: 4, FailedItems: 1, LastCompletedAt: &earlier},
+					"lora-step00002000.safetensors": {CompletedItems: 2, LastCompletedAt: &sampledAt},
+					"deleted.safetensors":           {FailedItems: 3},
+				},
+			},
+		}}
+		tree = &fakeSampleTree{
+			subdirs: map[string][]string{
+				"/samples/qwen_lora":        {"forest", "city", ".thumbnails"},
+				"/samples/qwen_lora/forest": {"lora-step00001000.safetensors", "lora-step00002000.safetensors"},
+				"/samples/qwen_lora/city":   {"lora-step00001000.safetensors", "notes"},
+			},
+			images: map[string][]string{
+				"/samples/qwen_lora/forest/lora-step00001000.safetensors": {"a.png", "b.png"},
+				"/samples/qwen_lora/city/lora-step00001000.safetensors":   {"a.png"},
+				"/samples/qwen_lora/city/notes":                           {"x.png"},
+			},
+		}
+		usage = fakeUsageReporter{
+			"qwen_lora": {Directory: "qwen_lora", Bytes: 4096, FileCount: 5, Checkpoints: []model.CheckpointStorageUsage{
+				{CheckpointFilename: "lora-step00001000.safetensors", Bytes: 3000, FileCount: 3, StudyCount: 2},
+				{CheckpointFilename: "lora-step00002000.safetensors", Bytes: 1000, FileCount: 1, StudyCount: 1},
+			}},
+		}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewTrainingRunSummaryService(discovery, st, tree, tree, usage, "/samples", logger)
+	})
+
+	It("aggregates checkpoints, sample jobs, images and disk usage", func() {
+		summary, err := svc.Summary(context.Background(), "qwen/lora")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary.Name).To(Equal("qwen/lora"))
+		Expect(summary.Checkpoints).To(Equal([]model.CheckpointSummary{
+			{Filename: "lora-step00001000.safetensors", StepNumber: 1000, HasSamples: true, ImageCount: 3, CompletedItems: 4, FailedItems: 1, Bytes: 3000},
+			{Filename: "lora-step00002000.safetensors", StepNumber: 2000, HasSamples: false, ImageCount: 0, CompletedItems: 2, Bytes: 1000},
+			{Filename: "lora.safetensors", StepNumber: 3000},
+		}))
+		Expect(summary.SampledCheckpointCount).To(Equal(1))
+		Expect(summary.LastSampledAt).To(Equal(&sampledAt))
+		Expect(summary.Jobs.Total).To(Equal(4))
+		Expect(summary.Jobs.ByStatus).To(HaveKeyWithValue(model.SampleJobStatusFailed, 1))
+		Expect(summary.Jobs.FailedItems).To(Equal(4))
+		Expect(summary.DiskBytes).To(Equal(int64(4096)))
+		Expect(summary.DiskFileCount).To(Equal(5))
+	})
+
+	It("counts checkpoints with a legacy sample directory as sampled", func() {
+		discovery.runs[0].Checkpoints[0].HasSamples = true
+		summary, err := svc.Summary(context.Background(), "other")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary.Checkpoints).To(HaveLen(1))
+		Expect(summary.Checkpoints[0].HasSamples).To(BeTrue())
+		Expect(summary.SampledCheckpointCount).To(Equal(1))
+		Expect(summary.LastSampledAt).To(BeNil())
+		Expect(summary.Jobs.Total).To(BeZero())
+	})
+
+	It("summarizes a run whose checkpoints are gone but whose jobs remain", func() {
+		discovery.runs = nil
+		summary, err := svc.Summary(context.Background(), "qwen/lora")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary.Checkpoints).To(BeEmpty())
+		Expect(summary.Jobs.Total).To(Equal(4))
+	})
+
+	It("returns a not found error for an unknown run", func() {
+		_, err := svc.Summary(context.Background(), "missing")
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+	})
+
+	DescribeTable("rejects names that are not a training run directory",
+		func(name string) {
+			_, err := svc.Summary(context.Background(), name)
+			Expect(err).To(MatchError(ContainSubstring("invalid training run name")))
+		},
+		Entry("empty", ""),
+		Entry("dot", "."),
+		Entry("parent", ".."),
+	)
+
+	It("returns discovery errors", func() {
+		discovery.err = errors.New("disk gone")
+		_, err := svc.Summary(context.Background(), "qwen/lora")
+		Expect(err).To(MatchError(ContainSubstring("disk gone")))
+	})
+})
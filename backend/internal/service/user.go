@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx that carries the user an API request acts
+// for.
+func WithUser(ctx context.Context, user model.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the user ctx carries. ok is false for requests
+// without a token and for work not done on behalf of a request.
+func UserFromContext(ctx context.Context) (user model.User, ok bool) {
+	user, ok = ctx.Value(userKey{}).(model.User)
+	return user, ok
+}
+
+// ownerOf returns the name of the user ctx carries, which owns the items
+// created on its behalf, or "" for unowned items.
+func ownerOf(ctx context.Context) string {
+	user, _ := UserFromContext(ctx)
+	return user.Name
+}
+
+// ownerScope resolves the owner filter of a list request. An empty owner
+// lists the user's own and the unowned items, or every item for requests
+// without a user. model.OwnerAll lists every item and a user name lists that
+// user's items; non-admin users may only list their own.
+func ownerScope(ctx context.Context, owner string) (model.OwnerScope, error) {
+	user, ok := UserFromContext(ctx)
+	switch {
+	case owner == "":
+		if !ok {
+			return model.OwnerScope{}, nil
+		}
+		return model.OwnerScope{Owner: user.Name, IncludeUnowned: true}, nil
+	case ok && !user.Admin && owner != user.Name:
+		return model.OwnerScope{}, fmt.Errorf("forbidden: user %s may only list their own items, listing %q requires an admin user", user.Name, owner)
+	case owner == model.OwnerAll:
+		return model.OwnerScope{}, nil
+	default:
+		return model.OwnerScope{Owner: owner}, nil
+	}
+}
+
+// checkOwner returns an error if the request of ctx may not change an item
+// owned by owner. Unowned items may be changed by anyone; owned items only by
+// their owner and admin users.
+func checkOwner(ctx context.Context, kind, id, owner string) error {
+	if owner == "" {
+		return nil
+	}
+	user, ok := UserFromContext(ctx)
+	if ok && (user.Admin || user.Name == owner) {
+		return nil
+	}
+	return fmt.Errorf("forbidden: %s %s is owned by %s", kind, id, owner)
+}
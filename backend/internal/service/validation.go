@@ -12,7 +12,7 @@ import (
 
 // ValidationFileSystem defines the filesystem operations needed for validation.
 type ValidationFileSystem interface {
-	ListPNGFiles(dir string) ([]string, error)
+	ListImageFiles(dir string) ([]string, error)
 	DirectoryExists(path string) bool
 	ReadFile(path string) ([]byte, error)
 }
@@ -23,6 +23,7 @@ type ValidationFileSystem interface {
 // and compares against the maximum count across all checkpoints.
 type ValidationService struct {
 	fs        ValidationFileSystem
+	recursive RecursiveImageLister // optional; counts images in subdirectories
 	sampleDir string
 	logger    *logrus.Entry
 }
@@ -36,6 +37,23 @@ func NewValidationService(fs ValidationFileSystem, sampleDir string, logger *log
 	}
 }
 
+// SetRecursiveImageLister makes validation count the images in
+// subdirectories of the checkpoint directories too, where images saved under
+// an output layout are. If not set, only the images directly in the
+// checkpoint directories are counted.
+func (v *ValidationService) SetRecursiveImageLister(lister RecursiveImageLister) {
+	v.recursive = lister
+}
+
+// listImageFiles returns the sample images in the checkpoint directory dir,
+// leaving out thumbnails.
+func (v *ValidationService) listImageFiles(dir string) ([]string, error) {
+	if v.recursive == nil {
+		return v.fs.ListImageFiles(dir)
+	}
+	return listImagesRecursive(v.recursive, dir)
+}
+
 // ValidateTrainingRun checks the completeness of sample images for a training run.
 // For each checkpoint, it counts the PNG files in the sample directory. The maximum
 // count across all checkpoints is treated as the expected count. Checkpoints with
@@ -83,7 +101,7 @@ func (v *ValidationService) ValidateTrainingRun(tr model.TrainingRun, studyName
 			continue
 		}
 
-		files, err := v.fs.ListPNGFiles(sampleDirPath)
+		files, err := v.listImageFiles(sampleDirPath)
 		if err != nil {
 			v.logger.WithFields(logrus.Fields{
 				"checkpoint":     cp.Filename,
@@ -188,7 +206,7 @@ func (v *ValidationService) ValidateTrainingRunWithStudy(tr model.TrainingRun, s
 			}
 
 			if v.fs.DirectoryExists(sampleDirPath) {
-				files, err := v.fs.ListPNGFiles(sampleDirPath)
+				files, err := v.listImageFiles(sampleDirPath)
 				if err != nil {
 					v.logger.WithFields(logrus.Fields{
 						"checkpoint":     cp.Filename,
@@ -304,7 +322,7 @@ func (v *ValidationService) ValidateTrainingRunWithManifest(tr model.TrainingRun
 		sampleDirPath := filepath.Join(v.sampleDir, studyOutputDir, cp.Filename)
 
 		if v.fs.DirectoryExists(sampleDirPath) {
-			files, err := v.fs.ListPNGFiles(sampleDirPath)
+			files, err := v.listImageFiles(sampleDirPath)
 			if err != nil {
 				v.logger.WithFields(logrus.Fields{
 					"checkpoint":     cp.Filename,
@@ -31,7 +31,7 @@ func newFakeValidationFS() *fakeValidationFS {
 	}
 }
 
-func (f *fakeValidationFS) ListPNGFiles(dir string) ([]string, error) {
+func (f *fakeValidationFS) ListImageFiles(dir string) ([]string, error) {
 	if err, ok := f.errs[dir]; ok {
 		return nil, err
 	}
@@ -213,7 +213,7 @@ var _ = Describe("ValidationService", func() {
 			Expect(result.Checkpoints[0].Missing).To(Equal(0))
 		})
 
-		It("returns error when ListPNGFiles fails", func() {
+		It("returns error when ListImageFiles fails", func() {
 			tr := model.TrainingRun{
 				Name: "model",
 				Checkpoints: []model.Checkpoint{
@@ -567,7 +567,7 @@ var _ = Describe("ValidationService", func() {
 			Expect(result.Checkpoints[0].Missing).To(Equal(2))
 		})
 
-		It("returns error when ListPNGFiles fails", func() {
+		It("returns error when ListImageFiles fails", func() {
 			tr := model.TrainingRun{
 				Name: "model",
 				Checkpoints: []model.Checkpoint{
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Elo parameters. Every checkpoint starts at eloInitialRating; a vote moves
+// the two ratings by at most eloK points.
+const (
+	eloInitialRating = 1500.0
+	eloK             = 32.0
+)
+
+// VoteStore defines the persistence operations the vote service needs.
+type VoteStore interface {
+	ListVoteCandidates(ctx context.Context, trainingRunName string) ([]model.VoteCandidate, error)
+	CreateVote(ctx context.Context, v model.Vote) error
+	ListVotes(ctx context.Context, trainingRunName string) ([]model.Vote, error)
+}
+
+// VoteService serves pairs of images for pairwise checkpoint voting, records
+// votes, and ranks the checkpoints of a training run by Elo rating. Only
+// images generated with the same parameters by different checkpoints are
+// paired, so that a vote compares checkpoints and nothing else.
+type VoteService struct {
+	store     VoteStore
+	sampleDir string
+	logger    *logrus.Entry
+	// intN returns a random int in [0, n); replaced in tests.
+	intN func(n int) int
+}
+
+// NewVoteService creates a VoteService for images under sampleDir.
+func NewVoteService(store VoteStore, sampleDir string, logger *logrus.Logger) *VoteService {
+	return &VoteService{
+		store:     store,
+		sampleDir: sampleDir,
+		logger:    logger.WithField("component", "vote"),
+		intN:      rand.IntN,
+	}
+}
+
+// Pair returns a random pair of comparable images of a training run. When
+// promptName is non-empty, only images of that prompt are paired. Returns a
+// "not found" error if the training run has no comparable images.
+func (s *VoteService) Pair(ctx context.Context, trainingRunName, promptName string) (model.VotePair, error) {
+	s.logger.WithFields(logrus.Fields{
+		"training_run": trainingRunName,
+		"prompt_name":  promptName,
+	}).Trace("entering Pair")
+	defer s.logger.Trace("returning from Pair")
+
+	if trainingRunName == "" {
+		return model.VotePair{}, fmt.Errorf("training run must not be empty")
+	}
+	candidates, err := s.store.ListVoteCandidates(ctx, trainingRunName)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list vote candidates")
+		return model.VotePair{}, fmt.Errorf("listing vote candidates: %w", err)
+	}
+
+	var groups [][]model.VoteCandidate
+	for _, g := range groupVoteCandidates(candidates) {
+		if promptName != "" && g[0].PromptName != promptName {
+			continue
+		}
+		if distinctCheckpoints(g) >= 2 {
+			groups = append(groups, g)
+		}
+	}
+	if len(groups) == 0 {
+		s.logger.WithField("training_run", trainingRunName).Debug("no comparable image pairs")
+		return model.VotePair{}, fmt.Errorf("comparable image pairs not found for training run %s", trainingRunName)
+	}
+
+	group := groups[s.intN(len(groups))]
+	a := group[s.intN(len(group))]
+	var others []model.VoteCandidate
+	for _, c := range group {
+		if c.ComparableWith(a) {
+			others = append(others, c)
+		}
+	}
+	b := others[s.intN(len(others))]
+
+	imageA, err := s.pairImage(a)
+	if err != nil {
+		return model.VotePair{}, err
+	}
+	imageB, err := s.pairImage(b)
+	if err != nil {
+		return model.VotePair{}, err
+	}
+	return model.VotePair{
+		TrainingRunName: trainingRunName,
+		PromptName:      a.PromptName,
+		Steps:           a.Steps,
+		CFG:             a.CFG,
+		SamplerName:     a.SamplerName,
+		Scheduler:       a.Scheduler,
+		Seed:            a.Seed,
+		A:               imageA,
+		B:               imageB,
+	}, nil
+}
+
+// Vote records that the image of winnerItemID was preferred over the image of
+// loserItemID. Both must be completed images of the training run. Returns a
+// "not found" error if either is not, and a "not comparable" error if they
+// differ in more than the checkpoint.
+func (s *VoteService) Vote(ctx context.Context, trainingRunName, winnerItemID, loserItemID string) (model.Vote, error) {
+	s.logger.WithFields(logrus.Fields{
+		"training_run": trainingRunName,
+		"winner":       winnerItemID,
+		"loser":        loserItemID,
+	}).Trace("entering Vote")
+	defer s.logger.Trace("returning from Vote")
+
+	if trainingRunName == "" {
+		return model.Vote{}, fmt.Errorf("training run must not be empty")
+	}
+	candidates, err := s.store.ListVoteCandidates(ctx, trainingRunName)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list vote candidates")
+		return model.Vote{}, fmt.Errorf("listing vote candidates: %w", err)
+	}
+	byID := make(map[string]model.VoteCandidate, len(candidates))
+	for _, c := range candidates {
+		byID[c.ItemID] = c
+	}
+	winner, ok := byID[winnerItemID]
+	if !ok {
+		return model.Vote{}, fmt.Errorf("image %s not found in training run %s", winnerItemID, trainingRunName)
+	}
+	loser, ok := byID[loserItemID]
+	if !ok {
+		return model.Vote{}, fmt.Errorf("image %s not found in training run %s", loserItemID, trainingRunName)
+	}
+	if !winner.ComparableWith(loser) {
+		s.logger.WithFields(logrus.Fields{
+			"winner": winnerItemID,
+			"loser":  loserItemID,
+		}).Warn("vote rejected, images not comparable")
+		return model.Vote{}, fmt.Errorf("images %s and %s are not comparable: they must share every parameter but the checkpoint", winnerItemID, loserItemID)
+	}
+
+	v := model.Vote{
+		ID:               uuid.New().String(),
+		TrainingRunName:  trainingRunName,
+		PromptName:       winner.PromptName,
+		WinnerItemID:     winner.ItemID,
+		LoserItemID:      loser.ItemID,
+		WinnerCheckpoint: winner.CheckpointFilename,
+		LoserCheckpoint:  loser.CheckpointFilename,
+		CreatedAt:        time.Now().UTC(),
+	}
+	if err := s.store.CreateVote(ctx, v); err != nil {
+		s.logger.WithError(err).Error("failed to create vote")
+		return model.Vote{}, fmt.Errorf("creating vote: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"vote_id":      v.ID,
+		"training_run": trainingRunName,
+		"winner":       v.WinnerCheckpoint,
+		"loser":        v.LoserCheckpoint,
+	}).Info("vote recorded")
+	return v, nil
+}
+
+// Rankings replays the votes of a training run in the order they were cast
+// and returns the resulting Elo ratings, across all prompts and per prompt.
+func (s *VoteService) Rankings(ctx context.Context, trainingRunName string) (model.VoteRankings, error) {
+	s.logger.WithField("training_run", trainingRunName).Trace("entering Rankings")
+	defer s.logger.Trace("returning from Rankings")
+
+	if trainingRunName == "" {
+		return model.VoteRankings{}, fmt.Errorf("training run must not be empty")
+	}
+	votes, err := s.store.ListVotes(ctx, trainingRunName)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list votes")
+		return model.VoteRankings{}, fmt.Errorf("listing votes: %w", err)
+	}
+
+	overall := newEloTable()
+	perPrompt := make(map[string]*eloTable)
+	for _, v := range votes {
+		overall.record(v.WinnerCheckpoint, v.LoserCheckpoint)
+		t, ok := perPrompt[v.PromptName]
+		if !ok {
+			t = newEloTable()
+			perPrompt[v.PromptName] = t
+		}
+		t.record(v.WinnerCheckpoint, v.LoserCheckpoint)
+	}
+
+	rankings := model.VoteRankings{
+		TrainingRunName: trainingRunName,
+		VoteCount:       len(votes),
+		Overall:         overall.ratings(),
+		Prompts:         []model.PromptRatings{},
+	}
+	for name, t := range perPrompt {
+		rankings.Prompts = append(rankings.Prompts, model.PromptRatings{PromptName: name, Ratings: t.ratings()})
+	}
+	sort.Slice(rankings.Prompts, func(i, j int) bool {
+		return rankings.Prompts[i].PromptName < rankings.Prompts[j].PromptName
+	})
+	return rankings, nil
+}
+
+// pairImage converts a candidate to a pair side with a path relative to the
+// sample directory.
+func (s *VoteService) pairImage(c model.VoteCandidate) (model.VotePairImage, error) {
+	rel, err := filepath.Rel(s.sampleDir, c.OutputPath)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"output_path": c.OutputPath,
+			"error":       err.Error(),
+		}).Error("failed to compute relative path of image")
+		return model.VotePairImage{}, fmt.Errorf("computing relative path of %s: %w", c.OutputPath, err)
+	}
+	return model.VotePairImage{
+		ItemID:             c.ItemID,
+		CheckpointFilename: c.CheckpointFilename,
+		ImagePath:          filepath.ToSlash(rel),
+	}, nil
+}
+
+// groupVoteCandidates groups candidates that share every generation parameter,
+// in order of first appearance.
+func groupVoteCandidates(candidates []model.VoteCandidate) [][]model.VoteCandidate {
+	type key struct {
+		prompt, sampler, scheduler string
+		steps, width, height       int
+		cfg                        float64
+		seed                       int64
+	}
+	index := make(map[key]int)
+	var groups [][]model.VoteCandidate
+	for _, c := range candidates {
+		k := key{c.PromptName, c.SamplerName, c.Scheduler, c.Steps, c.Width, c.Height, c.CFG, c.Seed}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], c)
+	}
+	return groups
+}
+
+// distinctCheckpoints counts the checkpoints among candidates.
+func distinctCheckpoints(candidates []model.VoteCandidate) int {
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		seen[c.CheckpointFilename] = true
+	}
+	return len(seen)
+}
+
+// eloTable accumulates Elo ratings from a sequence of pairwise results.
+type eloTable struct {
+	entries map[string]*model.CheckpointRating
+}
+
+func newEloTable() *eloTable {
+	return &eloTable{entries: make(map[string]*model.CheckpointRating)}
+}
+
+func (t *eloTable) entry(checkpoint string) *model.CheckpointRating {
+	e, ok := t.entries[checkpoint]
+	if !ok {
+		e = &model.CheckpointRating{CheckpointFilename: checkpoint, Rating: eloInitialRating}
+		t.entries[checkpoint] = e
+	}
+	return e
+}
+
+// record applies a win of winner over loser.
+func (t *eloTable) record(winner, loser string) {
+	w, l := t.entry(winner), t.entry(loser)
+	expected := 1 / (1 + math.Pow(10, (l.Rating-w.Rating)/400))
+	delta := eloK * (1 - expected)
+	w.Rating += delta
+	l.Rating -= delta
+	w.Wins++
+	l.Losses++
+}
+
+// ratings returns the ratings best first, ties broken by checkpoint filename.
+func (t *eloTable) ratings() []model.CheckpointRating {
+	out := make([]model.CheckpointRating, 0, len(t.entries))
+	for _, e := range t.entries {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Rating != out[j].Rating {
+			return out[i].Rating > out[j].Rating
+		}
+		return out[i].CheckpointFilename < out[j].CheckpointFilename
+	})
+	return out
+}
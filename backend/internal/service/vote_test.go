@@ -0,0 +1,243 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeVoteStore is an in-memory VoteStore.
+type fakeVoteStore struct {
+	candidates map[string][]model.VoteCandidate
+	votes      []model.Vote
+	listErr    error
+	createErr  error
+}
+
+func (f *fakeVoteStore) ListVoteCandidates(ctx context.Context, trainingRunName string) ([]model.VoteCandidate, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	return f.candidates[trainingRunName], nil
+}
+
+func (f *fakeVoteStore) CreateVote(ctx context.Context, v model.Vote) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.votes = append(f.votes, v)
+	return nil
+}
+
+func (f *fakeVoteStore) ListVotes(ctx context.Context, trainingRunName string) ([]model.Vote, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	var out []model.Vote
+	for _, v := range f.votes {
+		if v.TrainingRunName == trainingRunName {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// voteCandidate returns a completed image of checkpoint for prompt, with
+// otherwise fixed parameters.
+func voteCandidate(id, checkpoint, prompt string) model.VoteCandidate {
+	return model.VoteCandidate{
+		ItemID:             id,
+		CheckpointFilename: checkpoint,
+		PromptName:         prompt,
+		Steps:              20,
+		CFG:                7,
+		SamplerName:        "euler",
+		Scheduler:          "simple",
+		Seed:               42,
+		Width:              512,
+		Height:             512,
+		OutputPath:         "/samples/run/study/" + checkpoint + "/" + prompt + ".png",
+	}
+}
+
+var _ = Describe("VoteService", func() {
+	var (
+		store *fakeVoteStore
+		svc   *service.VoteService
+	)
+
+	BeforeEach(func() {
+		store = &fakeVoteStore{candidates: make(map[string][]model.VoteCandidate)}
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		svc = service.NewVoteService(store, "/samples", logger)
+	})
+
+	Describe("Pair", func() {
+		It("pairs images that differ only by checkpoint", func() {
+			otherSeed := voteCandidate("i3", "b.safetensors", "forest")
+			otherSeed.Seed = 7
+			store.candidates["run"] = []model.VoteCandidate{
+				voteCandidate("i1", "a.safetensors", "forest"),
+				voteCandidate("i2", "b.safetensors", "forest"),
+				otherSeed,
+				voteCandidate("i4", "a.safetensors", "lake"),
+			}
+
+			for range 10 {
+				pair, err := svc.Pair(ctx, "run", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pair.TrainingRunName).To(Equal("run"))
+				Expect(pair.PromptName).To(Equal("forest"))
+				Expect(pair.Seed).To(Equal(int64(42)))
+				Expect([]string{pair.A.ItemID, pair.B.ItemID}).To(ConsistOf("i1", "i2"))
+				Expect([]string{pair.A.ImagePath, pair.B.ImagePath}).To(ConsistOf(
+					"run/study/a.safetensors/forest.png",
+					"run/study/b.safetensors/forest.png",
+				))
+			}
+		})
+
+		It("pairs only images of the requested prompt", func() {
+			store.candidates["run"] = []model.VoteCandidate{
+				voteCandidate("i1", "a.safetensors", "forest"),
+				voteCandidate("i2", "b.safetensors", "forest"),
+				voteCandidate("i3", "a.safetensors", "lake"),
+				voteCandidate("i4", "b.safetensors", "lake"),
+			}
+
+			pair, err := svc.Pair(ctx, "run", "lake")
+			Expect(err).NotTo(HaveOccurred())
+			Expect([]string{pair.A.ItemID, pair.B.ItemID}).To(ConsistOf("i3", "i4"))
+		})
+
+		It("returns a not found error without comparable images", func() {
+			store.candidates["run"] = []model.VoteCandidate{
+				voteCandidate("i1", "a.safetensors", "forest"),
+				voteCandidate("i2", "a.safetensors", "lake"),
+			}
+
+			_, err := svc.Pair(ctx, "run", "")
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+
+		It("rejects an empty training run", func() {
+			_, err := svc.Pair(ctx, "", "")
+			Expect(err).To(MatchError(ContainSubstring("must not be empty")))
+		})
+	})
+
+	Describe("Vote", func() {
+		BeforeEach(func() {
+			otherSeed := voteCandidate("i3", "b.safetensors", "forest")
+			otherSeed.Seed = 7
+			store.candidates["run"] = []model.VoteCandidate{
+				voteCandidate("i1", "a.safetensors", "forest"),
+				voteCandidate("i2", "b.safetensors", "forest"),
+				otherSeed,
+			}
+		})
+
+		It("records the winning and losing checkpoints", func() {
+			v, err := svc.Vote(ctx, "run", "i2", "i1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v.ID).NotTo(BeEmpty())
+			Expect(v.PromptName).To(Equal("forest"))
+			Expect(v.WinnerCheckpoint).To(Equal("b.safetensors"))
+			Expect(v.LoserCheckpoint).To(Equal("a.safetensors"))
+			Expect(store.votes).To(Equal([]model.Vote{v}))
+		})
+
+		It("rejects images that differ in more than the checkpoint", func() {
+			_, err := svc.Vote(ctx, "run", "i1", "i3")
+			Expect(err).To(MatchError(ContainSubstring("not comparable")))
+			Expect(store.votes).To(BeEmpty())
+		})
+
+		It("rejects images of the same checkpoint", func() {
+			_, err := svc.Vote(ctx, "run", "i1", "i1")
+			Expect(err).To(MatchError(ContainSubstring("not comparable")))
+		})
+
+		It("returns a not found error for an image outside the training run", func() {
+			_, err := svc.Vote(ctx, "run", "i1", "missing")
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+
+		It("returns an error when the vote cannot be stored", func() {
+			store.createErr = errors.New("database is locked")
+			_, err := svc.Vote(ctx, "run", "i1", "i2")
+			Expect(err).To(MatchError(ContainSubstring("creating vote")))
+		})
+	})
+
+	Describe("Rankings", func() {
+		vote := func(prompt, winner, loser string) model.Vote {
+			return model.Vote{TrainingRunName: "run", PromptName: prompt, WinnerCheckpoint: winner, LoserCheckpoint: loser}
+		}
+
+		It("starts every checkpoint at 1500 and moves ratings by Elo", func() {
+			store.votes = []model.Vote{vote("forest", "b", "a")}
+
+			rankings, err := svc.Rankings(ctx, "run")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rankings.VoteCount).To(Equal(1))
+			Expect(rankings.Overall).To(Equal([]model.CheckpointRating{
+				{CheckpointFilename: "b", Rating: 1516, Wins: 1},
+				{CheckpointFilename: "a", Rating: 1484, Losses: 1},
+			}))
+		})
+
+		It("ranks checkpoints overall and per prompt", func() {
+			store.votes = []model.Vote{
+				vote("forest", "c", "a"),
+				vote("forest", "c", "b"),
+				vote("forest", "b", "a"),
+				vote("lake", "a", "c"),
+				{TrainingRunName: "other", PromptName: "forest", WinnerCheckpoint: "a", LoserCheckpoint: "c"},
+			}
+
+			rankings, err := svc.Rankings(ctx, "run")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rankings.TrainingRunName).To(Equal("run"))
+			Expect(rankings.VoteCount).To(Equal(4))
+
+			names := func(ratings []model.CheckpointRating) []string {
+				var out []string
+				for _, r := range ratings {
+					out = append(out, r.CheckpointFilename)
+				}
+				return out
+			}
+			Expect(names(rankings.Overall)).To(Equal([]string{"c", "b", "a"}))
+			Expect(rankings.Prompts).To(HaveLen(2))
+			Expect(rankings.Prompts[0].PromptName).To(Equal("forest"))
+			Expect(names(rankings.Prompts[0].Ratings)).To(Equal([]string{"c", "b", "a"}))
+			Expect(rankings.Prompts[1].PromptName).To(Equal("lake"))
+			Expect(names(rankings.Prompts[1].Ratings)).To(Equal([]string{"a", "c"}))
+			Expect(rankings.Overall[0].Wins).To(Equal(2))
+			Expect(rankings.Overall[0].Losses).To(Equal(1))
+		})
+
+		It("returns empty rankings without votes", func() {
+			rankings, err := svc.Rankings(ctx, "run")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rankings.VoteCount).To(Equal(0))
+			Expect(rankings.Overall).To(BeEmpty())
+			Expect(rankings.Prompts).To(BeEmpty())
+		})
+
+		It("returns an error when the votes cannot be read", func() {
+			store.listErr = errors.New("database is locked")
+			_, err := svc.Rankings(ctx, "run")
+			Expect(err).To(MatchError(ContainSubstring("listing votes")))
+		})
+	})
+})
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrVRAMLimitExceeded is returned by VRAMGuard.Check when a job's estimated
+// VRAM requirement exceeds the configured hard limit.
+var ErrVRAMLimitExceeded = errors.New("estimated VRAM requirement exceeds the configured limit")
+
+// vramStatsTimeout bounds the /system_stats request made when a job is
+// created, so that an unresponsive ComfyUI does not hold up job creation.
+const vramStatsTimeout = 5 * time.Second
+
+// vramProfile is a rough VRAM cost model of a model family: the weights that
+// must be resident while sampling, plus the activations, which grow with the
+// image area.
+type vramProfile struct {
+	weightsMB      int
+	perMegapixelMB int
+}
+
+// vramProfiles are keyed by the type input of a workflow's clip_loader node
+// (CLIPLoader, DualCLIPLoader), which names the model family. The figures
+// are estimates for fp16 weights without offloading, not measurements;
+// ComfyUI can often make larger models fit by offloading, which is why an
+// estimate above the GPU's memory only warns unless a hard limit is set.
+var vramProfiles = map[string]vramProfile{
+	"stable_diffusion": {weightsMB: 2500, perMegapixelMB: 1500},
+	"sdxl":             {weightsMB: 7000, perMegapixelMB: 2000},
+	"sd3":              {weightsMB: 9000, perMegapixelMB: 2500},
+	"flux":             {weightsMB: 14000, perMegapixelMB: 3000},
+	"chroma":           {weightsMB: 12000, perMegapixelMB: 3000},
+	"hidream":          {weightsMB: 20000, perMegapixelMB: 3500},
+	"qwen_image":       {weightsMB: 20000, perMegapixelMB: 3500},
+	"wan":              {weightsMB: 15000, perMegapixelMB: 3500},
+}
+
+// unknownVRAMProfile is used for workflows whose model family is not
+// recognized.
+var unknownVRAMProfile = vramProfile{weightsMB: 8000, perMegapixelMB: 2500}
+
+// SystemStatsSource reports the devices ComfyUI runs on. It is satisfied by
+// store.ComfyUIHTTPClient.
+type SystemStatsSource interface {
+	SystemStats(ctx context.Context) (model.ComfyUISystemStats, error)
+}
+
+// VRAMGuard estimates whether the images of a sample job fit in the memory
+// of ComfyUI's GPU, from the model family of the job's workflow and the
+// image resolution. A job that likely does not fit gets a warning; it is
+// refused only when its estimate exceeds the configured hard limit.
+type VRAMGuard struct {
+	stats       SystemStatsSource
+	workflows   WorkflowTemplateSource
+	hardLimitMB int
+	logger      *logrus.Entry
+}
+
+// NewVRAMGuard creates a VRAMGuard. hardLimitMB of zero disables refusal.
+func NewVRAMGuard(stats SystemStatsSource, workflows WorkflowTemplateSource, hardLimitMB int, logger *logrus.Logger) *VRAMGuard {
+	return &VRAMGuard{
+		stats:       stats,
+		workflows:   workflows,
+		hardLimitMB: hardLimitMB,
+		logger:      logger.WithField("component", "vram_guard"),
+	}
+}
+
+// Check estimates the VRAM needed to sample width×height images with
+// workflowName. It returns ErrVRAMLimitExceeded, wrapped with the estimate,
+// if the estimate exceeds the hard limit. When ComfyUI reports the GPU's
+// memory and the estimate exceeds it, the returned estimate carries a
+// warning. A workflow that cannot be loaded is estimated as an unknown
+// model family; unavailable system stats only skip the warning.
+func (g *VRAMGuard) Check(workflowName string, width, height int) (model.VRAMEstimate, error) {
+	g.logger.WithFields(logrus.Fields{
+		"workflow": workflowName,
+		"width":    width,
+		"height":   height,
+	}).Trace("entering Check")
+	defer g.logger.Trace("returning from Check")
+
+	family := g.modelFamily(workflowName)
+	profile, ok := vramProfiles[family]
+	if !ok {
+		profile = unknownVRAMProfile
+	}
+	megapixels := float64(width) * float64(height) / 1e6
+	est := model.VRAMEstimate{
+		ModelFamily: family,
+		RequiredMB:  profile.weightsMB + int(float64(profile.perMegapixelMB)*megapixels+0.5),
+	}
+
+	if g.hardLimitMB > 0 && est.RequiredMB > g.hardLimitMB {
+		g.logger.WithFields(logrus.Fields{
+			"workflow":      workflowName,
+			"model_family":  family,
+			"required_mb":   est.RequiredMB,
+			"hard_limit_mb": g.hardLimitMB,
+		}).Warn("refusing job over VRAM hard limit")
+		return est, fmt.Errorf("%w: %s at %dx%d needs about %d MB, limit is %d MB",
+			ErrVRAMLimitExceeded, familyLabel(family), width, height, est.RequiredMB, g.hardLimitMB)
+	}
+
+	est.AvailableMB = g.gpuMemoryMB()
+	if est.AvailableMB > 0 && est.RequiredMB > est.AvailableMB {
+		est.Warning = fmt.Sprintf("%s at %dx%d needs about %d MB of VRAM, but the GPU has %d MB; sampling may fail or be slow",
+			familyLabel(family), width, height, est.RequiredMB, est.AvailableMB)
+		g.logger.WithFields(logrus.Fields{
+			"workflow":     workflowName,
+			"model_family": family,
+			"required_mb":  est.RequiredMB,
+			"available_mb": est.AvailableMB,
+		}).Info("job likely exceeds GPU memory")
+	}
+	return est, nil
+}
+
+// HasRoomForModel reports whether ComfyUI's GPU has enough free memory to
+// load the weights of another model of workflowName's family next to the
+// models it already holds. It is used to decide whether to pre-warm the next
+// checkpoint of a job, and returns an error when ComfyUI does not report its
+// free memory.
+func (g *VRAMGuard) HasRoomForModel(workflowName string) (bool, error) {
+	g.logger.WithField("workflow", workflowName).Trace("entering HasRoomForModel")
+	defer g.logger.Trace("returning from HasRoomForModel")
+
+	freeMB, err := g.gpuFreeMemoryMB()
+	if err != nil {
+		return false, err
+	}
+	family := g.modelFamily(workflowName)
+	profile, ok := vramProfiles[family]
+	if !ok {
+		profile = unknownVRAMProfile
+	}
+	g.logger.WithFields(logrus.Fields{
+		"workflow":     workflowName,
+		"model_family": family,
+		"weights_mb":   profile.weightsMB,
+		"free_mb":      freeMB,
+	}).Debug("checked free VRAM for another model")
+	return freeMB >= profile.weightsMB, nil
+}
+
+// modelFamily returns the type input of the workflow's first clip_loader
+// node that has one, or "" if the workflow cannot be loaded or names no
+// family.
+func (g *VRAMGuard) modelFamily(workflowName string) string {
+	if g.workflows == nil {
+		return ""
+	}
+	workflow, err := g.workflows.Get(context.Background(), workflowName)
+	if err != nil {
+		g.logger.WithFields(logrus.Fields{
+			"workflow": workflowName,
+			"error":    err.Error(),
+		}).Warn("failed to load workflow for VRAM estimate")
+		return ""
+	}
+	for _, nodeID := range workflow.Roles[string(model.CSRoleCLIPLoader)] {
+		node, ok := workflow.Workflow[nodeID].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		inputs, ok := node["inputs"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if family, ok := inputs["type"].(string); ok && family != "" {
+			return family
+		}
+	}
+	return ""
+}
+
+// gpuMemoryMB returns the total memory of the first GPU ComfyUI reports, or
+// 0 if it is unknown.
+func (g *VRAMGuard) gpuMemoryMB() int {
+	if g.stats == nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), vramStatsTimeout)
+	defer cancel()
+	stats, err := g.stats.SystemStats(ctx)
+	if err != nil {
+		g.logger.WithError(err).Debug("system stats unavailable, skipping VRAM check")
+		return 0
+	}
+	for _, d := range stats.Devices {
+		if d.Type != "cpu" && d.VRAMTotal > 0 {
+			return int(d.VRAMTotal / (1024 * 1024))
+		}
+	}
+	return 0
+}
+
+// gpuFreeMemoryMB returns the free memory of the first GPU ComfyUI reports.
+func (g *VRAMGuard) gpuFreeMemoryMB() (int, error) {
+	if g.stats == nil {
+		return 0, errors.New("system stats are not available")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), vramStatsTimeout)
+	defer cancel()
+	stats, err := g.stats.SystemStats(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("fetching system stats: %w", err)
+	}
+	for _, d := range stats.Devices {
+		if d.Type != "cpu" && d.VRAMTotal > 0 {
+			return int(d.VRAMFree / (1024 * 1024)), nil
+		}
+	}
+	return 0, errors.New("ComfyUI reports no GPU")
+}
+
+// familyLabel names a model family in messages.
+func familyLabel(family string) string {
+	if family == "" {
+		return "a model of unknown family"
+	}
+	return family
+}
@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeSystemStatsSource returns fixed ComfyUI system stats.
+type fakeSystemStatsSource struct {
+	stats model.ComfyUISystemStats
+	err   error
+}
+
+func (f *fakeSystemStatsSource) SystemStats(ctx context.Context) (model.ComfyUISystemStats, error) {
+	return f.stats, f.err
+}
+
+// clipLoaderWorkflow returns a workflow whose clip_loader node has the given
+// type input.
+func clipLoaderWorkflow(name, clipType string) model.WorkflowTemplate {
+	return model.WorkflowTemplate{
+		Name: name,
+		Workflow: map[string]interface{}{
+			"1": map[string]interface{}{
+				"class_type": "CLIPLoader",
+				"inputs":     map[string]interface{}{"clip_name": "clip.safetensors", "type": clipType},
+			},
+		},
+		Roles: map[string][]string{"clip_loader": {"1"}},
+	}
+}
+
+var _ = Describe("VRAMGuard", func() {
+	const gb = int64(1024 * 1024 * 1024)
+
+	var (
+		stats     *fakeSystemStatsSource
+		workflows *fakeWorkflowTemplateSource
+		logger    *logrus.Logger
+	)
+
+	BeforeEach(func() {
+		stats = &fakeSystemStatsSource{stats: model.ComfyUISystemStats{Devices: []model.ComfyUIDevice{
+			{Name: "cuda:0 NVIDIA GeForce RTX 4070", Type: "cuda", VRAMTotal: 12 * gb},
+		}}}
+		workflows = &fakeWorkflowTemplateSource{templates: map[string]model.WorkflowTemplate{
+			"sdxl.json": clipLoaderWorkflow("sdxl.json", "sdxl"),
+			"flux.json": clipLoaderWorkflow("flux.json", "flux"),
+		}}
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	})
+
+	It("does not warn when the job fits on the GPU", func() {
+		guard := service.NewVRAMGuard(stats, workflows, 0, logger)
+
+		est, err := guard.Check("sdxl.json", 1024, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(est.ModelFamily).To(Equal("sdxl"))
+		Expect(est.AvailableMB).To(Equal(12 * 1024))
+		Expect(est.RequiredMB).To(BeNumerically("<", est.AvailableMB))
+		Expect(est.Warning).To(BeEmpty())
+	})
+
+	It("warns when the job likely exceeds the GPU's memory", func() {
+		guard := service.NewVRAMGuard(stats, workflows, 0, logger)
+
+		est, err := guard.Check("flux.json", 1024, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(est.ModelFamily).To(Equal("flux"))
+		Expect(est.RequiredMB).To(BeNumerically(">", est.AvailableMB))
+		Expect(est.Warning).To(ContainSubstring("flux at 1024x1024 needs about"))
+		Expect(est.Warning).To(ContainSubstring("the GPU has 12288 MB"))
+	})
+
+	It("requires more memory for larger images", func() {
+		guard := service.NewVRAMGuard(stats, workflows, 0, logger)
+
+		small, err := guard.Check("sdxl.json", 512, 512)
+		Expect(err).NotTo(HaveOccurred())
+		large, err := guard.Check("sdxl.json", 2048, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(large.RequiredMB).To(BeNumerically(">", small.RequiredMB))
+	})
+
+	It("refuses a job over the hard limit", func() {
+		guard := service.NewVRAMGuard(stats, workflows, 10000, logger)
+
+		_, err := guard.Check("flux.json", 1024, 1024)
+		Expect(err).To(MatchError(service.ErrVRAMLimitExceeded))
+		Expect(err.Error()).To(ContainSubstring("limit is 10000 MB"))
+
+		_, err = guard.Check("sdxl.json", 512, 512)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("skips the warning when system stats are unavailable", func() {
+		stats.err = errors.New("connection refused")
+		guard := service.NewVRAMGuard(stats, workflows, 0, logger)
+
+		est, err := guard.Check("flux.json", 2048, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(est.AvailableMB).To(Equal(0))
+		Expect(est.Warning).To(BeEmpty())
+	})
+
+	It("ignores CPU devices", func() {
+		stats.stats.Devices = []model.ComfyUIDevice{{Name: "cpu", Type: "cpu", VRAMTotal: 64 * gb}}
+		guard := service.NewVRAMGuard(stats, workflows, 0, logger)
+
+		est, err := guard.Check("flux.json", 1024, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(est.AvailableMB).To(Equal(0))
+	})
+
+	It("estimates workflows of unknown family conservatively", func() {
+		guard := service.NewVRAMGuard(stats, workflows, 0, logger)
+
+		est, err := guard.Check("missing.json", 1024, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(est.ModelFamily).To(BeEmpty())
+		Expect(est.RequiredMB).To(BeNumerically(">", 0))
+	})
+
+	Describe("HasRoomForModel", func() {
+		It("compares the GPU's free memory with the weights of the model family", func() {
+			stats.stats.Devices[0].VRAMFree = 8 * gb
+			guard := service.NewVRAMGuard(stats, workflows, 0, logger)
+
+			room, err := guard.HasRoomForModel("sdxl.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(room).To(BeTrue())
+
+			room, err = guard.HasRoomForModel("flux.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(room).To(BeFalse())
+		})
+
+		It("returns an error when system stats are unavailable", func() {
+			stats.err = errors.New("connection refused")
+			guard := service.NewVRAMGuard(stats, workflows, 0, logger)
+
+			_, err := guard.HasRoomForModel("sdxl.json")
+			Expect(err).To(MatchError(ContainSubstring("connection refused")))
+		})
+
+		It("returns an error when ComfyUI reports no GPU", func() {
+			stats.stats.Devices = []model.ComfyUIDevice{{Name: "cpu", Type: "cpu", VRAMTotal: 64 * gb, VRAMFree: 64 * gb}}
+			guard := service.NewVRAMGuard(stats, workflows, 0, logger)
+
+			_, err := guard.HasRoomForModel("sdxl.json")
+			Expect(err).To(MatchError(ContainSubstring("no GPU")))
+		})
+	})
+})
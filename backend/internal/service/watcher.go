@@ -1,10 +1,12 @@
 package service
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
@@ -16,6 +18,18 @@ type WatcherEventSink interface {
 	Broadcast(event model.FSEvent)
 }
 
+// ThumbnailInvalidator drops cached thumbnails of sample images that changed.
+type ThumbnailInvalidator interface {
+	Invalidate(relPath string)
+}
+
+// ImageIndexUpdater records sample images written to or removed from the
+// sample directory in the image index.
+type ImageIndexUpdater interface {
+	AddImage(relPath string)
+	RemoveImage(relPath string)
+}
+
 // WatcherNotifier provides filesystem notification capabilities.
 // This interface allows testing without real fsnotify.
 type WatcherNotifier interface {
@@ -46,6 +60,11 @@ func NewFSNotifier() (WatcherNotifier, error) {
 	return &fsnotifyAdapter{w: w}, nil
 }
 
+// SubdirectoryLister lists the immediate subdirectories of a directory.
+type SubdirectoryLister interface {
+	ListSubdirectories(root string) ([]string, error)
+}
+
 // IsDirFunc determines whether a path is a directory.
 // Replaceable for testing.
 type IsDirFunc func(path string) bool
@@ -61,7 +80,22 @@ type Watcher struct {
 	mu        sync.Mutex
 	notifier  WatcherNotifier
 	sink      WatcherEventSink
+	thumbs    ThumbnailInvalidator // nil when no thumbnail cache is configured
+	images    ImageIndexUpdater    // nil when no image index is configured
 	sampleDir string
+	// checkpointDirs and checkpointLister are set by WatchCheckpointDirs and
+	// only read by the event loop, which is restarted whenever they change.
+	checkpointDirs   []string
+	checkpointLister SubdirectoryLister
+	// runDirs and runRoot are the sample directories watched for the current
+	// training run and the directory new checkpoint sample directories appear
+	// in. Like checkpointDirs, they only change while the event loop is stopped.
+	runDirs []string
+	runRoot string
+	// lastEvent is the previous event read by the event loop, used to
+	// coalesce repeated events. Only accessed by the event loop.
+	lastEvent *fsnotify.Event
+	stats     watcherCounters
 	isDir     IsDirFunc
 	logger    *logrus.Entry
 	done      chan struct{}
@@ -69,6 +103,14 @@ type Watcher struct {
 	watching  bool
 }
 
+// watcherCounters are the atomically updated counters behind model.WatcherStats.
+type watcherCounters struct {
+	eventsReceived   atomic.Int64
+	eventsCoalesced  atomic.Int64
+	overflows        atomic.Int64
+	rescansTriggered atomic.Int64
+}
+
 // NewWatcher creates a new Watcher.
 func NewWatcher(notifier WatcherNotifier, sink WatcherEventSink, sampleDir string, logger *logrus.Logger) *Watcher {
 	return &Watcher{
@@ -85,6 +127,20 @@ func (w *Watcher) SetIsDirFunc(fn IsDirFunc) {
 	w.isDir = fn
 }
 
+// SetThumbnailInvalidator sets the thumbnail cache to invalidate when a
+// watched sample image is created, modified, removed, or renamed.
+// It must be called before the first WatchTrainingRun.
+func (w *Watcher) SetThumbnailInvalidator(thumbs ThumbnailInvalidator) {
+	w.thumbs = thumbs
+}
+
+// SetImageIndex sets the image index to update when a watched sample image
+// is created, removed, or renamed. It must be called before the first
+// WatchTrainingRun.
+func (w *Watcher) SetImageIndex(images ImageIndexUpdater) {
+	w.images = images
+}
+
 // WatchTrainingRun starts watching directories belonging to the given training run.
 // Any previously watched directories are cleared first.
 // The study name is derived from run.Name — for study-scoped runs like "study/model",
@@ -122,10 +178,12 @@ func (w *Watcher) WatchTrainingRun(run model.TrainingRun) error {
 	// Watch the directory where new checkpoint directories would appear.
 	// For study-scoped runs, this is the study directory; for legacy runs, the sample_dir root.
 	if studyName != "" {
-		dirs = append(dirs, filepath.Join(w.sampleDir, studyName))
+		w.runRoot = filepath.Join(w.sampleDir, studyName)
 	} else {
-		dirs = append(dirs, w.sampleDir)
+		w.runRoot = w.sampleDir
 	}
+	dirs = append(dirs, w.runRoot)
+	w.runDirs = dirs
 
 	w.logger.WithFields(logrus.Fields{
 		"run_name":  run.Name,
@@ -143,17 +201,49 @@ func (w *Watcher) WatchTrainingRun(run model.TrainingRun) error {
 		}
 	}
 
-	// Start the event processing loop
-	w.done = make(chan struct{})
-	w.stopped = make(chan struct{})
-	w.watching = true
-	go w.loop()
+	w.startLocked()
 
 	w.logger.WithField("run_name", run.Name).Info("started watching training run directories")
 
 	return nil
 }
 
+// WatchCheckpointDirs starts watching the checkpoint directories and their
+// subdirectories, broadcasting checkpoint_added and checkpoint_removed events
+// when .safetensors files appear or disappear. Unlike training run
+// directories, checkpoint directories stay watched until Stop is called.
+func (w *Watcher) WatchCheckpointDirs(dirs []string, lister SubdirectoryLister) {
+	w.logger.WithField("dir_count", len(dirs)).Trace("entering WatchCheckpointDirs")
+	defer w.logger.Trace("returning from WatchCheckpointDirs")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Restart the event loop so that it observes the new directories.
+	wasWatching := w.watching
+	w.stopLocked()
+	w.checkpointDirs = dirs
+	w.checkpointLister = lister
+	for _, dir := range dirs {
+		watchDirTree(w.notifier, lister, dir, w.logger)
+	}
+	if wasWatching || len(dirs) > 0 {
+		w.startLocked()
+	}
+
+	w.logger.WithField("dir_count", len(dirs)).Info("started watching checkpoint directories")
+}
+
+// Stats returns the watcher's event counters.
+func (w *Watcher) Stats() model.WatcherStats {
+	return model.WatcherStats{
+		EventsReceived:   w.stats.eventsReceived.Load(),
+		EventsCoalesced:  w.stats.eventsCoalesced.Load(),
+		Overflows:        w.stats.overflows.Load(),
+		RescansTriggered: w.stats.rescansTriggered.Load(),
+	}
+}
+
 // Stop stops watching all directories.
 func (w *Watcher) Stop() {
 	w.mu.Lock()
@@ -161,6 +251,14 @@ func (w *Watcher) Stop() {
 	w.stopLocked()
 }
 
+// startLocked starts the event processing loop.
+func (w *Watcher) startLocked() {
+	w.done = make(chan struct{})
+	w.stopped = make(chan struct{})
+	w.watching = true
+	go w.loop()
+}
+
 func (w *Watcher) stopLocked() {
 	if !w.watching {
 		return
@@ -184,16 +282,86 @@ func (w *Watcher) loop() {
 			if !ok {
 				return
 			}
+			w.stats.eventsReceived.Add(1)
+			if w.lastEvent != nil && *w.lastEvent == ev {
+				w.stats.eventsCoalesced.Add(1)
+				continue
+			}
+			w.lastEvent = &ev
 			w.handleEvent(ev)
 		case err, ok := <-w.notifier.Errors():
 			if !ok {
 				return
 			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				w.handleOverflow()
+				continue
+			}
 			w.logger.WithError(err).Error("filesystem watcher error")
 		}
 	}
 }
 
+// handleOverflow recovers from an overflow of the OS event queue, which
+// drops an unknown set of events, by rescanning the watched directories.
+func (w *Watcher) handleOverflow() {
+	overflows := w.stats.overflows.Add(1)
+	w.logger.WithFields(logrus.Fields{
+		"overflows":       overflows,
+		"events_received": w.stats.eventsReceived.Load(),
+	}).Warn("filesystem event queue overflowed, rescanning watched directories")
+
+	// Events after the overflow must not be coalesced with one before it:
+	// the events in between are lost.
+	w.lastEvent = nil
+	w.rescan()
+}
+
+// rescan restores the watches of the watched directories and announces the
+// training run's sample directories as changed. Directories created while
+// events were lost are only found by listing them, so the checkpoint
+// directory trees are walked again, and a directory_added event for the run
+// makes clients rescan it, which rewatches its new sample directories.
+// Lost checkpoint file events cannot be recovered here.
+func (w *Watcher) rescan() {
+	w.stats.rescansTriggered.Add(1)
+
+	for _, dir := range w.checkpointDirs {
+		watchDirTree(w.notifier, w.checkpointLister, dir, w.logger)
+	}
+
+	if len(w.runDirs) == 0 {
+		return
+	}
+	for _, dir := range w.runDirs {
+		if err := w.notifier.Add(dir); err != nil {
+			w.logger.WithFields(logrus.Fields{
+				"dir":   dir,
+				"error": err.Error(),
+			}).Error("failed to watch directory")
+		}
+	}
+	relPath, err := filepath.Rel(w.sampleDir, w.runRoot)
+	if err != nil {
+		w.logger.WithFields(logrus.Fields{
+			"absolute_path": w.runRoot,
+			"error":         err.Error(),
+		}).Error("failed to compute relative path for rescanned directory")
+		return
+	}
+	if relPath == "." {
+		relPath = ""
+	}
+	w.sink.Broadcast(model.FSEvent{
+		Type: model.EventDirectoryAdded,
+		Path: filepath.ToSlash(relPath),
+	})
+	w.logger.WithFields(logrus.Fields{
+		"directory_path": relPath,
+		"dir_count":      len(w.runDirs),
+	}).Info("rescanned watched directories")
+}
+
 // handleEvent converts an fsnotify event into a model.FSEvent and broadcasts it.
 func (w *Watcher) handleEvent(ev fsnotify.Event) {
 	w.logger.WithFields(logrus.Fields{
@@ -202,6 +370,13 @@ func (w *Watcher) handleEvent(ev fsnotify.Event) {
 	}).Trace("entering handleEvent")
 	defer w.logger.Trace("returning from handleEvent")
 
+	if !isWithinDir(w.sampleDir, ev.Name) {
+		if dirIdx, relPath, ok := w.checkpointRelPath(ev.Name); ok {
+			w.handleCheckpointEvent(ev, dirIdx, relPath)
+			return
+		}
+	}
+
 	relPath, err := filepath.Rel(w.sampleDir, ev.Name)
 	if err != nil {
 		w.logger.WithFields(logrus.Fields{
@@ -217,15 +392,24 @@ func (w *Watcher) handleEvent(ev fsnotify.Event) {
 		"relative_path": relPath,
 	}).Debug("processing filesystem event")
 
+	// Any change to a sample image makes its cached thumbnail stale.
+	if w.thumbs != nil && isSampleImagePath(relPath) &&
+		ev.Op.Has(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) {
+		w.thumbs.Invalidate(relPath)
+	}
+
 	switch {
 	case ev.Op.Has(fsnotify.Create):
-		if isPNGFile(ev.Name) {
+		if isSampleImagePath(relPath) {
+			if w.images != nil {
+				w.images.AddImage(relPath)
+			}
 			w.sink.Broadcast(model.FSEvent{
 				Type: model.EventImageAdded,
 				Path: relPath,
 			})
 			w.logger.WithField("image_path", relPath).Info("image added")
-		} else if w.isDir(ev.Name) {
+		} else if !isThumbnailDir(relPath) && w.isDir(ev.Name) {
 			w.sink.Broadcast(model.FSEvent{
 				Type: model.EventDirectoryAdded,
 				Path: relPath,
@@ -240,7 +424,10 @@ func (w *Watcher) handleEvent(ev fsnotify.Event) {
 			}
 		}
 	case ev.Op.Has(fsnotify.Remove) || ev.Op.Has(fsnotify.Rename):
-		if isPNGFile(ev.Name) {
+		if isSampleImagePath(relPath) {
+			if w.images != nil {
+				w.images.RemoveImage(relPath)
+			}
 			w.sink.Broadcast(model.FSEvent{
 				Type: model.EventImageRemoved,
 				Path: relPath,
@@ -250,7 +437,90 @@ func (w *Watcher) handleEvent(ev fsnotify.Event) {
 	}
 }
 
-// isPNGFile checks if a path has a .png extension (case-insensitive).
-func isPNGFile(path string) bool {
-	return strings.EqualFold(filepath.Ext(path), ".png")
+// handleCheckpointEvent broadcasts checkpoint file changes and watches new
+// subdirectories of a checkpoint directory.
+func (w *Watcher) handleCheckpointEvent(ev fsnotify.Event, dirIdx int, relPath string) {
+	w.logger.WithFields(logrus.Fields{
+		"event_op":      ev.Op.String(),
+		"dir_index":     dirIdx,
+		"relative_path": relPath,
+	}).Debug("processing checkpoint directory event")
+
+	switch {
+	case ev.Op.Has(fsnotify.Create):
+		if isCheckpointFile(relPath) {
+			w.sink.Broadcast(model.FSEvent{
+				Type: model.EventCheckpointAdded,
+				Path: relPath,
+			})
+			w.logger.WithField("checkpoint_path", relPath).Info("checkpoint added")
+		} else if w.isDir(ev.Name) {
+			watchDirTree(w.notifier, w.checkpointLister, ev.Name, w.logger)
+		}
+	case ev.Op.Has(fsnotify.Remove) || ev.Op.Has(fsnotify.Rename):
+		if isCheckpointFile(relPath) {
+			w.sink.Broadcast(model.FSEvent{
+				Type: model.EventCheckpointRemoved,
+				Path: relPath,
+			})
+			w.logger.WithField("checkpoint_path", relPath).Info("checkpoint removed")
+		}
+	}
+}
+
+// checkpointRelPath returns the index of the checkpoint directory containing
+// path and the slash-separated path relative to it.
+func (w *Watcher) checkpointRelPath(path string) (int, string, bool) {
+	for i, dir := range w.checkpointDirs {
+		if !isWithinDir(dir, path) {
+			continue
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			continue
+		}
+		return i, filepath.ToSlash(relPath), true
+	}
+	return 0, "", false
+}
+
+// isWithinDir reports whether path is strictly inside dir.
+func isWithinDir(dir, path string) bool {
+	return strings.HasPrefix(filepath.Clean(path), filepath.Clean(dir)+string(filepath.Separator))
+}
+
+// watchDirTree adds watches for dir and all of its subdirectories. Failures
+// are logged and the affected subtree is skipped.
+func watchDirTree(notifier WatcherNotifier, lister SubdirectoryLister, dir string, logger *logrus.Entry) {
+	if err := notifier.Add(dir); err != nil {
+		logger.WithFields(logrus.Fields{
+			"dir":   dir,
+			"error": err.Error(),
+		}).Error("failed to watch directory")
+		return
+	}
+	logger.WithField("dir", dir).Debug("watching directory")
+
+	subdirs, err := lister.ListSubdirectories(dir)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"dir":   dir,
+			"error": err.Error(),
+		}).Error("failed to list subdirectories")
+		return
+	}
+	for _, name := range subdirs {
+		watchDirTree(notifier, lister, filepath.Join(dir, name), logger)
+	}
+}
+
+// isCheckpointFile reports whether path is a .safetensors checkpoint.
+func isCheckpointFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".safetensors")
+}
+
+// isSampleImagePath reports whether relPath is a sample image in any supported
+// output format. Generated thumbnails are not sample images.
+func isSampleImagePath(relPath string) bool {
+	return model.IsSampleImageFile(relPath) && !isThumbnailPath(relPath)
 }
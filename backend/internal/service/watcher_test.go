@@ -1,7 +1,9 @@
 package service_test
 
 import (
+	"errors"
 	"io"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -109,6 +111,58 @@ func (s *fakeEventSink) waitForEvents(count int, timeout time.Duration) []model.
 	}
 }
 
+// fakeThumbnailInvalidator records invalidated paths.
+type fakeThumbnailInvalidator struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (f *fakeThumbnailInvalidator) Invalidate(relPath string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = append(f.paths, relPath)
+}
+
+func (f *fakeThumbnailInvalidator) getPaths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cp := make([]string, len(f.paths))
+	copy(cp, f.paths)
+	return cp
+}
+
+// fakeImageIndexUpdater records the images added to and removed from the
+// image index.
+type fakeImageIndexUpdater struct {
+	mu      sync.Mutex
+	added   []string
+	removed []string
+}
+
+func (f *fakeImageIndexUpdater) AddImage(relPath string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.added = append(f.added, relPath)
+}
+
+func (f *fakeImageIndexUpdater) RemoveImage(relPath string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removed = append(f.removed, relPath)
+}
+
+func (f *fakeImageIndexUpdater) getAdded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.added...)
+}
+
+func (f *fakeImageIndexUpdater) getRemoved() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.removed...)
+}
+
 var _ = Describe("Watcher", func() {
 	var (
 		notifier  *fakeNotifier
@@ -353,6 +407,241 @@ var _ = Describe("Watcher", func() {
 			Expect(events).To(HaveLen(1))
 			Expect(events[0].Type).To(Equal(model.EventImageAdded))
 		})
+
+		It("broadcasts image_added for WebP and JPEG sample images", func() {
+			notifier.events <- fsnotify.Event{
+				Name: "/samples/checkpoint.safetensors/image.webp",
+				Op:   fsnotify.Create,
+			}
+			notifier.events <- fsnotify.Event{
+				Name: "/samples/checkpoint.safetensors/image.jpg",
+				Op:   fsnotify.Create,
+			}
+
+			events := sink.waitForEvents(2, time.Second)
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Path).To(Equal("checkpoint.safetensors/image.webp"))
+			Expect(events[1].Path).To(Equal("checkpoint.safetensors/image.jpg"))
+		})
+
+		It("ignores generated JPEG thumbnails", func() {
+			watcher.SetIsDirFunc(func(path string) bool { return false })
+
+			notifier.events <- fsnotify.Event{
+				Name: "/samples/checkpoint.safetensors/thumbnails/image.jpg",
+				Op:   fsnotify.Create,
+			}
+
+			time.Sleep(50 * time.Millisecond)
+			Expect(sink.getEvents()).To(BeEmpty())
+		})
+
+		It("does not watch or announce the thumbnail cache directory", func() {
+			watcher.SetIsDirFunc(func(path string) bool { return true })
+
+			notifier.events <- fsnotify.Event{
+				Name: "/samples/checkpoint.safetensors/.thumbnails",
+				Op:   fsnotify.Create,
+			}
+
+			time.Sleep(50 * time.Millisecond)
+			Expect(sink.getEvents()).To(BeEmpty())
+			Expect(notifier.getAdded()).NotTo(ContainElement("/samples/checkpoint.safetensors/.thumbnails"))
+		})
+	})
+
+	Describe("thumbnail invalidation", func() {
+		var thumbs *fakeThumbnailInvalidator
+
+		BeforeEach(func() {
+			thumbs = &fakeThumbnailInvalidator{}
+			watcher.SetThumbnailInvalidator(thumbs)
+			watcher.SetIsDirFunc(func(path string) bool { return false })
+			err := watcher.WatchTrainingRun(model.TrainingRun{Name: "test"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		DescribeTable("invalidates the cached thumbnail when a sample image changes",
+			func(op fsnotify.Op) {
+				notifier.events <- fsnotify.Event{
+					Name: "/samples/checkpoint.safetensors/image.png",
+					Op:   op,
+				}
+
+				Eventually(thumbs.getPaths).Should(Equal([]string{"checkpoint.safetensors/image.png"}))
+			},
+			Entry("created", fsnotify.Create),
+			Entry("overwritten", fsnotify.Write),
+			Entry("removed", fsnotify.Remove),
+			Entry("renamed", fsnotify.Rename),
+		)
+
+		It("ignores thumbnails and non-image files", func() {
+			notifier.events <- fsnotify.Event{
+				Name: "/samples/checkpoint.safetensors/.thumbnails/image.jpg",
+				Op:   fsnotify.Write,
+			}
+			notifier.events <- fsnotify.Event{
+				Name: "/samples/checkpoint.safetensors/image.json",
+				Op:   fsnotify.Write,
+			}
+
+			time.Sleep(50 * time.Millisecond)
+			Expect(thumbs.getPaths()).To(BeEmpty())
+		})
+	})
+
+	Describe("image index updates", func() {
+		var images *fakeImageIndexUpdater
+
+		BeforeEach(func() {
+			images = &fakeImageIndexUpdater{}
+			watcher.SetImageIndex(images)
+			watcher.SetIsDirFunc(func(path string) bool { return false })
+			err := watcher.WatchTrainingRun(model.TrainingRun{Name: "test"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("records created and removed sample images", func() {
+			notifier.events <- fsnotify.Event{Name: "/samples/checkpoint.safetensors/a.png", Op: fsnotify.Create}
+			notifier.events <- fsnotify.Event{Name: "/samples/checkpoint.safetensors/b.png", Op: fsnotify.Remove}
+			notifier.events <- fsnotify.Event{Name: "/samples/checkpoint.safetensors/c.png", Op: fsnotify.Rename}
+
+			Eventually(images.getAdded).Should(Equal([]string{"checkpoint.safetensors/a.png"}))
+			Eventually(images.getRemoved).Should(Equal([]string{"checkpoint.safetensors/b.png", "checkpoint.safetensors/c.png"}))
+		})
+
+		It("ignores thumbnails", func() {
+			notifier.events <- fsnotify.Event{Name: "/samples/checkpoint.safetensors/.thumbnails/a.jpg", Op: fsnotify.Create}
+
+			time.Sleep(50 * time.Millisecond)
+			Expect(images.getAdded()).To(BeEmpty())
+		})
+	})
+
+	Describe("checkpoint directory watching", func() {
+		BeforeEach(func() {
+			watcher.SetIsDirFunc(func(path string) bool { return filepath.Ext(path) == "" })
+			watcher.WatchCheckpointDirs([]string{"/checkpoints", "/more-checkpoints"}, &fakeAutoSampleDirs{subdirs: map[string][]string{
+				"/checkpoints": {"qwen"},
+			}})
+		})
+
+		It("watches the checkpoint directories and their subdirectories", func() {
+			Expect(notifier.getAdded()).To(Equal([]string{"/checkpoints", "/checkpoints/qwen", "/more-checkpoints"}))
+		})
+
+		It("broadcasts checkpoint_added with the path relative to the checkpoint directory", func() {
+			notifier.events <- fsnotify.Event{Name: "/checkpoints/qwen/my-lora-step00001000.safetensors", Op: fsnotify.Create}
+
+			events := sink.waitForEvents(1, time.Second)
+			Expect(events).To(Equal([]model.FSEvent{{Type: model.EventCheckpointAdded, Path: "qwen/my-lora-step00001000.safetensors"}}))
+		})
+
+		DescribeTable("broadcasts checkpoint_removed",
+			func(op fsnotify.Op) {
+				notifier.events <- fsnotify.Event{Name: "/more-checkpoints/my-lora.safetensors", Op: op}
+
+				events := sink.waitForEvents(1, time.Second)
+				Expect(events).To(Equal([]model.FSEvent{{Type: model.EventCheckpointRemoved, Path: "my-lora.safetensors"}}))
+			},
+			Entry("when a checkpoint is removed", fsnotify.Remove),
+			Entry("when a checkpoint is renamed", fsnotify.Rename),
+		)
+
+		It("ignores files that are not checkpoints", func() {
+			notifier.events <- fsnotify.Event{Name: "/checkpoints/notes.txt", Op: fsnotify.Create}
+			Consistently(sink.getEvents, 50*time.Millisecond).Should(BeEmpty())
+		})
+
+		It("watches subdirectories created later", func() {
+			notifier.events <- fsnotify.Event{Name: "/checkpoints/flux", Op: fsnotify.Create}
+			Eventually(notifier.getAdded).Should(ContainElement("/checkpoints/flux"))
+			Expect(sink.getEvents()).To(BeEmpty())
+		})
+
+		It("keeps watching checkpoint directories after the viewed training run changes", func() {
+			Expect(watcher.WatchTrainingRun(model.TrainingRun{Name: "other"})).To(Succeed())
+			notifier.events <- fsnotify.Event{Name: "/checkpoints/new.safetensors", Op: fsnotify.Create}
+
+			events := sink.waitForEvents(1, time.Second)
+			Expect(events).To(Equal([]model.FSEvent{{Type: model.EventCheckpointAdded, Path: "new.safetensors"}}))
+		})
+	})
+
+	Describe("event counters", func() {
+		BeforeEach(func() {
+			Expect(watcher.WatchTrainingRun(model.TrainingRun{Name: "test"})).To(Succeed())
+		})
+
+		It("coalesces repeated events for the same file", func() {
+			for i := 0; i < 3; i++ {
+				notifier.events <- fsnotify.Event{Name: "/samples/cp1/image.png", Op: fsnotify.Create}
+			}
+			notifier.events <- fsnotify.Event{Name: "/samples/cp1/other.png", Op: fsnotify.Create}
+
+			events := sink.waitForEvents(2, time.Second)
+			Expect(events).To(Equal([]model.FSEvent{
+				{Type: model.EventImageAdded, Path: "cp1/image.png"},
+				{Type: model.EventImageAdded, Path: "cp1/other.png"},
+			}))
+			Expect(watcher.Stats()).To(Equal(model.WatcherStats{EventsReceived: 4, EventsCoalesced: 2}))
+		})
+
+		It("does not coalesce different operations on the same file", func() {
+			notifier.events <- fsnotify.Event{Name: "/samples/cp1/image.png", Op: fsnotify.Create}
+			notifier.events <- fsnotify.Event{Name: "/samples/cp1/image.png", Op: fsnotify.Remove}
+
+			Expect(sink.waitForEvents(2, time.Second)).To(HaveLen(2))
+			Expect(watcher.Stats().EventsCoalesced).To(BeZero())
+		})
+	})
+
+	Describe("event queue overflow", func() {
+		BeforeEach(func() {
+			watcher.SetIsDirFunc(func(path string) bool { return filepath.Ext(path) == "" })
+			watcher.WatchCheckpointDirs([]string{"/checkpoints"}, &fakeAutoSampleDirs{subdirs: map[string][]string{
+				"/checkpoints": {"qwen"},
+			}})
+			Expect(watcher.WatchTrainingRun(model.TrainingRun{
+				Name:        "my-study/model",
+				Checkpoints: []model.Checkpoint{{Filename: "model-step1000.safetensors", HasSamples: true}},
+			})).To(Succeed())
+		})
+
+		It("rewatches the watched directories and announces the run directory", func() {
+			addedBefore := len(notifier.getAdded())
+			notifier.errors <- fsnotify.ErrEventOverflow
+
+			events := sink.waitForEvents(1, time.Second)
+			Expect(events).To(Equal([]model.FSEvent{{Type: model.EventDirectoryAdded, Path: "my-study"}}))
+			Expect(notifier.getAdded()[addedBefore:]).To(ConsistOf(
+				"/checkpoints",
+				"/checkpoints/qwen",
+				"/samples/my-study/model-step1000.safetensors",
+				"/samples/my-study",
+			))
+			Expect(watcher.Stats()).To(Equal(model.WatcherStats{Overflows: 1, RescansTriggered: 1}))
+		})
+
+		It("does not coalesce an event repeated across an overflow", func() {
+			notifier.events <- fsnotify.Event{Name: "/samples/my-study/model-step1000.safetensors/a.png", Op: fsnotify.Create}
+			Expect(sink.waitForEvents(1, time.Second)).To(HaveLen(1))
+			notifier.errors <- fsnotify.ErrEventOverflow
+			Expect(sink.waitForEvents(2, time.Second)).To(HaveLen(2))
+
+			notifier.events <- fsnotify.Event{Name: "/samples/my-study/model-step1000.safetensors/a.png", Op: fsnotify.Create}
+
+			Expect(sink.waitForEvents(3, time.Second)).To(HaveLen(3))
+			Expect(watcher.Stats().EventsCoalesced).To(BeZero())
+		})
+
+		It("counts other watcher errors as neither overflows nor rescans", func() {
+			notifier.errors <- errors.New("boom")
+
+			Consistently(sink.getEvents, 50*time.Millisecond).Should(BeEmpty())
+			Expect(watcher.Stats()).To(Equal(model.WatcherStats{}))
+		})
 	})
 
 	Describe("Stop", func() {
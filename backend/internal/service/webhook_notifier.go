@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// webhookTimeout bounds the notification of one job, including every webhook.
+const webhookTimeout = 30 * time.Second
+
+// Discord embed colors of the job events.
+var discordEventColors = map[model.JobEvent]int{
+	model.JobEventCompleted: 0x2ecc71,
+	model.JobEventFailed:    0xe74c3c,
+	model.JobEventStopped:   0x95a5a6,
+}
+
+// WebhookPoster posts a JSON payload to a webhook URL. It is satisfied by
+// store.WebhookClient.
+type WebhookPoster interface {
+	Post(ctx context.Context, url string, payload []byte) error
+}
+
+// NotificationItemStore lists the items of a job for its summary.
+type NotificationItemStore interface {
+	ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error)
+}
+
+// WebhookNotifier calls the configured webhooks when a sample job completes,
+// fails, or is stopped. Webhooks are called in the background so that a slow
+// or unreachable endpoint never holds up the job executor; failures are
+// logged and not retried.
+type WebhookNotifier struct {
+	store     NotificationItemStore
+	poster    WebhookPoster
+	webhooks  []model.WebhookConfig
+	gridLinks *GridLinkService
+	logger    *logrus.Entry
+
+	wg sync.WaitGroup
+}
+
+// NewWebhookNotifier creates a WebhookNotifier for the webhooks in cfg.
+func NewWebhookNotifier(store NotificationItemStore, poster WebhookPoster, cfg model.NotificationsConfig, logger *logrus.Logger) *WebhookNotifier {
+	n := &WebhookNotifier{
+		store:    store,
+		poster:   poster,
+		webhooks: cfg.Webhooks,
+		logger:   logger.WithField("component", "webhook_notifier"),
+	}
+	if cfg.UIURL != "" {
+		n.gridLinks = NewGridLinkService(cfg.UIURL)
+	}
+	return n
+}
+
+// JobFinished implements JobNotifier. It notifies the webhooks subscribed to
+// the event of the job's status in the background; statuses that do not end
+// a job are ignored.
+func (n *WebhookNotifier) JobFinished(job model.SampleJob) {
+	event, ok := model.JobEventForStatus(job.Status)
+	if !ok {
+		return
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+		defer cancel()
+		n.Notify(ctx, n.Summarize(ctx, job, event))
+	}()
+}
+
+// Wait blocks until background notifications have been sent.
+func (n *WebhookNotifier) Wait() {
+	n.wg.Wait()
+}
+
+// Summarize builds the notification summary of job. Item counts and the
+// duration are computed from the job's items; if they cannot be listed, the
+// summary falls back to the job's own counters.
+func (n *WebhookNotifier) Summarize(ctx context.Context, job model.SampleJob, event model.JobEvent) model.JobSummary {
+	summary := model.JobSummary{
+		Event:           event,
+		JobID:           job.ID,
+		TrainingRunName: job.TrainingRunName,
+		StudyName:       job.StudyName,
+		Status:          job.Status,
+		TotalItems:      job.TotalItems,
+		CompletedItems:  job.CompletedItems,
+	}
+	if n.gridLinks != nil {
+		summary.GridURL = n.gridLinks.URL(model.GridLink{TrainingRun: job.TrainingRunName, StudyName: job.StudyName})
+	}
+
+	items, err := n.store.ListSampleJobItems(ctx, job.ID)
+	if err != nil {
+		n.logger.WithFields(logrus.Fields{
+			"job_id": job.ID,
+			"error":  err.Error(),
+		}).Warn("failed to list job items for notification, using job counters")
+		return summary
+	}
+	summary.TotalItems = len(items)
+	summary.CompletedItems = 0
+	var first, last time.Time
+	for _, item := range items {
+		switch item.Status {
+		case model.SampleJobItemStatusCompleted:
+			summary.CompletedItems++
+		case model.SampleJobItemStatusFailed, model.SampleJobItemStatusSkipped:
+			summary.FailedItems++
+		case model.SampleJobItemStatusPending:
+			summary.PendingItems++
+		}
+		if item.StartedAt != nil && (first.IsZero() || item.StartedAt.Before(first)) {
+			first = *item.StartedAt
+		}
+		if item.CompletedAt != nil && item.CompletedAt.After(last) {
+			last = *item.CompletedAt
+		}
+	}
+	if !first.IsZero() && last.After(first) {
+		summary.Duration = last.Sub(first)
+	}
+	return summary
+}
+
+// Notify posts summary to every webhook subscribed to its event. A failing
+// webhook does not prevent the others from being called.
+func (n *WebhookNotifier) Notify(ctx context.Context, summary model.JobSummary) {
+	n.logger.WithFields(logrus.Fields{
+		"job_id": summary.JobID,
+		"event":  summary.Event,
+	}).Trace("entering Notify")
+	defer n.logger.Trace("returning from Notify")
+
+	for i, w := range n.webhooks {
+		if !slices.Contains(w.Events, summary.Event) {
+			continue
+		}
+		payload, err := webhookPayload(w.Format, summary)
+		if err != nil {
+			n.logger.WithError(err).Error("failed to encode webhook payload")
+			continue
+		}
+		if err := n.poster.Post(ctx, w.URL, payload); err != nil {
+			n.logger.WithFields(logrus.Fields{
+				"webhook": i,
+				"job_id":  summary.JobID,
+				"event":   summary.Event,
+				"error":   err.Error(),
+			}).Warn("webhook notification failed")
+			continue
+		}
+		n.logger.WithFields(logrus.Fields{
+			"webhook": i,
+			"job_id":  summary.JobID,
+			"event":   summary.Event,
+		}).Info("webhook notified")
+	}
+}
+
+// webhookJSONPayload is the payload of json webhooks.
+type webhookJSONPayload struct {
+	Event           string  `json:"event"`
+	JobID           string  `json:"job_id"`
+	TrainingRun     string  `json:"training_run"`
+	Study           string  `json:"study"`
+	Status          string  `json:"status"`
+	TotalItems      int     `json:"total_items"`
+	CompletedItems  int     `json:"completed_items"`
+	FailedItems     int     `json:"failed_items"`
+	PendingItems    int     `json:"pending_items"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	GridURL         string  `json:"grid_url,omitempty"`
+	Message         string  `json:"message"`
+}
+
+// discordPayload is the payload of Discord webhooks.
+type discordPayload struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	URL         string         `json:"url,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// slackPayload is the payload of Slack incoming webhooks.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// webhookPayload encodes summary in format.
+func webhookPayload(format model.WebhookFormat, summary model.JobSummary) ([]byte, error) {
+	switch format {
+	case model.WebhookFormatDiscord:
+		return json.Marshal(discordPayload{Embeds: []discordEmbed{{
+			Title:       jobSummaryTitle(summary),
+			Description: jobSummaryMessage(summary),
+			URL:         summary.GridURL,
+			Color:       discordEventColors[summary.Event],
+			Fields: []discordField{
+				{Name: "Completed", Value: fmt.Sprintf("%d / %d", summary.CompletedItems, summary.TotalItems), Inline: true},
+				{Name: "Failed", Value: fmt.Sprintf("%d", summary.FailedItems), Inline: true},
+				{Name: "Duration", Value: summary.Duration.Round(time.Second).String(), Inline: true},
+			},
+		}}})
+	case model.WebhookFormatSlack:
+		text := "*" + jobSummaryTitle(summary) + "*\n" + jobSummaryMessage(summary)
+		if summary.GridURL != "" {
+			text += "\n<" + summary.GridURL + "|Open in grid>"
+		}
+		return json.Marshal(slackPayload{Text: text})
+	default:
+		return json.Marshal(webhookJSONPayload{
+			Event:           string(summary.Event),
+			JobID:           summary.JobID,
+			TrainingRun:     summary.TrainingRunName,
+			Study:           summary.StudyName,
+			Status:          string(summary.Status),
+			TotalItems:      summary.TotalItems,
+			CompletedItems:  summary.CompletedItems,
+			FailedItems:     summary.FailedItems,
+			PendingItems:    summary.PendingItems,
+			DurationSeconds: summary.Duration.Seconds(),
+			GridURL:         summary.GridURL,
+			Message:         jobSummaryMessage(summary),
+		})
+	}
+}
+
+// jobSummaryTitle returns a one-line title, e.g. "Sample job completed: my-lora".
+func jobSummaryTitle(summary model.JobSummary) string {
+	return fmt.Sprintf("Sample job %s: %s", summary.Event, summary.TrainingRunName)
+}
+
+// jobSummaryMessage describes the outcome of the job in one sentence.
+func jobSummaryMessage(summary model.JobSummary) string {
+	return fmt.Sprintf("Study %q on %s %s with %d of %d items completed and %d failed in %s.",
+		summary.StudyName, summary.TrainingRunName, summary.Event,
+		summary.CompletedItems, summary.TotalItems, summary.FailedItems,
+		summary.Duration.Round(time.Second))
+}
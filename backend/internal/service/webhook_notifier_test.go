@@ -0,0 +1,173 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+// fakeNotificationItemStore returns fixed job items.
+type fakeNotificationItemStore struct {
+	items []model.SampleJobItem
+	err   error
+}
+
+func (f *fakeNotificationItemStore) ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error) {
+	return f.items, f.err
+}
+
+// recordingWebhookPoster records posted payloads by URL.
+type recordingWebhookPoster struct {
+	mu       sync.Mutex
+	payloads map[string][]byte
+	failURL  string
+}
+
+func (p *recordingWebhookPoster) Post(ctx context.Context, url string, payload []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if url == p.failURL {
+		return errors.New("webhook request to hooks.example.com failed with status 500")
+	}
+	p.payloads[url] = payload
+	return nil
+}
+
+var _ = Describe("WebhookNotifier", func() {
+	var (
+		store  *fakeNotificationItemStore
+		poster *recordingWebhookPoster
+		logger *logrus.Logger
+		start  time.Time
+		job    model.SampleJob
+	)
+
+	BeforeEach(func() {
+		start = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		at := func(d time.Duration) *time.Time {
+			t := start.Add(d)
+			return &t
+		}
+		store = &fakeNotificationItemStore{items: []model.SampleJobItem{
+			{ID: "i1", Status: model.SampleJobItemStatusCompleted, StartedAt: at(0), CompletedAt: at(time.Minute)},
+			{ID: "i2", Status: model.SampleJobItemStatusCompleted, StartedAt: at(time.Minute), CompletedAt: at(90 * time.Second)},
+			{ID: "i3", Status: model.SampleJobItemStatusFailed, StartedAt: at(90 * time.Second), CompletedAt: at(2 * time.Minute)},
+			{ID: "i4", Status: model.SampleJobItemStatusSkipped},
+		}}
+		poster = &recordingWebhookPoster{payloads: map[string][]byte{}}
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+		job = model.SampleJob{
+			ID:              "job-1",
+			TrainingRunName: "my-lora",
+			StudyName:       "Sweep",
+			Status:          model.SampleJobStatusCompletedWithErrors,
+			TotalItems:      4,
+			CompletedItems:  2,
+		}
+	})
+
+	newNotifier := func(cfg model.NotificationsConfig) *service.WebhookNotifier {
+		return service.NewWebhookNotifier(store, poster, cfg, logger)
+	}
+
+	Describe("Summarize", func() {
+		It("counts the items and measures the duration", func() {
+			summary := newNotifier(model.NotificationsConfig{UIURL: "http://ui.local"}).Summarize(ctx, job, model.JobEventFailed)
+			Expect(summary.TotalItems).To(Equal(4))
+			Expect(summary.CompletedItems).To(Equal(2))
+			Expect(summary.FailedItems).To(Equal(2))
+			Expect(summary.PendingItems).To(Equal(0))
+			Expect(summary.Duration).To(Equal(2 * time.Minute))
+			Expect(summary.GridURL).To(Equal("http://ui.local/?run=my-lora&study=Sweep&v=1"))
+		})
+
+		It("falls back to the job counters when the items cannot be listed", func() {
+			store.err = errors.New("database is locked")
+			summary := newNotifier(model.NotificationsConfig{}).Summarize(ctx, job, model.JobEventFailed)
+			Expect(summary.TotalItems).To(Equal(4))
+			Expect(summary.CompletedItems).To(Equal(2))
+			Expect(summary.Duration).To(BeZero())
+			Expect(summary.GridURL).To(BeEmpty())
+		})
+	})
+
+	Describe("JobFinished", func() {
+		It("posts each format to the webhooks subscribed to the event", func() {
+			notifier := newNotifier(model.NotificationsConfig{Webhooks: []model.WebhookConfig{
+				{URL: "http://json", Format: model.WebhookFormatJSON, Events: model.AllJobEvents},
+				{URL: "http://discord", Format: model.WebhookFormatDiscord, Events: []model.JobEvent{model.JobEventFailed}},
+				{URL: "http://slack", Format: model.WebhookFormatSlack, Events: []model.JobEvent{model.JobEventFailed}},
+				{URL: "http://completed-only", Format: model.WebhookFormatJSON, Events: []model.JobEvent{model.JobEventCompleted}},
+			}})
+
+			notifier.JobFinished(job)
+			notifier.Wait()
+
+			Expect(poster.payloads).To(HaveLen(3))
+
+			var generic map[string]any
+			Expect(json.Unmarshal(poster.payloads["http://json"], &generic)).To(Succeed())
+			Expect(generic).To(HaveKeyWithValue("event", "failed"))
+			Expect(generic).To(HaveKeyWithValue("job_id", "job-1"))
+			Expect(generic).To(HaveKeyWithValue("training_run", "my-lora"))
+			Expect(generic).To(HaveKeyWithValue("status", "completed_with_errors"))
+			Expect(generic).To(HaveKeyWithValue("total_items", 4.0))
+			Expect(generic).To(HaveKeyWithValue("failed_items", 2.0))
+			Expect(generic).To(HaveKeyWithValue("duration_seconds", 120.0))
+			Expect(generic).NotTo(HaveKey("grid_url"))
+
+			var discord struct {
+				Embeds []struct {
+					Title string `json:"title"`
+					Color int    `json:"color"`
+				} `json:"embeds"`
+			}
+			Expect(json.Unmarshal(poster.payloads["http://discord"], &discord)).To(Succeed())
+			Expect(discord.Embeds).To(HaveLen(1))
+			Expect(discord.Embeds[0].Title).To(Equal("Sample job failed: my-lora"))
+			Expect(discord.Embeds[0].Color).NotTo(BeZero())
+
+			var slack struct {
+				Text string `json:"text"`
+			}
+			Expect(json.Unmarshal(poster.payloads["http://slack"], &slack)).To(Succeed())
+			Expect(slack.Text).To(ContainSubstring("2 of 4 items completed and 2 failed in 2m0s"))
+		})
+
+		It("keeps notifying the other webhooks when one fails", func() {
+			poster.failURL = "http://down"
+			notifier := newNotifier(model.NotificationsConfig{Webhooks: []model.WebhookConfig{
+				{URL: "http://down", Format: model.WebhookFormatJSON, Events: model.AllJobEvents},
+				{URL: "http://up", Format: model.WebhookFormatJSON, Events: model.AllJobEvents},
+			}})
+
+			notifier.JobFinished(job)
+			notifier.Wait()
+
+			Expect(poster.payloads).To(HaveKey("http://up"))
+		})
+
+		It("ignores jobs that have not finished", func() {
+			notifier := newNotifier(model.NotificationsConfig{Webhooks: []model.WebhookConfig{
+				{URL: "http://json", Format: model.WebhookFormatJSON, Events: model.AllJobEvents},
+			}})
+			job.Status = model.SampleJobStatusRunning
+
+			notifier.JobFinished(job)
+			notifier.Wait()
+
+			Expect(poster.payloads).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// BuildWorkflowGraph lays a workflow template out as a graph of nodes and
+// links, marking the inputs substituted for the nodes' cs_roles. Nodes are
+// ordered by ID and inputs by name. Substitutions that may not do what the
+// workflow author expects, such as a substituted input that is linked from
+// another node, are reported as warnings.
+func BuildWorkflowGraph(tmpl model.WorkflowTemplate) model.WorkflowGraph {
+	graph := model.WorkflowGraph{
+		Name:     tmpl.Name,
+		Nodes:    []model.WorkflowGraphNode{},
+		Links:    []model.WorkflowGraphLink{},
+		Warnings: []string{},
+	}
+
+	ids := make([]string, 0, len(tmpl.Workflow))
+	for id := range tmpl.Workflow {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessNodeID(ids[i], ids[j]) })
+
+	for _, id := range ids {
+		nodeMap, ok := tmpl.Workflow[id].(map[string]interface{})
+		if !ok {
+			graph.Warnings = append(graph.Warnings, fmt.Sprintf("node %s is not an object", id))
+			continue
+		}
+		node := model.WorkflowGraphNode{ID: id, Inputs: []model.WorkflowGraphInput{}}
+		node.ClassType, _ = nodeMap["class_type"].(string)
+		if meta, ok := nodeMap["_meta"].(map[string]interface{}); ok {
+			node.Title, _ = meta["title"].(string)
+			node.Role, _ = meta["cs_role"].(string)
+		}
+		node.KnownRole = node.Role != "" && model.IsKnownRole(node.Role)
+
+		substituted := make(map[string]bool)
+		for _, name := range model.CSRole(node.Role).SubstitutedInputs() {
+			if key, ok := inputKey(nodeMap, name); ok {
+				substituted[key] = true
+			}
+		}
+
+		inputs, _ := nodeMap["inputs"].(map[string]interface{})
+		names := make([]string, 0, len(inputs))
+		for name := range inputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			input := model.WorkflowGraphInput{Name: name, Substituted: substituted[name]}
+			if from, output, ok := parseNodeLink(inputs[name]); ok {
+				input.Linked = true
+				graph.Links = append(graph.Links, model.WorkflowGraphLink{
+					FromNode:   from,
+					FromOutput: output,
+					ToNode:     id,
+					ToInput:    name,
+				})
+				if _, exists := tmpl.Workflow[from]; !exists {
+					graph.Warnings = append(graph.Warnings, fmt.Sprintf("input %q of node %s links to missing node %s", name, id, from))
+				}
+				if input.Substituted {
+					graph.Warnings = append(graph.Warnings, fmt.Sprintf("input %q of node %s is linked from node %s; cs_role %s replaces the link with a value", name, id, from, node.Role))
+				}
+			} else {
+				input.Value = inputs[name]
+			}
+			node.Inputs = append(node.Inputs, input)
+			delete(substituted, name)
+		}
+
+		// Substituted inputs the node does not declare are added at
+		// submission time, which ComfyUI may ignore.
+		missing := make([]string, 0, len(substituted))
+		for name := range substituted {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		for _, name := range missing {
+			node.Inputs = append(node.Inputs, model.WorkflowGraphInput{Name: name, Substituted: true})
+			graph.Warnings = append(graph.Warnings, fmt.Sprintf("node %s has no input %q; cs_role %s adds it", id, name, node.Role))
+		}
+
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	return graph
+}
+
+// parseNodeLink reports whether an input value is a link to another node's
+// output, which ComfyUI's API format writes as [node ID, output index].
+func parseNodeLink(value interface{}) (string, int, bool) {
+	pair, ok := value.([]interface{})
+	if !ok || len(pair) != 2 {
+		return "", 0, false
+	}
+	from, ok := pair[0].(string)
+	if !ok {
+		return "", 0, false
+	}
+	output, ok := toInt(pair[1])
+	if !ok {
+		return "", 0, false
+	}
+	return from, output, true
+}
+
+// lessNodeID orders node IDs numerically, segment by segment, so that "9"
+// comes before "10" and subgraph IDs such as "75:58" sort after "75".
+// Non-numeric segments are compared as strings.
+func lessNodeID(a, b string) bool {
+	as, bs := strings.Split(a, ":"), strings.Split(b, ":")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+var _ = Describe("BuildWorkflowGraph", func() {
+	It("lays out nodes, links and substituted inputs", func() {
+		graph := BuildWorkflowGraph(model.WorkflowTemplate{
+			Name: "flux.json",
+			Workflow: map[string]interface{}{
+				"10": map[string]interface{}{
+					"class_type": "SaveImage",
+					"inputs":     map[string]interface{}{"filename_prefix": "ComfyUI", "images": []interface{}{"9", float64(0)}},
+					"_meta":      map[string]interface{}{"title": "Save", "cs_role": "save_image"},
+				},
+				"9": map[string]interface{}{
+					"class_type": "VAEDecode",
+					"inputs":     map[string]interface{}{"samples": []interface{}{"3", float64(0)}},
+				},
+				"3": map[string]interface{}{
+					"class_type": "KSampler",
+					"inputs": map[string]interface{}{
+						"seed": float64(1), "steps": float64(20), "cfg": float64(4),
+						"sampler_name": "euler", "scheduler": "simple", "denoise": float64(1),
+					},
+					"_meta": map[string]interface{}{"cs_role": "sampler"},
+				},
+			},
+		})
+
+		Expect(graph.Name).To(Equal("flux.json"))
+		Expect(graph.Warnings).To(BeEmpty())
+		Expect(graph.Nodes).To(HaveLen(3))
+		Expect([]string{graph.Nodes[0].ID, graph.Nodes[1].ID, graph.Nodes[2].ID}).To(Equal([]string{"3", "9", "10"}))
+
+		sampler := graph.Nodes[0]
+		Expect(sampler.ClassType).To(Equal("KSampler"))
+		Expect(sampler.Role).To(Equal("sampler"))
+		Expect(sampler.KnownRole).To(BeTrue())
+		substituted := map[string]bool{}
+		for _, in := range sampler.Inputs {
+			substituted[in.Name] = in.Substituted
+		}
+		Expect(substituted).To(Equal(map[string]bool{
+			"cfg": true, "denoise": false, "sampler_name": true, "scheduler": true, "seed": true, "steps": true,
+		}))
+
+		save := graph.Nodes[2]
+		Expect(save.Title).To(Equal("Save"))
+		Expect(save.Inputs).To(Equal([]model.WorkflowGraphInput{
+			{Name: "filename_prefix", Value: "ComfyUI", Substituted: true},
+			{Name: "images", Linked: true},
+		}))
+
+		Expect(graph.Links).To(ConsistOf(
+			model.WorkflowGraphLink{FromNode: "3", FromOutput: 0, ToNode: "9", ToInput: "samples"},
+			model.WorkflowGraphLink{FromNode: "9", FromOutput: 0, ToNode: "10", ToInput: "images"},
+		))
+	})
+
+	It("warns about substituted inputs that are linked or missing", func() {
+		graph := BuildWorkflowGraph(model.WorkflowTemplate{
+			Workflow: map[string]interface{}{
+				"7": map[string]interface{}{
+					"class_type": "CLIPTextEncode",
+					"inputs":     map[string]interface{}{"text": []interface{}{"12", float64(0)}},
+					"_meta":      map[string]interface{}{"cs_role": "negative_prompt"},
+				},
+				"8": map[string]interface{}{
+					"class_type": "EmptyLatentImage",
+					"inputs":     map[string]interface{}{"width": float64(1024), "height": float64(1024)},
+					"_meta":      map[string]interface{}{"cs_role": "latent_image"},
+				},
+			},
+		})
+
+		Expect(graph.Warnings).To(ConsistOf(
+			`input "text" of node 7 links to missing node 12`,
+			`input "text" of node 7 is linked from node 12; cs_role negative_prompt replaces the link with a value`,
+			`node 8 has no input "batch_size"; cs_role latent_image adds it`,
+		))
+		latent := graph.Nodes[1]
+		Expect(latent.Inputs[len(latent.Inputs)-1]).To(Equal(model.WorkflowGraphInput{Name: "batch_size", Substituted: true}))
+	})
+
+	It("substitutes nothing on nodes with unknown roles", func() {
+		graph := BuildWorkflowGraph(model.WorkflowTemplate{
+			Workflow: map[string]interface{}{
+				"5": map[string]interface{}{
+					"class_type": "LoraLoader",
+					"inputs":     map[string]interface{}{"strength_model": float64(1)},
+					"_meta":      map[string]interface{}{"cs_role": "lora"},
+				},
+			},
+		})
+
+		Expect(graph.Nodes[0].Role).To(Equal("lora"))
+		Expect(graph.Nodes[0].KnownRole).To(BeFalse())
+		Expect(graph.Nodes[0].Inputs[0].Substituted).To(BeFalse())
+	})
+
+	It("orders subgraph node IDs after their parent", func() {
+		ids := []string{"75:58", "100", "75", "9", "75:6"}
+		graph := model.WorkflowTemplate{Workflow: map[string]interface{}{}}
+		for _, id := range ids {
+			graph.Workflow[id] = map[string]interface{}{"class_type": "Node"}
+		}
+
+		var ordered []string
+		for _, n := range BuildWorkflowGraph(graph).Nodes {
+			ordered = append(ordered, n.ID)
+		}
+		Expect(ordered).To(Equal([]string{"9", "75", "75:6", "75:58", "100"}))
+	})
+
+	// The graph reports what substituteNode writes; this keeps the two in step.
+	DescribeTable("matches the inputs substituteNode writes",
+		func(role model.CSRole) {
+			shift, denoise, strength := 3.0, 0.5, 0.8
+			job := model.SampleJob{
+				VAE: "ae.safetensors", CLIP: "clip_l.safetensors", Shift: &shift,
+				ControlNetModel: "control_canny.safetensors", ControlNetStrength: &strength,
+			}
+			item := model.SampleJobItem{NegativePrompt: "blurry", Denoise: &denoise}
+			workflow := map[string]interface{}{
+				"1": map[string]interface{}{"inputs": map[string]interface{}{}},
+			}
+
+			Expect(substituteNode(workflow, "1", string(role), job, item, uploadedImages{Input: "cs-input.png"})).To(Succeed())
+
+			var written []string
+			for name := range workflow["1"].(map[string]interface{})["inputs"].(map[string]interface{}) {
+				written = append(written, name)
+			}
+			Expect(written).To(ConsistOf(role.SubstitutedInputs()))
+		},
+		func() []TableEntry {
+			var entries []TableEntry
+			for _, role := range model.KnownCSRoles() {
+				entries = append(entries, Entry(string(role), role))
+			}
+			return entries
+		}(),
+	)
+})
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// inputKey returns the key of a node's input that Checkpoint Sampler writes
+// for the input it calls name (one of its role's SubstitutedInputs). Nodes
+// whose inputs are named differently, such as custom samplers taking
+// "noise_seed" instead of "seed", rename them in _meta.cs_inputs:
+//
+//	"_meta": {"cs_role": "sampler", "cs_inputs": {"seed": "noise_seed"}}
+//
+// Mapping a name to "" leaves that input alone; ok is false for it. Invalid
+// entries are reported when the workflow is loaded and otherwise ignored.
+func inputKey(node map[string]interface{}, name string) (key string, ok bool) {
+	meta, _ := node["_meta"].(map[string]interface{})
+	aliases, _ := meta["cs_inputs"].(map[string]interface{})
+	alias, isString := aliases[name].(string)
+	if !isString {
+		return name, true
+	}
+	return alias, alias != ""
+}
+
+// aliasableInputs returns the inputs of a role that _meta.cs_inputs may
+// rename: the inputs it substitutes, and for controlnet_apply the image input
+// linked to the conditioning image's loader.
+func aliasableInputs(role model.CSRole) []string {
+	names := role.SubstitutedInputs()
+	if role == model.CSRoleControlNetApply {
+		names = append(names, "image")
+	}
+	return names
+}
+
+// validateInputAliases returns warnings for the _meta.cs_inputs entries of a
+// node that are not a string renaming one of the role's inputs.
+func validateInputAliases(nodeID string, role string, meta map[string]interface{}) []string {
+	raw, ok := meta["cs_inputs"]
+	if !ok {
+		return nil
+	}
+	aliases, ok := raw.(map[string]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("cs_inputs on node %s must be an object", nodeID)}
+	}
+
+	allowed := make(map[string]bool)
+	for _, name := range aliasableInputs(model.CSRole(role)) {
+		allowed[name] = true
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings []string
+	for _, name := range names {
+		if _, ok := aliases[name].(string); !ok {
+			warnings = append(warnings, fmt.Sprintf("cs_inputs %q on node %s must be a string", name, nodeID))
+			continue
+		}
+		if !allowed[name] {
+			warnings = append(warnings, fmt.Sprintf("cs_inputs on node %s renames %q, which cs_role %q does not substitute", nodeID, name, role))
+		}
+	}
+	return warnings
+}
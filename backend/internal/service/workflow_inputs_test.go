@@ -0,0 +1,97 @@
+package service
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+var _ = Describe("cs_inputs aliases", func() {
+	var workflow map[string]interface{}
+
+	BeforeEach(func() {
+		workflow = map[string]interface{}{
+			"3": map[string]interface{}{
+				"class_type": "CustomSampler",
+				"_meta": map[string]interface{}{
+					"cs_role":   "sampler",
+					"cs_inputs": map[string]interface{}{"seed": "noise_seed", "scheduler": ""},
+				},
+				"inputs": map[string]interface{}{"noise_seed": float64(0), "steps": float64(20), "cfg": float64(7), "sampler_name": "euler"},
+			},
+		}
+	})
+
+	inputsOf := func(nodeID string) map[string]interface{} {
+		return workflow[nodeID].(map[string]interface{})["inputs"].(map[string]interface{})
+	}
+
+	It("writes renamed inputs and skips inputs mapped to an empty name", func() {
+		item := model.SampleJobItem{Seed: 42, Steps: 30, CFG: 3.5, SamplerName: "dpmpp_2m", Scheduler: "karras"}
+
+		Expect(substituteNode(workflow, "3", "sampler", model.SampleJob{}, item, uploadedImages{})).To(Succeed())
+
+		Expect(inputsOf("3")).To(Equal(map[string]interface{}{
+			"noise_seed": int64(42), "steps": 30, "cfg": 3.5, "sampler_name": "dpmpp_2m",
+		}))
+	})
+
+	It("follows a renamed image input of a controlnet_apply node to its loader", func() {
+		workflow["12"] = map[string]interface{}{
+			"class_type": "ApplyControl",
+			"_meta": map[string]interface{}{
+				"cs_role":   "controlnet_apply",
+				"cs_inputs": map[string]interface{}{"image": "hint", "strength": "weight"},
+			},
+			"inputs": map[string]interface{}{"hint": []interface{}{"13", float64(0)}, "weight": float64(1)},
+		}
+		workflow["13"] = map[string]interface{}{
+			"class_type": "LoadImageCustom",
+			"_meta":      map[string]interface{}{"cs_inputs": map[string]interface{}{"image": "file"}},
+			"inputs":     map[string]interface{}{"file": "example.png"},
+		}
+		strength := 0.6
+		job := model.SampleJob{ControlNetStrength: &strength}
+
+		Expect(substituteNode(workflow, "12", "controlnet_apply", job, model.SampleJobItem{}, uploadedImages{ControlNet: "cs-controlnet-1.png"})).To(Succeed())
+
+		Expect(inputsOf("12")).To(HaveKeyWithValue("weight", 0.6))
+		Expect(inputsOf("13")).To(Equal(map[string]interface{}{"file": "cs-controlnet-1.png"}))
+	})
+
+	It("is used when minimizing the pre-warm workflow", func() {
+		workflow["3"].(map[string]interface{})["_meta"].(map[string]interface{})["cs_inputs"] = map[string]interface{}{"steps": "num_steps"}
+		inputsOf("3")["num_steps"] = float64(20)
+
+		minimizePrewarmWorkflow(workflow, map[string][]string{"sampler": {"3"}})
+
+		Expect(inputsOf("3")).To(HaveKeyWithValue("num_steps", 1))
+		Expect(inputsOf("3")).To(HaveKeyWithValue("steps", float64(20)))
+	})
+
+	It("marks renamed inputs as substituted in the graph", func() {
+		graph := BuildWorkflowGraph(model.WorkflowTemplate{Workflow: workflow})
+
+		substituted := map[string]bool{}
+		for _, in := range graph.Nodes[0].Inputs {
+			substituted[in.Name] = in.Substituted
+		}
+		Expect(substituted).To(Equal(map[string]bool{
+			"noise_seed": true, "steps": true, "cfg": true, "sampler_name": true,
+		}))
+		Expect(graph.Warnings).To(BeEmpty())
+	})
+
+	DescribeTable("validateInputAliases",
+		func(aliases interface{}, expected []string) {
+			meta := map[string]interface{}{"cs_role": "sampler", "cs_inputs": aliases}
+			Expect(validateInputAliases("3", "sampler", meta)).To(Equal(expected))
+		},
+		Entry("accepts renames of substituted inputs", map[string]interface{}{"seed": "noise_seed", "scheduler": ""}, nil),
+		Entry("rejects a non-object", "noise_seed", []string{"cs_inputs on node 3 must be an object"}),
+		Entry("rejects non-string names", map[string]interface{}{"seed": float64(1)}, []string{`cs_inputs "seed" on node 3 must be a string`}),
+		Entry("rejects inputs the role does not substitute", map[string]interface{}{"denoise": "strength"},
+			[]string{`cs_inputs on node 3 renames "denoise", which cs_role "sampler" does not substitute`}),
+	)
+})
@@ -3,19 +3,48 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/sirupsen/logrus"
 )
 
-// WorkflowLoader loads and validates ComfyUI workflow templates.
+var (
+	// ErrWorkflowNotFound is returned for a workflow template that does not
+	// exist in the workflow directory.
+	ErrWorkflowNotFound = errors.New("workflow not found")
+	// ErrWorkflowExists is returned when saving or renaming a workflow
+	// template would replace an existing one.
+	ErrWorkflowExists = errors.New("workflow already exists")
+	// ErrWorkflowInUse is returned when renaming or deleting a workflow
+	// template that studies still reference.
+	ErrWorkflowInUse = errors.New("workflow is used by studies")
+	// ErrInvalidWorkflow is returned when saving a workflow that is not a
+	// ComfyUI API-format workflow with the required cs_roles.
+	ErrInvalidWorkflow = errors.New("invalid workflow")
+)
+
+// WorkflowStudySource lists the studies whose workflow templates must not be
+// renamed or deleted from under them.
+type WorkflowStudySource interface {
+	List(ctx context.Context) ([]model.Study, error)
+}
+
+// WorkflowLoader loads, validates, and manages ComfyUI workflow templates.
 type WorkflowLoader struct {
+	mu          sync.RWMutex
 	workflowDir string
-	logger      *logrus.Entry
+	// writeMu serializes Save, Rename, and Delete so that their existence
+	// checks are not raced by another write.
+	writeMu sync.Mutex
+	studies WorkflowStudySource
+	logger  *logrus.Entry
 }
 
 // NewWorkflowLoader creates a new workflow loader service.
@@ -26,19 +55,40 @@ func NewWorkflowLoader(workflowDir string, logger *logrus.Logger) *WorkflowLoade
 	}
 }
 
+// SetWorkflowDir changes the directory templates are loaded from. It is
+// called when the configuration is reloaded.
+func (l *WorkflowLoader) SetWorkflowDir(dir string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.workflowDir = dir
+}
+
+// SetStudySource sets the studies checked before a workflow template is
+// renamed or deleted. Without one, workflows are never considered in use.
+func (l *WorkflowLoader) SetStudySource(studies WorkflowStudySource) {
+	l.studies = studies
+}
+
+// dir returns the current workflow directory.
+func (l *WorkflowLoader) dir() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.workflowDir
+}
+
 // List returns all workflow templates found in the workflow directory.
 func (l *WorkflowLoader) List(ctx context.Context) ([]model.WorkflowTemplate, error) {
 	l.logger.Trace("entering List")
 	defer l.logger.Trace("returning from List")
 
-	entries, err := os.ReadDir(l.workflowDir)
+	entries, err := os.ReadDir(l.dir())
 	if err != nil {
 		if os.IsNotExist(err) {
-			l.logger.WithField("workflow_dir", l.workflowDir).Debug("workflow directory does not exist, returning empty list")
+			l.logger.WithField("workflow_dir", l.dir()).Debug("workflow directory does not exist, returning empty list")
 			return []model.WorkflowTemplate{}, nil
 		}
 		l.logger.WithFields(logrus.Fields{
-			"workflow_dir": l.workflowDir,
+			"workflow_dir": l.dir(),
 			"error":        err.Error(),
 		}).Error("failed to read workflow directory")
 		return nil, fmt.Errorf("reading workflow directory: %w", err)
@@ -53,7 +103,7 @@ func (l *WorkflowLoader) List(ctx context.Context) ([]model.WorkflowTemplate, er
 			continue
 		}
 
-		path := filepath.Join(l.workflowDir, entry.Name())
+		path := filepath.Join(l.dir(), entry.Name())
 		workflow, err := l.loadWorkflow(ctx, path)
 		if err != nil {
 			l.logger.WithFields(logrus.Fields{
@@ -74,30 +124,20 @@ func (l *WorkflowLoader) Get(ctx context.Context, name string) (model.WorkflowTe
 	l.logger.WithField("name", name).Trace("entering Get")
 	defer l.logger.Trace("returning from Get")
 
-	// B-104: Reject empty workflow names early with a descriptive error rather
-	// than appending ".json" and failing with "workflow not found: .json".
-	if name == "" {
-		l.logger.Warn("empty workflow name")
-		return model.WorkflowTemplate{}, fmt.Errorf("workflow name must not be empty")
-	}
-
-	// Sanitize the name to prevent path traversal
-	if strings.Contains(name, "..") || strings.Contains(name, "/") || strings.Contains(name, "\\") {
-		l.logger.WithField("name", name).Warn("invalid workflow name")
-		return model.WorkflowTemplate{}, fmt.Errorf("invalid workflow name: %s", name)
-	}
-
-	if !strings.HasSuffix(name, ".json") {
-		name = name + ".json"
+	fileName, err := workflowFileName(name)
+	if err != nil {
+		l.logger.WithField("name", name).Warn(err.Error())
+		return model.WorkflowTemplate{}, err
 	}
+	name = fileName
 
-	path := filepath.Join(l.workflowDir, name)
+	path := filepath.Join(l.dir(), name)
 	workflow, err := l.loadWorkflow(ctx, path)
 	if err != nil {
 		// Check if the underlying error is a not found error
 		if os.IsNotExist(err) || strings.Contains(err.Error(), "no such file") {
 			l.logger.WithField("name", name).Debug("workflow not found")
-			return model.WorkflowTemplate{}, fmt.Errorf("workflow not found: %s", name)
+			return model.WorkflowTemplate{}, fmt.Errorf("%w: %s", ErrWorkflowNotFound, name)
 		}
 		l.logger.WithFields(logrus.Fields{
 			"name":  name,
@@ -109,6 +149,262 @@ func (l *WorkflowLoader) Get(ctx context.Context, name string) (model.WorkflowTe
 	return workflow, nil
 }
 
+// Save validates a ComfyUI API-format workflow and writes it to the workflow
+// directory as name (".json" is appended if missing). A workflow without the
+// required cs_roles is rejected with ErrInvalidWorkflow. An existing workflow
+// of the same name is replaced only if overwrite is set; otherwise Save
+// returns ErrWorkflowExists.
+func (l *WorkflowLoader) Save(ctx context.Context, name string, workflow map[string]interface{}, overwrite bool) (model.WorkflowTemplate, error) {
+	l.logger.WithFields(logrus.Fields{
+		"name":      name,
+		"overwrite": overwrite,
+	}).Trace("entering Save")
+	defer l.logger.Trace("returning from Save")
+
+	name, err := workflowFileName(name)
+	if err != nil {
+		return model.WorkflowTemplate{}, err
+	}
+	if err := validateAPIFormat(workflow); err != nil {
+		return model.WorkflowTemplate{}, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+
+	path := filepath.Join(l.dir(), name)
+	template := model.WorkflowTemplate{
+		Name:     name,
+		Path:     path,
+		Workflow: workflow,
+		Roles:    make(map[string][]string),
+		Warnings: []string{},
+	}
+	l.extractRoles(&template)
+	l.validate(&template)
+	if template.ValidationState != model.ValidationStateValid {
+		return model.WorkflowTemplate{}, fmt.Errorf("%w: no node has the required cs_role %q", ErrInvalidWorkflow, model.CSRoleSaveImage)
+	}
+
+	data, err := json.MarshalIndent(workflow, "", "  ")
+	if err != nil {
+		return model.WorkflowTemplate{}, fmt.Errorf("encoding workflow JSON: %w", err)
+	}
+
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+
+	if !overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return model.WorkflowTemplate{}, fmt.Errorf("%w: %s", ErrWorkflowExists, name)
+		} else if !os.IsNotExist(err) {
+			return model.WorkflowTemplate{}, fmt.Errorf("checking workflow file: %w", err)
+		}
+	}
+
+	// Write to a temporary file and rename it into place so that List and
+	// Get never read a partially written workflow.
+	tmp, err := os.CreateTemp(l.dir(), ".upload-*.tmp")
+	if err != nil {
+		l.logger.WithFields(logrus.Fields{
+			"workflow_dir": l.dir(),
+			"error":        err.Error(),
+		}).Error("failed to create workflow file")
+		return model.WorkflowTemplate{}, fmt.Errorf("creating workflow file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return model.WorkflowTemplate{}, fmt.Errorf("writing workflow file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return model.WorkflowTemplate{}, fmt.Errorf("writing workflow file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return model.WorkflowTemplate{}, fmt.Errorf("writing workflow file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		l.logger.WithFields(logrus.Fields{
+			"name":  name,
+			"error": err.Error(),
+		}).Error("failed to save workflow file")
+		return model.WorkflowTemplate{}, fmt.Errorf("saving workflow file: %w", err)
+	}
+
+	l.logger.WithFields(logrus.Fields{
+		"name":       name,
+		"role_count": len(template.Roles),
+	}).Info("workflow saved")
+	return template, nil
+}
+
+// Rename renames a workflow template. It returns ErrWorkflowNotFound if the
+// workflow does not exist, ErrWorkflowExists if newName is taken, and
+// ErrWorkflowInUse if a study references the workflow.
+func (l *WorkflowLoader) Rename(ctx context.Context, name string, newName string) (model.WorkflowTemplate, error) {
+	l.logger.WithFields(logrus.Fields{
+		"name":     name,
+		"new_name": newName,
+	}).Trace("entering Rename")
+	defer l.logger.Trace("returning from Rename")
+
+	name, err := workflowFileName(name)
+	if err != nil {
+		return model.WorkflowTemplate{}, err
+	}
+	newName, err = workflowFileName(newName)
+	if err != nil {
+		return model.WorkflowTemplate{}, err
+	}
+
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+
+	from := filepath.Join(l.dir(), name)
+	to := filepath.Join(l.dir(), newName)
+	if _, err := os.Stat(from); err != nil {
+		if os.IsNotExist(err) {
+			return model.WorkflowTemplate{}, fmt.Errorf("%w: %s", ErrWorkflowNotFound, name)
+		}
+		return model.WorkflowTemplate{}, fmt.Errorf("checking workflow file: %w", err)
+	}
+	if name == newName {
+		return l.loadWorkflow(ctx, from)
+	}
+	if _, err := os.Stat(to); err == nil {
+		return model.WorkflowTemplate{}, fmt.Errorf("%w: %s", ErrWorkflowExists, newName)
+	} else if !os.IsNotExist(err) {
+		return model.WorkflowTemplate{}, fmt.Errorf("checking workflow file: %w", err)
+	}
+	if err := l.checkNotInUse(ctx, name); err != nil {
+		return model.WorkflowTemplate{}, err
+	}
+
+	if err := os.Rename(from, to); err != nil {
+		l.logger.WithFields(logrus.Fields{
+			"name":     name,
+			"new_name": newName,
+			"error":    err.Error(),
+		}).Error("failed to rename workflow file")
+		return model.WorkflowTemplate{}, fmt.Errorf("renaming workflow file: %w", err)
+	}
+
+	l.logger.WithFields(logrus.Fields{
+		"name":     name,
+		"new_name": newName,
+	}).Info("workflow renamed")
+	return l.loadWorkflow(ctx, to)
+}
+
+// Delete removes a workflow template. It returns ErrWorkflowNotFound if the
+// workflow does not exist and ErrWorkflowInUse if a study references it.
+// Sample jobs keep the snapshot of the workflow they were created with.
+func (l *WorkflowLoader) Delete(ctx context.Context, name string) error {
+	l.logger.WithField("name", name).Trace("entering Delete")
+	defer l.logger.Trace("returning from Delete")
+
+	name, err := workflowFileName(name)
+	if err != nil {
+		return err
+	}
+
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+
+	path := filepath.Join(l.dir(), name)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrWorkflowNotFound, name)
+		}
+		return fmt.Errorf("checking workflow file: %w", err)
+	}
+	if err := l.checkNotInUse(ctx, name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		l.logger.WithFields(logrus.Fields{
+			"name":  name,
+			"error": err.Error(),
+		}).Error("failed to delete workflow file")
+		return fmt.Errorf("deleting workflow file: %w", err)
+	}
+
+	l.logger.WithField("name", name).Info("workflow deleted")
+	return nil
+}
+
+// checkNotInUse returns ErrWorkflowInUse, naming the studies, if any study
+// uses the workflow file name.
+func (l *WorkflowLoader) checkNotInUse(ctx context.Context, name string) error {
+	if l.studies == nil {
+		return nil
+	}
+	studies, err := l.studies.List(ctx)
+	if err != nil {
+		return fmt.Errorf("listing studies: %w", err)
+	}
+	var users []string
+	for _, st := range studies {
+		if st.WorkflowTemplate == "" {
+			continue
+		}
+		if used, err := workflowFileName(st.WorkflowTemplate); err == nil && used == name {
+			users = append(users, st.Name)
+		}
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	sort.Strings(users)
+	l.logger.WithFields(logrus.Fields{
+		"name":    name,
+		"studies": users,
+	}).Debug("workflow is in use")
+	return fmt.Errorf("%w: %s is used by %s", ErrWorkflowInUse, name, strings.Join(users, ", "))
+}
+
+// workflowFileName returns the file name of the workflow template called
+// name, appending ".json" if missing. It rejects empty names and names that
+// could escape the workflow directory.
+func workflowFileName(name string) (string, error) {
+	// B-104: Reject empty workflow names early with a descriptive error rather
+	// than appending ".json" and failing with "workflow not found: .json".
+	if name == "" {
+		return "", fmt.Errorf("workflow name must not be empty")
+	}
+	// Sanitize the name to prevent path traversal
+	// and hidden files such as Save's temporary files.
+	if strings.Contains(name, "..") || strings.Contains(name, "/") || strings.Contains(name, "\\") || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid workflow name: %s", name)
+	}
+	if !strings.HasSuffix(name, ".json") {
+		name = name + ".json"
+	}
+	return name, nil
+}
+
+// validateAPIFormat checks that workflow is a ComfyUI API-format workflow: a
+// non-empty map of node IDs to nodes that each name their class_type. Graphs
+// saved from the ComfyUI editor (with "nodes" and "links" arrays) fail this.
+func validateAPIFormat(workflow map[string]interface{}) error {
+	if len(workflow) == 0 {
+		return fmt.Errorf("workflow has no nodes")
+	}
+	ids := make([]string, 0, len(workflow))
+	for id := range workflow {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		node, ok := workflow[id].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("node %s is not an object; export the workflow in API format", id)
+		}
+		if classType, ok := node["class_type"].(string); !ok || classType == "" {
+			return fmt.Errorf("node %s has no class_type; export the workflow in API format", id)
+		}
+	}
+	return nil
+}
+
 // loadWorkflow loads and validates a workflow from a file path.
 func (l *WorkflowLoader) loadWorkflow(ctx context.Context, path string) (model.WorkflowTemplate, error) {
 	data, err := os.ReadFile(path)
@@ -158,6 +454,21 @@ func (l *WorkflowLoader) extractRoles(template *model.WorkflowTemplate) {
 		// Record the role and node ID
 		template.Roles[role] = append(template.Roles[role], nodeID)
 
+		if def, ok := meta["cs_default"]; ok {
+			l.extractDefault(template, nodeID, role, def)
+		}
+
+		if model.IsKnownRole(role) {
+			for _, warning := range validateInputAliases(nodeID, role, meta) {
+				template.Warnings = append(template.Warnings, warning)
+				l.logger.WithFields(logrus.Fields{
+					"workflow": template.Name,
+					"node_id":  nodeID,
+					"cs_role":  role,
+				}).Warn(warning)
+			}
+		}
+
 		// Check if it's a known role
 		if !model.IsKnownRole(role) {
 			warning := fmt.Sprintf("unknown cs_role %q on node %s", role, nodeID)
@@ -176,6 +487,52 @@ func (l *WorkflowLoader) extractRoles(template *model.WorkflowTemplate) {
 	}).Debug("roles extracted from workflow")
 }
 
+// extractDefault records the cs_default value of a node as a job-creation
+// default for its role. Unsupported roles, values of the wrong type, and
+// conflicting values are reported as warnings and ignored.
+func (l *WorkflowLoader) extractDefault(template *model.WorkflowTemplate, nodeID string, role string, value interface{}) {
+	var warning string
+	switch model.CSRole(role) {
+	case model.CSRoleVAELoader, model.CSRoleCLIPLoader:
+		v, ok := value.(string)
+		if !ok || v == "" {
+			warning = fmt.Sprintf("cs_default on node %s must be a non-empty string", nodeID)
+			break
+		}
+		field := &template.Defaults.VAE
+		if model.CSRole(role) == model.CSRoleCLIPLoader {
+			field = &template.Defaults.CLIP
+		}
+		if *field != "" && *field != v {
+			warning = fmt.Sprintf("conflicting cs_default for role %q on node %s", role, nodeID)
+			break
+		}
+		*field = v
+	case model.CSRoleShift:
+		v, ok := value.(float64)
+		if !ok {
+			warning = fmt.Sprintf("cs_default on node %s must be a number", nodeID)
+			break
+		}
+		if template.Defaults.Shift != nil && *template.Defaults.Shift != v {
+			warning = fmt.Sprintf("conflicting cs_default for role %q on node %s", role, nodeID)
+			break
+		}
+		template.Defaults.Shift = &v
+	default:
+		warning = fmt.Sprintf("cs_default is not supported for role %q on node %s", role, nodeID)
+	}
+
+	if warning != "" {
+		template.Warnings = append(template.Warnings, warning)
+		l.logger.WithFields(logrus.Fields{
+			"workflow": template.Name,
+			"node_id":  nodeID,
+			"cs_role":  role,
+		}).Warn(warning)
+	}
+}
+
 // validate checks workflow requirements.
 func (l *WorkflowLoader) validate(template *model.WorkflowTemplate) {
 	// Required: save_image role must be present
@@ -191,17 +548,17 @@ func (l *WorkflowLoader) validate(template *model.WorkflowTemplate) {
 
 // EnsureWorkflowDir creates the workflow directory if it does not exist.
 func (l *WorkflowLoader) EnsureWorkflowDir() error {
-	l.logger.WithField("workflow_dir", l.workflowDir).Trace("entering EnsureWorkflowDir")
+	l.logger.WithField("workflow_dir", l.dir()).Trace("entering EnsureWorkflowDir")
 	defer l.logger.Trace("returning from EnsureWorkflowDir")
 
-	if err := os.MkdirAll(l.workflowDir, 0755); err != nil {
+	if err := os.MkdirAll(l.dir(), 0755); err != nil {
 		l.logger.WithFields(logrus.Fields{
-			"workflow_dir": l.workflowDir,
+			"workflow_dir": l.dir(),
 			"error":        err.Error(),
 		}).Error("failed to create workflow directory")
 		return fmt.Errorf("creating workflow directory: %w", err)
 	}
 
-	l.logger.WithField("workflow_dir", l.workflowDir).Info("workflow directory ensured")
+	l.logger.WithField("workflow_dir", l.dir()).Info("workflow directory ensured")
 	return nil
 }
@@ -302,6 +302,121 @@ var _ = Describe("WorkflowLoader", func() {
 		})
 	})
 
+	Describe("Save", func() {
+		saveImageNode := map[string]interface{}{
+			"class_type": "SaveImage",
+			"_meta":      map[string]interface{}{"cs_role": "save_image"},
+		}
+
+		It("writes a valid workflow and returns its template", func() {
+			wf, err := loader.Save(ctx, "uploaded", map[string]interface{}{"9": saveImageNode}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wf.Name).To(Equal("uploaded.json"))
+			Expect(wf.ValidationState).To(Equal(model.ValidationStateValid))
+			Expect(wf.Roles).To(HaveKeyWithValue("save_image", []string{"9"}))
+
+			loaded, err := loader.Get(ctx, "uploaded.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.Workflow).To(Equal(wf.Workflow))
+
+			entries, err := os.ReadDir(workflowDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1), "no temporary files are left behind")
+		})
+
+		It("rejects a workflow without the save_image role", func() {
+			_, err := loader.Save(ctx, "no-save.json", map[string]interface{}{
+				"3": map[string]interface{}{"class_type": "KSampler", "_meta": map[string]interface{}{"cs_role": "sampler"}},
+			}, false)
+			Expect(err).To(MatchError(service.ErrInvalidWorkflow))
+			Expect(err.Error()).To(ContainSubstring("save_image"))
+			Expect(filepath.Join(workflowDir, "no-save.json")).NotTo(BeAnExistingFile())
+		})
+
+		DescribeTable("rejects workflows not in API format",
+			func(workflow map[string]interface{}, expected string) {
+				_, err := loader.Save(ctx, "bad.json", workflow, false)
+				Expect(err).To(MatchError(service.ErrInvalidWorkflow))
+				Expect(err.Error()).To(ContainSubstring(expected))
+			},
+			Entry("empty", map[string]interface{}{}, "no nodes"),
+			Entry("editor format", map[string]interface{}{"nodes": []interface{}{}, "links": []interface{}{}}, "is not an object"),
+			Entry("node without class_type", map[string]interface{}{"1": map[string]interface{}{"inputs": map[string]interface{}{}}}, "no class_type"),
+		)
+
+		It("refuses to replace an existing workflow unless overwrite is set", func() {
+			_, err := loader.Save(ctx, "wf.json", map[string]interface{}{"9": saveImageNode}, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = loader.Save(ctx, "wf.json", map[string]interface{}{"10": saveImageNode}, false)
+			Expect(err).To(MatchError(service.ErrWorkflowExists))
+
+			wf, err := loader.Save(ctx, "wf.json", map[string]interface{}{"10": saveImageNode}, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wf.Roles).To(HaveKeyWithValue("save_image", []string{"10"}))
+		})
+
+		It("rejects invalid names", func() {
+			for _, name := range []string{"", "../escape.json", "sub/wf.json", ".hidden.json"} {
+				_, err := loader.Save(ctx, name, map[string]interface{}{"9": saveImageNode}, false)
+				Expect(err).To(HaveOccurred(), name)
+			}
+		})
+	})
+
+	Describe("Rename and Delete", func() {
+		var studies *fakeWorkflowStudySource
+
+		BeforeEach(func() {
+			_, err := loader.Save(ctx, "wf.json", map[string]interface{}{
+				"9": map[string]interface{}{"class_type": "SaveImage", "_meta": map[string]interface{}{"cs_role": "save_image"}},
+			}, false)
+			Expect(err).NotTo(HaveOccurred())
+			studies = &fakeWorkflowStudySource{}
+			loader.SetStudySource(studies)
+		})
+
+		It("renames a workflow", func() {
+			wf, err := loader.Rename(ctx, "wf.json", "renamed")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wf.Name).To(Equal("renamed.json"))
+			Expect(filepath.Join(workflowDir, "renamed.json")).To(BeAnExistingFile())
+			Expect(filepath.Join(workflowDir, "wf.json")).NotTo(BeAnExistingFile())
+		})
+
+		It("refuses to rename onto an existing workflow", func() {
+			Expect(os.WriteFile(filepath.Join(workflowDir, "taken.json"), []byte("{}"), 0644)).To(Succeed())
+			_, err := loader.Rename(ctx, "wf.json", "taken.json")
+			Expect(err).To(MatchError(service.ErrWorkflowExists))
+		})
+
+		It("deletes a workflow", func() {
+			Expect(loader.Delete(ctx, "wf")).To(Succeed())
+			Expect(filepath.Join(workflowDir, "wf.json")).NotTo(BeAnExistingFile())
+		})
+
+		It("returns ErrWorkflowNotFound for a missing workflow", func() {
+			_, err := loader.Rename(ctx, "missing.json", "other.json")
+			Expect(err).To(MatchError(service.ErrWorkflowNotFound))
+			Expect(loader.Delete(ctx, "missing.json")).To(MatchError(service.ErrWorkflowNotFound))
+		})
+
+		It("refuses to rename or delete a workflow used by studies", func() {
+			studies.studies = []model.Study{
+				{Name: "Sweep B", WorkflowTemplate: "wf"},
+				{Name: "Sweep A", WorkflowTemplate: "wf.json"},
+				{Name: "Other", WorkflowTemplate: "other.json"},
+			}
+
+			_, err := loader.Rename(ctx, "wf.json", "renamed.json")
+			Expect(err).To(MatchError(service.ErrWorkflowInUse))
+			Expect(err.Error()).To(ContainSubstring("Sweep A, Sweep B"))
+			err = loader.Delete(ctx, "wf.json")
+			Expect(err).To(MatchError(service.ErrWorkflowInUse))
+			Expect(filepath.Join(workflowDir, "wf.json")).To(BeAnExistingFile())
+		})
+	})
+
 	Describe("Role extraction", func() {
 		DescribeTable("extracts all known roles",
 			func(role string) {
@@ -415,6 +530,128 @@ var _ = Describe("WorkflowLoader", func() {
 		})
 	})
 
+	Describe("Default extraction", func() {
+		writeWorkflow := func(filename string, nodes map[string]interface{}) {
+			nodes["1"] = map[string]interface{}{
+				"_meta": map[string]interface{}{"cs_role": "save_image"},
+			}
+			data, _ := json.Marshal(nodes)
+			Expect(os.WriteFile(filepath.Join(workflowDir, filename), data, 0644)).To(Succeed())
+		}
+
+		It("reads cs_default values of the vae_loader, clip_loader, and shift nodes", func() {
+			writeWorkflow("flux.json", map[string]interface{}{
+				"2": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "vae_loader", "cs_default": "ae.safetensors"},
+				},
+				"3": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "clip_loader", "cs_default": "clip_l.safetensors"},
+				},
+				"4": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "shift", "cs_default": 3.1},
+				},
+			})
+
+			wf, err := loader.Get(ctx, "flux.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wf.Defaults.VAE).To(Equal("ae.safetensors"))
+			Expect(wf.Defaults.CLIP).To(Equal("clip_l.safetensors"))
+			Expect(wf.Defaults.Shift).To(HaveValue(Equal(3.1)))
+			Expect(wf.Warnings).To(BeEmpty())
+		})
+
+		It("leaves defaults empty when no node declares cs_default", func() {
+			writeWorkflow("plain.json", map[string]interface{}{
+				"2": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "vae_loader"},
+				},
+			})
+
+			wf, err := loader.Get(ctx, "plain.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wf.Defaults).To(Equal(model.WorkflowDefaults{}))
+		})
+
+		It("accepts the same default declared on several nodes", func() {
+			writeWorkflow("two-vae.json", map[string]interface{}{
+				"2": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "vae_loader", "cs_default": "ae.safetensors"},
+				},
+				"3": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "vae_loader", "cs_default": "ae.safetensors"},
+				},
+			})
+
+			wf, err := loader.Get(ctx, "two-vae.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wf.Defaults.VAE).To(Equal("ae.safetensors"))
+			Expect(wf.Warnings).To(BeEmpty())
+		})
+
+		DescribeTable("warns about and ignores invalid cs_default values",
+			func(nodes map[string]interface{}, warning string) {
+				writeWorkflow("invalid-default.json", nodes)
+
+				wf, err := loader.Get(ctx, "invalid-default.json")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(wf.ValidationState).To(Equal(model.ValidationStateValid))
+				Expect(wf.Warnings).To(ContainElement(ContainSubstring(warning)))
+			},
+			Entry("a non-string VAE", map[string]interface{}{
+				"2": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "vae_loader", "cs_default": 1},
+				},
+			}, "must be a non-empty string"),
+			Entry("an empty CLIP", map[string]interface{}{
+				"2": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "clip_loader", "cs_default": ""},
+				},
+			}, "must be a non-empty string"),
+			Entry("a non-numeric shift", map[string]interface{}{
+				"2": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "shift", "cs_default": "3.1"},
+				},
+			}, "must be a number"),
+			Entry("conflicting values", map[string]interface{}{
+				"2": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "vae_loader", "cs_default": "ae.safetensors"},
+				},
+				"3": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "vae_loader", "cs_default": "other.safetensors"},
+				},
+			}, "conflicting cs_default"),
+			Entry("an unsupported role", map[string]interface{}{
+				"2": map[string]interface{}{
+					"_meta": map[string]interface{}{"cs_role": "sampler", "cs_default": "euler"},
+				},
+			}, "cs_default is not supported for role \"sampler\""),
+		)
+	})
+
+	It("warns about invalid cs_inputs renames but keeps the workflow valid", func() {
+		workflow := map[string]interface{}{
+			"3": map[string]interface{}{
+				"class_type": "CustomSampler",
+				"_meta": map[string]interface{}{
+					"cs_role":   "sampler",
+					"cs_inputs": map[string]interface{}{"seed": "noise_seed", "text": "prompt"},
+				},
+			},
+			"9": map[string]interface{}{
+				"class_type": "SaveImage",
+				"_meta":      map[string]interface{}{"cs_role": "save_image"},
+			},
+		}
+		data, err := json.Marshal(workflow)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(workflowDir, "custom.json"), data, 0644)).To(Succeed())
+
+		wf, err := loader.Get(ctx, "custom.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wf.ValidationState).To(Equal(model.ValidationStateValid))
+		Expect(wf.Warnings).To(ConsistOf(`cs_inputs on node 3 renames "text", which cs_role "sampler" does not substitute`))
+	})
+
 	Describe("Validation", func() {
 		It("validates workflow with only save_image role", func() {
 			workflow := map[string]interface{}{
@@ -466,3 +703,12 @@ var _ = Describe("WorkflowLoader", func() {
 		})
 	})
 })
+
+// fakeWorkflowStudySource returns a fixed list of studies.
+type fakeWorkflowStudySource struct {
+	studies []model.Study
+}
+
+func (f *fakeWorkflowStudySource) List(ctx context.Context) ([]model.Study, error) {
+	return f.studies, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// PreviewWorkflow substitutes the values of item of job into the workflow
+// template as the job executor does before submitting a sample, and reports
+// the inputs that changed. Nothing is uploaded or submitted: image inputs
+// are set to the server paths of the job's images, where the executor sets
+// the names of their uploaded copies. An empty VAE, text encoder, or shift
+// falls back to the workflow's cs_default, as when a sample job is created.
+func PreviewWorkflow(tmpl model.WorkflowTemplate, job model.SampleJob, item model.SampleJobItem) (model.WorkflowPreview, error) {
+	if job.VAE == "" {
+		job.VAE = tmpl.Defaults.VAE
+	}
+	if job.CLIP == "" {
+		job.CLIP = tmpl.Defaults.CLIP
+	}
+	if job.Shift == nil {
+		job.Shift = tmpl.Defaults.Shift
+	}
+
+	workflow, err := deepCloneWorkflow(tmpl.Workflow)
+	if err != nil {
+		return model.WorkflowPreview{}, err
+	}
+	images := uploadedImages{Input: job.InputImage, ControlNet: job.ControlNetImage}
+	if err := substituteRoles(workflow, tmpl.Roles, job, item, images); err != nil {
+		return model.WorkflowPreview{}, err
+	}
+
+	return model.WorkflowPreview{
+		Name:          tmpl.Name,
+		Workflow:      workflow,
+		Substitutions: diffWorkflowInputs(tmpl.Workflow, workflow),
+	}, nil
+}
+
+// diffWorkflowInputs returns the inputs of the nodes of after that differ
+// from before. Substitution only writes inputs, so other node fields are not
+// compared.
+func diffWorkflowInputs(before, after map[string]interface{}) []model.WorkflowSubstitution {
+	ids := make([]string, 0, len(after))
+	for id := range after {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessNodeID(ids[i], ids[j]) })
+
+	changes := []model.WorkflowSubstitution{}
+	for _, id := range ids {
+		node, _ := after[id].(map[string]interface{})
+		inputs, _ := node["inputs"].(map[string]interface{})
+		oldNode, _ := before[id].(map[string]interface{})
+		oldInputs, _ := oldNode["inputs"].(map[string]interface{})
+		var role string
+		if meta, ok := node["_meta"].(map[string]interface{}); ok {
+			role, _ = meta["cs_role"].(string)
+		}
+
+		names := make([]string, 0, len(inputs))
+		for name := range inputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			previous, ok := oldInputs[name]
+			// Substituted numbers are Go ints and the template's are
+			// float64s decoded from JSON; compare them as JSON would.
+			if ok && reflect.DeepEqual(jsonValue(previous), jsonValue(inputs[name])) {
+				continue
+			}
+			changes = append(changes, model.WorkflowSubstitution{
+				NodeID:   id,
+				Role:     role,
+				Input:    name,
+				Previous: previous,
+				Value:    inputs[name],
+			})
+		}
+	}
+	return changes
+}
+
+// jsonValue returns v with integer types converted to float64, the type
+// encoding/json decodes numbers to.
+func jsonValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return v
+}
@@ -0,0 +1,100 @@
+package service_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/service"
+)
+
+var _ = Describe("PreviewWorkflow", func() {
+	var tmpl model.WorkflowTemplate
+
+	BeforeEach(func() {
+		tmpl = model.WorkflowTemplate{
+			Name: "flux.json",
+			Workflow: map[string]interface{}{
+				"3": map[string]interface{}{
+					"class_type": "KSampler",
+					"_meta":      map[string]interface{}{"cs_role": "sampler"},
+					"inputs": map[string]interface{}{
+						"seed": float64(0), "steps": float64(20), "cfg": float64(7), "sampler_name": "euler", "scheduler": "normal",
+						"model": []interface{}{"4", float64(0)},
+					},
+				},
+				"4": map[string]interface{}{
+					"class_type": "UNETLoader",
+					"_meta":      map[string]interface{}{"cs_role": "unet_loader"},
+					"inputs":     map[string]interface{}{"unet_name": "placeholder.safetensors"},
+				},
+				"8": map[string]interface{}{
+					"class_type": "VAELoader",
+					"_meta":      map[string]interface{}{"cs_role": "vae_loader"},
+					"inputs":     map[string]interface{}{"vae_name": "placeholder.safetensors"},
+				},
+				"9": map[string]interface{}{
+					"class_type": "SaveImage",
+					"_meta":      map[string]interface{}{"cs_role": "save_image"},
+					"inputs":     map[string]interface{}{"filename_prefix": "ComfyUI"},
+				},
+			},
+			Roles: map[string][]string{
+				"sampler": {"3"}, "unet_loader": {"4"}, "vae_loader": {"8"}, "save_image": {"9"},
+			},
+			Defaults: model.WorkflowDefaults{VAE: "ae.safetensors"},
+		}
+	})
+
+	It("substitutes the sample and lists the inputs that changed", func() {
+		item := model.SampleJobItem{
+			CheckpointFilename: "lora-step00001000.safetensors",
+			ComfyUIModelPath:   "flux/lora-step00001000.safetensors",
+			Seed:               42, Steps: 20, CFG: 3.5, SamplerName: "euler", Scheduler: "normal",
+		}
+
+		preview, err := service.PreviewWorkflow(tmpl, model.SampleJob{}, item)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.Name).To(Equal("flux.json"))
+		Expect(preview.Substitutions).To(Equal([]model.WorkflowSubstitution{
+			{NodeID: "3", Role: "sampler", Input: "cfg", Previous: float64(7), Value: 3.5},
+			{NodeID: "3", Role: "sampler", Input: "seed", Previous: float64(0), Value: int64(42)},
+			{NodeID: "4", Role: "unet_loader", Input: "unet_name", Previous: "placeholder.safetensors", Value: "flux/lora-step00001000.safetensors"},
+			{NodeID: "8", Role: "vae_loader", Input: "vae_name", Previous: "placeholder.safetensors", Value: "ae.safetensors"},
+			{NodeID: "9", Role: "save_image", Input: "filename_prefix", Previous: "ComfyUI", Value: "sample_lora-step00001000"},
+		}))
+		Expect(preview.Workflow["3"].(map[string]interface{})["inputs"]).To(HaveKeyWithValue("model", []interface{}{"4", float64(0)}))
+	})
+
+	It("leaves the template unchanged", func() {
+		_, err := service.PreviewWorkflow(tmpl, model.SampleJob{VAE: "other.safetensors"}, model.SampleJobItem{Seed: 7})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tmpl.Workflow["8"].(map[string]interface{})["inputs"]).To(HaveKeyWithValue("vae_name", "placeholder.safetensors"))
+	})
+
+	It("prefers the job's VAE over the workflow default", func() {
+		preview, err := service.PreviewWorkflow(tmpl, model.SampleJob{VAE: "other.safetensors"}, model.SampleJobItem{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.Workflow["8"].(map[string]interface{})["inputs"]).To(HaveKeyWithValue("vae_name", "other.safetensors"))
+	})
+
+	It("shows the server path of the input image", func() {
+		tmpl.Workflow["10"] = map[string]interface{}{
+			"class_type": "LoadImage",
+			"_meta":      map[string]interface{}{"cs_role": "load_image"},
+			"inputs":     map[string]interface{}{"image": "example.png"},
+		}
+		tmpl.Roles["load_image"] = []string{"10"}
+
+		preview, err := service.PreviewWorkflow(tmpl, model.SampleJob{InputImage: "/data/inputs/cat.png"}, model.SampleJobItem{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.Workflow["10"].(map[string]interface{})["inputs"]).To(HaveKeyWithValue("image", "/data/inputs/cat.png"))
+	})
+
+	It("returns the substitution error of a malformed node", func() {
+		tmpl.Workflow["4"] = map[string]interface{}{"class_type": "UNETLoader"}
+
+		_, err := service.PreviewWorkflow(tmpl, model.SampleJob{}, model.SampleJobItem{})
+		Expect(err).To(MatchError(ContainSubstring("node 4 has no inputs")))
+	})
+})
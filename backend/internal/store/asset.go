@@ -0,0 +1,356 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// assetEntity is the persistence representation of an asset.
+type assetEntity struct {
+	ID          string
+	Kind        string
+	Filename    string
+	ContentType string
+	Size        int64
+	SHA256      string
+	Path        string
+	CreatedAt   string // RFC3339
+}
+
+// assetUploadEntity is the persistence representation of an asset upload.
+type assetUploadEntity struct {
+	ID          string
+	Kind        string
+	Filename    string
+	ContentType string
+	Size        int64
+	CreatedAt   string // RFC3339
+	UpdatedAt   string // RFC3339
+}
+
+const assetSelectColumns = `id, kind, filename, content_type, size, sha256, path, created_at`
+
+const assetUploadSelectColumns = `id, kind, filename, content_type, size, created_at, updated_at`
+
+// ListAssets returns the assets of the given kind, newest first. An empty
+// kind returns assets of every kind.
+func (s *Store) ListAssets(ctx context.Context, kind model.AssetKind) ([]model.Asset, error) {
+	s.logger.WithField("kind", kind).Trace("entering ListAssets")
+	defer s.logger.Trace("returning from ListAssets")
+
+	query := "SELECT " + assetSelectColumns + " FROM assets"
+	var args []interface{}
+	if kind != "" {
+		query += " WHERE kind = ?"
+		args = append(args, string(kind))
+	}
+	query += " ORDER BY created_at DESC, id"
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query assets")
+		return nil, fmt.Errorf("querying assets: %w", err)
+	}
+	defer rows.Close()
+
+	var assets []model.Asset
+	for rows.Next() {
+		var e assetEntity
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Filename, &e.ContentType, &e.Size, &e.SHA256, &e.Path, &e.CreatedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan asset row")
+			return nil, fmt.Errorf("scanning asset row: %w", err)
+		}
+		a, err := assetEntityToModel(e)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to convert entity to model")
+			return nil, err
+		}
+		assets = append(assets, a)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating assets")
+		return nil, fmt.Errorf("iterating assets: %w", err)
+	}
+	s.logger.WithField("asset_count", len(assets)).Debug("listed assets from database")
+	return assets, nil
+}
+
+// GetAsset returns a single asset by ID, or sql.ErrNoRows if not found.
+func (s *Store) GetAsset(ctx context.Context, id string) (model.Asset, error) {
+	s.logger.WithField("asset_id", id).Trace("entering GetAsset")
+	defer s.logger.Trace("returning from GetAsset")
+
+	return s.getAsset(ctx, "id = ?", id)
+}
+
+// GetAssetBySHA256 returns the asset of the given kind with the given content
+// hash, or sql.ErrNoRows if there is none.
+func (s *Store) GetAssetBySHA256(ctx context.Context, kind model.AssetKind, sha256 string) (model.Asset, error) {
+	s.logger.WithFields(logrus.Fields{
+		"kind":   kind,
+		"sha256": sha256,
+	}).Trace("entering GetAssetBySHA256")
+	defer s.logger.Trace("returning from GetAssetBySHA256")
+
+	return s.getAsset(ctx, "kind = ? AND sha256 = ?", string(kind), sha256)
+}
+
+func (s *Store) getAsset(ctx context.Context, where string, args ...interface{}) (model.Asset, error) {
+	var e assetEntity
+	err := s.db.QueryRowContext(ctx,
+		"SELECT "+assetSelectColumns+" FROM assets WHERE "+where, args...,
+	).Scan(&e.ID, &e.Kind, &e.Filename, &e.ContentType, &e.Size, &e.SHA256, &e.Path, &e.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.WithField("where", where).Debug("asset not found in database")
+		} else {
+			s.logger.WithError(err).Error("failed to query asset")
+		}
+		return model.Asset{}, err
+	}
+	s.logger.WithField("asset_id", e.ID).Debug("fetched asset from database")
+	return assetEntityToModel(e)
+}
+
+// CreateAsset inserts a new asset.
+func (s *Store) CreateAsset(ctx context.Context, a model.Asset) error {
+	s.logger.WithFields(logrus.Fields{
+		"asset_id": a.ID,
+		"kind":     a.Kind,
+	}).Trace("entering CreateAsset")
+	defer s.logger.Trace("returning from CreateAsset")
+
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO assets ("+assetSelectColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		a.ID,
+		string(a.Kind),
+		a.Filename,
+		a.ContentType,
+		a.Size,
+		a.SHA256,
+		a.Path,
+		a.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"asset_id": a.ID,
+			"error":    err.Error(),
+		}).Error("failed to insert asset into database")
+		return fmt.Errorf("inserting asset: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"asset_id": a.ID,
+		"kind":     a.Kind,
+		"size":     a.Size,
+	}).Info("inserted asset into database")
+	return nil
+}
+
+// DeleteAsset removes an asset by ID. Returns sql.ErrNoRows if not found.
+func (s *Store) DeleteAsset(ctx context.Context, id string) error {
+	s.logger.WithField("asset_id", id).Trace("entering DeleteAsset")
+	defer s.logger.Trace("returning from DeleteAsset")
+
+	return s.deleteByID(ctx, "assets", "asset", id)
+}
+
+// GetAssetUpload returns a single asset upload by ID, or sql.ErrNoRows if not
+// found. Received is not stored and is left zero.
+func (s *Store) GetAssetUpload(ctx context.Context, id string) (model.AssetUpload, error) {
+	s.logger.WithField("upload_id", id).Trace("entering GetAssetUpload")
+	defer s.logger.Trace("returning from GetAssetUpload")
+
+	var e assetUploadEntity
+	err := s.db.QueryRowContext(ctx,
+		"SELECT "+assetUploadSelectColumns+" FROM asset_uploads WHERE id = ?", id,
+	).Scan(&e.ID, &e.Kind, &e.Filename, &e.ContentType, &e.Size, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.WithField("upload_id", id).Debug("asset upload not found in database")
+		} else {
+			s.logger.WithFields(logrus.Fields{
+				"upload_id": id,
+				"error":     err.Error(),
+			}).Error("failed to query asset upload")
+		}
+		return model.AssetUpload{}, err
+	}
+	s.logger.WithField("upload_id", id).Debug("fetched asset upload from database")
+	return assetUploadEntityToModel(e)
+}
+
+// ListAssetUploadsUpdatedBefore returns the uploads that have not received
+// data since cutoff.
+func (s *Store) ListAssetUploadsUpdatedBefore(ctx context.Context, cutoff time.Time) ([]model.AssetUpload, error) {
+	s.logger.WithField("cutoff", cutoff).Trace("entering ListAssetUploadsUpdatedBefore")
+	defer s.logger.Trace("returning from ListAssetUploadsUpdatedBefore")
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+assetUploadSelectColumns+" FROM asset_uploads WHERE updated_at < ? ORDER BY updated_at",
+		cutoff.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query asset uploads")
+		return nil, fmt.Errorf("querying asset uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var uploads []model.AssetUpload
+	for rows.Next() {
+		var e assetUploadEntity
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Filename, &e.ContentType, &e.Size, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan asset upload row")
+			return nil, fmt.Errorf("scanning asset upload row: %w", err)
+		}
+		u, err := assetUploadEntityToModel(e)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to convert entity to model")
+			return nil, err
+		}
+		uploads = append(uploads, u)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating asset uploads")
+		return nil, fmt.Errorf("iterating asset uploads: %w", err)
+	}
+	s.logger.WithField("upload_count", len(uploads)).Debug("listed stale asset uploads from database")
+	return uploads, nil
+}
+
+// CreateAssetUpload inserts a new asset upload.
+func (s *Store) CreateAssetUpload(ctx context.Context, u model.AssetUpload) error {
+	s.logger.WithFields(logrus.Fields{
+		"upload_id": u.ID,
+		"kind":      u.Kind,
+	}).Trace("entering CreateAssetUpload")
+	defer s.logger.Trace("returning from CreateAssetUpload")
+
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO asset_uploads ("+assetUploadSelectColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		u.ID,
+		string(u.Kind),
+		u.Filename,
+		u.ContentType,
+		u.Size,
+		u.CreatedAt.UTC().Format(time.RFC3339),
+		u.UpdatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"upload_id": u.ID,
+			"error":     err.Error(),
+		}).Error("failed to insert asset upload into database")
+		return fmt.Errorf("inserting asset upload: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"upload_id": u.ID,
+		"kind":      u.Kind,
+		"size":      u.Size,
+	}).Info("inserted asset upload into database")
+	return nil
+}
+
+// TouchAssetUpload sets the updated_at time of an asset upload. Returns
+// sql.ErrNoRows if not found.
+func (s *Store) TouchAssetUpload(ctx context.Context, id string, updatedAt time.Time) error {
+	s.logger.WithField("upload_id", id).Trace("entering TouchAssetUpload")
+	defer s.logger.Trace("returning from TouchAssetUpload")
+
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE asset_uploads SET updated_at = ? WHERE id = ?",
+		updatedAt.UTC().Format(time.RFC3339), id,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"upload_id": id,
+			"error":     err.Error(),
+		}).Error("failed to update asset upload")
+		return fmt.Errorf("updating asset upload: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	s.logger.WithField("upload_id", id).Debug("touched asset upload")
+	return nil
+}
+
+// DeleteAssetUpload removes an asset upload by ID. Returns sql.ErrNoRows if
+// not found.
+func (s *Store) DeleteAssetUpload(ctx context.Context, id string) error {
+	s.logger.WithField("upload_id", id).Trace("entering DeleteAssetUpload")
+	defer s.logger.Trace("returning from DeleteAssetUpload")
+
+	return s.deleteByID(ctx, "asset_uploads", "asset upload", id)
+}
+
+// deleteByID deletes the row with the given ID from table. Returns
+// sql.ErrNoRows if there is no such row.
+func (s *Store) deleteByID(ctx context.Context, table string, noun string, id string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM "+table+" WHERE id = ?", id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"id":    id,
+			"error": err.Error(),
+		}).Errorf("failed to delete %s from database", noun)
+		return fmt.Errorf("deleting %s: %w", noun, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"id":    id,
+			"error": err.Error(),
+		}).Error("failed to check rows affected")
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("id", id).Debugf("no rows affected, %s not found", noun)
+		return sql.ErrNoRows
+	}
+	s.logger.WithField("id", id).Infof("deleted %s from database", noun)
+	return nil
+}
+
+func assetEntityToModel(e assetEntity) (model.Asset, error) {
+	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
+	if err != nil {
+		return model.Asset{}, fmt.Errorf("parsing created_at: %w", err)
+	}
+	return model.Asset{
+		ID:          e.ID,
+		Kind:        model.AssetKind(e.Kind),
+		Filename:    e.Filename,
+		ContentType: e.ContentType,
+		Size:        e.Size,
+		SHA256:      e.SHA256,
+		Path:        e.Path,
+		CreatedAt:   createdAt,
+	}, nil
+}
+
+func assetUploadEntityToModel(e assetUploadEntity) (model.AssetUpload, error) {
+	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
+	if err != nil {
+		return model.AssetUpload{}, fmt.Errorf("parsing created_at: %w", err)
+	}
+	updatedAt, err := time.Parse(time.RFC3339, e.UpdatedAt)
+	if err != nil {
+		return model.AssetUpload{}, fmt.Errorf("parsing updated_at: %w", err)
+	}
+	return model.AssetUpload{
+		ID:          e.ID,
+		Kind:        model.AssetKind(e.Kind),
+		Filename:    e.Filename,
+		ContentType: e.ContentType,
+		Size:        e.Size,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+	}, nil
+}
@@ -0,0 +1,168 @@
+package store_test
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("AssetStore", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "asset-store-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newAsset := func(id string, kind model.AssetKind, sha string, createdAt time.Time) model.Asset {
+		return model.Asset{
+			ID:          id,
+			Kind:        kind,
+			Filename:    id + ".png",
+			ContentType: "image/png",
+			Size:        1234,
+			SHA256:      sha,
+			Path:        string(kind) + "/" + sha + ".png",
+			CreatedAt:   createdAt,
+		}
+	}
+
+	Describe("assets", func() {
+		It("round-trips an asset", func() {
+			a := newAsset("a1", model.AssetKindImage, "abc", now)
+			Expect(st.CreateAsset(ctx, a)).To(Succeed())
+
+			got, err := st.GetAsset(ctx, "a1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(Equal(a))
+		})
+
+		It("returns sql.ErrNoRows for a missing asset", func() {
+			_, err := st.GetAsset(ctx, "missing")
+			Expect(err).To(Equal(sql.ErrNoRows))
+		})
+
+		It("lists assets newest first, optionally filtered by kind", func() {
+			Expect(st.CreateAsset(ctx, newAsset("a1", model.AssetKindImage, "aaa", now))).To(Succeed())
+			Expect(st.CreateAsset(ctx, newAsset("a2", model.AssetKindWildcards, "bbb", now.Add(time.Minute)))).To(Succeed())
+			Expect(st.CreateAsset(ctx, newAsset("a3", model.AssetKindImage, "ccc", now.Add(2*time.Minute)))).To(Succeed())
+
+			all, err := st.ListAssets(ctx, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(all).To(HaveLen(3))
+			Expect(all[0].ID).To(Equal("a3"))
+			Expect(all[2].ID).To(Equal("a1"))
+
+			images, err := st.ListAssets(ctx, model.AssetKindImage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(images).To(HaveLen(2))
+			Expect(images[0].ID).To(Equal("a3"))
+			Expect(images[1].ID).To(Equal("a1"))
+		})
+
+		It("finds an asset by kind and content hash", func() {
+			Expect(st.CreateAsset(ctx, newAsset("a1", model.AssetKindImage, "abc", now))).To(Succeed())
+
+			got, err := st.GetAssetBySHA256(ctx, model.AssetKindImage, "abc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.ID).To(Equal("a1"))
+
+			_, err = st.GetAssetBySHA256(ctx, model.AssetKindWildcards, "abc")
+			Expect(err).To(Equal(sql.ErrNoRows))
+		})
+
+		It("rejects a second asset with the same kind and hash", func() {
+			Expect(st.CreateAsset(ctx, newAsset("a1", model.AssetKindImage, "abc", now))).To(Succeed())
+			Expect(st.CreateAsset(ctx, newAsset("a2", model.AssetKindImage, "abc", now))).NotTo(Succeed())
+		})
+
+		It("deletes an asset", func() {
+			Expect(st.CreateAsset(ctx, newAsset("a1", model.AssetKindImage, "abc", now))).To(Succeed())
+			Expect(st.DeleteAsset(ctx, "a1")).To(Succeed())
+			_, err := st.GetAsset(ctx, "a1")
+			Expect(err).To(Equal(sql.ErrNoRows))
+			Expect(st.DeleteAsset(ctx, "a1")).To(Equal(sql.ErrNoRows))
+		})
+	})
+
+	Describe("uploads", func() {
+		newUpload := func(id string, updatedAt time.Time) model.AssetUpload {
+			return model.AssetUpload{
+				ID:          id,
+				Kind:        model.AssetKindWildcards,
+				Filename:    "colors.txt",
+				ContentType: "text/plain",
+				Size:        4096,
+				CreatedAt:   now,
+				UpdatedAt:   updatedAt,
+			}
+		}
+
+		It("round-trips an upload", func() {
+			u := newUpload("u1", now)
+			Expect(st.CreateAssetUpload(ctx, u)).To(Succeed())
+
+			got, err := st.GetAssetUpload(ctx, "u1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(Equal(u))
+		})
+
+		It("lists uploads not updated since a cutoff", func() {
+			Expect(st.CreateAssetUpload(ctx, newUpload("old", now))).To(Succeed())
+			Expect(st.CreateAssetUpload(ctx, newUpload("new", now.Add(time.Hour)))).To(Succeed())
+
+			stale, err := st.ListAssetUploadsUpdatedBefore(ctx, now.Add(time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stale).To(HaveLen(1))
+			Expect(stale[0].ID).To(Equal("old"))
+		})
+
+		It("touches an upload", func() {
+			Expect(st.CreateAssetUpload(ctx, newUpload("u1", now))).To(Succeed())
+			Expect(st.TouchAssetUpload(ctx, "u1", now.Add(time.Hour))).To(Succeed())
+
+			got, err := st.GetAssetUpload(ctx, "u1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.UpdatedAt).To(Equal(now.Add(time.Hour)))
+			Expect(st.TouchAssetUpload(ctx, "missing", now)).To(Equal(sql.ErrNoRows))
+		})
+
+		It("deletes an upload", func() {
+			Expect(st.CreateAssetUpload(ctx, newUpload("u1", now))).To(Succeed())
+			Expect(st.DeleteAssetUpload(ctx, "u1")).To(Succeed())
+			_, err := st.GetAssetUpload(ctx, "u1")
+			Expect(err).To(Equal(sql.ErrNoRows))
+			Expect(st.DeleteAssetUpload(ctx, "u1")).To(Equal(sql.ErrNoRows))
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ListChanges returns up to limit change log entries with a sequence number
+// greater than since, in ascending sequence order. The change log is filled
+// by triggers (see migration 32) and keeps only the latest change of each
+// entity.
+func (s *Store) ListChanges(ctx context.Context, since int64, limit int) ([]model.Change, error) {
+	s.logger.WithFields(logrus.Fields{
+		"since": since,
+		"limit": limit,
+	}).Trace("entering ListChanges")
+	defer s.logger.Trace("returning from ListChanges")
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT seq, entity, entity_id FROM change_log WHERE seq > ? ORDER BY seq LIMIT ?",
+		since, limit,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"since": since,
+			"error": err.Error(),
+		}).Error("failed to query change log")
+		return nil, fmt.Errorf("querying change log: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []model.Change
+	for rows.Next() {
+		var c model.Change
+		var entity string
+		if err := rows.Scan(&c.Seq, &entity, &c.EntityID); err != nil {
+			s.logger.WithError(err).Error("failed to scan change log row")
+			return nil, fmt.Errorf("scanning change log row: %w", err)
+		}
+		c.Entity = model.ChangeEntity(entity)
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating change log")
+		return nil, fmt.Errorf("iterating change log: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"since":        since,
+		"change_count": len(changes),
+	}).Debug("listed changes")
+	return changes, nil
+}
@@ -0,0 +1,241 @@
+package store_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("Change log", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "change-log-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+		now = time.Now().UTC().Truncate(time.Second)
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	createStudy := func(id string) {
+		Expect(st.CreateStudy(ctx, model.Study{
+			ID:                    id,
+			Name:                  "Study " + id,
+			Prompts:               []model.NamedPrompt{{Name: "p", Text: "a cat"}},
+			Steps:                 []int{4},
+			CFGs:                  []float64{7.0},
+			SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+			Seeds:                 []int64{42},
+			Width:                 512,
+			Height:                512,
+			CreatedAt:             now,
+			UpdatedAt:             now,
+		})).To(Succeed())
+	}
+
+	job := func(id, studyID string) model.SampleJob {
+		return model.SampleJob{
+			ID:              id,
+			TrainingRunName: "run",
+			StudyID:         studyID,
+			StudyName:       "Study " + studyID,
+			WorkflowName:    "flux-dev",
+			Status:          model.SampleJobStatusPending,
+			Version:         1,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+	}
+
+	item := func(id, jobID string) model.SampleJobItem {
+		return model.SampleJobItem{
+			ID:                 id,
+			JobID:              jobID,
+			CheckpointFilename: "cp.safetensors",
+			ComfyUIModelPath:   "cp.safetensors",
+			PromptName:         "p",
+			PromptText:         "a cat",
+			Steps:              4,
+			CFG:                7.0,
+			SamplerName:        "euler",
+			Scheduler:          "simple",
+			Seed:               42,
+			Status:             model.SampleJobItemStatusPending,
+			Version:            1,
+			CreatedAt:          now,
+			UpdatedAt:          now,
+		}
+	}
+
+	changes := func(since int64) []model.Change {
+		cs, err := st.ListChanges(ctx, since, 100)
+		Expect(err).NotTo(HaveOccurred())
+		return cs
+	}
+
+	entities := func(cs []model.Change) []string {
+		var out []string
+		for _, c := range cs {
+			out = append(out, string(c.Entity)+":"+c.EntityID)
+		}
+		return out
+	}
+
+	It("is empty for a new database", func() {
+		Expect(changes(0)).To(BeEmpty())
+	})
+
+	It("records writes in sequence order, one entry per entity", func() {
+		createStudy("s1")
+		Expect(st.CreateSampleJobWithItems(ctx, job("j1", "s1"), []model.SampleJobItem{item("i1", "j1"), item("i2", "j1")})).To(Succeed())
+
+		cs := changes(0)
+		Expect(entities(cs)).To(Equal([]string{"study:s1", "sample_job:j1", "sample_job_items:j1"}))
+		Expect(cs[0].Seq).To(BeNumerically("<", cs[1].Seq))
+		Expect(cs[1].Seq).To(BeNumerically("<", cs[2].Seq))
+	})
+
+	It("moves an entity changed again past the cursor", func() {
+		createStudy("s1")
+		createStudy("s2")
+		cursor := changes(0)[1].Seq
+
+		i := item("i1", "j1")
+		Expect(st.CreateSampleJobWithItems(ctx, job("j1", "s1"), []model.SampleJobItem{i})).To(Succeed())
+		i.Status = model.SampleJobItemStatusCompleted
+		Expect(st.UpdateSampleJobItem(ctx, &i)).To(Succeed())
+		st1, err := st.GetStudy(ctx, "s1")
+		Expect(err).NotTo(HaveOccurred())
+		st1.Name = "Renamed"
+		Expect(st.UpdateStudy(ctx, st1)).To(Succeed())
+
+		Expect(entities(changes(cursor))).To(Equal([]string{"sample_job:j1", "sample_job_items:j1", "study:s1"}))
+		Expect(entities(changes(0))).To(HaveLen(4))
+	})
+
+	It("records deletes, including foreign key cascades", func() {
+		createStudy("s1")
+		Expect(st.CreateSampleJobWithItems(ctx, job("j1", "s1"), []model.SampleJobItem{item("i1", "j1")})).To(Succeed())
+		cursor := changes(0)[2].Seq
+
+		Expect(st.DeleteStudy(ctx, "s1")).To(Succeed())
+
+		Expect(entities(changes(cursor))).To(ConsistOf("study:s1", "sample_job:j1", "sample_job_items:j1"))
+	})
+
+	It("records presets and image index directories", func() {
+		Expect(st.CreatePreset(ctx, model.Preset{ID: "p1", Name: "Preset", CreatedAt: now, UpdatedAt: now})).To(Succeed())
+		Expect(st.ReplaceIndexedImageDir(ctx, model.IndexedImageDir{
+			Dir:       "study/cp.safetensors",
+			ModTime:   now,
+			IndexedAt: now,
+			Filenames: []string{"a.png", "b.png"},
+		})).To(Succeed())
+		cursor := changes(0)[1].Seq
+		Expect(st.AddIndexedImage(ctx, "study/cp.safetensors", "c.png", now, now)).To(Succeed())
+
+		Expect(entities(changes(0))).To(Equal([]string{"preset:p1", "image_dir:study/cp.safetensors"}))
+		Expect(entities(changes(cursor))).To(Equal([]string{"image_dir:study/cp.safetensors"}))
+	})
+
+	It("returns at most limit changes", func() {
+		createStudy("s1")
+		createStudy("s2")
+		createStudy("s3")
+
+		cs, err := st.ListChanges(ctx, 0, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entities(cs)).To(Equal([]string{"study:s1", "study:s2"}))
+	})
+})
+
+var _ = Describe("CountSampleJobItemsByStatus", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "item-counts-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	It("counts items by status, skipped items as failed", func() {
+		now := time.Now().UTC().Truncate(time.Second)
+		Expect(st.CreateStudy(ctx, model.Study{ID: "s1", Name: "Study", CreatedAt: now, UpdatedAt: now})).To(Succeed())
+		var items []model.SampleJobItem
+		for i, status := range []model.SampleJobItemStatus{
+			model.SampleJobItemStatusCompleted,
+			model.SampleJobItemStatusCompleted,
+			model.SampleJobItemStatusFailed,
+			model.SampleJobItemStatusSkipped,
+			model.SampleJobItemStatusPending,
+			model.SampleJobItemStatusRunning,
+		} {
+			items = append(items, model.SampleJobItem{
+				ID:        string(rune('a' + i)),
+				JobID:     "j1",
+				Status:    status,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
+		Expect(st.CreateSampleJobWithItems(ctx, model.SampleJob{
+			ID:        "j1",
+			StudyID:   "s1",
+			Status:    model.SampleJobStatusRunning,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}, items)).To(Succeed())
+
+		counts, err := st.CountSampleJobItemsByStatus(ctx, "j1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(counts).To(Equal(model.ItemStatusCounts{Completed: 2, Failed: 2, Pending: 1}))
+
+		counts, err = st.CountSampleJobItemsByStatus(ctx, "missing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(counts).To(Equal(model.ItemStatusCounts{}))
+	})
+})
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ListCheckpointHashes returns the stored checkpoint hashes ordered by path.
+func (s *Store) ListCheckpointHashes(ctx context.Context) ([]model.CheckpointHash, error) {
+	s.logger.Trace("entering ListCheckpointHashes")
+	defer s.logger.Trace("returning from ListCheckpointHashes")
+
+	rows, err := s.db.QueryContext(ctx, "SELECT path, size, mod_time, hash, computed_at FROM checkpoint_hashes ORDER BY path")
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query checkpoint hashes")
+		return nil, fmt.Errorf("querying checkpoint hashes: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := []model.CheckpointHash{}
+	for rows.Next() {
+		var h model.CheckpointHash
+		var modTime, computedAt string
+		if err := rows.Scan(&h.Path, &h.Size, &modTime, &h.Hash, &computedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan checkpoint hash row")
+			return nil, fmt.Errorf("scanning checkpoint hash row: %w", err)
+		}
+		if h.ModTime, err = time.Parse(time.RFC3339Nano, modTime); err != nil {
+			return nil, fmt.Errorf("parsing mod_time: %w", err)
+		}
+		if h.ComputedAt, err = time.Parse(time.RFC3339Nano, computedAt); err != nil {
+			return nil, fmt.Errorf("parsing computed_at: %w", err)
+		}
+		hashes = append(hashes, h)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating checkpoint hashes")
+		return nil, fmt.Errorf("iterating checkpoint hashes: %w", err)
+	}
+	return hashes, nil
+}
+
+// SaveCheckpointHash records h, replacing the hash previously stored for its
+// path.
+func (s *Store) SaveCheckpointHash(ctx context.Context, h model.CheckpointHash) error {
+	s.logger.WithFields(logrus.Fields{
+		"path": h.Path,
+		"hash": h.Hash,
+	}).Trace("entering SaveCheckpointHash")
+	defer s.logger.Trace("returning from SaveCheckpointHash")
+
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO checkpoint_hashes (path, size, mod_time, hash, computed_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(path) DO UPDATE SET size = excluded.size, mod_time = excluded.mod_time,
+			hash = excluded.hash, computed_at = excluded.computed_at`,
+		h.Path, h.Size, formatIndexTime(h.ModTime), h.Hash, formatIndexTime(h.ComputedAt),
+	); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  h.Path,
+			"error": err.Error(),
+		}).Error("failed to upsert checkpoint hash")
+		return fmt.Errorf("upserting checkpoint hash: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"path": h.Path,
+		"hash": h.Hash,
+	}).Debug("saved checkpoint hash")
+	return nil
+}
+
+// DeleteCheckpointHash removes the hash stored for path. Deleting a path
+// without a hash is a no-op.
+func (s *Store) DeleteCheckpointHash(ctx context.Context, path string) error {
+	s.logger.WithField("path", path).Trace("entering DeleteCheckpointHash")
+	defer s.logger.Trace("returning from DeleteCheckpointHash")
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM checkpoint_hashes WHERE path = ?", path); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to delete checkpoint hash")
+		return fmt.Errorf("deleting checkpoint hash: %w", err)
+	}
+	s.logger.WithField("path", path).Debug("deleted checkpoint hash")
+	return nil
+}
@@ -0,0 +1,80 @@
+package store_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("CheckpointHashStore", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "checkpoint-hash-store-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	// Modification times are compared for equality, so their sub-second
+	// precision must survive the round trip.
+	modTime := time.Date(2025, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	computedAt := modTime.Add(time.Minute)
+
+	It("returns an empty list when nothing has been hashed", func() {
+		hashes, err := st.ListCheckpointHashes(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hashes).To(BeEmpty())
+	})
+
+	It("saves, replaces and lists hashes ordered by path", func() {
+		Expect(st.SaveCheckpointHash(ctx, model.CheckpointHash{Path: "/b.safetensors", Size: 2, ModTime: modTime, Hash: "bb", ComputedAt: computedAt})).To(Succeed())
+		Expect(st.SaveCheckpointHash(ctx, model.CheckpointHash{Path: "/a.safetensors", Size: 1, ModTime: modTime, Hash: "aa", ComputedAt: computedAt})).To(Succeed())
+		Expect(st.SaveCheckpointHash(ctx, model.CheckpointHash{Path: "/b.safetensors", Size: 3, ModTime: modTime, Hash: "cc", ComputedAt: computedAt})).To(Succeed())
+
+		hashes, err := st.ListCheckpointHashes(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hashes).To(HaveLen(2))
+		Expect(hashes[0].Path).To(Equal("/a.safetensors"))
+		Expect(hashes[1].Path).To(Equal("/b.safetensors"))
+		Expect(hashes[1].Size).To(Equal(int64(3)))
+		Expect(hashes[1].Hash).To(Equal("cc"))
+		Expect(hashes[1].ModTime.Equal(modTime)).To(BeTrue())
+		Expect(hashes[1].ComputedAt.Equal(computedAt)).To(BeTrue())
+	})
+
+	It("deletes hashes", func() {
+		Expect(st.SaveCheckpointHash(ctx, model.CheckpointHash{Path: "/a.safetensors", Size: 1, ModTime: modTime, Hash: "aa", ComputedAt: computedAt})).To(Succeed())
+		Expect(st.DeleteCheckpointHash(ctx, "/a.safetensors")).To(Succeed())
+		Expect(st.DeleteCheckpointHash(ctx, "/missing.safetensors")).To(Succeed())
+
+		hashes, err := st.ListCheckpointHashes(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hashes).To(BeEmpty())
+	})
+})
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
@@ -16,6 +18,7 @@ import (
 
 // ComfyUIHTTPClient provides HTTP operations for interacting with ComfyUI.
 type ComfyUIHTTPClient struct {
+	mu      sync.RWMutex
 	baseURL string
 	client  *http.Client
 	logger  *logrus.Entry
@@ -33,12 +36,27 @@ func NewComfyUIHTTPClient(baseURL string, logger *logrus.Logger) *ComfyUIHTTPCli
 	}
 }
 
+// SetBaseURL changes the ComfyUI server that subsequent requests are sent to.
+// It is called when the configuration is reloaded.
+func (c *ComfyUIHTTPClient) SetBaseURL(baseURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseURL = baseURL
+}
+
+// base returns the current ComfyUI base URL.
+func (c *ComfyUIHTTPClient) base() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.baseURL
+}
+
 // HealthCheck verifies that ComfyUI is reachable.
 func (c *ComfyUIHTTPClient) HealthCheck(ctx context.Context) error {
 	c.logger.Trace("entering HealthCheck")
 	defer c.logger.Trace("returning from HealthCheck")
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/system_stats", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base()+"/system_stats", nil)
 	if err != nil {
 		c.logger.WithError(err).Error("failed to create health check request")
 		return fmt.Errorf("creating health check request: %w", err)
@@ -61,6 +79,55 @@ func (c *ComfyUIHTTPClient) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// systemStatsEntity is the JSON-serializable store entity for the
+// /system_stats response.
+type systemStatsEntity struct {
+	Devices []struct {
+		Name      string `json:"name"`
+		Type      string `json:"type"`
+		VRAMTotal int64  `json:"vram_total"`
+		VRAMFree  int64  `json:"vram_free"`
+	} `json:"devices"`
+}
+
+// SystemStats retrieves the compute devices ComfyUI runs on.
+func (c *ComfyUIHTTPClient) SystemStats(ctx context.Context) (model.ComfyUISystemStats, error) {
+	c.logger.Trace("entering SystemStats")
+	defer c.logger.Trace("returning from SystemStats")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base()+"/system_stats", nil)
+	if err != nil {
+		return model.ComfyUISystemStats{}, fmt.Errorf("creating system stats request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return model.ComfyUISystemStats{}, fmt.Errorf("getting system stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return model.ComfyUISystemStats{}, fmt.Errorf("get system stats failed with status %d", resp.StatusCode)
+	}
+
+	var entity systemStatsEntity
+	if err := json.NewDecoder(resp.Body).Decode(&entity); err != nil {
+		return model.ComfyUISystemStats{}, fmt.Errorf("decoding system stats response: %w", err)
+	}
+
+	stats := model.ComfyUISystemStats{Devices: make([]model.ComfyUIDevice, len(entity.Devices))}
+	for i, d := range entity.Devices {
+		stats.Devices[i] = model.ComfyUIDevice{
+			Name:      d.Name,
+			Type:      d.Type,
+			VRAMTotal: d.VRAMTotal,
+			VRAMFree:  d.VRAMFree,
+		}
+	}
+	c.logger.WithField("device_count", len(stats.Devices)).Debug("retrieved ComfyUI system stats")
+	return stats, nil
+}
+
 // promptRequestEntity is the JSON-serializable store entity for prompt requests.
 type promptRequestEntity struct {
 	Prompt     map[string]interface{} `json:"prompt"`
@@ -107,7 +174,7 @@ func (c *ComfyUIHTTPClient) SubmitPrompt(ctx context.Context, req model.PromptRe
 		return nil, fmt.Errorf("marshaling prompt request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/prompt", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base()+"/prompt", bytes.NewReader(body))
 	if err != nil {
 		c.logger.WithError(err).Error("failed to create prompt request")
 		return nil, fmt.Errorf("creating prompt request: %w", err)
@@ -169,7 +236,7 @@ func toModelHistoryResponse(entity historyResponseEntity) model.HistoryResponse
 
 // GetHistory retrieves the execution history for a prompt.
 func (c *ComfyUIHTTPClient) GetHistory(ctx context.Context, promptID string) (model.HistoryResponse, error) {
-	url := c.baseURL + "/history"
+	url := c.base() + "/history"
 	if promptID != "" {
 		url = fmt.Sprintf("%s/%s", url, promptID)
 	}
@@ -243,9 +310,27 @@ func (q *QueueItem) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// toModelComfyUIQueue converts the store entity to model.ComfyUIQueue.
+func toModelComfyUIQueue(status QueueStatus) *model.ComfyUIQueue {
+	toEntries := func(items []QueueItem) []model.ComfyUIQueueEntry {
+		entries := make([]model.ComfyUIQueueEntry, len(items))
+		for i, item := range items {
+			entries[i] = model.ComfyUIQueueEntry{Number: item.Number, PromptID: item.PromptID}
+		}
+		return entries
+	}
+	return &model.ComfyUIQueue{
+		Running: toEntries(status.Running),
+		Pending: toEntries(status.Pending),
+	}
+}
+
 // GetQueueStatus retrieves the current queue status.
-func (c *ComfyUIHTTPClient) GetQueueStatus(ctx context.Context) (*QueueStatus, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/queue", nil)
+func (c *ComfyUIHTTPClient) GetQueueStatus(ctx context.Context) (*model.ComfyUIQueue, error) {
+	c.logger.Trace("entering GetQueueStatus")
+	defer c.logger.Trace("returning from GetQueueStatus")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base()+"/queue", nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating queue status request: %w", err)
 	}
@@ -265,7 +350,38 @@ func (c *ComfyUIHTTPClient) GetQueueStatus(ctx context.Context) (*QueueStatus, e
 		return nil, fmt.Errorf("decoding queue status response: %w", err)
 	}
 
-	return &queueStatus, nil
+	return toModelComfyUIQueue(queueStatus), nil
+}
+
+// RecentLog retrieves the log lines ComfyUI keeps in memory from its
+// /internal/logs endpoint.
+func (c *ComfyUIHTTPClient) RecentLog(ctx context.Context) (string, error) {
+	c.logger.Trace("entering RecentLog")
+	defer c.logger.Trace("returning from RecentLog")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base()+"/internal/logs", nil)
+	if err != nil {
+		return "", fmt.Errorf("creating logs request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("getting logs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get logs failed with status %d", resp.StatusCode)
+	}
+
+	// The endpoint returns the whole log as a single JSON string.
+	var log string
+	if err := json.NewDecoder(resp.Body).Decode(&log); err != nil {
+		return "", fmt.Errorf("decoding logs response: %w", err)
+	}
+
+	c.logger.WithField("log_bytes", len(log)).Debug("retrieved ComfyUI log")
+	return log, nil
 }
 
 // ObjectInfo represents the schema for a ComfyUI node type.
@@ -287,7 +403,7 @@ func (c *ComfyUIHTTPClient) GetObjectInfo(ctx context.Context, nodeType string)
 	c.logger.WithField("node_type", nodeType).Trace("entering GetObjectInfo")
 	defer c.logger.Trace("returning from GetObjectInfo")
 
-	url := c.baseURL + "/object_info"
+	url := c.base() + "/object_info"
 	if nodeType != "" {
 		url = fmt.Sprintf("%s/%s", url, nodeType)
 	}
@@ -352,7 +468,7 @@ func (c *ComfyUIHTTPClient) DownloadImage(ctx context.Context, filename string,
 	defer c.logger.Trace("returning from DownloadImage")
 
 	// Build URL with properly encoded query parameters
-	baseURL := c.baseURL + "/view"
+	baseURL := c.base() + "/view"
 	params := url.Values{}
 	params.Set("filename", filename)
 	if subfolder != "" {
@@ -404,6 +520,91 @@ func (c *ComfyUIHTTPClient) DownloadImage(ctx context.Context, filename string,
 	return data, nil
 }
 
+// uploadImageResponseEntity is the JSON-serializable store entity for an image upload.
+type uploadImageResponseEntity struct {
+	Name      string `json:"name"`
+	Subfolder string `json:"subfolder"`
+	Type      string `json:"type"`
+}
+
+// UploadImage uploads an image to ComfyUI's input directory, overwriting any
+// existing file of the same name. Returns the name ComfyUI stored the image
+// under, which is the value LoadImage nodes expect.
+func (c *ComfyUIHTTPClient) UploadImage(ctx context.Context, filename string, data []byte) (string, error) {
+	c.logger.WithFields(logrus.Fields{
+		"filename": filename,
+		"size":     len(data),
+	}).Trace("entering UploadImage")
+	defer c.logger.Trace("returning from UploadImage")
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("image", filename)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to create upload form file")
+		return "", fmt.Errorf("creating upload form file: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		c.logger.WithError(err).Error("failed to write upload form file")
+		return "", fmt.Errorf("writing upload form file: %w", err)
+	}
+	if err := writer.WriteField("type", "input"); err != nil {
+		return "", fmt.Errorf("writing upload form field: %w", err)
+	}
+	if err := writer.WriteField("overwrite", "true"); err != nil {
+		return "", fmt.Errorf("writing upload form field: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("closing upload form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base()+"/upload/image", &body)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to create upload request")
+		return "", fmt.Errorf("creating upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	c.logger.WithField("filename", filename).Debug("uploading image to ComfyUI")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"filename": filename,
+			"error":    err.Error(),
+		}).Error("failed to upload image")
+		return "", fmt.Errorf("uploading image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		c.logger.WithFields(logrus.Fields{
+			"filename":    filename,
+			"status_code": resp.StatusCode,
+			"response":    string(bodyBytes),
+		}).Error("image upload returned non-OK status")
+		return "", fmt.Errorf("upload image failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var respEntity uploadImageResponseEntity
+	if err := json.NewDecoder(resp.Body).Decode(&respEntity); err != nil {
+		c.logger.WithError(err).Error("failed to decode upload response")
+		return "", fmt.Errorf("decoding upload response: %w", err)
+	}
+	if respEntity.Name == "" {
+		return "", fmt.Errorf("upload response has no image name")
+	}
+
+	// Images uploaded to a subfolder are referenced as "subfolder/name".
+	name := respEntity.Name
+	if respEntity.Subfolder != "" {
+		name = respEntity.Subfolder + "/" + name
+	}
+
+	c.logger.WithField("name", name).Info("image uploaded successfully")
+	return name, nil
+}
+
 // CancelPrompt cancels a queued or running prompt by deleting it from the queue.
 func (c *ComfyUIHTTPClient) CancelPrompt(ctx context.Context, promptID string) error {
 	c.logger.WithField("prompt_id", promptID).Trace("entering CancelPrompt")
@@ -419,7 +620,7 @@ func (c *ComfyUIHTTPClient) CancelPrompt(ctx context.Context, promptID string) e
 		return fmt.Errorf("marshaling cancel request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/queue", bytes.NewReader(bodyJSON))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base()+"/queue", bytes.NewReader(bodyJSON))
 	if err != nil {
 		c.logger.WithError(err).Error("failed to create cancel request")
 		return fmt.Errorf("creating cancel request: %w", err)
@@ -450,3 +651,46 @@ func (c *ComfyUIHTTPClient) CancelPrompt(ctx context.Context, promptID string) e
 	c.logger.WithField("prompt_id", promptID).Info("prompt canceled successfully")
 	return nil
 }
+
+// InterruptPrompt stops a prompt that ComfyUI is executing. Deleting a prompt
+// from the queue does not stop it once it runs. ComfyUI versions that do not
+// take a prompt ID interrupt whichever prompt is running.
+func (c *ComfyUIHTTPClient) InterruptPrompt(ctx context.Context, promptID string) error {
+	c.logger.WithField("prompt_id", promptID).Trace("entering InterruptPrompt")
+	defer c.logger.Trace("returning from InterruptPrompt")
+
+	bodyJSON, err := json.Marshal(map[string]interface{}{"prompt_id": promptID})
+	if err != nil {
+		return fmt.Errorf("marshaling interrupt request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base()+"/interrupt", bytes.NewReader(bodyJSON))
+	if err != nil {
+		return fmt.Errorf("creating interrupt request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	c.logger.WithField("prompt_id", promptID).Debug("interrupting prompt in ComfyUI")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"prompt_id": promptID,
+			"error":     err.Error(),
+		}).Error("failed to interrupt prompt")
+		return fmt.Errorf("interrupting prompt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		c.logger.WithFields(logrus.Fields{
+			"prompt_id":   promptID,
+			"status_code": resp.StatusCode,
+			"response":    string(bodyBytes),
+		}).Error("interrupt prompt returned non-OK status")
+		return fmt.Errorf("interrupt prompt failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	c.logger.WithField("prompt_id", promptID).Info("prompt interrupted")
+	return nil
+}
@@ -122,6 +122,46 @@ var _ = Describe("ComfyUIHTTPClient HTTP operations", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("health check failed"))
 		})
+
+		It("uses the new server after SetBaseURL", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			client := store.NewComfyUIHTTPClient("http://localhost:19999", logger)
+			Expect(client.HealthCheck(ctx)).NotTo(Succeed())
+
+			client.SetBaseURL(server.URL)
+			Expect(client.HealthCheck(ctx)).To(Succeed())
+		})
+	})
+
+	Describe("SystemStats", func() {
+		It("returns the reported devices", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/system_stats"))
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"system": {"os": "posix"}, "devices": [{"name": "cuda:0 NVIDIA GeForce RTX 4090", "type": "cuda", "index": 0, "vram_total": 25393692672, "vram_free": 24000000000}]}`))
+			}))
+
+			stats, err := createClient(server).SystemStats(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats.Devices).To(Equal([]model.ComfyUIDevice{{
+				Name:      "cuda:0 NVIDIA GeForce RTX 4090",
+				Type:      "cuda",
+				VRAMTotal: 25393692672,
+				VRAMFree:  24000000000,
+			}}))
+		})
+
+		It("fails when server returns non-200", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+
+			_, err := createClient(server).SystemStats(ctx)
+			Expect(err).To(MatchError(ContainSubstring("status 500")))
+		})
 	})
 
 	Describe("SubmitPrompt", func() {
@@ -171,6 +211,65 @@ var _ = Describe("ComfyUIHTTPClient HTTP operations", func() {
 		})
 	})
 
+	Describe("UploadImage", func() {
+		It("uploads the image as multipart form data", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/upload/image"))
+				Expect(r.Method).To(Equal(http.MethodPost))
+
+				file, header, err := r.FormFile("image")
+				Expect(err).NotTo(HaveOccurred())
+				defer file.Close()
+				data, err := io.ReadAll(file)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(header.Filename).To(Equal("reference.png"))
+				Expect(data).To(Equal([]byte("png-data")))
+				Expect(r.FormValue("type")).To(Equal("input"))
+				Expect(r.FormValue("overwrite")).To(Equal("true"))
+
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"name":      "reference.png",
+					"subfolder": "",
+					"type":      "input",
+				})
+			}))
+
+			client := createClient(server)
+			name, err := client.UploadImage(ctx, "reference.png", []byte("png-data"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("reference.png"))
+		})
+
+		It("prefixes the name with the subfolder ComfyUI reports", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"name":      "reference.png",
+					"subfolder": "uploads",
+					"type":      "input",
+				})
+			}))
+
+			client := createClient(server)
+			name, err := client.UploadImage(ctx, "reference.png", []byte("png-data"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("uploads/reference.png"))
+		})
+
+		It("handles server errors", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("invalid image"))
+			}))
+
+			client := createClient(server)
+			_, err := client.UploadImage(ctx, "reference.png", []byte("png-data"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("status 400"))
+		})
+	})
+
 	Describe("GetHistory", func() {
 		It("retrieves history successfully", func() {
 			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -255,6 +354,56 @@ var _ = Describe("ComfyUIHTTPClient HTTP operations", func() {
 		})
 	})
 
+	Describe("InterruptPrompt", func() {
+		It("posts the prompt ID to the interrupt endpoint", func() {
+			var body map[string]interface{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/interrupt"))
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			client := createClient(server)
+			Expect(client.InterruptPrompt(ctx, "prompt-123")).To(Succeed())
+			Expect(body).To(HaveKeyWithValue("prompt_id", "prompt-123"))
+		})
+
+		It("fails when the server returns non-200", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+
+			client := createClient(server)
+			Expect(client.InterruptPrompt(ctx, "prompt-123")).To(MatchError(ContainSubstring("status 500")))
+		})
+	})
+
+	Describe("RecentLog", func() {
+		It("retrieves the log text", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/internal/logs"))
+				Expect(r.Method).To(Equal(http.MethodGet))
+				json.NewEncoder(w).Encode("2025-01-01 - Starting server\n2025-01-01 - Prompt executed\n")
+			}))
+
+			client := createClient(server)
+			log, err := client.RecentLog(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(log).To(Equal("2025-01-01 - Starting server\n2025-01-01 - Prompt executed\n"))
+		})
+
+		It("fails when the server returns non-200", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+
+			client := createClient(server)
+			_, err := client.RecentLog(ctx)
+			Expect(err).To(MatchError(ContainSubstring("status 404")))
+		})
+	})
+
 	Describe("GetObjectInfo", func() {
 		It("retrieves object info for a specific node type", func() {
 			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// comfyUILogTailBytes is how much of the end of a ComfyUI log file is read.
+// It comfortably holds a few hundred lines including a long traceback.
+const comfyUILogTailBytes = 256 * 1024
+
+// ComfyUILogFile reads the end of a log file that ComfyUI writes to, for
+// setups where ComfyUI's /internal/logs endpoint is unavailable.
+type ComfyUILogFile struct {
+	path   string
+	logger *logrus.Entry
+}
+
+// NewComfyUILogFile creates a ComfyUILogFile for the log file at path.
+func NewComfyUILogFile(path string, logger *logrus.Logger) *ComfyUILogFile {
+	return &ComfyUILogFile{
+		path:   path,
+		logger: logger.WithField("component", "comfyui_log_file"),
+	}
+}
+
+// RecentLog returns the last part of the log file. When the file is longer
+// than comfyUILogTailBytes, the partial first line is dropped.
+func (f *ComfyUILogFile) RecentLog(ctx context.Context) (string, error) {
+	f.logger.WithField("path", f.path).Trace("entering RecentLog")
+	defer f.logger.Trace("returning from RecentLog")
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return "", fmt.Errorf("opening ComfyUI log file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("reading ComfyUI log file info: %w", err)
+	}
+	offset := info.Size() - comfyUILogTailBytes
+	if offset < 0 {
+		offset = 0
+	}
+	data, err := io.ReadAll(io.NewSectionReader(file, offset, info.Size()-offset))
+	if err != nil {
+		return "", fmt.Errorf("reading ComfyUI log file: %w", err)
+	}
+	if offset > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+
+	f.logger.WithFields(logrus.Fields{
+		"path":      f.path,
+		"log_bytes": len(data),
+	}).Debug("read ComfyUI log file")
+	return string(data), nil
+}
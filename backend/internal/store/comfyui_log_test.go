@@ -0,0 +1,60 @@
+package store_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("ComfyUILogFile", func() {
+	var (
+		tmpDir string
+		logger *logrus.Logger
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "comfyui-log-test-*")
+		Expect(err).NotTo(HaveOccurred())
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("returns the whole file when it is short", func() {
+		path := filepath.Join(tmpDir, "comfyui.log")
+		Expect(os.WriteFile(path, []byte("line 1\nline 2\n"), 0644)).To(Succeed())
+
+		log, err := store.NewComfyUILogFile(path, logger).RecentLog(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(log).To(Equal("line 1\nline 2\n"))
+	})
+
+	It("returns whole lines from the end of a long file", func() {
+		path := filepath.Join(tmpDir, "comfyui.log")
+		line := strings.Repeat("x", 99) + "\n"
+		Expect(os.WriteFile(path, []byte(strings.Repeat(line, 5000)+"last line\n"), 0644)).To(Succeed())
+
+		log, err := store.NewComfyUILogFile(path, logger).RecentLog(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(log)).To(BeNumerically("<=", 256*1024))
+		Expect(log).To(HavePrefix(line))
+		Expect(log).To(HaveSuffix(line + "last line\n"))
+	})
+
+	It("fails when the file does not exist", func() {
+		_, err := store.NewComfyUILogFile(filepath.Join(tmpDir, "missing.log"), logger).RecentLog(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("opening ComfyUI log file")))
+	})
+})
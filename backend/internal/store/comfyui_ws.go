@@ -108,6 +108,22 @@ func DeriveWebSocketURL(httpURL string, clientID string) string {
 	return wsURL.String()
 }
 
+// SetBaseURL changes the ComfyUI server to connect to. It is called when the
+// configuration is reloaded. An open connection is dropped so that the
+// disconnect handler fires and the next reconnect uses the new URL; the
+// client ID is kept.
+func (c *ComfyUIWSClient) SetBaseURL(baseURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.url = DeriveWebSocketURL(baseURL, c.clientID)
+	if c.conn != nil {
+		c.logger.WithField("url", c.url).Info("ComfyUI URL changed, dropping connection")
+		// readLoop observes the read error, clears conn, and fires the
+		// disconnect handler.
+		c.conn.Close()
+	}
+}
+
 // AddHandler registers an event handler.
 func (c *ComfyUIWSClient) AddHandler(handler model.ComfyUIEventHandler) {
 	c.mu.Lock()
@@ -126,7 +142,7 @@ func (c *ComfyUIWSClient) SetDisconnectHandler(handler func()) {
 
 // Connect establishes the WebSocket connection and starts listening for events.
 func (c *ComfyUIWSClient) Connect(ctx context.Context) error {
-	c.logger.WithField("url", c.url).Trace("entering Connect")
+	c.logger.Trace("entering Connect")
 	defer c.logger.Trace("returning from Connect")
 
 	c.mu.Lock()
@@ -135,14 +151,15 @@ func (c *ComfyUIWSClient) Connect(ctx context.Context) error {
 		c.logger.Warn("already connected")
 		return fmt.Errorf("already connected")
 	}
+	wsURL := c.url
 	c.mu.Unlock()
 
-	c.logger.WithField("url", c.url).Debug("dialing ComfyUI WebSocket")
+	c.logger.WithField("url", wsURL).Debug("dialing ComfyUI WebSocket")
 	dialer := websocket.DefaultDialer
-	conn, _, err := dialer.DialContext(ctx, c.url, nil)
+	conn, _, err := dialer.DialContext(ctx, wsURL, nil)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
-			"url":   c.url,
+			"url":   wsURL,
 			"error": err.Error(),
 		}).Error("failed to dial ComfyUI WebSocket")
 		return fmt.Errorf("dialing ComfyUI WebSocket: %w", err)
@@ -19,14 +19,30 @@ import (
 // only applies to the single connection that executes the statement; other
 // pool connections would not have foreign_keys enabled.
 func OpenDB(dbPath string) (*sql.DB, error) {
+	return openDB(dbPath, "_pragma=journal_mode%28WAL%29&_pragma=busy_timeout%285000%29&_pragma=foreign_keys%281%29")
+}
+
+// OpenSharedDB opens a SQLite database for use by several backend processes
+// at once (multi-process mode). In addition to the OpenDB settings it:
+//   - raises busy_timeout to 30s so writers queue behind another process's
+//     write transaction instead of failing with SQLITE_BUSY,
+//   - sets synchronous=NORMAL, which is durable under WAL and avoids an fsync
+//     per commit while two processes write,
+//   - begins transactions IMMEDIATE so a transaction takes the write lock up
+//     front rather than failing when upgrading from a read lock.
+func OpenSharedDB(dbPath string) (*sql.DB, error) {
+	return openDB(dbPath, "_pragma=journal_mode%28WAL%29&_pragma=busy_timeout%2830000%29&_pragma=foreign_keys%281%29&_pragma=synchronous%28NORMAL%29&_txlock=immediate")
+}
+
+// openDB opens the database at dbPath with the given DSN query parameters and
+// verifies that foreign keys are enforced.
+func openDB(dbPath string, params string) (*sql.DB, error) {
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("creating database directory: %w", err)
 	}
 
-	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode%%28WAL%%29&_pragma=busy_timeout%%285000%%29&_pragma=foreign_keys%%281%%29",
-		dbPath,
-	)
+	dsn := fmt.Sprintf("file:%s?%s", dbPath, params)
 
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
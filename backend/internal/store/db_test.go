@@ -51,6 +51,30 @@ var _ = Describe("OpenDB", func() {
 		Expect(foreignKeys).To(Equal(1))
 	})
 
+	It("opens a shared database with multi-process settings", func() {
+		dbPath := filepath.Join(tmpDir, "shared.db")
+		db, err := store.OpenSharedDB(dbPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer db.Close()
+
+		var journalMode string
+		Expect(db.QueryRow("PRAGMA journal_mode").Scan(&journalMode)).To(Succeed())
+		Expect(journalMode).To(Equal("wal"))
+
+		var busyTimeout int
+		Expect(db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout)).To(Succeed())
+		Expect(busyTimeout).To(Equal(30000))
+
+		// synchronous=NORMAL is reported as 1
+		var synchronous int
+		Expect(db.QueryRow("PRAGMA synchronous").Scan(&synchronous)).To(Succeed())
+		Expect(synchronous).To(Equal(1))
+
+		var foreignKeys int
+		Expect(db.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys)).To(Succeed())
+		Expect(foreignKeys).To(Equal(1))
+	})
+
 	It("creates the parent directory if it does not exist", func() {
 		dbPath := filepath.Join(tmpDir, "subdir", "nested", "test.db")
 		db, err := store.OpenDB(dbPath)
@@ -364,7 +388,7 @@ var _ = Describe("Migrate", func() {
 		var count int
 		err = db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(count).To(Equal(20))
+		Expect(count).To(Equal(53))
 
 		// Verify the table is functional with width and height columns
 		// First create a study and job to satisfy foreign key constraints
@@ -412,12 +436,61 @@ var _ = Describe("Migrate", func() {
 		Expect(width).To(Equal(1024))
 		Expect(height).To(Equal(768))
 	})
+
+	It("classifies items skipped before skip reasons existed by their message", func() {
+		Expect(store.Migrate(db, store.AllMigrations()[:37])).To(Succeed())
+		_, err := db.Exec(`
+			INSERT INTO studies (
+				id, name, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs,
+				seeds, width, height, created_at, updated_at
+			) VALUES (
+				'test-study', 'Test Study', '[]', '', '[20]', '[7.5]',
+				'[{"sampler":"euler","scheduler":"normal"}]', '[1]', 512, 512,
+				'2025-01-01T00:00:00Z', '2025-01-01T00:00:00Z'
+			);
+			INSERT INTO sample_jobs (
+				id, training_run_name, study_id, study_name, workflow_name, status,
+				total_items, created_at, updated_at
+			) VALUES (
+				'test-job', 'test-run', 'test-study', 'Test Study', 'workflow', 'completed',
+				3, '2025-01-01T00:00:00Z', '2025-01-01T00:00:00Z'
+			)
+		`)
+		Expect(err).NotTo(HaveOccurred())
+		for id, msg := range map[string]string{
+			"existing":  "output already exists",
+			"unmatched": "checkpoint not found in ComfyUI: no match",
+			"other":     "something else",
+		} {
+			_, err = db.Exec(`
+				INSERT INTO sample_job_items (
+					id, job_id, checkpoint_filename, comfyui_model_path, prompt_name, prompt_text,
+					steps, cfg, sampler_name, scheduler, seed, status, error_message, created_at, updated_at
+				) VALUES (?, 'test-job', 'model.safetensors', '', 'p', 'p', 20, 7.5, 'euler', 'normal', 1, 'skipped', ?,
+					'2025-01-01T00:00:00Z', '2025-01-01T00:00:00Z')
+			`, id, msg)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(store.Migrate(db, store.AllMigrations())).To(Succeed())
+
+		reasons := map[string]string{}
+		rows, err := db.Query("SELECT id, skip_reason FROM sample_job_items")
+		Expect(err).NotTo(HaveOccurred())
+		defer rows.Close()
+		for rows.Next() {
+			var id, reason string
+			Expect(rows.Scan(&id, &reason)).To(Succeed())
+			reasons[id] = reason
+		}
+		Expect(reasons).To(Equal(map[string]string{"existing": "duplicate", "unmatched": "checkpoint_not_found", "other": ""}))
+	})
 })
 
 var _ = Describe("AllMigrations", func() {
 	It("returns the presets table as migration 1", func() {
 		migrations := store.AllMigrations()
-		Expect(migrations).To(HaveLen(20))
+		Expect(migrations).To(HaveLen(53))
 		Expect(migrations[0].Version).To(Equal(1))
 		Expect(migrations[0].SQL).To(ContainSubstring("CREATE TABLE"))
 		Expect(migrations[0].SQL).To(ContainSubstring("presets"))
@@ -606,7 +679,7 @@ var _ = Describe("ResetDB", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		// Verify all application tables exist
-		tables := []string{"presets", "studies", "sample_jobs", "sample_job_items", "schema_migrations"}
+		tables := []string{"presets", "studies", "sample_jobs", "sample_job_items", "sample_job_parameters", "pins", "process_leases", "galleries", "training_run_configs", "assets", "asset_uploads", "schema_migrations"}
 		for _, t := range tables {
 			var name string
 			err := s.DB().QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name=?", t).Scan(&name)
@@ -6,7 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 	"github.com/sirupsen/logrus"
 )
 
@@ -74,19 +76,44 @@ func (fs *FileSystem) DirectoryExists(path string) bool {
 	return err == nil && info.IsDir()
 }
 
+// DirModTime returns the modification time of the directory at path.
+func (fs *FileSystem) DirModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !info.IsDir() {
+		return time.Time{}, fmt.Errorf("%s is not a directory", path)
+	}
+	return info.ModTime(), nil
+}
+
+// StatFile returns the size and modification time of the regular file at path.
+func (fs *FileSystem) StatFile(path string) (int64, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if info.IsDir() {
+		return 0, time.Time{}, fmt.Errorf("%s is a directory", path)
+	}
+	return info.Size(), info.ModTime(), nil
+}
+
 // FileExists reports whether the given path exists and is a regular file.
 func (fs *FileSystem) FileExists(path string) bool {
 	info, err := os.Stat(path)
 	return err == nil && !info.IsDir()
 }
 
-// ListPNGFiles returns the names of .png files in the given directory.
-// Only regular files with a .png extension (case-insensitive) are returned.
-func (fs *FileSystem) ListPNGFiles(dir string) ([]string, error) {
-	fs.logger.WithField("directory", dir).Trace("entering ListPNGFiles")
-	defer fs.logger.Trace("returning from ListPNGFiles")
+// ListImageFiles returns the names of sample image files in the given directory.
+// Only regular files with a .png, .webp, .jpg or .jpeg extension
+// (case-insensitive) are returned.
+func (fs *FileSystem) ListImageFiles(dir string) ([]string, error) {
+	fs.logger.WithField("directory", dir).Trace("entering ListImageFiles")
+	defer fs.logger.Trace("returning from ListImageFiles")
 
-	fs.logger.WithField("directory", dir).Debug("reading directory for PNG files")
+	fs.logger.WithField("directory", dir).Debug("reading directory for image files")
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		fields := logrus.Fields{
@@ -94,7 +121,7 @@ func (fs *FileSystem) ListPNGFiles(dir string) ([]string, error) {
 			"error":     err.Error(),
 		}
 		if os.IsNotExist(err) {
-			fs.logger.WithFields(fields).Debug("directory not found, no PNG files")
+			fs.logger.WithFields(fields).Debug("directory not found, no image files")
 		} else {
 			fs.logger.WithFields(fields).Error("failed to read directory")
 		}
@@ -106,14 +133,112 @@ func (fs *FileSystem) ListPNGFiles(dir string) ([]string, error) {
 		if entry.IsDir() {
 			continue
 		}
-		if strings.EqualFold(filepath.Ext(entry.Name()), ".png") {
+		if model.IsSampleImageFile(entry.Name()) {
 			files = append(files, entry.Name())
 		}
 	}
 	fs.logger.WithFields(logrus.Fields{
 		"directory":  dir,
 		"file_count": len(files),
-	}).Debug("PNG files listed")
+	}).Debug("image files listed")
+	return files, nil
+}
+
+// ListImageFilesRecursive recursively scans root for sample image files and returns
+// their paths relative to root, using forward slashes.
+func (fs *FileSystem) ListImageFilesRecursive(root string) ([]string, error) {
+	fs.logger.WithField("root", root).Trace("entering ListImageFilesRecursive")
+	defer fs.logger.Trace("returning from ListImageFilesRecursive")
+
+	var files []string
+
+	fs.logger.WithField("root", root).Debug("scanning for image files")
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		if model.IsSampleImageFile(path) {
+			relPath, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(relPath))
+		}
+		return nil
+	})
+	if err != nil {
+		fields := logrus.Fields{
+			"root":  root,
+			"error": err.Error(),
+		}
+		if os.IsNotExist(err) {
+			fs.logger.WithFields(fields).Debug("directory not found, no image files")
+		} else {
+			fs.logger.WithFields(fields).Error("failed to scan for image files")
+		}
+		return nil, fmt.Errorf("scanning for image files: %w", err)
+	}
+
+	fs.logger.WithFields(logrus.Fields{
+		"root":       root,
+		"file_count": len(files),
+	}).Debug("image files listed recursively")
+	return files, nil
+}
+
+// ListFileUsage recursively lists the regular files under root with their
+// size and modification time. Paths are relative to root, using forward
+// slashes. Returns an empty slice (not an error) if root does not exist.
+func (fs *FileSystem) ListFileUsage(root string) ([]model.FileUsage, error) {
+	fs.logger.WithField("root", root).Trace("entering ListFileUsage")
+	defer fs.logger.Trace("returning from ListFileUsage")
+
+	files := []model.FileUsage{}
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Removed while walking
+				return nil
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, model.FileUsage{
+			Path:    filepath.ToSlash(relPath),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		fs.logger.WithFields(logrus.Fields{
+			"root":  root,
+			"error": err.Error(),
+		}).Error("failed to scan for file usage")
+		return nil, fmt.Errorf("scanning file usage of %s: %w", root, err)
+	}
+
+	fs.logger.WithFields(logrus.Fields{
+		"root":       root,
+		"file_count": len(files),
+	}).Debug("file usage listed")
 	return files, nil
 }
 
@@ -172,6 +297,83 @@ func (fs *FileSystem) RemoveSampleDir(sampleDir string, checkpointFilename strin
 	return nil
 }
 
+// RemoveDirectory removes path and everything under it. If the directory
+// does not exist, this is a no-op (not an error).
+func (fs *FileSystem) RemoveDirectory(path string) error {
+	fs.logger.WithField("path", path).Trace("entering RemoveDirectory")
+	defer fs.logger.Trace("returning from RemoveDirectory")
+
+	if err := os.RemoveAll(path); err != nil {
+		fs.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to remove directory")
+		return fmt.Errorf("removing directory %s: %w", path, err)
+	}
+	fs.logger.WithField("path", path).Info("directory removed")
+	return nil
+}
+
+// RemoveFile removes a single file. If the file does not exist, this is a
+// no-op (not an error).
+func (fs *FileSystem) RemoveFile(path string) error {
+	fs.logger.WithField("path", path).Trace("entering RemoveFile")
+	defer fs.logger.Trace("returning from RemoveFile")
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		fs.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to remove file")
+		return fmt.Errorf("removing file %s: %w", path, err)
+	}
+	return nil
+}
+
+// CopyFile copies the file at src to dst, creating dst's directory. The copy
+// is written under a temporary name and renamed into place, so that dst
+// either does not exist or is complete.
+func (fs *FileSystem) CopyFile(src, dst string) error {
+	fs.logger.WithFields(logrus.Fields{
+		"src": src,
+		"dst": dst,
+	}).Trace("entering CopyFile")
+	defer fs.logger.Trace("returning from CopyFile")
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", src, err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", dst, err)
+	}
+	tmp := dst + ".partial"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", tmp, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("copying %s to %s: %w", src, dst, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("renaming %s to %s: %w", tmp, dst, err)
+	}
+	fs.logger.WithFields(logrus.Fields{
+		"src": src,
+		"dst": dst,
+	}).Debug("copied file")
+	return nil
+}
+
 // CheckpointSampleDirRemover implements service.SampleDirRemover by removing per-checkpoint
 // sample directories under a configured sample root directory.
 type CheckpointSampleDirRemover struct {
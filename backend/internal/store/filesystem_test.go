@@ -4,6 +4,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -33,7 +34,7 @@ var _ = Describe("FileSystem", func() {
 		os.RemoveAll(tmpDir)
 	})
 
-	Describe("ListPNGFiles", func() {
+	Describe("ListImageFiles", func() {
 		It("returns only .png files, ignoring .json sidecar files", func() {
 			// Write a mix of PNG and JSON files
 			Expect(os.WriteFile(filepath.Join(tmpDir, "image1.png"), []byte("png"), 0644)).To(Succeed())
@@ -42,7 +43,7 @@ var _ = Describe("FileSystem", func() {
 			Expect(os.WriteFile(filepath.Join(tmpDir, "image2.json"), []byte(`{}`), 0644)).To(Succeed())
 			Expect(os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("text"), 0644)).To(Succeed())
 
-			files, err := fs.ListPNGFiles(tmpDir)
+			files, err := fs.ListImageFiles(tmpDir)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(files).To(HaveLen(2))
@@ -51,22 +52,32 @@ var _ = Describe("FileSystem", func() {
 			}
 		})
 
+		It("returns WebP and JPEG images alongside PNG files", func() {
+			for _, name := range []string{"a.png", "b.webp", "c.jpg", "d.JPEG", "d.json"} {
+				Expect(os.WriteFile(filepath.Join(tmpDir, name), []byte("img"), 0644)).To(Succeed())
+			}
+
+			files, err := fs.ListImageFiles(tmpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(ConsistOf("a.png", "b.webp", "c.jpg", "d.JPEG"))
+		})
+
 		It("returns empty list when directory contains only .json files", func() {
 			Expect(os.WriteFile(filepath.Join(tmpDir, "image.json"), []byte(`{}`), 0644)).To(Succeed())
 
-			files, err := fs.ListPNGFiles(tmpDir)
+			files, err := fs.ListImageFiles(tmpDir)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(files).To(BeEmpty())
 		})
 
 		It("returns empty list for empty directory", func() {
-			files, err := fs.ListPNGFiles(tmpDir)
+			files, err := fs.ListImageFiles(tmpDir)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(files).To(BeEmpty())
 		})
 
 		It("returns error when directory does not exist", func() {
-			_, err := fs.ListPNGFiles(filepath.Join(tmpDir, "nonexistent"))
+			_, err := fs.ListImageFiles(filepath.Join(tmpDir, "nonexistent"))
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -83,7 +94,7 @@ var _ = Describe("FileSystem", func() {
 
 			It("logs at debug level (not error) when directory does not exist", func() {
 				lc.Reset()
-				_, _ = fsHook.ListPNGFiles(filepath.Join(tmpDir, "nonexistent"))
+				_, _ = fsHook.ListImageFiles(filepath.Join(tmpDir, "nonexistent"))
 
 				Expect(lc.EntriesAtLevel(logrus.ErrorLevel)).To(BeEmpty(), "expected no error-level log entries for a missing directory")
 				Expect(lc.EntriesAtLevel(logrus.DebugLevel)).NotTo(BeEmpty(), "expected at least one debug-level log entry for a missing directory")
@@ -91,6 +102,115 @@ var _ = Describe("FileSystem", func() {
 		})
 	})
 
+	Describe("ListImageFilesRecursive", func() {
+		It("returns PNG files in nested directories relative to the root", func() {
+			nested := filepath.Join(tmpDir, "study", "model.safetensors")
+			Expect(os.MkdirAll(nested, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(tmpDir, "top.png"), []byte("png"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(nested, "seed=1.png"), []byte("png"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(nested, "seed=1.json"), []byte(`{}`), 0644)).To(Succeed())
+
+			files, err := fs.ListImageFilesRecursive(tmpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(ConsistOf("top.png", "study/model.safetensors/seed=1.png"))
+		})
+
+		It("returns error when root directory does not exist", func() {
+			_, err := fs.ListImageFilesRecursive(filepath.Join(tmpDir, "nonexistent"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ListFileUsage", func() {
+		It("returns every regular file with its size and modification time", func() {
+			nested := filepath.Join(tmpDir, "run", "study", "model.safetensors")
+			Expect(os.MkdirAll(filepath.Join(nested, ".thumbnails"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(nested, "seed=1.png"), []byte("pngdata"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(nested, ".thumbnails", "seed=1.jpg"), []byte("jpg"), 0644)).To(Succeed())
+			mtime := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+			Expect(os.Chtimes(filepath.Join(nested, "seed=1.png"), mtime, mtime)).To(Succeed())
+
+			files, err := fs.ListFileUsage(tmpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(2))
+			Expect(files[0].Path).To(Equal("run/study/model.safetensors/.thumbnails/seed=1.jpg"))
+			Expect(files[0].Size).To(Equal(int64(3)))
+			Expect(files[1].Path).To(Equal("run/study/model.safetensors/seed=1.png"))
+			Expect(files[1].Size).To(Equal(int64(7)))
+			Expect(files[1].ModTime.Equal(mtime)).To(BeTrue())
+		})
+
+		It("returns an empty list when the root does not exist", func() {
+			files, err := fs.ListFileUsage(filepath.Join(tmpDir, "nonexistent"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(BeEmpty())
+		})
+	})
+
+	Describe("RemoveDirectory", func() {
+		It("removes a directory tree and ignores a missing one", func() {
+			nested := filepath.Join(tmpDir, "run", "study")
+			Expect(os.MkdirAll(nested, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(nested, "a.png"), []byte("png"), 0644)).To(Succeed())
+
+			Expect(fs.RemoveDirectory(filepath.Join(tmpDir, "run"))).To(Succeed())
+			Expect(filepath.Join(tmpDir, "run")).NotTo(BeADirectory())
+			Expect(fs.RemoveDirectory(filepath.Join(tmpDir, "run"))).To(Succeed())
+		})
+	})
+
+	Describe("RemoveFile", func() {
+		It("removes a file and ignores a missing one", func() {
+			file := filepath.Join(tmpDir, "a.png")
+			Expect(os.WriteFile(file, []byte("png"), 0644)).To(Succeed())
+
+			Expect(fs.RemoveFile(file)).To(Succeed())
+			Expect(file).NotTo(BeAnExistingFile())
+			Expect(fs.RemoveFile(file)).To(Succeed())
+		})
+	})
+
+	Describe("CopyFile", func() {
+		It("copies a file into a new directory", func() {
+			src := filepath.Join(tmpDir, "model.safetensors")
+			Expect(os.WriteFile(src, []byte("weights"), 0644)).To(Succeed())
+			dst := filepath.Join(tmpDir, "staging", "model.safetensors")
+
+			Expect(fs.CopyFile(src, dst)).To(Succeed())
+			data, err := os.ReadFile(dst)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("weights"))
+			Expect(dst + ".partial").NotTo(BeAnExistingFile())
+		})
+
+		It("returns error when the source does not exist", func() {
+			dst := filepath.Join(tmpDir, "staging", "model.safetensors")
+			Expect(fs.CopyFile(filepath.Join(tmpDir, "missing.safetensors"), dst)).NotTo(Succeed())
+			Expect(dst).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Describe("DirModTime", func() {
+		It("returns the modification time of a directory", func() {
+			mtime := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+			Expect(os.Chtimes(tmpDir, mtime, mtime)).To(Succeed())
+
+			got, err := fs.DirModTime(tmpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Equal(mtime)).To(BeTrue())
+		})
+
+		It("returns error for a file or a missing directory", func() {
+			file := filepath.Join(tmpDir, "a.png")
+			Expect(os.WriteFile(file, []byte("png"), 0644)).To(Succeed())
+
+			_, err := fs.DirModTime(file)
+			Expect(err).To(HaveOccurred())
+			_, err = fs.DirModTime(filepath.Join(tmpDir, "missing"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("ListSafetensorsFiles", func() {
 		Context("log level for directory-not-found", func() {
 			var (
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -80,8 +81,8 @@ func NewFixtureSeeder(store *Store, sampleDir string, logger *logrus.Logger) *Fi
 // It is idempotent: if the fixture study already exists in the database,
 // seeding is skipped to prevent silent state duplication if the cleaner
 // fails mid-reset.
-func (s *FixtureSeeder) SeedFixtures() error {
-	_, err := s.store.GetStudy(E2EFixtureStudyID)
+func (s *FixtureSeeder) SeedFixtures(ctx context.Context) error {
+	_, err := s.store.GetStudy(ctx, E2EFixtureStudyID)
 	if err == nil {
 		s.logger.WithField("study_id", E2EFixtureStudyID).Info("fixture data already present, skipping re-seeding")
 		return nil
@@ -92,7 +93,7 @@ func (s *FixtureSeeder) SeedFixtures() error {
 
 	s.logger.Info("seeding E2E fixture study and sample directories")
 
-	if err := s.seedFixtureStudy(); err != nil {
+	if err := s.seedFixtureStudy(ctx); err != nil {
 		return fmt.Errorf("seeding fixture study: %w", err)
 	}
 
@@ -100,7 +101,7 @@ func (s *FixtureSeeder) SeedFixtures() error {
 		return fmt.Errorf("seeding fixture sample directories: %w", err)
 	}
 
-	if err := s.seedSlashFixtureStudy(); err != nil {
+	if err := s.seedSlashFixtureStudy(ctx); err != nil {
 		return fmt.Errorf("seeding slash fixture study: %w", err)
 	}
 
@@ -114,7 +115,7 @@ func (s *FixtureSeeder) SeedFixtures() error {
 
 // seedFixtureStudy inserts the fixture study into the database.
 // The study has 2 prompts × 1 step × 1 cfg × 1 sampler/scheduler pair × 1 seed = 2 images/checkpoint.
-func (s *FixtureSeeder) seedFixtureStudy() error {
+func (s *FixtureSeeder) seedFixtureStudy(ctx context.Context) error {
 	now := time.Now().UTC()
 	study := model.Study{
 		ID:   E2EFixtureStudyID,
@@ -136,7 +137,7 @@ func (s *FixtureSeeder) seedFixtureStudy() error {
 		UpdatedAt:             now,
 	}
 
-	if err := s.store.CreateStudy(study); err != nil {
+	if err := s.store.CreateStudy(ctx, study); err != nil {
 		s.logger.WithError(err).Error("failed to seed fixture study")
 		return fmt.Errorf("creating fixture study: %w", err)
 	}
@@ -180,7 +181,7 @@ func (s *FixtureSeeder) seedFixtureSampleDirs() error {
 // seedSlashFixtureStudy inserts the slash-fixture study into the database.
 // This study is associated with the "test-run/my-model" training run (which has
 // a slash in its name) and exercises the B-088 slash sanitization code path.
-func (s *FixtureSeeder) seedSlashFixtureStudy() error {
+func (s *FixtureSeeder) seedSlashFixtureStudy(ctx context.Context) error {
 	now := time.Now().UTC()
 	study := model.Study{
 		ID:   E2ESlashFixtureStudyID,
@@ -202,7 +203,7 @@ func (s *FixtureSeeder) seedSlashFixtureStudy() error {
 		UpdatedAt:             now,
 	}
 
-	if err := s.store.CreateStudy(study); err != nil {
+	if err := s.store.CreateStudy(ctx, study); err != nil {
 		s.logger.WithError(err).Error("failed to seed slash fixture study")
 		return fmt.Errorf("creating slash fixture study: %w", err)
 	}
@@ -0,0 +1,249 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// galleryEntity is the persistence representation of a gallery.
+type galleryEntity struct {
+	ID              string
+	Slug            string
+	Title           string
+	TrainingRunName string
+	StudyName       string
+	Filters         string // JSON object of dimension name -> values
+	Dimensions      string // JSON array of galleryDimensionJSON
+	Images          string // JSON array of galleryImageJSON
+	CreatedAt       string // RFC3339
+}
+
+// galleryDimensionJSON is the JSON representation of a model.Dimension.
+type galleryDimensionJSON struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Values []string `json:"values"`
+}
+
+// galleryImageJSON is the JSON representation of a model.GalleryImage.
+type galleryImageJSON struct {
+	RelativePath string            `json:"relative_path"`
+	Dimensions   map[string]string `json:"dimensions"`
+}
+
+const gallerySelectColumns = `id, slug, title, training_run_name, study_name, filters, dimensions, images, created_at`
+
+// ListGalleries returns all galleries, newest first.
+func (s *Store) ListGalleries(ctx context.Context) ([]model.Gallery, error) {
+	s.logger.Trace("entering ListGalleries")
+	defer s.logger.Trace("returning from ListGalleries")
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+gallerySelectColumns+" FROM galleries ORDER BY created_at DESC, id")
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query galleries")
+		return nil, fmt.Errorf("querying galleries: %w", err)
+	}
+	defer rows.Close()
+
+	var galleries []model.Gallery
+	for rows.Next() {
+		var e galleryEntity
+		if err := rows.Scan(&e.ID, &e.Slug, &e.Title, &e.TrainingRunName, &e.StudyName, &e.Filters, &e.Dimensions, &e.Images, &e.CreatedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan gallery row")
+			return nil, fmt.Errorf("scanning gallery row: %w", err)
+		}
+		g, err := galleryEntityToModel(e)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to convert entity to model")
+			return nil, err
+		}
+		galleries = append(galleries, g)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating galleries")
+		return nil, fmt.Errorf("iterating galleries: %w", err)
+	}
+	s.logger.WithField("gallery_count", len(galleries)).Debug("listed galleries from database")
+	return galleries, nil
+}
+
+// GetGallery returns a gallery by ID, or sql.ErrNoRows if not found.
+func (s *Store) GetGallery(ctx context.Context, id string) (model.Gallery, error) {
+	s.logger.WithField("gallery_id", id).Trace("entering GetGallery")
+	defer s.logger.Trace("returning from GetGallery")
+
+	return s.getGalleryWhere(ctx, "id", id)
+}
+
+// GetGalleryBySlug returns a gallery by its public slug, or sql.ErrNoRows if
+// not found.
+func (s *Store) GetGalleryBySlug(ctx context.Context, slug string) (model.Gallery, error) {
+	s.logger.WithField("slug", slug).Trace("entering GetGalleryBySlug")
+	defer s.logger.Trace("returning from GetGalleryBySlug")
+
+	return s.getGalleryWhere(ctx, "slug", slug)
+}
+
+// getGalleryWhere fetches a single gallery by the given unique column.
+func (s *Store) getGalleryWhere(ctx context.Context, column, value string) (model.Gallery, error) {
+	var e galleryEntity
+	err := s.db.QueryRowContext(ctx,
+		"SELECT "+gallerySelectColumns+" FROM galleries WHERE "+column+" = ?", value,
+	).Scan(&e.ID, &e.Slug, &e.Title, &e.TrainingRunName, &e.StudyName, &e.Filters, &e.Dimensions, &e.Images, &e.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.WithField(column, value).Debug("gallery not found in database")
+		} else {
+			s.logger.WithFields(logrus.Fields{
+				column:  value,
+				"error": err.Error(),
+			}).Error("failed to query gallery")
+		}
+		return model.Gallery{}, err
+	}
+	s.logger.WithField("gallery_id", e.ID).Debug("fetched gallery from database")
+	return galleryEntityToModel(e)
+}
+
+// CreateGallery inserts a new gallery.
+func (s *Store) CreateGallery(ctx context.Context, g model.Gallery) error {
+	s.logger.WithFields(logrus.Fields{
+		"gallery_id": g.ID,
+		"slug":       g.Slug,
+	}).Trace("entering CreateGallery")
+	defer s.logger.Trace("returning from CreateGallery")
+
+	e, err := galleryModelToEntity(g)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to convert model to entity")
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO galleries (`+gallerySelectColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ID, e.Slug, e.Title, e.TrainingRunName, e.StudyName, e.Filters, e.Dimensions, e.Images, e.CreatedAt,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"gallery_id": g.ID,
+			"error":      err.Error(),
+		}).Error("failed to insert gallery into database")
+		return fmt.Errorf("inserting gallery: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"gallery_id":  g.ID,
+		"slug":        g.Slug,
+		"image_count": len(g.Images),
+	}).Info("inserted gallery into database")
+	return nil
+}
+
+// DeleteGallery removes a gallery by ID. Returns sql.ErrNoRows if not found.
+func (s *Store) DeleteGallery(ctx context.Context, id string) error {
+	s.logger.WithField("gallery_id", id).Trace("entering DeleteGallery")
+	defer s.logger.Trace("returning from DeleteGallery")
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM galleries WHERE id = ?", id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"gallery_id": id,
+			"error":      err.Error(),
+		}).Error("failed to delete gallery from database")
+		return fmt.Errorf("deleting gallery: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"gallery_id": id,
+			"error":      err.Error(),
+		}).Error("failed to check rows affected")
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("gallery_id", id).Debug("gallery not found for deletion")
+		return sql.ErrNoRows
+	}
+	s.logger.WithField("gallery_id", id).Info("deleted gallery from database")
+	return nil
+}
+
+func galleryEntityToModel(e galleryEntity) (model.Gallery, error) {
+	var filters map[string][]string
+	if err := json.Unmarshal([]byte(e.Filters), &filters); err != nil {
+		return model.Gallery{}, fmt.Errorf("unmarshaling filters: %w", err)
+	}
+	var dims []galleryDimensionJSON
+	if err := json.Unmarshal([]byte(e.Dimensions), &dims); err != nil {
+		return model.Gallery{}, fmt.Errorf("unmarshaling dimensions: %w", err)
+	}
+	var images []galleryImageJSON
+	if err := json.Unmarshal([]byte(e.Images), &images); err != nil {
+		return model.Gallery{}, fmt.Errorf("unmarshaling images: %w", err)
+	}
+	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
+	if err != nil {
+		return model.Gallery{}, fmt.Errorf("parsing created_at: %w", err)
+	}
+
+	g := model.Gallery{
+		ID:              e.ID,
+		Slug:            e.Slug,
+		Title:           e.Title,
+		TrainingRunName: e.TrainingRunName,
+		StudyName:       e.StudyName,
+		Filters:         filters,
+		Dimensions:      make([]model.Dimension, len(dims)),
+		Images:          make([]model.GalleryImage, len(images)),
+		CreatedAt:       createdAt,
+	}
+	for i, d := range dims {
+		g.Dimensions[i] = model.Dimension{Name: d.Name, Type: model.DimensionType(d.Type), Values: d.Values}
+	}
+	for i, img := range images {
+		g.Images[i] = model.GalleryImage{RelativePath: img.RelativePath, Dimensions: img.Dimensions}
+	}
+	return g, nil
+}
+
+func galleryModelToEntity(g model.Gallery) (galleryEntity, error) {
+	filters := g.Filters
+	if filters == nil {
+		filters = map[string][]string{}
+	}
+	filtersJSON, err := json.Marshal(filters)
+	if err != nil {
+		return galleryEntity{}, fmt.Errorf("marshaling filters: %w", err)
+	}
+	dims := make([]galleryDimensionJSON, len(g.Dimensions))
+	for i, d := range g.Dimensions {
+		dims[i] = galleryDimensionJSON{Name: d.Name, Type: string(d.Type), Values: d.Values}
+	}
+	dimsJSON, err := json.Marshal(dims)
+	if err != nil {
+		return galleryEntity{}, fmt.Errorf("marshaling dimensions: %w", err)
+	}
+	images := make([]galleryImageJSON, len(g.Images))
+	for i, img := range g.Images {
+		images[i] = galleryImageJSON{RelativePath: img.RelativePath, Dimensions: img.Dimensions}
+	}
+	imagesJSON, err := json.Marshal(images)
+	if err != nil {
+		return galleryEntity{}, fmt.Errorf("marshaling images: %w", err)
+	}
+	return galleryEntity{
+		ID:              g.ID,
+		Slug:            g.Slug,
+		Title:           g.Title,
+		TrainingRunName: g.TrainingRunName,
+		StudyName:       g.StudyName,
+		Filters:         string(filtersJSON),
+		Dimensions:      string(dimsJSON),
+		Images:          string(imagesJSON),
+		CreatedAt:       g.CreatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
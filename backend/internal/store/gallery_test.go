@@ -0,0 +1,114 @@
+package store_test
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("GalleryStore", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "gallery-store-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newGallery := func(id, slug string, createdAt time.Time) model.Gallery {
+		return model.Gallery{
+			ID:              id,
+			Slug:            slug,
+			Title:           "My LoRA",
+			TrainingRunName: "my-lora",
+			StudyName:       "study-a",
+			Filters:         map[string][]string{"seed": {"42"}},
+			Dimensions: []model.Dimension{
+				{Name: "checkpoint", Type: model.DimensionTypeString, Values: []string{"a.safetensors", "b.safetensors"}},
+			},
+			Images: []model.GalleryImage{
+				{RelativePath: "my-lora/study-a/a.safetensors/seed=42&_00001_.png", Dimensions: map[string]string{"checkpoint": "a.safetensors"}},
+				{RelativePath: "my-lora/study-a/b.safetensors/seed=42&_00001_.png", Dimensions: map[string]string{"checkpoint": "b.safetensors"}},
+			},
+			CreatedAt: createdAt,
+		}
+	}
+
+	It("returns an empty list when nothing is published", func() {
+		galleries, err := st.ListGalleries(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(galleries).To(BeEmpty())
+	})
+
+	It("round-trips the snapshot through create and get", func() {
+		g := newGallery("g-1", "slug-1", now)
+		Expect(st.CreateGallery(ctx, g)).To(Succeed())
+
+		got, err := st.GetGallery(ctx, "g-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(g))
+
+		bySlug, err := st.GetGalleryBySlug(ctx, "slug-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bySlug.ID).To(Equal("g-1"))
+	})
+
+	It("lists galleries newest first", func() {
+		Expect(st.CreateGallery(ctx, newGallery("g-1", "slug-1", now))).To(Succeed())
+		Expect(st.CreateGallery(ctx, newGallery("g-2", "slug-2", now.Add(time.Hour)))).To(Succeed())
+
+		galleries, err := st.ListGalleries(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(galleries).To(HaveLen(2))
+		Expect(galleries[0].ID).To(Equal("g-2"))
+		Expect(galleries[1].ID).To(Equal("g-1"))
+	})
+
+	It("rejects a duplicate slug", func() {
+		Expect(st.CreateGallery(ctx, newGallery("g-1", "slug-1", now))).To(Succeed())
+		Expect(st.CreateGallery(ctx, newGallery("g-2", "slug-1", now))).NotTo(Succeed())
+	})
+
+	It("returns sql.ErrNoRows for an unknown slug", func() {
+		_, err := st.GetGalleryBySlug(ctx, "missing")
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+
+	It("deletes a gallery", func() {
+		Expect(st.CreateGallery(ctx, newGallery("g-1", "slug-1", now))).To(Succeed())
+		Expect(st.DeleteGallery(ctx, "g-1")).To(Succeed())
+		Expect(st.DeleteGallery(ctx, "g-1")).To(Equal(sql.ErrNoRows))
+
+		_, err := st.GetGalleryBySlug(ctx, "slug-1")
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+})
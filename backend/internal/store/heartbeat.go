@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// HeartbeatFile is a heartbeat target that updates the modification time of
+// a file, creating it if it does not exist.
+type HeartbeatFile struct {
+	path string
+}
+
+// NewHeartbeatFile creates a HeartbeatFile for the file at path.
+func NewHeartbeatFile(path string) *HeartbeatFile {
+	return &HeartbeatFile{path: path}
+}
+
+// Beat touches the heartbeat file.
+func (f *HeartbeatFile) Beat(ctx context.Context) error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening heartbeat file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("closing heartbeat file: %w", err)
+	}
+	now := time.Now()
+	if err := os.Chtimes(f.path, now, now); err != nil {
+		return fmt.Errorf("touching heartbeat file: %w", err)
+	}
+	return nil
+}
+
+// HeartbeatURL is a heartbeat target that requests a URL with GET, as
+// expected by healthchecks.io-style cron monitors.
+type HeartbeatURL struct {
+	url    string
+	client *http.Client
+}
+
+// NewHeartbeatURL creates a HeartbeatURL for rawURL.
+func NewHeartbeatURL(rawURL string) *HeartbeatURL {
+	return &HeartbeatURL{
+		url: rawURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Beat requests the heartbeat URL. Any 2xx status is a success. Errors
+// name only the host, since monitoring URLs usually embed a secret check ID.
+func (u *HeartbeatURL) Beat(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.url, nil)
+	if err != nil {
+		return fmt.Errorf("creating heartbeat request: %w", err)
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("heartbeat request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("heartbeat request to %s failed with status %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
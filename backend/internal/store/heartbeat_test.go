@@ -0,0 +1,85 @@
+package store_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("HeartbeatFile", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "heartbeat")
+	})
+
+	It("creates the file if it does not exist", func() {
+		Expect(store.NewHeartbeatFile(path).Beat(context.Background())).To(Succeed())
+		Expect(path).To(BeAnExistingFile())
+	})
+
+	It("updates the modification time of an existing file", func() {
+		Expect(os.WriteFile(path, []byte("keep"), 0644)).To(Succeed())
+		old := time.Now().Add(-time.Hour)
+		Expect(os.Chtimes(path, old, old)).To(Succeed())
+
+		Expect(store.NewHeartbeatFile(path).Beat(context.Background())).To(Succeed())
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ModTime()).To(BeTemporally(">", old.Add(30*time.Minute)))
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("keep"))
+	})
+
+	It("returns an error when the directory does not exist", func() {
+		missing := filepath.Join(filepath.Dir(path), "missing", "heartbeat")
+		Expect(store.NewHeartbeatFile(missing).Beat(context.Background())).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("HeartbeatURL", func() {
+	It("requests the URL with GET", func() {
+		var method, requestPath string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, requestPath = r.Method, r.URL.Path
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		Expect(store.NewHeartbeatURL(server.URL + "/ping/abc").Beat(context.Background())).To(Succeed())
+		Expect(method).To(Equal(http.MethodGet))
+		Expect(requestPath).To(Equal("/ping/abc"))
+	})
+
+	It("returns an error without the URL path on a non-2xx status", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		err := store.NewHeartbeatURL(server.URL + "/ping/secret-check-id").Beat(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("status 404"))
+		Expect(err.Error()).NotTo(ContainSubstring("secret-check-id"))
+	})
+
+	It("returns an error without the URL path when the request fails", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		url := server.URL + "/ping/secret-check-id"
+		server.Close()
+
+		err := store.NewHeartbeatURL(url).Beat(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).NotTo(ContainSubstring("secret-check-id"))
+	})
+})
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// imageAnnotationEntity is the persistence representation of an image
+// annotation.
+type imageAnnotationEntity struct {
+	Path      string
+	Rating    int
+	Tags      string // JSON array
+	UpdatedAt string // RFC3339
+}
+
+// ListImageAnnotations returns the annotations matching filter ordered by path.
+func (s *Store) ListImageAnnotations(ctx context.Context, filter model.ImageAnnotationFilter) ([]model.ImageAnnotation, error) {
+	s.logger.WithFields(logrus.Fields{
+		"path_prefix": filter.PathPrefix,
+		"min_rating":  filter.MinRating,
+		"tag":         filter.Tag,
+	}).Trace("entering ListImageAnnotations")
+	defer s.logger.Trace("returning from ListImageAnnotations")
+
+	var conds []string
+	var args []any
+	if filter.PathPrefix != "" {
+		// A range rather than LIKE, so that '%' and '_' in query-encoded
+		// filenames need no escaping; '0' is the byte after '/'.
+		dir := strings.TrimSuffix(filter.PathPrefix, "/")
+		conds = append(conds, "path >= ? AND path < ?")
+		args = append(args, dir+"/", dir+"0")
+	}
+	if filter.MinRating > 0 {
+		conds = append(conds, "rating >= ?")
+		args = append(args, filter.MinRating)
+	}
+	if filter.Tag != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM json_each(image_annotations.tags) WHERE value = ?)")
+		args = append(args, filter.Tag)
+	}
+	query := "SELECT path, rating, tags, updated_at FROM image_annotations"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY path"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query image annotations")
+		return nil, fmt.Errorf("querying image annotations: %w", err)
+	}
+	defer rows.Close()
+
+	var annotations []model.ImageAnnotation
+	for rows.Next() {
+		var e imageAnnotationEntity
+		if err := rows.Scan(&e.Path, &e.Rating, &e.Tags, &e.UpdatedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan image annotation row")
+			return nil, fmt.Errorf("scanning image annotation row: %w", err)
+		}
+		a, err := imageAnnotationEntityToModel(e)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to convert entity to model")
+			return nil, err
+		}
+		annotations = append(annotations, a)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating image annotations")
+		return nil, fmt.Errorf("iterating image annotations: %w", err)
+	}
+	s.logger.WithField("annotation_count", len(annotations)).Debug("listed image annotations from database")
+	return annotations, nil
+}
+
+// GetImageAnnotation returns the annotation of the image at path. Returns
+// sql.ErrNoRows if the image is not annotated.
+func (s *Store) GetImageAnnotation(ctx context.Context, path string) (model.ImageAnnotation, error) {
+	s.logger.WithField("path", path).Trace("entering GetImageAnnotation")
+	defer s.logger.Trace("returning from GetImageAnnotation")
+
+	var e imageAnnotationEntity
+	err := s.db.QueryRowContext(ctx, "SELECT path, rating, tags, updated_at FROM image_annotations WHERE path = ?", path).
+		Scan(&e.Path, &e.Rating, &e.Tags, &e.UpdatedAt)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("path", path).Debug("image annotation not found in database")
+		return model.ImageAnnotation{}, sql.ErrNoRows
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to query image annotation")
+		return model.ImageAnnotation{}, fmt.Errorf("querying image annotation: %w", err)
+	}
+	return imageAnnotationEntityToModel(e)
+}
+
+// SaveImageAnnotations inserts or replaces the given annotations in one
+// transaction. Empty annotations (no rating and no tags) are deleted instead,
+// so that clearing an image's annotation leaves no row behind.
+func (s *Store) SaveImageAnnotations(ctx context.Context, annotations []model.ImageAnnotation) error {
+	s.logger.WithField("annotation_count", len(annotations)).Trace("entering SaveImageAnnotations")
+	defer s.logger.Trace("returning from SaveImageAnnotations")
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to begin image annotation transaction")
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, a := range annotations {
+		if a.IsEmpty() {
+			if _, err := tx.Exec("DELETE FROM image_annotations WHERE path = ?", a.Path); err != nil {
+				s.logger.WithFields(logrus.Fields{
+					"path":  a.Path,
+					"error": err.Error(),
+				}).Error("failed to delete image annotation from database")
+				return fmt.Errorf("deleting image annotation: %w", err)
+			}
+			continue
+		}
+		e, err := imageAnnotationModelToEntity(a)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO image_annotations (path, rating, tags, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(path) DO UPDATE SET rating = excluded.rating, tags = excluded.tags, updated_at = excluded.updated_at`,
+			e.Path, e.Rating, e.Tags, e.UpdatedAt,
+		); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"path":  a.Path,
+				"error": err.Error(),
+			}).Error("failed to upsert image annotation into database")
+			return fmt.Errorf("saving image annotation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.WithError(err).Error("failed to commit image annotation transaction")
+		return fmt.Errorf("committing image annotations: %w", err)
+	}
+	s.logger.WithField("annotation_count", len(annotations)).Info("saved image annotations to database")
+	return nil
+}
+
+// DeleteImageAnnotation removes the annotation of the image at path. Returns
+// sql.ErrNoRows if the image is not annotated.
+func (s *Store) DeleteImageAnnotation(ctx context.Context, path string) error {
+	s.logger.WithField("path", path).Trace("entering DeleteImageAnnotation")
+	defer s.logger.Trace("returning from DeleteImageAnnotation")
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM image_annotations WHERE path = ?", path)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to delete image annotation from database")
+		return fmt.Errorf("deleting image annotation: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to check rows affected")
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("path", path).Debug("image annotation not found for deletion")
+		return sql.ErrNoRows
+	}
+	s.logger.WithField("path", path).Info("deleted image annotation from database")
+	return nil
+}
+
+// GetSampleJobItemOutputPath returns the absolute output path of a sample job
+// item, which is empty until the item completes. Returns sql.ErrNoRows if no
+// item has the given ID.
+func (s *Store) GetSampleJobItemOutputPath(ctx context.Context, itemID string) (string, error) {
+	s.logger.WithField("sample_job_item_id", itemID).Trace("entering GetSampleJobItemOutputPath")
+	defer s.logger.Trace("returning from GetSampleJobItemOutputPath")
+
+	var outputPath sql.NullString
+	err := s.db.QueryRowContext(ctx, "SELECT output_path FROM sample_job_items WHERE id = ?", itemID).Scan(&outputPath)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("sample_job_item_id", itemID).Debug("sample job item not found in database")
+		return "", sql.ErrNoRows
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_item_id": itemID,
+			"error":              err.Error(),
+		}).Error("failed to query sample job item output path")
+		return "", fmt.Errorf("querying sample job item output path: %w", err)
+	}
+	return outputPath.String, nil
+}
+
+func imageAnnotationEntityToModel(e imageAnnotationEntity) (model.ImageAnnotation, error) {
+	var tags []string
+	if err := json.Unmarshal([]byte(e.Tags), &tags); err != nil {
+		return model.ImageAnnotation{}, fmt.Errorf("parsing tags: %w", err)
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	updatedAt, err := time.Parse(time.RFC3339, e.UpdatedAt)
+	if err != nil {
+		return model.ImageAnnotation{}, fmt.Errorf("parsing updated_at: %w", err)
+	}
+	return model.ImageAnnotation{
+		Path:      e.Path,
+		Rating:    e.Rating,
+		Tags:      tags,
+		UpdatedAt: updatedAt,
+	}, nil
+}
+
+func imageAnnotationModelToEntity(a model.ImageAnnotation) (imageAnnotationEntity, error) {
+	tags := a.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return imageAnnotationEntity{}, fmt.Errorf("marshaling tags: %w", err)
+	}
+	return imageAnnotationEntity{
+		Path:      a.Path,
+		Rating:    a.Rating,
+		Tags:      string(tagsJSON),
+		UpdatedAt: a.UpdatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,149 @@
+package store_test
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("ImageAnnotationStore", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "image-annotation-store-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	paths := func(annotations []model.ImageAnnotation) []string {
+		var result []string
+		for _, a := range annotations {
+			result = append(result, a.Path)
+		}
+		return result
+	}
+
+	It("saves, gets, and replaces an annotation", func() {
+		Expect(st.SaveImageAnnotations(ctx, []model.ImageAnnotation{
+			{Path: "run/study/a.safetensors/x.png", Rating: 4, Tags: []string{"keeper"}, UpdatedAt: now},
+		})).To(Succeed())
+
+		a, err := st.GetImageAnnotation(ctx, "run/study/a.safetensors/x.png")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.Rating).To(Equal(4))
+		Expect(a.Tags).To(Equal([]string{"keeper"}))
+		Expect(a.UpdatedAt).To(Equal(now))
+
+		Expect(st.SaveImageAnnotations(ctx, []model.ImageAnnotation{
+			{Path: "run/study/a.safetensors/x.png", Rating: 2, Tags: []string{}, UpdatedAt: now.Add(time.Hour)},
+		})).To(Succeed())
+
+		a, err = st.GetImageAnnotation(ctx, "run/study/a.safetensors/x.png")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.Rating).To(Equal(2))
+		Expect(a.Tags).To(BeEmpty())
+		Expect(a.UpdatedAt).To(Equal(now.Add(time.Hour)))
+	})
+
+	It("returns sql.ErrNoRows for an image without an annotation", func() {
+		_, err := st.GetImageAnnotation(ctx, "missing.png")
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+
+	It("deletes the row when an annotation is saved empty", func() {
+		Expect(st.SaveImageAnnotations(ctx, []model.ImageAnnotation{
+			{Path: "x.png", Rating: 3, UpdatedAt: now},
+		})).To(Succeed())
+		Expect(st.SaveImageAnnotations(ctx, []model.ImageAnnotation{
+			{Path: "x.png", UpdatedAt: now},
+		})).To(Succeed())
+
+		_, err := st.GetImageAnnotation(ctx, "x.png")
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+
+	Describe("ListImageAnnotations", func() {
+		BeforeEach(func() {
+			Expect(st.SaveImageAnnotations(ctx, []model.ImageAnnotation{
+				{Path: "run/study/b.safetensors/index=0&cfg=7_.png", Rating: 5, Tags: []string{"keeper"}, UpdatedAt: now},
+				{Path: "run/study/a.safetensors/x.png", Rating: 2, Tags: []string{"hands", "reject"}, UpdatedAt: now},
+				{Path: "run-2/study/a.safetensors/x.png", Rating: 4, Tags: []string{"keeper"}, UpdatedAt: now},
+			})).To(Succeed())
+		})
+
+		It("lists all annotations ordered by path", func() {
+			annotations, err := st.ListImageAnnotations(ctx, model.ImageAnnotationFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths(annotations)).To(Equal([]string{
+				"run-2/study/a.safetensors/x.png",
+				"run/study/a.safetensors/x.png",
+				"run/study/b.safetensors/index=0&cfg=7_.png",
+			}))
+		})
+
+		It("filters by directory without matching sibling directories sharing the prefix", func() {
+			annotations, err := st.ListImageAnnotations(ctx, model.ImageAnnotationFilter{PathPrefix: "run"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths(annotations)).To(Equal([]string{
+				"run/study/a.safetensors/x.png",
+				"run/study/b.safetensors/index=0&cfg=7_.png",
+			}))
+		})
+
+		It("filters by minimum rating and tag", func() {
+			annotations, err := st.ListImageAnnotations(ctx, model.ImageAnnotationFilter{MinRating: 4, Tag: "keeper"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths(annotations)).To(Equal([]string{
+				"run-2/study/a.safetensors/x.png",
+				"run/study/b.safetensors/index=0&cfg=7_.png",
+			}))
+
+			annotations, err = st.ListImageAnnotations(ctx, model.ImageAnnotationFilter{Tag: "hands"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths(annotations)).To(Equal([]string{"run/study/a.safetensors/x.png"}))
+		})
+	})
+
+	It("deletes an annotation and returns sql.ErrNoRows for a missing one", func() {
+		Expect(st.SaveImageAnnotations(ctx, []model.ImageAnnotation{
+			{Path: "x.png", Rating: 1, UpdatedAt: now},
+		})).To(Succeed())
+
+		Expect(st.DeleteImageAnnotation(ctx, "x.png")).To(Succeed())
+		Expect(st.DeleteImageAnnotation(ctx, "x.png")).To(Equal(sql.ErrNoRows))
+	})
+
+	It("returns sql.ErrNoRows for the output path of a missing sample job item", func() {
+		_, err := st.GetSampleJobItemOutputPath(ctx, "missing")
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+})
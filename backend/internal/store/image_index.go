@@ -0,0 +1,269 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// GetIndexedImageDir returns the image index entry of dir, with filenames in
+// ascending order. Returns sql.ErrNoRows if dir has not been indexed.
+func (s *Store) GetIndexedImageDir(ctx context.Context, dir string) (model.IndexedImageDir, error) {
+	s.logger.WithField("dir", dir).Trace("entering GetIndexedImageDir")
+	defer s.logger.Trace("returning from GetIndexedImageDir")
+
+	// A single statement so that the directory and its images are read from
+	// one snapshot.
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT d.mod_time, d.indexed_at, i.filename
+		FROM image_dirs d LEFT JOIN images i ON i.dir = d.dir
+		WHERE d.dir = ?
+		ORDER BY i.filename`,
+		dir,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   dir,
+			"error": err.Error(),
+		}).Error("failed to query indexed image directory")
+		return model.IndexedImageDir{}, fmt.Errorf("querying indexed image directory: %w", err)
+	}
+	defer rows.Close()
+
+	d := model.IndexedImageDir{Dir: dir, Filenames: []string{}}
+	found := false
+	for rows.Next() {
+		var modTime, indexedAt string
+		var filename sql.NullString
+		if err := rows.Scan(&modTime, &indexedAt, &filename); err != nil {
+			s.logger.WithError(err).Error("failed to scan indexed image row")
+			return model.IndexedImageDir{}, fmt.Errorf("scanning indexed image row: %w", err)
+		}
+		if !found {
+			if d.ModTime, err = time.Parse(time.RFC3339Nano, modTime); err != nil {
+				return model.IndexedImageDir{}, fmt.Errorf("parsing mod_time: %w", err)
+			}
+			if d.IndexedAt, err = time.Parse(time.RFC3339Nano, indexedAt); err != nil {
+				return model.IndexedImageDir{}, fmt.Errorf("parsing indexed_at: %w", err)
+			}
+			found = true
+		}
+		if filename.Valid {
+			d.Filenames = append(d.Filenames, filename.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating indexed images")
+		return model.IndexedImageDir{}, fmt.Errorf("iterating indexed images: %w", err)
+	}
+	if !found {
+		s.logger.WithField("dir", dir).Debug("image directory not indexed")
+		return model.IndexedImageDir{}, sql.ErrNoRows
+	}
+	s.logger.WithFields(logrus.Fields{
+		"dir":         dir,
+		"image_count": len(d.Filenames),
+	}).Debug("read indexed image directory")
+	return d, nil
+}
+
+// ReplaceIndexedImageDir records d in the image index, replacing the images
+// previously indexed for its directory.
+func (s *Store) ReplaceIndexedImageDir(ctx context.Context, d model.IndexedImageDir) error {
+	s.logger.WithFields(logrus.Fields{
+		"dir":         d.Dir,
+		"image_count": len(d.Filenames),
+	}).Trace("entering ReplaceIndexedImageDir")
+	defer s.logger.Trace("returning from ReplaceIndexedImageDir")
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   d.Dir,
+			"error": err.Error(),
+		}).Error("failed to begin image index transaction")
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO image_dirs (dir, mod_time, indexed_at) VALUES (?, ?, ?)
+		ON CONFLICT(dir) DO UPDATE SET mod_time = excluded.mod_time, indexed_at = excluded.indexed_at`,
+		d.Dir, formatIndexTime(d.ModTime), formatIndexTime(d.IndexedAt),
+	); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   d.Dir,
+			"error": err.Error(),
+		}).Error("failed to upsert indexed image directory")
+		return fmt.Errorf("upserting indexed image directory: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM images WHERE dir = ?", d.Dir); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   d.Dir,
+			"error": err.Error(),
+		}).Error("failed to delete indexed images")
+		return fmt.Errorf("deleting indexed images: %w", err)
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO images (dir, filename) VALUES (?, ?)")
+	if err != nil {
+		s.logger.WithError(err).Error("failed to prepare indexed image insert")
+		return fmt.Errorf("preparing indexed image insert: %w", err)
+	}
+	defer stmt.Close()
+	for _, filename := range d.Filenames {
+		if _, err := stmt.Exec(d.Dir, filename); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"dir":      d.Dir,
+				"filename": filename,
+				"error":    err.Error(),
+			}).Error("failed to insert indexed image")
+			return fmt.Errorf("inserting indexed image %s: %w", filename, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   d.Dir,
+			"error": err.Error(),
+		}).Error("failed to commit image index transaction")
+		return fmt.Errorf("committing indexed image directory: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"dir":         d.Dir,
+		"image_count": len(d.Filenames),
+	}).Debug("indexed image directory")
+	return nil
+}
+
+// AddIndexedImage adds filename to the indexed images of dir and records the
+// directory's new modification time. Returns sql.ErrNoRows if dir has not
+// been indexed.
+func (s *Store) AddIndexedImage(ctx context.Context, dir, filename string, modTime, indexedAt time.Time) error {
+	return s.updateIndexedImage(ctx, dir, filename, modTime, indexedAt,
+		"INSERT INTO images (dir, filename) VALUES (?, ?) ON CONFLICT(dir, filename) DO NOTHING")
+}
+
+// RemoveIndexedImage removes filename from the indexed images of dir and
+// records the directory's new modification time. Returns sql.ErrNoRows if
+// dir has not been indexed.
+func (s *Store) RemoveIndexedImage(ctx context.Context, dir, filename string, modTime, indexedAt time.Time) error {
+	return s.updateIndexedImage(ctx, dir, filename, modTime, indexedAt,
+		"DELETE FROM images WHERE dir = ? AND filename = ?")
+}
+
+// updateIndexedImage runs imageSQL, which takes dir and filename, and
+// updates the modification time of dir in one transaction.
+func (s *Store) updateIndexedImage(ctx context.Context, dir, filename string, modTime, indexedAt time.Time, imageSQL string) error {
+	s.logger.WithFields(logrus.Fields{
+		"dir":      dir,
+		"filename": filename,
+	}).Trace("entering updateIndexedImage")
+	defer s.logger.Trace("returning from updateIndexedImage")
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   dir,
+			"error": err.Error(),
+		}).Error("failed to begin image index transaction")
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"UPDATE image_dirs SET mod_time = ?, indexed_at = ? WHERE dir = ?",
+		formatIndexTime(modTime), formatIndexTime(indexedAt), dir,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   dir,
+			"error": err.Error(),
+		}).Error("failed to update indexed image directory")
+		return fmt.Errorf("updating indexed image directory: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   dir,
+			"error": err.Error(),
+		}).Error("failed to check rows affected")
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("dir", dir).Debug("image directory not indexed")
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec(imageSQL, dir, filename); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":      dir,
+			"filename": filename,
+			"error":    err.Error(),
+		}).Error("failed to update indexed image")
+		return fmt.Errorf("updating indexed image %s: %w", filename, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   dir,
+			"error": err.Error(),
+		}).Error("failed to commit image index transaction")
+		return fmt.Errorf("committing indexed image: %w", err)
+	}
+	return nil
+}
+
+// ListIndexedImageDirs returns the indexed directories in ascending order.
+func (s *Store) ListIndexedImageDirs(ctx context.Context) ([]string, error) {
+	s.logger.Trace("entering ListIndexedImageDirs")
+	defer s.logger.Trace("returning from ListIndexedImageDirs")
+
+	rows, err := s.db.QueryContext(ctx, "SELECT dir FROM image_dirs ORDER BY dir")
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query indexed image directories")
+		return nil, fmt.Errorf("querying indexed image directories: %w", err)
+	}
+	defer rows.Close()
+
+	var dirs []string
+	for rows.Next() {
+		var dir string
+		if err := rows.Scan(&dir); err != nil {
+			s.logger.WithError(err).Error("failed to scan indexed image directory row")
+			return nil, fmt.Errorf("scanning indexed image directory row: %w", err)
+		}
+		dirs = append(dirs, dir)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating indexed image directories")
+		return nil, fmt.Errorf("iterating indexed image directories: %w", err)
+	}
+	return dirs, nil
+}
+
+// DeleteIndexedImageDir removes dir and its images from the image index.
+// Deleting a directory that is not indexed is a no-op.
+func (s *Store) DeleteIndexedImageDir(ctx context.Context, dir string) error {
+	s.logger.WithField("dir", dir).Trace("entering DeleteIndexedImageDir")
+	defer s.logger.Trace("returning from DeleteIndexedImageDir")
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM image_dirs WHERE dir = ?", dir); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"dir":   dir,
+			"error": err.Error(),
+		}).Error("failed to delete indexed image directory")
+		return fmt.Errorf("deleting indexed image directory: %w", err)
+	}
+	s.logger.WithField("dir", dir).Debug("deleted indexed image directory")
+	return nil
+}
+
+// formatIndexTime formats an image index timestamp. Modification times are
+// compared for equality, so they keep their full precision.
+func formatIndexTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
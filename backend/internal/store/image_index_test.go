@@ -0,0 +1,144 @@
+package store_test
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("ImageIndexStore", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "image-index-store-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	// A modification time with sub-second precision, which must survive the
+	// round trip since it is compared for equality.
+	modTime := time.Date(2025, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	indexedAt := modTime.Add(time.Minute)
+
+	indexDir := func(dir string, filenames ...string) {
+		Expect(st.ReplaceIndexedImageDir(ctx, model.IndexedImageDir{
+			Dir:       dir,
+			ModTime:   modTime,
+			IndexedAt: indexedAt,
+			Filenames: filenames,
+		})).To(Succeed())
+	}
+
+	It("returns sql.ErrNoRows for a directory that has not been indexed", func() {
+		_, err := st.GetIndexedImageDir(ctx, "study/cp.safetensors")
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+
+	It("round-trips an indexed directory with sorted filenames", func() {
+		indexDir("study/cp.safetensors", "b.png", "a.png")
+
+		d, err := st.GetIndexedImageDir(ctx, "study/cp.safetensors")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.Dir).To(Equal("study/cp.safetensors"))
+		Expect(d.ModTime.Equal(modTime)).To(BeTrue())
+		Expect(d.IndexedAt.Equal(indexedAt)).To(BeTrue())
+		Expect(d.Filenames).To(Equal([]string{"a.png", "b.png"}))
+	})
+
+	It("returns an empty listing for an indexed directory without images", func() {
+		indexDir("study")
+
+		d, err := st.GetIndexedImageDir(ctx, "study")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.Filenames).To(BeEmpty())
+	})
+
+	It("replaces the images of a directory indexed again", func() {
+		indexDir("study/cp.safetensors", "a.png", "b.png")
+		indexDir("study/cp.safetensors", "c.png")
+
+		d, err := st.GetIndexedImageDir(ctx, "study/cp.safetensors")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.Filenames).To(Equal([]string{"c.png"}))
+	})
+
+	Describe("AddIndexedImage and RemoveIndexedImage", func() {
+		newModTime := modTime.Add(time.Second)
+
+		It("adds an image and records the new modification time", func() {
+			indexDir("study/cp.safetensors", "a.png")
+
+			Expect(st.AddIndexedImage(ctx, "study/cp.safetensors", "b.png", newModTime, newModTime)).To(Succeed())
+			Expect(st.AddIndexedImage(ctx, "study/cp.safetensors", "b.png", newModTime, newModTime)).To(Succeed())
+
+			d, err := st.GetIndexedImageDir(ctx, "study/cp.safetensors")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d.Filenames).To(Equal([]string{"a.png", "b.png"}))
+			Expect(d.ModTime.Equal(newModTime)).To(BeTrue())
+		})
+
+		It("removes an image", func() {
+			indexDir("study/cp.safetensors", "a.png", "b.png")
+
+			Expect(st.RemoveIndexedImage(ctx, "study/cp.safetensors", "a.png", newModTime, newModTime)).To(Succeed())
+
+			d, err := st.GetIndexedImageDir(ctx, "study/cp.safetensors")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d.Filenames).To(Equal([]string{"b.png"}))
+		})
+
+		It("returns sql.ErrNoRows for a directory that has not been indexed", func() {
+			err := st.AddIndexedImage(ctx, "study/cp.safetensors", "a.png", newModTime, newModTime)
+			Expect(err).To(Equal(sql.ErrNoRows))
+
+			_, err = st.GetIndexedImageDir(ctx, "study/cp.safetensors")
+			Expect(err).To(Equal(sql.ErrNoRows))
+		})
+	})
+
+	It("lists and deletes indexed directories together with their images", func() {
+		indexDir("b", "x.png")
+		indexDir("a", "y.png")
+
+		dirs, err := st.ListIndexedImageDirs(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dirs).To(Equal([]string{"a", "b"}))
+
+		Expect(st.DeleteIndexedImageDir(ctx, "b")).To(Succeed())
+		Expect(st.DeleteIndexedImageDir(ctx, "missing")).To(Succeed())
+
+		_, err = st.GetIndexedImageDir(ctx, "b")
+		Expect(err).To(Equal(sql.ErrNoRows))
+		indexDir("b")
+		d, err := st.GetIndexedImageDir(ctx, "b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.Filenames).To(BeEmpty())
+	})
+})
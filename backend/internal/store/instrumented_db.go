@@ -0,0 +1,247 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// DefaultSlowQueryThreshold is the duration above which a statement is
+// logged as slow.
+const DefaultSlowQueryThreshold = 100 * time.Millisecond
+
+// maxTrackedStatements bounds the number of distinct statements tracked
+// individually. Statements built with a variable number of placeholders
+// would otherwise grow the table without bound; later ones are tracked
+// together under otherStatementsKey.
+const maxTrackedStatements = 200
+
+// otherStatementsKey groups statements beyond maxTrackedStatements.
+const otherStatementsKey = "(other statements)"
+
+// instrumentedDB wraps a *sql.DB, recording the count and duration of each
+// statement and logging statements slower than the slow query threshold.
+// For Query, the duration covers running the statement up to the first row,
+// not iterating over the result.
+type instrumentedDB struct {
+	db     *sql.DB
+	logger *logrus.Entry
+	now    func() time.Time
+
+	mu            sync.Mutex
+	slowThreshold time.Duration
+	queries       int64
+	errors        int64
+	slowQueries   int64
+	totalDuration time.Duration
+	statements    map[string]*model.DBStatementStats
+}
+
+func newInstrumentedDB(db *sql.DB, logger *logrus.Entry) *instrumentedDB {
+	return &instrumentedDB{
+		db:            db,
+		logger:        logger,
+		now:           time.Now,
+		slowThreshold: DefaultSlowQueryThreshold,
+		statements:    make(map[string]*model.DBStatementStats),
+	}
+}
+
+func (d *instrumentedDB) Exec(query string, args ...any) (sql.Result, error) {
+	return d.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext is Exec, canceled when ctx is done.
+func (d *instrumentedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := d.now()
+	result, err := d.db.ExecContext(ctx, query, args...)
+	d.record(query, start, err)
+	return result, err
+}
+
+func (d *instrumentedDB) Query(query string, args ...any) (*sql.Rows, error) {
+	return d.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext is Query, canceled when ctx is done.
+func (d *instrumentedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := d.now()
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	d.record(query, start, err)
+	return rows, err
+}
+
+func (d *instrumentedDB) QueryRow(query string, args ...any) *sql.Row {
+	return d.QueryRowContext(context.Background(), query, args...)
+}
+
+// QueryRowContext is QueryRow, canceled when ctx is done.
+func (d *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	start := d.now()
+	row := d.db.QueryRowContext(ctx, query, args...)
+	err := row.Err()
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	d.record(query, start, err)
+	return row
+}
+
+func (d *instrumentedDB) Close() error {
+	return d.db.Close()
+}
+
+// Begin starts a transaction whose statements are recorded like those run
+// directly on the database.
+func (d *instrumentedDB) Begin() (*instrumentedTx, error) {
+	return d.BeginTx(context.Background())
+}
+
+// BeginTx is Begin for a transaction whose statements run under ctx. The
+// transaction is rolled back if ctx is done before it is committed.
+func (d *instrumentedDB) BeginTx(ctx context.Context) (*instrumentedTx, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedTx{tx: tx, d: d, ctx: ctx}, nil
+}
+
+// instrumentedTx wraps a *sql.Tx, recording its statements in the
+// instrumentedDB that began it.
+type instrumentedTx struct {
+	tx  *sql.Tx
+	d   *instrumentedDB
+	ctx context.Context
+}
+
+func (t *instrumentedTx) Exec(query string, args ...any) (sql.Result, error) {
+	start := t.d.now()
+	result, err := t.tx.ExecContext(t.ctx, query, args...)
+	t.d.record(query, start, err)
+	return result, err
+}
+
+// Prepare returns a prepared statement whose executions are each recorded
+// under query.
+func (t *instrumentedTx) Prepare(query string) (*instrumentedStmt, error) {
+	stmt, err := t.tx.PrepareContext(t.ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{stmt: stmt, query: query, d: t.d, ctx: t.ctx}, nil
+}
+
+func (t *instrumentedTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *instrumentedTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// instrumentedStmt wraps a *sql.Stmt prepared within an instrumentedTx; it
+// runs under the transaction's context.
+type instrumentedStmt struct {
+	stmt  *sql.Stmt
+	query string
+	d     *instrumentedDB
+	ctx   context.Context
+}
+
+func (s *instrumentedStmt) Exec(args ...any) (sql.Result, error) {
+	start := s.d.now()
+	result, err := s.stmt.ExecContext(s.ctx, args...)
+	s.d.record(s.query, start, err)
+	return result, err
+}
+
+func (s *instrumentedStmt) Close() error {
+	return s.stmt.Close()
+}
+
+// record adds a statement's outcome to the totals and logs it if slow.
+func (d *instrumentedDB) record(query string, start time.Time, err error) {
+	elapsed := d.now().Sub(start)
+	key := normalizeQuery(query)
+
+	d.mu.Lock()
+	d.queries++
+	d.totalDuration += elapsed
+	st, ok := d.statements[key]
+	if !ok {
+		if len(d.statements) >= maxTrackedStatements {
+			key = otherStatementsKey
+			st = d.statements[key]
+		}
+		if st == nil {
+			st = &model.DBStatementStats{Query: key}
+			d.statements[key] = st
+		}
+	}
+	st.Count++
+	st.TotalDuration += elapsed
+	if elapsed > st.MaxDuration {
+		st.MaxDuration = elapsed
+	}
+	if err != nil {
+		d.errors++
+		st.Errors++
+	}
+	slow := elapsed >= d.slowThreshold
+	if slow {
+		d.slowQueries++
+	}
+	d.mu.Unlock()
+
+	if slow {
+		d.logger.WithFields(logrus.Fields{
+			"query":       key,
+			"duration_ms": elapsed.Milliseconds(),
+		}).Warn("slow database query")
+	}
+}
+
+// stats returns the recorded totals together with the connection pool stats.
+func (d *instrumentedDB) stats() model.DBStats {
+	pool := d.db.Stats()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	statements := make([]model.DBStatementStats, 0, len(d.statements))
+	for _, st := range d.statements {
+		statements = append(statements, *st)
+	}
+	sort.Slice(statements, func(i, j int) bool {
+		if statements[i].TotalDuration != statements[j].TotalDuration {
+			return statements[i].TotalDuration > statements[j].TotalDuration
+		}
+		return statements[i].Query < statements[j].Query
+	})
+	return model.DBStats{
+		Queries:            d.queries,
+		Errors:             d.errors,
+		TotalDuration:      d.totalDuration,
+		SlowQueries:        d.slowQueries,
+		SlowQueryThreshold: d.slowThreshold,
+		OpenConnections:    pool.OpenConnections,
+		InUse:              pool.InUse,
+		Idle:               pool.Idle,
+		WaitCount:          pool.WaitCount,
+		WaitDuration:       pool.WaitDuration,
+		Statements:         statements,
+	}
+}
+
+// normalizeQuery collapses runs of whitespace in query so that statements
+// differing only in formatting are tracked together.
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
@@ -0,0 +1,124 @@
+package store_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/testutil"
+)
+
+var _ = Describe("Store DB stats", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+		logs   *testutil.LogCapture
+	)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "db-stats-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logs = testutil.NewLogCapture()
+		st, err = store.New(db, logs.Logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	It("starts with no queries recorded", func() {
+		stats := st.DBStats()
+		Expect(stats.Queries).To(BeZero())
+		Expect(stats.Statements).To(BeEmpty())
+		Expect(stats.SlowQueryThreshold).To(Equal(store.DefaultSlowQueryThreshold))
+	})
+
+	It("counts queries per statement", func() {
+		Expect(st.CreatePin(ctx, model.Pin{Path: "a.png", Kind: model.PinKindImage, CreatedAt: now})).To(Succeed())
+		Expect(st.CreatePin(ctx, model.Pin{Path: "b.png", Kind: model.PinKindImage, CreatedAt: now})).To(Succeed())
+		_, err := st.ListPins(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		stats := st.DBStats()
+		Expect(stats.Queries).To(Equal(int64(3)))
+		Expect(stats.Errors).To(BeZero())
+		Expect(stats.Statements).To(HaveLen(2))
+		counts := map[int64]int{}
+		for _, s := range stats.Statements {
+			Expect(s.Query).NotTo(ContainSubstring("\n"))
+			Expect(s.MaxDuration).To(BeNumerically("<=", s.TotalDuration))
+			counts[s.Count]++
+		}
+		Expect(counts).To(Equal(map[int64]int{1: 1, 2: 1}))
+	})
+
+	It("counts failed statements", func() {
+		err := st.CreateSampleJobItem(ctx, model.SampleJobItem{ID: "item-1", JobID: "missing-job", CreatedAt: now, UpdatedAt: now})
+		Expect(err).To(HaveOccurred())
+
+		stats := st.DBStats()
+		Expect(stats.Errors).To(Equal(int64(1)))
+		Expect(stats.Statements).To(HaveLen(1))
+		Expect(stats.Statements[0].Query).To(HavePrefix("INSERT INTO sample_job_items"))
+		Expect(stats.Statements[0].Errors).To(Equal(int64(1)))
+	})
+
+	It("counts statements run in a transaction", func() {
+		Expect(st.CreateStudy(ctx, model.Study{ID: "study-1", Name: "Study", CreatedAt: now, UpdatedAt: now})).To(Succeed())
+		job := model.SampleJob{ID: "job-1", TrainingRunName: "run", StudyID: "study-1", Status: model.SampleJobStatusPending, CreatedAt: now, UpdatedAt: now}
+		items := []model.SampleJobItem{
+			{ID: "item-1", JobID: "job-1", Status: model.SampleJobItemStatusPending, CreatedAt: now, UpdatedAt: now},
+			{ID: "item-2", JobID: "job-1", Status: model.SampleJobItemStatusPending, CreatedAt: now, UpdatedAt: now},
+		}
+		Expect(st.CreateSampleJobWithItems(ctx, job, items)).To(Succeed())
+
+		counts := map[string]int64{}
+		for _, s := range st.DBStats().Statements {
+			if strings.HasPrefix(s.Query, "INSERT INTO sample_job") {
+				counts[strings.Fields(s.Query)[2]] = s.Count
+			}
+		}
+		Expect(counts).To(Equal(map[string]int64{"sample_jobs": 1, "sample_job_items": 2}))
+	})
+
+	It("logs statements slower than the threshold as warnings", func() {
+		st.SetSlowQueryThreshold(time.Nanosecond)
+		_, err := st.ListPins(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		stats := st.DBStats()
+		Expect(stats.SlowQueries).To(Equal(int64(1)))
+		warnings := logs.EntriesAtLevel(logrus.WarnLevel)
+		Expect(warnings).To(HaveLen(1))
+		Expect(warnings[0].Message).To(Equal("slow database query"))
+		Expect(warnings[0].Data["query"]).To(HavePrefix("SELECT"))
+		Expect(warnings[0].Data).To(HaveKey("duration_ms"))
+	})
+
+	It("does not log statements faster than the threshold", func() {
+		st.SetSlowQueryThreshold(time.Hour)
+		_, err := st.ListPins(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(st.DBStats().SlowQueries).To(BeZero())
+		Expect(logs.EntriesAtLevel(logrus.WarnLevel)).To(BeEmpty())
+	})
+})
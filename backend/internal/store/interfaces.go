@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// PresetStore persists dimension mapping presets. Methods taking an ID
+// return sql.ErrNoRows if no preset has that ID.
+type PresetStore interface {
+	ListPresets(ctx context.Context) ([]model.Preset, error)
+	GetPreset(ctx context.Context, id string) (model.Preset, error)
+	CreatePreset(ctx context.Context, p model.Preset) error
+	UpdatePreset(ctx context.Context, p model.Preset) error
+	DeletePreset(ctx context.Context, id string) error
+}
+
+// StudyStore persists studies (formerly sample presets) and their archived
+// versions. Study names are unique. Methods taking an ID return sql.ErrNoRows
+// if no study has that ID; deleting a study deletes its versions and sample
+// jobs.
+type StudyStore interface {
+	ListStudies(ctx context.Context) ([]model.Study, error)
+	GetStudy(ctx context.Context, id string) (model.Study, error)
+	GetStudyByName(ctx context.Context, name string, excludeID string) (model.Study, error)
+	CreateStudy(ctx context.Context, st model.Study) error
+	UpdateStudy(ctx context.Context, st model.Study) error
+	DeleteStudy(ctx context.Context, id string) error
+
+	ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error)
+	CreateStudyVersion(ctx context.Context, v model.StudyVersion) error
+	StudyVersionInUse(ctx context.Context, studyID string, version int) (bool, error)
+}
+
+// SampleJobStore persists sample jobs and their items. A job must reference
+// an existing study and an item an existing job. Methods taking an ID return
+// sql.ErrNoRows if no job or item has that ID; deleting a job deletes its
+// items and parameter snapshot.
+type SampleJobStore interface {
+	ListSampleJobs(ctx context.Context) ([]model.SampleJob, error)
+	ListSampleJobsDesc(ctx context.Context, filter model.SampleJobFilter, page model.Page) ([]model.SampleJob, error)
+	CountSampleJobs(ctx context.Context, filter model.SampleJobFilter) (int, error)
+	HasRunningJob(ctx context.Context) (bool, error)
+	GetSampleJob(ctx context.Context, id string) (model.SampleJob, error)
+	CreateSampleJob(ctx context.Context, j model.SampleJob) error
+	CreateSampleJobWithItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error
+	CreateStudyWithSampleJob(ctx context.Context, st model.Study, j model.SampleJob, items []model.SampleJobItem) error
+	UpdateSampleJob(ctx context.Context, j *model.SampleJob) error
+	TransitionSampleJob(ctx context.Context, j *model.SampleJob, from model.SampleJobStatus) error
+	AppendSampleJobItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error
+	DeleteSampleJob(ctx context.Context, id string) error
+	SeedSampleJobs(ctx context.Context, jobs []model.SampleJob) error
+
+	ListTrashedSampleJobs(ctx context.Context, scope model.OwnerScope) ([]model.SampleJob, error)
+	GetTrashedSampleJob(ctx context.Context, id string) (model.SampleJob, error)
+	TrashSampleJob(ctx context.Context, id string, deletedAt time.Time, deleteData bool) error
+	RestoreSampleJob(ctx context.Context, id string) error
+
+	ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error)
+	ListSampleJobItemsPage(ctx context.Context, jobID string, query model.SampleJobItemQuery, page model.Page) ([]model.SampleJobItem, error)
+	CountSampleJobItems(ctx context.Context, jobID string, filter model.SampleJobItemFilter) (int, error)
+	CountSampleJobItemsByStatus(ctx context.Context, jobID string) (model.ItemStatusCounts, error)
+	CreateSampleJobItem(ctx context.Context, i model.SampleJobItem) error
+	UpdateSampleJobItem(ctx context.Context, i *model.SampleJobItem) error
+	AverageItemDuration(ctx context.Context, limit int) (avg time.Duration, ok bool, err error)
+	SummarizeTrainingRunJobs(ctx context.Context, trainingRunName string) (model.TrainingRunJobStats, error)
+
+	GetSampleJobParameters(ctx context.Context, jobID string) (model.SampleJobParameters, error)
+	CreateSampleJobParameters(ctx context.Context, p model.SampleJobParameters) error
+}
+
+// ErrSampleJobStatusChanged is returned by TransitionSampleJob when the job's
+// stored status is not the one the transition starts from.
+var ErrSampleJobStatusChanged = errors.New("sample job status changed")
+
+// ErrVersionConflict is returned when a sample job or item was updated after
+// the version being saved was read. Callers re-read it and retry.
+var ErrVersionConflict = errors.New("version conflict")
+
+var (
+	_ PresetStore    = (*Store)(nil)
+	_ StudyStore     = (*Store)(nil)
+	_ SampleJobStore = (*Store)(nil)
+
+	_ PresetStore    = (*MemoryStore)(nil)
+	_ StudyStore     = (*MemoryStore)(nil)
+	_ SampleJobStore = (*MemoryStore)(nil)
+)
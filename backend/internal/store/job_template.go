@@ -0,0 +1,249 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// jobTemplateEntity is the persistence representation of a job template.
+type jobTemplateEntity struct {
+	ID                   string
+	Name                 string
+	StudyID              string
+	CheckpointFilter     string
+	ClearExisting        bool
+	MissingOnly          bool
+	SkipExisting         bool
+	AppendNewCheckpoints bool
+	OutputFormat         string
+	OutputQuality        int
+	VAE                  string
+	CLIP                 string
+	Shift                sql.NullFloat64
+	ControlNetModel      string
+	ControlNetStrength   sql.NullFloat64
+	ControlNetImage      string
+	CreatedAt            string // RFC3339
+	UpdatedAt            string // RFC3339
+}
+
+const jobTemplateSelectColumns = `id, name, study_id, checkpoint_filter, clear_existing, missing_only, skip_existing, append_new_checkpoints, output_format, output_quality, vae, clip, shift, controlnet_model, controlnet_strength, controlnet_image, created_at, updated_at`
+
+// scanJobTemplate scans a row of jobTemplateSelectColumns.
+func scanJobTemplate(row interface{ Scan(...any) error }) (jobTemplateEntity, error) {
+	var e jobTemplateEntity
+	err := row.Scan(&e.ID, &e.Name, &e.StudyID, &e.CheckpointFilter, &e.ClearExisting, &e.MissingOnly, &e.SkipExisting, &e.AppendNewCheckpoints, &e.OutputFormat, &e.OutputQuality, &e.VAE, &e.CLIP, &e.Shift, &e.ControlNetModel, &e.ControlNetStrength, &e.ControlNetImage, &e.CreatedAt, &e.UpdatedAt)
+	return e, err
+}
+
+// ListJobTemplates returns all job templates ordered by name.
+func (s *Store) ListJobTemplates(ctx context.Context) ([]model.JobTemplate, error) {
+	s.logger.Trace("entering ListJobTemplates")
+	defer s.logger.Trace("returning from ListJobTemplates")
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+jobTemplateSelectColumns+" FROM job_templates ORDER BY name, created_at, id")
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query job templates")
+		return nil, fmt.Errorf("querying job templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []model.JobTemplate
+	for rows.Next() {
+		e, err := scanJobTemplate(rows)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to scan job template row")
+			return nil, fmt.Errorf("scanning job template row: %w", err)
+		}
+		t, err := jobTemplateEntityToModel(e)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating job templates")
+		return nil, fmt.Errorf("iterating job templates: %w", err)
+	}
+	s.logger.WithField("job_template_count", len(templates)).Debug("listed job templates from database")
+	return templates, nil
+}
+
+// GetJobTemplate returns a job template by ID, or sql.ErrNoRows if not found.
+func (s *Store) GetJobTemplate(ctx context.Context, id string) (model.JobTemplate, error) {
+	s.logger.WithField("job_template_id", id).Trace("entering GetJobTemplate")
+	defer s.logger.Trace("returning from GetJobTemplate")
+
+	e, err := scanJobTemplate(s.db.QueryRowContext(ctx, "SELECT "+jobTemplateSelectColumns+" FROM job_templates WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.WithField("job_template_id", id).Debug("job template not found in database")
+		} else {
+			s.logger.WithFields(logrus.Fields{
+				"job_template_id": id,
+				"error":           err.Error(),
+			}).Error("failed to query job template")
+		}
+		return model.JobTemplate{}, err
+	}
+	s.logger.WithField("job_template_id", id).Debug("fetched job template from database")
+	return jobTemplateEntityToModel(e)
+}
+
+// CreateJobTemplate inserts a new job template. Its study must exist.
+func (s *Store) CreateJobTemplate(ctx context.Context, t model.JobTemplate) error {
+	s.logger.WithFields(logrus.Fields{
+		"job_template_id":   t.ID,
+		"job_template_name": t.Name,
+	}).Trace("entering CreateJobTemplate")
+	defer s.logger.Trace("returning from CreateJobTemplate")
+
+	e := jobTemplateModelToEntity(t)
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO job_templates (`+jobTemplateSelectColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ID, e.Name, e.StudyID, e.CheckpointFilter, e.ClearExisting, e.MissingOnly, e.SkipExisting, e.AppendNewCheckpoints, e.OutputFormat, e.OutputQuality, e.VAE, e.CLIP, e.Shift, e.ControlNetModel, e.ControlNetStrength, e.ControlNetImage, e.CreatedAt, e.UpdatedAt,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_template_id": t.ID,
+			"error":           err.Error(),
+		}).Error("failed to insert job template into database")
+		return fmt.Errorf("inserting job template: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"job_template_id":   t.ID,
+		"job_template_name": t.Name,
+	}).Info("inserted job template into database")
+	return nil
+}
+
+// UpdateJobTemplate replaces the configuration of a job template, keeping its
+// creation time. Returns sql.ErrNoRows if the template does not exist.
+func (s *Store) UpdateJobTemplate(ctx context.Context, t model.JobTemplate) error {
+	s.logger.WithField("job_template_id", t.ID).Trace("entering UpdateJobTemplate")
+	defer s.logger.Trace("returning from UpdateJobTemplate")
+
+	e := jobTemplateModelToEntity(t)
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE job_templates SET name = ?, study_id = ?, checkpoint_filter = ?, clear_existing = ?, missing_only = ?, skip_existing = ?, append_new_checkpoints = ?, output_format = ?, output_quality = ?, vae = ?, clip = ?, shift = ?, controlnet_model = ?, controlnet_strength = ?, controlnet_image = ?, updated_at = ?
+		WHERE id = ?`,
+		e.Name, e.StudyID, e.CheckpointFilter, e.ClearExisting, e.MissingOnly, e.SkipExisting, e.AppendNewCheckpoints, e.OutputFormat, e.OutputQuality, e.VAE, e.CLIP, e.Shift, e.ControlNetModel, e.ControlNetStrength, e.ControlNetImage, e.UpdatedAt,
+		e.ID,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_template_id": t.ID,
+			"error":           err.Error(),
+		}).Error("failed to update job template in database")
+		return fmt.Errorf("updating job template: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("job_template_id", t.ID).Debug("job template not found for update")
+		return sql.ErrNoRows
+	}
+	s.logger.WithField("job_template_id", t.ID).Info("updated job template in database")
+	return nil
+}
+
+// DeleteJobTemplate removes a job template by ID. Returns sql.ErrNoRows if
+// not found.
+func (s *Store) DeleteJobTemplate(ctx context.Context, id string) error {
+	s.logger.WithField("job_template_id", id).Trace("entering DeleteJobTemplate")
+	defer s.logger.Trace("returning from DeleteJobTemplate")
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM job_templates WHERE id = ?", id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_template_id": id,
+			"error":           err.Error(),
+		}).Error("failed to delete job template from database")
+		return fmt.Errorf("deleting job template: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("job_template_id", id).Debug("job template not found for deletion")
+		return sql.ErrNoRows
+	}
+	s.logger.WithField("job_template_id", id).Info("deleted job template from database")
+	return nil
+}
+
+func jobTemplateEntityToModel(e jobTemplateEntity) (model.JobTemplate, error) {
+	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
+	if err != nil {
+		return model.JobTemplate{}, fmt.Errorf("parsing created_at: %w", err)
+	}
+	updatedAt, err := time.Parse(time.RFC3339, e.UpdatedAt)
+	if err != nil {
+		return model.JobTemplate{}, fmt.Errorf("parsing updated_at: %w", err)
+	}
+	t := model.JobTemplate{
+		ID:                   e.ID,
+		Name:                 e.Name,
+		StudyID:              e.StudyID,
+		CheckpointFilter:     e.CheckpointFilter,
+		ClearExisting:        e.ClearExisting,
+		MissingOnly:          e.MissingOnly,
+		SkipExisting:         e.SkipExisting,
+		AppendNewCheckpoints: e.AppendNewCheckpoints,
+		Output:               model.ImageOutputOptions{Format: model.OutputFormat(e.OutputFormat), Quality: e.OutputQuality},
+		Overrides: model.ModelOverrides{
+			VAE:             e.VAE,
+			CLIP:            e.CLIP,
+			ControlNetModel: e.ControlNetModel,
+			ControlNetImage: e.ControlNetImage,
+		},
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}
+	if e.Shift.Valid {
+		t.Overrides.Shift = &e.Shift.Float64
+	}
+	if e.ControlNetStrength.Valid {
+		t.Overrides.ControlNetStrength = &e.ControlNetStrength.Float64
+	}
+	return t, nil
+}
+
+func jobTemplateModelToEntity(t model.JobTemplate) jobTemplateEntity {
+	e := jobTemplateEntity{
+		ID:                   t.ID,
+		Name:                 t.Name,
+		StudyID:              t.StudyID,
+		CheckpointFilter:     t.CheckpointFilter,
+		ClearExisting:        t.ClearExisting,
+		MissingOnly:          t.MissingOnly,
+		SkipExisting:         t.SkipExisting,
+		AppendNewCheckpoints: t.AppendNewCheckpoints,
+		OutputFormat:         string(t.Output.Format),
+		OutputQuality:        t.Output.Quality,
+		VAE:                  t.Overrides.VAE,
+		CLIP:                 t.Overrides.CLIP,
+		ControlNetModel:      t.Overrides.ControlNetModel,
+		ControlNetImage:      t.Overrides.ControlNetImage,
+		CreatedAt:            t.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:            t.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if e.OutputFormat == "" {
+		e.OutputFormat = string(model.OutputFormatPNG)
+	}
+	if t.Overrides.Shift != nil {
+		e.Shift = sql.NullFloat64{Float64: *t.Overrides.Shift, Valid: true}
+	}
+	if t.Overrides.ControlNetStrength != nil {
+		e.ControlNetStrength = sql.NullFloat64{Float64: *t.Overrides.ControlNetStrength, Valid: true}
+	}
+	return e
+}
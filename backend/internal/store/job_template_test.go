@@ -0,0 +1,134 @@
+package store_test
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("JobTemplateStore", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "job-template-store-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(st.CreateStudy(ctx, model.Study{
+			ID:                    "study-1",
+			Name:                  "Sweep",
+			Prompts:               []model.NamedPrompt{{Name: "forest", Text: "a forest"}},
+			Steps:                 []int{20},
+			CFGs:                  []float64{7},
+			SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+			Seeds:                 []int64{42},
+			Width:                 512,
+			Height:                512,
+			CreatedAt:             now,
+			UpdatedAt:             now,
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	newTemplate := func(id, name string) model.JobTemplate {
+		shift := 3.1
+		return model.JobTemplate{
+			ID:                   id,
+			Name:                 name,
+			StudyID:              "study-1",
+			CheckpointFilter:     "*-step*.safetensors",
+			ClearExisting:        true,
+			SkipExisting:         true,
+			AppendNewCheckpoints: true,
+			Output:               model.ImageOutputOptions{Format: model.OutputFormatWebP, Quality: 80},
+			Overrides:            model.ModelOverrides{VAE: "ae.safetensors", CLIP: "clip_l.safetensors", Shift: &shift},
+			CreatedAt:            now,
+			UpdatedAt:            now,
+		}
+	}
+
+	It("round-trips a template through create and get", func() {
+		t := newTemplate("t-1", "Nightly")
+		Expect(st.CreateJobTemplate(ctx, t)).To(Succeed())
+
+		got, err := st.GetJobTemplate(ctx, "t-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(t))
+	})
+
+	It("lists templates ordered by name", func() {
+		Expect(st.CreateJobTemplate(ctx, newTemplate("t-1", "Zeta"))).To(Succeed())
+		Expect(st.CreateJobTemplate(ctx, newTemplate("t-2", "Alpha"))).To(Succeed())
+
+		templates, err := st.ListJobTemplates(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(templates).To(HaveLen(2))
+		Expect(templates[0].Name).To(Equal("Alpha"))
+		Expect(templates[1].Name).To(Equal("Zeta"))
+	})
+
+	It("updates a template, keeping its creation time", func() {
+		Expect(st.CreateJobTemplate(ctx, newTemplate("t-1", "Nightly"))).To(Succeed())
+		updated := newTemplate("t-1", "Weekly")
+		updated.Overrides = model.ModelOverrides{}
+		updated.CreatedAt = now.Add(time.Hour)
+		updated.UpdatedAt = now.Add(time.Hour)
+		Expect(st.UpdateJobTemplate(ctx, updated)).To(Succeed())
+
+		got, err := st.GetJobTemplate(ctx, "t-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got.Name).To(Equal("Weekly"))
+		Expect(got.Overrides.Shift).To(BeNil())
+		Expect(got.CreatedAt).To(Equal(now))
+		Expect(got.UpdatedAt).To(Equal(now.Add(time.Hour)))
+	})
+
+	It("rejects a template of an unknown study", func() {
+		t := newTemplate("t-1", "Nightly")
+		t.StudyID = "missing"
+		Expect(st.CreateJobTemplate(ctx, t)).To(MatchError(ContainSubstring("FOREIGN KEY constraint failed")))
+	})
+
+	It("deletes the templates of a deleted study", func() {
+		Expect(st.CreateJobTemplate(ctx, newTemplate("t-1", "Nightly"))).To(Succeed())
+		Expect(st.DeleteStudy(ctx, "study-1")).To(Succeed())
+
+		_, err := st.GetJobTemplate(ctx, "t-1")
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+
+	It("returns sql.ErrNoRows for an unknown template", func() {
+		_, err := st.GetJobTemplate(ctx, "missing")
+		Expect(err).To(Equal(sql.ErrNoRows))
+		Expect(st.UpdateJobTemplate(ctx, newTemplate("missing", "Ghost"))).To(Equal(sql.ErrNoRows))
+		Expect(st.DeleteJobTemplate(ctx, "missing")).To(Equal(sql.ErrNoRows))
+	})
+})
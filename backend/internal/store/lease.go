@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// leaseEntity is the persistence representation of a process lease.
+type leaseEntity struct {
+	Name       string
+	Holder     string
+	AcquiredAt string // RFC3339 UTC
+	RenewedAt  string // RFC3339 UTC
+	ExpiresAt  string // RFC3339 UTC
+}
+
+// AcquireLease takes or renews the named lease for holder until now+ttl.
+// The lease is granted when it is free, already held by holder, or held by
+// another holder whose lease has expired. The upsert is a single statement,
+// so two processes racing for a free lease cannot both win. Returns the
+// lease as stored afterwards and whether holder now owns it.
+func (s *Store) AcquireLease(ctx context.Context, name string, holder string, now time.Time, ttl time.Duration) (model.ProcessLease, bool, error) {
+	s.logger.WithFields(logrus.Fields{
+		"lease":  name,
+		"holder": holder,
+	}).Trace("entering AcquireLease")
+	defer s.logger.Trace("returning from AcquireLease")
+
+	nowStr := now.UTC().Format(time.RFC3339)
+	expiresStr := now.Add(ttl).UTC().Format(time.RFC3339)
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO process_leases (name, holder, acquired_at, renewed_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			acquired_at = CASE WHEN process_leases.holder = excluded.holder
+				THEN process_leases.acquired_at ELSE excluded.acquired_at END,
+			holder = excluded.holder,
+			renewed_at = excluded.renewed_at,
+			expires_at = excluded.expires_at
+		WHERE process_leases.holder = excluded.holder OR process_leases.expires_at <= excluded.renewed_at`,
+		name, holder, nowStr, nowStr, expiresStr,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"lease":  name,
+			"holder": holder,
+			"error":  err.Error(),
+		}).Error("failed to upsert lease")
+		return model.ProcessLease{}, false, fmt.Errorf("acquiring lease %s: %w", name, err)
+	}
+
+	lease, err := s.GetLease(ctx, name)
+	if err != nil {
+		return model.ProcessLease{}, false, err
+	}
+	return lease, lease.Holder == holder, nil
+}
+
+// GetLease returns the named lease. Returns sql.ErrNoRows if it has never been taken.
+func (s *Store) GetLease(ctx context.Context, name string) (model.ProcessLease, error) {
+	s.logger.WithField("lease", name).Trace("entering GetLease")
+	defer s.logger.Trace("returning from GetLease")
+
+	var e leaseEntity
+	err := s.db.QueryRowContext(ctx,
+		"SELECT name, holder, acquired_at, renewed_at, expires_at FROM process_leases WHERE name = ?",
+		name,
+	).Scan(&e.Name, &e.Holder, &e.AcquiredAt, &e.RenewedAt, &e.ExpiresAt)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("lease", name).Debug("lease not found in database")
+		return model.ProcessLease{}, sql.ErrNoRows
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"lease": name,
+			"error": err.Error(),
+		}).Error("failed to query lease")
+		return model.ProcessLease{}, fmt.Errorf("querying lease %s: %w", name, err)
+	}
+	return leaseEntityToModel(e)
+}
+
+// ReleaseLease gives up the named lease if holder owns it. Releasing a lease
+// held by someone else, or not held at all, is a no-op.
+func (s *Store) ReleaseLease(ctx context.Context, name string, holder string) error {
+	s.logger.WithFields(logrus.Fields{
+		"lease":  name,
+		"holder": holder,
+	}).Trace("entering ReleaseLease")
+	defer s.logger.Trace("returning from ReleaseLease")
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM process_leases WHERE name = ? AND holder = ?", name, holder)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"lease":  name,
+			"holder": holder,
+			"error":  err.Error(),
+		}).Error("failed to release lease")
+		return fmt.Errorf("releasing lease %s: %w", name, err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"lease":  name,
+			"holder": holder,
+		}).Info("released lease")
+	}
+	return nil
+}
+
+func leaseEntityToModel(e leaseEntity) (model.ProcessLease, error) {
+	acquiredAt, err := time.Parse(time.RFC3339, e.AcquiredAt)
+	if err != nil {
+		return model.ProcessLease{}, fmt.Errorf("parsing acquired_at: %w", err)
+	}
+	renewedAt, err := time.Parse(time.RFC3339, e.RenewedAt)
+	if err != nil {
+		return model.ProcessLease{}, fmt.Errorf("parsing renewed_at: %w", err)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, e.ExpiresAt)
+	if err != nil {
+		return model.ProcessLease{}, fmt.Errorf("parsing expires_at: %w", err)
+	}
+	return model.ProcessLease{
+		Name:       e.Name,
+		Holder:     e.Holder,
+		AcquiredAt: acquiredAt,
+		RenewedAt:  renewedAt,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
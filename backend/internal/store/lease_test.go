@@ -0,0 +1,103 @@
+package store_test
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("LeaseStore", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "lease-store-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenSharedDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ttl := 30 * time.Second
+
+	It("grants a free lease", func() {
+		lease, acquired, err := st.AcquireLease(ctx, "executor", "a", now, ttl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+		Expect(lease.Holder).To(Equal("a"))
+		Expect(lease.AcquiredAt).To(Equal(now))
+		Expect(lease.ExpiresAt).To(Equal(now.Add(ttl)))
+	})
+
+	It("renews a lease for its holder and keeps the original acquisition time", func() {
+		_, _, err := st.AcquireLease(ctx, "executor", "a", now, ttl)
+		Expect(err).NotTo(HaveOccurred())
+
+		later := now.Add(10 * time.Second)
+		lease, acquired, err := st.AcquireLease(ctx, "executor", "a", later, ttl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+		Expect(lease.AcquiredAt).To(Equal(now))
+		Expect(lease.RenewedAt).To(Equal(later))
+		Expect(lease.ExpiresAt).To(Equal(later.Add(ttl)))
+	})
+
+	It("refuses a lease held by another holder until it expires", func() {
+		_, _, err := st.AcquireLease(ctx, "executor", "a", now, ttl)
+		Expect(err).NotTo(HaveOccurred())
+
+		lease, acquired, err := st.AcquireLease(ctx, "executor", "b", now.Add(10*time.Second), ttl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acquired).To(BeFalse())
+		Expect(lease.Holder).To(Equal("a"))
+
+		takeover := now.Add(ttl)
+		lease, acquired, err = st.AcquireLease(ctx, "executor", "b", takeover, ttl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+		Expect(lease.Holder).To(Equal("b"))
+		Expect(lease.AcquiredAt).To(Equal(takeover))
+	})
+
+	It("releases only the holder's own lease", func() {
+		_, _, err := st.AcquireLease(ctx, "executor", "a", now, ttl)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(st.ReleaseLease(ctx, "executor", "b")).To(Succeed())
+		lease, err := st.GetLease(ctx, "executor")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lease.Holder).To(Equal("a"))
+
+		Expect(st.ReleaseLease(ctx, "executor", "a")).To(Succeed())
+		_, err = st.GetLease(ctx, "executor")
+		Expect(err).To(Equal(sql.ErrNoRows))
+
+		_, acquired, err := st.AcquireLease(ctx, "executor", "b", now, ttl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+	})
+})
@@ -0,0 +1,1276 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// MemoryStore is an in-memory implementation of PresetStore, StudyStore and
+// SampleJobStore. It lets other programs embed SampleJobService and
+// JobExecutor without SQLite, and gives tests a store that behaves like the
+// real one.
+//
+// Rows are kept as the same entities Store reads and writes, so values read
+// back exactly as they would from the database: timestamps are truncated to
+// whole seconds (item timing keeps sub-second precision), empty optional
+// fields read back as their zero values, and so on. Unique IDs, unique study
+// names, foreign keys and cascading deletes are enforced as in the schema.
+// MemoryStore is safe for concurrent use.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	seq      int64 // last insertion number; breaks ties like SQLite's rowid
+	presets  map[string]memoryRow[presetEntity]
+	studies  map[string]memoryRow[studyEntity]
+	versions map[string][]studyVersionEntity // by study ID, oldest first
+	jobs     map[string]memoryRow[sampleJobEntity]
+	items    map[string]memoryRow[sampleJobItemEntity]
+	params   map[string]sampleJobParametersEntity
+	logger   *logrus.Entry
+}
+
+// memoryRow is a stored entity and its insertion number.
+type memoryRow[E any] struct {
+	seq    int64
+	entity E
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore(logger *logrus.Logger) *MemoryStore {
+	return &MemoryStore{
+		presets:  make(map[string]memoryRow[presetEntity]),
+		studies:  make(map[string]memoryRow[studyEntity]),
+		versions: make(map[string][]studyVersionEntity),
+		jobs:     make(map[string]memoryRow[sampleJobEntity]),
+		items:    make(map[string]memoryRow[sampleJobItemEntity]),
+		params:   make(map[string]sampleJobParametersEntity),
+		logger:   logger.WithField("component", "memory_store"),
+	}
+}
+
+// errUniqueConstraint returns the error of inserting a duplicate column
+// value, worded like SQLite's.
+func errUniqueConstraint(column string) error {
+	return fmt.Errorf("UNIQUE constraint failed: %s", column)
+}
+
+// errForeignKeyConstraint is returned when a job references a missing study
+// or an item a missing job, worded like SQLite's.
+var errForeignKeyConstraint = errors.New("FOREIGN KEY constraint failed")
+
+// nextSeq returns the next insertion number. The caller must hold mu.
+func (m *MemoryStore) nextSeq() int64 {
+	m.seq++
+	return m.seq
+}
+
+// ListPresets returns all presets ordered by name.
+func (m *MemoryStore) ListPresets(ctx context.Context) ([]model.Preset, error) {
+	m.logger.Trace("entering ListPresets")
+	defer m.logger.Trace("returning from ListPresets")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := sortedRows(m.presets, nil, func(a, b memoryRow[presetEntity]) int {
+		return cmp.Or(cmp.Compare(a.entity.Name, b.entity.Name), cmp.Compare(a.seq, b.seq))
+	})
+	var presets []model.Preset
+	for _, r := range rows {
+		p, err := entityToModel(r.entity)
+		if err != nil {
+			return nil, err
+		}
+		presets = append(presets, p)
+	}
+	return presets, nil
+}
+
+// GetPreset returns a single preset by ID, or sql.ErrNoRows if not found.
+func (m *MemoryStore) GetPreset(ctx context.Context, id string) (model.Preset, error) {
+	m.logger.WithField("preset_id", id).Trace("entering GetPreset")
+	defer m.logger.Trace("returning from GetPreset")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.presets[id]
+	if !ok {
+		return model.Preset{}, sql.ErrNoRows
+	}
+	return entityToModel(r.entity)
+}
+
+// CreatePreset inserts a new preset. When the preset is its training run's
+// default, the run's previous default stops being one.
+func (m *MemoryStore) CreatePreset(ctx context.Context, p model.Preset) error {
+	m.logger.WithField("preset_id", p.ID).Trace("entering CreatePreset")
+	defer m.logger.Trace("returning from CreatePreset")
+
+	e, err := presetModelToEntity(p)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.presets[p.ID]; ok {
+		return fmt.Errorf("inserting preset: %w", errUniqueConstraint("presets.id"))
+	}
+	m.clearDefaultPreset(e)
+	m.presets[p.ID] = memoryRow[presetEntity]{seq: m.nextSeq(), entity: e}
+	return nil
+}
+
+// UpdatePreset updates an existing preset's name, mapping, training run,
+// view and default flag. When the preset becomes its training run's default,
+// the run's previous default stops being one. Returns sql.ErrNoRows if the
+// preset does not exist.
+func (m *MemoryStore) UpdatePreset(ctx context.Context, p model.Preset) error {
+	m.logger.WithField("preset_id", p.ID).Trace("entering UpdatePreset")
+	defer m.logger.Trace("returning from UpdatePreset")
+
+	e, err := presetModelToEntity(p)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.presets[p.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	e.Owner = r.entity.Owner
+	m.clearDefaultPreset(e)
+	e.CreatedAt = r.entity.CreatedAt
+	r.entity = e
+	m.presets[p.ID] = r
+	return nil
+}
+
+// clearDefaultPreset unsets the default flag of the other presets of e's
+// training run and owner when e is the run's default. The caller must hold mu.
+func (m *MemoryStore) clearDefaultPreset(e presetEntity) {
+	if !e.IsDefault {
+		return
+	}
+	for id, r := range m.presets {
+		if id != e.ID && r.entity.TrainingRun == e.TrainingRun && r.entity.Owner == e.Owner && r.entity.IsDefault {
+			r.entity.IsDefault = false
+			m.presets[id] = r
+		}
+	}
+}
+
+// DeletePreset removes a preset by ID. Returns sql.ErrNoRows if the preset
+// does not exist.
+func (m *MemoryStore) DeletePreset(ctx context.Context, id string) error {
+	m.logger.WithField("preset_id", id).Trace("entering DeletePreset")
+	defer m.logger.Trace("returning from DeletePreset")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.presets[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(m.presets, id)
+	return nil
+}
+
+// ListStudies returns all studies ordered by name.
+func (m *MemoryStore) ListStudies(ctx context.Context) ([]model.Study, error) {
+	m.logger.Trace("entering ListStudies")
+	defer m.logger.Trace("returning from ListStudies")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := sortedRows(m.studies, nil, func(a, b memoryRow[studyEntity]) int {
+		return cmp.Or(cmp.Compare(a.entity.Name, b.entity.Name), cmp.Compare(a.seq, b.seq))
+	})
+	var studies []model.Study
+	for _, r := range rows {
+		st, err := memoryStudyToModel(r.entity)
+		if err != nil {
+			return nil, err
+		}
+		studies = append(studies, st)
+	}
+	return studies, nil
+}
+
+// GetStudy returns a single study by ID, or sql.ErrNoRows if not found.
+func (m *MemoryStore) GetStudy(ctx context.Context, id string) (model.Study, error) {
+	m.logger.WithField("study_id", id).Trace("entering GetStudy")
+	defer m.logger.Trace("returning from GetStudy")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.studies[id]
+	if !ok {
+		return model.Study{}, sql.ErrNoRows
+	}
+	return memoryStudyToModel(r.entity)
+}
+
+// GetStudyByName returns the first study with the given name, excluding the
+// study with excludeID (pass "" to include all studies). Returns sql.ErrNoRows
+// if no matching study is found.
+func (m *MemoryStore) GetStudyByName(ctx context.Context, name string, excludeID string) (model.Study, error) {
+	m.logger.WithField("study_name", name).Trace("entering GetStudyByName")
+	defer m.logger.Trace("returning from GetStudyByName")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := sortedRows(m.studies, func(e studyEntity) bool {
+		return e.Name == name && (excludeID == "" || e.ID != excludeID)
+	}, compareSeq[studyEntity])
+	if len(rows) == 0 {
+		return model.Study{}, sql.ErrNoRows
+	}
+	return memoryStudyToModel(rows[0].entity)
+}
+
+// CreateStudy inserts a new study.
+func (m *MemoryStore) CreateStudy(ctx context.Context, st model.Study) error {
+	m.logger.WithField("study_id", st.ID).Trace("entering CreateStudy")
+	defer m.logger.Trace("returning from CreateStudy")
+
+	entity, err := studyModelToEntity(st)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkNewStudy(entity); err != nil {
+		return fmt.Errorf("inserting study: %w", err)
+	}
+	m.insertStudy(entity)
+	return nil
+}
+
+// UpdateStudy updates an existing study. Returns sql.ErrNoRows if the study
+// does not exist.
+func (m *MemoryStore) UpdateStudy(ctx context.Context, st model.Study) error {
+	m.logger.WithField("study_id", st.ID).Trace("entering UpdateStudy")
+	defer m.logger.Trace("returning from UpdateStudy")
+
+	entity, err := studyModelToEntity(st)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.studies[st.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	for id, other := range m.studies {
+		if id != st.ID && other.entity.Name == entity.Name {
+			return fmt.Errorf("updating study: %w", errUniqueConstraint("studies.name"))
+		}
+	}
+	entity.Owner = r.entity.Owner
+	entity.CreatedAt = r.entity.CreatedAt
+	r.entity = copyStudyEntity(entity)
+	m.studies[st.ID] = r
+	return nil
+}
+
+// DeleteStudy removes a study by ID, together with its archived versions,
+// sample jobs and their items. Returns sql.ErrNoRows if the study does not
+// exist.
+func (m *MemoryStore) DeleteStudy(ctx context.Context, id string) error {
+	m.logger.WithField("study_id", id).Trace("entering DeleteStudy")
+	defer m.logger.Trace("returning from DeleteStudy")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.studies[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(m.studies, id)
+	delete(m.versions, id)
+	for jobID, r := range m.jobs {
+		if r.entity.StudyID == id {
+			m.deleteJob(jobID)
+		}
+	}
+	return nil
+}
+
+// ListStudyVersions returns the archived versions of a study, oldest first.
+func (m *MemoryStore) ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error) {
+	m.logger.WithField("study_id", studyID).Trace("entering ListStudyVersions")
+	defer m.logger.Trace("returning from ListStudyVersions")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var versions []model.StudyVersion
+	for _, e := range m.versions[studyID] {
+		v, err := studyVersionEntityToModel(e)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// CreateStudyVersion archives a version of a study. Archiving a version that
+// already exists is a no-op.
+func (m *MemoryStore) CreateStudyVersion(ctx context.Context, v model.StudyVersion) error {
+	m.logger.WithFields(logrus.Fields{
+		"study_id": v.StudyID,
+		"version":  v.Version,
+	}).Trace("entering CreateStudyVersion")
+	defer m.logger.Trace("returning from CreateStudyVersion")
+
+	e, err := studyVersionModelToEntity(v)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.studies[v.StudyID]; !ok {
+		return fmt.Errorf("inserting study version: %w", errForeignKeyConstraint)
+	}
+	versions := m.versions[v.StudyID]
+	i, found := slices.BinarySearchFunc(versions, e.Version, func(a studyVersionEntity, version int) int {
+		return cmp.Compare(a.Version, version)
+	})
+	if !found {
+		m.versions[v.StudyID] = slices.Insert(versions, i, e)
+	}
+	return nil
+}
+
+// StudyVersionInUse reports whether any sample job was created from the
+// given version of a study.
+func (m *MemoryStore) StudyVersionInUse(ctx context.Context, studyID string, version int) (bool, error) {
+	m.logger.WithFields(logrus.Fields{
+		"study_id": studyID,
+		"version":  version,
+	}).Trace("entering StudyVersionInUse")
+	defer m.logger.Trace("returning from StudyVersionInUse")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, r := range m.jobs {
+		if r.entity.StudyID == studyID && r.entity.StudyVersion == version {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListSampleJobs returns all sample jobs ordered by created_at ascending
+// (oldest first, FIFO).
+func (m *MemoryStore) ListSampleJobs(ctx context.Context) ([]model.SampleJob, error) {
+	m.logger.Trace("entering ListSampleJobs")
+	defer m.logger.Trace("returning from ListSampleJobs")
+
+	return m.listSampleJobs(false, model.SampleJobFilter{}, model.Page{})
+}
+
+// ListSampleJobsDesc returns the sample jobs matching filter in page ordered
+// by created_at descending (newest first).
+func (m *MemoryStore) ListSampleJobsDesc(ctx context.Context, filter model.SampleJobFilter, page model.Page) ([]model.SampleJob, error) {
+	m.logger.WithFields(logrus.Fields{
+		"owner":  filter.Scope.Owner,
+		"labels": len(filter.Labels),
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	}).Trace("entering ListSampleJobsDesc")
+	defer m.logger.Trace("returning from ListSampleJobsDesc")
+
+	return m.listSampleJobs(true, filter, page)
+}
+
+// listSampleJobs is the shared implementation for ListSampleJobs and
+// ListSampleJobsDesc.
+func (m *MemoryStore) listSampleJobs(descending bool, filter model.SampleJobFilter, page model.Page) ([]model.SampleJob, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matches := func(e sampleJobEntity) bool { return sampleJobEntityMatches(e, filter) }
+	rows := sortedRows(m.jobs, matches, func(a, b memoryRow[sampleJobEntity]) int {
+		c := cmp.Or(cmp.Compare(a.entity.CreatedAt, b.entity.CreatedAt), cmp.Compare(a.seq, b.seq))
+		if descending {
+			return -c
+		}
+		return c
+	})
+	var jobs []model.SampleJob
+	for _, r := range pageRows(rows, page) {
+		j, err := sampleJobEntityToModel(r.entity)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// CountSampleJobs returns the number of sample jobs matching filter.
+func (m *MemoryStore) CountSampleJobs(ctx context.Context, filter model.SampleJobFilter) (int, error) {
+	m.logger.WithField("owner", filter.Scope.Owner).Trace("entering CountSampleJobs")
+	defer m.logger.Trace("returning from CountSampleJobs")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, r := range m.jobs {
+		if sampleJobEntityMatches(r.entity, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// sampleJobEntityMatches reports whether e is outside the trash and matches
+// filter, like sampleJobWhere.
+func sampleJobEntityMatches(e sampleJobEntity, filter model.SampleJobFilter) bool {
+	if e.DeletedAt.Valid {
+		return false
+	}
+	labels, err := decodeJobLabels(e.Labels)
+	return err == nil && filter.Includes(e.Owner, labels)
+}
+
+// HasRunningJob returns true if any sample job outside the trash currently
+// has status "running".
+func (m *MemoryStore) HasRunningJob(ctx context.Context) (bool, error) {
+	m.logger.Trace("entering HasRunningJob")
+	defer m.logger.Trace("returning from HasRunningJob")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, r := range m.jobs {
+		if r.entity.Status == string(model.SampleJobStatusRunning) && !r.entity.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetSampleJob returns a single sample job by ID, or sql.ErrNoRows if not
+// found or in the trash.
+func (m *MemoryStore) GetSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
+	m.logger.WithField("sample_job_id", id).Trace("entering GetSampleJob")
+	defer m.logger.Trace("returning from GetSampleJob")
+
+	return m.getSampleJob(id, false)
+}
+
+// GetTrashedSampleJob returns a single sample job in the trash by ID, or
+// sql.ErrNoRows if not found or not in the trash.
+func (m *MemoryStore) GetTrashedSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
+	m.logger.WithField("sample_job_id", id).Trace("entering GetTrashedSampleJob")
+	defer m.logger.Trace("returning from GetTrashedSampleJob")
+
+	return m.getSampleJob(id, true)
+}
+
+// getSampleJob is the shared implementation for GetSampleJob and
+// GetTrashedSampleJob.
+func (m *MemoryStore) getSampleJob(id string, trashed bool) (model.SampleJob, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.jobs[id]
+	if !ok || r.entity.DeletedAt.Valid != trashed {
+		return model.SampleJob{}, sql.ErrNoRows
+	}
+	return sampleJobEntityToModel(r.entity)
+}
+
+// CreateSampleJob inserts a new sample job.
+func (m *MemoryStore) CreateSampleJob(ctx context.Context, j model.SampleJob) error {
+	m.logger.WithField("sample_job_id", j.ID).Trace("entering CreateSampleJob")
+	defer m.logger.Trace("returning from CreateSampleJob")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entity := sampleJobModelToEntity(j)
+	if err := m.checkNewJob(entity, nil); err != nil {
+		return fmt.Errorf("inserting sample job: %w", err)
+	}
+	m.insertJob(entity)
+	return nil
+}
+
+// CreateSampleJobWithItems inserts a new sample job together with its items.
+// If any insert would fail, nothing is inserted.
+func (m *MemoryStore) CreateSampleJobWithItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error {
+	m.logger.WithFields(logrus.Fields{
+		"sample_job_id": j.ID,
+		"item_count":    len(items),
+	}).Trace("entering CreateSampleJobWithItems")
+	defer m.logger.Trace("returning from CreateSampleJobWithItems")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobEntity := sampleJobModelToEntity(j)
+	if err := m.checkNewJob(jobEntity, nil); err != nil {
+		return fmt.Errorf("inserting sample job: %w", err)
+	}
+	itemEntities, err := m.checkNewItems(items, &jobEntity)
+	if err != nil {
+		return err
+	}
+	m.insertJob(jobEntity)
+	m.insertItems(itemEntities)
+	return nil
+}
+
+// CreateStudyWithSampleJob inserts a new study, a sample job of it and the
+// job's items. If any insert would fail, nothing is inserted.
+func (m *MemoryStore) CreateStudyWithSampleJob(ctx context.Context, st model.Study, j model.SampleJob, items []model.SampleJobItem) error {
+	m.logger.WithFields(logrus.Fields{
+		"study_id":      st.ID,
+		"sample_job_id": j.ID,
+		"item_count":    len(items),
+	}).Trace("entering CreateStudyWithSampleJob")
+	defer m.logger.Trace("returning from CreateStudyWithSampleJob")
+
+	studyEntity, err := studyModelToEntity(st)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkNewStudy(studyEntity); err != nil {
+		return fmt.Errorf("inserting study: %w", err)
+	}
+	jobEntity := sampleJobModelToEntity(j)
+	if err := m.checkNewJob(jobEntity, &studyEntity); err != nil {
+		return fmt.Errorf("inserting sample job: %w", err)
+	}
+	itemEntities, err := m.checkNewItems(items, &jobEntity)
+	if err != nil {
+		return err
+	}
+	m.insertStudy(studyEntity)
+	m.insertJob(jobEntity)
+	m.insertItems(itemEntities)
+	return nil
+}
+
+// UpdateSampleJob updates an existing sample job if its stored version is
+// j.Version, and increments the version, both stored and on j. Returns
+// sql.ErrNoRows if the job does not exist and ErrVersionConflict if it was
+// updated since j was read.
+func (m *MemoryStore) UpdateSampleJob(ctx context.Context, j *model.SampleJob) error {
+	m.logger.WithField("sample_job_id", j.ID).Trace("entering UpdateSampleJob")
+	defer m.logger.Trace("returning from UpdateSampleJob")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.updateJob(*j); err != nil {
+		return err
+	}
+	j.Version++
+	return nil
+}
+
+// TransitionSampleJob updates an existing sample job like UpdateSampleJob while
+// its stored status is from. Returns sql.ErrNoRows if the job does not exist,
+// ErrSampleJobStatusChanged if its status is no longer from, and
+// ErrVersionConflict if it was otherwise updated since j was read.
+func (m *MemoryStore) TransitionSampleJob(ctx context.Context, j *model.SampleJob, from model.SampleJobStatus) error {
+	m.logger.WithField("sample_job_id", j.ID).Trace("entering TransitionSampleJob")
+	defer m.logger.Trace("returning from TransitionSampleJob")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.jobs[j.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if r.entity.Status != string(from) {
+		return fmt.Errorf("%w: job %s is %s, not %s", ErrSampleJobStatusChanged, j.ID, r.entity.Status, from)
+	}
+	if err := m.updateJob(*j); err != nil {
+		return err
+	}
+	j.Version++
+	return nil
+}
+
+// AppendSampleJobItems updates an existing sample job like UpdateSampleJob and
+// inserts new items for it. Returns sql.ErrNoRows if the job does not exist
+// and ErrVersionConflict if it was updated since j was read; if any insert
+// would fail, neither the job nor the items are changed.
+func (m *MemoryStore) AppendSampleJobItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error {
+	m.logger.WithFields(logrus.Fields{
+		"sample_job_id": j.ID,
+		"item_count":    len(items),
+	}).Trace("entering AppendSampleJobItems")
+	defer m.logger.Trace("returning from AppendSampleJobItems")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.jobs[j.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	itemEntities, err := m.checkNewItems(items, nil)
+	if err != nil {
+		return err
+	}
+	if err := m.updateJob(j); err != nil {
+		return err
+	}
+	m.insertItems(itemEntities)
+	return nil
+}
+
+// DeleteSampleJob removes a sample job and its items by ID. Returns
+// sql.ErrNoRows if the job does not exist.
+func (m *MemoryStore) DeleteSampleJob(ctx context.Context, id string) error {
+	m.logger.WithField("sample_job_id", id).Trace("entering DeleteSampleJob")
+	defer m.logger.Trace("returning from DeleteSampleJob")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.jobs[id]; !ok {
+		return sql.ErrNoRows
+	}
+	m.deleteJob(id)
+	return nil
+}
+
+// ListTrashedSampleJobs returns the sample jobs in scope that are in the
+// trash, most recently deleted first.
+func (m *MemoryStore) ListTrashedSampleJobs(ctx context.Context, scope model.OwnerScope) ([]model.SampleJob, error) {
+	m.logger.WithField("owner", scope.Owner).Trace("entering ListTrashedSampleJobs")
+	defer m.logger.Trace("returning from ListTrashedSampleJobs")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inScope := func(e sampleJobEntity) bool { return e.DeletedAt.Valid && scope.Includes(e.Owner) }
+	rows := sortedRows(m.jobs, inScope, func(a, b memoryRow[sampleJobEntity]) int {
+		return -cmp.Or(cmp.Compare(a.entity.DeletedAt.String, b.entity.DeletedAt.String), cmp.Compare(a.seq, b.seq))
+	})
+	var jobs []model.SampleJob
+	for _, r := range rows {
+		j, err := sampleJobEntityToModel(r.entity)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// TrashSampleJob moves a sample job to the trash, recording when it was
+// deleted and whether purging it removes its sample files. Returns
+// sql.ErrNoRows if the job does not exist or is already in the trash.
+func (m *MemoryStore) TrashSampleJob(ctx context.Context, id string, deletedAt time.Time, deleteData bool) error {
+	m.logger.WithField("sample_job_id", id).Trace("entering TrashSampleJob")
+	defer m.logger.Trace("returning from TrashSampleJob")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.jobs[id]
+	if !ok || r.entity.DeletedAt.Valid {
+		return sql.ErrNoRows
+	}
+	r.entity.DeletedAt = sql.NullString{String: deletedAt.UTC().Format(time.RFC3339), Valid: true}
+	r.entity.DeleteData = deleteData
+	r.entity.Version++
+	m.jobs[id] = r
+	return nil
+}
+
+// RestoreSampleJob moves a sample job out of the trash. Returns sql.ErrNoRows
+// if the job does not exist or is not in the trash.
+func (m *MemoryStore) RestoreSampleJob(ctx context.Context, id string) error {
+	m.logger.WithField("sample_job_id", id).Trace("entering RestoreSampleJob")
+	defer m.logger.Trace("returning from RestoreSampleJob")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.jobs[id]
+	if !ok || !r.entity.DeletedAt.Valid {
+		return sql.ErrNoRows
+	}
+	r.entity.DeletedAt = sql.NullString{}
+	r.entity.DeleteData = false
+	r.entity.Version++
+	m.jobs[id] = r
+	return nil
+}
+
+// SeedSampleJobs inserts multiple sample jobs, creating a minimal stub study
+// for each referenced study that does not exist. It is intended for test
+// infrastructure only.
+func (m *MemoryStore) SeedSampleJobs(ctx context.Context, jobs []model.SampleJob) error {
+	m.logger.WithField("job_count", len(jobs)).Trace("entering SeedSampleJobs")
+	defer m.logger.Trace("returning from SeedSampleJobs")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, j := range jobs {
+		if j.StudyID == "" {
+			continue
+		}
+		if _, ok := m.studies[j.StudyID]; ok {
+			continue
+		}
+		name := j.StudyName
+		if name == "" {
+			name = "Stub Study " + j.StudyID
+		}
+		stub := studyEntity{
+			ID:                    j.StudyID,
+			Name:                  name,
+			Prompts:               "[]",
+			Steps:                 "[]",
+			CFGs:                  "[]",
+			SamplerSchedulerPairs: "[]",
+			Seeds:                 "[]",
+			Resolutions:           "[]",
+			DenoiseStrengths:      "[]",
+			Wildcards:             "[]",
+			SeedMode:              string(model.SeedModeExplicit),
+			Width:                 512,
+			Height:                512,
+			CreatedAt:             now,
+			UpdatedAt:             now,
+		}
+		if err := m.checkNewStudy(stub); err != nil {
+			return fmt.Errorf("ensuring stub study %s: inserting stub study: %w", j.StudyID, err)
+		}
+		m.insertStudy(stub)
+	}
+
+	for _, j := range jobs {
+		entity := sampleJobModelToEntity(j)
+		if err := m.checkNewJob(entity, nil); err != nil {
+			return fmt.Errorf("seeding sample job %s: inserting sample job: %w", j.ID, err)
+		}
+		m.insertJob(entity)
+	}
+	return nil
+}
+
+// ListSampleJobItems returns all items for a specific job, ordered by created_at.
+func (m *MemoryStore) ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error) {
+	m.logger.WithField("job_id", jobID).Trace("entering ListSampleJobItems")
+	defer m.logger.Trace("returning from ListSampleJobItems")
+
+	return m.listSampleJobItems(jobID, model.SampleJobItemQuery{}, model.Page{})
+}
+
+// ListSampleJobItemsPage returns the items of a specific job that match
+// query.Filter, in query's order, limited to page.
+func (m *MemoryStore) ListSampleJobItemsPage(ctx context.Context, jobID string, query model.SampleJobItemQuery, page model.Page) ([]model.SampleJobItem, error) {
+	m.logger.WithFields(logrus.Fields{
+		"job_id": jobID,
+		"sort":   query.Sort,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	}).Trace("entering ListSampleJobItemsPage")
+	defer m.logger.Trace("returning from ListSampleJobItemsPage")
+
+	return m.listSampleJobItems(jobID, query, page)
+}
+
+// listSampleJobItems is the shared implementation for ListSampleJobItems and
+// ListSampleJobItemsPage. Ties are broken by insertion order, as in Store.
+func (m *MemoryStore) listSampleJobItems(jobID string, query model.SampleJobItemQuery, page model.Page) ([]model.SampleJobItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := sortedRows(m.items, func(e sampleJobItemEntity) bool {
+		return sampleJobItemMatches(e, jobID, query.Filter)
+	}, func(a, b memoryRow[sampleJobItemEntity]) int {
+		return compareSampleJobItems(a, b, query)
+	})
+	var items []model.SampleJobItem
+	for _, r := range pageRows(rows, page) {
+		i, err := sampleJobItemEntityToModel(r.entity)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, nil
+}
+
+// CountSampleJobItems returns the number of items of a specific job that
+// match filter.
+func (m *MemoryStore) CountSampleJobItems(ctx context.Context, jobID string, filter model.SampleJobItemFilter) (int, error) {
+	m.logger.WithField("job_id", jobID).Trace("entering CountSampleJobItems")
+	defer m.logger.Trace("returning from CountSampleJobItems")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, r := range m.items {
+		if sampleJobItemMatches(r.entity, jobID, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountSampleJobItemsByStatus returns the item status counts of a specific
+// job. Skipped items are counted as failed.
+func (m *MemoryStore) CountSampleJobItemsByStatus(ctx context.Context, jobID string) (model.ItemStatusCounts, error) {
+	m.logger.WithField("job_id", jobID).Trace("entering CountSampleJobItemsByStatus")
+	defer m.logger.Trace("returning from CountSampleJobItemsByStatus")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var counts model.ItemStatusCounts
+	for _, r := range m.items {
+		if r.entity.JobID != jobID {
+			continue
+		}
+		switch model.SampleJobItemStatus(r.entity.Status) {
+		case model.SampleJobItemStatusCompleted:
+			counts.Completed++
+		case model.SampleJobItemStatusFailed:
+			counts.Failed++
+		case model.SampleJobItemStatusSkipped:
+			counts.Failed++
+			counts.AddSkipped(model.SampleJobItemSkipReason(r.entity.SkipReason), 1)
+		case model.SampleJobItemStatusPending:
+			counts.Pending++
+		}
+	}
+	return counts, nil
+}
+
+// CreateSampleJobItem inserts a new sample job item.
+func (m *MemoryStore) CreateSampleJobItem(ctx context.Context, i model.SampleJobItem) error {
+	m.logger.WithField("sample_job_item_id", i.ID).Trace("entering CreateSampleJobItem")
+	defer m.logger.Trace("returning from CreateSampleJobItem")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entities, err := m.checkNewItems([]model.SampleJobItem{i}, nil)
+	if err != nil {
+		return err
+	}
+	m.insertItems(entities)
+	return nil
+}
+
+// UpdateSampleJobItem updates an existing sample job item if its stored
+// version is i.Version, and increments the version, both stored and on i.
+// Returns sql.ErrNoRows if the item does not exist and ErrVersionConflict if
+// it was updated since i was read.
+func (m *MemoryStore) UpdateSampleJobItem(ctx context.Context, i *model.SampleJobItem) error {
+	m.logger.WithField("sample_job_item_id", i.ID).Trace("entering UpdateSampleJobItem")
+	defer m.logger.Trace("returning from UpdateSampleJobItem")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.items[i.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if r.entity.Version != i.Version {
+		return fmt.Errorf("%w: sample_job_items row %s is at version %d, not %d", ErrVersionConflict, i.ID, r.entity.Version, i.Version)
+	}
+	entity := sampleJobItemModelToEntity(*i)
+	if _, ok := m.jobs[entity.JobID]; !ok {
+		return fmt.Errorf("updating sample job item: %w", errForeignKeyConstraint)
+	}
+	entity.CreatedAt = r.entity.CreatedAt
+	entity.Version = r.entity.Version + 1
+	r.entity = entity
+	m.items[i.ID] = r
+	i.Version = entity.Version
+	return nil
+}
+
+// AverageItemDuration returns the mean duration of the most recent completed
+// sample job items that recorded a duration, considering at most limit items.
+// Returns ok=false when no completed item has timing data.
+func (m *MemoryStore) AverageItemDuration(ctx context.Context, limit int) (avg time.Duration, ok bool, err error) {
+	m.logger.WithField("limit", limit).Trace("entering AverageItemDuration")
+	defer m.logger.Trace("returning from AverageItemDuration")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := sortedRows(m.items, func(e sampleJobItemEntity) bool {
+		return e.Status == string(model.SampleJobItemStatusCompleted) && e.DurationMs.Valid
+	}, func(a, b memoryRow[sampleJobItemEntity]) int {
+		// NULL sorts first in SQLite, so it comes last in descending order.
+		return cmp.Or(
+			-compareNullStrings(a.entity.CompletedAt, b.entity.CompletedAt),
+			cmp.Compare(a.seq, b.seq),
+		)
+	})
+	if limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+	var total float64
+	for _, r := range rows {
+		total += float64(r.entity.DurationMs.Int64)
+	}
+	return time.Duration(total / float64(len(rows)) * float64(time.Millisecond)), true, nil
+}
+
+// SummarizeTrainingRunJobs aggregates the sample jobs of a training run that
+// are not in the trash, and their finished items per checkpoint.
+func (m *MemoryStore) SummarizeTrainingRunJobs(ctx context.Context, trainingRunName string) (model.TrainingRunJobStats, error) {
+	m.logger.WithField("training_run", trainingRunName).Trace("entering SummarizeTrainingRunJobs")
+	defer m.logger.Trace("returning from SummarizeTrainingRunJobs")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := model.TrainingRunJobStats{
+		JobStatusCounts: make(map[model.SampleJobStatus]int),
+		Checkpoints:     make(map[string]model.CheckpointJobStats),
+	}
+	for _, r := range m.jobs {
+		if r.entity.TrainingRunName == trainingRunName && !r.entity.DeletedAt.Valid {
+			stats.JobStatusCounts[model.SampleJobStatus(r.entity.Status)]++
+		}
+	}
+	// The latest completion per checkpoint, compared as strings like
+	// SQLite's MAX.
+	lastCompletedAt := make(map[string]sql.NullString)
+	for _, r := range m.items {
+		job, ok := m.jobs[r.entity.JobID]
+		if !ok || job.entity.TrainingRunName != trainingRunName || job.entity.DeletedAt.Valid {
+			continue
+		}
+		checkpoint := r.entity.CheckpointFilename
+		c := stats.Checkpoints[checkpoint]
+		switch model.SampleJobItemStatus(r.entity.Status) {
+		case model.SampleJobItemStatusCompleted:
+			c.CompletedItems++
+			if compareNullStrings(r.entity.CompletedAt, lastCompletedAt[checkpoint]) > 0 {
+				lastCompletedAt[checkpoint] = r.entity.CompletedAt
+			}
+		case model.SampleJobItemStatusFailed, model.SampleJobItemStatusSkipped:
+			c.FailedItems++
+		default:
+			continue
+		}
+		stats.Checkpoints[checkpoint] = c
+	}
+	for checkpoint, completedAt := range lastCompletedAt {
+		t, err := parseNullTime(completedAt)
+		if err != nil {
+			return model.TrainingRunJobStats{}, fmt.Errorf("parsing completed_at: %w", err)
+		}
+		c := stats.Checkpoints[checkpoint]
+		c.LastCompletedAt = t
+		stats.Checkpoints[checkpoint] = c
+	}
+	return stats, nil
+}
+
+// GetSampleJobParameters returns the parameter snapshot of a sample job, or
+// sql.ErrNoRows if the job has none.
+func (m *MemoryStore) GetSampleJobParameters(ctx context.Context, jobID string) (model.SampleJobParameters, error) {
+	m.logger.WithField("sample_job_id", jobID).Trace("entering GetSampleJobParameters")
+	defer m.logger.Trace("returning from GetSampleJobParameters")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.params[jobID]
+	if !ok {
+		return model.SampleJobParameters{}, sql.ErrNoRows
+	}
+	return sampleJobParametersEntityToModel(e)
+}
+
+// CreateSampleJobParameters stores the parameter snapshot of a sample job.
+// A job keeps its first snapshot; storing another one is a no-op.
+func (m *MemoryStore) CreateSampleJobParameters(ctx context.Context, p model.SampleJobParameters) error {
+	m.logger.WithField("sample_job_id", p.JobID).Trace("entering CreateSampleJobParameters")
+	defer m.logger.Trace("returning from CreateSampleJobParameters")
+
+	e, err := sampleJobParametersModelToEntity(p)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.jobs[p.JobID]; !ok {
+		return errForeignKeyConstraint
+	}
+	if _, ok := m.params[p.JobID]; !ok {
+		m.params[p.JobID] = e
+	}
+	return nil
+}
+
+// checkNewStudy returns the error of inserting entity, if any. The caller
+// must hold mu.
+func (m *MemoryStore) checkNewStudy(entity studyEntity) error {
+	if _, ok := m.studies[entity.ID]; ok {
+		return errUniqueConstraint("studies.id")
+	}
+	for _, r := range m.studies {
+		if r.entity.Name == entity.Name {
+			return errUniqueConstraint("studies.name")
+		}
+	}
+	return nil
+}
+
+// insertStudy inserts entity. The caller must hold mu.
+func (m *MemoryStore) insertStudy(entity studyEntity) {
+	m.studies[entity.ID] = memoryRow[studyEntity]{seq: m.nextSeq(), entity: copyStudyEntity(entity)}
+}
+
+// checkNewJob returns the error of inserting entity, if any. pendingStudy is
+// a study inserted in the same operation, or nil. The caller must hold mu.
+func (m *MemoryStore) checkNewJob(entity sampleJobEntity, pendingStudy *studyEntity) error {
+	if _, ok := m.jobs[entity.ID]; ok {
+		return errUniqueConstraint("sample_jobs.id")
+	}
+	if _, ok := m.studies[entity.StudyID]; !ok && (pendingStudy == nil || pendingStudy.ID != entity.StudyID) {
+		return errForeignKeyConstraint
+	}
+	return nil
+}
+
+// insertJob inserts entity at version 1. The caller must hold mu.
+func (m *MemoryStore) insertJob(entity sampleJobEntity) {
+	entity.Version = 1
+	m.jobs[entity.ID] = memoryRow[sampleJobEntity]{seq: m.nextSeq(), entity: entity}
+}
+
+// updateJob replaces the job with j's ID if its version is j.Version, keeping
+// its creation time and incrementing the version. The caller must hold mu.
+func (m *MemoryStore) updateJob(j model.SampleJob) error {
+	r, ok := m.jobs[j.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if r.entity.Version != j.Version {
+		return fmt.Errorf("%w: sample_jobs row %s is at version %d, not %d", ErrVersionConflict, j.ID, r.entity.Version, j.Version)
+	}
+	entity := sampleJobModelToEntity(j)
+	if _, ok := m.studies[entity.StudyID]; !ok {
+		return fmt.Errorf("updating sample job: %w", errForeignKeyConstraint)
+	}
+	entity.Owner = r.entity.Owner
+	entity.DeletedAt = r.entity.DeletedAt
+	entity.DeleteData = r.entity.DeleteData
+	entity.CreatedAt = r.entity.CreatedAt
+	entity.Version = r.entity.Version + 1
+	r.entity = entity
+	m.jobs[j.ID] = r
+	return nil
+}
+
+// deleteJob removes the job with id and its items. The caller must hold mu.
+func (m *MemoryStore) deleteJob(id string) {
+	delete(m.jobs, id)
+	delete(m.params, id)
+	for itemID, r := range m.items {
+		if r.entity.JobID == id {
+			delete(m.items, itemID)
+		}
+	}
+}
+
+// checkNewItems converts items to entities, returning the error of inserting
+// any of them. pendingJob is a job inserted in the same operation, or nil.
+// The caller must hold mu.
+func (m *MemoryStore) checkNewItems(items []model.SampleJobItem, pendingJob *sampleJobEntity) ([]sampleJobItemEntity, error) {
+	entities := make([]sampleJobItemEntity, len(items))
+	seen := make(map[string]bool, len(items))
+	for n, i := range items {
+		_, exists := m.items[i.ID]
+		if exists || seen[i.ID] {
+			return nil, fmt.Errorf("inserting sample job item %s: %w", i.ID, errUniqueConstraint("sample_job_items.id"))
+		}
+		if _, ok := m.jobs[i.JobID]; !ok && (pendingJob == nil || pendingJob.ID != i.JobID) {
+			return nil, fmt.Errorf("inserting sample job item %s: %w", i.ID, errForeignKeyConstraint)
+		}
+		seen[i.ID] = true
+		entities[n] = sampleJobItemModelToEntity(i)
+	}
+	return entities, nil
+}
+
+// insertItems inserts entities in order, at version 1. The caller must hold
+// mu.
+func (m *MemoryStore) insertItems(entities []sampleJobItemEntity) {
+	for _, e := range entities {
+		e.Version = 1
+		m.items[e.ID] = memoryRow[sampleJobItemEntity]{seq: m.nextSeq(), entity: e}
+	}
+}
+
+// sortedRows returns the rows for which keep returns true (all rows if keep
+// is nil), sorted by compare.
+func sortedRows[E any](rows map[string]memoryRow[E], keep func(E) bool, compare func(a, b memoryRow[E]) int) []memoryRow[E] {
+	var result []memoryRow[E]
+	for _, r := range rows {
+		if keep == nil || keep(r.entity) {
+			result = append(result, r)
+		}
+	}
+	slices.SortFunc(result, compare)
+	return result
+}
+
+// compareSeq orders rows by insertion.
+func compareSeq[E any](a, b memoryRow[E]) int {
+	return cmp.Compare(a.seq, b.seq)
+}
+
+// pageRows returns the rows in page, like LIMIT and OFFSET.
+func pageRows[E any](rows []memoryRow[E], page model.Page) []memoryRow[E] {
+	if page.Offset > 0 {
+		if page.Offset >= len(rows) {
+			return nil
+		}
+		rows = rows[page.Offset:]
+	}
+	if page.Limit > 0 && page.Limit < len(rows) {
+		rows = rows[:page.Limit]
+	}
+	return rows
+}
+
+// sampleJobItemMatches reports whether e is an item of jobID that matches
+// filter, like sampleJobItemWhere.
+func sampleJobItemMatches(e sampleJobItemEntity, jobID string, filter model.SampleJobItemFilter) bool {
+	return e.JobID == jobID &&
+		(filter.Status == "" || e.Status == string(filter.Status)) &&
+		(filter.CheckpointFilename == "" || e.CheckpointFilename == filter.CheckpointFilename) &&
+		(filter.PromptName == "" || e.PromptName == filter.PromptName) &&
+		(filter.SamplerName == "" || e.SamplerName == filter.SamplerName) &&
+		(filter.SkipReason == "" || e.SkipReason == string(filter.SkipReason))
+}
+
+// compareSampleJobItems orders items like sampleJobItemOrderBy.
+func compareSampleJobItems(a, b memoryRow[sampleJobItemEntity], query model.SampleJobItemQuery) int {
+	dir := 1
+	if query.Descending {
+		dir = -1
+	}
+	switch query.Sort {
+	case model.SampleJobItemSortDuration:
+		// Items without a duration come last in either direction.
+		if a.entity.DurationMs.Valid != b.entity.DurationMs.Valid {
+			if a.entity.DurationMs.Valid {
+				return -1
+			}
+			return 1
+		}
+		return dir * cmp.Or(cmp.Compare(a.entity.DurationMs.Int64, b.entity.DurationMs.Int64), cmp.Compare(a.seq, b.seq))
+	case model.SampleJobItemSortSeed:
+		return dir * cmp.Or(cmp.Compare(a.entity.Seed, b.entity.Seed), cmp.Compare(a.seq, b.seq))
+	case model.SampleJobItemSortScore:
+		// Unscored items come last in either direction.
+		as, aok := entityScore(a.entity, query.ScoreName)
+		bs, bok := entityScore(b.entity, query.ScoreName)
+		if aok != bok {
+			if aok {
+				return -1
+			}
+			return 1
+		}
+		return dir * cmp.Or(cmp.Compare(as, bs), cmp.Compare(a.seq, b.seq))
+	default:
+		return dir * cmp.Or(cmp.Compare(a.entity.CreatedAt, b.entity.CreatedAt), cmp.Compare(a.seq, b.seq))
+	}
+}
+
+// entityScore returns the score of an item entity by name, and whether the
+// item has that score.
+func entityScore(e sampleJobItemEntity, name string) (float64, bool) {
+	if e.Scores == "" {
+		return 0, false
+	}
+	var scores map[string]float64
+	if err := json.Unmarshal([]byte(e.Scores), &scores); err != nil {
+		return 0, false
+	}
+	score, ok := scores[name]
+	return score, ok
+}
+
+// compareNullStrings orders nullable strings with NULL first, as SQLite does.
+func compareNullStrings(a, b sql.NullString) int {
+	if a.Valid != b.Valid {
+		if a.Valid {
+			return 1
+		}
+		return -1
+	}
+	return cmp.Compare(a.String, b.String)
+}
+
+// copyStudyEntity returns e with its own copy of the shift value, so that
+// stored studies do not alias the caller's.
+func copyStudyEntity(e studyEntity) studyEntity {
+	if e.Shift != nil {
+		shift := *e.Shift
+		e.Shift = &shift
+	}
+	return e
+}
+
+// memoryStudyToModel converts a stored study entity to its model without
+// exposing the stored shift value.
+func memoryStudyToModel(e studyEntity) (model.Study, error) {
+	return studyEntityToModel(copyStudyEntity(e))
+}
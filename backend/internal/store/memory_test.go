@@ -0,0 +1,838 @@
+package store_test
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+// contractStore is implemented by both Store and MemoryStore.
+type contractStore interface {
+	store.PresetStore
+	store.StudyStore
+	store.SampleJobStore
+}
+
+// The same specs run against the SQLite store and the in-memory store, so the
+// in-memory store can stand in for the real one.
+var _ = Describe("MemoryStore", func() {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	describeStoreContract("SQLite store", func() contractStore {
+		tmpDir, err := os.MkdirTemp("", "store-contract-test-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, tmpDir)
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+		st, err := store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(st.Close)
+		return st
+	})
+
+	describeStoreContract("in-memory store", func() contractStore {
+		return store.NewMemoryStore(logger)
+	})
+})
+
+func describeStoreContract(name string, newStore func() contractStore) {
+	Describe(name, func() {
+		var (
+			st  contractStore
+			now time.Time
+		)
+
+		BeforeEach(func() {
+			st = newStore()
+			now = time.Date(2026, 3, 4, 5, 6, 7, 890, time.UTC)
+		})
+
+		study := func(id, name string) model.Study {
+			shift := 3.0
+			return model.Study{
+				ID:                    id,
+				Name:                  name,
+				Version:               1,
+				Prompts:               []model.NamedPrompt{{Name: "forest", Text: "a forest"}},
+				Steps:                 []int{20},
+				CFGs:                  []float64{7},
+				SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "simple"}},
+				Seeds:                 []int64{42},
+				SeedMode:              model.SeedModeExplicit,
+				Width:                 512,
+				Height:                512,
+				Shift:                 &shift,
+				CreatedAt:             now,
+				UpdatedAt:             now,
+			}
+		}
+		job := func(id, studyID string, createdAt time.Time) model.SampleJob {
+			return model.SampleJob{
+				ID:              id,
+				TrainingRunName: "run",
+				StudyID:         studyID,
+				StudyName:       "Study",
+				StudyVersion:    1,
+				WorkflowName:    "flow.json",
+				Status:          model.SampleJobStatusPending,
+				Version:         1,
+				CreatedAt:       createdAt,
+				UpdatedAt:       createdAt,
+			}
+		}
+		item := func(id, jobID string, seed int64, durationMs *int64) model.SampleJobItem {
+			return model.SampleJobItem{
+				ID:                 id,
+				JobID:              jobID,
+				CheckpointFilename: "a.safetensors",
+				PromptName:         "forest",
+				Seed:               seed,
+				Status:             model.SampleJobItemStatusPending,
+				DurationMs:         durationMs,
+				Version:            1,
+				CreatedAt:          now,
+				UpdatedAt:          now,
+			}
+		}
+		ms := func(v int64) *int64 { return &v }
+		itemIDs := func(items []model.SampleJobItem) []string {
+			ids := make([]string, len(items))
+			for i, it := range items {
+				ids[i] = it.ID
+			}
+			return ids
+		}
+
+		Describe("presets", func() {
+			It("round-trips presets ordered by name", func() {
+				Expect(st.CreatePreset(ctx, model.Preset{ID: "p2", Name: "Zeta", CreatedAt: now, UpdatedAt: now})).To(Succeed())
+				Expect(st.CreatePreset(ctx, model.Preset{ID: "p1", Name: "Alpha", Mapping: model.PresetMapping{X: "cfg"}, CreatedAt: now, UpdatedAt: now})).To(Succeed())
+
+				presets, err := st.ListPresets(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(presets).To(HaveLen(2))
+				Expect(presets[0].Name).To(Equal("Alpha"))
+				Expect(presets[0].Mapping).To(Equal(model.PresetMapping{X: "cfg", Combos: []string{}}))
+				Expect(presets[0].CreatedAt).To(Equal(now.Truncate(time.Second)))
+			})
+
+			It("round-trips the training run, view and default flag", func() {
+				view := model.PresetView{
+					Filters: map[string][]string{"seed": {"420"}},
+					Sort:    []model.PresetSort{{Dimension: "checkpoint", Descending: true}, {Dimension: "cfg"}},
+				}
+				Expect(st.CreatePreset(ctx, model.Preset{ID: "p1", Name: "One", TrainingRun: "my-lora", View: view, Default: true, CreatedAt: now, UpdatedAt: now})).To(Succeed())
+
+				p, err := st.GetPreset(ctx, "p1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(p.TrainingRun).To(Equal("my-lora"))
+				Expect(p.View).To(Equal(view))
+				Expect(p.Default).To(BeTrue())
+			})
+
+			It("keeps one default preset per training run", func() {
+				Expect(st.CreatePreset(ctx, model.Preset{ID: "p1", Name: "One", TrainingRun: "a", Default: true, CreatedAt: now, UpdatedAt: now})).To(Succeed())
+				Expect(st.CreatePreset(ctx, model.Preset{ID: "p2", Name: "Two", TrainingRun: "b", Default: true, CreatedAt: now, UpdatedAt: now})).To(Succeed())
+				Expect(st.CreatePreset(ctx, model.Preset{ID: "p3", Name: "Three", TrainingRun: "a", CreatedAt: now, UpdatedAt: now})).To(Succeed())
+
+				Expect(st.UpdatePreset(ctx, model.Preset{ID: "p3", Name: "Three", TrainingRun: "a", Default: true, CreatedAt: now, UpdatedAt: now})).To(Succeed())
+
+				presets, err := st.ListPresets(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				defaults := map[string]bool{}
+				for _, p := range presets {
+					defaults[p.ID] = p.Default
+				}
+				Expect(defaults).To(Equal(map[string]bool{"p1": false, "p2": true, "p3": true}))
+			})
+
+			It("rejects a duplicate ID", func() {
+				Expect(st.CreatePreset(ctx, model.Preset{ID: "p1", Name: "One", CreatedAt: now, UpdatedAt: now})).To(Succeed())
+				Expect(st.CreatePreset(ctx, model.Preset{ID: "p1", Name: "Two", CreatedAt: now, UpdatedAt: now})).To(MatchError(ContainSubstring("UNIQUE constraint failed")))
+			})
+
+			It("keeps the creation time on update", func() {
+				Expect(st.CreatePreset(ctx, model.Preset{ID: "p1", Name: "One", CreatedAt: now, UpdatedAt: now})).To(Succeed())
+				later := now.Add(time.Hour)
+				Expect(st.UpdatePreset(ctx, model.Preset{ID: "p1", Name: "Renamed", CreatedAt: later, UpdatedAt: later})).To(Succeed())
+
+				p, err := st.GetPreset(ctx, "p1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(p.Name).To(Equal("Renamed"))
+				Expect(p.CreatedAt).To(Equal(now.Truncate(time.Second)))
+				Expect(p.UpdatedAt).To(Equal(later.Truncate(time.Second)))
+			})
+
+			It("returns sql.ErrNoRows for an unknown preset", func() {
+				_, err := st.GetPreset(ctx, "missing")
+				Expect(err).To(Equal(sql.ErrNoRows))
+				Expect(st.UpdatePreset(ctx, model.Preset{ID: "missing"})).To(Equal(sql.ErrNoRows))
+				Expect(st.DeletePreset(ctx, "missing")).To(Equal(sql.ErrNoRows))
+			})
+		})
+
+		Describe("studies", func() {
+			It("round-trips a study", func() {
+				s := study("s1", "Sweep")
+				Expect(st.CreateStudy(ctx, s)).To(Succeed())
+
+				got, err := st.GetStudy(ctx, "s1")
+				Expect(err).NotTo(HaveOccurred())
+				s.CreatedAt = now.Truncate(time.Second)
+				s.UpdatedAt = now.Truncate(time.Second)
+				Expect(got).To(Equal(s))
+			})
+
+			It("round-trips the img2img fields of a study", func() {
+				s := study("s1", "Sweep")
+				s.InputImage = "/assets/image/ref.png"
+				s.DenoiseStrengths = []float64{0.4, 0.7}
+				Expect(st.CreateStudy(ctx, s)).To(Succeed())
+
+				got, err := st.GetStudy(ctx, "s1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.InputImage).To(Equal("/assets/image/ref.png"))
+				Expect(got.DenoiseStrengths).To(Equal([]float64{0.4, 0.7}))
+			})
+
+			It("round-trips the resolutions of a study", func() {
+				s := study("s1", "Sweep")
+				s.Resolutions = []model.Resolution{{Width: 832, Height: 1216}, {Width: 1216, Height: 832}}
+				Expect(st.CreateStudy(ctx, s)).To(Succeed())
+
+				got, err := st.GetStudy(ctx, "s1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Resolutions).To(Equal(s.Resolutions))
+			})
+
+			It("round-trips the wildcards of a study", func() {
+				s := study("s1", "Sweep")
+				s.Wildcards = []model.Wildcard{{Name: "color", Values: []string{"red", "blue"}}}
+				Expect(st.CreateStudy(ctx, s)).To(Succeed())
+
+				got, err := st.GetStudy(ctx, "s1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Wildcards).To(Equal(s.Wildcards))
+			})
+
+			It("round-trips the seed mode of a study", func() {
+				s := study("s1", "Sweep")
+				s.Seeds = nil
+				s.SeedMode = model.SeedModeRandomPerCheckpoint
+				s.RandomSeedCount = 4
+				Expect(st.CreateStudy(ctx, s)).To(Succeed())
+
+				got, err := st.GetStudy(ctx, "s1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.SeedMode).To(Equal(model.SeedModeRandomPerCheckpoint))
+				Expect(got.RandomSeedCount).To(Equal(4))
+			})
+
+			It("round-trips per-prompt overrides", func() {
+				s := study("s1", "Sweep")
+				s.Prompts = []model.NamedPrompt{
+					{Name: "portrait", Text: "a portrait", Steps: []int{20}, CFGs: []float64{3.5}, Seeds: []int64{7}, Width: 832, Height: 1216},
+					{Name: "plain", Text: "a plain prompt"},
+				}
+				Expect(st.CreateStudy(ctx, s)).To(Succeed())
+
+				got, err := st.GetStudy(ctx, "s1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Prompts).To(Equal(s.Prompts))
+			})
+
+			It("enforces unique names", func() {
+				Expect(st.CreateStudy(ctx, study("s1", "Sweep"))).To(Succeed())
+				Expect(st.CreateStudy(ctx, study("s2", "Sweep"))).To(MatchError(ContainSubstring("UNIQUE constraint failed")))
+				Expect(st.CreateStudy(ctx, study("s2", "Other"))).To(Succeed())
+				Expect(st.UpdateStudy(ctx, study("s2", "Sweep"))).To(MatchError(ContainSubstring("UNIQUE constraint failed")))
+			})
+
+			It("finds studies by name, excluding an ID", func() {
+				Expect(st.CreateStudy(ctx, study("s1", "Sweep"))).To(Succeed())
+
+				found, err := st.GetStudyByName(ctx, "Sweep", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found.ID).To(Equal("s1"))
+				_, err = st.GetStudyByName(ctx, "Sweep", "s1")
+				Expect(err).To(Equal(sql.ErrNoRows))
+			})
+
+			It("deletes a study's jobs and items with it", func() {
+				Expect(st.CreateStudy(ctx, study("s1", "Sweep"))).To(Succeed())
+				Expect(st.CreateSampleJobWithItems(ctx, job("j1", "s1", now), []model.SampleJobItem{item("i1", "j1", 1, nil)})).To(Succeed())
+
+				Expect(st.DeleteStudy(ctx, "s1")).To(Succeed())
+				_, err := st.GetSampleJob(ctx, "j1")
+				Expect(err).To(Equal(sql.ErrNoRows))
+				items, err := st.ListSampleJobItems(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(BeEmpty())
+				Expect(st.DeleteStudy(ctx, "s1")).To(Equal(sql.ErrNoRows))
+			})
+
+			Describe("versions", func() {
+				BeforeEach(func() {
+					Expect(st.CreateStudy(ctx, study("s1", "Sweep"))).To(Succeed())
+				})
+
+				It("archives versions immutably, oldest first", func() {
+					v2 := study("s1", "Sweep")
+					v2.Version = 2
+					v2.Steps = []int{30}
+					Expect(st.CreateStudyVersion(ctx, model.StudyVersion{StudyID: "s1", Version: 2, Study: v2, CreatedAt: now})).To(Succeed())
+					Expect(st.CreateStudyVersion(ctx, model.StudyVersion{StudyID: "s1", Version: 1, Study: study("s1", "Sweep"), CreatedAt: now})).To(Succeed())
+					replaced := study("s1", "Replaced")
+					Expect(st.CreateStudyVersion(ctx, model.StudyVersion{StudyID: "s1", Version: 1, Study: replaced, CreatedAt: now})).To(Succeed())
+
+					versions, err := st.ListStudyVersions(ctx, "s1")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(versions).To(HaveLen(2))
+					Expect(versions[0].Version).To(Equal(1))
+					Expect(versions[0].Study.Name).To(Equal("Sweep"))
+					Expect(versions[0].CreatedAt).To(Equal(now.Truncate(time.Second)))
+					Expect(versions[1].Version).To(Equal(2))
+					Expect(versions[1].Study.Steps).To(Equal([]int{30}))
+				})
+
+				It("rejects versions of a missing study", func() {
+					Expect(st.CreateStudyVersion(ctx, model.StudyVersion{StudyID: "missing", Version: 1, Study: study("missing", "Gone"), CreatedAt: now})).
+						To(MatchError(ContainSubstring("FOREIGN KEY constraint failed")))
+				})
+
+				It("reports whether a job was created from a version", func() {
+					j := job("j1", "s1", now)
+					j.StudyVersion = 2
+					Expect(st.CreateSampleJob(ctx, j)).To(Succeed())
+
+					inUse, err := st.StudyVersionInUse(ctx, "s1", 1)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(inUse).To(BeFalse())
+					inUse, err = st.StudyVersionInUse(ctx, "s1", 2)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(inUse).To(BeTrue())
+
+					got, err := st.GetSampleJob(ctx, "j1")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(got.StudyVersion).To(Equal(2))
+				})
+
+				It("deletes a study's versions with it", func() {
+					Expect(st.CreateStudyVersion(ctx, model.StudyVersion{StudyID: "s1", Version: 1, Study: study("s1", "Sweep"), CreatedAt: now})).To(Succeed())
+					Expect(st.DeleteStudy(ctx, "s1")).To(Succeed())
+
+					versions, err := st.ListStudyVersions(ctx, "s1")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(versions).To(BeEmpty())
+				})
+			})
+		})
+
+		Describe("sample jobs", func() {
+			BeforeEach(func() {
+				Expect(st.CreateStudy(ctx, study("s1", "Sweep"))).To(Succeed())
+			})
+
+			It("round-trips a job and its items", func() {
+				j := job("j1", "s1", now)
+				j.CheckpointFilenames = nil
+				started := now.Add(time.Second)
+				i := item("i1", "j1", 7, ms(1500))
+				i.StartedAt = &started
+				Expect(st.CreateSampleJobWithItems(ctx, j, []model.SampleJobItem{i})).To(Succeed())
+
+				gotJob, err := st.GetSampleJob(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gotJob.CheckpointFilenames).To(Equal([]string{}))
+				Expect(gotJob.OutputFormat).To(Equal(model.OutputFormatPNG))
+				Expect(gotJob.CreatedAt).To(Equal(now.Truncate(time.Second)))
+
+				items, err := st.ListSampleJobItems(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(HaveLen(1))
+				Expect(*items[0].StartedAt).To(Equal(started))
+				Expect(*items[0].DurationMs).To(Equal(int64(1500)))
+				Expect(items[0].Denoise).To(BeNil())
+			})
+
+			It("round-trips the input image of a job and the denoise strength of its items", func() {
+				j := job("j1", "s1", now)
+				j.InputImage = "/assets/image/ref.png"
+				denoise := 0.6
+				i := item("i1", "j1", 7, nil)
+				i.Denoise = &denoise
+				Expect(st.CreateSampleJobWithItems(ctx, j, []model.SampleJobItem{i})).To(Succeed())
+
+				gotJob, err := st.GetSampleJob(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gotJob.InputImage).To(Equal("/assets/image/ref.png"))
+				items, err := st.ListSampleJobItems(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items[0].Denoise).To(HaveValue(Equal(0.6)))
+			})
+
+			It("round-trips the wildcard values of an item", func() {
+				i := item("i1", "j1", 7, nil)
+				i.Wildcards = map[string]string{"color": "red"}
+				Expect(st.CreateSampleJobWithItems(ctx, job("j1", "s1", now), []model.SampleJobItem{i, item("i2", "j1", 8, nil)})).To(Succeed())
+
+				items, err := st.ListSampleJobItems(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items[0].Wildcards).To(Equal(map[string]string{"color": "red"}))
+				Expect(items[1].Wildcards).To(BeNil())
+			})
+
+			It("round-trips the resolution of an item", func() {
+				i := item("i1", "j1", 7, nil)
+				i.Resolution = "832x1216"
+				Expect(st.CreateSampleJobWithItems(ctx, job("j1", "s1", now), []model.SampleJobItem{i})).To(Succeed())
+
+				items, err := st.ListSampleJobItems(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items[0].Resolution).To(Equal("832x1216"))
+			})
+
+			It("round-trips the ControlNet settings of a job", func() {
+				j := job("j1", "s1", now)
+				Expect(st.CreateSampleJob(ctx, j)).To(Succeed())
+				got, err := st.GetSampleJob(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.ControlNetModel).To(BeEmpty())
+				Expect(got.ControlNetStrength).To(BeNil())
+
+				strength := 0.8
+				j.ControlNetModel = "control_canny.safetensors"
+				j.ControlNetStrength = &strength
+				j.ControlNetImage = "/assets/image/edges.png"
+				Expect(st.UpdateSampleJob(ctx, &j)).To(Succeed())
+
+				got, err = st.GetSampleJob(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.ControlNetModel).To(Equal("control_canny.safetensors"))
+				Expect(got.ControlNetStrength).To(HaveValue(Equal(0.8)))
+				Expect(got.ControlNetImage).To(Equal("/assets/image/edges.png"))
+			})
+
+			It("keeps the first parameter snapshot of a job and deletes it with the job", func() {
+				Expect(st.CreateSampleJob(ctx, job("j1", "s1", now))).To(Succeed())
+				_, err := st.GetSampleJobParameters(ctx, "j1")
+				Expect(err).To(MatchError(sql.ErrNoRows))
+
+				snapshot := model.SampleJobParameters{
+					JobID: "j1",
+					Study: study("s1", "Sweep"),
+					Workflow: model.WorkflowTemplate{
+						Name:     "flow.json",
+						Workflow: map[string]interface{}{"3": map[string]interface{}{"class_type": "KSampler"}},
+						Roles:    map[string][]string{"sampler": {"3"}},
+					},
+					CreatedAt: now,
+				}
+				Expect(st.CreateSampleJobParameters(ctx, snapshot)).To(Succeed())
+				edited := snapshot
+				edited.Study.Steps = []int{50}
+				Expect(st.CreateSampleJobParameters(ctx, edited)).To(Succeed())
+
+				got, err := st.GetSampleJobParameters(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Study.Steps).To(Equal([]int{20}))
+				Expect(got.Study.Shift).To(HaveValue(Equal(3.0)))
+				Expect(got.Study.Prompts).To(Equal([]model.NamedPrompt{{Name: "forest", Text: "a forest"}}))
+				Expect(got.Workflow).To(Equal(snapshot.Workflow))
+				Expect(got.CreatedAt).To(Equal(now.Truncate(time.Second)))
+
+				Expect(st.DeleteSampleJob(ctx, "j1")).To(Succeed())
+				_, err = st.GetSampleJobParameters(ctx, "j1")
+				Expect(err).To(MatchError(sql.ErrNoRows))
+			})
+
+			It("requires the job of a parameter snapshot to exist", func() {
+				err := st.CreateSampleJobParameters(ctx, model.SampleJobParameters{JobID: "missing", CreatedAt: now})
+				Expect(err).To(MatchError(ContainSubstring("FOREIGN KEY constraint failed")))
+			})
+
+			It("requires the job's study to exist", func() {
+				Expect(st.CreateSampleJob(ctx, job("j1", "missing", now))).To(MatchError(ContainSubstring("FOREIGN KEY constraint failed")))
+			})
+
+			It("inserts neither the job nor its items when an item fails", func() {
+				items := []model.SampleJobItem{item("i1", "j1", 1, nil), item("i1", "j1", 2, nil)}
+				Expect(st.CreateSampleJobWithItems(ctx, job("j1", "s1", now), items)).To(MatchError(ContainSubstring("UNIQUE constraint failed")))
+
+				_, err := st.GetSampleJob(ctx, "j1")
+				Expect(err).To(Equal(sql.ErrNoRows))
+			})
+
+			It("inserts neither the study nor the job when the study's name is taken", func() {
+				err := st.CreateStudyWithSampleJob(ctx, study("s2", "Sweep"), job("j1", "s2", now), nil)
+				Expect(err).To(MatchError(ContainSubstring("UNIQUE constraint failed")))
+
+				_, err = st.GetStudy(ctx, "s2")
+				Expect(err).To(Equal(sql.ErrNoRows))
+				Expect(st.CountSampleJobs(ctx, model.SampleJobFilter{})).To(Equal(0))
+			})
+
+			It("lists jobs oldest first, and newest first by page, breaking ties by insertion", func() {
+				Expect(st.CreateSampleJob(ctx, job("j1", "s1", now))).To(Succeed())
+				Expect(st.CreateSampleJob(ctx, job("j2", "s1", now))).To(Succeed())
+				Expect(st.CreateSampleJob(ctx, job("j0", "s1", now.Add(-time.Hour)))).To(Succeed())
+
+				jobs, err := st.ListSampleJobs(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect([]string{jobs[0].ID, jobs[1].ID, jobs[2].ID}).To(Equal([]string{"j0", "j1", "j2"}))
+
+				jobs, err = st.ListSampleJobsDesc(ctx, model.SampleJobFilter{}, model.Page{Limit: 2, Offset: 1})
+				Expect(err).NotTo(HaveOccurred())
+				Expect([]string{jobs[0].ID, jobs[1].ID}).To(Equal([]string{"j1", "j0"}))
+			})
+
+			It("reports running jobs", func() {
+				j := job("j1", "s1", now)
+				Expect(st.CreateSampleJob(ctx, j)).To(Succeed())
+				Expect(st.HasRunningJob(ctx)).To(BeFalse())
+
+				j.Status = model.SampleJobStatusRunning
+				Expect(st.UpdateSampleJob(ctx, &j)).To(Succeed())
+				Expect(st.HasRunningJob(ctx)).To(BeTrue())
+			})
+
+			It("transitions a job only from its stored status", func() {
+				j := job("j1", "s1", now)
+				Expect(st.CreateSampleJob(ctx, j)).To(Succeed())
+
+				j.Status = model.SampleJobStatusRunning
+				Expect(st.TransitionSampleJob(ctx, &j, model.SampleJobStatusPending)).To(Succeed())
+				Expect(st.GetSampleJob(ctx, "j1")).To(HaveField("Status", model.SampleJobStatusRunning))
+
+				j.Status = model.SampleJobStatusStopped
+				Expect(st.TransitionSampleJob(ctx, &j, model.SampleJobStatusPending)).To(MatchError(store.ErrSampleJobStatusChanged))
+				Expect(st.GetSampleJob(ctx, "j1")).To(HaveField("Status", model.SampleJobStatusRunning))
+
+				missing := job("missing", "s1", now)
+				Expect(st.TransitionSampleJob(ctx, &missing, model.SampleJobStatusPending)).To(Equal(sql.ErrNoRows))
+			})
+
+			It("saves a job update only at the stored version", func() {
+				j := job("j1", "s1", now)
+				Expect(st.CreateSampleJob(ctx, j)).To(Succeed())
+
+				stale := j
+				j.CompletedItems = 1
+				Expect(st.UpdateSampleJob(ctx, &j)).To(Succeed())
+				Expect(j.Version).To(Equal(2))
+				Expect(st.GetSampleJob(ctx, "j1")).To(HaveField("Version", 2))
+
+				// stale still carries version 1.
+				stale.CompletedItems = 2
+				Expect(st.UpdateSampleJob(ctx, &stale)).To(MatchError(store.ErrVersionConflict))
+				stale.Status = model.SampleJobStatusRunning
+				Expect(st.TransitionSampleJob(ctx, &stale, model.SampleJobStatusPending)).To(MatchError(store.ErrVersionConflict))
+				Expect(st.AppendSampleJobItems(ctx, stale, []model.SampleJobItem{item("i1", "j1", 1, nil)})).To(MatchError(store.ErrVersionConflict))
+				Expect(stale.Version).To(Equal(1))
+
+				got, err := st.GetSampleJob(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.CompletedItems).To(Equal(1))
+				Expect(got.Status).To(Equal(model.SampleJobStatusPending))
+				Expect(st.CountSampleJobItems(ctx, "j1", model.SampleJobItemFilter{})).To(BeZero())
+			})
+
+			It("appends items to an existing job only", func() {
+				j := job("j1", "s1", now)
+				Expect(st.CreateSampleJob(ctx, j)).To(Succeed())
+				j.TotalItems = 1
+				Expect(st.AppendSampleJobItems(ctx, j, []model.SampleJobItem{item("i1", "j1", 1, nil)})).To(Succeed())
+				Expect(st.CountSampleJobItems(ctx, "j1", model.SampleJobItemFilter{})).To(Equal(1))
+
+				Expect(st.AppendSampleJobItems(ctx, job("missing", "s1", now), nil)).To(Equal(sql.ErrNoRows))
+			})
+
+			It("deletes a job's items with it", func() {
+				Expect(st.CreateSampleJobWithItems(ctx, job("j1", "s1", now), []model.SampleJobItem{item("i1", "j1", 1, nil)})).To(Succeed())
+				Expect(st.DeleteSampleJob(ctx, "j1")).To(Succeed())
+
+				deleted := item("i1", "j1", 1, nil)
+				Expect(st.UpdateSampleJobItem(ctx, &deleted)).To(Equal(sql.ErrNoRows))
+				Expect(st.DeleteSampleJob(ctx, "j1")).To(Equal(sql.ErrNoRows))
+			})
+
+			It("hides trashed jobs until they are restored", func() {
+				Expect(st.CreateSampleJob(ctx, job("j1", "s1", now))).To(Succeed())
+				j2 := job("j2", "s1", now)
+				j2.Status = model.SampleJobStatusRunning
+				Expect(st.CreateSampleJob(ctx, j2)).To(Succeed())
+
+				Expect(st.TrashSampleJob(ctx, "j2", now, true)).To(Succeed())
+				Expect(st.TrashSampleJob(ctx, "j2", now, true)).To(Equal(sql.ErrNoRows))
+				_, err := st.GetSampleJob(ctx, "j2")
+				Expect(err).To(Equal(sql.ErrNoRows))
+				Expect(st.ListSampleJobs(ctx)).To(HaveLen(1))
+				Expect(st.CountSampleJobs(ctx, model.SampleJobFilter{})).To(Equal(1))
+				Expect(st.HasRunningJob(ctx)).To(BeFalse())
+
+				trashed, err := st.GetTrashedSampleJob(ctx, "j2")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(trashed.DeletedAt).To(HaveValue(Equal(now.Truncate(time.Second))))
+				Expect(trashed.DeleteData).To(BeTrue())
+				Expect(trashed.Version).To(Equal(2))
+				Expect(st.ListTrashedSampleJobs(ctx, model.OwnerScope{})).To(HaveLen(1))
+				_, err = st.GetTrashedSampleJob(ctx, "j1")
+				Expect(err).To(Equal(sql.ErrNoRows))
+
+				Expect(st.RestoreSampleJob(ctx, "j2")).To(Succeed())
+				Expect(st.RestoreSampleJob(ctx, "j2")).To(Equal(sql.ErrNoRows))
+				got, err := st.GetSampleJob(ctx, "j2")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.DeletedAt).To(BeNil())
+				Expect(got.DeleteData).To(BeFalse())
+				Expect(st.ListTrashedSampleJobs(ctx, model.OwnerScope{})).To(BeEmpty())
+			})
+
+			It("lists trashed jobs in scope, most recently deleted first", func() {
+				for _, id := range []string{"j1", "j2", "j3"} {
+					j := job(id, "s1", now)
+					if id == "j3" {
+						j.Owner = "bob"
+					}
+					Expect(st.CreateSampleJob(ctx, j)).To(Succeed())
+				}
+				Expect(st.TrashSampleJob(ctx, "j1", now.Add(time.Hour), false)).To(Succeed())
+				Expect(st.TrashSampleJob(ctx, "j2", now, false)).To(Succeed())
+				Expect(st.TrashSampleJob(ctx, "j3", now, false)).To(Succeed())
+
+				jobs, err := st.ListTrashedSampleJobs(ctx, model.OwnerScope{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect([]string{jobs[0].ID, jobs[1].ID, jobs[2].ID}).To(Equal([]string{"j1", "j3", "j2"}))
+
+				jobs, err = st.ListTrashedSampleJobs(ctx, model.OwnerScope{Owner: "bob"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(jobs).To(HaveLen(1))
+				Expect(jobs[0].ID).To(Equal("j3"))
+			})
+
+			It("keeps a job in the trash when it is updated", func() {
+				j := job("j1", "s1", now)
+				Expect(st.CreateSampleJob(ctx, j)).To(Succeed())
+				Expect(st.TrashSampleJob(ctx, "j1", now, true)).To(Succeed())
+
+				j.Version = 2
+				j.Status = model.SampleJobStatusStopped
+				Expect(st.UpdateSampleJob(ctx, &j)).To(Succeed())
+				got, err := st.GetTrashedSampleJob(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Status).To(Equal(model.SampleJobStatusStopped))
+				Expect(got.DeleteData).To(BeTrue())
+			})
+
+			It("stores notes and labels and filters jobs by label", func() {
+				j1 := job("j1", "s1", now)
+				j1.Notes = "lr=1e-5 experiment, bad data mix"
+				j1.Labels = map[string]string{"lr": "1e-5", "data": "mix-b"}
+				j2 := job("j2", "s1", now)
+				j2.Labels = map[string]string{"lr": "3e-5"}
+				j3 := job("j3", "s1", now)
+				for _, j := range []model.SampleJob{j1, j2, j3} {
+					Expect(st.CreateSampleJob(ctx, j)).To(Succeed())
+				}
+
+				got, err := st.GetSampleJob(ctx, "j1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Notes).To(Equal(j1.Notes))
+				Expect(got.Labels).To(Equal(j1.Labels))
+				got, err = st.GetSampleJob(ctx, "j3")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Labels).To(BeNil())
+
+				ids := func(filter model.SampleJobFilter) []string {
+					jobs, err := st.ListSampleJobsDesc(ctx, filter, model.Page{})
+					Expect(err).NotTo(HaveOccurred())
+					var ids []string
+					for _, j := range jobs {
+						ids = append(ids, j.ID)
+					}
+					return ids
+				}
+				value := func(v string) *string { return &v }
+				Expect(ids(model.SampleJobFilter{Labels: []model.LabelSelector{{Key: "lr"}}})).To(ConsistOf("j1", "j2"))
+				Expect(ids(model.SampleJobFilter{Labels: []model.LabelSelector{{Key: "lr", Value: value("3e-5")}}})).To(ConsistOf("j2"))
+				Expect(ids(model.SampleJobFilter{Labels: []model.LabelSelector{{Key: "lr"}, {Key: "data", Value: value("mix-b")}}})).To(ConsistOf("j1"))
+				Expect(ids(model.SampleJobFilter{Labels: []model.LabelSelector{{Key: "data", Value: value("mix-a")}}})).To(BeEmpty())
+				Expect(st.CountSampleJobs(ctx, model.SampleJobFilter{Labels: []model.LabelSelector{{Key: "lr"}}})).To(Equal(2))
+
+				got.Version = 1
+				got.Notes = "baseline"
+				got.Labels = map[string]string{"baseline": ""}
+				Expect(st.UpdateSampleJob(ctx, &got)).To(Succeed())
+				Expect(ids(model.SampleJobFilter{Labels: []model.LabelSelector{{Key: "baseline", Value: value("")}}})).To(ConsistOf("j3"))
+				got, err = st.GetSampleJob(ctx, "j3")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got.Notes).To(Equal("baseline"))
+			})
+
+			Describe("items", func() {
+				BeforeEach(func() {
+					completed := item("i2", "j1", 5, ms(300))
+					completed.Status = model.SampleJobItemStatusCompleted
+					completed.Scores = map[string]float64{"aesthetic": 4}
+					scored := item("i4", "j1", 3, ms(200))
+					scored.Scores = map[string]float64{"aesthetic": 6}
+					skipped := item("i3", "j1", 1, nil)
+					skipped.Status = model.SampleJobItemStatusSkipped
+					skipped.SkipReason = model.SampleJobItemSkipReasonCheckpointNotFound
+					skipped.PromptName = "lake"
+					Expect(st.CreateSampleJobWithItems(ctx, job("j1", "s1", now), []model.SampleJobItem{
+						item("i1", "j1", 9, ms(100)),
+						completed,
+						skipped,
+						scored,
+					})).To(Succeed())
+				})
+
+				DescribeTable("orders and pages items",
+					func(query model.SampleJobItemQuery, page model.Page, expected []string) {
+						items, err := st.ListSampleJobItemsPage(ctx, "j1", query, page)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(itemIDs(items)).To(Equal(expected))
+					},
+					Entry("by creation", model.SampleJobItemQuery{}, model.Page{}, []string{"i1", "i2", "i3", "i4"}),
+					Entry("by creation, descending", model.SampleJobItemQuery{Descending: true}, model.Page{}, []string{"i4", "i3", "i2", "i1"}),
+					Entry("by duration, missing last", model.SampleJobItemQuery{Sort: model.SampleJobItemSortDuration}, model.Page{}, []string{"i1", "i4", "i2", "i3"}),
+					Entry("by duration descending, missing last", model.SampleJobItemQuery{Sort: model.SampleJobItemSortDuration, Descending: true}, model.Page{}, []string{"i2", "i4", "i1", "i3"}),
+					Entry("by seed", model.SampleJobItemQuery{Sort: model.SampleJobItemSortSeed}, model.Page{}, []string{"i3", "i4", "i2", "i1"}),
+					Entry("by score, unscored last", model.SampleJobItemQuery{Sort: model.SampleJobItemSortScore, ScoreName: "aesthetic"}, model.Page{}, []string{"i2", "i4", "i1", "i3"}),
+					Entry("by score descending, unscored last", model.SampleJobItemQuery{Sort: model.SampleJobItemSortScore, ScoreName: "aesthetic", Descending: true}, model.Page{}, []string{"i4", "i2", "i3", "i1"}),
+					Entry("filtered", model.SampleJobItemQuery{Filter: model.SampleJobItemFilter{PromptName: "forest"}}, model.Page{}, []string{"i1", "i2", "i4"}),
+					Entry("filtered by skip reason", model.SampleJobItemQuery{Filter: model.SampleJobItemFilter{SkipReason: model.SampleJobItemSkipReasonCheckpointNotFound}}, model.Page{}, []string{"i3"}),
+					Entry("paged", model.SampleJobItemQuery{}, model.Page{Limit: 2, Offset: 1}, []string{"i2", "i3"}),
+					Entry("past the end", model.SampleJobItemQuery{}, model.Page{Offset: 10}, []string{}),
+				)
+
+				It("counts items by status, skipped as failed", func() {
+					counts, err := st.CountSampleJobItemsByStatus(ctx, "j1")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(counts).To(Equal(model.ItemStatusCounts{
+						Completed: 1, Failed: 1, Pending: 2,
+						Skipped: map[model.SampleJobItemSkipReason]int{model.SampleJobItemSkipReasonCheckpointNotFound: 1},
+					}))
+					Expect(st.CountSampleJobItems(ctx, "j1", model.SampleJobItemFilter{Status: model.SampleJobItemStatusPending})).To(Equal(2))
+				})
+
+				It("averages the durations of the most recent completed items", func() {
+					newer := item("i5", "j1", 2, ms(500))
+					newer.Status = model.SampleJobItemStatusCompleted
+					completedAt := now.Add(time.Minute)
+					newer.CompletedAt = &completedAt
+					Expect(st.CreateSampleJobItem(ctx, newer)).To(Succeed())
+
+					avg, ok, err := st.AverageItemDuration(ctx, 1)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(ok).To(BeTrue())
+					Expect(avg).To(Equal(500 * time.Millisecond))
+
+					avg, _, err = st.AverageItemDuration(ctx, 10)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(avg).To(Equal(400 * time.Millisecond))
+				})
+
+				It("summarizes the jobs of a training run per checkpoint, leaving out trashed jobs", func() {
+					completed := item("i5", "j2", 2, ms(500))
+					completed.CheckpointFilename = "b.safetensors"
+					completed.Status = model.SampleJobItemStatusCompleted
+					completedAt := now.Add(time.Minute)
+					completed.CompletedAt = &completedAt
+					earlier := item("i6", "j2", 4, ms(500))
+					earlier.CheckpointFilename = "b.safetensors"
+					earlier.Status = model.SampleJobItemStatusCompleted
+					earlierAt := now.Add(time.Second)
+					earlier.CompletedAt = &earlierAt
+					failed := item("i7", "j2", 6, nil)
+					failed.CheckpointFilename = "b.safetensors"
+					failed.Status = model.SampleJobItemStatusFailed
+					failedJob := job("j2", "s1", now)
+					failedJob.Status = model.SampleJobStatusFailed
+					Expect(st.CreateSampleJobWithItems(ctx, failedJob, []model.SampleJobItem{completed, earlier, failed})).To(Succeed())
+
+					trashedItem := item("i8", "j3", 1, nil)
+					trashedItem.Status = model.SampleJobItemStatusFailed
+					Expect(st.CreateSampleJobWithItems(ctx, job("j3", "s1", now), []model.SampleJobItem{trashedItem})).To(Succeed())
+					Expect(st.TrashSampleJob(ctx, "j3", now, false)).To(Succeed())
+					otherRun := job("j4", "s1", now)
+					otherRun.TrainingRunName = "other"
+					Expect(st.CreateSampleJob(ctx, otherRun)).To(Succeed())
+
+					stats, err := st.SummarizeTrainingRunJobs(ctx, "run")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(stats.JobStatusCounts).To(Equal(map[model.SampleJobStatus]int{
+						model.SampleJobStatusPending: 1,
+						model.SampleJobStatusFailed:  1,
+					}))
+					Expect(stats.Checkpoints).To(HaveLen(2))
+					Expect(stats.Checkpoints["a.safetensors"]).To(Equal(model.CheckpointJobStats{CompletedItems: 1, FailedItems: 1}))
+					b := stats.Checkpoints["b.safetensors"]
+					Expect(b.CompletedItems).To(Equal(2))
+					Expect(b.FailedItems).To(Equal(1))
+					Expect(b.LastCompletedAt).NotTo(BeNil())
+					Expect(*b.LastCompletedAt).To(BeTemporally("==", completedAt))
+
+					stats, err = st.SummarizeTrainingRunJobs(ctx, "missing")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(stats.JobStatusCounts).To(BeEmpty())
+					Expect(stats.Checkpoints).To(BeEmpty())
+				})
+
+				It("saves an item update only at the stored version", func() {
+					i := item("i1", "j1", 9, ms(100))
+					stale := i
+					i.Status = model.SampleJobItemStatusRunning
+					Expect(st.UpdateSampleJobItem(ctx, &i)).To(Succeed())
+					Expect(i.Version).To(Equal(2))
+
+					stale.Status = model.SampleJobItemStatusFailed
+					Expect(st.UpdateSampleJobItem(ctx, &stale)).To(MatchError(store.ErrVersionConflict))
+					Expect(stale.Version).To(Equal(1))
+
+					i.Status = model.SampleJobItemStatusFailed
+					Expect(st.UpdateSampleJobItem(ctx, &i)).To(Succeed())
+					items, err := st.ListSampleJobItems(ctx, "j1")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(items).To(ContainElement(And(HaveField("ID", "i1"), HaveField("Status", model.SampleJobItemStatusFailed), HaveField("Version", 3))))
+				})
+
+				It("keeps the creation time on update and requires the item to exist", func() {
+					updated := item("i1", "j1", 9, ms(100))
+					updated.Status = model.SampleJobItemStatusRunning
+					updated.CreatedAt = now.Add(time.Hour)
+					Expect(st.UpdateSampleJobItem(ctx, &updated)).To(Succeed())
+
+					items, err := st.ListSampleJobItemsPage(ctx, "j1", model.SampleJobItemQuery{Filter: model.SampleJobItemFilter{Status: model.SampleJobItemStatusRunning}}, model.Page{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(items).To(HaveLen(1))
+					Expect(items[0].CreatedAt).To(Equal(now.Truncate(time.Second)))
+
+					missing := item("missing", "j1", 1, nil)
+					Expect(st.UpdateSampleJobItem(ctx, &missing)).To(Equal(sql.ErrNoRows))
+				})
+			})
+
+			It("seeds jobs, creating stub studies", func() {
+				Expect(st.SeedSampleJobs(ctx, []model.SampleJob{job("j1", "s1", now), job("j2", "stub", now)})).To(Succeed())
+
+				stub, err := st.GetStudy(ctx, "stub")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stub.Name).To(Equal("Study"))
+				Expect(stub.Width).To(Equal(512))
+				Expect(st.CountSampleJobs(ctx, model.SampleJobFilter{})).To(Equal(2))
+			})
+		})
+	})
+}
@@ -1,5 +1,7 @@
 package store
 
+import "strings"
+
 // Migration represents a forward-only database migration.
 type Migration struct {
 	Version int
@@ -299,5 +301,411 @@ ALTER TABLE studies ADD COLUMN shift REAL;`,
 			Version: 20,
 			SQL:     `ALTER TABLE sample_jobs ADD COLUMN clear_existing INTEGER NOT NULL DEFAULT 0;`,
 		},
+		{
+			// Add per-item timing columns to sample_job_items. started_at is set
+			// when the executor submits the item to ComfyUI, completed_at and
+			// duration_ms when it reaches a terminal state. All columns are
+			// nullable since pending and pre-existing items have no timing.
+			Version: 21,
+			SQL: `ALTER TABLE sample_job_items ADD COLUMN started_at TEXT;
+ALTER TABLE sample_job_items ADD COLUMN completed_at TEXT;
+ALTER TABLE sample_job_items ADD COLUMN duration_ms INTEGER;`,
+		},
+		{
+			// Add pins table. A pin protects an image or a whole checkpoint
+			// sample directory (path relative to the sample directory) from
+			// cleanup operations such as clear_existing and delete_data.
+			Version: 22,
+			SQL: `CREATE TABLE IF NOT EXISTS pins (
+				path       TEXT PRIMARY KEY,
+				kind       TEXT NOT NULL,
+				created_at TEXT NOT NULL
+			)`,
+		},
+		{
+			// Add process_leases table for multi-process mode. Each row is a
+			// named advisory lease (e.g. "executor") held by one backend
+			// instance until expires_at; other instances may take it over
+			// once it has expired. Timestamps are RFC3339 UTC so that they
+			// compare correctly as strings.
+			Version: 23,
+			SQL: `CREATE TABLE IF NOT EXISTS process_leases (
+				name        TEXT PRIMARY KEY,
+				holder      TEXT NOT NULL,
+				acquired_at TEXT NOT NULL,
+				renewed_at  TEXT NOT NULL,
+				expires_at  TEXT NOT NULL
+			)`,
+		},
+		{
+			// Add output format and quality to sample_jobs. Existing jobs keep
+			// saving PNGs; output_quality is only used by lossy formats.
+			Version: 24,
+			SQL: `ALTER TABLE sample_jobs ADD COLUMN output_format TEXT NOT NULL DEFAULT 'png';
+ALTER TABLE sample_jobs ADD COLUMN output_quality INTEGER NOT NULL DEFAULT 0;`,
+		},
+		{
+			// Add galleries table for published public galleries. filters,
+			// dimensions and images are JSON snapshots taken at publish time so
+			// that a gallery does not change when the training run does.
+			Version: 25,
+			SQL: `CREATE TABLE IF NOT EXISTS galleries (
+				id                TEXT PRIMARY KEY,
+				slug              TEXT NOT NULL UNIQUE,
+				title             TEXT NOT NULL,
+				training_run_name TEXT NOT NULL,
+				study_name        TEXT NOT NULL DEFAULT '',
+				filters           TEXT NOT NULL DEFAULT '{}',
+				dimensions        TEXT NOT NULL DEFAULT '[]',
+				images            TEXT NOT NULL DEFAULT '[]',
+				created_at        TEXT NOT NULL
+			)`,
+		},
+		{
+			// Add training_run_configs table for training runs defined through
+			// the API. dimensions is a JSON array of {name, type, pattern}.
+			Version: 26,
+			SQL: `CREATE TABLE IF NOT EXISTS training_run_configs (
+				id         TEXT PRIMARY KEY,
+				name       TEXT NOT NULL UNIQUE,
+				pattern    TEXT NOT NULL,
+				dimensions TEXT NOT NULL DEFAULT '[]',
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			)`,
+		},
+		{
+			// Add assets and asset_uploads tables for managed asset uploads.
+			// Assets are unique per kind and content hash. The bytes received
+			// by an upload are not stored: the partial file is the source of
+			// truth so that an interrupted write cannot desynchronize them.
+			Version: 27,
+			SQL: `CREATE TABLE IF NOT EXISTS assets (
+				id           TEXT PRIMARY KEY,
+				kind         TEXT NOT NULL,
+				filename     TEXT NOT NULL,
+				content_type TEXT NOT NULL,
+				size         INTEGER NOT NULL,
+				sha256       TEXT NOT NULL,
+				path         TEXT NOT NULL,
+				created_at   TEXT NOT NULL,
+				UNIQUE (kind, sha256)
+			);
+CREATE TABLE IF NOT EXISTS asset_uploads (
+				id           TEXT PRIMARY KEY,
+				kind         TEXT NOT NULL,
+				filename     TEXT NOT NULL,
+				content_type TEXT NOT NULL,
+				size         INTEGER NOT NULL,
+				created_at   TEXT NOT NULL,
+				updated_at   TEXT NOT NULL
+			);`,
+		},
+		{
+			// Index sample job items by job and status so that item listings
+			// filtered by status do not scan every item of a large job.
+			Version: 28,
+			SQL:     `CREATE INDEX IF NOT EXISTS idx_sample_job_items_job_status ON sample_job_items (job_id, status);`,
+		},
+		{
+			// Add comfyui_log column for the ComfyUI log lines captured when an item fails.
+			Version: 29,
+			SQL:     `ALTER TABLE sample_job_items ADD COLUMN comfyui_log TEXT NOT NULL DEFAULT '';`,
+		},
+		{
+			// Add append_new_checkpoints column: when set, checkpoints of the
+			// training run that appear after the job is created are added to it.
+			Version: 30,
+			SQL:     `ALTER TABLE sample_jobs ADD COLUMN append_new_checkpoints INTEGER NOT NULL DEFAULT 0;`,
+		},
+		{
+			// Add image index tables. image_dirs records each directory under
+			// the sample directory that has been read and its modification
+			// time then; images lists the sample images found in it. Listings
+			// are served from here while the modification time is unchanged.
+			Version: 31,
+			SQL: `CREATE TABLE IF NOT EXISTS image_dirs (
+				dir        TEXT PRIMARY KEY,
+				mod_time   TEXT NOT NULL,
+				indexed_at TEXT NOT NULL
+			);
+CREATE TABLE IF NOT EXISTS images (
+				dir      TEXT NOT NULL REFERENCES image_dirs(dir) ON DELETE CASCADE,
+				filename TEXT NOT NULL,
+				PRIMARY KEY (dir, filename)
+			);`,
+		},
+		{
+			// Add the change log behind GET /api/sync. It holds one row per
+			// entity that changed, carrying the sequence number of its latest
+			// change, and is filled by triggers so that every write path,
+			// including foreign key cascades, is recorded in the writing
+			// transaction. Sample job items are logged under their job. Tables
+			// rebuilt by later migrations must recreate their triggers.
+			Version: 32,
+			SQL: `CREATE TABLE IF NOT EXISTS change_log (
+				entity    TEXT NOT NULL,
+				entity_id TEXT NOT NULL,
+				seq       INTEGER NOT NULL,
+				PRIMARY KEY (entity, entity_id)
+			);
+CREATE INDEX IF NOT EXISTS idx_change_log_seq ON change_log (seq);
+` + changeLogTriggers("sample_jobs", "sample_job", "id") +
+				changeLogTriggers("sample_job_items", "sample_job_items", "job_id") +
+				changeLogTriggers("studies", "study", "id") +
+				changeLogTriggers("presets", "preset", "id") +
+				changeLogTriggers("image_dirs", "image_dir", "dir") +
+				changeLogTriggers("images", "image_dir", "dir"),
+		},
+		{
+			// Add pairwise checkpoint votes. Votes keep the checkpoint
+			// filenames and carry no foreign keys, so that rankings survive
+			// the deletion of the sample jobs that were voted on.
+			Version: 33,
+			SQL: `CREATE TABLE IF NOT EXISTS votes (
+				id                TEXT PRIMARY KEY,
+				training_run_name TEXT NOT NULL,
+				prompt_name       TEXT NOT NULL,
+				winner_item_id    TEXT NOT NULL,
+				loser_item_id     TEXT NOT NULL,
+				winner_checkpoint TEXT NOT NULL,
+				loser_checkpoint  TEXT NOT NULL,
+				created_at        TEXT NOT NULL
+			);
+CREATE INDEX IF NOT EXISTS idx_votes_training_run ON votes (training_run_name);`,
+		},
+		{
+			// Add img2img support. A study may name an input image that its
+			// samples start from and a list of denoise strengths to sweep;
+			// jobs keep the input image of their study at creation time and
+			// items their denoise strength. denoise is NULL for items of
+			// text-to-image jobs.
+			Version: 34,
+			SQL: `ALTER TABLE studies ADD COLUMN input_image TEXT;
+ALTER TABLE studies ADD COLUMN denoise_strengths TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE sample_jobs ADD COLUMN input_image TEXT NOT NULL DEFAULT '';
+ALTER TABLE sample_job_items ADD COLUMN denoise REAL;`,
+		},
+		{
+			Version: 35,
+			SQL: `ALTER TABLE sample_jobs ADD COLUMN controlnet_model TEXT NOT NULL DEFAULT '';
+ALTER TABLE sample_jobs ADD COLUMN controlnet_strength REAL;
+ALTER TABLE sample_jobs ADD COLUMN controlnet_image TEXT NOT NULL DEFAULT '';`,
+		},
+		{
+			Version: 36,
+			SQL: `CREATE TABLE IF NOT EXISTS sample_job_parameters (
+				job_id     TEXT PRIMARY KEY,
+				study      TEXT NOT NULL,
+				workflow   TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				FOREIGN KEY (job_id) REFERENCES sample_jobs(id) ON DELETE CASCADE
+			);`,
+		},
+		{
+			// Add prompt wildcards. A study may define named value lists
+			// that {name} placeholders in its prompt texts expand over;
+			// items record the values they were rendered with as a JSON
+			// object, empty when their prompt used no wildcards.
+			Version: 37,
+			SQL: `ALTER TABLE studies ADD COLUMN wildcards TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE sample_job_items ADD COLUMN wildcards TEXT NOT NULL DEFAULT '';`,
+		},
+		{
+			// Add a machine-readable skip reason to items alongside their
+			// error message. Items skipped before it existed are classified
+			// by the messages the two skip causes wrote.
+			Version: 38,
+			SQL: `ALTER TABLE sample_job_items ADD COLUMN skip_reason TEXT NOT NULL DEFAULT '';
+UPDATE sample_job_items SET skip_reason = 'duplicate' WHERE status = 'skipped' AND error_message = 'output already exists';
+UPDATE sample_job_items SET skip_reason = 'checkpoint_not_found' WHERE status = 'skipped' AND error_message LIKE 'checkpoint not found in ComfyUI%';`,
+		},
+		{
+			// Add seed modes. Studies in a random seed mode draw
+			// random_seed_count seeds per combination or per checkpoint
+			// instead of using their seed list; existing studies keep
+			// their explicit seeds.
+			Version: 39,
+			SQL: `ALTER TABLE studies ADD COLUMN seed_mode TEXT NOT NULL DEFAULT 'explicit';
+ALTER TABLE studies ADD COLUMN random_seed_count INTEGER NOT NULL DEFAULT 0;`,
+		},
+		{
+			// Add study version history. Editing a study that a job was
+			// created from archives its previous configuration in
+			// study_versions (as a JSON-encoded studyEntity, like the job
+			// parameter snapshot) and increments its version; jobs record
+			// the version they were created from. Existing studies and
+			// jobs start at version 1.
+			Version: 40,
+			SQL: `ALTER TABLE studies ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE sample_jobs ADD COLUMN study_version INTEGER NOT NULL DEFAULT 1;
+CREATE TABLE IF NOT EXISTS study_versions (
+	study_id   TEXT NOT NULL,
+	version    INTEGER NOT NULL,
+	study      TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (study_id, version),
+	FOREIGN KEY (study_id) REFERENCES studies(id) ON DELETE CASCADE
+);`,
+		},
+		{
+			// Add job templates: saved sample job configurations that are
+			// run against a training run to create a job. Deleting a study
+			// deletes its templates, like its jobs.
+			Version: 41,
+			SQL: `CREATE TABLE IF NOT EXISTS job_templates (
+	id                     TEXT PRIMARY KEY,
+	name                   TEXT NOT NULL,
+	study_id               TEXT NOT NULL,
+	checkpoint_filter      TEXT NOT NULL DEFAULT '',
+	clear_existing         INTEGER NOT NULL DEFAULT 0,
+	missing_only           INTEGER NOT NULL DEFAULT 0,
+	skip_existing          INTEGER NOT NULL DEFAULT 0,
+	append_new_checkpoints INTEGER NOT NULL DEFAULT 0,
+	output_format          TEXT NOT NULL DEFAULT 'png',
+	output_quality         INTEGER NOT NULL DEFAULT 0,
+	vae                    TEXT NOT NULL DEFAULT '',
+	clip                   TEXT NOT NULL DEFAULT '',
+	shift                  REAL,
+	controlnet_model       TEXT NOT NULL DEFAULT '',
+	controlnet_strength    REAL,
+	controlnet_image       TEXT NOT NULL DEFAULT '',
+	created_at             TEXT NOT NULL,
+	updated_at             TEXT NOT NULL,
+	FOREIGN KEY (study_id) REFERENCES studies(id) ON DELETE CASCADE
+);`,
+		},
+		{
+			// Add image annotations: a star rating (0 = unrated) and a JSON
+			// array of tags per sample image, keyed by the image path
+			// relative to the sample directory like pins.
+			Version: 42,
+			SQL: `CREATE TABLE IF NOT EXISTS image_annotations (
+	path       TEXT PRIMARY KEY,
+	rating     INTEGER NOT NULL DEFAULT 0,
+	tags       TEXT NOT NULL DEFAULT '[]',
+	updated_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_image_annotations_rating ON image_annotations (rating);`,
+		},
+		{
+			// Add automatic image scores: a JSON object mapping score names
+			// to values, empty when the image was not scored.
+			Version: 43,
+			SQL:     `ALTER TABLE sample_job_items ADD COLUMN scores TEXT NOT NULL DEFAULT '';`,
+		},
+		{
+			// Record the staged copy of the checkpoint an item was sampled
+			// from, so that it can be removed when the job finishes.
+			Version: 44,
+			SQL:     `ALTER TABLE sample_job_items ADD COLUMN staged_checkpoint TEXT NOT NULL DEFAULT '';`,
+		},
+		{
+			// Add per-checkpoint workflow overrides: a JSON array of
+			// pattern/workflow pairs on the job, the override workflow each
+			// item is sampled with (empty for the job's workflow), and the
+			// snapshot of the override workflows' templates by name.
+			Version: 45,
+			SQL: `ALTER TABLE sample_jobs ADD COLUMN workflow_overrides TEXT NOT NULL DEFAULT '';
+ALTER TABLE sample_job_items ADD COLUMN workflow_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE sample_job_parameters ADD COLUMN override_workflows TEXT NOT NULL DEFAULT '';`,
+		},
+		{
+			// Add resolution sweeps: a JSON array of width/height pairs on
+			// the study, and the resolution each item was swept at (empty
+			// when the study sweeps none).
+			Version: 46,
+			SQL: `ALTER TABLE studies ADD COLUMN resolutions TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE sample_job_items ADD COLUMN resolution TEXT NOT NULL DEFAULT '';`,
+		},
+		{
+			// Add view presets: a preset may be scoped to a training run,
+			// carry the JSON filter and sort state of the view, and be the
+			// run's default view. The partial index allows one default per
+			// training run.
+			Version: 47,
+			SQL: `ALTER TABLE presets ADD COLUMN training_run TEXT NOT NULL DEFAULT '';
+ALTER TABLE presets ADD COLUMN view_state TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE presets ADD COLUMN is_default INTEGER NOT NULL DEFAULT 0;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_presets_default_training_run ON presets (training_run) WHERE is_default = 1;`,
+		},
+		{
+			// Add row versions to sample jobs and items for optimistic
+			// concurrency: an update is saved only if it carries the stored
+			// version, and increments it.
+			Version: 48,
+			SQL: `ALTER TABLE sample_jobs ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE sample_job_items ADD COLUMN version INTEGER NOT NULL DEFAULT 1;`,
+		},
+		{
+			// Record the ID of the API request that created each sample job,
+			// for correlating the job with the request's log entries.
+			Version: 49,
+			SQL:     `ALTER TABLE sample_jobs ADD COLUMN created_by_request_id TEXT NOT NULL DEFAULT '';`,
+		},
+		{
+			// Record the user that created each preset, study and sample
+			// job; existing rows stay unowned. Each user has their own
+			// default preset of a training run.
+			Version: 50,
+			SQL: `ALTER TABLE presets ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE studies ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE sample_jobs ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_sample_jobs_owner ON sample_jobs (owner);
+DROP INDEX IF EXISTS idx_presets_default_training_run;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_presets_default_training_run ON presets (training_run, owner) WHERE is_default = 1;`,
+		},
+		{
+			// Soft delete: deleting a sample job moves it to the trash by
+			// setting deleted_at, remembering whether purging it also
+			// removes its sample files. Trashed jobs are restored or purged.
+			Version: 51,
+			SQL: `ALTER TABLE sample_jobs ADD COLUMN deleted_at TEXT;
+ALTER TABLE sample_jobs ADD COLUMN delete_data INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_sample_jobs_deleted_at ON sample_jobs (deleted_at);`,
+		},
+		{
+			// Job annotations: free-text notes and key/value labels (a JSON
+			// object, empty when the job has none) users record with a job.
+			Version: 52,
+			SQL: `ALTER TABLE sample_jobs ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+ALTER TABLE sample_jobs ADD COLUMN labels TEXT NOT NULL DEFAULT '';`,
+		},
+		{
+			// Checkpoint hashes: a fast hash of each discovered checkpoint file,
+			// keyed by its absolute path. A hash is reused while the file's size
+			// and modification time are unchanged, and used to find the same
+			// checkpoint under several training runs or filenames.
+			Version: 53,
+			SQL: `CREATE TABLE IF NOT EXISTS checkpoint_hashes (
+				path        TEXT PRIMARY KEY,
+				size        INTEGER NOT NULL,
+				mod_time    TEXT NOT NULL,
+				hash        TEXT NOT NULL,
+				computed_at TEXT NOT NULL
+			);
+CREATE INDEX IF NOT EXISTS idx_checkpoint_hashes_hash ON checkpoint_hashes (hash);`,
+		},
+	}
+}
+
+// changeLogTriggers returns the statements creating the triggers that record
+// inserts, updates and deletes of rows of table in the change log, as entity
+// keyed by idColumn. Each change takes the next sequence number; since SQLite
+// serializes writers, sequence numbers are assigned in commit order.
+func changeLogTriggers(table, entity, idColumn string) string {
+	var stmts string
+	for _, t := range []struct{ event, row string }{
+		{"INSERT", "NEW"},
+		{"UPDATE", "NEW"},
+		{"DELETE", "OLD"},
+	} {
+		stmts += `CREATE TRIGGER IF NOT EXISTS change_log_` + table + `_` + strings.ToLower(t.event) + ` AFTER ` + t.event + ` ON ` + table + ` BEGIN
+	INSERT INTO change_log (entity, entity_id, seq)
+	VALUES ('` + entity + `', ` + t.row + `.` + idColumn + `, (SELECT COALESCE(MAX(seq), 0) + 1 FROM change_log))
+	ON CONFLICT (entity, entity_id) DO UPDATE SET seq = excluded.seq;
+END;
+`
 	}
+	return stmts
 }
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// pinEntity is the persistence representation of a pin.
+type pinEntity struct {
+	Path      string
+	Kind      string
+	CreatedAt string // RFC3339
+}
+
+// ListPins returns all pins ordered by path.
+func (s *Store) ListPins(ctx context.Context) ([]model.Pin, error) {
+	s.logger.Trace("entering ListPins")
+	defer s.logger.Trace("returning from ListPins")
+
+	rows, err := s.db.QueryContext(ctx, "SELECT path, kind, created_at FROM pins ORDER BY path")
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query pins")
+		return nil, fmt.Errorf("querying pins: %w", err)
+	}
+	defer rows.Close()
+
+	var pins []model.Pin
+	for rows.Next() {
+		var e pinEntity
+		if err := rows.Scan(&e.Path, &e.Kind, &e.CreatedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan pin row")
+			return nil, fmt.Errorf("scanning pin row: %w", err)
+		}
+		p, err := pinEntityToModel(e)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to convert entity to model")
+			return nil, err
+		}
+		pins = append(pins, p)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating pins")
+		return nil, fmt.Errorf("iterating pins: %w", err)
+	}
+	s.logger.WithField("pin_count", len(pins)).Debug("listed pins from database")
+	return pins, nil
+}
+
+// CreatePin inserts a pin. Pinning an already-pinned path is a no-op so that
+// pin requests are idempotent; the original kind and timestamp are kept.
+func (s *Store) CreatePin(ctx context.Context, p model.Pin) error {
+	s.logger.WithField("path", p.Path).Trace("entering CreatePin")
+	defer s.logger.Trace("returning from CreatePin")
+
+	e := pinModelToEntity(p)
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO pins (path, kind, created_at) VALUES (?, ?, ?) ON CONFLICT(path) DO NOTHING",
+		e.Path, e.Kind, e.CreatedAt,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  p.Path,
+			"error": err.Error(),
+		}).Error("failed to insert pin into database")
+		return fmt.Errorf("inserting pin: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"path": p.Path,
+		"kind": p.Kind,
+	}).Info("inserted pin into database")
+	return nil
+}
+
+// GetPin returns the pin on path. Returns sql.ErrNoRows if the path is not
+// pinned.
+func (s *Store) GetPin(ctx context.Context, path string) (model.Pin, error) {
+	s.logger.WithField("path", path).Trace("entering GetPin")
+	defer s.logger.Trace("returning from GetPin")
+
+	var e pinEntity
+	err := s.db.QueryRowContext(ctx, "SELECT path, kind, created_at FROM pins WHERE path = ?", path).
+		Scan(&e.Path, &e.Kind, &e.CreatedAt)
+	if err == sql.ErrNoRows {
+		s.logger.WithField("path", path).Debug("pin not found in database")
+		return model.Pin{}, sql.ErrNoRows
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to query pin")
+		return model.Pin{}, fmt.Errorf("querying pin: %w", err)
+	}
+	p, err := pinEntityToModel(e)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to convert entity to model")
+		return model.Pin{}, err
+	}
+	return p, nil
+}
+
+// DeletePin removes a pin by path. Returns sql.ErrNoRows if the path is not pinned.
+func (s *Store) DeletePin(ctx context.Context, path string) error {
+	s.logger.WithField("path", path).Trace("entering DeletePin")
+	defer s.logger.Trace("returning from DeletePin")
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM pins WHERE path = ?", path)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to delete pin from database")
+		return fmt.Errorf("deleting pin: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err.Error(),
+		}).Error("failed to check rows affected")
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("path", path).Debug("pin not found for deletion")
+		return sql.ErrNoRows
+	}
+	s.logger.WithField("path", path).Info("deleted pin from database")
+	return nil
+}
+
+func pinEntityToModel(e pinEntity) (model.Pin, error) {
+	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
+	if err != nil {
+		return model.Pin{}, fmt.Errorf("parsing created_at: %w", err)
+	}
+	return model.Pin{
+		Path:      e.Path,
+		Kind:      model.PinKind(e.Kind),
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func pinModelToEntity(p model.Pin) pinEntity {
+	return pinEntity{
+		Path:      p.Path,
+		Kind:      string(p.Kind),
+		CreatedAt: p.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
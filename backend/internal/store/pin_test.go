@@ -0,0 +1,101 @@
+package store_test
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("PinStore", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "pin-store-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	It("returns an empty list when nothing is pinned", func() {
+		pins, err := st.ListPins(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pins).To(BeEmpty())
+	})
+
+	It("creates pins and lists them ordered by path", func() {
+		Expect(st.CreatePin(ctx, model.Pin{Path: "run/study/b.safetensors", Kind: model.PinKindCheckpoint, CreatedAt: now})).To(Succeed())
+		Expect(st.CreatePin(ctx, model.Pin{Path: "run/study/a.safetensors/x.png", Kind: model.PinKindImage, CreatedAt: now})).To(Succeed())
+
+		pins, err := st.ListPins(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pins).To(HaveLen(2))
+		Expect(pins[0].Path).To(Equal("run/study/a.safetensors/x.png"))
+		Expect(pins[0].Kind).To(Equal(model.PinKindImage))
+		Expect(pins[0].CreatedAt).To(Equal(now))
+		Expect(pins[1].Kind).To(Equal(model.PinKindCheckpoint))
+	})
+
+	It("treats pinning an already-pinned path as a no-op", func() {
+		Expect(st.CreatePin(ctx, model.Pin{Path: "a.png", Kind: model.PinKindImage, CreatedAt: now})).To(Succeed())
+		Expect(st.CreatePin(ctx, model.Pin{Path: "a.png", Kind: model.PinKindImage, CreatedAt: now.Add(time.Hour)})).To(Succeed())
+
+		pins, err := st.ListPins(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pins).To(HaveLen(1))
+		Expect(pins[0].CreatedAt).To(Equal(now))
+	})
+
+	It("gets a pin by path", func() {
+		Expect(st.CreatePin(ctx, model.Pin{Path: "a.png", Kind: model.PinKindImage, CreatedAt: now})).To(Succeed())
+
+		pin, err := st.GetPin(ctx, "a.png")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pin).To(Equal(model.Pin{Path: "a.png", Kind: model.PinKindImage, CreatedAt: now}))
+	})
+
+	It("returns sql.ErrNoRows when getting a path that is not pinned", func() {
+		_, err := st.GetPin(ctx, "missing.png")
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+
+	It("deletes a pin", func() {
+		Expect(st.CreatePin(ctx, model.Pin{Path: "a.png", Kind: model.PinKindImage, CreatedAt: now})).To(Succeed())
+		Expect(st.DeletePin(ctx, "a.png")).To(Succeed())
+
+		pins, err := st.ListPins(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pins).To(BeEmpty())
+	})
+
+	It("returns sql.ErrNoRows when deleting a path that is not pinned", func() {
+		Expect(st.DeletePin(ctx, "missing.png")).To(Equal(sql.ErrNoRows))
+	})
+})
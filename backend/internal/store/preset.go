@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -12,11 +13,15 @@ import (
 
 // presetEntity is the persistence representation of a preset.
 type presetEntity struct {
-	ID        string
-	Name      string
-	Mapping   string // JSON
-	CreatedAt string // RFC3339
-	UpdatedAt string // RFC3339
+	ID          string
+	Name        string
+	Mapping     string // JSON
+	TrainingRun string
+	ViewState   string // JSON
+	IsDefault   bool
+	Owner       string
+	CreatedAt   string // RFC3339
+	UpdatedAt   string // RFC3339
 }
 
 // mappingJSON is the JSON shape stored in the mapping column.
@@ -29,12 +34,25 @@ type mappingJSON struct {
 	Combos  []string `json:"combos"`
 }
 
+// viewStateJSON is the JSON shape stored in the view_state column.
+type viewStateJSON struct {
+	Filters map[string][]string `json:"filters,omitempty"`
+	Sort    []presetSortJSON    `json:"sort,omitempty"`
+}
+
+type presetSortJSON struct {
+	Dimension  string `json:"dimension"`
+	Descending bool   `json:"descending,omitempty"`
+}
+
+const presetColumns = "id, name, mapping, training_run, view_state, is_default, owner, created_at, updated_at"
+
 // ListPresets returns all presets ordered by name.
-func (s *Store) ListPresets() ([]model.Preset, error) {
+func (s *Store) ListPresets(ctx context.Context) ([]model.Preset, error) {
 	s.logger.Trace("entering ListPresets")
 	defer s.logger.Trace("returning from ListPresets")
 
-	rows, err := s.db.Query("SELECT id, name, mapping, created_at, updated_at FROM presets ORDER BY name")
+	rows, err := s.db.QueryContext(ctx, "SELECT "+presetColumns+" FROM presets ORDER BY name")
 	if err != nil {
 		s.logger.WithError(err).Error("failed to query presets")
 		return nil, fmt.Errorf("querying presets: %w", err)
@@ -44,7 +62,7 @@ func (s *Store) ListPresets() ([]model.Preset, error) {
 	var presets []model.Preset
 	for rows.Next() {
 		var e presetEntity
-		if err := rows.Scan(&e.ID, &e.Name, &e.Mapping, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		if err := rows.Scan(&e.ID, &e.Name, &e.Mapping, &e.TrainingRun, &e.ViewState, &e.IsDefault, &e.Owner, &e.CreatedAt, &e.UpdatedAt); err != nil {
 			s.logger.WithError(err).Error("failed to scan preset row")
 			return nil, fmt.Errorf("scanning preset row: %w", err)
 		}
@@ -64,14 +82,14 @@ func (s *Store) ListPresets() ([]model.Preset, error) {
 }
 
 // GetPreset returns a single preset by ID, or sql.ErrNoRows if not found.
-func (s *Store) GetPreset(id string) (model.Preset, error) {
+func (s *Store) GetPreset(ctx context.Context, id string) (model.Preset, error) {
 	s.logger.WithField("preset_id", id).Trace("entering GetPreset")
 	defer s.logger.Trace("returning from GetPreset")
 
 	var e presetEntity
-	err := s.db.QueryRow(
-		"SELECT id, name, mapping, created_at, updated_at FROM presets WHERE id = ?", id,
-	).Scan(&e.ID, &e.Name, &e.Mapping, &e.CreatedAt, &e.UpdatedAt)
+	err := s.db.QueryRowContext(ctx,
+		"SELECT "+presetColumns+" FROM presets WHERE id = ?", id,
+	).Scan(&e.ID, &e.Name, &e.Mapping, &e.TrainingRun, &e.ViewState, &e.IsDefault, &e.Owner, &e.CreatedAt, &e.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			s.logger.WithField("preset_id", id).Debug("preset not found in database")
@@ -87,29 +105,40 @@ func (s *Store) GetPreset(id string) (model.Preset, error) {
 	return entityToModel(e)
 }
 
-// CreatePreset inserts a new preset.
-func (s *Store) CreatePreset(p model.Preset) error {
+// CreatePreset inserts a new preset. When the preset is its training run's
+// default, the run's previous default stops being one.
+func (s *Store) CreatePreset(ctx context.Context, p model.Preset) error {
 	s.logger.WithFields(logrus.Fields{
 		"preset_id":   p.ID,
 		"preset_name": p.Name,
 	}).Trace("entering CreatePreset")
 	defer s.logger.Trace("returning from CreatePreset")
 
-	mappingBytes, err := modelMappingToJSON(p.Mapping)
+	e, err := presetModelToEntity(p)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"preset_id": p.ID,
 			"error":     err.Error(),
-		}).Error("failed to marshal preset mapping")
+		}).Error("failed to marshal preset")
 		return err
 	}
-	_, err = s.db.Exec(
-		"INSERT INTO presets (id, name, mapping, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-		p.ID,
-		p.Name,
-		string(mappingBytes),
-		p.CreatedAt.UTC().Format(time.RFC3339),
-		p.UpdatedAt.UTC().Format(time.RFC3339),
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to begin preset transaction")
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := clearDefaultPreset(tx, e); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"preset_id": p.ID,
+			"error":     err.Error(),
+		}).Error("failed to clear previous default preset")
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO presets ("+presetColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		e.ID, e.Name, e.Mapping, e.TrainingRun, e.ViewState, e.IsDefault, e.Owner, e.CreatedAt, e.UpdatedAt,
 	)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
@@ -119,6 +148,10 @@ func (s *Store) CreatePreset(p model.Preset) error {
 		}).Error("failed to insert preset into database")
 		return fmt.Errorf("inserting preset: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		s.logger.WithError(err).Error("failed to commit preset transaction")
+		return fmt.Errorf("committing preset: %w", err)
+	}
 	s.logger.WithFields(logrus.Fields{
 		"preset_id":   p.ID,
 		"preset_name": p.Name,
@@ -126,29 +159,42 @@ func (s *Store) CreatePreset(p model.Preset) error {
 	return nil
 }
 
-// UpdatePreset updates an existing preset's name and mapping. Returns
-// sql.ErrNoRows if the preset does not exist.
-func (s *Store) UpdatePreset(p model.Preset) error {
+// UpdatePreset updates an existing preset's name, mapping, training run,
+// view and default flag. When the preset becomes its training run's default,
+// the run's previous default stops being one. Returns sql.ErrNoRows if the
+// preset does not exist.
+func (s *Store) UpdatePreset(ctx context.Context, p model.Preset) error {
 	s.logger.WithFields(logrus.Fields{
 		"preset_id":   p.ID,
 		"preset_name": p.Name,
 	}).Trace("entering UpdatePreset")
 	defer s.logger.Trace("returning from UpdatePreset")
 
-	mappingBytes, err := modelMappingToJSON(p.Mapping)
+	e, err := presetModelToEntity(p)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"preset_id": p.ID,
 			"error":     err.Error(),
-		}).Error("failed to marshal preset mapping")
+		}).Error("failed to marshal preset")
 		return err
 	}
-	result, err := s.db.Exec(
-		"UPDATE presets SET name = ?, mapping = ?, updated_at = ? WHERE id = ?",
-		p.Name,
-		string(mappingBytes),
-		p.UpdatedAt.UTC().Format(time.RFC3339),
-		p.ID,
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to begin preset transaction")
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := clearDefaultPreset(tx, e); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"preset_id": p.ID,
+			"error":     err.Error(),
+		}).Error("failed to clear previous default preset")
+		return err
+	}
+	result, err := tx.Exec(
+		"UPDATE presets SET name = ?, mapping = ?, training_run = ?, view_state = ?, is_default = ?, updated_at = ? WHERE id = ?",
+		e.Name, e.Mapping, e.TrainingRun, e.ViewState, e.IsDefault, e.UpdatedAt, e.ID,
 	)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
@@ -170,6 +216,10 @@ func (s *Store) UpdatePreset(p model.Preset) error {
 		s.logger.WithField("preset_id", p.ID).Debug("no rows affected, preset not found")
 		return sql.ErrNoRows
 	}
+	if err := tx.Commit(); err != nil {
+		s.logger.WithError(err).Error("failed to commit preset transaction")
+		return fmt.Errorf("committing preset: %w", err)
+	}
 	s.logger.WithFields(logrus.Fields{
 		"preset_id":   p.ID,
 		"preset_name": p.Name,
@@ -177,13 +227,28 @@ func (s *Store) UpdatePreset(p model.Preset) error {
 	return nil
 }
 
+// clearDefaultPreset unsets the default flag of the other presets of e's
+// training run and owner when e is the run's default.
+func clearDefaultPreset(tx *instrumentedTx, e presetEntity) error {
+	if !e.IsDefault {
+		return nil
+	}
+	if _, err := tx.Exec(
+		"UPDATE presets SET is_default = 0 WHERE training_run = ? AND owner = ? AND is_default = 1 AND id != ?",
+		e.TrainingRun, e.Owner, e.ID,
+	); err != nil {
+		return fmt.Errorf("clearing default preset: %w", err)
+	}
+	return nil
+}
+
 // DeletePreset removes a preset by ID. Returns sql.ErrNoRows if the preset
 // does not exist.
-func (s *Store) DeletePreset(id string) error {
+func (s *Store) DeletePreset(ctx context.Context, id string) error {
 	s.logger.WithField("preset_id", id).Trace("entering DeletePreset")
 	defer s.logger.Trace("returning from DeletePreset")
 
-	result, err := s.db.Exec("DELETE FROM presets WHERE id = ?", id)
+	result, err := s.db.ExecContext(ctx, "DELETE FROM presets WHERE id = ?", id)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"preset_id": id,
@@ -212,6 +277,10 @@ func entityToModel(e presetEntity) (model.Preset, error) {
 	if err := json.Unmarshal([]byte(e.Mapping), &m); err != nil {
 		return model.Preset{}, fmt.Errorf("unmarshaling preset mapping: %w", err)
 	}
+	var v viewStateJSON
+	if err := json.Unmarshal([]byte(e.ViewState), &v); err != nil {
+		return model.Preset{}, fmt.Errorf("unmarshaling preset view state: %w", err)
+	}
 	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
 	if err != nil {
 		return model.Preset{}, fmt.Errorf("parsing created_at: %w", err)
@@ -220,6 +289,10 @@ func entityToModel(e presetEntity) (model.Preset, error) {
 	if err != nil {
 		return model.Preset{}, fmt.Errorf("parsing updated_at: %w", err)
 	}
+	view := model.PresetView{Filters: v.Filters}
+	for _, sort := range v.Sort {
+		view.Sort = append(view.Sort, model.PresetSort{Dimension: sort.Dimension, Descending: sort.Descending})
+	}
 	return model.Preset{
 		ID:   e.ID,
 		Name: e.Name,
@@ -231,8 +304,38 @@ func entityToModel(e presetEntity) (model.Preset, error) {
 			YSlider: m.YSlider,
 			Combos:  m.Combos,
 		},
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+		TrainingRun: e.TrainingRun,
+		View:        view,
+		Default:     e.IsDefault,
+		Owner:       e.Owner,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+	}, nil
+}
+
+func presetModelToEntity(p model.Preset) (presetEntity, error) {
+	mappingBytes, err := modelMappingToJSON(p.Mapping)
+	if err != nil {
+		return presetEntity{}, err
+	}
+	v := viewStateJSON{Filters: p.View.Filters}
+	for _, sort := range p.View.Sort {
+		v.Sort = append(v.Sort, presetSortJSON{Dimension: sort.Dimension, Descending: sort.Descending})
+	}
+	viewBytes, err := json.Marshal(v)
+	if err != nil {
+		return presetEntity{}, fmt.Errorf("marshaling preset view state: %w", err)
+	}
+	return presetEntity{
+		ID:          p.ID,
+		Name:        p.Name,
+		Mapping:     string(mappingBytes),
+		TrainingRun: p.TrainingRun,
+		ViewState:   string(viewBytes),
+		IsDefault:   p.Default,
+		Owner:       p.Owner,
+		CreatedAt:   p.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   p.UpdatedAt.UTC().Format(time.RFC3339),
 	}, nil
 }
 
@@ -1,6 +1,7 @@
 package store_test
 
 import (
+	"context"
 	"database/sql"
 	"io"
 	"os"
@@ -64,10 +65,10 @@ var _ = Describe("PresetStore", func() {
 	Describe("CreatePreset", func() {
 		It("creates a preset and reads it back", func() {
 			p := makePreset("p1", "Test Preset")
-			err := st.CreatePreset(p)
+			err := st.CreatePreset(ctx, p)
 			Expect(err).NotTo(HaveOccurred())
 
-			got, err := st.GetPreset("p1")
+			got, err := st.GetPreset(ctx, "p1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got.ID).To(Equal("p1"))
 			Expect(got.Name).To(Equal("Test Preset"))
@@ -89,10 +90,10 @@ var _ = Describe("PresetStore", func() {
 				CreatedAt: now,
 				UpdatedAt: now,
 			}
-			err := st.CreatePreset(p)
+			err := st.CreatePreset(ctx, p)
 			Expect(err).NotTo(HaveOccurred())
 
-			got, err := st.GetPreset("p2")
+			got, err := st.GetPreset(ctx, "p2")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got.Mapping.X).To(Equal(""))
 			Expect(got.Mapping.Y).To(Equal(""))
@@ -100,29 +101,52 @@ var _ = Describe("PresetStore", func() {
 			Expect(got.Mapping.Combos).To(Equal([]string{"seed"}))
 		})
 
+		It("keeps one default per training run and owner", func() {
+			for _, p := range []model.Preset{
+				{ID: "p1", Name: "Shared", TrainingRun: "my-lora", Default: true},
+				{ID: "p2", Name: "Alice's", TrainingRun: "my-lora", Default: true, Owner: "alice"},
+				{ID: "p3", Name: "Alice's new", TrainingRun: "my-lora", Default: true, Owner: "alice"},
+			} {
+				p.Mapping = model.PresetMapping{Combos: []string{}}
+				p.CreatedAt, p.UpdatedAt = now, now
+				Expect(st.CreatePreset(ctx, p)).To(Succeed())
+			}
+
+			defaults := map[string]bool{}
+			presets, err := st.ListPresets(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			for _, p := range presets {
+				defaults[p.ID] = p.Default
+			}
+			Expect(defaults).To(Equal(map[string]bool{"p1": true, "p2": false, "p3": true}))
+			got, err := st.GetPreset(ctx, "p3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Owner).To(Equal("alice"))
+		})
+
 		It("returns error for duplicate ID", func() {
 			p := makePreset("dup", "First")
-			Expect(st.CreatePreset(p)).To(Succeed())
+			Expect(st.CreatePreset(ctx, p)).To(Succeed())
 
 			p2 := makePreset("dup", "Second")
-			err := st.CreatePreset(p2)
+			err := st.CreatePreset(ctx, p2)
 			Expect(err).To(HaveOccurred())
 		})
 	})
 
 	Describe("ListPresets", func() {
 		It("returns empty slice when no presets exist", func() {
-			presets, err := st.ListPresets()
+			presets, err := st.ListPresets(ctx)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(presets).To(BeEmpty())
 		})
 
 		It("returns presets ordered by name", func() {
-			Expect(st.CreatePreset(makePreset("p1", "Zebra"))).To(Succeed())
-			Expect(st.CreatePreset(makePreset("p2", "Alpha"))).To(Succeed())
-			Expect(st.CreatePreset(makePreset("p3", "Middle"))).To(Succeed())
+			Expect(st.CreatePreset(ctx, makePreset("p1", "Zebra"))).To(Succeed())
+			Expect(st.CreatePreset(ctx, makePreset("p2", "Alpha"))).To(Succeed())
+			Expect(st.CreatePreset(ctx, makePreset("p3", "Middle"))).To(Succeed())
 
-			presets, err := st.ListPresets()
+			presets, err := st.ListPresets(ctx)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(presets).To(HaveLen(3))
 			Expect(presets[0].Name).To(Equal("Alpha"))
@@ -133,7 +157,7 @@ var _ = Describe("PresetStore", func() {
 
 	Describe("GetPreset", func() {
 		It("returns sql.ErrNoRows for non-existent preset", func() {
-			_, err := st.GetPreset("nonexistent")
+			_, err := st.GetPreset(ctx, "nonexistent")
 			Expect(err).To(Equal(sql.ErrNoRows))
 		})
 	})
@@ -141,15 +165,15 @@ var _ = Describe("PresetStore", func() {
 	Describe("UpdatePreset", func() {
 		It("updates name and mapping", func() {
 			p := makePreset("u1", "Original")
-			Expect(st.CreatePreset(p)).To(Succeed())
+			Expect(st.CreatePreset(ctx, p)).To(Succeed())
 
 			p.Name = "Updated"
 			p.Mapping.X = "seed"
 			p.Mapping.Combos = []string{"cfg"}
 			p.UpdatedAt = now.Add(time.Hour)
-			Expect(st.UpdatePreset(p)).To(Succeed())
+			Expect(st.UpdatePreset(ctx, p)).To(Succeed())
 
-			got, err := st.GetPreset("u1")
+			got, err := st.GetPreset(ctx, "u1")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got.Name).To(Equal("Updated"))
 			Expect(got.Mapping.X).To(Equal("seed"))
@@ -159,24 +183,41 @@ var _ = Describe("PresetStore", func() {
 
 		It("returns sql.ErrNoRows for non-existent preset", func() {
 			p := makePreset("missing", "Ghost")
-			err := st.UpdatePreset(p)
+			err := st.UpdatePreset(ctx, p)
 			Expect(err).To(Equal(sql.ErrNoRows))
 		})
 	})
 
 	Describe("DeletePreset", func() {
 		It("deletes an existing preset", func() {
-			Expect(st.CreatePreset(makePreset("d1", "ToDelete"))).To(Succeed())
+			Expect(st.CreatePreset(ctx, makePreset("d1", "ToDelete"))).To(Succeed())
 
-			Expect(st.DeletePreset("d1")).To(Succeed())
+			Expect(st.DeletePreset(ctx, "d1")).To(Succeed())
 
-			_, err := st.GetPreset("d1")
+			_, err := st.GetPreset(ctx, "d1")
 			Expect(err).To(Equal(sql.ErrNoRows))
 		})
 
 		It("returns sql.ErrNoRows for non-existent preset", func() {
-			err := st.DeletePreset("nonexistent")
+			err := st.DeletePreset(ctx, "nonexistent")
 			Expect(err).To(Equal(sql.ErrNoRows))
 		})
 	})
+
+	Describe("with a canceled context", func() {
+		It("does not run the statement", func() {
+			canceled, cancel := context.WithCancel(ctx)
+			cancel()
+
+			err := st.CreatePreset(canceled, makePreset("c1", "Canceled"))
+			Expect(err).To(MatchError(context.Canceled))
+
+			_, err = st.ListPresets(canceled)
+			Expect(err).To(MatchError(context.Canceled))
+
+			presets, err := st.ListPresets(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(presets).To(BeEmpty())
+		})
+	})
 })
@@ -1,9 +1,11 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
@@ -12,22 +14,38 @@ import (
 
 // sampleJobEntity is the persistence representation of a sample job.
 type sampleJobEntity struct {
-	ID                  string
-	TrainingRunName     string
-	StudyID             string
-	StudyName           string
-	WorkflowName        string
-	VAE                 sql.NullString
-	CLIP                sql.NullString
-	Shift               sql.NullFloat64
-	CheckpointFilenames string // JSON-encoded []string
-	ClearExisting       bool
-	Status              string
-	TotalItems          int
-	CompletedItems      int
-	ErrorMessage        sql.NullString
-	CreatedAt           string // RFC3339
-	UpdatedAt           string // RFC3339
+	ID                   string
+	TrainingRunName      string
+	StudyID              string
+	StudyName            string
+	StudyVersion         int
+	WorkflowName         string
+	WorkflowOverrides    string // JSON-encoded []workflowOverrideJSON; empty if none
+	VAE                  sql.NullString
+	CLIP                 sql.NullString
+	Shift                sql.NullFloat64
+	CheckpointFilenames  string // JSON-encoded []string
+	ClearExisting        bool
+	AppendNewCheckpoints bool
+	OutputFormat         string
+	OutputQuality        int
+	InputImage           string
+	ControlNetModel      string
+	ControlNetStrength   sql.NullFloat64
+	ControlNetImage      string
+	Status               string
+	TotalItems           int
+	CompletedItems       int
+	ErrorMessage         sql.NullString
+	CreatedByRequestID   string
+	Owner                string
+	Notes                string
+	Labels               string // JSON-encoded map[string]string; empty if none
+	Version              int
+	CreatedAt            string         // RFC3339
+	UpdatedAt            string         // RFC3339
+	DeletedAt            sql.NullString // RFC3339; NULL unless the job is in the trash
+	DeleteData           bool
 }
 
 // sampleJobItemEntity is the persistence representation of a sample job item.
@@ -36,6 +54,7 @@ type sampleJobItemEntity struct {
 	JobID              string
 	CheckpointFilename string
 	ComfyUIModelPath   string
+	WorkflowName       string
 	PromptName         string
 	PromptText         string
 	NegativePrompt     string
@@ -53,33 +72,79 @@ type sampleJobItemEntity struct {
 	ExceptionType      string
 	NodeType           string
 	Traceback          string
+	ComfyUILog         string
+	StartedAt          sql.NullString // RFC3339Nano
+	CompletedAt        sql.NullString // RFC3339Nano
+	DurationMs         sql.NullInt64
+	Denoise            sql.NullFloat64
+	Resolution         string // "WxH"; empty unless swept
+	Wildcards          string // JSON-encoded map[string]string; empty if none
+	Scores             string // JSON-encoded map[string]float64; empty if none
+	SkipReason         string
+	StagedCheckpoint   string
+	Version            int
 	CreatedAt          string // RFC3339
 	UpdatedAt          string // RFC3339
 }
 
+// workflowOverrideJSON is the JSON shape of a workflow override of a sample
+// job.
+type workflowOverrideJSON struct {
+	Pattern  string `json:"pattern"`
+	Workflow string `json:"workflow"`
+}
+
 // ListSampleJobs returns all sample jobs ordered by created_at ascending (oldest first, FIFO).
 // This ordering is used by the job executor for deterministic FIFO pickup.
-func (s *Store) ListSampleJobs() ([]model.SampleJob, error) {
+func (s *Store) ListSampleJobs(ctx context.Context) ([]model.SampleJob, error) {
 	s.logger.Trace("entering ListSampleJobs")
 	defer s.logger.Trace("returning from ListSampleJobs")
 
-	return s.listSampleJobsOrdered("ASC")
+	return s.listSampleJobsOrdered(ctx, "ASC", model.SampleJobFilter{}, model.Page{})
 }
 
-// ListSampleJobsDesc returns all sample jobs ordered by created_at descending (newest first).
+// ListSampleJobsDesc returns the sample jobs matching filter in page ordered by created_at descending (newest first).
 // This ordering is used for UI display so that recently created jobs appear at the top.
-func (s *Store) ListSampleJobsDesc() ([]model.SampleJob, error) {
-	s.logger.Trace("entering ListSampleJobsDesc")
+func (s *Store) ListSampleJobsDesc(ctx context.Context, filter model.SampleJobFilter, page model.Page) ([]model.SampleJob, error) {
+	s.logger.WithFields(logrus.Fields{
+		"owner":  filter.Scope.Owner,
+		"labels": len(filter.Labels),
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	}).Trace("entering ListSampleJobsDesc")
 	defer s.logger.Trace("returning from ListSampleJobsDesc")
 
-	return s.listSampleJobsOrdered("DESC")
+	return s.listSampleJobsOrdered(ctx, "DESC", filter, page)
+}
+
+// CountSampleJobs returns the number of sample jobs matching filter.
+func (s *Store) CountSampleJobs(ctx context.Context, filter model.SampleJobFilter) (int, error) {
+	s.logger.WithField("owner", filter.Scope.Owner).Trace("entering CountSampleJobs")
+	defer s.logger.Trace("returning from CountSampleJobs")
+
+	where, args := sampleJobWhere(filter)
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sample_jobs WHERE deleted_at IS NULL`+where, args...).Scan(&count); err != nil {
+		s.logger.WithError(err).Error("failed to count sample jobs")
+		return 0, fmt.Errorf("counting sample jobs: %w", err)
+	}
+	return count, nil
 }
 
 // listSampleJobsOrdered is the shared implementation for ListSampleJobs and ListSampleJobsDesc.
-// direction must be "ASC" or "DESC".
-func (s *Store) listSampleJobsOrdered(direction string) ([]model.SampleJob, error) {
-	rows, err := s.db.Query(`SELECT id, training_run_name, study_id, study_name, workflow_name, vae, clip, shift, checkpoint_filenames, clear_existing, status, total_items, completed_items, error_message, created_at, updated_at
-		FROM sample_jobs ORDER BY created_at ` + direction)
+// direction must be "ASC" or "DESC". Jobs created within the same second (e.g. by a
+// bulk create) are ordered by insertion via rowid.
+func (s *Store) listSampleJobsOrdered(ctx context.Context, direction string, filter model.SampleJobFilter, page model.Page) ([]model.SampleJob, error) {
+	where, args := sampleJobWhere(filter)
+	limit, offset := pageLimitOffset(page)
+	return s.querySampleJobs(ctx, `SELECT `+sampleJobColumns+`
+		FROM sample_jobs WHERE deleted_at IS NULL`+where+` ORDER BY created_at `+direction+`, rowid `+direction+` LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+}
+
+// querySampleJobs returns the sample jobs of a query selecting
+// sampleJobColumns.
+func (s *Store) querySampleJobs(ctx context.Context, query string, args ...any) ([]model.SampleJob, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		s.logger.WithError(err).Error("failed to query sample jobs")
 		return nil, fmt.Errorf("querying sample jobs: %w", err)
@@ -89,7 +154,7 @@ func (s *Store) listSampleJobsOrdered(direction string) ([]model.SampleJob, erro
 	var jobs []model.SampleJob
 	for rows.Next() {
 		var e sampleJobEntity
-		if err := rows.Scan(&e.ID, &e.TrainingRunName, &e.StudyID, &e.StudyName, &e.WorkflowName, &e.VAE, &e.CLIP, &e.Shift, &e.CheckpointFilenames, &e.ClearExisting, &e.Status, &e.TotalItems, &e.CompletedItems, &e.ErrorMessage, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		if err := rows.Scan(sampleJobScanArgs(&e)...); err != nil {
 			s.logger.WithError(err).Error("failed to scan sample job row")
 			return nil, fmt.Errorf("scanning sample job row: %w", err)
 		}
@@ -108,13 +173,13 @@ func (s *Store) listSampleJobsOrdered(direction string) ([]model.SampleJob, erro
 	return jobs, nil
 }
 
-// HasRunningJob returns true if any sample job currently has status "running".
-func (s *Store) HasRunningJob() (bool, error) {
+// HasRunningJob returns true if any sample job outside the trash currently has status "running".
+func (s *Store) HasRunningJob(ctx context.Context) (bool, error) {
 	s.logger.Trace("entering HasRunningJob")
 	defer s.logger.Trace("returning from HasRunningJob")
 
 	var count int
-	err := s.db.QueryRow(`SELECT COUNT(*) FROM sample_jobs WHERE status = 'running'`).Scan(&count)
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sample_jobs WHERE status = 'running' AND deleted_at IS NULL`).Scan(&count)
 	if err != nil {
 		s.logger.WithError(err).Error("failed to query running job count")
 		return false, fmt.Errorf("querying running job count: %w", err)
@@ -123,16 +188,32 @@ func (s *Store) HasRunningJob() (bool, error) {
 	return count > 0, nil
 }
 
-// GetSampleJob returns a single sample job by ID, or sql.ErrNoRows if not found.
-func (s *Store) GetSampleJob(id string) (model.SampleJob, error) {
+// GetSampleJob returns a single sample job by ID, or sql.ErrNoRows if not
+// found or in the trash.
+func (s *Store) GetSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
 	s.logger.WithField("sample_job_id", id).Trace("entering GetSampleJob")
 	defer s.logger.Trace("returning from GetSampleJob")
 
+	return s.getSampleJob(ctx, id, "deleted_at IS NULL")
+}
+
+// GetTrashedSampleJob returns a single sample job in the trash by ID, or
+// sql.ErrNoRows if not found or not in the trash.
+func (s *Store) GetTrashedSampleJob(ctx context.Context, id string) (model.SampleJob, error) {
+	s.logger.WithField("sample_job_id", id).Trace("entering GetTrashedSampleJob")
+	defer s.logger.Trace("returning from GetTrashedSampleJob")
+
+	return s.getSampleJob(ctx, id, "deleted_at IS NOT NULL")
+}
+
+// getSampleJob is the shared implementation for GetSampleJob and
+// GetTrashedSampleJob; trashed is the condition on deleted_at.
+func (s *Store) getSampleJob(ctx context.Context, id, trashed string) (model.SampleJob, error) {
 	var e sampleJobEntity
-	err := s.db.QueryRow(
-		`SELECT id, training_run_name, study_id, study_name, workflow_name, vae, clip, shift, checkpoint_filenames, clear_existing, status, total_items, completed_items, error_message, created_at, updated_at
-		FROM sample_jobs WHERE id = ?`, id,
-	).Scan(&e.ID, &e.TrainingRunName, &e.StudyID, &e.StudyName, &e.WorkflowName, &e.VAE, &e.CLIP, &e.Shift, &e.CheckpointFilenames, &e.ClearExisting, &e.Status, &e.TotalItems, &e.CompletedItems, &e.ErrorMessage, &e.CreatedAt, &e.UpdatedAt)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT `+sampleJobColumns+`
+		FROM sample_jobs WHERE id = ? AND `+trashed, id,
+	).Scan(sampleJobScanArgs(&e)...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			s.logger.WithField("sample_job_id", id).Debug("sample job not found in database")
@@ -149,7 +230,7 @@ func (s *Store) GetSampleJob(id string) (model.SampleJob, error) {
 }
 
 // CreateSampleJob inserts a new sample job.
-func (s *Store) CreateSampleJob(j model.SampleJob) error {
+func (s *Store) CreateSampleJob(ctx context.Context, j model.SampleJob) error {
 	s.logger.WithFields(logrus.Fields{
 		"sample_job_id":      j.ID,
 		"training_run_name":  j.TrainingRunName,
@@ -158,26 +239,7 @@ func (s *Store) CreateSampleJob(j model.SampleJob) error {
 
 	entity := sampleJobModelToEntity(j)
 
-	_, err := s.db.Exec(
-		`INSERT INTO sample_jobs (id, training_run_name, study_id, study_name, workflow_name, vae, clip, shift, checkpoint_filenames, clear_existing, status, total_items, completed_items, error_message, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		entity.ID,
-		entity.TrainingRunName,
-		entity.StudyID,
-		entity.StudyName,
-		entity.WorkflowName,
-		entity.VAE,
-		entity.CLIP,
-		entity.Shift,
-		entity.CheckpointFilenames,
-		entity.ClearExisting,
-		entity.Status,
-		entity.TotalItems,
-		entity.CompletedItems,
-		entity.ErrorMessage,
-		entity.CreatedAt,
-		entity.UpdatedAt,
-	)
+	_, err := s.db.ExecContext(ctx, insertSampleJobSQL, sampleJobInsertArgs(entity)...)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"sample_job_id":     j.ID,
@@ -193,35 +255,20 @@ func (s *Store) CreateSampleJob(j model.SampleJob) error {
 	return nil
 }
 
-// UpdateSampleJob updates an existing sample job. Returns sql.ErrNoRows if the job does not exist.
-func (s *Store) UpdateSampleJob(j model.SampleJob) error {
+// UpdateSampleJob updates an existing sample job if its stored version is
+// j.Version, and increments the version, both stored and on j. Returns
+// sql.ErrNoRows if the job does not exist and ErrVersionConflict if it was
+// updated since j was read.
+func (s *Store) UpdateSampleJob(ctx context.Context, j *model.SampleJob) error {
 	s.logger.WithFields(logrus.Fields{
 		"sample_job_id":     j.ID,
 		"training_run_name": j.TrainingRunName,
 	}).Trace("entering UpdateSampleJob")
 	defer s.logger.Trace("returning from UpdateSampleJob")
 
-	entity := sampleJobModelToEntity(j)
+	entity := sampleJobModelToEntity(*j)
 
-	result, err := s.db.Exec(
-		`UPDATE sample_jobs SET training_run_name = ?, study_id = ?, study_name = ?, workflow_name = ?, vae = ?, clip = ?, shift = ?, checkpoint_filenames = ?, clear_existing = ?, status = ?, total_items = ?, completed_items = ?, error_message = ?, updated_at = ?
-		WHERE id = ?`,
-		entity.TrainingRunName,
-		entity.StudyID,
-		entity.StudyName,
-		entity.WorkflowName,
-		entity.VAE,
-		entity.CLIP,
-		entity.Shift,
-		entity.CheckpointFilenames,
-		entity.ClearExisting,
-		entity.Status,
-		entity.TotalItems,
-		entity.CompletedItems,
-		entity.ErrorMessage,
-		entity.UpdatedAt,
-		entity.ID,
-	)
+	result, err := s.db.ExecContext(ctx, updateSampleJobSQL, sampleJobUpdateArgs(entity)...)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"sample_job_id":     j.ID,
@@ -239,9 +286,9 @@ func (s *Store) UpdateSampleJob(j model.SampleJob) error {
 		return fmt.Errorf("checking rows affected: %w", err)
 	}
 	if rows == 0 {
-		s.logger.WithField("sample_job_id", j.ID).Debug("no rows affected, sample job not found")
-		return sql.ErrNoRows
+		return s.missedUpdateError(ctx, "sample_jobs", j.ID, j.Version)
 	}
+	j.Version++
 	s.logger.WithFields(logrus.Fields{
 		"sample_job_id":     j.ID,
 		"training_run_name": j.TrainingRunName,
@@ -249,12 +296,70 @@ func (s *Store) UpdateSampleJob(j model.SampleJob) error {
 	return nil
 }
 
+// TransitionSampleJob updates an existing sample job like UpdateSampleJob, but
+// only while its stored status is from. Returns sql.ErrNoRows if the job does
+// not exist, ErrSampleJobStatusChanged if its status is no longer from, and
+// ErrVersionConflict if it was otherwise updated since j was read.
+func (s *Store) TransitionSampleJob(ctx context.Context, j *model.SampleJob, from model.SampleJobStatus) error {
+	s.logger.WithFields(logrus.Fields{
+		"sample_job_id": j.ID,
+		"from_status":   from,
+		"to_status":     j.Status,
+	}).Trace("entering TransitionSampleJob")
+	defer s.logger.Trace("returning from TransitionSampleJob")
+
+	entity := sampleJobModelToEntity(*j)
+
+	result, err := s.db.ExecContext(ctx, updateSampleJobSQL+" AND status = ?", append(sampleJobUpdateArgs(entity), string(from))...)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": j.ID,
+			"error":         err.Error(),
+		}).Error("failed to transition sample job in database")
+		return fmt.Errorf("transitioning sample job: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": j.ID,
+			"error":         err.Error(),
+		}).Error("failed to check rows affected")
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		var status string
+		if err := s.db.QueryRowContext(ctx, "SELECT status FROM sample_jobs WHERE id = ?", j.ID).Scan(&status); err != nil {
+			if err == sql.ErrNoRows {
+				s.logger.WithField("sample_job_id", j.ID).Debug("no rows affected, sample job not found")
+				return sql.ErrNoRows
+			}
+			return fmt.Errorf("reading sample job status: %w", err)
+		}
+		if status != string(from) {
+			s.logger.WithFields(logrus.Fields{
+				"sample_job_id":  j.ID,
+				"from_status":    from,
+				"current_status": status,
+			}).Debug("sample job status changed before the transition")
+			return fmt.Errorf("%w: job %s is %s, not %s", ErrSampleJobStatusChanged, j.ID, status, from)
+		}
+		return s.missedUpdateError(ctx, "sample_jobs", j.ID, j.Version)
+	}
+	j.Version++
+	s.logger.WithFields(logrus.Fields{
+		"sample_job_id": j.ID,
+		"from_status":   from,
+		"to_status":     j.Status,
+	}).Info("transitioned sample job in database")
+	return nil
+}
+
 // DeleteSampleJob removes a sample job and its items by ID. Returns sql.ErrNoRows if the job does not exist.
-func (s *Store) DeleteSampleJob(id string) error {
+func (s *Store) DeleteSampleJob(ctx context.Context, id string) error {
 	s.logger.WithField("sample_job_id", id).Trace("entering DeleteSampleJob")
 	defer s.logger.Trace("returning from DeleteSampleJob")
 
-	result, err := s.db.Exec("DELETE FROM sample_jobs WHERE id = ?", id)
+	result, err := s.db.ExecContext(ctx, "DELETE FROM sample_jobs WHERE id = ?", id)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"sample_job_id": id,
@@ -278,13 +383,166 @@ func (s *Store) DeleteSampleJob(id string) error {
 	return nil
 }
 
+// ListTrashedSampleJobs returns the sample jobs in scope that are in the
+// trash, most recently deleted first.
+func (s *Store) ListTrashedSampleJobs(ctx context.Context, scope model.OwnerScope) ([]model.SampleJob, error) {
+	s.logger.WithField("owner", scope.Owner).Trace("entering ListTrashedSampleJobs")
+	defer s.logger.Trace("returning from ListTrashedSampleJobs")
+
+	where, args := ownerWhere(scope)
+	return s.querySampleJobs(ctx, `SELECT `+sampleJobColumns+`
+		FROM sample_jobs WHERE deleted_at IS NOT NULL`+where+` ORDER BY deleted_at DESC, rowid DESC`, args...)
+}
+
+// TrashSampleJob moves a sample job to the trash, recording when it was
+// deleted and whether purging it removes its sample files. Returns
+// sql.ErrNoRows if the job does not exist or is already in the trash.
+func (s *Store) TrashSampleJob(ctx context.Context, id string, deletedAt time.Time, deleteData bool) error {
+	s.logger.WithFields(logrus.Fields{
+		"sample_job_id": id,
+		"delete_data":   deleteData,
+	}).Trace("entering TrashSampleJob")
+	defer s.logger.Trace("returning from TrashSampleJob")
+
+	return s.setSampleJobTrashed(ctx, id,
+		`UPDATE sample_jobs SET deleted_at = ?, delete_data = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL`,
+		deletedAt.UTC().Format(time.RFC3339), deleteData, id)
+}
+
+// RestoreSampleJob moves a sample job out of the trash. Returns sql.ErrNoRows
+// if the job does not exist or is not in the trash.
+func (s *Store) RestoreSampleJob(ctx context.Context, id string) error {
+	s.logger.WithField("sample_job_id", id).Trace("entering RestoreSampleJob")
+	defer s.logger.Trace("returning from RestoreSampleJob")
+
+	return s.setSampleJobTrashed(ctx, id,
+		`UPDATE sample_jobs SET deleted_at = NULL, delete_data = 0, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL`,
+		id)
+}
+
+// setSampleJobTrashed is the shared implementation for TrashSampleJob and
+// RestoreSampleJob.
+func (s *Store) setSampleJobTrashed(ctx context.Context, id, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": id,
+			"error":         err.Error(),
+		}).Error("failed to update sample job trash state in database")
+		return fmt.Errorf("updating sample job trash state: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": id,
+			"error":         err.Error(),
+		}).Error("failed to check rows affected")
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("sample_job_id", id).Debug("no rows affected, sample job not found")
+		return sql.ErrNoRows
+	}
+	s.logger.WithField("sample_job_id", id).Info("updated sample job trash state in database")
+	return nil
+}
+
 // ListSampleJobItems returns all items for a specific job, ordered by created_at.
-func (s *Store) ListSampleJobItems(jobID string) ([]model.SampleJobItem, error) {
+func (s *Store) ListSampleJobItems(ctx context.Context, jobID string) ([]model.SampleJobItem, error) {
 	s.logger.WithField("job_id", jobID).Trace("entering ListSampleJobItems")
 	defer s.logger.Trace("returning from ListSampleJobItems")
 
-	rows, err := s.db.Query(`SELECT id, job_id, checkpoint_filename, comfyui_model_path, prompt_name, prompt_text, negative_prompt, steps, cfg, sampler_name, scheduler, seed, width, height, status, comfyui_prompt_id, output_path, error_message, exception_type, node_type, traceback, created_at, updated_at
-		FROM sample_job_items WHERE job_id = ? ORDER BY created_at`, jobID)
+	return s.listSampleJobItems(ctx, jobID, model.SampleJobItemQuery{}, model.Page{})
+}
+
+// ListSampleJobItemsPage returns the items of a specific job that match
+// query.Filter, in query's order, limited to page. Filtering and sorting are
+// done in SQL so that large jobs are not loaded into memory.
+func (s *Store) ListSampleJobItemsPage(ctx context.Context, jobID string, query model.SampleJobItemQuery, page model.Page) ([]model.SampleJobItem, error) {
+	s.logger.WithFields(logrus.Fields{
+		"job_id": jobID,
+		"sort":   query.Sort,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	}).Trace("entering ListSampleJobItemsPage")
+	defer s.logger.Trace("returning from ListSampleJobItemsPage")
+
+	return s.listSampleJobItems(ctx, jobID, query, page)
+}
+
+// CountSampleJobItems returns the number of items of a specific job that
+// match filter.
+func (s *Store) CountSampleJobItems(ctx context.Context, jobID string, filter model.SampleJobItemFilter) (int, error) {
+	s.logger.WithField("job_id", jobID).Trace("entering CountSampleJobItems")
+	defer s.logger.Trace("returning from CountSampleJobItems")
+
+	where, args := sampleJobItemWhere(jobID, filter)
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sample_job_items WHERE `+where, args...).Scan(&count); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_id": jobID,
+			"error":  err.Error(),
+		}).Error("failed to count sample job items")
+		return 0, fmt.Errorf("counting sample job items: %w", err)
+	}
+	return count, nil
+}
+
+// CountSampleJobItemsByStatus returns the item status counts of a specific
+// job. Skipped items are counted as failed, as in
+// SampleJobService.GetItemCounts.
+func (s *Store) CountSampleJobItemsByStatus(ctx context.Context, jobID string) (model.ItemStatusCounts, error) {
+	s.logger.WithField("job_id", jobID).Trace("entering CountSampleJobItemsByStatus")
+	defer s.logger.Trace("returning from CountSampleJobItemsByStatus")
+
+	rows, err := s.db.QueryContext(ctx, "SELECT status, skip_reason, COUNT(*) FROM sample_job_items WHERE job_id = ? GROUP BY status, skip_reason", jobID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_id": jobID,
+			"error":  err.Error(),
+		}).Error("failed to count sample job items by status")
+		return model.ItemStatusCounts{}, fmt.Errorf("counting sample job items by status: %w", err)
+	}
+	defer rows.Close()
+
+	var counts model.ItemStatusCounts
+	for rows.Next() {
+		var status, skipReason string
+		var n int
+		if err := rows.Scan(&status, &skipReason, &n); err != nil {
+			s.logger.WithError(err).Error("failed to scan sample job item count row")
+			return model.ItemStatusCounts{}, fmt.Errorf("scanning sample job item count row: %w", err)
+		}
+		switch model.SampleJobItemStatus(status) {
+		case model.SampleJobItemStatusCompleted:
+			counts.Completed += n
+		case model.SampleJobItemStatusFailed:
+			counts.Failed += n
+		case model.SampleJobItemStatusSkipped:
+			counts.Failed += n
+			counts.AddSkipped(model.SampleJobItemSkipReason(skipReason), n)
+		case model.SampleJobItemStatusPending:
+			counts.Pending += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating sample job item counts")
+		return model.ItemStatusCounts{}, fmt.Errorf("iterating sample job item counts: %w", err)
+	}
+	return counts, nil
+}
+
+// listSampleJobItems is the shared implementation for ListSampleJobItems and
+// ListSampleJobItemsPage. Ties are broken by insertion order via rowid, so
+// that pages do not overlap.
+func (s *Store) listSampleJobItems(ctx context.Context, jobID string, query model.SampleJobItemQuery, page model.Page) ([]model.SampleJobItem, error) {
+	where, args := sampleJobItemWhere(jobID, query.Filter)
+	orderBy, orderArgs := sampleJobItemOrderBy(query)
+	args = append(args, orderArgs...)
+	limit, offset := pageLimitOffset(page)
+	args = append(args, limit, offset)
+	rows, err := s.db.QueryContext(ctx, `SELECT id, job_id, checkpoint_filename, comfyui_model_path, workflow_name, prompt_name, prompt_text, negative_prompt, steps, cfg, sampler_name, scheduler, seed, width, height, status, comfyui_prompt_id, output_path, error_message, exception_type, node_type, traceback, comfyui_log, started_at, completed_at, duration_ms, denoise, resolution, wildcards, scores, skip_reason, staged_checkpoint, created_at, updated_at, version
+		FROM sample_job_items WHERE `+where+` ORDER BY `+orderBy+` LIMIT ? OFFSET ?`, args...)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"job_id": jobID,
@@ -294,10 +552,50 @@ func (s *Store) ListSampleJobItems(jobID string) ([]model.SampleJobItem, error)
 	}
 	defer rows.Close()
 
+	items, err := s.scanSampleJobItems(rows)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"job_id":     jobID,
+		"item_count": len(items),
+	}).Debug("listed sample job items from database")
+	return items, nil
+}
+
+// ListSampleJobItemsByPromptIDs returns the items last submitted to ComfyUI
+// under one of promptIDs, in no particular order. Prompt IDs that match no
+// item are left out.
+func (s *Store) ListSampleJobItemsByPromptIDs(ctx context.Context, promptIDs []string) ([]model.SampleJobItem, error) {
+	s.logger.WithField("prompt_count", len(promptIDs)).Trace("entering ListSampleJobItemsByPromptIDs")
+	defer s.logger.Trace("returning from ListSampleJobItemsByPromptIDs")
+
+	if len(promptIDs) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(promptIDs)), ", ")
+	args := make([]any, len(promptIDs))
+	for i, id := range promptIDs {
+		args[i] = id
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id, job_id, checkpoint_filename, comfyui_model_path, workflow_name, prompt_name, prompt_text, negative_prompt, steps, cfg, sampler_name, scheduler, seed, width, height, status, comfyui_prompt_id, output_path, error_message, exception_type, node_type, traceback, comfyui_log, started_at, completed_at, duration_ms, denoise, resolution, wildcards, scores, skip_reason, staged_checkpoint, created_at, updated_at, version
+		FROM sample_job_items WHERE comfyui_prompt_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query sample job items by prompt ID")
+		return nil, fmt.Errorf("querying sample job items by prompt ID: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanSampleJobItems(rows)
+}
+
+// scanSampleJobItems reads the sample job item rows selected with the
+// columns of listSampleJobItems.
+func (s *Store) scanSampleJobItems(rows *sql.Rows) ([]model.SampleJobItem, error) {
 	var items []model.SampleJobItem
 	for rows.Next() {
 		var e sampleJobItemEntity
-		if err := rows.Scan(&e.ID, &e.JobID, &e.CheckpointFilename, &e.ComfyUIModelPath, &e.PromptName, &e.PromptText, &e.NegativePrompt, &e.Steps, &e.CFG, &e.SamplerName, &e.Scheduler, &e.Seed, &e.Width, &e.Height, &e.Status, &e.ComfyUIPromptID, &e.OutputPath, &e.ErrorMessage, &e.ExceptionType, &e.NodeType, &e.Traceback, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		if err := rows.Scan(&e.ID, &e.JobID, &e.CheckpointFilename, &e.ComfyUIModelPath, &e.WorkflowName, &e.PromptName, &e.PromptText, &e.NegativePrompt, &e.Steps, &e.CFG, &e.SamplerName, &e.Scheduler, &e.Seed, &e.Width, &e.Height, &e.Status, &e.ComfyUIPromptID, &e.OutputPath, &e.ErrorMessage, &e.ExceptionType, &e.NodeType, &e.Traceback, &e.ComfyUILog, &e.StartedAt, &e.CompletedAt, &e.DurationMs, &e.Denoise, &e.Resolution, &e.Wildcards, &e.Scores, &e.SkipReason, &e.StagedCheckpoint, &e.CreatedAt, &e.UpdatedAt, &e.Version); err != nil {
 			s.logger.WithError(err).Error("failed to scan sample job item row")
 			return nil, fmt.Errorf("scanning sample job item row: %w", err)
 		}
@@ -312,15 +610,11 @@ func (s *Store) ListSampleJobItems(jobID string) ([]model.SampleJobItem, error)
 		s.logger.WithError(err).Error("error iterating sample job items")
 		return nil, fmt.Errorf("iterating sample job items: %w", err)
 	}
-	s.logger.WithFields(logrus.Fields{
-		"job_id":     jobID,
-		"item_count": len(items),
-	}).Debug("listed sample job items from database")
 	return items, nil
 }
 
 // CreateSampleJobItem inserts a new sample job item.
-func (s *Store) CreateSampleJobItem(i model.SampleJobItem) error {
+func (s *Store) CreateSampleJobItem(ctx context.Context, i model.SampleJobItem) error {
 	s.logger.WithFields(logrus.Fields{
 		"sample_job_item_id": i.ID,
 		"job_id":             i.JobID,
@@ -329,33 +623,7 @@ func (s *Store) CreateSampleJobItem(i model.SampleJobItem) error {
 
 	entity := sampleJobItemModelToEntity(i)
 
-	_, err := s.db.Exec(
-		`INSERT INTO sample_job_items (id, job_id, checkpoint_filename, comfyui_model_path, prompt_name, prompt_text, negative_prompt, steps, cfg, sampler_name, scheduler, seed, width, height, status, comfyui_prompt_id, output_path, error_message, exception_type, node_type, traceback, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		entity.ID,
-		entity.JobID,
-		entity.CheckpointFilename,
-		entity.ComfyUIModelPath,
-		entity.PromptName,
-		entity.PromptText,
-		entity.NegativePrompt,
-		entity.Steps,
-		entity.CFG,
-		entity.SamplerName,
-		entity.Scheduler,
-		entity.Seed,
-		entity.Width,
-		entity.Height,
-		entity.Status,
-		entity.ComfyUIPromptID,
-		entity.OutputPath,
-		entity.ErrorMessage,
-		entity.ExceptionType,
-		entity.NodeType,
-		entity.Traceback,
-		entity.CreatedAt,
-		entity.UpdatedAt,
-	)
+	_, err := s.db.ExecContext(ctx, insertSampleJobItemSQL, sampleJobItemInsertArgs(entity)...)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"sample_job_item_id": i.ID,
@@ -371,22 +639,335 @@ func (s *Store) CreateSampleJobItem(i model.SampleJobItem) error {
 	return nil
 }
 
-// UpdateSampleJobItem updates an existing sample job item. Returns sql.ErrNoRows if the item does not exist.
-func (s *Store) UpdateSampleJobItem(i model.SampleJobItem) error {
+// CreateSampleJobWithItems inserts a new sample job together with its items
+// in one transaction, so that a failure leaves neither the job nor any of its
+// items behind.
+func (s *Store) CreateSampleJobWithItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error {
+	s.logger.WithFields(logrus.Fields{
+		"sample_job_id":     j.ID,
+		"training_run_name": j.TrainingRunName,
+		"item_count":        len(items),
+	}).Trace("entering CreateSampleJobWithItems")
+	defer s.logger.Trace("returning from CreateSampleJobWithItems")
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": j.ID,
+			"error":         err.Error(),
+		}).Error("failed to begin sample job transaction")
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(insertSampleJobSQL, sampleJobInsertArgs(sampleJobModelToEntity(j))...); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id":     j.ID,
+			"training_run_name": j.TrainingRunName,
+			"error":             err.Error(),
+		}).Error("failed to insert sample job into database")
+		return fmt.Errorf("inserting sample job: %w", err)
+	}
+
+	if err := s.insertSampleJobItems(tx, j.ID, items); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": j.ID,
+			"error":         err.Error(),
+		}).Error("failed to commit sample job transaction")
+		return fmt.Errorf("committing sample job: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"sample_job_id":     j.ID,
+		"training_run_name": j.TrainingRunName,
+		"item_count":        len(items),
+	}).Info("inserted sample job and items into database")
+	return nil
+}
+
+// CreateStudyWithSampleJob inserts a new study, a sample job of it and the
+// job's items in one transaction, so that a failure leaves neither the study
+// nor the job behind.
+func (s *Store) CreateStudyWithSampleJob(ctx context.Context, st model.Study, j model.SampleJob, items []model.SampleJobItem) error {
+	s.logger.WithFields(logrus.Fields{
+		"study_id":      st.ID,
+		"sample_job_id": j.ID,
+		"item_count":    len(items),
+	}).Trace("entering CreateStudyWithSampleJob")
+	defer s.logger.Trace("returning from CreateStudyWithSampleJob")
+
+	studyEntity, err := studyModelToEntity(st)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"study_id": st.ID,
+			"error":    err.Error(),
+		}).Error("failed to convert model to entity")
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": j.ID,
+			"error":         err.Error(),
+		}).Error("failed to begin sample job transaction")
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(insertStudySQL, studyInsertArgs(studyEntity)...); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"study_id":   st.ID,
+			"study_name": st.Name,
+			"error":      err.Error(),
+		}).Error("failed to insert study into database")
+		return fmt.Errorf("inserting study: %w", err)
+	}
+
+	if _, err := tx.Exec(insertSampleJobSQL, sampleJobInsertArgs(sampleJobModelToEntity(j))...); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id":     j.ID,
+			"training_run_name": j.TrainingRunName,
+			"error":             err.Error(),
+		}).Error("failed to insert sample job into database")
+		return fmt.Errorf("inserting sample job: %w", err)
+	}
+
+	if err := s.insertSampleJobItems(tx, j.ID, items); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": j.ID,
+			"error":         err.Error(),
+		}).Error("failed to commit sample job transaction")
+		return fmt.Errorf("committing sample job: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"study_id":      st.ID,
+		"sample_job_id": j.ID,
+		"item_count":    len(items),
+	}).Info("inserted study, sample job and items into database")
+	return nil
+}
+
+// AppendSampleJobItems updates an existing sample job like UpdateSampleJob
+// and inserts new items for it in one transaction. Returns sql.ErrNoRows if
+// the job does not exist and ErrVersionConflict if it was updated since j was
+// read.
+func (s *Store) AppendSampleJobItems(ctx context.Context, j model.SampleJob, items []model.SampleJobItem) error {
+	s.logger.WithFields(logrus.Fields{
+		"sample_job_id": j.ID,
+		"item_count":    len(items),
+	}).Trace("entering AppendSampleJobItems")
+	defer s.logger.Trace("returning from AppendSampleJobItems")
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": j.ID,
+			"error":         err.Error(),
+		}).Error("failed to begin sample job transaction")
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(updateSampleJobSQL, sampleJobUpdateArgs(sampleJobModelToEntity(j))...)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": j.ID,
+			"error":         err.Error(),
+		}).Error("failed to update sample job in database")
+		return fmt.Errorf("updating sample job: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		tx.Rollback()
+		return s.missedUpdateError(ctx, "sample_jobs", j.ID, j.Version)
+	}
+
+	if err := s.insertSampleJobItems(tx, j.ID, items); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": j.ID,
+			"error":         err.Error(),
+		}).Error("failed to commit sample job transaction")
+		return fmt.Errorf("committing sample job: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"sample_job_id": j.ID,
+		"item_count":    len(items),
+	}).Info("appended items to sample job in database")
+	return nil
+}
+
+// insertSampleJobItems inserts items of job jobID within tx, preparing the
+// insert once and reusing it for every item.
+func (s *Store) insertSampleJobItems(tx *instrumentedTx, jobID string, items []model.SampleJobItem) error {
+	stmt, err := tx.Prepare(insertSampleJobItemSQL)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": jobID,
+			"error":         err.Error(),
+		}).Error("failed to prepare sample job item insert")
+		return fmt.Errorf("preparing sample job item insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, i := range items {
+		if _, err := stmt.Exec(sampleJobItemInsertArgs(sampleJobItemModelToEntity(i))...); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"sample_job_id":      jobID,
+				"sample_job_item_id": i.ID,
+				"error":              err.Error(),
+			}).Error("failed to insert sample job item into database")
+			return fmt.Errorf("inserting sample job item %s: %w", i.ID, err)
+		}
+	}
+	return nil
+}
+
+// AverageItemDuration returns the mean duration of the most recent completed
+// sample job items that recorded a duration, considering at most limit items.
+// Returns ok=false when no completed item has timing data.
+func (s *Store) AverageItemDuration(ctx context.Context, limit int) (avg time.Duration, ok bool, err error) {
+	s.logger.WithField("limit", limit).Trace("entering AverageItemDuration")
+	defer s.logger.Trace("returning from AverageItemDuration")
+
+	var avgMs sql.NullFloat64
+	err = s.db.QueryRowContext(ctx, `SELECT AVG(duration_ms) FROM (
+			SELECT duration_ms FROM sample_job_items
+			WHERE status = ? AND duration_ms IS NOT NULL
+			ORDER BY completed_at DESC LIMIT ?)`,
+		string(model.SampleJobItemStatusCompleted), limit,
+	).Scan(&avgMs)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query average item duration")
+		return 0, false, fmt.Errorf("querying average item duration: %w", err)
+	}
+	if !avgMs.Valid {
+		s.logger.Debug("no completed items with timing data")
+		return 0, false, nil
+	}
+	avg = time.Duration(avgMs.Float64 * float64(time.Millisecond))
+	s.logger.WithField("average_ms", avgMs.Float64).Debug("computed average item duration")
+	return avg, true, nil
+}
+
+// SummarizeTrainingRunJobs aggregates the sample jobs of a training run that
+// are not in the trash: their count per status, and per checkpoint the
+// completed and failed (including skipped) items and when the last completed
+// item finished.
+func (s *Store) SummarizeTrainingRunJobs(ctx context.Context, trainingRunName string) (model.TrainingRunJobStats, error) {
+	s.logger.WithField("training_run", trainingRunName).Trace("entering SummarizeTrainingRunJobs")
+	defer s.logger.Trace("returning from SummarizeTrainingRunJobs")
+
+	stats := model.TrainingRunJobStats{
+		JobStatusCounts: make(map[model.SampleJobStatus]int),
+		Checkpoints:     make(map[string]model.CheckpointJobStats),
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM sample_jobs
+		WHERE training_run_name = ? AND deleted_at IS NULL GROUP BY status`, trainingRunName)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"training_run": trainingRunName,
+			"error":        err.Error(),
+		}).Error("failed to count sample jobs by status")
+		return model.TrainingRunJobStats{}, fmt.Errorf("counting sample jobs by status: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			s.logger.WithError(err).Error("failed to scan sample job count row")
+			return model.TrainingRunJobStats{}, fmt.Errorf("scanning sample job count row: %w", err)
+		}
+		stats.JobStatusCounts[model.SampleJobStatus(status)] = n
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating sample job counts")
+		return model.TrainingRunJobStats{}, fmt.Errorf("iterating sample job counts: %w", err)
+	}
+	rows.Close()
+
+	rows, err = s.db.QueryContext(ctx, `SELECT i.checkpoint_filename, i.status, COUNT(*), MAX(i.completed_at)
+		FROM sample_job_items i JOIN sample_jobs j ON j.id = i.job_id
+		WHERE j.training_run_name = ? AND j.deleted_at IS NULL AND i.status IN (?, ?, ?)
+		GROUP BY i.checkpoint_filename, i.status`,
+		trainingRunName,
+		string(model.SampleJobItemStatusCompleted),
+		string(model.SampleJobItemStatusFailed),
+		string(model.SampleJobItemStatusSkipped),
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"training_run": trainingRunName,
+			"error":        err.Error(),
+		}).Error("failed to count sample job items by checkpoint")
+		return model.TrainingRunJobStats{}, fmt.Errorf("counting sample job items by checkpoint: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var checkpoint, status string
+		var n int
+		var lastCompletedAt sql.NullString
+		if err := rows.Scan(&checkpoint, &status, &n, &lastCompletedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan sample job item count row")
+			return model.TrainingRunJobStats{}, fmt.Errorf("scanning sample job item count row: %w", err)
+		}
+		c := stats.Checkpoints[checkpoint]
+		if model.SampleJobItemStatus(status) == model.SampleJobItemStatusCompleted {
+			c.CompletedItems += n
+			if c.LastCompletedAt, err = parseNullTime(lastCompletedAt); err != nil {
+				return model.TrainingRunJobStats{}, fmt.Errorf("parsing completed_at: %w", err)
+			}
+		} else {
+			c.FailedItems += n
+		}
+		stats.Checkpoints[checkpoint] = c
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating sample job item counts")
+		return model.TrainingRunJobStats{}, fmt.Errorf("iterating sample job item counts: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"training_run":     trainingRunName,
+		"checkpoint_count": len(stats.Checkpoints),
+	}).Debug("summarized training run sample jobs")
+	return stats, nil
+}
+
+// UpdateSampleJobItem updates an existing sample job item if its stored
+// version is i.Version, and increments the version, both stored and on i.
+// Returns sql.ErrNoRows if the item does not exist and ErrVersionConflict if
+// it was updated since i was read.
+func (s *Store) UpdateSampleJobItem(ctx context.Context, i *model.SampleJobItem) error {
 	s.logger.WithFields(logrus.Fields{
 		"sample_job_item_id": i.ID,
 		"job_id":             i.JobID,
 	}).Trace("entering UpdateSampleJobItem")
 	defer s.logger.Trace("returning from UpdateSampleJobItem")
 
-	entity := sampleJobItemModelToEntity(i)
+	entity := sampleJobItemModelToEntity(*i)
 
-	result, err := s.db.Exec(
-		`UPDATE sample_job_items SET job_id = ?, checkpoint_filename = ?, comfyui_model_path = ?, prompt_name = ?, prompt_text = ?, negative_prompt = ?, steps = ?, cfg = ?, sampler_name = ?, scheduler = ?, seed = ?, width = ?, height = ?, status = ?, comfyui_prompt_id = ?, output_path = ?, error_message = ?, exception_type = ?, node_type = ?, traceback = ?, updated_at = ?
-		WHERE id = ?`,
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE sample_job_items SET job_id = ?, checkpoint_filename = ?, comfyui_model_path = ?, workflow_name = ?, prompt_name = ?, prompt_text = ?, negative_prompt = ?, steps = ?, cfg = ?, sampler_name = ?, scheduler = ?, seed = ?, width = ?, height = ?, status = ?, comfyui_prompt_id = ?, output_path = ?, error_message = ?, exception_type = ?, node_type = ?, traceback = ?, comfyui_log = ?, started_at = ?, completed_at = ?, duration_ms = ?, denoise = ?, resolution = ?, wildcards = ?, scores = ?, skip_reason = ?, staged_checkpoint = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ?`,
 		entity.JobID,
 		entity.CheckpointFilename,
 		entity.ComfyUIModelPath,
+		entity.WorkflowName,
 		entity.PromptName,
 		entity.PromptText,
 		entity.NegativePrompt,
@@ -404,8 +985,19 @@ func (s *Store) UpdateSampleJobItem(i model.SampleJobItem) error {
 		entity.ExceptionType,
 		entity.NodeType,
 		entity.Traceback,
+		entity.ComfyUILog,
+		entity.StartedAt,
+		entity.CompletedAt,
+		entity.DurationMs,
+		entity.Denoise,
+		entity.Resolution,
+		entity.Wildcards,
+		entity.Scores,
+		entity.SkipReason,
+		entity.StagedCheckpoint,
 		entity.UpdatedAt,
 		entity.ID,
+		entity.Version,
 	)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
@@ -424,9 +1016,9 @@ func (s *Store) UpdateSampleJobItem(i model.SampleJobItem) error {
 		return fmt.Errorf("checking rows affected: %w", err)
 	}
 	if rows == 0 {
-		s.logger.WithField("sample_job_item_id", i.ID).Debug("no rows affected, sample job item not found")
-		return sql.ErrNoRows
+		return s.missedUpdateError(ctx, "sample_job_items", i.ID, i.Version)
 	}
+	i.Version++
 	s.logger.WithFields(logrus.Fields{
 		"sample_job_item_id": i.ID,
 		"job_id":             i.JobID,
@@ -434,11 +1026,35 @@ func (s *Store) UpdateSampleJobItem(i model.SampleJobItem) error {
 	return nil
 }
 
+// missedUpdateError returns the error of an update of row id of table (sample
+// jobs or items) at version that changed no row: sql.ErrNoRows if the row does
+// not exist, and ErrVersionConflict if it was updated since version was read.
+func (s *Store) missedUpdateError(ctx context.Context, table, id string, version int) error {
+	var stored int
+	if err := s.db.QueryRowContext(ctx, "SELECT version FROM "+table+" WHERE id = ?", id).Scan(&stored); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.WithFields(logrus.Fields{
+				"table": table,
+				"id":    id,
+			}).Debug("no rows affected, row not found")
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("reading %s version: %w", table, err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"table":          table,
+		"id":             id,
+		"version":        version,
+		"stored_version": stored,
+	}).Debug("no rows affected, row was updated concurrently")
+	return fmt.Errorf("%w: %s row %s is at version %d, not %d", ErrVersionConflict, table, id, stored, version)
+}
+
 // SeedSampleJobs inserts multiple sample jobs directly into the database.
 // For each unique study_id referenced by a job, a minimal stub study is
 // created if no study with that ID already exists, satisfying the FK constraint.
 // This is intended for test infrastructure only (E2E seed endpoint).
-func (s *Store) SeedSampleJobs(jobs []model.SampleJob) error {
+func (s *Store) SeedSampleJobs(ctx context.Context, jobs []model.SampleJob) error {
 	s.logger.WithField("job_count", len(jobs)).Trace("entering SeedSampleJobs")
 	defer s.logger.Trace("returning from SeedSampleJobs")
 
@@ -447,14 +1063,14 @@ func (s *Store) SeedSampleJobs(jobs []model.SampleJob) error {
 	for _, j := range jobs {
 		if j.StudyID != "" && !seen[j.StudyID] {
 			seen[j.StudyID] = true
-			if err := s.ensureStubStudy(j.StudyID, j.StudyName); err != nil {
+			if err := s.ensureStubStudy(ctx, j.StudyID, j.StudyName); err != nil {
 				return fmt.Errorf("ensuring stub study %s: %w", j.StudyID, err)
 			}
 		}
 	}
 
 	for _, j := range jobs {
-		if err := s.CreateSampleJob(j); err != nil {
+		if err := s.CreateSampleJob(ctx, j); err != nil {
 			s.logger.WithFields(logrus.Fields{
 				"sample_job_id": j.ID,
 				"error":         err.Error(),
@@ -469,10 +1085,10 @@ func (s *Store) SeedSampleJobs(jobs []model.SampleJob) error {
 // ensureStubStudy inserts a minimal study row with the given ID and name if
 // no study with that ID exists. Used by SeedSampleJobs to satisfy the FK
 // constraint on sample_jobs(study_id) without requiring a real study to exist.
-func (s *Store) ensureStubStudy(studyID, studyName string) error {
+func (s *Store) ensureStubStudy(ctx context.Context, studyID, studyName string) error {
 	// Check if the study already exists.
 	var count int
-	if err := s.db.QueryRow("SELECT COUNT(*) FROM studies WHERE id = ?", studyID).Scan(&count); err != nil {
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM studies WHERE id = ?", studyID).Scan(&count); err != nil {
 		return fmt.Errorf("checking study existence: %w", err)
 	}
 	if count > 0 {
@@ -485,7 +1101,7 @@ func (s *Store) ensureStubStudy(studyID, studyName string) error {
 	}
 	now := time.Now().UTC().Format(time.RFC3339)
 
-	_, err := s.db.Exec(
+	_, err := s.db.ExecContext(ctx,
 		`INSERT INTO studies (id, name, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, created_at, updated_at)
 		VALUES (?, ?, '', '[]', '', '[]', '[]', '[]', '[]', 512, 512, ?, ?)`,
 		studyID, name, now, now,
@@ -509,11 +1125,19 @@ func sampleJobEntityToModel(e sampleJobEntity) (model.SampleJob, error) {
 	if err != nil {
 		return model.SampleJob{}, fmt.Errorf("parsing updated_at: %w", err)
 	}
+	deletedAt, err := parseNullTime(e.DeletedAt)
+	if err != nil {
+		return model.SampleJob{}, fmt.Errorf("parsing deleted_at: %w", err)
+	}
 
 	var shift *float64
 	if e.Shift.Valid {
 		shift = &e.Shift.Float64
 	}
+	var controlNetStrength *float64
+	if e.ControlNetStrength.Valid {
+		controlNetStrength = &e.ControlNetStrength.Float64
+	}
 
 	var checkpointFilenames []string
 	if e.CheckpointFilenames != "" && e.CheckpointFilenames != "[]" {
@@ -525,26 +1149,184 @@ func sampleJobEntityToModel(e sampleJobEntity) (model.SampleJob, error) {
 		checkpointFilenames = []string{}
 	}
 
+	labels, err := decodeJobLabels(e.Labels)
+	if err != nil {
+		return model.SampleJob{}, err
+	}
+
+	var workflowOverrides []model.WorkflowOverride
+	if e.WorkflowOverrides != "" {
+		var overrides []workflowOverrideJSON
+		if err := json.Unmarshal([]byte(e.WorkflowOverrides), &overrides); err != nil {
+			return model.SampleJob{}, fmt.Errorf("parsing workflow_overrides: %w", err)
+		}
+		for _, o := range overrides {
+			workflowOverrides = append(workflowOverrides, model.WorkflowOverride{Pattern: o.Pattern, Workflow: o.Workflow})
+		}
+	}
+
 	return model.SampleJob{
-		ID:                  e.ID,
-		TrainingRunName:     e.TrainingRunName,
-		StudyID:             e.StudyID,
-		StudyName:           e.StudyName,
-		WorkflowName:        e.WorkflowName,
-		VAE:                 e.VAE.String,
-		CLIP:                e.CLIP.String,
-		Shift:               shift,
-		CheckpointFilenames: checkpointFilenames,
-		ClearExisting:       e.ClearExisting,
-		Status:              model.SampleJobStatus(e.Status),
-		TotalItems:          e.TotalItems,
-		CompletedItems:      e.CompletedItems,
-		ErrorMessage:        e.ErrorMessage.String,
-		CreatedAt:           createdAt,
-		UpdatedAt:           updatedAt,
+		ID:                   e.ID,
+		TrainingRunName:      e.TrainingRunName,
+		StudyID:              e.StudyID,
+		StudyName:            e.StudyName,
+		StudyVersion:         e.StudyVersion,
+		WorkflowName:         e.WorkflowName,
+		WorkflowOverrides:    workflowOverrides,
+		VAE:                  e.VAE.String,
+		CLIP:                 e.CLIP.String,
+		Shift:                shift,
+		CheckpointFilenames:  checkpointFilenames,
+		ClearExisting:        e.ClearExisting,
+		AppendNewCheckpoints: e.AppendNewCheckpoints,
+		OutputFormat:         model.OutputFormat(e.OutputFormat),
+		OutputQuality:        e.OutputQuality,
+		InputImage:           e.InputImage,
+		ControlNetModel:      e.ControlNetModel,
+		ControlNetStrength:   controlNetStrength,
+		ControlNetImage:      e.ControlNetImage,
+		Status:               model.SampleJobStatus(e.Status),
+		TotalItems:           e.TotalItems,
+		CompletedItems:       e.CompletedItems,
+		ErrorMessage:         e.ErrorMessage.String,
+		CreatedByRequestID:   e.CreatedByRequestID,
+		Owner:                e.Owner,
+		Notes:                e.Notes,
+		Labels:               labels,
+		Version:              e.Version,
+		CreatedAt:            createdAt,
+		UpdatedAt:            updatedAt,
+		DeletedAt:            deletedAt,
+		DeleteData:           e.DeleteData,
 	}, nil
 }
 
+// sampleJobColumns are the columns of sample_jobs read into a sampleJobEntity
+// by sampleJobScanArgs.
+const sampleJobColumns = `id, training_run_name, study_id, study_name, study_version, workflow_name, workflow_overrides, vae, clip, shift, checkpoint_filenames, clear_existing, append_new_checkpoints, output_format, output_quality, input_image, controlnet_model, controlnet_strength, controlnet_image, status, total_items, completed_items, error_message, created_by_request_id, owner, notes, labels, created_at, updated_at, version, deleted_at, delete_data`
+
+// sampleJobScanArgs returns the scan destinations of sampleJobColumns in e.
+func sampleJobScanArgs(e *sampleJobEntity) []any {
+	return []any{&e.ID, &e.TrainingRunName, &e.StudyID, &e.StudyName, &e.StudyVersion, &e.WorkflowName, &e.WorkflowOverrides, &e.VAE, &e.CLIP, &e.Shift, &e.CheckpointFilenames, &e.ClearExisting, &e.AppendNewCheckpoints, &e.OutputFormat, &e.OutputQuality, &e.InputImage, &e.ControlNetModel, &e.ControlNetStrength, &e.ControlNetImage, &e.Status, &e.TotalItems, &e.CompletedItems, &e.ErrorMessage, &e.CreatedByRequestID, &e.Owner, &e.Notes, &e.Labels, &e.CreatedAt, &e.UpdatedAt, &e.Version, &e.DeletedAt, &e.DeleteData}
+}
+
+const insertSampleJobSQL = `INSERT INTO sample_jobs (id, training_run_name, study_id, study_name, study_version, workflow_name, workflow_overrides, vae, clip, shift, checkpoint_filenames, clear_existing, append_new_checkpoints, output_format, output_quality, input_image, controlnet_model, controlnet_strength, controlnet_image, status, total_items, completed_items, error_message, created_by_request_id, owner, notes, labels, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// sampleJobInsertArgs returns the arguments of insertSampleJobSQL for entity.
+func sampleJobInsertArgs(entity sampleJobEntity) []any {
+	return []any{
+		entity.ID,
+		entity.TrainingRunName,
+		entity.StudyID,
+		entity.StudyName,
+		entity.StudyVersion,
+		entity.WorkflowName,
+		entity.WorkflowOverrides,
+		entity.VAE,
+		entity.CLIP,
+		entity.Shift,
+		entity.CheckpointFilenames,
+		entity.ClearExisting,
+		entity.AppendNewCheckpoints,
+		entity.OutputFormat,
+		entity.OutputQuality,
+		entity.InputImage,
+		entity.ControlNetModel,
+		entity.ControlNetStrength,
+		entity.ControlNetImage,
+		entity.Status,
+		entity.TotalItems,
+		entity.CompletedItems,
+		entity.ErrorMessage,
+		entity.CreatedByRequestID,
+		entity.Owner,
+		entity.Notes,
+		entity.Labels,
+		entity.CreatedAt,
+		entity.UpdatedAt,
+	}
+}
+
+const updateSampleJobSQL = `UPDATE sample_jobs SET training_run_name = ?, study_id = ?, study_name = ?, study_version = ?, workflow_name = ?, workflow_overrides = ?, vae = ?, clip = ?, shift = ?, checkpoint_filenames = ?, clear_existing = ?, append_new_checkpoints = ?, output_format = ?, output_quality = ?, input_image = ?, controlnet_model = ?, controlnet_strength = ?, controlnet_image = ?, status = ?, total_items = ?, completed_items = ?, error_message = ?, notes = ?, labels = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ?`
+
+// sampleJobUpdateArgs returns the arguments of updateSampleJobSQL for entity.
+func sampleJobUpdateArgs(entity sampleJobEntity) []any {
+	return []any{
+		entity.TrainingRunName,
+		entity.StudyID,
+		entity.StudyName,
+		entity.StudyVersion,
+		entity.WorkflowName,
+		entity.WorkflowOverrides,
+		entity.VAE,
+		entity.CLIP,
+		entity.Shift,
+		entity.CheckpointFilenames,
+		entity.ClearExisting,
+		entity.AppendNewCheckpoints,
+		entity.OutputFormat,
+		entity.OutputQuality,
+		entity.InputImage,
+		entity.ControlNetModel,
+		entity.ControlNetStrength,
+		entity.ControlNetImage,
+		entity.Status,
+		entity.TotalItems,
+		entity.CompletedItems,
+		entity.ErrorMessage,
+		entity.Notes,
+		entity.Labels,
+		entity.UpdatedAt,
+		entity.ID,
+		entity.Version,
+	}
+}
+
+const insertSampleJobItemSQL = `INSERT INTO sample_job_items (id, job_id, checkpoint_filename, comfyui_model_path, workflow_name, prompt_name, prompt_text, negative_prompt, steps, cfg, sampler_name, scheduler, seed, width, height, status, comfyui_prompt_id, output_path, error_message, exception_type, node_type, traceback, comfyui_log, started_at, completed_at, duration_ms, denoise, resolution, wildcards, scores, skip_reason, staged_checkpoint, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// sampleJobItemInsertArgs returns the arguments of insertSampleJobItemSQL for entity.
+func sampleJobItemInsertArgs(entity sampleJobItemEntity) []any {
+	return []any{
+		entity.ID,
+		entity.JobID,
+		entity.CheckpointFilename,
+		entity.ComfyUIModelPath,
+		entity.WorkflowName,
+		entity.PromptName,
+		entity.PromptText,
+		entity.NegativePrompt,
+		entity.Steps,
+		entity.CFG,
+		entity.SamplerName,
+		entity.Scheduler,
+		entity.Seed,
+		entity.Width,
+		entity.Height,
+		entity.Status,
+		entity.ComfyUIPromptID,
+		entity.OutputPath,
+		entity.ErrorMessage,
+		entity.ExceptionType,
+		entity.NodeType,
+		entity.Traceback,
+		entity.ComfyUILog,
+		entity.StartedAt,
+		entity.CompletedAt,
+		entity.DurationMs,
+		entity.Denoise,
+		entity.Resolution,
+		entity.Wildcards,
+		entity.Scores,
+		entity.SkipReason,
+		entity.StagedCheckpoint,
+		entity.CreatedAt,
+		entity.UpdatedAt,
+	}
+}
+
 func sampleJobModelToEntity(j model.SampleJob) sampleJobEntity {
 	vae := sql.NullString{String: j.VAE, Valid: j.VAE != ""}
 	clip := sql.NullString{String: j.CLIP, Valid: j.CLIP != ""}
@@ -552,8 +1334,22 @@ func sampleJobModelToEntity(j model.SampleJob) sampleJobEntity {
 	if j.Shift != nil {
 		shift = sql.NullFloat64{Float64: *j.Shift, Valid: true}
 	}
+	var controlNetStrength sql.NullFloat64
+	if j.ControlNetStrength != nil {
+		controlNetStrength = sql.NullFloat64{Float64: *j.ControlNetStrength, Valid: true}
+	}
 	errMsg := sql.NullString{String: j.ErrorMessage, Valid: j.ErrorMessage != ""}
 
+	outputFormat := j.OutputFormat
+	if outputFormat == "" {
+		outputFormat = model.OutputFormatPNG
+	}
+
+	studyVersion := j.StudyVersion
+	if studyVersion == 0 {
+		studyVersion = 1
+	}
+
 	checkpointFilenames := "[]"
 	if len(j.CheckpointFilenames) > 0 {
 		b, err := json.Marshal(j.CheckpointFilenames)
@@ -562,23 +1358,49 @@ func sampleJobModelToEntity(j model.SampleJob) sampleJobEntity {
 		}
 	}
 
+	var workflowOverrides string
+	if len(j.WorkflowOverrides) > 0 {
+		overrides := make([]workflowOverrideJSON, len(j.WorkflowOverrides))
+		for i, o := range j.WorkflowOverrides {
+			overrides[i] = workflowOverrideJSON{Pattern: o.Pattern, Workflow: o.Workflow}
+		}
+		b, err := json.Marshal(overrides)
+		if err == nil {
+			workflowOverrides = string(b)
+		}
+	}
+
 	return sampleJobEntity{
-		ID:                  j.ID,
-		TrainingRunName:     j.TrainingRunName,
-		StudyID:             j.StudyID,
-		StudyName:           j.StudyName,
-		WorkflowName:        j.WorkflowName,
-		VAE:                 vae,
-		CLIP:                clip,
-		Shift:               shift,
-		CheckpointFilenames: checkpointFilenames,
-		ClearExisting:       j.ClearExisting,
-		Status:              string(j.Status),
-		TotalItems:          j.TotalItems,
-		CompletedItems:      j.CompletedItems,
-		ErrorMessage:        errMsg,
-		CreatedAt:           j.CreatedAt.UTC().Format(time.RFC3339),
-		UpdatedAt:           j.UpdatedAt.UTC().Format(time.RFC3339),
+		ID:                   j.ID,
+		TrainingRunName:      j.TrainingRunName,
+		StudyID:              j.StudyID,
+		StudyName:            j.StudyName,
+		StudyVersion:         studyVersion,
+		WorkflowName:         j.WorkflowName,
+		WorkflowOverrides:    workflowOverrides,
+		VAE:                  vae,
+		CLIP:                 clip,
+		Shift:                shift,
+		CheckpointFilenames:  checkpointFilenames,
+		ClearExisting:        j.ClearExisting,
+		AppendNewCheckpoints: j.AppendNewCheckpoints,
+		OutputFormat:         string(outputFormat),
+		OutputQuality:        j.OutputQuality,
+		InputImage:           j.InputImage,
+		ControlNetModel:      j.ControlNetModel,
+		ControlNetStrength:   controlNetStrength,
+		ControlNetImage:      j.ControlNetImage,
+		Status:               string(j.Status),
+		TotalItems:           j.TotalItems,
+		CompletedItems:       j.CompletedItems,
+		ErrorMessage:         errMsg,
+		CreatedByRequestID:   j.CreatedByRequestID,
+		Owner:                j.Owner,
+		Notes:                j.Notes,
+		Labels:               encodeJobLabels(j.Labels),
+		Version:              j.Version,
+		CreatedAt:            j.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:            j.UpdatedAt.UTC().Format(time.RFC3339),
 	}
 }
 
@@ -591,12 +1413,43 @@ func sampleJobItemEntityToModel(e sampleJobItemEntity) (model.SampleJobItem, err
 	if err != nil {
 		return model.SampleJobItem{}, fmt.Errorf("parsing updated_at: %w", err)
 	}
+	startedAt, err := parseNullTime(e.StartedAt)
+	if err != nil {
+		return model.SampleJobItem{}, fmt.Errorf("parsing started_at: %w", err)
+	}
+	completedAt, err := parseNullTime(e.CompletedAt)
+	if err != nil {
+		return model.SampleJobItem{}, fmt.Errorf("parsing completed_at: %w", err)
+	}
+	var durationMs *int64
+	if e.DurationMs.Valid {
+		d := e.DurationMs.Int64
+		durationMs = &d
+	}
+	var denoise *float64
+	if e.Denoise.Valid {
+		d := e.Denoise.Float64
+		denoise = &d
+	}
+	var wildcards map[string]string
+	if e.Wildcards != "" {
+		if err := json.Unmarshal([]byte(e.Wildcards), &wildcards); err != nil {
+			return model.SampleJobItem{}, fmt.Errorf("unmarshaling wildcards: %w", err)
+		}
+	}
+	var scores map[string]float64
+	if e.Scores != "" {
+		if err := json.Unmarshal([]byte(e.Scores), &scores); err != nil {
+			return model.SampleJobItem{}, fmt.Errorf("unmarshaling scores: %w", err)
+		}
+	}
 
 	return model.SampleJobItem{
 		ID:                 e.ID,
 		JobID:              e.JobID,
 		CheckpointFilename: e.CheckpointFilename,
 		ComfyUIModelPath:   e.ComfyUIModelPath,
+		WorkflowName:       e.WorkflowName,
 		PromptName:         e.PromptName,
 		PromptText:         e.PromptText,
 		NegativePrompt:     e.NegativePrompt,
@@ -614,6 +1467,17 @@ func sampleJobItemEntityToModel(e sampleJobItemEntity) (model.SampleJobItem, err
 		ExceptionType:      e.ExceptionType,
 		NodeType:           e.NodeType,
 		Traceback:          e.Traceback,
+		ComfyUILog:         e.ComfyUILog,
+		StartedAt:          startedAt,
+		CompletedAt:        completedAt,
+		DurationMs:         durationMs,
+		Denoise:            denoise,
+		Resolution:         e.Resolution,
+		Wildcards:          wildcards,
+		Scores:             scores,
+		SkipReason:         model.SampleJobItemSkipReason(e.SkipReason),
+		StagedCheckpoint:   e.StagedCheckpoint,
+		Version:            e.Version,
 		CreatedAt:          createdAt,
 		UpdatedAt:          updatedAt,
 	}, nil
@@ -623,12 +1487,35 @@ func sampleJobItemModelToEntity(i model.SampleJobItem) sampleJobItemEntity {
 	promptID := sql.NullString{String: i.ComfyUIPromptID, Valid: i.ComfyUIPromptID != ""}
 	outputPath := sql.NullString{String: i.OutputPath, Valid: i.OutputPath != ""}
 	errMsg := sql.NullString{String: i.ErrorMessage, Valid: i.ErrorMessage != ""}
+	var durationMs sql.NullInt64
+	if i.DurationMs != nil {
+		durationMs = sql.NullInt64{Int64: *i.DurationMs, Valid: true}
+	}
+	var denoise sql.NullFloat64
+	if i.Denoise != nil {
+		denoise = sql.NullFloat64{Float64: *i.Denoise, Valid: true}
+	}
+	var wildcards string
+	if len(i.Wildcards) > 0 {
+		b, err := json.Marshal(i.Wildcards)
+		if err == nil {
+			wildcards = string(b)
+		}
+	}
+	var scores string
+	if len(i.Scores) > 0 {
+		b, err := json.Marshal(i.Scores)
+		if err == nil {
+			scores = string(b)
+		}
+	}
 
 	return sampleJobItemEntity{
 		ID:                 i.ID,
 		JobID:              i.JobID,
 		CheckpointFilename: i.CheckpointFilename,
 		ComfyUIModelPath:   i.ComfyUIModelPath,
+		WorkflowName:       i.WorkflowName,
 		PromptName:         i.PromptName,
 		PromptText:         i.PromptText,
 		NegativePrompt:     i.NegativePrompt,
@@ -646,7 +1533,157 @@ func sampleJobItemModelToEntity(i model.SampleJobItem) sampleJobItemEntity {
 		ExceptionType:      i.ExceptionType,
 		NodeType:           i.NodeType,
 		Traceback:          i.Traceback,
+		ComfyUILog:         i.ComfyUILog,
+		StartedAt:          formatNullTime(i.StartedAt),
+		CompletedAt:        formatNullTime(i.CompletedAt),
+		DurationMs:         durationMs,
+		Denoise:            denoise,
+		Resolution:         i.Resolution,
+		Wildcards:          wildcards,
+		Scores:             scores,
+		SkipReason:         string(i.SkipReason),
+		StagedCheckpoint:   i.StagedCheckpoint,
+		Version:            i.Version,
 		CreatedAt:          i.CreatedAt.UTC().Format(time.RFC3339),
 		UpdatedAt:          i.UpdatedAt.UTC().Format(time.RFC3339),
 	}
 }
+
+// parseNullTime parses a nullable RFC3339 column (with optional fractional
+// seconds) into a *time.Time.
+func parseNullTime(ns sql.NullString) (*time.Time, error) {
+	if !ns.Valid || ns.String == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, ns.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// formatNullTime formats a *time.Time as a nullable RFC3339Nano column value.
+// Sub-second precision is kept so per-item timing survives a round trip.
+func formatNullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339Nano), Valid: true}
+}
+
+// pageLimitOffset converts page into LIMIT and OFFSET arguments. SQLite
+// treats a negative LIMIT as no limit.
+func pageLimitOffset(page model.Page) (int, int) {
+	if page.Limit <= 0 {
+		return -1, page.Offset
+	}
+	return page.Limit, page.Offset
+}
+
+// ownerWhere builds the condition, with its leading " AND ", selecting the
+// rows in scope; it is empty for the zero scope.
+func ownerWhere(scope model.OwnerScope) (string, []any) {
+	switch {
+	case scope.Owner == "":
+		return "", nil
+	case scope.IncludeUnowned:
+		return " AND owner IN (?, '')", []any{scope.Owner}
+	default:
+		return " AND owner = ?", []any{scope.Owner}
+	}
+}
+
+// sampleJobWhere builds the conditions, each with a leading " AND ", selecting
+// the sample jobs that match filter.
+func sampleJobWhere(filter model.SampleJobFilter) (string, []any) {
+	where, args := ownerWhere(filter.Scope)
+	for _, l := range filter.Labels {
+		// Jobs without labels have an empty labels column, which json_each
+		// cannot parse; NULLIF turns it into no rows.
+		if l.Value == nil {
+			where += " AND EXISTS (SELECT 1 FROM json_each(NULLIF(sample_jobs.labels, '')) WHERE key = ?)"
+			args = append(args, l.Key)
+		} else {
+			where += " AND EXISTS (SELECT 1 FROM json_each(NULLIF(sample_jobs.labels, '')) WHERE key = ? AND value = ?)"
+			args = append(args, l.Key, *l.Value)
+		}
+	}
+	return where, args
+}
+
+// encodeJobLabels returns the labels column of labels: a JSON object, or
+// empty if there are none.
+func encodeJobLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// decodeJobLabels parses a labels column written by encodeJobLabels. It
+// returns nil for an empty column.
+func decodeJobLabels(column string) (map[string]string, error) {
+	if column == "" {
+		return nil, nil
+	}
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(column), &labels); err != nil {
+		return nil, fmt.Errorf("parsing labels: %w", err)
+	}
+	return labels, nil
+}
+
+// sampleJobItemWhere builds the WHERE clause selecting the items of jobID
+// that match filter.
+func sampleJobItemWhere(jobID string, filter model.SampleJobItemFilter) (string, []any) {
+	where := "job_id = ?"
+	args := []any{jobID}
+	if filter.Status != "" {
+		where += " AND status = ?"
+		args = append(args, string(filter.Status))
+	}
+	if filter.CheckpointFilename != "" {
+		where += " AND checkpoint_filename = ?"
+		args = append(args, filter.CheckpointFilename)
+	}
+	if filter.PromptName != "" {
+		where += " AND prompt_name = ?"
+		args = append(args, filter.PromptName)
+	}
+	if filter.SamplerName != "" {
+		where += " AND sampler_name = ?"
+		args = append(args, filter.SamplerName)
+	}
+	if filter.SkipReason != "" {
+		where += " AND skip_reason = ?"
+		args = append(args, string(filter.SkipReason))
+	}
+	return where, args
+}
+
+// sampleJobItemOrderBy builds the ORDER BY clause for query and its
+// arguments. Unknown sort keys fall back to creation order.
+func sampleJobItemOrderBy(query model.SampleJobItemQuery) (string, []any) {
+	dir := "ASC"
+	if query.Descending {
+		dir = "DESC"
+	}
+	switch query.Sort {
+	case model.SampleJobItemSortDuration:
+		return "duration_ms IS NULL, duration_ms " + dir + ", rowid " + dir, nil
+	case model.SampleJobItemSortSeed:
+		return "seed " + dir + ", rowid " + dir, nil
+	case model.SampleJobItemSortScore:
+		// Unscored items have an empty scores column, which json_extract
+		// rejects, hence the NULLIF.
+		score := "json_extract(NULLIF(scores, ''), ?)"
+		path := "$." + query.ScoreName
+		return score + " IS NULL, " + score + " " + dir + ", rowid " + dir, []any{path, path}
+	default:
+		return "created_at " + dir + ", rowid " + dir, nil
+	}
+}
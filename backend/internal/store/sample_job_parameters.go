@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// sampleJobParametersEntity is the persistence representation of the
+// parameter snapshot of a sample job.
+type sampleJobParametersEntity struct {
+	JobID             string
+	Study             string // JSON-encoded studyEntity
+	Workflow          string // JSON-encoded workflowSnapshotJSON
+	OverrideWorkflows string // JSON-encoded []workflowSnapshotJSON; empty if none
+	CreatedAt         string // RFC3339
+}
+
+// workflowSnapshotJSON is the JSON shape of a snapshotted workflow template.
+type workflowSnapshotJSON struct {
+	Name     string                 `json:"name"`
+	Workflow map[string]interface{} `json:"workflow"`
+	Roles    map[string][]string    `json:"roles"`
+}
+
+// GetSampleJobParameters returns the parameter snapshot of a sample job, or
+// sql.ErrNoRows if the job has none.
+func (s *Store) GetSampleJobParameters(ctx context.Context, jobID string) (model.SampleJobParameters, error) {
+	s.logger.WithField("sample_job_id", jobID).Trace("entering GetSampleJobParameters")
+	defer s.logger.Trace("returning from GetSampleJobParameters")
+
+	var e sampleJobParametersEntity
+	err := s.db.QueryRowContext(ctx,
+		`SELECT job_id, study, workflow, override_workflows, created_at FROM sample_job_parameters WHERE job_id = ?`, jobID,
+	).Scan(&e.JobID, &e.Study, &e.Workflow, &e.OverrideWorkflows, &e.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.WithField("sample_job_id", jobID).Debug("sample job has no parameter snapshot")
+		} else {
+			s.logger.WithFields(logrus.Fields{
+				"sample_job_id": jobID,
+				"error":         err.Error(),
+			}).Error("failed to query sample job parameters")
+		}
+		return model.SampleJobParameters{}, err
+	}
+	return sampleJobParametersEntityToModel(e)
+}
+
+// CreateSampleJobParameters stores the parameter snapshot of a sample job.
+// A job keeps its first snapshot: storing another one for the same job is a
+// no-op, so that concurrent starts agree on the parameters.
+func (s *Store) CreateSampleJobParameters(ctx context.Context, p model.SampleJobParameters) error {
+	s.logger.WithField("sample_job_id", p.JobID).Trace("entering CreateSampleJobParameters")
+	defer s.logger.Trace("returning from CreateSampleJobParameters")
+
+	e, err := sampleJobParametersModelToEntity(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO sample_job_parameters (job_id, study, workflow, override_workflows, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(job_id) DO NOTHING`,
+		e.JobID, e.Study, e.Workflow, e.OverrideWorkflows, e.CreatedAt,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"sample_job_id": p.JobID,
+			"error":         err.Error(),
+		}).Error("failed to insert sample job parameters")
+		return fmt.Errorf("inserting sample job parameters: %w", err)
+	}
+	s.logger.WithField("sample_job_id", p.JobID).Debug("stored sample job parameter snapshot")
+	return nil
+}
+
+func sampleJobParametersEntityToModel(e sampleJobParametersEntity) (model.SampleJobParameters, error) {
+	var studyE studyEntity
+	if err := json.Unmarshal([]byte(e.Study), &studyE); err != nil {
+		return model.SampleJobParameters{}, fmt.Errorf("unmarshaling study snapshot: %w", err)
+	}
+	study, err := studyEntityToModel(studyE)
+	if err != nil {
+		return model.SampleJobParameters{}, fmt.Errorf("converting study snapshot: %w", err)
+	}
+
+	var workflow workflowSnapshotJSON
+	if err := json.Unmarshal([]byte(e.Workflow), &workflow); err != nil {
+		return model.SampleJobParameters{}, fmt.Errorf("unmarshaling workflow snapshot: %w", err)
+	}
+
+	var overrideWorkflows map[string]model.WorkflowTemplate
+	if e.OverrideWorkflows != "" {
+		var overrides []workflowSnapshotJSON
+		if err := json.Unmarshal([]byte(e.OverrideWorkflows), &overrides); err != nil {
+			return model.SampleJobParameters{}, fmt.Errorf("unmarshaling override workflow snapshots: %w", err)
+		}
+		overrideWorkflows = make(map[string]model.WorkflowTemplate, len(overrides))
+		for _, w := range overrides {
+			overrideWorkflows[w.Name] = model.WorkflowTemplate{
+				Name:     w.Name,
+				Workflow: w.Workflow,
+				Roles:    w.Roles,
+			}
+		}
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
+	if err != nil {
+		return model.SampleJobParameters{}, fmt.Errorf("parsing created_at: %w", err)
+	}
+
+	return model.SampleJobParameters{
+		JobID: e.JobID,
+		Study: study,
+		Workflow: model.WorkflowTemplate{
+			Name:     workflow.Name,
+			Workflow: workflow.Workflow,
+			Roles:    workflow.Roles,
+		},
+		OverrideWorkflows: overrideWorkflows,
+		CreatedAt:         createdAt,
+	}, nil
+}
+
+func sampleJobParametersModelToEntity(p model.SampleJobParameters) (sampleJobParametersEntity, error) {
+	studyE, err := studyModelToEntity(p.Study)
+	if err != nil {
+		return sampleJobParametersEntity{}, fmt.Errorf("converting study snapshot: %w", err)
+	}
+	studyBytes, err := json.Marshal(studyE)
+	if err != nil {
+		return sampleJobParametersEntity{}, fmt.Errorf("marshaling study snapshot: %w", err)
+	}
+	workflowBytes, err := json.Marshal(workflowSnapshotJSON{
+		Name:     p.Workflow.Name,
+		Workflow: p.Workflow.Workflow,
+		Roles:    p.Workflow.Roles,
+	})
+	if err != nil {
+		return sampleJobParametersEntity{}, fmt.Errorf("marshaling workflow snapshot: %w", err)
+	}
+	var overrideWorkflows string
+	if len(p.OverrideWorkflows) > 0 {
+		names := make([]string, 0, len(p.OverrideWorkflows))
+		for name := range p.OverrideWorkflows {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		overrides := make([]workflowSnapshotJSON, len(names))
+		for i, name := range names {
+			w := p.OverrideWorkflows[name]
+			overrides[i] = workflowSnapshotJSON{Name: name, Workflow: w.Workflow, Roles: w.Roles}
+		}
+		b, err := json.Marshal(overrides)
+		if err != nil {
+			return sampleJobParametersEntity{}, fmt.Errorf("marshaling override workflow snapshots: %w", err)
+		}
+		overrideWorkflows = string(b)
+	}
+	return sampleJobParametersEntity{
+		JobID:             p.JobID,
+		Study:             string(studyBytes),
+		Workflow:          string(workflowBytes),
+		OverrideWorkflows: overrideWorkflows,
+		CreatedAt:         p.CreatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
@@ -2,6 +2,7 @@ package store_test
 
 import (
 	"database/sql"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -64,7 +65,7 @@ var _ = Describe("SampleJob Store", func() {
 			CreatedAt: now,
 			UpdatedAt: now,
 		}
-		err := s.CreateStudy(study)
+		err := s.CreateStudy(ctx, study)
 		Expect(err).NotTo(HaveOccurred())
 	}
 
@@ -90,6 +91,7 @@ var _ = Describe("SampleJob Store", func() {
 				TotalItems:      10,
 				CompletedItems:  0,
 				ErrorMessage:    "",
+				Version:         1,
 				CreatedAt:       now,
 				UpdatedAt:       now,
 			}
@@ -97,10 +99,10 @@ var _ = Describe("SampleJob Store", func() {
 
 		Describe("CreateSampleJob", func() {
 			It("creates a new sample job with all fields", func() {
-				err := s.CreateSampleJob(sampleJob)
+				err := s.CreateSampleJob(ctx, sampleJob)
 				Expect(err).NotTo(HaveOccurred())
 
-				retrieved, err := s.GetSampleJob(sampleJob.ID)
+				retrieved, err := s.GetSampleJob(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(retrieved.ID).To(Equal(sampleJob.ID))
 				Expect(retrieved.TrainingRunName).To(Equal(sampleJob.TrainingRunName))
@@ -137,10 +139,10 @@ var _ = Describe("SampleJob Store", func() {
 					UpdatedAt:       now,
 				}
 
-				err := s.CreateSampleJob(job)
+				err := s.CreateSampleJob(ctx, job)
 				Expect(err).NotTo(HaveOccurred())
 
-				retrieved, err := s.GetSampleJob(job.ID)
+				retrieved, err := s.GetSampleJob(ctx, job.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(retrieved.VAE).To(Equal(""))
 				Expect(retrieved.CLIP).To(Equal(""))
@@ -149,11 +151,11 @@ var _ = Describe("SampleJob Store", func() {
 			})
 
 			It("rejects duplicate ID", func() {
-				err := s.CreateSampleJob(sampleJob)
+				err := s.CreateSampleJob(ctx, sampleJob)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Try to create again with same ID
-				err = s.CreateSampleJob(sampleJob)
+				err = s.CreateSampleJob(ctx, sampleJob)
 				Expect(err).To(HaveOccurred())
 			})
 
@@ -172,14 +174,14 @@ var _ = Describe("SampleJob Store", func() {
 					UpdatedAt:       now,
 				}
 
-				err := s.CreateSampleJob(jobWithInvalidStudy)
+				err := s.CreateSampleJob(ctx, jobWithInvalidStudy)
 				Expect(err).To(HaveOccurred())
 			})
 		})
 
 		Describe("ListSampleJobs", func() {
 			It("returns empty slice when no jobs exist", func() {
-				result, err := s.ListSampleJobs()
+				result, err := s.ListSampleJobs(ctx)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(0))
 			})
@@ -202,16 +204,16 @@ var _ = Describe("SampleJob Store", func() {
 				job3.CreatedAt = now
 				job3.UpdatedAt = now
 
-				err := s.CreateSampleJob(job1)
+				err := s.CreateSampleJob(ctx, job1)
 				Expect(err).NotTo(HaveOccurred())
 
-				err = s.CreateSampleJob(job2)
+				err = s.CreateSampleJob(ctx, job2)
 				Expect(err).NotTo(HaveOccurred())
 
-				err = s.CreateSampleJob(job3)
+				err = s.CreateSampleJob(ctx, job3)
 				Expect(err).NotTo(HaveOccurred())
 
-				result, err := s.ListSampleJobs()
+				result, err := s.ListSampleJobs(ctx)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(3))
 				// Should be ordered by created_at ASC (oldest first): job1, job2, job3
@@ -243,16 +245,16 @@ var _ = Describe("SampleJob Store", func() {
 				pendingJob3.UpdatedAt = now.Add(-1 * time.Hour)
 
 				// Insert in non-chronological order to verify ordering is from query, not insertion
-				err := s.CreateSampleJob(pendingJob3)
+				err := s.CreateSampleJob(ctx, pendingJob3)
 				Expect(err).NotTo(HaveOccurred())
 
-				err = s.CreateSampleJob(pendingJob1)
+				err = s.CreateSampleJob(ctx, pendingJob1)
 				Expect(err).NotTo(HaveOccurred())
 
-				err = s.CreateSampleJob(pendingJob2)
+				err = s.CreateSampleJob(ctx, pendingJob2)
 				Expect(err).NotTo(HaveOccurred())
 
-				result, err := s.ListSampleJobs()
+				result, err := s.ListSampleJobs(ctx)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(3))
 
@@ -264,11 +266,29 @@ var _ = Describe("SampleJob Store", func() {
 				Expect(result[2].ID).To(Equal("pending-job-3"))
 				Expect(result[2].Status).To(Equal(model.SampleJobStatusPending))
 			})
+
+			It("orders jobs created in the same second by insertion", func() {
+				now := time.Now().UTC().Truncate(time.Second)
+				for _, id := range []string{"job-b", "job-a", "job-c"} {
+					job := sampleJob
+					job.ID = id
+					job.CreatedAt = now
+					job.UpdatedAt = now
+					Expect(s.CreateSampleJob(ctx, job)).To(Succeed())
+				}
+
+				result, err := s.ListSampleJobs(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(HaveLen(3))
+				Expect(result[0].ID).To(Equal("job-b"))
+				Expect(result[1].ID).To(Equal("job-a"))
+				Expect(result[2].ID).To(Equal("job-c"))
+			})
 		})
 
 		Describe("ListSampleJobsDesc", func() {
 			It("returns empty slice when no jobs exist", func() {
-				result, err := s.ListSampleJobsDesc()
+				result, err := s.ListSampleJobsDesc(ctx, model.SampleJobFilter{}, model.Page{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(0))
 			})
@@ -314,11 +334,11 @@ var _ = Describe("SampleJob Store", func() {
 				}
 
 				// Insert oldest first
-				Expect(s.CreateSampleJob(job1)).To(Succeed())
-				Expect(s.CreateSampleJob(job2)).To(Succeed())
-				Expect(s.CreateSampleJob(job3)).To(Succeed())
+				Expect(s.CreateSampleJob(ctx, job1)).To(Succeed())
+				Expect(s.CreateSampleJob(ctx, job2)).To(Succeed())
+				Expect(s.CreateSampleJob(ctx, job3)).To(Succeed())
 
-				result, err := s.ListSampleJobsDesc()
+				result, err := s.ListSampleJobsDesc(ctx, model.SampleJobFilter{}, model.Page{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(3))
 				// Should be ordered newest-first: job3, job2, job1
@@ -355,43 +375,97 @@ var _ = Describe("SampleJob Store", func() {
 					UpdatedAt:       now,
 				}
 
-				Expect(s.CreateSampleJob(jobA)).To(Succeed())
-				Expect(s.CreateSampleJob(jobB)).To(Succeed())
+				Expect(s.CreateSampleJob(ctx, jobA)).To(Succeed())
+				Expect(s.CreateSampleJob(ctx, jobB)).To(Succeed())
 
-				asc, err := s.ListSampleJobs()
+				asc, err := s.ListSampleJobs(ctx)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(asc).To(HaveLen(2))
 				Expect(asc[0].ID).To(Equal("job-order-a")) // oldest first (FIFO for executor)
 
-				desc, err := s.ListSampleJobsDesc()
+				desc, err := s.ListSampleJobsDesc(ctx, model.SampleJobFilter{}, model.Page{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(desc).To(HaveLen(2))
 				Expect(desc[0].ID).To(Equal("job-order-b")) // newest first (for UI display)
 			})
+
+			It("returns the requested page", func() {
+				now := time.Now().UTC().Truncate(time.Second)
+				for i := 0; i < 5; i++ {
+					job := sampleJob
+					job.ID = fmt.Sprintf("job-page-%d", i)
+					job.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+					job.UpdatedAt = job.CreatedAt
+					Expect(s.CreateSampleJob(ctx, job)).To(Succeed())
+				}
+
+				result, err := s.ListSampleJobsDesc(ctx, model.SampleJobFilter{}, model.Page{Limit: 2, Offset: 1})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(HaveLen(2))
+				Expect(result[0].ID).To(Equal("job-page-3"))
+				Expect(result[1].ID).To(Equal("job-page-2"))
+
+				result, err = s.ListSampleJobsDesc(ctx, model.SampleJobFilter{}, model.Page{Offset: 4})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(HaveLen(1))
+				Expect(result[0].ID).To(Equal("job-page-0"))
+
+				count, err := s.CountSampleJobs(ctx, model.SampleJobFilter{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(5))
+			})
+
+			It("filters jobs by owner", func() {
+				now := time.Now().UTC().Truncate(time.Second)
+				for i, owner := range []string{"", "alice", "bob"} {
+					job := sampleJob
+					job.ID = fmt.Sprintf("job-owner-%d", i)
+					job.Owner = owner
+					job.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+					job.UpdatedAt = job.CreatedAt
+					Expect(s.CreateSampleJob(ctx, job)).To(Succeed())
+				}
+
+				result, err := s.ListSampleJobsDesc(ctx, model.SampleJobFilter{Scope: model.OwnerScope{Owner: "alice", IncludeUnowned: true}}, model.Page{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(HaveLen(2))
+				Expect(result[0].ID).To(Equal("job-owner-1"))
+				Expect(result[0].Owner).To(Equal("alice"))
+				Expect(result[1].ID).To(Equal("job-owner-0"))
+
+				result, err = s.ListSampleJobsDesc(ctx, model.SampleJobFilter{Scope: model.OwnerScope{Owner: "bob"}}, model.Page{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(HaveLen(1))
+				Expect(result[0].ID).To(Equal("job-owner-2"))
+
+				count, err := s.CountSampleJobs(ctx, model.SampleJobFilter{Scope: model.OwnerScope{Owner: "alice", IncludeUnowned: true}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(2))
+			})
 		})
 
 		Describe("GetSampleJob", func() {
 			BeforeEach(func() {
-				err := s.CreateSampleJob(sampleJob)
+				err := s.CreateSampleJob(ctx, sampleJob)
 				Expect(err).NotTo(HaveOccurred())
 			})
 
 			It("retrieves a sample job by ID", func() {
-				result, err := s.GetSampleJob(sampleJob.ID)
+				result, err := s.GetSampleJob(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result.ID).To(Equal(sampleJob.ID))
 				Expect(result.TrainingRunName).To(Equal(sampleJob.TrainingRunName))
 			})
 
 			It("returns sql.ErrNoRows for non-existent ID", func() {
-				_, err := s.GetSampleJob("nonexistent")
+				_, err := s.GetSampleJob(ctx, "nonexistent")
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
 		})
 
 		Describe("UpdateSampleJob", func() {
 			BeforeEach(func() {
-				err := s.CreateSampleJob(sampleJob)
+				err := s.CreateSampleJob(ctx, sampleJob)
 				Expect(err).NotTo(HaveOccurred())
 			})
 
@@ -401,13 +475,15 @@ var _ = Describe("SampleJob Store", func() {
 				updated.CompletedItems = 5
 				updated.UpdatedAt = time.Now().UTC()
 
-				err := s.UpdateSampleJob(updated)
+				err := s.UpdateSampleJob(ctx, &updated)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(updated.Version).To(Equal(sampleJob.Version + 1))
 
-				retrieved, err := s.GetSampleJob(updated.ID)
+				retrieved, err := s.GetSampleJob(ctx, updated.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(retrieved.Status).To(Equal(model.SampleJobStatusRunning))
 				Expect(retrieved.CompletedItems).To(Equal(5))
+				Expect(retrieved.Version).To(Equal(updated.Version))
 				// CreatedAt should remain unchanged
 				Expect(retrieved.CreatedAt.Unix()).To(Equal(sampleJob.CreatedAt.Unix()))
 			})
@@ -417,10 +493,10 @@ var _ = Describe("SampleJob Store", func() {
 				updated.ErrorMessage = "test error"
 				updated.UpdatedAt = time.Now().UTC()
 
-				err := s.UpdateSampleJob(updated)
+				err := s.UpdateSampleJob(ctx, &updated)
 				Expect(err).NotTo(HaveOccurred())
 
-				retrieved, err := s.GetSampleJob(updated.ID)
+				retrieved, err := s.GetSampleJob(ctx, updated.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(retrieved.ErrorMessage).To(Equal("test error"))
 			})
@@ -428,28 +504,39 @@ var _ = Describe("SampleJob Store", func() {
 			It("returns sql.ErrNoRows for non-existent ID", func() {
 				nonExistent := sampleJob
 				nonExistent.ID = "nonexistent"
-				err := s.UpdateSampleJob(nonExistent)
+				err := s.UpdateSampleJob(ctx, &nonExistent)
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
+
+			It("persists append mode", func() {
+				updated := sampleJob
+				updated.AppendNewCheckpoints = true
+
+				Expect(s.UpdateSampleJob(ctx, &updated)).To(Succeed())
+
+				retrieved, err := s.GetSampleJob(ctx, updated.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(retrieved.AppendNewCheckpoints).To(BeTrue())
+			})
 		})
 
 		Describe("DeleteSampleJob", func() {
 			BeforeEach(func() {
-				err := s.CreateSampleJob(sampleJob)
+				err := s.CreateSampleJob(ctx, sampleJob)
 				Expect(err).NotTo(HaveOccurred())
 			})
 
 			It("deletes an existing sample job", func() {
-				err := s.DeleteSampleJob(sampleJob.ID)
+				err := s.DeleteSampleJob(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Verify it's gone
-				_, err = s.GetSampleJob(sampleJob.ID)
+				_, err = s.GetSampleJob(ctx, sampleJob.ID)
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
 
 			It("returns sql.ErrNoRows for non-existent ID", func() {
-				err := s.DeleteSampleJob("nonexistent")
+				err := s.DeleteSampleJob(ctx, "nonexistent")
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
 
@@ -472,21 +559,92 @@ var _ = Describe("SampleJob Store", func() {
 					CreatedAt:          now,
 					UpdatedAt:          now,
 				}
-				err := s.CreateSampleJobItem(item)
+				err := s.CreateSampleJobItem(ctx, item)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Delete the parent job
-				err = s.DeleteSampleJob(sampleJob.ID)
+				err = s.DeleteSampleJob(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Verify the job item is also deleted
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(0))
 			})
 		})
 	})
 
+	Describe("Output format persistence", func() {
+		BeforeEach(func() {
+			createStudy("study-1")
+		})
+
+		It("persists output format and quality", func() {
+			now := time.Now().UTC().Truncate(time.Second)
+			job := model.SampleJob{
+				ID:              "job-webp",
+				TrainingRunName: "test-run",
+				StudyID:         "study-1",
+				StudyName:       "Test Study",
+				WorkflowName:    "flux-dev",
+				OutputFormat:    model.OutputFormatWebP,
+				OutputQuality:   80,
+				Status:          model.SampleJobStatusPending,
+				CreatedAt:       now,
+				UpdatedAt:       now,
+			}
+			Expect(s.CreateSampleJob(ctx, job)).To(Succeed())
+
+			retrieved, err := s.GetSampleJob(ctx, job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(retrieved.OutputFormat).To(Equal(model.OutputFormatWebP))
+			Expect(retrieved.OutputQuality).To(Equal(80))
+		})
+
+		It("stores png when no output format is set", func() {
+			now := time.Now().UTC().Truncate(time.Second)
+			job := model.SampleJob{
+				ID:              "job-default-format",
+				TrainingRunName: "test-run",
+				StudyID:         "study-1",
+				StudyName:       "Test Study",
+				WorkflowName:    "flux-dev",
+				Status:          model.SampleJobStatusPending,
+				CreatedAt:       now,
+				UpdatedAt:       now,
+			}
+			Expect(s.CreateSampleJob(ctx, job)).To(Succeed())
+
+			retrieved, err := s.GetSampleJob(ctx, job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(retrieved.OutputFormat).To(Equal(model.OutputFormatPNG))
+		})
+
+		It("persists the ID of the request that created the job", func() {
+			now := time.Now().UTC().Truncate(time.Second)
+			job := model.SampleJob{
+				ID:                 "job-request-id",
+				TrainingRunName:    "test-run",
+				StudyID:            "study-1",
+				StudyName:          "Test Study",
+				WorkflowName:       "flux-dev",
+				Status:             model.SampleJobStatusPending,
+				CreatedByRequestID: "req-123",
+				CreatedAt:          now,
+				UpdatedAt:          now,
+			}
+			Expect(s.CreateSampleJob(ctx, job)).To(Succeed())
+
+			retrieved, err := s.GetSampleJob(ctx, job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(retrieved.CreatedByRequestID).To(Equal("req-123"))
+
+			jobs, err := s.ListSampleJobs(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobs).To(ConsistOf(HaveField("CreatedByRequestID", "req-123")))
+		})
+	})
+
 	Describe("CheckpointFilenames persistence", func() {
 		BeforeEach(func() {
 			createStudy("study-1")
@@ -507,10 +665,10 @@ var _ = Describe("SampleJob Store", func() {
 				CreatedAt:           now,
 				UpdatedAt:           now,
 			}
-			err := s.CreateSampleJob(job)
+			err := s.CreateSampleJob(ctx, job)
 			Expect(err).NotTo(HaveOccurred())
 
-			retrieved, err := s.GetSampleJob(job.ID)
+			retrieved, err := s.GetSampleJob(ctx, job.ID)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(retrieved.CheckpointFilenames).To(ConsistOf("ckpt-step00001000.safetensors", "ckpt-step00002000.safetensors"))
 		})
@@ -530,10 +688,10 @@ var _ = Describe("SampleJob Store", func() {
 				CreatedAt:           now,
 				UpdatedAt:           now,
 			}
-			err := s.CreateSampleJob(job)
+			err := s.CreateSampleJob(ctx, job)
 			Expect(err).NotTo(HaveOccurred())
 
-			retrieved, err := s.GetSampleJob(job.ID)
+			retrieved, err := s.GetSampleJob(ctx, job.ID)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(retrieved.CheckpointFilenames).To(BeEmpty())
 		})
@@ -553,16 +711,93 @@ var _ = Describe("SampleJob Store", func() {
 				CreatedAt:           now,
 				UpdatedAt:           now,
 			}
-			err := s.CreateSampleJob(job)
+			err := s.CreateSampleJob(ctx, job)
 			Expect(err).NotTo(HaveOccurred())
 
-			jobs, err := s.ListSampleJobs()
+			jobs, err := s.ListSampleJobs(ctx)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(jobs).To(HaveLen(1))
 			Expect(jobs[0].CheckpointFilenames).To(ConsistOf("model-step00004500.safetensors"))
 		})
 	})
 
+	Describe("Workflow override persistence", func() {
+		BeforeEach(func() {
+			createStudy("study-1")
+		})
+
+		It("persists the job's workflow overrides and each item's workflow", func() {
+			now := time.Now().UTC().Truncate(time.Second)
+			job := model.SampleJob{
+				ID:              "job-overrides",
+				TrainingRunName: "test-run",
+				StudyID:         "study-1",
+				StudyName:       "Test Study",
+				WorkflowName:    "sdxl.json",
+				WorkflowOverrides: []model.WorkflowOverride{
+					{Pattern: "*-flux-*", Workflow: "flux-dev.json"},
+				},
+				Status:     model.SampleJobStatusPending,
+				TotalItems: 2,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+			item := func(id, checkpoint, workflow string) model.SampleJobItem {
+				return model.SampleJobItem{
+					ID: id, JobID: job.ID, CheckpointFilename: checkpoint, WorkflowName: workflow,
+					PromptName: "forest", PromptText: "a forest", Steps: 20, CFG: 7, SamplerName: "euler", Scheduler: "normal",
+					Width: 1024, Height: 1024, Status: model.SampleJobItemStatusPending, CreatedAt: now, UpdatedAt: now,
+				}
+			}
+			Expect(s.CreateSampleJobWithItems(ctx, job, []model.SampleJobItem{
+				item("item-sdxl", "run-sdxl-step1.safetensors", ""),
+				item("item-flux", "run-flux-step1.safetensors", "flux-dev.json"),
+			})).To(Succeed())
+
+			retrieved, err := s.GetSampleJob(ctx, job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(retrieved.WorkflowOverrides).To(Equal(job.WorkflowOverrides))
+
+			items, err := s.ListSampleJobItems(ctx, job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			workflows := map[string]string{}
+			for _, i := range items {
+				workflows[i.ID] = i.WorkflowName
+			}
+			Expect(workflows).To(Equal(map[string]string{"item-sdxl": "", "item-flux": "flux-dev.json"}))
+		})
+
+		It("persists the override workflows of the job's parameter snapshot", func() {
+			now := time.Now().UTC().Truncate(time.Second)
+			Expect(s.CreateSampleJob(ctx, model.SampleJob{
+				ID: "job-params", TrainingRunName: "test-run", StudyID: "study-1", StudyName: "Test Study",
+				WorkflowName: "sdxl.json", Status: model.SampleJobStatusPending, CreatedAt: now, UpdatedAt: now,
+			})).To(Succeed())
+			flux := model.WorkflowTemplate{
+				Name:     "flux-dev.json",
+				Workflow: map[string]interface{}{"1": map[string]interface{}{"class_type": "UNETLoader"}},
+				Roles:    map[string][]string{"unet_loader": {"1"}},
+			}
+			Expect(s.CreateSampleJobParameters(ctx, model.SampleJobParameters{
+				JobID:             "job-params",
+				Study:             model.Study{ID: "study-1", Name: "Test Study"},
+				Workflow:          model.WorkflowTemplate{Name: "sdxl.json", Workflow: map[string]interface{}{}},
+				OverrideWorkflows: map[string]model.WorkflowTemplate{"flux-dev.json": flux},
+				CreatedAt:         now,
+			})).To(Succeed())
+
+			params, err := s.GetSampleJobParameters(ctx, "job-params")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(params.OverrideWorkflows).To(Equal(map[string]model.WorkflowTemplate{"flux-dev.json": flux}))
+			workflow, ok := params.WorkflowOf(model.SampleJobItem{WorkflowName: "flux-dev.json"})
+			Expect(ok).To(BeTrue())
+			Expect(workflow.Name).To(Equal("flux-dev.json"))
+			workflow, ok = params.WorkflowOf(model.SampleJobItem{})
+			Expect(ok).To(BeTrue())
+			Expect(workflow.Name).To(Equal("sdxl.json"))
+		})
+	})
+
 	Describe("SampleJobItem CRUD operations", func() {
 		var sampleJob model.SampleJob
 		var sampleJobItem model.SampleJobItem
@@ -581,10 +816,11 @@ var _ = Describe("SampleJob Store", func() {
 				Status:          model.SampleJobStatusPending,
 				TotalItems:      10,
 				CompletedItems:  0,
+				Version:         1,
 				CreatedAt:       now,
 				UpdatedAt:       now,
 			}
-			err := s.CreateSampleJob(sampleJob)
+			err := s.CreateSampleJob(ctx, sampleJob)
 			Expect(err).NotTo(HaveOccurred())
 
 			sampleJobItem = model.SampleJobItem{
@@ -605,6 +841,7 @@ var _ = Describe("SampleJob Store", func() {
 				ComfyUIPromptID:    "",
 				OutputPath:         "",
 				ErrorMessage:       "",
+				Version:            1,
 				CreatedAt:          now,
 				UpdatedAt:          now,
 			}
@@ -612,10 +849,10 @@ var _ = Describe("SampleJob Store", func() {
 
 		Describe("CreateSampleJobItem", func() {
 			It("creates a new sample job item with all fields", func() {
-				err := s.CreateSampleJobItem(sampleJobItem)
+				err := s.CreateSampleJobItem(ctx, sampleJobItem)
 				Expect(err).NotTo(HaveOccurred())
 
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 				Expect(items[0].ID).To(Equal(sampleJobItem.ID))
@@ -656,10 +893,10 @@ var _ = Describe("SampleJob Store", func() {
 					UpdatedAt:          now,
 				}
 
-				err := s.CreateSampleJobItem(itemWithExplicitSize)
+				err := s.CreateSampleJobItem(ctx, itemWithExplicitSize)
 				Expect(err).NotTo(HaveOccurred())
 
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 				Expect(items[0].Width).To(Equal(1024))
@@ -687,10 +924,10 @@ var _ = Describe("SampleJob Store", func() {
 					UpdatedAt:          now,
 				}
 
-				err := s.CreateSampleJobItem(itemWithZeroSize)
+				err := s.CreateSampleJobItem(ctx, itemWithZeroSize)
 				Expect(err).NotTo(HaveOccurred())
 
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 				// Zero values are stored as-is (migration only applies to existing rows)
@@ -720,10 +957,10 @@ var _ = Describe("SampleJob Store", func() {
 					UpdatedAt:          now,
 				}
 
-				err := s.CreateSampleJobItem(itemWithOptionals)
+				err := s.CreateSampleJobItem(ctx, itemWithOptionals)
 				Expect(err).NotTo(HaveOccurred())
 
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 				Expect(items[0].ComfyUIPromptID).To(Equal("prompt-123"))
@@ -749,14 +986,198 @@ var _ = Describe("SampleJob Store", func() {
 					UpdatedAt:          now,
 				}
 
-				err := s.CreateSampleJobItem(itemWithInvalidJob)
+				err := s.CreateSampleJobItem(ctx, itemWithInvalidJob)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Describe("CreateSampleJobWithItems", func() {
+			var newJob model.SampleJob
+
+			BeforeEach(func() {
+				newJob = sampleJob
+				newJob.ID = "job-2"
+			})
+
+			itemsFor := func(jobID string, n int) []model.SampleJobItem {
+				items := make([]model.SampleJobItem, n)
+				for i := range items {
+					items[i] = sampleJobItem
+					items[i].ID = fmt.Sprintf("%s-item-%d", jobID, i)
+					items[i].JobID = jobID
+					items[i].Seed = int64(i)
+				}
+				return items
+			}
+
+			It("inserts the job and all of its items", func() {
+				err := s.CreateSampleJobWithItems(ctx, newJob, itemsFor(newJob.ID, 50))
+				Expect(err).NotTo(HaveOccurred())
+
+				job, err := s.GetSampleJob(ctx, newJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.TrainingRunName).To(Equal(newJob.TrainingRunName))
+
+				items, err := s.ListSampleJobItems(ctx, newJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(HaveLen(50))
+				Expect(items[0].CheckpointFilename).To(Equal(sampleJobItem.CheckpointFilename))
+			})
+
+			It("inserts a job without items", func() {
+				Expect(s.CreateSampleJobWithItems(ctx, newJob, nil)).To(Succeed())
+
+				_, err := s.GetSampleJob(ctx, newJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("leaves neither the job nor any item behind when an item fails to insert", func() {
+				items := itemsFor(newJob.ID, 5)
+				items[3].ID = items[1].ID // duplicate primary key
+
+				err := s.CreateSampleJobWithItems(ctx, newJob, items)
 				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("inserting sample job item"))
+
+				_, err = s.GetSampleJob(ctx, newJob.ID)
+				Expect(err).To(Equal(sql.ErrNoRows))
+				remaining, err := s.ListSampleJobItems(ctx, newJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(remaining).To(BeEmpty())
+			})
+
+			It("inserts no items when the job fails to insert", func() {
+				err := s.CreateSampleJobWithItems(ctx, sampleJob, itemsFor(sampleJob.ID, 3))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("inserting sample job"))
+
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(BeEmpty())
+			})
+		})
+
+		Describe("CreateStudyWithSampleJob", func() {
+			var (
+				newStudy model.Study
+				newJob   model.SampleJob
+				newItem  model.SampleJobItem
+			)
+
+			BeforeEach(func() {
+				now := time.Now().UTC().Truncate(time.Second)
+				newStudy = model.Study{
+					ID:                    "study-2",
+					Name:                  "Inline Study",
+					Prompts:               []model.NamedPrompt{{Name: "cat", Text: "a cat"}},
+					Steps:                 []int{20},
+					CFGs:                  []float64{5.0},
+					SamplerSchedulerPairs: []model.SamplerSchedulerPair{{Sampler: "euler", Scheduler: "normal"}},
+					Seeds:                 []int64{1},
+					Width:                 512,
+					Height:                512,
+					CreatedAt:             now,
+					UpdatedAt:             now,
+				}
+				newJob = sampleJob
+				newJob.ID = "job-2"
+				newJob.StudyID = newStudy.ID
+				newJob.StudyName = newStudy.Name
+				newItem = sampleJobItem
+				newItem.ID = "job-2-item-0"
+				newItem.JobID = newJob.ID
+			})
+
+			It("inserts the study, the job, and its items", func() {
+				Expect(s.CreateStudyWithSampleJob(ctx, newStudy, newJob, []model.SampleJobItem{newItem})).To(Succeed())
+
+				study, err := s.GetStudy(ctx, newStudy.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(study.Name).To(Equal("Inline Study"))
+				job, err := s.GetSampleJob(ctx, newJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.StudyID).To(Equal(newStudy.ID))
+				items, err := s.ListSampleJobItems(ctx, newJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(HaveLen(1))
+			})
+
+			It("leaves no study behind when the job fails to insert", func() {
+				newJob.ID = sampleJob.ID // duplicate primary key
+
+				err := s.CreateStudyWithSampleJob(ctx, newStudy, newJob, nil)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("inserting sample job"))
+
+				_, err = s.GetStudy(ctx, newStudy.ID)
+				Expect(err).To(Equal(sql.ErrNoRows))
+			})
+
+			It("inserts nothing when the study fails to insert", func() {
+				newStudy.ID = "study-1" // duplicate primary key
+				newJob.StudyID = "study-1"
+
+				err := s.CreateStudyWithSampleJob(ctx, newStudy, newJob, []model.SampleJobItem{newItem})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("inserting study"))
+
+				_, err = s.GetSampleJob(ctx, newJob.ID)
+				Expect(err).To(Equal(sql.ErrNoRows))
+			})
+		})
+
+		Describe("AppendSampleJobItems", func() {
+			It("updates the job and inserts the new items", func() {
+				Expect(s.CreateSampleJobItem(ctx, sampleJobItem)).To(Succeed())
+
+				updated := sampleJob
+				updated.CheckpointFilenames = []string{"checkpoint-001.safetensors", "checkpoint-002.safetensors"}
+				updated.TotalItems = 2
+				newItem := sampleJobItem
+				newItem.ID = "item-2"
+				newItem.CheckpointFilename = "checkpoint-002.safetensors"
+
+				Expect(s.AppendSampleJobItems(ctx, updated, []model.SampleJobItem{newItem})).To(Succeed())
+
+				job, err := s.GetSampleJob(ctx, sampleJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.TotalItems).To(Equal(2))
+				Expect(job.CheckpointFilenames).To(Equal(updated.CheckpointFilenames))
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(HaveLen(2))
+			})
+
+			It("returns sql.ErrNoRows and inserts nothing for a non-existent job", func() {
+				missing := sampleJob
+				missing.ID = "nonexistent"
+
+				err := s.AppendSampleJobItems(ctx, missing, []model.SampleJobItem{sampleJobItem})
+				Expect(err).To(Equal(sql.ErrNoRows))
+
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(BeEmpty())
+			})
+
+			It("leaves the job unchanged when an item fails to insert", func() {
+				Expect(s.CreateSampleJobItem(ctx, sampleJobItem)).To(Succeed())
+
+				updated := sampleJob
+				updated.TotalItems = 99
+
+				err := s.AppendSampleJobItems(ctx, updated, []model.SampleJobItem{sampleJobItem}) // duplicate ID
+				Expect(err).To(HaveOccurred())
+
+				job, err := s.GetSampleJob(ctx, sampleJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.TotalItems).To(Equal(sampleJob.TotalItems))
 			})
 		})
 
 		Describe("ListSampleJobItems", func() {
 			It("returns empty slice when no items exist for job", func() {
-				result, err := s.ListSampleJobItems(sampleJob.ID)
+				result, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(0))
 			})
@@ -779,16 +1200,16 @@ var _ = Describe("SampleJob Store", func() {
 				item3.CreatedAt = now
 				item3.UpdatedAt = now
 
-				err := s.CreateSampleJobItem(item1)
+				err := s.CreateSampleJobItem(ctx, item1)
 				Expect(err).NotTo(HaveOccurred())
 
-				err = s.CreateSampleJobItem(item2)
+				err = s.CreateSampleJobItem(ctx, item2)
 				Expect(err).NotTo(HaveOccurred())
 
-				err = s.CreateSampleJobItem(item3)
+				err = s.CreateSampleJobItem(ctx, item3)
 				Expect(err).NotTo(HaveOccurred())
 
-				result, err := s.ListSampleJobItems(sampleJob.ID)
+				result, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(3))
 				// Should be ordered by created_at ASC: item1, item2, item3
@@ -812,34 +1233,186 @@ var _ = Describe("SampleJob Store", func() {
 					CreatedAt:       now,
 					UpdatedAt:       now,
 				}
-				err := s.CreateSampleJob(job2)
+				err := s.CreateSampleJob(ctx, job2)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Create items for job-1
 				item1 := sampleJobItem
 				item1.ID = "item-1"
 				item1.JobID = "job-1"
-				err = s.CreateSampleJobItem(item1)
+				err = s.CreateSampleJobItem(ctx, item1)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Create items for job-2
 				item2 := sampleJobItem
 				item2.ID = "item-2"
 				item2.JobID = "job-2"
-				err = s.CreateSampleJobItem(item2)
+				err = s.CreateSampleJobItem(ctx, item2)
 				Expect(err).NotTo(HaveOccurred())
 
 				// List items for job-1 should only return item-1
-				result, err := s.ListSampleJobItems("job-1")
+				result, err := s.ListSampleJobItems(ctx, "job-1")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(1))
 				Expect(result[0].ID).To(Equal("item-1"))
 			})
 		})
 
+		Describe("ListSampleJobItemsByPromptIDs", func() {
+			It("returns the items submitted under the given prompt IDs", func() {
+				for i, promptID := range []string{"prompt-a", "prompt-b", ""} {
+					item := sampleJobItem
+					item.ID = fmt.Sprintf("item-%d", i)
+					item.ComfyUIPromptID = promptID
+					Expect(s.CreateSampleJobItem(ctx, item)).To(Succeed())
+				}
+
+				items, err := s.ListSampleJobItemsByPromptIDs(ctx, []string{"prompt-b", "prompt-unknown"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(HaveLen(1))
+				Expect(items[0].ID).To(Equal("item-1"))
+				Expect(items[0].JobID).To(Equal(sampleJob.ID))
+			})
+
+			It("returns nothing for no prompt IDs", func() {
+				items, err := s.ListSampleJobItemsByPromptIDs(ctx, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(BeEmpty())
+			})
+		})
+
+		Describe("ListSampleJobItemsPage", func() {
+			BeforeEach(func() {
+				// Items created in the same second keep their insertion order.
+				for i := 0; i < 5; i++ {
+					item := sampleJobItem
+					item.ID = fmt.Sprintf("item-page-%d", i)
+					Expect(s.CreateSampleJobItem(ctx, item)).To(Succeed())
+				}
+			})
+
+			It("returns the requested page in insertion order", func() {
+				result, err := s.ListSampleJobItemsPage(ctx, sampleJob.ID, model.SampleJobItemQuery{}, model.Page{Limit: 2, Offset: 2})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(HaveLen(2))
+				Expect(result[0].ID).To(Equal("item-page-2"))
+				Expect(result[1].ID).To(Equal("item-page-3"))
+			})
+
+			It("returns every item from the offset when the limit is zero", func() {
+				result, err := s.ListSampleJobItemsPage(ctx, sampleJob.ID, model.SampleJobItemQuery{}, model.Page{Offset: 3})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(HaveLen(2))
+			})
+
+			It("returns nothing past the end", func() {
+				result, err := s.ListSampleJobItemsPage(ctx, sampleJob.ID, model.SampleJobItemQuery{}, model.Page{Limit: 10, Offset: 10})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(BeEmpty())
+			})
+
+			It("counts the job's items", func() {
+				count, err := s.CountSampleJobItems(ctx, sampleJob.ID, model.SampleJobItemFilter{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(5))
+
+				count, err = s.CountSampleJobItems(ctx, "no-such-job", model.SampleJobItemFilter{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(BeZero())
+			})
+		})
+
+		Describe("ListSampleJobItemsPage filtering and sorting", func() {
+			BeforeEach(func() {
+				items := []struct {
+					id, checkpoint, prompt, sampler string
+					seed                            int64
+					status                          model.SampleJobItemStatus
+					durationMs                      *int64
+					scores                          map[string]float64
+				}{
+					{"a", "ckpt-1.safetensors", "forest", "euler", 30, model.SampleJobItemStatusCompleted, ptrInt64(2000), map[string]float64{"aesthetic": 5.5}},
+					{"b", "ckpt-1.safetensors", "city", "dpmpp_2m", 10, model.SampleJobItemStatusFailed, ptrInt64(500), nil},
+					{"c", "ckpt-2.safetensors", "forest", "euler", 20, model.SampleJobItemStatusCompleted, ptrInt64(1000), map[string]float64{"aesthetic": 7, "clip_similarity": 0.3}},
+					{"d", "ckpt-2.safetensors", "city", "euler", 40, model.SampleJobItemStatusPending, nil, map[string]float64{"clip_similarity": 0.2}},
+				}
+				for _, it := range items {
+					item := sampleJobItem
+					item.ID = it.id
+					item.CheckpointFilename = it.checkpoint
+					item.PromptName = it.prompt
+					item.SamplerName = it.sampler
+					item.Seed = it.seed
+					item.Status = it.status
+					item.DurationMs = it.durationMs
+					item.Scores = it.scores
+					Expect(s.CreateSampleJobItem(ctx, item)).To(Succeed())
+				}
+			})
+
+			ids := func(items []model.SampleJobItem) []string {
+				result := make([]string, len(items))
+				for i, item := range items {
+					result[i] = item.ID
+				}
+				return result
+			}
+
+			DescribeTable("filters items",
+				func(filter model.SampleJobItemFilter, expected []string) {
+					result, err := s.ListSampleJobItemsPage(ctx, sampleJob.ID, model.SampleJobItemQuery{Filter: filter}, model.Page{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(ids(result)).To(Equal(expected))
+
+					count, err := s.CountSampleJobItems(ctx, sampleJob.ID, filter)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(count).To(Equal(len(expected)))
+				},
+				Entry("by status", model.SampleJobItemFilter{Status: model.SampleJobItemStatusCompleted}, []string{"a", "c"}),
+				Entry("by checkpoint", model.SampleJobItemFilter{CheckpointFilename: "ckpt-2.safetensors"}, []string{"c", "d"}),
+				Entry("by prompt", model.SampleJobItemFilter{PromptName: "city"}, []string{"b", "d"}),
+				Entry("by sampler", model.SampleJobItemFilter{SamplerName: "dpmpp_2m"}, []string{"b"}),
+				Entry("by several fields", model.SampleJobItemFilter{PromptName: "forest", CheckpointFilename: "ckpt-2.safetensors"}, []string{"c"}),
+				Entry("matching nothing", model.SampleJobItemFilter{SamplerName: "heun"}, []string{}),
+			)
+
+			DescribeTable("sorts items",
+				func(query model.SampleJobItemQuery, expected []string) {
+					result, err := s.ListSampleJobItemsPage(ctx, sampleJob.ID, query, model.Page{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(ids(result)).To(Equal(expected))
+				},
+				Entry("by creation", model.SampleJobItemQuery{}, []string{"a", "b", "c", "d"}),
+				Entry("by creation, descending", model.SampleJobItemQuery{Sort: model.SampleJobItemSortCreatedAt, Descending: true}, []string{"d", "c", "b", "a"}),
+				Entry("by seed", model.SampleJobItemQuery{Sort: model.SampleJobItemSortSeed}, []string{"b", "c", "a", "d"}),
+				Entry("by duration, unfinished last", model.SampleJobItemQuery{Sort: model.SampleJobItemSortDuration}, []string{"b", "c", "a", "d"}),
+				Entry("by duration descending, unfinished last", model.SampleJobItemQuery{Sort: model.SampleJobItemSortDuration, Descending: true}, []string{"a", "c", "b", "d"}),
+				Entry("by score, unscored last", model.SampleJobItemQuery{Sort: model.SampleJobItemSortScore, ScoreName: "aesthetic"}, []string{"a", "c", "b", "d"}),
+				Entry("by score descending, unscored last", model.SampleJobItemQuery{Sort: model.SampleJobItemSortScore, ScoreName: "aesthetic", Descending: true}, []string{"c", "a", "d", "b"}),
+				Entry("by another score", model.SampleJobItemQuery{Sort: model.SampleJobItemSortScore, ScoreName: "clip_similarity", Descending: true}, []string{"c", "d", "b", "a"}),
+			)
+
+			It("round-trips item scores", func() {
+				result, err := s.ListSampleJobItemsPage(ctx, sampleJob.ID, model.SampleJobItemQuery{}, model.Page{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result[0].Scores).To(Equal(map[string]float64{"aesthetic": 5.5}))
+				Expect(result[1].Scores).To(BeNil())
+			})
+
+			It("pages the filtered, sorted items", func() {
+				query := model.SampleJobItemQuery{
+					Filter: model.SampleJobItemFilter{SamplerName: "euler"},
+					Sort:   model.SampleJobItemSortSeed,
+				}
+				result, err := s.ListSampleJobItemsPage(ctx, sampleJob.ID, query, model.Page{Limit: 1, Offset: 1})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ids(result)).To(Equal([]string{"a"}))
+			})
+		})
+
 		Describe("UpdateSampleJobItem", func() {
 			BeforeEach(func() {
-				err := s.CreateSampleJobItem(sampleJobItem)
+				err := s.CreateSampleJobItem(ctx, sampleJobItem)
 				Expect(err).NotTo(HaveOccurred())
 			})
 
@@ -850,13 +1423,15 @@ var _ = Describe("SampleJob Store", func() {
 				updated.OutputPath = "/outputs/result.png"
 				updated.UpdatedAt = time.Now().UTC()
 
-				err := s.UpdateSampleJobItem(updated)
+				err := s.UpdateSampleJobItem(ctx, &updated)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(updated.Version).To(Equal(sampleJobItem.Version + 1))
 
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 				Expect(items[0].Status).To(Equal(model.SampleJobItemStatusCompleted))
+				Expect(items[0].Version).To(Equal(updated.Version))
 				Expect(items[0].ComfyUIPromptID).To(Equal("prompt-456"))
 				Expect(items[0].OutputPath).To(Equal("/outputs/result.png"))
 				// CreatedAt should remain unchanged
@@ -868,19 +1443,31 @@ var _ = Describe("SampleJob Store", func() {
 				updated.ErrorMessage = "test error"
 				updated.UpdatedAt = time.Now().UTC()
 
-				err := s.UpdateSampleJobItem(updated)
+				err := s.UpdateSampleJobItem(ctx, &updated)
 				Expect(err).NotTo(HaveOccurred())
 
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 				Expect(items[0].ErrorMessage).To(Equal("test error"))
 			})
 
+			It("updates the staged checkpoint", func() {
+				updated := sampleJobItem
+				updated.StagedCheckpoint = "/staging/checkpoint-001.safetensors"
+				updated.UpdatedAt = time.Now().UTC()
+
+				Expect(s.UpdateSampleJobItem(ctx, &updated)).To(Succeed())
+
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items[0].StagedCheckpoint).To(Equal("/staging/checkpoint-001.safetensors"))
+			})
+
 			It("returns sql.ErrNoRows for non-existent ID", func() {
 				nonExistent := sampleJobItem
 				nonExistent.ID = "nonexistent"
-				err := s.UpdateSampleJobItem(nonExistent)
+				err := s.UpdateSampleJobItem(ctx, &nonExistent)
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
 		})
@@ -908,41 +1495,141 @@ var _ = Describe("SampleJob Store", func() {
 					UpdatedAt:          now,
 				}
 
-				err := s.CreateSampleJobItem(item)
+				err := s.CreateSampleJobItem(ctx, item)
 				Expect(err).NotTo(HaveOccurred())
 
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 				Expect(items[0].NegativePrompt).To(Equal("blurry, artifacts, bad quality"))
 			})
 
 			It("stores empty string when negative_prompt is not set", func() {
-				err := s.CreateSampleJobItem(sampleJobItem)
+				err := s.CreateSampleJobItem(ctx, sampleJobItem)
 				Expect(err).NotTo(HaveOccurred())
 
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 				Expect(items[0].NegativePrompt).To(Equal(""))
 			})
 
 			It("updates negative_prompt on existing item", func() {
-				err := s.CreateSampleJobItem(sampleJobItem)
+				err := s.CreateSampleJobItem(ctx, sampleJobItem)
 				Expect(err).NotTo(HaveOccurred())
 
 				updated := sampleJobItem
 				updated.NegativePrompt = "low quality, blurry"
 				updated.UpdatedAt = time.Now().UTC()
 
-				err = s.UpdateSampleJobItem(updated)
+				err = s.UpdateSampleJobItem(ctx, &updated)
 				Expect(err).NotTo(HaveOccurred())
 
-				items, err := s.ListSampleJobItems(sampleJob.ID)
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 				Expect(items[0].NegativePrompt).To(Equal("low quality, blurry"))
 			})
 		})
+
+		Describe("Timing persistence", func() {
+			It("stores nil timing fields for a new item", func() {
+				err := s.CreateSampleJobItem(ctx, sampleJobItem)
+				Expect(err).NotTo(HaveOccurred())
+
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(HaveLen(1))
+				Expect(items[0].StartedAt).To(BeNil())
+				Expect(items[0].CompletedAt).To(BeNil())
+				Expect(items[0].DurationMs).To(BeNil())
+			})
+
+			It("round-trips started_at, completed_at and duration_ms with sub-second precision", func() {
+				err := s.CreateSampleJobItem(ctx, sampleJobItem)
+				Expect(err).NotTo(HaveOccurred())
+
+				startedAt := time.Date(2025, 1, 1, 12, 0, 0, 250_000_000, time.UTC)
+				completedAt := startedAt.Add(12345 * time.Millisecond)
+				durationMs := int64(12345)
+
+				updated := sampleJobItem
+				updated.Status = model.SampleJobItemStatusCompleted
+				updated.StartedAt = &startedAt
+				updated.CompletedAt = &completedAt
+				updated.DurationMs = &durationMs
+				updated.UpdatedAt = time.Now().UTC()
+				Expect(s.UpdateSampleJobItem(ctx, &updated)).To(Succeed())
+
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(HaveLen(1))
+				Expect(items[0].StartedAt).NotTo(BeNil())
+				Expect(items[0].StartedAt.Equal(startedAt)).To(BeTrue())
+				Expect(items[0].CompletedAt).NotTo(BeNil())
+				Expect(items[0].CompletedAt.Equal(completedAt)).To(BeTrue())
+				Expect(items[0].DurationMs).NotTo(BeNil())
+				Expect(*items[0].DurationMs).To(Equal(int64(12345)))
+			})
+
+			It("clears timing fields when set back to nil", func() {
+				startedAt := time.Now().UTC()
+				durationMs := int64(10)
+				withTiming := sampleJobItem
+				withTiming.StartedAt = &startedAt
+				withTiming.CompletedAt = &startedAt
+				withTiming.DurationMs = &durationMs
+				Expect(s.CreateSampleJobItem(ctx, withTiming)).To(Succeed())
+
+				cleared := withTiming
+				cleared.StartedAt = nil
+				cleared.CompletedAt = nil
+				cleared.DurationMs = nil
+				Expect(s.UpdateSampleJobItem(ctx, &cleared)).To(Succeed())
+
+				items, err := s.ListSampleJobItems(ctx, sampleJob.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(items).To(HaveLen(1))
+				Expect(items[0].StartedAt).To(BeNil())
+				Expect(items[0].CompletedAt).To(BeNil())
+				Expect(items[0].DurationMs).To(BeNil())
+			})
+		})
+
+		Describe("AverageItemDuration", func() {
+			completeItem := func(id string, durationMs int64, completedAt time.Time) {
+				item := sampleJobItem
+				item.ID = id
+				Expect(s.CreateSampleJobItem(ctx, item)).To(Succeed())
+				item.Status = model.SampleJobItemStatusCompleted
+				item.CompletedAt = &completedAt
+				item.DurationMs = &durationMs
+				Expect(s.UpdateSampleJobItem(ctx, &item)).To(Succeed())
+			}
+
+			It("reports no estimate when no item has timing data", func() {
+				Expect(s.CreateSampleJobItem(ctx, sampleJobItem)).To(Succeed())
+
+				_, ok, err := s.AverageItemDuration(ctx, 100)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+
+			It("averages the most recently completed items", func() {
+				base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+				completeItem("old", 100000, base)
+				completeItem("recent-1", 1000, base.Add(time.Minute))
+				completeItem("recent-2", 3000, base.Add(2*time.Minute))
+
+				avg, ok, err := s.AverageItemDuration(ctx, 2)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(avg).To(Equal(2 * time.Second))
+			})
+		})
 	})
 })
+
+func ptrInt64(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+)
+
+// maxScoringResponseBytes bounds the response body read from a scoring
+// service.
+const maxScoringResponseBytes = 1 << 20
+
+// ScoringClient posts sample images to an external scoring service, such as
+// an aesthetic predictor or a CLIP similarity server.
+//
+// The image is posted as a JSON object with the image base64-encoded in
+// "image", its MIME type in "content_type", and the item's generation
+// parameters. The service responds with {"scores": {"<name>": <number>}}.
+type ScoringClient struct {
+	url    string
+	client *http.Client
+}
+
+// NewScoringClient creates a ScoringClient for the service at rawURL.
+// Request timeouts are left to the caller's context.
+func NewScoringClient(rawURL string) *ScoringClient {
+	return &ScoringClient{
+		url:    rawURL,
+		client: &http.Client{},
+	}
+}
+
+// scoringRequest is the JSON body posted to the scoring service.
+type scoringRequest struct {
+	Image              string `json:"image"`
+	ContentType        string `json:"content_type"`
+	Filename           string `json:"filename"`
+	JobID              string `json:"job_id"`
+	ItemID             string `json:"item_id"`
+	TrainingRun        string `json:"training_run"`
+	CheckpointFilename string `json:"checkpoint_filename"`
+	PromptName         string `json:"prompt_name"`
+	PromptText         string `json:"prompt_text"`
+	NegativePrompt     string `json:"negative_prompt"`
+}
+
+// scoringResponse is the JSON body returned by the scoring service.
+type scoringResponse struct {
+	Scores map[string]float64 `json:"scores"`
+}
+
+// ScoreImage posts req to the scoring service and returns the scores it
+// computed. Any 2xx status is a success. Errors name only the host, since
+// service URLs may embed credentials.
+func (c *ScoringClient) ScoreImage(ctx context.Context, req model.ImageScoreRequest) (map[string]float64, error) {
+	contentType := mime.TypeByExtension(filepath.Ext(req.Filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	body, err := json.Marshal(scoringRequest{
+		Image:              base64.StdEncoding.EncodeToString(req.Image),
+		ContentType:        contentType,
+		Filename:           req.Filename,
+		JobID:              req.JobID,
+		ItemID:             req.ItemID,
+		TrainingRun:        req.TrainingRunName,
+		CheckpointFilename: req.CheckpointFilename,
+		PromptName:         req.PromptName,
+		PromptText:         req.PromptText,
+		NegativePrompt:     req.NegativePrompt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling scoring request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("creating scoring request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("scoring request to %s failed: %w", httpReq.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("scoring request to %s failed with status %d", httpReq.URL.Host, resp.StatusCode)
+	}
+	var result scoringResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxScoringResponseBytes)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding scoring response from %s: %w", httpReq.URL.Host, err)
+	}
+	return result.Scores, nil
+}
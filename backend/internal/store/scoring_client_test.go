@@ -0,0 +1,66 @@
+package store_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("ScoringClient", func() {
+	req := model.ImageScoreRequest{
+		Image:              []byte("png-bytes"),
+		Filename:           "index=0&seed=42_.png",
+		ItemID:             "item-1",
+		CheckpointFilename: "model-step00001000.safetensors",
+		PromptText:         "a forest at dawn",
+	}
+
+	It("posts the image as JSON and returns the scores", func() {
+		var contentType string
+		var body map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType = r.Header.Get("Content-Type")
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			w.Write([]byte(`{"scores":{"aesthetic":6.25,"clip_similarity":0.31}}`))
+		}))
+		defer server.Close()
+
+		scores, err := store.NewScoringClient(server.URL+"/score").ScoreImage(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scores).To(Equal(map[string]float64{"aesthetic": 6.25, "clip_similarity": 0.31}))
+		Expect(contentType).To(Equal("application/json"))
+		Expect(body["image"]).To(Equal(base64.StdEncoding.EncodeToString([]byte("png-bytes"))))
+		Expect(body["content_type"]).To(Equal("image/png"))
+		Expect(body["item_id"]).To(Equal("item-1"))
+		Expect(body["prompt_text"]).To(Equal("a forest at dawn"))
+	})
+
+	It("returns an error naming only the host for a non-2xx status", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		_, err := store.NewScoringClient(server.URL+"/score?token=secret-token").ScoreImage(context.Background(), req)
+		Expect(err).To(MatchError(ContainSubstring("status 503")))
+		Expect(err.Error()).NotTo(ContainSubstring("secret-token"))
+	})
+
+	It("returns an error for a malformed response", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"scores":{"aesthetic":"high"}}`))
+		}))
+		defer server.Close()
+
+		_, err := store.NewScoringClient(server.URL).ScoreImage(context.Background(), req)
+		Expect(err).To(MatchError(ContainSubstring("decoding scoring response")))
+	})
+})
@@ -3,13 +3,16 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
 )
 
 // Store provides access to the persistence layer.
 type Store struct {
-	db     *sql.DB
+	db     *instrumentedDB
 	logger *logrus.Entry
 }
 
@@ -26,11 +29,25 @@ func New(db *sql.DB, logger *logrus.Logger) (*Store, error) {
 	}
 	entry.Info("database migrations completed")
 	return &Store{
-		db:     db,
+		db:     newInstrumentedDB(db, entry),
 		logger: entry,
 	}, nil
 }
 
+// SetSlowQueryThreshold sets the duration above which a statement is logged
+// as slow. The default is DefaultSlowQueryThreshold.
+func (s *Store) SetSlowQueryThreshold(threshold time.Duration) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.slowThreshold = threshold
+}
+
+// DBStats returns the query counts and durations recorded since startup and
+// the state of the connection pool.
+func (s *Store) DBStats() model.DBStats {
+	return s.db.stats()
+}
+
 // Close closes the underlying database connection.
 func (s *Store) Close() error {
 	return s.db.Close()
@@ -38,7 +55,7 @@ func (s *Store) Close() error {
 
 // DB returns the underlying database connection for use in queries.
 func (s *Store) DB() *sql.DB {
-	return s.db
+	return s.db.db
 }
 
 // ResetDB drops all application tables and the schema_migrations tracking
@@ -50,8 +67,22 @@ func (s *Store) ResetDB() error {
 
 	// Drop tables in reverse dependency order to respect foreign keys.
 	tables := []string{
+		"votes",
+		"change_log",
+		"images",
+		"image_dirs",
+		"asset_uploads",
+		"assets",
+		"training_run_configs",
+		"galleries",
+		"process_leases",
+		"image_annotations",
+		"pins",
+		"job_templates",
+		"sample_job_parameters",
 		"sample_job_items",
 		"sample_jobs",
+		"study_versions",
 		"studies",
 		"sample_presets",
 		"presets",
@@ -66,7 +97,7 @@ func (s *Store) ResetDB() error {
 	s.logger.Info("all tables dropped for database reset")
 
 	// Rerun migrations to recreate the schema.
-	if err := Migrate(s.db, AllMigrations()); err != nil {
+	if err := Migrate(s.db.db, AllMigrations()); err != nil {
 		s.logger.WithError(err).Error("migration failed during database reset")
 		return fmt.Errorf("running migrations after reset: %w", err)
 	}
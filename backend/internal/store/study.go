@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 type studyEntity struct {
 	ID                    string
 	Name                  string
+	Version               int
 	PromptPrefix          string
 	Prompts               string // JSON
 	NegativePrompt        string
@@ -23,18 +25,31 @@ type studyEntity struct {
 	Seeds                 string // JSON
 	Width                 int
 	Height                int
+	Resolutions           string   // JSON
 	WorkflowTemplate      *string  // nullable
 	VAE                   *string  // nullable
 	TextEncoder           *string  // nullable
 	Shift                 *float64 // nullable
+	InputImage            *string  // nullable
+	DenoiseStrengths      string   // JSON
+	Wildcards             string   // JSON
+	SeedMode              string   // model.SeedMode
+	RandomSeedCount       int      // zero in the explicit seed mode
+	Owner                 string
 	CreatedAt             string   // RFC3339
 	UpdatedAt             string   // RFC3339
 }
 
-// promptJSON is the JSON shape for named prompts.
+// promptJSON is the JSON shape for named prompts. The overrides are omitted
+// when unset, so prompts stored before they existed read back unchanged.
 type promptJSON struct {
-	Name string `json:"name"`
-	Text string `json:"text"`
+	Name   string    `json:"name"`
+	Text   string    `json:"text"`
+	Steps  []int     `json:"steps,omitempty"`
+	CFGs   []float64 `json:"cfgs,omitempty"`
+	Seeds  []int64   `json:"seeds,omitempty"`
+	Width  int       `json:"width,omitempty"`
+	Height int       `json:"height,omitempty"`
 }
 
 // samplerSchedulerPairJSON is the JSON shape for sampler/scheduler pairs.
@@ -43,12 +58,24 @@ type samplerSchedulerPairJSON struct {
 	Scheduler string `json:"scheduler"`
 }
 
+// resolutionJSON is the JSON shape for swept resolutions.
+type resolutionJSON struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// wildcardJSON is the JSON shape for prompt wildcards.
+type wildcardJSON struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
 // ListStudies returns all studies ordered by name.
-func (s *Store) ListStudies() ([]model.Study, error) {
+func (s *Store) ListStudies(ctx context.Context) ([]model.Study, error) {
 	s.logger.Trace("entering ListStudies")
 	defer s.logger.Trace("returning from ListStudies")
 
-	rows, err := s.db.Query(`SELECT id, name, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, workflow_template, vae, text_encoder, shift, created_at, updated_at
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name, version, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, resolutions, workflow_template, vae, text_encoder, shift, input_image, denoise_strengths, wildcards, seed_mode, random_seed_count, owner, created_at, updated_at
 		FROM studies ORDER BY name`)
 	if err != nil {
 		s.logger.WithError(err).Error("failed to query studies")
@@ -59,7 +86,7 @@ func (s *Store) ListStudies() ([]model.Study, error) {
 	var studies []model.Study
 	for rows.Next() {
 		var e studyEntity
-		if err := rows.Scan(&e.ID, &e.Name, &e.PromptPrefix, &e.Prompts, &e.NegativePrompt, &e.Steps, &e.CFGs, &e.SamplerSchedulerPairs, &e.Seeds, &e.Width, &e.Height, &e.WorkflowTemplate, &e.VAE, &e.TextEncoder, &e.Shift, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		if err := rows.Scan(&e.ID, &e.Name, &e.Version, &e.PromptPrefix, &e.Prompts, &e.NegativePrompt, &e.Steps, &e.CFGs, &e.SamplerSchedulerPairs, &e.Seeds, &e.Width, &e.Height, &e.Resolutions, &e.WorkflowTemplate, &e.VAE, &e.TextEncoder, &e.Shift, &e.InputImage, &e.DenoiseStrengths, &e.Wildcards, &e.SeedMode, &e.RandomSeedCount, &e.Owner, &e.CreatedAt, &e.UpdatedAt); err != nil {
 			s.logger.WithError(err).Error("failed to scan study row")
 			return nil, fmt.Errorf("scanning study row: %w", err)
 		}
@@ -79,15 +106,15 @@ func (s *Store) ListStudies() ([]model.Study, error) {
 }
 
 // GetStudy returns a single study by ID, or sql.ErrNoRows if not found.
-func (s *Store) GetStudy(id string) (model.Study, error) {
+func (s *Store) GetStudy(ctx context.Context, id string) (model.Study, error) {
 	s.logger.WithField("study_id", id).Trace("entering GetStudy")
 	defer s.logger.Trace("returning from GetStudy")
 
 	var e studyEntity
-	err := s.db.QueryRow(
-		`SELECT id, name, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, workflow_template, vae, text_encoder, shift, created_at, updated_at
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, name, version, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, resolutions, workflow_template, vae, text_encoder, shift, input_image, denoise_strengths, wildcards, seed_mode, random_seed_count, owner, created_at, updated_at
 		FROM studies WHERE id = ?`, id,
-	).Scan(&e.ID, &e.Name, &e.PromptPrefix, &e.Prompts, &e.NegativePrompt, &e.Steps, &e.CFGs, &e.SamplerSchedulerPairs, &e.Seeds, &e.Width, &e.Height, &e.WorkflowTemplate, &e.VAE, &e.TextEncoder, &e.Shift, &e.CreatedAt, &e.UpdatedAt)
+	).Scan(&e.ID, &e.Name, &e.Version, &e.PromptPrefix, &e.Prompts, &e.NegativePrompt, &e.Steps, &e.CFGs, &e.SamplerSchedulerPairs, &e.Seeds, &e.Width, &e.Height, &e.Resolutions, &e.WorkflowTemplate, &e.VAE, &e.TextEncoder, &e.Shift, &e.InputImage, &e.DenoiseStrengths, &e.Wildcards, &e.SeedMode, &e.RandomSeedCount, &e.Owner, &e.CreatedAt, &e.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			s.logger.WithField("study_id", id).Debug("study not found in database")
@@ -104,7 +131,7 @@ func (s *Store) GetStudy(id string) (model.Study, error) {
 }
 
 // CreateStudy inserts a new study.
-func (s *Store) CreateStudy(st model.Study) error {
+func (s *Store) CreateStudy(ctx context.Context, st model.Study) error {
 	s.logger.WithFields(logrus.Fields{
 		"study_id":   st.ID,
 		"study_name": st.Name,
@@ -120,27 +147,7 @@ func (s *Store) CreateStudy(st model.Study) error {
 		return err
 	}
 
-	_, err = s.db.Exec(
-		`INSERT INTO studies (id, name, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, workflow_template, vae, text_encoder, shift, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		entity.ID,
-		entity.Name,
-		entity.PromptPrefix,
-		entity.Prompts,
-		entity.NegativePrompt,
-		entity.Steps,
-		entity.CFGs,
-		entity.SamplerSchedulerPairs,
-		entity.Seeds,
-		entity.Width,
-		entity.Height,
-		entity.WorkflowTemplate,
-		entity.VAE,
-		entity.TextEncoder,
-		entity.Shift,
-		entity.CreatedAt,
-		entity.UpdatedAt,
-	)
+	_, err = s.db.ExecContext(ctx, insertStudySQL, studyInsertArgs(entity)...)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"study_id":   st.ID,
@@ -158,7 +165,7 @@ func (s *Store) CreateStudy(st model.Study) error {
 
 // UpdateStudy updates an existing study. Returns
 // sql.ErrNoRows if the study does not exist.
-func (s *Store) UpdateStudy(st model.Study) error {
+func (s *Store) UpdateStudy(ctx context.Context, st model.Study) error {
 	s.logger.WithFields(logrus.Fields{
 		"study_id":   st.ID,
 		"study_name": st.Name,
@@ -174,10 +181,11 @@ func (s *Store) UpdateStudy(st model.Study) error {
 		return err
 	}
 
-	result, err := s.db.Exec(
-		`UPDATE studies SET name = ?, prompt_prefix = ?, prompts = ?, negative_prompt = ?, steps = ?, cfgs = ?, sampler_scheduler_pairs = ?, seeds = ?, width = ?, height = ?, workflow_template = ?, vae = ?, text_encoder = ?, shift = ?, updated_at = ?
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE studies SET name = ?, version = ?, prompt_prefix = ?, prompts = ?, negative_prompt = ?, steps = ?, cfgs = ?, sampler_scheduler_pairs = ?, seeds = ?, width = ?, height = ?, resolutions = ?, workflow_template = ?, vae = ?, text_encoder = ?, shift = ?, input_image = ?, denoise_strengths = ?, wildcards = ?, seed_mode = ?, random_seed_count = ?, updated_at = ?
 		WHERE id = ?`,
 		entity.Name,
+		entity.Version,
 		entity.PromptPrefix,
 		entity.Prompts,
 		entity.NegativePrompt,
@@ -187,10 +195,16 @@ func (s *Store) UpdateStudy(st model.Study) error {
 		entity.Seeds,
 		entity.Width,
 		entity.Height,
+		entity.Resolutions,
 		entity.WorkflowTemplate,
 		entity.VAE,
 		entity.TextEncoder,
 		entity.Shift,
+		entity.InputImage,
+		entity.DenoiseStrengths,
+		entity.Wildcards,
+		entity.SeedMode,
+		entity.RandomSeedCount,
 		entity.UpdatedAt,
 		entity.ID,
 	)
@@ -224,22 +238,22 @@ func (s *Store) UpdateStudy(st model.Study) error {
 // GetStudyByName returns the first study with the given name, excluding the
 // study with excludeID (pass "" to include all studies). Returns sql.ErrNoRows
 // if no matching study is found.
-func (s *Store) GetStudyByName(name string, excludeID string) (model.Study, error) {
+func (s *Store) GetStudyByName(ctx context.Context, name string, excludeID string) (model.Study, error) {
 	s.logger.WithField("study_name", name).Trace("entering GetStudyByName")
 	defer s.logger.Trace("returning from GetStudyByName")
 
 	var e studyEntity
 	var err error
 	if excludeID == "" {
-		err = s.db.QueryRow(
-			`SELECT id, name, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, workflow_template, vae, text_encoder, shift, created_at, updated_at
+		err = s.db.QueryRowContext(ctx,
+			`SELECT id, name, version, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, resolutions, workflow_template, vae, text_encoder, shift, input_image, denoise_strengths, wildcards, seed_mode, random_seed_count, owner, created_at, updated_at
 			FROM studies WHERE name = ? LIMIT 1`, name,
-		).Scan(&e.ID, &e.Name, &e.PromptPrefix, &e.Prompts, &e.NegativePrompt, &e.Steps, &e.CFGs, &e.SamplerSchedulerPairs, &e.Seeds, &e.Width, &e.Height, &e.WorkflowTemplate, &e.VAE, &e.TextEncoder, &e.Shift, &e.CreatedAt, &e.UpdatedAt)
+		).Scan(&e.ID, &e.Name, &e.Version, &e.PromptPrefix, &e.Prompts, &e.NegativePrompt, &e.Steps, &e.CFGs, &e.SamplerSchedulerPairs, &e.Seeds, &e.Width, &e.Height, &e.Resolutions, &e.WorkflowTemplate, &e.VAE, &e.TextEncoder, &e.Shift, &e.InputImage, &e.DenoiseStrengths, &e.Wildcards, &e.SeedMode, &e.RandomSeedCount, &e.Owner, &e.CreatedAt, &e.UpdatedAt)
 	} else {
-		err = s.db.QueryRow(
-			`SELECT id, name, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, workflow_template, vae, text_encoder, shift, created_at, updated_at
+		err = s.db.QueryRowContext(ctx,
+			`SELECT id, name, version, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, resolutions, workflow_template, vae, text_encoder, shift, input_image, denoise_strengths, wildcards, seed_mode, random_seed_count, owner, created_at, updated_at
 			FROM studies WHERE name = ? AND id != ? LIMIT 1`, name, excludeID,
-		).Scan(&e.ID, &e.Name, &e.PromptPrefix, &e.Prompts, &e.NegativePrompt, &e.Steps, &e.CFGs, &e.SamplerSchedulerPairs, &e.Seeds, &e.Width, &e.Height, &e.WorkflowTemplate, &e.VAE, &e.TextEncoder, &e.Shift, &e.CreatedAt, &e.UpdatedAt)
+		).Scan(&e.ID, &e.Name, &e.Version, &e.PromptPrefix, &e.Prompts, &e.NegativePrompt, &e.Steps, &e.CFGs, &e.SamplerSchedulerPairs, &e.Seeds, &e.Width, &e.Height, &e.Resolutions, &e.WorkflowTemplate, &e.VAE, &e.TextEncoder, &e.Shift, &e.InputImage, &e.DenoiseStrengths, &e.Wildcards, &e.SeedMode, &e.RandomSeedCount, &e.Owner, &e.CreatedAt, &e.UpdatedAt)
 	}
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -258,11 +272,11 @@ func (s *Store) GetStudyByName(name string, excludeID string) (model.Study, erro
 
 // DeleteStudy removes a study by ID. Returns sql.ErrNoRows if the study
 // does not exist.
-func (s *Store) DeleteStudy(id string) error {
+func (s *Store) DeleteStudy(ctx context.Context, id string) error {
 	s.logger.WithField("study_id", id).Trace("entering DeleteStudy")
 	defer s.logger.Trace("returning from DeleteStudy")
 
-	result, err := s.db.Exec("DELETE FROM studies WHERE id = ?", id)
+	result, err := s.db.ExecContext(ctx, "DELETE FROM studies WHERE id = ?", id)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"study_id": id,
@@ -312,6 +326,52 @@ func studyEntityToModel(e studyEntity) (model.Study, error) {
 		return model.Study{}, fmt.Errorf("unmarshaling seeds: %w", err)
 	}
 
+	// Denoise strengths are optional; an empty list is returned as nil.
+	var denoiseStrengths []float64
+	if err := json.Unmarshal([]byte(e.DenoiseStrengths), &denoiseStrengths); err != nil {
+		return model.Study{}, fmt.Errorf("unmarshaling denoise_strengths: %w", err)
+	}
+	if len(denoiseStrengths) == 0 {
+		denoiseStrengths = nil
+	}
+
+	// Wildcards are optional too. Study snapshots taken before the column
+	// existed carry no value at all.
+	var wildcards []model.Wildcard
+	if e.Wildcards != "" {
+		var wildcardsJSON []wildcardJSON
+		if err := json.Unmarshal([]byte(e.Wildcards), &wildcardsJSON); err != nil {
+			return model.Study{}, fmt.Errorf("unmarshaling wildcards: %w", err)
+		}
+		for _, w := range wildcardsJSON {
+			wildcards = append(wildcards, model.Wildcard{Name: w.Name, Values: w.Values})
+		}
+	}
+
+	// Resolutions are optional as well, and missing from older snapshots.
+	var resolutions []model.Resolution
+	if e.Resolutions != "" {
+		var resolutionsJSON []resolutionJSON
+		if err := json.Unmarshal([]byte(e.Resolutions), &resolutionsJSON); err != nil {
+			return model.Study{}, fmt.Errorf("unmarshaling resolutions: %w", err)
+		}
+		for _, r := range resolutionsJSON {
+			resolutions = append(resolutions, model.Resolution{Width: r.Width, Height: r.Height})
+		}
+	}
+
+	// Snapshots taken before seed modes existed used explicit seeds.
+	seedMode := model.SeedMode(e.SeedMode)
+	if seedMode == "" {
+		seedMode = model.SeedModeExplicit
+	}
+
+	// Snapshots taken before study versions existed are of the first one.
+	version := e.Version
+	if version == 0 {
+		version = 1
+	}
+
 	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
 	if err != nil {
 		return model.Study{}, fmt.Errorf("parsing created_at: %w", err)
@@ -324,8 +384,13 @@ func studyEntityToModel(e studyEntity) (model.Study, error) {
 	namedPrompts := make([]model.NamedPrompt, len(prompts))
 	for i, p := range prompts {
 		namedPrompts[i] = model.NamedPrompt{
-			Name: p.Name,
-			Text: p.Text,
+			Name:   p.Name,
+			Text:   p.Text,
+			Steps:  p.Steps,
+			CFGs:   p.CFGs,
+			Seeds:  p.Seeds,
+			Width:  p.Width,
+			Height: p.Height,
 		}
 	}
 
@@ -350,10 +415,15 @@ func studyEntityToModel(e studyEntity) (model.Study, error) {
 	if e.TextEncoder != nil {
 		textEncoder = *e.TextEncoder
 	}
+	inputImage := ""
+	if e.InputImage != nil {
+		inputImage = *e.InputImage
+	}
 
 	return model.Study{
 		ID:                    e.ID,
 		Name:                  e.Name,
+		Version:               version,
 		PromptPrefix:          e.PromptPrefix,
 		Prompts:               namedPrompts,
 		NegativePrompt:        e.NegativePrompt,
@@ -363,10 +433,17 @@ func studyEntityToModel(e studyEntity) (model.Study, error) {
 		Seeds:                 seeds,
 		Width:                 e.Width,
 		Height:                e.Height,
+		Resolutions:           resolutions,
 		WorkflowTemplate:      workflowTemplate,
 		VAE:                   vae,
 		TextEncoder:           textEncoder,
 		Shift:                 e.Shift,
+		InputImage:            inputImage,
+		DenoiseStrengths:      denoiseStrengths,
+		Wildcards:             wildcards,
+		SeedMode:              seedMode,
+		RandomSeedCount:       e.RandomSeedCount,
+		Owner:                 e.Owner,
 		CreatedAt:             createdAt,
 		UpdatedAt:             updatedAt,
 	}, nil
@@ -376,8 +453,13 @@ func studyModelToEntity(st model.Study) (studyEntity, error) {
 	prompts := make([]promptJSON, len(st.Prompts))
 	for i, np := range st.Prompts {
 		prompts[i] = promptJSON{
-			Name: np.Name,
-			Text: np.Text,
+			Name:   np.Name,
+			Text:   np.Text,
+			Steps:  np.Steps,
+			CFGs:   np.CFGs,
+			Seeds:  np.Seeds,
+			Width:  np.Width,
+			Height: np.Height,
 		}
 	}
 
@@ -413,6 +495,33 @@ func studyModelToEntity(st model.Study) (studyEntity, error) {
 		return studyEntity{}, fmt.Errorf("marshaling seeds: %w", err)
 	}
 
+	denoiseStrengths := st.DenoiseStrengths
+	if denoiseStrengths == nil {
+		denoiseStrengths = []float64{}
+	}
+	denoiseBytes, err := json.Marshal(denoiseStrengths)
+	if err != nil {
+		return studyEntity{}, fmt.Errorf("marshaling denoise_strengths: %w", err)
+	}
+
+	wildcardsJSON := make([]wildcardJSON, len(st.Wildcards))
+	for i, w := range st.Wildcards {
+		wildcardsJSON[i] = wildcardJSON{Name: w.Name, Values: w.Values}
+	}
+	wildcardsBytes, err := json.Marshal(wildcardsJSON)
+	if err != nil {
+		return studyEntity{}, fmt.Errorf("marshaling wildcards: %w", err)
+	}
+
+	resolutionsJSON := make([]resolutionJSON, len(st.Resolutions))
+	for i, r := range st.Resolutions {
+		resolutionsJSON[i] = resolutionJSON{Width: r.Width, Height: r.Height}
+	}
+	resolutionsBytes, err := json.Marshal(resolutionsJSON)
+	if err != nil {
+		return studyEntity{}, fmt.Errorf("marshaling resolutions: %w", err)
+	}
+
 	// Convert empty string fields to nil pointers so they are stored as NULL.
 	var workflowTemplate *string
 	if st.WorkflowTemplate != "" {
@@ -426,10 +535,20 @@ func studyModelToEntity(st model.Study) (studyEntity, error) {
 	if st.TextEncoder != "" {
 		textEncoder = &st.TextEncoder
 	}
+	var inputImage *string
+	if st.InputImage != "" {
+		inputImage = &st.InputImage
+	}
+
+	version := st.Version
+	if version == 0 {
+		version = 1
+	}
 
 	return studyEntity{
 		ID:                    st.ID,
 		Name:                  st.Name,
+		Version:               version,
 		PromptPrefix:          st.PromptPrefix,
 		Prompts:               string(promptsBytes),
 		NegativePrompt:        st.NegativePrompt,
@@ -439,11 +558,52 @@ func studyModelToEntity(st model.Study) (studyEntity, error) {
 		Seeds:                 string(seedsBytes),
 		Width:                 st.Width,
 		Height:                st.Height,
+		Resolutions:           string(resolutionsBytes),
 		WorkflowTemplate:      workflowTemplate,
 		VAE:                   vae,
 		TextEncoder:           textEncoder,
 		Shift:                 st.Shift,
+		InputImage:            inputImage,
+		DenoiseStrengths:      string(denoiseBytes),
+		Wildcards:             string(wildcardsBytes),
+		SeedMode:              string(st.SeedMode),
+		RandomSeedCount:       st.RandomSeedCount,
+		Owner:                 st.Owner,
 		CreatedAt:             st.CreatedAt.UTC().Format(time.RFC3339),
 		UpdatedAt:             st.UpdatedAt.UTC().Format(time.RFC3339),
 	}, nil
 }
+
+const insertStudySQL = `INSERT INTO studies (id, name, version, prompt_prefix, prompts, negative_prompt, steps, cfgs, sampler_scheduler_pairs, seeds, width, height, resolutions, workflow_template, vae, text_encoder, shift, input_image, denoise_strengths, wildcards, seed_mode, random_seed_count, owner, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// studyInsertArgs returns the arguments of insertStudySQL for entity.
+func studyInsertArgs(entity studyEntity) []any {
+	return []any{
+		entity.ID,
+		entity.Name,
+		entity.Version,
+		entity.PromptPrefix,
+		entity.Prompts,
+		entity.NegativePrompt,
+		entity.Steps,
+		entity.CFGs,
+		entity.SamplerSchedulerPairs,
+		entity.Seeds,
+		entity.Width,
+		entity.Height,
+		entity.Resolutions,
+		entity.WorkflowTemplate,
+		entity.VAE,
+		entity.TextEncoder,
+		entity.Shift,
+		entity.InputImage,
+		entity.DenoiseStrengths,
+		entity.Wildcards,
+		entity.SeedMode,
+		entity.RandomSeedCount,
+		entity.Owner,
+		entity.CreatedAt,
+		entity.UpdatedAt,
+	}
+}
@@ -67,10 +67,10 @@ var _ = Describe("Study Store", func() {
 				UpdatedAt: now,
 			}
 
-			err := s.CreateStudy(original)
+			err := s.CreateStudy(ctx, original)
 			Expect(err).NotTo(HaveOccurred())
 
-			retrieved, err := s.GetStudy("test-id")
+			retrieved, err := s.GetStudy(ctx, "test-id")
 			Expect(err).NotTo(HaveOccurred())
 
 			// Verify all fields match
@@ -107,10 +107,10 @@ var _ = Describe("Study Store", func() {
 				UpdatedAt: now,
 			}
 
-			err := s.CreateStudy(original)
+			err := s.CreateStudy(ctx, original)
 			Expect(err).NotTo(HaveOccurred())
 
-			retrieved, err := s.GetStudy("single-pair")
+			retrieved, err := s.GetStudy(ctx, "single-pair")
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(retrieved.SamplerSchedulerPairs).To(HaveLen(1))
@@ -167,7 +167,7 @@ var _ = Describe("Study Store", func() {
 			defer migratedStore.Close()
 
 			// Read the migrated study (was preset)
-			study, err := migratedStore.GetStudy("migration-test-id")
+			study, err := migratedStore.GetStudy(ctx, "migration-test-id")
 			Expect(err).NotTo(HaveOccurred())
 
 			// Verify cross-product: 2 samplers x 2 schedulers = 4 pairs
@@ -217,52 +217,52 @@ var _ = Describe("Study Store", func() {
 
 		Describe("CreateStudy", func() {
 			It("creates a new study", func() {
-				err := s.CreateStudy(study)
+				err := s.CreateStudy(ctx, study)
 				Expect(err).NotTo(HaveOccurred())
 
-				retrieved, err := s.GetStudy(study.ID)
+				retrieved, err := s.GetStudy(ctx, study.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(retrieved.ID).To(Equal(study.ID))
 				Expect(retrieved.Name).To(Equal(study.Name))
 			})
 
 			It("rejects duplicate ID", func() {
-				err := s.CreateStudy(study)
+				err := s.CreateStudy(ctx, study)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Try to create again with same ID
-				err = s.CreateStudy(study)
+				err = s.CreateStudy(ctx, study)
 				Expect(err).To(HaveOccurred())
 			})
 
 			// AC: Concurrent creation of studies with the same name is prevented at the DB level
 			It("rejects duplicate name via UNIQUE constraint", func() {
-				err := s.CreateStudy(study)
+				err := s.CreateStudy(ctx, study)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Create a second study with a different ID but the same name
 				duplicate := study
 				duplicate.ID = "study-duplicate"
-				err = s.CreateStudy(duplicate)
+				err = s.CreateStudy(ctx, duplicate)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("UNIQUE"))
 			})
 
 			It("allows studies with different names", func() {
-				err := s.CreateStudy(study)
+				err := s.CreateStudy(ctx, study)
 				Expect(err).NotTo(HaveOccurred())
 
 				different := study
 				different.ID = "study-different"
 				different.Name = "Different Study Name"
-				err = s.CreateStudy(different)
+				err = s.CreateStudy(ctx, different)
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
 
 		Describe("ListStudies", func() {
 			It("returns empty slice when no studies exist", func() {
-				result, err := s.ListStudies()
+				result, err := s.ListStudies(ctx)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(0))
 			})
@@ -276,13 +276,13 @@ var _ = Describe("Study Store", func() {
 				study2.ID = "id-2"
 				study2.Name = "Apple"
 
-				err := s.CreateStudy(study1)
+				err := s.CreateStudy(ctx, study1)
 				Expect(err).NotTo(HaveOccurred())
 
-				err = s.CreateStudy(study2)
+				err = s.CreateStudy(ctx, study2)
 				Expect(err).NotTo(HaveOccurred())
 
-				result, err := s.ListStudies()
+				result, err := s.ListStudies(ctx)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(2))
 				// Should be ordered by name: Apple, Zebra
@@ -293,26 +293,26 @@ var _ = Describe("Study Store", func() {
 
 		Describe("GetStudy", func() {
 			BeforeEach(func() {
-				err := s.CreateStudy(study)
+				err := s.CreateStudy(ctx, study)
 				Expect(err).NotTo(HaveOccurred())
 			})
 
 			It("retrieves a study by ID", func() {
-				result, err := s.GetStudy(study.ID)
+				result, err := s.GetStudy(ctx, study.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result.ID).To(Equal(study.ID))
 				Expect(result.Name).To(Equal(study.Name))
 			})
 
 			It("returns sql.ErrNoRows for non-existent ID", func() {
-				_, err := s.GetStudy("nonexistent")
+				_, err := s.GetStudy(ctx, "nonexistent")
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
 		})
 
 		Describe("UpdateStudy", func() {
 			BeforeEach(func() {
-				err := s.CreateStudy(study)
+				err := s.CreateStudy(ctx, study)
 				Expect(err).NotTo(HaveOccurred())
 			})
 
@@ -322,12 +322,12 @@ var _ = Describe("Study Store", func() {
 				other := study
 				other.ID = "study-other"
 				other.Name = "Other Study"
-				err := s.CreateStudy(other)
+				err := s.CreateStudy(ctx, other)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Attempt to rename the second study to match the first
 				other.Name = study.Name
-				err = s.UpdateStudy(other)
+				err = s.UpdateStudy(ctx, other)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("UNIQUE"))
 			})
@@ -342,10 +342,10 @@ var _ = Describe("Study Store", func() {
 				}
 				updated.UpdatedAt = time.Now().UTC()
 
-				err := s.UpdateStudy(updated)
+				err := s.UpdateStudy(ctx, updated)
 				Expect(err).NotTo(HaveOccurred())
 
-				retrieved, err := s.GetStudy(updated.ID)
+				retrieved, err := s.GetStudy(ctx, updated.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(retrieved.Name).To(Equal("Updated Name"))
 				Expect(retrieved.Width).To(Equal(1024))
@@ -360,28 +360,28 @@ var _ = Describe("Study Store", func() {
 			It("returns sql.ErrNoRows for non-existent ID", func() {
 				nonExistent := study
 				nonExistent.ID = "nonexistent"
-				err := s.UpdateStudy(nonExistent)
+				err := s.UpdateStudy(ctx, nonExistent)
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
 		})
 
 		Describe("DeleteStudy", func() {
 			BeforeEach(func() {
-				err := s.CreateStudy(study)
+				err := s.CreateStudy(ctx, study)
 				Expect(err).NotTo(HaveOccurred())
 			})
 
 			It("deletes an existing study", func() {
-				err := s.DeleteStudy(study.ID)
+				err := s.DeleteStudy(ctx, study.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Verify it's gone
-				_, err = s.GetStudy(study.ID)
+				_, err = s.GetStudy(ctx, study.ID)
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
 
 			It("returns sql.ErrNoRows for non-existent ID", func() {
-				err := s.DeleteStudy("nonexistent")
+				err := s.DeleteStudy(ctx, "nonexistent")
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
 
@@ -399,19 +399,19 @@ var _ = Describe("Study Store", func() {
 					CreatedAt:       now,
 					UpdatedAt:       now,
 				}
-				err := s.CreateSampleJob(job)
+				err := s.CreateSampleJob(ctx, job)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Verify the job exists
-				_, err = s.GetSampleJob(job.ID)
+				_, err = s.GetSampleJob(ctx, job.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Delete the study
-				err = s.DeleteStudy(study.ID)
+				err = s.DeleteStudy(ctx, study.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Verify the job is also deleted via cascade
-				_, err = s.GetSampleJob(job.ID)
+				_, err = s.GetSampleJob(ctx, job.ID)
 				Expect(err).To(Equal(sql.ErrNoRows))
 			})
 
@@ -429,7 +429,7 @@ var _ = Describe("Study Store", func() {
 					CreatedAt:       now,
 					UpdatedAt:       now,
 				}
-				err := s.CreateSampleJob(job)
+				err := s.CreateSampleJob(ctx, job)
 				Expect(err).NotTo(HaveOccurred())
 
 				item := model.SampleJobItem{
@@ -450,23 +450,23 @@ var _ = Describe("Study Store", func() {
 					CreatedAt:          now,
 					UpdatedAt:          now,
 				}
-				err = s.CreateSampleJobItem(item)
+				err = s.CreateSampleJobItem(ctx, item)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Verify the item exists
-				items, err := s.ListSampleJobItems(job.ID)
+				items, err := s.ListSampleJobItems(ctx, job.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(1))
 
 				// Delete the study — should cascade to jobs and items
-				err = s.DeleteStudy(study.ID)
+				err = s.DeleteStudy(ctx, study.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				// Verify the job and item are gone
-				_, err = s.GetSampleJob(job.ID)
+				_, err = s.GetSampleJob(ctx, job.ID)
 				Expect(err).To(Equal(sql.ErrNoRows))
 
-				items, err = s.ListSampleJobItems(job.ID)
+				items, err = s.ListSampleJobItems(ctx, job.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(items).To(HaveLen(0))
 			})
@@ -488,7 +488,7 @@ var _ = Describe("Study Store", func() {
 						CreatedAt:       now,
 						UpdatedAt:       now,
 					}
-					err := s.CreateSampleJob(job)
+					err := s.CreateSampleJob(ctx, job)
 					Expect(err).NotTo(HaveOccurred())
 
 					// Create items for each job
@@ -511,27 +511,27 @@ var _ = Describe("Study Store", func() {
 							CreatedAt:          now,
 							UpdatedAt:          now,
 						}
-						err = s.CreateSampleJobItem(item)
+						err = s.CreateSampleJobItem(ctx, item)
 						Expect(err).NotTo(HaveOccurred())
 					}
 				}
 
 				// Verify jobs and items exist
-				jobs, err := s.ListSampleJobs()
+				jobs, err := s.ListSampleJobs(ctx)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(jobs).To(HaveLen(2))
 
 				// Delete the study
-				err = s.DeleteStudy(study.ID)
+				err = s.DeleteStudy(ctx, study.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				// All jobs and items should be gone
-				jobs, err = s.ListSampleJobs()
+				jobs, err = s.ListSampleJobs(ctx)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(jobs).To(HaveLen(0))
 
 				for _, jobID := range []string{"job-a", "job-b"} {
-					items, err := s.ListSampleJobItems(jobID)
+					items, err := s.ListSampleJobItems(ctx, jobID)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(items).To(HaveLen(0))
 				}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// studyVersionEntity is the persistence representation of an archived study
+// version.
+type studyVersionEntity struct {
+	StudyID   string
+	Version   int
+	Study     string // JSON-encoded studyEntity
+	CreatedAt string // RFC3339
+}
+
+// ListStudyVersions returns the archived versions of a study, oldest first.
+// A study that was never edited after a job used it has none.
+func (s *Store) ListStudyVersions(ctx context.Context, studyID string) ([]model.StudyVersion, error) {
+	s.logger.WithField("study_id", studyID).Trace("entering ListStudyVersions")
+	defer s.logger.Trace("returning from ListStudyVersions")
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT study_id, version, study, created_at FROM study_versions WHERE study_id = ? ORDER BY version`, studyID,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"study_id": studyID,
+			"error":    err.Error(),
+		}).Error("failed to query study versions")
+		return nil, fmt.Errorf("querying study versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []model.StudyVersion
+	for rows.Next() {
+		var e studyVersionEntity
+		if err := rows.Scan(&e.StudyID, &e.Version, &e.Study, &e.CreatedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan study version row")
+			return nil, fmt.Errorf("scanning study version row: %w", err)
+		}
+		v, err := studyVersionEntityToModel(e)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating study version rows: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"study_id": studyID,
+		"count":    len(versions),
+	}).Debug("listed study versions from database")
+	return versions, nil
+}
+
+// CreateStudyVersion archives a version of a study. A version is immutable:
+// archiving one that already exists is a no-op, so that an edit retried after
+// a failure does not replace the configuration the version's jobs used.
+func (s *Store) CreateStudyVersion(ctx context.Context, v model.StudyVersion) error {
+	s.logger.WithFields(logrus.Fields{
+		"study_id": v.StudyID,
+		"version":  v.Version,
+	}).Trace("entering CreateStudyVersion")
+	defer s.logger.Trace("returning from CreateStudyVersion")
+
+	e, err := studyVersionModelToEntity(v)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO study_versions (study_id, version, study, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(study_id, version) DO NOTHING`,
+		e.StudyID, e.Version, e.Study, e.CreatedAt,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"study_id": v.StudyID,
+			"version":  v.Version,
+			"error":    err.Error(),
+		}).Error("failed to insert study version")
+		return fmt.Errorf("inserting study version: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"study_id": v.StudyID,
+		"version":  v.Version,
+	}).Info("archived study version")
+	return nil
+}
+
+// StudyVersionInUse reports whether any sample job was created from the
+// given version of a study.
+func (s *Store) StudyVersionInUse(ctx context.Context, studyID string, version int) (bool, error) {
+	s.logger.WithFields(logrus.Fields{
+		"study_id": studyID,
+		"version":  version,
+	}).Trace("entering StudyVersionInUse")
+	defer s.logger.Trace("returning from StudyVersionInUse")
+
+	var inUse bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM sample_jobs WHERE study_id = ? AND study_version = ?)`, studyID, version,
+	).Scan(&inUse)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"study_id": studyID,
+			"version":  version,
+			"error":    err.Error(),
+		}).Error("failed to check study version use")
+		return false, fmt.Errorf("checking study version use: %w", err)
+	}
+	return inUse, nil
+}
+
+func studyVersionEntityToModel(e studyVersionEntity) (model.StudyVersion, error) {
+	var studyE studyEntity
+	if err := json.Unmarshal([]byte(e.Study), &studyE); err != nil {
+		return model.StudyVersion{}, fmt.Errorf("unmarshaling study version: %w", err)
+	}
+	study, err := studyEntityToModel(studyE)
+	if err != nil {
+		return model.StudyVersion{}, fmt.Errorf("converting study version: %w", err)
+	}
+	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
+	if err != nil {
+		return model.StudyVersion{}, fmt.Errorf("parsing created_at: %w", err)
+	}
+	return model.StudyVersion{
+		StudyID:   e.StudyID,
+		Version:   e.Version,
+		Study:     study,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func studyVersionModelToEntity(v model.StudyVersion) (studyVersionEntity, error) {
+	studyE, err := studyModelToEntity(v.Study)
+	if err != nil {
+		return studyVersionEntity{}, fmt.Errorf("converting study version: %w", err)
+	}
+	studyBytes, err := json.Marshal(studyE)
+	if err != nil {
+		return studyVersionEntity{}, fmt.Errorf("marshaling study version: %w", err)
+	}
+	return studyVersionEntity{
+		StudyID:   v.StudyID,
+		Version:   v.Version,
+		Study:     string(studyBytes),
+		CreatedAt: v.CreatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,259 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// trainingRunConfigEntity is the persistence representation of a training run config.
+type trainingRunConfigEntity struct {
+	ID         string
+	Name       string
+	Pattern    string
+	Dimensions string // JSON array of trainingRunDimensionJSON
+	CreatedAt  string // RFC3339
+	UpdatedAt  string // RFC3339
+}
+
+// trainingRunDimensionJSON is the JSON representation of a model.TrainingRunDimensionConfig.
+type trainingRunDimensionJSON struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Pattern string `json:"pattern"`
+}
+
+const trainingRunConfigSelectColumns = `id, name, pattern, dimensions, created_at, updated_at`
+
+// ListTrainingRunConfigs returns all training run configs ordered by name.
+func (s *Store) ListTrainingRunConfigs(ctx context.Context) ([]model.TrainingRunConfig, error) {
+	s.logger.Trace("entering ListTrainingRunConfigs")
+	defer s.logger.Trace("returning from ListTrainingRunConfigs")
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+trainingRunConfigSelectColumns+" FROM training_run_configs ORDER BY name")
+	if err != nil {
+		s.logger.WithError(err).Error("failed to query training run configs")
+		return nil, fmt.Errorf("querying training run configs: %w", err)
+	}
+	defer rows.Close()
+
+	var configs []model.TrainingRunConfig
+	for rows.Next() {
+		var e trainingRunConfigEntity
+		if err := rows.Scan(&e.ID, &e.Name, &e.Pattern, &e.Dimensions, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan training run config row")
+			return nil, fmt.Errorf("scanning training run config row: %w", err)
+		}
+		c, err := trainingRunConfigEntityToModel(e)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to convert entity to model")
+			return nil, err
+		}
+		configs = append(configs, c)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating training run configs")
+		return nil, fmt.Errorf("iterating training run configs: %w", err)
+	}
+	s.logger.WithField("config_count", len(configs)).Debug("listed training run configs from database")
+	return configs, nil
+}
+
+// GetTrainingRunConfig returns a single training run config by ID, or
+// sql.ErrNoRows if not found.
+func (s *Store) GetTrainingRunConfig(ctx context.Context, id string) (model.TrainingRunConfig, error) {
+	s.logger.WithField("config_id", id).Trace("entering GetTrainingRunConfig")
+	defer s.logger.Trace("returning from GetTrainingRunConfig")
+
+	var e trainingRunConfigEntity
+	err := s.db.QueryRowContext(ctx,
+		"SELECT "+trainingRunConfigSelectColumns+" FROM training_run_configs WHERE id = ?", id,
+	).Scan(&e.ID, &e.Name, &e.Pattern, &e.Dimensions, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.WithField("config_id", id).Debug("training run config not found in database")
+		} else {
+			s.logger.WithFields(logrus.Fields{
+				"config_id": id,
+				"error":     err.Error(),
+			}).Error("failed to query training run config")
+		}
+		return model.TrainingRunConfig{}, err
+	}
+	s.logger.WithField("config_id", id).Debug("fetched training run config from database")
+	return trainingRunConfigEntityToModel(e)
+}
+
+// CreateTrainingRunConfig inserts a new training run config.
+func (s *Store) CreateTrainingRunConfig(ctx context.Context, c model.TrainingRunConfig) error {
+	s.logger.WithFields(logrus.Fields{
+		"config_id":   c.ID,
+		"config_name": c.Name,
+	}).Trace("entering CreateTrainingRunConfig")
+	defer s.logger.Trace("returning from CreateTrainingRunConfig")
+
+	dims, err := trainingRunDimensionsToJSON(c.Dimensions)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": c.ID,
+			"error":     err.Error(),
+		}).Error("failed to marshal training run config dimensions")
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		"INSERT INTO training_run_configs ("+trainingRunConfigSelectColumns+") VALUES (?, ?, ?, ?, ?, ?)",
+		c.ID,
+		c.Name,
+		c.Pattern,
+		string(dims),
+		c.CreatedAt.UTC().Format(time.RFC3339),
+		c.UpdatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id":   c.ID,
+			"config_name": c.Name,
+			"error":       err.Error(),
+		}).Error("failed to insert training run config into database")
+		return fmt.Errorf("inserting training run config: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"config_id":   c.ID,
+		"config_name": c.Name,
+	}).Info("inserted training run config into database")
+	return nil
+}
+
+// UpdateTrainingRunConfig updates an existing training run config. Returns
+// sql.ErrNoRows if the config does not exist.
+func (s *Store) UpdateTrainingRunConfig(ctx context.Context, c model.TrainingRunConfig) error {
+	s.logger.WithFields(logrus.Fields{
+		"config_id":   c.ID,
+		"config_name": c.Name,
+	}).Trace("entering UpdateTrainingRunConfig")
+	defer s.logger.Trace("returning from UpdateTrainingRunConfig")
+
+	dims, err := trainingRunDimensionsToJSON(c.Dimensions)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": c.ID,
+			"error":     err.Error(),
+		}).Error("failed to marshal training run config dimensions")
+		return err
+	}
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE training_run_configs SET name = ?, pattern = ?, dimensions = ?, updated_at = ? WHERE id = ?",
+		c.Name,
+		c.Pattern,
+		string(dims),
+		c.UpdatedAt.UTC().Format(time.RFC3339),
+		c.ID,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id":   c.ID,
+			"config_name": c.Name,
+			"error":       err.Error(),
+		}).Error("failed to update training run config in database")
+		return fmt.Errorf("updating training run config: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": c.ID,
+			"error":     err.Error(),
+		}).Error("failed to check rows affected")
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("config_id", c.ID).Debug("no rows affected, training run config not found")
+		return sql.ErrNoRows
+	}
+	s.logger.WithFields(logrus.Fields{
+		"config_id":   c.ID,
+		"config_name": c.Name,
+	}).Info("updated training run config in database")
+	return nil
+}
+
+// DeleteTrainingRunConfig removes a training run config by ID. Returns
+// sql.ErrNoRows if the config does not exist.
+func (s *Store) DeleteTrainingRunConfig(ctx context.Context, id string) error {
+	s.logger.WithField("config_id", id).Trace("entering DeleteTrainingRunConfig")
+	defer s.logger.Trace("returning from DeleteTrainingRunConfig")
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM training_run_configs WHERE id = ?", id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": id,
+			"error":     err.Error(),
+		}).Error("failed to delete training run config from database")
+		return fmt.Errorf("deleting training run config: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": id,
+			"error":     err.Error(),
+		}).Error("failed to check rows affected")
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		s.logger.WithField("config_id", id).Debug("no rows affected, training run config not found")
+		return sql.ErrNoRows
+	}
+	s.logger.WithField("config_id", id).Info("deleted training run config from database")
+	return nil
+}
+
+func trainingRunConfigEntityToModel(e trainingRunConfigEntity) (model.TrainingRunConfig, error) {
+	var dims []trainingRunDimensionJSON
+	if err := json.Unmarshal([]byte(e.Dimensions), &dims); err != nil {
+		return model.TrainingRunConfig{}, fmt.Errorf("unmarshaling training run config dimensions: %w", err)
+	}
+	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
+	if err != nil {
+		return model.TrainingRunConfig{}, fmt.Errorf("parsing created_at: %w", err)
+	}
+	updatedAt, err := time.Parse(time.RFC3339, e.UpdatedAt)
+	if err != nil {
+		return model.TrainingRunConfig{}, fmt.Errorf("parsing updated_at: %w", err)
+	}
+	c := model.TrainingRunConfig{
+		ID:         e.ID,
+		Name:       e.Name,
+		Pattern:    e.Pattern,
+		Dimensions: make([]model.TrainingRunDimensionConfig, len(dims)),
+		CreatedAt:  createdAt,
+		UpdatedAt:  updatedAt,
+	}
+	for i, d := range dims {
+		c.Dimensions[i] = model.TrainingRunDimensionConfig{
+			Name:    d.Name,
+			Type:    model.DimensionType(d.Type),
+			Pattern: d.Pattern,
+		}
+	}
+	return c, nil
+}
+
+func trainingRunDimensionsToJSON(dims []model.TrainingRunDimensionConfig) ([]byte, error) {
+	j := make([]trainingRunDimensionJSON, len(dims))
+	for i, d := range dims {
+		j[i] = trainingRunDimensionJSON{
+			Name:    d.Name,
+			Type:    string(d.Type),
+			Pattern: d.Pattern,
+		}
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling training run config dimensions: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,123 @@
+package store_test
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("TrainingRunConfigStore", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "training-run-config-store-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newConfig := func(id, name string) model.TrainingRunConfig {
+		return model.TrainingRunConfig{
+			ID:      id,
+			Name:    name,
+			Pattern: `^qwen/psai4rt-v0\.3\.0-.+\.safetensors$`,
+			Dimensions: []model.TrainingRunDimensionConfig{
+				{Name: "step", Type: model.DimensionTypeInt, Pattern: `-step(\d+)`},
+			},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	It("returns an empty list when no configs exist", func() {
+		configs, err := st.ListTrainingRunConfigs(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(configs).To(BeEmpty())
+	})
+
+	It("creates and fetches a config", func() {
+		c := newConfig("c-1", "psai4rt v0.3.0")
+		Expect(st.CreateTrainingRunConfig(ctx, c)).To(Succeed())
+
+		got, err := st.GetTrainingRunConfig(ctx, "c-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(c))
+	})
+
+	It("lists configs ordered by name", func() {
+		Expect(st.CreateTrainingRunConfig(ctx, newConfig("c-1", "zeta"))).To(Succeed())
+		Expect(st.CreateTrainingRunConfig(ctx, newConfig("c-2", "alpha"))).To(Succeed())
+
+		configs, err := st.ListTrainingRunConfigs(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(configs).To(HaveLen(2))
+		Expect(configs[0].Name).To(Equal("alpha"))
+		Expect(configs[1].Name).To(Equal("zeta"))
+	})
+
+	It("rejects duplicate names", func() {
+		Expect(st.CreateTrainingRunConfig(ctx, newConfig("c-1", "same"))).To(Succeed())
+		Expect(st.CreateTrainingRunConfig(ctx, newConfig("c-2", "same"))).NotTo(Succeed())
+	})
+
+	It("updates a config", func() {
+		Expect(st.CreateTrainingRunConfig(ctx, newConfig("c-1", "before"))).To(Succeed())
+		c := newConfig("c-1", "after")
+		c.Pattern = `^after/`
+		c.Dimensions = nil
+		c.UpdatedAt = now.Add(time.Hour)
+		Expect(st.UpdateTrainingRunConfig(ctx, c)).To(Succeed())
+
+		got, err := st.GetTrainingRunConfig(ctx, "c-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got.Name).To(Equal("after"))
+		Expect(got.Pattern).To(Equal(`^after/`))
+		Expect(got.Dimensions).To(BeEmpty())
+		Expect(got.CreatedAt).To(Equal(now))
+		Expect(got.UpdatedAt).To(Equal(now.Add(time.Hour)))
+	})
+
+	It("deletes a config", func() {
+		Expect(st.CreateTrainingRunConfig(ctx, newConfig("c-1", "gone"))).To(Succeed())
+		Expect(st.DeleteTrainingRunConfig(ctx, "c-1")).To(Succeed())
+		_, err := st.GetTrainingRunConfig(ctx, "c-1")
+		Expect(err).To(Equal(sql.ErrNoRows))
+	})
+
+	DescribeTable("returns sql.ErrNoRows for a missing config",
+		func(op func() error) {
+			Expect(op()).To(Equal(sql.ErrNoRows))
+		},
+		Entry("on get", func() error { _, err := st.GetTrainingRunConfig(ctx, "missing"); return err }),
+		Entry("on update", func() error { return st.UpdateTrainingRunConfig(ctx, newConfig("missing", "x")) }),
+		Entry("on delete", func() error { return st.DeleteTrainingRunConfig(ctx, "missing") }),
+	)
+})
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// voteEntity is the persistence representation of a vote.
+type voteEntity struct {
+	ID               string
+	TrainingRunName  string
+	PromptName       string
+	WinnerItemID     string
+	LoserItemID      string
+	WinnerCheckpoint string
+	LoserCheckpoint  string
+	CreatedAt        string // RFC3339
+}
+
+// ListVoteCandidates returns the completed sample job items of a training
+// run that have an output image, ordered by creation.
+func (s *Store) ListVoteCandidates(ctx context.Context, trainingRunName string) ([]model.VoteCandidate, error) {
+	s.logger.WithField("training_run", trainingRunName).Trace("entering ListVoteCandidates")
+	defer s.logger.Trace("returning from ListVoteCandidates")
+
+	rows, err := s.db.QueryContext(ctx, `SELECT i.id, i.checkpoint_filename, i.prompt_name, i.steps, i.cfg, i.sampler_name, i.scheduler, i.seed, i.width, i.height, i.output_path
+		FROM sample_job_items i JOIN sample_jobs j ON j.id = i.job_id
+		WHERE j.training_run_name = ? AND i.status = ? AND i.output_path IS NOT NULL AND i.output_path != ''
+		ORDER BY i.created_at, i.rowid`,
+		trainingRunName, string(model.SampleJobItemStatusCompleted),
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"training_run": trainingRunName,
+			"error":        err.Error(),
+		}).Error("failed to query vote candidates")
+		return nil, fmt.Errorf("querying vote candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []model.VoteCandidate
+	for rows.Next() {
+		var c model.VoteCandidate
+		if err := rows.Scan(&c.ItemID, &c.CheckpointFilename, &c.PromptName, &c.Steps, &c.CFG, &c.SamplerName, &c.Scheduler, &c.Seed, &c.Width, &c.Height, &c.OutputPath); err != nil {
+			s.logger.WithError(err).Error("failed to scan vote candidate row")
+			return nil, fmt.Errorf("scanning vote candidate row: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating vote candidates")
+		return nil, fmt.Errorf("iterating vote candidates: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"training_run":    trainingRunName,
+		"candidate_count": len(candidates),
+	}).Debug("listed vote candidates from database")
+	return candidates, nil
+}
+
+// CreateVote inserts a vote.
+func (s *Store) CreateVote(ctx context.Context, v model.Vote) error {
+	s.logger.WithFields(logrus.Fields{
+		"vote_id":      v.ID,
+		"training_run": v.TrainingRunName,
+	}).Trace("entering CreateVote")
+	defer s.logger.Trace("returning from CreateVote")
+
+	e := voteModelToEntity(v)
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO votes (id, training_run_name, prompt_name, winner_item_id, loser_item_id, winner_checkpoint, loser_checkpoint, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ID, e.TrainingRunName, e.PromptName, e.WinnerItemID, e.LoserItemID, e.WinnerCheckpoint, e.LoserCheckpoint, e.CreatedAt,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"vote_id": v.ID,
+			"error":   err.Error(),
+		}).Error("failed to insert vote into database")
+		return fmt.Errorf("inserting vote: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"vote_id":      v.ID,
+		"training_run": v.TrainingRunName,
+	}).Debug("inserted vote into database")
+	return nil
+}
+
+// ListVotes returns the votes of a training run in the order they were cast.
+func (s *Store) ListVotes(ctx context.Context, trainingRunName string) ([]model.Vote, error) {
+	s.logger.WithField("training_run", trainingRunName).Trace("entering ListVotes")
+	defer s.logger.Trace("returning from ListVotes")
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, training_run_name, prompt_name, winner_item_id, loser_item_id, winner_checkpoint, loser_checkpoint, created_at
+		FROM votes WHERE training_run_name = ? ORDER BY rowid`,
+		trainingRunName,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"training_run": trainingRunName,
+			"error":        err.Error(),
+		}).Error("failed to query votes")
+		return nil, fmt.Errorf("querying votes: %w", err)
+	}
+	defer rows.Close()
+
+	var votes []model.Vote
+	for rows.Next() {
+		var e voteEntity
+		if err := rows.Scan(&e.ID, &e.TrainingRunName, &e.PromptName, &e.WinnerItemID, &e.LoserItemID, &e.WinnerCheckpoint, &e.LoserCheckpoint, &e.CreatedAt); err != nil {
+			s.logger.WithError(err).Error("failed to scan vote row")
+			return nil, fmt.Errorf("scanning vote row: %w", err)
+		}
+		v, err := voteEntityToModel(e)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to convert entity to model")
+			return nil, err
+		}
+		votes = append(votes, v)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.WithError(err).Error("error iterating votes")
+		return nil, fmt.Errorf("iterating votes: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"training_run": trainingRunName,
+		"vote_count":   len(votes),
+	}).Debug("listed votes from database")
+	return votes, nil
+}
+
+func voteEntityToModel(e voteEntity) (model.Vote, error) {
+	createdAt, err := time.Parse(time.RFC3339, e.CreatedAt)
+	if err != nil {
+		return model.Vote{}, fmt.Errorf("parsing created_at: %w", err)
+	}
+	return model.Vote{
+		ID:               e.ID,
+		TrainingRunName:  e.TrainingRunName,
+		PromptName:       e.PromptName,
+		WinnerItemID:     e.WinnerItemID,
+		LoserItemID:      e.LoserItemID,
+		WinnerCheckpoint: e.WinnerCheckpoint,
+		LoserCheckpoint:  e.LoserCheckpoint,
+		CreatedAt:        createdAt,
+	}, nil
+}
+
+func voteModelToEntity(v model.Vote) voteEntity {
+	return voteEntity{
+		ID:               v.ID,
+		TrainingRunName:  v.TrainingRunName,
+		PromptName:       v.PromptName,
+		WinnerItemID:     v.WinnerItemID,
+		LoserItemID:      v.LoserItemID,
+		WinnerCheckpoint: v.WinnerCheckpoint,
+		LoserCheckpoint:  v.LoserCheckpoint,
+		CreatedAt:        v.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,116 @@
+package store_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/model"
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("Votes", func() {
+	var (
+		st     *store.Store
+		tmpDir string
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "vote-test-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err := store.OpenDB(filepath.Join(tmpDir, "test.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		st, err = store.New(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+		now = time.Now().UTC().Truncate(time.Second)
+		Expect(st.CreateStudy(ctx, model.Study{ID: "s1", Name: "Study", CreatedAt: now, UpdatedAt: now})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		if st != nil {
+			st.Close()
+		}
+		os.RemoveAll(tmpDir)
+	})
+
+	createJob := func(jobID, trainingRun string, items ...model.SampleJobItem) {
+		for i := range items {
+			items[i].JobID = jobID
+			items[i].CreatedAt = now
+			items[i].UpdatedAt = now
+		}
+		Expect(st.CreateSampleJobWithItems(ctx, model.SampleJob{
+			ID:              jobID,
+			TrainingRunName: trainingRun,
+			StudyID:         "s1",
+			Status:          model.SampleJobStatusRunning,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}, items)).To(Succeed())
+	}
+
+	Describe("ListVoteCandidates", func() {
+		It("returns the completed images of the training run", func() {
+			createJob("j1", "run-a",
+				model.SampleJobItem{ID: "i1", CheckpointFilename: "a.safetensors", PromptName: "forest", Steps: 20, CFG: 7, SamplerName: "euler", Scheduler: "simple", Seed: 42, Width: 512, Height: 512, Status: model.SampleJobItemStatusCompleted, OutputPath: "/samples/a/1.png"},
+				model.SampleJobItem{ID: "i2", CheckpointFilename: "b.safetensors", Status: model.SampleJobItemStatusPending},
+				model.SampleJobItem{ID: "i3", CheckpointFilename: "b.safetensors", Status: model.SampleJobItemStatusFailed, OutputPath: ""},
+			)
+			createJob("j2", "run-b",
+				model.SampleJobItem{ID: "i4", CheckpointFilename: "c.safetensors", Status: model.SampleJobItemStatusCompleted, OutputPath: "/samples/c/1.png"},
+			)
+
+			candidates, err := st.ListVoteCandidates(ctx, "run-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(candidates).To(Equal([]model.VoteCandidate{{
+				ItemID:             "i1",
+				CheckpointFilename: "a.safetensors",
+				PromptName:         "forest",
+				Steps:              20,
+				CFG:                7,
+				SamplerName:        "euler",
+				Scheduler:          "simple",
+				Seed:               42,
+				Width:              512,
+				Height:             512,
+				OutputPath:         "/samples/a/1.png",
+			}}))
+		})
+	})
+
+	Describe("CreateVote and ListVotes", func() {
+		It("returns the votes of a training run in the order they were cast", func() {
+			later := now.Add(-time.Hour)
+			Expect(st.CreateVote(ctx, model.Vote{ID: "v1", TrainingRunName: "run-a", PromptName: "forest", WinnerItemID: "i1", LoserItemID: "i2", WinnerCheckpoint: "a.safetensors", LoserCheckpoint: "b.safetensors", CreatedAt: now})).To(Succeed())
+			Expect(st.CreateVote(ctx, model.Vote{ID: "v2", TrainingRunName: "run-b", PromptName: "forest", CreatedAt: now})).To(Succeed())
+			Expect(st.CreateVote(ctx, model.Vote{ID: "v3", TrainingRunName: "run-a", PromptName: "lake", CreatedAt: later})).To(Succeed())
+
+			votes, err := st.ListVotes(ctx, "run-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(votes).To(HaveLen(2))
+			Expect(votes[0]).To(Equal(model.Vote{ID: "v1", TrainingRunName: "run-a", PromptName: "forest", WinnerItemID: "i1", LoserItemID: "i2", WinnerCheckpoint: "a.safetensors", LoserCheckpoint: "b.safetensors", CreatedAt: now}))
+			Expect(votes[1].ID).To(Equal("v3"))
+		})
+
+		It("keeps votes when the voted sample job is deleted", func() {
+			createJob("j1", "run-a", model.SampleJobItem{ID: "i1", Status: model.SampleJobItemStatusCompleted})
+			Expect(st.CreateVote(ctx, model.Vote{ID: "v1", TrainingRunName: "run-a", WinnerItemID: "i1", LoserItemID: "i2", CreatedAt: now})).To(Succeed())
+			Expect(st.DeleteSampleJob(ctx, "j1")).To(Succeed())
+
+			votes, err := st.ListVotes(ctx, "run-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(votes).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WebhookClient posts JSON payloads to webhook URLs.
+type WebhookClient struct {
+	client *http.Client
+}
+
+// NewWebhookClient creates a WebhookClient.
+func NewWebhookClient() *WebhookClient {
+	return &WebhookClient{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Post sends payload to rawURL with POST as application/json. Any 2xx status
+// is a success. Errors name only the host, since webhook URLs usually embed a
+// secret token.
+func (c *WebhookClient) Post(ctx context.Context, rawURL string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(payload))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("webhook request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook request to %s failed with status %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
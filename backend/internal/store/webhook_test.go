@@ -0,0 +1,51 @@
+package store_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/store"
+)
+
+var _ = Describe("WebhookClient", func() {
+	It("posts the payload as JSON", func() {
+		var method, contentType, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, contentType = r.Method, r.Header.Get("Content-Type")
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		Expect(store.NewWebhookClient().Post(context.Background(), server.URL+"/hook", []byte(`{"event":"completed"}`))).To(Succeed())
+		Expect(method).To(Equal(http.MethodPost))
+		Expect(contentType).To(Equal("application/json"))
+		Expect(body).To(Equal(`{"event":"completed"}`))
+	})
+
+	It("returns an error naming only the host for a non-2xx status", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		err := store.NewWebhookClient().Post(context.Background(), server.URL+"/api/webhooks/123/secret-token", []byte(`{}`))
+		Expect(err).To(MatchError(ContainSubstring("status 400")))
+		Expect(err.Error()).NotTo(ContainSubstring("secret-token"))
+	})
+
+	It("returns an error naming only the host when the request fails", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Close()
+
+		err := store.NewWebhookClient().Post(context.Background(), server.URL+"/hook/secret-token", []byte(`{}`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).NotTo(ContainSubstring("secret-token"))
+	})
+})
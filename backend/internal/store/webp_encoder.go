@@ -0,0 +1,81 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CWebPBinary is the name of the libwebp command-line encoder looked up on PATH.
+const CWebPBinary = "cwebp"
+
+// CWebPEncoder encodes images to WebP by running the cwebp command-line tool.
+// The Go standard library has no WebP encoder.
+type CWebPEncoder struct {
+	path   string
+	logger *logrus.Entry
+}
+
+// NewCWebPEncoder creates a CWebPEncoder that runs the cwebp binary at path.
+func NewCWebPEncoder(path string, logger *logrus.Logger) *CWebPEncoder {
+	return &CWebPEncoder{
+		path:   path,
+		logger: logger.WithField("component", "cwebp_encoder"),
+	}
+}
+
+// FindCWebPEncoder looks up cwebp on PATH and returns an encoder for it, or an
+// error if it is not installed.
+func FindCWebPEncoder(logger *logrus.Logger) (*CWebPEncoder, error) {
+	path, err := exec.LookPath(CWebPBinary)
+	if err != nil {
+		return nil, fmt.Errorf("looking up %s: %w", CWebPBinary, err)
+	}
+	return NewCWebPEncoder(path, logger), nil
+}
+
+// EncodeWebP encodes the PNG image data as lossy WebP at the given quality
+// (1-100). The input and output are staged in a temporary directory that is
+// removed before returning.
+func (e *CWebPEncoder) EncodeWebP(pngData []byte, quality int) ([]byte, error) {
+	e.logger.WithField("quality", quality).Trace("entering EncodeWebP")
+	defer e.logger.Trace("returning from EncodeWebP")
+
+	dir, err := os.MkdirTemp("", "cwebp-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	inPath := filepath.Join(dir, "in.png")
+	outPath := filepath.Join(dir, "out.webp")
+	if err := os.WriteFile(inPath, pngData, 0600); err != nil {
+		return nil, fmt.Errorf("writing cwebp input: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(e.path, "-quiet", "-q", strconv.Itoa(quality), inPath, "-o", outPath)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"error":  err.Error(),
+			"stderr": stderr.String(),
+		}).Error("cwebp failed")
+		return nil, fmt.Errorf("running %s: %w", CWebPBinary, err)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading cwebp output: %w", err)
+	}
+	e.logger.WithFields(logrus.Fields{
+		"input_bytes":  len(pngData),
+		"output_bytes": len(data),
+	}).Debug("encoded WebP image")
+	return data, nil
+}
@@ -0,0 +1,156 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kmacmcfarlane/checkpoint-sampler/backend/pkg/client"
+)
+
+// jobDetail returns a sample job with its progress as GET
+// /api/sample-jobs/{id} serves it.
+func jobDetail(status string, completed, total int) map[string]any {
+	return map[string]any{
+		"job": map[string]any{
+			"id": "j1", "training_run_name": "my-lora", "study_id": "s1", "study_name": "Sweep", "study_version": 1,
+			"workflow_name": "qwen-image.json", "input_image": "", "status": status,
+			"total_items": total, "completed_items": completed, "failed_items": 0, "pending_items": total - completed,
+			"checkpoint_filenames": []string{}, "append_new_checkpoints": false, "output_format": "png",
+			"created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-01T00:00:00Z",
+		},
+		"progress": map[string]any{"checkpoints_completed": 0, "total_checkpoints": 2},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	Expect(json.NewEncoder(w).Encode(v)).To(Succeed())
+}
+
+var _ = Describe("Client", func() {
+	var (
+		mux *http.ServeMux
+		c   *client.Client
+	)
+
+	BeforeEach(func() {
+		mux = http.NewServeMux()
+		server := httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+		var err error
+		c, err = client.New(client.Config{ServerURL: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects server URLs that are not HTTP", func() {
+		_, err := client.New(client.Config{ServerURL: "localhost:8080"})
+		Expect(err).To(MatchError(ContainSubstring("must be http:// or https://")))
+	})
+
+	It("calls the API with the generated clients", func() {
+		mux.HandleFunc("GET /api/sample-jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.PathValue("id")).To(Equal("j1"))
+			writeJSON(w, jobDetail("running", 1, 4))
+		})
+
+		detail, err := c.SampleJobs.Show(context.Background(), &client.ShowSampleJobPayload{ID: "j1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(detail.Job.Status).To(Equal("running"))
+		Expect(detail.Progress.TotalCheckpoints).To(Equal(2))
+	})
+
+	It("sends the token as a bearer token", func() {
+		server := httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+		mux.HandleFunc("GET /api/studies", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer alice-token-0123456789"))
+			writeJSON(w, []any{})
+		})
+
+		tc, err := client.New(client.Config{ServerURL: server.URL, Token: "alice-token-0123456789"})
+		Expect(err).NotTo(HaveOccurred())
+		studies, err := tc.Studies.List(context.Background(), &client.ListStudiesPayload{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(studies).To(BeEmpty())
+	})
+
+	Describe("WaitForJob", func() {
+		It("polls the job until it finishes", func() {
+			var polls atomic.Int32
+			mux.HandleFunc("GET /api/sample-jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+				if polls.Add(1) < 3 {
+					writeJSON(w, jobDetail("running", 1, 4))
+					return
+				}
+				writeJSON(w, jobDetail("completed_with_errors", 4, 4))
+			})
+
+			detail, err := c.WaitForJob(context.Background(), "j1", time.Millisecond)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(detail.Job.Status).To(Equal("completed_with_errors"))
+			Expect(polls.Load()).To(BeEquivalentTo(3))
+		})
+
+		It("stops when the context is done", func() {
+			mux.HandleFunc("GET /api/sample-jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, jobDetail("running", 1, 4))
+			})
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			_, err := c.WaitForJob(ctx, "j1", time.Millisecond)
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+	})
+
+	Describe("FollowJob", func() {
+		It("reports the job's progress, then each progress event, until the job finishes", func() {
+			mux.HandleFunc("GET /api/sample-jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, jobDetail("running", 1, 4))
+			})
+			upgrader := websocket.Upgrader{}
+			mux.HandleFunc("GET /api/ws/v2", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query()["job_id"]).To(Equal([]string{"j1"}))
+				conn, err := upgrader.Upgrade(w, r, nil)
+				Expect(err).NotTo(HaveOccurred())
+				defer conn.Close()
+				for _, msg := range []map[string]any{
+					{"version": 2, "type": "connected"},
+					{"version": 2, "type": "job_progress", "job_id": "j1", "payload": map[string]any{
+						"type": "job_progress", "path": "", "status": "running", "completed_items": 3, "job_eta_seconds": 90.4,
+					}},
+					{"version": 2, "type": "inference_progress", "job_id": "j1", "payload": map[string]any{
+						"type": "inference_progress", "path": "", "current_value": 12, "max_value": 30,
+					}},
+					{"version": 2, "type": "job_progress", "job_id": "j1", "payload": map[string]any{
+						"type": "job_progress", "path": "", "status": "completed", "completed_items": 4, "checkpoints_completed": 2,
+					}},
+				} {
+					Expect(conn.WriteJSON(msg)).To(Succeed())
+				}
+				// Wait for the client to hang up.
+				conn.ReadMessage() //nolint:errcheck
+			})
+
+			var reported []client.JobProgress
+			final, err := c.FollowJob(context.Background(), "j1", func(p client.JobProgress) {
+				reported = append(reported, p)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reported).To(Equal([]client.JobProgress{
+				{Status: "running", CompletedItems: 1, TotalItems: 4, TotalCheckpoints: 2},
+				{Status: "running", CompletedItems: 3, TotalItems: 4, TotalCheckpoints: 2, ETA: 90 * time.Second},
+				{Status: "completed", CompletedItems: 4, TotalItems: 4, CheckpointsCompleted: 2, TotalCheckpoints: 2},
+			}))
+			Expect(final.Finished()).To(BeTrue())
+		})
+	})
+})
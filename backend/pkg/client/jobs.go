@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// JobProgress is the progress of a sample job.
+type JobProgress struct {
+	Status               string
+	CompletedItems       int
+	FailedItems          int
+	TotalItems           int
+	CheckpointsCompleted int
+	TotalCheckpoints     int
+	ETA                  time.Duration // 0 if unknown
+}
+
+// Finished reports whether the job will make no more progress unless it is
+// resumed.
+func (p JobProgress) Finished() bool {
+	return IsJobFinished(p.Status)
+}
+
+// IsJobFinished reports whether a sample job with the given status will make
+// no more progress unless it is resumed.
+func IsJobFinished(status string) bool {
+	switch status {
+	case "completed", "completed_with_errors", "failed", "stopped":
+		return true
+	}
+	return false
+}
+
+// WaitForJob polls a sample job every interval until it finishes, and
+// returns it.
+func (c *Client) WaitForJob(ctx context.Context, id string, interval time.Duration) (*SampleJobDetail, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		detail, err := c.SampleJobs.Show(ctx, &ShowSampleJobPayload{ID: id})
+		if err != nil {
+			return nil, err
+		}
+		if IsJobFinished(detail.Job.Status) {
+			return detail, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// FollowJob calls fn with the progress of a sample job, then again each time
+// the server reports progress over the WebSocket, until the job finishes. It
+// returns the final progress. Unlike WaitForJob, it does not poll.
+func (c *Client) FollowJob(ctx context.Context, id string, fn func(JobProgress)) (JobProgress, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Subscribe before reading the job so that no event is missed between the
+	// two.
+	stream, err := c.WS.SubscribeV2(ctx, &SubscribePayload{JobID: []string{id}})
+	if err != nil {
+		return JobProgress{}, fmt.Errorf("subscribing to job events: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		stream.Close() //nolint:errcheck
+	}()
+
+	detail, err := c.SampleJobs.Show(ctx, &ShowSampleJobPayload{ID: id})
+	if err != nil {
+		return JobProgress{}, err
+	}
+	p := progressFromDetail(detail)
+	fn(p)
+
+	for !p.Finished() {
+		msg, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return p, ctx.Err()
+			}
+			return p, fmt.Errorf("receiving job events: %w", err)
+		}
+		if msg.Type != "job_progress" || msg.Payload == nil {
+			continue
+		}
+		p = progressFromEvent(p, msg.Payload)
+		fn(p)
+	}
+	return p, nil
+}
+
+func progressFromDetail(detail *SampleJobDetail) JobProgress {
+	p := JobProgress{
+		Status:               detail.Job.Status,
+		CompletedItems:       detail.Job.CompletedItems,
+		FailedItems:          detail.Job.FailedItems,
+		TotalItems:           detail.Job.TotalItems,
+		CheckpointsCompleted: detail.Progress.CheckpointsCompleted,
+		TotalCheckpoints:     detail.Progress.TotalCheckpoints,
+	}
+	if t := detail.Progress.EstimatedCompletionTime; t != nil {
+		if done, err := time.Parse(time.RFC3339, *t); err == nil && time.Until(done) > 0 {
+			p.ETA = time.Until(done).Round(time.Second)
+		}
+	}
+	return p
+}
+
+// progressFromEvent returns p updated with the fields present in a
+// job_progress event.
+func progressFromEvent(p JobProgress, ev *WSEvent) JobProgress {
+	if ev.Status != nil {
+		p.Status = *ev.Status
+	}
+	if ev.CompletedItems != nil {
+		p.CompletedItems = *ev.CompletedItems
+	}
+	if ev.FailedItems != nil {
+		p.FailedItems = *ev.FailedItems
+	}
+	if ev.TotalItems != nil {
+		p.TotalItems = *ev.TotalItems
+	}
+	if ev.CheckpointsCompleted != nil {
+		p.CheckpointsCompleted = *ev.CheckpointsCompleted
+	}
+	if ev.TotalCheckpoints != nil {
+		p.TotalCheckpoints = *ev.TotalCheckpoints
+	}
+	p.ETA = 0
+	if ev.JobEtaSeconds != nil {
+		p.ETA = time.Duration(*ev.JobEtaSeconds * float64(time.Second)).Round(time.Second)
+	}
+	return p
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	genhealth "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/health"
+	genimages "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/images"
+	gensamplejobs "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/sample_jobs"
+	genstudies "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/studies"
+	gentrainingruns "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/training_runs"
+	genws "github.com/kmacmcfarlane/checkpoint-sampler/backend/internal/api/gen/ws"
+)
+
+// The generated types live in internal packages, which other modules cannot
+// import. These aliases let them name the types the service clients take and
+// return.
+
+// Training runs.
+type (
+	TrainingRun               = gentrainingruns.TrainingRunResponse
+	Checkpoint                = gentrainingruns.CheckpointResponse
+	ListTrainingRunsPayload   = gentrainingruns.ListPayload
+	TrainingRunSummary        = gentrainingruns.TrainingRunSummaryResponse
+	CheckpointSummary         = gentrainingruns.CheckpointSummaryResponse
+	TrainingRunSummaryPayload = gentrainingruns.SummaryPayload
+)
+
+// Studies.
+type (
+	Study                = genstudies.StudyResponse
+	ListStudiesPayload   = genstudies.ListPayload
+	CreateStudyPayload   = genstudies.CreateStudyPayload
+	UpdateStudyPayload   = genstudies.UpdateStudyPayload
+	NamedPrompt          = genstudies.NamedPrompt
+	SamplerSchedulerPair = genstudies.SamplerSchedulerPair
+	Wildcard             = genstudies.Wildcard
+	Resolution           = genstudies.Resolution
+	ExportStudiesPayload = genstudies.ExportPayload
+	StudyExportDocument  = genstudies.StudyExportDocument
+	ImportStudiesPayload = genstudies.ImportStudiesPayload
+	StudyImportResult    = genstudies.StudyImportResult
+)
+
+// Sample jobs.
+type (
+	SampleJob                   = gensamplejobs.SampleJobResponse
+	SampleJobDetail             = gensamplejobs.SampleJobDetailResponse
+	SampleJobProgress           = gensamplejobs.JobProgressResponse
+	SampleJobItem               = gensamplejobs.SampleJobItemResponse
+	SampleJobPreview            = gensamplejobs.SampleJobPreviewResponse
+	CreateSampleJobPayload      = gensamplejobs.CreateSampleJobPayload
+	WorkflowOverride            = gensamplejobs.WorkflowOverride
+	ListSampleJobsPayload       = gensamplejobs.ListPayload
+	SampleJobList               = gensamplejobs.ListResult
+	ListSampleJobItemsPayload   = gensamplejobs.ListItemsPayload
+	SampleJobItemList           = gensamplejobs.ListItemsResult
+	ShowSampleJobPayload        = gensamplejobs.ShowPayload
+	StartSampleJobPayload       = gensamplejobs.StartPayload
+	StopSampleJobPayload        = gensamplejobs.StopPayload
+	ResumeSampleJobPayload      = gensamplejobs.ResumePayload
+	RetryFailedSampleJobPayload = gensamplejobs.RetryFailedPayload
+	AnnotateSampleJobPayload    = gensamplejobs.AnnotatePayload
+	DeleteSampleJobPayload      = gensamplejobs.DeletePayload
+	ListTrashPayload            = gensamplejobs.ListTrashPayload
+	TrashList                   = gensamplejobs.ListTrashResult
+	RestoreSampleJobPayload     = gensamplejobs.RestorePayload
+	PurgeSampleJobPayload       = gensamplejobs.PurgePayload
+	EmptyTrashResult            = gensamplejobs.EmptyTrashResult
+)
+
+// Images.
+type (
+	DownloadImagePayload = genimages.DownloadPayload
+	ImageFileResult      = genimages.ImageFileResult
+	ImageDownloadResult  = genimages.ImageDownloadResult
+)
+
+// WebSocket events (protocol version 2).
+type (
+	SubscribePayload = genws.SubscribeV2Payload
+	WSMessage        = genws.WSMessage
+	WSEvent          = genws.FSEventResponse
+	WSSubscription   = genws.WSSubscription
+)
+
+// Health.
+type HealthResult = genhealth.HealthResult
@@ -341,6 +341,95 @@ describe('ApiClient', () => {
     })
   })
 
+  describe('uploadWorkflow', () => {
+    it('posts the workflow to /api/workflows', async () => {
+      const client = new ApiClient({ baseUrl: 'http://localhost:8080/api' })
+      const workflow = { '9': { class_type: 'SaveImage', _meta: { cs_role: 'save_image' } } }
+      const saved = { name: 'flux.json', validation_state: 'valid', roles: { save_image: ['9'] }, warnings: [], workflow }
+      mockFetch({ json: () => Promise.resolve(saved) })
+
+      const result = await client.uploadWorkflow('flux', workflow)
+
+      expect(globalThis.fetch).toHaveBeenCalledWith(
+        'http://localhost:8080/api/workflows',
+        expect.objectContaining({
+          method: 'POST',
+          body: JSON.stringify({ name: 'flux', workflow, overwrite: false }),
+        }),
+      )
+      expect(result).toEqual(saved)
+    })
+  })
+
+  describe('previewWorkflow', () => {
+    it('posts the sample values to /api/workflows/{name}/preview', async () => {
+      const client = new ApiClient({ baseUrl: 'http://localhost:8080/api' })
+      const preview = { name: 'flux.json', workflow: {}, substitutions: [{ node_id: '3', role: 'sampler', input: 'seed', previous: 0, value: 42 }] }
+      mockFetch({ json: () => Promise.resolve(preview) })
+
+      const result = await client.previewWorkflow('flux.json', { checkpoint: 'flux/lora.safetensors', prompt: 'a cat', seed: 42 })
+
+      expect(globalThis.fetch).toHaveBeenCalledWith(
+        'http://localhost:8080/api/workflows/flux.json/preview',
+        expect.objectContaining({
+          method: 'POST',
+          body: JSON.stringify({ checkpoint: 'flux/lora.safetensors', prompt: 'a cat', seed: 42 }),
+        }),
+      )
+      expect(result).toEqual(preview)
+    })
+  })
+
+  describe('renameWorkflow', () => {
+    it('puts the new name to /api/workflows/{name}', async () => {
+      const client = new ApiClient({ baseUrl: 'http://localhost:8080/api' })
+      mockFetch({ json: () => Promise.resolve({ name: 'new.json' }) })
+
+      await client.renameWorkflow('old.json', 'new.json')
+
+      expect(globalThis.fetch).toHaveBeenCalledWith(
+        'http://localhost:8080/api/workflows/old.json',
+        expect.objectContaining({
+          method: 'PUT',
+          body: JSON.stringify({ new_name: 'new.json' }),
+        }),
+      )
+    })
+  })
+
+  describe('deleteWorkflow', () => {
+    it('sends DELETE to /api/workflows/{name}', async () => {
+      const client = new ApiClient({ baseUrl: 'http://localhost:8080/api' })
+      mockFetch({ ok: true, status: 204, json: () => Promise.resolve(undefined) })
+
+      await client.deleteWorkflow('flux.json')
+
+      expect(globalThis.fetch).toHaveBeenCalledWith(
+        'http://localhost:8080/api/workflows/flux.json',
+        { method: 'DELETE' },
+      )
+    })
+
+    it('throws the conflict when studies use the workflow', async () => {
+      const client = new ApiClient()
+      mockFetch({
+        ok: false,
+        status: 409,
+        json: () => Promise.resolve({ name: 'conflict', message: 'workflow is used by studies: flux.json is used by Sweep', id: 'req4', temporary: false, timeout: false, fault: false }),
+      })
+
+      let thrown: ApiError | undefined
+      try {
+        await client.deleteWorkflow('flux.json')
+      } catch (err) {
+        thrown = err as ApiError
+      }
+
+      expect(thrown).toBeDefined()
+      expect(thrown!.code).toBe('conflict')
+    })
+  })
+
   describe('getCheckpointMetadata', () => {
     it('fetches metadata from /api/checkpoints/{filename}/metadata', async () => {
       const client = new ApiClient({ baseUrl: 'http://localhost:8080/api' })
@@ -1,4 +1,4 @@
-import type { AffectedRun, ApiError, ApiErrorResponse, CheckpointMetadata, ComfyUIModelType, ComfyUIModels, ComfyUIStatus, CreateSampleJobPayload, CreateStudyPayload, DemoStatus, ForkStudyPayload, HasSamplesResponse, HealthStatus, ImageMetadata, Preset, PresetMapping, SampleJob, SampleJobDetail, Study, StudyAvailability, ScanResult, TrainingRun, UpdateStudyPayload, ValidationResult, WorkflowSummary } from './types'
+import type { AffectedRun, ApiError, ApiErrorResponse, CheckpointMetadata, ComfyUIModelType, ComfyUIModels, ComfyUIStatus, CreateSampleJobPayload, CreateStudyPayload, DemoStatus, ForkStudyPayload, HasSamplesResponse, HealthStatus, ImageMetadata, Preset, PresetMapping, SampleJob, SampleJobDetail, Study, StudyAvailability, ScanResult, TrainingRun, UpdateStudyPayload, ValidationResult, WorkflowDetail, WorkflowPreview, WorkflowPreviewPayload, WorkflowSummary } from './types'
 
 const DEFAULT_BASE_URL = '/api'
 
@@ -242,6 +242,48 @@ export class ApiClient {
     return this.request<WorkflowSummary[]>('/workflows')
   }
 
+  /** POST /api/workflows — upload an API-format workflow; rejected unless it has a save_image node. */
+  async uploadWorkflow(name: string, workflow: Record<string, unknown>, overwrite: boolean = false): Promise<WorkflowDetail> {
+    return this.request<WorkflowDetail>('/workflows', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ name, workflow, overwrite }),
+    })
+  }
+
+  /** POST /api/workflows/{name}/preview — substitute sample values into a workflow without submitting it. */
+  async previewWorkflow(name: string, payload: WorkflowPreviewPayload): Promise<WorkflowPreview> {
+    return this.request<WorkflowPreview>(`/workflows/${encodeURIComponent(name)}/preview`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(payload),
+    })
+  }
+
+  /** PUT /api/workflows/{name} — rename a workflow template. */
+  async renameWorkflow(name: string, newName: string): Promise<WorkflowDetail> {
+    return this.request<WorkflowDetail>(`/workflows/${encodeURIComponent(name)}`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ new_name: newName }),
+    })
+  }
+
+  /** DELETE /api/workflows/{name} — delete a workflow template. */
+  async deleteWorkflow(name: string): Promise<void> {
+    const url = `${this.baseUrl}/workflows/${encodeURIComponent(name)}`
+    let response: Response
+    try {
+      response = await fetch(url, { method: 'DELETE' })
+    } catch (err: unknown) {
+      const message = err instanceof Error ? err.message : 'Network error'
+      throw { code: 'NETWORK_ERROR', message } satisfies ApiError
+    }
+    if (!response.ok) {
+      throw await normalizeError(response)
+    }
+  }
+
   /** GET /api/sample-jobs — list all sample jobs. */
   async listSampleJobs(): Promise<SampleJob[]> {
     return this.request<SampleJob[]>('/sample-jobs')
@@ -113,6 +113,8 @@ export interface ImageMetadata {
   string_metadata: Record<string, string>
   /** Quantitative metadata fields (seed, steps, cfg) represented as numbers. */
   numeric_metadata: Record<string, number>
+  /** Where the metadata was read from: the sidecar, the filename, PNG tEXt chunks, or none. */
+  source?: 'sidecar' | 'filename' | 'png' | 'none'
 }
 
 /** A filesystem change event received over WebSocket. */
@@ -246,6 +248,9 @@ export interface FailedItemDetail {
   traceback?: string
 }
 
+/** Format sample images are saved in. webp and jpeg are transcoded server-side. */
+export type OutputFormat = 'png' | 'webp' | 'jpeg'
+
 /** A sample job. */
 export interface SampleJob {
   id: string
@@ -258,6 +263,10 @@ export interface SampleJob {
   shift?: number
   /** List of checkpoint filenames selected at job creation. Empty means all checkpoints were included. */
   checkpoint_filenames: string[]
+  /** Format sample images are saved in. */
+  output_format?: OutputFormat
+  /** Encoder quality for webp and jpeg output (absent for png). */
+  output_quality?: number
   status: SampleJobStatus
   total_items: number
   completed_items: number
@@ -295,6 +304,10 @@ export interface CreateSampleJobPayload {
   clear_existing?: boolean
   /** When true, only generate samples that are missing on disk (skips items whose output file already exists). */
   missing_only?: boolean
+  /** Format to save sample images in (default png). */
+  output_format?: OutputFormat
+  /** Encoder quality 1-100 for webp and jpeg output (default 90). */
+  output_quality?: number
 }
 
 /** Workflow template summary. */
@@ -314,6 +327,44 @@ export interface WorkflowDetail {
   workflow: unknown
 }
 
+/** Values of a sample to substitute into a workflow preview. */
+export interface WorkflowPreviewPayload {
+  checkpoint: string
+  prompt: string
+  negative_prompt?: string
+  seed?: number
+  steps?: number
+  cfg?: number
+  sampler_name?: string
+  scheduler?: string
+  width?: number
+  height?: number
+  vae?: string
+  clip?: string
+  shift?: number
+  denoise?: number
+  input_image?: string
+  controlnet_model?: string
+  controlnet_strength?: number
+  controlnet_image?: string
+}
+
+/** An input of a previewed workflow that differs from the template. */
+export interface WorkflowSubstitution {
+  node_id: string
+  role: string
+  input: string
+  previous?: unknown
+  value?: unknown
+}
+
+/** A workflow template with the values of a sample substituted. */
+export interface WorkflowPreview {
+  name: string
+  workflow: Record<string, unknown>
+  substitutions: WorkflowSubstitution[]
+}
+
 /** Result of verifying expected images exist on disk for a completed checkpoint. */
 export interface CheckpointCompletenessInfo {
   checkpoint: string